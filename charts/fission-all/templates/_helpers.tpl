@@ -30,6 +30,21 @@ canary:
   {{- else }}
   prometheusSvc: {{ .Values.prometheus.serviceEndpoint | default "" | quote }}
   {{- end }}
+fissionConfig:
+  executor:
+    maxConcurrentSpecializations: {{ .Values.executor.maxConcurrentSpecializations | default 0 }}
+    evictionMinIdle: {{ .Values.executor.eviction.minIdle | default "" | quote }}
+    evictionMaxPods: {{ .Values.executor.eviction.maxPods | default 5 }}
+    podReadyTimeout: {{ .Values.executor.podReadyTimeout | default "" | quote }}
+  router:
+    maxInflightRequests: {{ .Values.router.maxInflightRequests | default 0 }}
+    roundTripTimeout: {{ .Values.router.roundTrip.timeout | default "50ms" | quote }}
+    roundTripMaxRetries: {{ .Values.router.roundTrip.maxRetries | default 10 }}
+    displayAccessLog: {{ .Values.router.displayAccessLog | default false }}
+  buildermgr:
+    maxConcurrentBuilds: {{ .Values.buildermgr.maxConcurrentBuilds | default 10 }}
+    maxConcurrentBuildsPerEnvironment: {{ .Values.buildermgr.maxConcurrentBuildsPerEnvironment | default 5 }}
+    buildCacheTTL: {{ .Values.buildermgr.buildCacheTTL | default "" | quote }}
   {{- printf "\n" -}}
 {{- end -}}
 
@@ -51,3 +66,30 @@ This template generates the image name for the deployment depending on the value
   {{- end }}
 {{- end }}
 {{- end -}}
+
+{{/*
+Address of the sink the logger ships function logs to, the in-cluster service of the sink by default.
+*/}}
+{{- define "logSinkHost" -}}
+{{- if .Values.logger.sinkHost -}}
+  {{ .Values.logger.sinkHost }}
+{{- else -}}
+  {{ .Values.logger.sink }}
+{{- end }}
+{{- end -}}
+
+{{- define "logSinkPort" -}}
+{{- if .Values.logger.sinkPort -}}
+  {{ .Values.logger.sinkPort }}
+{{- else if eq .Values.logger.sink "loki" -}}
+  3100
+{{- else if eq .Values.logger.sink "elasticsearch" -}}
+  9200
+{{- else -}}
+  8086
+{{- end }}
+{{- end -}}
+
+{{- define "logSinkURL" -}}
+{{- if .Values.logger.sinkTLS -}}https{{- else -}}http{{- end -}}://{{ include "logSinkHost" . }}:{{ include "logSinkPort" . }}
+{{- end -}}
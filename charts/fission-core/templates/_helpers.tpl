@@ -29,6 +29,21 @@ canary:
   {{- else }}
   prometheusSvc: {{ .Values.prometheus.serviceEndpoint | default "" | quote }}
   {{- end }}
+fissionConfig:
+  executor:
+    maxConcurrentSpecializations: {{ .Values.executor.maxConcurrentSpecializations | default 0 }}
+    evictionMinIdle: {{ .Values.executor.eviction.minIdle | default "" | quote }}
+    evictionMaxPods: {{ .Values.executor.eviction.maxPods | default 5 }}
+    podReadyTimeout: {{ .Values.executor.podReadyTimeout | default "" | quote }}
+  router:
+    maxInflightRequests: {{ .Values.router.maxInflightRequests | default 0 }}
+    roundTripTimeout: {{ .Values.router.roundTrip.timeout | default "50ms" | quote }}
+    roundTripMaxRetries: {{ .Values.router.roundTrip.maxRetries | default 10 }}
+    displayAccessLog: {{ .Values.router.displayAccessLog | default false }}
+  buildermgr:
+    maxConcurrentBuilds: {{ .Values.buildermgr.maxConcurrentBuilds | default 10 }}
+    maxConcurrentBuildsPerEnvironment: {{ .Values.buildermgr.maxConcurrentBuildsPerEnvironment | default 5 }}
+    buildCacheTTL: {{ .Values.buildermgr.buildCacheTTL | default "" | quote }}
   {{- printf "\n" -}}
 {{- end -}}
 
@@ -22,6 +22,7 @@ import (
 	"go.uber.org/zap"
 
 	builder "github.com/fission/fission/pkg/builder"
+	"github.com/fission/fission/pkg/tracing"
 )
 
 // Usage: builder <shared volume path>
@@ -33,5 +34,5 @@ func Run(logger *zap.Logger, shareVolume string) error {
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
-	return http.ListenAndServe(":8001", mux)
+	return http.ListenAndServe(":8001", tracing.Handler(mux, "builder"))
 }
@@ -22,6 +22,8 @@ import (
 	"flag"
 	"fmt"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
 	"os"
 
 	"contrib.go.opencensus.io/exporter/jaeger"
@@ -30,6 +32,9 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/fission/fission/pkg/fetcher"
+	"github.com/fission/fission/pkg/mtls"
+	"github.com/fission/fission/pkg/secretstore"
+	"github.com/fission/fission/pkg/tracing"
 )
 
 func registerTraceExporter(collectorEndpoint string) error {
@@ -55,13 +60,30 @@ func registerTraceExporter(collectorEndpoint string) error {
 	return nil
 }
 
+// registerOTLPExporter exports the OpenTelemetry spans of fetcher to the
+// OTLP collector, if any. Fetcher only continues the traces of its callers.
+func registerOTLPExporter(collectorEndpoint string) (func(context.Context) error, error) {
+	if collectorEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	return tracing.Init(context.Background(), "Fission-Fetcher", collectorEndpoint, 1)
+}
+
 func Run(logger *zap.Logger) {
 	flag.Usage = fetcherUsage
 	collectorEndpoint := flag.String("jaeger-collector-endpoint", "", "")
+	otlpCollectorEndpoint := flag.String("otlp-collector-endpoint", "", "host:port of the OTLP collector to export OpenTelemetry spans to")
 	specializeOnStart := flag.Bool("specialize-on-startup", false, "Flag to activate specialize process at pod starup")
 	specializePayload := flag.String("specialize-request", "", "JSON payload for specialize request")
+	fetchPayload := flag.String("fetch-request", "", "JSON payload for a fetch request to run before exiting, instead of serving")
 	secretDir := flag.String("secret-dir", "", "Path to shared secrets directory")
 	configDir := flag.String("cfgmap-dir", "", "Path to shared configmap directory")
+	mtlsCertDir := flag.String("mtls-cert-dir", "", "Path to the mTLS certificate directory, serves fetcher and function requests with mTLS if set")
+	archiveCacheDir := flag.String("archive-cache-dir", "", "Path to the archive cache shared by the fetchers of the node, archives are always downloaded if not set")
+	archiveCacheMaxBytes := flag.Int64("archive-cache-max-bytes", 0, "Size of the archive cache, unbounded if 0")
+	vaultAddr := flag.String("vault-addr", "", "Address of Vault to resolve the Vault secrets of functions from")
+	vaultAuthPath := flag.String("vault-auth-path", "kubernetes", "Mount path of the kubernetes auth method of Vault")
+	vaultRole := flag.String("vault-role", "", "Vault role to log in as for Vault secrets without one")
 
 	flag.Parse()
 	if flag.NArg() == 0 {
@@ -82,12 +104,38 @@ func Run(logger *zap.Logger) {
 	if err := registerTraceExporter(*collectorEndpoint); err != nil {
 		logger.Fatal("could not register trace exporter", zap.Error(err), zap.String("collector_endpoint", *collectorEndpoint))
 	}
+	shutdownTracing, err := registerOTLPExporter(*otlpCollectorEndpoint)
+	if err != nil {
+		logger.Fatal("could not register OTLP exporter", zap.Error(err), zap.String("collector_endpoint", *otlpCollectorEndpoint))
+	}
 
-	f, err := fetcher.MakeFetcher(logger, dir, *secretDir, *configDir)
+	f, err := fetcher.MakeFetcher(logger, dir, *secretDir, *configDir, *archiveCacheDir, *archiveCacheMaxBytes, secretstore.Config{
+		VaultAddr:     *vaultAddr,
+		VaultAuthPath: *vaultAuthPath,
+		VaultRole:     *vaultRole,
+	})
 	if err != nil {
 		logger.Fatal("error making fetcher", zap.Error(err))
 	}
 
+	if len(*fetchPayload) > 0 {
+		var fetchReq fetcher.FunctionFetchRequest
+
+		err := json.Unmarshal([]byte(*fetchPayload), &fetchReq)
+		if err != nil {
+			logger.Fatal("error decoding fetch request", zap.Error(err))
+		}
+
+		err = f.FetchPackage(context.Background(), fetchReq)
+		if err != nil {
+			logger.Fatal("error fetching package", zap.Error(err))
+		}
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error("error flushing spans", zap.Error(err))
+		}
+		return
+	}
+
 	readyToServe := false
 
 	// do specialization in other goroutine to prevent blocking in newdeploy
@@ -105,6 +153,8 @@ func Run(logger *zap.Logger) {
 			if err != nil {
 				logger.Fatal("error specializing function pod", zap.Error(err))
 			}
+			f.WatchReferencedResources(specializeReq)
+			f.StartProbes(specializeReq)
 
 			readyToServe = true
 		}
@@ -117,7 +167,7 @@ func Run(logger *zap.Logger) {
 	mux.HandleFunc("/version", f.VersionHandler)
 
 	readinessHandler := func(w http.ResponseWriter, r *http.Request) {
-		if !*specializeOnStart || readyToServe {
+		if (!*specializeOnStart || readyToServe) && f.FunctionReady() {
 			w.WriteHeader(http.StatusOK)
 		} else {
 			w.WriteHeader(http.StatusServiceUnavailable)
@@ -128,17 +178,54 @@ func Run(logger *zap.Logger) {
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
+	mux.HandleFunc(fetcher.StartupProbePath, f.StartupProbeHandler)
+	mux.HandleFunc(fetcher.LivenessProbePath, f.LivenessProbeHandler)
 
 	// For backward compatibility
 	// TODO: remove this path in future
 	mux.HandleFunc("/readniess-healthz", readinessHandler)
 
+	if len(*mtlsCertDir) > 0 {
+		serveMTLS(logger, &mtls.Config{CertDir: *mtlsCertDir}, mux)
+	}
+
 	logger.Info("fetcher ready to receive requests")
 	http.ListenAndServe(":8000", &ochttp.Handler{
-		Handler: mux,
+		Handler: tracing.Handler(mux, "fetcher"),
 	})
 }
 
+// serveMTLS serves the fetcher API and proxies function requests to the
+// function container with mTLS. The plain HTTP port stays open for probes
+// from kubelet, which cannot present a client certificate.
+func serveMTLS(logger *zap.Logger, cfg *mtls.Config, mux *http.ServeMux) {
+	tlsConfig, err := cfg.ServerTLSConfig()
+	if err != nil {
+		logger.Fatal("error loading mTLS certificate", zap.Error(err), zap.String("directory", cfg.CertDir))
+	}
+
+	functionURL, _ := url.Parse("http://127.0.0.1:8888")
+	servers := []*http.Server{
+		{
+			Addr:      fmt.Sprintf(":%v", mtls.APIPort),
+			Handler:   &ochttp.Handler{Handler: tracing.Handler(mux, "fetcher")},
+			TLSConfig: tlsConfig,
+		},
+		{
+			Addr:      fmt.Sprintf(":%v", mtls.FunctionPort),
+			Handler:   httputil.NewSingleHostReverseProxy(functionURL),
+			TLSConfig: tlsConfig,
+		},
+	}
+	for _, server := range servers {
+		go func(server *http.Server) {
+			// certificates are taken from TLSConfig
+			err := server.ListenAndServeTLS("", "")
+			logger.Fatal("done listening with mTLS", zap.Error(err), zap.String("address", server.Addr))
+		}(server)
+	}
+}
+
 func fetcherUsage() {
-	fmt.Println("Usage: fetcher [-specialize-on-startup] [-specialize-request <json>] [-secret-dir <string>] [-cfgmap-dir <string>] <shared volume path>")
+	fmt.Println("Usage: fetcher [-specialize-on-startup] [-specialize-request <json>] [-secret-dir <string>] [-cfgmap-dir <string>] [-mtls-cert-dir <string>] <shared volume path>")
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
@@ -30,16 +31,23 @@ import (
 	"go.uber.org/zap/zapcore"
 
 	"github.com/fission/fission/cmd/fission-bundle/mqtrigger"
+	"github.com/fission/fission/pkg/buckettrigger"
 	"github.com/fission/fission/pkg/buildermgr"
 	"github.com/fission/fission/pkg/controller"
+	"github.com/fission/fission/pkg/dbtrigger"
 	"github.com/fission/fission/pkg/executor"
+	"github.com/fission/fission/pkg/gitops"
 	"github.com/fission/fission/pkg/info"
 	"github.com/fission/fission/pkg/kubewatcher"
 	functionLogger "github.com/fission/fission/pkg/logger"
 	mqt "github.com/fission/fission/pkg/mqtrigger"
 	"github.com/fission/fission/pkg/router"
+	"github.com/fission/fission/pkg/shutdown"
 	"github.com/fission/fission/pkg/storagesvc"
 	"github.com/fission/fission/pkg/timer"
+	"github.com/fission/fission/pkg/tracing"
+	"github.com/fission/fission/pkg/webhook"
+	"github.com/fission/fission/pkg/workflow"
 )
 
 func runController(logger *zap.Logger, port int) {
@@ -47,13 +55,15 @@ func runController(logger *zap.Logger, port int) {
 	logger.Fatal("controller exited")
 }
 
-func runRouter(logger *zap.Logger, port int, executorUrl string) {
-	router.Start(logger, port, executorUrl)
-	logger.Fatal("router exited")
+func runRouter(logger *zap.Logger, coordinator *shutdown.Coordinator, port int, executorUrl string) {
+	router.Start(logger, coordinator, port, executorUrl)
+	if !coordinator.ShuttingDown() {
+		logger.Fatal("router exited")
+	}
 }
 
-func runExecutor(logger *zap.Logger, port int, functionNamespace, envBuilderNamespace string) {
-	err := executor.StartExecutor(logger, functionNamespace, envBuilderNamespace, port)
+func runExecutor(logger *zap.Logger, coordinator *shutdown.Coordinator, port int, functionNamespace, envBuilderNamespace string) {
+	err := executor.StartExecutor(logger, coordinator, functionNamespace, envBuilderNamespace, port)
 	if err != nil {
 		logger.Fatal("error starting executor", zap.Error(err))
 	}
@@ -66,15 +76,43 @@ func runKubeWatcher(logger *zap.Logger, routerUrl string) {
 	}
 }
 
-func runTimer(logger *zap.Logger, routerUrl string) {
-	err := timer.Start(logger, routerUrl)
+func runTimer(logger *zap.Logger, routerUrl string, storageSvcUrl string) {
+	err := timer.Start(logger, routerUrl, storageSvcUrl)
 	if err != nil {
 		logger.Fatal("error starting timer", zap.Error(err))
 	}
 }
 
-func runMessageQueueMgr(logger *zap.Logger, routerUrl string) {
-	err := mqtrigger.Start(logger, routerUrl)
+func runBucketTrigger(logger *zap.Logger, port int, routerUrl string) {
+	err := buckettrigger.Start(logger, port, routerUrl)
+	if err != nil {
+		logger.Fatal("error starting bucket trigger manager", zap.Error(err))
+	}
+}
+
+func runDatabaseTrigger(logger *zap.Logger, routerUrl string) {
+	err := dbtrigger.Start(logger, routerUrl)
+	if err != nil {
+		logger.Fatal("error starting database trigger manager", zap.Error(err))
+	}
+}
+
+func runWorkflowEngine(logger *zap.Logger, port int, routerUrl string) {
+	err := workflow.Start(logger, port, routerUrl)
+	if err != nil {
+		logger.Fatal("error starting workflow engine", zap.Error(err))
+	}
+}
+
+func runGitOps(logger *zap.Logger, controllerUrl string) {
+	err := gitops.Start(logger, controllerUrl)
+	if err != nil {
+		logger.Fatal("error starting gitops controller", zap.Error(err))
+	}
+}
+
+func runMessageQueueMgr(logger *zap.Logger, coordinator *shutdown.Coordinator, routerUrl string) {
+	err := mqtrigger.Start(logger, coordinator, routerUrl)
 	if err != nil {
 		logger.Fatal("error starting message queue manager", zap.Error(err))
 	}
@@ -88,20 +126,27 @@ func runMQManager(logger *zap.Logger, routerURL string) {
 	}
 }
 
-func runStorageSvc(logger *zap.Logger, port int, storage storagesvc.Storage) {
+func runStorageSvc(logger *zap.Logger, port int, storage storagesvc.StorageBackend) {
 	err := storagesvc.Start(logger, storage, port)
 	if err != nil {
 		logger.Fatal("error starting storage service", zap.Error(err))
 	}
 }
 
-func runBuilderMgr(logger *zap.Logger, storageSvcUrl string, envBuilderNamespace string) {
-	err := buildermgr.Start(logger, storageSvcUrl, envBuilderNamespace)
+func runBuilderMgr(logger *zap.Logger, coordinator *shutdown.Coordinator, storageSvcUrl string, envBuilderNamespace string) {
+	err := buildermgr.Start(logger, coordinator, storageSvcUrl, envBuilderNamespace)
 	if err != nil {
 		logger.Fatal("error starting builder manager", zap.Error(err))
 	}
 }
 
+func runWebhook(logger *zap.Logger, port int) {
+	err := webhook.Start(logger, port)
+	if err != nil {
+		logger.Fatal("error starting admission webhook", zap.Error(err))
+	}
+}
+
 func runLogger() {
 	functionLogger.Start()
 	log.Fatalf("Error: Logger exited.")
@@ -124,13 +169,9 @@ func getStringArgWithDefault(arg interface{}, defaultValue string) string {
 	}
 }
 
-func registerTraceExporter(logger *zap.Logger, arguments map[string]interface{}) error {
-	collectorEndpoint := os.Getenv("TRACE_JAEGER_COLLECTOR_ENDPOINT")
-	if len(collectorEndpoint) == 0 {
-		logger.Info("skipping trace exporter registration")
-		return nil
-	}
-
+// getServiceName returns the name the component started by arguments
+// traces its spans with.
+func getServiceName(arguments map[string]interface{}) string {
 	serviceName := "Fission-Unknown"
 
 	if arguments["--controllerPort"] != nil {
@@ -151,12 +192,31 @@ func registerTraceExporter(logger *zap.Logger, arguments map[string]interface{})
 		serviceName = "Fission-StorageSvc"
 	} else if arguments["--mqt_keda"] == true {
 		serviceName = "Fission-Keda-MQTrigger"
+	} else if arguments["--webhookPort"] != nil {
+		serviceName = "Fission-Webhook"
+	} else if arguments["--gitops"] == true {
+		serviceName = "Fission-GitOps"
+	} else if arguments["--bucketTriggerPort"] != nil {
+		serviceName = "Fission-BucketTrigger"
+	} else if arguments["--dbtrigger"] == true {
+		serviceName = "Fission-DatabaseTrigger"
+	} else if arguments["--workflowPort"] != nil {
+		serviceName = "Fission-Workflow"
+	}
+	return serviceName
+}
+
+func registerTraceExporter(logger *zap.Logger, coordinator *shutdown.Coordinator, arguments map[string]interface{}) error {
+	collectorEndpoint := os.Getenv("TRACE_JAEGER_COLLECTOR_ENDPOINT")
+	if len(collectorEndpoint) == 0 {
+		logger.Info("skipping trace exporter registration")
+		return nil
 	}
 
 	exporter, err := jaeger.NewExporter(jaeger.Options{
 		CollectorEndpoint: collectorEndpoint,
 		Process: jaeger.Process{
-			ServiceName: serviceName,
+			ServiceName: getServiceName(arguments),
 			Tags: []jaeger.Tag{
 				jaeger.BoolTag("fission", true),
 			},
@@ -172,6 +232,33 @@ func registerTraceExporter(logger *zap.Logger, arguments map[string]interface{})
 	}
 	trace.RegisterExporter(exporter)
 	trace.ApplyConfig(trace.Config{DefaultSampler: trace.ProbabilitySampler(samplingRate)})
+	// spans are sent in batches, the last one is sent on shutdown
+	coordinator.OnFlush("traces", func(ctx context.Context) error {
+		exporter.Flush()
+		return nil
+	})
+	return nil
+}
+
+// registerOTLPExporter exports the OpenTelemetry spans of the component to
+// the OTLP collector, if any.
+func registerOTLPExporter(logger *zap.Logger, coordinator *shutdown.Coordinator, arguments map[string]interface{}) error {
+	collectorEndpoint := os.Getenv(tracing.EnvOTLPCollectorEndpoint)
+	if len(collectorEndpoint) == 0 {
+		logger.Info("skipping OTLP exporter registration")
+		return nil
+	}
+
+	samplingRate, err := strconv.ParseFloat(os.Getenv("TRACING_SAMPLING_RATE"), 64)
+	if err != nil {
+		return err
+	}
+	shutdownTracing, err := tracing.Init(context.Background(), getServiceName(arguments), collectorEndpoint, samplingRate)
+	if err != nil {
+		return err
+	}
+	// spans are sent in batches, the last one is sent on shutdown
+	coordinator.OnFlush("OTLP traces", shutdownTracing)
 	return nil
 }
 
@@ -204,6 +291,23 @@ Use it to start one or more of the fission servers:
  in the Kubernetes API resource object. It supports various storage
  backends.
 
+ The admission webhook validates Fission objects when they are created
+ or updated, so that invalid objects are rejected right away.
+
+ The gitops controller applies the specs of the Git repositories of
+ FissionSources whenever they change.
+
+ The bucket trigger manager implements bucket triggers: it receives the
+ event notifications of object storage buckets and invokes the functions
+ of the BucketTriggers they are sent to.
+
+ The database trigger manager implements database triggers: it consumes
+ the change events Debezium captures of databases from Kafka and invokes
+ the functions of the DatabaseTriggers of the tables changed.
+
+ The workflow engine executes Workflows, invoking the functions of their
+ steps one after the other and recording executions in their status.
+
 Usage:
   fission-bundle --controllerPort=<port>
   fission-bundle --routerPort=<port> [--executorUrl=<url>]
@@ -211,9 +315,14 @@ Usage:
   fission-bundle --kubewatcher [--routerUrl=<url>]
   fission-bundle --storageServicePort=<port> --storageType=<storateType>
   fission-bundle --builderMgr [--storageSvcUrl=<url>] [--envbuilder-namespace=<namespace>]
-  fission-bundle --timer [--routerUrl=<url>]
+  fission-bundle --timer [--routerUrl=<url>] [--storageSvcUrl=<url>]
   fission-bundle --mqt   [--routerUrl=<url>]
   fission-bundle --mqt_keda [--routerUrl=<url>]
+  fission-bundle --webhookPort=<port>
+  fission-bundle --gitops [--controllerUrl=<url>]
+  fission-bundle --bucketTriggerPort=<port> [--routerUrl=<url>]
+  fission-bundle --dbtrigger [--routerUrl=<url>]
+  fission-bundle --workflowPort=<port> [--routerUrl=<url>]
   fission-bundle --logger
   fission-bundle --version
 Options:
@@ -221,8 +330,12 @@ Options:
   --routerPort=<port>             Port that the router should listen on.
   --executorPort=<port>           Port that the executor should listen on.
   --storageServicePort=<port>     Port that the storage service should listen on.
+  --webhookPort=<port>            Port that the admission webhook should listen on.
+  --bucketTriggerPort=<port>      Port that the bucket trigger manager should listen on.
+  --workflowPort=<port>           Port that the workflow engine should listen on.
   --executorUrl=<url>             Executor URL. Not required if --executorPort is specified.
   --routerUrl=<url>               Router URL.
+  --controllerUrl=<url>           Controller URL.
   --etcdUrl=<etcdUrl>             Etcd URL.
   --storageSvcUrl=<url>           StorageService URL.
   --filePath=<filePath>           Directory to store functions in.
@@ -232,6 +345,8 @@ Options:
   --mqt                           Start message queue trigger.
   --mqt_keda					  Start message queue trigger of kind KEDA
   --builderMgr                    Start builder manager.
+  --gitops                        Start gitops controller.
+  --dbtrigger                     Start database trigger manager.
   --version                       Print version information
 `
 
@@ -261,17 +376,32 @@ Options:
 		logger.Fatal("Could not parse command line arguments", zap.Error(err))
 	}
 
-	err = registerTraceExporter(logger, arguments)
+	// On SIGTERM, components stop accepting work, drain the work in flight
+	// within the grace period, flush their metrics and traces, and exit.
+	coordinator := shutdown.MakeCoordinator(logger)
+	go func() {
+		coordinator.WaitForSignal()
+		logger.Sync()
+		os.Exit(0)
+	}()
+
+	err = registerTraceExporter(logger, coordinator, arguments)
 	if err != nil {
 		logger.Fatal("Could not register trace exporter", zap.Error(err), zap.Any("argument", arguments))
 	}
 
+	err = registerOTLPExporter(logger, coordinator, arguments)
+	if err != nil {
+		logger.Fatal("Could not register OTLP exporter", zap.Error(err), zap.Any("argument", arguments))
+	}
+
 	functionNs := getStringArgWithDefault(arguments["--namespace"], "fission-function")
 	envBuilderNs := getStringArgWithDefault(arguments["--envbuilder-namespace"], "fission-builder")
 
 	executorUrl := getStringArgWithDefault(arguments["--executorUrl"], "http://executor.fission")
 	routerUrl := getStringArgWithDefault(arguments["--routerUrl"], "http://router.fission")
 	storageSvcUrl := getStringArgWithDefault(arguments["--storageSvcUrl"], "http://storagesvc.fission")
+	controllerUrl := getStringArgWithDefault(arguments["--controllerUrl"], "http://controller.fission")
 
 	if arguments["--controllerPort"] != nil {
 		port := getPort(logger, arguments["--controllerPort"])
@@ -280,12 +410,12 @@ Options:
 
 	if arguments["--routerPort"] != nil {
 		port := getPort(logger, arguments["--routerPort"])
-		runRouter(logger, port, executorUrl)
+		runRouter(logger, coordinator, port, executorUrl)
 	}
 
 	if arguments["--executorPort"] != nil {
 		port := getPort(logger, arguments["--executorPort"])
-		runExecutor(logger, port, functionNs, envBuilderNs)
+		runExecutor(logger, coordinator, port, functionNs, envBuilderNs)
 	}
 
 	if arguments["--kubewatcher"] == true {
@@ -293,11 +423,11 @@ Options:
 	}
 
 	if arguments["--timer"] == true {
-		runTimer(logger, routerUrl)
+		runTimer(logger, routerUrl, storageSvcUrl)
 	}
 
 	if arguments["--mqt"] == true {
-		runMessageQueueMgr(logger, routerUrl)
+		runMessageQueueMgr(logger, coordinator, routerUrl)
 	}
 
 	if arguments["--mqt_keda"] == true {
@@ -305,7 +435,30 @@ Options:
 	}
 
 	if arguments["--builderMgr"] == true {
-		runBuilderMgr(logger, storageSvcUrl, envBuilderNs)
+		runBuilderMgr(logger, coordinator, storageSvcUrl, envBuilderNs)
+	}
+
+	if arguments["--webhookPort"] != nil {
+		port := getPort(logger, arguments["--webhookPort"])
+		runWebhook(logger, port)
+	}
+
+	if arguments["--gitops"] == true {
+		runGitOps(logger, controllerUrl)
+	}
+
+	if arguments["--bucketTriggerPort"] != nil {
+		port := getPort(logger, arguments["--bucketTriggerPort"])
+		runBucketTrigger(logger, port, routerUrl)
+	}
+
+	if arguments["--dbtrigger"] == true {
+		runDatabaseTrigger(logger, routerUrl)
+	}
+
+	if arguments["--workflowPort"] != nil {
+		port := getPort(logger, arguments["--workflowPort"])
+		runWorkflowEngine(logger, port, routerUrl)
 	}
 
 	if arguments["--logger"] == true {
@@ -315,12 +468,10 @@ Options:
 	if arguments["--storageServicePort"] != nil {
 		port := getPort(logger, arguments["--storageServicePort"])
 
-		var storage storagesvc.Storage
-
-		if arguments["--storageType"] != nil && arguments["--storageType"] == string(storagesvc.StorageTypeS3) {
-			storage = storagesvc.NewS3Storage()
-		} else if arguments["--storageType"] == string(storagesvc.StorageTypeLocal) {
-			storage = storagesvc.NewLocalStorage("/fission")
+		storageType, _ := arguments["--storageType"].(string)
+		storage, err := storagesvc.MakeStorageBackend(storagesvc.StorageType(storageType), "/fission")
+		if err != nil {
+			logger.Fatal("error configuring storage backend", zap.Error(err))
 		}
 		runStorageSvc(logger, port, storage)
 	}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/crd"
@@ -32,12 +33,20 @@ import (
 	"github.com/fission/fission/pkg/mqtrigger/factory"
 	"github.com/fission/fission/pkg/mqtrigger/messageQueue"
 	_ "github.com/fission/fission/pkg/mqtrigger/messageQueue/azurequeuestorage"
+	_ "github.com/fission/fission/pkg/mqtrigger/messageQueue/gcppubsub"
 	_ "github.com/fission/fission/pkg/mqtrigger/messageQueue/kafka"
 	_ "github.com/fission/fission/pkg/mqtrigger/messageQueue/nats"
+	_ "github.com/fission/fission/pkg/mqtrigger/messageQueue/pulsar"
+	_ "github.com/fission/fission/pkg/mqtrigger/messageQueue/rabbitmq"
+	_ "github.com/fission/fission/pkg/mqtrigger/messageQueue/redisstreams"
+	_ "github.com/fission/fission/pkg/mqtrigger/messageQueue/sqs"
+	"github.com/fission/fission/pkg/shutdown"
 )
 
-func Start(logger *zap.Logger, routerUrl string) error {
-	fissionClient, _, _, _, err := crd.MakeFissionClient()
+// Start starts the message queue trigger manager. On shutdown, it stops
+// consuming messages.
+func Start(logger *zap.Logger, coordinator *shutdown.Coordinator, routerUrl string) error {
+	fissionClient, kubeClient, _, _, err := crd.MakeFissionClient()
 
 	if err != nil {
 		return errors.Wrap(err, "failed to get fission or kubernetes client")
@@ -69,6 +78,13 @@ func Start(logger *zap.Logger, routerUrl string) error {
 			MQType:  (string)(mqType),
 			Url:     mqUrl,
 			Secrets: secrets,
+			SecretGetter: func(namespace string, name string) (map[string][]byte, error) {
+				secret, err := kubeClient.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
+				if err != nil {
+					return nil, err
+				}
+				return secret.Data, nil
+			},
 		},
 		routerUrl,
 	)
@@ -76,7 +92,21 @@ func Start(logger *zap.Logger, routerUrl string) error {
 		logger.Fatal("failed to connect to remote message queue server", zap.Error(err))
 	}
 
-	mqtrigger.MakeMessageQueueTriggerManager(logger, fissionClient, mqType, mq).Run()
+	// the manager runs the consumers of autoscaled triggers, which run it
+	// for their trigger only
+	consumerTrigger := os.Getenv(mqtrigger.ConsumerTriggerEnv)
+	var scaler *mqtrigger.ConsumerScaler
+	if len(consumerTrigger) == 0 {
+		if lagReporter, ok := mq.(messageQueue.LagReporter); ok {
+			scaler = mqtrigger.MakeConsumerScaler(logger, kubeClient, os.Getenv("POD_NAMESPACE"), mqType, lagReporter)
+		} else {
+			logger.Info("message queue doesn't report lag, consumers of autoscaled triggers won't be scaled", zap.String("type", string(mqType)))
+		}
+	}
+
+	mqtManager := mqtrigger.MakeMessageQueueTriggerManager(logger, fissionClient, mqType, mq, scaler, consumerTrigger)
+	mqtManager.Run(coordinator.Context())
+	coordinator.OnShutdown("message queue triggers", mqtManager.Stop)
 
 	return nil
 }
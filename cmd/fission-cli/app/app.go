@@ -9,14 +9,22 @@ import (
 	wrapper "github.com/fission/fission/pkg/fission-cli/cliwrapper/driver/cobra"
 	"github.com/fission/fission/pkg/fission-cli/cliwrapper/driver/cobra/helptemplate"
 	"github.com/fission/fission/pkg/fission-cli/cmd"
+	"github.com/fission/fission/pkg/fission-cli/cmd/audit"
 	"github.com/fission/fission/pkg/fission-cli/cmd/canaryconfig"
+	"github.com/fission/fission/pkg/fission-cli/cmd/completion"
+	"github.com/fission/fission/pkg/fission-cli/cmd/config"
+	"github.com/fission/fission/pkg/fission-cli/cmd/dashboard"
+	"github.com/fission/fission/pkg/fission-cli/cmd/drift"
 	"github.com/fission/fission/pkg/fission-cli/cmd/environment"
 	"github.com/fission/fission/pkg/fission-cli/cmd/function"
 	"github.com/fission/fission/pkg/fission-cli/cmd/httptrigger"
 	"github.com/fission/fission/pkg/fission-cli/cmd/kubewatch"
 	"github.com/fission/fission/pkg/fission-cli/cmd/mqtrigger"
 	_package "github.com/fission/fission/pkg/fission-cli/cmd/package"
+	"github.com/fission/fission/pkg/fission-cli/cmd/restore"
+	"github.com/fission/fission/pkg/fission-cli/cmd/simulate"
 	"github.com/fission/fission/pkg/fission-cli/cmd/spec"
+	"github.com/fission/fission/pkg/fission-cli/cmd/status"
 	"github.com/fission/fission/pkg/fission-cli/cmd/support"
 	"github.com/fission/fission/pkg/fission-cli/cmd/timetrigger"
 	"github.com/fission/fission/pkg/fission-cli/cmd/version"
@@ -25,8 +33,13 @@ import (
 	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
 	"github.com/fission/fission/pkg/fission-cli/util"
 	_ "github.com/fission/fission/pkg/mqtrigger/messageQueue/azurequeuestorage"
+	_ "github.com/fission/fission/pkg/mqtrigger/messageQueue/gcppubsub"
 	_ "github.com/fission/fission/pkg/mqtrigger/messageQueue/kafka"
 	_ "github.com/fission/fission/pkg/mqtrigger/messageQueue/nats"
+	_ "github.com/fission/fission/pkg/mqtrigger/messageQueue/pulsar"
+	_ "github.com/fission/fission/pkg/mqtrigger/messageQueue/rabbitmq"
+	_ "github.com/fission/fission/pkg/mqtrigger/messageQueue/redisstreams"
+	_ "github.com/fission/fission/pkg/mqtrigger/messageQueue/sqs"
 )
 
 const (
@@ -81,7 +94,7 @@ func App() *cobra.Command {
 	groups = append(groups, helptemplate.CreateCmdGroup("Trigger Commands", httptrigger.Commands(), mqtrigger.Commands(), timetrigger.Commands(), kubewatch.Commands()))
 	groups = append(groups, helptemplate.CreateCmdGroup("Deploy Strategies Commands", canaryconfig.Commands()))
 	groups = append(groups, helptemplate.CreateCmdGroup("Declarative Application Commands", spec.Commands()))
-	groups = append(groups, helptemplate.CreateCmdGroup("Other Commands", support.Commands(), version.Commands()))
+	groups = append(groups, helptemplate.CreateCmdGroup("Other Commands", status.Commands(), dashboard.Commands(), drift.Commands(), restore.Commands(), audit.Commands(), simulate.Commands(), config.Commands(), support.Commands(), completion.Commands(), version.Commands()))
 	groups.Add(rootCmd)
 
 	flagExposer := helptemplate.ActsAsRootCommand(rootCmd, nil, groups...)
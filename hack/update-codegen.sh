@@ -0,0 +1,20 @@
+#!/bin/bash
+
+# Regenerates deepcopy functions, clientset, informers and listers of Fission CRDs.
+# The generated clientset, informers and listers are published under pkg/generated
+# for building informer-based controllers.
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+SCRIPT_ROOT=$(realpath $(dirname $0))/..
+CODEGEN_PKG=${CODEGEN_PKG:-$(cd ${SCRIPT_ROOT}; ls -d -1 ${GOPATH}/src/k8s.io/code-generator 2>/dev/null || echo ../code-generator)}
+
+bash ${CODEGEN_PKG}/generate-groups.sh \
+    all \
+    github.com/fission/fission/pkg/generated \
+    github.com/fission/fission/pkg/apis \
+    "core:v1" \
+    --output-base "$(dirname ${BASH_SOURCE})/../../../.." \
+    --go-header-file ${SCRIPT_ROOT}/pkg/apis/boilerplate.txt
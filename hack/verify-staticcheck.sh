@@ -4,4 +4,4 @@ set -o errexit
 set -o nounset
 set -o pipefail
 
-go list ./...| grep -v vendor | grep -v "examples" | grep -v "demos" | grep -v "genclient" | grep -v "test" | xargs -I@ staticcheck @
+go list ./...| grep -v vendor | grep -v "examples" | grep -v "demos" | grep -v "pkg/generated" | grep -v "test" | xargs -I@ staticcheck @
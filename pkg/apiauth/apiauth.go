@@ -0,0 +1,250 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apiauth authenticates users of the controller API with static
+// tokens or OpenID Connect ID tokens, and authorizes their requests with
+// roles bound to them per namespace.
+package apiauth
+
+import (
+	"crypto/subtle"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/ghodss/yaml"
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/fission/fission/pkg/jwt"
+)
+
+const (
+	// RoleViewer may read objects.
+	RoleViewer Role = "viewer"
+	// RoleDeployer may read, create, update and delete objects.
+	RoleDeployer Role = "deployer"
+	// RoleAdmin may do everything, including reading the audit log.
+	RoleAdmin Role = "admin"
+
+	ActionRead  Action = "read"
+	ActionWrite Action = "write"
+	ActionAdmin Action = "admin"
+
+	// TokenEnv is the environment variable the CLI takes the token to
+	// authenticate to the controller with from.
+	TokenEnv = "FISSION_AUTH_TOKEN"
+)
+
+type (
+	Role   string
+	Action string
+
+	// Config is the authentication and authorization config of the controller API.
+	Config struct {
+		// Tokens are static bearer tokens of users.
+		Tokens []TokenConfig `json:"tokens,omitempty"`
+
+		// OIDC, if set, accepts ID tokens of an OpenID Connect provider.
+		OIDC *OIDCConfig `json:"oidc,omitempty"`
+
+		// Bindings grant roles to users and groups.
+		Bindings []Binding `json:"bindings"`
+	}
+
+	TokenConfig struct {
+		Token  string   `json:"token"`
+		User   string   `json:"user"`
+		Groups []string `json:"groups,omitempty"`
+	}
+
+	OIDCConfig struct {
+		// Issuer is the URL of the provider, its keys are found by OpenID Connect discovery.
+		Issuer string `json:"issuer"`
+
+		// ClientID is the audience ID tokens must be issued for.
+		ClientID string `json:"clientID"`
+
+		// UsernameClaim is the claim holding the user name. Defaults to "sub".
+		UsernameClaim string `json:"usernameClaim,omitempty"`
+
+		// GroupsClaim is the claim holding the groups of the user. Defaults to "groups".
+		GroupsClaim string `json:"groupsClaim,omitempty"`
+	}
+
+	// Binding grants a role to users and groups in namespaces.
+	Binding struct {
+		Role   Role     `json:"role"`
+		Users  []string `json:"users,omitempty"`
+		Groups []string `json:"groups,omitempty"`
+
+		// Namespaces the role is granted in, all namespaces if empty.
+		Namespaces []string `json:"namespaces,omitempty"`
+	}
+
+	// Identity is an authenticated user.
+	Identity struct {
+		User   string
+		Groups []string
+	}
+
+	// Attributes describe what a request does.
+	Attributes struct {
+		Action Action
+
+		// Namespace of the objects, the empty string for requests
+		// on objects in all namespaces.
+		Namespace string
+
+		// AnyNamespace marks requests not bound to a namespace, such as
+		// archive uploads, which users allowed the action in any
+		// namespace may make.
+		AnyNamespace bool
+	}
+
+	// Auth authenticates and authorizes requests to the controller API.
+	Auth struct {
+		logger   *zap.Logger
+		config   *Config
+		oidc     *oidcProvider
+		verifier *jwt.Verifier
+	}
+)
+
+// Allows returns true if the role allows the action.
+func (role Role) Allows(action Action) bool {
+	switch role {
+	case RoleAdmin:
+		return true
+	case RoleDeployer:
+		return action == ActionRead || action == ActionWrite
+	case RoleViewer:
+		return action == ActionRead
+	}
+	return false
+}
+
+// LoadConfig reads the YAML config at path.
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading API auth config %v", path)
+	}
+	config := &Config{}
+	err = yaml.Unmarshal(b, config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error decoding API auth config %v", path)
+	}
+	err = config.Validate()
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid API auth config %v", path)
+	}
+	return config, nil
+}
+
+func (config *Config) Validate() error {
+	result := &multierror.Error{}
+
+	for i, t := range config.Tokens {
+		if len(t.Token) == 0 || len(t.User) == 0 {
+			result = multierror.Append(result, errors.Errorf("token %v must have a token and a user", i))
+		}
+	}
+	if config.OIDC != nil && len(config.OIDC.Issuer) == 0 {
+		result = multierror.Append(result, errors.New("OIDC issuer is required"))
+	}
+	if len(config.Tokens) == 0 && config.OIDC == nil {
+		result = multierror.Append(result, errors.New("at least one token or an OIDC provider is required"))
+	}
+	for i, b := range config.Bindings {
+		switch b.Role {
+		case RoleAdmin, RoleDeployer, RoleViewer:
+		default:
+			result = multierror.Append(result, errors.Errorf("binding %v has unknown role %q", i, b.Role))
+		}
+		if len(b.Users) == 0 && len(b.Groups) == 0 {
+			result = multierror.Append(result, errors.Errorf("binding %v must have a user or group", i))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+func MakeAuth(logger *zap.Logger, config *Config) *Auth {
+	auth := &Auth{
+		logger: logger.Named("api_auth"),
+		config: config,
+	}
+	if config.OIDC != nil {
+		auth.oidc = makeOIDCProvider(config.OIDC)
+		auth.verifier = jwt.MakeVerifier(auth.logger)
+	}
+	return auth
+}
+
+// Authenticate returns the identity of the user making the request.
+func (auth *Auth) Authenticate(r *http.Request) (*Identity, error) {
+	token, err := jwt.BearerToken(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range auth.config.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t.Token)) == 1 {
+			return &Identity{User: t.User, Groups: t.Groups}, nil
+		}
+	}
+
+	if auth.oidc != nil && jwt.IsJWT(token) {
+		return auth.oidc.authenticate(auth.verifier, token)
+	}
+
+	return nil, errors.New("invalid token")
+}
+
+// Authorize returns true if any role bound to the user allows the request.
+func (auth *Auth) Authorize(id *Identity, attrs Attributes) bool {
+	for _, b := range auth.config.Bindings {
+		if !b.Role.Allows(attrs.Action) || !b.matches(id) {
+			continue
+		}
+		if len(b.Namespaces) == 0 || attrs.AnyNamespace {
+			return true
+		}
+		for _, ns := range b.Namespaces {
+			if len(attrs.Namespace) > 0 && ns == attrs.Namespace {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (b *Binding) matches(id *Identity) bool {
+	for _, user := range b.Users {
+		if user == id.User {
+			return true
+		}
+	}
+	for _, group := range b.Groups {
+		for _, g := range id.Groups {
+			if group == g {
+				return true
+			}
+		}
+	}
+	return false
+}
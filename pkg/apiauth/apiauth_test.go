@@ -0,0 +1,172 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiauth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestAuthorize(t *testing.T) {
+	auth := MakeAuth(zap.NewNop(), &Config{
+		Tokens: []TokenConfig{{Token: "t", User: "root"}},
+		Bindings: []Binding{
+			{Role: RoleAdmin, Users: []string{"root"}},
+			{Role: RoleDeployer, Groups: []string{"team-a"}, Namespaces: []string{"a"}},
+			{Role: RoleViewer, Users: []string{"bob"}, Namespaces: []string{"a", "b"}},
+		},
+	})
+
+	root := &Identity{User: "root"}
+	alice := &Identity{User: "alice", Groups: []string{"team-a"}}
+	bob := &Identity{User: "bob"}
+	eve := &Identity{User: "eve"}
+
+	tests := []struct {
+		id    *Identity
+		attrs Attributes
+		want  bool
+	}{
+		{root, Attributes{Action: ActionAdmin}, true},
+		{root, Attributes{Action: ActionWrite, Namespace: "c"}, true},
+		{alice, Attributes{Action: ActionWrite, Namespace: "a"}, true},
+		{alice, Attributes{Action: ActionWrite, Namespace: "b"}, false},
+		{alice, Attributes{Action: ActionRead}, false},
+		{alice, Attributes{Action: ActionWrite, AnyNamespace: true}, true},
+		{alice, Attributes{Action: ActionAdmin, Namespace: "a"}, false},
+		{bob, Attributes{Action: ActionRead, Namespace: "b"}, true},
+		{bob, Attributes{Action: ActionWrite, Namespace: "b"}, false},
+		{bob, Attributes{Action: ActionWrite, AnyNamespace: true}, false},
+		{eve, Attributes{Action: ActionRead, Namespace: "a"}, false},
+	}
+	for _, tt := range tests {
+		if got := auth.Authorize(tt.id, tt.attrs); got != tt.want {
+			t.Errorf("Authorize(%v, %+v) = %v, want %v", tt.id.User, tt.attrs, got, tt.want)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	config := &Config{
+		Tokens:   []TokenConfig{{Token: "t", User: "root"}},
+		Bindings: []Binding{{Role: RoleAdmin, Users: []string{"root"}}},
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	config.Bindings = append(config.Bindings, Binding{Role: "owner", Users: []string{"root"}}, Binding{Role: RoleViewer})
+	if err := config.Validate(); err == nil {
+		t.Error("expected error for unknown role and binding without subjects")
+	}
+}
+
+func signTestToken(t *testing.T, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hasher := crypto.SHA256.New()
+	hasher.Write([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hasher.Sum(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestAuthenticate(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var issuer string
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			fmt.Fprintf(w, `{"issuer":"%v","jwks_uri":"%v/keys"}`, issuer, issuer)
+		case "/keys":
+			fmt.Fprintf(w, `{"keys":[{"kid":"k1","kty":"RSA","use":"sig","n":"%v","e":"%v"}]}`,
+				base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer provider.Close()
+	issuer = provider.URL
+
+	auth := MakeAuth(zap.NewNop(), &Config{
+		Tokens: []TokenConfig{{Token: "static-token", User: "ci", Groups: []string{"deployers"}}},
+		OIDC:   &OIDCConfig{Issuer: issuer, ClientID: "fission", UsernameClaim: "email"},
+	})
+
+	authenticate := func(token string) (*Identity, error) {
+		r := httptest.NewRequest(http.MethodGet, "/v2/functions", nil)
+		if len(token) > 0 {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		return auth.Authenticate(r)
+	}
+
+	id, err := authenticate("static-token")
+	if err != nil || id.User != "ci" || len(id.Groups) != 1 || id.Groups[0] != "deployers" {
+		t.Errorf("unexpected identity %+v for static token, error %v", id, err)
+	}
+
+	claims := map[string]interface{}{
+		"iss":    issuer,
+		"aud":    "fission",
+		"sub":    "1234",
+		"email":  "alice@example.com",
+		"groups": []string{"team-a", "team-b"},
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}
+	id, err = authenticate(signTestToken(t, key, claims))
+	if err != nil || id.User != "alice@example.com" || len(id.Groups) != 2 {
+		t.Errorf("unexpected identity %+v for ID token, error %v", id, err)
+	}
+
+	claims["aud"] = "other"
+	if _, err = authenticate(signTestToken(t, key, claims)); err == nil {
+		t.Error("expected error for ID token of another client")
+	}
+	claims["aud"] = "fission"
+
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	if _, err = authenticate(signTestToken(t, key, claims)); err == nil {
+		t.Error("expected error for expired ID token")
+	}
+
+	if _, err = authenticate("wrong-token"); err == nil {
+		t.Error("expected error for unknown token")
+	}
+	if _, err = authenticate(""); err == nil {
+		t.Error("expected error for missing token")
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiauth
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/fission/fission/pkg/jwt"
+)
+
+type (
+	// oidcProvider verifies ID tokens of an OpenID Connect provider,
+	// whose key set URL is found by discovery on first use.
+	oidcProvider struct {
+		config *OIDCConfig
+	}
+)
+
+func makeOIDCProvider(config *OIDCConfig) *oidcProvider {
+	return &oidcProvider{
+		config: config,
+	}
+}
+
+func (p *oidcProvider) authenticate(verifier *jwt.Verifier, token string) (*Identity, error) {
+	jwksURL, err := verifier.DiscoverJWKSURL(p.config.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := verifier.Verify(token, jwksURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims["iss"] != p.config.Issuer {
+		return nil, errors.Errorf("unexpected issuer %v", claims["iss"])
+	}
+	if len(p.config.ClientID) > 0 && !jwt.AudienceMatches(claims["aud"], []string{p.config.ClientID}) {
+		return nil, errors.Errorf("unexpected audience %v", claims["aud"])
+	}
+
+	usernameClaim := p.config.UsernameClaim
+	if len(usernameClaim) == 0 {
+		usernameClaim = "sub"
+	}
+	user, ok := claims[usernameClaim]
+	if !ok {
+		return nil, errors.Errorf("token has no %q claim", usernameClaim)
+	}
+	id := &Identity{User: jwt.ClaimToString(user)}
+
+	groupsClaim := p.config.GroupsClaim
+	if len(groupsClaim) == 0 {
+		groupsClaim = "groups"
+	}
+	switch groups := claims[groupsClaim].(type) {
+	case string:
+		id.Groups = []string{groups}
+	case []interface{}:
+		for _, g := range groups {
+			id.Groups = append(id.Groups, jwt.ClaimToString(g))
+		}
+	}
+
+	return id, nil
+}
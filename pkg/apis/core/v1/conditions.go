@@ -0,0 +1,59 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+// GetCondition returns the condition of the given type, or nil if the
+// function has none.
+func (status *FunctionStatus) GetCondition(condType FunctionConditionType) *FunctionCondition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == condType {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition adds a condition or replaces the one of the same type, and
+// returns whether the status changed. The last transition time is kept
+// unless the status of the condition changes, and observations older than
+// the current one are ignored. Observing the same condition again changes
+// nothing, so that status updates, each bumping the resource version of
+// the function, only happen on actual changes.
+func (status *FunctionStatus) SetCondition(cond FunctionCondition) bool {
+	current := status.GetCondition(cond.Type)
+	if current == nil {
+		if cond.LastTransitionTime.IsZero() {
+			cond.LastTransitionTime = cond.LastUpdateTime
+		}
+		status.Conditions = append(status.Conditions, cond)
+		return true
+	}
+
+	if cond.LastUpdateTime.Before(&current.LastUpdateTime) {
+		return false
+	}
+	if current.Status == cond.Status {
+		cond.LastTransitionTime = current.LastTransitionTime
+	} else if cond.LastTransitionTime.IsZero() {
+		cond.LastTransitionTime = cond.LastUpdateTime
+	}
+	if current.Status == cond.Status && current.Reason == cond.Reason && current.Message == cond.Message {
+		return false
+	}
+	*current = cond
+	return true
+}
@@ -37,6 +37,24 @@ const (
 
 	// ArchiveTypeUrl means the package contents are at the specified URL.
 	ArchiveTypeUrl ArchiveType = "url"
+
+	// ArchiveTypeGit means the package contents are in the specified Git
+	// repository.
+	ArchiveTypeGit ArchiveType = "git"
+)
+
+const (
+	// URL schemes of URL archives in object stores, which fetcher reads
+	// with the credentials of the auth secret of the archive.
+	ArchiveURLSchemeS3    = "s3"
+	ArchiveURLSchemeGCS   = "gs"
+	ArchiveURLSchemeAzure = "azblob"
+)
+
+const (
+	ArchiveFormatZip    ArchiveFormat = "zip"
+	ArchiveFormatTarGz  ArchiveFormat = "tar.gz"
+	ArchiveFormatBinary ArchiveFormat = "binary"
 )
 
 const (
@@ -47,11 +65,29 @@ const (
 	BuildStatusNone      = "none"
 )
 
+const (
+	// BuildOutputArchive builds packages into deploy archives the fetcher
+	// downloads into function pods.
+	BuildOutputArchive BuildOutput = "archive"
+
+	// BuildOutputImage builds packages into container images function pods
+	// run from directly.
+	BuildOutputImage BuildOutput = "image"
+)
+
 const (
 	AllowedFunctionsPerContainerSingle   = "single"
 	AllowedFunctionsPerContainerInfinite = "infinite"
 )
 
+const (
+	RolloutStrategyRecreate RolloutStrategyType = "recreate"
+	RolloutStrategyGradual  RolloutStrategyType = "gradual"
+
+	DefaultRolloutMaxSurge     = 1
+	DefaultRolloutStepInterval = 10
+)
+
 const (
 	ExecutorTypePoolmgr   ExecutorType = "poolmgr"
 	ExecutorTypeNewdeploy ExecutorType = "newdeploy"
@@ -61,6 +97,76 @@ const (
 	StrategyTypeExecution = "execution"
 )
 
+const (
+	// FunctionPackageBuilt is whether the package of a function is built,
+	// or needs no build.
+	FunctionPackageBuilt FunctionConditionType = "PackageBuilt"
+	// FunctionPodsReady is whether executor got pods serving a function the
+	// last time it was asked to.
+	FunctionPodsReady FunctionConditionType = "PodsReady"
+	// FunctionLastError holds the last error executor got serving a
+	// function in its message.
+	FunctionLastError FunctionConditionType = "LastError"
+	// FunctionQuarantined is whether executor stopped specializing a
+	// function whose pods crash over and over, until its spec changes.
+	FunctionQuarantined FunctionConditionType = "Quarantined"
+)
+
+const (
+	// FissionSourceSynced means the specs of the last revision were
+	// applied.
+	FissionSourceSynced FissionSourceSyncStatus = "Synced"
+	// FissionSourceFailed means the last sync failed, and is retried at
+	// the next interval.
+	FissionSourceFailed FissionSourceSyncStatus = "Failed"
+)
+
+const (
+	BucketProviderS3    BucketProvider = "s3"
+	BucketProviderMinIO BucketProvider = "minio"
+	BucketProviderGCS   BucketProvider = "gcs"
+)
+
+const (
+	// BucketEventCreated is an object created or overwritten.
+	BucketEventCreated BucketEventType = "created"
+	// BucketEventDeleted is an object deleted.
+	BucketEventDeleted BucketEventType = "deleted"
+)
+
+const (
+	DatabaseTypePostgres DatabaseType = "postgres"
+	DatabaseTypeMySQL    DatabaseType = "mysql"
+)
+
+const (
+	DatabaseOperationInsert DatabaseOperation = "insert"
+	DatabaseOperationUpdate DatabaseOperation = "update"
+	DatabaseOperationDelete DatabaseOperation = "delete"
+	// DatabaseOperationRead is a row read by the snapshot a connector
+	// takes when it starts.
+	DatabaseOperationRead DatabaseOperation = "read"
+)
+
+const (
+	WorkflowExecutionSucceeded WorkflowExecutionPhase = "Succeeded"
+	WorkflowExecutionFailed    WorkflowExecutionPhase = "Failed"
+)
+
+// DefaultWorkflowHistoryLimit is how many executions of a workflow are
+// kept in its status by default.
+const DefaultWorkflowHistoryLimit = 10
+
+const (
+	// DefaultInvocationHistorySize is how many invocations of a function
+	// router keeps in its history by default.
+	DefaultInvocationHistorySize = 100
+
+	// MaxInvocationErrorLength is how much of the error messages of
+	// invocations is kept in histories.
+	MaxInvocationErrorLength = 256
+)
+
 const (
 	SharedVolumeUserfunc   = "userfunc"
 	SharedVolumePackages   = "packages"
@@ -69,9 +175,14 @@ const (
 )
 
 const (
-	MessageQueueTypeNats  = "nats-streaming"
-	MessageQueueTypeASQ   = "azure-storage-queue"
-	MessageQueueTypeKafka = "kafka"
+	MessageQueueTypeNats         = "nats-streaming"
+	MessageQueueTypeASQ          = "azure-storage-queue"
+	MessageQueueTypeKafka        = "kafka"
+	MessageQueueTypeRabbitMQ     = "rabbitmq"
+	MessageQueueTypeSQS          = "aws-sqs-queue"
+	MessageQueueTypeGCPPubSub    = "gcp-pubsub"
+	MessageQueueTypeRedisStreams = "redis-streams"
+	MessageQueueTypePulsar       = "pulsar"
 )
 
 const (
@@ -102,14 +213,185 @@ const (
 	MaxIterationsForCanaryConfig = 10
 )
 
+const (
+	CanarySLOTypeLatency CanarySLOType = "latency"
+	CanarySLOTypeQuery   CanarySLOType = "query"
+)
+
+const (
+	CanaryMetricsProviderPrometheus  CanaryMetricsProviderType = "prometheus"
+	CanaryMetricsProviderDatadog     CanaryMetricsProviderType = "datadog"
+	CanaryMetricsProviderCloudWatch  CanaryMetricsProviderType = "cloudwatch"
+	CanaryMetricsProviderStackdriver CanaryMetricsProviderType = "stackdriver"
+)
+
+const (
+	SessionAffinityTypeCookie SessionAffinityType = "cookie"
+	SessionAffinityTypeHeader SessionAffinityType = "header"
+
+	DefaultSessionAffinityCookie = "fission-session"
+	DefaultSessionAffinityTTL    = 1800
+)
+
+const (
+	FeatureFlagSourceTypeLaunchDarkly FeatureFlagSourceType = "launchdarkly"
+	FeatureFlagSourceTypeUnleash      FeatureFlagSourceType = "unleash"
+	FeatureFlagSourceTypeConfigMap    FeatureFlagSourceType = "configmap"
+
+	DefaultLaunchDarklyURL         = "https://clientsdk.launchdarkly.com"
+	DefaultFeatureFlagKeyHeader    = "X-Fission-User"
+	DefaultFeatureFlagHeaderPrefix = "X-Fission-Flag-"
+	DefaultFeatureFlagCacheTTL     = 30
+)
+
+const (
+	ExperimentAssignmentTypeCookie ExperimentAssignmentType = "cookie"
+	ExperimentAssignmentTypeHeader ExperimentAssignmentType = "header"
+
+	DefaultExperimentCookie = "fission-experiment"
+
+	// HeaderExperiment and HeaderExperimentVariant tell functions the
+	// experiment and variant a request takes part in.
+	HeaderExperiment        = "X-Fission-Experiment"
+	HeaderExperimentVariant = "X-Fission-Experiment-Variant"
+)
+
+const (
+	// MaxHTTPTriggerRetries limits the retries of a request to an HTTP trigger.
+	MaxHTTPTriggerRetries = 10
+
+	DefaultRetryBackoff                 = 100
+	DefaultCircuitBreakerErrorThreshold = 5
+	DefaultCircuitBreakerOpenDuration   = 30
+	DefaultLongPollTimeout              = 300
+	DefaultLongPollKeepAliveInterval    = 30
+)
+
+const (
+	IngressTypeIngress   IngressType = "ingress"
+	IngressTypeHTTPRoute IngressType = "httproute"
+)
+
+const (
+	// InvocationPriorityInteractive is for requests of users waiting for the response.
+	InvocationPriorityInteractive InvocationPriority = "interactive"
+	InvocationPriorityStandard    InvocationPriority = "standard"
+	// InvocationPriorityBatch is for bulk and background invocations that
+	// can wait, like the ones of time and message queue triggers.
+	InvocationPriorityBatch InvocationPriority = "batch"
+)
+
+const (
+	// CloudEventsModeBinary passes the attributes of events in "ce-" headers
+	// and their data as the body.
+	CloudEventsModeBinary CloudEventsMode = "binary"
+	// CloudEventsModeStructured passes events as a whole in the JSON body.
+	CloudEventsModeStructured CloudEventsMode = "structured"
+	// CloudEventsModeNone passes requests as is.
+	CloudEventsModeNone CloudEventsMode = "none"
+)
+
+const (
+	QuotaWindowDay   QuotaWindow = "day"
+	QuotaWindowMonth QuotaWindow = "month"
+)
+
+const (
+	// SecretProviderVault reads secrets from HashiCorp Vault.
+	SecretProviderVault SecretProvider = "vault"
+	// SecretProviderAWS reads secrets from AWS Secrets Manager.
+	SecretProviderAWS SecretProvider = "aws"
+	// SecretProviderGCP reads secrets from GCP Secret Manager.
+	SecretProviderGCP SecretProvider = "gcp"
+)
+
+const (
+	DefaultOpenAPISpecPath = "openapi.yaml"
+)
+
+const (
+	TimeTriggerOutputTypeStorage TimeTriggerOutputType = "storage"
+	TimeTriggerOutputTypeEmail   TimeTriggerOutputType = "email"
+
+	DefaultTimeTriggerOutputTimeout = 300
+
+	// Annotations timer sets on a time trigger after uploading its output to the storage service.
+	ANNOTATION_TIMER_LAST_OUTPUT_ID   = "timerLastOutputId"
+	ANNOTATION_TIMER_LAST_OUTPUT_TIME = "timerLastOutputTime"
+
+	// ANNOTATION_TIMER_LAST_FIRE_TIME is the annotation timer records the
+	// scheduled time of the last fire of a time trigger in, to fire it once
+	// across replicas and catch up on the fires missed since.
+	ANNOTATION_TIMER_LAST_FIRE_TIME = "timerLastFireTime"
+
+	// ANNOTATION_KUBEWATCHER_RESOURCE_VERSION is the annotation kubewatcher
+	// bookmarks the resource version of the last event of a watch trigger
+	// in, to resume watching from it after restarts.
+	ANNOTATION_KUBEWATCHER_RESOURCE_VERSION = "kubewatcherResourceVersion"
+)
+
+const (
+	CanaryMatchTypeHeader CanaryMatchType = "header"
+	CanaryMatchTypeCookie CanaryMatchType = "cookie"
+	CanaryMatchTypeQuery  CanaryMatchType = "query"
+)
+
 const (
 	DefaultSpecializationTimeOut = 120
 )
 
+const (
+	// FunctionInflightRequestsMetric is the metric router reports the number of
+	// in-flight requests of each function with, labeled by function namespace and name.
+	FunctionInflightRequestsMetric = "fission_function_inflight_requests"
+)
+
+const (
+	// Event sources set these headers on requests invoking functions through router,
+	// so that router is able to report metrics for each trigger.
+	HeaderTriggerType = "X-Fission-Trigger-Type"
+	HeaderTriggerName = "X-Fission-Trigger-Name"
+	// HeaderEventTime is the time in RFC3339 format the event was produced at, the
+	// difference to the time router receives the request is reported as trigger lag.
+	HeaderEventTime = "X-Fission-Event-Time"
+	// HeaderPriority is the InvocationPriority of the request.
+	HeaderPriority = "X-Fission-Priority"
+	// HeaderCloudEventsMode is the CloudEventsMode of the trigger, for
+	// router to pass the request to the function in.
+	HeaderCloudEventsMode = "X-Fission-CloudEvents-Mode"
+)
+
+const (
+	// HeaderContinue is set by list APIs of controller on responses holding
+	// one page of objects, it's the token to get the next page with.
+	HeaderContinue = "X-Fission-Continue"
+)
+
+const (
+	TriggerTypeHTTP         = "http"
+	TriggerTypeMessageQueue = "messagequeue"
+	TriggerTypeTime         = "time"
+	TriggerTypeKubeWatch    = "kubewatch"
+	TriggerTypeBucket       = "bucket"
+	TriggerTypeDatabase     = "database"
+	TriggerTypeWorkflow     = "workflow"
+)
+
+const (
+	// Metrics router reports for each trigger, labeled by trigger type, namespace and name.
+	TriggerCallsMetric       = "fission_trigger_calls_total"
+	TriggerErrorsMetric      = "fission_trigger_errors_total"
+	TriggerLastSuccessMetric = "fission_trigger_last_success_timestamp_seconds"
+	TriggerLagMetric         = "fission_trigger_lag_seconds"
+)
+
 const (
 	FETCH_SOURCE = iota
 	FETCH_DEPLOYMENT
 	FETCH_URL
+	// FETCH_IMAGE fetches nothing but secrets and configmaps, the deployment
+	// archive is part of the function image already.
+	FETCH_IMAGE
 )
 
 // executor kubernetes object label key
@@ -121,6 +403,7 @@ const (
 	FUNCTION_NAME             = "functionName"
 	FUNCTION_UID              = "functionUid"
 	FUNCTION_RESOURCE_VERSION = "functionResourceVersion"
+	FUNCTION_GENERATION       = "functionGeneration"
 	EXECUTOR_TYPE             = "executorType"
 )
 
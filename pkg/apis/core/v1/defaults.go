@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+// Defaults of the fields of functions and environments left unset. The CLI
+// uses them as the defaults of its flags, the controller and the mutating
+// webhook fill them in objects created in other ways.
+const (
+	DefaultIdleTimeout            = 120
+	DefaultConcurrency            = 500
+	DefaultRequestsPerPod         = 1
+	DefaultTargetCPUPercent       = 80
+	DefaultMaxScale               = 1
+	DefaultTerminationGracePeriod = 360
+	DefaultBuildCommand           = "build"
+)
+
+// SetDefaults fills the unset fields of a function spec. References without
+// a namespace refer to objects in the namespace of the function.
+func (spec *FunctionSpec) SetDefaults(namespace string) {
+	if len(spec.Environment.Name) > 0 && len(spec.Environment.Namespace) == 0 {
+		spec.Environment.Namespace = namespace
+	}
+	if len(spec.Package.PackageRef.Name) > 0 && len(spec.Package.PackageRef.Namespace) == 0 {
+		spec.Package.PackageRef.Namespace = namespace
+	}
+
+	if len(spec.InvokeStrategy.StrategyType) == 0 {
+		spec.InvokeStrategy.StrategyType = StrategyTypeExecution
+	}
+	es := &spec.InvokeStrategy.ExecutionStrategy
+	if len(es.ExecutorType) == 0 {
+		es.ExecutorType = ExecutorTypePoolmgr
+		// pools are pods of environments, containers have none
+		if spec.Container != nil {
+			es.ExecutorType = ExecutorTypeNewdeploy
+		}
+	}
+	if es.SpecializationTimeout == 0 {
+		es.SpecializationTimeout = DefaultSpecializationTimeOut
+	}
+	if es.ExecutorType == ExecutorTypeNewdeploy {
+		if es.MaxScale == 0 {
+			es.MaxScale = DefaultMaxScale
+			if es.MinScale > es.MaxScale {
+				es.MaxScale = es.MinScale
+			}
+		}
+		if es.TargetCPUPercent == 0 {
+			es.TargetCPUPercent = DefaultTargetCPUPercent
+		}
+	}
+
+	if spec.FunctionTimeout == 0 {
+		spec.FunctionTimeout = DEFAULT_FUNCTION_TIMEOUT
+	}
+	if spec.IdleTimeout == nil {
+		idleTimeout := DefaultIdleTimeout
+		spec.IdleTimeout = &idleTimeout
+	}
+	if spec.Concurrency == 0 {
+		spec.Concurrency = DefaultConcurrency
+	}
+	if spec.RequestsPerPod == 0 {
+		spec.RequestsPerPod = DefaultRequestsPerPod
+	}
+}
+
+// SetDefaults fills the unset fields of an environment spec. Environments
+// with a builder default to the version 2 interface, other ones to the
+// version 1 interface.
+func (spec *EnvironmentSpec) SetDefaults() {
+	if spec.Version == 0 {
+		spec.Version = 1
+		if len(spec.Builder.Image) > 0 {
+			spec.Version = 2
+		}
+	}
+	if len(spec.Builder.Image) > 0 && len(spec.Builder.Command) == 0 {
+		spec.Builder.Command = DefaultBuildCommand
+	}
+	if spec.TerminationGracePeriod == 0 {
+		spec.TerminationGracePeriod = DefaultTerminationGracePeriod
+	}
+}
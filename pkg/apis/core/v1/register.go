@@ -68,6 +68,20 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&PackageList{},
 		&CanaryConfig{},
 		&CanaryConfigList{},
+		&FissionConfig{},
+		&FissionConfigList{},
+		&FissionSource{},
+		&FissionSourceList{},
+		&BucketTrigger{},
+		&BucketTriggerList{},
+		&DatabaseTrigger{},
+		&DatabaseTriggerList{},
+		&Workflow{},
+		&WorkflowList{},
+		&InvocationHistory{},
+		&InvocationHistoryList{},
+		&FunctionUsage{},
+		&FunctionUsageList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -66,6 +66,11 @@ type (
 		metav1.TypeMeta   `json:",inline"`
 		metav1.ObjectMeta `json:"metadata"`
 		Spec              FunctionSpec `json:"spec"`
+
+		// Status is set by executor and buildermgr through the status
+		// subresource, updating a function leaves it as is.
+		// +optional
+		Status FunctionStatus `json:"status,omitempty"`
 	}
 
 	// FunctionList is a list of Functions.
@@ -164,6 +169,108 @@ type (
 		Items           []MessageQueueTrigger `json:"items"`
 	}
 
+	// BucketTrigger invokes functions with the metadata of objects created
+	// in or deleted from an object storage bucket, on the event
+	// notifications the bucket sends to the bucket trigger manager.
+	// +genclient
+	// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+	BucketTrigger struct {
+		metav1.TypeMeta   `json:",inline"`
+		metav1.ObjectMeta `json:"metadata"`
+
+		Spec BucketTriggerSpec `json:"spec"`
+	}
+
+	// BucketTriggerList is a list of BucketTriggers.
+	// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+	BucketTriggerList struct {
+		metav1.TypeMeta `json:",inline"`
+		metav1.ListMeta `json:"metadata"`
+		Items           []BucketTrigger `json:"items"`
+	}
+
+	// DatabaseTrigger invokes functions with the rows changed in the tables
+	// of a database, on the change events Debezium captures of the database
+	// and publishes to Kafka.
+	// +genclient
+	// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+	DatabaseTrigger struct {
+		metav1.TypeMeta   `json:",inline"`
+		metav1.ObjectMeta `json:"metadata"`
+
+		Spec DatabaseTriggerSpec `json:"spec"`
+	}
+
+	// DatabaseTriggerList is a list of DatabaseTriggers.
+	// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+	DatabaseTriggerList struct {
+		metav1.TypeMeta `json:",inline"`
+		metav1.ListMeta `json:"metadata"`
+		Items           []DatabaseTrigger `json:"items"`
+	}
+
+	// Workflow composes functions into a sequence of steps, branching on
+	// the status codes of their responses and fanning out to functions
+	// invoked in parallel. The workflow engine executes workflows and
+	// records their last executions in their status.
+	// +genclient
+	// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+	Workflow struct {
+		metav1.TypeMeta   `json:",inline"`
+		metav1.ObjectMeta `json:"metadata"`
+		Spec              WorkflowSpec   `json:"spec"`
+		Status            WorkflowStatus `json:"status"`
+	}
+
+	// WorkflowList is a list of Workflows.
+	// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+	WorkflowList struct {
+		metav1.TypeMeta `json:",inline"`
+		metav1.ListMeta `json:"metadata"`
+		Items           []Workflow `json:"items"`
+	}
+
+	// InvocationHistory keeps the most recent invocations of a function, as
+	// router records them. It has the name of the function, and is deleted
+	// with it.
+	// +genclient
+	// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+	InvocationHistory struct {
+		metav1.TypeMeta   `json:",inline"`
+		metav1.ObjectMeta `json:"metadata"`
+
+		// Invocations are the recorded invocations, the most recent first.
+		// +optional
+		Invocations []InvocationRecord `json:"invocations,omitempty"`
+	}
+
+	// InvocationHistoryList is a list of InvocationHistories.
+	// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+	InvocationHistoryList struct {
+		metav1.TypeMeta `json:",inline"`
+		metav1.ListMeta `json:"metadata"`
+		Items           []InvocationHistory `json:"items"`
+	}
+
+	// FunctionUsage accounts for the resources a function used, for
+	// chargeback. It has the name of the function, and is deleted with it.
+	// +genclient
+	// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+	FunctionUsage struct {
+		metav1.TypeMeta   `json:",inline"`
+		metav1.ObjectMeta `json:"metadata"`
+
+		Usage ResourceUsage `json:"usage"`
+	}
+
+	// FunctionUsageList is a list of FunctionUsages.
+	// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+	FunctionUsageList struct {
+		metav1.TypeMeta `json:",inline"`
+		metav1.ListMeta `json:"metadata"`
+		Items           []FunctionUsage `json:"items"`
+	}
+
 	// CanaryConfig is for canary deployment of two functions.
 	// +genclient
 	// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -183,6 +290,49 @@ type (
 		Items []CanaryConfig `json:"items"`
 	}
 
+	// FissionConfig holds the tunables of the Fission components. Each
+	// component reconciles the FissionConfig named "fission" in the
+	// namespace Fission is installed in, and reports the settings in
+	// effect in its status.
+	// +genclient
+	// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+	FissionConfig struct {
+		metav1.TypeMeta   `json:",inline"`
+		metav1.ObjectMeta `json:"metadata"`
+		Spec              FissionConfigSpec   `json:"spec"`
+		Status            FissionConfigStatus `json:"status"`
+	}
+
+	// FissionConfigList is a list of FissionConfigs.
+	// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+	FissionConfigList struct {
+		metav1.TypeMeta `json:",inline"`
+		metav1.ListMeta `json:"metadata"`
+
+		Items []FissionConfig `json:"items"`
+	}
+
+	// FissionSource is a Git repository holding Fission specs, which the
+	// gitops controller applies as spec apply does whenever the branch
+	// changes, and reports the sync status of in its status.
+	// +genclient
+	// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+	FissionSource struct {
+		metav1.TypeMeta   `json:",inline"`
+		metav1.ObjectMeta `json:"metadata"`
+		Spec              FissionSourceSpec   `json:"spec"`
+		Status            FissionSourceStatus `json:"status"`
+	}
+
+	// FissionSourceList is a list of FissionSources.
+	// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+	FissionSourceList struct {
+		metav1.TypeMeta `json:",inline"`
+		metav1.ListMeta `json:"metadata"`
+
+		Items []FissionSource `json:"items"`
+	}
+
 	//
 	// Functions and packages
 	//
@@ -200,30 +350,85 @@ type (
 		Sum  string       `json:"sum,omitempty"`
 	}
 
-	// ArchiveType is either literal or URL, indicating whether
+	// ArchiveType is either literal, URL or git, indicating whether
 	// the package is specified in the Archive struct or
 	// externally.
 	ArchiveType string
 
+	// ArchiveFormat is the format of package contents.
+	ArchiveFormat string
+
 	// Package contains or references a collection of source or
 	// binary files.
 	Archive struct {
-		// Type defines how the package is specified: literal or URL.
+		// Type defines how the package is specified: literal, URL or git.
 		// Available value:
 		//  - literal
 		//  - url
+		//  - git
 		Type ArchiveType `json:"type,omitempty"`
 
 		// Literal contents of the package. Can be used for
 		// encoding packages below TODO (256KB?) size.
 		Literal []byte `json:"literal,omitempty"`
 
-		// URL references a package.
+		// URL references a package. Besides HTTP(S) URLs, like presigned
+		// URLs of object stores, packages may be read from object stores
+		// with s3://bucket/key, gs://bucket/key or
+		// azblob://container/blob URLs.
 		URL string `json:"url,omitempty"`
 
+		// AuthSecret is the secret in the namespace of the package with the
+		// credentials of the object store of the URL. S3 secrets hold
+		// access-key-id and secret-access-key, with optional session-token,
+		// region and endpoint for S3 compatible stores like MinIO. GCS
+		// secrets hold the access-key-id and secret-access-key of an HMAC
+		// key, and Azure secrets account-name and account-key. S3 archives
+		// without a secret are read with the credentials of fetcher, like
+		// the IAM role of its pod.
+		// +optional
+		AuthSecret string `json:"authSecret,omitempty"`
+
 		// Checksum ensures the integrity of packages
 		// referenced by URL. Ignored for literals.
 		Checksum Checksum `json:"checksum,omitempty"`
+
+		// Git references a package in a Git repository, cloned by
+		// fetcher when the package is fetched. Used with type git.
+		// +optional
+		Git *GitSource `json:"git,omitempty"`
+
+		// Format of the package contents, used by fetcher to decide how
+		// to extract it. Detected from the contents if empty.
+		// Available value:
+		//  - zip
+		//  - tar.gz
+		//  - binary: a single executable, e.g. a static Go or Rust binary
+		// +optional
+		Format ArchiveFormat `json:"format,omitempty"`
+	}
+
+	// GitSource references the contents of a package in a Git repository.
+	GitSource struct {
+		// URL of the repository to clone, over HTTPS or SSH.
+		URL string `json:"url"`
+
+		// Ref is the branch, tag or commit to check out. The default
+		// branch of the repository if empty.
+		// +optional
+		Ref string `json:"ref,omitempty"`
+
+		// SubPath is the directory of the repository holding the
+		// package contents. The whole repository if empty.
+		// +optional
+		SubPath string `json:"subPath,omitempty"`
+
+		// AuthSecret is the name of a secret in the namespace of the
+		// package to authenticate to the repository with: either
+		// "username" and "password" (or a token) over HTTPS, or
+		// "ssh-privatekey" and optionally "known_hosts" over SSH.
+		// +optional
+		AuthSecret string `json:"authSecret,omitempty"`
 	}
 
 	// EnvironmentReference is a reference to a environment.
@@ -244,6 +449,52 @@ type (
 		Name      string `json:"name"`
 	}
 
+	// ConfigReload configures the reload of the secrets and configmaps of
+	// a function in its running pods. Fetcher watches them, and renames
+	// the new content of each key changed over its file, so functions
+	// reading a file see either its old or its new content. Keys removed
+	// are removed, secrets and configmaps deleted are kept as they were.
+	ConfigReload struct {
+		// CallbackPath, if set, is the path of the function container
+		// fetcher POSTs to once it updated a secret or configmap, with
+		// its kind, namespace and name as JSON, e.g. for the function to
+		// reconnect with new credentials.
+		// +optional
+		CallbackPath string `json:"callbackPath,omitempty"`
+	}
+
+	// SecretProvider is the kind of external secret store a secret is
+	// kept in.
+	SecretProvider string
+
+	// ExternalSecretReference is a reference to a secret of an external
+	// secret store. Fetcher resolves it when specializing the pods of the
+	// function and writes its keys where it writes the keys of a
+	// kubernetes secret of the same name, so functions read both alike.
+	// Secrets kept as JSON objects have a key per field, other secrets
+	// have a single "value" key.
+	ExternalSecretReference struct {
+		// Name of the secret in the namespace of the function.
+		Name string `json:"name"`
+
+		// Provider is the secret store keeping the secret: "vault",
+		// "aws" or "gcp".
+		Provider SecretProvider `json:"provider"`
+
+		// Path of the secret in its store: the path of a Vault secret,
+		// e.g. "secret/data/db" for the KV engine v2, the name or ARN
+		// of an AWS Secrets Manager secret, or the resource name of a
+		// GCP Secret Manager version, e.g.
+		// "projects/p/secrets/db/versions/latest".
+		Path string `json:"path"`
+
+		// Role is the Vault role fetcher logs in as with the service
+		// account token of the pod. Defaults to the role fetcher is
+		// configured with.
+		// +optional
+		Role string `json:"role,omitempty"`
+	}
+
 	// BuildStatus indicates the current build status of a package.
 	BuildStatus string
 
@@ -263,9 +514,43 @@ type (
 		// BuildCommand is a custom build command that builder used to build the source archive.
 		BuildCommand string `json:"buildcmd,omitempty"`
 
+		// BuildSteps are run one after the other in place of BuildCommand to build
+		// the source archive. The build fails at the first failing step.
+		BuildSteps []BuildStep `json:"buildSteps,omitempty"`
+
+		// DeploymentImage is the container image holding the deployment archive and the
+		// environment runtime, built by builder manager for environments with an image
+		// build output. Newdeploy functions of the package run from it without fetching the archive.
+		DeploymentImage string `json:"deploymentImage,omitempty"`
+
+		// SourceMap is the manifest of the source maps in the deployable archive, stored by
+		// builder manager for builds outputting source maps. The logs API uses it to map the
+		// stack frames of functions to their original sources.
+		SourceMap Archive `json:"sourceMap,omitempty"`
+
 		// In the future, we can have a debug build here too
 	}
 
+	// BuildStep is a named step of a package build.
+	BuildStep struct {
+		// Name of the step, shown in the build logs.
+		Name string `json:"name"`
+
+		// Image the step runs in. Steps without an image run in the environment builder
+		// and share the deployment package it outputs, steps with an image run in a
+		// separate pod against the source archive, e.g. to lint or test it, and
+		// don't change the deployment package.
+		Image string `json:"image,omitempty"`
+
+		// Command of the step and its arguments. Steps in the environment builder
+		// run the builder command by default.
+		Command []string `json:"command,omitempty"`
+
+		// Env are the environment variables of the step, in addition to SRC_PKG
+		// and DEPLOY_PKG. Steps in the environment builder only support values.
+		Env []apiv1.EnvVar `json:"env,omitempty"`
+	}
+
 	// PackageStatus contains the build status of a package also the build log for examination.
 	PackageStatus struct {
 		// TODO: Add another status field to indicate whether a package
@@ -331,6 +616,19 @@ type (
 		// Reference to a list of configmaps.
 		ConfigMaps []ConfigMapReference `json:"configmaps"`
 
+		// ExternalSecrets are secrets of external secret stores, resolved
+		// by fetcher with short-lived credentials instead of being copied
+		// into kubernetes secrets. Container functions, which have no
+		// fetcher, can't reference them.
+		// +optional
+		ExternalSecrets []ExternalSecretReference `json:"externalSecrets,omitempty"`
+
+		// ConfigReload has fetcher update the secrets and configmaps of
+		// the function in its running pods when they change, instead of
+		// executor recreating the pods.
+		// +optional
+		ConfigReload *ConfigReload `json:"configReload,omitempty"`
+
 		// cpu and memory resources as per K8S standards
 		// This is only for newdeploy to set up resource limitation
 		// when creating deployment for a function.
@@ -357,6 +655,141 @@ type (
 		// RequestsPerPod indicates the maximum number of concurrent requests that can be served by a specialized pod
 		// This is optional. If not specified default value will be taken as 1
 		RequestsPerPod int `json:"requestsPerPod,omitempty"`
+
+		// CoLocateWith names functions in the same namespace this function
+		// calls or is called by, e.g. the other steps of a pipeline. Executor
+		// prefers the nodes running pods of these functions for the pods of
+		// this function, to cut the latency of the calls between them. It's
+		// a hint only, pods go to other nodes if these have no room.
+		// +optional
+		CoLocateWith []string `json:"colocatewith,omitempty"`
+
+		// Priority of the function when resources run short. Under node
+		// pressure, executor evicts the idle pods of low priority functions
+		// with little recent traffic first. Defaults to "standard".
+		// +optional
+		Priority InvocationPriority `json:"priority,omitempty"`
+
+		// InvocationQuotas limit the invocations of the function per day
+		// or month, at most one per window. Router rejects invocations
+		// beyond them with 429 until the window ends.
+		// +optional
+		InvocationQuotas []InvocationQuota `json:"invocationQuotas,omitempty"`
+
+		// Container is the image the function runs, for functions
+		// without environment and package. These functions are run by
+		// newdeploy only.
+		// +optional
+		Container *FunctionContainer `json:"container,omitempty"`
+
+		// Probes check the health of the function in its pods, once they
+		// are specialized. They override the probes of the environment of
+		// the same kind.
+		// +optional
+		Probes *Probes `json:"probes,omitempty"`
+
+		// PodSpec is merged into the pods of the function after the pod
+		// spec of its environment, with the same strategic merge. The
+		// function container is named after the function. Pods of poolmgr
+		// are shared by the functions of an environment, so only newdeploy
+		// functions may set it.
+		// +optional
+		PodSpec *apiv1.PodSpec `json:"podspec,omitempty"`
+	}
+
+	// Probes check the health of functions in specialized pods. Until a
+	// pod is specialized, it runs the environment rather than the
+	// function, so fetcher runs the probes on behalf of kubelet once the
+	// function is loaded. It runs their HTTP GET or TCP socket actions
+	// against the function container, with their timings, and restarts
+	// the container on failures of the startup or liveness probes, then
+	// loads the function again. Exec actions are only supported by
+	// container functions, which pass probes on to their container.
+	//
+	// With newdeploy, pods receive no requests while the readiness probe
+	// fails. With poolmgr, requests are sent to pods directly: the
+	// specialization of pods completes once the function is ready, and
+	// later readiness failures only show in the pod status.
+	Probes struct {
+		// Startup holds off the readiness and liveness probes until it
+		// succeeds, for functions slow to start.
+		// +optional
+		Startup *apiv1.Probe `json:"startup,omitempty"`
+
+		// Readiness tells when the function is ready for requests.
+		// +optional
+		Readiness *apiv1.Probe `json:"readiness,omitempty"`
+
+		// Liveness tells when the function has to be restarted.
+		// +optional
+		Liveness *apiv1.Probe `json:"liveness,omitempty"`
+	}
+
+	// FunctionContainer is a container image serving the requests of a
+	// function on its own, like a handler already containerized.
+	FunctionContainer struct {
+		// Image of the container.
+		Image string `json:"image"`
+
+		// Command of the container, the entrypoint of the image if
+		// empty.
+		// +optional
+		Command []string `json:"command,omitempty"`
+
+		// Port the container serves function requests on, 8888 if
+		// unset.
+		// +optional
+		Port int32 `json:"port,omitempty"`
+
+		// ImagePullSecret is the secret to pull the image with from a
+		// private registry.
+		// +optional
+		ImagePullSecret string `json:"imagepullsecret,omitempty"`
+	}
+
+	// QuotaWindow is the calendar period of an invocation quota, "day"
+	// or "month". Windows start at midnight UTC.
+	QuotaWindow string
+
+	// InvocationQuota limits the invocations over a calendar window.
+	InvocationQuota struct {
+		// Limit is how many invocations are allowed per window.
+		Limit int64 `json:"limit"`
+
+		Window QuotaWindow `json:"window"`
+	}
+
+	// FunctionConditionType is the type of a condition of a function.
+	FunctionConditionType string
+
+	// FunctionCondition is an observation of the state of a function.
+	FunctionCondition struct {
+		Type   FunctionConditionType `json:"type"`
+		Status apiv1.ConditionStatus `json:"status"`
+
+		// LastUpdateTime is when the condition was last observed to change.
+		// +optional
+		LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+
+		// LastTransitionTime is when the condition last changed from one
+		// status to another.
+		// +optional
+		LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+		// Reason is a CamelCase reason of the last transition.
+		// +optional
+		Reason string `json:"reason,omitempty"`
+
+		// Message is a human readable detail of the last transition.
+		// +optional
+		Message string `json:"message,omitempty"`
+	}
+
+	// FunctionStatus tells whether a function is actually servable.
+	FunctionStatus struct {
+		// Conditions are the latest observations of the state of the function.
+		// +optional
+		Conditions []FunctionCondition `json:"conditions,omitempty"`
 	}
 
 	// InvokeStrategy is a set of controls over how the function executes.
@@ -407,6 +840,11 @@ type (
 		// This is only for newdeploy to set up target CPU utilization of HPA.
 		TargetCPUPercent int
 
+		// This is only for newdeploy to scale on the average number of in-flight
+		// requests per pod reported by router, instead of only on CPU utilization.
+		// Zero disables concurrency-based scaling.
+		TargetConcurrencyPerPod int
+
 		// This is the timeout setting for executor to wait for pod specialization.
 		SpecializationTimeout int
 	}
@@ -430,6 +868,40 @@ type (
 		// Function Reference by weight. this map contains function name as key and its weight
 		// as the value. This is for canary upgrade purpose.
 		FunctionWeights map[string]int `json:"functionweights"`
+
+		// MatchRoute sends requests matching a rule to one of the functions in
+		// FunctionWeights regardless of the weights. Only for function-weights type.
+		// +optional
+		MatchRoute *FunctionMatchRoute `json:"matchroute,omitempty"`
+	}
+
+	// FunctionMatchRoute routes the requests matching Match to Function.
+	FunctionMatchRoute struct {
+		// Match is the rule a request must satisfy.
+		Match CanaryMatch `json:"match"`
+
+		// Function is the name of the function receiving the matched requests.
+		Function string `json:"function"`
+	}
+
+	CanaryMatchType string
+
+	// CanaryMatch describes a request header, cookie or query
+	// parameter used to pick requests for the new function.
+	CanaryMatch struct {
+		// Type of the request attribute to match.
+		// Available value:
+		// - header
+		// - cookie
+		// - query
+		Type CanaryMatchType `json:"type"`
+
+		// Name of the header, cookie or query parameter.
+		Name string `json:"name"`
+
+		// Value the attribute must equal. If empty, any request
+		// carrying the attribute is matched.
+		Value string `json:"value,omitempty"`
 	}
 
 	//
@@ -471,15 +943,23 @@ type (
 		Container *apiv1.Container `json:"container,omitempty"`
 
 		// (Optional) Podspec allows modification of deployed runtime pod with Kubernetes PodSpec
-		// The merging logic is briefly described below and detailed MergePodSpec function
-		// - Volumes mounts and env variables for function and fetcher container are appended
-		// - All additional containers and init containers are appended
-		// - Volume definitions are appended
+		// It is merged into the generated pods like a strategic merge patch of kubectl
+		// - Containers, init containers and volumes are merged by name, so sidecars are added
+		//   and the function and fetcher containers are changed by naming them. The function
+		//   container is named after the environment for poolmgr and after the function for newdeploy
+		// - Env variables, ports and volume mounts of containers are merged by name, port and path
 		// - Lists such as tolerations, ImagePullSecrets, HostAliases are appended
-		// - Structs are merged and variables from pod spec take precedence
+		// - Other lists replace the generated ones
+		// - Structs such as securityContext and affinity are merged field by field, and fields
+		//   from pod spec take precedence
 		//
 		// You can set either PodSpec or Container, but not both.
 		PodSpec *apiv1.PodSpec `json:"podspec,omitempty"`
+
+		// (Optional) Probes check the health of the functions of the
+		// environment once they are loaded, unless overridden by the
+		// probes of the functions.
+		Probes *Probes `json:"probes,omitempty"`
 	}
 
 	// Builder is the setting for environment builder.
@@ -503,8 +983,21 @@ type (
 
 		// PodSpec will store the spec of the pod that will be applied to the pod created for the builder
 		PodSpec *apiv1.PodSpec `json:"podspec,omitempty"`
+
+		// (Optional) MaxConcurrentBuilds is how many packages of the environment
+		// are built at the same time. Builder manager uses its default if 0.
+		MaxConcurrentBuilds int `json:"maxConcurrentBuilds,omitempty"`
+
+		// (Optional) Output is what builds of the environment produce, a deploy
+		// archive (the default) or also a container image pushed to the registry
+		// configured for builder manager. Functions run by the newdeploy executor
+		// run from the image, the poolmgr executor keeps using the archive.
+		Output BuildOutput `json:"output,omitempty"`
 	}
 
+	// BuildOutput is the kind of artifact a build produces.
+	BuildOutput string
+
 	// EnvironmentSpec contains with builder, runtime and some other related environment settings.
 	EnvironmentSpec struct {
 		// Version is the Environment API version
@@ -559,19 +1052,96 @@ type (
 		// ImagePullSecret is the secret for Kubernetes to pull an image from a
 		// private registry.
 		ImagePullSecret string `json:"imagepullsecret"`
+
+		// RolloutStrategy controls how poolmgr replaces the pre-warm pool
+		// when the environment changes, e.g. to a new runtime image.
+		// (Optional) defaults to recreating the whole pool at once.
+		// +optional
+		RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+
+		// HTTPTriggerDefaults are the router middleware settings inherited by
+		// the HTTP triggers of functions using the environment, unless a
+		// trigger sets its own.
+		// +optional
+		HTTPTriggerDefaults *HTTPTriggerDefaults `json:"httpTriggerDefaults,omitempty"`
 	}
 
 	AllowedFunctionsPerContainer string
 
+	RolloutStrategyType string
+
+	// RolloutStrategy describes how the pre-warm pool of an environment is
+	// moved to a new version of the environment.
+	RolloutStrategy struct {
+		// Type is either "recreate", tearing the old pool down as soon as
+		// the new one is created, or "gradual", moving pods from the old
+		// pool to the new one in steps. Defaults to "recreate".
+		Type RolloutStrategyType `json:"type,omitempty"`
+
+		// MaxSurge is the number of pods of the new pool started in each
+		// step of a gradual rollout. Defaults to 1.
+		// +optional
+		MaxSurge int32 `json:"maxSurge,omitempty"`
+
+		// MaxUnavailable is the number of pods the pool may be short of its
+		// size during a gradual rollout. The old pool is only scaled down
+		// as pods of the new pool become ready otherwise. Defaults to 0.
+		// +optional
+		MaxUnavailable int32 `json:"maxUnavailable,omitempty"`
+
+		// Canary, if true, makes a gradual rollout start a single pod of
+		// the new pool first, and go on only once it's ready.
+		// +optional
+		Canary bool `json:"canary,omitempty"`
+
+		// StepInterval is the time in seconds between the steps of a
+		// gradual rollout. Defaults to 10.
+		// +optional
+		StepInterval int `json:"stepInterval,omitempty"`
+	}
+
+	// HTTPTriggerDefaults hold the middleware settings of an environment
+	// that HTTP triggers inherit. Each setting applies to triggers leaving
+	// it unset; a trigger setting it replaces the default as a whole.
+	// Triggers with functions of different environments inherit nothing.
+	HTTPTriggerDefaults struct {
+		// Authentication makes router verify the bearer token of requests.
+		// +optional
+		Authentication *HTTPTriggerAuthentication `json:"authentication,omitempty"`
+
+		// RequestValidation makes router validate requests against an
+		// OpenAPI spec in the namespace of the trigger.
+		// +optional
+		RequestValidation *RequestValidation `json:"requestvalidation,omitempty"`
+
+		// Timeout in seconds of requests to the trigger, retries included.
+		// +optional
+		Timeout int `json:"timeout,omitempty"`
+
+		// Retry makes router retry failed requests.
+		// +optional
+		Retry *HTTPTriggerRetryPolicy `json:"retry,omitempty"`
+
+		// CircuitBreaker makes router reject requests for a while after
+		// consecutive requests failed.
+		// +optional
+		CircuitBreaker *CircuitBreakerPolicy `json:"circuitbreaker,omitempty"`
+	}
+
 	//
 	// Triggers
 	//
 
 	// HTTPTriggerSpec is for router to expose user functions at the given URL path.
 	HTTPTriggerSpec struct {
-		// TODO: remove this field since we have IngressConfig already
-		// Deprecated: the original idea of this field is not for setting Ingress.
-		// Since we have IngressConfig now, remove Host after couple releases.
+		// Host restricts the trigger to requests to the given host, so that
+		// one router can serve many virtual hosts. A wildcard host like
+		// "*.example.com" matches any host with one more label. The port
+		// and case of the request host are ignored. Routes of triggers with
+		// an exact host take precedence over wildcard hosts, which take
+		// precedence over triggers for any host.
+		// Use IngressConfig to expose the trigger through an ingress.
+		// +optional
 		Host string `json:"host"`
 
 		// RelativeURL is the exposed URL for external client to access a function with.
@@ -589,44 +1159,379 @@ type (
 		// TODO: make IngressConfig a independent Fission resource
 		// IngressConfig for router to set up Ingress.
 		IngressConfig IngressConfig `json:"ingressconfig"`
-	}
 
-	// IngressConfig is for router to set up Ingress.
-	IngressConfig struct {
-		// Annotations will be add to metadata when creating Ingress.
-		Annotations map[string]string `json:"annotations"`
+		// Authentication, if set, makes router verify the bearer token of
+		// every incoming request before forwarding it to the function.
+		// +optional
+		Authentication *HTTPTriggerAuthentication `json:"authentication,omitempty"`
 
-		// Path is for path matching. The format of path
-		// depends on what ingress controller you used.
-		Path string `json:"path"`
+		// SessionAffinity, if set, makes router send consecutive requests
+		// of a client session to the same function pod.
+		// +optional
+		SessionAffinity *SessionAffinity `json:"sessionaffinity,omitempty"`
 
-		// Host is for ingress controller to apply rules. If
-		// host is empty or "*", the rule applies to all
-		// inbound HTTP traffic.
-		Host string `json:"host"`
+		// RequestValidation, if set, makes router validate incoming requests
+		// against an OpenAPI spec and reject invalid ones with 400 before
+		// the function is invoked.
+		// +optional
+		RequestValidation *RequestValidation `json:"requestvalidation,omitempty"`
 
-		// TLS is for user to specify a Secret that contains
-		// TLS key and certificate. The domain name in the
-		// key and crt must match the value of Host field.
-		TLS string `json:"tls"`
-	}
+		// FeatureFlags, if set, makes router evaluate feature flags for
+		// every request and pass them to the function as request headers.
+		// +optional
+		FeatureFlags *FeatureFlagSource `json:"featureflags,omitempty"`
 
-	// KubernetesWatchTriggerSpec
-	KubernetesWatchTriggerSpec struct {
-		Namespace string `json:"namespace"`
+		// Timeout in seconds of requests to the trigger, retries included.
+		// Requests that don't complete in time get 504. Zero means requests
+		// are only limited by the timeout of the function.
+		// +optional
+		Timeout int `json:"timeout,omitempty"`
 
-		// Type of resource to watch (Pod, Service, etc.)
-		Type string `json:"type"`
+		// Retry, if set, makes router retry requests that failed with an error
+		// or a retryable status code. Only requests with an idempotent method or
+		// an "Idempotency-Key" header are retried.
+		// +optional
+		Retry *HTTPTriggerRetryPolicy `json:"retry,omitempty"`
 
-		// Resource labels
-		LabelSelector map[string]string `json:"labelselector"`
+		// CircuitBreaker, if set, makes router reject requests with 503 for a
+		// while after consecutive requests to the trigger failed.
+		// +optional
+		CircuitBreaker *CircuitBreakerPolicy `json:"circuitbreaker,omitempty"`
 
-		// The reference to a function for kubewatcher to invoke with
-		// when receiving events.
-		FunctionReference FunctionReference `json:"functionref"`
-	}
+		// Priority of requests to the trigger. When router or executor are
+		// busy, requests with a higher priority are served first. Clients
+		// may lower the priority of a request with the "X-Fission-Priority"
+		// header, but not raise it above this one. Defaults to "standard".
+		// +optional
+		Priority InvocationPriority `json:"priority,omitempty"`
 
-	// Type of message queue
+		// Experiment, if set, makes router run an A/B test on the trigger.
+		// Users taking part are consistently sent to the function of the
+		// variant they are assigned to, other users to FunctionReference.
+		// +optional
+		Experiment *Experiment `json:"experiment,omitempty"`
+
+		// LongPoll, if set, tunes router for long polling endpoints, which
+		// hold requests open until an event happens or the poll times out.
+		// +optional
+		LongPoll *LongPollPolicy `json:"longpoll,omitempty"`
+
+		// CloudEvents is the CloudEvents format requests to the trigger are
+		// passed to the function in, the one router is set up with if empty.
+		// +optional
+		CloudEvents CloudEventsMode `json:"cloudevents,omitempty"`
+	}
+
+	ExperimentAssignmentType string
+
+	// Experiment is an A/B test splitting the users of an HTTP trigger
+	// among variants. Users are assigned by a hash of their key, so that
+	// they stay with their variant across requests and router replicas.
+	Experiment struct {
+		// Name of the experiment, which salts the assignment of users and
+		// is reported in metrics. Defaults to the name of the trigger.
+		// +optional
+		Name string `json:"name,omitempty"`
+
+		// Assignment describes the key users are assigned by.
+		Assignment ExperimentAssignment `json:"assignment"`
+
+		// Exposure is the percentage of users taking part in the experiment.
+		// Raising it keeps the users already taking part in their variants.
+		// Defaults to 100.
+		// +optional
+		Exposure *int `json:"exposure,omitempty"`
+
+		// Variants the users taking part are split among.
+		Variants []ExperimentVariant `json:"variants"`
+	}
+
+	// ExperimentAssignment describes where the key of a user comes from.
+	ExperimentAssignment struct {
+		// Type of the key.
+		// Available value:
+		// - cookie: router issues a cookie with a random key to clients without one
+		// - header: the key is the value of a request header, requests without it
+		//   don't take part in the experiment
+		Type ExperimentAssignmentType `json:"type"`
+
+		// Name of the cookie or header carrying the key.
+		// Defaults to "fission-experiment" for cookie assignment.
+		// +optional
+		Name string `json:"name,omitempty"`
+	}
+
+	// ExperimentVariant is a variant of an experiment.
+	ExperimentVariant struct {
+		// Name of the variant, passed to the function in the
+		// "X-Fission-Experiment-Variant" header and reported in metrics.
+		Name string `json:"name"`
+
+		// Function serving the variant, in the namespace of the trigger.
+		Function string `json:"function"`
+
+		// Weight of the variant relative to the other variants. Defaults to 1.
+		// +optional
+		Weight *int `json:"weight,omitempty"`
+	}
+
+	// InvocationPriority is the priority class of a function invocation,
+	// one of "interactive", "standard" or "batch".
+	InvocationPriority string
+
+	// CloudEventsMode is the CloudEvents 1.0 format function invocations
+	// are made in, one of "binary", "structured" or "none".
+	CloudEventsMode string
+
+	// HTTPTriggerRetryPolicy describes how router retries failed requests.
+	HTTPTriggerRetryPolicy struct {
+		// MaxRetries is the number of times a request is retried after the first attempt.
+		MaxRetries int `json:"maxretries"`
+
+		// Backoff in milliseconds before the first retry, doubled for
+		// every further retry. Defaults to 100.
+		// +optional
+		Backoff int `json:"backoff,omitempty"`
+
+		// RetryOn lists the response status codes that are retried.
+		// Defaults to 502, 503 and 504.
+		// +optional
+		RetryOn []int `json:"retryon,omitempty"`
+	}
+
+	// CircuitBreakerPolicy describes when router stops sending requests to
+	// the function of a trigger. After OpenDuration, a single request is let
+	// through to probe the function, and the circuit closes if it succeeds.
+	CircuitBreakerPolicy struct {
+		// ErrorThreshold is the number of consecutive failed requests, with an
+		// error or a 5xx status code, that opens the circuit. Defaults to 5.
+		// +optional
+		ErrorThreshold int `json:"errorthreshold,omitempty"`
+
+		// OpenDuration in seconds the circuit stays open. Defaults to 30.
+		// +optional
+		OpenDuration int `json:"openduration,omitempty"`
+	}
+
+	// LongPollPolicy describes how router serves long polls. Polls are let
+	// run for Timeout instead of the timeout of the function, aren't
+	// retried when they time out, and keep the pod of the function alive
+	// while they're open. Timeout of the trigger, if set, still bounds them.
+	LongPollPolicy struct {
+		// Timeout in seconds a poll is held open. Defaults to 300.
+		// +optional
+		Timeout int `json:"timeout,omitempty"`
+
+		// KeepAliveInterval in seconds at which the pod serving a poll is
+		// marked as in use. Defaults to 30.
+		// +optional
+		KeepAliveInterval int `json:"keepaliveinterval,omitempty"`
+	}
+
+	FeatureFlagSourceType string
+
+	// FeatureFlagSource describes where router gets the feature flags of
+	// the requests to an HTTP trigger from. Flags are evaluated for the
+	// user or tenant identified by the value of KeyHeader, and each flag
+	// is passed to the function in a "<HeaderPrefix><flag name>" header.
+	FeatureFlagSource struct {
+		// Type of the flag source.
+		// Available value:
+		// - launchdarkly: flags are evaluated by the LaunchDarkly client-side SDK API
+		// - unleash: flags are evaluated by the Unleash frontend API
+		// - configmap: flags are read from a ConfigMap, with one key per flag
+		Type FeatureFlagSourceType `json:"type"`
+
+		// URL of the LaunchDarkly or Unleash API.
+		// Defaults to "https://clientsdk.launchdarkly.com" for LaunchDarkly.
+		// +optional
+		URL string `json:"url,omitempty"`
+
+		// Secret holding the credential of the LaunchDarkly or Unleash API in
+		// its "token" key, in the namespace of the trigger. That's the
+		// client-side ID for LaunchDarkly and a frontend token for Unleash.
+		// +optional
+		Secret string `json:"secret,omitempty"`
+
+		// ConfigMap holding the flags, in the namespace of the trigger.
+		// A value is either the value of the flag, or a YAML object with
+		// a default "value", per-key "targets" and a percentage "rollout"
+		// of variants.
+		// +optional
+		ConfigMap string `json:"configmap,omitempty"`
+
+		// KeyHeader is the request header carrying the user or tenant
+		// key flags are evaluated for. Defaults to "X-Fission-User".
+		// +optional
+		KeyHeader string `json:"keyheader,omitempty"`
+
+		// Flags to pass to the function. Defaults to all flags of the source.
+		// +optional
+		Flags []string `json:"flags,omitempty"`
+
+		// HeaderPrefix of the flag headers. Defaults to "X-Fission-Flag-".
+		// +optional
+		HeaderPrefix string `json:"headerprefix,omitempty"`
+
+		// CacheTTL in seconds evaluated flags are cached for. Defaults to 30.
+		// +optional
+		CacheTTL int `json:"cachettl,omitempty"`
+	}
+
+	// RequestValidation references the OpenAPI spec (Swagger 2.0 or OpenAPI 3)
+	// requests to an HTTP trigger are validated against. Exactly one of
+	// ConfigMap and Package must be set.
+	RequestValidation struct {
+		// ConfigMap holding the spec, in the namespace of the trigger.
+		// +optional
+		ConfigMap string `json:"configmap,omitempty"`
+
+		// Package holding the spec, in the namespace of the trigger.
+		// +optional
+		Package string `json:"package,omitempty"`
+
+		// Path is the key of the spec in the ConfigMap, or the path of the
+		// spec file in the package archive. Defaults to "openapi.yaml".
+		// +optional
+		Path string `json:"path,omitempty"`
+
+		// OperationID of the operation requests are validated against.
+		// Defaults to the operation matching the relative URL and method
+		// of the trigger.
+		// +optional
+		OperationID string `json:"operationid,omitempty"`
+	}
+
+	SessionAffinityType string
+
+	// SessionAffinity describes how router identifies the session of a request.
+	SessionAffinity struct {
+		// Type of session affinity.
+		// Available value:
+		// - cookie: router issues a session cookie to clients without one
+		// - header: requests are pinned by the value of a request header
+		Type SessionAffinityType `json:"type"`
+
+		// Name of the cookie or header carrying the session ID.
+		// Defaults to "fission-session" for cookie affinity.
+		// +optional
+		Name string `json:"name,omitempty"`
+
+		// TTL in seconds a session stays pinned to a pod after its
+		// last request. Defaults to 1800.
+		// +optional
+		TTL int `json:"ttl,omitempty"`
+	}
+
+	// HTTPTriggerAuthentication is the authentication config of an HTTP trigger.
+	HTTPTriggerAuthentication struct {
+		// JWT holds the settings for validating JSON Web Tokens
+		// issued by an OIDC provider.
+		JWT *JWTAuthentication `json:"jwt,omitempty"`
+	}
+
+	// JWTAuthentication describes how router validates a JSON Web Token
+	// carried in the "Authorization: Bearer <token>" request header.
+	JWTAuthentication struct {
+		// JWKSURL is the URL of the JSON Web Key Set used to verify token signatures.
+		// Defaults to the key set in the OpenID Connect discovery document of Issuer.
+		// +optional
+		JWKSURL string `json:"jwksurl,omitempty"`
+
+		// Issuer, if not empty, must match the "iss" claim of the token.
+		// Either Issuer or JWKSURL is required.
+		Issuer string `json:"issuer,omitempty"`
+
+		// Audiences, if not empty, requires the "aud" claim of the token
+		// to contain at least one of the values.
+		Audiences []string `json:"audiences,omitempty"`
+
+		// RequiredClaims is a set of claims the token must carry with
+		// exactly the given string values.
+		RequiredClaims map[string]string `json:"requiredclaims,omitempty"`
+
+		// ClaimsToHeaders maps claim names to request header names.
+		// Router passes the claim values to the function with these headers.
+		// The "sub" claim is always passed with header "X-Fission-Auth-Subject".
+		ClaimsToHeaders map[string]string `json:"claimstoheaders,omitempty"`
+	}
+
+	// IngressConfig is for router to set up Ingress.
+	IngressConfig struct {
+		// Annotations will be add to metadata when creating Ingress.
+		Annotations map[string]string `json:"annotations"`
+
+		// Path is for path matching. The format of path
+		// depends on what ingress controller you used.
+		Path string `json:"path"`
+
+		// Host is for ingress controller to apply rules. If
+		// host is empty or "*", the rule applies to all
+		// inbound HTTP traffic.
+		Host string `json:"host"`
+
+		// TLS is for user to specify a Secret that contains
+		// TLS key and certificate. The domain name in the
+		// key and crt must match the value of Host field.
+		TLS string `json:"tls"`
+
+		// Type of the object router creates for the trigger, an Ingress
+		// or a Gateway API HTTPRoute. Defaults to "ingress".
+		// +optional
+		Type IngressType `json:"type,omitempty"`
+
+		// Gateway is the Gateway the HTTPRoute attaches to, as "name" or
+		// "namespace/name". It's required with type "httproute". TLS is set
+		// up on the listeners of the Gateway, so TLS must be empty then.
+		// +optional
+		Gateway string `json:"gateway,omitempty"`
+	}
+
+	IngressType string
+
+	// KubernetesWatchTriggerSpec
+	KubernetesWatchTriggerSpec struct {
+		// Namespace of resources to watch, all namespaces if empty. Ignored
+		// for cluster-scoped resources.
+		Namespace string `json:"namespace"`
+
+		// Type of resource to watch (Pod, Service, etc.), the kind of the
+		// resources of APIVersion if set.
+		Type string `json:"type"`
+
+		// APIVersion is the group and version of the resources to watch, like
+		// "cert-manager.io/v1", for kubewatcher to watch any resource,
+		// including custom ones. Type is one of Pod, Service,
+		// ReplicationController and Job if empty.
+		// +optional
+		APIVersion string `json:"apiVersion,omitempty"`
+
+		// Resource labels
+		LabelSelector map[string]string `json:"labelselector"`
+
+		// FieldSelector selects the resources to watch by fields, like
+		// "status.phase=Running".
+		// +optional
+		FieldSelector string `json:"fieldselector,omitempty"`
+
+		// Filter is a JSONPath expression events are passed to the function
+		// for if it finds a value other than null, false, "False" or an empty
+		// string in the resource, like
+		// {.status.conditions[?(@.type=="Ready")].status}.
+		// +optional
+		Filter string `json:"filter,omitempty"`
+
+		// The reference to a function for kubewatcher to invoke with
+		// when receiving events.
+		FunctionReference FunctionReference `json:"functionref"`
+
+		// CloudEvents is the CloudEvents format events are passed to the
+		// function in, the one router is set up with if empty.
+		// +optional
+		CloudEvents CloudEventsMode `json:"cloudevents,omitempty"`
+	}
+
+	// Type of message queue
 	MessageQueueType string
 
 	// MessageQueueTriggerSpec defines a binding from a topic in a
@@ -636,7 +1541,7 @@ type (
 		// when receiving messages from subscribed topic.
 		FunctionReference FunctionReference `json:"functionref"`
 
-		// Type of message queue (NATS, Kafka, AzureQueue)
+		// Type of message queue (NATS, Kafka, AzureQueue, RabbitMQ, AWS SQS, GCP Pub/Sub, Redis Streams, Pulsar)
 		MessageQueueType MessageQueueType `json:"messageQueueType"`
 
 		// Subscribed topic
@@ -651,9 +1556,33 @@ type (
 		// Maximum times for message queue trigger to retry
 		MaxRetries int `json:"maxRetries"`
 
+		// RetryPolicy is how the function is retried on the messages it fails
+		// on, right away up to MaxRetries times if not set.
+		// +optional
+		RetryPolicy *MessageQueueTriggerRetryPolicy `json:"retryPolicy,omitempty"`
+
 		// Content type of payload
 		ContentType string `json:"contentType"`
 
+		// BatchSize, if greater than 1, is the most messages the function is
+		// invoked with at once, as a JSON array of their payloads: as is if
+		// JSON, or else as strings. The messages of a batch are acked, or
+		// not, together.
+		// +optional
+		BatchSize int `json:"batchSize,omitempty"`
+
+		// BatchWindow is how long a batch is held open for more messages after
+		// the first one, string representation of time.Duration, ex: 500ms,
+		// 10s (default: "1s").
+		// +optional
+		BatchWindow string `json:"batchWindow,omitempty"`
+
+		// MaxConcurrentInvocations, if greater than 0, is the most invocations
+		// of the function in flight at once per consumer replica. Messages
+		// wait to be handled beyond it.
+		// +optional
+		MaxConcurrentInvocations int `json:"maxConcurrentInvocations,omitempty"`
+
 		// The period to check each trigger source on every ScaledObject, and scale the deployment up or down accordingly
 		// +optional
 		PollingInterval *int32 `json:"pollingInterval,omitempty"`
@@ -670,6 +1599,13 @@ type (
 		// +optional
 		MaxReplicaCount *int32 `json:"maxReplicaCount,omitempty"`
 
+		// Autoscaling, if set, has the consumers of a fission trigger run in a
+		// deployment of their own, scaled by the message queue trigger manager
+		// with the messages waiting to be consumed, the way KEDA scales those
+		// of keda triggers, with the settings above.
+		// +optional
+		Autoscaling *MessageQueueTriggerAutoscaling `json:"autoscaling,omitempty"`
+
 		// ScalerTrigger fields
 		// +optional
 		Metadata map[string]string `json:"metadata"`
@@ -681,16 +1617,416 @@ type (
 		// Kind of Message Queue Trigger to be created, by default its fission
 		// +optional
 		MqtKind string `json:"mqtkind,omitempty"`
+
+		// CloudEvents is the CloudEvents format messages are passed to the
+		// function in, the one router is set up with if empty.
+		// +optional
+		CloudEvents CloudEventsMode `json:"cloudevents,omitempty"`
+	}
+
+	// MessageQueueTriggerAutoscaling scales the consumer replicas of a
+	// trigger to the messages waiting to be consumed, the lag of the
+	// consumers, divided by TargetLag: between MinReplicaCount and
+	// MaxReplicaCount, checked every PollingInterval seconds, scaled down
+	// no sooner than CooldownPeriod seconds after scaling up.
+	MessageQueueTriggerAutoscaling struct {
+		// TargetLag is the number of messages waiting per replica.
+		TargetLag int64 `json:"targetLag"`
+	}
+
+	// MessageQueueTriggerRetryPolicy retries the function on a message it
+	// fails on, waiting a backoff doubled after each attempt, and publishes
+	// the message to the dead-letter topic once out of attempts.
+	MessageQueueTriggerRetryPolicy struct {
+		// MaxAttempts is the most times the function is invoked with a
+		// message, MaxRetries + 1 if 0.
+		// +optional
+		MaxAttempts int `json:"maxAttempts,omitempty"`
+
+		// Backoff is how long to wait before the first retry, string
+		// representation of time.Duration, ex: 500ms, 10s. Retries are
+		// right away if empty.
+		// +optional
+		Backoff string `json:"backoff,omitempty"`
+
+		// MaxBackoff bounds the wait between retries, string representation
+		// of time.Duration (default: "1m" if empty or 0).
+		// +optional
+		MaxBackoff string `json:"maxBackoff,omitempty"`
+
+		// Jitter, if set, has each wait a random duration up to the backoff,
+		// so the retries of messages failing together are spread out.
+		// +optional
+		Jitter bool `json:"jitter,omitempty"`
+
+		// DeadLetterTopic, if set, is the topic the messages the function
+		// failed on are published to as is, while the error responses of
+		// the function are published to the error topic.
+		// +optional
+		DeadLetterTopic string `json:"deadLetterTopic,omitempty"`
 	}
 
 	// TimeTriggerSpec invokes the specific function at a time or
 	// times specified by a cron string.
 	TimeTriggerSpec struct {
-		// Cron schedule
-		Cron string `json:"cron"`
+		// Cron schedule. One of Cron, At and Every must be set.
+		// +optional
+		Cron string `json:"cron,omitempty"`
+
+		// At is the time in RFC3339 format to fire the trigger once at,
+		// like "2021-06-01T09:00:00Z".
+		// +optional
+		At string `json:"at,omitempty"`
+
+		// Every is the interval to fire the trigger at, like "90s" or
+		// "1h30m". Fires are aligned to multiples of the interval since the
+		// zero time, so that "1h" fires on the hour.
+		// +optional
+		Every string `json:"every,omitempty"`
+
+		// Jitter is the max random delay of the fires of the trigger, like
+		// "30s", to spread the load of triggers firing at the same time.
+		// +optional
+		Jitter string `json:"jitter,omitempty"`
 
 		// The reference to function
 		FunctionReference `json:"functionref"`
+
+		// Output, if set, makes timer wait for the response of the function
+		// and deliver it, turning the function into a reporting job.
+		// +optional
+		Output *TimeTriggerOutput `json:"output,omitempty"`
+
+		// CloudEvents is the CloudEvents format the function is invoked in,
+		// the one router is set up with if empty.
+		// +optional
+		CloudEvents CloudEventsMode `json:"cloudevents,omitempty"`
+
+		// Timezone is the IANA name of the time zone the cron schedule is
+		// in, like "Europe/Paris". Defaults to UTC.
+		// +optional
+		Timezone string `json:"timezone,omitempty"`
+
+		// Payload is the JSON body the function is invoked with. It's a Go
+		// template, executed at each invocation with:
+		// - .Time: the time of the invocation in the time zone of the trigger
+		// - .Trigger, .Namespace: the name and namespace of the trigger
+		// - .Timezone: the time zone of the trigger
+		// For example: {"date": "{{ .Time.Format "2006-01-02" }}"}
+		// +optional
+		Payload string `json:"payload,omitempty"`
+
+		// Method is the HTTP method the function is invoked with, POST if empty.
+		// +optional
+		Method string `json:"method,omitempty"`
+
+		// Headers are the HTTP headers the function is invoked with, besides
+		// the X-Fission ones.
+		// +optional
+		Headers map[string]string `json:"headers,omitempty"`
+
+		// CatchUpWindow is the number of seconds back timer replays the fires
+		// missed while it was down, from the last recorded one. Missed fires
+		// are skipped if 0.
+		// +optional
+		CatchUpWindow int `json:"catchUpWindow,omitempty"`
+	}
+
+	TimeTriggerOutputType string
+
+	// TimeTriggerOutput describes where timer delivers the responses of the
+	// function of a time trigger. Only successful (2xx) responses are delivered.
+	TimeTriggerOutput struct {
+		// Type of output.
+		// Available value:
+		// - storage: the response is uploaded to the storage service, and its ID
+		//   recorded in the "timerLastOutputId" annotation of the trigger
+		// - email: the response is sent by email
+		Type TimeTriggerOutputType `json:"type"`
+
+		// Email settings, required for email output.
+		// +optional
+		Email *EmailOutput `json:"email,omitempty"`
+
+		// Timeout in seconds timer waits for the response. Defaults to 300.
+		// +optional
+		Timeout int `json:"timeout,omitempty"`
+	}
+
+	// EmailOutput describes the email a time trigger output is sent with.
+	// Text responses are the body of the email, others are attached to it.
+	EmailOutput struct {
+		// To lists the recipients of the email.
+		To []string `json:"to"`
+
+		// Subject of the email. Defaults to "Report of <trigger name>".
+		// +optional
+		Subject string `json:"subject,omitempty"`
+
+		// SMTPSecret is the secret holding the SMTP server settings in the keys
+		// "host", "port", "username", "password" and "from", in the namespace
+		// of the trigger. Port defaults to 587, username and password are only
+		// required by servers asking for authentication.
+		SMTPSecret string `json:"smtpsecret"`
+	}
+
+	// BucketProvider is the object storage service a bucket is in, which
+	// decides the format of its event notifications.
+	BucketProvider string
+
+	// BucketEventType is the kind of change of an object in a bucket.
+	BucketEventType string
+
+	// BucketTriggerSpec is the bucket, the object events and the function
+	// of a bucket trigger.
+	BucketTriggerSpec struct {
+		// Provider of the bucket, which sends notifications to
+		// /buckets/<namespace>/<name> of the bucket trigger manager:
+		//  s3: Amazon S3, through an SNS topic with an HTTPS subscription
+		//  minio: MinIO, through a webhook target
+		//  gcs: Google Cloud Storage, through a Pub/Sub push subscription
+		Provider BucketProvider `json:"provider"`
+
+		// Bucket the notifications are of, others are ignored.
+		Bucket string `json:"bucket"`
+
+		// Events to invoke the function on, "created" and "deleted". All
+		// of them if empty.
+		// +optional
+		Events []BucketEventType `json:"events,omitempty"`
+
+		// Prefix and Suffix filter the keys of the objects, like "uploads/"
+		// and ".jpg".
+		// +optional
+		Prefix string `json:"prefix,omitempty"`
+		// +optional
+		Suffix string `json:"suffix,omitempty"`
+
+		// Secret is the name of a secret in the namespace of the trigger
+		// holding a "token" key, that notifications must carry as bearer
+		// token or "token" query parameter. Notifications aren't
+		// authenticated if empty.
+		// +optional
+		Secret string `json:"secret,omitempty"`
+
+		// The reference to a function for the bucket trigger manager to
+		// invoke with the metadata of objects.
+		FunctionReference FunctionReference `json:"functionref"`
+
+		// CloudEvents is the CloudEvents format the function is invoked in,
+		// the one router is set up with if empty.
+		// +optional
+		CloudEvents CloudEventsMode `json:"cloudevents,omitempty"`
+	}
+
+	// DatabaseType is the database changes are captured of, which decides
+	// the layout of the metadata of change events.
+	DatabaseType string
+
+	// DatabaseOperation is the kind of change of a row.
+	DatabaseOperation string
+
+	// DatabaseTriggerSpec is the tables, the operations and the function of
+	// a database trigger.
+	DatabaseTriggerSpec struct {
+		// Database changes are captured of, "postgres" or "mysql", with the
+		// Debezium connector of the database: through a logical
+		// replication slot of PostgreSQL or the binlog of MySQL.
+		Database DatabaseType `json:"database"`
+
+		// TopicPrefix is the prefix of the Kafka topics the Debezium
+		// connector publishes changes to, its topic.prefix, or
+		// database.server.name before Debezium 2.0. The changes of a table
+		// are read from "<topicPrefix>.<schema>.<table>".
+		TopicPrefix string `json:"topicPrefix"`
+
+		// Schema of the tables with PostgreSQL, like "public", or their
+		// database with MySQL.
+		Schema string `json:"schema"`
+
+		// Tables whose row changes invoke the function.
+		Tables []string `json:"tables"`
+
+		// Operations to invoke the function on, "insert", "update",
+		// "delete", and "read" for the rows of the snapshots connectors
+		// take when they start. All but "read" if empty.
+		// +optional
+		Operations []DatabaseOperation `json:"operations,omitempty"`
+
+		// MaxRetries is the number of times the invocation of a change is
+		// retried with exponential backoff when the function fails, before
+		// the change is skipped. Changes are delivered in order per row, so
+		// a failing change holds back the ones after it while retried.
+		// +optional
+		MaxRetries int `json:"maxRetries,omitempty"`
+
+		// The reference to a function for the database trigger manager to
+		// invoke with the changes of rows.
+		FunctionReference FunctionReference `json:"functionref"`
+
+		// CloudEvents is the CloudEvents format the function is invoked in,
+		// the one router is set up with if empty.
+		// +optional
+		CloudEvents CloudEventsMode `json:"cloudevents,omitempty"`
+	}
+
+	// WorkflowSpec is the steps of a workflow.
+	WorkflowSpec struct {
+		// Steps of the workflow. Executions start at the first step, with
+		// the body of the request executing the workflow as input, and
+		// each step is invoked with the output of the one before. The
+		// output of the last step is the output of the execution.
+		Steps []WorkflowStep `json:"steps"`
+
+		// HistoryLimit is how many of the last executions are kept in the
+		// status, DefaultWorkflowHistoryLimit if 0.
+		// +optional
+		HistoryLimit int `json:"historyLimit,omitempty"`
+	}
+
+	// WorkflowStep invokes a function, or several functions in parallel,
+	// of the namespace of the workflow.
+	WorkflowStep struct {
+		// Name of the step, unique in the workflow.
+		Name string `json:"name"`
+
+		// Function invoked with the input of the step, whose response is
+		// the output of the step.
+		// +optional
+		Function string `json:"function,omitempty"`
+
+		// Parallel are functions invoked at the same time with the input
+		// of the step, in place of Function. The output of the step is
+		// the JSON array of their responses, in order, and its status
+		// code the highest of theirs.
+		// +optional
+		Parallel []string `json:"parallel,omitempty"`
+
+		// MaxRetries is how many times the invocation of a function is
+		// retried with exponential backoff, when it fails with a 5xx or
+		// 429 status code or doesn't respond.
+		// +optional
+		MaxRetries int `json:"maxRetries,omitempty"`
+
+		// Branches choose the step to go to after this one by its status
+		// code, the first matching one is taken.
+		// +optional
+		Branches []WorkflowBranch `json:"branches,omitempty"`
+
+		// Next is the step to go to after this one when no branch
+		// matches and its status code is 2xx, the step following it if
+		// empty. Other status codes fail the execution.
+		// +optional
+		Next string `json:"next,omitempty"`
+
+		// End ends the execution after this step when no branch matches.
+		// +optional
+		End bool `json:"end,omitempty"`
+	}
+
+	// WorkflowBranch is the step a workflow goes to on some status codes.
+	WorkflowBranch struct {
+		// StatusCode matched, like "404", or a class of them like "4xx".
+		StatusCode string `json:"statusCode"`
+
+		// Next is the step to go to, the execution ends with the output
+		// of the step if empty.
+		// +optional
+		Next string `json:"next,omitempty"`
+	}
+
+	// WorkflowExecutionPhase is how an execution of a workflow ended.
+	WorkflowExecutionPhase string
+
+	// WorkflowStatus is the execution history of a workflow.
+	WorkflowStatus struct {
+		// Executions are the last executions of the workflow, the latest
+		// first.
+		// +optional
+		Executions []WorkflowExecution `json:"executions,omitempty"`
+	}
+
+	// WorkflowExecution is an execution of a workflow.
+	WorkflowExecution struct {
+		ID    string                 `json:"id"`
+		Phase WorkflowExecutionPhase `json:"phase"`
+
+		// Steps executed, in order.
+		Steps []WorkflowStepExecution `json:"steps,omitempty"`
+
+		// Error is why the execution failed, if it did.
+		// +optional
+		Error string `json:"error,omitempty"`
+
+		StartTime      metav1.Time `json:"startTime"`
+		CompletionTime metav1.Time `json:"completionTime"`
+	}
+
+	// WorkflowStepExecution is a step executed by an execution of a
+	// workflow.
+	WorkflowStepExecution struct {
+		Name string `json:"name"`
+
+		// Attempts is how many times the functions of the step were
+		// invoked, with retries.
+		Attempts int `json:"attempts"`
+
+		// StatusCode of the step, 0 if a function didn't respond.
+		StatusCode int `json:"statusCode"`
+
+		// Error is why the step failed, if it did.
+		// +optional
+		Error string `json:"error,omitempty"`
+
+		StartTime      metav1.Time `json:"startTime"`
+		CompletionTime metav1.Time `json:"completionTime"`
+	}
+
+	// InvocationRecord is an invocation of a function.
+	InvocationRecord struct {
+		// Time is when router received the request.
+		Time metav1.Time `json:"time"`
+
+		// TriggerType and TriggerName are the trigger that invoked the
+		// function, empty if it was invoked directly, e.g. by a test.
+		// +optional
+		TriggerType string `json:"triggerType,omitempty"`
+		// +optional
+		TriggerName string `json:"triggerName,omitempty"`
+
+		Duration metav1.Duration `json:"duration"`
+
+		// StatusCode of the response, or of the error router responded
+		// with if the function didn't respond.
+		StatusCode int `json:"statusCode"`
+
+		// Error is the start of the error message router got invoking the
+		// function, if it didn't respond.
+		// +optional
+		Error string `json:"error,omitempty"`
+	}
+
+	// ResourceUsage is the resources used by the pods of a function, as
+	// executor meters them, and its invocations, as router counts them.
+	ResourceUsage struct {
+		// Since is when the usage started to be accounted.
+		Since metav1.Time `json:"since"`
+
+		// LastUpdated is when the usage was last accounted.
+		LastUpdated metav1.Time `json:"lastUpdated"`
+
+		// CPUMillicoreSeconds is the CPU time used, in millicore-seconds.
+		CPUMillicoreSeconds int64 `json:"cpuMillicoreSeconds"`
+
+		// MemoryMegabyteSeconds is the memory used over time, in
+		// megabyte-seconds.
+		MemoryMegabyteSeconds int64 `json:"memoryMegabyteSeconds"`
+
+		// Invocations is how many times the function was invoked.
+		Invocations int64 `json:"invocations"`
+
+		// EgressBytes is how many bytes the pods sent over the network.
+		EgressBytes int64 `json:"egressBytes"`
 	}
 
 	FailureType string
@@ -715,6 +2051,100 @@ type (
 		// Threshold in percentage beyond which the new version of the function is considered unstable
 		FailureThreshold int         `json:"failurethreshold"`
 		FailureType      FailureType `json:"failureType"`
+
+		// Match, if set, routes requests matching the rule to the new function
+		// in addition to the weighted traffic. With a WeightIncrement of 0 only
+		// matched requests reach the new function until the config is updated.
+		// +optional
+		Match *CanaryMatch `json:"match,omitempty"`
+
+		// SLOs are extra criteria the new function must meet at every weight
+		// increment in addition to FailureThreshold. Violating any of them
+		// rolls the canary back.
+		// +optional
+		SLOs []CanarySLO `json:"slos,omitempty"`
+
+		// AnalysisWindows are the time windows (e.g. "1m", "10m") the failure
+		// threshold and SLOs are evaluated over. A criterion is considered
+		// violated only if it's violated in every window, which avoids rolling
+		// back on short spikes while still catching sustained regressions.
+		// Defaults to WeightIncrementDuration.
+		// +optional
+		AnalysisWindows []string `json:"analysiswindows,omitempty"`
+
+		// MetricsProvider is the metrics backend the new function is analysed
+		// with. Defaults to the Prometheus server fission is configured with.
+		// +optional
+		MetricsProvider *CanaryMetricsProvider `json:"metricsprovider,omitempty"`
+	}
+
+	CanaryMetricsProviderType string
+
+	// CanaryMetricsProvider is a metrics backend router metrics are shipped to.
+	CanaryMetricsProvider struct {
+		// Type of the metrics backend.
+		// Available value:
+		// - prometheus
+		// - datadog
+		// - cloudwatch
+		// - stackdriver
+		Type CanaryMetricsProviderType `json:"type"`
+
+		// Address of the metrics backend API. Defaults to the public API
+		// endpoint of the provider, or the Prometheus server fission is
+		// configured with.
+		// +optional
+		Address string `json:"address,omitempty"`
+
+		// Secret in the same namespace as the canary config holding the
+		// credentials of the metrics backend.
+		// - datadog: "api-key" and "application-key"
+		// - cloudwatch: "access-key-id", "secret-access-key" and optionally "session-token",
+		//   the default AWS credential chain is used otherwise
+		// - stackdriver: optionally "access-token", the GCE metadata server is used otherwise
+		// +optional
+		Secret string `json:"secret,omitempty"`
+
+		// Region of CloudWatch.
+		// +optional
+		Region string `json:"region,omitempty"`
+
+		// Namespace of router metrics in CloudWatch (default: "ContainerInsights/Prometheus").
+		// +optional
+		MetricNamespace string `json:"metricnamespace,omitempty"`
+
+		// Project of Stackdriver.
+		// +optional
+		Project string `json:"project,omitempty"`
+	}
+
+	CanarySLOType string
+
+	// CanarySLO is a service level objective for the new function of a canary config.
+	CanarySLO struct {
+		// Name of the SLO, used in logs.
+		Name string `json:"name"`
+
+		// Type of the SLO.
+		// Available value:
+		// - latency: the Percentile of function call duration must not exceed Threshold
+		// - query: the value of Query must not exceed Threshold
+		Type CanarySLOType `json:"type"`
+
+		// Percentile of function call duration for latency SLO, e.g. 95 or 99.
+		// +optional
+		Percentile int `json:"percentile,omitempty"`
+
+		// Query is an expression in the query language of the metrics provider
+		// (PromQL for Prometheus) for query SLO. The placeholders
+		// {{function}}, {{namespace}}, {{path}}, {{method}} and {{window}}
+		// are replaced before the query gets executed.
+		// +optional
+		Query string `json:"query,omitempty"`
+
+		// Threshold is the maximum allowed value. For latency SLO, it's a
+		// duration like "300ms"; for query SLO, it's a number like "0.05".
+		Threshold string `json:"threshold"`
 	}
 
 	// CanaryConfigStatus represents canary config status
@@ -722,6 +2152,143 @@ type (
 		Status string `json:"status"`
 	}
 
+	// FissionConfigSpec is the configuration of the Fission components.
+	// Settings left unset keep the values the components were deployed
+	// with.
+	FissionConfigSpec struct {
+		Executor   ExecutorConfig   `json:"executor,omitempty"`
+		Router     RouterConfig     `json:"router,omitempty"`
+		BuilderMgr BuilderMgrConfig `json:"buildermgr,omitempty"`
+	}
+
+	// ExecutorConfig is the configuration of the executor.
+	ExecutorConfig struct {
+		// MaxConcurrentSpecializations limits the pods specialized at a
+		// time, 0 for no limit.
+		MaxConcurrentSpecializations *int `json:"maxConcurrentSpecializations,omitempty"`
+
+		// EvictionMinIdle is how long specialized pods must be idle to be
+		// evicted under node pressure, e.g. 2m. Empty disables eviction.
+		EvictionMinIdle *string `json:"evictionMinIdle,omitempty"`
+
+		// EvictionMaxPods is how many pods are evicted at most at a time.
+		EvictionMaxPods *int `json:"evictionMaxPods,omitempty"`
+
+		// PodReadyTimeout is how long pool pods may take to become ready.
+		PodReadyTimeout *string `json:"podReadyTimeout,omitempty"`
+	}
+
+	// RouterConfig is the configuration of the router.
+	RouterConfig struct {
+		// MaxInflightRequests limits the requests served at a time, 0 for
+		// no limit.
+		MaxInflightRequests *int `json:"maxInflightRequests,omitempty"`
+
+		// RoundTripTimeout is the initial timeout of requests to functions,
+		// e.g. 50ms.
+		RoundTripTimeout *string `json:"roundTripTimeout,omitempty"`
+
+		// RoundTripMaxRetries is how many times requests to functions are
+		// retried.
+		RoundTripMaxRetries *int `json:"roundTripMaxRetries,omitempty"`
+
+		// DisplayAccessLog logs every request served.
+		DisplayAccessLog *bool `json:"displayAccessLog,omitempty"`
+	}
+
+	// BuilderMgrConfig is the configuration of the builder manager.
+	BuilderMgrConfig struct {
+		// MaxConcurrentBuilds limits the packages built at a time.
+		MaxConcurrentBuilds *int `json:"maxConcurrentBuilds,omitempty"`
+
+		// MaxConcurrentBuildsPerEnvironment limits the packages built at
+		// a time by environments not setting their own limit.
+		MaxConcurrentBuildsPerEnvironment *int `json:"maxConcurrentBuildsPerEnvironment,omitempty"`
+
+		// BuildCacheTTL is how long deploy archives of builds are reused
+		// after their last use, e.g. 168h. 0 disables the build cache.
+		BuildCacheTTL *string `json:"buildCacheTTL,omitempty"`
+	}
+
+	// FissionConfigStatus is the configuration in effect in each component,
+	// by component name.
+	FissionConfigStatus struct {
+		Components map[string]ComponentConfigStatus `json:"components,omitempty"`
+	}
+
+	// ComponentConfigStatus is the configuration in effect in a component.
+	ComponentConfigStatus struct {
+		// ObservedGeneration is the generation of the FissionConfig the
+		// component last reconciled.
+		ObservedGeneration int64 `json:"observedGeneration"`
+
+		// Effective are the settings in effect by setting name.
+		Effective map[string]string `json:"effective,omitempty"`
+
+		// RestartRequired are the changed settings that take effect only
+		// once the component restarts.
+		RestartRequired []string `json:"restartRequired,omitempty"`
+
+		// Error is why settings couldn't be applied, if any couldn't.
+		Error string `json:"error,omitempty"`
+
+		UpdatedAt metav1.Time `json:"updatedAt"`
+	}
+
+	// FissionSourceSpec is the Git repository, branch and directory of the
+	// specs a FissionSource applies.
+	FissionSourceSpec struct {
+		// URL of the repository to clone, over HTTPS or SSH.
+		URL string `json:"url"`
+
+		// Branch to apply the specs of. The default branch of the
+		// repository if empty.
+		// +optional
+		Branch string `json:"branch,omitempty"`
+
+		// Path is the spec directory in the repository, "specs" if empty.
+		// +optional
+		Path string `json:"path,omitempty"`
+
+		// AuthSecret is the name of a secret in the namespace of the
+		// FissionSource to authenticate to the repository with, as for
+		// GitSource.
+		// +optional
+		AuthSecret string `json:"authSecret,omitempty"`
+
+		// Interval is how often the branch is checked for changes, e.g.
+		// 5m. 1m if empty.
+		// +optional
+		Interval string `json:"interval,omitempty"`
+
+		// Prune deletes the objects applied from the specs before once
+		// they're removed from them, as spec apply --prune does.
+		// +optional
+		Prune bool `json:"prune,omitempty"`
+	}
+
+	// FissionSourceSyncStatus is whether the specs of a FissionSource are
+	// in sync with the cluster.
+	FissionSourceSyncStatus string
+
+	// FissionSourceStatus is the sync status of a FissionSource.
+	FissionSourceStatus struct {
+		// ObservedGeneration is the generation of the FissionSource last
+		// synced.
+		ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+		// SyncStatus is whether the last sync succeeded.
+		SyncStatus FissionSourceSyncStatus `json:"syncStatus,omitempty"`
+
+		// Revision is the commit the specs were last applied from.
+		Revision string `json:"revision,omitempty"`
+
+		// Message is what the last sync applied, or why it failed.
+		Message string `json:"message,omitempty"`
+
+		LastSyncTime metav1.Time `json:"lastSyncTime,omitempty"`
+	}
+
 	// MetadataAccessor lets you work with object metadata and type metadata
 	// from any of the versioned or internal API objects.
 	MetadataAccessor interface {
@@ -731,5 +2298,5 @@ type (
 )
 
 func (a Archive) IsEmpty() bool {
-	return len(a.Literal) == 0 && len(a.URL) == 0
+	return len(a.Literal) == 0 && len(a.URL) == 0 && a.Git == nil
 }
@@ -17,15 +17,27 @@ limitations under the License.
 package v1
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/mail"
+	"net/url"
+	"path"
 	"regexp"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/robfig/cron"
+	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/jsonpath"
 
 	"github.com/fission/fission/pkg/mqtrigger/validator"
 )
@@ -38,6 +50,13 @@ const (
 	totalAnnotationSizeLimitB int = 256 * (1 << 10) // 256 kB
 )
 
+// validTopicName matches the names Kafka topics are made of.
+var validTopicName = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// validStatusCode matches the status codes branches of workflow steps are
+// taken on, like "404" or "4xx".
+var validStatusCode = regexp.MustCompile(`^[1-5]([0-9]{2}|xx)$`)
+
 type (
 	ValidationErrorType int
 
@@ -118,9 +137,12 @@ func ValidateKubeLabel(field string, labels map[string]string) error {
 		// Example: XXX -> YYY
 		// KubernetesWatchTriggerSpec.LabelSelector.Key: Invalid value: XXX
 		// KubernetesWatchTriggerSpec.LabelSelector.Value: Invalid value: YYY
-		result = multierror.Append(result,
-			MakeValidationErr(ErrorInvalidValue, fmt.Sprintf("%v.Key", field), k, validation.IsQualifiedName(k)...),
-			MakeValidationErr(ErrorInvalidValue, fmt.Sprintf("%v.Value", field), v, validation.IsValidLabelValue(v)...))
+		if e := validation.IsQualifiedName(k); len(e) > 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, fmt.Sprintf("%v.Key", field), k, e...))
+		}
+		if e := validation.IsValidLabelValue(v); len(e) > 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, fmt.Sprintf("%v.Value", field), v, e...))
+		}
 	}
 
 	return result.ErrorOrNil()
@@ -183,11 +205,45 @@ func (archive Archive) Validate() error {
 	if len(archive.Type) > 0 {
 		switch archive.Type {
 		case ArchiveTypeLiteral, ArchiveTypeUrl: // no op
+		case ArchiveTypeGit:
+			if archive.Git == nil {
+				result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "Archive.Git", nil, "repository is required for git archives"))
+			}
+			if len(archive.Format) > 0 {
+				result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "Archive.Format", archive.Format, "git archives are checked out into a directory and have no format"))
+			}
 		default:
 			result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "Archive.Type", archive.Type, "not a valid archive type"))
 		}
 	}
 
+	if archive.Git != nil {
+		result = multierror.Append(result, archive.Git.Validate())
+	}
+
+	if u, err := url.Parse(archive.URL); err == nil {
+		switch u.Scheme {
+		case ArchiveURLSchemeS3, ArchiveURLSchemeGCS, ArchiveURLSchemeAzure:
+			if len(u.Host) == 0 || len(strings.TrimPrefix(u.Path, "/")) == 0 {
+				result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "Archive.URL", archive.URL, "object store URLs must name a bucket or container and a key"))
+			}
+		}
+	}
+
+	if len(archive.AuthSecret) > 0 {
+		for _, msg := range validation.IsDNS1123Subdomain(archive.AuthSecret) {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "Archive.AuthSecret", archive.AuthSecret, msg))
+		}
+	}
+
+	if len(archive.Format) > 0 {
+		switch archive.Format {
+		case ArchiveFormatZip, ArchiveFormatTarGz, ArchiveFormatBinary: // no op
+		default:
+			result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "Archive.Format", archive.Format, "not a valid archive format"))
+		}
+	}
+
 	if archive.Checksum != (Checksum{}) {
 		result = multierror.Append(result, archive.Checksum.Validate())
 	}
@@ -195,6 +251,35 @@ func (archive Archive) Validate() error {
 	return result.ErrorOrNil()
 }
 
+func (git GitSource) Validate() error {
+	result := &multierror.Error{}
+
+	if len(git.URL) == 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "GitSource.URL", git.URL, "repository URL is required"))
+	} else if strings.HasPrefix(git.URL, "-") {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "GitSource.URL", git.URL, "must not start with '-'"))
+	}
+
+	if strings.HasPrefix(git.Ref, "-") {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "GitSource.Ref", git.Ref, "must not start with '-'"))
+	}
+
+	if len(git.SubPath) > 0 {
+		subPath := path.Clean(git.SubPath)
+		if path.IsAbs(subPath) || subPath == ".." || strings.HasPrefix(subPath, "../") {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "GitSource.SubPath", git.SubPath, "must be a relative path within the repository"))
+		}
+	}
+
+	if len(git.AuthSecret) > 0 {
+		for _, msg := range validation.IsDNS1123Subdomain(git.AuthSecret) {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "GitSource.AuthSecret", git.AuthSecret, msg))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
 func (ref EnvironmentReference) Validate() error {
 	result := &multierror.Error{}
 	result = multierror.Append(result, ValidateKubeReference("EnvironmentReference", ref.Name, ref.Namespace))
@@ -213,6 +298,35 @@ func (ref ConfigMapReference) Validate() error {
 	return result.ErrorOrNil()
 }
 
+func (reload ConfigReload) Validate() error {
+	result := &multierror.Error{}
+
+	if len(reload.CallbackPath) > 0 && !strings.HasPrefix(reload.CallbackPath, "/") {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "ConfigReload.CallbackPath", reload.CallbackPath, "must start with /"))
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (ref ExternalSecretReference) Validate() error {
+	result := &multierror.Error{}
+
+	result = multierror.Append(result, ValidateKubeName("ExternalSecretReference.Name", ref.Name))
+	switch ref.Provider {
+	case SecretProviderVault, SecretProviderAWS, SecretProviderGCP: // no op
+	default:
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "ExternalSecretReference.Provider", ref.Provider, "not a supported secret provider"))
+	}
+	if len(ref.Path) == 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "ExternalSecretReference.Path", ref.Path, "must not be empty"))
+	}
+	if len(ref.Role) > 0 && ref.Provider != SecretProviderVault {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "ExternalSecretReference.Role", ref.Role, "only Vault secrets take a role"))
+	}
+
+	return result.ErrorOrNil()
+}
+
 func (spec PackageSpec) Validate() error {
 	result := &multierror.Error{}
 
@@ -224,6 +338,46 @@ func (spec PackageSpec) Validate() error {
 		}
 	}
 
+	if len(spec.BuildSteps) > 0 {
+		if len(spec.BuildCommand) > 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "PackageSpec.BuildCommand", spec.BuildCommand, "build command and build steps are mutually exclusive"))
+		}
+
+		names := make(map[string]bool)
+		var builderSteps int
+		for _, step := range spec.BuildSteps {
+			result = multierror.Append(result, step.Validate())
+			if names[step.Name] {
+				result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "BuildStep.Name", step.Name, "duplicate build step"))
+			}
+			names[step.Name] = true
+			if len(step.Image) == 0 {
+				builderSteps++
+			}
+		}
+		if builderSteps == 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "PackageSpec.BuildSteps", len(spec.BuildSteps), "at least one step must run in the environment builder to output the deployment package"))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (step BuildStep) Validate() error {
+	result := &multierror.Error{}
+
+	if len(step.Name) == 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "BuildStep.Name", step.Name, "must not be empty"))
+	}
+	for _, env := range step.Env {
+		if e := validation.IsEnvVarName(env.Name); len(e) > 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "BuildStep.Env", env.Name, e...))
+		}
+		if env.ValueFrom != nil && len(step.Image) == 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "BuildStep.Env", env.Name, "steps in the environment builder only support values"))
+		}
+	}
+
 	return result.ErrorOrNil()
 }
 
@@ -268,11 +422,69 @@ func (spec FunctionSpec) Validate() error {
 	for _, c := range spec.ConfigMaps {
 		result = multierror.Append(result, c.Validate())
 	}
+	// external secrets are written where kubernetes secrets of the same name are
+	secretNames := make(map[string]bool)
+	for _, s := range spec.Secrets {
+		secretNames[s.Name] = true
+	}
+	for _, s := range spec.ExternalSecrets {
+		result = multierror.Append(result, s.Validate())
+		if secretNames[s.Name] {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FunctionSpec.ExternalSecrets.Name", s.Name, "must be unique among the secrets of the function"))
+		}
+		secretNames[s.Name] = true
+	}
 
 	if spec.InvokeStrategy != (InvokeStrategy{}) {
 		result = multierror.Append(result, spec.InvokeStrategy.Validate())
 	}
 
+	for _, name := range spec.CoLocateWith {
+		result = multierror.Append(result, ValidateKubeName("FunctionSpec.CoLocateWith", name))
+	}
+
+	if len(spec.Priority) > 0 {
+		result = multierror.Append(result, spec.Priority.Validate())
+	}
+
+	windows := make(map[QuotaWindow]bool)
+	for _, q := range spec.InvocationQuotas {
+		result = multierror.Append(result, q.Validate())
+		if windows[q.Window] {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FunctionSpec.InvocationQuotas.Window", q.Window, "only one quota per window is allowed"))
+		}
+		windows[q.Window] = true
+	}
+
+	if spec.Container != nil {
+		result = multierror.Append(result, spec.Container.Validate())
+		if spec.Environment != (EnvironmentReference{}) || spec.Package != (FunctionPackageRef{}) {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidObject, "FunctionSpec.Container", spec.Container.Image, "functions run either a container or an environment and package, not both"))
+		}
+		if len(spec.ExternalSecrets) > 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidObject, "FunctionSpec.ExternalSecrets", len(spec.ExternalSecrets), "container functions have no fetcher to resolve external secrets"))
+		}
+		if spec.ConfigReload != nil {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidObject, "FunctionSpec.ConfigReload", spec.ConfigReload.CallbackPath, "container functions have no fetcher to reload secrets and configmaps"))
+		}
+		if executor := spec.InvokeStrategy.ExecutionStrategy.ExecutorType; len(executor) > 0 && executor != ExecutorTypeNewdeploy {
+			result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "FunctionSpec.InvokeStrategy.ExecutionStrategy.ExecutorType", executor, "container functions are run by newdeploy only"))
+		}
+	}
+
+	if spec.ConfigReload != nil {
+		result = multierror.Append(result, spec.ConfigReload.Validate())
+	}
+
+	// container functions pass probes on to their container as they are
+	if spec.Probes != nil && spec.Container == nil {
+		result = multierror.Append(result, spec.Probes.Validate())
+	}
+
+	if spec.PodSpec != nil && spec.Container == nil && spec.InvokeStrategy.ExecutionStrategy.ExecutorType != ExecutorTypeNewdeploy {
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "FunctionSpec.InvokeStrategy.ExecutionStrategy.ExecutorType", spec.InvokeStrategy.ExecutionStrategy.ExecutorType, "function pod specs are merged by newdeploy only, poolmgr pods are shared by the functions of an environment"))
+	}
+
 	// TODO Add below validation warning
 	/*if spec.FunctionTimeout <= 0 {
 		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FunctionTimeout value", spec.FunctionTimeout, "not a valid value. Should always be more than 0"))
@@ -321,6 +533,10 @@ func (es ExecutionStrategy) Validate() error {
 			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "ExecutionStrategy.TargetCPUPercent", es.TargetCPUPercent, "TargetCPUPercent must be a value between 1 - 100"))
 		}
 
+		if es.TargetConcurrencyPerPod < 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "ExecutionStrategy.TargetConcurrencyPerPod", es.TargetConcurrencyPerPod, "TargetConcurrencyPerPod must be greater than or equal to 0"))
+		}
+
 		// TODO Add validation warning
 		//if es.SpecializationTimeout < 120 {
 		//	result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "ExecutionStrategy.SpecializationTimeout", es.SpecializationTimeout, "SpecializationTimeout must be a value equal to or greater than 120"))
@@ -344,6 +560,136 @@ func (ref FunctionReference) Validate() error {
 		result = multierror.Append(result, ValidateKubeName("FunctionReference.Name", ref.Name))
 	}
 
+	if ref.MatchRoute != nil {
+		if ref.Type != FunctionReferenceTypeFunctionWeights {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FunctionReference.MatchRoute", ref.Type, "only supported for function-weights reference type"))
+		} else if _, ok := ref.FunctionWeights[ref.MatchRoute.Function]; !ok {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FunctionReference.MatchRoute.Function", ref.MatchRoute.Function, "must be one of the functions in FunctionWeights"))
+		}
+		result = multierror.Append(result, ref.MatchRoute.Match.Validate())
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (spec CanaryConfigSpec) Validate() error {
+	result := &multierror.Error{}
+
+	result = multierror.Append(result,
+		ValidateKubeName("CanaryConfigSpec.Trigger", spec.Trigger),
+		ValidateKubeName("CanaryConfigSpec.NewFunction", spec.NewFunction),
+		ValidateKubeName("CanaryConfigSpec.OldFunction", spec.OldFunction))
+
+	if spec.WeightIncrement < 0 || spec.WeightIncrement > 100 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "CanaryConfigSpec.WeightIncrement", spec.WeightIncrement, "must be a value between 0 - 100"))
+	}
+
+	if spec.WeightIncrement == 0 && spec.Match == nil {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "CanaryConfigSpec.WeightIncrement", spec.WeightIncrement, "must be greater than 0 if no match rule is specified"))
+	}
+
+	if _, err := time.ParseDuration(spec.WeightIncrementDuration); err != nil {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "CanaryConfigSpec.WeightIncrementDuration", spec.WeightIncrementDuration, err.Error()))
+	}
+
+	if spec.FailureThreshold < 0 || spec.FailureThreshold > 100 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "CanaryConfigSpec.FailureThreshold", spec.FailureThreshold, "must be a value between 0 - 100"))
+	}
+
+	if spec.Match != nil {
+		result = multierror.Append(result, spec.Match.Validate())
+	}
+
+	for _, slo := range spec.SLOs {
+		result = multierror.Append(result, slo.Validate())
+	}
+
+	for _, window := range spec.AnalysisWindows {
+		if d, err := time.ParseDuration(window); err != nil || d <= 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "CanaryConfigSpec.AnalysisWindows", window, "not a valid duration"))
+		}
+	}
+
+	if spec.MetricsProvider != nil {
+		result = multierror.Append(result, spec.MetricsProvider.Validate())
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (provider CanaryMetricsProvider) Validate() error {
+	result := &multierror.Error{}
+
+	switch provider.Type {
+	case CanaryMetricsProviderPrometheus, CanaryMetricsProviderDatadog:
+	case CanaryMetricsProviderCloudWatch:
+		if len(provider.Region) == 0 && len(provider.Address) == 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "CanaryMetricsProvider.Region", provider.Region, "region or address is required for cloudwatch"))
+		}
+	case CanaryMetricsProviderStackdriver:
+		if len(provider.Project) == 0 && len(provider.Address) == 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "CanaryMetricsProvider.Project", provider.Project, "project or address is required for stackdriver"))
+		}
+	default:
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "CanaryMetricsProvider.Type", provider.Type, "not a supported metrics provider"))
+	}
+
+	if len(provider.Address) > 0 {
+		u, err := url.Parse(provider.Address)
+		if err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "CanaryMetricsProvider.Address", provider.Address, "must be an absolute http(s) URL"))
+		}
+	}
+
+	if len(provider.Secret) > 0 {
+		result = multierror.Append(result, ValidateKubeName("CanaryMetricsProvider.Secret", provider.Secret))
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (slo CanarySLO) Validate() error {
+	result := &multierror.Error{}
+
+	switch slo.Type {
+	case CanarySLOTypeLatency:
+		if slo.Percentile <= 0 || slo.Percentile >= 100 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "CanarySLO.Percentile", slo.Percentile, "must be a value between 1 - 99"))
+		}
+		if d, err := time.ParseDuration(slo.Threshold); err != nil || d <= 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "CanarySLO.Threshold", slo.Threshold, "must be a positive duration for latency SLO"))
+		}
+	case CanarySLOTypeQuery:
+		if len(slo.Query) == 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "CanarySLO.Query", slo.Query, "must not be empty for query SLO"))
+		}
+		if _, err := strconv.ParseFloat(slo.Threshold, 64); err != nil {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "CanarySLO.Threshold", slo.Threshold, "must be a number for query SLO"))
+		}
+	default:
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "CanarySLO.Type", slo.Type, "not a valid SLO type"))
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (match CanaryMatch) Validate() error {
+	result := &multierror.Error{}
+
+	switch match.Type {
+	case CanaryMatchTypeHeader, CanaryMatchTypeCookie, CanaryMatchTypeQuery: // no op
+	default:
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "CanaryMatch.Type", match.Type, "not a valid match type"))
+	}
+
+	if len(match.Name) == 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "CanaryMatch.Name", match.Name, "must not be empty"))
+	} else if match.Type == CanaryMatchTypeHeader {
+		for _, msg := range validation.IsHTTPHeaderName(match.Name) {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "CanaryMatch.Name", match.Name, msg))
+		}
+	}
+
 	return result.ErrorOrNil()
 }
 
@@ -358,12 +704,31 @@ func (runtime Runtime) Validate() error {
 		result = multierror.Append(result, ValidateKubePort("Runtime.FunctionEndpointPort", int(runtime.FunctionEndpointPort)))
 	}
 
+	if runtime.Probes != nil {
+		result = multierror.Append(result, runtime.Probes.Validate())
+	}
+
 	return result.ErrorOrNil()
 }
 
 func (builder Builder) Validate() error {
-	// do nothing for now
-	return nil
+	result := &multierror.Error{}
+
+	if builder.MaxConcurrentBuilds < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "Builder.MaxConcurrentBuilds", builder.MaxConcurrentBuilds, "must be greater than or equal to 0"))
+	}
+
+	switch builder.Output {
+	case "", BuildOutputArchive: // no op
+	case BuildOutputImage:
+		if len(builder.Image) == 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "Builder.Output", builder.Output, "image builds need a builder image"))
+		}
+	default:
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "Builder.Output", builder.Output, "not a valid build output"))
+	}
+
+	return result.ErrorOrNil()
 }
 
 func (spec EnvironmentSpec) Validate() error {
@@ -395,6 +760,61 @@ func (spec EnvironmentSpec) Validate() error {
 		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "EnvironmentSpec.TerminationGracePeriod", spec.TerminationGracePeriod, "must be greater than or equal to 0"))
 	}
 
+	if spec.RolloutStrategy != nil {
+		result = multierror.Append(result, spec.RolloutStrategy.Validate())
+	}
+
+	if spec.HTTPTriggerDefaults != nil {
+		result = multierror.Append(result, spec.HTTPTriggerDefaults.Validate())
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (strategy RolloutStrategy) Validate() error {
+	result := &multierror.Error{}
+
+	switch strategy.Type {
+	case "", RolloutStrategyRecreate, RolloutStrategyGradual: // no op
+	default:
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "EnvironmentSpec.RolloutStrategy.Type", strategy.Type, "not a supported rollout strategy"))
+	}
+	if strategy.MaxSurge < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "EnvironmentSpec.RolloutStrategy.MaxSurge", strategy.MaxSurge, "must be greater than or equal to 0"))
+	}
+	if strategy.MaxUnavailable < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "EnvironmentSpec.RolloutStrategy.MaxUnavailable", strategy.MaxUnavailable, "must be greater than or equal to 0"))
+	}
+	if strategy.StepInterval < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "EnvironmentSpec.RolloutStrategy.StepInterval", strategy.StepInterval, "must be greater than or equal to 0"))
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (defaults HTTPTriggerDefaults) Validate() error {
+	result := &multierror.Error{}
+
+	if defaults.Authentication != nil {
+		result = multierror.Append(result, defaults.Authentication.Validate())
+	}
+
+	if defaults.RequestValidation != nil {
+		result = multierror.Append(result, defaults.RequestValidation.Validate())
+	}
+
+	if defaults.Timeout < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "EnvironmentSpec.HTTPTriggerDefaults.Timeout", defaults.Timeout, "must not be negative"))
+	}
+
+	if defaults.Retry != nil {
+		result = multierror.Append(result, defaults.Retry.Validate())
+	}
+
+	if defaults.CircuitBreaker != nil {
+		result = multierror.Append(result, defaults.CircuitBreaker.Validate())
+	}
+
 	return result.ErrorOrNil()
 }
 
@@ -411,7 +831,12 @@ func (spec HTTPTriggerSpec) Validate() error {
 	result = multierror.Append(result, spec.FunctionReference.Validate())
 
 	if len(spec.Host) > 0 {
-		e := validation.IsDNS1123Subdomain(spec.Host)
+		var e []string
+		if strings.HasPrefix(spec.Host, "*.") {
+			e = validation.IsWildcardDNS1123Subdomain(spec.Host)
+		} else {
+			e = validation.IsDNS1123Subdomain(spec.Host)
+		}
 		if len(e) > 0 {
 			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Host", spec.Host, e...))
 		}
@@ -419,72 +844,451 @@ func (spec HTTPTriggerSpec) Validate() error {
 
 	result = multierror.Append(result, spec.IngressConfig.Validate())
 
-	return result.ErrorOrNil()
-}
+	if spec.Authentication != nil {
+		result = multierror.Append(result, spec.Authentication.Validate())
+	}
 
-func (config IngressConfig) Validate() error {
-	result := &multierror.Error{}
+	if spec.SessionAffinity != nil {
+		result = multierror.Append(result, spec.SessionAffinity.Validate())
+	}
 
-	// Details for how to validate Ingress host rule,
-	// see https://github.com/kubernetes/kubernetes/blob/release-1.16/pkg/apis/networking/validation/validation.go
+	if spec.RequestValidation != nil {
+		result = multierror.Append(result, spec.RequestValidation.Validate())
+	}
 
-	if len(config.Path) > 0 {
-		if !strings.HasPrefix(config.Path, "/") {
-			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.IngressConfig.IngressRule.Path", config.Path, "must be an absolute path"))
-		}
+	if spec.FeatureFlags != nil {
+		result = multierror.Append(result, spec.FeatureFlags.Validate())
+	}
 
-		_, err := regexp.CompilePOSIX(config.Path)
-		if err != nil {
-			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.IngressConfig.IngressRule.Path", config.Path, "must be a valid regex"))
-		}
+	if spec.Timeout < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Timeout", spec.Timeout, "must not be negative"))
 	}
 
-	// In Ingress, to accept requests from all host, the host field will
-	// be an empty string instead of "*" shown in kubectl. The router replaces
-	// the asterisk with "" when creating/updateing the Ingress, so here we
-	// skip the check if the Host is equal to "*".
-	if len(config.Host) > 0 && config.Host != "*" {
-		if strings.Contains(config.Host, "*") {
-			for _, msg := range validation.IsWildcardDNS1123Subdomain(config.Host) {
-				result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.IngressConfig.IngressRule.Host", config.Host, msg))
-			}
-		}
-		for _, msg := range validation.IsDNS1123Subdomain(config.Host) {
-			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.IngressConfig.IngressRule.Host", config.Host, msg))
-		}
+	if spec.Retry != nil {
+		result = multierror.Append(result, spec.Retry.Validate())
 	}
 
-	// Details for how to validate annotations,
-	// see https://github.com/kubernetes/kubernetes/blob/512eccac1f1d72d6d1cb304bc565c50d1f2e295e/staging/src/k8s.io/apimachinery/pkg/api/validation/objectmeta.go#L46
+	if spec.CircuitBreaker != nil {
+		result = multierror.Append(result, spec.CircuitBreaker.Validate())
+	}
 
-	var totalSize int64
-	for k, v := range config.Annotations {
-		for _, msg := range validation.IsQualifiedName(strings.ToLower(k)) {
-			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.IngressConfig.Annotations.key", k, msg))
-		}
-		totalSize += (int64)(len(k)) + (int64)(len(v))
+	if len(spec.Priority) > 0 {
+		result = multierror.Append(result, spec.Priority.Validate())
 	}
-	if totalSize > (int64)(totalAnnotationSizeLimitB) {
-		msg := fmt.Sprintf("must have at most %v characters", totalSize)
-		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.IngressConfig.Annotations.value", totalAnnotationSizeLimitB, msg))
+
+	if spec.Experiment != nil {
+		result = multierror.Append(result, spec.Experiment.Validate())
+	}
+
+	if len(spec.CloudEvents) > 0 {
+		result = multierror.Append(result, spec.CloudEvents.Validate())
+	}
+
+	if spec.LongPoll != nil {
+		result = multierror.Append(result, spec.LongPoll.Validate())
 	}
 
 	return result.ErrorOrNil()
 }
 
-func (spec KubernetesWatchTriggerSpec) Validate() error {
+func (experiment Experiment) Validate() error {
 	result := &multierror.Error{}
 
-	switch strings.ToUpper(spec.Type) {
-	case "POD", "SERVICE", "REPLICATIONCONTROLLER", "JOB":
+	switch experiment.Assignment.Type {
+	case ExperimentAssignmentTypeCookie: // no op
+	case ExperimentAssignmentTypeHeader:
+		for _, msg := range validation.IsHTTPHeaderName(experiment.Assignment.Name) {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Experiment.Assignment.Name", experiment.Assignment.Name, msg))
+		}
 	default:
-		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "KubernetesWatchTriggerSpec.Type", spec.Type, "not a valid supported type"))
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "HTTPTriggerSpec.Experiment.Assignment.Type", experiment.Assignment.Type, "not a supported assignment type"))
 	}
 
-	result = multierror.Append(result,
-		ValidateKubeName("KubernetesWatchTriggerSpec.Namespace", spec.Namespace),
-		ValidateKubeLabel("KubernetesWatchTriggerSpec.LabelSelector", spec.LabelSelector),
-		spec.FunctionReference.Validate())
+	if experiment.Exposure != nil && (*experiment.Exposure < 0 || *experiment.Exposure > 100) {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Experiment.Exposure", *experiment.Exposure, "must be between 0 and 100"))
+	}
+
+	if len(experiment.Variants) == 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Experiment.Variants", nil, "at least one variant is required"))
+	}
+	names := make(map[string]bool)
+	totalWeight := 0
+	for _, variant := range experiment.Variants {
+		if len(variant.Name) == 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Experiment.Variants.Name", variant.Name, "must not be empty"))
+		} else if names[variant.Name] {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Experiment.Variants.Name", variant.Name, "must be unique"))
+		}
+		names[variant.Name] = true
+
+		for _, msg := range validation.IsDNS1123Subdomain(variant.Function) {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Experiment.Variants.Function", variant.Function, msg))
+		}
+
+		if variant.Weight == nil {
+			totalWeight++
+		} else if *variant.Weight < 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Experiment.Variants.Weight", *variant.Weight, "must not be negative"))
+		} else {
+			totalWeight += *variant.Weight
+		}
+	}
+	if len(experiment.Variants) > 0 && totalWeight == 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Experiment.Variants.Weight", totalWeight, "at least one variant must have a positive weight"))
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (mode CloudEventsMode) Validate() error {
+	switch mode {
+	case CloudEventsModeBinary, CloudEventsModeStructured, CloudEventsModeNone: // no op
+	default:
+		return MakeValidationErr(ErrorUnsupportedType, "CloudEventsMode", mode, "not a supported CloudEvents mode")
+	}
+	return nil
+}
+
+func (priority InvocationPriority) Validate() error {
+	switch priority {
+	case InvocationPriorityInteractive, InvocationPriorityStandard, InvocationPriorityBatch: // no op
+	default:
+		return MakeValidationErr(ErrorUnsupportedType, "InvocationPriority", priority, "not a supported priority")
+	}
+	return nil
+}
+
+func (container FunctionContainer) Validate() error {
+	result := &multierror.Error{}
+
+	if len(container.Image) == 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FunctionContainer.Image", container.Image, "must not be empty"))
+	}
+	if container.Port < 0 || container.Port > 65535 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FunctionContainer.Port", container.Port, "not a valid port"))
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (probes Probes) Validate() error {
+	result := &multierror.Error{}
+
+	for _, p := range []struct {
+		field string
+		probe *apiv1.Probe
+	}{
+		{"Probes.Startup", probes.Startup},
+		{"Probes.Readiness", probes.Readiness},
+		{"Probes.Liveness", probes.Liveness},
+	} {
+		field, probe := p.field, p.probe
+		if probe == nil {
+			continue
+		}
+		switch {
+		case probe.Exec != nil:
+			result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, field, "exec", "exec probes are only supported by container functions"))
+		case probe.HTTPGet != nil:
+			if probe.HTTPGet.Port.Type != intstr.Int {
+				result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, field+".HTTPGet.Port", probe.HTTPGet.Port.String(), "must be a port number"))
+			}
+		case probe.TCPSocket != nil:
+			if probe.TCPSocket.Port.Type != intstr.Int {
+				result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, field+".TCPSocket.Port", probe.TCPSocket.Port.String(), "must be a port number"))
+			}
+		default:
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, field, "", "must have an httpGet or tcpSocket action"))
+		}
+		if probe.InitialDelaySeconds < 0 || probe.TimeoutSeconds < 0 || probe.PeriodSeconds < 0 ||
+			probe.SuccessThreshold < 0 || probe.FailureThreshold < 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, field, "", "timings and thresholds must not be negative"))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (quota InvocationQuota) Validate() error {
+	result := &multierror.Error{}
+
+	if quota.Limit <= 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "InvocationQuota.Limit", quota.Limit, "must be positive"))
+	}
+	switch quota.Window {
+	case QuotaWindowDay, QuotaWindowMonth: // no op
+	default:
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "InvocationQuota.Window", quota.Window, "not a supported window"))
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (policy HTTPTriggerRetryPolicy) Validate() error {
+	result := &multierror.Error{}
+
+	if policy.MaxRetries < 0 || policy.MaxRetries > MaxHTTPTriggerRetries {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Retry.MaxRetries", policy.MaxRetries, fmt.Sprintf("must be between 0 and %v", MaxHTTPTriggerRetries)))
+	}
+	if policy.Backoff < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Retry.Backoff", policy.Backoff, "must not be negative"))
+	}
+	for _, code := range policy.RetryOn {
+		if code < 400 || code > 599 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Retry.RetryOn", code, "must be a 4xx or 5xx status code"))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (policy CircuitBreakerPolicy) Validate() error {
+	result := &multierror.Error{}
+
+	if policy.ErrorThreshold < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.CircuitBreaker.ErrorThreshold", policy.ErrorThreshold, "must not be negative"))
+	}
+	if policy.OpenDuration < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.CircuitBreaker.OpenDuration", policy.OpenDuration, "must not be negative"))
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (policy LongPollPolicy) Validate() error {
+	result := &multierror.Error{}
+
+	if policy.Timeout < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.LongPoll.Timeout", policy.Timeout, "must not be negative"))
+	}
+	if policy.KeepAliveInterval < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.LongPoll.KeepAliveInterval", policy.KeepAliveInterval, "must not be negative"))
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (source FeatureFlagSource) Validate() error {
+	result := &multierror.Error{}
+
+	switch source.Type {
+	case FeatureFlagSourceTypeLaunchDarkly, FeatureFlagSourceTypeUnleash:
+		if len(source.URL) > 0 {
+			u, err := url.Parse(source.URL)
+			if err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") {
+				result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.FeatureFlags.URL", source.URL, "must be an absolute http(s) URL"))
+			}
+		} else if source.Type == FeatureFlagSourceTypeUnleash {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.FeatureFlags.URL", source.URL, "must be set for unleash"))
+		}
+		result = multierror.Append(result, ValidateKubeName("HTTPTriggerSpec.FeatureFlags.Secret", source.Secret))
+	case FeatureFlagSourceTypeConfigMap:
+		result = multierror.Append(result, ValidateKubeName("HTTPTriggerSpec.FeatureFlags.ConfigMap", source.ConfigMap))
+	default:
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "HTTPTriggerSpec.FeatureFlags.Type", source.Type, "not a supported feature flag source type"))
+	}
+
+	if len(source.KeyHeader) > 0 {
+		for _, msg := range validation.IsHTTPHeaderName(source.KeyHeader) {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.FeatureFlags.KeyHeader", source.KeyHeader, msg))
+		}
+	}
+	if len(source.HeaderPrefix) > 0 {
+		for _, msg := range validation.IsHTTPHeaderName(source.HeaderPrefix) {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.FeatureFlags.HeaderPrefix", source.HeaderPrefix, msg))
+		}
+	}
+	if source.CacheTTL < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.FeatureFlags.CacheTTL", source.CacheTTL, "must not be negative"))
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (rv RequestValidation) Validate() error {
+	result := &multierror.Error{}
+
+	if (len(rv.ConfigMap) > 0) == (len(rv.Package) > 0) {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.RequestValidation", nil, "exactly one of configmap and package must be set"))
+	}
+	if len(rv.ConfigMap) > 0 {
+		result = multierror.Append(result, ValidateKubeName("HTTPTriggerSpec.RequestValidation.ConfigMap", rv.ConfigMap))
+	}
+	if len(rv.Package) > 0 {
+		result = multierror.Append(result, ValidateKubeName("HTTPTriggerSpec.RequestValidation.Package", rv.Package))
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (affinity SessionAffinity) Validate() error {
+	result := &multierror.Error{}
+
+	switch affinity.Type {
+	case SessionAffinityTypeCookie: // no op
+	case SessionAffinityTypeHeader:
+		for _, msg := range validation.IsHTTPHeaderName(affinity.Name) {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.SessionAffinity.Name", affinity.Name, msg))
+		}
+	default:
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "HTTPTriggerSpec.SessionAffinity.Type", affinity.Type, "not a supported session affinity type"))
+	}
+
+	if affinity.TTL < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.SessionAffinity.TTL", affinity.TTL, "must not be negative"))
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (auth HTTPTriggerAuthentication) Validate() error {
+	result := &multierror.Error{}
+
+	if auth.JWT == nil {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Authentication", nil, "no authentication method specified"))
+		return result.ErrorOrNil()
+	}
+
+	if len(auth.JWT.JWKSURL) > 0 {
+		u, err := url.Parse(auth.JWT.JWKSURL)
+		if err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Authentication.JWT.JWKSURL", auth.JWT.JWKSURL, "must be an absolute http(s) URL"))
+		}
+	} else {
+		// the key set is discovered from the issuer
+		u, err := url.Parse(auth.JWT.Issuer)
+		if err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Authentication.JWT.Issuer", auth.JWT.Issuer, "must be an absolute http(s) URL to discover the key set, or JWKSURL must be set"))
+		}
+	}
+
+	for claim, header := range auth.JWT.ClaimsToHeaders {
+		if len(claim) == 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Authentication.JWT.ClaimsToHeaders.key", claim, "must not be empty"))
+		}
+		for _, msg := range validation.IsHTTPHeaderName(header) {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Authentication.JWT.ClaimsToHeaders.value", header, msg))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (config IngressConfig) Validate() error {
+	result := &multierror.Error{}
+
+	// Details for how to validate Ingress host rule,
+	// see https://github.com/kubernetes/kubernetes/blob/release-1.16/pkg/apis/networking/validation/validation.go
+
+	if len(config.Path) > 0 {
+		if !strings.HasPrefix(config.Path, "/") {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.IngressConfig.IngressRule.Path", config.Path, "must be an absolute path"))
+		}
+
+		_, err := regexp.CompilePOSIX(config.Path)
+		if err != nil {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.IngressConfig.IngressRule.Path", config.Path, "must be a valid regex"))
+		}
+	}
+
+	// In Ingress, to accept requests from all host, the host field will
+	// be an empty string instead of "*" shown in kubectl. The router replaces
+	// the asterisk with "" when creating/updateing the Ingress, so here we
+	// skip the check if the Host is equal to "*".
+	if len(config.Host) > 0 && config.Host != "*" {
+		if strings.Contains(config.Host, "*") {
+			for _, msg := range validation.IsWildcardDNS1123Subdomain(config.Host) {
+				result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.IngressConfig.IngressRule.Host", config.Host, msg))
+			}
+		}
+		for _, msg := range validation.IsDNS1123Subdomain(config.Host) {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.IngressConfig.IngressRule.Host", config.Host, msg))
+		}
+	}
+
+	switch config.Type {
+	case "", IngressTypeIngress:
+		if len(config.Gateway) > 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.IngressConfig.Gateway", config.Gateway, "only supported with type httproute"))
+		}
+	case IngressTypeHTTPRoute:
+		if len(config.Gateway) == 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.IngressConfig.Gateway", config.Gateway, "required with type httproute"))
+		} else {
+			for _, name := range strings.SplitN(config.Gateway, "/", 2) {
+				result = multierror.Append(result, ValidateKubeName("HTTPTriggerSpec.IngressConfig.Gateway", name))
+			}
+		}
+		if len(config.TLS) > 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.IngressConfig.TLS", config.TLS, "not supported with type httproute, set up TLS on the listeners of the Gateway"))
+		}
+	default:
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "HTTPTriggerSpec.IngressConfig.Type", config.Type, "not a supported ingress type"))
+	}
+
+	// Details for how to validate annotations,
+	// see https://github.com/kubernetes/kubernetes/blob/512eccac1f1d72d6d1cb304bc565c50d1f2e295e/staging/src/k8s.io/apimachinery/pkg/api/validation/objectmeta.go#L46
+
+	var totalSize int64
+	for k, v := range config.Annotations {
+		for _, msg := range validation.IsQualifiedName(strings.ToLower(k)) {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.IngressConfig.Annotations.key", k, msg))
+		}
+		totalSize += (int64)(len(k)) + (int64)(len(v))
+	}
+	if totalSize > (int64)(totalAnnotationSizeLimitB) {
+		msg := fmt.Sprintf("must have at most %v characters", totalSize)
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.IngressConfig.Annotations.value", totalAnnotationSizeLimitB, msg))
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (spec KubernetesWatchTriggerSpec) Validate() error {
+	result := &multierror.Error{}
+
+	if len(spec.APIVersion) > 0 {
+		_, err := schema.ParseGroupVersion(spec.APIVersion)
+		if err != nil {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "KubernetesWatchTriggerSpec.APIVersion", spec.APIVersion, err.Error()))
+		}
+		if len(spec.Type) == 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "KubernetesWatchTriggerSpec.Type", spec.Type, "kind of resources to watch is required"))
+		}
+	} else {
+		switch strings.ToUpper(spec.Type) {
+		case "POD", "SERVICE", "REPLICATIONCONTROLLER", "JOB":
+		default:
+			result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "KubernetesWatchTriggerSpec.Type", spec.Type, "not a valid supported type, set the API version to watch other resources"))
+		}
+	}
+
+	// all namespaces are watched if empty
+	if len(spec.Namespace) > 0 {
+		result = multierror.Append(result, ValidateKubeName("KubernetesWatchTriggerSpec.Namespace", spec.Namespace))
+	}
+
+	result = multierror.Append(result,
+		ValidateKubeLabel("KubernetesWatchTriggerSpec.LabelSelector", spec.LabelSelector),
+		spec.FunctionReference.Validate())
+
+	if len(spec.FieldSelector) > 0 {
+		_, err := fields.ParseSelector(spec.FieldSelector)
+		if err != nil {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "KubernetesWatchTriggerSpec.FieldSelector", spec.FieldSelector, err.Error()))
+		}
+	}
+
+	if len(spec.Filter) > 0 {
+		err := jsonpath.New("filter").Parse(spec.Filter)
+		if err != nil {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "KubernetesWatchTriggerSpec.Filter", spec.Filter, err.Error()))
+		}
+	}
+
+	if len(spec.CloudEvents) > 0 {
+		result = multierror.Append(result, spec.CloudEvents.Validate())
+	}
 
 	return result.ErrorOrNil()
 }
@@ -506,22 +1310,421 @@ func (spec MessageQueueTriggerSpec) Validate() error {
 		}
 	}
 
+	if spec.RetryPolicy != nil {
+		if spec.MqtKind == "keda" {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "MessageQueueTriggerSpec.RetryPolicy", spec.RetryPolicy, "keda triggers retry up to MaxRetries times"))
+		}
+		result = multierror.Append(result, spec.RetryPolicy.Validate())
+		if len(spec.RetryPolicy.DeadLetterTopic) > 0 && validator.IsValidMessageQueue((string)(spec.MessageQueueType), spec.MqtKind) &&
+			!validator.IsValidTopic((string)(spec.MessageQueueType), spec.RetryPolicy.DeadLetterTopic, spec.MqtKind) {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "MessageQueueTriggerSpec.RetryPolicy.DeadLetterTopic", spec.RetryPolicy.DeadLetterTopic, "not a valid topic"))
+		}
+	}
+
+	if spec.BatchSize < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "MessageQueueTriggerSpec.BatchSize", spec.BatchSize, "must be greater than or equal to 0"))
+	}
+	if spec.BatchSize > 1 && (spec.MqtKind == "keda" || spec.MessageQueueType == MessageQueueTypeASQ) {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "MessageQueueTriggerSpec.BatchSize", spec.BatchSize, "batching is not supported by keda triggers or azure-storage-queue"))
+	}
+	if spec.MaxConcurrentInvocations < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "MessageQueueTriggerSpec.MaxConcurrentInvocations", spec.MaxConcurrentInvocations, "must be greater than or equal to 0"))
+	}
+	if spec.Autoscaling != nil {
+		if spec.MqtKind == "keda" {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "MessageQueueTriggerSpec.Autoscaling", spec.Autoscaling, "keda triggers are scaled by KEDA"))
+		}
+		if spec.Autoscaling.TargetLag <= 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "MessageQueueTriggerSpec.Autoscaling.TargetLag", spec.Autoscaling.TargetLag, "must be greater than 0"))
+		}
+		if spec.MinReplicaCount != nil && spec.MaxReplicaCount != nil && *spec.MinReplicaCount > *spec.MaxReplicaCount {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "MessageQueueTriggerSpec.MinReplicaCount", *spec.MinReplicaCount, "must be less than or equal to MaxReplicaCount"))
+		}
+	}
+	if len(spec.BatchWindow) > 0 {
+		if d, err := time.ParseDuration(spec.BatchWindow); err != nil {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "MessageQueueTriggerSpec.BatchWindow", spec.BatchWindow, err.Error()))
+		} else if d <= 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "MessageQueueTriggerSpec.BatchWindow", spec.BatchWindow, "must be greater than 0"))
+		}
+	}
+	if len(spec.CloudEvents) > 0 {
+		if spec.MqtKind == "keda" {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "MessageQueueTriggerSpec.CloudEvents", spec.CloudEvents, "keda triggers invoke in the CloudEvents mode of router"))
+		}
+		result = multierror.Append(result, spec.CloudEvents.Validate())
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (spec BucketTriggerSpec) Validate() error {
+	result := &multierror.Error{}
+
+	switch spec.Provider {
+	case BucketProviderS3, BucketProviderMinIO, BucketProviderGCS:
+	default:
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "BucketTriggerSpec.Provider", spec.Provider, "not a supported provider: s3|minio|gcs"))
+	}
+
+	if len(spec.Bucket) == 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "BucketTriggerSpec.Bucket", spec.Bucket, "bucket name is required"))
+	} else if strings.ContainsAny(spec.Bucket, "/ ") {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "BucketTriggerSpec.Bucket", spec.Bucket, "must not contain '/' or spaces"))
+	}
+
+	for _, e := range spec.Events {
+		switch e {
+		case BucketEventCreated, BucketEventDeleted:
+		default:
+			result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "BucketTriggerSpec.Events", e, "not a supported event: created|deleted"))
+		}
+	}
+
+	if len(spec.Secret) > 0 {
+		for _, msg := range validation.IsDNS1123Subdomain(spec.Secret) {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "BucketTriggerSpec.Secret", spec.Secret, msg))
+		}
+	}
+
+	result = multierror.Append(result, spec.FunctionReference.Validate())
+
+	if len(spec.CloudEvents) > 0 {
+		result = multierror.Append(result, spec.CloudEvents.Validate())
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (spec DatabaseTriggerSpec) Validate() error {
+	result := &multierror.Error{}
+
+	switch spec.Database {
+	case DatabaseTypePostgres, DatabaseTypeMySQL:
+	default:
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "DatabaseTriggerSpec.Database", spec.Database, "not a supported database: postgres|mysql"))
+	}
+
+	result = multierror.Append(result,
+		validateTopicName("DatabaseTriggerSpec.TopicPrefix", spec.TopicPrefix),
+		validateTopicName("DatabaseTriggerSpec.Schema", spec.Schema))
+
+	if len(spec.Tables) == 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "DatabaseTriggerSpec.Tables", spec.Tables, "at least one table is required"))
+	}
+	for _, table := range spec.Tables {
+		result = multierror.Append(result, validateTopicName("DatabaseTriggerSpec.Tables", table))
+	}
+
+	for _, op := range spec.Operations {
+		switch op {
+		case DatabaseOperationInsert, DatabaseOperationUpdate, DatabaseOperationDelete, DatabaseOperationRead:
+		default:
+			result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "DatabaseTriggerSpec.Operations", op, "not a supported operation: insert|update|delete|read"))
+		}
+	}
+
+	if spec.MaxRetries < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "DatabaseTriggerSpec.MaxRetries", spec.MaxRetries, "must not be negative"))
+	}
+
+	result = multierror.Append(result, spec.FunctionReference.Validate())
+
+	if len(spec.CloudEvents) > 0 {
+		result = multierror.Append(result, spec.CloudEvents.Validate())
+	}
+
+	return result.ErrorOrNil()
+}
+
+// validateTopicName checks a name is made of the characters Kafka topic
+// names are, which Debezium replaces others in names of tables with.
+func validateTopicName(field string, name string) error {
+	if len(name) == 0 {
+		return MakeValidationErr(ErrorInvalidValue, field, name, "name is required")
+	}
+	if !validTopicName.MatchString(name) {
+		return MakeValidationErr(ErrorInvalidValue, field, name, "must consist of alphanumeric characters, '.', '_' or '-'")
+	}
+	return nil
+}
+
+func (spec WorkflowSpec) Validate() error {
+	result := &multierror.Error{}
+
+	if len(spec.Steps) == 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "WorkflowSpec.Steps", spec.Steps, "at least one step is required"))
+	}
+
+	steps := make(map[string]bool, len(spec.Steps))
+	for _, step := range spec.Steps {
+		if steps[step.Name] {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "WorkflowSpec.Steps.Name", step.Name, "must be unique"))
+		}
+		steps[step.Name] = true
+	}
+	checkNext := func(field string, next string) {
+		if len(next) > 0 && !steps[next] {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, field, next, "not a step of the workflow"))
+		}
+	}
+
+	for _, step := range spec.Steps {
+		result = multierror.Append(result, ValidateKubeName("WorkflowSpec.Steps.Name", step.Name))
+
+		if (len(step.Function) > 0) == (len(step.Parallel) > 0) {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "WorkflowSpec.Steps.Function", step.Function, "exactly one of function and parallel is required"))
+		}
+		functions := step.Parallel
+		if len(step.Function) > 0 {
+			functions = []string{step.Function}
+		}
+		for _, fn := range functions {
+			for _, msg := range validation.IsDNS1123Subdomain(fn) {
+				result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "WorkflowSpec.Steps.Function", fn, msg))
+			}
+		}
+
+		if step.MaxRetries < 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "WorkflowSpec.Steps.MaxRetries", step.MaxRetries, "must not be negative"))
+		}
+
+		for _, branch := range step.Branches {
+			if !validStatusCode.MatchString(branch.StatusCode) {
+				result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "WorkflowSpec.Steps.Branches.StatusCode", branch.StatusCode, "must be a status code like 404, or a class of them like 4xx"))
+			}
+			checkNext("WorkflowSpec.Steps.Branches.Next", branch.Next)
+		}
+
+		if len(step.Next) > 0 && step.End {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "WorkflowSpec.Steps.Next", step.Next, "must be empty for steps ending the workflow"))
+		}
+		checkNext("WorkflowSpec.Steps.Next", step.Next)
+	}
+
+	if spec.HistoryLimit < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "WorkflowSpec.HistoryLimit", spec.HistoryLimit, "must not be negative"))
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (policy MessageQueueTriggerRetryPolicy) Validate() error {
+	result := &multierror.Error{}
+
+	if policy.MaxAttempts < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "MessageQueueTriggerRetryPolicy.MaxAttempts", policy.MaxAttempts, "must be greater than or equal to 0"))
+	}
+	for field, backoff := range map[string]string{
+		"MessageQueueTriggerRetryPolicy.Backoff":    policy.Backoff,
+		"MessageQueueTriggerRetryPolicy.MaxBackoff": policy.MaxBackoff,
+	} {
+		if len(backoff) == 0 {
+			continue
+		}
+		if d, err := time.ParseDuration(backoff); err != nil {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, field, backoff, err.Error()))
+		} else if d < 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, field, backoff, "must be greater than or equal to 0"))
+		}
+	}
+
 	return result.ErrorOrNil()
 }
 
 func (spec TimeTriggerSpec) Validate() error {
 	result := &multierror.Error{}
 
-	err := IsValidCronSpec(spec.Cron)
-	if err != nil {
-		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "TimeTriggerSpec.Cron", spec.Cron, "not a valid cron spec"))
+	schedules := 0
+	if len(spec.Cron) > 0 {
+		schedules++
+		err := IsValidCronSpec(spec.Cron)
+		if err != nil {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "TimeTriggerSpec.Cron", spec.Cron, "not a valid cron spec"))
+		}
+	}
+	if len(spec.At) > 0 {
+		schedules++
+		_, err := time.Parse(time.RFC3339, spec.At)
+		if err != nil {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "TimeTriggerSpec.At", spec.At, "not a valid RFC3339 time"))
+		}
+	}
+	var every time.Duration
+	if len(spec.Every) > 0 {
+		schedules++
+		var err error
+		every, err = time.ParseDuration(spec.Every)
+		if err != nil || every < time.Second {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "TimeTriggerSpec.Every", spec.Every, "not a valid duration of at least 1s"))
+		}
+	}
+	if schedules != 1 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidObject, "TimeTriggerSpec", nil, "exactly one of cron, at and every must be set"))
+	}
+	if len(spec.Jitter) > 0 {
+		jitter, err := time.ParseDuration(spec.Jitter)
+		if err != nil || jitter < 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "TimeTriggerSpec.Jitter", spec.Jitter, "not a valid duration"))
+		} else if every > 0 && jitter >= every {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "TimeTriggerSpec.Jitter", spec.Jitter, "must be shorter than the interval"))
+		}
 	}
 
 	result = multierror.Append(result, spec.FunctionReference.Validate())
 
+	if spec.Output != nil {
+		result = multierror.Append(result, spec.Output.Validate())
+	}
+
+	if len(spec.CloudEvents) > 0 {
+		result = multierror.Append(result, spec.CloudEvents.Validate())
+	}
+
+	if len(spec.Timezone) > 0 {
+		_, err := time.LoadLocation(spec.Timezone)
+		if err != nil {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "TimeTriggerSpec.Timezone", spec.Timezone, "not a valid IANA time zone"))
+		}
+	}
+
+	if len(spec.Payload) > 0 {
+		_, err := template.New("payload").Parse(spec.Payload)
+		if err != nil {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "TimeTriggerSpec.Payload", spec.Payload, "not a valid template", err.Error()))
+		} else if !strings.Contains(spec.Payload, "{{") && !json.Valid([]byte(spec.Payload)) {
+			// templated payloads are checked once executed
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "TimeTriggerSpec.Payload", spec.Payload, "not valid JSON"))
+		}
+	}
+
+	switch spec.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions: // no op
+	default:
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "TimeTriggerSpec.Method", spec.Method, "not a supported HTTP method"))
+	}
+
+	for k := range spec.Headers {
+		if strings.HasPrefix(strings.ToLower(k), "x-fission-") {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "TimeTriggerSpec.Headers", k, "X-Fission headers are set by timer"))
+			continue
+		}
+		if errs := validation.IsHTTPHeaderName(k); len(errs) > 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "TimeTriggerSpec.Headers", k, errs...))
+		}
+	}
+
+	if spec.CatchUpWindow < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "TimeTriggerSpec.CatchUpWindow", spec.CatchUpWindow, "must not be negative"))
+	}
+
 	return result.ErrorOrNil()
 }
 
+func (output TimeTriggerOutput) Validate() error {
+	result := &multierror.Error{}
+
+	switch output.Type {
+	case TimeTriggerOutputTypeStorage: // no op
+	case TimeTriggerOutputTypeEmail:
+		if output.Email == nil {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "TimeTriggerSpec.Output.Email", nil, "must be set for email output"))
+			break
+		}
+		if len(output.Email.To) == 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "TimeTriggerSpec.Output.Email.To", nil, "must have at least one recipient"))
+		}
+		for _, to := range output.Email.To {
+			if _, err := mail.ParseAddress(to); err != nil {
+				result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "TimeTriggerSpec.Output.Email.To", to, "not a valid email address"))
+			}
+		}
+		result = multierror.Append(result, ValidateKubeName("TimeTriggerSpec.Output.Email.SMTPSecret", output.Email.SMTPSecret))
+	default:
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "TimeTriggerSpec.Output.Type", output.Type, "not a supported output type"))
+	}
+
+	if output.Timeout < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "TimeTriggerSpec.Output.Timeout", output.Timeout, "must not be negative"))
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (spec FissionConfigSpec) Validate() error {
+	result := &multierror.Error{}
+
+	result = multierror.Append(result,
+		validateConfigCount("FissionConfigSpec.Executor.MaxConcurrentSpecializations", spec.Executor.MaxConcurrentSpecializations),
+		validateConfigDuration("FissionConfigSpec.Executor.EvictionMinIdle", spec.Executor.EvictionMinIdle),
+		validateConfigDuration("FissionConfigSpec.Executor.PodReadyTimeout", spec.Executor.PodReadyTimeout),
+		validateConfigCount("FissionConfigSpec.Router.MaxInflightRequests", spec.Router.MaxInflightRequests),
+		validateConfigDuration("FissionConfigSpec.Router.RoundTripTimeout", spec.Router.RoundTripTimeout),
+		validateConfigCount("FissionConfigSpec.Router.RoundTripMaxRetries", spec.Router.RoundTripMaxRetries),
+		validateConfigCount("FissionConfigSpec.BuilderMgr.MaxConcurrentBuilds", spec.BuilderMgr.MaxConcurrentBuilds),
+		validateConfigCount("FissionConfigSpec.BuilderMgr.MaxConcurrentBuildsPerEnvironment", spec.BuilderMgr.MaxConcurrentBuildsPerEnvironment),
+		validateConfigDuration("FissionConfigSpec.BuilderMgr.BuildCacheTTL", spec.BuilderMgr.BuildCacheTTL))
+
+	if spec.Executor.EvictionMaxPods != nil && *spec.Executor.EvictionMaxPods <= 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FissionConfigSpec.Executor.EvictionMaxPods", *spec.Executor.EvictionMaxPods, "must be greater than 0"))
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (spec FissionSourceSpec) Validate() error {
+	result := &multierror.Error{}
+
+	if len(spec.URL) == 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FissionSourceSpec.URL", spec.URL, "repository URL is required"))
+	} else if strings.HasPrefix(spec.URL, "-") {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FissionSourceSpec.URL", spec.URL, "must not start with '-'"))
+	}
+
+	if strings.HasPrefix(spec.Branch, "-") {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FissionSourceSpec.Branch", spec.Branch, "must not start with '-'"))
+	}
+
+	if len(spec.Path) > 0 {
+		specPath := path.Clean(spec.Path)
+		if path.IsAbs(specPath) || specPath == ".." || strings.HasPrefix(specPath, "../") {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FissionSourceSpec.Path", spec.Path, "must be a relative path within the repository"))
+		}
+	}
+
+	if len(spec.AuthSecret) > 0 {
+		for _, msg := range validation.IsDNS1123Subdomain(spec.AuthSecret) {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FissionSourceSpec.AuthSecret", spec.AuthSecret, msg))
+		}
+	}
+
+	if len(spec.Interval) > 0 {
+		if d, err := time.ParseDuration(spec.Interval); err != nil || d <= 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FissionSourceSpec.Interval", spec.Interval, "must be a duration like 30s, 5m or 2h"))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+func validateConfigCount(field string, count *int) error {
+	if count != nil && *count < 0 {
+		return MakeValidationErr(ErrorInvalidValue, field, *count, "must be greater than or equal to 0")
+	}
+	return nil
+}
+
+func validateConfigDuration(field string, duration *string) error {
+	if duration == nil || len(*duration) == 0 {
+		return nil
+	}
+	if d, err := time.ParseDuration(*duration); err != nil || d < 0 {
+		return MakeValidationErr(ErrorInvalidValue, field, *duration, "must be a duration like 30s, 5m or 2h")
+	}
+	return nil
+}
+
 func validateMetadata(field string, m metav1.ObjectMeta) error {
 	return ValidateKubeReference(field, m.Name, m.Namespace)
 }
@@ -653,3 +1856,87 @@ func (ml *MessageQueueTriggerList) Validate() error {
 	}
 	return result.ErrorOrNil()
 }
+
+func (b *BucketTrigger) Validate() error {
+	result := &multierror.Error{}
+
+	result = multierror.Append(result,
+		validateMetadata("BucketTrigger", b.ObjectMeta),
+		b.Spec.Validate())
+
+	return result.ErrorOrNil()
+}
+
+func (bl *BucketTriggerList) Validate() error {
+	result := &multierror.Error{}
+	for _, b := range bl.Items {
+		result = multierror.Append(result, b.Validate())
+	}
+	return result.ErrorOrNil()
+}
+
+func (d *DatabaseTrigger) Validate() error {
+	result := &multierror.Error{}
+
+	result = multierror.Append(result,
+		validateMetadata("DatabaseTrigger", d.ObjectMeta),
+		d.Spec.Validate())
+
+	return result.ErrorOrNil()
+}
+
+func (dl *DatabaseTriggerList) Validate() error {
+	result := &multierror.Error{}
+	for _, d := range dl.Items {
+		result = multierror.Append(result, d.Validate())
+	}
+	return result.ErrorOrNil()
+}
+
+func (w *Workflow) Validate() error {
+	result := &multierror.Error{}
+
+	result = multierror.Append(result,
+		validateMetadata("Workflow", w.ObjectMeta),
+		w.Spec.Validate())
+
+	return result.ErrorOrNil()
+}
+
+func (wl *WorkflowList) Validate() error {
+	result := &multierror.Error{}
+	for _, w := range wl.Items {
+		result = multierror.Append(result, w.Validate())
+	}
+	return result.ErrorOrNil()
+}
+
+func (c *CanaryConfig) Validate() error {
+	result := &multierror.Error{}
+
+	result = multierror.Append(result,
+		validateMetadata("CanaryConfig", c.ObjectMeta),
+		c.Spec.Validate())
+
+	return result.ErrorOrNil()
+}
+
+func (c *FissionConfig) Validate() error {
+	result := &multierror.Error{}
+
+	result = multierror.Append(result,
+		validateMetadata("FissionConfig", c.ObjectMeta),
+		c.Spec.Validate())
+
+	return result.ErrorOrNil()
+}
+
+func (s *FissionSource) Validate() error {
+	result := &multierror.Error{}
+
+	result = multierror.Append(result,
+		validateMetadata("FissionSource", s.ObjectMeta),
+		s.Spec.Validate())
+
+	return result.ErrorOrNil()
+}
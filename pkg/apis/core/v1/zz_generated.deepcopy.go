@@ -34,6 +34,11 @@ func (in *Archive) DeepCopyInto(out *Archive) {
 		copy(*out, *in)
 	}
 	out.Checksum = in.Checksum
+	if in.Git != nil {
+		in, out := &in.Git, &out.Git
+		*out = new(GitSource)
+		**out = **in
+	}
 	return
 }
 
@@ -48,53 +53,26 @@ func (in *Archive) DeepCopy() *Archive {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Builder) DeepCopyInto(out *Builder) {
-	*out = *in
-	if in.Container != nil {
-		in, out := &in.Container, &out.Container
-		*out = new(corev1.Container)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.PodSpec != nil {
-		in, out := &in.PodSpec, &out.PodSpec
-		*out = new(corev1.PodSpec)
-		(*in).DeepCopyInto(*out)
-	}
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Builder.
-func (in *Builder) DeepCopy() *Builder {
-	if in == nil {
-		return nil
-	}
-	out := new(Builder)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CanaryConfig) DeepCopyInto(out *CanaryConfig) {
+func (in *BucketTrigger) DeepCopyInto(out *BucketTrigger) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryConfig.
-func (in *CanaryConfig) DeepCopy() *CanaryConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BucketTrigger.
+func (in *BucketTrigger) DeepCopy() *BucketTrigger {
 	if in == nil {
 		return nil
 	}
-	out := new(CanaryConfig)
+	out := new(BucketTrigger)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CanaryConfig) DeepCopyObject() runtime.Object {
+func (in *BucketTrigger) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -102,13 +80,13 @@ func (in *CanaryConfig) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CanaryConfigList) DeepCopyInto(out *CanaryConfigList) {
+func (in *BucketTriggerList) DeepCopyInto(out *BucketTriggerList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]CanaryConfig, len(*in))
+		*out = make([]BucketTrigger, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -116,18 +94,18 @@ func (in *CanaryConfigList) DeepCopyInto(out *CanaryConfigList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryConfigList.
-func (in *CanaryConfigList) DeepCopy() *CanaryConfigList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BucketTriggerList.
+func (in *BucketTriggerList) DeepCopy() *BucketTriggerList {
 	if in == nil {
 		return nil
 	}
-	out := new(CanaryConfigList)
+	out := new(BucketTriggerList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CanaryConfigList) DeepCopyObject() runtime.Object {
+func (in *BucketTriggerList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -135,90 +113,134 @@ func (in *CanaryConfigList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CanaryConfigSpec) DeepCopyInto(out *CanaryConfigSpec) {
+func (in *BucketTriggerSpec) DeepCopyInto(out *BucketTriggerSpec) {
 	*out = *in
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]BucketEventType, len(*in))
+		copy(*out, *in)
+	}
+	out.FunctionReference = in.FunctionReference
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryConfigSpec.
-func (in *CanaryConfigSpec) DeepCopy() *CanaryConfigSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BucketTriggerSpec.
+func (in *BucketTriggerSpec) DeepCopy() *BucketTriggerSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(CanaryConfigSpec)
+	out := new(BucketTriggerSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CanaryConfigStatus) DeepCopyInto(out *CanaryConfigStatus) {
+func (in *BuildStep) DeepCopyInto(out *BuildStep) {
 	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryConfigStatus.
-func (in *CanaryConfigStatus) DeepCopy() *CanaryConfigStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildStep.
+func (in *BuildStep) DeepCopy() *BuildStep {
 	if in == nil {
 		return nil
 	}
-	out := new(CanaryConfigStatus)
+	out := new(BuildStep)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Checksum) DeepCopyInto(out *Checksum) {
+func (in *Builder) DeepCopyInto(out *Builder) {
 	*out = *in
+	if in.Container != nil {
+		in, out := &in.Container, &out.Container
+		*out = new(corev1.Container)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodSpec != nil {
+		in, out := &in.PodSpec, &out.PodSpec
+		*out = new(corev1.PodSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Checksum.
-func (in *Checksum) DeepCopy() *Checksum {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Builder.
+func (in *Builder) DeepCopy() *Builder {
 	if in == nil {
 		return nil
 	}
-	out := new(Checksum)
+	out := new(Builder)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ConfigMapReference) DeepCopyInto(out *ConfigMapReference) {
+func (in *BuilderMgrConfig) DeepCopyInto(out *BuilderMgrConfig) {
 	*out = *in
+	if in.MaxConcurrentBuilds != nil {
+		in, out := &in.MaxConcurrentBuilds, &out.MaxConcurrentBuilds
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxConcurrentBuildsPerEnvironment != nil {
+		in, out := &in.MaxConcurrentBuildsPerEnvironment, &out.MaxConcurrentBuildsPerEnvironment
+		*out = new(int)
+		**out = **in
+	}
+	if in.BuildCacheTTL != nil {
+		in, out := &in.BuildCacheTTL, &out.BuildCacheTTL
+		*out = new(string)
+		**out = **in
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapReference.
-func (in *ConfigMapReference) DeepCopy() *ConfigMapReference {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuilderMgrConfig.
+func (in *BuilderMgrConfig) DeepCopy() *BuilderMgrConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(ConfigMapReference)
+	out := new(BuilderMgrConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Environment) DeepCopyInto(out *Environment) {
+func (in *CanaryConfig) DeepCopyInto(out *CanaryConfig) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Environment.
-func (in *Environment) DeepCopy() *Environment {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryConfig.
+func (in *CanaryConfig) DeepCopy() *CanaryConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(Environment)
+	out := new(CanaryConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Environment) DeepCopyObject() runtime.Object {
+func (in *CanaryConfig) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -226,13 +248,13 @@ func (in *Environment) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *EnvironmentList) DeepCopyInto(out *EnvironmentList) {
+func (in *CanaryConfigList) DeepCopyInto(out *CanaryConfigList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]Environment, len(*in))
+		*out = make([]CanaryConfig, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -240,18 +262,18 @@ func (in *EnvironmentList) DeepCopyInto(out *EnvironmentList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentList.
-func (in *EnvironmentList) DeepCopy() *EnvironmentList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryConfigList.
+func (in *CanaryConfigList) DeepCopy() *CanaryConfigList {
 	if in == nil {
 		return nil
 	}
-	out := new(EnvironmentList)
+	out := new(CanaryConfigList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *EnvironmentList) DeepCopyObject() runtime.Object {
+func (in *CanaryConfigList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -259,193 +281,200 @@ func (in *EnvironmentList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *EnvironmentReference) DeepCopyInto(out *EnvironmentReference) {
+func (in *CanaryConfigSpec) DeepCopyInto(out *CanaryConfigSpec) {
 	*out = *in
+	if in.Match != nil {
+		in, out := &in.Match, &out.Match
+		*out = new(CanaryMatch)
+		**out = **in
+	}
+	if in.SLOs != nil {
+		in, out := &in.SLOs, &out.SLOs
+		*out = make([]CanarySLO, len(*in))
+		copy(*out, *in)
+	}
+	if in.AnalysisWindows != nil {
+		in, out := &in.AnalysisWindows, &out.AnalysisWindows
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MetricsProvider != nil {
+		in, out := &in.MetricsProvider, &out.MetricsProvider
+		*out = new(CanaryMetricsProvider)
+		**out = **in
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentReference.
-func (in *EnvironmentReference) DeepCopy() *EnvironmentReference {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryConfigSpec.
+func (in *CanaryConfigSpec) DeepCopy() *CanaryConfigSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(EnvironmentReference)
+	out := new(CanaryConfigSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *EnvironmentSpec) DeepCopyInto(out *EnvironmentSpec) {
+func (in *CanaryConfigStatus) DeepCopyInto(out *CanaryConfigStatus) {
 	*out = *in
-	in.Runtime.DeepCopyInto(&out.Runtime)
-	in.Builder.DeepCopyInto(&out.Builder)
-	in.Resources.DeepCopyInto(&out.Resources)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentSpec.
-func (in *EnvironmentSpec) DeepCopy() *EnvironmentSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryConfigStatus.
+func (in *CanaryConfigStatus) DeepCopy() *CanaryConfigStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(EnvironmentSpec)
+	out := new(CanaryConfigStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ExecutionStrategy) DeepCopyInto(out *ExecutionStrategy) {
+func (in *CanaryMatch) DeepCopyInto(out *CanaryMatch) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecutionStrategy.
-func (in *ExecutionStrategy) DeepCopy() *ExecutionStrategy {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryMatch.
+func (in *CanaryMatch) DeepCopy() *CanaryMatch {
 	if in == nil {
 		return nil
 	}
-	out := new(ExecutionStrategy)
+	out := new(CanaryMatch)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Function) DeepCopyInto(out *Function) {
+func (in *CanaryMetricsProvider) DeepCopyInto(out *CanaryMetricsProvider) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Function.
-func (in *Function) DeepCopy() *Function {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryMetricsProvider.
+func (in *CanaryMetricsProvider) DeepCopy() *CanaryMetricsProvider {
 	if in == nil {
 		return nil
 	}
-	out := new(Function)
+	out := new(CanaryMetricsProvider)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Function) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *FunctionList) DeepCopyInto(out *FunctionList) {
+func (in *CanarySLO) DeepCopyInto(out *CanarySLO) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]Function, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionList.
-func (in *FunctionList) DeepCopy() *FunctionList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanarySLO.
+func (in *CanarySLO) DeepCopy() *CanarySLO {
 	if in == nil {
 		return nil
 	}
-	out := new(FunctionList)
+	out := new(CanarySLO)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *FunctionList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Checksum) DeepCopyInto(out *Checksum) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Checksum.
+func (in *Checksum) DeepCopy() *Checksum {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(Checksum)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *FunctionPackageRef) DeepCopyInto(out *FunctionPackageRef) {
+func (in *CircuitBreakerPolicy) DeepCopyInto(out *CircuitBreakerPolicy) {
 	*out = *in
-	out.PackageRef = in.PackageRef
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionPackageRef.
-func (in *FunctionPackageRef) DeepCopy() *FunctionPackageRef {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CircuitBreakerPolicy.
+func (in *CircuitBreakerPolicy) DeepCopy() *CircuitBreakerPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(FunctionPackageRef)
+	out := new(CircuitBreakerPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *FunctionReference) DeepCopyInto(out *FunctionReference) {
+func (in *ComponentConfigStatus) DeepCopyInto(out *ComponentConfigStatus) {
 	*out = *in
-	if in.FunctionWeights != nil {
-		in, out := &in.FunctionWeights, &out.FunctionWeights
-		*out = make(map[string]int, len(*in))
+	if in.Effective != nil {
+		in, out := &in.Effective, &out.Effective
+		*out = make(map[string]string, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val
 		}
 	}
+	if in.RestartRequired != nil {
+		in, out := &in.RestartRequired, &out.RestartRequired
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionReference.
-func (in *FunctionReference) DeepCopy() *FunctionReference {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentConfigStatus.
+func (in *ComponentConfigStatus) DeepCopy() *ComponentConfigStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(FunctionReference)
+	out := new(ComponentConfigStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *FunctionSpec) DeepCopyInto(out *FunctionSpec) {
+func (in *ConfigMapReference) DeepCopyInto(out *ConfigMapReference) {
 	*out = *in
-	out.Environment = in.Environment
-	out.Package = in.Package
-	if in.Secrets != nil {
-		in, out := &in.Secrets, &out.Secrets
-		*out = make([]SecretReference, len(*in))
-		copy(*out, *in)
-	}
-	if in.ConfigMaps != nil {
-		in, out := &in.ConfigMaps, &out.ConfigMaps
-		*out = make([]ConfigMapReference, len(*in))
-		copy(*out, *in)
-	}
-	in.Resources.DeepCopyInto(&out.Resources)
-	out.InvokeStrategy = in.InvokeStrategy
-	if in.IdleTimeout != nil {
-		in, out := &in.IdleTimeout, &out.IdleTimeout
-		*out = new(int)
-		**out = **in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapReference.
+func (in *ConfigMapReference) DeepCopy() *ConfigMapReference {
+	if in == nil {
+		return nil
 	}
+	out := new(ConfigMapReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigReload) DeepCopyInto(out *ConfigReload) {
+	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionSpec.
-func (in *FunctionSpec) DeepCopy() *FunctionSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigReload.
+func (in *ConfigReload) DeepCopy() *ConfigReload {
 	if in == nil {
 		return nil
 	}
-	out := new(FunctionSpec)
+	out := new(ConfigReload)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HTTPTrigger) DeepCopyInto(out *HTTPTrigger) {
+func (in *DatabaseTrigger) DeepCopyInto(out *DatabaseTrigger) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -453,18 +482,18 @@ func (in *HTTPTrigger) DeepCopyInto(out *HTTPTrigger) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPTrigger.
-func (in *HTTPTrigger) DeepCopy() *HTTPTrigger {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseTrigger.
+func (in *DatabaseTrigger) DeepCopy() *DatabaseTrigger {
 	if in == nil {
 		return nil
 	}
-	out := new(HTTPTrigger)
+	out := new(DatabaseTrigger)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HTTPTrigger) DeepCopyObject() runtime.Object {
+func (in *DatabaseTrigger) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -472,13 +501,13 @@ func (in *HTTPTrigger) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HTTPTriggerList) DeepCopyInto(out *HTTPTriggerList) {
+func (in *DatabaseTriggerList) DeepCopyInto(out *DatabaseTriggerList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]HTTPTrigger, len(*in))
+		*out = make([]DatabaseTrigger, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -486,18 +515,18 @@ func (in *HTTPTriggerList) DeepCopyInto(out *HTTPTriggerList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPTriggerList.
-func (in *HTTPTriggerList) DeepCopy() *HTTPTriggerList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseTriggerList.
+func (in *DatabaseTriggerList) DeepCopy() *DatabaseTriggerList {
 	if in == nil {
 		return nil
 	}
-	out := new(HTTPTriggerList)
+	out := new(DatabaseTriggerList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HTTPTriggerList) DeepCopyObject() runtime.Object {
+func (in *DatabaseTriggerList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -505,12 +534,1026 @@ func (in *HTTPTriggerList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HTTPTriggerSpec) DeepCopyInto(out *HTTPTriggerSpec) {
+func (in *DatabaseTriggerSpec) DeepCopyInto(out *DatabaseTriggerSpec) {
 	*out = *in
-	in.FunctionReference.DeepCopyInto(&out.FunctionReference)
-	in.IngressConfig.DeepCopyInto(&out.IngressConfig)
-	return
-}
+	if in.Tables != nil {
+		in, out := &in.Tables, &out.Tables
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Operations != nil {
+		in, out := &in.Operations, &out.Operations
+		*out = make([]DatabaseOperation, len(*in))
+		copy(*out, *in)
+	}
+	out.FunctionReference = in.FunctionReference
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseTriggerSpec.
+func (in *DatabaseTriggerSpec) DeepCopy() *DatabaseTriggerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseTriggerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EmailOutput) DeepCopyInto(out *EmailOutput) {
+	*out = *in
+	if in.To != nil {
+		in, out := &in.To, &out.To
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EmailOutput.
+func (in *EmailOutput) DeepCopy() *EmailOutput {
+	if in == nil {
+		return nil
+	}
+	out := new(EmailOutput)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Environment) DeepCopyInto(out *Environment) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Environment.
+func (in *Environment) DeepCopy() *Environment {
+	if in == nil {
+		return nil
+	}
+	out := new(Environment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Environment) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentList) DeepCopyInto(out *EnvironmentList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Environment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentList.
+func (in *EnvironmentList) DeepCopy() *EnvironmentList {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EnvironmentList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentReference) DeepCopyInto(out *EnvironmentReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentReference.
+func (in *EnvironmentReference) DeepCopy() *EnvironmentReference {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentSpec) DeepCopyInto(out *EnvironmentSpec) {
+	*out = *in
+	in.Runtime.DeepCopyInto(&out.Runtime)
+	in.Builder.DeepCopyInto(&out.Builder)
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.RolloutStrategy != nil {
+		in, out := &in.RolloutStrategy, &out.RolloutStrategy
+		*out = new(RolloutStrategy)
+		**out = **in
+	}
+	if in.HTTPTriggerDefaults != nil {
+		in, out := &in.HTTPTriggerDefaults, &out.HTTPTriggerDefaults
+		*out = new(HTTPTriggerDefaults)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentSpec.
+func (in *EnvironmentSpec) DeepCopy() *EnvironmentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExecutionStrategy) DeepCopyInto(out *ExecutionStrategy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecutionStrategy.
+func (in *ExecutionStrategy) DeepCopy() *ExecutionStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(ExecutionStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExecutorConfig) DeepCopyInto(out *ExecutorConfig) {
+	*out = *in
+	if in.MaxConcurrentSpecializations != nil {
+		in, out := &in.MaxConcurrentSpecializations, &out.MaxConcurrentSpecializations
+		*out = new(int)
+		**out = **in
+	}
+	if in.EvictionMinIdle != nil {
+		in, out := &in.EvictionMinIdle, &out.EvictionMinIdle
+		*out = new(string)
+		**out = **in
+	}
+	if in.EvictionMaxPods != nil {
+		in, out := &in.EvictionMaxPods, &out.EvictionMaxPods
+		*out = new(int)
+		**out = **in
+	}
+	if in.PodReadyTimeout != nil {
+		in, out := &in.PodReadyTimeout, &out.PodReadyTimeout
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecutorConfig.
+func (in *ExecutorConfig) DeepCopy() *ExecutorConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ExecutorConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Experiment) DeepCopyInto(out *Experiment) {
+	*out = *in
+	out.Assignment = in.Assignment
+	if in.Exposure != nil {
+		in, out := &in.Exposure, &out.Exposure
+		*out = new(int)
+		**out = **in
+	}
+	if in.Variants != nil {
+		in, out := &in.Variants, &out.Variants
+		*out = make([]ExperimentVariant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Experiment.
+func (in *Experiment) DeepCopy() *Experiment {
+	if in == nil {
+		return nil
+	}
+	out := new(Experiment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExperimentAssignment) DeepCopyInto(out *ExperimentAssignment) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentAssignment.
+func (in *ExperimentAssignment) DeepCopy() *ExperimentAssignment {
+	if in == nil {
+		return nil
+	}
+	out := new(ExperimentAssignment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExperimentVariant) DeepCopyInto(out *ExperimentVariant) {
+	*out = *in
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentVariant.
+func (in *ExperimentVariant) DeepCopy() *ExperimentVariant {
+	if in == nil {
+		return nil
+	}
+	out := new(ExperimentVariant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalSecretReference) DeepCopyInto(out *ExternalSecretReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalSecretReference.
+func (in *ExternalSecretReference) DeepCopy() *ExternalSecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalSecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FeatureFlagSource) DeepCopyInto(out *FeatureFlagSource) {
+	*out = *in
+	if in.Flags != nil {
+		in, out := &in.Flags, &out.Flags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FeatureFlagSource.
+func (in *FeatureFlagSource) DeepCopy() *FeatureFlagSource {
+	if in == nil {
+		return nil
+	}
+	out := new(FeatureFlagSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FissionConfig) DeepCopyInto(out *FissionConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FissionConfig.
+func (in *FissionConfig) DeepCopy() *FissionConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(FissionConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FissionConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FissionConfigList) DeepCopyInto(out *FissionConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FissionConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FissionConfigList.
+func (in *FissionConfigList) DeepCopy() *FissionConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(FissionConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FissionConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FissionConfigSpec) DeepCopyInto(out *FissionConfigSpec) {
+	*out = *in
+	in.Executor.DeepCopyInto(&out.Executor)
+	in.Router.DeepCopyInto(&out.Router)
+	in.BuilderMgr.DeepCopyInto(&out.BuilderMgr)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FissionConfigSpec.
+func (in *FissionConfigSpec) DeepCopy() *FissionConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FissionConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FissionConfigStatus) DeepCopyInto(out *FissionConfigStatus) {
+	*out = *in
+	if in.Components != nil {
+		in, out := &in.Components, &out.Components
+		*out = make(map[string]ComponentConfigStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FissionConfigStatus.
+func (in *FissionConfigStatus) DeepCopy() *FissionConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FissionConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FissionSource) DeepCopyInto(out *FissionSource) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FissionSource.
+func (in *FissionSource) DeepCopy() *FissionSource {
+	if in == nil {
+		return nil
+	}
+	out := new(FissionSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FissionSource) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FissionSourceList) DeepCopyInto(out *FissionSourceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FissionSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FissionSourceList.
+func (in *FissionSourceList) DeepCopy() *FissionSourceList {
+	if in == nil {
+		return nil
+	}
+	out := new(FissionSourceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FissionSourceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FissionSourceSpec) DeepCopyInto(out *FissionSourceSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FissionSourceSpec.
+func (in *FissionSourceSpec) DeepCopy() *FissionSourceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FissionSourceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FissionSourceStatus) DeepCopyInto(out *FissionSourceStatus) {
+	*out = *in
+	in.LastSyncTime.DeepCopyInto(&out.LastSyncTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FissionSourceStatus.
+func (in *FissionSourceStatus) DeepCopy() *FissionSourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FissionSourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Function) DeepCopyInto(out *Function) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Function.
+func (in *Function) DeepCopy() *Function {
+	if in == nil {
+		return nil
+	}
+	out := new(Function)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Function) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FunctionCondition) DeepCopyInto(out *FunctionCondition) {
+	*out = *in
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionCondition.
+func (in *FunctionCondition) DeepCopy() *FunctionCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(FunctionCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FunctionContainer) DeepCopyInto(out *FunctionContainer) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionContainer.
+func (in *FunctionContainer) DeepCopy() *FunctionContainer {
+	if in == nil {
+		return nil
+	}
+	out := new(FunctionContainer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FunctionList) DeepCopyInto(out *FunctionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Function, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionList.
+func (in *FunctionList) DeepCopy() *FunctionList {
+	if in == nil {
+		return nil
+	}
+	out := new(FunctionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FunctionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FunctionMatchRoute) DeepCopyInto(out *FunctionMatchRoute) {
+	*out = *in
+	out.Match = in.Match
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionMatchRoute.
+func (in *FunctionMatchRoute) DeepCopy() *FunctionMatchRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(FunctionMatchRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FunctionPackageRef) DeepCopyInto(out *FunctionPackageRef) {
+	*out = *in
+	out.PackageRef = in.PackageRef
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionPackageRef.
+func (in *FunctionPackageRef) DeepCopy() *FunctionPackageRef {
+	if in == nil {
+		return nil
+	}
+	out := new(FunctionPackageRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FunctionReference) DeepCopyInto(out *FunctionReference) {
+	*out = *in
+	if in.FunctionWeights != nil {
+		in, out := &in.FunctionWeights, &out.FunctionWeights
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MatchRoute != nil {
+		in, out := &in.MatchRoute, &out.MatchRoute
+		*out = new(FunctionMatchRoute)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionReference.
+func (in *FunctionReference) DeepCopy() *FunctionReference {
+	if in == nil {
+		return nil
+	}
+	out := new(FunctionReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FunctionSpec) DeepCopyInto(out *FunctionSpec) {
+	*out = *in
+	out.Environment = in.Environment
+	out.Package = in.Package
+	if in.Secrets != nil {
+		in, out := &in.Secrets, &out.Secrets
+		*out = make([]SecretReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConfigMaps != nil {
+		in, out := &in.ConfigMaps, &out.ConfigMaps
+		*out = make([]ConfigMapReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExternalSecrets != nil {
+		in, out := &in.ExternalSecrets, &out.ExternalSecrets
+		*out = make([]ExternalSecretReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConfigReload != nil {
+		in, out := &in.ConfigReload, &out.ConfigReload
+		*out = new(ConfigReload)
+		**out = **in
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	out.InvokeStrategy = in.InvokeStrategy
+	if in.IdleTimeout != nil {
+		in, out := &in.IdleTimeout, &out.IdleTimeout
+		*out = new(int)
+		**out = **in
+	}
+	if in.CoLocateWith != nil {
+		in, out := &in.CoLocateWith, &out.CoLocateWith
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.InvocationQuotas != nil {
+		in, out := &in.InvocationQuotas, &out.InvocationQuotas
+		*out = make([]InvocationQuota, len(*in))
+		copy(*out, *in)
+	}
+	if in.Container != nil {
+		in, out := &in.Container, &out.Container
+		*out = new(FunctionContainer)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Probes != nil {
+		in, out := &in.Probes, &out.Probes
+		*out = new(Probes)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodSpec != nil {
+		in, out := &in.PodSpec, &out.PodSpec
+		*out = new(corev1.PodSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionSpec.
+func (in *FunctionSpec) DeepCopy() *FunctionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FunctionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FunctionStatus) DeepCopyInto(out *FunctionStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]FunctionCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionStatus.
+func (in *FunctionStatus) DeepCopy() *FunctionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FunctionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FunctionUsage) DeepCopyInto(out *FunctionUsage) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Usage.DeepCopyInto(&out.Usage)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionUsage.
+func (in *FunctionUsage) DeepCopy() *FunctionUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(FunctionUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FunctionUsage) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FunctionUsageList) DeepCopyInto(out *FunctionUsageList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FunctionUsage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionUsageList.
+func (in *FunctionUsageList) DeepCopy() *FunctionUsageList {
+	if in == nil {
+		return nil
+	}
+	out := new(FunctionUsageList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FunctionUsageList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitSource) DeepCopyInto(out *GitSource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitSource.
+func (in *GitSource) DeepCopy() *GitSource {
+	if in == nil {
+		return nil
+	}
+	out := new(GitSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPTrigger) DeepCopyInto(out *HTTPTrigger) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPTrigger.
+func (in *HTTPTrigger) DeepCopy() *HTTPTrigger {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPTrigger)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HTTPTrigger) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPTriggerAuthentication) DeepCopyInto(out *HTTPTriggerAuthentication) {
+	*out = *in
+	if in.JWT != nil {
+		in, out := &in.JWT, &out.JWT
+		*out = new(JWTAuthentication)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPTriggerAuthentication.
+func (in *HTTPTriggerAuthentication) DeepCopy() *HTTPTriggerAuthentication {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPTriggerAuthentication)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPTriggerDefaults) DeepCopyInto(out *HTTPTriggerDefaults) {
+	*out = *in
+	if in.Authentication != nil {
+		in, out := &in.Authentication, &out.Authentication
+		*out = new(HTTPTriggerAuthentication)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RequestValidation != nil {
+		in, out := &in.RequestValidation, &out.RequestValidation
+		*out = new(RequestValidation)
+		**out = **in
+	}
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(HTTPTriggerRetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CircuitBreaker != nil {
+		in, out := &in.CircuitBreaker, &out.CircuitBreaker
+		*out = new(CircuitBreakerPolicy)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPTriggerDefaults.
+func (in *HTTPTriggerDefaults) DeepCopy() *HTTPTriggerDefaults {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPTriggerDefaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPTriggerList) DeepCopyInto(out *HTTPTriggerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HTTPTrigger, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPTriggerList.
+func (in *HTTPTriggerList) DeepCopy() *HTTPTriggerList {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPTriggerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HTTPTriggerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPTriggerRetryPolicy) DeepCopyInto(out *HTTPTriggerRetryPolicy) {
+	*out = *in
+	if in.RetryOn != nil {
+		in, out := &in.RetryOn, &out.RetryOn
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPTriggerRetryPolicy.
+func (in *HTTPTriggerRetryPolicy) DeepCopy() *HTTPTriggerRetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPTriggerRetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPTriggerSpec) DeepCopyInto(out *HTTPTriggerSpec) {
+	*out = *in
+	in.FunctionReference.DeepCopyInto(&out.FunctionReference)
+	in.IngressConfig.DeepCopyInto(&out.IngressConfig)
+	if in.Authentication != nil {
+		in, out := &in.Authentication, &out.Authentication
+		*out = new(HTTPTriggerAuthentication)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SessionAffinity != nil {
+		in, out := &in.SessionAffinity, &out.SessionAffinity
+		*out = new(SessionAffinity)
+		**out = **in
+	}
+	if in.RequestValidation != nil {
+		in, out := &in.RequestValidation, &out.RequestValidation
+		*out = new(RequestValidation)
+		**out = **in
+	}
+	if in.FeatureFlags != nil {
+		in, out := &in.FeatureFlags, &out.FeatureFlags
+		*out = new(FeatureFlagSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(HTTPTriggerRetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CircuitBreaker != nil {
+		in, out := &in.CircuitBreaker, &out.CircuitBreaker
+		*out = new(CircuitBreakerPolicy)
+		**out = **in
+	}
+	if in.Experiment != nil {
+		in, out := &in.Experiment, &out.Experiment
+		*out = new(Experiment)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LongPoll != nil {
+		in, out := &in.LongPoll, &out.LongPoll
+		*out = new(LongPollPolicy)
+		**out = **in
+	}
+	return
+}
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPTriggerSpec.
 func (in *HTTPTriggerSpec) DeepCopy() *HTTPTriggerSpec {
@@ -545,6 +1588,106 @@ func (in *IngressConfig) DeepCopy() *IngressConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InvocationQuota) DeepCopyInto(out *InvocationQuota) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvocationQuota.
+func (in *InvocationQuota) DeepCopy() *InvocationQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(InvocationQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InvocationHistory) DeepCopyInto(out *InvocationHistory) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Invocations != nil {
+		in, out := &in.Invocations, &out.Invocations
+		*out = make([]InvocationRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvocationHistory.
+func (in *InvocationHistory) DeepCopy() *InvocationHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(InvocationHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *InvocationHistory) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InvocationHistoryList) DeepCopyInto(out *InvocationHistoryList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]InvocationHistory, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvocationHistoryList.
+func (in *InvocationHistoryList) DeepCopy() *InvocationHistoryList {
+	if in == nil {
+		return nil
+	}
+	out := new(InvocationHistoryList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *InvocationHistoryList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InvocationRecord) DeepCopyInto(out *InvocationRecord) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	out.Duration = in.Duration
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvocationRecord.
+func (in *InvocationRecord) DeepCopy() *InvocationRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(InvocationRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *InvokeStrategy) DeepCopyInto(out *InvokeStrategy) {
 	*out = *in
@@ -562,6 +1705,41 @@ func (in *InvokeStrategy) DeepCopy() *InvokeStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JWTAuthentication) DeepCopyInto(out *JWTAuthentication) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequiredClaims != nil {
+		in, out := &in.RequiredClaims, &out.RequiredClaims
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ClaimsToHeaders != nil {
+		in, out := &in.ClaimsToHeaders, &out.ClaimsToHeaders
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JWTAuthentication.
+func (in *JWTAuthentication) DeepCopy() *JWTAuthentication {
+	if in == nil {
+		return nil
+	}
+	out := new(JWTAuthentication)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubernetesWatchTrigger) DeepCopyInto(out *KubernetesWatchTrigger) {
 	*out = *in
@@ -646,6 +1824,22 @@ func (in *KubernetesWatchTriggerSpec) DeepCopy() *KubernetesWatchTriggerSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LongPollPolicy) DeepCopyInto(out *LongPollPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LongPollPolicy.
+func (in *LongPollPolicy) DeepCopy() *LongPollPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(LongPollPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MessageQueueTrigger) DeepCopyInto(out *MessageQueueTrigger) {
 	*out = *in
@@ -673,6 +1867,22 @@ func (in *MessageQueueTrigger) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MessageQueueTriggerAutoscaling) DeepCopyInto(out *MessageQueueTriggerAutoscaling) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MessageQueueTriggerAutoscaling.
+func (in *MessageQueueTriggerAutoscaling) DeepCopy() *MessageQueueTriggerAutoscaling {
+	if in == nil {
+		return nil
+	}
+	out := new(MessageQueueTriggerAutoscaling)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MessageQueueTriggerList) DeepCopyInto(out *MessageQueueTriggerList) {
 	*out = *in
@@ -706,10 +1916,31 @@ func (in *MessageQueueTriggerList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MessageQueueTriggerRetryPolicy) DeepCopyInto(out *MessageQueueTriggerRetryPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MessageQueueTriggerRetryPolicy.
+func (in *MessageQueueTriggerRetryPolicy) DeepCopy() *MessageQueueTriggerRetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(MessageQueueTriggerRetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MessageQueueTriggerSpec) DeepCopyInto(out *MessageQueueTriggerSpec) {
 	*out = *in
 	in.FunctionReference.DeepCopyInto(&out.FunctionReference)
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(MessageQueueTriggerRetryPolicy)
+		**out = **in
+	}
 	if in.PollingInterval != nil {
 		in, out := &in.PollingInterval, &out.PollingInterval
 		*out = new(int32)
@@ -730,6 +1961,11 @@ func (in *MessageQueueTriggerSpec) DeepCopyInto(out *MessageQueueTriggerSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Autoscaling != nil {
+		in, out := &in.Autoscaling, &out.Autoscaling
+		*out = new(MessageQueueTriggerAutoscaling)
+		**out = **in
+	}
 	if in.Metadata != nil {
 		in, out := &in.Metadata, &out.Metadata
 		*out = make(map[string]string, len(*in))
@@ -833,6 +2069,14 @@ func (in *PackageSpec) DeepCopyInto(out *PackageSpec) {
 	out.Environment = in.Environment
 	in.Source.DeepCopyInto(&out.Source)
 	in.Deployment.DeepCopyInto(&out.Deployment)
+	if in.BuildSteps != nil {
+		in, out := &in.BuildSteps, &out.BuildSteps
+		*out = make([]BuildStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.SourceMap.DeepCopyInto(&out.SourceMap)
 	return
 }
 
@@ -863,6 +2107,123 @@ func (in *PackageStatus) DeepCopy() *PackageStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Probes) DeepCopyInto(out *Probes) {
+	*out = *in
+	if in.Startup != nil {
+		in, out := &in.Startup, &out.Startup
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Readiness != nil {
+		in, out := &in.Readiness, &out.Readiness
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Liveness != nil {
+		in, out := &in.Liveness, &out.Liveness
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Probes.
+func (in *Probes) DeepCopy() *Probes {
+	if in == nil {
+		return nil
+	}
+	out := new(Probes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequestValidation) DeepCopyInto(out *RequestValidation) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequestValidation.
+func (in *RequestValidation) DeepCopy() *RequestValidation {
+	if in == nil {
+		return nil
+	}
+	out := new(RequestValidation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceUsage) DeepCopyInto(out *ResourceUsage) {
+	*out = *in
+	in.Since.DeepCopyInto(&out.Since)
+	in.LastUpdated.DeepCopyInto(&out.LastUpdated)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceUsage.
+func (in *ResourceUsage) DeepCopy() *ResourceUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStrategy) DeepCopyInto(out *RolloutStrategy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStrategy.
+func (in *RolloutStrategy) DeepCopy() *RolloutStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RouterConfig) DeepCopyInto(out *RouterConfig) {
+	*out = *in
+	if in.MaxInflightRequests != nil {
+		in, out := &in.MaxInflightRequests, &out.MaxInflightRequests
+		*out = new(int)
+		**out = **in
+	}
+	if in.RoundTripTimeout != nil {
+		in, out := &in.RoundTripTimeout, &out.RoundTripTimeout
+		*out = new(string)
+		**out = **in
+	}
+	if in.RoundTripMaxRetries != nil {
+		in, out := &in.RoundTripMaxRetries, &out.RoundTripMaxRetries
+		*out = new(int)
+		**out = **in
+	}
+	if in.DisplayAccessLog != nil {
+		in, out := &in.DisplayAccessLog, &out.DisplayAccessLog
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RouterConfig.
+func (in *RouterConfig) DeepCopy() *RouterConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RouterConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Runtime) DeepCopyInto(out *Runtime) {
 	*out = *in
@@ -876,6 +2237,11 @@ func (in *Runtime) DeepCopyInto(out *Runtime) {
 		*out = new(corev1.PodSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Probes != nil {
+		in, out := &in.Probes, &out.Probes
+		*out = new(Probes)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -905,6 +2271,22 @@ func (in *SecretReference) DeepCopy() *SecretReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SessionAffinity) DeepCopyInto(out *SessionAffinity) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SessionAffinity.
+func (in *SessionAffinity) DeepCopy() *SessionAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(SessionAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TimeTrigger) DeepCopyInto(out *TimeTrigger) {
 	*out = *in
@@ -965,10 +2347,43 @@ func (in *TimeTriggerList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeTriggerOutput) DeepCopyInto(out *TimeTriggerOutput) {
+	*out = *in
+	if in.Email != nil {
+		in, out := &in.Email, &out.Email
+		*out = new(EmailOutput)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeTriggerOutput.
+func (in *TimeTriggerOutput) DeepCopy() *TimeTriggerOutput {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeTriggerOutput)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TimeTriggerSpec) DeepCopyInto(out *TimeTriggerSpec) {
 	*out = *in
 	in.FunctionReference.DeepCopyInto(&out.FunctionReference)
+	if in.Output != nil {
+		in, out := &in.Output, &out.Output
+		*out = new(TimeTriggerOutput)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -997,3 +2412,195 @@ func (in *ValidationError) DeepCopy() *ValidationError {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Workflow) DeepCopyInto(out *Workflow) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Workflow.
+func (in *Workflow) DeepCopy() *Workflow {
+	if in == nil {
+		return nil
+	}
+	out := new(Workflow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Workflow) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowBranch) DeepCopyInto(out *WorkflowBranch) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowBranch.
+func (in *WorkflowBranch) DeepCopy() *WorkflowBranch {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowBranch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowExecution) DeepCopyInto(out *WorkflowExecution) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]WorkflowStepExecution, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	in.CompletionTime.DeepCopyInto(&out.CompletionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowExecution.
+func (in *WorkflowExecution) DeepCopy() *WorkflowExecution {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowExecution)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowList) DeepCopyInto(out *WorkflowList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Workflow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowList.
+func (in *WorkflowList) DeepCopy() *WorkflowList {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkflowList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowSpec) DeepCopyInto(out *WorkflowSpec) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]WorkflowStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowSpec.
+func (in *WorkflowSpec) DeepCopy() *WorkflowSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowStatus) DeepCopyInto(out *WorkflowStatus) {
+	*out = *in
+	if in.Executions != nil {
+		in, out := &in.Executions, &out.Executions
+		*out = make([]WorkflowExecution, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowStatus.
+func (in *WorkflowStatus) DeepCopy() *WorkflowStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowStep) DeepCopyInto(out *WorkflowStep) {
+	*out = *in
+	if in.Parallel != nil {
+		in, out := &in.Parallel, &out.Parallel
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Branches != nil {
+		in, out := &in.Branches, &out.Branches
+		*out = make([]WorkflowBranch, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowStep.
+func (in *WorkflowStep) DeepCopy() *WorkflowStep {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowStepExecution) DeepCopyInto(out *WorkflowStepExecution) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	in.CompletionTime.DeepCopyInto(&out.CompletionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowStepExecution.
+func (in *WorkflowStepExecution) DeepCopy() *WorkflowStepExecution {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowStepExecution)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,36 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package versioned forwards to the package of the same path under pkg/generated,
+// where the generated code of Fission CRDs moved.
+//
+// Deprecated: import github.com/fission/fission/pkg/generated/clientset/versioned instead.
+package versioned
+
+import (
+	generated "github.com/fission/fission/pkg/generated/clientset/versioned"
+)
+
+type (
+	Clientset = generated.Clientset
+	Interface = generated.Interface
+)
+
+var (
+	New               = generated.New
+	NewForConfig      = generated.NewForConfig
+	NewForConfigOrDie = generated.NewForConfigOrDie
+)
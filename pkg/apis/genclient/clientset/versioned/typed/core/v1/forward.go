@@ -0,0 +1,63 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1 forwards to the package of the same path under pkg/generated,
+// where the generated code of Fission CRDs moved.
+//
+// Deprecated: import github.com/fission/fission/pkg/generated/clientset/versioned/typed/core/v1 instead.
+package v1
+
+import (
+	generated "github.com/fission/fission/pkg/generated/clientset/versioned/typed/core/v1"
+)
+
+type (
+	CanaryConfigExpansion           = generated.CanaryConfigExpansion
+	CanaryConfigInterface           = generated.CanaryConfigInterface
+	CanaryConfigsGetter             = generated.CanaryConfigsGetter
+	CoreV1Client                    = generated.CoreV1Client
+	CoreV1Interface                 = generated.CoreV1Interface
+	EnvironmentExpansion            = generated.EnvironmentExpansion
+	EnvironmentInterface            = generated.EnvironmentInterface
+	EnvironmentsGetter              = generated.EnvironmentsGetter
+	FissionConfigExpansion          = generated.FissionConfigExpansion
+	FissionConfigInterface          = generated.FissionConfigInterface
+	FissionConfigsGetter            = generated.FissionConfigsGetter
+	FunctionExpansion               = generated.FunctionExpansion
+	FunctionInterface               = generated.FunctionInterface
+	FunctionsGetter                 = generated.FunctionsGetter
+	HTTPTriggerExpansion            = generated.HTTPTriggerExpansion
+	HTTPTriggerInterface            = generated.HTTPTriggerInterface
+	HTTPTriggersGetter              = generated.HTTPTriggersGetter
+	KubernetesWatchTriggerExpansion = generated.KubernetesWatchTriggerExpansion
+	KubernetesWatchTriggerInterface = generated.KubernetesWatchTriggerInterface
+	KubernetesWatchTriggersGetter   = generated.KubernetesWatchTriggersGetter
+	MessageQueueTriggerExpansion    = generated.MessageQueueTriggerExpansion
+	MessageQueueTriggerInterface    = generated.MessageQueueTriggerInterface
+	MessageQueueTriggersGetter      = generated.MessageQueueTriggersGetter
+	PackageExpansion                = generated.PackageExpansion
+	PackageInterface                = generated.PackageInterface
+	PackagesGetter                  = generated.PackagesGetter
+	TimeTriggerExpansion            = generated.TimeTriggerExpansion
+	TimeTriggerInterface            = generated.TimeTriggerInterface
+	TimeTriggersGetter              = generated.TimeTriggersGetter
+)
+
+var (
+	New               = generated.New
+	NewForConfig      = generated.NewForConfig
+	NewForConfigOrDie = generated.NewForConfigOrDie
+)
@@ -0,0 +1,60 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1 forwards to the package of the same path under pkg/generated,
+// where the generated code of Fission CRDs moved.
+//
+// Deprecated: import github.com/fission/fission/pkg/generated/informers/externalversions/core/v1 instead.
+package v1
+
+import (
+	generated "github.com/fission/fission/pkg/generated/informers/externalversions/core/v1"
+)
+
+type (
+	CanaryConfigInformer           = generated.CanaryConfigInformer
+	EnvironmentInformer            = generated.EnvironmentInformer
+	FissionConfigInformer          = generated.FissionConfigInformer
+	FunctionInformer               = generated.FunctionInformer
+	HTTPTriggerInformer            = generated.HTTPTriggerInformer
+	Interface                      = generated.Interface
+	KubernetesWatchTriggerInformer = generated.KubernetesWatchTriggerInformer
+	MessageQueueTriggerInformer    = generated.MessageQueueTriggerInformer
+	PackageInformer                = generated.PackageInformer
+	TimeTriggerInformer            = generated.TimeTriggerInformer
+)
+
+var (
+	New                                       = generated.New
+	NewCanaryConfigInformer                   = generated.NewCanaryConfigInformer
+	NewEnvironmentInformer                    = generated.NewEnvironmentInformer
+	NewFilteredCanaryConfigInformer           = generated.NewFilteredCanaryConfigInformer
+	NewFilteredEnvironmentInformer            = generated.NewFilteredEnvironmentInformer
+	NewFilteredFissionConfigInformer          = generated.NewFilteredFissionConfigInformer
+	NewFilteredFunctionInformer               = generated.NewFilteredFunctionInformer
+	NewFilteredHTTPTriggerInformer            = generated.NewFilteredHTTPTriggerInformer
+	NewFilteredKubernetesWatchTriggerInformer = generated.NewFilteredKubernetesWatchTriggerInformer
+	NewFilteredMessageQueueTriggerInformer    = generated.NewFilteredMessageQueueTriggerInformer
+	NewFilteredPackageInformer                = generated.NewFilteredPackageInformer
+	NewFilteredTimeTriggerInformer            = generated.NewFilteredTimeTriggerInformer
+	NewFissionConfigInformer                  = generated.NewFissionConfigInformer
+	NewFunctionInformer                       = generated.NewFunctionInformer
+	NewHTTPTriggerInformer                    = generated.NewHTTPTriggerInformer
+	NewKubernetesWatchTriggerInformer         = generated.NewKubernetesWatchTriggerInformer
+	NewMessageQueueTriggerInformer            = generated.NewMessageQueueTriggerInformer
+	NewPackageInformer                        = generated.NewPackageInformer
+	NewTimeTriggerInformer                    = generated.NewTimeTriggerInformer
+)
@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1 forwards to the package of the same path under pkg/generated,
+// where the generated code of Fission CRDs moved.
+//
+// Deprecated: import github.com/fission/fission/pkg/generated/listers/core/v1 instead.
+package v1
+
+import (
+	generated "github.com/fission/fission/pkg/generated/listers/core/v1"
+)
+
+type (
+	CanaryConfigLister                             = generated.CanaryConfigLister
+	CanaryConfigListerExpansion                    = generated.CanaryConfigListerExpansion
+	CanaryConfigNamespaceLister                    = generated.CanaryConfigNamespaceLister
+	CanaryConfigNamespaceListerExpansion           = generated.CanaryConfigNamespaceListerExpansion
+	EnvironmentLister                              = generated.EnvironmentLister
+	EnvironmentListerExpansion                     = generated.EnvironmentListerExpansion
+	EnvironmentNamespaceLister                     = generated.EnvironmentNamespaceLister
+	EnvironmentNamespaceListerExpansion            = generated.EnvironmentNamespaceListerExpansion
+	FissionConfigLister                            = generated.FissionConfigLister
+	FissionConfigListerExpansion                   = generated.FissionConfigListerExpansion
+	FissionConfigNamespaceLister                   = generated.FissionConfigNamespaceLister
+	FissionConfigNamespaceListerExpansion          = generated.FissionConfigNamespaceListerExpansion
+	FunctionLister                                 = generated.FunctionLister
+	FunctionListerExpansion                        = generated.FunctionListerExpansion
+	FunctionNamespaceLister                        = generated.FunctionNamespaceLister
+	FunctionNamespaceListerExpansion               = generated.FunctionNamespaceListerExpansion
+	HTTPTriggerLister                              = generated.HTTPTriggerLister
+	HTTPTriggerListerExpansion                     = generated.HTTPTriggerListerExpansion
+	HTTPTriggerNamespaceLister                     = generated.HTTPTriggerNamespaceLister
+	HTTPTriggerNamespaceListerExpansion            = generated.HTTPTriggerNamespaceListerExpansion
+	KubernetesWatchTriggerLister                   = generated.KubernetesWatchTriggerLister
+	KubernetesWatchTriggerListerExpansion          = generated.KubernetesWatchTriggerListerExpansion
+	KubernetesWatchTriggerNamespaceLister          = generated.KubernetesWatchTriggerNamespaceLister
+	KubernetesWatchTriggerNamespaceListerExpansion = generated.KubernetesWatchTriggerNamespaceListerExpansion
+	MessageQueueTriggerLister                      = generated.MessageQueueTriggerLister
+	MessageQueueTriggerListerExpansion             = generated.MessageQueueTriggerListerExpansion
+	MessageQueueTriggerNamespaceLister             = generated.MessageQueueTriggerNamespaceLister
+	MessageQueueTriggerNamespaceListerExpansion    = generated.MessageQueueTriggerNamespaceListerExpansion
+	PackageLister                                  = generated.PackageLister
+	PackageListerExpansion                         = generated.PackageListerExpansion
+	PackageNamespaceLister                         = generated.PackageNamespaceLister
+	PackageNamespaceListerExpansion                = generated.PackageNamespaceListerExpansion
+	TimeTriggerLister                              = generated.TimeTriggerLister
+	TimeTriggerListerExpansion                     = generated.TimeTriggerListerExpansion
+	TimeTriggerNamespaceLister                     = generated.TimeTriggerNamespaceLister
+	TimeTriggerNamespaceListerExpansion            = generated.TimeTriggerNamespaceListerExpansion
+)
+
+var (
+	NewCanaryConfigLister           = generated.NewCanaryConfigLister
+	NewEnvironmentLister            = generated.NewEnvironmentLister
+	NewFissionConfigLister          = generated.NewFissionConfigLister
+	NewFunctionLister               = generated.NewFunctionLister
+	NewHTTPTriggerLister            = generated.NewHTTPTriggerLister
+	NewKubernetesWatchTriggerLister = generated.NewKubernetesWatchTriggerLister
+	NewMessageQueueTriggerLister    = generated.NewMessageQueueTriggerLister
+	NewPackageLister                = generated.NewPackageLister
+	NewTimeTriggerLister            = generated.NewTimeTriggerLister
+)
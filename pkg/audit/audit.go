@@ -0,0 +1,160 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package audit records who created, updated or deleted which Fission
+// objects, when, and what changed.
+package audit
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	OperationCreate  Operation = "create"
+	OperationUpdate  Operation = "update"
+	OperationDelete  Operation = "delete"
+	OperationRestore Operation = "restore"
+
+	// UserHeader is the header the CLI reports the local user in. The user
+	// an authenticating proxy sets in RemoteUserHeader takes precedence.
+	UserHeader       = "X-Fission-User"
+	RemoteUserHeader = "X-Remote-User"
+
+	// sinkQueueSize is how many entries may wait to be written to sinks
+	// before entries are dropped, so that slow sinks don't block the API.
+	sinkQueueSize = 1000
+)
+
+type (
+	Operation string
+
+	// Entry is an operation on an object.
+	Entry struct {
+		Time       time.Time `json:"time"`
+		User       string    `json:"user"`
+		RemoteAddr string    `json:"remoteAddr"`
+		UserAgent  string    `json:"userAgent,omitempty"`
+
+		Operation Operation `json:"operation"`
+		Kind      string    `json:"kind"`
+		Namespace string    `json:"namespace"`
+		Name      string    `json:"name"`
+
+		// Status is the HTTP status code of the operation, Error the error
+		// message if it failed.
+		Status int    `json:"status"`
+		Error  string `json:"error,omitempty"`
+
+		Changes []Change `json:"changes,omitempty"`
+	}
+
+	// Filter selects entries, empty fields match all.
+	Filter struct {
+		Kind      string
+		Namespace string
+		Name      string
+		User      string
+		Since     time.Time
+
+		// Limit is the maximum number of entries, the most recent ones are kept.
+		Limit int
+	}
+
+	// Log writes entries to sinks and keeps the most recent ones for queries.
+	Log struct {
+		logger *zap.Logger
+		sinks  []Sink
+		queue  chan Entry
+
+		lock    sync.RWMutex
+		entries []Entry
+		size    int
+	}
+)
+
+// MakeLog returns a log writing entries to the sinks and keeping the last
+// size entries in memory.
+func MakeLog(logger *zap.Logger, sinks []Sink, size int) *Log {
+	l := &Log{
+		logger:  logger.Named("audit"),
+		sinks:   sinks,
+		queue:   make(chan Entry, sinkQueueSize),
+		entries: make([]Entry, 0, size),
+		size:    size,
+	}
+	go l.writeEntries()
+	return l
+}
+
+// Record adds an entry to the log.
+func (l *Log) Record(e Entry) {
+	l.lock.Lock()
+	if l.size > 0 {
+		if len(l.entries) == l.size {
+			copy(l.entries, l.entries[1:])
+			l.entries = l.entries[:l.size-1]
+		}
+		l.entries = append(l.entries, e)
+	}
+	l.lock.Unlock()
+
+	select {
+	case l.queue <- e:
+	default:
+		l.logger.Error("dropping audit log entry, sinks are falling behind",
+			zap.String("operation", string(e.Operation)), zap.String("kind", e.Kind),
+			zap.String("name", e.Name), zap.String("namespace", e.Namespace), zap.String("user", e.User))
+	}
+}
+
+func (l *Log) writeEntries() {
+	for e := range l.queue {
+		for _, sink := range l.sinks {
+			if err := sink.Write(e); err != nil {
+				l.logger.Error("error writing audit log entry", zap.Error(err), zap.String("sink", sink.String()))
+			}
+		}
+	}
+}
+
+// List returns the entries kept in memory that match the filter, oldest first.
+func (l *Log) List(filter Filter) []Entry {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	entries := []Entry{}
+	for _, e := range l.entries {
+		if filter.Matches(&e) {
+			entries = append(entries, e)
+		}
+	}
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+	return entries
+}
+
+// Matches returns true if the entry is selected by the filter.
+func (f *Filter) Matches(e *Entry) bool {
+	return (len(f.Kind) == 0 || f.Kind == e.Kind) &&
+		(len(f.Namespace) == 0 || f.Namespace == e.Namespace) &&
+		(len(f.Name) == 0 || f.Name == e.Name) &&
+		(len(f.User) == 0 || f.User == e.User) &&
+		!e.Time.Before(f.Since)
+}
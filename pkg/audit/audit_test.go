@@ -0,0 +1,119 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestDiff(t *testing.T) {
+	oldObj := []byte(`{"metadata":{"name":"hello","labels":{"app":"hello"},"resourceVersion":"1"},"spec":{"environment":{"name":"nodejs"},"concurrency":500,"secrets":[{"name":"a"}]}}`)
+	newObj := []byte(`{"metadata":{"name":"hello","labels":{"app":"hello","team":"x"},"resourceVersion":"2"},"spec":{"environment":{"name":"go"},"secrets":[{"name":"a"},{"name":"b"}]}}`)
+
+	tests := []struct {
+		name    string
+		oldObj  []byte
+		newObj  []byte
+		changes []Change
+	}{
+		{
+			name:   "update",
+			oldObj: oldObj,
+			newObj: newObj,
+			changes: []Change{
+				{Path: "metadata.labels.team", Old: "", New: `"x"`},
+				{Path: "spec.concurrency", Old: "500", New: ""},
+				{Path: "spec.environment.name", Old: `"nodejs"`, New: `"go"`},
+				{Path: "spec.secrets", Old: `[{"name":"a"}]`, New: `[{"name":"a"},{"name":"b"}]`},
+			},
+		},
+		{
+			name:   "create",
+			newObj: []byte(`{"metadata":{"name":"hello"},"spec":{"concurrency":500}}`),
+			changes: []Change{
+				{Path: "spec", Old: "", New: `{"concurrency":500}`},
+			},
+		},
+		{
+			name:   "delete",
+			oldObj: []byte(`{"metadata":{"name":"hello"},"spec":{"concurrency":500}}`),
+			changes: []Change{
+				{Path: "spec", Old: `{"concurrency":500}`, New: ""},
+			},
+		},
+		{
+			name:   "unchanged",
+			oldObj: oldObj,
+			newObj: oldObj,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changes, err := Diff(test.oldObj, test.newObj, "metadata.labels", "metadata.annotations", "spec")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(changes, test.changes) {
+				t.Errorf("expected changes %+v, got %+v", test.changes, changes)
+			}
+		})
+	}
+}
+
+func TestLogList(t *testing.T) {
+	l := MakeLog(zap.NewNop(), nil, 3)
+	start := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i, name := range []string{"a", "b", "c", "d"} {
+		l.Record(Entry{
+			Time:      start.Add(time.Duration(i) * time.Minute),
+			User:      "alice",
+			Operation: OperationUpdate,
+			Kind:      "function",
+			Namespace: "default",
+			Name:      name,
+		})
+	}
+
+	names := func(entries []Entry) []string {
+		var n []string
+		for _, e := range entries {
+			n = append(n, e.Name)
+		}
+		return n
+	}
+
+	if got := names(l.List(Filter{})); !reflect.DeepEqual(got, []string{"b", "c", "d"}) {
+		t.Errorf("expected the last 3 entries to be kept, got %v", got)
+	}
+	if got := names(l.List(Filter{Limit: 2})); !reflect.DeepEqual(got, []string{"c", "d"}) {
+		t.Errorf("expected the last 2 entries, got %v", got)
+	}
+	if got := names(l.List(Filter{Since: start.Add(2 * time.Minute)})); !reflect.DeepEqual(got, []string{"c", "d"}) {
+		t.Errorf("expected entries since the third minute, got %v", got)
+	}
+	if got := names(l.List(Filter{Name: "c"})); !reflect.DeepEqual(got, []string{"c"}) {
+		t.Errorf("expected entries of c, got %v", got)
+	}
+	if got := l.List(Filter{User: "bob"}); len(got) != 0 {
+		t.Errorf("expected no entries of bob, got %v", got)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// maxValueLength is the length values of changes are truncated to, e.g.
+// literal archives of packages.
+const maxValueLength = 256
+
+// Change is a field with a new value, values are JSON encoded and empty if
+// the field is not set.
+type Change struct {
+	Path string `json:"path"`
+	Old  string `json:"old"`
+	New  string `json:"new"`
+}
+
+// Diff returns the changes between the JSON forms of the old and the new
+// object under the given dot-separated paths. Either object may be nil, e.g.
+// for created and deleted objects.
+func Diff(oldObj, newObj []byte, paths ...string) ([]Change, error) {
+	oldMap, err := toMap(oldObj)
+	if err != nil {
+		return nil, err
+	}
+	newMap, err := toMap(newObj)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, path := range paths {
+		changes = diffValues(changes, path, fieldValue(oldMap, path), fieldValue(newMap, path))
+	}
+	return changes, nil
+}
+
+func diffValues(changes []Change, path string, oldValue, newValue interface{}) []Change {
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := map[string]bool{}
+		for k := range oldMap {
+			keys[k] = true
+		}
+		for k := range newMap {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			changes = diffValues(changes, path+"."+k, oldMap[k], newMap[k])
+		}
+		return changes
+	}
+
+	oldList, oldIsList := oldValue.([]interface{})
+	newList, newIsList := newValue.([]interface{})
+	if oldIsList && newIsList && len(oldList) == len(newList) {
+		for i := range oldList {
+			changes = diffValues(changes, fmt.Sprintf("%v[%v]", path, i), oldList[i], newList[i])
+		}
+		return changes
+	}
+
+	if reflect.DeepEqual(oldValue, newValue) {
+		return changes
+	}
+	return append(changes, Change{
+		Path: path,
+		Old:  encode(oldValue),
+		New:  encode(newValue),
+	})
+}
+
+func toMap(obj []byte) (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	if len(obj) == 0 {
+		return m, nil
+	}
+	err := json.Unmarshal(obj, &m)
+	return m, err
+}
+
+func fieldValue(obj map[string]interface{}, path string) interface{} {
+	var value interface{} = obj
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
+
+func encode(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	if len(raw) > maxValueLength {
+		return string(raw[:maxValueLength]) + "..."
+	}
+	return string(raw)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const webhookTimeout = 10 * time.Second
+
+type (
+	// Sink stores audit log entries.
+	Sink interface {
+		Write(e Entry) error
+		String() string
+	}
+
+	// writerSink writes entries as JSON lines, e.g. to stdout or a file.
+	writerSink struct {
+		name   string
+		lock   sync.Mutex
+		writer io.Writer
+	}
+
+	// webhookSink posts entries as JSON to a URL.
+	webhookSink struct {
+		url    string
+		client *http.Client
+	}
+)
+
+// ParseSinks returns the sinks of a comma-separated list, in which "stdout"
+// is standard output, "file:///path" a file entries are appended to and an
+// http(s) URL a webhook entries are posted to.
+func ParseSinks(spec string) ([]Sink, error) {
+	var sinks []Sink
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		switch {
+		case len(s) == 0:
+			continue
+
+		case s == "stdout":
+			sinks = append(sinks, &writerSink{name: s, writer: os.Stdout})
+
+		case strings.HasPrefix(s, "file://"):
+			path := strings.TrimPrefix(s, "file://")
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error opening audit log file %v", path)
+			}
+			sinks = append(sinks, &writerSink{name: s, writer: f})
+
+		case strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://"):
+			sinks = append(sinks, &webhookSink{url: s, client: &http.Client{Timeout: webhookTimeout}})
+
+		default:
+			return nil, errors.Errorf("unknown audit log sink %q, expected stdout, file:///path or an http(s) URL", s)
+		}
+	}
+	return sinks, nil
+}
+
+func (s *writerSink) Write(e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	_, err = s.writer.Write(line)
+	return err
+}
+
+func (s *writerSink) String() string {
+	return s.name
+}
+
+func (s *webhookSink) Write(e Entry) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("webhook responded with status %v", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *webhookSink) String() string {
+	return s.url
+}
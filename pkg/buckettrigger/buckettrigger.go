@@ -0,0 +1,267 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package buckettrigger invokes the functions of BucketTriggers on the
+// event notifications of object storage buckets. Buckets send
+// notifications to /buckets/<namespace>/<name> of the bucket trigger
+// manager, where name is the trigger: S3 buckets through an SNS topic with
+// an HTTPS subscription, MinIO buckets through a webhook target, and GCS
+// buckets through a Pub/Sub push subscription. The function is invoked
+// through router with the metadata of each object created or deleted that
+// the trigger matches.
+package buckettrigger
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+	k8sCache "k8s.io/client-go/tools/cache"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/publisher"
+	"github.com/fission/fission/pkg/utils"
+)
+
+const (
+	// maxNotificationSize bounds the size of notifications, Pub/Sub
+	// messages are up to 10MB.
+	maxNotificationSize = 10 << 20
+
+	// tokenKey is the key of the token in the secrets of triggers.
+	tokenKey = "token"
+
+	resyncPeriod = 30 * time.Second
+)
+
+type (
+	// Manager receives the notifications of buckets and invokes the
+	// functions of the triggers they are sent to.
+	Manager struct {
+		logger     *zap.Logger
+		kubeClient kubernetes.Interface
+		triggers   k8sCache.Store
+		publisher  publisher.Publisher
+
+		// httpClient confirms SNS subscriptions.
+		httpClient *http.Client
+	}
+)
+
+// Start listens to notifications of buckets on port, invoking functions
+// through router at routerUrl.
+func Start(logger *zap.Logger, port int, routerUrl string) error {
+	fissionClient, kubeClient, _, _, err := crd.MakeFissionClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to get fission or kubernetes client")
+	}
+
+	err = fissionClient.WaitForCRDs()
+	if err != nil {
+		return errors.Wrap(err, "error waiting for CRDs")
+	}
+
+	lw := k8sCache.NewListWatchFromClient(fissionClient.CoreV1().RESTClient(), "buckettriggers", metav1.NamespaceAll, fields.Everything())
+	store, controller := k8sCache.NewInformer(lw, &fv1.BucketTrigger{}, resyncPeriod, k8sCache.ResourceEventHandlerFuncs{})
+	go controller.Run(make(chan struct{}))
+
+	m := MakeManager(logger, kubeClient, store, publisher.MakeWebhookPublisher(logger, routerUrl))
+	logger.Info("starting bucket trigger manager", zap.Int("port", port))
+	go func() {
+		err := http.ListenAndServe(fmt.Sprintf(":%v", port), m.GetHandler())
+		logger.Fatal("done listening", zap.Error(err))
+	}()
+	return nil
+}
+
+// MakeManager returns a manager of the triggers in store.
+func MakeManager(logger *zap.Logger, kubeClient kubernetes.Interface, triggers k8sCache.Store, publisher publisher.Publisher) *Manager {
+	return &Manager{
+		logger:     logger.Named("bucket_trigger_manager"),
+		kubeClient: kubeClient,
+		triggers:   triggers,
+		publisher:  publisher,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// GetHandler returns the HTTP handler of notifications.
+func (m *Manager) GetHandler() http.Handler {
+	r := mux.NewRouter()
+	r.HandleFunc("/buckets/{namespace}/{name}", m.notificationHandler).Methods("POST")
+	r.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods("GET")
+	return r
+}
+
+func (m *Manager) notificationHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace, name := vars["namespace"], vars["name"]
+	logger := m.logger.With(zap.String("trigger", name), zap.String("namespace", namespace))
+
+	obj, exists, err := m.triggers.GetByKey(fmt.Sprintf("%v/%v", namespace, name))
+	if err != nil || !exists {
+		http.Error(w, "bucket trigger not found", http.StatusNotFound)
+		return
+	}
+	trigger := obj.(*fv1.BucketTrigger)
+
+	err = m.authorize(r, trigger)
+	if err != nil {
+		logger.Info("rejected unauthorized notification", zap.Error(err))
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxNotificationSize))
+	if err != nil {
+		http.Error(w, "error reading notification", http.StatusBadRequest)
+		return
+	}
+
+	// SNS asks HTTP subscriptions to confirm they want its messages
+	if trigger.Spec.Provider == fv1.BucketProviderS3 && r.Header.Get(snsMessageTypeHeader) == snsSubscriptionConfirmation {
+		err = m.confirmSubscription(r.Context(), body)
+		if err != nil {
+			logger.Error("error confirming sns subscription", zap.Error(err))
+			http.Error(w, "error confirming subscription", http.StatusBadRequest)
+			return
+		}
+		logger.Info("confirmed sns subscription")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	events, err := parseNotification(trigger.Spec.Provider, r.Header, body)
+	if err != nil {
+		logger.Info("error parsing notification", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for i := range events {
+		if matches(&trigger.Spec, &events[i]) {
+			m.invoke(trigger, &events[i])
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// authorize checks a notification carries the token of the secret of a
+// trigger, as bearer token or token query parameter, if it has one.
+func (m *Manager) authorize(r *http.Request, trigger *fv1.BucketTrigger) error {
+	if len(trigger.Spec.Secret) == 0 {
+		return nil
+	}
+
+	secret, err := m.kubeClient.CoreV1().Secrets(trigger.ObjectMeta.Namespace).Get(trigger.Spec.Secret, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return errors.Errorf("secret %q not found", trigger.Spec.Secret)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "error getting secret %q", trigger.Spec.Secret)
+	}
+	expected := secret.Data[tokenKey]
+	if len(expected) == 0 {
+		return errors.Errorf("secret %q has no %q key", trigger.Spec.Secret, tokenKey)
+	}
+
+	token := r.URL.Query().Get(tokenKey)
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), expected) != 1 {
+		return errors.New("notification token doesn't match")
+	}
+	return nil
+}
+
+// confirmSubscription confirms the SNS subscription of a subscription
+// confirmation message, by visiting its subscribe URL.
+func (m *Manager) confirmSubscription(ctx context.Context, body []byte) error {
+	var msg snsMessage
+	err := json.Unmarshal(body, &msg)
+	if err != nil {
+		return errors.Wrap(err, "error decoding sns message")
+	}
+
+	// only SNS is asked, so that notifications can't make requests
+	// anywhere else
+	u, err := url.Parse(msg.SubscribeURL)
+	if err != nil {
+		return errors.Wrap(err, "error parsing subscribe url")
+	}
+	if u.Scheme != "https" || !strings.HasPrefix(u.Hostname(), "sns.") || !strings.HasSuffix(u.Hostname(), ".amazonaws.com") {
+		return errors.Errorf("subscribe url %q is not of sns", msg.SubscribeURL)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := m.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, "error visiting subscribe url")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("subscribe url returned status %v", resp.StatusCode)
+	}
+	return nil
+}
+
+// invoke invokes the function of a trigger with an event.
+func (m *Manager) invoke(trigger *fv1.BucketTrigger, e *Event) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		m.logger.Error("error encoding bucket event", zap.Error(err))
+		return
+	}
+
+	eventTime := e.Time
+	if eventTime.IsZero() {
+		eventTime = time.Now()
+	}
+	headers := map[string]string{
+		"Content-Type":                "application/json",
+		"X-Fission-Bucket-Name":       e.Bucket,
+		"X-Fission-Bucket-Key":        e.Key,
+		"X-Fission-Bucket-Event-Type": string(e.Type),
+		fv1.HeaderTriggerType:         fv1.TriggerTypeBucket,
+		fv1.HeaderTriggerName:         trigger.ObjectMeta.Name,
+		fv1.HeaderCloudEventsMode:     string(trigger.Spec.CloudEvents),
+		fv1.HeaderEventTime:           eventTime.UTC().Format(time.RFC3339Nano),
+	}
+
+	// triggers can only be created in the same namespace as the function
+	url := utils.UrlForFunction(trigger.Spec.FunctionReference.Name, trigger.ObjectMeta.Namespace)
+	m.publisher.Publish(string(body), headers, url)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buckettrigger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8sCache "k8s.io/client-go/tools/cache"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+type (
+	publishedRequest struct {
+		body    string
+		headers map[string]string
+		target  string
+	}
+
+	fakePublisher struct {
+		requests []publishedRequest
+	}
+)
+
+func (p *fakePublisher) Publish(body string, headers map[string]string, target string) {
+	p.requests = append(p.requests, publishedRequest{body, headers, target})
+}
+
+func TestNotificationHandler(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(&apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "minio-token", Namespace: "default"},
+		Data:       map[string][]byte{tokenKey: []byte("s3cr3t")},
+	})
+	store := k8sCache.NewStore(k8sCache.MetaNamespaceKeyFunc)
+	err := store.Add(&fv1.BucketTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "photos", Namespace: "default"},
+		Spec: fv1.BucketTriggerSpec{
+			Provider: fv1.BucketProviderMinIO,
+			Bucket:   "photos",
+			Events:   []fv1.BucketEventType{fv1.BucketEventCreated},
+			Secret:   "minio-token",
+			FunctionReference: fv1.FunctionReference{
+				Type: fv1.FunctionReferenceTypeFunctionName,
+				Name: "thumbnail",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &fakePublisher{}
+	handler := MakeManager(zap.NewNop(), kubeClient, store, p).GetHandler()
+
+	for _, test := range []struct {
+		name   string
+		path   string
+		token  string
+		status int
+	}{
+		{"unknown trigger", "/buckets/default/videos", "s3cr3t", http.StatusNotFound},
+		{"missing token", "/buckets/default/photos", "", http.StatusUnauthorized},
+		{"wrong token", "/buckets/default/photos", "guess", http.StatusUnauthorized},
+		{"valid token", "/buckets/default/photos", "s3cr3t", http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodPost, test.path, strings.NewReader(s3Records))
+		if len(test.token) > 0 {
+			req.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != test.status {
+			t.Errorf("%v: expected status %v, got %v", test.name, test.status, w.Code)
+		}
+	}
+
+	// only the created event of the notification matches
+	if len(p.requests) != 1 {
+		t.Fatalf("expected 1 invocation, got %v", len(p.requests))
+	}
+	r := p.requests[0]
+	if r.target != "/fission-function/thumbnail" {
+		t.Errorf("unexpected target %v", r.target)
+	}
+	if r.headers["X-Fission-Bucket-Key"] != "uploads/my cat.jpg" ||
+		r.headers[fv1.HeaderTriggerType] != fv1.TriggerTypeBucket ||
+		r.headers[fv1.HeaderTriggerName] != "photos" {
+		t.Errorf("unexpected headers %v", r.headers)
+	}
+}
+
+func TestConfirmSubscriptionRejectsOtherHosts(t *testing.T) {
+	m := MakeManager(zap.NewNop(), fake.NewSimpleClientset(), k8sCache.NewStore(k8sCache.MetaNamespaceKeyFunc), &fakePublisher{})
+	for _, u := range []string{
+		"http://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription",
+		"https://169.254.169.254/latest/meta-data",
+		"https://sns.us-east-1.amazonaws.com.example.com/",
+	} {
+		body := `{"Type": "SubscriptionConfirmation", "SubscribeURL": "` + u + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		err := m.confirmSubscription(req.Context(), []byte(body))
+		if err == nil {
+			t.Errorf("expected subscribe url %v to be rejected", u)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buckettrigger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	// snsMessageTypeHeader is the type of the messages SNS sends to HTTP
+	// subscriptions.
+	snsMessageTypeHeader = "X-Amz-Sns-Message-Type"
+
+	snsSubscriptionConfirmation = "SubscriptionConfirmation"
+	snsNotification             = "Notification"
+)
+
+type (
+	// Event is an object created in or deleted from a bucket, which the
+	// functions of bucket triggers are invoked with as JSON.
+	Event struct {
+		Type fv1.BucketEventType `json:"type"`
+
+		// Name is the name the provider gives the event, like
+		// "ObjectCreated:Put" or "OBJECT_FINALIZE".
+		Name string `json:"name"`
+
+		Provider    fv1.BucketProvider `json:"provider"`
+		Bucket      string             `json:"bucket"`
+		Key         string             `json:"key"`
+		Size        int64              `json:"size,omitempty"`
+		ETag        string             `json:"etag,omitempty"`
+		ContentType string             `json:"contentType,omitempty"`
+		VersionID   string             `json:"versionId,omitempty"`
+		Time        time.Time          `json:"time"`
+	}
+
+	// s3Notification is the S3 event notification format, which MinIO
+	// sends as well.
+	s3Notification struct {
+		Records []s3Record `json:"Records"`
+	}
+
+	s3Record struct {
+		EventName string    `json:"eventName"`
+		EventTime time.Time `json:"eventTime"`
+		S3        struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key         string `json:"key"`
+				Size        int64  `json:"size"`
+				ETag        string `json:"eTag"`
+				ContentType string `json:"contentType"`
+				VersionID   string `json:"versionId"`
+			} `json:"object"`
+		} `json:"s3"`
+	}
+
+	// snsMessage is a message of an SNS topic sent to an HTTP subscription.
+	snsMessage struct {
+		Type         string `json:"Type"`
+		MessageID    string `json:"MessageId"`
+		TopicArn     string `json:"TopicArn"`
+		Message      string `json:"Message"`
+		SubscribeURL string `json:"SubscribeURL"`
+	}
+
+	// pubsubPush is a message of a Pub/Sub push subscription, which GCS
+	// notifications are published as with the object as data.
+	pubsubPush struct {
+		Message struct {
+			Attributes  map[string]string `json:"attributes"`
+			Data        []byte            `json:"data"`
+			MessageID   string            `json:"messageId"`
+			PublishTime time.Time         `json:"publishTime"`
+		} `json:"message"`
+		Subscription string `json:"subscription"`
+	}
+
+	gcsObject struct {
+		Size        string `json:"size"`
+		ContentType string `json:"contentType"`
+		ETag        string `json:"etag"`
+	}
+)
+
+// parseNotification returns the events of a notification of a provider.
+// Notifications of other changes than objects created or deleted, such as
+// the test events S3 sends when notifications are set up, have none.
+func parseNotification(provider fv1.BucketProvider, header http.Header, body []byte) ([]Event, error) {
+	switch provider {
+	case fv1.BucketProviderS3:
+		// notifications come through SNS unless sent with raw message
+		// delivery
+		if header.Get(snsMessageTypeHeader) == snsNotification {
+			var msg snsMessage
+			err := json.Unmarshal(body, &msg)
+			if err != nil {
+				return nil, errors.Wrap(err, "error decoding sns message")
+			}
+			body = []byte(msg.Message)
+		}
+		return parseS3Notification(provider, body)
+	case fv1.BucketProviderMinIO:
+		return parseS3Notification(provider, body)
+	case fv1.BucketProviderGCS:
+		return parseGCSNotification(body)
+	}
+	return nil, errors.Errorf("unsupported bucket provider %q", provider)
+}
+
+func parseS3Notification(provider fv1.BucketProvider, body []byte) ([]Event, error) {
+	var n s3Notification
+	err := json.Unmarshal(body, &n)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding s3 notification")
+	}
+
+	var events []Event
+	for _, r := range n.Records {
+		var eventType fv1.BucketEventType
+		switch {
+		case strings.Contains(r.EventName, "ObjectCreated:"):
+			eventType = fv1.BucketEventCreated
+		case strings.Contains(r.EventName, "ObjectRemoved:"):
+			eventType = fv1.BucketEventDeleted
+		default:
+			continue
+		}
+
+		// keys are URL encoded, with spaces as '+'
+		key, err := url.QueryUnescape(r.S3.Object.Key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding object key %q", r.S3.Object.Key)
+		}
+
+		events = append(events, Event{
+			Type:        eventType,
+			Name:        r.EventName,
+			Provider:    provider,
+			Bucket:      r.S3.Bucket.Name,
+			Key:         key,
+			Size:        r.S3.Object.Size,
+			ETag:        r.S3.Object.ETag,
+			ContentType: r.S3.Object.ContentType,
+			VersionID:   r.S3.Object.VersionID,
+			Time:        r.EventTime,
+		})
+	}
+	return events, nil
+}
+
+func parseGCSNotification(body []byte) ([]Event, error) {
+	var push pubsubPush
+	err := json.Unmarshal(body, &push)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding pubsub message")
+	}
+
+	attrs := push.Message.Attributes
+	var eventType fv1.BucketEventType
+	switch attrs["eventType"] {
+	case "OBJECT_FINALIZE":
+		eventType = fv1.BucketEventCreated
+	case "OBJECT_DELETE":
+		eventType = fv1.BucketEventDeleted
+	default:
+		return nil, nil
+	}
+
+	e := Event{
+		Type:      eventType,
+		Name:      attrs["eventType"],
+		Provider:  fv1.BucketProviderGCS,
+		Bucket:    attrs["bucketId"],
+		Key:       attrs["objectId"],
+		VersionID: attrs["objectGeneration"],
+		Time:      push.Message.PublishTime,
+	}
+	if t, err := time.Parse(time.RFC3339Nano, attrs["eventTime"]); err == nil {
+		e.Time = t
+	}
+
+	// the object is the data unless the notifications are set up without
+	// payload
+	if len(push.Message.Data) > 0 {
+		var obj gcsObject
+		err = json.Unmarshal(push.Message.Data, &obj)
+		if err != nil {
+			return nil, errors.Wrap(err, "error decoding gcs object")
+		}
+		e.Size, _ = strconv.ParseInt(obj.Size, 10, 64)
+		e.ContentType = obj.ContentType
+		e.ETag = obj.ETag
+	}
+	return []Event{e}, nil
+}
+
+// matches returns whether a trigger invokes its function on an event.
+func matches(spec *fv1.BucketTriggerSpec, e *Event) bool {
+	if e.Bucket != spec.Bucket {
+		return false
+	}
+	if !strings.HasPrefix(e.Key, spec.Prefix) || !strings.HasSuffix(e.Key, spec.Suffix) {
+		return false
+	}
+	if len(spec.Events) == 0 {
+		return true
+	}
+	for _, t := range spec.Events {
+		if t == e.Type {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buckettrigger
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const s3Records = `{"Records": [
+	{"eventName": "ObjectCreated:Put", "eventTime": "2021-03-01T10:00:00.000Z",
+	 "s3": {"bucket": {"name": "photos"}, "object": {"key": "uploads/my+cat.jpg", "size": 1024, "eTag": "abc"}}},
+	{"eventName": "ObjectRemoved:Delete", "eventTime": "2021-03-01T10:00:01.000Z",
+	 "s3": {"bucket": {"name": "photos"}, "object": {"key": "uploads/old.jpg"}}},
+	{"eventName": "ObjectRestore:Post", "eventTime": "2021-03-01T10:00:02.000Z",
+	 "s3": {"bucket": {"name": "photos"}, "object": {"key": "uploads/archived.jpg"}}}
+]}`
+
+func TestParseS3Notification(t *testing.T) {
+	sns, err := json.Marshal(map[string]string{"Type": snsNotification, "Message": s3Records})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snsHeader := http.Header{}
+	snsHeader.Set(snsMessageTypeHeader, snsNotification)
+
+	for _, test := range []struct {
+		name     string
+		provider fv1.BucketProvider
+		header   http.Header
+		body     string
+	}{
+		{"sns", fv1.BucketProviderS3, snsHeader, string(sns)},
+		{"raw", fv1.BucketProviderS3, http.Header{}, s3Records},
+		{"minio", fv1.BucketProviderMinIO, http.Header{}, s3Records},
+	} {
+		events, err := parseNotification(test.provider, test.header, []byte(test.body))
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		if len(events) != 2 {
+			t.Fatalf("%v: expected 2 events, got %v", test.name, len(events))
+		}
+		if events[0].Type != fv1.BucketEventCreated || events[0].Key != "uploads/my cat.jpg" ||
+			events[0].Bucket != "photos" || events[0].Size != 1024 || events[0].Provider != test.provider {
+			t.Errorf("%v: unexpected created event %+v", test.name, events[0])
+		}
+		if events[1].Type != fv1.BucketEventDeleted || events[1].Key != "uploads/old.jpg" {
+			t.Errorf("%v: unexpected deleted event %+v", test.name, events[1])
+		}
+	}
+
+	_, err = parseNotification(fv1.BucketProviderS3, http.Header{}, []byte("not json"))
+	if err == nil {
+		t.Error("expected error parsing malformed notification")
+	}
+}
+
+func TestParseGCSNotification(t *testing.T) {
+	data := base64.StdEncoding.EncodeToString([]byte(`{"size": "2048", "contentType": "image/png", "etag": "xyz"}`))
+	body := `{"message": {"attributes": {"eventType": "OBJECT_FINALIZE", "bucketId": "photos",
+		"objectId": "uploads/dog.png", "objectGeneration": "1", "eventTime": "2021-03-01T10:00:00.000Z"},
+		"data": "` + data + `", "messageId": "1", "publishTime": "2021-03-01T10:00:01.000Z"},
+		"subscription": "projects/p/subscriptions/s"}`
+
+	events, err := parseNotification(fv1.BucketProviderGCS, http.Header{}, []byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %v", len(events))
+	}
+	e := events[0]
+	if e.Type != fv1.BucketEventCreated || e.Bucket != "photos" || e.Key != "uploads/dog.png" ||
+		e.Size != 2048 || e.ContentType != "image/png" || e.Time.Second() != 0 {
+		t.Errorf("unexpected event %+v", e)
+	}
+
+	events, err = parseNotification(fv1.BucketProviderGCS, http.Header{},
+		[]byte(`{"message": {"attributes": {"eventType": "OBJECT_METADATA_UPDATE"}}}`))
+	if err != nil || len(events) != 0 {
+		t.Errorf("expected no events of metadata updates, got %v, %v", events, err)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	spec := &fv1.BucketTriggerSpec{
+		Bucket: "photos",
+		Events: []fv1.BucketEventType{fv1.BucketEventCreated},
+		Prefix: "uploads/",
+		Suffix: ".jpg",
+	}
+	for _, test := range []struct {
+		event   Event
+		matches bool
+	}{
+		{Event{Type: fv1.BucketEventCreated, Bucket: "photos", Key: "uploads/cat.jpg"}, true},
+		{Event{Type: fv1.BucketEventDeleted, Bucket: "photos", Key: "uploads/cat.jpg"}, false},
+		{Event{Type: fv1.BucketEventCreated, Bucket: "videos", Key: "uploads/cat.jpg"}, false},
+		{Event{Type: fv1.BucketEventCreated, Bucket: "photos", Key: "thumbs/cat.jpg"}, false},
+		{Event{Type: fv1.BucketEventCreated, Bucket: "photos", Key: "uploads/cat.png"}, false},
+	} {
+		if matches(spec, &test.event) != test.matches {
+			t.Errorf("expected %+v to match %v", test.event, test.matches)
+		}
+	}
+
+	all := &fv1.BucketTriggerSpec{Bucket: "photos"}
+	if !matches(all, &Event{Type: fv1.BucketEventDeleted, Bucket: "photos", Key: "a"}) {
+		t.Error("expected triggers without events to match all events")
+	}
+}
@@ -0,0 +1,328 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package buildcache remembers the deploy archives of package builds, so
+// that a package with the same source built by the same builder reuses the
+// deploy archive instead of being built again. Entries are kept in config
+// maps in the namespace of the packages, and their archives are kept by the
+// archive pruner of the storage service until they expire.
+package buildcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	// LabelBuildCache marks the config maps cache entries are stored in.
+	LabelBuildCache = "fission.io/buildcache"
+
+	// DefaultTTL is how long entries are kept after their last use.
+	DefaultTTL = 7 * 24 * time.Hour
+
+	// pruneInterval is how often expired entries are deleted.
+	pruneInterval = time.Hour
+
+	dataKey = "buildcache.json"
+)
+
+type (
+	// Entry is the deploy archive a build of a package produced.
+	Entry struct {
+		Key string `json:"key"`
+
+		// Package is the package the archive was built for, as
+		// namespace/name, and ResourceVersion its version.
+		Package         string `json:"package"`
+		ResourceVersion string `json:"resourceVersion"`
+
+		Deployment fv1.Archive `json:"deployment"`
+		SourceMap  fv1.Archive `json:"sourceMap,omitempty"`
+
+		BuiltAt   time.Time `json:"builtAt"`
+		ExpiresAt time.Time `json:"expiresAt"`
+	}
+
+	// Store stores cache entries in config maps. A nil store caches nothing.
+	Store struct {
+		logger           *zap.Logger
+		kubernetesClient kubernetes.Interface
+		ttl              time.Duration
+	}
+
+	// builderKey is what the deploy archive of a build depends on.
+	builderKey struct {
+		Source       fv1.Checksum    `json:"source"`
+		EnvNamespace string          `json:"envNamespace"`
+		EnvName      string          `json:"envName"`
+		Builder      fv1.Builder     `json:"builder"`
+		BuildCommand string          `json:"buildCommand"`
+		BuildSteps   []fv1.BuildStep `json:"buildSteps,omitempty"`
+		KeepArchive  bool            `json:"keepArchive"`
+	}
+)
+
+// MakeStore returns a store keeping entries for ttl after their last use, or
+// nil if ttl isn't positive.
+func MakeStore(logger *zap.Logger, kubernetesClient kubernetes.Interface, ttl time.Duration) *Store {
+	if ttl <= 0 {
+		return nil
+	}
+	return &Store{
+		logger:           logger.Named("build_cache"),
+		kubernetesClient: kubernetesClient,
+		ttl:              ttl,
+	}
+}
+
+// Key returns the cache key of the build of a package by the builder of an
+// environment. Packages are cached only if the checksum of their source is
+// known, that is for literal sources, or URL sources with a checksum once
+// fetched is true. The checksum of a URL source is only what the package
+// claims until the fetcher has verified the source fetched against it.
+func Key(pkg *fv1.Package, env *fv1.Environment, fetched bool) (string, bool) {
+	var source fv1.Checksum
+	if fetched {
+		source = pkg.Spec.Source.Checksum
+	}
+	if len(pkg.Spec.Source.Literal) > 0 {
+		sum := sha256.Sum256(pkg.Spec.Source.Literal)
+		source = fv1.Checksum{
+			Type: fv1.ChecksumTypeSHA256,
+			Sum:  hex.EncodeToString(sum[:]),
+		}
+	}
+	if len(source.Sum) == 0 {
+		return "", false
+	}
+
+	buildCmd := pkg.Spec.BuildCommand
+	if len(buildCmd) == 0 {
+		buildCmd = env.Spec.Builder.Command
+	}
+	data, err := json.Marshal(builderKey{
+		Source:       source,
+		EnvNamespace: env.ObjectMeta.Namespace,
+		EnvName:      env.ObjectMeta.Name,
+		Builder:      env.Spec.Builder,
+		BuildCommand: buildCmd,
+		BuildSteps:   pkg.Spec.BuildSteps,
+		KeepArchive:  env.Spec.KeepArchive,
+	})
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), true
+}
+
+func configMapName(key string) string {
+	return "buildcache-" + key
+}
+
+// Lookup returns the cached deploy archive for the build of a package, or nil
+// if there is none. Entries found are kept for another TTL. See Key for
+// fetched.
+func (s *Store) Lookup(pkg *fv1.Package, env *fv1.Environment, fetched bool) (*Entry, error) {
+	if s == nil {
+		return nil, nil
+	}
+	key, ok := Key(pkg, env, fetched)
+	if !ok {
+		return nil, nil
+	}
+
+	namespace := pkg.ObjectMeta.Namespace
+	cm, err := s.kubernetesClient.CoreV1().ConfigMaps(namespace).Get(configMapName(key), metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "error getting build cache entry")
+	}
+	e, err := FromConfigMap(cm)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if e.IsExpired(now) || e.Key != key {
+		return nil, nil
+	}
+
+	e.ExpiresAt = now.Add(s.ttl).UTC()
+	updated, err := e.configMap(namespace)
+	if err != nil {
+		return nil, err
+	}
+	updated.ObjectMeta.ResourceVersion = cm.ObjectMeta.ResourceVersion
+	_, err = s.kubernetesClient.CoreV1().ConfigMaps(namespace).Update(updated)
+	if err != nil {
+		// the entry is still good, it just expires sooner
+		s.logger.Info("error extending build cache entry", zap.Error(err), zap.String("key", key))
+	}
+	return e, nil
+}
+
+// Put caches the deploy archive of the build of a package, and its source
+// map manifest if any. The source of the package was fetched, and so
+// verified, by the build.
+func (s *Store) Put(pkg *fv1.Package, env *fv1.Environment, deployment fv1.Archive, sourceMap fv1.Archive) error {
+	if s == nil {
+		return nil
+	}
+	key, ok := Key(pkg, env, true)
+	if !ok || len(deployment.URL) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	e := &Entry{
+		Key:             key,
+		Package:         pkg.ObjectMeta.Namespace + "/" + pkg.ObjectMeta.Name,
+		ResourceVersion: pkg.ObjectMeta.ResourceVersion,
+		Deployment:      deployment,
+		SourceMap:       sourceMap,
+		BuiltAt:         now,
+		ExpiresAt:       now.Add(s.ttl),
+	}
+	cm, err := e.configMap(pkg.ObjectMeta.Namespace)
+	if err != nil {
+		return err
+	}
+
+	client := s.kubernetesClient.CoreV1().ConfigMaps(pkg.ObjectMeta.Namespace)
+	_, err = client.Create(cm)
+	if kerrors.IsAlreadyExists(err) {
+		// an identical build raced this one, or the entry expired
+		var existing *apiv1.ConfigMap
+		existing, err = client.Get(cm.ObjectMeta.Name, metav1.GetOptions{})
+		if err == nil {
+			cm.ObjectMeta.ResourceVersion = existing.ObjectMeta.ResourceVersion
+			_, err = client.Update(cm)
+		}
+	}
+	if err != nil {
+		return errors.Wrap(err, "error storing build cache entry")
+	}
+	return nil
+}
+
+// List returns the entries of all namespaces, expired or not.
+func List(kubernetesClient kubernetes.Interface) ([]Entry, error) {
+	cms, err := kubernetesClient.CoreV1().ConfigMaps(metav1.NamespaceAll).List(metav1.ListOptions{
+		LabelSelector: LabelBuildCache + "=true",
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing build cache entries")
+	}
+	entries := make([]Entry, 0, len(cms.Items))
+	for i := range cms.Items {
+		e, err := FromConfigMap(&cms.Items[i])
+		if err != nil {
+			continue
+		}
+		entries = append(entries, *e)
+	}
+	return entries, nil
+}
+
+// Run deletes expired entries until stopCh is closed.
+func (s *Store) Run(stopCh <-chan struct{}) {
+	if s == nil {
+		return
+	}
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		s.deleteExpired()
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Store) deleteExpired() {
+	cms, err := s.kubernetesClient.CoreV1().ConfigMaps(metav1.NamespaceAll).List(metav1.ListOptions{
+		LabelSelector: LabelBuildCache + "=true",
+	})
+	if err != nil {
+		s.logger.Error("error listing build cache entries", zap.Error(err))
+		return
+	}
+	now := time.Now()
+	for i := range cms.Items {
+		cm := &cms.Items[i]
+		e, err := FromConfigMap(cm)
+		if err == nil && !e.IsExpired(now) {
+			continue
+		}
+		err = s.kubernetesClient.CoreV1().ConfigMaps(cm.ObjectMeta.Namespace).Delete(cm.ObjectMeta.Name, &metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			s.logger.Error("error deleting expired build cache entry", zap.Error(err),
+				zap.String("config_map", cm.ObjectMeta.Name), zap.String("namespace", cm.ObjectMeta.Namespace))
+		}
+	}
+}
+
+// IsExpired returns true if the entry wasn't used for its TTL.
+func (e *Entry) IsExpired(now time.Time) bool {
+	return now.After(e.ExpiresAt)
+}
+
+func (e *Entry) configMap(namespace string) (*apiv1.ConfigMap, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding build cache entry")
+	}
+	return &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configMapName(e.Key),
+			Namespace: namespace,
+			Labels: map[string]string{
+				LabelBuildCache: "true",
+			},
+		},
+		Data: map[string]string{
+			dataKey: string(data),
+		},
+	}, nil
+}
+
+// FromConfigMap returns the entry stored in a config map.
+func FromConfigMap(cm *apiv1.ConfigMap) (*Entry, error) {
+	data, ok := cm.Data[dataKey]
+	if !ok {
+		return nil, errors.Errorf("config map %v/%v has no build cache entry", cm.ObjectMeta.Namespace, cm.ObjectMeta.Name)
+	}
+	e := &Entry{}
+	err := json.Unmarshal([]byte(data), e)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error decoding build cache entry in config map %v/%v", cm.ObjectMeta.Namespace, cm.ObjectMeta.Name)
+	}
+	return e, nil
+}
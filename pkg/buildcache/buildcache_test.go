@@ -0,0 +1,188 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildcache
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func makePackage(name string, sum string) *fv1.Package {
+	return &fv1.Package{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       metav1.NamespaceDefault,
+			ResourceVersion: "100",
+		},
+		Spec: fv1.PackageSpec{
+			Source: fv1.Archive{
+				Type:     fv1.ArchiveTypeUrl,
+				URL:      "http://storagesvc.fission/v1/archive?id=src-" + name,
+				Checksum: fv1.Checksum{Type: fv1.ChecksumTypeSHA256, Sum: sum},
+			},
+		},
+	}
+}
+
+func TestKey(t *testing.T) {
+	env := &fv1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "node", Namespace: metav1.NamespaceDefault},
+		Spec: fv1.EnvironmentSpec{
+			Builder: fv1.Builder{Image: "fission/node-builder:1.10.0", Command: "build"},
+		},
+	}
+
+	key, ok := Key(makePackage("a", "abc"), env, true)
+	if !ok {
+		t.Fatal("expected package with source checksum to be cached")
+	}
+	// the key doesn't depend on the package
+	if other, _ := Key(makePackage("b", "abc"), env, true); other != key {
+		t.Fatal("expected packages with the same source to have the same key")
+	}
+	if other, _ := Key(makePackage("a", "def"), env, true); other == key {
+		t.Fatal("expected packages with other sources to have other keys")
+	}
+
+	pkg := makePackage("a", "abc")
+	pkg.Spec.BuildCommand = "build --prod"
+	if other, _ := Key(pkg, env, true); other == key {
+		t.Fatal("expected other build command to change the key")
+	}
+
+	pkg = makePackage("a", "abc")
+	pkg.Spec.BuildSteps = []fv1.BuildStep{{Name: "compile"}}
+	if other, _ := Key(pkg, env, true); other == key {
+		t.Fatal("expected build steps to change the key")
+	}
+
+	upgraded := env.DeepCopy()
+	upgraded.Spec.Builder.Image = "fission/node-builder:1.11.0"
+	if other, _ := Key(makePackage("a", "abc"), upgraded, true); other == key {
+		t.Fatal("expected other builder image to change the key")
+	}
+
+	// the checksum of a URL source is trusted only once fetched
+	if _, ok = Key(makePackage("a", "abc"), env, false); ok {
+		t.Fatal("expected package with unverified source checksum not to be cached")
+	}
+
+	// environments of the same builder in other namespaces don't share builds
+	other := env.DeepCopy()
+	other.ObjectMeta.Namespace = "other"
+	if k, _ := Key(makePackage("a", "abc"), other, true); k == key {
+		t.Fatal("expected other environment namespace to change the key")
+	}
+	other = env.DeepCopy()
+	other.ObjectMeta.Name = "node-2"
+	if k, _ := Key(makePackage("a", "abc"), other, true); k == key {
+		t.Fatal("expected other environment name to change the key")
+	}
+
+	if _, ok = Key(makePackage("a", ""), env, true); ok {
+		t.Fatal("expected package without source checksum not to be cached")
+	}
+	pkg = makePackage("a", "")
+	pkg.Spec.Source = fv1.Archive{Type: fv1.ArchiveTypeLiteral, Literal: []byte("module.exports = ...")}
+	if _, ok = Key(pkg, env, false); !ok {
+		t.Fatal("expected package with literal source to be cached")
+	}
+}
+
+func TestStore(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	s := MakeStore(zap.NewNop(), kubeClient, time.Hour)
+	env := &fv1.Environment{
+		Spec: fv1.EnvironmentSpec{
+			Builder: fv1.Builder{Image: "fission/go-builder", Command: "build"},
+		},
+	}
+	deployment := fv1.Archive{
+		Type:     fv1.ArchiveTypeUrl,
+		URL:      "http://storagesvc.fission/v1/archive?id=deploy-a",
+		Checksum: fv1.Checksum{Type: fv1.ChecksumTypeSHA256, Sum: "0123"},
+	}
+	sourceMap := fv1.Archive{
+		Type: fv1.ArchiveTypeUrl,
+		URL:  "http://storagesvc.fission/v1/archive?id=sourcemap-a",
+	}
+
+	e, err := s.Lookup(makePackage("b", "abc"), env, true)
+	if err != nil || e != nil {
+		t.Fatalf("expected no entry before a build, got %v, %v", e, err)
+	}
+
+	if err = s.Put(makePackage("a", "abc"), env, deployment, fv1.Archive{}); err != nil {
+		t.Fatal(err)
+	}
+	// a second identical build replaces the entry
+	if err = s.Put(makePackage("a", "abc"), env, deployment, sourceMap); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err = s.Lookup(makePackage("b", "abc"), env, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e == nil || e.Deployment.URL != deployment.URL || e.Package != "default/a" {
+		t.Fatalf("expected the deploy archive of package a, got %+v", e)
+	}
+	if e.SourceMap.URL != sourceMap.URL {
+		t.Fatalf("expected the source map manifest of package a, got %+v", e.SourceMap)
+	}
+	// URL sources not fetched yet don't reuse builds
+	if e, _ = s.Lookup(makePackage("b", "abc"), env, false); e != nil {
+		t.Fatal("expected package with unverified source checksum not to reuse a build")
+	}
+
+	entries, err := List(kubeClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %v", len(entries))
+	}
+
+	// expired entries are ignored and deleted
+	expired := MakeStore(zap.NewNop(), kubeClient, time.Nanosecond)
+	if err = expired.Put(makePackage("c", "def"), env, deployment, fv1.Archive{}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	if e, _ = expired.Lookup(makePackage("c", "def"), env, true); e != nil {
+		t.Fatal("expected expired entry to be ignored")
+	}
+	expired.deleteExpired()
+	if entries, _ = List(kubeClient); len(entries) != 1 {
+		t.Fatalf("expected expired entry to be deleted, got %v entries", len(entries))
+	}
+
+	// a nil store caches nothing
+	var disabled *Store
+	if err = disabled.Put(makePackage("a", "abc"), env, deployment, fv1.Archive{}); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ = disabled.Lookup(makePackage("a", "abc"), env, true); e != nil {
+		t.Fatal("expected nil store to cache nothing")
+	}
+}
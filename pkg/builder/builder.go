@@ -52,6 +52,13 @@ type (
 		// 1. SRC_PKG: path to source package directory
 		// 2. DEPLOY_PKG: path to deployment package directory
 		BuildCommand string `json:"command"`
+		// Arguments of the build command.
+		BuildArgs []string `json:"args,omitempty"`
+		// Environment variables of the build command, in the form "key=value".
+		Env []string `json:"env,omitempty"`
+		// DeployPkgFilename is the deployment package of an earlier build step to
+		// build into, so that the steps of a build share the deployment package.
+		DeployPkgFilename string `json:"deployPkgFilename,omitempty"`
 	}
 
 	PackageBuildResponse struct {
@@ -118,13 +125,16 @@ func (builder *Builder) Handler(w http.ResponseWriter, r *http.Request) {
 	builder.logger.Info("starting build")
 	srcPkgPath := filepath.Join(builder.sharedVolumePath, req.SrcPkgFilename)
 	deployPkgFilename := fmt.Sprintf("%v-%v", req.SrcPkgFilename, strings.ToLower(uniuri.NewLen(6)))
+	if len(req.DeployPkgFilename) > 0 {
+		deployPkgFilename = filepath.Base(req.DeployPkgFilename)
+	}
 	deployPkgPath := filepath.Join(builder.sharedVolumePath, deployPkgFilename)
 	buildCmd := req.BuildCommand
 	if len(buildCmd) == 0 {
 		// use default build command
 		buildCmd = "/build"
 	}
-	buildLogs, err := builder.build(buildCmd, srcPkgPath, deployPkgPath)
+	buildLogs, err := builder.build(buildCmd, req.BuildArgs, req.Env, srcPkgPath, deployPkgPath)
 	if err != nil {
 		e := "error building source package"
 		builder.logger.Error(e, zap.Error(err))
@@ -164,8 +174,8 @@ func (builder *Builder) reply(w http.ResponseWriter, pkgFilename string, buildLo
 	}
 }
 
-func (builder *Builder) build(command string, srcPkgPath string, deployPkgPath string) (string, error) {
-	cmd := exec.Command(command)
+func (builder *Builder) build(command string, args []string, env []string, srcPkgPath string, deployPkgPath string) (string, error) {
+	cmd := exec.Command(command, args...)
 
 	fi, err := os.Stat(srcPkgPath)
 	if err != nil {
@@ -178,7 +188,7 @@ func (builder *Builder) build(command string, srcPkgPath string, deployPkgPath s
 	}
 
 	// set env variables for build command
-	cmd.Env = append(os.Environ(),
+	cmd.Env = append(append(os.Environ(), env...),
 		fmt.Sprintf("%v=%v", envSrcPkg, srcPkgPath),
 		fmt.Sprintf("%v=%v", envDeployPkg, deployPkgPath),
 	)
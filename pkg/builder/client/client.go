@@ -18,6 +18,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
@@ -26,15 +27,18 @@ import (
 
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"golang.org/x/net/context/ctxhttp"
 
 	builder "github.com/fission/fission/pkg/builder"
 	ferror "github.com/fission/fission/pkg/error"
+	"github.com/fission/fission/pkg/tracing"
 )
 
 type (
 	Client struct {
-		logger *zap.Logger
-		url    string
+		logger     *zap.Logger
+		url        string
+		httpClient *http.Client
 	}
 )
 
@@ -42,10 +46,13 @@ func MakeClient(logger *zap.Logger, builderUrl string) *Client {
 	return &Client{
 		logger: logger.Named("builder_client"),
 		url:    strings.TrimSuffix(builderUrl, "/"),
+		httpClient: &http.Client{
+			Transport: tracing.Transport(nil),
+		},
 	}
 }
 
-func (c *Client) Build(req *builder.PackageBuildRequest) (*builder.PackageBuildResponse, error) {
+func (c *Client) Build(ctx context.Context, req *builder.PackageBuildRequest) (*builder.PackageBuildResponse, error) {
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "error marshaling json")
@@ -55,7 +62,7 @@ func (c *Client) Build(req *builder.PackageBuildRequest) (*builder.PackageBuildR
 	var resp *http.Response
 
 	for i := 0; i < maxRetries; i++ {
-		resp, err = http.Post(c.url, "application/json", bytes.NewReader(body))
+		resp, err = ctxhttp.Post(ctx, c.httpClient, c.url, "application/json", bytes.NewReader(body))
 
 		if err == nil {
 			if resp.StatusCode == 200 {
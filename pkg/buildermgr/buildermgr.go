@@ -17,15 +17,25 @@ limitations under the License.
 package buildermgr
 
 import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
+	"github.com/fission/fission/pkg/buildcache"
 	"github.com/fission/fission/pkg/crd"
 	fetcherConfig "github.com/fission/fission/pkg/fetcher/config"
+	"github.com/fission/fission/pkg/fissionconfig"
+	"github.com/fission/fission/pkg/fnstatus"
+	"github.com/fission/fission/pkg/shutdown"
 )
 
-// Start the buildermgr service.
-func Start(logger *zap.Logger, storageSvcUrl string, envBuilderNamespace string) error {
+// Start the buildermgr service, and return once it is shut down by the
+// coordinator.
+func Start(logger *zap.Logger, coordinator *shutdown.Coordinator, storageSvcUrl string, envBuilderNamespace string) error {
 	bmLogger := logger.Named("builder_manager")
 
 	fissionClient, kubernetesClient, _, _, err := crd.MakeFissionClient()
@@ -38,6 +48,10 @@ func Start(logger *zap.Logger, storageSvcUrl string, envBuilderNamespace string)
 		return errors.Wrap(err, "error waiting for CRDs")
 	}
 
+	// the FissionConfig overrides the settings builder manager was deployed with
+	configReconciler := fissionconfig.MakeReconciler(bmLogger, fissionClient, fissionconfig.ComponentBuilderMgr)
+	configReconciler.Load()
+
 	fetcherConfig, err := fetcherConfig.MakeFetcherConfig("/packages")
 	if err != nil {
 		return errors.Wrap(err, "error making fetcher config")
@@ -46,9 +60,53 @@ func Start(logger *zap.Logger, storageSvcUrl string, envBuilderNamespace string)
 	envWatcher := makeEnvironmentWatcher(bmLogger, fissionClient, kubernetesClient, fetcherConfig, envBuilderNamespace)
 	go envWatcher.watchEnvironments()
 
+	cacheTTL := buildcache.DefaultTTL
+	if ttl := os.Getenv("BUILDERMGR_BUILD_CACHE_TTL"); len(ttl) > 0 {
+		cacheTTL, err = time.ParseDuration(ttl)
+		if err != nil {
+			bmLogger.Error("failed to parse build cache TTL from 'BUILDERMGR_BUILD_CACHE_TTL' - builds are not cached",
+				zap.Error(err), zap.String("value", ttl))
+			cacheTTL = 0
+		}
+	}
+	cachedBuilds := buildcache.MakeStore(bmLogger, kubernetesClient, cacheTTL)
+	go cachedBuilds.Run(make(chan struct{}))
+
+	maxBuilds, maxBuildsPerEnv := getBuildConcurrency(bmLogger)
 	pkgWatcher := makePackageWatcher(bmLogger, fissionClient,
-		kubernetesClient, envBuilderNamespace, storageSvcUrl)
+		kubernetesClient, envWatcher.envStore, envBuilderNamespace, storageSvcUrl, maxBuilds, maxBuildsPerEnv, cachedBuilds,
+		makeImageBuilder(bmLogger, kubernetesClient, fetcherConfig), makeStepBuilder(bmLogger, kubernetesClient, fetcherConfig))
 	go pkgWatcher.watchPackages()
+	// on shutdown, no more builds start and the running ones get to finish,
+	// those interrupted are built again once builder manager is back
+	coordinator.OnShutdown("builds", pkgWatcher.buildQueue.drain)
+	coordinator.OnFlush("interrupted builds", pkgWatcher.buildQueue.requeueRunning)
+
+	configReconciler.SetLive("BUILDERMGR_MAX_CONCURRENT_BUILDS_PER_ENV", func(value string) error {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		if limit <= 0 {
+			limit = defaultMaxConcurrentBuildsPerEnv
+		}
+		pkgWatcher.buildQueue.envSlots.setDefaultLimit(limit)
+		return nil
+	})
+	go configReconciler.Run(coordinator.Context().Done())
+
+	go serveMetric(bmLogger, coordinator)
+
+	recorder := fnstatus.MakeRecorder(bmLogger, fissionClient, fnstatus.DefaultInterval)
+	go recorder.Run(context.Background())
+	coordinator.OnFlush("function status", func(ctx context.Context) error {
+		recorder.Flush()
+		return nil
+	})
+
+	fnWatcher := makeFunctionWatcher(bmLogger, fissionClient, recorder)
+	go fnWatcher.watchFunctions()
 
-	select {}
+	<-coordinator.Context().Done()
+	return nil
 }
@@ -0,0 +1,320 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildermgr
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sCache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/cache"
+)
+
+const (
+	// defaultMaxConcurrentBuilds is how many packages are built at the
+	// same time in the whole cluster.
+	defaultMaxConcurrentBuilds = 10
+
+	// defaultMaxConcurrentBuildsPerEnv is how many packages of an
+	// environment are built at the same time, unless the environment
+	// sets its own limit.
+	defaultMaxConcurrentBuildsPerEnv = 5
+
+	// Packages whose environment already runs as many builds as it may
+	// are retried with a backoff between these durations.
+	envBusyBaseDelay = 500 * time.Millisecond
+	envBusyMaxDelay  = 10 * time.Second
+)
+
+type (
+	// buildQueue builds pending packages with a bounded pool of workers.
+	// Packages are queued by key, so that the updates of a package
+	// queued more than once end up in a single build.
+	buildQueue struct {
+		logger     *zap.Logger
+		pkgw       *packageWatcher
+		buildCache *cache.Cache
+		queue      workqueue.RateLimitingInterface
+		workers    int
+		envSlots   *envSlots
+
+		// draining is set once the queue stops starting builds, and done
+		// is closed once the workers returned.
+		draining int32
+		done     chan struct{}
+
+		// running are the keys of the packages being built
+		runningLock sync.Mutex
+		running     map[string]bool
+	}
+
+	// envSlots counts the builds running for each environment.
+	envSlots struct {
+		lock         sync.Mutex
+		defaultLimit int
+		running      map[string]int
+	}
+)
+
+func makeBuildQueue(logger *zap.Logger, pkgw *packageWatcher, workers int, envLimit int) *buildQueue {
+	return &buildQueue{
+		logger:     logger.Named("build_queue"),
+		pkgw:       pkgw,
+		buildCache: cache.MakeCache(0, 0),
+		queue: workqueue.NewRateLimitingQueue(
+			workqueue.NewItemExponentialFailureRateLimiter(envBusyBaseDelay, envBusyMaxDelay)),
+		workers:  workers,
+		envSlots: makeEnvSlots(envLimit),
+		done:     make(chan struct{}),
+		running:  make(map[string]bool),
+	}
+}
+
+// getBuildConcurrency returns the cluster-wide and per environment limits
+// of concurrent builds set in the environment of builder manager.
+func getBuildConcurrency(logger *zap.Logger) (int, int) {
+	parse := func(name string, defaultValue int) int {
+		value := os.Getenv(name)
+		if len(value) == 0 {
+			return defaultValue
+		}
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			logger.Error(fmt.Sprintf("failed to parse '%v' - using the default limit", name),
+				zap.String("value", value),
+				zap.Int("default", defaultValue))
+			return defaultValue
+		}
+		return limit
+	}
+	return parse("BUILDERMGR_MAX_CONCURRENT_BUILDS", defaultMaxConcurrentBuilds),
+		parse("BUILDERMGR_MAX_CONCURRENT_BUILDS_PER_ENV", defaultMaxConcurrentBuildsPerEnv)
+}
+
+// add queues a pending package to be built.
+func (bq *buildQueue) add(pkg *fv1.Package) {
+	key, err := k8sCache.MetaNamespaceKeyFunc(pkg)
+	if err != nil {
+		bq.logger.Error("error getting package key", zap.Error(err), zap.String("package_name", pkg.ObjectMeta.Name))
+		return
+	}
+	bq.queue.Add(key)
+	buildQueueDepth.Set(float64(bq.queue.Len()))
+}
+
+// run starts the workers and blocks until the queue is shut down.
+func (bq *buildQueue) run() {
+	bq.logger.Info("starting build workers", zap.Int("workers", bq.workers),
+		zap.Int("default_builds_per_environment", bq.envSlots.defaultLimit))
+
+	var wg sync.WaitGroup
+	for i := 0; i < bq.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for bq.processNext() {
+			}
+		}()
+	}
+	wg.Wait()
+	close(bq.done)
+}
+
+func (bq *buildQueue) shutdown() {
+	bq.queue.ShutDown()
+}
+
+// drain stops starting builds, and waits for the running builds to finish
+// or the context to be done. Queued packages stay pending, and are built
+// once builder manager is back.
+func (bq *buildQueue) drain(ctx context.Context) error {
+	atomic.StoreInt32(&bq.draining, 1)
+	bq.queue.ShutDown()
+	select {
+	case <-bq.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runningBuilds returns the keys of the packages being built.
+func (bq *buildQueue) runningBuilds() []string {
+	bq.runningLock.Lock()
+	defer bq.runningLock.Unlock()
+	keys := make([]string, 0, len(bq.running))
+	for key := range bq.running {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// requeueRunning sets the packages still being built after draining back
+// to pending, as their builds are interrupted and would otherwise be left
+// running forever.
+func (bq *buildQueue) requeueRunning(ctx context.Context) error {
+	var lastErr error
+	for _, key := range bq.runningBuilds() {
+		namespace, name, err := k8sCache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		pkg, err := bq.pkgw.fissionClient.CoreV1().Packages(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if pkg.Status.BuildStatus != fv1.BuildStatusRunning {
+			continue
+		}
+		_, err = updatePackage(bq.logger, bq.pkgw.fissionClient, pkg, fv1.BuildStatusPending,
+			"build interrupted by the shutdown of builder manager, it will be built again", nil)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		bq.logger.Info("interrupted build set back to pending", zap.String("package", key))
+	}
+	return lastErr
+}
+
+// processNext builds the next package of the queue, and returns false once
+// the queue is shut down.
+func (bq *buildQueue) processNext() bool {
+	item, quit := bq.queue.Get()
+	if quit {
+		return false
+	}
+	defer bq.queue.Done(item)
+	// packages queued while draining are built once builder manager is back
+	if atomic.LoadInt32(&bq.draining) == 1 {
+		bq.queue.Forget(item)
+		return false
+	}
+	buildQueueDepth.Set(float64(bq.queue.Len()))
+
+	key := item.(string)
+	obj, exists, err := bq.pkgw.pkgStore.GetByKey(key)
+	if err != nil {
+		bq.logger.Error("error getting package from store", zap.Error(err), zap.String("package", key))
+		bq.queue.Forget(item)
+		return true
+	}
+	// the package was deleted, or built meanwhile
+	if !exists {
+		bq.queue.Forget(item)
+		return true
+	}
+	pkg := obj.(*fv1.Package)
+	if pkg.Status.BuildStatus != fv1.BuildStatusPending {
+		bq.queue.Forget(item)
+		return true
+	}
+
+	envKey := fmt.Sprintf("%v/%v", pkg.Spec.Environment.Namespace, pkg.Spec.Environment.Name)
+	if !bq.envSlots.acquire(envKey, envLimit(bq.pkgw.envStore, envKey)) {
+		bq.logger.Debug("environment is running as many builds as it may, will retry later",
+			zap.String("package", key), zap.String("environment", envKey))
+		bq.queue.AddRateLimited(item)
+		return true
+	}
+	bq.queue.Forget(item)
+
+	bq.setRunning(key, true)
+	buildsRunning.WithLabelValues(envKey).Inc()
+	start := time.Now()
+	bq.pkgw.build(bq.buildCache, pkg)
+	buildDuration.WithLabelValues(envKey).Observe(time.Since(start).Seconds())
+	buildsRunning.WithLabelValues(envKey).Dec()
+	bq.setRunning(key, false)
+
+	bq.envSlots.release(envKey)
+	return true
+}
+
+func (bq *buildQueue) setRunning(key string, running bool) {
+	bq.runningLock.Lock()
+	defer bq.runningLock.Unlock()
+	if running {
+		bq.running[key] = true
+	} else {
+		delete(bq.running, key)
+	}
+}
+
+// envLimit returns how many builds the environment of a package may run at
+// the same time, or 0 for the default limit. The environment is read from
+// the store of the environment informer, so dequeuing a package does not
+// hit the API server.
+func envLimit(envStore k8sCache.Store, envKey string) int {
+	obj, exists, err := envStore.GetByKey(envKey)
+	if err != nil || !exists {
+		// the build reports a missing environment
+		return 0
+	}
+	return obj.(*fv1.Environment).Spec.Builder.MaxConcurrentBuilds
+}
+
+func makeEnvSlots(defaultLimit int) *envSlots {
+	return &envSlots{
+		defaultLimit: defaultLimit,
+		running:      make(map[string]int),
+	}
+}
+
+// acquire takes a build slot of an environment, and returns false if the
+// environment runs as many builds as its limit, or the default limit if 0.
+func (s *envSlots) acquire(env string, limit int) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if limit <= 0 {
+		limit = s.defaultLimit
+	}
+	if s.running[env] >= limit {
+		return false
+	}
+	s.running[env]++
+	return true
+}
+
+// setDefaultLimit changes the limit of environments not setting their own.
+// Environments running more builds than it finish them normally.
+func (s *envSlots) setDefaultLimit(limit int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.defaultLimit = limit
+}
+
+func (s *envSlots) release(env string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.running[env]--
+	if s.running[env] <= 0 {
+		delete(s.running, env)
+	}
+}
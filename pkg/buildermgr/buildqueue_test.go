@@ -0,0 +1,122 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildermgr
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sCache "k8s.io/client-go/tools/cache"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestEnvSlots(t *testing.T) {
+	s := makeEnvSlots(2)
+
+	// the default limit applies to environments without their own
+	if !s.acquire("default/nodejs", 0) || !s.acquire("default/nodejs", 0) {
+		t.Fatal("expected 2 builds of the environment to run")
+	}
+	if s.acquire("default/nodejs", 0) {
+		t.Fatal("expected the third build of the environment to wait")
+	}
+	// other environments have their own slots
+	if !s.acquire("default/go", 1) {
+		t.Fatal("expected build of another environment to run")
+	}
+	if s.acquire("default/go", 1) {
+		t.Fatal("expected the limit of the environment to be used")
+	}
+
+	s.release("default/nodejs")
+	if !s.acquire("default/nodejs", 0) {
+		t.Fatal("expected a released slot to be taken again")
+	}
+
+	s.release("default/go")
+	if _, ok := s.running["default/go"]; ok {
+		t.Fatal("expected environments without builds to be dropped")
+	}
+}
+
+func TestEnvLimit(t *testing.T) {
+	store := k8sCache.NewStore(k8sCache.MetaNamespaceKeyFunc)
+	env := &fv1.Environment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "go"}}
+	env.Spec.Builder.MaxConcurrentBuilds = 3
+	if err := store.Add(env); err != nil {
+		t.Fatal(err)
+	}
+	err := store.Add(&fv1.Environment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nodejs"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for envKey, want := range map[string]int{
+		"default/go":     3,
+		"default/nodejs": 0,
+		"default/python": 0,
+	} {
+		if got := envLimit(store, envKey); got != want {
+			t.Errorf("envLimit(%q) = %v, want %v", envKey, got, want)
+		}
+	}
+}
+
+func TestGetBuildConcurrency(t *testing.T) {
+	defer os.Unsetenv("BUILDERMGR_MAX_CONCURRENT_BUILDS")
+	defer os.Unsetenv("BUILDERMGR_MAX_CONCURRENT_BUILDS_PER_ENV")
+
+	os.Setenv("BUILDERMGR_MAX_CONCURRENT_BUILDS", "20")
+	os.Setenv("BUILDERMGR_MAX_CONCURRENT_BUILDS_PER_ENV", "many")
+	builds, buildsPerEnv := getBuildConcurrency(zap.NewNop())
+	if builds != 20 {
+		t.Errorf("expected 20 concurrent builds, got %v", builds)
+	}
+	if buildsPerEnv != defaultMaxConcurrentBuildsPerEnv {
+		t.Errorf("expected invalid limit to fall back to the default, got %v", buildsPerEnv)
+	}
+}
+
+func TestBuildQueueDrain(t *testing.T) {
+	bq := makeBuildQueue(zap.NewNop(), nil, 2, 1)
+	bq.queue.Add("default/hello")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	drained := make(chan error, 1)
+	go func() {
+		drained <- bq.drain(ctx)
+	}()
+	// packages queued before draining are not built
+	for atomic.LoadInt32(&bq.draining) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	go bq.run()
+
+	if err := <-drained; err != nil {
+		t.Fatalf("expected queue to drain, got %v", err)
+	}
+	if running := bq.runningBuilds(); len(running) != 0 {
+		t.Errorf("expected no running builds, got %v", running)
+	}
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildermgr
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dchest/uniuri"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/builder"
+	"github.com/fission/fission/pkg/fetcher"
+	fetcherConfig "github.com/fission/fission/pkg/fetcher/config"
+)
+
+const (
+	defaultBuildStepTimeout = 10 * time.Minute
+
+	buildStepSourceFilename = "source"
+)
+
+type (
+	// stepBuilder runs the steps of a package build in order. Steps without an
+	// image run in the environment builder, steps with an image run in a job
+	// next to it.
+	stepBuilder struct {
+		logger        *zap.Logger
+		k8sClient     kubernetes.Interface
+		fetcherConfig *fetcherConfig.Config
+		timeout       time.Duration
+	}
+
+	// packageBuilder is what runs the steps of a build in the environment
+	// builder.
+	packageBuilder interface {
+		Build(ctx context.Context, req *builder.PackageBuildRequest) (*builder.PackageBuildResponse, error)
+	}
+)
+
+func makeStepBuilder(logger *zap.Logger, k8sClient kubernetes.Interface, fetcherConfig *fetcherConfig.Config) *stepBuilder {
+	return &stepBuilder{
+		logger:        logger.Named("step_builder"),
+		k8sClient:     k8sClient,
+		fetcherConfig: fetcherConfig,
+		timeout:       defaultBuildStepTimeout,
+	}
+}
+
+// build runs the build steps of a package, stopping at the first failing
+// step, and returns the build response of the last step run in the
+// environment builder with the logs of all steps.
+func (sb *stepBuilder) build(ctx context.Context, builderC packageBuilder, pkg *fv1.Package, env *fv1.Environment, namespace string,
+	srcPkgFilename string) (*builder.PackageBuildResponse, error) {
+
+	resp := &builder.PackageBuildResponse{}
+	var buildLogs strings.Builder
+
+	for i, step := range pkg.Spec.BuildSteps {
+		fmt.Fprintf(&buildLogs, "=== Step %v/%v: %v ===\n", i+1, len(pkg.Spec.BuildSteps), step.Name)
+		start := time.Now()
+
+		var logs string
+		var err error
+		if len(step.Image) > 0 {
+			logs, err = sb.runJob(pkg, namespace, step)
+		} else {
+			var stepResp *builder.PackageBuildResponse
+			stepResp, err = builderC.Build(ctx, builderStepRequest(srcPkgFilename, resp.ArtifactFilename, env, step))
+			if stepResp != nil {
+				logs = stepResp.BuildLogs
+				if err == nil {
+					resp.ArtifactFilename = stepResp.ArtifactFilename
+				}
+			}
+		}
+		buildLogs.WriteString(logs)
+
+		if err != nil {
+			fmt.Fprintf(&buildLogs, "=== Step %v failed after %v: %v ===\n", step.Name, time.Since(start).Round(time.Second), err)
+			resp.BuildLogs = buildLogs.String()
+			return resp, errors.Wrapf(err, "build step %v failed", step.Name)
+		}
+		fmt.Fprintf(&buildLogs, "=== Step %v succeeded in %v ===\n", step.Name, time.Since(start).Round(time.Second))
+	}
+
+	resp.BuildLogs = buildLogs.String()
+	if len(resp.ArtifactFilename) == 0 {
+		return resp, errors.New("no build step ran in the environment builder to output the deployment package")
+	}
+	return resp, nil
+}
+
+// builderStepRequest returns the request running a build step in the
+// environment builder, into the deployment package of earlier steps if any.
+func builderStepRequest(srcPkgFilename string, deployPkgFilename string, env *fv1.Environment,
+	step fv1.BuildStep) *builder.PackageBuildRequest {
+
+	req := &builder.PackageBuildRequest{
+		SrcPkgFilename:    srcPkgFilename,
+		BuildCommand:      env.Spec.Builder.Command,
+		DeployPkgFilename: deployPkgFilename,
+	}
+	if len(step.Command) > 0 {
+		req.BuildCommand = step.Command[0]
+		req.BuildArgs = step.Command[1:]
+	}
+	for _, e := range step.Env {
+		req.Env = append(req.Env, fmt.Sprintf("%v=%v", e.Name, e.Value))
+	}
+	return req
+}
+
+// runJob runs a build step with an image in a job against the source archive
+// of the package, and returns its logs.
+func (sb *stepBuilder) runJob(pkg *fv1.Package, namespace string, step fv1.BuildStep) (string, error) {
+	name := fmt.Sprintf("fission-build-step-%v", strings.ToLower(uniuri.NewLen(8)))
+
+	job, err := sb.makeJob(name, pkg, step)
+	if err != nil {
+		return "", err
+	}
+	_, err = sb.k8sClient.BatchV1().Jobs(namespace).Create(job)
+	if err != nil {
+		return "", errors.Wrap(err, "error creating build step job")
+	}
+	defer deleteJob(sb.logger, sb.k8sClient, namespace, name)
+
+	sb.logger.Info("started build step",
+		zap.String("package_name", pkg.ObjectMeta.Name), zap.String("step", step.Name), zap.String("job", name))
+
+	jobErr := waitForJob(sb.logger, sb.k8sClient, namespace, name, sb.timeout)
+	_, logs := jobPods(sb.k8sClient, namespace, name)
+	return logs, jobErr
+}
+
+// makeJob returns a job fetching the source archive of a package, then running
+// a build step in it.
+func (sb *stepBuilder) makeJob(name string, pkg *fv1.Package, step fv1.BuildStep) (*batchv1.Job, error) {
+	fetchContainer, err := sb.fetcherConfig.NewFetchContainer(fetcher.FunctionFetchRequest{
+		FetchType:   fv1.FETCH_SOURCE,
+		Package:     pkg.ObjectMeta,
+		Filename:    buildStepSourceFilename,
+		KeepArchive: false,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error making build step fetcher")
+	}
+
+	srcPkgPath := filepath.Join(sb.fetcherConfig.SharedMountPath(), buildStepSourceFilename)
+	stepEnv := append([]apiv1.EnvVar{}, step.Env...)
+	stepEnv = append(stepEnv, apiv1.EnvVar{Name: "SRC_PKG", Value: srcPkgPath})
+
+	backoffLimit := int32(0)
+	deadline := int64(sb.timeout.Seconds())
+	labels := imageBuildLabels(pkg)
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &deadline,
+			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: apiv1.PodSpec{
+					RestartPolicy:      apiv1.RestartPolicyNever,
+					ServiceAccountName: fv1.FissionBuilderSA,
+					InitContainers:     []apiv1.Container{*fetchContainer},
+					Containers: []apiv1.Container{
+						{
+							Name:         "step",
+							Image:        step.Image,
+							Command:      step.Command,
+							WorkingDir:   srcPkgPath,
+							Env:          stepEnv,
+							VolumeMounts: fetchContainer.VolumeMounts,
+						},
+					},
+					Volumes: []apiv1.Volume{
+						{
+							Name: fv1.SharedVolumeUserfunc,
+							VolumeSource: apiv1.VolumeSource{
+								EmptyDir: &apiv1.EmptyDirVolumeSource{},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
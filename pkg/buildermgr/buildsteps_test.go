@@ -0,0 +1,153 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildermgr
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/builder"
+	fetcherConfig "github.com/fission/fission/pkg/fetcher/config"
+)
+
+// fakeBuilder records build requests, and fails the commands in fail.
+type fakeBuilder struct {
+	requests []builder.PackageBuildRequest
+	fail     map[string]bool
+}
+
+func (b *fakeBuilder) Build(ctx context.Context, req *builder.PackageBuildRequest) (*builder.PackageBuildResponse, error) {
+	b.requests = append(b.requests, *req)
+	resp := &builder.PackageBuildResponse{
+		ArtifactFilename: req.DeployPkgFilename,
+		BuildLogs:        "running " + req.BuildCommand + "\n",
+	}
+	if len(resp.ArtifactFilename) == 0 {
+		resp.ArtifactFilename = req.SrcPkgFilename + "-deploy"
+	}
+	if b.fail[req.BuildCommand] {
+		return resp, errors.New("exit status 1")
+	}
+	return resp, nil
+}
+
+func makeTestStepBuilder(t *testing.T) *stepBuilder {
+	cfg, err := fetcherConfig.MakeFetcherConfig("/packages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return makeStepBuilder(zap.NewNop(), fake.NewSimpleClientset(), cfg)
+}
+
+func TestBuildSteps(t *testing.T) {
+	sb := makeTestStepBuilder(t)
+	env := &fv1.Environment{
+		Spec: fv1.EnvironmentSpec{
+			Builder: fv1.Builder{Image: "fission/node-builder", Command: "build"},
+		},
+	}
+	pkg := &fv1.Package{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello-pkg", Namespace: "default"},
+		Spec: fv1.PackageSpec{
+			BuildSteps: []fv1.BuildStep{
+				{Name: "install", Command: []string{"npm", "ci"}, Env: []apiv1.EnvVar{{Name: "NODE_ENV", Value: "production"}}},
+				{Name: "compile"},
+			},
+		},
+	}
+
+	b := &fakeBuilder{}
+	resp, err := sb.build(context.Background(), b, pkg, env, "fission-builder", "hello-src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.requests) != 2 {
+		t.Fatalf("expected a build request per step, got %v", b.requests)
+	}
+	install, compile := b.requests[0], b.requests[1]
+	if install.BuildCommand != "npm" || strings.Join(install.BuildArgs, " ") != "ci" || install.Env[0] != "NODE_ENV=production" {
+		t.Errorf("unexpected request of the install step %+v", install)
+	}
+	// the builder command is the default command of steps
+	if compile.BuildCommand != "build" {
+		t.Errorf("expected the builder command to run, got %q", compile.BuildCommand)
+	}
+	// later steps build into the deployment package of earlier steps
+	if compile.DeployPkgFilename != "hello-src-deploy" || resp.ArtifactFilename != "hello-src-deploy" {
+		t.Errorf("expected the steps to share the deployment package, got %q and %q", compile.DeployPkgFilename, resp.ArtifactFilename)
+	}
+	for _, log := range []string{"=== Step 1/2: install ===", "running npm", "=== Step compile succeeded"} {
+		if !strings.Contains(resp.BuildLogs, log) {
+			t.Errorf("expected %q in build logs %q", log, resp.BuildLogs)
+		}
+	}
+
+	// the build stops at the first failing step
+	b = &fakeBuilder{fail: map[string]bool{"npm": true}}
+	resp, err = sb.build(context.Background(), b, pkg, env, "fission-builder", "hello-src")
+	if err == nil {
+		t.Fatal("expected the build to fail")
+	}
+	if len(b.requests) != 1 {
+		t.Fatalf("expected the build to stop at the failing step, got %v", b.requests)
+	}
+	if !strings.Contains(resp.BuildLogs, "=== Step install failed") {
+		t.Errorf("expected the failing step in build logs %q", resp.BuildLogs)
+	}
+}
+
+func TestBuildStepJob(t *testing.T) {
+	sb := makeTestStepBuilder(t)
+	pkg := &fv1.Package{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello-pkg", Namespace: "default"},
+	}
+	step := fv1.BuildStep{
+		Name:    "lint",
+		Image:   "node:12",
+		Command: []string{"npx", "eslint", "."},
+		Env:     []apiv1.EnvVar{{Name: "CI", Value: "true"}},
+	}
+
+	job, err := sb.makeJob("lint", pkg, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	podSpec := job.Spec.Template.Spec
+	if len(podSpec.InitContainers) != 1 || !strings.Contains(strings.Join(podSpec.InitContainers[0].Command, " "), `"fetchType":0`) {
+		t.Fatalf("expected a fetcher init container fetching the source archive, got %v", podSpec.InitContainers)
+	}
+	c := podSpec.Containers[0]
+	if c.Image != "node:12" || strings.Join(c.Command, " ") != "npx eslint ." || c.WorkingDir != "/packages/source" {
+		t.Errorf("unexpected step container %+v", c)
+	}
+	var srcPkg bool
+	for _, e := range c.Env {
+		srcPkg = srcPkg || (e.Name == "SRC_PKG" && e.Value == "/packages/source")
+	}
+	if !srcPkg || c.Env[0].Name != "CI" {
+		t.Errorf("unexpected step environment %v", c.Env)
+	}
+}
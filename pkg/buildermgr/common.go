@@ -25,34 +25,45 @@ import (
 
 	"github.com/dchest/uniuri"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/buildcache"
 	"github.com/fission/fission/pkg/builder"
 	builderClient "github.com/fission/fission/pkg/builder/client"
 	"github.com/fission/fission/pkg/crd"
 	ferror "github.com/fission/fission/pkg/error"
 	"github.com/fission/fission/pkg/fetcher"
 	fetcherClient "github.com/fission/fission/pkg/fetcher/client"
+	"github.com/fission/fission/pkg/tracing"
 )
 
 // buildPackage helps to build source package into deployment package.
 // Following is the steps buildPackage function takes to complete the whole process.
 // 1. Send fetch request to fetcher to fetch source package.
-// 2. Send build request to builder to start a build.
+// 2. Send build request to builder to start a build, or run the build steps of the package.
 // 3. Send upload request to fetcher to upload deployment package.
 // 4. Return upload response and build logs.
 // *. Return build logs and error if any one of steps above failed.
 func buildPackage(ctx context.Context, logger *zap.Logger, fissionClient *crd.FissionClient, envBuilderNamespace string,
-	storageSvcUrl string, pkg *fv1.Package) (uploadResp *fetcher.ArchiveUploadResponse, buildLogs string, err error) {
+	storageSvcUrl string, steps *stepBuilder, lookup func(*fv1.Environment) *buildcache.Entry,
+	pkg *fv1.Package) (uploadResp *fetcher.ArchiveUploadResponse, buildLogs string, cached *buildcache.Entry, err error) {
+
+	// the fetch, build and upload requests are traced together
+	ctx, span := tracing.StartSpan(ctx, "build package",
+		attribute.String("package", pkg.ObjectMeta.Name), attribute.String("namespace", pkg.ObjectMeta.Namespace))
+	defer func() {
+		tracing.EndSpan(span, err)
+	}()
 
 	env, err := fissionClient.CoreV1().Environments(pkg.Spec.Environment.Namespace).Get(pkg.Spec.Environment.Name, metav1.GetOptions{})
 	if err != nil {
 		e := "error getting environment CRD info"
 		logger.Error(e, zap.Error(err))
 		e = fmt.Sprintf("%s: %v", e, err)
-		return nil, e, ferror.MakeError(http.StatusInternalServerError, e)
+		return nil, e, nil, ferror.MakeError(http.StatusInternalServerError, e)
 	}
 
 	svcName := fmt.Sprintf("%v-%v.%v", env.ObjectMeta.Name, env.ObjectMeta.ResourceVersion, envBuilderNamespace)
@@ -73,22 +84,31 @@ func buildPackage(ctx context.Context, logger *zap.Logger, fissionClient *crd.Fi
 		e := "error fetching source package"
 		logger.Error(e, zap.Error(err))
 		e = fmt.Sprintf("%s: %v", e, err)
-		return nil, e, ferror.MakeError(http.StatusInternalServerError, e)
-	}
-
-	buildCmd := pkg.Spec.BuildCommand
-	if len(buildCmd) == 0 {
-		buildCmd = env.Spec.Builder.Command
+		return nil, e, nil, ferror.MakeError(http.StatusInternalServerError, e)
 	}
 
-	pkgBuildReq := &builder.PackageBuildRequest{
-		SrcPkgFilename: srcPkgFilename,
-		BuildCommand:   buildCmd,
+	// The fetcher verified the source against its checksum, so an
+	// identical build can be reused now.
+	if cached = lookup(env); cached != nil {
+		return nil, "", cached, nil
 	}
 
 	logger.Info("started building with source package", zap.String("source_package", srcPkgFilename))
-	// send build request to builder
-	buildResp, err := builderC.Build(pkgBuildReq)
+	var buildResp *builder.PackageBuildResponse
+	if len(pkg.Spec.BuildSteps) > 0 {
+		buildResp, err = steps.build(ctx, builderC, pkg, env, envBuilderNamespace, srcPkgFilename)
+	} else {
+		buildCmd := pkg.Spec.BuildCommand
+		if len(buildCmd) == 0 {
+			buildCmd = env.Spec.Builder.Command
+		}
+
+		// send build request to builder
+		buildResp, err = builderC.Build(ctx, &builder.PackageBuildRequest{
+			SrcPkgFilename: srcPkgFilename,
+			BuildCommand:   buildCmd,
+		})
+	}
 	if err != nil {
 		e := fmt.Sprintf("Error building deployment package: %v", err)
 		var buildLogs string
@@ -96,7 +116,7 @@ func buildPackage(ctx context.Context, logger *zap.Logger, fissionClient *crd.Fi
 			buildLogs = buildResp.BuildLogs
 		}
 		buildLogs += fmt.Sprintf("%v\n", e)
-		return nil, buildLogs, ferror.MakeError(http.StatusInternalServerError, e)
+		return nil, buildLogs, nil, ferror.MakeError(http.StatusInternalServerError, e)
 	}
 
 	logger.Info("build succeed", zap.String("source_package", srcPkgFilename), zap.String("deployment_package", buildResp.ArtifactFilename))
@@ -115,10 +135,10 @@ func buildPackage(ctx context.Context, logger *zap.Logger, fissionClient *crd.Fi
 	if err != nil {
 		e := fmt.Sprintf("Error uploading deployment package: %v", err)
 		buildResp.BuildLogs += fmt.Sprintf("%v\n", e)
-		return nil, buildResp.BuildLogs, ferror.MakeError(http.StatusInternalServerError, e)
+		return nil, buildResp.BuildLogs, nil, ferror.MakeError(http.StatusInternalServerError, e)
 	}
 
-	return uploadResp, buildResp.BuildLogs, nil
+	return uploadResp, buildResp.BuildLogs, nil, nil
 }
 
 func updatePackage(logger *zap.Logger, fissionClient *crd.FissionClient,
@@ -137,6 +157,7 @@ func updatePackage(logger *zap.Logger, fissionClient *crd.FissionClient,
 			URL:      uploadResp.ArchiveDownloadUrl,
 			Checksum: uploadResp.Checksum,
 		}
+		pkg.Spec.SourceMap = uploadResp.SourceMap
 	}
 
 	// update package spec
@@ -27,10 +27,11 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
+	k8sCache "k8s.io/client-go/tools/cache"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/crd"
@@ -86,6 +87,8 @@ type (
 		fetcherConfig          *fetcherConfig.Config
 		builderImagePullPolicy apiv1.PullPolicy
 		useIstio               bool
+		envStore               k8sCache.Store
+		envController          k8sCache.Controller
 	}
 )
 
@@ -120,6 +123,22 @@ func makeEnvironmentWatcher(
 		fetcherConfig:          fetcherConfig,
 	}
 
+	lw := k8sCache.NewListWatchFromClient(fissionClient.CoreV1().RESTClient(), "environments", metav1.NamespaceAll, fields.Everything())
+	envWatcher.envStore, envWatcher.envController = k8sCache.NewInformer(lw, &fv1.Environment{}, 60*time.Minute, k8sCache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			envWatcher.sync()
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if oldObj.(*fv1.Environment).ObjectMeta.ResourceVersion == newObj.(*fv1.Environment).ObjectMeta.ResourceVersion {
+				return
+			}
+			envWatcher.sync()
+		},
+		DeleteFunc: func(obj interface{}) {
+			envWatcher.sync()
+		},
+	})
+
 	go envWatcher.service()
 
 	return envWatcher
@@ -144,38 +163,10 @@ func (envw *environmentWatcher) getLabels(envName string, envNamespace string, e
 	}
 }
 
+// watchEnvironments keeps the builders in sync with the environments, and
+// blocks while the environment informer runs.
 func (envw *environmentWatcher) watchEnvironments() {
-	rv := ""
-	for {
-		wi, err := envw.fissionClient.CoreV1().Environments(metav1.NamespaceAll).Watch(metav1.ListOptions{
-			ResourceVersion: rv,
-		})
-		if err != nil {
-			if utils.IsNetworkError(err) {
-				envw.logger.Error("encountered network error, retrying later", zap.Error(err))
-				time.Sleep(5 * time.Second)
-				continue
-			}
-			envw.logger.Fatal("error watching environment list", zap.Error(err))
-		}
-
-		for {
-			ev, more := <-wi.ResultChan()
-			if !more {
-				// restart watch from last rv
-				break
-			}
-			if ev.Type == watch.Error {
-				// restart watch from the start
-				rv = ""
-				time.Sleep(time.Second)
-				break
-			}
-			env := ev.Object.(*fv1.Environment)
-			rv = env.ObjectMeta.ResourceVersion
-			envw.sync()
-		}
-	}
+	envw.envController.Run(make(chan struct{}))
 }
 
 func (envw *environmentWatcher) sync() {
@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildermgr
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	k8sCache "k8s.io/client-go/tools/cache"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/fnstatus"
+)
+
+type (
+	// functionWatcher records the PackageBuilt condition of functions, when
+	// the build status of their package changes and when they are created
+	// or moved to another package.
+	functionWatcher struct {
+		logger        *zap.Logger
+		fissionClient *crd.FissionClient
+		recorder      *fnstatus.Recorder
+		fnStore       k8sCache.Store
+		pkgStore      k8sCache.Store
+	}
+)
+
+func makeFunctionWatcher(logger *zap.Logger, fissionClient *crd.FissionClient, recorder *fnstatus.Recorder) *functionWatcher {
+	return &functionWatcher{
+		logger:        logger.Named("function_watcher"),
+		fissionClient: fissionClient,
+		recorder:      recorder,
+	}
+}
+
+func (fnw *functionWatcher) watchFunctions() {
+	restClient := fnw.fissionClient.CoreV1().RESTClient()
+
+	pkgLW := k8sCache.NewListWatchFromClient(restClient, "packages", apiv1.NamespaceAll, fields.Everything())
+	pkgStore, pkgController := k8sCache.NewInformer(pkgLW, &fv1.Package{}, 60*time.Minute, k8sCache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			fnw.packageChanged(obj.(*fv1.Package))
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldPkg := oldObj.(*fv1.Package)
+			pkg := newObj.(*fv1.Package)
+			if oldPkg.Status.BuildStatus == pkg.Status.BuildStatus {
+				return
+			}
+			fnw.packageChanged(pkg)
+		},
+	})
+	fnLW := k8sCache.NewListWatchFromClient(restClient, "functions", apiv1.NamespaceAll, fields.Everything())
+	fnStore, fnController := k8sCache.NewInformer(fnLW, &fv1.Function{}, 60*time.Minute, k8sCache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			fnw.functionChanged(obj.(*fv1.Function))
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldFn := oldObj.(*fv1.Function)
+			fn := newObj.(*fv1.Function)
+			if oldFn.Spec.Package.PackageRef == fn.Spec.Package.PackageRef {
+				return
+			}
+			fnw.functionChanged(fn)
+		},
+	})
+
+	// each store is used by the handlers of the other informer
+	fnw.pkgStore = pkgStore
+	fnw.fnStore = fnStore
+	go pkgController.Run(make(chan struct{}))
+	fnController.Run(make(chan struct{}))
+}
+
+// packageChanged records the PackageBuilt condition of the functions of a
+// package.
+func (fnw *functionWatcher) packageChanged(pkg *fv1.Package) {
+	if len(pkg.Status.BuildStatus) == 0 {
+		return
+	}
+	for _, obj := range fnw.fnStore.List() {
+		fn := obj.(*fv1.Function)
+		if fnw.packageOf(fn) == pkg.ObjectMeta.Namespace+"/"+pkg.ObjectMeta.Name {
+			fnw.recorder.RecordPackage(&fn.ObjectMeta, pkg)
+		}
+	}
+}
+
+// functionChanged records the PackageBuilt condition of a function.
+func (fnw *functionWatcher) functionChanged(fn *fv1.Function) {
+	if len(fn.Spec.Package.PackageRef.Name) == 0 {
+		return
+	}
+	obj, exists, err := fnw.pkgStore.GetByKey(fnw.packageOf(fn))
+	if err != nil {
+		fnw.logger.Error("error getting package of function",
+			zap.String("function", fn.ObjectMeta.Name),
+			zap.String("namespace", fn.ObjectMeta.Namespace),
+			zap.Error(err))
+		return
+	}
+	if !exists {
+		// packageChanged records it once the package is created
+		return
+	}
+	pkg := obj.(*fv1.Package)
+	if len(pkg.Status.BuildStatus) == 0 {
+		return
+	}
+	fnw.recorder.RecordPackage(&fn.ObjectMeta, pkg)
+}
+
+// packageOf returns the store key of the package of a function.
+func (fnw *functionWatcher) packageOf(fn *fv1.Function) string {
+	ref := fn.Spec.Package.PackageRef
+	namespace := ref.Namespace
+	if len(namespace) == 0 {
+		namespace = fn.ObjectMeta.Namespace
+	}
+	return namespace + "/" + ref.Name
+}
@@ -0,0 +1,390 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildermgr
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/dchest/uniuri"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/fetcher"
+	fetcherConfig "github.com/fission/fission/pkg/fetcher/config"
+)
+
+const (
+	defaultImageBuilderImage = "gcr.io/kaniko-project/executor:v1.3.0"
+	defaultImageBuildTimeout = 10 * time.Minute
+
+	imageBuildDockerfileDir = "/fission-build"
+	jobPollInterval         = 2 * time.Second
+)
+
+type (
+	// imageBuilder builds the deploy archives of packages into container
+	// images, by running kaniko in a job next to the environment builder.
+	imageBuilder struct {
+		logger         *zap.Logger
+		k8sClient      kubernetes.Interface
+		fetcherConfig  *fetcherConfig.Config
+		podNamespace   string
+		registry       string
+		registrySecret string
+		builderImage   string
+		timeout        time.Duration
+	}
+)
+
+func makeImageBuilder(logger *zap.Logger, k8sClient kubernetes.Interface, fetcherConfig *fetcherConfig.Config) *imageBuilder {
+	builderImage := os.Getenv("BUILDERMGR_IMAGE_BUILDER_IMAGE")
+	if len(builderImage) == 0 {
+		builderImage = defaultImageBuilderImage
+	}
+
+	timeout := defaultImageBuildTimeout
+	if t := os.Getenv("BUILDERMGR_IMAGE_BUILD_TIMEOUT"); len(t) > 0 {
+		d, err := time.ParseDuration(t)
+		if err != nil || d <= 0 {
+			logger.Error("failed to parse image build timeout from 'BUILDERMGR_IMAGE_BUILD_TIMEOUT' - set to the default value",
+				zap.Error(err), zap.String("value", t), zap.Duration("default", defaultImageBuildTimeout))
+		} else {
+			timeout = d
+		}
+	}
+
+	return &imageBuilder{
+		logger:         logger.Named("image_builder"),
+		k8sClient:      k8sClient,
+		fetcherConfig:  fetcherConfig,
+		podNamespace:   os.Getenv("POD_NAMESPACE"),
+		registry:       strings.TrimSuffix(os.Getenv("BUILDERMGR_IMAGE_REGISTRY"), "/"),
+		registrySecret: os.Getenv("BUILDERMGR_IMAGE_REGISTRY_SECRET"),
+		builderImage:   builderImage,
+		timeout:        timeout,
+	}
+}
+
+// build builds the deploy archive of a package into an image based on the
+// environment runtime image, pushes it, and returns its digest reference
+// along with the build logs.
+func (ib *imageBuilder) build(pkg *fv1.Package, env *fv1.Environment, namespace string,
+	uploadResp *fetcher.ArchiveUploadResponse) (string, string, error) {
+	if len(ib.registry) == 0 {
+		return "", "", errors.New("no image registry is configured for builder manager")
+	}
+
+	err := ib.setupRegistrySecret(namespace)
+	if err != nil {
+		return "", "", err
+	}
+
+	name := fmt.Sprintf("fission-image-build-%v", strings.ToLower(uniuri.NewLen(8)))
+	destination := imageDestination(ib.registry, pkg, uploadResp)
+
+	cm := &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: imageBuildLabels(pkg),
+		},
+		Data: map[string]string{
+			"Dockerfile": imageDockerfile(env.Spec.Runtime.Image),
+		},
+	}
+	_, err = ib.k8sClient.CoreV1().ConfigMaps(namespace).Create(cm)
+	if err != nil {
+		return "", "", errors.Wrap(err, "error creating image build dockerfile")
+	}
+	defer func() {
+		err := ib.k8sClient.CoreV1().ConfigMaps(namespace).Delete(name, &metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			ib.logger.Error("error deleting image build dockerfile", zap.Error(err), zap.String("name", name))
+		}
+	}()
+
+	job, err := ib.makeJob(name, pkg, env, destination)
+	if err != nil {
+		return "", "", err
+	}
+	_, err = ib.k8sClient.BatchV1().Jobs(namespace).Create(job)
+	if err != nil {
+		return "", "", errors.Wrap(err, "error creating image build job")
+	}
+	defer deleteJob(ib.logger, ib.k8sClient, namespace, name)
+
+	ib.logger.Info("started building deployment image",
+		zap.String("package_name", pkg.ObjectMeta.Name), zap.String("job", name), zap.String("destination", destination))
+
+	jobErr := waitForJob(ib.logger, ib.k8sClient, namespace, name, ib.timeout)
+	digest, buildLogs := ib.jobResult(namespace, name)
+	if jobErr != nil {
+		return "", buildLogs, jobErr
+	}
+	if len(digest) == 0 {
+		return "", buildLogs, errors.New("image build finished without an image digest")
+	}
+
+	return imageReference(destination, digest), buildLogs, nil
+}
+
+// setupRegistrySecret copies the registry credentials builder manager is
+// configured with into the namespace image builds run in.
+func (ib *imageBuilder) setupRegistrySecret(namespace string) error {
+	if len(ib.registrySecret) == 0 || namespace == ib.podNamespace {
+		return nil
+	}
+
+	_, err := ib.k8sClient.CoreV1().Secrets(namespace).Get(ib.registrySecret, metav1.GetOptions{})
+	if err == nil {
+		return nil
+	} else if !k8serrors.IsNotFound(err) {
+		return errors.Wrap(err, "error getting image registry secret")
+	}
+
+	secret, err := ib.k8sClient.CoreV1().Secrets(ib.podNamespace).Get(ib.registrySecret, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "error getting image registry secret %v", ib.registrySecret)
+	}
+	_, err = ib.k8sClient.CoreV1().Secrets(namespace).Create(&apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: secret.ObjectMeta.Name,
+		},
+		Type: secret.Type,
+		Data: secret.Data,
+	})
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
+		return errors.Wrap(err, "error copying image registry secret")
+	}
+	return nil
+}
+
+// makeJob returns a job fetching the deploy archive of a package, then
+// building and pushing the image with kaniko. Kaniko writes the digest of the
+// image to its termination message.
+func (ib *imageBuilder) makeJob(name string, pkg *fv1.Package, env *fv1.Environment, destination string) (*batchv1.Job, error) {
+	fetchContainer, err := ib.fetcherConfig.NewFetchContainer(fetcher.FunctionFetchRequest{
+		FetchType:   fv1.FETCH_DEPLOYMENT,
+		Package:     pkg.ObjectMeta,
+		Filename:    path.Base(fetcherConfig.DeploymentImagePath),
+		KeepArchive: env.Spec.KeepArchive,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error making image build fetcher")
+	}
+
+	volumes := []apiv1.Volume{
+		{
+			Name: fv1.SharedVolumeUserfunc,
+			VolumeSource: apiv1.VolumeSource{
+				EmptyDir: &apiv1.EmptyDirVolumeSource{},
+			},
+		},
+		{
+			Name: "dockerfile",
+			VolumeSource: apiv1.VolumeSource{
+				ConfigMap: &apiv1.ConfigMapVolumeSource{
+					LocalObjectReference: apiv1.LocalObjectReference{Name: name},
+				},
+			},
+		},
+	}
+	mounts := []apiv1.VolumeMount{
+		fetchContainer.VolumeMounts[0],
+		{
+			Name:      "dockerfile",
+			MountPath: imageBuildDockerfileDir,
+		},
+	}
+	if len(ib.registrySecret) > 0 {
+		volumes = append(volumes, apiv1.Volume{
+			Name: "registry-credentials",
+			VolumeSource: apiv1.VolumeSource{
+				Secret: &apiv1.SecretVolumeSource{
+					SecretName: ib.registrySecret,
+					Items: []apiv1.KeyToPath{
+						{Key: apiv1.DockerConfigJsonKey, Path: "config.json"},
+					},
+				},
+			},
+		})
+		mounts = append(mounts, apiv1.VolumeMount{
+			Name:      "registry-credentials",
+			MountPath: "/kaniko/.docker",
+			ReadOnly:  true,
+		})
+	}
+
+	backoffLimit := int32(0)
+	deadline := int64(ib.timeout.Seconds())
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: imageBuildLabels(pkg),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &deadline,
+			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: imageBuildLabels(pkg),
+				},
+				Spec: apiv1.PodSpec{
+					RestartPolicy:      apiv1.RestartPolicyNever,
+					ServiceAccountName: fv1.FissionBuilderSA,
+					InitContainers:     []apiv1.Container{*fetchContainer},
+					Containers: []apiv1.Container{
+						{
+							Name:  "kaniko",
+							Image: ib.builderImage,
+							Args: []string{
+								"--context=dir://" + ib.fetcherConfig.SharedMountPath(),
+								"--dockerfile=" + path.Join(imageBuildDockerfileDir, "Dockerfile"),
+								"--destination=" + destination,
+								"--digest-file=/dev/termination-log",
+							},
+							TerminationMessagePath: "/dev/termination-log",
+							VolumeMounts:           mounts,
+						},
+					},
+					Volumes: volumes,
+				},
+			},
+		},
+	}, nil
+}
+
+// waitForJob waits for a build job to complete.
+func waitForJob(logger *zap.Logger, k8sClient kubernetes.Interface, namespace string, name string, timeout time.Duration) error {
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-deadline:
+			return errors.Errorf("job %v did not complete in %v", name, timeout)
+		case <-ticker.C:
+		}
+
+		job, err := k8sClient.BatchV1().Jobs(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			logger.Error("error getting build job", zap.Error(err), zap.String("name", name))
+			continue
+		}
+		if job.Status.Succeeded > 0 {
+			return nil
+		}
+		if job.Status.Failed > 0 {
+			return errors.Errorf("job %v failed", name)
+		}
+	}
+}
+
+// deleteJob deletes a build job along with its pods.
+func deleteJob(logger *zap.Logger, k8sClient kubernetes.Interface, namespace string, name string) {
+	propagation := metav1.DeletePropagationBackground
+	err := k8sClient.BatchV1().Jobs(namespace).Delete(name, &metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		logger.Error("error deleting build job", zap.Error(err), zap.String("name", name))
+	}
+}
+
+// jobPods returns the pods of a build job, and the logs of their containers.
+func jobPods(k8sClient kubernetes.Interface, namespace string, name string) ([]apiv1.Pod, string) {
+	pods, err := k8sClient.CoreV1().Pods(namespace).List(metav1.ListOptions{
+		LabelSelector: "job-name=" + name,
+	})
+	if err != nil {
+		return nil, fmt.Sprintf("error getting pods of job %v: %v\n", name, err)
+	}
+
+	var sb strings.Builder
+	for _, pod := range pods.Items {
+		for _, c := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+			logs, err := k8sClient.CoreV1().Pods(namespace).GetLogs(pod.ObjectMeta.Name, &apiv1.PodLogOptions{
+				Container: c.Name,
+			}).Do().Raw()
+			if err != nil {
+				fmt.Fprintf(&sb, "error getting logs of container %v: %v\n", c.Name, err)
+				continue
+			}
+			sb.Write(logs)
+		}
+	}
+	return pods.Items, sb.String()
+}
+
+// jobResult returns the image digest an image build job reported, and the
+// logs of its containers.
+func (ib *imageBuilder) jobResult(namespace string, name string) (digest string, buildLogs string) {
+	pods, buildLogs := jobPods(ib.k8sClient, namespace, name)
+	for _, pod := range pods {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name == "kaniko" && status.State.Terminated != nil && status.State.Terminated.ExitCode == 0 {
+				digest = strings.TrimSpace(status.State.Terminated.Message)
+			}
+		}
+	}
+
+	return digest, buildLogs
+}
+
+func imageBuildLabels(pkg *fv1.Package) map[string]string {
+	return map[string]string{
+		"packageName":      pkg.ObjectMeta.Name,
+		"packageNamespace": pkg.ObjectMeta.Namespace,
+	}
+}
+
+// imageDockerfile returns the dockerfile of deployment images, adding the
+// deploy archive fetched into the build context to the runtime image.
+func imageDockerfile(runtimeImage string) string {
+	return fmt.Sprintf("FROM %v\nCOPY %v %v\n",
+		runtimeImage, path.Base(fetcherConfig.DeploymentImagePath), fetcherConfig.DeploymentImagePath)
+}
+
+// imageDestination returns where the deployment image of a package is pushed
+// to, tagged with the checksum of its deploy archive.
+func imageDestination(registry string, pkg *fv1.Package, uploadResp *fetcher.ArchiveUploadResponse) string {
+	tag := pkg.ObjectMeta.ResourceVersion
+	if sum := uploadResp.Checksum.Sum; len(sum) >= 12 {
+		tag = sum[:12]
+	}
+	return fmt.Sprintf("%v/%v-%v:%v", registry, pkg.ObjectMeta.Namespace, pkg.ObjectMeta.Name, tag)
+}
+
+// imageReference pins an image to its digest.
+func imageReference(destination string, digest string) string {
+	repository := destination
+	if i := strings.LastIndex(destination, ":"); i > strings.LastIndex(destination, "/") {
+		repository = destination[:i]
+	}
+	return repository + "@" + digest
+}
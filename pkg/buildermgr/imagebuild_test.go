@@ -0,0 +1,140 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildermgr
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/fetcher"
+	fetcherConfig "github.com/fission/fission/pkg/fetcher/config"
+)
+
+func TestImageDestination(t *testing.T) {
+	pkg := &fv1.Package{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello-pkg", Namespace: "default", ResourceVersion: "42"},
+	}
+
+	dest := imageDestination("registry.example.com/fission", pkg, &fetcher.ArchiveUploadResponse{
+		Checksum: fv1.Checksum{Type: fv1.ChecksumTypeSHA256, Sum: "0123456789abcdef"},
+	})
+	if dest != "registry.example.com/fission/default-hello-pkg:0123456789ab" {
+		t.Fatalf("unexpected destination %q", dest)
+	}
+
+	// the resource version tags images of archives without a checksum
+	dest = imageDestination("registry.example.com/fission", pkg, &fetcher.ArchiveUploadResponse{})
+	if dest != "registry.example.com/fission/default-hello-pkg:42" {
+		t.Fatalf("unexpected destination %q", dest)
+	}
+}
+
+func TestImageReference(t *testing.T) {
+	for dest, expected := range map[string]string{
+		"registry.example.com/fission/default-hello:42":      "registry.example.com/fission/default-hello@sha256:abc",
+		"registry.example.com:5000/fission/default-hello:42": "registry.example.com:5000/fission/default-hello@sha256:abc",
+		"registry.example.com:5000/fission/default-hello":    "registry.example.com:5000/fission/default-hello@sha256:abc",
+	} {
+		if ref := imageReference(dest, "sha256:abc"); ref != expected {
+			t.Errorf("expected reference %q for %q, got %q", expected, dest, ref)
+		}
+	}
+}
+
+func TestImageBuildJob(t *testing.T) {
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Unsetenv("BUILDERMGR_IMAGE_REGISTRY_SECRET")
+	defer os.Unsetenv("POD_NAMESPACE")
+	os.Setenv("BUILDERMGR_IMAGE_REGISTRY_SECRET", "registry-credentials")
+	os.Setenv("POD_NAMESPACE", "fission")
+
+	cfg, err := fetcherConfig.MakeFetcherConfig("/packages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := fake.NewSimpleClientset(&apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "registry-credentials", Namespace: "fission"},
+		Type:       apiv1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{apiv1.DockerConfigJsonKey: []byte("{}")},
+	})
+	ib := makeImageBuilder(logger, client, cfg)
+
+	// the registry credentials are copied to the namespace builds run in
+	err = ib.setupRegistrySecret("fission-builder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := client.CoreV1().Secrets("fission-builder").Get("registry-credentials", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected registry secret to be copied: %v", err)
+	}
+	if secret.Type != apiv1.SecretTypeDockerConfigJson {
+		t.Fatalf("unexpected secret type %v", secret.Type)
+	}
+
+	pkg := &fv1.Package{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello-pkg", Namespace: "default"},
+	}
+	env := &fv1.Environment{
+		Spec: fv1.EnvironmentSpec{
+			Runtime: fv1.Runtime{Image: "fission/node-env"},
+		},
+	}
+	job, err := ib.makeJob("build", pkg, env, "registry.example.com/fission/default-hello-pkg:42")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	podSpec := job.Spec.Template.Spec
+	if len(podSpec.InitContainers) != 1 || podSpec.InitContainers[0].Name != "fetcher" {
+		t.Fatalf("expected a fetcher init container, got %v", podSpec.InitContainers)
+	}
+	if !strings.Contains(strings.Join(podSpec.InitContainers[0].Command, " "), "-fetch-request") {
+		t.Fatal("expected the fetcher to run a single fetch request")
+	}
+
+	kaniko := podSpec.Containers[0]
+	args := strings.Join(kaniko.Args, " ")
+	for _, arg := range []string{"--context=dir:///packages", "--destination=registry.example.com/fission/default-hello-pkg:42", "--digest-file=/dev/termination-log"} {
+		if !strings.Contains(args, arg) {
+			t.Errorf("expected kaniko argument %q in %q", arg, args)
+		}
+	}
+	var credentials bool
+	for _, m := range kaniko.VolumeMounts {
+		credentials = credentials || m.MountPath == "/kaniko/.docker"
+	}
+	if !credentials {
+		t.Error("expected the registry credentials to be mounted")
+	}
+
+	expected := "FROM fission/node-env\nCOPY deployarchive /fission/deployarchive\n"
+	if dockerfile := imageDockerfile(env.Spec.Runtime.Image); dockerfile != expected {
+		t.Errorf("unexpected dockerfile %q", dockerfile)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildermgr
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+
+	"github.com/fission/fission/pkg/shutdown"
+)
+
+var (
+	buildQueueDepth = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fission_build_queue_depth",
+			Help: "How many packages wait to be built.",
+		},
+	)
+	// environment: namespace/name of the environment of the package
+	buildsRunning = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "fission_builds_running",
+			Help: "How many packages are being built by environment.",
+		},
+		[]string{"environment"},
+	)
+	buildDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "fission_build_duration_seconds",
+			Help:    "How long package builds take by environment.",
+			Buckets: []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200},
+		},
+		[]string{"environment"},
+	)
+	// result: hit or miss
+	buildCacheLookups = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fission_build_cache_lookups_total",
+			Help: "How many lookups of the deploy archives of identical builds hit or missed.",
+		},
+		[]string{"result"},
+	)
+	// status: succeeded or failed
+	buildsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fission_builds_total",
+			Help: "How many packages were built by environment and status.",
+		},
+		[]string{"environment", "status"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(buildQueueDepth)
+	prometheus.MustRegister(buildsRunning)
+	prometheus.MustRegister(buildDuration)
+	prometheus.MustRegister(buildsTotal)
+	prometheus.MustRegister(buildCacheLookups)
+}
+
+func serveMetric(logger *zap.Logger, coordinator *shutdown.Coordinator) {
+	// Expose the registered metrics via HTTP.
+	metricAddr := ":8080"
+	http.Handle("/metrics", promhttp.Handler())
+	err := coordinator.ServeMetrics(&http.Server{Addr: metricAddr})
+	if err != nil {
+		logger.Fatal("done listening on metrics endpoint", zap.Error(err))
+	}
+}
@@ -30,8 +30,11 @@ import (
 	k8sCache "k8s.io/client-go/tools/cache"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/buildcache"
+	"github.com/fission/fission/pkg/buildlog"
 	"github.com/fission/fission/pkg/cache"
 	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/fetcher"
 	"github.com/fission/fission/pkg/utils"
 )
 
@@ -41,14 +44,21 @@ type (
 		fissionClient    *crd.FissionClient
 		k8sClient        *kubernetes.Clientset
 		podStore         k8sCache.Store
+		envStore         k8sCache.Store
 		pkgStore         k8sCache.Store
+		buildLogs        *buildlog.Store
+		buildQueue       *buildQueue
+		cachedBuilds     *buildcache.Store
+		imageBuilder     *imageBuilder
+		stepBuilder      *stepBuilder
 		builderNamespace string
 		storageSvcUrl    string
 	}
 )
 
 func makePackageWatcher(logger *zap.Logger, fissionClient *crd.FissionClient, k8sClientSet *kubernetes.Clientset,
-	builderNamespace string, storageSvcUrl string) *packageWatcher {
+	envStore k8sCache.Store, builderNamespace string, storageSvcUrl string, maxBuilds int, maxBuildsPerEnv int,
+	cachedBuilds *buildcache.Store, imageBuilder *imageBuilder, stepBuilder *stepBuilder) *packageWatcher {
 	lw := k8sCache.NewListWatchFromClient(k8sClientSet.CoreV1().RESTClient(), "pods", metav1.NamespaceAll, fields.Everything())
 	store, controller := k8sCache.NewInformer(lw, &apiv1.Pod{}, 30*time.Second, k8sCache.ResourceEventHandlerFuncs{})
 	go controller.Run(make(chan struct{}))
@@ -58,9 +68,15 @@ func makePackageWatcher(logger *zap.Logger, fissionClient *crd.FissionClient, k8
 		fissionClient:    fissionClient,
 		k8sClient:        k8sClientSet,
 		podStore:         store,
+		envStore:         envStore,
+		buildLogs:        buildlog.MakeStore(logger, k8sClientSet, buildlog.DefaultHistory),
+		cachedBuilds:     cachedBuilds,
+		imageBuilder:     imageBuilder,
+		stepBuilder:      stepBuilder,
 		builderNamespace: builderNamespace,
 		storageSvcUrl:    storageSvcUrl,
 	}
+	pkgw.buildQueue = makeBuildQueue(pkgw.logger, pkgw, maxBuilds, maxBuildsPerEnv)
 	return pkgw
 }
 
@@ -90,17 +106,26 @@ func (pkgw *packageWatcher) build(buildCache *cache.Cache, srcpkg *fv1.Package)
 
 	pkgw.logger.Info("starting build for package", zap.String("package_name", srcpkg.ObjectMeta.Name), zap.String("resource_version", srcpkg.ObjectMeta.ResourceVersion))
 
+	// the image and source maps of an earlier build are stale once the package is rebuilt
+	srcpkg.Spec.DeploymentImage = ""
+	srcpkg.Spec.SourceMap = fv1.Archive{}
 	pkg, err := updatePackage(pkgw.logger, pkgw.fissionClient, srcpkg, fv1.BuildStatusRunning, "", nil)
 	if err != nil {
 		pkgw.logger.Error("error setting package pending state", zap.Error(err))
 		return
 	}
 
+	rec, err := pkgw.buildLogs.Start(pkg)
+	if err != nil {
+		// the build goes on, its log is still kept in the package status
+		pkgw.logger.Error("error recording build start", zap.Error(err), zap.String("package_name", pkg.ObjectMeta.Name))
+	}
+
 	env, err := pkgw.fissionClient.CoreV1().Environments(pkg.Spec.Environment.Namespace).Get(pkg.Spec.Environment.Name, metav1.GetOptions{})
 	if k8serrors.IsNotFound(err) {
 		e := "environment does not exist"
 		pkgw.logger.Error(e, zap.String("environment", pkg.Spec.Environment.Name))
-		_, er := updatePackage(pkgw.logger, pkgw.fissionClient, pkg,
+		_, er := pkgw.updatePackage(rec, pkg,
 			fv1.BuildStatusFailed, fmt.Sprintf("%s: %q", e, pkg.Spec.Environment.Name), nil)
 		if er != nil {
 			pkgw.logger.Error(
@@ -113,6 +138,16 @@ func (pkgw *packageWatcher) build(buildCache *cache.Cache, srcpkg *fv1.Package)
 		return
 	}
 
+	// Reuse the deploy archive of an identical build if there is one. Image
+	// builds need the builder namespace set up below, so they are not reused.
+	// URL sources are looked up again once the fetcher verified them.
+	if err == nil && env.Spec.Builder.Output != fv1.BuildOutputImage {
+		if cached := pkgw.lookupBuild(pkg, env, false); cached != nil {
+			pkgw.reuseBuild(rec, pkg, cached)
+			return
+		}
+	}
+
 	// Create a new BackOff for health check on environment builder pod
 	healthCheckBackOff := utils.NewDefaultBackOff()
 	//if err != nil {
@@ -183,10 +218,16 @@ func (pkgw *packageWatcher) build(buildCache *cache.Cache, srcpkg *fv1.Package)
 			}
 
 			ctx := context.Background()
-			uploadResp, buildLogs, err := buildPackage(ctx, pkgw.logger, pkgw.fissionClient, builderNs, pkgw.storageSvcUrl, pkg)
+			lookup := func(env *fv1.Environment) *buildcache.Entry {
+				if env.Spec.Builder.Output == fv1.BuildOutputImage {
+					return nil
+				}
+				return pkgw.lookupBuild(pkg, env, true)
+			}
+			uploadResp, buildLogs, cached, err := buildPackage(ctx, pkgw.logger, pkgw.fissionClient, builderNs, pkgw.storageSvcUrl, pkgw.stepBuilder, lookup, pkg)
 			if err != nil {
 				pkgw.logger.Error("error building package", zap.Error(err), zap.String("package_name", pkg.ObjectMeta.Name))
-				_, er := updatePackage(pkgw.logger, pkgw.fissionClient, pkg, fv1.BuildStatusFailed, buildLogs, nil)
+				_, er := pkgw.updatePackage(rec, pkg, fv1.BuildStatusFailed, buildLogs, nil)
 				if er != nil {
 					pkgw.logger.Error(
 						"error updating package",
@@ -197,76 +238,48 @@ func (pkgw *packageWatcher) build(buildCache *cache.Cache, srcpkg *fv1.Package)
 				}
 				return
 			}
+			if cached != nil {
+				pkgw.reuseBuild(rec, pkg, cached)
+				return
+			}
 
-			pkgw.logger.Info("starting package info update", zap.String("package_name", pkg.ObjectMeta.Name))
-
-			fnList, err := pkgw.fissionClient.CoreV1().
-				Functions(metav1.NamespaceAll).List(metav1.ListOptions{})
+			err = pkgw.cachedBuilds.Put(pkg, env, fv1.Archive{
+				Type:     fv1.ArchiveTypeUrl,
+				URL:      uploadResp.ArchiveDownloadUrl,
+				Checksum: uploadResp.Checksum,
+			}, uploadResp.SourceMap)
 			if err != nil {
-				e := "error getting function list"
-				pkgw.logger.Error(e, zap.Error(err))
-				buildLogs += fmt.Sprintf("%s: %v\n", e, err)
-				_, er := updatePackage(pkgw.logger, pkgw.fissionClient, pkg, fv1.BuildStatusFailed, buildLogs, nil)
-				if er != nil {
-					pkgw.logger.Error(
-						"error updating package",
-						zap.String("package_name", pkg.ObjectMeta.Name),
-						zap.String("resource_version", pkg.ObjectMeta.ResourceVersion),
-						zap.Error(er),
-					)
-				}
+				pkgw.logger.Error("error caching build", zap.Error(err), zap.String("package_name", pkg.ObjectMeta.Name))
 			}
 
-			// A package may be used by multiple functions. Update
-			// functions with old package resource version
-			for _, fn := range fnList.Items {
-				if fn.Spec.Package.PackageRef.Name == pkg.ObjectMeta.Name &&
-					fn.Spec.Package.PackageRef.Namespace == pkg.ObjectMeta.Namespace &&
-					fn.Spec.Package.PackageRef.ResourceVersion != pkg.ObjectMeta.ResourceVersion {
-					fn.Spec.Package.PackageRef.ResourceVersion = pkg.ObjectMeta.ResourceVersion
-					// update CRD
-					_, err = pkgw.fissionClient.CoreV1().Functions(fn.ObjectMeta.Namespace).Update(&fn)
-					if err != nil {
-						e := "error updating function package resource version"
-						pkgw.logger.Error(e, zap.Error(err))
-						buildLogs += fmt.Sprintf("%s: %v\n", e, err)
-						_, er := updatePackage(pkgw.logger, pkgw.fissionClient, pkg, fv1.BuildStatusFailed, buildLogs, nil)
-						if er != nil {
-							pkgw.logger.Error(
-								"error updating package",
-								zap.String("package_name", pkg.ObjectMeta.Name),
-								zap.String("resource_version", pkg.ObjectMeta.ResourceVersion),
-								zap.Error(er),
-							)
-						}
-						return
+			if env.Spec.Builder.Output == fv1.BuildOutputImage {
+				image, imageLogs, err := pkgw.imageBuilder.build(pkg, env, builderNs, uploadResp)
+				buildLogs += imageLogs
+				if err != nil {
+					pkgw.logger.Error("error building deployment image", zap.Error(err), zap.String("package_name", pkg.ObjectMeta.Name))
+					buildLogs += fmt.Sprintf("Error building deployment image: %v\n", err)
+					_, er := pkgw.updatePackage(rec, pkg, fv1.BuildStatusFailed, buildLogs, nil)
+					if er != nil {
+						pkgw.logger.Error(
+							"error updating package",
+							zap.String("package_name", pkg.ObjectMeta.Name),
+							zap.String("resource_version", pkg.ObjectMeta.ResourceVersion),
+							zap.Error(er),
+						)
 					}
+					return
 				}
+				pkgw.logger.Info("built deployment image", zap.String("package_name", pkg.ObjectMeta.Name), zap.String("image", image))
+				pkg.Spec.DeploymentImage = image
 			}
 
-			_, err = updatePackage(pkgw.logger, pkgw.fissionClient, pkg,
-				fv1.BuildStatusSucceeded, buildLogs, uploadResp)
-			if err != nil {
-				pkgw.logger.Error("error updating package info", zap.Error(err), zap.String("package_name", pkg.ObjectMeta.Name))
-				_, er := updatePackage(pkgw.logger, pkgw.fissionClient, pkg, fv1.BuildStatusFailed, buildLogs, nil)
-				if er != nil {
-					pkgw.logger.Error(
-						"error updating package",
-						zap.String("package_name", pkg.ObjectMeta.Name),
-						zap.String("resource_version", pkg.ObjectMeta.ResourceVersion),
-						zap.Error(er),
-					)
-				}
-				return
-			}
-
-			pkgw.logger.Info("completed package build request", zap.String("package_name", pkg.ObjectMeta.Name))
+			pkgw.succeedBuild(rec, pkg, buildLogs, uploadResp)
 			return
 		}
 		time.Sleep(healthCheckBackOff.GetNext())
 	}
 	// build timeout
-	_, err = updatePackage(pkgw.logger, pkgw.fissionClient, pkg,
+	_, err = pkgw.updatePackage(rec, pkg,
 		fv1.BuildStatusFailed, "Build timeout due to environment builder not ready", nil)
 	if err != nil {
 		pkgw.logger.Error(
@@ -281,8 +294,123 @@ func (pkgw *packageWatcher) build(buildCache *cache.Cache, srcpkg *fv1.Package)
 		zap.String("package", fmt.Sprintf("%s.%s", pkg.ObjectMeta.Name, pkg.ObjectMeta.Namespace)))
 }
 
+// lookupBuild returns the deploy archive of an identical build of a
+// package, or nil if there is none. See buildcache.Key for fetched.
+func (pkgw *packageWatcher) lookupBuild(pkg *fv1.Package, env *fv1.Environment, fetched bool) *buildcache.Entry {
+	if _, ok := buildcache.Key(pkg, env, fetched); !ok {
+		return nil
+	}
+	cached, err := pkgw.cachedBuilds.Lookup(pkg, env, fetched)
+	if err != nil {
+		pkgw.logger.Error("error looking up build cache", zap.Error(err), zap.String("package_name", pkg.ObjectMeta.Name))
+	}
+	if cached == nil {
+		buildCacheLookups.WithLabelValues("miss").Inc()
+		return nil
+	}
+	buildCacheLookups.WithLabelValues("hit").Inc()
+	return cached
+}
+
+// reuseBuild completes the build of a package with the deploy archive of an
+// identical build.
+func (pkgw *packageWatcher) reuseBuild(rec *buildlog.Record, pkg *fv1.Package, cached *buildcache.Entry) {
+	pkgw.logger.Info("reusing deploy archive of identical build",
+		zap.String("package_name", pkg.ObjectMeta.Name),
+		zap.String("built_for", cached.Package))
+	buildLogs := fmt.Sprintf("Source and builder unchanged, reused the deploy archive built for package %v (resource version %v) at %v\n",
+		cached.Package, cached.ResourceVersion, cached.BuiltAt.Format(time.RFC3339))
+	pkgw.succeedBuild(rec, pkg, buildLogs, &fetcher.ArchiveUploadResponse{
+		ArchiveDownloadUrl: cached.Deployment.URL,
+		Checksum:           cached.Deployment.Checksum,
+		SourceMap:          cached.SourceMap,
+	})
+}
+
+// succeedBuild points the functions using a package built to its new
+// version, and marks the build succeeded.
+func (pkgw *packageWatcher) succeedBuild(rec *buildlog.Record, pkg *fv1.Package, buildLogs string,
+	uploadResp *fetcher.ArchiveUploadResponse) {
+	pkgw.logger.Info("starting package info update", zap.String("package_name", pkg.ObjectMeta.Name))
+
+	fnList, err := pkgw.fissionClient.CoreV1().
+		Functions(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		e := "error getting function list"
+		pkgw.logger.Error(e, zap.Error(err))
+		buildLogs += fmt.Sprintf("%s: %v\n", e, err)
+		_, er := pkgw.updatePackage(rec, pkg, fv1.BuildStatusFailed, buildLogs, nil)
+		if er != nil {
+			pkgw.logger.Error(
+				"error updating package",
+				zap.String("package_name", pkg.ObjectMeta.Name),
+				zap.String("resource_version", pkg.ObjectMeta.ResourceVersion),
+				zap.Error(er),
+			)
+		}
+		return
+	}
+
+	// A package may be used by multiple functions. Update
+	// functions with old package resource version
+	for _, fn := range fnList.Items {
+		if fn.Spec.Package.PackageRef.Name == pkg.ObjectMeta.Name &&
+			fn.Spec.Package.PackageRef.Namespace == pkg.ObjectMeta.Namespace &&
+			fn.Spec.Package.PackageRef.ResourceVersion != pkg.ObjectMeta.ResourceVersion {
+			fn.Spec.Package.PackageRef.ResourceVersion = pkg.ObjectMeta.ResourceVersion
+			// update CRD
+			_, err = pkgw.fissionClient.CoreV1().Functions(fn.ObjectMeta.Namespace).Update(&fn)
+			if err != nil {
+				e := "error updating function package resource version"
+				pkgw.logger.Error(e, zap.Error(err))
+				buildLogs += fmt.Sprintf("%s: %v\n", e, err)
+				_, er := pkgw.updatePackage(rec, pkg, fv1.BuildStatusFailed, buildLogs, nil)
+				if er != nil {
+					pkgw.logger.Error(
+						"error updating package",
+						zap.String("package_name", pkg.ObjectMeta.Name),
+						zap.String("resource_version", pkg.ObjectMeta.ResourceVersion),
+						zap.Error(er),
+					)
+				}
+				return
+			}
+		}
+	}
+
+	_, err = pkgw.updatePackage(rec, pkg,
+		fv1.BuildStatusSucceeded, buildLogs, uploadResp)
+	if err != nil {
+		pkgw.logger.Error("error updating package info", zap.Error(err), zap.String("package_name", pkg.ObjectMeta.Name))
+		_, er := pkgw.updatePackage(rec, pkg, fv1.BuildStatusFailed, buildLogs, nil)
+		if er != nil {
+			pkgw.logger.Error(
+				"error updating package",
+				zap.String("package_name", pkg.ObjectMeta.Name),
+				zap.String("resource_version", pkg.ObjectMeta.ResourceVersion),
+				zap.Error(er),
+			)
+		}
+		return
+	}
+
+	pkgw.logger.Info("completed package build request", zap.String("package_name", pkg.ObjectMeta.Name))
+}
+
+// updatePackage updates the status of a package being built, and keeps
+// the log of the build once it's over.
+func (pkgw *packageWatcher) updatePackage(rec *buildlog.Record, pkg *fv1.Package, status fv1.BuildStatus,
+	buildLogs string, uploadResp *fetcher.ArchiveUploadResponse) (*fv1.Package, error) {
+	err := pkgw.buildLogs.Finish(pkg, rec, status, buildLogs)
+	if err != nil {
+		pkgw.logger.Error("error recording build log", zap.Error(err), zap.String("package_name", pkg.ObjectMeta.Name))
+	}
+	buildsTotal.WithLabelValues(fmt.Sprintf("%v/%v", pkg.Spec.Environment.Namespace, pkg.Spec.Environment.Name),
+		string(status)).Inc()
+	return updatePackage(pkgw.logger, pkgw.fissionClient, pkg, status, buildLogs, uploadResp)
+}
+
 func (pkgw *packageWatcher) watchPackages() {
-	buildCache := cache.MakeCache(0, 0)
 	lw := k8sCache.NewListWatchFromClient(pkgw.fissionClient.CoreV1().RESTClient(), "packages", apiv1.NamespaceAll, fields.Everything())
 
 	processPkg := func(pkg *fv1.Package) {
@@ -301,7 +429,7 @@ func (pkgw *packageWatcher) watchPackages() {
 
 		// Only build pending state packages.
 		if pkg.Status.BuildStatus == fv1.BuildStatusPending {
-			go pkgw.build(buildCache, pkg)
+			pkgw.buildQueue.add(pkg)
 		}
 	}
 
@@ -328,6 +456,8 @@ func (pkgw *packageWatcher) watchPackages() {
 	})
 
 	pkgw.pkgStore = pkgStore
+	go pkgw.buildQueue.run()
+	defer pkgw.buildQueue.shutdown()
 	controller.Run(make(chan struct{}))
 }
 
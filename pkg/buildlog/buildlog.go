@@ -0,0 +1,236 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package buildlog keeps the logs of the builds of packages in config maps
+// owned by the packages, one per build, so that the logs of past builds
+// aren't overwritten by the next build.
+package buildlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	// LabelBuildLog marks the config maps build logs are stored in.
+	LabelBuildLog = "fission.io/buildlog"
+	// LabelPackageUID is the UID of the package built. Package names may
+	// be too long for label values.
+	LabelPackageUID = "fission.io/buildlog-package-uid"
+
+	// DefaultHistory is how many builds of a package logs are kept for.
+	DefaultHistory = 10
+
+	// maxLogLength keeps records well under the size limit of config maps.
+	// Longer logs keep their tail, where errors usually are.
+	maxLogLength = 512 * 1024
+
+	dataKey = "buildlog.json"
+)
+
+type (
+	// Record is the log of a build of a package.
+	Record struct {
+		// ID is the name of the config map the record is stored in.
+		ID        string `json:"id"`
+		Package   string `json:"package"`
+		Namespace string `json:"namespace"`
+		UID       string `json:"uid"`
+		// ResourceVersion is the resource version of the package built.
+		ResourceVersion string `json:"resourceVersion"`
+
+		Status     fv1.BuildStatus `json:"status"`
+		StartedAt  time.Time       `json:"startedAt"`
+		FinishedAt time.Time       `json:"finishedAt"`
+
+		Log string `json:"log"`
+		// Truncated is true if the head of the log was dropped.
+		Truncated bool `json:"truncated,omitempty"`
+	}
+
+	// Store stores build logs in config maps.
+	Store struct {
+		logger           *zap.Logger
+		kubernetesClient kubernetes.Interface
+		history          int
+	}
+)
+
+// MakeStore returns a store keeping the logs of the last history builds of
+// each package.
+func MakeStore(logger *zap.Logger, kubernetesClient kubernetes.Interface, history int) *Store {
+	if history <= 0 {
+		history = DefaultHistory
+	}
+	return &Store{
+		logger:           logger.Named("build_log_store"),
+		kubernetesClient: kubernetesClient,
+		history:          history,
+	}
+}
+
+// IsFinished returns true if the build the record is of is over.
+func (r *Record) IsFinished() bool {
+	return r.Status != fv1.BuildStatusRunning && r.Status != fv1.BuildStatusPending
+}
+
+func (r *Record) configMap(pkg *fv1.Package) (*apiv1.ConfigMap, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding build log")
+	}
+	cm := &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.ID,
+			Namespace: r.Namespace,
+			// logs are deleted along with their package
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(pkg, fv1.SchemeGroupVersion.WithKind("Package")),
+			},
+			Labels: map[string]string{
+				LabelBuildLog:   "true",
+				LabelPackageUID: r.UID,
+			},
+		},
+		Data: map[string]string{
+			dataKey: string(data),
+		},
+	}
+	return cm, nil
+}
+
+// FromConfigMap returns the build log stored in a config map.
+func FromConfigMap(cm *apiv1.ConfigMap) (*Record, error) {
+	data, ok := cm.Data[dataKey]
+	if !ok {
+		return nil, errors.Errorf("config map %v/%v has no build log", cm.ObjectMeta.Namespace, cm.ObjectMeta.Name)
+	}
+	r := &Record{}
+	err := json.Unmarshal([]byte(data), r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error decoding build log in config map %v/%v", cm.ObjectMeta.Namespace, cm.ObjectMeta.Name)
+	}
+	return r, nil
+}
+
+// Start records the start of a build of a package, and drops the logs of
+// the oldest builds of the package beyond the history.
+func (s *Store) Start(pkg *fv1.Package) (*Record, error) {
+	now := time.Now().UTC()
+	r := &Record{
+		ID:              fmt.Sprintf("buildlog-%v-%v", pkg.ObjectMeta.UID, strconv.FormatInt(now.UnixNano(), 36)),
+		Package:         pkg.ObjectMeta.Name,
+		Namespace:       pkg.ObjectMeta.Namespace,
+		UID:             string(pkg.ObjectMeta.UID),
+		ResourceVersion: pkg.ObjectMeta.ResourceVersion,
+		Status:          fv1.BuildStatusRunning,
+		StartedAt:       now,
+	}
+	cm, err := r.configMap(pkg)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.kubernetesClient.CoreV1().ConfigMaps(r.Namespace).Create(cm)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating build log of package %v/%v", r.Namespace, r.Package)
+	}
+
+	s.prune(pkg)
+	return r, nil
+}
+
+// Finish records the status and log of a build once it's over. Nothing
+// is recorded for a nil record, e.g. one that failed to start.
+func (s *Store) Finish(pkg *fv1.Package, r *Record, status fv1.BuildStatus, log string) error {
+	if r == nil {
+		return nil
+	}
+	r.Status = status
+	r.FinishedAt = time.Now().UTC()
+	r.Log, r.Truncated = truncate(log)
+
+	cm, err := r.configMap(pkg)
+	if err != nil {
+		return err
+	}
+	_, err = s.kubernetesClient.CoreV1().ConfigMaps(r.Namespace).Update(cm)
+	if err != nil {
+		return errors.Wrapf(err, "error updating build log of package %v/%v", r.Namespace, r.Package)
+	}
+	return nil
+}
+
+func truncate(log string) (string, bool) {
+	if len(log) <= maxLogLength {
+		return log, false
+	}
+	return log[len(log)-maxLogLength:], true
+}
+
+// List returns the build logs of a package, the most recent build first.
+func (s *Store) List(pkg *fv1.Package) ([]Record, error) {
+	cms, err := s.kubernetesClient.CoreV1().ConfigMaps(pkg.ObjectMeta.Namespace).List(metav1.ListOptions{
+		LabelSelector: labels.Set{
+			LabelBuildLog:   "true",
+			LabelPackageUID: string(pkg.ObjectMeta.UID),
+		}.AsSelector().String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	records := []Record{}
+	for i := range cms.Items {
+		r, err := FromConfigMap(&cms.Items[i])
+		if err != nil {
+			s.logger.Error("ignoring invalid build log", zap.Error(err))
+			continue
+		}
+		records = append(records, *r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].StartedAt.After(records[j].StartedAt)
+	})
+	return records, nil
+}
+
+func (s *Store) prune(pkg *fv1.Package) {
+	records, err := s.List(pkg)
+	if err != nil {
+		s.logger.Error("error listing build logs to prune", zap.Error(err),
+			zap.String("package", pkg.ObjectMeta.Name), zap.String("namespace", pkg.ObjectMeta.Namespace))
+		return
+	}
+	for i := s.history; i < len(records); i++ {
+		err = s.kubernetesClient.CoreV1().ConfigMaps(pkg.ObjectMeta.Namespace).Delete(records[i].ID, &metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			s.logger.Error("error deleting old build log", zap.Error(err), zap.String("config_map", records[i].ID))
+		}
+	}
+}
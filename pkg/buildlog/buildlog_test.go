@@ -0,0 +1,116 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildlog
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestStore(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	s := MakeStore(zap.NewNop(), kubeClient, 2)
+
+	pkg := &fv1.Package{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "hello",
+			Namespace:       metav1.NamespaceDefault,
+			UID:             "0b6c5e2a-8f3a-4a57-9e2b-5d3c1f0e7a91",
+			ResourceVersion: "100",
+		},
+	}
+	other := pkg.DeepCopy()
+	other.ObjectMeta.Name = "other"
+	other.ObjectMeta.UID = "6d2f9a1e-3b4c-4e5f-8a7b-9c0d1e2f3a4b"
+
+	first, err := s.Start(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Finish(pkg, first, fv1.BuildStatusFailed, "npm ERR! missing script: build\n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Start(other); err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.Start(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := s.List(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected the logs of 2 builds, got %v", len(records))
+	}
+	if records[0].ID != second.ID || records[0].IsFinished() {
+		t.Fatalf("expected the running build first, got %+v", records[0])
+	}
+	if records[1].Status != fv1.BuildStatusFailed || !strings.Contains(records[1].Log, "npm ERR!") {
+		t.Fatalf("expected the log of the failed build, got %+v", records[1])
+	}
+
+	cm, err := kubeClient.CoreV1().ConfigMaps(pkg.ObjectMeta.Namespace).Get(second.ID, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cm.ObjectMeta.OwnerReferences) != 1 || cm.ObjectMeta.OwnerReferences[0].UID != pkg.ObjectMeta.UID {
+		t.Fatalf("expected build log to be owned by its package, got %v", cm.ObjectMeta.OwnerReferences)
+	}
+
+	// the oldest build is dropped beyond the history
+	if _, err = s.Start(pkg); err != nil {
+		t.Fatal(err)
+	}
+	records, err = s.List(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1].ID != second.ID {
+		t.Fatalf("expected the logs of the last 2 builds, got %+v", records)
+	}
+
+	// logs of other packages are kept
+	records, err = s.List(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected the log of the build of the other package, got %v", len(records))
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	log := strings.Repeat("x", maxLogLength) + "error: build failed"
+	truncated, ok := truncate(log)
+	if !ok || len(truncated) != maxLogLength || !strings.HasSuffix(truncated, "error: build failed") {
+		t.Fatalf("expected the tail of the log to be kept, got %v bytes", len(truncated))
+	}
+
+	truncated, ok = truncate("ok")
+	if ok || truncated != "ok" {
+		t.Fatalf("expected short log to be kept as is, got %q", truncated)
+	}
+}
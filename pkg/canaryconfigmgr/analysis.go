@@ -0,0 +1,137 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package canaryconfigmgr
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// analysisWindows returns the time windows criteria of canary config are evaluated over.
+func analysisWindows(canaryConfig *fv1.CanaryConfig) []string {
+	if len(canaryConfig.Spec.AnalysisWindows) > 0 {
+		return canaryConfig.Spec.AnalysisWindows
+	}
+	return []string{canaryConfig.Spec.WeightIncrementDuration}
+}
+
+// getFailurePercentage returns the lowest failure percentage of the new function across
+// all analysis windows, so that the failure threshold is considered crossed only if it's
+// crossed in every window. Windows without requests are skipped; -1 is returned if no
+// window received any requests.
+func (canaryCfgMgr *canaryConfigMgr) getFailurePercentage(provider MetricsProvider, canaryConfig *fv1.CanaryConfig, trigger *fv1.HTTPTrigger) (float64, error) {
+	result := float64(-1)
+
+	for _, window := range analysisWindows(canaryConfig) {
+		failurePercent, err := provider.GetFunctionFailurePercentage(trigger.Spec.RelativeURL, trigger.Spec.Method,
+			canaryConfig.Spec.NewFunction, canaryConfig.ObjectMeta.Namespace, window)
+		if failurePercent == -1 {
+			// no requests to the function during this window
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if result == -1 || failurePercent < result {
+			result = failurePercent
+		}
+	}
+
+	return result, nil
+}
+
+// getViolatedSLO returns the name of the first SLO the new function violates in every
+// analysis window, or an empty string if all SLOs are met.
+func (canaryCfgMgr *canaryConfigMgr) getViolatedSLO(provider MetricsProvider, canaryConfig *fv1.CanaryConfig, trigger *fv1.HTTPTrigger) (string, error) {
+	for _, slo := range canaryConfig.Spec.SLOs {
+		violated := true
+
+		for _, window := range analysisWindows(canaryConfig) {
+			value, threshold, err := canaryCfgMgr.evaluateSLO(provider, &slo, canaryConfig, trigger, window)
+			if err != nil {
+				return "", errors.Wrapf(err, "error evaluating SLO %v", slo.Name)
+			}
+
+			canaryCfgMgr.logger.Info("SLO evaluated for canary config",
+				zap.String("slo", slo.Name),
+				zap.String("window", window),
+				zap.Float64("value", value),
+				zap.Float64("threshold", threshold),
+				zap.String("name", canaryConfig.ObjectMeta.Name),
+				zap.String("namespace", canaryConfig.ObjectMeta.Namespace))
+
+			// NaN means there is no data to judge on during this window
+			if math.IsNaN(value) || value <= threshold {
+				violated = false
+				break
+			}
+		}
+
+		if violated {
+			return slo.Name, nil
+		}
+	}
+
+	return "", nil
+}
+
+// evaluateSLO returns the current value of SLO and its threshold within the window.
+func (canaryCfgMgr *canaryConfigMgr) evaluateSLO(provider MetricsProvider, slo *fv1.CanarySLO, canaryConfig *fv1.CanaryConfig,
+	trigger *fv1.HTTPTrigger, window string) (float64, float64, error) {
+
+	switch slo.Type {
+	case fv1.CanarySLOTypeLatency:
+		threshold, err := time.ParseDuration(slo.Threshold)
+		if err != nil {
+			return 0, 0, errors.Wrapf(err, "error parsing latency threshold %v", slo.Threshold)
+		}
+		latency, err := provider.GetFunctionLatencyPercentile(trigger.Spec.RelativeURL, trigger.Spec.Method,
+			canaryConfig.Spec.NewFunction, canaryConfig.ObjectMeta.Namespace, slo.Percentile, window)
+		if err != nil {
+			return 0, 0, err
+		}
+		return latency, threshold.Seconds(), nil
+
+	case fv1.CanarySLOTypeQuery:
+		threshold, err := strconv.ParseFloat(slo.Threshold, 64)
+		if err != nil {
+			return 0, 0, errors.Wrapf(err, "error parsing query threshold %v", slo.Threshold)
+		}
+		query := strings.NewReplacer(
+			"{{function}}", canaryConfig.Spec.NewFunction,
+			"{{namespace}}", canaryConfig.ObjectMeta.Namespace,
+			"{{path}}", trigger.Spec.RelativeURL,
+			"{{method}}", trigger.Spec.Method,
+			"{{window}}", window,
+		).Replace(slo.Query)
+		value, err := provider.GetQueryValue(query)
+		if err != nil {
+			return 0, 0, err
+		}
+		return value, threshold, nil
+
+	default:
+		return 0, 0, errors.Errorf("unsupported SLO type %v", slo.Type)
+	}
+}
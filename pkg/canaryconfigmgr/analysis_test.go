@@ -0,0 +1,332 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package canaryconfigmgr
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// fakeMetricsProvider returns canned values by window, and by query for
+// query SLOs.
+type fakeMetricsProvider struct {
+	failures  map[string]float64
+	latencies map[string]float64
+	values    map[string]float64
+	err       error
+
+	percentiles []int
+	queries     []string
+}
+
+func (p *fakeMetricsProvider) GetFunctionFailurePercentage(path, method, funcName, funcNs string, window string) (float64, error) {
+	failure, ok := p.failures[window]
+	if !ok {
+		return -1, errors.Errorf("no requests to function %v in %v", funcName, window)
+	}
+	return failure, p.err
+}
+
+func (p *fakeMetricsProvider) GetFunctionLatencyPercentile(path, method, funcName, funcNs string, percentile int, window string) (float64, error) {
+	p.percentiles = append(p.percentiles, percentile)
+	latency, ok := p.latencies[window]
+	if !ok {
+		return math.NaN(), p.err
+	}
+	return latency, p.err
+}
+
+func (p *fakeMetricsProvider) GetQueryValue(queryString string) (float64, error) {
+	p.queries = append(p.queries, queryString)
+	value, ok := p.values[queryString]
+	if !ok {
+		return math.NaN(), p.err
+	}
+	return value, p.err
+}
+
+func makeTestCanaryConfig(windows []string, slos ...fv1.CanarySLO) (*fv1.CanaryConfig, *fv1.HTTPTrigger) {
+	canaryConfig := &fv1.CanaryConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "canary", Namespace: "fn"},
+		Spec: fv1.CanaryConfigSpec{
+			NewFunction:             "hello-v2",
+			WeightIncrementDuration: "1m",
+			AnalysisWindows:         windows,
+			SLOs:                    slos,
+		},
+	}
+	trigger := &fv1.HTTPTrigger{
+		Spec: fv1.HTTPTriggerSpec{RelativeURL: "/hello", Method: http.MethodGet},
+	}
+	return canaryConfig, trigger
+}
+
+func TestGetFailurePercentage(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		windows  []string
+		failures map[string]float64
+		err      error
+		expected float64
+		wantErr  bool
+	}{
+		{
+			name:     "minimum across windows",
+			windows:  []string{"1m", "10m"},
+			failures: map[string]float64{"1m": 40, "10m": 5},
+			expected: 5,
+		},
+		{
+			name:     "windows without requests skipped",
+			windows:  []string{"1m", "10m"},
+			failures: map[string]float64{"10m": 20},
+			expected: 20,
+		},
+		{
+			name:     "no requests in any window",
+			windows:  []string{"1m", "10m"},
+			expected: -1,
+		},
+		{
+			name:     "weight increment duration by default",
+			failures: map[string]float64{"1m": 30, "10m": 0},
+			expected: 30,
+		},
+		{
+			name:     "provider error",
+			windows:  []string{"1m"},
+			failures: map[string]float64{"1m": 0},
+			err:      errors.New("unavailable"),
+			wantErr:  true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			mgr := &canaryConfigMgr{logger: zap.NewNop()}
+			canaryConfig, trigger := makeTestCanaryConfig(test.windows)
+			provider := &fakeMetricsProvider{failures: test.failures, err: test.err}
+
+			failure, err := mgr.getFailurePercentage(provider, canaryConfig, trigger)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if failure != test.expected {
+				t.Errorf("expected failure percentage %v, got %v", test.expected, failure)
+			}
+		})
+	}
+}
+
+func TestEvaluateSLO(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		slo       fv1.CanarySLO
+		provider  *fakeMetricsProvider
+		value     float64
+		threshold float64
+		query     string
+		wantErr   bool
+	}{
+		{
+			name:      "latency in seconds",
+			slo:       fv1.CanarySLO{Name: "p99", Type: fv1.CanarySLOTypeLatency, Percentile: 99, Threshold: "500ms"},
+			provider:  &fakeMetricsProvider{latencies: map[string]float64{"5m": 0.75}},
+			value:     0.75,
+			threshold: 0.5,
+		},
+		{
+			name:     "invalid latency threshold",
+			slo:      fv1.CanarySLO{Name: "p99", Type: fv1.CanarySLOTypeLatency, Percentile: 99, Threshold: "fast"},
+			provider: &fakeMetricsProvider{},
+			wantErr:  true,
+		},
+		{
+			name: "query placeholders substituted",
+			slo: fv1.CanarySLO{Name: "errors", Type: fv1.CanarySLOTypeQuery, Threshold: "0.1",
+				Query: `sum(rate(errors{name="{{function}}",namespace="{{namespace}}",path="{{path}}",method="{{method}}"}[{{window}}]))`},
+			provider: &fakeMetricsProvider{values: map[string]float64{
+				`sum(rate(errors{name="hello-v2",namespace="fn",path="/hello",method="GET"}[5m]))`: 0.05,
+			}},
+			value:     0.05,
+			threshold: 0.1,
+			query:     `sum(rate(errors{name="hello-v2",namespace="fn",path="/hello",method="GET"}[5m]))`,
+		},
+		{
+			name:     "invalid query threshold",
+			slo:      fv1.CanarySLO{Name: "errors", Type: fv1.CanarySLOTypeQuery, Query: "up", Threshold: "high"},
+			provider: &fakeMetricsProvider{},
+			wantErr:  true,
+		},
+		{
+			name:     "provider error",
+			slo:      fv1.CanarySLO{Name: "errors", Type: fv1.CanarySLOTypeQuery, Query: "up", Threshold: "1"},
+			provider: &fakeMetricsProvider{err: errors.New("unavailable")},
+			wantErr:  true,
+		},
+		{
+			name:     "unsupported type",
+			slo:      fv1.CanarySLO{Name: "other", Type: "throughput", Threshold: "1"},
+			provider: &fakeMetricsProvider{},
+			wantErr:  true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			mgr := &canaryConfigMgr{logger: zap.NewNop()}
+			canaryConfig, trigger := makeTestCanaryConfig(nil)
+
+			value, threshold, err := mgr.evaluateSLO(test.provider, &test.slo, canaryConfig, trigger, "5m")
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != test.value || threshold != test.threshold {
+				t.Errorf("expected value %v and threshold %v, got %v and %v", test.value, test.threshold, value, threshold)
+			}
+			if test.slo.Type == fv1.CanarySLOTypeLatency && (len(test.provider.percentiles) != 1 || test.provider.percentiles[0] != test.slo.Percentile) {
+				t.Errorf("expected percentile %v, got %v", test.slo.Percentile, test.provider.percentiles)
+			}
+			if len(test.query) > 0 && (len(test.provider.queries) != 1 || test.provider.queries[0] != test.query) {
+				t.Errorf("expected query %q, got %q", test.query, test.provider.queries)
+			}
+		})
+	}
+}
+
+func TestEvaluateSLOPrometheusLatency(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.FormValue("query")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"status": "success", "data": {"resultType": "vector", "result": [{"metric": {}, "value": [1610000000, "0.25"]}]}}`)
+	}))
+	defer server.Close()
+
+	provider, err := MakePrometheusClient(zap.NewNop(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := &canaryConfigMgr{logger: zap.NewNop()}
+	canaryConfig, trigger := makeTestCanaryConfig(nil)
+	slo := &fv1.CanarySLO{Name: "p95", Type: fv1.CanarySLOTypeLatency, Percentile: 95, Threshold: "1s"}
+
+	value, threshold, err := mgr.evaluateSLO(provider, slo, canaryConfig, trigger, "5m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != 0.25 || threshold != 1 {
+		t.Errorf("expected value 0.25 and threshold 1, got %v and %v", value, threshold)
+	}
+	expected := `histogram_quantile(0.95, sum(rate(fission_function_latency_seconds_bucket{path="/hello",method="GET",name="hello-v2",namespace="fn"}[5m])) by (le))`
+	if query != expected {
+		t.Errorf("expected query %q, got %q", expected, query)
+	}
+}
+
+func TestGetViolatedSLO(t *testing.T) {
+	latency := fv1.CanarySLO{Name: "p99", Type: fv1.CanarySLOTypeLatency, Percentile: 99, Threshold: "500ms"}
+	errorRate := fv1.CanarySLO{Name: "errors", Type: fv1.CanarySLOTypeQuery, Query: "errors[{{window}}]", Threshold: "0.1"}
+
+	for _, test := range []struct {
+		name     string
+		windows  []string
+		slos     []fv1.CanarySLO
+		provider *fakeMetricsProvider
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "violated in every window",
+			windows:  []string{"1m", "10m"},
+			slos:     []fv1.CanarySLO{latency},
+			provider: &fakeMetricsProvider{latencies: map[string]float64{"1m": 0.9, "10m": 0.6}},
+			expected: "p99",
+		},
+		{
+			name:     "met in one window",
+			windows:  []string{"1m", "10m"},
+			slos:     []fv1.CanarySLO{latency},
+			provider: &fakeMetricsProvider{latencies: map[string]float64{"1m": 0.9, "10m": 0.4}},
+		},
+		{
+			name:     "no data in one window",
+			windows:  []string{"1m", "10m"},
+			slos:     []fv1.CanarySLO{latency},
+			provider: &fakeMetricsProvider{latencies: map[string]float64{"1m": 0.9}},
+		},
+		{
+			name:    "first violated SLO",
+			windows: []string{"1m", "10m"},
+			slos:    []fv1.CanarySLO{latency, errorRate},
+			provider: &fakeMetricsProvider{
+				latencies: map[string]float64{"1m": 0.1, "10m": 0.1},
+				values:    map[string]float64{"errors[1m]": 0.5, "errors[10m]": 0.2},
+			},
+			expected: "errors",
+		},
+		{
+			name:     "all met",
+			windows:  []string{"1m"},
+			slos:     []fv1.CanarySLO{latency, errorRate},
+			provider: &fakeMetricsProvider{latencies: map[string]float64{"1m": 0.5}, values: map[string]float64{"errors[1m]": 0.1}},
+		},
+		{
+			name:     "provider error",
+			windows:  []string{"1m"},
+			slos:     []fv1.CanarySLO{errorRate},
+			provider: &fakeMetricsProvider{err: errors.New("unavailable")},
+			wantErr:  true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			mgr := &canaryConfigMgr{logger: zap.NewNop()}
+			canaryConfig, trigger := makeTestCanaryConfig(test.windows, test.slos...)
+
+			violated, err := mgr.getViolatedSLO(test.provider, canaryConfig, trigger)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if violated != test.expected {
+				t.Errorf("expected violated SLO %q, got %q", test.expected, violated)
+			}
+		})
+	}
+}
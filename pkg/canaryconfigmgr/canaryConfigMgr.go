@@ -28,13 +28,12 @@ import (
 	"go.uber.org/zap"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 	k8sCache "k8s.io/client-go/tools/cache"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/generated/informers/externalversions"
 )
 
 const (
@@ -48,11 +47,42 @@ type canaryConfigMgr struct {
 	canaryConfigStore      k8sCache.Store
 	canaryConfigController k8sCache.Controller
 	promClient             *PrometheusApiClient
-	crdClient              rest.Interface
 	canaryCfgCancelFuncMap *canaryConfigCancelFuncMap
 }
 
-func MakeCanaryConfigMgr(logger *zap.Logger, fissionClient *crd.FissionClient, kubeClient *kubernetes.Clientset, crdClient rest.Interface, prometheusSvc string) (*canaryConfigMgr, error) {
+func MakeCanaryConfigMgr(logger *zap.Logger, fissionClient *crd.FissionClient, kubeClient *kubernetes.Clientset, prometheusSvc string) (*canaryConfigMgr, error) {
+	// prometheus is optional, canary configs can specify other metrics providers
+	var promClient *PrometheusApiClient
+	prometheusSvc, err := GetPrometheusSvcURL(logger, prometheusSvc)
+	if err != nil {
+		logger.Warn("prometheus server not found, only canary configs with metrics provider specified can be processed", zap.Error(err))
+	} else {
+		logger.Info("try to start canary config manager with prometheus service url", zap.String("prometheus", prometheusSvc))
+
+		promClient, err = MakePrometheusClient(logger, prometheusSvc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	configMgr := &canaryConfigMgr{
+		logger:                 logger.Named("canary_config_manager"),
+		fissionClient:          fissionClient,
+		kubeClient:             kubeClient,
+		promClient:             promClient,
+		canaryCfgCancelFuncMap: makecanaryConfigCancelFuncMap(),
+	}
+
+	store, controller := configMgr.initCanaryConfigController()
+	configMgr.canaryConfigStore = store
+	configMgr.canaryConfigController = controller
+
+	return configMgr, nil
+}
+
+// GetPrometheusSvcURL returns the given prometheus service url, or finds the url of the
+// prometheus server installed along with fission from environment variables if it's empty.
+func GetPrometheusSvcURL(logger *zap.Logger, prometheusSvc string) (string, error) {
 	if prometheusSvc == "" {
 		logger.Info("try to retrieve prometheus server information from environment variables")
 
@@ -71,43 +101,23 @@ func MakeCanaryConfigMgr(logger *zap.Logger, fissionClient *crd.FissionClient, k
 			}
 		}
 		if len(prometheusSvcHost) == 0 && len(prometheusSvcPort) == 0 {
-			return nil, errors.New("unable to get prometheus service url")
+			return "", errors.New("unable to get prometheus service url")
 		}
 		prometheusSvc = fmt.Sprintf("http://%v:%v", prometheusSvcHost, prometheusSvcPort)
 	}
 
-	logger.Info("try to start canary config manager with prometheus service url", zap.String("prometheus", prometheusSvc))
-
 	_, err := url.Parse(prometheusSvc)
 	if err != nil {
-		return nil, errors.Errorf("prometheus service url not found/invalid, cant create canary config manager: %v", prometheusSvc)
+		return "", errors.Errorf("prometheus service url not found/invalid: %v", prometheusSvc)
 	}
 
-	promClient, err := MakePrometheusClient(logger, prometheusSvc)
-	if err != nil {
-		return nil, err
-	}
-
-	configMgr := &canaryConfigMgr{
-		logger:                 logger.Named("canary_config_manager"),
-		fissionClient:          fissionClient,
-		kubeClient:             kubeClient,
-		crdClient:              crdClient,
-		promClient:             promClient,
-		canaryCfgCancelFuncMap: makecanaryConfigCancelFuncMap(),
-	}
-
-	store, controller := configMgr.initCanaryConfigController()
-	configMgr.canaryConfigStore = store
-	configMgr.canaryConfigController = controller
-
-	return configMgr, nil
+	return prometheusSvc, nil
 }
 
 func (canaryCfgMgr *canaryConfigMgr) initCanaryConfigController() (k8sCache.Store, k8sCache.Controller) {
 	resyncPeriod := 30 * time.Second
-	listWatch := k8sCache.NewListWatchFromClient(canaryCfgMgr.crdClient, "canaryconfigs", metav1.NamespaceAll, fields.Everything())
-	store, controller := k8sCache.NewInformer(listWatch, &fv1.CanaryConfig{}, resyncPeriod,
+	informer := externalversions.NewSharedInformerFactory(canaryCfgMgr.fissionClient, resyncPeriod).Core().V1().CanaryConfigs().Informer()
+	informer.AddEventHandler(
 		k8sCache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj interface{}) {
 				canaryConfig := obj.(*fv1.CanaryConfig)
@@ -135,7 +145,7 @@ func (canaryCfgMgr *canaryConfigMgr) initCanaryConfigController() (k8sCache.Stor
 			},
 		})
 
-	return store, controller
+	return informer.GetStore(), informer
 }
 
 func (canaryCfgMgr *canaryConfigMgr) Run(ctx context.Context) {
@@ -176,6 +186,19 @@ func (canaryCfgMgr *canaryConfigMgr) addCanaryConfig(canaryConfig *fv1.CanaryCon
 			zap.String("version", canaryConfig.ObjectMeta.ResourceVersion))
 		return
 	}
+
+	if canaryConfig.Spec.Match != nil {
+		err = canaryCfgMgr.applyMatchRoute(canaryConfig)
+		if err != nil {
+			// the match route is set again when weights get incremented
+			canaryCfgMgr.logger.Error("error applying match route of canary config",
+				zap.Error(err),
+				zap.String("name", canaryConfig.ObjectMeta.Name),
+				zap.String("namespace", canaryConfig.ObjectMeta.Namespace),
+				zap.String("version", canaryConfig.ObjectMeta.ResourceVersion))
+		}
+	}
+
 	canaryCfgMgr.processCanaryConfig(&ctx, canaryConfig, ticker)
 }
 
@@ -275,9 +298,19 @@ func (canaryCfgMgr *canaryConfigMgr) RollForwardOrBack(canaryConfig *fv1.CanaryC
 	}
 
 	if triggerObj.Spec.FunctionReference.Type == fv1.FunctionReferenceTypeFunctionWeights &&
-		triggerObj.Spec.FunctionReference.FunctionWeights[canaryConfig.Spec.NewFunction] != 0 {
-		failurePercent, err := canaryCfgMgr.promClient.GetFunctionFailurePercentage(triggerObj.Spec.RelativeURL, triggerObj.Spec.Method,
-			canaryConfig.Spec.NewFunction, canaryConfig.ObjectMeta.Namespace, canaryConfig.Spec.WeightIncrementDuration)
+		(triggerObj.Spec.FunctionReference.FunctionWeights[canaryConfig.Spec.NewFunction] != 0 || canaryConfig.Spec.Match != nil) {
+		provider, err := canaryCfgMgr.getMetricsProvider(canaryConfig)
+		if err != nil {
+			// silently ignore. wait for next window to increment weight
+			canaryCfgMgr.logger.Error("error getting metrics provider",
+				zap.Error(err),
+				zap.String("name", canaryConfig.ObjectMeta.Name),
+				zap.String("namespace", canaryConfig.ObjectMeta.Namespace),
+				zap.String("version", canaryConfig.ObjectMeta.ResourceVersion))
+			return
+		}
+
+		failurePercent, err := canaryCfgMgr.getFailurePercentage(provider, canaryConfig, triggerObj)
 
 		if err != nil {
 			// silently ignore. wait for next window to increment weight
@@ -321,6 +354,36 @@ func (canaryCfgMgr *canaryConfigMgr) RollForwardOrBack(canaryConfig *fv1.CanaryC
 			close(quit)
 			return
 		}
+
+		violatedSLO, err := canaryCfgMgr.getViolatedSLO(provider, canaryConfig, triggerObj)
+		if err != nil {
+			// silently ignore. wait for next window to increment weight
+			canaryCfgMgr.logger.Error("error evaluating SLOs",
+				zap.Error(err),
+				zap.String("name", canaryConfig.ObjectMeta.Name),
+				zap.String("namespace", canaryConfig.ObjectMeta.Namespace),
+				zap.String("version", canaryConfig.ObjectMeta.ResourceVersion))
+			return
+		}
+
+		if len(violatedSLO) > 0 {
+			canaryCfgMgr.logger.Error("SLO violated in all analysis windows, so rolling back",
+				zap.String("slo", violatedSLO),
+				zap.String("name", canaryConfig.ObjectMeta.Name),
+				zap.String("namespace", canaryConfig.ObjectMeta.Namespace),
+				zap.String("version", canaryConfig.ObjectMeta.ResourceVersion))
+			ticker.Stop()
+			err := canaryCfgMgr.rollback(canaryConfig, triggerObj)
+			if err != nil {
+				canaryCfgMgr.logger.Error("error rolling back canary config",
+					zap.Error(err),
+					zap.String("name", canaryConfig.ObjectMeta.Name),
+					zap.String("namespace", canaryConfig.ObjectMeta.Namespace),
+					zap.String("version", canaryConfig.ObjectMeta.ResourceVersion))
+			}
+			close(quit)
+			return
+		}
 	}
 
 	doneProcessingCanaryConfig, err := canaryCfgMgr.rollForward(canaryConfig, triggerObj)
@@ -359,7 +422,8 @@ func (canaryCfgMgr *canaryConfigMgr) RollForwardOrBack(canaryConfig *fv1.CanaryC
 	}
 }
 
-func (canaryCfgMgr *canaryConfigMgr) updateHttpTriggerWithRetries(triggerName, triggerNamespace string, fnWeights map[string]int) (err error) {
+func (canaryCfgMgr *canaryConfigMgr) updateHttpTriggerWithRetries(triggerName, triggerNamespace string, fnWeights map[string]int,
+	matchRoute *fv1.FunctionMatchRoute) (err error) {
 	for i := 0; i < maxRetries; i++ {
 		triggerObj, err := canaryCfgMgr.fissionClient.CoreV1().HTTPTriggers(triggerNamespace).Get(triggerName, metav1.GetOptions{})
 		if err != nil {
@@ -369,6 +433,7 @@ func (canaryCfgMgr *canaryConfigMgr) updateHttpTriggerWithRetries(triggerName, t
 		}
 
 		triggerObj.Spec.FunctionReference.FunctionWeights = fnWeights
+		triggerObj.Spec.FunctionReference.MatchRoute = matchRoute
 
 		_, err = canaryCfgMgr.fissionClient.CoreV1().HTTPTriggers(triggerNamespace).Update(triggerObj)
 		switch {
@@ -445,7 +510,8 @@ func (canaryCfgMgr *canaryConfigMgr) rollback(canaryConfig *fv1.CanaryConfig, tr
 	functionWeights[canaryConfig.Spec.NewFunction] = 0
 	functionWeights[canaryConfig.Spec.OldFunction] = 100
 
-	err := canaryCfgMgr.updateHttpTriggerWithRetries(trigger.ObjectMeta.Name, trigger.ObjectMeta.Namespace, functionWeights)
+	// stop sending matched requests to the new function as well
+	err := canaryCfgMgr.updateHttpTriggerWithRetries(trigger.ObjectMeta.Name, trigger.ObjectMeta.Namespace, functionWeights, nil)
 	if err != nil {
 		return err
 	}
@@ -478,10 +544,37 @@ func (canaryCfgMgr *canaryConfigMgr) rollForward(canaryConfig *fv1.CanaryConfig,
 		zap.String("namespace", canaryConfig.ObjectMeta.Namespace),
 		zap.Any("function_weights", functionWeights))
 
-	err := canaryCfgMgr.updateHttpTriggerWithRetries(trigger.ObjectMeta.Name, trigger.ObjectMeta.Namespace, functionWeights)
+	// once the new function receives all the traffic, the match route is no longer needed
+	var matchRoute *fv1.FunctionMatchRoute
+	if !doneProcessingCanaryConfig {
+		matchRoute = getMatchRoute(canaryConfig)
+	}
+
+	err := canaryCfgMgr.updateHttpTriggerWithRetries(trigger.ObjectMeta.Name, trigger.ObjectMeta.Namespace, functionWeights, matchRoute)
 	return doneProcessingCanaryConfig, err
 }
 
+// applyMatchRoute starts routing the requests matching the rule of canary config to the new function
+// immediately, instead of waiting for the first weight increment.
+func (canaryCfgMgr *canaryConfigMgr) applyMatchRoute(canaryConfig *fv1.CanaryConfig) error {
+	triggerObj, err := canaryCfgMgr.fissionClient.CoreV1().HTTPTriggers(canaryConfig.ObjectMeta.Namespace).Get(canaryConfig.Spec.Trigger, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrap(err, "error getting http trigger object")
+	}
+	return canaryCfgMgr.updateHttpTriggerWithRetries(triggerObj.ObjectMeta.Name, triggerObj.ObjectMeta.Namespace,
+		triggerObj.Spec.FunctionReference.FunctionWeights, getMatchRoute(canaryConfig))
+}
+
+func getMatchRoute(canaryConfig *fv1.CanaryConfig) *fv1.FunctionMatchRoute {
+	if canaryConfig.Spec.Match == nil {
+		return nil
+	}
+	return &fv1.FunctionMatchRoute{
+		Match:    *canaryConfig.Spec.Match,
+		Function: canaryConfig.Spec.NewFunction,
+	}
+}
+
 func (canaryCfgMgr *canaryConfigMgr) reSyncCanaryConfigs() {
 	for _, obj := range canaryCfgMgr.canaryConfigStore.List() {
 		canaryConfig := obj.(*fv1.CanaryConfig)
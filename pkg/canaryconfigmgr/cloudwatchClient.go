@@ -0,0 +1,226 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package canaryconfigmgr
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	cloudWatchDefaultRegion          = "us-east-1"
+	cloudWatchDefaultMetricNamespace = "ContainerInsights/Prometheus"
+)
+
+type (
+	// CloudWatchApiClient queries router metrics collected by the CloudWatch agent with
+	// Prometheus scraping, keeping namespace, name, path and method as dimensions.
+	CloudWatchApiClient struct {
+		logger          *zap.Logger
+		client          cloudwatchiface.CloudWatchAPI
+		metricNamespace string
+	}
+)
+
+// MakeCloudWatchClient returns a client of the CloudWatch API with the credentials of
+// the secret of the metrics provider, or else those of the canary config manager itself.
+func MakeCloudWatchClient(logger *zap.Logger, provider *fv1.CanaryMetricsProvider, secret map[string]string) (*CloudWatchApiClient, error) {
+	region := provider.Region
+	if len(region) == 0 {
+		region = cloudWatchDefaultRegion
+	}
+
+	metricNamespace := provider.MetricNamespace
+	if len(metricNamespace) == 0 {
+		metricNamespace = cloudWatchDefaultMetricNamespace
+	}
+
+	config := &aws.Config{
+		Region:     aws.String(region),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	if len(provider.Address) > 0 {
+		config.Endpoint = aws.String(strings.TrimSuffix(provider.Address, "/"))
+	}
+
+	// without credentials in the secret, the default credential chain
+	// of the SDK applies
+	accessKeyID, secretAccessKey := secret["access-key-id"], secret["secret-access-key"]
+	if len(accessKeyID) > 0 || len(secretAccessKey) > 0 {
+		if len(accessKeyID) == 0 || len(secretAccessKey) == 0 {
+			return nil, errors.New("both access-key-id and secret-access-key are required in the secret of cloudwatch metrics provider")
+		}
+		config.Credentials = credentials.NewStaticCredentials(accessKeyID, secretAccessKey, secret["session-token"])
+	}
+
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating aws session for cloudwatch metrics provider")
+	}
+
+	return &CloudWatchApiClient{
+		logger:          logger.Named("cloudwatch_api_client"),
+		client:          cloudwatch.New(sess),
+		metricNamespace: metricNamespace,
+	}, nil
+}
+
+func (c *CloudWatchApiClient) GetFunctionFailurePercentage(path, method, funcName, funcNs string, window string) (float64, error) {
+	duration, err := time.ParseDuration(window)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error parsing window %v", window)
+	}
+
+	dimensions := cloudWatchDimensions(path, method, funcName, funcNs)
+
+	// counters are reported as deltas, the sum of which is the count within the window
+	reqs, err := c.getMetricStat("fission_function_calls_total", dimensions, cloudwatch.StatisticSum, duration)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(reqs) || reqs <= 0 {
+		return -1, fmt.Errorf("no requests to this url %v and method %v in the window: %v", path, method, window)
+	}
+
+	failedReqs, err := c.getMetricStat("fission_function_errors_total", dimensions, cloudwatch.StatisticSum, duration)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(failedReqs) {
+		failedReqs = 0
+	}
+
+	c.logger.Info("function requests",
+		zap.Float64("requests", reqs),
+		zap.Float64("failed_requests", failedReqs),
+		zap.String("function", funcName))
+
+	return (failedReqs / reqs) * 100, nil
+}
+
+// GetFunctionLatencyPercentile returns the percentile from the quantiles of the function
+// call duration summary, so only 50, 90 and 99 percentiles are supported.
+func (c *CloudWatchApiClient) GetFunctionLatencyPercentile(path, method, funcName, funcNs string, percentile int, window string) (float64, error) {
+	duration, err := time.ParseDuration(window)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error parsing window %v", window)
+	}
+
+	switch percentile {
+	case 50, 90, 99:
+	default:
+		return 0, errors.Errorf("percentile %v is not supported by cloudwatch metrics provider, use a query SLO instead", percentile)
+	}
+
+	dimensions := cloudWatchDimensions(path, method, funcName, funcNs)
+	dimensions = append(dimensions, &cloudwatch.Dimension{
+		Name:  aws.String("quantile"),
+		Value: aws.String(fmt.Sprint(float64(percentile) / 100)),
+	})
+
+	return c.getMetricStat("fission_function_duration_seconds", dimensions, cloudwatch.StatisticMaximum, duration)
+}
+
+// GetQueryValue returns the latest value of a metric math or Metrics Insights expression.
+func (c *CloudWatchApiClient) GetQueryValue(queryString string) (float64, error) {
+	return c.getMetricData(&cloudwatch.MetricDataQuery{
+		Id:         aws.String("q1"),
+		Expression: aws.String(queryString),
+		Period:     aws.Int64(60),
+	}, defaultQueryLookback)
+}
+
+// getMetricStat returns the statistic of the metric over the whole window.
+func (c *CloudWatchApiClient) getMetricStat(metricName string, dimensions []*cloudwatch.Dimension, stat string, window time.Duration) (float64, error) {
+	// period must be a multiple of 60 seconds
+	period := int64(math.Ceil(window.Minutes())) * 60
+	if period < 60 {
+		period = 60
+	}
+
+	return c.getMetricData(&cloudwatch.MetricDataQuery{
+		Id: aws.String("m1"),
+		MetricStat: &cloudwatch.MetricStat{
+			Metric: &cloudwatch.Metric{
+				Namespace:  aws.String(c.metricNamespace),
+				MetricName: aws.String(metricName),
+				Dimensions: dimensions,
+			},
+			Period: aws.Int64(period),
+			Stat:   aws.String(stat),
+		},
+	}, time.Duration(period)*time.Second)
+}
+
+// getMetricData executes the query over the last lookback duration and returns the sum
+// of the latest value of each result, or NaN if there is no data.
+func (c *CloudWatchApiClient) getMetricData(query *cloudwatch.MetricDataQuery, lookback time.Duration) (float64, error) {
+	now := time.Now().UTC()
+	input := &cloudwatch.GetMetricDataInput{
+		MetricDataQueries: []*cloudwatch.MetricDataQuery{query},
+		StartTime:         aws.Time(now.Add(-lookback)),
+		EndTime:           aws.Time(now),
+		ScanBy:            aws.String(cloudwatch.ScanByTimestampDescending),
+	}
+
+	total, found := float64(0), false
+	latest := make(map[string]bool)
+	err := c.client.GetMetricDataPages(input, func(page *cloudwatch.GetMetricDataOutput, lastPage bool) bool {
+		for _, r := range page.MetricDataResults {
+			// values are ordered by descending timestamps, so the first
+			// value of a result is the latest one across all pages
+			id := aws.StringValue(r.Id)
+			if latest[id] || len(r.Values) == 0 {
+				continue
+			}
+			latest[id] = true
+			total += aws.Float64Value(r.Values[0])
+			found = true
+		}
+		return true
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "error getting cloudwatch metric data")
+	}
+
+	if !found {
+		return math.NaN(), nil
+	}
+	return total, nil
+}
+
+func cloudWatchDimensions(path, method, funcName, funcNs string) []*cloudwatch.Dimension {
+	return []*cloudwatch.Dimension{
+		{Name: aws.String("namespace"), Value: aws.String(funcNs)},
+		{Name: aws.String("name"), Value: aws.String(funcName)},
+		{Name: aws.String("path"), Value: aws.String(path)},
+		{Name: aws.String("method"), Value: aws.String(method)},
+	}
+}
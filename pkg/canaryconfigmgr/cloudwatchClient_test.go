@@ -0,0 +1,171 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package canaryconfigmgr
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const cloudWatchResponse = `<GetMetricDataResponse xmlns="http://monitoring.amazonaws.com/doc/2010-08-01/">
+  <GetMetricDataResult>
+    <MetricDataResults>
+      %v
+    </MetricDataResults>
+  </GetMetricDataResult>
+  <ResponseMetadata>
+    <RequestId>1</RequestId>
+  </ResponseMetadata>
+</GetMetricDataResponse>`
+
+func cloudWatchResult(id string, values ...float64) string {
+	var result strings.Builder
+	fmt.Fprintf(&result, "<member><Id>%v</Id><StatusCode>Complete</StatusCode><Values>", id)
+	for _, v := range values {
+		fmt.Fprintf(&result, "<member>%v</member>", v)
+	}
+	result.WriteString("</Values></member>")
+	return result.String()
+}
+
+func TestCloudWatchClient(t *testing.T) {
+	var queries []map[string]string
+	values := map[string][]float64{
+		"fission_function_calls_total":      {40, 10},
+		"fission_function_errors_total":     {2},
+		"fission_function_duration_seconds": {0.25, 0.5},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			t.Errorf("expected request signed with access key id, got %q", r.Header.Get("Authorization"))
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		query := make(map[string]string)
+		for k := range r.PostForm {
+			query[strings.TrimPrefix(k, "MetricDataQueries.member.1.")] = r.PostForm.Get(k)
+		}
+		queries = append(queries, query)
+
+		w.Header().Set("Content-Type", "text/xml")
+		if query["Expression"] == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `<ErrorResponse><Error><Type>Sender</Type><Code>ValidationError</Code><Message>invalid expression</Message></Error><RequestId>1</RequestId></ErrorResponse>`)
+			return
+		}
+		if len(query["Expression"]) > 0 {
+			fmt.Fprintf(w, cloudWatchResponse, cloudWatchResult("q1", 7))
+			return
+		}
+		fmt.Fprintf(w, cloudWatchResponse, cloudWatchResult(query["Id"], values[query["MetricStat.Metric.MetricName"]]...))
+	}))
+	defer server.Close()
+
+	c, err := MakeCloudWatchClient(zap.NewNop(), &fv1.CanaryMetricsProvider{
+		Type:    fv1.CanaryMetricsProviderCloudWatch,
+		Address: server.URL,
+		Region:  "eu-west-1",
+	}, map[string]string{"access-key-id": "AKID", "secret-access-key": "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failure, err := c.GetFunctionFailurePercentage("/hello", "GET", "hello", "default", "90s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failure != 5 {
+		t.Errorf("expected failure percentage from the latest values, got %v", failure)
+	}
+	want := map[string]string{
+		"Action":                       "GetMetricData",
+		"ScanBy":                       "TimestampDescending",
+		"Id":                           "m1",
+		"MetricStat.Metric.Namespace":  cloudWatchDefaultMetricNamespace,
+		"MetricStat.Metric.MetricName": "fission_function_calls_total",
+		"MetricStat.Metric.Dimensions.member.1.Name":  "namespace",
+		"MetricStat.Metric.Dimensions.member.1.Value": "default",
+		"MetricStat.Metric.Dimensions.member.3.Value": "/hello",
+		"MetricStat.Metric.Dimensions.member.4.Value": "GET",
+		"MetricStat.Period":                           "120",
+		"MetricStat.Stat":                             "Sum",
+	}
+	for k, v := range want {
+		if queries[0][k] != v {
+			t.Errorf("expected %v of the calls query to be %q, got %q", k, v, queries[0][k])
+		}
+	}
+
+	latency, err := c.GetFunctionLatencyPercentile("/hello", "GET", "hello", "default", 99, "1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latency != 0.25 {
+		t.Errorf("expected latest latency, got %v", latency)
+	}
+	query := queries[len(queries)-1]
+	if query["MetricStat.Metric.Dimensions.member.5.Name"] != "quantile" ||
+		query["MetricStat.Metric.Dimensions.member.5.Value"] != "0.99" ||
+		query["MetricStat.Stat"] != "Maximum" {
+		t.Errorf("unexpected latency query %v", query)
+	}
+	if _, err = c.GetFunctionLatencyPercentile("/hello", "GET", "hello", "default", 95, "1m"); err == nil {
+		t.Error("expected unsupported percentile to fail")
+	}
+
+	value, err := c.GetQueryValue("SUM(METRICS())")
+	if err != nil {
+		t.Fatal(err)
+	}
+	query = queries[len(queries)-1]
+	if value != 7 || query["Id"] != "q1" || query["Expression"] != "SUM(METRICS())" || query["Period"] != "60" {
+		t.Errorf("unexpected value %v of expression query %v", value, query)
+	}
+
+	_, err = c.GetQueryValue("bad")
+	if err == nil || !strings.Contains(err.Error(), "invalid expression") {
+		t.Errorf("expected cloudwatch error message, got %v", err)
+	}
+
+	values["fission_function_calls_total"] = nil
+	if failure, err = c.GetFunctionFailurePercentage("/hello", "GET", "hello", "default", "1m"); err == nil || failure != -1 {
+		t.Errorf("expected no requests to fail with -1, got %v, %v", failure, err)
+	}
+	values["fission_function_duration_seconds"] = nil
+	if latency, err = c.GetFunctionLatencyPercentile("/hello", "GET", "hello", "default", 50, "1m"); err != nil || !math.IsNaN(latency) {
+		t.Errorf("expected no data to be NaN, got %v, %v", latency, err)
+	}
+}
+
+func TestMakeCloudWatchClient(t *testing.T) {
+	provider := &fv1.CanaryMetricsProvider{Type: fv1.CanaryMetricsProviderCloudWatch}
+	if _, err := MakeCloudWatchClient(zap.NewNop(), provider, map[string]string{"access-key-id": "AKID"}); err == nil {
+		t.Error("expected access key id without secret access key to fail")
+	}
+	if _, err := MakeCloudWatchClient(zap.NewNop(), provider, map[string]string{}); err != nil {
+		t.Errorf("expected default credentials without a secret, got %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package canaryconfigmgr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	datadogDefaultAddress = "https://api.datadoghq.com"
+
+	// lookback of queries without a window, e.g. query SLOs
+	defaultQueryLookback = 5 * time.Minute
+)
+
+type (
+	// DatadogApiClient queries router metrics collected by the Datadog agent with
+	// the OpenMetrics integration under the "fission" namespace, e.g. the metric
+	// fission_function_calls_total is queried as fission.function_calls_total.
+	// Function call latency has to be sent as a distribution to query percentiles.
+	DatadogApiClient struct {
+		logger     *zap.Logger
+		httpClient *http.Client
+		address    string
+		apiKey     string
+		appKey     string
+	}
+
+	datadogQueryResponse struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Series []struct {
+			Pointlist [][]*float64 `json:"pointlist"`
+		} `json:"series"`
+	}
+)
+
+func MakeDatadogClient(logger *zap.Logger, provider *fv1.CanaryMetricsProvider, credentials map[string]string) (*DatadogApiClient, error) {
+	address := provider.Address
+	if len(address) == 0 {
+		address = datadogDefaultAddress
+	}
+
+	apiKey, appKey := credentials["api-key"], credentials["application-key"]
+	if len(apiKey) == 0 || len(appKey) == 0 {
+		return nil, errors.New("api-key and application-key are required in the secret of datadog metrics provider")
+	}
+
+	return &DatadogApiClient{
+		logger:     logger.Named("datadog_api_client"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		address:    strings.TrimSuffix(address, "/"),
+		apiKey:     apiKey,
+		appKey:     appKey,
+	}, nil
+}
+
+func (c *DatadogApiClient) GetFunctionFailurePercentage(path, method, funcName, funcNs string, window string) (float64, error) {
+	duration, err := time.ParseDuration(window)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error parsing window %v", window)
+	}
+
+	tags := datadogTags(path, method, funcName, funcNs)
+	rollup := int(duration.Seconds())
+
+	// sum of all points within the window
+	reqs, err := c.query(fmt.Sprintf("sum:fission.function_calls_total{%v}.as_count().rollup(sum, %v)", tags, rollup), duration, false)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(reqs) || reqs <= 0 {
+		return -1, fmt.Errorf("no requests to this url %v and method %v in the window: %v", path, method, window)
+	}
+
+	failedReqs, err := c.query(fmt.Sprintf("sum:fission.function_errors_total{%v}.as_count().rollup(sum, %v)", tags, rollup), duration, false)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(failedReqs) {
+		failedReqs = 0
+	}
+
+	c.logger.Info("function requests",
+		zap.Float64("requests", reqs),
+		zap.Float64("failed_requests", failedReqs),
+		zap.String("function", funcName))
+
+	return (failedReqs / reqs) * 100, nil
+}
+
+func (c *DatadogApiClient) GetFunctionLatencyPercentile(path, method, funcName, funcNs string, percentile int, window string) (float64, error) {
+	duration, err := time.ParseDuration(window)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error parsing window %v", window)
+	}
+
+	queryString := fmt.Sprintf("p%v:fission.function_latency_seconds{%v}.rollup(max, %v)",
+		percentile, datadogTags(path, method, funcName, funcNs), int(duration.Seconds()))
+	return c.query(queryString, duration, true)
+}
+
+func (c *DatadogApiClient) GetQueryValue(queryString string) (float64, error) {
+	return c.query(queryString, defaultQueryLookback, true)
+}
+
+// query executes the query over the last lookback duration. If latest is true, the sum of
+// the latest point of each series is returned, otherwise the sum of all points. NaN is returned
+// if the result is empty.
+func (c *DatadogApiClient) query(queryString string, lookback time.Duration, latest bool) (float64, error) {
+	now := time.Now()
+	params := url.Values{}
+	params.Set("from", fmt.Sprint(now.Add(-lookback).Unix()))
+	params.Set("to", fmt.Sprint(now.Unix()))
+	params.Set("query", queryString)
+
+	req, err := http.NewRequest(http.MethodGet, c.address+"/api/v1/query?"+params.Encode(), nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "error creating datadog query request")
+	}
+	req.Header.Set("DD-API-KEY", c.apiKey)
+	req.Header.Set("DD-APPLICATION-KEY", c.appKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error executing query: %s", queryString)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, errors.Wrap(err, "error reading datadog query response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, errors.Errorf("error executing query: %s, status code: %v, response: %s", queryString, resp.StatusCode, string(body))
+	}
+
+	result := &datadogQueryResponse{}
+	err = json.Unmarshal(body, result)
+	if err != nil {
+		return 0, errors.Wrap(err, "error decoding datadog query response")
+	}
+	if result.Status == "error" {
+		return 0, errors.Errorf("error executing query: %s: %v", queryString, result.Error)
+	}
+
+	total, found := float64(0), false
+	for _, series := range result.Series {
+		for i := len(series.Pointlist) - 1; i >= 0; i-- {
+			// a point is [timestamp, value], value is null if there is no data
+			point := series.Pointlist[i]
+			if len(point) != 2 || point[1] == nil {
+				continue
+			}
+			total += *point[1]
+			found = true
+			if latest {
+				break
+			}
+		}
+	}
+
+	if !found {
+		return math.NaN(), nil
+	}
+	return total, nil
+}
+
+func datadogTags(path, method, funcName, funcNs string) string {
+	return fmt.Sprintf("path:%v,method:%v,name:%v,namespace:%v", path, method, funcName, funcNs)
+}
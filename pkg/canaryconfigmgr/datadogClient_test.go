@@ -0,0 +1,115 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package canaryconfigmgr
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestDatadogClient(t *testing.T) {
+	var queries []string
+	series := map[string]string{
+		"fission.function_calls_total":     `[[1, 10], [2, null], [3, 30]]`,
+		"fission.function_errors_total":    `[[1, 1], [3, 1]]`,
+		"fission.function_latency_seconds": `[[1, 0.5], [2, 0.25], [3, null]]`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			t.Errorf("unexpected path %v", r.URL.Path)
+		}
+		if r.Header.Get("DD-API-KEY") != "api" || r.Header.Get("DD-APPLICATION-KEY") != "app" {
+			t.Errorf("expected api and application keys, got %v", r.Header)
+		}
+		query := r.URL.Query().Get("query")
+		queries = append(queries, query)
+
+		w.Header().Set("Content-Type", "application/json")
+		if query == "bad" {
+			fmt.Fprint(w, `{"status": "error", "error": "invalid query"}`)
+			return
+		}
+		for metric, points := range series {
+			if strings.Contains(query, metric+"{") {
+				fmt.Fprintf(w, `{"status": "ok", "series": [{"pointlist": %v}]}`, points)
+				return
+			}
+		}
+		fmt.Fprint(w, `{"status": "ok", "series": []}`)
+	}))
+	defer server.Close()
+
+	c, err := MakeDatadogClient(zap.NewNop(), &fv1.CanaryMetricsProvider{
+		Type:    fv1.CanaryMetricsProviderDatadog,
+		Address: server.URL + "/",
+	}, map[string]string{"api-key": "api", "application-key": "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// counts are summed over the window
+	failure, err := c.GetFunctionFailurePercentage("/hello", "GET", "hello", "default", "2m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failure != 5 {
+		t.Errorf("expected failure percentage from the sum of points, got %v", failure)
+	}
+	if want := "sum:fission.function_calls_total{path:/hello,method:GET,name:hello,namespace:default}.as_count().rollup(sum, 120)"; queries[0] != want {
+		t.Errorf("expected query %q, got %q", want, queries[0])
+	}
+
+	// latencies are the latest non-null point
+	latency, err := c.GetFunctionLatencyPercentile("/hello", "GET", "hello", "default", 95, "1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latency != 0.25 {
+		t.Errorf("expected latest latency, got %v", latency)
+	}
+	if want := "p95:fission.function_latency_seconds{path:/hello,method:GET,name:hello,namespace:default}.rollup(max, 60)"; queries[len(queries)-1] != want {
+		t.Errorf("expected query %q, got %q", want, queries[len(queries)-1])
+	}
+
+	if _, err = c.GetQueryValue("bad"); err == nil || !strings.Contains(err.Error(), "invalid query") {
+		t.Errorf("expected datadog error message, got %v", err)
+	}
+	value, err := c.GetQueryValue("avg:other{*}")
+	if err != nil || !math.IsNaN(value) {
+		t.Errorf("expected empty result to be NaN, got %v, %v", value, err)
+	}
+
+	delete(series, "fission.function_calls_total")
+	if failure, err = c.GetFunctionFailurePercentage("/hello", "GET", "hello", "default", "1m"); err == nil || failure != -1 {
+		t.Errorf("expected no requests to fail with -1, got %v, %v", failure, err)
+	}
+}
+
+func TestMakeDatadogClient(t *testing.T) {
+	provider := &fv1.CanaryMetricsProvider{Type: fv1.CanaryMetricsProviderDatadog}
+	if _, err := MakeDatadogClient(zap.NewNop(), provider, map[string]string{"api-key": "api"}); err == nil {
+		t.Error("expected missing application key to fail")
+	}
+}
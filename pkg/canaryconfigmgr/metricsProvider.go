@@ -0,0 +1,87 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package canaryconfigmgr
+
+import (
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+type (
+	// MetricsProvider is a metrics backend canary configs are analysed with.
+	MetricsProvider interface {
+		// GetFunctionFailurePercentage returns the percentage of failed requests to the function
+		// within the window, or -1 along with an error if there were no requests.
+		GetFunctionFailurePercentage(path, method, funcName, funcNs string, window string) (float64, error)
+
+		// GetFunctionLatencyPercentile returns the given percentile of the function call duration
+		// in seconds within the window, or NaN if there were no requests.
+		GetFunctionLatencyPercentile(path, method, funcName, funcNs string, percentile int, window string) (float64, error)
+
+		// GetQueryValue returns the value of a query in the query language of the provider.
+		GetQueryValue(queryString string) (float64, error)
+	}
+)
+
+// getMetricsProvider returns the metrics provider canary config is analysed with.
+func (canaryCfgMgr *canaryConfigMgr) getMetricsProvider(canaryConfig *fv1.CanaryConfig) (MetricsProvider, error) {
+	provider := canaryConfig.Spec.MetricsProvider
+
+	if provider == nil || (provider.Type == fv1.CanaryMetricsProviderPrometheus && len(provider.Address) == 0) {
+		if canaryCfgMgr.promClient == nil {
+			return nil, errors.New("no prometheus server configured, specify a metrics provider for canary config")
+		}
+		return canaryCfgMgr.promClient, nil
+	}
+
+	credentials, err := canaryCfgMgr.getMetricsProviderCredentials(canaryConfig.ObjectMeta.Namespace, provider.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	switch provider.Type {
+	case fv1.CanaryMetricsProviderPrometheus:
+		return MakePrometheusClient(canaryCfgMgr.logger, provider.Address)
+	case fv1.CanaryMetricsProviderDatadog:
+		return MakeDatadogClient(canaryCfgMgr.logger, provider, credentials)
+	case fv1.CanaryMetricsProviderCloudWatch:
+		return MakeCloudWatchClient(canaryCfgMgr.logger, provider, credentials)
+	case fv1.CanaryMetricsProviderStackdriver:
+		return MakeStackdriverClient(canaryCfgMgr.logger, provider, credentials)
+	default:
+		return nil, errors.Errorf("unsupported metrics provider: %v", provider.Type)
+	}
+}
+
+// getMetricsProviderCredentials returns the data of the secret holding metrics provider credentials.
+func (canaryCfgMgr *canaryConfigMgr) getMetricsProviderCredentials(namespace string, secretName string) (map[string]string, error) {
+	credentials := make(map[string]string)
+	if len(secretName) == 0 {
+		return credentials, nil
+	}
+
+	secret, err := canaryCfgMgr.kubeClient.CoreV1().Secrets(namespace).Get(secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting metrics provider secret %v", secretName)
+	}
+	for k, v := range secret.Data {
+		credentials[k] = string(v)
+	}
+	return credentials, nil
+}
@@ -18,6 +18,7 @@ package canaryconfigmgr
 
 import (
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/pkg/errors"
@@ -34,8 +35,15 @@ type PrometheusApiClient struct {
 }
 
 func MakePrometheusClient(logger *zap.Logger, prometheusSvc string) (*PrometheusApiClient, error) {
+	return makePrometheusClient(logger, prometheusSvc, nil)
+}
+
+// makePrometheusClient creates a client of the Prometheus compatible API at prometheusSvc,
+// roundTripper is used to send requests if it's not nil.
+func makePrometheusClient(logger *zap.Logger, prometheusSvc string, roundTripper http.RoundTripper) (*PrometheusApiClient, error) {
 	promApiConfig := prometheus.Config{
-		Address: prometheusSvc,
+		Address:      prometheusSvc,
+		RoundTripper: roundTripper,
 	}
 
 	promApiClient, err := prometheus.NewClient(promApiConfig)
@@ -124,6 +132,53 @@ func (promApiClient *PrometheusApiClient) GetTotalFailedRequestsToFuncInWindow(f
 	return failedReqsInCurrentWindow, nil
 }
 
+// GetFunctionLatencyPercentile returns the given percentile of the function call duration in seconds
+// within the window. NaN is returned if there were no requests to the function during the window.
+func (promApiClient *PrometheusApiClient) GetFunctionLatencyPercentile(path, method, funcName, funcNs string, percentile int, window string) (float64, error) {
+	queryString := fmt.Sprintf("histogram_quantile(%v, sum(rate(fission_function_latency_seconds_bucket{path=\"%s\",method=\"%s\",name=\"%s\",namespace=\"%s\"}[%v])) by (le))",
+		float64(percentile)/100, path, method, funcName, funcNs, window)
+
+	latency, err := promApiClient.executeQuery(queryString)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error executing query: %s", queryString)
+	}
+
+	promApiClient.logger.Info("function latency",
+		zap.Int("percentile", percentile),
+		zap.Float64("latency_seconds", latency),
+		zap.String("window", window),
+		zap.String("function", funcName))
+
+	return latency, nil
+}
+
+// GetQueryValue returns the value of an arbitrary PromQL expression.
+func (promApiClient *PrometheusApiClient) GetQueryValue(queryString string) (float64, error) {
+	val, err := promApiClient.executeQuery(queryString)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error executing query: %s", queryString)
+	}
+	return val, nil
+}
+
+// QueryVector returns the instant vector result of a PromQL expression.
+func (promApiClient *PrometheusApiClient) QueryVector(queryString string) (model.Vector, error) {
+	val, warn, err := promApiClient.client.Query(context.Background(), queryString, time.Now())
+	if err != nil {
+		return nil, errors.Wrapf(err, "error executing query: %s", queryString)
+	}
+
+	if warn != nil {
+		promApiClient.logger.Warn("receive prometheus client query warning", zap.Any("msg", warn))
+	}
+
+	vectorVal, ok := val.(model.Vector)
+	if !ok {
+		return nil, errors.Errorf("unexpected result type %v of query: %s", val.Type(), queryString)
+	}
+	return vectorVal, nil
+}
+
 func (promApiClient *PrometheusApiClient) executeQuery(queryString string) (float64, error) {
 	val, warn, err := promApiClient.client.Query(context.Background(), queryString, time.Now())
 	if err != nil {
@@ -0,0 +1,48 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package canaryconfigmgr
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/gcpauth"
+)
+
+const (
+	// Stackdriver (Cloud Monitoring) serves a Prometheus compatible query API for
+	// metrics collected by Managed Service for Prometheus.
+	stackdriverPrometheusAddress = "https://monitoring.googleapis.com/v1/projects/%v/location/global/prometheus"
+)
+
+// MakeStackdriverClient returns a client of the Prometheus compatible API of Stackdriver, so
+// router metrics and PromQL query SLOs work the same way as with Prometheus.
+func MakeStackdriverClient(logger *zap.Logger, provider *fv1.CanaryMetricsProvider, credentials map[string]string) (*PrometheusApiClient, error) {
+	address := provider.Address
+	if len(address) == 0 {
+		address = fmt.Sprintf(stackdriverPrometheusAddress, provider.Project)
+	}
+
+	// requests are authorized with a static access token, or one of the
+	// default service account from the GCE metadata server
+	return makePrometheusClient(logger.Named("stackdriver"), address, gcpauth.MakeTokenRoundTripper(http.DefaultTransport, gcpauth.Credentials{
+		AccessToken: credentials["access-token"],
+	}))
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package canaryconfigmgr
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestStackdriverClient(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			t.Errorf("unexpected path %v", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("expected access token, got %q", r.Header.Get("Authorization"))
+		}
+		query = r.FormValue("query")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"status": "success", "data": {"resultType": "vector", "result": [`+
+			`{"metric": {"path": "/a"}, "value": [1610000000, "2"]}, {"metric": {"path": "/b"}, "value": [1610000000, "3"]}]}}`)
+	}))
+	defer server.Close()
+
+	c, err := MakeStackdriverClient(zap.NewNop(), &fv1.CanaryMetricsProvider{
+		Type:    fv1.CanaryMetricsProviderStackdriver,
+		Address: server.URL,
+		Project: "project",
+	}, map[string]string{"access-token": "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := c.GetQueryValue(`sum(rate(fission_function_calls_total[1m]))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != 5 {
+		t.Errorf("expected sum of the vector, got %v", value)
+	}
+	if query != `sum(rate(fission_function_calls_total[1m]))` {
+		t.Errorf("unexpected query %q", query)
+	}
+}
@@ -20,19 +20,40 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	apiv1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
+	"github.com/fission/fission/pkg/apiauth"
+	"github.com/fission/fission/pkg/audit"
+	"github.com/fission/fission/pkg/buildlog"
+	"github.com/fission/fission/pkg/canaryconfigmgr"
 	"github.com/fission/fission/pkg/crd"
 	ferror "github.com/fission/fission/pkg/error"
+	executorClient "github.com/fission/fission/pkg/executor/client"
 	"github.com/fission/fission/pkg/fission-cli/logdb"
+	"github.com/fission/fission/pkg/fnanalysis"
 	"github.com/fission/fission/pkg/info"
+	"github.com/fission/fission/pkg/logstore"
+	"github.com/fission/fission/pkg/pkgrevision"
+	"github.com/fission/fission/pkg/sourcemap"
+	"github.com/fission/fission/pkg/tombstone"
+)
+
+const (
+	// defaultTombstoneRetention is how long tombstones of deleted objects are kept.
+	defaultTombstoneRetention = 7 * 24 * time.Hour
+
+	// defaultAuditBufferSize is how many audit log entries are kept for queries.
+	defaultAuditBufferSize = 1000
 )
 
 var podNamespace string
@@ -52,8 +73,18 @@ type (
 		storageServiceUrl string
 		builderManagerUrl string
 		workflowApiUrl    string
+		executor          *executorClient.Client
+		tombstones        *tombstone.Keeper
+		buildLogs         *buildlog.Store
+		pkgRevisions      *pkgrevision.Store
+		analyzer          *fnanalysis.Analyzer
+		logStore          logstore.Store
+		sourceMaps        *sourcemap.Store
+		auditLog          *audit.Log
+		auth              *apiauth.Auth
 		functionNamespace string
 		featureStatus     map[string]string
+		promClient        *canaryconfigmgr.PrometheusApiClient
 	}
 
 	logDBConfig struct {
@@ -87,6 +118,65 @@ func MakeAPI(logger *zap.Logger, featureStatus map[string]string) (*API, error)
 		api.workflowApiUrl = "http://workflows-apiserver"
 	}
 
+	executorUrl := os.Getenv("EXECUTOR_URL")
+	if len(executorUrl) == 0 {
+		executorUrl = "http://executor"
+	}
+	api.executor = executorClient.MakeClient(logger, executorUrl)
+
+	retention := defaultTombstoneRetention
+	if r := os.Getenv("TOMBSTONE_RETENTION"); len(r) > 0 {
+		d, parseErr := time.ParseDuration(r)
+		if parseErr != nil {
+			return nil, errors.Wrapf(parseErr, "invalid tombstone retention %q", r)
+		}
+		retention = d
+	}
+	api.tombstones = tombstone.MakeKeeper(logger, api.fissionClient, api.kubernetesClient, podNamespace, retention)
+	api.buildLogs = buildlog.MakeStore(logger, api.kubernetesClient, buildlog.DefaultHistory)
+	api.pkgRevisions = pkgrevision.MakeStore(logger, api.fissionClient, api.kubernetesClient, pkgrevision.DefaultHistory)
+	api.analyzer = fnanalysis.MakeAnalyzer(logger, api.kubernetesClient)
+	api.sourceMaps = sourcemap.MakeStore(sourcemap.DefaultStoreSize)
+
+	// function logs are queried from the sink the logger ships them to
+	logSink, ok := os.LookupEnv("LOG_SINK")
+	if !ok {
+		logSink = logstore.SinkInfluxDB
+	}
+	logStore, logErr := logstore.MakeStore(logSink, os.Getenv("LOG_SINK_URL"),
+		os.Getenv("LOG_SINK_USERNAME"), os.Getenv("LOG_SINK_PASSWORD"))
+	if logErr != nil {
+		return nil, logErr
+	}
+	api.logStore = logStore
+
+	sinkSpec, ok := os.LookupEnv("AUDIT_SINKS")
+	if !ok {
+		sinkSpec = "stdout"
+	}
+	sinks, sinkErr := audit.ParseSinks(sinkSpec)
+	if sinkErr != nil {
+		return nil, sinkErr
+	}
+	bufferSize := defaultAuditBufferSize
+	if s := os.Getenv("AUDIT_BUFFER_SIZE"); len(s) > 0 {
+		size, parseErr := strconv.Atoi(s)
+		if parseErr != nil {
+			return nil, errors.Wrapf(parseErr, "invalid audit buffer size %q", s)
+		}
+		bufferSize = size
+	}
+	api.auditLog = audit.MakeLog(logger, sinks, bufferSize)
+
+	// API authentication is enabled by mounting its config
+	if authConfigPath := os.Getenv("API_AUTH_CONFIG"); len(authConfigPath) > 0 {
+		authConfig, authErr := apiauth.LoadConfig(authConfigPath)
+		if authErr != nil {
+			return nil, authErr
+		}
+		api.auth = apiauth.MakeAuth(logger, authConfig)
+	}
+
 	fnNs := os.Getenv("FISSION_FUNCTION_NAMESPACE")
 	if len(fnNs) > 0 {
 		api.functionNamespace = fnNs
@@ -95,6 +185,7 @@ func MakeAPI(logger *zap.Logger, featureStatus map[string]string) (*API, error)
 	}
 
 	api.featureStatus = featureStatus
+	api.promClient = makePrometheusClient(logger)
 
 	return api, err
 }
@@ -195,6 +286,8 @@ func (api *API) GetSvcName(w http.ResponseWriter, r *http.Request) {
 
 func (api *API) GetHandler() http.Handler {
 	r := mux.NewRouter()
+	r.Use(api.authMiddleware)
+	r.Use(api.auditMiddleware)
 	r.HandleFunc("/healthz", api.HealthHandler).Methods("GET")
 	// Give a useful error message if an older CLI attempts to make a request
 	r.HandleFunc(`/v1/{rest:[a-zA-Z0-9=\-\/]+}`, api.ApiVersionMismatchHandler)
@@ -205,12 +298,18 @@ func (api *API) GetHandler() http.Handler {
 	r.HandleFunc("/v2/packages/{package}", api.PackageApiGet).Methods("GET")
 	r.HandleFunc("/v2/packages/{package}", api.PackageApiUpdate).Methods("PUT")
 	r.HandleFunc("/v2/packages/{package}", api.PackageApiDelete).Methods("DELETE")
+	r.HandleFunc("/v2/packages/{package}/buildlogs", api.PackageApiBuildLogs).Methods("GET")
+	r.HandleFunc("/v2/packages/{package}/revisions", api.PackageApiRevisions).Methods("GET")
 
 	r.HandleFunc("/v2/functions", api.FunctionApiList).Methods("GET")
 	r.HandleFunc("/v2/functions", api.FunctionApiCreate).Methods("POST")
 	r.HandleFunc("/v2/functions/{function}", api.FunctionApiGet).Methods("GET")
 	r.HandleFunc("/v2/functions/{function}", api.FunctionApiUpdate).Methods("PUT")
 	r.HandleFunc("/v2/functions/{function}", api.FunctionApiDelete).Methods("DELETE")
+	r.HandleFunc("/v2/functions/{function}/analysis", api.FunctionApiAnalyze).Methods("GET")
+	r.HandleFunc("/v2/functions/{function}/invocations", api.FunctionApiInvocations).Methods("GET")
+	r.HandleFunc("/v2/functions/{function}/logs", api.FunctionApiLogs).Methods("GET")
+	r.HandleFunc("/v2/functionusages", api.FunctionApiUsages).Methods("GET")
 
 	r.HandleFunc("/v2/triggers/http", api.HTTPTriggerApiList).Methods("GET")
 	r.HandleFunc("/v2/triggers/http", api.HTTPTriggerApiCreate).Methods("POST")
@@ -251,8 +350,24 @@ func (api *API) GetHandler() http.Handler {
 	r.HandleFunc("/v2/canaryconfigs/{canaryConfig}", api.CanaryConfigApiDelete).Methods("DELETE")
 	r.HandleFunc("/v2/canaryconfigs", api.CanaryConfigApiList).Methods("GET")
 
+	r.HandleFunc("/v2/status", api.StatusApiGet).Methods("GET")
+	r.HandleFunc("/v2/drift", api.DriftApiGet).Methods("GET")
+
+	r.HandleFunc("/v2/tombstones", api.TombstoneApiList).Methods("GET")
+	r.HandleFunc("/v2/tombstones/restore", api.TombstoneApiRestore).Methods("POST")
+
+	r.HandleFunc("/v2/audit", api.AuditApiList).Methods("GET")
+
+	r.HandleFunc("/v2/config", api.FissionConfigApiGet).Methods("GET")
+
 	r.HandleFunc("/proxy/{dbType}", api.FunctionLogsApiPost).Methods("POST")
 	r.HandleFunc("/proxy/storage/v1/archive", api.StorageServiceProxy)
+	r.HandleFunc("/proxy/storage/v1/archive/delta", api.StorageServiceProxy).Methods("POST")
+	r.HandleFunc("/proxy/storage/v1/archive/signature", api.StorageServiceProxy).Methods("GET")
+	r.HandleFunc("/proxy/storage/v1/archive/uploads", api.StorageServiceProxy).Methods("POST")
+	r.HandleFunc("/proxy/storage/v1/archive/uploads/{id}", api.StorageServiceProxy).Methods("GET", "DELETE")
+	r.HandleFunc("/proxy/storage/v1/archive/uploads/{id}/parts/{number}", api.StorageServiceProxy).Methods("PUT")
+	r.HandleFunc("/proxy/storage/v1/archive/uploads/{id}/complete", api.StorageServiceProxy).Methods("POST")
 	r.HandleFunc("/proxy/logs/{function}", api.FunctionPodLogs).Methods("POST")
 	r.HandleFunc("/proxy/workflows-apiserver/{path:.*}", api.WorkflowApiserverProxy)
 	r.HandleFunc("/proxy/svcname", api.GetSvcName).Queries("application", "").Methods("GET")
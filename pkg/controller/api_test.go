@@ -17,6 +17,7 @@ limitations under the License.
 package controller
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -37,6 +38,7 @@ import (
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/controller/client"
 	"github.com/fission/fission/pkg/controller/client/rest"
+	clientv1 "github.com/fission/fission/pkg/controller/client/v1"
 	"github.com/fission/fission/pkg/crd"
 	ferror "github.com/fission/fission/pkg/error"
 	"github.com/fission/fission/pkg/fission-cli/cmd"
@@ -135,6 +137,40 @@ func TestFunctionApi(t *testing.T) {
 	panicIf(err)
 	assert(len(funcs) == 2, fmt.Sprintf("created two functions, but found %v", len(funcs)))
 
+	funcs, continueToken, err := g.Client().V1().Function().ListPage(testNS, &clientv1.ListOptions{Limit: 1})
+	panicIf(err)
+	assert(len(funcs) == 1 && len(continueToken) > 0, "first page should hold one function and a continue token")
+	funcs, continueToken, err = g.Client().V1().Function().ListPage(testNS, &clientv1.ListOptions{Limit: 1, Continue: continueToken})
+	panicIf(err)
+	assert(len(funcs) == 1 && len(continueToken) == 0, "last page should hold one function and no continue token")
+
+	funcs, _, err = g.Client().V1().Function().ListPage(testNS, &clientv1.ListOptions{
+		FieldSelector: "metadata.name=bar",
+		Fields:        []string{"metadata.name"},
+	})
+	panicIf(err)
+	assert(len(funcs) == 1 && funcs[0].ObjectMeta.Name == "bar", "field selector should select function bar")
+	assert(len(funcs[0].Spec.Package.FunctionName) == 0, "fields not requested should not be returned")
+
+	funcs, _, err = g.Client().V1().Function().ListPage(testNS, &clientv1.ListOptions{FieldSelector: "spec.package.functionName!=yyy"})
+	panicIf(err)
+	assert(len(funcs) == 0, fmt.Sprintf("field selector on spec should select no function, but found %v", len(funcs)))
+
+	// a watch starts with the existing functions
+	errStopWatch := errors.New("stop watch")
+	var events []clientv1.WatchEvent
+	err = g.Client().V1().Function().Watch(testNS, &clientv1.ListOptions{Fields: []string{"metadata.name"}}, func(event clientv1.WatchEvent) error {
+		events = append(events, event)
+		if len(events) == 2 {
+			return errStopWatch
+		}
+		return nil
+	})
+	assert(err == errStopWatch, fmt.Sprintf("watch should be stopped by the handler, but got %v", err))
+	for _, event := range events {
+		assert(event.Type == "ADDED", fmt.Sprintf("watch should start with added functions, but got %v", event.Type))
+	}
+
 	funcs_url := g.Client().ServerURL() + "/v2/functions"
 	resp, err := http.Get(funcs_url)
 	panicIf(err)
@@ -0,0 +1,275 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"github.com/go-openapi/spec"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fission/fission/pkg/audit"
+	ferror "github.com/fission/fission/pkg/error"
+)
+
+// maxAuditedResponseSize is how much of responses is kept to get the
+// metadata of created objects and error messages from.
+const maxAuditedResponseSize = 64 * 1024
+
+type (
+	auditedResource struct {
+		// path is the path of the objects in the API, after /v2/
+		path     string
+		kind     string
+		resource string
+	}
+
+	// auditRecorder passes responses through, keeping the status code and
+	// the start of the body.
+	auditRecorder struct {
+		http.ResponseWriter
+		status int
+		body   bytes.Buffer
+	}
+)
+
+var auditedResources = []auditedResource{
+	{path: "packages", kind: "package", resource: "packages"},
+	{path: "functions", kind: "function", resource: "functions"},
+	{path: "environments", kind: "environment", resource: "environments"},
+	{path: "watches", kind: "watch", resource: "kuberneteswatchtriggers"},
+	{path: "triggers/http", kind: "httptrigger", resource: "httptriggers"},
+	{path: "triggers/time", kind: "timetrigger", resource: "timetriggers"},
+	{path: "triggers/messagequeue", kind: "mqtrigger", resource: "messagequeuetriggers"},
+	{path: "canaryconfigs", kind: "canaryconfig", resource: "canaryconfigs"},
+}
+
+func RegisterAuditRoute(ws *restful.WebService) {
+	tags := []string{"Audit"}
+	specTag = append(specTag, spec.Tag{TagProps: spec.TagProps{Name: "Audit", Description: "Audit Log Operation"}})
+
+	ws.Route(
+		ws.GET("/v2/audit").
+			Doc("List the most recent create, update, delete and restore operations on objects").
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			To(func(req *restful.Request, resp *restful.Response) {
+				resp.ResponseWriter.WriteHeader(http.StatusOK)
+			}).
+			Param(ws.QueryParameter("kind", "Kind of objects, e.g. function").DataType("string").Required(false)).
+			Param(ws.QueryParameter("namespace", "Namespace of objects, all namespaces if not given").DataType("string").Required(false)).
+			Param(ws.QueryParameter("name", "Name of objects").DataType("string").Required(false)).
+			Param(ws.QueryParameter("user", "User who made the changes").DataType("string").Required(false)).
+			Param(ws.QueryParameter("since", "Only operations since the RFC3339 time, or the duration ago, e.g. 1h").DataType("string").Required(false)).
+			Param(ws.QueryParameter("limit", "Maximum number of operations, the most recent ones are returned").DataType("integer").Required(false)).
+			Produces(restful.MIME_JSON).
+			Writes([]audit.Entry{}).
+			Returns(http.StatusOK, "List of operations, oldest first", []audit.Entry{}))
+}
+
+func (a *API) AuditApiList(w http.ResponseWriter, r *http.Request) {
+	filter := audit.Filter{
+		Kind:      a.extractQueryParamFromRequest(r, "kind"),
+		Namespace: a.extractQueryParamFromRequest(r, "namespace"),
+		Name:      a.extractQueryParamFromRequest(r, "name"),
+		User:      a.extractQueryParamFromRequest(r, "user"),
+	}
+
+	if since := a.extractQueryParamFromRequest(r, "since"); len(since) > 0 {
+		if d, err := time.ParseDuration(since); err == nil {
+			filter.Since = time.Now().Add(-d)
+		} else if t, err := time.Parse(time.RFC3339, since); err == nil {
+			filter.Since = t
+		} else {
+			a.respondWithError(w, ferror.MakeError(ferror.ErrorInvalidArgument, fmt.Sprintf("invalid since value: %v", since)))
+			return
+		}
+	}
+
+	if limit := a.extractQueryParamFromRequest(r, "limit"); len(limit) > 0 {
+		var err error
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 0 {
+			a.respondWithError(w, ferror.MakeError(ferror.ErrorInvalidArgument, fmt.Sprintf("invalid limit value: %v", limit)))
+			return
+		}
+	}
+
+	resp, err := json.Marshal(a.auditLog.List(filter))
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+	a.respondWithSuccess(w, resp)
+}
+
+// auditMiddleware records creates, updates, deletes and restores of objects
+// in the audit log, with the changes to their labels, annotations and specs.
+func (a *API) auditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op, res, ok := auditedOperation(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		entry := audit.Entry{
+			Time:       time.Now().UTC(),
+			User:       requestUser(r),
+			RemoteAddr: remoteAddr(r),
+			UserAgent:  r.UserAgent(),
+			Operation:  op,
+			Kind:       res.kind,
+			Namespace:  a.extractQueryParamFromRequest(r, "namespace"),
+		}
+		for _, name := range mux.Vars(r) {
+			entry.Name = name
+		}
+		if op == audit.OperationRestore {
+			entry.Name = a.extractQueryParamFromRequest(r, "name")
+		}
+		if len(entry.Namespace) == 0 && op == audit.OperationUpdate {
+			// updates carry the namespace in the object
+			if ns, err := requestObjectNamespace(r); err == nil {
+				entry.Namespace = ns
+			}
+		}
+		if len(entry.Namespace) == 0 && op != audit.OperationCreate {
+			entry.Namespace = metav1.NamespaceDefault
+		}
+
+		var oldObj []byte
+		if op == audit.OperationUpdate || op == audit.OperationDelete {
+			oldObj = a.getAuditedObject(res, entry.Namespace, entry.Name)
+		}
+
+		recorder := &auditRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		entry.Status = recorder.status
+		if recorder.status >= http.StatusMultipleChoices {
+			entry.Error = strings.TrimSpace(recorder.body.String())
+			a.auditLog.Record(entry)
+			return
+		}
+
+		if op == audit.OperationCreate || op == audit.OperationRestore {
+			m := metav1.ObjectMeta{}
+			if err := json.Unmarshal(recorder.body.Bytes(), &m); err == nil {
+				entry.Name = m.Name
+				entry.Namespace = m.Namespace
+			}
+		}
+
+		var newObj []byte
+		if op != audit.OperationDelete {
+			newObj = a.getAuditedObject(res, entry.Namespace, entry.Name)
+		}
+		changes, err := audit.Diff(oldObj, newObj, "metadata.labels", "metadata.annotations", "spec")
+		if err != nil {
+			a.logger.Error("error getting changes of audited object", zap.Error(err))
+		}
+		entry.Changes = changes
+		a.auditLog.Record(entry)
+	})
+}
+
+// getAuditedObject returns the JSON form of an object, or nil if it can't be
+// read, in which case the audit log entry just has no changes.
+func (a *API) getAuditedObject(res *auditedResource, namespace string, name string) []byte {
+	if len(name) == 0 || len(res.resource) == 0 {
+		return nil
+	}
+	obj, err := a.fissionClient.CoreV1().RESTClient().Get().
+		Namespace(namespace).Resource(res.resource).Name(name).Do().Raw()
+	if err != nil {
+		return nil
+	}
+	return obj
+}
+
+// auditedOperation returns the operation a request makes and the kind of
+// object it makes it on, and false if the request is not audited.
+func auditedOperation(r *http.Request) (audit.Operation, *auditedResource, bool) {
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+
+	if path == "tombstones/restore" && r.Method == http.MethodPost {
+		kind := r.URL.Query().Get("kind")
+		for i := range auditedResources {
+			if auditedResources[i].kind == kind {
+				return audit.OperationRestore, &auditedResources[i], true
+			}
+		}
+		return audit.OperationRestore, &auditedResource{kind: kind}, true
+	}
+
+	for i := range auditedResources {
+		res := &auditedResources[i]
+		if path == res.path && r.Method == http.MethodPost {
+			return audit.OperationCreate, res, true
+		}
+		if strings.HasPrefix(path, res.path+"/") && !strings.Contains(strings.TrimPrefix(path, res.path+"/"), "/") {
+			switch r.Method {
+			case http.MethodPut:
+				return audit.OperationUpdate, res, true
+			case http.MethodDelete:
+				return audit.OperationDelete, res, true
+			}
+		}
+	}
+	return "", nil, false
+}
+
+// requestUser returns the user an authenticating proxy in front of the
+// controller set, or else the local user the CLI reports.
+func requestUser(r *http.Request) string {
+	if user := r.Header.Get(audit.RemoteUserHeader); len(user) > 0 {
+		return user
+	}
+	return r.Header.Get(audit.UserHeader)
+}
+
+func remoteAddr(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); len(forwarded) > 0 {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	return r.RemoteAddr
+}
+
+func (rec *auditRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *auditRecorder) Write(data []byte) (int, error) {
+	if room := maxAuditedResponseSize - rec.body.Len(); room > 0 {
+		if len(data) < room {
+			room = len(data)
+		}
+		rec.body.Write(data[:room])
+	}
+	return rec.ResponseWriter.Write(data)
+}
@@ -0,0 +1,351 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fission/fission/pkg/apiauth"
+	"github.com/fission/fission/pkg/audit"
+	ferror "github.com/fission/fission/pkg/error"
+)
+
+var (
+	// publicRoutes are served without authentication.
+	publicRoutes = map[string]bool{
+		"/":                            true,
+		"/healthz":                     true,
+		"/v2/apidocs.json":             true,
+		`/v1/{rest:[a-zA-Z0-9=\-\/]+}`: true,
+	}
+
+	// readOnlyPostRoutes take POST requests that only read.
+	readOnlyPostRoutes = map[string]bool{
+		"/proxy/logs/{function}": true,
+	}
+
+	// adminRoutes are served to admins only.
+	adminRoutes = map[string]bool{
+		"/v2/audit":  true,
+		"/v2/config": true,
+		// the workflows API server has no notion of namespaces to check
+		"/proxy/workflows-apiserver/{path:.*}": true,
+		// the log database runs the queries of the caller with the
+		// credentials of the controller, over the logs of all namespaces
+		"/proxy/{dbType}": true,
+	}
+
+	// anyNamespaceRoutes serve requests not bound to a namespace.
+	anyNamespaceRoutes = map[string]bool{
+		"/proxy/svcname": true,
+	}
+
+	// archiveRoutes act on the archive whose id is in the given query
+	// parameter, in the namespaces of the packages referencing the archive.
+	// Uploads of new archives, without id, are not bound to a namespace.
+	archiveRoutes = map[string]string{
+		"/proxy/storage/v1/archive":           "id",
+		"/proxy/storage/v1/archive/delta":     "base",
+		"/proxy/storage/v1/archive/signature": "id",
+	}
+
+	// uploadRoutes act on the multipart upload whose id is in the path, in
+	// the namespace the upload was started in. Uploads are started in the
+	// namespace in the query, like other requests.
+	uploadRoutes = map[string]bool{
+		"/proxy/storage/v1/archive/uploads/{id}":                true,
+		"/proxy/storage/v1/archive/uploads/{id}/parts/{number}": true,
+		"/proxy/storage/v1/archive/uploads/{id}/complete":       true,
+	}
+)
+
+// storageNamespaces returns the namespaces the archives and multipart
+// uploads of the storage service belong to.
+type storageNamespaces interface {
+	// archiveNamespaces returns the namespaces of the packages
+	// referencing an archive.
+	archiveNamespaces(id string) ([]string, error)
+	// uploadNamespace returns the namespace a multipart upload was
+	// started in, or false if there is no such upload.
+	uploadNamespace(id string) (string, bool, error)
+}
+
+// authMiddleware authenticates requests and checks that the roles bound to
+// the user allow them, if API authentication is enabled.
+func (a *API) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.auth == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		route := routeTemplate(r)
+		if publicRoutes[route] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		id, err := a.auth.Authenticate(r)
+		if err != nil {
+			a.logger.Debug("rejecting unauthenticated request",
+				zap.Error(err),
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path))
+			w.Header().Set("WWW-Authenticate", `Bearer realm="fission"`)
+			a.respondWithError(w, ferror.MakeError(ferror.ErrorNotAuthenticated, "authentication required: "+err.Error()))
+			return
+		}
+
+		attrs, err := requestAttributes(r, route, a)
+		if err != nil {
+			a.respondWithError(w, err)
+			return
+		}
+		if !authorizeAny(a.auth, id, attrs) {
+			a.respondWithError(w, ferror.MakeError(ferror.ErrorNotAuthorized,
+				describeDenial(id, attrs)))
+			return
+		}
+
+		// the audit log records the authenticated user, not the one the client reports
+		r.Header.Set(audit.RemoteUserHeader, id.User)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorizeAny returns true if any of the attributes of a request is allowed.
+func authorizeAny(auth *apiauth.Auth, id *apiauth.Identity, attrs []apiauth.Attributes) bool {
+	for _, a := range attrs {
+		if auth.Authorize(id, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestAttributes returns the action a request makes and the namespace it
+// makes it in. Requests on archives referenced in several namespaces have
+// attributes per namespace, and are allowed if any of them is.
+func requestAttributes(r *http.Request, route string, storage storageNamespaces) ([]apiauth.Attributes, error) {
+	attrs := apiauth.Attributes{
+		Action:       apiauth.ActionWrite,
+		AnyNamespace: anyNamespaceRoutes[route],
+	}
+	switch {
+	case adminRoutes[route]:
+		attrs.Action = apiauth.ActionAdmin
+	case r.Method == http.MethodGet || r.Method == http.MethodHead || readOnlyPostRoutes[route]:
+		attrs.Action = apiauth.ActionRead
+	}
+	if attrs.AnyNamespace {
+		return []apiauth.Attributes{attrs}, nil
+	}
+
+	if param, ok := archiveRoutes[route]; ok {
+		id := r.URL.Query().Get(param)
+		if len(id) == 0 {
+			attrs.AnyNamespace = true
+			return []apiauth.Attributes{attrs}, nil
+		}
+		namespaces, err := storage.archiveNamespaces(id)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case len(namespaces) == 0:
+			// archives of no package, like ones of deleted packages,
+			// are left to admins of all namespaces
+			attrs.Action = apiauth.ActionAdmin
+		case len(namespaces) > 1 && r.Method == http.MethodDelete:
+			// deleting an archive shared by packages of several
+			// namespaces breaks the packages of all of them
+			attrs.Action = apiauth.ActionAdmin
+		default:
+			// each upload of an archive has an id of its own, packages
+			// of other namespaces only reference it by copying the
+			// archive URL of a package they can read
+			all := make([]apiauth.Attributes, 0, len(namespaces))
+			for _, ns := range namespaces {
+				nsAttrs := attrs
+				nsAttrs.Namespace = ns
+				all = append(all, nsAttrs)
+			}
+			return all, nil
+		}
+		return []apiauth.Attributes{attrs}, nil
+	}
+
+	if uploadRoutes[route] {
+		ns, found, err := storage.uploadNamespace(mux.Vars(r)["id"])
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			// the storage service responds the upload is not found
+			attrs.AnyNamespace = true
+		}
+		attrs.Namespace = ns
+		return []apiauth.Attributes{attrs}, nil
+	}
+
+	if hasRequestObject(r, route) {
+		// creates and updates act on the namespace of the object in the
+		// body, whatever the query says
+		ns, err := requestObjectNamespace(r)
+		if err != nil {
+			return nil, err
+		}
+		attrs.Namespace = ns
+		return []apiauth.Attributes{attrs}, nil
+	}
+
+	attrs.Namespace = r.URL.Query().Get("namespace")
+	if len(attrs.Namespace) == 0 {
+		if len(mux.Vars(r)) == 0 && r.Method == http.MethodGet {
+			// lists without namespace span all namespaces
+			attrs.Namespace = metav1.NamespaceAll
+		} else {
+			attrs.Namespace = metav1.NamespaceDefault
+		}
+	}
+	return []apiauth.Attributes{attrs}, nil
+}
+
+// hasRequestObject returns true if the request creates or updates the object in its body.
+func hasRequestObject(r *http.Request, route string) bool {
+	if !strings.HasPrefix(route, "/v2/") || route == "/v2/tombstones/restore" {
+		return false
+	}
+	return r.Method == http.MethodPost || r.Method == http.MethodPut
+}
+
+// requestObjectNamespace returns the namespace of the object in the request
+// body, leaving the body to be read again by the handler.
+func requestObjectNamespace(r *http.Request) (string, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return "", err
+	}
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var obj struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+	}
+	err = json.Unmarshal(body, &obj)
+	if err != nil {
+		return "", ferror.MakeError(ferror.ErrorInvalidArgument, "failed to decode the request body: "+err.Error())
+	}
+	if len(obj.Metadata.Namespace) == 0 {
+		return metav1.NamespaceDefault, nil
+	}
+	return obj.Metadata.Namespace, nil
+}
+
+// archiveNamespaces returns the namespaces of the packages whose source or
+// deployment archive has the given id.
+func (a *API) archiveNamespaces(id string) ([]string, error) {
+	pkgs, err := a.fissionClient.CoreV1().Packages(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var namespaces []string
+	seen := make(map[string]bool)
+	for _, pkg := range pkgs.Items {
+		if archiveID(pkg.Spec.Source.URL) != id && archiveID(pkg.Spec.Deployment.URL) != id {
+			continue
+		}
+		if !seen[pkg.ObjectMeta.Namespace] {
+			seen[pkg.ObjectMeta.Namespace] = true
+			namespaces = append(namespaces, pkg.ObjectMeta.Namespace)
+		}
+	}
+	return namespaces, nil
+}
+
+// uploadNamespace returns the namespace the multipart upload with the given
+// id was started in, as the storage service recorded it.
+func (a *API) uploadNamespace(id string) (string, bool, error) {
+	resp, err := http.Get(a.storageServiceUrl + "/v1/archive/uploads/" + url.PathEscape(id))
+	if err != nil {
+		return "", false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", false, ferror.MakeError(ferror.ErrorInternal,
+			fmt.Sprintf("error getting multipart upload %v: %v", id, resp.Status))
+	}
+	var upload struct {
+		Namespace string `json:"namespace"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&upload)
+	if err != nil {
+		return "", false, err
+	}
+	return upload.Namespace, true, nil
+}
+
+// archiveID returns the id of the archive of storagesvc at the given URL,
+// or "" if the URL is not one of storagesvc.
+func archiveID(archiveURL string) string {
+	if len(archiveURL) == 0 {
+		return ""
+	}
+	u, err := url.Parse(archiveURL)
+	if err != nil || !strings.HasSuffix(u.Path, "/archive") {
+		return ""
+	}
+	return u.Query().Get("id")
+}
+
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+	tpl, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return tpl
+}
+
+func describeDenial(id *apiauth.Identity, attrs []apiauth.Attributes) string {
+	scopes := make([]string, 0, len(attrs))
+	for _, a := range attrs {
+		scope := fmt.Sprintf("namespace %v", a.Namespace)
+		if a.AnyNamespace {
+			scope = "any namespace"
+		} else if len(a.Namespace) == 0 {
+			scope = "all namespaces"
+		}
+		scopes = append(scopes, scope)
+	}
+	return fmt.Sprintf("user %v is not allowed to %v in %v", id.User, attrs[0].Action, strings.Join(scopes, " or "))
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/fission/fission/pkg/apiauth"
+)
+
+// fakeStorage has archives referenced by packages of the given namespaces,
+// and multipart uploads started in the given namespaces.
+type fakeStorage struct {
+	archives map[string][]string
+	uploads  map[string]string
+}
+
+func (f *fakeStorage) archiveNamespaces(id string) ([]string, error) {
+	return f.archives[id], nil
+}
+
+func (f *fakeStorage) uploadNamespace(id string) (string, bool, error) {
+	ns, ok := f.uploads[id]
+	return ns, ok, nil
+}
+
+func TestAuthorizeArchiveRoutes(t *testing.T) {
+	auth := apiauth.MakeAuth(zap.NewNop(), &apiauth.Config{
+		Bindings: []apiauth.Binding{
+			{Role: apiauth.RoleAdmin, Users: []string{"root"}},
+			{Role: apiauth.RoleDeployer, Users: []string{"alice"}, Namespaces: []string{"a"}},
+			{Role: apiauth.RoleViewer, Users: []string{"bob"}, Namespaces: []string{"a"}},
+		},
+	})
+	storage := &fakeStorage{
+		archives: map[string][]string{
+			"mine":   {"a"},
+			"theirs": {"b"},
+			// package of b copied the archive URL of a package of a
+			"shared": {"b", "a"},
+		},
+		uploads: map[string]string{
+			"mine":   "a",
+			"theirs": "b",
+		},
+	}
+
+	var attrs []apiauth.Attributes
+	var attrsErr error
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		attrs, attrsErr = requestAttributes(r, routeTemplate(r), storage)
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/proxy/storage/v1/archive", handler)
+	router.HandleFunc("/proxy/storage/v1/archive/delta", handler).Methods("POST")
+	router.HandleFunc("/proxy/storage/v1/archive/signature", handler).Methods("GET")
+	router.HandleFunc("/proxy/storage/v1/archive/uploads", handler).Methods("POST")
+	router.HandleFunc("/proxy/storage/v1/archive/uploads/{id}", handler).Methods("GET", "DELETE")
+	router.HandleFunc("/proxy/storage/v1/archive/uploads/{id}/parts/{number}", handler).Methods("PUT")
+	router.HandleFunc("/proxy/storage/v1/archive/uploads/{id}/complete", handler).Methods("POST")
+	router.HandleFunc("/proxy/workflows-apiserver/{path:.*}", handler)
+	router.HandleFunc("/proxy/logs/{function}", handler).Methods("POST")
+	router.HandleFunc("/proxy/{dbType}", handler).Methods("POST")
+
+	alice := &apiauth.Identity{User: "alice"}
+	bob := &apiauth.Identity{User: "bob"}
+	root := &apiauth.Identity{User: "root"}
+	tests := []struct {
+		id     *apiauth.Identity
+		method string
+		url    string
+		want   bool
+	}{
+		{alice, http.MethodPost, "/proxy/storage/v1/archive", true},
+		{alice, http.MethodGet, "/proxy/storage/v1/archive?id=mine", true},
+		{alice, http.MethodDelete, "/proxy/storage/v1/archive?id=mine", true},
+		{alice, http.MethodGet, "/proxy/storage/v1/archive?id=theirs", false},
+		{alice, http.MethodDelete, "/proxy/storage/v1/archive?id=theirs", false},
+		{alice, http.MethodDelete, "/proxy/storage/v1/archive?id=orphan", false},
+		{root, http.MethodDelete, "/proxy/storage/v1/archive?id=orphan", true},
+		{alice, http.MethodGet, "/proxy/storage/v1/archive?id=shared", true},
+		{alice, http.MethodDelete, "/proxy/storage/v1/archive?id=shared", false},
+		{root, http.MethodDelete, "/proxy/storage/v1/archive?id=shared", true},
+		{alice, http.MethodPost, "/proxy/storage/v1/archive/delta?base=mine", true},
+		{alice, http.MethodPost, "/proxy/storage/v1/archive/delta?base=shared", true},
+		{alice, http.MethodPost, "/proxy/storage/v1/archive/delta?base=theirs", false},
+		{alice, http.MethodGet, "/proxy/storage/v1/archive/signature?id=theirs", false},
+		{alice, http.MethodPost, "/proxy/storage/v1/archive/uploads?namespace=a", true},
+		{alice, http.MethodPost, "/proxy/storage/v1/archive/uploads?namespace=b", false},
+		{alice, http.MethodPost, "/proxy/storage/v1/archive/uploads", false},
+		{bob, http.MethodPost, "/proxy/storage/v1/archive/uploads?namespace=a", false},
+		{alice, http.MethodGet, "/proxy/storage/v1/archive/uploads/mine", true},
+		{alice, http.MethodPut, "/proxy/storage/v1/archive/uploads/mine/parts/1", true},
+		{alice, http.MethodPost, "/proxy/storage/v1/archive/uploads/mine/complete", true},
+		{alice, http.MethodGet, "/proxy/storage/v1/archive/uploads/theirs", false},
+		{alice, http.MethodPut, "/proxy/storage/v1/archive/uploads/theirs/parts/1", false},
+		{alice, http.MethodPost, "/proxy/storage/v1/archive/uploads/theirs/complete", false},
+		{alice, http.MethodDelete, "/proxy/storage/v1/archive/uploads/theirs", false},
+		{bob, http.MethodPut, "/proxy/storage/v1/archive/uploads/mine/parts/1", false},
+		{alice, http.MethodGet, "/proxy/storage/v1/archive/uploads/expired", true},
+		{alice, http.MethodPost, "/proxy/workflows-apiserver/workflow", false},
+		{alice, http.MethodGet, "/proxy/workflows-apiserver/workflow", false},
+		{root, http.MethodPost, "/proxy/workflows-apiserver/workflow", true},
+		{bob, http.MethodPost, "/proxy/logs/hello?namespace=a", true},
+		{alice, http.MethodPost, "/proxy/influxdb?namespace=a", false},
+		{root, http.MethodPost, "/proxy/influxdb", true},
+	}
+	for _, tt := range tests {
+		attrs, attrsErr = nil, nil
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.url, nil))
+		if attrsErr != nil {
+			t.Fatalf("%v %v: %v", tt.method, tt.url, attrsErr)
+		}
+		if got := authorizeAny(auth, tt.id, attrs); got != tt.want {
+			t.Errorf("%v %v by %v: allowed = %v, want %v (%+v)", tt.method, tt.url, tt.id.User, got, tt.want, attrs)
+		}
+	}
+}
+
+func TestArchiveID(t *testing.T) {
+	for url, want := range map[string]string{
+		"http://storagesvc.fission/v1/archive?id=abc":       "abc",
+		"http://controller/proxy/storage/v1/archive?id=abc": "abc",
+		"https://example.com/code.zip?id=abc":               "",
+		"":                                                  "",
+	} {
+		if got := archiveID(url); got != want {
+			t.Errorf("archiveID(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
@@ -46,6 +46,7 @@ func RegisterCanaryConfigRoute(ws *restful.WebService) {
 				resp.ResponseWriter.WriteHeader(http.StatusOK)
 			}).
 			Param(ws.QueryParameter("namespace", "Namespace of canaryConfig").DataType("string").DefaultValue(metav1.NamespaceAll).Required(false)).
+			Do(listQueryParams(ws)).
 			Produces(restful.MIME_JSON).
 			Writes([]fv1.CanaryConfig{}).
 			Returns(http.StatusOK, "List of canaryConfigs", []fv1.CanaryConfig{}))
@@ -180,19 +181,23 @@ func (a *API) CanaryConfigApiList(w http.ResponseWriter, r *http.Request) {
 		ns = metav1.NamespaceDefault
 	}
 
-	canaryCfgs, err := a.fissionClient.CoreV1().CanaryConfigs(ns).List(metav1.ListOptions{})
-	if err != nil {
-		a.respondWithError(w, err)
+	query, ok := a.listQueryOrError(w, r)
+	if !ok {
+		return
+	}
+
+	if query.watch {
+		a.respondWithWatch(w, r, query, a.fissionClient.CoreV1().CanaryConfigs(ns).Watch)
 		return
 	}
 
-	resp, err := json.Marshal(canaryCfgs.Items)
+	canaryCfgs, err := a.fissionClient.CoreV1().CanaryConfigs(ns).List(query.options)
 	if err != nil {
 		a.respondWithError(w, err)
 		return
 	}
 
-	a.respondWithSuccess(w, resp)
+	a.respondWithList(w, query, canaryCfgs.Items, canaryCfgs.Continue)
 }
 
 func (a *API) CanaryConfigApiUpdate(w http.ResponseWriter, r *http.Request) {
@@ -22,10 +22,15 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"os/user"
 	"strings"
 
 	"github.com/pkg/errors"
 	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/fission/fission/pkg/apiauth"
+	"github.com/fission/fission/pkg/audit"
 )
 
 type (
@@ -41,12 +46,20 @@ type (
 
 	RESTClient struct {
 		url string
+
+		// user is the local user, reported to the controller for its audit log.
+		user string
+
+		// token authenticates requests if the controller requires authentication.
+		token string
 	}
 )
 
 func NewRESTClient(serverUrl string) Interface {
 	return &RESTClient{
-		url: strings.TrimSuffix(serverUrl, "/"),
+		url:   strings.TrimSuffix(serverUrl, "/"),
+		user:  localUser(),
+		token: os.Getenv(apiauth.TokenEnv),
 	}
 }
 
@@ -113,6 +126,12 @@ func (c *RESTClient) sendRequest(method string, relativeUrl string, headers map[
 	for k, v := range headers {
 		req.Header.Set(k, v)
 	}
+	if len(c.user) > 0 {
+		req.Header.Set(audit.UserHeader, c.user)
+	}
+	if len(c.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
 	// TODO: accept context
 	return ctxhttp.Do(context.Background(), &http.Client{}, req)
 }
@@ -124,3 +143,10 @@ func (c *RESTClient) v2CrdUrl(relativeUrl string) string {
 func (c *RESTClient) proxyUrl(relativeUrl string) string {
 	return c.url + "/proxy/" + strings.TrimPrefix(relativeUrl, "/")
 }
+
+func localUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
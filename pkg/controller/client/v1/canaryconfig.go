@@ -49,6 +49,11 @@ func newCanaryConfigClient(c *V1) CanaryConfigInterface {
 }
 
 func (c *CanaryConfig) Create(canaryConf *fv1.CanaryConfig) (*metav1.ObjectMeta, error) {
+	err := canaryConf.Validate()
+	if err != nil {
+		return nil, fv1.AggregateValidationErrors("CanaryConfig", err)
+	}
+
 	reqbody, err := json.Marshal(canaryConf)
 	if err != nil {
 		return nil, err
@@ -99,6 +104,11 @@ func (c *CanaryConfig) Get(m *metav1.ObjectMeta) (*fv1.CanaryConfig, error) {
 }
 
 func (c *CanaryConfig) Update(canaryConf *fv1.CanaryConfig) (*metav1.ObjectMeta, error) {
+	err := canaryConf.Validate()
+	if err != nil {
+		return nil, fv1.AggregateValidationErrors("CanaryConfig", err)
+	}
+
 	reqbody, err := json.Marshal(canaryConf)
 	if err != nil {
 		return nil, err
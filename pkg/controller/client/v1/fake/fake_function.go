@@ -21,6 +21,8 @@ import (
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	v1 "github.com/fission/fission/pkg/controller/client/v1"
+	"github.com/fission/fission/pkg/fnanalysis"
+	"github.com/fission/fission/pkg/logstore"
 )
 
 type (
@@ -54,3 +56,27 @@ func (c *FakeFunction) Delete(m *metav1.ObjectMeta) error {
 func (c *FakeFunction) List(functionNamespace string) ([]fv1.Function, error) {
 	return nil, nil
 }
+
+func (c *FakeFunction) ListPage(functionNamespace string, opts *v1.ListOptions) ([]fv1.Function, string, error) {
+	return nil, "", nil
+}
+
+func (c *FakeFunction) Watch(functionNamespace string, opts *v1.ListOptions, handler func(v1.WatchEvent) error) error {
+	return nil
+}
+
+func (c *FakeFunction) Analyze(m *metav1.ObjectMeta) (*fnanalysis.Report, error) {
+	return nil, nil
+}
+
+func (c *FakeFunction) Invocations(m *metav1.ObjectMeta, limit int) ([]fv1.InvocationRecord, error) {
+	return nil, nil
+}
+
+func (c *FakeFunction) Logs(m *metav1.ObjectMeta, query *logstore.Query) ([]logstore.Entry, error) {
+	return nil, nil
+}
+
+func (c *FakeFunction) Usages(functionNamespace string) ([]fv1.FunctionUsage, error) {
+	return nil, nil
+}
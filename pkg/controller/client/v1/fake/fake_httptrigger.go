@@ -50,3 +50,11 @@ func (c *FakeHTTPTrigger) Delete(m *metav1.ObjectMeta) error {
 func (c *FakeHTTPTrigger) List(triggerNamespace string) ([]fv1.HTTPTrigger, error) {
 	return nil, nil
 }
+
+func (c *FakeHTTPTrigger) ListPage(triggerNamespace string, opts *v1.ListOptions) ([]fv1.HTTPTrigger, string, error) {
+	return nil, "", nil
+}
+
+func (c *FakeHTTPTrigger) Watch(triggerNamespace string, opts *v1.ListOptions, handler func(v1.WatchEvent) error) error {
+	return nil
+}
@@ -20,8 +20,12 @@ import (
 	"io"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/audit"
 	v1 "github.com/fission/fission/pkg/controller/client/v1"
+	"github.com/fission/fission/pkg/drift"
 	"github.com/fission/fission/pkg/info"
+	"github.com/fission/fission/pkg/tombstone"
 )
 
 // TODO: we should remove this interface, having this for now is for backward compatibility.
@@ -46,9 +50,33 @@ func (c *FakeMisc) GetSvcURL(label string) (string, error) {
 }
 
 func (c *FakeMisc) ServerInfo() (*info.ServerInfo, error) {
-	return &info.ServerInfo{}, nil
+	return &info.ServerInfo{Features: info.SupportedFeatures}, nil
 }
 
 func (c *FakeMisc) PodLogs(m *metav1.ObjectMeta) (io.ReadCloser, int, error) {
 	return nil, 0, nil
 }
+
+func (c *FakeMisc) Status(namespace string, window string) (*info.NamespaceStatus, error) {
+	return &info.NamespaceStatus{}, nil
+}
+
+func (c *FakeMisc) Drift(revert bool) ([]drift.Drift, error) {
+	return nil, nil
+}
+
+func (c *FakeMisc) Tombstones(kind string, namespace string, name string) ([]tombstone.Tombstone, error) {
+	return nil, nil
+}
+
+func (c *FakeMisc) Restore(kind string, namespace string, name string, uid string) (*metav1.ObjectMeta, error) {
+	return nil, nil
+}
+
+func (c *FakeMisc) AuditLog(filter *v1.AuditFilter) ([]audit.Entry, error) {
+	return nil, nil
+}
+
+func (c *FakeMisc) FissionConfig() (*fv1.FissionConfig, error) {
+	return nil, nil
+}
@@ -22,6 +22,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/buildlog"
+	"github.com/fission/fission/pkg/pkgrevision"
 )
 
 type (
@@ -51,3 +53,19 @@ func (c *FakePackage) Delete(m *metav1.ObjectMeta) error {
 func (c *FakePackage) List(pkgNamespace string) ([]fv1.Package, error) {
 	return nil, nil
 }
+
+func (c *FakePackage) ListPage(pkgNamespace string, opts *v1.ListOptions) ([]fv1.Package, string, error) {
+	return nil, "", nil
+}
+
+func (c *FakePackage) Watch(pkgNamespace string, opts *v1.ListOptions, handler func(v1.WatchEvent) error) error {
+	return nil
+}
+
+func (c *FakePackage) BuildLogs(m *metav1.ObjectMeta) ([]buildlog.Record, error) {
+	return nil, nil
+}
+
+func (c *FakePackage) Revisions(m *metav1.ObjectMeta) ([]pkgrevision.Revision, error) {
+	return nil, nil
+}
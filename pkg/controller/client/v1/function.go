@@ -19,11 +19,16 @@ package v1
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strconv"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/controller/client/rest"
+	"github.com/fission/fission/pkg/fnanalysis"
+	"github.com/fission/fission/pkg/logstore"
 )
 
 type (
@@ -38,6 +43,12 @@ type (
 		Update(f *fv1.Function) (*metav1.ObjectMeta, error)
 		Delete(m *metav1.ObjectMeta) error
 		List(functionNamespace string) ([]fv1.Function, error)
+		ListPage(functionNamespace string, opts *ListOptions) ([]fv1.Function, string, error)
+		Watch(functionNamespace string, opts *ListOptions, handler func(WatchEvent) error) error
+		Analyze(m *metav1.ObjectMeta) (*fnanalysis.Report, error)
+		Invocations(m *metav1.ObjectMeta, limit int) ([]fv1.InvocationRecord, error)
+		Logs(m *metav1.ObjectMeta, query *logstore.Query) ([]logstore.Entry, error)
+		Usages(functionNamespace string) ([]fv1.FunctionUsage, error)
 	}
 
 	Function struct {
@@ -176,3 +187,143 @@ func (c *Function) List(functionNamespace string) ([]fv1.Function, error) {
 
 	return funcs, nil
 }
+
+// ListPage returns a page of functions selected by the options, and the token
+// for the next page.
+func (c *Function) ListPage(functionNamespace string, opts *ListOptions) ([]fv1.Function, string, error) {
+	relativeUrl := fmt.Sprintf("functions?namespace=%v", functionNamespace)
+	funcs := make([]fv1.Function, 0)
+	continueToken, err := listPage(c.client, relativeUrl, opts, &funcs)
+	if err != nil {
+		return nil, "", err
+	}
+	return funcs, continueToken, nil
+}
+
+// Watch streams the changes of functions selected by the options to handler,
+// starting with all existing functions unless opts.ResourceVersion is set.
+func (c *Function) Watch(functionNamespace string, opts *ListOptions, handler func(WatchEvent) error) error {
+	relativeUrl := fmt.Sprintf("functions?namespace=%v", functionNamespace)
+	return watchList(c.client, relativeUrl, opts, handler)
+}
+
+// Analyze returns the compute isolation report of a function.
+func (c *Function) Analyze(m *metav1.ObjectMeta) (*fnanalysis.Report, error) {
+	relativeUrl := fmt.Sprintf("functions/%v/analysis", m.Name)
+	relativeUrl += fmt.Sprintf("?namespace=%v", m.Namespace)
+
+	resp, err := c.client.Get(relativeUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := handleResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var report fnanalysis.Report
+	err = json.Unmarshal(body, &report)
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// Invocations returns the most recent invocations of a function, the most
+// recent first, all the ones recorded if limit is 0.
+func (c *Function) Invocations(m *metav1.ObjectMeta, limit int) ([]fv1.InvocationRecord, error) {
+	relativeUrl := fmt.Sprintf("functions/%v/invocations", m.Name)
+	relativeUrl += fmt.Sprintf("?namespace=%v", m.Namespace)
+	if limit > 0 {
+		relativeUrl += fmt.Sprintf("&limit=%v", limit)
+	}
+
+	resp, err := c.client.Get(relativeUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := handleResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var invocations []fv1.InvocationRecord
+	err = json.Unmarshal(body, &invocations)
+	if err != nil {
+		return nil, err
+	}
+
+	return invocations, nil
+}
+
+// Logs returns the log entries of a function selected by query, from the
+// log sink the logger ships them to. The function UID of query is ignored.
+func (c *Function) Logs(m *metav1.ObjectMeta, query *logstore.Query) ([]logstore.Entry, error) {
+	params := url.Values{}
+	params.Set("namespace", m.Namespace)
+	if len(query.Pod) > 0 {
+		params.Set("pod", query.Pod)
+	}
+	if !query.Since.IsZero() {
+		params.Set("since", query.Since.Format(time.RFC3339Nano))
+	}
+	if !query.Until.IsZero() {
+		params.Set("until", query.Until.Format(time.RFC3339Nano))
+	}
+	if query.Limit > 0 {
+		params.Set("limit", strconv.Itoa(query.Limit))
+	}
+	if query.Reverse {
+		params.Set("reverse", "true")
+	}
+	relativeUrl := fmt.Sprintf("functions/%v/logs?%v", m.Name, params.Encode())
+
+	resp, err := c.client.Get(relativeUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := handleResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []logstore.Entry
+	err = json.Unmarshal(body, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Usages returns the resource usage of the functions of a namespace, of all
+// namespaces if functionNamespace is empty.
+func (c *Function) Usages(functionNamespace string) ([]fv1.FunctionUsage, error) {
+	relativeUrl := fmt.Sprintf("functionusages?namespace=%v", functionNamespace)
+
+	resp, err := c.client.Get(relativeUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := handleResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var usages []fv1.FunctionUsage
+	err = json.Unmarshal(body, &usages)
+	if err != nil {
+		return nil, err
+	}
+
+	return usages, nil
+}
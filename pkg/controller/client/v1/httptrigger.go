@@ -37,6 +37,8 @@ type (
 		Update(t *fv1.HTTPTrigger) (*metav1.ObjectMeta, error)
 		Delete(m *metav1.ObjectMeta) error
 		List(triggerNamespace string) ([]fv1.HTTPTrigger, error)
+		ListPage(triggerNamespace string, opts *ListOptions) ([]fv1.HTTPTrigger, string, error)
+		Watch(triggerNamespace string, opts *ListOptions, handler func(WatchEvent) error) error
 	}
 
 	HTTPTrigger struct {
@@ -161,3 +163,22 @@ func (c *HTTPTrigger) List(triggerNamespace string) ([]fv1.HTTPTrigger, error) {
 
 	return triggers, nil
 }
+
+// ListPage returns a page of HTTP triggers selected by the options, and the token
+// for the next page.
+func (c *HTTPTrigger) ListPage(triggerNamespace string, opts *ListOptions) ([]fv1.HTTPTrigger, string, error) {
+	relativeUrl := fmt.Sprintf("triggers/http?namespace=%v", triggerNamespace)
+	triggers := make([]fv1.HTTPTrigger, 0)
+	continueToken, err := listPage(c.client, relativeUrl, opts, &triggers)
+	if err != nil {
+		return nil, "", err
+	}
+	return triggers, continueToken, nil
+}
+
+// Watch streams the changes of HTTP triggers selected by the options to handler,
+// starting with all existing HTTP triggers unless opts.ResourceVersion is set.
+func (c *HTTPTrigger) Watch(triggerNamespace string, opts *ListOptions, handler func(WatchEvent) error) error {
+	relativeUrl := fmt.Sprintf("triggers/http?namespace=%v", triggerNamespace)
+	return watchList(c.client, relativeUrl, opts, handler)
+}
@@ -22,13 +22,19 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/audit"
 	"github.com/fission/fission/pkg/controller/client/rest"
+	"github.com/fission/fission/pkg/drift"
 	"github.com/fission/fission/pkg/fission-cli/console"
 	"github.com/fission/fission/pkg/info"
+	"github.com/fission/fission/pkg/tombstone"
 )
 
 // TODO: we should remove this interface, having this for now is for backward compatibility.
@@ -43,11 +49,29 @@ type (
 		GetSvcURL(label string) (string, error)
 		ServerInfo() (*info.ServerInfo, error)
 		PodLogs(m *metav1.ObjectMeta) (io.ReadCloser, int, error)
+		Status(namespace string, window string) (*info.NamespaceStatus, error)
+		Drift(revert bool) ([]drift.Drift, error)
+		Tombstones(kind string, namespace string, name string) ([]tombstone.Tombstone, error)
+		Restore(kind string, namespace string, name string, uid string) (*metav1.ObjectMeta, error)
+		AuditLog(filter *AuditFilter) ([]audit.Entry, error)
+		FissionConfig() (*fv1.FissionConfig, error)
 	}
 
 	Misc struct {
 		client rest.Interface
 	}
+
+	// AuditFilter selects audit log entries, empty fields match all.
+	AuditFilter struct {
+		Kind      string
+		Namespace string
+		Name      string
+		User      string
+
+		// Since is an RFC3339 time or a duration ago, e.g. 1h.
+		Since string
+		Limit int
+	}
 )
 
 func newMiscClient(c *V1) MiscInterface {
@@ -128,3 +152,165 @@ func (c *Misc) PodLogs(m *metav1.ObjectMeta) (io.ReadCloser, int, error) {
 	}
 	return resp.Body, resp.StatusCode, nil
 }
+
+func (c *Misc) Status(namespace string, window string) (*info.NamespaceStatus, error) {
+	relativeUrl := fmt.Sprintf("status?namespace=%v", namespace)
+	if len(window) > 0 {
+		relativeUrl += fmt.Sprintf("&window=%v", window)
+	}
+
+	resp, err := c.client.Get(relativeUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := handleResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &info.NamespaceStatus{}
+	err = json.Unmarshal(body, status)
+	if err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+func (c *Misc) Drift(revert bool) ([]drift.Drift, error) {
+	relativeUrl := "drift?revert=" + strconv.FormatBool(revert)
+
+	resp, err := c.client.Get(relativeUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := handleResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var drifts []drift.Drift
+	err = json.Unmarshal(body, &drifts)
+	if err != nil {
+		return nil, err
+	}
+
+	return drifts, nil
+}
+
+func (c *Misc) Tombstones(kind string, namespace string, name string) ([]tombstone.Tombstone, error) {
+	query := url.Values{}
+	query.Set("kind", kind)
+	query.Set("namespace", namespace)
+	query.Set("name", name)
+
+	resp, err := c.client.Get("tombstones?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := handleResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var tombstones []tombstone.Tombstone
+	err = json.Unmarshal(body, &tombstones)
+	if err != nil {
+		return nil, err
+	}
+
+	return tombstones, nil
+}
+
+func (c *Misc) Restore(kind string, namespace string, name string, uid string) (*metav1.ObjectMeta, error) {
+	query := url.Values{}
+	query.Set("kind", kind)
+	query.Set("namespace", namespace)
+	query.Set("name", name)
+	if len(uid) > 0 {
+		query.Set("uid", uid)
+	}
+
+	resp, err := c.client.Create("tombstones/restore?"+query.Encode(), "application/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := handleCreateResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var m metav1.ObjectMeta
+	err = json.Unmarshal(body, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+func (c *Misc) AuditLog(filter *AuditFilter) ([]audit.Entry, error) {
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"kind":      filter.Kind,
+		"namespace": filter.Namespace,
+		"name":      filter.Name,
+		"user":      filter.User,
+		"since":     filter.Since,
+	} {
+		if len(value) > 0 {
+			query.Set(key, value)
+		}
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+
+	resp, err := c.client.Get("audit?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := handleResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []audit.Entry
+	err = json.Unmarshal(body, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (c *Misc) FissionConfig() (*fv1.FissionConfig, error) {
+	resp, err := c.client.Get("config")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := handleResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg fv1.FissionConfig
+	err = json.Unmarshal(body, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
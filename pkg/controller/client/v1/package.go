@@ -24,6 +24,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/buildlog"
+	"github.com/fission/fission/pkg/pkgrevision"
 )
 
 type (
@@ -37,6 +39,10 @@ type (
 		Update(f *fv1.Package) (*metav1.ObjectMeta, error)
 		Delete(m *metav1.ObjectMeta) error
 		List(pkgNamespace string) ([]fv1.Package, error)
+		ListPage(pkgNamespace string, opts *ListOptions) ([]fv1.Package, string, error)
+		Watch(pkgNamespace string, opts *ListOptions, handler func(WatchEvent) error) error
+		BuildLogs(m *metav1.ObjectMeta) ([]buildlog.Record, error)
+		Revisions(m *metav1.ObjectMeta) ([]pkgrevision.Revision, error)
 	}
 
 	Package struct {
@@ -161,3 +167,74 @@ func (c *Package) List(pkgNamespace string) ([]fv1.Package, error) {
 
 	return funcs, nil
 }
+
+// ListPage returns a page of packages selected by the options, and the token
+// for the next page.
+func (c *Package) ListPage(pkgNamespace string, opts *ListOptions) ([]fv1.Package, string, error) {
+	relativeUrl := fmt.Sprintf("packages?namespace=%v", pkgNamespace)
+	pkgs := make([]fv1.Package, 0)
+	continueToken, err := listPage(c.client, relativeUrl, opts, &pkgs)
+	if err != nil {
+		return nil, "", err
+	}
+	return pkgs, continueToken, nil
+}
+
+// Watch streams the changes of packages selected by the options to handler,
+// starting with all existing packages unless opts.ResourceVersion is set.
+func (c *Package) Watch(pkgNamespace string, opts *ListOptions, handler func(WatchEvent) error) error {
+	relativeUrl := fmt.Sprintf("packages?namespace=%v", pkgNamespace)
+	return watchList(c.client, relativeUrl, opts, handler)
+}
+
+// BuildLogs returns the logs of the last builds of a package, the most
+// recent build first.
+func (c *Package) BuildLogs(m *metav1.ObjectMeta) ([]buildlog.Record, error) {
+	relativeUrl := fmt.Sprintf("packages/%v/buildlogs", m.Name)
+	relativeUrl += fmt.Sprintf("?namespace=%v", m.Namespace)
+
+	resp, err := c.client.Get(relativeUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := handleResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []buildlog.Record
+	err = json.Unmarshal(body, &records)
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// Revisions returns the last generations of a package, the most recent
+// first.
+func (c *Package) Revisions(m *metav1.ObjectMeta) ([]pkgrevision.Revision, error) {
+	relativeUrl := fmt.Sprintf("packages/%v/revisions", m.Name)
+	relativeUrl += fmt.Sprintf("?namespace=%v", m.Namespace)
+
+	resp, err := c.client.Get(relativeUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := handleResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []pkgrevision.Revision
+	err = json.Unmarshal(body, &revisions)
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
@@ -17,9 +17,18 @@ limitations under the License.
 package v1
 
 import (
+	"encoding/json"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	ferror "github.com/fission/fission/pkg/error"
 
 	"github.com/fission/fission/pkg/controller/client/rest"
@@ -41,6 +50,43 @@ type (
 	V1 struct {
 		restClient rest.Interface
 	}
+
+	// ListOptions selects the objects returned by list APIs and returns
+	// them a page at a time.
+	ListOptions struct {
+		// LabelSelector selects objects by labels, e.g. "app=foo".
+		LabelSelector string
+
+		// FieldSelector selects objects by fields, e.g. "spec.environment.name=nodejs".
+		FieldSelector string
+
+		// Fields are the only fields returned of each object, e.g.
+		// "metadata.name". All fields are returned if empty.
+		Fields []string
+
+		// Limit is the maximum number of objects in a page, all objects
+		// are returned if 0.
+		Limit int64
+
+		// Continue is the token of the page to return, it's returned
+		// with the previous page.
+		Continue string
+
+		// ResourceVersion makes watches only return changes after this
+		// version, e.g. the one of the last object of a previous watch.
+		// Watches start with all existing objects if empty.
+		ResourceVersion string
+	}
+
+	// WatchEvent is a change of an object returned by watches.
+	WatchEvent struct {
+		// Type is ADDED, MODIFIED or DELETED.
+		Type string `json:"type"`
+
+		// Object is the JSON form of the object, with only the fields
+		// in the options if any.
+		Object json.RawMessage `json:"object"`
+	}
 )
 
 func MakeV1Client(restClient rest.Interface) *V1 {
@@ -83,6 +129,101 @@ func (c *V1) TimeTrigger() TimeTriggerInterface {
 	return newTimeTriggerClient(c)
 }
 
+// encode returns the options as URL query parameters, prefixed with "&".
+func (opts *ListOptions) encode() string {
+	if opts == nil {
+		return ""
+	}
+
+	values := url.Values{}
+	if len(opts.LabelSelector) > 0 {
+		values.Set("labelSelector", opts.LabelSelector)
+	}
+	if len(opts.FieldSelector) > 0 {
+		values.Set("fieldSelector", opts.FieldSelector)
+	}
+	if len(opts.Fields) > 0 {
+		values.Set("fields", strings.Join(opts.Fields, ","))
+	}
+	if opts.Limit > 0 {
+		values.Set("limit", strconv.FormatInt(opts.Limit, 10))
+	}
+	if len(opts.Continue) > 0 {
+		values.Set("continue", opts.Continue)
+	}
+	if len(opts.ResourceVersion) > 0 {
+		values.Set("resourceVersion", opts.ResourceVersion)
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return "&" + values.Encode()
+}
+
+// listPage gets a page of objects from the list API at relativeUrl, decodes
+// them into items and returns the token for the next page, which is empty
+// on the last page.
+func listPage(client rest.Interface, relativeUrl string, opts *ListOptions, items interface{}) (string, error) {
+	resp, err := client.Get(relativeUrl + opts.encode())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := handleResponse(resp)
+	if err != nil {
+		return "", err
+	}
+
+	err = json.Unmarshal(body, items)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Header.Get(fv1.HeaderContinue), nil
+}
+
+// watchList streams the changes of objects selected by the options from the
+// list API at relativeUrl to handler, until handler returns an error or the
+// server ends the watch.
+func watchList(client rest.Interface, relativeUrl string, opts *ListOptions, handler func(WatchEvent) error) error {
+	resp, err := client.Get(relativeUrl + opts.encode() + "&watch=true")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return ferror.MakeErrorFromHTTP(resp)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event WatchEvent
+		err = decoder.Decode(&event)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "error decoding watch event")
+		}
+
+		if event.Type == "ERROR" {
+			var status metav1.Status
+			err = json.Unmarshal(event.Object, &status)
+			if err != nil {
+				return errors.Wrap(err, "error decoding watch error")
+			}
+			return errors.Errorf("watch ended with error: %v", status.Message)
+		}
+
+		err = handler(event)
+		if err != nil {
+			return err
+		}
+	}
+}
+
 func handleResponse(resp *http.Response) ([]byte, error) {
 	if resp.StatusCode != 200 {
 		return nil, ferror.MakeErrorFromHTTP(resp)
@@ -26,12 +26,13 @@ import (
 	"github.com/fission/fission/pkg/canaryconfigmgr"
 	"github.com/fission/fission/pkg/crd"
 	config "github.com/fission/fission/pkg/featureconfig"
+	"github.com/fission/fission/pkg/fissionconfig"
 )
 
 func ConfigCanaryFeature(context context.Context, logger *zap.Logger, fissionClient *crd.FissionClient, kubeClient *kubernetes.Clientset, featureConfig *config.FeatureConfig, featureStatus map[string]string) error {
 	// start the appropriate controller
 	if featureConfig.CanaryConfig.IsEnabled {
-		canaryCfgMgr, err := canaryconfigmgr.MakeCanaryConfigMgr(logger, fissionClient, kubeClient, fissionClient.CoreV1().RESTClient(),
+		canaryCfgMgr, err := canaryconfigmgr.MakeCanaryConfigMgr(logger, fissionClient, kubeClient,
 			featureConfig.CanaryConfig.PrometheusSvc)
 		if err != nil {
 			featureStatus[config.CanaryFeature] = err.Error()
@@ -63,5 +64,13 @@ func ConfigureFeatures(context context.Context, logger *zap.Logger, unitTestMode
 	// configure respective features
 	// in the future when new optional features are added, we need to add corresponding feature handlers and invoke them here
 	err = ConfigCanaryFeature(context, logger, fissionClient, kubeClient, featureConfig, featureStatus)
+
+	// components reconcile the FissionConfig, seeded with the deployed spec
+	if featureConfig.FissionConfig != nil {
+		cfgErr := fissionconfig.Deploy(logger, fissionClient, podNamespace, featureConfig.FissionConfig)
+		if cfgErr != nil {
+			logger.Error("error deploying FissionConfig - components keep their deployed settings", zap.Error(cfgErr))
+		}
+	}
 	return featureStatus, err
 }
@@ -53,5 +53,7 @@ func Start(logger *zap.Logger, port int, unitTestFlag bool) {
 	if err != nil {
 		cLogger.Fatal("failed to start controller", zap.Error(err))
 	}
+	go api.tombstones.Run(ctx.Done())
+	go api.pkgRevisions.Run(ctx.Done())
 	api.Serve(port)
 }
@@ -0,0 +1,180 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"github.com/go-openapi/spec"
+	"github.com/pkg/errors"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fission/fission/pkg/drift"
+	ferror "github.com/fission/fission/pkg/error"
+	"github.com/fission/fission/pkg/router/util"
+)
+
+func RegisterDriftRoute(ws *restful.WebService) {
+	tags := []string{"Drift"}
+	specTag = append(specTag, spec.Tag{TagProps: spec.TagProps{Name: "Drift", Description: "Drift Operation"}})
+
+	ws.Route(
+		ws.GET("/v2/drift").
+			Doc("Get kubernetes objects generated for functions and triggers that differ from their specs").
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			To(func(req *restful.Request, resp *restful.Response) {
+				resp.ResponseWriter.WriteHeader(http.StatusOK)
+			}).
+			Param(ws.QueryParameter("revert", "Bring the objects back in line with the specs").DataType("boolean").DefaultValue("false").Required(false)).
+			Produces(restful.MIME_JSON).
+			Writes([]drift.Drift{}).
+			Returns(http.StatusOK, "List of drifted objects", []drift.Drift{}))
+}
+
+// DriftApiGet reports the deployments, services and HPAs of functions, which
+// executor generates, and the ingresses of HTTP triggers, which router
+// generates, that differ from what the specs imply.
+func (a *API) DriftApiGet(w http.ResponseWriter, r *http.Request) {
+	revert := false
+	if revertStr := a.extractQueryParamFromRequest(r, "revert"); len(revertStr) > 0 {
+		var err error
+		revert, err = strconv.ParseBool(revertStr)
+		if err != nil {
+			a.respondWithError(w, ferror.MakeError(ferror.ErrorInvalidArgument, fmt.Sprintf("invalid revert value: %v", revertStr)))
+			return
+		}
+	}
+
+	drifts := []drift.Drift{}
+
+	fnDrifts, err := a.executor.DetectDrift(r.Context(), revert)
+	if err != nil {
+		a.respondWithError(w, errors.Wrap(err, "error getting drift of function objects from executor"))
+		return
+	}
+	drifts = append(drifts, fnDrifts...)
+
+	ingressDrifts, err := a.ingressDrift(revert)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+	drifts = append(drifts, ingressDrifts...)
+
+	resp, err := json.Marshal(drifts)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+	a.respondWithSuccess(w, resp)
+}
+
+// ingressDrift compares the ingresses of HTTP triggers with the ones router
+// creates for them. Router periodically reconciles ingresses as well,
+// reverting just doesn't wait for it.
+func (a *API) ingressDrift(revert bool) ([]drift.Drift, error) {
+	triggers, err := a.fissionClient.CoreV1().HTTPTriggers(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	ingressClient := a.kubernetesClient.ExtensionsV1beta1().Ingresses(podNamespace)
+	ingresses, err := ingressClient.List(metav1.ListOptions{
+		// the labels router sets on the ingresses of triggers
+		LabelSelector: "triggerName,triggerNamespace",
+	})
+	if err != nil {
+		return nil, err
+	}
+	live := make(map[string]*v1beta1.Ingress, len(ingresses.Items))
+	for i := range ingresses.Items {
+		live[ingresses.Items[i].ObjectMeta.Name] = &ingresses.Items[i]
+	}
+
+	var drifts []drift.Drift
+	for i := range triggers.Items {
+		trigger := &triggers.Items[i]
+		if !util.UsesIngress(trigger) {
+			continue
+		}
+
+		owner := fmt.Sprintf("httptrigger %v/%v", trigger.ObjectMeta.Namespace, trigger.ObjectMeta.Name)
+		expected := util.GetIngressSpec(podNamespace, trigger)
+		ingress, ok := live[expected.ObjectMeta.Name]
+		if !ok {
+			d := drift.Drift{Kind: "Ingress", Namespace: podNamespace, Name: expected.ObjectMeta.Name, Owner: owner, Reason: drift.ReasonMissing}
+			if revert {
+				_, err = ingressClient.Create(expected)
+				d.SetRevertResult(err)
+			}
+			drifts = append(drifts, d)
+			continue
+		}
+		delete(live, expected.ObjectMeta.Name)
+
+		diffs, err := drift.Diff(expected, ingress, "metadata.labels", "metadata.annotations", "spec")
+		if err != nil {
+			return nil, err
+		}
+		if len(diffs) == 0 {
+			continue
+		}
+		d := drift.Drift{Kind: "Ingress", Namespace: podNamespace, Name: ingress.ObjectMeta.Name, Owner: owner, Reason: drift.ReasonModified, Fields: diffs}
+		if revert {
+			ingress.ObjectMeta.Labels = mergeMaps(ingress.ObjectMeta.Labels, expected.ObjectMeta.Labels)
+			ingress.ObjectMeta.Annotations = mergeMaps(ingress.ObjectMeta.Annotations, expected.ObjectMeta.Annotations)
+			ingress.Spec = expected.Spec
+			_, err = ingressClient.Update(ingress)
+			d.SetRevertResult(err)
+		}
+		drifts = append(drifts, d)
+	}
+
+	// ingresses left are of triggers that no longer exist or no longer want one
+	for name, ingress := range live {
+		d := drift.Drift{
+			Kind:      "Ingress",
+			Namespace: podNamespace,
+			Name:      name,
+			Owner:     fmt.Sprintf("httptrigger %v/%v", ingress.ObjectMeta.Labels["triggerNamespace"], ingress.ObjectMeta.Labels["triggerName"]),
+			Reason:    drift.ReasonOrphaned,
+		}
+		if revert {
+			d.SetRevertResult(ingressClient.Delete(name, &metav1.DeleteOptions{}))
+		}
+		drifts = append(drifts, d)
+	}
+
+	return drifts, nil
+}
+
+// mergeMaps sets the expected entries in m, keeping the entries added by others.
+func mergeMaps(m map[string]string, expected map[string]string) map[string]string {
+	if m == nil {
+		m = make(map[string]string, len(expected))
+	}
+	for k, v := range expected {
+		m[k] = v
+	}
+	return m
+}
@@ -44,6 +44,7 @@ func RegisterEnvironmentRoute(ws *restful.WebService) {
 				resp.ResponseWriter.WriteHeader(http.StatusOK)
 			}).
 			Param(ws.QueryParameter("namespace", "Namespace of environment").DataType("string").DefaultValue(metav1.NamespaceAll).Required(false)).
+			Do(listQueryParams(ws)).
 			Produces(restful.MIME_JSON).
 			Writes([]fv1.Environment{}).
 			Returns(http.StatusOK, "List of environments", []fv1.Environment{}))
@@ -105,19 +106,23 @@ func (a *API) EnvironmentApiList(w http.ResponseWriter, r *http.Request) {
 		ns = metav1.NamespaceAll
 	}
 
-	envs, err := a.fissionClient.CoreV1().Environments(ns).List(metav1.ListOptions{})
-	if err != nil {
-		a.respondWithError(w, err)
+	query, ok := a.listQueryOrError(w, r)
+	if !ok {
+		return
+	}
+
+	if query.watch {
+		a.respondWithWatch(w, r, query, a.fissionClient.CoreV1().Environments(ns).Watch)
 		return
 	}
 
-	resp, err := json.Marshal(envs.Items)
+	envs, err := a.fissionClient.CoreV1().Environments(ns).List(query.options)
 	if err != nil {
 		a.respondWithError(w, err)
 		return
 	}
 
-	a.respondWithSuccess(w, resp)
+	a.respondWithList(w, query, envs.Items, envs.Continue)
 }
 
 func (a *API) EnvironmentApiCreate(w http.ResponseWriter, r *http.Request) {
@@ -135,6 +140,8 @@ func (a *API) EnvironmentApiCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	env.Spec.SetDefaults()
+
 	// check if namespace exists, if not create it.
 	err = a.createNsIfNotExists(env.ObjectMeta.Namespace)
 	if err != nil {
@@ -205,6 +212,8 @@ func (a *API) EnvironmentApiUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	env.Spec.SetDefaults()
+
 	enew, err := a.fissionClient.CoreV1().Environments(env.ObjectMeta.Namespace).Update(&env)
 	if err != nil {
 		a.respondWithError(w, err)
@@ -0,0 +1,61 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"github.com/go-openapi/spec"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/fissionconfig"
+)
+
+func RegisterFissionConfigRoute(ws *restful.WebService) {
+	tags := []string{"FissionConfig"}
+	specTag = append(specTag, spec.Tag{TagProps: spec.TagProps{Name: "FissionConfig", Description: "FissionConfig Operation"}})
+
+	ws.Route(
+		ws.GET("/v2/config").
+			Doc("Get the config of the Fission components and the settings in effect in each").
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			To(func(req *restful.Request, resp *restful.Response) {
+				resp.ResponseWriter.WriteHeader(http.StatusOK)
+			}).
+			Produces(restful.MIME_JSON).
+			Writes(fv1.FissionConfig{}).
+			Returns(http.StatusOK, "FissionConfig", fv1.FissionConfig{}))
+}
+
+func (a *API) FissionConfigApiGet(w http.ResponseWriter, r *http.Request) {
+	cfg, err := a.fissionClient.CoreV1().FissionConfigs(podNamespace).Get(fissionconfig.Name, metav1.GetOptions{})
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+
+	resp, err := json.Marshal(cfg)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+	a.respondWithSuccess(w, resp)
+}
@@ -27,6 +27,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/emicklei/go-restful"
 	restfulspec "github.com/emicklei/go-restful-openapi"
@@ -35,12 +36,16 @@ import (
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	apiv1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	ferror "github.com/fission/fission/pkg/error"
+	"github.com/fission/fission/pkg/fnanalysis"
+	"github.com/fission/fission/pkg/logstore"
+	"github.com/fission/fission/pkg/sourcemap"
 )
 
 func RegisterFunctionRoute(ws *restful.WebService) {
@@ -55,6 +60,7 @@ func RegisterFunctionRoute(ws *restful.WebService) {
 				resp.ResponseWriter.WriteHeader(http.StatusOK)
 			}).
 			Param(ws.QueryParameter("namespace", "Namespace of function").DataType("string").DefaultValue(metav1.NamespaceAll).Required(false)).
+			Do(listQueryParams(ws)).
 			Produces(restful.MIME_JSON).
 			Writes([]fv1.Function{}).
 			Returns(http.StatusOK, "List of functions", []fv1.Function{}))
@@ -108,6 +114,63 @@ func RegisterFunctionRoute(ws *restful.WebService) {
 			Param(ws.QueryParameter("namespace", "Namespace of function").DataType("string").DefaultValue(metav1.NamespaceAll).Required(false)).
 			Produces(restful.MIME_JSON).
 			Returns(http.StatusOK, "Only HTTP status returned", nil))
+
+	ws.Route(
+		ws.GET("/v2/functions/{function}/analysis").
+			Doc("Analyze compute isolation of function").
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			To(func(req *restful.Request, resp *restful.Response) {
+				resp.ResponseWriter.WriteHeader(http.StatusOK)
+			}).
+			Param(ws.PathParameter("function", "Function name").DataType("string").DefaultValue("").Required(true)).
+			Param(ws.QueryParameter("namespace", "Namespace of function").DataType("string").DefaultValue(metav1.NamespaceDefault).Required(false)).
+			Produces(restful.MIME_JSON).
+			Writes(fnanalysis.Report{}).
+			Returns(http.StatusOK, "Compute isolation report of function, with findings and recommendations", fnanalysis.Report{}))
+
+	ws.Route(
+		ws.GET("/v2/functions/{function}/invocations").
+			Doc("List the most recent invocations of function").
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			To(func(req *restful.Request, resp *restful.Response) {
+				resp.ResponseWriter.WriteHeader(http.StatusOK)
+			}).
+			Param(ws.PathParameter("function", "Function name").DataType("string").DefaultValue("").Required(true)).
+			Param(ws.QueryParameter("namespace", "Namespace of function").DataType("string").DefaultValue(metav1.NamespaceDefault).Required(false)).
+			Param(ws.QueryParameter("limit", "Maximum number of invocations, the most recent ones are returned").DataType("integer").Required(false)).
+			Produces(restful.MIME_JSON).
+			Writes([]fv1.InvocationRecord{}).
+			Returns(http.StatusOK, "List of invocations, the most recent first", []fv1.InvocationRecord{}))
+
+	ws.Route(
+		ws.GET("/v2/functions/{function}/logs").
+			Doc("Query the logs of function from the log sink").
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			To(func(req *restful.Request, resp *restful.Response) {
+				resp.ResponseWriter.WriteHeader(http.StatusOK)
+			}).
+			Param(ws.PathParameter("function", "Function name").DataType("string").DefaultValue("").Required(true)).
+			Param(ws.QueryParameter("namespace", "Namespace of function").DataType("string").DefaultValue(metav1.NamespaceDefault).Required(false)).
+			Param(ws.QueryParameter("pod", "Only the logs of this pod of function").DataType("string").Required(false)).
+			Param(ws.QueryParameter("since", "Only the logs at or after this RFC 3339 time").DataType("string").Required(false)).
+			Param(ws.QueryParameter("until", "Only the logs at or before this RFC 3339 time").DataType("string").Required(false)).
+			Param(ws.QueryParameter("limit", "Maximum number of log entries").DataType("integer").Required(false)).
+			Param(ws.QueryParameter("reverse", "Return the most recent log entries first").DataType("boolean").Required(false)).
+			Produces(restful.MIME_JSON).
+			Writes([]logstore.Entry{}).
+			Returns(http.StatusOK, "List of log entries", []logstore.Entry{}))
+
+	ws.Route(
+		ws.GET("/v2/functionusages").
+			Doc("List the resource usage of functions").
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			To(func(req *restful.Request, resp *restful.Response) {
+				resp.ResponseWriter.WriteHeader(http.StatusOK)
+			}).
+			Param(ws.QueryParameter("namespace", "Namespace of functions").DataType("string").DefaultValue(metav1.NamespaceAll).Required(false)).
+			Produces(restful.MIME_JSON).
+			Writes([]fv1.FunctionUsage{}).
+			Returns(http.StatusOK, "List of function usages", []fv1.FunctionUsage{}))
 }
 
 func (a *API) FunctionApiList(w http.ResponseWriter, r *http.Request) {
@@ -116,19 +179,23 @@ func (a *API) FunctionApiList(w http.ResponseWriter, r *http.Request) {
 		ns = metav1.NamespaceAll
 	}
 
-	funcs, err := a.fissionClient.CoreV1().Functions(ns).List(metav1.ListOptions{})
-	if err != nil {
-		a.respondWithError(w, err)
+	query, ok := a.listQueryOrError(w, r)
+	if !ok {
+		return
+	}
+
+	if query.watch {
+		a.respondWithWatch(w, r, query, a.fissionClient.CoreV1().Functions(ns).Watch)
 		return
 	}
 
-	resp, err := json.Marshal(funcs.Items)
+	funcs, err := a.fissionClient.CoreV1().Functions(ns).List(query.options)
 	if err != nil {
 		a.respondWithError(w, err)
 		return
 	}
 
-	a.respondWithSuccess(w, resp)
+	a.respondWithList(w, query, funcs.Items, funcs.Continue)
 }
 
 func (a *API) FunctionApiCreate(w http.ResponseWriter, r *http.Request) {
@@ -145,6 +212,8 @@ func (a *API) FunctionApiCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	f.Spec.SetDefaults(f.ObjectMeta.Namespace)
+
 	// check if namespace exists, if not create it.
 	err = a.createNsIfNotExists(f.ObjectMeta.Namespace)
 	if err != nil {
@@ -190,6 +259,182 @@ func (a *API) FunctionApiGet(w http.ResponseWriter, r *http.Request) {
 	a.respondWithSuccess(w, resp)
 }
 
+// FunctionApiAnalyze reports on how the pods of a function are affected by
+// their resource limits and by the pods they share nodes with.
+func (a *API) FunctionApiAnalyze(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["function"]
+	ns := a.extractQueryParamFromRequest(r, "namespace")
+	if len(ns) == 0 {
+		ns = metav1.NamespaceDefault
+	}
+
+	f, err := a.fissionClient.CoreV1().Functions(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+
+	report, err := a.analyzer.Analyze(&f.ObjectMeta)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+
+	resp, err := json.Marshal(report)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+	a.respondWithSuccess(w, resp)
+}
+
+// FunctionApiInvocations lists the most recent invocations of a function,
+// as router recorded them in its invocation history.
+func (a *API) FunctionApiInvocations(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["function"]
+	ns := a.extractQueryParamFromRequest(r, "namespace")
+	if len(ns) == 0 {
+		ns = metav1.NamespaceDefault
+	}
+
+	var limit int
+	if l := a.extractQueryParamFromRequest(r, "limit"); len(l) > 0 {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 0 {
+			a.respondWithError(w, ferror.MakeError(ferror.ErrorInvalidArgument, fmt.Sprintf("invalid limit value: %v", l)))
+			return
+		}
+	}
+
+	f, err := a.fissionClient.CoreV1().Functions(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+
+	invocations := []fv1.InvocationRecord{}
+	history, err := a.fissionClient.CoreV1().InvocationHistories(ns).Get(name, metav1.GetOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		a.respondWithError(w, err)
+		return
+	}
+	if err == nil {
+		// skip the history of a deleted function of the same name
+		for _, ref := range history.ObjectMeta.OwnerReferences {
+			if ref.UID == f.ObjectMeta.UID {
+				invocations = history.Invocations
+			}
+		}
+	}
+	if limit > 0 && len(invocations) > limit {
+		invocations = invocations[:limit]
+	}
+
+	resp, err := json.Marshal(invocations)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+	a.respondWithSuccess(w, resp)
+}
+
+// FunctionApiLogs queries the logs of a function in a time range from the
+// sink the logger ships them to.
+func (a *API) FunctionApiLogs(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["function"]
+	ns := a.extractQueryParamFromRequest(r, "namespace")
+	if len(ns) == 0 {
+		ns = metav1.NamespaceDefault
+	}
+
+	if a.logStore == nil {
+		a.respondWithError(w, ferror.MakeError(ferror.ErrorNotFound,
+			"function logs are not shipped to a queryable log sink, get them from the function pods"))
+		return
+	}
+
+	query := &logstore.Query{
+		Pod: a.extractQueryParamFromRequest(r, "pod"),
+	}
+	for param, t := range map[string]*time.Time{"since": &query.Since, "until": &query.Until} {
+		v := a.extractQueryParamFromRequest(r, param)
+		if len(v) == 0 {
+			continue
+		}
+		var err error
+		*t, err = time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			a.respondWithError(w, ferror.MakeError(ferror.ErrorInvalidArgument, fmt.Sprintf("invalid %v value: %v", param, v)))
+			return
+		}
+	}
+	if l := a.extractQueryParamFromRequest(r, "limit"); len(l) > 0 {
+		var err error
+		query.Limit, err = strconv.Atoi(l)
+		if err != nil || query.Limit < 0 {
+			a.respondWithError(w, ferror.MakeError(ferror.ErrorInvalidArgument, fmt.Sprintf("invalid limit value: %v", l)))
+			return
+		}
+	}
+	if rev := a.extractQueryParamFromRequest(r, "reverse"); len(rev) > 0 {
+		var err error
+		query.Reverse, err = strconv.ParseBool(rev)
+		if err != nil {
+			a.respondWithError(w, ferror.MakeError(ferror.ErrorInvalidArgument, fmt.Sprintf("invalid reverse value: %v", rev)))
+			return
+		}
+	}
+
+	f, err := a.fissionClient.CoreV1().Functions(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+	query.FunctionUID = string(f.ObjectMeta.UID)
+
+	entries, err := a.logStore.Query(query)
+	if err != nil {
+		a.respondWithError(w, errors.Wrapf(err, "error querying logs from %v", a.logStore))
+		return
+	}
+	if entries == nil {
+		entries = []logstore.Entry{}
+	}
+
+	resp, err := json.Marshal(entries)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+	a.respondWithSuccess(w, resp)
+}
+
+// FunctionApiUsages lists the resource usage of functions, as executor
+// and router metered it.
+func (a *API) FunctionApiUsages(w http.ResponseWriter, r *http.Request) {
+	ns := a.extractQueryParamFromRequest(r, "namespace")
+	if len(ns) == 0 {
+		ns = metav1.NamespaceAll
+	}
+
+	usages, err := a.fissionClient.CoreV1().FunctionUsages(ns).List(metav1.ListOptions{})
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+
+	resp, err := json.Marshal(usages.Items)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+	a.respondWithSuccess(w, resp)
+}
+
 func (a *API) FunctionApiUpdate(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	name := vars["function"]
@@ -213,6 +458,8 @@ func (a *API) FunctionApiUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	f.Spec.SetDefaults(f.ObjectMeta.Namespace)
+
 	fnew, err := a.fissionClient.CoreV1().Functions(f.ObjectMeta.Namespace).Update(&f)
 	if err != nil {
 		a.respondWithError(w, err)
@@ -286,15 +533,15 @@ func (a *API) FunctionPodLogs(w http.ResponseWriter, r *http.Request) {
 	fnName := vars["function"]
 
 	ns := a.extractQueryParamFromRequest(r, "namespace")
-	podNs := "fission-function"
+	podNs := a.functionNamespace
 
 	if len(ns) == 0 {
 		ns = metav1.NamespaceDefault
 	} else if ns != metav1.NamespaceDefault {
 		// If the function namespace is "default", executor
-		// will create function pods under "fission-function".
-		// Otherwise, the function pod will be created under
-		// the same namespace of function.
+		// will create function pods under the function namespace
+		// fission is installed with. Otherwise, the function pod
+		// will be created under the same namespace of function.
 		podNs = ns
 	}
 
@@ -332,14 +579,44 @@ func (a *API) FunctionPodLogs(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// get the pod with highest resource version
-	err = getContainerLog(a.kubernetesClient, w, f, &pods[0])
+	err = getContainerLog(a.kubernetesClient, w, f, &pods[0], a.functionSourceMap(f))
 	if err != nil {
 		a.respondWithError(w, errors.Wrapf(err, "error getting container logs"))
 		return
 	}
 }
 
-func getContainerLog(kubernetesClient *kubernetes.Clientset, w http.ResponseWriter, fn *fv1.Function, pod *apiv1.Pod) error {
+// functionSourceMap returns the source map manifest of the package of a
+// function, or nil if it has none.
+func (a *API) functionSourceMap(fn *fv1.Function) *sourcemap.Manifest {
+	ref := fn.Spec.Package.PackageRef
+	if len(ref.Name) == 0 {
+		return nil
+	}
+	pkg, err := a.fissionClient.CoreV1().Packages(ref.Namespace).Get(ref.Name, metav1.GetOptions{})
+	if err != nil {
+		a.logger.Info("error getting function package, logs are not enriched", zap.Error(err),
+			zap.String("function", fn.ObjectMeta.Name), zap.String("package", ref.Name))
+		return nil
+	}
+	if len(pkg.Spec.SourceMap.URL) == 0 && len(pkg.Spec.SourceMap.Literal) == 0 {
+		return nil
+	}
+
+	manifest, err := a.sourceMaps.Get(pkg.Spec.SourceMap)
+	if err != nil {
+		a.logger.Info("error getting source map manifest, logs are not enriched", zap.Error(err),
+			zap.String("function", fn.ObjectMeta.Name), zap.String("package", ref.Name))
+		return nil
+	}
+	return manifest
+}
+
+// getContainerLog writes the logs of the containers of a function pod,
+// mapping the stack frames in them to the original sources when the
+// package of the function has source maps.
+func getContainerLog(kubernetesClient *kubernetes.Clientset, w http.ResponseWriter, fn *fv1.Function, pod *apiv1.Pod,
+	manifest *sourcemap.Manifest) error {
 	seq := strings.Repeat("=", 35)
 
 	for _, container := range pod.Spec.Containers {
@@ -358,7 +635,11 @@ func getContainerLog(kubernetesClient *kubernetes.Clientset, w http.ResponseWrit
 			return errors.Wrap(err, "error writing response")
 		}
 
-		_, err = io.Copy(w, podLogs)
+		if manifest != nil {
+			err = manifest.Copy(w, podLogs)
+		} else {
+			_, err = io.Copy(w, podLogs)
+		}
 		if err != nil {
 			return errors.Wrapf(err, "error copying pod log")
 		}
@@ -44,6 +44,7 @@ func RegisterHTTPTriggerRoute(ws *restful.WebService) {
 				resp.ResponseWriter.WriteHeader(http.StatusOK)
 			}).
 			Param(ws.QueryParameter("namespace", "Namespace of httpTrigger").DataType("string").DefaultValue(metav1.NamespaceAll).Required(false)).
+			Do(listQueryParams(ws)).
 			Produces(restful.MIME_JSON).
 			Writes([]fv1.HTTPTrigger{}).
 			Returns(http.StatusOK, "List of httpTriggers", []fv1.HTTPTrigger{}))
@@ -105,19 +106,23 @@ func (a *API) HTTPTriggerApiList(w http.ResponseWriter, r *http.Request) {
 		ns = metav1.NamespaceAll
 	}
 
-	triggers, err := a.fissionClient.CoreV1().HTTPTriggers(ns).List(metav1.ListOptions{})
-	if err != nil {
-		a.respondWithError(w, err)
+	query, ok := a.listQueryOrError(w, r)
+	if !ok {
+		return
+	}
+
+	if query.watch {
+		a.respondWithWatch(w, r, query, a.fissionClient.CoreV1().HTTPTriggers(ns).Watch)
 		return
 	}
 
-	resp, err := json.Marshal(triggers.Items)
+	triggers, err := a.fissionClient.CoreV1().HTTPTriggers(ns).List(query.options)
 	if err != nil {
 		a.respondWithError(w, err)
 		return
 	}
 
-	a.respondWithSuccess(w, resp)
+	a.respondWithList(w, query, triggers.Items, triggers.Continue)
 }
 
 // checkHTTPTriggerDuplicates checks whether the tuple (Method, Host, URL) is duplicate or not.
@@ -0,0 +1,270 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	ferror "github.com/fission/fission/pkg/error"
+)
+
+type (
+	// listQuery holds the query parameters of list APIs:
+	//   limit, continue: return one page of objects and a token for the next one
+	//   labelSelector: only return objects with matching labels
+	//   fieldSelector: only return objects with matching fields, e.g. spec.environment.name=nodejs
+	//   fields: only return the given fields of objects, e.g. metadata.name,spec.environment
+	//   watch, resourceVersion: stream changes of objects after the given version instead
+	listQuery struct {
+		// options are passed to kubernetes, which pages the objects and
+		// selects them by labels and by the fields it supports for CRDs.
+		options metav1.ListOptions
+
+		// fieldSelector selects objects by the fields kubernetes doesn't
+		// support, so a page may hold less objects than the limit.
+		fieldSelector fields.Selector
+
+		fields []string
+
+		// watch streams changes of the selected objects instead of listing them.
+		watch bool
+	}
+)
+
+// fields of CRDs kubernetes can select objects by
+var kubeSelectableFields = map[string]bool{
+	"metadata.name":      true,
+	"metadata.namespace": true,
+}
+
+// listQueryParams documents the query parameters of list APIs.
+func listQueryParams(ws *restful.WebService) func(*restful.RouteBuilder) {
+	return func(b *restful.RouteBuilder) {
+		b.Param(ws.QueryParameter("limit", "Maximum number of objects to return, the token for the next page is in the "+fv1.HeaderContinue+" response header").DataType("integer").Required(false)).
+			Param(ws.QueryParameter("continue", "Token of the page to return, from the "+fv1.HeaderContinue+" header of the previous page").DataType("string").Required(false)).
+			Param(ws.QueryParameter("labelSelector", "Only return objects with matching labels, e.g. app=foo").DataType("string").Required(false)).
+			Param(ws.QueryParameter("fieldSelector", "Only return objects with matching fields, e.g. spec.environment.name=nodejs").DataType("string").Required(false)).
+			Param(ws.QueryParameter("fields", "Comma-separated fields to return of each object, e.g. metadata.name,spec.environment").DataType("string").Required(false))
+	}
+}
+
+// parseListQuery returns the list query in the request.
+func parseListQuery(r *http.Request) (*listQuery, error) {
+	values := r.URL.Query()
+	query := &listQuery{
+		options: metav1.ListOptions{
+			Continue: values.Get("continue"),
+		},
+	}
+
+	if limitStr := values.Get("limit"); len(limitStr) > 0 {
+		limit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || limit < 0 {
+			return nil, errors.Errorf("invalid limit %q", limitStr)
+		}
+		query.options.Limit = limit
+	}
+
+	if labelSelector := values.Get("labelSelector"); len(labelSelector) > 0 {
+		if _, err := labels.Parse(labelSelector); err != nil {
+			return nil, errors.Wrap(err, "invalid label selector")
+		}
+		query.options.LabelSelector = labelSelector
+	}
+
+	if fieldSelector := values.Get("fieldSelector"); len(fieldSelector) > 0 {
+		selector, err := fields.ParseSelector(fieldSelector)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid field selector")
+		}
+
+		var kubeRequirements []string
+		for _, req := range selector.Requirements() {
+			if kubeSelectableFields[req.Field] {
+				kubeRequirements = append(kubeRequirements,
+					fmt.Sprintf("%v%v%v", req.Field, req.Operator, fields.EscapeValue(req.Value)))
+			}
+		}
+		query.options.FieldSelector = strings.Join(kubeRequirements, ",")
+		if len(kubeRequirements) < len(selector.Requirements()) {
+			query.fieldSelector = selector
+		}
+	}
+
+	if watchStr := values.Get("watch"); len(watchStr) > 0 {
+		watch, err := strconv.ParseBool(watchStr)
+		if err != nil {
+			return nil, errors.Errorf("invalid watch %q", watchStr)
+		}
+		if watch && (query.options.Limit > 0 || len(query.options.Continue) > 0) {
+			return nil, errors.New("limit and continue cannot be used with watch")
+		}
+		query.watch = watch
+		query.options.ResourceVersion = values.Get("resourceVersion")
+	}
+
+	if fieldsStr := values.Get("fields"); len(fieldsStr) > 0 {
+		for _, field := range strings.Split(fieldsStr, ",") {
+			field = strings.TrimSpace(field)
+			if len(field) > 0 {
+				query.fields = append(query.fields, field)
+			}
+		}
+	}
+
+	return query, nil
+}
+
+// marshal returns the JSON array of the selected objects, with only the
+// requested fields.
+func (q *listQuery) marshal(items interface{}) ([]byte, error) {
+	if q.fieldSelector == nil && len(q.fields) == 0 {
+		return json.Marshal(items)
+	}
+
+	// work on the JSON form of objects so that any object type works
+	raw, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	var objs []map[string]interface{}
+	err = json.Unmarshal(raw, &objs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0, len(objs))
+	for _, obj := range objs {
+		if obj, ok := q.apply(obj); ok {
+			result = append(result, obj)
+		}
+	}
+	return json.Marshal(result)
+}
+
+// apply returns the requested fields of an object, and false if the object
+// isn't selected.
+func (q *listQuery) apply(obj map[string]interface{}) (map[string]interface{}, bool) {
+	if q.fieldSelector != nil && !q.fieldSelector.Matches(selectorFields(obj, q.fieldSelector)) {
+		return nil, false
+	}
+	if len(q.fields) > 0 {
+		obj = sparseObject(obj, q.fields)
+	}
+	return obj, true
+}
+
+// selectorFields returns the values of fields in the selector, fields an
+// object doesn't have are empty.
+func selectorFields(obj map[string]interface{}, selector fields.Selector) fields.Set {
+	set := fields.Set{}
+	for _, req := range selector.Requirements() {
+		value, ok := fieldValue(obj, req.Field)
+		if !ok {
+			set[req.Field] = ""
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			set[req.Field] = v
+		case nil:
+			set[req.Field] = ""
+		case map[string]interface{}, []interface{}:
+			// only scalar fields can be selected
+			set[req.Field] = ""
+		default:
+			set[req.Field] = fmt.Sprintf("%v", v)
+		}
+	}
+	return set
+}
+
+// fieldValue returns the value of the field with the given dot-separated path.
+func fieldValue(obj map[string]interface{}, path string) (interface{}, bool) {
+	var value interface{} = obj
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok = m[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// sparseObject returns a copy of the object with only the given fields.
+func sparseObject(obj map[string]interface{}, paths []string) map[string]interface{} {
+	result := map[string]interface{}{}
+	for _, path := range paths {
+		value, ok := fieldValue(obj, path)
+		if !ok {
+			continue
+		}
+
+		keys := strings.Split(path, ".")
+		parent := result
+		for _, key := range keys[:len(keys)-1] {
+			child, ok := parent[key].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				parent[key] = child
+			}
+			parent = child
+		}
+		parent[keys[len(keys)-1]] = value
+	}
+	return result
+}
+
+// respondWithList writes the selected objects and sets the token for the
+// next page in the response header, if there are more objects.
+func (a *API) respondWithList(w http.ResponseWriter, query *listQuery, items interface{}, continueToken string) {
+	resp, err := query.marshal(items)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+	if len(continueToken) > 0 {
+		w.Header().Set(fv1.HeaderContinue, continueToken)
+	}
+	a.respondWithSuccess(w, resp)
+}
+
+// listQueryOrError returns the list query in the request, or responds with
+// an error if the query is invalid.
+func (a *API) listQueryOrError(w http.ResponseWriter, r *http.Request) (*listQuery, bool) {
+	query, err := parseListQuery(r)
+	if err != nil {
+		a.respondWithError(w, ferror.MakeError(ferror.ErrorInvalidArgument, err.Error()))
+		return nil, false
+	}
+	return query, true
+}
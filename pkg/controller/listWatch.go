@@ -0,0 +1,164 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// watchHeartbeatInterval is how often an idle event stream gets a comment,
+// so that proxies don't close the connection.
+const watchHeartbeatInterval = 30 * time.Second
+
+type (
+	// watchEvent is a change of an object streamed by list APIs with
+	// watch=true, in the format of kubernetes watch events.
+	watchEvent struct {
+		Type   watch.EventType        `json:"type"`
+		Object map[string]interface{} `json:"object"`
+	}
+
+	// watchFunc starts watching objects with the options.
+	watchFunc func(metav1.ListOptions) (watch.Interface, error)
+)
+
+// respondWithWatch streams the changes of the objects selected by the query,
+// until the client goes away or kubernetes ends the watch. Clients resume
+// watching from the resourceVersion of the last object they got.
+//
+// Events are sent as server-sent events if the request accepts
+// text/event-stream, with the resourceVersion as event ID so that browsers
+// resume on their own, or as newline-delimited JSON otherwise.
+func (a *API) respondWithWatch(w http.ResponseWriter, r *http.Request, query *listQuery, watchFn watchFunc) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		a.respondWithError(w, errors.New("streaming is not supported by the response writer"))
+		return
+	}
+
+	sse := strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+	options := query.options
+	if sse && len(options.ResourceVersion) == 0 {
+		options.ResourceVersion = r.Header.Get("Last-Event-ID")
+	}
+
+	watcher, err := watchFn(options)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+	defer watcher.Stop()
+
+	if sse {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	}
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(watchHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-heartbeat.C:
+			if !sse {
+				continue
+			}
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return
+			}
+			data, selected, err := query.marshalEvent(event)
+			if err != nil {
+				a.logger.Error("error encoding watch event", zap.Error(err))
+				return
+			}
+			if !selected {
+				continue
+			}
+
+			if sse {
+				if accessor, err := meta.Accessor(event.Object); err == nil && event.Type != watch.Error {
+					_, err = fmt.Fprintf(w, "id: %v\n", accessor.GetResourceVersion())
+					if err != nil {
+						return
+					}
+				}
+				_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+			} else {
+				_, err = fmt.Fprintf(w, "%s\n", data)
+			}
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+
+			if event.Type == watch.Error {
+				// e.g. the resourceVersion is too old, the client has to list again
+				return
+			}
+		}
+	}
+}
+
+// marshalEvent returns the JSON form of a watch event with the requested
+// fields of the object, and false if the object isn't selected.
+func (q *listQuery) marshalEvent(event watch.Event) ([]byte, bool, error) {
+	raw, err := json.Marshal(event.Object)
+	if err != nil {
+		return nil, false, err
+	}
+	obj := map[string]interface{}{}
+	err = json.Unmarshal(raw, &obj)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// errors are sent as they are, the object is a metav1.Status
+	if event.Type != watch.Error {
+		var selected bool
+		obj, selected = q.apply(obj)
+		if !selected {
+			return nil, false, nil
+		}
+	}
+
+	data, err := json.Marshal(watchEvent{Type: event.Type, Object: obj})
+	return data, true, err
+}
@@ -43,6 +43,7 @@ func RegisterMessageQueueTriggerRoute(ws *restful.WebService) {
 				resp.ResponseWriter.WriteHeader(http.StatusOK)
 			}).
 			Param(ws.QueryParameter("namespace", "Namespace of messageQueueTrigger").DataType("string").DefaultValue(metav1.NamespaceAll).Required(false)).
+			Do(listQueryParams(ws)).
 			Produces(restful.MIME_JSON).
 			Writes([]fv1.MessageQueueTrigger{}).
 			Returns(http.StatusOK, "List of messageQueueTriggers", []fv1.MessageQueueTrigger{}))
@@ -105,17 +106,23 @@ func (a *API) MessageQueueTriggerApiList(w http.ResponseWriter, r *http.Request)
 		ns = metav1.NamespaceAll
 	}
 
-	triggers, err := a.fissionClient.CoreV1().MessageQueueTriggers(ns).List(metav1.ListOptions{})
-	if err != nil {
-		a.respondWithError(w, err)
+	query, ok := a.listQueryOrError(w, r)
+	if !ok {
+		return
+	}
+
+	if query.watch {
+		a.respondWithWatch(w, r, query, a.fissionClient.CoreV1().MessageQueueTriggers(ns).Watch)
 		return
 	}
-	resp, err := json.Marshal(triggers.Items)
+
+	triggers, err := a.fissionClient.CoreV1().MessageQueueTriggers(ns).List(query.options)
 	if err != nil {
 		a.respondWithError(w, err)
 		return
 	}
-	a.respondWithSuccess(w, resp)
+
+	a.respondWithList(w, query, triggers.Items, triggers.Continue)
 }
 
 func (a *API) MessageQueueTriggerApiCreate(w http.ResponseWriter, r *http.Request) {
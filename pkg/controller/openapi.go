@@ -51,6 +51,11 @@ func openAPIWebService() *restful.WebService {
 	RegisterWatchRoute(ws)
 	RegisterTimeTriggerRoute(ws)
 	RegisterCanaryConfigRoute(ws)
+	RegisterStatusRoute(ws)
+	RegisterDriftRoute(ws)
+	RegisterTombstoneRoute(ws)
+	RegisterAuditRoute(ws)
+	RegisterFissionConfigRoute(ws)
 
 	// proxy
 	RegisterStorageServiceProxyRoute(ws)
@@ -30,7 +30,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/buildlog"
 	ferror "github.com/fission/fission/pkg/error"
+	"github.com/fission/fission/pkg/pkgrevision"
 )
 
 func RegisterPackageRoute(ws *restful.WebService) {
@@ -45,6 +47,7 @@ func RegisterPackageRoute(ws *restful.WebService) {
 				resp.ResponseWriter.WriteHeader(http.StatusOK)
 			}).
 			Param(ws.QueryParameter("namespace", "Namespace of package").DataType("string").DefaultValue(metav1.NamespaceAll).Required(false)).
+			Do(listQueryParams(ws)).
 			Produces(restful.MIME_JSON).
 			Writes([]fv1.Package{}).
 			Returns(http.StatusOK, "List of packages", []fv1.Package{}))
@@ -98,6 +101,32 @@ func RegisterPackageRoute(ws *restful.WebService) {
 			Param(ws.QueryParameter("namespace", "Namespace of package").DataType("string").DefaultValue(metav1.NamespaceAll).Required(false)).
 			Produces(restful.MIME_JSON).
 			Returns(http.StatusOK, "Only HTTP status returned", nil))
+
+	ws.Route(
+		ws.GET("/v2/packages/{package}/buildlogs").
+			Doc("List build logs of package").
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			To(func(req *restful.Request, resp *restful.Response) {
+				resp.ResponseWriter.WriteHeader(http.StatusOK)
+			}).
+			Param(ws.PathParameter("package", "Package name").DataType("string").DefaultValue("").Required(true)).
+			Param(ws.QueryParameter("namespace", "Namespace of package").DataType("string").DefaultValue(metav1.NamespaceDefault).Required(false)).
+			Produces(restful.MIME_JSON).
+			Writes([]buildlog.Record{}).
+			Returns(http.StatusOK, "Build logs of the last builds of package, the most recent first", []buildlog.Record{}))
+
+	ws.Route(
+		ws.GET("/v2/packages/{package}/revisions").
+			Doc("List revisions of package").
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			To(func(req *restful.Request, resp *restful.Response) {
+				resp.ResponseWriter.WriteHeader(http.StatusOK)
+			}).
+			Param(ws.PathParameter("package", "Package name").DataType("string").DefaultValue("").Required(true)).
+			Param(ws.QueryParameter("namespace", "Namespace of package").DataType("string").DefaultValue(metav1.NamespaceDefault).Required(false)).
+			Produces(restful.MIME_JSON).
+			Writes([]pkgrevision.Revision{}).
+			Returns(http.StatusOK, "Last generations of package, the most recent first", []pkgrevision.Revision{}))
 }
 
 func (a *API) PackageApiList(w http.ResponseWriter, r *http.Request) {
@@ -105,19 +134,24 @@ func (a *API) PackageApiList(w http.ResponseWriter, r *http.Request) {
 	if len(ns) == 0 {
 		ns = metav1.NamespaceAll
 	}
-	funcs, err := a.fissionClient.CoreV1().Packages(ns).List(metav1.ListOptions{})
-	if err != nil {
-		a.respondWithError(w, err)
+
+	query, ok := a.listQueryOrError(w, r)
+	if !ok {
 		return
 	}
 
-	resp, err := json.Marshal(funcs.Items)
+	if query.watch {
+		a.respondWithWatch(w, r, query, a.fissionClient.CoreV1().Packages(ns).Watch)
+		return
+	}
+
+	funcs, err := a.fissionClient.CoreV1().Packages(ns).List(query.options)
 	if err != nil {
 		a.respondWithError(w, err)
 		return
 	}
 
-	a.respondWithSuccess(w, resp)
+	a.respondWithList(w, query, funcs.Items, funcs.Continue)
 }
 
 func (a *API) PackageApiCreate(w http.ResponseWriter, r *http.Request) {
@@ -252,3 +286,59 @@ func (a *API) PackageApiDelete(w http.ResponseWriter, r *http.Request) {
 
 	a.respondWithSuccess(w, []byte(""))
 }
+
+func (a *API) PackageApiBuildLogs(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["package"]
+	ns := a.extractQueryParamFromRequest(r, "namespace")
+	if len(ns) == 0 {
+		ns = metav1.NamespaceDefault
+	}
+
+	pkg, err := a.fissionClient.CoreV1().Packages(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+
+	records, err := a.buildLogs.List(pkg)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+
+	resp, err := json.Marshal(records)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+	a.respondWithSuccess(w, resp)
+}
+
+func (a *API) PackageApiRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["package"]
+	ns := a.extractQueryParamFromRequest(r, "namespace")
+	if len(ns) == 0 {
+		ns = metav1.NamespaceDefault
+	}
+
+	pkg, err := a.fissionClient.CoreV1().Packages(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+
+	revisions, err := a.pkgRevisions.List(pkg)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+
+	resp, err := json.Marshal(revisions)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+	a.respondWithSuccess(w, resp)
+}
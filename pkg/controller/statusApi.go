@@ -0,0 +1,313 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"github.com/go-openapi/spec"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/canaryconfigmgr"
+	ferror "github.com/fission/fission/pkg/error"
+	config "github.com/fission/fission/pkg/featureconfig"
+	"github.com/fission/fission/pkg/info"
+	"github.com/fission/fission/pkg/timer/schedule"
+)
+
+const defaultStatusWindow = "5m"
+
+type (
+	// triggerMetrics holds the metrics of triggers, keyed by trigger type and name.
+	triggerMetrics struct {
+		calls       map[string]float64
+		errors      map[string]float64
+		lag         map[string]float64
+		lastSuccess map[string]float64
+	}
+)
+
+func RegisterStatusRoute(ws *restful.WebService) {
+	tags := []string{"Status"}
+	specTag = append(specTag, spec.Tag{TagProps: spec.TagProps{Name: "Status", Description: "Event Source Status Operation"}})
+
+	ws.Route(
+		ws.GET("/v2/status").
+			Doc("Get health of all event sources in a namespace").
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			To(func(req *restful.Request, resp *restful.Response) {
+				resp.ResponseWriter.WriteHeader(http.StatusOK)
+			}).
+			Param(ws.QueryParameter("namespace", "Namespace of event sources").DataType("string").DefaultValue(metav1.NamespaceDefault).Required(false)).
+			Param(ws.QueryParameter("window", "Time window rates are calculated over").DataType("string").DefaultValue(defaultStatusWindow).Required(false)).
+			Produces(restful.MIME_JSON).
+			Writes(info.NamespaceStatus{}).
+			Returns(http.StatusOK, "Health of event sources", info.NamespaceStatus{}))
+}
+
+// makePrometheusClient returns a client of the prometheus server router reports metrics to,
+// or nil if there is no prometheus server.
+func makePrometheusClient(logger *zap.Logger) *canaryconfigmgr.PrometheusApiClient {
+	var prometheusSvc string
+	featureConfig, err := config.GetFeatureConfig()
+	if err == nil {
+		prometheusSvc = featureConfig.CanaryConfig.PrometheusSvc
+	}
+
+	prometheusSvc, err = canaryconfigmgr.GetPrometheusSvcURL(logger, prometheusSvc)
+	if err != nil {
+		logger.Info("prometheus server not found, event source status will be reported without metrics", zap.Error(err))
+		return nil
+	}
+
+	promClient, err := canaryconfigmgr.MakePrometheusClient(logger, prometheusSvc)
+	if err != nil {
+		logger.Error("error creating prometheus client, event source status will be reported without metrics", zap.Error(err))
+		return nil
+	}
+	return promClient
+}
+
+func (a *API) StatusApiGet(w http.ResponseWriter, r *http.Request) {
+	ns := a.extractQueryParamFromRequest(r, "namespace")
+	if len(ns) == 0 {
+		ns = metav1.NamespaceDefault
+	}
+
+	window := a.extractQueryParamFromRequest(r, "window")
+	if len(window) == 0 {
+		window = defaultStatusWindow
+	}
+	if _, err := model.ParseDuration(window); err != nil {
+		a.respondWithError(w, ferror.MakeError(ferror.ErrorInvalidArgument, fmt.Sprintf("invalid window: %v", window)))
+		return
+	}
+
+	sources, err := a.getEventSources(ns)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+
+	status := info.NamespaceStatus{
+		Namespace:    ns,
+		Window:       window,
+		EventSources: sources,
+	}
+
+	metrics, err := a.getTriggerMetrics(ns, window)
+	if err != nil {
+		// event sources are still reported, just without metrics
+		a.logger.Error("error getting trigger metrics", zap.Error(err), zap.String("namespace", ns))
+		status.MetricsError = err.Error()
+	} else {
+		for i := range status.EventSources {
+			metrics.apply(&status.EventSources[i])
+		}
+	}
+
+	resp, err := json.Marshal(status)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+
+	a.respondWithSuccess(w, resp)
+}
+
+// getEventSources lists all triggers in the namespace.
+func (a *API) getEventSources(ns string) ([]info.EventSourceHealth, error) {
+	var sources []info.EventSourceHealth
+
+	httpTriggers, err := a.fissionClient.CoreV1().HTTPTriggers(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range httpTriggers.Items {
+		sources = append(sources, info.EventSourceHealth{
+			Type:     fv1.TriggerTypeHTTP,
+			Name:     t.ObjectMeta.Name,
+			Function: functionReferenceString(&t.Spec.FunctionReference),
+			Source:   fmt.Sprintf("%v %v%v", t.Spec.Method, t.Spec.Host, t.Spec.RelativeURL),
+		})
+	}
+
+	mqTriggers, err := a.fissionClient.CoreV1().MessageQueueTriggers(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range mqTriggers.Items {
+		sources = append(sources, info.EventSourceHealth{
+			Type:     fv1.TriggerTypeMessageQueue,
+			Name:     t.ObjectMeta.Name,
+			Function: functionReferenceString(&t.Spec.FunctionReference),
+			Source:   fmt.Sprintf("%v:%v", t.Spec.MessageQueueType, t.Spec.Topic),
+		})
+	}
+
+	timeTriggers, err := a.fissionClient.CoreV1().TimeTriggers(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range timeTriggers.Items {
+		sources = append(sources, info.EventSourceHealth{
+			Type:     fv1.TriggerTypeTime,
+			Name:     t.ObjectMeta.Name,
+			Function: functionReferenceString(&t.Spec.FunctionReference),
+			Source:   schedule.Describe(&t.Spec),
+		})
+	}
+
+	watches, err := a.fissionClient.CoreV1().KubernetesWatchTriggers(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range watches.Items {
+		sources = append(sources, info.EventSourceHealth{
+			Type:     fv1.TriggerTypeKubeWatch,
+			Name:     t.ObjectMeta.Name,
+			Function: functionReferenceString(&t.Spec.FunctionReference),
+			Source:   fmt.Sprintf("%v/%v", t.Spec.Namespace, t.Spec.Type),
+		})
+	}
+
+	bucketTriggers, err := a.fissionClient.CoreV1().BucketTriggers(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range bucketTriggers.Items {
+		sources = append(sources, info.EventSourceHealth{
+			Type:     fv1.TriggerTypeBucket,
+			Name:     t.ObjectMeta.Name,
+			Function: functionReferenceString(&t.Spec.FunctionReference),
+			Source:   fmt.Sprintf("%v://%v/%v", t.Spec.Provider, t.Spec.Bucket, t.Spec.Prefix),
+		})
+	}
+
+	dbTriggers, err := a.fissionClient.CoreV1().DatabaseTriggers(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range dbTriggers.Items {
+		sources = append(sources, info.EventSourceHealth{
+			Type:     fv1.TriggerTypeDatabase,
+			Name:     t.ObjectMeta.Name,
+			Function: functionReferenceString(&t.Spec.FunctionReference),
+			Source:   fmt.Sprintf("%v:%v.%v.{%v}", t.Spec.Database, t.Spec.TopicPrefix, t.Spec.Schema, strings.Join(t.Spec.Tables, ",")),
+		})
+	}
+
+	return sources, nil
+}
+
+// getTriggerMetrics queries metrics of all triggers in the namespace.
+func (a *API) getTriggerMetrics(ns string, window string) (*triggerMetrics, error) {
+	if a.promClient == nil {
+		return nil, errors.New("prometheus server not configured")
+	}
+
+	var err error
+	metrics := &triggerMetrics{}
+
+	selector := fmt.Sprintf("{namespace=%q}", ns)
+
+	metrics.calls, err = a.queryTriggerMetric(fmt.Sprintf("sum(rate(%v%v[%v])) by (type, name)", fv1.TriggerCallsMetric, selector, window))
+	if err != nil {
+		return nil, err
+	}
+	metrics.errors, err = a.queryTriggerMetric(fmt.Sprintf("sum(rate(%v%v[%v])) by (type, name)", fv1.TriggerErrorsMetric, selector, window))
+	if err != nil {
+		return nil, err
+	}
+	metrics.lag, err = a.queryTriggerMetric(fmt.Sprintf("max(%v%v) by (type, name)", fv1.TriggerLagMetric, selector))
+	if err != nil {
+		return nil, err
+	}
+	metrics.lastSuccess, err = a.queryTriggerMetric(fmt.Sprintf("max(%v%v) by (type, name)", fv1.TriggerLastSuccessMetric, selector))
+	if err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+func (a *API) queryTriggerMetric(query string) (map[string]float64, error) {
+	vector, err := a.promClient.QueryVector(query)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]float64)
+	for _, sample := range vector {
+		if math.IsNaN(float64(sample.Value)) {
+			continue
+		}
+		key := triggerMetricKey(string(sample.Metric["type"]), string(sample.Metric["name"]))
+		values[key] = float64(sample.Value)
+	}
+	return values, nil
+}
+
+// apply sets the metrics of the trigger to its health.
+func (m *triggerMetrics) apply(source *info.EventSourceHealth) {
+	key := triggerMetricKey(source.Type, source.Name)
+
+	if calls, ok := m.calls[key]; ok {
+		source.FiringRate = &calls
+		if calls > 0 {
+			errorRate := m.errors[key] / calls * 100
+			source.ErrorRate = &errorRate
+		}
+	}
+	if lag, ok := m.lag[key]; ok {
+		source.Lag = &lag
+	}
+	if lastSuccess, ok := m.lastSuccess[key]; ok && lastSuccess > 0 {
+		t := time.Unix(int64(lastSuccess), 0).UTC()
+		source.LastSuccess = &t
+	}
+}
+
+func triggerMetricKey(triggerType string, name string) string {
+	return triggerType + "/" + name
+}
+
+// functionReferenceString returns the function(s) a function reference points to.
+func functionReferenceString(ref *fv1.FunctionReference) string {
+	if ref.Type != fv1.FunctionReferenceTypeFunctionWeights {
+		return ref.Name
+	}
+
+	var fns []string
+	for fn, weight := range ref.FunctionWeights {
+		fns = append(fns, fmt.Sprintf("%v:%v", fn, weight))
+	}
+	sort.Strings(fns)
+	return strings.Join(fns, ",")
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/canaryconfigmgr"
+	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/generated/clientset/versioned/fake"
+	"github.com/fission/fission/pkg/info"
+)
+
+func makeStatusTestAPI(promClient *canaryconfigmgr.PrometheusApiClient) *API {
+	cs := fake.NewSimpleClientset(
+		&fv1.HTTPTrigger{
+			ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "a"},
+			Spec: fv1.HTTPTriggerSpec{
+				Method:            http.MethodGet,
+				RelativeURL:       "/hello",
+				FunctionReference: fv1.FunctionReference{Type: fv1.FunctionReferenceTypeFunctionName, Name: "hello"},
+			},
+		},
+		&fv1.MessageQueueTrigger{
+			ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "a"},
+			Spec: fv1.MessageQueueTriggerSpec{
+				MessageQueueType: fv1.MessageQueueTypeKafka,
+				Topic:            "orders",
+				FunctionReference: fv1.FunctionReference{
+					Type:            fv1.FunctionReferenceTypeFunctionWeights,
+					FunctionWeights: map[string]int{"b": 20, "a": 80},
+				},
+			},
+		},
+		&fv1.TimeTrigger{
+			ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "a"},
+			Spec: fv1.TimeTriggerSpec{
+				Cron:              "@daily",
+				FunctionReference: fv1.FunctionReference{Type: fv1.FunctionReferenceTypeFunctionName, Name: "report"},
+			},
+		},
+		// triggers of other namespaces are not reported
+		&fv1.HTTPTrigger{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "b"},
+			Spec: fv1.HTTPTriggerSpec{
+				Method:            http.MethodGet,
+				RelativeURL:       "/other",
+				FunctionReference: fv1.FunctionReference{Type: fv1.FunctionReferenceTypeFunctionName, Name: "other"},
+			},
+		},
+	)
+	return &API{
+		logger:        zap.NewNop(),
+		fissionClient: &crd.FissionClient{Interface: cs},
+		promClient:    promClient,
+	}
+}
+
+func getStatus(t *testing.T, api *API, url string) (int, *info.NamespaceStatus) {
+	w := httptest.NewRecorder()
+	api.StatusApiGet(w, httptest.NewRequest(http.MethodGet, url, nil))
+	if w.Code != http.StatusOK {
+		return w.Code, nil
+	}
+	status := &info.NamespaceStatus{}
+	err := json.Unmarshal(w.Body.Bytes(), status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w.Code, status
+}
+
+func TestStatusApiGet(t *testing.T) {
+	var queries []string
+	results := map[string]string{
+		fv1.TriggerCallsMetric:       `{"metric": {"type": "http", "name": "hello"}, "value": [1610000000, "2"]}, {"metric": {"type": "messagequeue", "name": "orders"}, "value": [1610000000, "NaN"]}`,
+		fv1.TriggerErrorsMetric:      `{"metric": {"type": "http", "name": "hello"}, "value": [1610000000, "0.5"]}`,
+		fv1.TriggerLagMetric:         `{"metric": {"type": "messagequeue", "name": "orders"}, "value": [1610000000, "3"]}`,
+		fv1.TriggerLastSuccessMetric: `{"metric": {"type": "http", "name": "hello"}, "value": [1610000000, "1600000000"]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.FormValue("query")
+		queries = append(queries, query)
+
+		var result string
+		for metric, res := range results {
+			if strings.Contains(query, metric+"{") {
+				result = res
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"status": "success", "data": {"resultType": "vector", "result": [%v]}}`, result)
+	}))
+	defer server.Close()
+
+	promClient, err := canaryconfigmgr.MakePrometheusClient(zap.NewNop(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := makeStatusTestAPI(promClient)
+
+	code, status := getStatus(t, api, "/v2/status?namespace=a&window=10m")
+	if code != http.StatusOK {
+		t.Fatalf("expected status OK, got %v", code)
+	}
+	if status.Namespace != "a" || status.Window != "10m" || len(status.MetricsError) > 0 {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	if len(queries) != 4 {
+		t.Fatalf("expected a query per metric, got %q", queries)
+	}
+	for _, query := range queries {
+		if !strings.Contains(query, `{namespace="a"}`) {
+			t.Errorf("expected query of namespace a, got %q", query)
+		}
+	}
+	if want := `sum(rate(fission_trigger_calls_total{namespace="a"}[10m])) by (type, name)`; queries[0] != want {
+		t.Errorf("expected query %q, got %q", want, queries[0])
+	}
+
+	if len(status.EventSources) != 3 {
+		t.Fatalf("expected event sources of namespace a, got %+v", status.EventSources)
+	}
+	hello, orders, nightly := status.EventSources[0], status.EventSources[1], status.EventSources[2]
+
+	if hello.Type != fv1.TriggerTypeHTTP || hello.Name != "hello" || hello.Function != "hello" || hello.Source != "GET /hello" {
+		t.Errorf("unexpected http trigger %+v", hello)
+	}
+	if hello.FiringRate == nil || *hello.FiringRate != 2 || hello.ErrorRate == nil || *hello.ErrorRate != 25 {
+		t.Errorf("expected rates of http trigger, got %+v", hello)
+	}
+	if hello.LastSuccess == nil || !hello.LastSuccess.Equal(time.Unix(1600000000, 0)) {
+		t.Errorf("expected last success of http trigger, got %v", hello.LastSuccess)
+	}
+
+	if orders.Type != fv1.TriggerTypeMessageQueue || orders.Function != "a:80,b:20" || orders.Source != "kafka:orders" {
+		t.Errorf("unexpected message queue trigger %+v", orders)
+	}
+	// NaN rates are no data
+	if orders.FiringRate != nil || orders.ErrorRate != nil || orders.Lag == nil || *orders.Lag != 3 {
+		t.Errorf("expected only lag of message queue trigger, got %+v", orders)
+	}
+
+	if nightly.Type != fv1.TriggerTypeTime || nightly.Function != "report" || nightly.Source != "@daily" {
+		t.Errorf("unexpected time trigger %+v", nightly)
+	}
+	if nightly.FiringRate != nil || nightly.Lag != nil || nightly.LastSuccess != nil {
+		t.Errorf("expected no metrics of time trigger, got %+v", nightly)
+	}
+}
+
+func TestStatusApiGetWithoutMetrics(t *testing.T) {
+	api := makeStatusTestAPI(nil)
+
+	// event sources are reported without prometheus
+	code, status := getStatus(t, api, "/v2/status?namespace=a")
+	if code != http.StatusOK {
+		t.Fatalf("expected status OK, got %v", code)
+	}
+	if status.Window != defaultStatusWindow || len(status.MetricsError) == 0 || len(status.EventSources) != 3 {
+		t.Errorf("expected event sources with metrics error, got %+v", status)
+	}
+
+	code, status = getStatus(t, api, "/v2/status")
+	if code != http.StatusOK || status.Namespace != metav1.NamespaceDefault || len(status.EventSources) != 0 {
+		t.Errorf("expected empty default namespace, got %v %+v", code, status)
+	}
+
+	code, _ = getStatus(t, api, "/v2/status?namespace=a&window=soon")
+	if code != http.StatusBadRequest {
+		t.Errorf("expected bad request for invalid window, got %v", code)
+	}
+}
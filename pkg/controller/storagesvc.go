@@ -21,6 +21,7 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strings"
 
 	"github.com/emicklei/go-restful"
 	restfulspec "github.com/emicklei/go-restful-openapi"
@@ -54,6 +55,55 @@ func RegisterStorageServiceProxyRoute(ws *restful.WebService) {
 			To(func(req *restful.Request, resp *restful.Response) {
 				resp.ResponseWriter.WriteHeader(http.StatusOK)
 			}))
+	ws.Route(
+		ws.POST("/proxy/storage/v1/archive/delta").
+			Doc("Create archive from a delta against an existing archive").
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			To(func(req *restful.Request, resp *restful.Response) {
+				resp.ResponseWriter.WriteHeader(http.StatusOK)
+			}))
+	ws.Route(
+		ws.GET("/proxy/storage/v1/archive/signature").
+			Doc("Get delta signature of archive").
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			To(func(req *restful.Request, resp *restful.Response) {
+				resp.ResponseWriter.WriteHeader(http.StatusOK)
+			}))
+	ws.Route(
+		ws.POST("/proxy/storage/v1/archive/uploads").
+			Doc("Start multipart upload of archive").
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			To(func(req *restful.Request, resp *restful.Response) {
+				resp.ResponseWriter.WriteHeader(http.StatusOK)
+			}))
+	ws.Route(
+		ws.GET("/proxy/storage/v1/archive/uploads/{id}").
+			Doc("Get progress of multipart upload").
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			To(func(req *restful.Request, resp *restful.Response) {
+				resp.ResponseWriter.WriteHeader(http.StatusOK)
+			}))
+	ws.Route(
+		ws.DELETE("/proxy/storage/v1/archive/uploads/{id}").
+			Doc("Abort multipart upload").
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			To(func(req *restful.Request, resp *restful.Response) {
+				resp.ResponseWriter.WriteHeader(http.StatusOK)
+			}))
+	ws.Route(
+		ws.PUT("/proxy/storage/v1/archive/uploads/{id}/parts/{number}").
+			Doc("Upload part of multipart upload").
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			To(func(req *restful.Request, resp *restful.Response) {
+				resp.ResponseWriter.WriteHeader(http.StatusOK)
+			}))
+	ws.Route(
+		ws.POST("/proxy/storage/v1/archive/uploads/{id}/complete").
+			Doc("Create archive from the parts of multipart upload").
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			To(func(req *restful.Request, resp *restful.Response) {
+				resp.ResponseWriter.WriteHeader(http.StatusOK)
+			}))
 }
 
 func (api *API) StorageServiceProxy(w http.ResponseWriter, r *http.Request) {
@@ -68,7 +118,7 @@ func (api *API) StorageServiceProxy(w http.ResponseWriter, r *http.Request) {
 	director := func(req *http.Request) {
 		req.URL.Scheme = ssUrl.Scheme
 		req.URL.Host = ssUrl.Host
-		req.URL.Path = "/v1/archive"
+		req.URL.Path = strings.TrimPrefix(req.URL.Path, "/proxy/storage")
 		req.Host = ssUrl.Host
 	}
 	proxy := &httputil.ReverseProxy{
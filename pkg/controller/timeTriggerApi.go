@@ -18,6 +18,7 @@ package controller
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 
@@ -25,11 +26,11 @@ import (
 	restfulspec "github.com/emicklei/go-restful-openapi"
 	"github.com/go-openapi/spec"
 	"github.com/gorilla/mux"
-	"github.com/robfig/cron"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	ferror "github.com/fission/fission/pkg/error"
+	"github.com/fission/fission/pkg/timer/schedule"
 )
 
 func RegisterTimeTriggerRoute(ws *restful.WebService) {
@@ -44,6 +45,7 @@ func RegisterTimeTriggerRoute(ws *restful.WebService) {
 				resp.ResponseWriter.WriteHeader(http.StatusOK)
 			}).
 			Param(ws.QueryParameter("namespace", "Namespace of timeTrigger").DataType("string").DefaultValue(metav1.NamespaceAll).Required(false)).
+			Do(listQueryParams(ws)).
 			Produces(restful.MIME_JSON).
 			Writes([]fv1.TimeTrigger{}).
 			Returns(http.StatusOK, "List of timeTriggers", []fv1.TimeTrigger{}))
@@ -105,19 +107,23 @@ func (a *API) TimeTriggerApiList(w http.ResponseWriter, r *http.Request) {
 		ns = metav1.NamespaceAll
 	}
 
-	triggers, err := a.fissionClient.CoreV1().TimeTriggers(ns).List(metav1.ListOptions{})
-	if err != nil {
-		a.respondWithError(w, err)
+	query, ok := a.listQueryOrError(w, r)
+	if !ok {
+		return
+	}
+
+	if query.watch {
+		a.respondWithWatch(w, r, query, a.fissionClient.CoreV1().TimeTriggers(ns).Watch)
 		return
 	}
 
-	resp, err := json.Marshal(triggers.Items)
+	triggers, err := a.fissionClient.CoreV1().TimeTriggers(ns).List(query.options)
 	if err != nil {
 		a.respondWithError(w, err)
 		return
 	}
 
-	a.respondWithSuccess(w, resp)
+	a.respondWithList(w, query, triggers.Items, triggers.Continue)
 }
 
 func (a *API) TimeTriggerApiCreate(w http.ResponseWriter, r *http.Request) {
@@ -135,9 +141,9 @@ func (a *API) TimeTriggerApiCreate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// validate
-	_, err = cron.Parse(t.Spec.Cron)
+	_, err = schedule.Parse(&t.Spec)
 	if err != nil {
-		err = ferror.MakeError(ferror.ErrorInvalidArgument, "TimeTrigger cron spec is not valid")
+		err = ferror.MakeError(ferror.ErrorInvalidArgument, fmt.Sprintf("TimeTrigger schedule is not valid: %v", err))
 		a.respondWithError(w, err)
 		return
 	}
@@ -211,9 +217,9 @@ func (a *API) TimeTriggerApiUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = cron.Parse(t.Spec.Cron)
+	_, err = schedule.Parse(&t.Spec)
 	if err != nil {
-		err = ferror.MakeError(ferror.ErrorInvalidArgument, "TimeTrigger cron spec is not valid")
+		err = ferror.MakeError(ferror.ErrorInvalidArgument, fmt.Sprintf("TimeTrigger schedule is not valid: %v", err))
 		a.respondWithError(w, err)
 		return
 	}
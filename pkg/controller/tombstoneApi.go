@@ -0,0 +1,121 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"github.com/go-openapi/spec"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ferror "github.com/fission/fission/pkg/error"
+	"github.com/fission/fission/pkg/tombstone"
+)
+
+func RegisterTombstoneRoute(ws *restful.WebService) {
+	tags := []string{"Tombstone"}
+	specTag = append(specTag, spec.Tag{TagProps: spec.TagProps{Name: "Tombstone", Description: "Tombstone Operation"}})
+
+	ws.Route(
+		ws.GET("/v2/tombstones").
+			Doc("List tombstones of deleted objects").
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			To(func(req *restful.Request, resp *restful.Response) {
+				resp.ResponseWriter.WriteHeader(http.StatusOK)
+			}).
+			Param(ws.QueryParameter("kind", "Kind of deleted objects, all kinds if not given").DataType("string").Required(false)).
+			Param(ws.QueryParameter("namespace", "Namespace of deleted objects, all namespaces if not given").DataType("string").Required(false)).
+			Param(ws.QueryParameter("name", "Name of deleted objects").DataType("string").Required(false)).
+			Produces(restful.MIME_JSON).
+			Writes([]tombstone.Tombstone{}).
+			Returns(http.StatusOK, "List of tombstones, most recently deleted first", []tombstone.Tombstone{}))
+
+	ws.Route(
+		ws.POST("/v2/tombstones/restore").
+			Doc("Restore a deleted object from its tombstone").
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			To(func(req *restful.Request, resp *restful.Response) {
+				resp.ResponseWriter.WriteHeader(http.StatusCreated)
+			}).
+			Param(ws.QueryParameter("kind", "Kind of the deleted object").DataType("string").Required(true)).
+			Param(ws.QueryParameter("namespace", "Namespace of the deleted object").DataType("string").DefaultValue(metav1.NamespaceDefault).Required(false)).
+			Param(ws.QueryParameter("name", "Name of the deleted object").DataType("string").Required(true)).
+			Param(ws.QueryParameter("uid", "UID of the deleted object, the most recently deleted one if not given").DataType("string").Required(false)).
+			Produces(restful.MIME_JSON).
+			Writes(metav1.ObjectMeta{}).
+			Returns(http.StatusCreated, "ObjectMeta of restored object", metav1.ObjectMeta{}))
+}
+
+func (a *API) TombstoneApiList(w http.ResponseWriter, r *http.Request) {
+	kind := tombstone.Kind(a.extractQueryParamFromRequest(r, "kind"))
+	if len(kind) > 0 && !kind.IsValid() {
+		a.respondWithError(w, ferror.MakeError(ferror.ErrorInvalidArgument, fmt.Sprintf("tombstones are not kept for kind %q", kind)))
+		return
+	}
+	ns := a.extractQueryParamFromRequest(r, "namespace")
+	name := a.extractQueryParamFromRequest(r, "name")
+
+	tombstones, err := a.tombstones.List(kind, ns, name)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+
+	resp, err := json.Marshal(tombstones)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+	a.respondWithSuccess(w, resp)
+}
+
+func (a *API) TombstoneApiRestore(w http.ResponseWriter, r *http.Request) {
+	kind := tombstone.Kind(a.extractQueryParamFromRequest(r, "kind"))
+	if !kind.IsValid() {
+		a.respondWithError(w, ferror.MakeError(ferror.ErrorInvalidArgument, fmt.Sprintf("tombstones are not kept for kind %q", kind)))
+		return
+	}
+	name := a.extractQueryParamFromRequest(r, "name")
+	if len(name) == 0 {
+		a.respondWithError(w, ferror.MakeError(ferror.ErrorInvalidArgument, "name of the deleted object is required"))
+		return
+	}
+	ns := a.extractQueryParamFromRequest(r, "namespace")
+	if len(ns) == 0 {
+		ns = metav1.NamespaceDefault
+	}
+	uid := a.extractQueryParamFromRequest(r, "uid")
+
+	m, err := a.tombstones.Restore(kind, ns, name, uid)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+
+	resp, err := json.Marshal(m)
+	if err != nil {
+		a.respondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	a.respondWithSuccess(w, resp)
+}
@@ -30,7 +30,7 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
 
-	genClientset "github.com/fission/fission/pkg/apis/genclient/clientset/versioned"
+	genClientset "github.com/fission/fission/pkg/generated/clientset/versioned"
 )
 
 type (
@@ -22,6 +22,7 @@ import (
 	"go.uber.org/zap"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -31,6 +32,31 @@ const (
 	crdVersion   = "v1"
 )
 
+// functionPrinterColumns tell whether functions are servable in
+// "kubectl get functions".
+var functionPrinterColumns = []apiextensionsv1beta1.CustomResourceColumnDefinition{
+	{
+		Name:     "Executor",
+		Type:     "string",
+		JSONPath: ".spec.InvokeStrategy.ExecutionStrategy.ExecutorType",
+	},
+	{
+		Name:     "PackageBuilt",
+		Type:     "string",
+		JSONPath: `.status.conditions[?(@.type=="PackageBuilt")].status`,
+	},
+	{
+		Name:     "PodsReady",
+		Type:     "string",
+		JSONPath: `.status.conditions[?(@.type=="PodsReady")].status`,
+	},
+	{
+		Name:     "Age",
+		Type:     "date",
+		JSONPath: ".metadata.creationTimestamp",
+	},
+}
+
 // ensureCRD checks if the given CRD type exists, and creates it if
 // needed. (Note that this creates the CRD type; it doesn't create any
 // _instances_ of that type.)
@@ -45,7 +71,7 @@ func ensureCRD(logger *zap.Logger, clientset *apiextensionsclient.Clientset, crd
 
 		// return if the resource already exists
 		if k8serrors.IsAlreadyExists(err) {
-			return nil
+			return upgradeCRD(clientset, crd)
 		} else {
 			// The requests fail to connect to k8s api server before
 			// istio-prxoy is ready to serve traffic. Retry again.
@@ -58,6 +84,35 @@ func ensureCRD(logger *zap.Logger, clientset *apiextensionsclient.Clientset, crd
 	return err
 }
 
+// upgradeCRD brings a CRD created by another release up to date with the
+// subresources, printer columns and schema of this one.
+func upgradeCRD(clientset *apiextensionsclient.Clientset, crd *apiextensionsv1beta1.CustomResourceDefinition) error {
+	existing, err := clientset.ApiextensionsV1beta1().CustomResourceDefinitions().Get(crd.ObjectMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if !upgradeCRDSpec(&existing.Spec, &crd.Spec) {
+		return nil
+	}
+	_, err = clientset.ApiextensionsV1beta1().CustomResourceDefinitions().Update(existing)
+	return err
+}
+
+// upgradeCRDSpec sets the subresources, printer columns and schema of an
+// existing CRD to the ones of this release, and returns whether any of
+// them changed.
+func upgradeCRDSpec(existing *apiextensionsv1beta1.CustomResourceDefinitionSpec, spec *apiextensionsv1beta1.CustomResourceDefinitionSpec) bool {
+	if apiequality.Semantic.DeepEqual(existing.Subresources, spec.Subresources) &&
+		apiequality.Semantic.DeepEqual(existing.AdditionalPrinterColumns, spec.AdditionalPrinterColumns) &&
+		apiequality.Semantic.DeepEqual(existing.Validation, spec.Validation) {
+		return false
+	}
+	existing.Subresources = spec.Subresources
+	existing.AdditionalPrinterColumns = spec.AdditionalPrinterColumns
+	existing.Validation = spec.Validation
+	return true
+}
+
 // Ensure CRDs
 func EnsureFissionCRDs(logger *zap.Logger, clientset *apiextensionsclient.Clientset) error {
 	crds := []apiextensionsv1beta1.CustomResourceDefinition{
@@ -77,6 +132,12 @@ func EnsureFissionCRDs(logger *zap.Logger, clientset *apiextensionsclient.Client
 				},
 				PreserveUnknownFields: boolPtr(false),
 				Validation:            functionValidation,
+				// executor and buildermgr update the status without
+				// racing with users updating the spec
+				Subresources: &apiextensionsv1beta1.CustomResourceSubresources{
+					Status: &apiextensionsv1beta1.CustomResourceSubresourceStatus{},
+				},
+				AdditionalPrinterColumns: functionPrinterColumns,
 			},
 		},
 		// Environments (function containers)
@@ -195,6 +256,130 @@ func EnsureFissionCRDs(logger *zap.Logger, clientset *apiextensionsclient.Client
 				},
 			},
 		},
+		// FissionConfig: tunables of the Fission components
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "fissionconfigs.fission.io",
+			},
+			Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+				Group:   crdGroupName,
+				Version: crdVersion,
+				Scope:   apiextensionsv1beta1.NamespaceScoped,
+				Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
+					Kind:     "FissionConfig",
+					Plural:   "fissionconfigs",
+					Singular: "fissionconfig",
+				},
+				// components report their effective config in the status
+				Subresources: &apiextensionsv1beta1.CustomResourceSubresources{
+					Status: &apiextensionsv1beta1.CustomResourceSubresourceStatus{},
+				},
+			},
+		},
+		// Bucket triggers for functions
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "buckettriggers.fission.io",
+			},
+			Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+				Group:   crdGroupName,
+				Version: crdVersion,
+				Scope:   apiextensionsv1beta1.NamespaceScoped,
+				Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
+					Kind:     "BucketTrigger",
+					Plural:   "buckettriggers",
+					Singular: "buckettrigger",
+				},
+			},
+		},
+		// Database triggers for functions
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "databasetriggers.fission.io",
+			},
+			Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+				Group:   crdGroupName,
+				Version: crdVersion,
+				Scope:   apiextensionsv1beta1.NamespaceScoped,
+				Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
+					Kind:     "DatabaseTrigger",
+					Plural:   "databasetriggers",
+					Singular: "databasetrigger",
+				},
+			},
+		},
+		// Workflows composing functions
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "workflows.fission.io",
+			},
+			Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+				Group:   crdGroupName,
+				Version: crdVersion,
+				Scope:   apiextensionsv1beta1.NamespaceScoped,
+				Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
+					Kind:     "Workflow",
+					Plural:   "workflows",
+					Singular: "workflow",
+				},
+				// the workflow engine records executions in the status
+				Subresources: &apiextensionsv1beta1.CustomResourceSubresources{
+					Status: &apiextensionsv1beta1.CustomResourceSubresourceStatus{},
+				},
+			},
+		},
+		// Invocation histories of functions, recorded by router
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "invocationhistories.fission.io",
+			},
+			Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+				Group:   crdGroupName,
+				Version: crdVersion,
+				Scope:   apiextensionsv1beta1.NamespaceScoped,
+				Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
+					Kind:     "InvocationHistory",
+					Plural:   "invocationhistories",
+					Singular: "invocationhistory",
+				},
+			},
+		},
+		// Resource usage of functions, metered by executor and router
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "functionusages.fission.io",
+			},
+			Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+				Group:   crdGroupName,
+				Version: crdVersion,
+				Scope:   apiextensionsv1beta1.NamespaceScoped,
+				Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
+					Kind:     "FunctionUsage",
+					Plural:   "functionusages",
+					Singular: "functionusage",
+				},
+			},
+		},
+		// FissionSource: Git repositories of specs the gitops controller applies
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "fissionsources.fission.io",
+			},
+			Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+				Group:   crdGroupName,
+				Version: crdVersion,
+				Scope:   apiextensionsv1beta1.NamespaceScoped,
+				Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
+					Kind:     "FissionSource",
+					Plural:   "fissionsources",
+					Singular: "fissionsource",
+				},
+				// the gitops controller reports the sync status in the status
+				Subresources: &apiextensionsv1beta1.CustomResourceSubresources{
+					Status: &apiextensionsv1beta1.CustomResourceSubresourceStatus{},
+				},
+			},
+		},
 	}
 	for _, crd := range crds {
 		err := ensureCRD(logger, clientset, &crd)
@@ -29,7 +29,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
-	genInformerCoreV1 "github.com/fission/fission/pkg/apis/genclient/clientset/versioned/typed/core/v1"
+	genInformerCoreV1 "github.com/fission/fission/pkg/generated/clientset/versioned/typed/core/v1"
 )
 
 var testNS = metav1.NamespaceDefault
@@ -95,6 +95,25 @@ func functionTests(crdClient genInformerCoreV1.CoreV1Interface) {
 
 	log.Printf("f.ObjectMeta = %#v", f.ObjectMeta)
 
+	// update status, which updates don't change
+	f.Status.SetCondition(fv1.FunctionCondition{
+		Type:           fv1.FunctionPodsReady,
+		Status:         v1.ConditionTrue,
+		LastUpdateTime: metav1.Now().Rfc3339Copy(),
+	})
+	f, err = fi.UpdateStatus(f)
+	panicIf(err)
+	if cond := f.Status.GetCondition(fv1.FunctionPodsReady); cond == nil || cond.Status != v1.ConditionTrue {
+		log.Panicf("Bad result from UpdateStatus: %v", f.Status)
+	}
+	f.Status = fv1.FunctionStatus{}
+	f, err = fi.Update(f)
+	panicIf(err)
+	if len(f.Status.Conditions) != 1 {
+		log.Panicf("Update changed status: %v", f.Status)
+	}
+	function.ObjectMeta.ResourceVersion = f.ObjectMeta.ResourceVersion
+
 	// list
 	fl, err := fi.List(metav1.ListOptions{})
 	panicIf(err)
@@ -53,6 +53,102 @@ var (
 					Type:        "integer",
 					Description: "RequestsPerPod indicates the maximum number of concurrent requests that can be served by a specialized pod.\n This is optional. If not specified default value will be taken as 1",
 				},
+				"priority": {
+					Type:        "string",
+					Description: "Priority of the function when resources run short: interactive, standard or batch. Under node pressure, executor evicts the idle pods of low priority functions with little recent traffic first.\n This is optional. If not specified default value will be taken as standard",
+				},
+				"invocationQuotas": {
+					Type:        "array",
+					Description: "InvocationQuotas limit the invocations of the function per day or month, at most one per window. Router rejects invocations beyond them with 429 until the window ends.",
+					Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+						Schema: &apiextensionsv1beta1.JSONSchemaProps{
+							Type:     "object",
+							Required: []string{"limit", "window"},
+							Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+								"limit": {
+									Type:        "integer",
+									Description: "Limit is how many invocations are allowed per window.",
+								},
+								"window": {
+									Type:        "string",
+									Description: "Window is the calendar period of the quota: day or month. Windows start at midnight UTC.",
+								},
+							},
+						},
+					},
+				},
+				"container": {
+					Type:        "object",
+					Description: "Container is the image the function runs, for functions without environment and package. These functions are run by newdeploy only.",
+					Required:    []string{"image"},
+					Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+						"image": {
+							Type:        "string",
+							Description: "Image of the container.",
+						},
+						"command": {
+							Type:        "array",
+							Description: "Command of the container, the entrypoint of the image if empty.",
+							Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+								Schema: &apiextensionsv1beta1.JSONSchemaProps{
+									Type: "string",
+								},
+							},
+						},
+						"port": {
+							Type:        "integer",
+							Description: "Port the container serves function requests on, 8888 if unset.",
+						},
+						"imagepullsecret": {
+							Type:        "string",
+							Description: "ImagePullSecret is the secret to pull the image with from a private registry.",
+						},
+					},
+				},
+			},
+		},
+		"status": {
+			Type:        "object",
+			Description: "FunctionStatus tells whether a function is actually servable.",
+			Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+				"conditions": {
+					Type:        "array",
+					Description: "Conditions are the latest observations of the state of the function.",
+					Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+						Schema: &apiextensionsv1beta1.JSONSchemaProps{
+							Type:     "object",
+							Required: []string{"type", "status"},
+							Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+								"type": {
+									Type:        "string",
+									Description: "Type of the condition: PackageBuilt, PodsReady or LastError.",
+								},
+								"status": {
+									Type:        "string",
+									Description: "Status of the condition: True, False or Unknown.",
+								},
+								"lastUpdateTime": {
+									Type:        "string",
+									Format:      "date-time",
+									Description: "LastUpdateTime is when the condition was last observed to change.",
+								},
+								"lastTransitionTime": {
+									Type:        "string",
+									Format:      "date-time",
+									Description: "LastTransitionTime is when the condition last changed from one status to another.",
+								},
+								"reason": {
+									Type:        "string",
+									Description: "Reason is a CamelCase reason of the last transition.",
+								},
+								"message": {
+									Type:        "string",
+									Description: "Message is a human readable detail of the last transition.",
+								},
+							},
+						},
+					},
+				},
 			},
 		},
 	}
@@ -113,6 +209,58 @@ var (
 					Type:        "string",
 					Description: "ImagePullSecret is the secret for Kubernetes to pull an image from a private registry.",
 				},
+				"rolloutStrategy": {
+					Type:        "object",
+					Description: "RolloutStrategy controls how poolmgr replaces the pre-warm pool when the environment changes",
+					Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+						"type": {
+							Type:        "string",
+							Description: "Type of the rollout: recreate (default) tears the old pool down at once, gradual moves pods to the new pool in steps",
+						},
+						"maxSurge": {
+							Type:        "integer",
+							Description: "Number of pods of the new pool started in each step of a gradual rollout. Defaults to 1",
+						},
+						"maxUnavailable": {
+							Type:        "integer",
+							Description: "Number of pods the pool may be short of its size during a gradual rollout. Defaults to 0",
+						},
+						"canary": {
+							Type:        "boolean",
+							Description: "Start a single pod of the new pool first and go on only once it's ready",
+						},
+						"stepInterval": {
+							Type:        "integer",
+							Description: "Time in seconds between the steps of a gradual rollout. Defaults to 10",
+						},
+					},
+				},
+				"httpTriggerDefaults": {
+					Type:        "object",
+					Description: "HTTPTriggerDefaults are the router middleware settings inherited by the HTTP triggers of functions using the environment",
+					Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+						"authentication": {
+							Type:        "object",
+							Description: "Authentication of requests, unless set by the trigger",
+						},
+						"requestvalidation": {
+							Type:        "object",
+							Description: "OpenAPI validation of requests, unless set by the trigger",
+						},
+						"timeout": {
+							Type:        "integer",
+							Description: "Timeout in seconds of requests, unless set by the trigger",
+						},
+						"retry": {
+							Type:        "object",
+							Description: "Retry policy of requests, unless set by the trigger",
+						},
+						"circuitbreaker": {
+							Type:        "object",
+							Description: "Circuit breaker of the trigger, unless set by the trigger",
+						},
+					},
+				},
 			},
 		},
 	}
@@ -141,11 +289,53 @@ var (
 				"environment": environmentReferenceSchema,
 				"source":      archiveSchema,
 				"deployment":  archiveSchema,
+				"sourceMap":   archiveSchema,
 				"configmaps":  configMapReferenceSchema,
 				"buildcmd": {
 					Type:        "string",
 					Description: "BuildCommand is a custom build command that builder uses to build the source archive.",
 				},
+				"deploymentImage": {
+					Type:        "string",
+					Description: "DeploymentImage is the container image holding the deployment archive and the environment runtime, built by builder manager for environments with an image build output.",
+				},
+				"buildSteps": {
+					Type:        "array",
+					Description: "BuildSteps are run one after the other in place of BuildCommand to build the source archive.",
+					Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+						Schema: &apiextensionsv1beta1.JSONSchemaProps{
+							Type:     "object",
+							Required: []string{"name"},
+							Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+								"name": {
+									Type:        "string",
+									Description: "Name of the step, shown in the build logs.",
+								},
+								"image": {
+									Type:        "string",
+									Description: "Image the step runs in. Steps without an image run in the environment builder.",
+								},
+								"command": {
+									Type:        "array",
+									Description: "Command of the step and its arguments.",
+									Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+										Schema: &apiextensionsv1beta1.JSONSchemaProps{Type: "string"},
+									},
+								},
+								"env": {
+									Type:        "array",
+									Description: "Env are the environment variables of the step.",
+									Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+										Schema: &apiextensionsv1beta1.JSONSchemaProps{
+											Type:                   "object",
+											XPreserveUnknownFields: boolPtr(true),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
 			},
 		},
 		"status": {
@@ -187,7 +377,7 @@ var (
 	archiveSchemaProps = map[string]apiextensionsv1beta1.JSONSchemaProps{
 		"type": {
 			Type:        "string",
-			Description: "Type defines how the package is specified: literal or url.",
+			Description: "Type defines how the package is specified: literal, url or git.",
 		},
 		"literal": {
 			Type:        "string",
@@ -196,9 +386,40 @@ var (
 		},
 		"url": {
 			Type:        "string",
-			Description: "URL references a package.",
+			Description: "URL references a package, over HTTP(S) or in an object store with s3://, gs:// or azblob:// URLs.",
+		},
+		"authSecret": {
+			Type:        "string",
+			Description: "AuthSecret is the name of a secret in the namespace of the package with the credentials of the object store of the URL.",
 		},
 		"checksum": checksumSchema,
+		"format": {
+			Type:        "string",
+			Description: "Format of the package contents: zip, tar.gz or binary. Detected from the contents if empty.",
+		},
+		"git": {
+			Type:        "object",
+			Description: "Git references a package in a Git repository, cloned by fetcher when the package is fetched.",
+			Required:    []string{"url"},
+			Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+				"url": {
+					Type:        "string",
+					Description: "URL of the repository to clone, over HTTPS or SSH.",
+				},
+				"ref": {
+					Type:        "string",
+					Description: "Ref is the branch, tag or commit to check out. The default branch of the repository if empty.",
+				},
+				"subPath": {
+					Type:        "string",
+					Description: "SubPath is the directory of the repository holding the package contents. The whole repository if empty.",
+				},
+				"authSecret": {
+					Type:        "string",
+					Description: "AuthSecret is the name of a secret in the namespace of the package with username and password, or ssh-privatekey and optionally known_hosts.",
+				},
+			},
+		},
 	}
 	archiveSchema = apiextensionsv1beta1.JSONSchemaProps{
 		Type:        "object",
@@ -346,6 +567,10 @@ var (
 			Type:        "integer",
 			Description: "Only for newdeploy executor to set up target CPU utilization of HPA.",
 		},
+		"TargetConcurrencyPerPod": {
+			Type:        "integer",
+			Description: "Only for newdeploy executor to set up target average in-flight requests per pod of HPA.",
+		},
 		"SpecializationTimeout": {
 			Type:        "integer",
 			Description: "Timeout setting for executor to wait for pod specialization.",
@@ -413,6 +638,14 @@ var (
 			Description:            "(Optional) Podspec allows modification of deployed runtime pod with Kubernetes PodSpec.\n You can set either PodSpec or Container, but not both.",
 			XPreserveUnknownFields: boolPtr(true),
 		},
+		"maxConcurrentBuilds": {
+			Type:        "integer",
+			Description: "(Optional) MaxConcurrentBuilds is how many packages of the environment are built at the same time. Builder manager uses its default if 0.",
+		},
+		"output": {
+			Type:        "string",
+			Description: "(Optional) Output is what builds of the environment produce, a deploy archive (the default) or a container image.",
+		},
 	}
 	builderSchema = apiextensionsv1beta1.JSONSchemaProps{
 		Type:        "object",
@@ -18,6 +18,7 @@ package crd
 
 import (
 	"fmt"
+	"reflect"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -31,3 +32,26 @@ import (
 func CacheKey(metadata *metav1.ObjectMeta) string {
 	return fmt.Sprintf("%v_%v", metadata.UID, metadata.ResourceVersion)
 }
+
+// SpecCacheKey is like CacheKey, but identifies the spec of an object
+// by its generation, so that status updates keep the key. Functions,
+// whose status is updated as they run, are cached by this key. Objects
+// without a generation fall back to CacheKey.
+func SpecCacheKey(metadata *metav1.ObjectMeta) string {
+	if metadata.Generation == 0 {
+		return CacheKey(metadata)
+	}
+	return fmt.Sprintf("%v_g%v", metadata.UID, metadata.Generation)
+}
+
+// SpecChanged returns false if an update left the spec, labels and
+// annotations of an object as they were, like an update of the status.
+func SpecChanged(old *metav1.ObjectMeta, new *metav1.ObjectMeta) bool {
+	if old.ResourceVersion == new.ResourceVersion {
+		return false
+	}
+	if old.Generation == 0 || new.Generation == 0 || old.Generation != new.Generation {
+		return true
+	}
+	return !reflect.DeepEqual(old.Labels, new.Labels) || !reflect.DeepEqual(old.Annotations, new.Annotations)
+}
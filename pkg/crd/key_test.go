@@ -0,0 +1,98 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crd
+
+import (
+	"testing"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSpecCacheKey(t *testing.T) {
+	fn := metav1.ObjectMeta{UID: "uid", ResourceVersion: "10", Generation: 2}
+	statusUpdated := fn
+	statusUpdated.ResourceVersion = "11"
+	if SpecCacheKey(&fn) != SpecCacheKey(&statusUpdated) {
+		t.Errorf("expected a status update to keep the key %v, got %v", SpecCacheKey(&fn), SpecCacheKey(&statusUpdated))
+	}
+	specUpdated := statusUpdated
+	specUpdated.Generation = 3
+	if SpecCacheKey(&fn) == SpecCacheKey(&specUpdated) {
+		t.Errorf("expected a spec update to change the key %v", SpecCacheKey(&fn))
+	}
+	noGeneration := metav1.ObjectMeta{UID: "uid", ResourceVersion: "10"}
+	if SpecCacheKey(&noGeneration) != CacheKey(&noGeneration) {
+		t.Errorf("expected objects without generation to fall back to %v, got %v", CacheKey(&noGeneration), SpecCacheKey(&noGeneration))
+	}
+}
+
+func TestSpecChanged(t *testing.T) {
+	old := metav1.ObjectMeta{ResourceVersion: "10", Generation: 2, Labels: map[string]string{"a": "b"}}
+	for _, test := range []struct {
+		name    string
+		update  func(m *metav1.ObjectMeta)
+		changed bool
+	}{
+		{"resync", func(m *metav1.ObjectMeta) {}, false},
+		{"status update", func(m *metav1.ObjectMeta) { m.ResourceVersion = "11" }, false},
+		{"spec update", func(m *metav1.ObjectMeta) { m.ResourceVersion = "11"; m.Generation = 3 }, true},
+		{"label update", func(m *metav1.ObjectMeta) { m.ResourceVersion = "11"; m.Labels = map[string]string{"a": "c"} }, true},
+		{"no generation", func(m *metav1.ObjectMeta) { m.ResourceVersion = "11"; m.Generation = 0 }, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			updated := old
+			test.update(&updated)
+			if got := SpecChanged(&old, &updated); got != test.changed {
+				t.Errorf("SpecChanged() = %v, want %v", got, test.changed)
+			}
+		})
+	}
+}
+
+func TestUpgradeCRDSpec(t *testing.T) {
+	spec := apiextensionsv1beta1.CustomResourceDefinitionSpec{
+		Validation: &apiextensionsv1beta1.CustomResourceValidation{
+			OpenAPIV3Schema: &apiextensionsv1beta1.JSONSchemaProps{Type: "object"},
+		},
+		Subresources: &apiextensionsv1beta1.CustomResourceSubresources{
+			Status: &apiextensionsv1beta1.CustomResourceSubresourceStatus{},
+		},
+		AdditionalPrinterColumns: functionPrinterColumns,
+	}
+
+	// created by a release without status subresource
+	existing := apiextensionsv1beta1.CustomResourceDefinitionSpec{}
+	if !upgradeCRDSpec(&existing, &spec) || existing.Subresources == nil {
+		t.Fatal("expected the status subresource to be added")
+	}
+	if upgradeCRDSpec(&existing, &spec) {
+		t.Fatal("expected an up to date CRD to be left as is")
+	}
+
+	// subresource present, but a stale schema and printer columns
+	existing.Validation = &apiextensionsv1beta1.CustomResourceValidation{
+		OpenAPIV3Schema: &apiextensionsv1beta1.JSONSchemaProps{Type: "object", Description: "old"},
+	}
+	existing.AdditionalPrinterColumns = functionPrinterColumns[:1]
+	if !upgradeCRDSpec(&existing, &spec) {
+		t.Fatal("expected a stale schema to be upgraded")
+	}
+	if existing.Validation.OpenAPIV3Schema.Description != "" || len(existing.AdditionalPrinterColumns) != len(functionPrinterColumns) {
+		t.Fatalf("expected the schema and columns of this release, got %v", existing)
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dbtrigger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+type (
+	// Change is a row changed in a table, which the functions of database
+	// triggers are invoked with as JSON.
+	Change struct {
+		Operation fv1.DatabaseOperation `json:"operation"`
+
+		// Database is the name of the database of the table, and Schema
+		// its schema with PostgreSQL, or the database again with MySQL.
+		Database string `json:"database"`
+		Schema   string `json:"schema"`
+		Table    string `json:"table"`
+
+		// Key is the primary key of the row, and Before and After the row
+		// before and after the change, null for rows inserted and deleted.
+		// PostgreSQL only captures the whole row before updates and
+		// deletes of tables with REPLICA IDENTITY FULL.
+		Key    json.RawMessage `json:"key"`
+		Before json.RawMessage `json:"before"`
+		After  json.RawMessage `json:"after"`
+
+		// Time is when the change was made in the database.
+		Time time.Time `json:"time"`
+
+		// Source is the metadata of the change Debezium captured, such as
+		// the LSN of PostgreSQL or the binlog position of MySQL.
+		Source json.RawMessage `json:"source"`
+	}
+
+	// debeziumEnvelope is a change event of the Debezium connector of a
+	// database.
+	debeziumEnvelope struct {
+		Before json.RawMessage `json:"before"`
+		After  json.RawMessage `json:"after"`
+		Source json.RawMessage `json:"source"`
+		Op     string          `json:"op"`
+		TsMs   int64           `json:"ts_ms"`
+	}
+
+	debeziumSource struct {
+		DB     string `json:"db"`
+		Schema string `json:"schema"`
+		Table  string `json:"table"`
+		TsMs   int64  `json:"ts_ms"`
+	}
+
+	// schemaEnvelope is the JSON converter of Kafka Connect wrapping
+	// messages with their schema, unless schemas.enable is false.
+	schemaEnvelope struct {
+		Schema  json.RawMessage `json:"schema"`
+		Payload json.RawMessage `json:"payload"`
+	}
+)
+
+var debeziumOperations = map[string]fv1.DatabaseOperation{
+	"c": fv1.DatabaseOperationInsert,
+	"u": fv1.DatabaseOperationUpdate,
+	"d": fv1.DatabaseOperationDelete,
+	"r": fv1.DatabaseOperationRead,
+}
+
+// topics returns the Kafka topics the changes of the tables of a trigger
+// are published to.
+func topics(spec *fv1.DatabaseTriggerSpec) []string {
+	var topics []string
+	for _, table := range spec.Tables {
+		topics = append(topics, fmt.Sprintf("%v.%v.%v", spec.TopicPrefix, spec.Schema, table))
+	}
+	return topics
+}
+
+// parseChange returns the change of a Debezium change event with its key.
+// Tombstones following deletes and changes other than rows inserted,
+// updated, deleted or read, like tables truncated, have none.
+func parseChange(database fv1.DatabaseType, key []byte, value []byte) (*Change, error) {
+	value = unwrapSchema(value)
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	var e debeziumEnvelope
+	err := json.Unmarshal(value, &e)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding change event")
+	}
+	op, ok := debeziumOperations[e.Op]
+	if !ok {
+		return nil, nil
+	}
+
+	var source debeziumSource
+	if len(e.Source) > 0 {
+		err = json.Unmarshal(e.Source, &source)
+		if err != nil {
+			return nil, errors.Wrap(err, "error decoding change event source")
+		}
+	}
+
+	c := &Change{
+		Operation: op,
+		Database:  source.DB,
+		Schema:    source.Schema,
+		Table:     source.Table,
+		Key:       unwrapSchema(key),
+		Before:    nullIfEmpty(e.Before),
+		After:     nullIfEmpty(e.After),
+		Source:    nullIfEmpty(e.Source),
+	}
+	// MySQL has databases in place of schemas
+	if database == fv1.DatabaseTypeMySQL {
+		c.Schema = source.DB
+	}
+
+	switch {
+	case source.TsMs > 0:
+		c.Time = time.Unix(0, source.TsMs*int64(time.Millisecond)).UTC()
+	case e.TsMs > 0:
+		c.Time = time.Unix(0, e.TsMs*int64(time.Millisecond)).UTC()
+	}
+	return c, nil
+}
+
+// unwrapSchema returns the payload of a message wrapped with its schema, or
+// the message as is otherwise. Null payloads are empty.
+func unwrapSchema(msg []byte) []byte {
+	msg = bytes.TrimSpace(msg)
+	if len(msg) == 0 || bytes.Equal(msg, []byte("null")) {
+		return nil
+	}
+
+	var e schemaEnvelope
+	err := json.Unmarshal(msg, &e)
+	if err != nil || len(e.Schema) == 0 || len(e.Payload) == 0 {
+		return msg
+	}
+	if bytes.Equal(e.Payload, []byte("null")) {
+		return nil
+	}
+	return e.Payload
+}
+
+func nullIfEmpty(msg json.RawMessage) json.RawMessage {
+	if len(msg) == 0 {
+		return json.RawMessage("null")
+	}
+	return msg
+}
+
+// matches returns whether a trigger invokes its function on a change.
+func matches(spec *fv1.DatabaseTriggerSpec, c *Change) bool {
+	if len(spec.Operations) == 0 {
+		return c.Operation != fv1.DatabaseOperationRead
+	}
+	for _, op := range spec.Operations {
+		if op == c.Operation {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dbtrigger
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestParseChange(t *testing.T) {
+	postgresUpdate := `{"schema": {"type": "struct"}, "payload": {
+		"before": {"id": 1, "email": "old@example.com"},
+		"after": {"id": 1, "email": "new@example.com"},
+		"source": {"connector": "postgresql", "db": "shop", "schema": "public", "table": "customers", "ts_ms": 1614592800000, "lsn": 24023128},
+		"op": "u", "ts_ms": 1614592800123}}`
+
+	c, err := parseChange(fv1.DatabaseTypePostgres, []byte(`{"schema": {"type": "struct"}, "payload": {"id": 1}}`), []byte(postgresUpdate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Operation != fv1.DatabaseOperationUpdate || c.Database != "shop" || c.Schema != "public" || c.Table != "customers" {
+		t.Errorf("unexpected change %+v", c)
+	}
+	if string(c.Key) != `{"id": 1}` || string(c.After) != `{"id": 1, "email": "new@example.com"}` {
+		t.Errorf("unexpected key %s or after %s", c.Key, c.After)
+	}
+	if !c.Time.Equal(time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected time %v", c.Time)
+	}
+
+	// MySQL events without schemas have databases in place of schemas
+	mysqlInsert := `{"before": null, "after": {"id": 2},
+		"source": {"connector": "mysql", "db": "shop", "table": "orders", "file": "mysql-bin.000003", "pos": 154},
+		"op": "c", "ts_ms": 1614592800000}`
+	c, err = parseChange(fv1.DatabaseTypeMySQL, nil, []byte(mysqlInsert))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Operation != fv1.DatabaseOperationInsert || c.Schema != "shop" || c.Table != "orders" ||
+		string(c.Before) != "null" || string(c.Key) != "" || c.Time.IsZero() {
+		t.Errorf("unexpected change %+v", c)
+	}
+
+	for _, value := range []string{
+		"",
+		"null",
+		`{"schema": {"type": "struct"}, "payload": null}`,
+		`{"source": {"table": "orders"}, "op": "t"}`,
+	} {
+		c, err = parseChange(fv1.DatabaseTypePostgres, nil, []byte(value))
+		if err != nil || c != nil {
+			t.Errorf("expected no change of %q, got %+v, %v", value, c, err)
+		}
+	}
+
+	_, err = parseChange(fv1.DatabaseTypePostgres, nil, []byte("not json"))
+	if err == nil {
+		t.Error("expected error parsing malformed change event")
+	}
+}
+
+func TestMatches(t *testing.T) {
+	all := &fv1.DatabaseTriggerSpec{}
+	for op, expected := range map[fv1.DatabaseOperation]bool{
+		fv1.DatabaseOperationInsert: true,
+		fv1.DatabaseOperationUpdate: true,
+		fv1.DatabaseOperationDelete: true,
+		fv1.DatabaseOperationRead:   false,
+	} {
+		if matches(all, &Change{Operation: op}) != expected {
+			t.Errorf("expected %v to match %v without operations", op, expected)
+		}
+	}
+
+	deletes := &fv1.DatabaseTriggerSpec{Operations: []fv1.DatabaseOperation{fv1.DatabaseOperationDelete}}
+	if !matches(deletes, &Change{Operation: fv1.DatabaseOperationDelete}) ||
+		matches(deletes, &Change{Operation: fv1.DatabaseOperationInsert}) {
+		t.Error("expected only deletes to match")
+	}
+}
+
+func TestTopics(t *testing.T) {
+	spec := &fv1.DatabaseTriggerSpec{
+		TopicPrefix: "shop-db",
+		Schema:      "public",
+		Tables:      []string{"customers", "orders"},
+	}
+	expected := []string{"shop-db.public.customers", "shop-db.public.orders"}
+	if got := topics(spec); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected topics %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,302 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dbtrigger invokes the functions of DatabaseTriggers on the rows
+// changed in the tables of databases. Changes are captured by Debezium,
+// which tails the logical replication slot of PostgreSQL or the binlog of
+// MySQL and publishes the changes of each table to a Kafka topic, and read
+// by a consumer group per trigger. The changes of a trigger are delivered
+// in order, and their offsets committed once delivered, so that changes are
+// delivered at least once.
+package dbtrigger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	sarama "github.com/Shopify/sarama"
+	cluster "github.com/bsm/sarama-cluster"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	k8sCache "k8s.io/client-go/tools/cache"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/utils"
+)
+
+const (
+	resyncPeriod = 30 * time.Second
+
+	// initialRetryDelay is the delay before the first retry of a failed
+	// invocation, doubled for each retry after it up to maxRetryDelay.
+	initialRetryDelay = time.Second
+	maxRetryDelay     = time.Minute
+)
+
+type (
+	// Manager consumes the change events of the tables of database
+	// triggers and invokes their functions.
+	Manager struct {
+		logger     *zap.Logger
+		routerUrl  string
+		brokers    []string
+		version    sarama.KafkaVersion
+		httpClient *http.Client
+
+		lock          sync.Mutex
+		subscriptions map[k8stypes.UID]*subscription
+	}
+
+	// subscription consumes the change events of a trigger.
+	subscription struct {
+		trigger  *fv1.DatabaseTrigger
+		consumer *cluster.Consumer
+		done     chan struct{}
+		stopped  chan struct{}
+	}
+)
+
+// Start consumes the change events of database triggers from the Kafka
+// brokers in DEBEZIUM_KAFKA_BROKERS, invoking functions through router at
+// routerUrl.
+func Start(logger *zap.Logger, routerUrl string) error {
+	brokers := os.Getenv("DEBEZIUM_KAFKA_BROKERS")
+	if len(brokers) == 0 {
+		return errors.New("DEBEZIUM_KAFKA_BROKERS is not set")
+	}
+	version := sarama.V1_0_0_0
+	if v := os.Getenv("DEBEZIUM_KAFKA_VERSION"); len(v) > 0 {
+		var err error
+		version, err = sarama.ParseKafkaVersion(v)
+		if err != nil {
+			return errors.Wrapf(err, "error parsing kafka version %q", v)
+		}
+	}
+
+	fissionClient, _, _, _, err := crd.MakeFissionClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to get fission client")
+	}
+
+	err = fissionClient.WaitForCRDs()
+	if err != nil {
+		return errors.Wrap(err, "error waiting for CRDs")
+	}
+
+	m := MakeManager(logger, routerUrl, strings.Split(brokers, ","), version)
+	lw := k8sCache.NewListWatchFromClient(fissionClient.CoreV1().RESTClient(), "databasetriggers", metav1.NamespaceAll, fields.Everything())
+	_, controller := k8sCache.NewInformer(lw, &fv1.DatabaseTrigger{}, resyncPeriod, k8sCache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			m.subscribe(obj.(*fv1.DatabaseTrigger))
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldTrigger, newTrigger := oldObj.(*fv1.DatabaseTrigger), newObj.(*fv1.DatabaseTrigger)
+			if oldTrigger.ObjectMeta.UID == newTrigger.ObjectMeta.UID && reflect.DeepEqual(oldTrigger.Spec, newTrigger.Spec) {
+				return
+			}
+			m.unsubscribe(oldTrigger)
+			m.subscribe(newTrigger)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(k8sCache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if trigger, ok := obj.(*fv1.DatabaseTrigger); ok {
+				m.unsubscribe(trigger)
+			}
+		},
+	})
+	go controller.Run(make(chan struct{}))
+
+	logger.Info("started database trigger manager", zap.Strings("brokers", m.brokers))
+	return nil
+}
+
+// MakeManager returns a manager consuming change events from brokers.
+func MakeManager(logger *zap.Logger, routerUrl string, brokers []string, version sarama.KafkaVersion) *Manager {
+	return &Manager{
+		logger:        logger.Named("database_trigger_manager"),
+		routerUrl:     strings.TrimSuffix(routerUrl, "/"),
+		brokers:       brokers,
+		version:       version,
+		httpClient:    &http.Client{Timeout: 5 * time.Minute},
+		subscriptions: make(map[k8stypes.UID]*subscription),
+	}
+}
+
+// subscribe starts consuming the change events of a trigger, in the
+// consumer group of its UID.
+func (m *Manager) subscribe(trigger *fv1.DatabaseTrigger) {
+	logger := m.logger.With(zap.String("trigger", trigger.ObjectMeta.Name), zap.String("namespace", trigger.ObjectMeta.Namespace))
+
+	config := cluster.NewConfig()
+	config.Config.Version = m.version
+	config.Consumer.Return.Errors = true
+	// the offsets of changes delivered are committed often, for few to be
+	// delivered again after restarts
+	config.Consumer.Offsets.CommitInterval = time.Second
+
+	consumer, err := cluster.NewConsumer(m.brokers, string(trigger.ObjectMeta.UID), topics(&trigger.Spec), config)
+	if err != nil {
+		logger.Error("error creating consumer of change events", zap.Error(err))
+		return
+	}
+
+	sub := &subscription{
+		trigger:  trigger,
+		consumer: consumer,
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	m.lock.Lock()
+	m.subscriptions[trigger.ObjectMeta.UID] = sub
+	m.lock.Unlock()
+
+	go func() {
+		for err := range consumer.Errors() {
+			logger.Error("consumer error", zap.Error(err))
+		}
+	}()
+	go func() {
+		defer close(sub.stopped)
+		for msg := range consumer.Messages() {
+			if m.handle(logger, sub, msg) {
+				consumer.MarkOffset(msg, "")
+			}
+		}
+	}()
+	logger.Info("subscribed to change events", zap.Strings("topics", topics(&trigger.Spec)))
+}
+
+// unsubscribe stops consuming the change events of a trigger, committing
+// the offsets of the changes delivered.
+func (m *Manager) unsubscribe(trigger *fv1.DatabaseTrigger) {
+	m.lock.Lock()
+	sub, ok := m.subscriptions[trigger.ObjectMeta.UID]
+	delete(m.subscriptions, trigger.ObjectMeta.UID)
+	m.lock.Unlock()
+	if !ok {
+		return
+	}
+
+	// the change being retried isn't marked, and is delivered again to
+	// the next subscription
+	close(sub.done)
+	err := sub.consumer.Close()
+	if err != nil {
+		m.logger.Error("error closing consumer of change events", zap.Error(err),
+			zap.String("trigger", trigger.ObjectMeta.Name), zap.String("namespace", trigger.ObjectMeta.Namespace))
+	}
+	<-sub.stopped
+}
+
+// handle invokes the function of a subscription with the change of a
+// message, if the trigger matches it. It returns false if the subscription
+// stopped before the change was handled.
+func (m *Manager) handle(logger *zap.Logger, sub *subscription, msg *sarama.ConsumerMessage) bool {
+	select {
+	case <-sub.done:
+		return false
+	default:
+	}
+
+	trigger := sub.trigger
+	change, err := parseChange(trigger.Spec.Database, msg.Key, msg.Value)
+	if err != nil {
+		logger.Error("skipping malformed change event", zap.Error(err),
+			zap.String("topic", msg.Topic), zap.Int64("offset", msg.Offset))
+		return true
+	}
+	if change == nil || !matches(&trigger.Spec, change) {
+		return true
+	}
+
+	body, err := json.Marshal(change)
+	if err != nil {
+		logger.Error("error encoding change", zap.Error(err))
+		return true
+	}
+
+	delay := initialRetryDelay
+	for attempt := 0; ; attempt++ {
+		err = m.invoke(trigger, change, body)
+		if err == nil {
+			return true
+		}
+		if attempt >= trigger.Spec.MaxRetries {
+			logger.Error("skipping change the function failed on", zap.Error(err),
+				zap.String("topic", msg.Topic), zap.Int64("offset", msg.Offset), zap.Int("attempts", attempt+1))
+			return true
+		}
+		logger.Info("retrying change the function failed on", zap.Error(err), zap.Duration("delay", delay))
+
+		select {
+		case <-time.After(delay):
+		case <-sub.done:
+			return false
+		}
+		delay *= 2
+		if delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
+
+// invoke invokes the function of a trigger with a change.
+func (m *Manager) invoke(trigger *fv1.DatabaseTrigger, change *Change, body []byte) error {
+	// triggers can only be created in the same namespace as the function
+	url := m.routerUrl + utils.UrlForFunction(trigger.Spec.FunctionReference.Name, trigger.ObjectMeta.Namespace)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	eventTime := change.Time
+	if eventTime.IsZero() {
+		eventTime = time.Now()
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Fission-Database-Table", change.Schema+"."+change.Table)
+	req.Header.Set("X-Fission-Database-Operation", string(change.Operation))
+	req.Header.Set(fv1.HeaderTriggerType, fv1.TriggerTypeDatabase)
+	req.Header.Set(fv1.HeaderTriggerName, trigger.ObjectMeta.Name)
+	req.Header.Set(fv1.HeaderCloudEventsMode, string(trigger.Spec.CloudEvents))
+	req.Header.Set(fv1.HeaderEventTime, eventTime.UTC().Format(time.RFC3339Nano))
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "error invoking function")
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("function returned status %v", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dbtrigger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	sarama "github.com/Shopify/sarama"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const customerInsert = `{"before": null, "after": {"id": 1},
+	"source": {"db": "shop", "schema": "public", "table": "customers", "ts_ms": 1614592800000},
+	"op": "c"}`
+
+func makeTestSubscription(maxRetries int) *subscription {
+	return &subscription{
+		trigger: &fv1.DatabaseTrigger{
+			ObjectMeta: metav1.ObjectMeta{Name: "customers", Namespace: "default"},
+			Spec: fv1.DatabaseTriggerSpec{
+				Database:    fv1.DatabaseTypePostgres,
+				TopicPrefix: "shop-db",
+				Schema:      "public",
+				Tables:      []string{"customers"},
+				MaxRetries:  maxRetries,
+				FunctionReference: fv1.FunctionReference{
+					Type: fv1.FunctionReferenceTypeFunctionName,
+					Name: "welcome",
+				},
+			},
+		},
+		done: make(chan struct{}),
+	}
+}
+
+func TestHandleRetriesFailedInvocations(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fission-function/welcome" ||
+			r.Header.Get(fv1.HeaderTriggerType) != fv1.TriggerTypeDatabase ||
+			r.Header.Get("X-Fission-Database-Table") != "public.customers" ||
+			r.Header.Get("X-Fission-Database-Operation") != string(fv1.DatabaseOperationInsert) {
+			t.Errorf("unexpected request %v %v", r.URL.Path, r.Header)
+		}
+		// the function fails once
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	m := MakeManager(zap.NewNop(), server.URL, nil, sarama.V1_0_0_0)
+	msg := &sarama.ConsumerMessage{Topic: "shop-db.public.customers", Value: []byte(customerInsert)}
+	if !m.handle(m.logger, makeTestSubscription(1), msg) {
+		t.Error("expected change to be handled")
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 invocations, got %v", calls)
+	}
+}
+
+func TestHandleStopsWithSubscription(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	m := MakeManager(zap.NewNop(), server.URL, nil, sarama.V1_0_0_0)
+	msg := &sarama.ConsumerMessage{Topic: "shop-db.public.customers", Value: []byte(customerInsert)}
+
+	// changes failing past their retries are skipped
+	if !m.handle(m.logger, makeTestSubscription(0), msg) {
+		t.Error("expected change to be skipped")
+	}
+
+	// changes of subscriptions stopped aren't
+	sub := makeTestSubscription(3)
+	close(sub.done)
+	if m.handle(m.logger, sub, msg) {
+		t.Error("expected change not to be handled")
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 invocation, got %v", calls)
+	}
+}
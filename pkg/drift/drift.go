@@ -0,0 +1,190 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package drift finds the differences between the kubernetes objects
+// Fission generates for functions and triggers and what their specs imply,
+// e.g. after objects were edited with kubectl.
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// ReasonModified means fields of the object differ from the spec.
+	ReasonModified Reason = "modified"
+	// ReasonMissing means the object the spec implies doesn't exist.
+	ReasonMissing Reason = "missing"
+	// ReasonOrphaned means the object exists but its owner doesn't.
+	ReasonOrphaned Reason = "orphaned"
+)
+
+type (
+	Reason string
+
+	// Drift is an object that differs from what the spec of its owner implies.
+	Drift struct {
+		Kind      string `json:"kind"`
+		Namespace string `json:"namespace"`
+		Name      string `json:"name"`
+
+		// Owner is the Fission object the object is generated for, e.g. "function default/hello".
+		Owner string `json:"owner"`
+
+		Reason Reason      `json:"reason"`
+		Fields []FieldDiff `json:"fields,omitempty"`
+
+		// Reverted is true if the object was brought back in line with the spec.
+		Reverted bool `json:"reverted"`
+
+		// Error is the error reverting the object, if any.
+		Error string `json:"error,omitempty"`
+	}
+
+	// FieldDiff is a field with a value other than the expected one,
+	// values are JSON encoded and empty if the field is not set.
+	FieldDiff struct {
+		Path     string `json:"path"`
+		Expected string `json:"expected"`
+		Actual   string `json:"actual"`
+	}
+)
+
+// Diff returns the fields under the given dot-separated paths that differ
+// between the expected and the actual object. Only fields set in the
+// expected object are compared, so fields defaulted by kubernetes or added
+// by other controllers are not reported. Lists must have the same length,
+// their items are compared in order.
+func Diff(expected, actual interface{}, paths ...string) ([]FieldDiff, error) {
+	expectedObj, err := toMap(expected)
+	if err != nil {
+		return nil, err
+	}
+	actualObj, err := toMap(actual)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []FieldDiff
+	for _, path := range paths {
+		expectedValue, ok := fieldValue(expectedObj, path)
+		if !ok {
+			continue
+		}
+		actualValue, _ := fieldValue(actualObj, path)
+		diffs = diffValues(diffs, path, expectedValue, actualValue)
+	}
+	return diffs, nil
+}
+
+func diffValues(diffs []FieldDiff, path string, expected, actual interface{}) []FieldDiff {
+	switch e := expected.(type) {
+	case nil:
+		// unset fields are left to kubernetes
+		return diffs
+
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, key := range sortedKeys(e) {
+			diffs = diffValues(diffs, path+"."+key, e[key], a[key])
+		}
+		return diffs
+
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(e) {
+			break
+		}
+		for i := range e {
+			diffs = diffValues(diffs, fmt.Sprintf("%v[%v]", path, i), e[i], a[i])
+		}
+		return diffs
+
+	default:
+		if reflect.DeepEqual(expected, actual) {
+			return diffs
+		}
+	}
+
+	return append(diffs, FieldDiff{
+		Path:     path,
+		Expected: encode(expected),
+		Actual:   encode(actual),
+	})
+}
+
+// SetRevertResult records the result of reverting the object.
+func (d *Drift) SetRevertResult(err error) {
+	if err != nil {
+		d.Error = err.Error()
+		return
+	}
+	d.Reverted = true
+}
+
+// toMap returns the JSON form of an object.
+func toMap(obj interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	err = json.Unmarshal(raw, &m)
+	return m, err
+}
+
+// fieldValue returns the value of the field with the given dot-separated path.
+func fieldValue(obj map[string]interface{}, path string) (interface{}, bool) {
+	var value interface{} = obj
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok = m[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func encode(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(raw)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drift
+
+import (
+	"reflect"
+	"testing"
+)
+
+type (
+	testContainer struct {
+		Name            string            `json:"name"`
+		Image           string            `json:"image"`
+		ImagePullPolicy string            `json:"imagePullPolicy,omitempty"`
+		Env             map[string]string `json:"env,omitempty"`
+	}
+
+	testObject struct {
+		Labels     map[string]string `json:"labels,omitempty"`
+		Replicas   *int              `json:"replicas,omitempty"`
+		Containers []testContainer   `json:"containers,omitempty"`
+	}
+)
+
+func TestDiff(t *testing.T) {
+	one, two := 1, 2
+	expected := testObject{
+		Labels:   map[string]string{"functionName": "hello"},
+		Replicas: &one,
+		Containers: []testContainer{
+			{Name: "hello", Image: "node:1", Env: map[string]string{"A": "1"}},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		actual testObject
+		paths  []string
+		diffs  []FieldDiff
+	}{
+		{
+			name: "defaulted and added fields are ignored",
+			actual: testObject{
+				Labels:   map[string]string{"functionName": "hello", "added": "by-kubectl"},
+				Replicas: &one,
+				Containers: []testContainer{
+					{Name: "hello", Image: "node:1", ImagePullPolicy: "IfNotPresent", Env: map[string]string{"A": "1"}},
+				},
+			},
+			paths: []string{"labels", "replicas", "containers"},
+		},
+		{
+			name: "modified fields are reported",
+			actual: testObject{
+				Labels:   map[string]string{"functionName": "bye"},
+				Replicas: &two,
+				Containers: []testContainer{
+					{Name: "hello", Image: "node:2", Env: map[string]string{"A": "2"}},
+				},
+			},
+			paths: []string{"labels", "replicas", "containers"},
+			diffs: []FieldDiff{
+				{Path: "labels.functionName", Expected: `"hello"`, Actual: `"bye"`},
+				{Path: "replicas", Expected: "1", Actual: "2"},
+				{Path: "containers[0].env.A", Expected: `"1"`, Actual: `"2"`},
+				{Path: "containers[0].image", Expected: `"node:1"`, Actual: `"node:2"`},
+			},
+		},
+		{
+			name: "only given paths are compared",
+			actual: testObject{
+				Labels:   map[string]string{"functionName": "hello"},
+				Replicas: &two,
+			},
+			paths: []string{"labels"},
+		},
+		{
+			name: "removed fields are reported",
+			actual: testObject{
+				Replicas: &one,
+				Containers: []testContainer{
+					{Name: "hello", Image: "node:1"},
+				},
+			},
+			paths: []string{"labels", "containers"},
+			diffs: []FieldDiff{
+				{Path: "labels", Expected: `{"functionName":"hello"}`, Actual: ""},
+				{Path: "containers[0].env", Expected: `{"A":"1"}`, Actual: ""},
+			},
+		},
+		{
+			name: "lists of other length are reported",
+			actual: testObject{
+				Labels:   map[string]string{"functionName": "hello"},
+				Replicas: &one,
+				Containers: []testContainer{
+					{Name: "hello", Image: "node:1", Env: map[string]string{"A": "1"}},
+					{Name: "sidecar", Image: "proxy"},
+				},
+			},
+			paths: []string{"containers"},
+			diffs: []FieldDiff{
+				{
+					Path:     "containers",
+					Expected: `[{"env":{"A":"1"},"image":"node:1","name":"hello"}]`,
+					Actual:   `[{"env":{"A":"1"},"image":"node:1","name":"hello"},{"image":"proxy","name":"sidecar"}]`,
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diffs, err := Diff(expected, test.actual, test.paths...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(diffs, test.diffs) {
+				t.Errorf("expected diffs %+v, got %+v", test.diffs, diffs)
+			}
+		})
+	}
+}
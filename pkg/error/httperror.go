@@ -50,6 +50,8 @@ func MakeErrorFromHTTP(resp *http.Response) error {
 	switch resp.StatusCode {
 	case http.StatusBadRequest:
 		errCode = ErrorInvalidArgument
+	case http.StatusUnauthorized:
+		errCode = ErrorNotAuthenticated
 	case http.StatusForbidden:
 		errCode = ErrorNotAuthorized
 	case http.StatusNotFound:
@@ -79,6 +81,8 @@ func (err Error) HTTPStatus() int {
 	switch err.Code {
 	case ErrorInvalidArgument:
 		code = http.StatusBadRequest
+	case ErrorNotAuthenticated:
+		code = http.StatusUnauthorized
 	case ErrorNotAuthorized:
 		code = http.StatusForbidden
 	case ErrorNotFound:
@@ -136,6 +140,7 @@ const (
 	ErrorSizeLimitExceeded
 	ErrorRequestTimeout
 	ErrorTooManyRequests
+	ErrorNotAuthenticated
 )
 
 // must match order and len of the above const
@@ -150,4 +155,6 @@ var errorDescriptions = []string{
 	"Checksum verification failed",
 	"Size limit exceeded",
 	"Request time limit exceeded",
+	"Too many requests",
+	"Not authenticated",
 }
@@ -17,10 +17,13 @@ limitations under the License.
 package executor
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
@@ -28,10 +31,16 @@ import (
 	"github.com/pkg/errors"
 	"go.opencensus.io/plugin/ochttp"
 	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/drift"
 	ferror "github.com/fission/fission/pkg/error"
 	"github.com/fission/fission/pkg/executor/client"
+	"github.com/fission/fission/pkg/executor/executortype"
+	"github.com/fission/fission/pkg/shutdown"
+	"github.com/fission/fission/pkg/tracing"
 )
 
 func (executor *Executor) getServiceForFunctionAPI(w http.ResponseWriter, r *http.Request) {
@@ -73,6 +82,7 @@ func (executor *Executor) getServiceForFunctionAPI(w http.ResponseWriter, r *htt
 			if active > 1 || et.IsValid(fsvc) {
 				// Cached, return svc address
 				executor.logger.Debug("served from cache", zap.String("name", fsvc.Name), zap.String("address", fsvc.Address))
+				countAddressCacheLookup(t, true)
 				executor.writeResponse(w, fsvc.Address, fn.ObjectMeta.Name)
 				return
 			}
@@ -84,6 +94,7 @@ func (executor *Executor) getServiceForFunctionAPI(w http.ResponseWriter, r *htt
 			active--
 		}
 
+		countAddressCacheLookup(t, false)
 		if active >= concurrency {
 			errMsg := fmt.Sprintf("max concurrency reached for %v. All %v instance are active", fn.ObjectMeta.Name, concurrency)
 			executor.logger.Error("error occurred", zap.String("error", errMsg))
@@ -95,6 +106,7 @@ func (executor *Executor) getServiceForFunctionAPI(w http.ResponseWriter, r *htt
 		if err == nil {
 			if et.IsValid(fsvc) {
 				// Cached, return svc address
+				countAddressCacheLookup(t, true)
 				executor.writeResponse(w, fsvc.Address, fn.ObjectMeta.Name)
 				return
 			}
@@ -104,18 +116,37 @@ func (executor *Executor) getServiceForFunctionAPI(w http.ResponseWriter, r *htt
 				zap.String("address", fsvc.Address))
 			et.DeleteFuncSvcFromCache(fsvc)
 		}
+		countAddressCacheLookup(t, false)
 	}
 
-	serviceName, err := executor.getServiceForFunction(fn)
+	// router has already settled the priority of the request
+	priority := fv1.InvocationPriority(r.Header.Get(fv1.HeaderPriority))
+	if priority.Validate() != nil {
+		priority = fv1.InvocationPriorityStandard
+	}
+	// quarantined functions are not specialized until their spec changes
+	if err := executor.quarantine.Check(&fn.ObjectMeta); err != nil {
+		executor.statusRecorder.Record(&fn.ObjectMeta, fv1.FunctionLastError, apiv1.ConditionTrue, "Quarantined", err.Error())
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	serviceName, err := executor.getServiceForFunction(r.Context(), fn, priority)
 	if err != nil {
 		code, msg := ferror.GetHTTPError(err)
 		executor.logger.Error("error getting service for function",
 			zap.Error(err),
 			zap.String("function", fn.ObjectMeta.Name),
 			zap.String("fission_http_error", msg))
+		// rejected requests aren't crashes of the function
+		if code == http.StatusInternalServerError {
+			executor.quarantine.RecordFailure(&fn.ObjectMeta, fn.ObjectMeta.Generation, msg)
+		}
+		executor.statusRecorder.Record(&fn.ObjectMeta, fv1.FunctionPodsReady, apiv1.ConditionFalse, "ServiceFailed", msg)
+		executor.statusRecorder.Record(&fn.ObjectMeta, fv1.FunctionLastError, apiv1.ConditionTrue, "ServiceFailed", msg)
 		http.Error(w, msg, code)
 		return
 	}
+	executor.statusRecorder.Record(&fn.ObjectMeta, fv1.FunctionPodsReady, apiv1.ConditionTrue, "ServiceReady", "")
 	executor.writeResponse(w, serviceName, fn.ObjectMeta.Name)
 }
 
@@ -139,10 +170,12 @@ func (executor *Executor) writeResponse(w http.ResponseWriter, serviceName strin
 // stale addresses are not returned to the router.
 // To make it optimal, plan is to add an eager cache invalidator function that watches for pod deletion events and
 // invalidates the cache entry if the pod address was cached.
-func (executor *Executor) getServiceForFunction(fn *fv1.Function) (string, error) {
+func (executor *Executor) getServiceForFunction(ctx context.Context, fn *fv1.Function, priority fv1.InvocationPriority) (string, error) {
 	respChan := make(chan *createFuncServiceResponse)
 	executor.requestChan <- &createFuncServiceRequest{
+		ctx:      ctx,
 		function: fn,
+		priority: priority,
 		respChan: respChan,
 	}
 	resp := <-respChan
@@ -223,7 +256,7 @@ func (executor *Executor) unTapService(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to parse request", http.StatusBadRequest)
 		return
 	}
-	key := fmt.Sprintf("%v_%v", tapSvcReq.FnMetadata.UID, tapSvcReq.FnMetadata.ResourceVersion)
+	key := crd.SpecCacheKey(&tapSvcReq.FnMetadata)
 	t := tapSvcReq.FnExecutorType
 	if t != fv1.ExecutorTypePoolmgr {
 		msg := fmt.Sprintf("Unknown executor type '%v'", t)
@@ -238,6 +271,46 @@ func (executor *Executor) unTapService(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// detectDrift reports the function objects that differ from what function
+// specs imply, and reverts them if the "revert" query parameter is true.
+func (executor *Executor) detectDrift(w http.ResponseWriter, r *http.Request) {
+	revert := false
+	if revertStr := r.URL.Query().Get("revert"); len(revertStr) > 0 {
+		var err error
+		revert, err = strconv.ParseBool(revertStr)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid revert value '%v'", revertStr), http.StatusBadRequest)
+			return
+		}
+	}
+
+	drifts := []drift.Drift{}
+	for t, et := range executor.executorTypes {
+		detector, ok := et.(executortype.DriftDetector)
+		if !ok {
+			continue
+		}
+		d, err := detector.DetectDrift(revert)
+		if err != nil {
+			executor.logger.Error("error detecting drift", zap.Error(err), zap.String("executor_type", string(t)))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		drifts = append(drifts, d...)
+	}
+
+	resp, err := json.Marshal(drifts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, err = w.Write(resp)
+	if err != nil {
+		executor.logger.Error("error writing HTTP response", zap.Error(err))
+	}
+}
+
 // GetHandler returns an http.Handler.
 func (executor *Executor) GetHandler() http.Handler {
 	r := mux.NewRouter()
@@ -246,15 +319,41 @@ func (executor *Executor) GetHandler() http.Handler {
 	r.HandleFunc("/v2/tapServices", executor.tapServices).Methods("POST")
 	r.HandleFunc("/healthz", executor.healthHandler).Methods("GET")
 	r.HandleFunc("/v2/unTapService", executor.unTapService).Methods("POST")
+	r.HandleFunc("/v2/drift", executor.detectDrift).Methods("GET")
 	return r
 }
 
-// Serve starts an HTTP server.
-func (executor *Executor) Serve(port int) {
+// Serve starts an HTTP server. On shutdown, the server stops accepting
+// connections and waits for the specializations requested.
+func (executor *Executor) Serve(coordinator *shutdown.Coordinator, port int) {
 	executor.logger.Info("starting executor API", zap.Int("port", port))
-	address := fmt.Sprintf(":%v", port)
-	err := http.ListenAndServe(address, &ochttp.Handler{
-		Handler: executor.GetHandler(),
+	server := &http.Server{
+		Addr: fmt.Sprintf(":%v", port),
+		Handler: &ochttp.Handler{
+			Handler: tracing.Handler(executor.GetHandler(), "executor"),
+		},
+	}
+	err := coordinator.Serve("executor API", server, server.ListenAndServe)
+	if err != nil {
+		executor.logger.Fatal("done listening", zap.Error(err))
+	}
+}
+
+// ServeMTLS starts an HTTPS server that only accepts clients with a
+// certificate signed by the mTLS CA. The HTTP server stays up for probes.
+func (executor *Executor) ServeMTLS(coordinator *shutdown.Coordinator, port int, tlsConfig *tls.Config) {
+	executor.logger.Info("starting executor API with mTLS", zap.Int("port", port))
+	server := &http.Server{
+		Addr: fmt.Sprintf(":%v", port),
+		Handler: &ochttp.Handler{
+			Handler: tracing.Handler(executor.GetHandler(), "executor"),
+		},
+		TLSConfig: tlsConfig,
+	}
+	err := coordinator.Serve("executor API with mTLS", server, func() error {
+		return server.ListenAndServeTLS("", "")
 	})
-	executor.logger.Fatal("done listening", zap.Error(err))
+	if err != nil {
+		executor.logger.Fatal("done listening with mTLS", zap.Error(err))
+	}
 }
@@ -19,10 +19,12 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -33,7 +35,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/drift"
 	ferror "github.com/fission/fission/pkg/error"
+	"github.com/fission/fission/pkg/tracing"
 )
 
 type (
@@ -62,15 +66,27 @@ func MakeClient(logger *zap.Logger, executorURL string) *Client {
 		tappedByURL: make(map[string]TapServiceRequest),
 		requestChan: make(chan TapServiceRequest, 100),
 		httpClient: &http.Client{
-			Transport: &ochttp.Transport{},
+			Transport: tracing.Transport(&ochttp.Transport{}),
 		},
 	}
 	go c.service()
 	return c
 }
 
-// GetServiceForFunction returns the service name for a given function.
-func (c *Client) GetServiceForFunction(ctx context.Context, fn *fv1.Function) (string, error) {
+// SetTLSConfig makes the client talk to executor with the given TLS config,
+// used when executor serves requests with mTLS.
+func (c *Client) SetTLSConfig(tlsConfig *tls.Config) {
+	c.httpClient.Transport = tracing.Transport(&ochttp.Transport{
+		Base: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	})
+}
+
+// GetServiceForFunction returns the service name for a given function. If executor
+// has to specialize a pod for the function, it does so according to the priority.
+func (c *Client) GetServiceForFunction(ctx context.Context, fn *fv1.Function, priority fv1.InvocationPriority) (string, error) {
 	executorURL := c.executorURL + "/v2/getServiceForFunction"
 
 	body, err := json.Marshal(fn)
@@ -78,7 +94,16 @@ func (c *Client) GetServiceForFunction(ctx context.Context, fn *fv1.Function) (s
 		return "", errors.Wrap(err, "could not marshal request body for getting service for function")
 	}
 
-	resp, err := ctxhttp.Post(ctx, c.httpClient, executorURL, "application/json", bytes.NewReader(body))
+	req, err := http.NewRequest(http.MethodPost, executorURL, bytes.NewReader(body))
+	if err != nil {
+		return "", errors.Wrap(err, "error creating request for getting service for function")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(priority) > 0 {
+		req.Header.Set(fv1.HeaderPriority, string(priority))
+	}
+
+	resp, err := ctxhttp.Do(ctx, c.httpClient, req)
 	if err != nil {
 		return "", errors.Wrap(err, "error posting to getting service for function")
 	}
@@ -96,13 +121,46 @@ func (c *Client) GetServiceForFunction(ctx context.Context, fn *fv1.Function) (s
 	return string(svcName), nil
 }
 
+// DetectDrift returns the function objects that differ from what function
+// specs imply, executor reverts them if revert is true.
+func (c *Client) DetectDrift(ctx context.Context, revert bool) ([]drift.Drift, error) {
+	executorURL := c.executorURL + "/v2/drift?revert=" + strconv.FormatBool(revert)
+
+	req, err := http.NewRequest(http.MethodGet, executorURL, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating request for detecting drift")
+	}
+
+	resp, err := ctxhttp.Do(ctx, c.httpClient, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "error detecting drift")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, ferror.MakeErrorFromHTTP(resp)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading response body from detecting drift")
+	}
+
+	var drifts []drift.Drift
+	err = json.Unmarshal(body, &drifts)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding drift")
+	}
+	return drifts, nil
+}
+
 // UnTapService sends a request to /v2/unTapService.
 func (c *Client) UnTapService(ctx context.Context, fnMeta metav1.ObjectMeta, executorType fv1.ExecutorType, serviceURL *url.URL) error {
 	url := c.executorURL + "/v2/unTapService"
 	tapSvc := TapServiceRequest{
 		FnMetadata:     fnMeta,
 		FnExecutorType: executorType,
-		ServiceURL:     strings.TrimPrefix(serviceURL.String(), "http://"),
+		ServiceURL:     serviceURL.Host,
 	}
 
 	body, err := json.Marshal(tapSvc)
@@ -165,7 +223,7 @@ func (c *Client) TapService(fnMeta metav1.ObjectMeta, executorType fv1.ExecutorT
 		FnExecutorType: executorType,
 		// service url is for executor to know which
 		// pod/service is currently used to serve user function.
-		ServiceURL: serviceURL.String(),
+		ServiceURL: serviceURL.Host,
 	}
 }
 
@@ -177,7 +235,7 @@ func (c *Client) _tapService(tapSvcReqs []TapServiceRequest) error {
 		return err
 	}
 
-	resp, err := http.Post(executorURL, "application/json", bytes.NewReader(body))
+	resp, err := c.httpClient.Post(executorURL, "application/json", bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
@@ -160,6 +160,11 @@ func getSecretRelatedFuncs(logger *zap.Logger, m *metav1.ObjectMeta, fissionClie
 
 func refreshPods(logger *zap.Logger, funcs []fv1.Function, types map[fv1.ExecutorType]executortype.ExecutorType) {
 	for _, f := range funcs {
+		// fetcher updates the secrets and configmaps of these in place
+		if f.Spec.ConfigReload != nil {
+			continue
+		}
+
 		var err error
 
 		et, exists := types[f.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType]
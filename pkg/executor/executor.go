@@ -24,25 +24,42 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dchest/uniuri"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/crd"
+	ferror "github.com/fission/fission/pkg/error"
 	"github.com/fission/fission/pkg/executor/cms"
 	"github.com/fission/fission/pkg/executor/executortype"
 	"github.com/fission/fission/pkg/executor/executortype/newdeploy"
 	"github.com/fission/fission/pkg/executor/executortype/poolmgr"
 	"github.com/fission/fission/pkg/executor/fscache"
+	"github.com/fission/fission/pkg/executor/hook"
+	"github.com/fission/fission/pkg/executor/quarantine"
 	"github.com/fission/fission/pkg/executor/reaper"
 	"github.com/fission/fission/pkg/executor/util"
 	fetcherConfig "github.com/fission/fission/pkg/fetcher/config"
+	"github.com/fission/fission/pkg/fissionconfig"
+	"github.com/fission/fission/pkg/fnstatus"
+	"github.com/fission/fission/pkg/metering"
+	"github.com/fission/fission/pkg/mtls"
+	"github.com/fission/fission/pkg/qos"
+	"github.com/fission/fission/pkg/shutdown"
+	"github.com/fission/fission/pkg/tracing"
 )
 
+// defaultGuardedSpecializations is the limit of concurrent specializations
+// used when a resource budget is set without a limit, as the guard needs a
+// limit to cut down.
+const defaultGuardedSpecializations = 32
+
 type (
 	// Executor defines a fission function executor.
 	Executor struct {
@@ -55,10 +72,26 @@ type (
 
 		requestChan chan *createFuncServiceRequest
 		fsCreateWg  map[string]*sync.WaitGroup
+
+		// specializationLimiter limits the number of functions specialized at
+		// the same time, specializing the ones of high priority requests first.
+		specializationLimiter *qos.Limiter
+
+		// statusRecorder records the PodsReady and LastError
+		// conditions of functions.
+		statusRecorder *fnstatus.Recorder
+
+		// quarantine refuses to specialize functions whose pods crash
+		// over and over until their spec changes.
+		quarantine *quarantine.Quarantine
 	}
 
 	createFuncServiceRequest struct {
+		// ctx carries the trace of the request, the service is created
+		// with a context of its own.
+		ctx      context.Context
 		function *fv1.Function
+		priority fv1.InvocationPriority
 		respChan chan *createFuncServiceResponse
 	}
 
@@ -70,15 +103,20 @@ type (
 
 // MakeExecutor returns an Executor for given ExecutorType(s).
 func MakeExecutor(logger *zap.Logger, cms *cms.ConfigSecretController,
-	fissionClient *crd.FissionClient, types map[fv1.ExecutorType]executortype.ExecutorType) (*Executor, error) {
+	fissionClient *crd.FissionClient, types map[fv1.ExecutorType]executortype.ExecutorType,
+	specializationLimiter *qos.Limiter, statusRecorder *fnstatus.Recorder,
+	quarantine *quarantine.Quarantine) (*Executor, error) {
 	executor := &Executor{
 		logger:        logger.Named("executor"),
 		cms:           cms,
 		fissionClient: fissionClient,
 		executorTypes: types,
 
-		requestChan: make(chan *createFuncServiceRequest),
-		fsCreateWg:  make(map[string]*sync.WaitGroup),
+		requestChan:           make(chan *createFuncServiceRequest),
+		fsCreateWg:            make(map[string]*sync.WaitGroup),
+		specializationLimiter: specializationLimiter,
+		statusRecorder:        statusRecorder,
+		quarantine:            quarantine,
 	}
 	for _, et := range types {
 		go func(et executortype.ExecutorType) {
@@ -114,11 +152,11 @@ func (executor *Executor) serveCreateFuncServices() {
 					specializationTimeout = fv1.DefaultSpecializationTimeOut
 				}
 
-				fnSpecializationTimeoutContext, cancel := context.WithTimeout(context.Background(),
+				fnSpecializationTimeoutContext, cancel := context.WithTimeout(tracing.Detach(req.ctx),
 					time.Duration(specializationTimeout+buffer)*time.Second)
 				defer cancel()
 
-				fsvc, err := executor.createServiceForFunction(fnSpecializationTimeoutContext, req.function)
+				fsvc, err := executor.createServiceForFunction(fnSpecializationTimeoutContext, req.function, req.priority)
 				req.respChan <- &createFuncServiceResponse{
 					funcSvc: fsvc,
 					err:     err,
@@ -128,13 +166,13 @@ func (executor *Executor) serveCreateFuncServices() {
 		}
 
 		// Cache miss -- is this first one to request the func?
-		wg, found := executor.fsCreateWg[crd.CacheKey(fnMetadata)]
+		wg, found := executor.fsCreateWg[crd.SpecCacheKey(fnMetadata)]
 		if !found {
 			// create a waitgroup for other requests for
 			// the same function to wait on
 			wg := &sync.WaitGroup{}
 			wg.Add(1)
-			executor.fsCreateWg[crd.CacheKey(fnMetadata)] = wg
+			executor.fsCreateWg[crd.SpecCacheKey(fnMetadata)] = wg
 
 			// launch a goroutine for each request, to parallelize
 			// the specialization of different functions
@@ -157,16 +195,16 @@ func (executor *Executor) serveCreateFuncServices() {
 					specializationTimeout = fv1.DefaultSpecializationTimeOut
 				}
 
-				fnSpecializationTimeoutContext, cancel := context.WithTimeout(context.Background(),
+				fnSpecializationTimeoutContext, cancel := context.WithTimeout(tracing.Detach(req.ctx),
 					time.Duration(specializationTimeout+buffer)*time.Second)
 				defer cancel()
 
-				fsvc, err := executor.createServiceForFunction(fnSpecializationTimeoutContext, req.function)
+				fsvc, err := executor.createServiceForFunction(fnSpecializationTimeoutContext, req.function, req.priority)
 				req.respChan <- &createFuncServiceResponse{
 					funcSvc: fsvc,
 					err:     err,
 				}
-				delete(executor.fsCreateWg, crd.CacheKey(fnMetadata))
+				delete(executor.fsCreateWg, crd.SpecCacheKey(fnMetadata))
 				wg.Done()
 			}()
 		} else {
@@ -193,7 +231,7 @@ func (executor *Executor) serveCreateFuncServices() {
 	}
 }
 
-func (executor *Executor) createServiceForFunction(ctx context.Context, fn *fv1.Function) (*fscache.FuncSvc, error) {
+func (executor *Executor) createServiceForFunction(ctx context.Context, fn *fv1.Function, priority fv1.InvocationPriority) (*fscache.FuncSvc, error) {
 	executor.logger.Debug("no cached function service found, creating one",
 		zap.String("function_name", fn.ObjectMeta.Name),
 		zap.String("function_namespace", fn.ObjectMeta.Namespace))
@@ -204,7 +242,21 @@ func (executor *Executor) createServiceForFunction(ctx context.Context, fn *fv1.
 		return nil, errors.Errorf("Unknown executor type '%v'", t)
 	}
 
+	queued := time.Now()
+	release, err := executor.specializationLimiter.Acquire(ctx, priority)
+	observeSpecializationQueueWait(priority, queued)
+	if err != nil {
+		return nil, ferror.MakeError(ferror.ErrorTooManyRequests,
+			fmt.Sprintf("[%s] timed out waiting for specialization with %v priority", fn.ObjectMeta.Name, priority))
+	}
+	defer release()
+
+	ctx, span := tracing.StartSpan(ctx, "create function service",
+		attribute.String("function", fn.ObjectMeta.Name), attribute.String("executor_type", string(t)))
+	start := time.Now()
 	fsvc, fsvcErr := e.GetFuncSvc(ctx, fn)
+	observeColdStart(fn, start, fsvcErr)
+	tracing.EndSpan(span, fsvcErr)
 	if fsvcErr != nil {
 		e := "error creating service for function"
 		executor.logger.Error(e,
@@ -226,18 +278,20 @@ func (executor *Executor) getFunctionServiceFromCache(fn *fv1.Function) (*fscach
 	return e.GetFuncSvcFromCache(fn)
 }
 
-func serveMetric(logger *zap.Logger) {
+func serveMetric(logger *zap.Logger, coordinator *shutdown.Coordinator) {
 	// Expose the registered metrics via HTTP.
 	metricAddr := ":8080"
 	http.Handle("/metrics", promhttp.Handler())
-	err := http.ListenAndServe(metricAddr, nil)
-
-	logger.Fatal("done listening on metrics endpoint", zap.Error(err))
+	err := coordinator.ServeMetrics(&http.Server{Addr: metricAddr})
+	if err != nil {
+		logger.Fatal("done listening on metrics endpoint", zap.Error(err))
+	}
 }
 
 // StartExecutor Starts executor and the executor components such as Poolmgr,
-// deploymgr and potential future executor types
-func StartExecutor(logger *zap.Logger, functionNamespace string, envBuilderNamespace string, port int) error {
+// deploymgr and potential future executor types. They are stopped by the
+// coordinator on shutdown.
+func StartExecutor(logger *zap.Logger, coordinator *shutdown.Coordinator, functionNamespace string, envBuilderNamespace string, port int) error {
 	fissionClient, kubernetesClient, _, metricsClient, err := crd.MakeFissionClient()
 	if err != nil {
 		return errors.Wrap(err, "failed to get kubernetes client")
@@ -248,24 +302,47 @@ func StartExecutor(logger *zap.Logger, functionNamespace string, envBuilderNames
 		return errors.Wrap(err, "error waiting for CRDs")
 	}
 
+	// the FissionConfig overrides the settings executor was deployed with
+	configReconciler := fissionconfig.MakeReconciler(logger, fissionClient, fissionconfig.ComponentExecutor)
+	configReconciler.Load()
+
 	fetcherConfig, err := fetcherConfig.MakeFetcherConfig("/userfunc")
 	if err != nil {
 		return errors.Wrap(err, "Error making fetcher config")
 	}
 
+	if fetcherConfig.MTLS() != nil {
+		enableIstio, _ := strconv.ParseBool(os.Getenv("ENABLE_ISTIO"))
+		if enableIstio {
+			return errors.New("mTLS cannot be enabled together with Istio, which secures function traffic itself")
+		}
+	}
+
 	executorInstanceID := strings.ToLower(uniuri.NewLen(8))
 
+	deploymentHooks, err := hook.MakeDeploymentHooks(logger)
+	if err != nil {
+		return errors.Wrap(err, "error making deployment hooks")
+	}
+
+	// idle specialized pods are evicted early under node pressure if set
+	evictionPolicy, err := reaper.ParseEvictionPolicy(os.Getenv("EXECUTOR_EVICTION_MIN_IDLE"), os.Getenv("EXECUTOR_EVICTION_MAX_PODS"))
+	if err != nil {
+		logger.Error("failed to parse eviction policy from 'EXECUTOR_EVICTION_MIN_IDLE' and 'EXECUTOR_EVICTION_MAX_PODS' - idle pods are not evicted under node pressure",
+			zap.Error(err))
+	}
+
 	logger.Info("Starting executor", zap.String("instanceID", executorInstanceID))
 
 	gpm := poolmgr.MakeGenericPoolManager(
 		logger,
 		fissionClient, kubernetesClient, metricsClient,
-		functionNamespace, fetcherConfig, executorInstanceID)
+		functionNamespace, fetcherConfig, executorInstanceID, deploymentHooks, evictionPolicy)
 
 	ndm := newdeploy.MakeNewDeploy(
 		logger,
 		fissionClient, kubernetesClient, fissionClient.CoreV1().RESTClient(),
-		functionNamespace, fetcherConfig, executorInstanceID)
+		functionNamespace, fetcherConfig, executorInstanceID, deploymentHooks)
 
 	executorTypes := make(map[fv1.ExecutorType]executortype.ExecutorType)
 	executorTypes[gpm.GetTypeName()] = gpm
@@ -290,14 +367,100 @@ func StartExecutor(logger *zap.Logger, functionNamespace string, envBuilderNames
 
 	cms := cms.MakeConfigSecretController(logger, fissionClient, kubernetesClient, executorTypes)
 
-	api, err := MakeExecutor(logger, cms, fissionClient, executorTypes)
+	maxSpecializationsStr := os.Getenv("EXECUTOR_MAX_CONCURRENT_SPECIALIZATIONS")
+	maxSpecializations, err := strconv.Atoi(maxSpecializationsStr)
+	if err != nil && len(maxSpecializationsStr) > 0 {
+		logger.Error("failed to parse max concurrent specializations from 'EXECUTOR_MAX_CONCURRENT_SPECIALIZATIONS' - specializations are not limited",
+			zap.Error(err),
+			zap.String("value", maxSpecializationsStr))
+	}
+
+	// the guard cuts down the concurrent specializations while executor uses
+	// more memory or goroutines than its budget.
+	budget, err := qos.ParseBudget(os.Getenv("EXECUTOR_MEMORY_BUDGET"), os.Getenv("EXECUTOR_GOROUTINE_BUDGET"))
+	if err != nil {
+		logger.Error("failed to parse resource budget from 'EXECUTOR_MEMORY_BUDGET' and 'EXECUTOR_GOROUTINE_BUDGET' - specializations are not capped by resource usage",
+			zap.Error(err))
+	}
+	guard := qos.MakeGuard(logger.Named("guard"), "executor", budget, qos.DefaultGuardInterval)
+	if guard != nil && maxSpecializations <= 0 {
+		maxSpecializations = defaultGuardedSpecializations
+		logger.Info("resource budget set without a limit of concurrent specializations - using the default limit",
+			zap.Int("max_concurrent_specializations", maxSpecializations))
+	}
+	specializationLimiter := qos.MakeLimiter(maxSpecializations)
+	// the full limit changes with the FissionConfig, the guard scales it
+	fullCapacity := int64(maxSpecializations)
+	guard.Watch(func(level qos.Level) {
+		capacity := qos.CapacityAt(int(atomic.LoadInt64(&fullCapacity)), level)
+		specializationLimiter.SetCapacity(capacity)
+		logger.Info("changed the limit of concurrent specializations",
+			zap.Int("level", int(level)),
+			zap.Int("max_concurrent_specializations", capacity))
+	})
+	go guard.Run(coordinator.Context())
+
+	configReconciler.SetLive("EXECUTOR_MAX_CONCURRENT_SPECIALIZATIONS", func(value string) error {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		// limits can't be lifted or added to an unlimited executor
+		if specializationLimiter == nil || limit <= 0 {
+			return fissionconfig.ErrRestartRequired
+		}
+		atomic.StoreInt64(&fullCapacity, int64(limit))
+		specializationLimiter.SetCapacity(qos.CapacityAt(limit, guard.Level()))
+		return nil
+	})
+	go configReconciler.Run(coordinator.Context().Done())
+
+	statusRecorder := fnstatus.MakeRecorder(logger, fissionClient, fnstatus.DefaultInterval)
+	go statusRecorder.Run(context.Background())
+	// the conditions of specializations drained on shutdown are written last
+	coordinator.OnFlush("function status", func(ctx context.Context) error {
+		statusRecorder.Flush()
+		return nil
+	})
+
+	// functions whose pods crash over and over are quarantined, rather than
+	// specialized on and on.
+	quarantinePolicy, err := quarantine.ParsePolicy(os.Getenv("EXECUTOR_QUARANTINE_MAX_FAILURES"), os.Getenv("EXECUTOR_QUARANTINE_WINDOW"))
+	if err != nil {
+		logger.Error("failed to parse quarantine policy from 'EXECUTOR_QUARANTINE_MAX_FAILURES' and 'EXECUTOR_QUARANTINE_WINDOW' - functions are not quarantined",
+			zap.Error(err))
+	}
+	fnQuarantine := quarantine.MakeQuarantine(logger, quarantinePolicy, kubernetesClient, statusRecorder, os.Getenv("EXECUTOR_QUARANTINE_WEBHOOK_URL"))
+	go fnQuarantine.Run(coordinator.Context())
+
+	// the CPU, memory and egress of the pods of functions are added to
+	// their FunctionUsage, for fission fn top.
+	meteringIntervalStr := os.Getenv("EXECUTOR_METERING_INTERVAL")
+	meteringInterval, err := metering.ParseInterval(meteringIntervalStr)
+	if err != nil {
+		logger.Error("failed to parse metering interval from 'EXECUTOR_METERING_INTERVAL' - set to the default value",
+			zap.Error(err),
+			zap.String("value", meteringIntervalStr),
+			zap.Duration("default", meteringInterval))
+	}
+	meter := metering.MakeMeter(logger, fissionClient, kubernetesClient, metricsClient, meteringInterval)
+	go meter.Run(coordinator.Context())
+
+	api, err := MakeExecutor(logger, cms, fissionClient, executorTypes, specializationLimiter, statusRecorder, fnQuarantine)
 	if err != nil {
 		return err
 	}
 
 	go reaper.CleanupRoleBindings(logger, kubernetesClient, fissionClient, functionNamespace, envBuilderNamespace, time.Minute*30)
-	go api.Serve(port)
-	go serveMetric(logger)
+	go api.Serve(coordinator, port)
+	if mtlsConfig := fetcherConfig.MTLS(); mtlsConfig != nil {
+		tlsConfig, err := mtlsConfig.ServerTLSConfig()
+		if err != nil {
+			return errors.Wrap(err, "error loading mTLS certificate")
+		}
+		go api.ServeMTLS(coordinator, mtls.APIPort, tlsConfig)
+	}
+	go serveMetric(logger, coordinator)
 
 	return nil
 }
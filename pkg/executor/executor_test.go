@@ -42,6 +42,7 @@ import (
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/crd"
 	"github.com/fission/fission/pkg/executor/client"
+	"github.com/fission/fission/pkg/shutdown"
 )
 
 func panicIf(err error) {
@@ -173,7 +174,7 @@ func TestExecutor(t *testing.T) {
 
 	// create poolmgr
 	port := 9999
-	err = StartExecutor(logger, functionNs, "fission-builder", port)
+	err = StartExecutor(logger, shutdown.MakeCoordinator(logger), functionNs, "fission-builder", port)
 	if err != nil {
 		log.Panicf("failed to start poolmgr: %v", err)
 	}
@@ -256,7 +257,7 @@ func TestExecutor(t *testing.T) {
 
 	// the main test: get a service for a given function
 	t1 := time.Now()
-	svc, err := poolmgrClient.GetServiceForFunction(context.Background(), f)
+	svc, err := poolmgrClient.GetServiceForFunction(context.Background(), f, fv1.InvocationPriorityStandard)
 	if err != nil {
 		log.Panicf("failed to get func svc: %v", err)
 	}
@@ -22,6 +22,7 @@ import (
 	"go.uber.org/zap"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/drift"
 	"github.com/fission/fission/pkg/executor/fscache"
 )
 
@@ -64,3 +65,11 @@ type ExecutorType interface {
 	// CleanupOldExecutorObjects cleans up resources created by old executor instances
 	CleanupOldExecutorObjects()
 }
+
+// DriftDetector is implemented by executor types that can report the
+// kubernetes objects they generated which differ from what function
+// specs imply.
+type DriftDetector interface {
+	// DetectDrift returns the drifted objects, and reverts them if revert is true.
+	DetectDrift(revert bool) ([]drift.Drift, error)
+}
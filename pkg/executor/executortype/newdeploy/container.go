@@ -0,0 +1,136 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package newdeploy
+
+import (
+	"github.com/pkg/errors"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// defaultContainerPort is the port of container functions without one, the
+// port of environment containers.
+const defaultContainerPort = 8888
+
+// getEnvironment returns the environment of a function. Container functions
+// have none, they get one of their own image with the defaults.
+func (deploy *NewDeploy) getEnvironment(fn *fv1.Function) (*fv1.Environment, error) {
+	if fn.Spec.Container != nil {
+		return containerEnvironment(fn), nil
+	}
+	return deploy.fissionClient.CoreV1().
+		Environments(fn.Spec.Environment.Namespace).
+		Get(fn.Spec.Environment.Name, metav1.GetOptions{})
+}
+
+// containerEnvironment returns the environment a container function runs
+// in, with the image of the function.
+func containerEnvironment(fn *fv1.Function) *fv1.Environment {
+	return &fv1.Environment{
+		Spec: fv1.EnvironmentSpec{
+			Runtime: fv1.Runtime{
+				Image: fn.Spec.Container.Image,
+			},
+			ImagePullSecret:        fn.Spec.Container.ImagePullSecret,
+			TerminationGracePeriod: fv1.DefaultTerminationGracePeriod,
+		},
+	}
+}
+
+// containerPort returns the port a container function serves requests on.
+func containerPort(fn *fv1.Function) int32 {
+	if fn.Spec.Container.Port > 0 {
+		return fn.Spec.Container.Port
+	}
+	return defaultContainerPort
+}
+
+// functionPort returns the port the service of a function sends requests to.
+func (deploy *NewDeploy) functionPort(fn *fv1.Function) int {
+	if fn.Spec.Container != nil && deploy.fetcherConfig.MTLS() == nil {
+		return int(containerPort(fn))
+	}
+	return deploy.fetcherConfig.FunctionPort()
+}
+
+// setupContainer makes the main container of a pod run the image of a
+// container function. Without fetcher to tell when the function is ready,
+// the pod is ready once the container accepts connections, unless the
+// function has a readiness probe.
+func (deploy *NewDeploy) setupContainer(fn *fv1.Function, container *apiv1.Container) error {
+	port := containerPort(fn)
+	// with mTLS, fetcher serves function requests and passes them on to
+	// the port of environment containers.
+	if deploy.fetcherConfig.MTLS() != nil && port != defaultContainerPort {
+		return errors.Errorf("container functions must listen on port %v with mTLS, function %v listens on %v",
+			defaultContainerPort, fn.ObjectMeta.Name, port)
+	}
+
+	container.Command = fn.Spec.Container.Command
+	container.Ports[0].ContainerPort = port
+	// images may have no sleep to wait for the connections to drain with
+	container.Lifecycle = nil
+	container.ReadinessProbe = &apiv1.Probe{
+		PeriodSeconds: 1,
+		Handler: apiv1.Handler{
+			TCPSocket: &apiv1.TCPSocketAction{
+				Port: intstr.FromInt(int(port)),
+			},
+		},
+	}
+	// probes of the function are run by kubelet, as they are
+	if probes := fn.Spec.Probes; probes != nil {
+		container.StartupProbe = probes.Startup
+		container.LivenessProbe = probes.Liveness
+		if probes.Readiness != nil {
+			container.ReadinessProbe = probes.Readiness
+		}
+	}
+	return nil
+}
+
+// setupContainerPod gives the pod of a container function what fetcher
+// would give it otherwise: its secrets and configmaps, and, with mTLS,
+// fetcher itself to serve requests.
+func (deploy *NewDeploy) setupContainerPod(podSpec *apiv1.PodSpec, fn *fv1.Function) error {
+	if deploy.fetcherConfig.MTLS() != nil {
+		err := deploy.fetcherConfig.AddFetcherToPodSpec(podSpec, fn.ObjectMeta.Name)
+		if err != nil {
+			return err
+		}
+	}
+	return deploy.fetcherConfig.MountReferencedResources(podSpec, fn.ObjectMeta.Name, fn)
+}
+
+// updateSvcPort points the service of a container function to the port of
+// the container, which may change with the image.
+func (deploy *NewDeploy) updateSvcPort(fn *fv1.Function, ns string, svcName string) error {
+	svc, err := deploy.kubernetesClient.CoreV1().Services(ns).Get(svcName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	port := intstr.FromInt(deploy.functionPort(fn))
+	if len(svc.Spec.Ports) == 0 || svc.Spec.Ports[0].TargetPort == port {
+		return nil
+	}
+	svc.Spec.Ports[0].TargetPort = port
+	_, err = deploy.kubernetesClient.CoreV1().Services(ns).Update(svc)
+	return err
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package newdeploy
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	k8s_err "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8sTypes "k8s.io/apimachinery/pkg/types"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/drift"
+)
+
+// DetectDrift compares the deployments, services and HPAs of functions with
+// the ones their current specs imply. If revert is true, modified and
+// missing objects are brought back in line with the specs, and the objects
+// of functions that no longer exist are deleted.
+func (deploy *NewDeploy) DetectDrift(revert bool) ([]drift.Drift, error) {
+	fnList, err := deploy.fissionClient.CoreV1().Functions(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting function list")
+	}
+	fns := make(map[k8sTypes.UID]*fv1.Function)
+	for i := range fnList.Items {
+		fn := &fnList.Items[i]
+		if fn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType == fv1.ExecutorTypeNewdeploy {
+			fns[fn.ObjectMeta.UID] = fn
+		}
+	}
+
+	deplList, err := deploy.kubernetesClient.AppsV1().Deployments(metav1.NamespaceAll).List(metav1.ListOptions{
+		LabelSelector: labels.Set(map[string]string{fv1.EXECUTOR_TYPE: string(fv1.ExecutorTypeNewdeploy)}).AsSelector().String(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting deployment list")
+	}
+
+	var drifts []drift.Drift
+	for i := range deplList.Items {
+		depl := &deplList.Items[i]
+
+		fn, ok := fns[k8sTypes.UID(depl.ObjectMeta.Labels[fv1.FUNCTION_UID])]
+		if !ok {
+			d := drift.Drift{
+				Kind:      "Deployment",
+				Namespace: depl.ObjectMeta.Namespace,
+				Name:      depl.ObjectMeta.Name,
+				Owner:     fmt.Sprintf("function %v/%v", depl.ObjectMeta.Labels[fv1.FUNCTION_NAMESPACE], depl.ObjectMeta.Labels[fv1.FUNCTION_NAME]),
+				Reason:    drift.ReasonOrphaned,
+			}
+			if revert {
+				// deletes the service and HPA of the function as well
+				d.SetRevertResult(deploy.cleanupNewdeploy(depl.ObjectMeta.Namespace, depl.ObjectMeta.Name))
+			}
+			drifts = append(drifts, d)
+			continue
+		}
+
+		fnDrifts, err := deploy.functionDrift(fn, depl, revert)
+		if err != nil {
+			deploy.logger.Error("error detecting drift of function objects", zap.Error(err),
+				zap.String("function", fn.ObjectMeta.Name), zap.String("namespace", fn.ObjectMeta.Namespace))
+			continue
+		}
+		drifts = append(drifts, fnDrifts...)
+	}
+
+	return drifts, nil
+}
+
+// functionDrift returns the drift of the deployment, service and HPA of a function.
+func (deploy *NewDeploy) functionDrift(fn *fv1.Function, depl *appsv1.Deployment, revert bool) ([]drift.Drift, error) {
+	env, err := deploy.getEnvironment(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := fmt.Sprintf("function %v/%v", fn.ObjectMeta.Namespace, fn.ObjectMeta.Name)
+	ns := depl.ObjectMeta.Namespace
+	objName := depl.ObjectMeta.Name
+	deployLabels := deploy.getDeployLabels(fn.ObjectMeta, env.ObjectMeta)
+	deployAnnotations := deploy.getDeployAnnotations(fn.ObjectMeta)
+
+	var drifts []drift.Drift
+
+	// Replicas are left out, they are changed by HPA and the idle object reaper.
+	expectedDepl, err := deploy.getDeploymentSpec(fn, env, nil, objName, ns, deployLabels, deployAnnotations)
+	if err != nil {
+		return nil, err
+	}
+	diffs, err := drift.Diff(expectedDepl, depl, "metadata.labels", "spec.strategy", "spec.template.metadata", "spec.template.spec")
+	if err != nil {
+		return nil, err
+	}
+	if len(diffs) > 0 {
+		d := drift.Drift{Kind: "Deployment", Namespace: ns, Name: objName, Owner: owner, Reason: drift.ReasonModified, Fields: diffs}
+		if revert {
+			mergeLabels(&depl.ObjectMeta, expectedDepl.ObjectMeta.Labels)
+			depl.Spec.Strategy = expectedDepl.Spec.Strategy
+			depl.Spec.Template = expectedDepl.Spec.Template
+			d.SetRevertResult(deploy.updateDeployment(depl, ns))
+		}
+		drifts = append(drifts, d)
+	}
+
+	expectedSvc := deploy.getSvcSpec(fn, deployLabels, deployAnnotations, objName)
+	svc, err := deploy.kubernetesClient.CoreV1().Services(ns).Get(objName, metav1.GetOptions{})
+	if k8s_err.IsNotFound(err) {
+		d := drift.Drift{Kind: "Service", Namespace: ns, Name: objName, Owner: owner, Reason: drift.ReasonMissing}
+		if revert {
+			_, err = deploy.createOrGetSvc(fn, deployLabels, deployAnnotations, objName, ns)
+			d.SetRevertResult(err)
+		}
+		drifts = append(drifts, d)
+	} else if err != nil {
+		return nil, err
+	} else {
+		diffs, err = drift.Diff(expectedSvc, svc, "metadata.labels", "spec.ports", "spec.selector", "spec.type")
+		if err != nil {
+			return nil, err
+		}
+		if len(diffs) > 0 {
+			d := drift.Drift{Kind: "Service", Namespace: ns, Name: objName, Owner: owner, Reason: drift.ReasonModified, Fields: diffs}
+			if revert {
+				mergeLabels(&svc.ObjectMeta, expectedSvc.ObjectMeta.Labels)
+				svc.Spec.Ports = expectedSvc.Spec.Ports
+				svc.Spec.Selector = expectedSvc.Spec.Selector
+				svc.Spec.Type = expectedSvc.Spec.Type
+				_, err = deploy.kubernetesClient.CoreV1().Services(ns).Update(svc)
+				d.SetRevertResult(err)
+			}
+			drifts = append(drifts, d)
+		}
+	}
+
+	execStrategy := &fn.Spec.InvokeStrategy.ExecutionStrategy
+	expectedHpa := deploy.getHpaSpec(objName, execStrategy, objName, deployLabels, deployAnnotations)
+	hpa, err := deploy.getHpa(ns, objName)
+	if k8s_err.IsNotFound(err) {
+		d := drift.Drift{Kind: "HorizontalPodAutoscaler", Namespace: ns, Name: objName, Owner: owner, Reason: drift.ReasonMissing}
+		if revert {
+			_, err = deploy.createOrGetHpa(objName, execStrategy, depl, deployLabels, deployAnnotations)
+			d.SetRevertResult(err)
+		}
+		drifts = append(drifts, d)
+	} else if err != nil {
+		return nil, err
+	} else {
+		diffs, err = drift.Diff(expectedHpa, hpa,
+			"metadata.labels", "spec.scaleTargetRef", "spec.minReplicas", "spec.maxReplicas", "spec.metrics")
+		if err != nil {
+			return nil, err
+		}
+		if len(diffs) > 0 {
+			d := drift.Drift{Kind: "HorizontalPodAutoscaler", Namespace: ns, Name: objName, Owner: owner, Reason: drift.ReasonModified, Fields: diffs}
+			if revert {
+				mergeLabels(&hpa.ObjectMeta, expectedHpa.ObjectMeta.Labels)
+				hpa.Spec.ScaleTargetRef = expectedHpa.Spec.ScaleTargetRef
+				hpa.Spec.MinReplicas = expectedHpa.Spec.MinReplicas
+				hpa.Spec.MaxReplicas = expectedHpa.Spec.MaxReplicas
+				hpa.Spec.Metrics = expectedHpa.Spec.Metrics
+				d.SetRevertResult(deploy.updateHpa(hpa))
+			}
+			drifts = append(drifts, d)
+		}
+	}
+
+	return drifts, nil
+}
+
+// mergeLabels sets the expected labels on an object, keeping the labels
+// added by others.
+func mergeLabels(meta *metav1.ObjectMeta, expected map[string]string) {
+	if meta.Labels == nil {
+		meta.Labels = make(map[string]string, len(expected))
+	}
+	for k, v := range expected {
+		meta.Labels[k] = v
+	}
+}
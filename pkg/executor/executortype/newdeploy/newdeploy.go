@@ -25,7 +25,7 @@ import (
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
-	asv1 "k8s.io/api/autoscaling/v1"
+	asv2 "k8s.io/api/autoscaling/v2beta2"
 	apiv1 "k8s.io/api/core/v1"
 	k8s_err "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -34,6 +34,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/executor/hook"
 	"github.com/fission/fission/pkg/executor/util"
 	"github.com/fission/fission/pkg/utils"
 )
@@ -137,17 +138,31 @@ func (deploy *NewDeploy) setupRBACObjs(deployNamespace string, fn *fv1.Function)
 		return err
 	}
 
-	// create a cluster role binding for the fetcher SA, if not already created, granting access to do a get on packages in any ns
-	err = utils.SetupRoleBinding(deploy.logger, deploy.kubernetesClient, fv1.PackageGetterRB, fn.Spec.Package.PackageRef.Namespace, fv1.PackageGetterCR, fv1.ClusterRole, fv1.FissionFetcherSA, deployNamespace)
+	// copy the mTLS certificate for fetcher to this ns, if mTLS is enabled
+	err = deploy.fetcherConfig.SetupMTLSSecret(deploy.kubernetesClient, deployNamespace)
 	if err != nil {
-		deploy.logger.Error("error creating role binding for function",
+		deploy.logger.Error("error setting up mTLS secret for function",
 			zap.Error(err),
-			zap.String("role_binding", fv1.PackageGetterRB),
+			zap.String("namespace", deployNamespace),
 			zap.String("function_name", fn.ObjectMeta.Name),
 			zap.String("function_namespace", fn.ObjectMeta.Namespace))
 		return err
 	}
 
+	// create a cluster role binding for the fetcher SA, if not already created, granting access to do a get on packages in any ns
+	// container functions have no package to get
+	if fn.Spec.Container == nil {
+		err = utils.SetupRoleBinding(deploy.logger, deploy.kubernetesClient, fv1.PackageGetterRB, fn.Spec.Package.PackageRef.Namespace, fv1.PackageGetterCR, fv1.ClusterRole, fv1.FissionFetcherSA, deployNamespace)
+		if err != nil {
+			deploy.logger.Error("error creating role binding for function",
+				zap.Error(err),
+				zap.String("role_binding", fv1.PackageGetterRB),
+				zap.String("function_name", fn.ObjectMeta.Name),
+				zap.String("function_namespace", fn.ObjectMeta.Namespace))
+			return err
+		}
+	}
+
 	// create rolebinding in function namespace for fetcherSA.envNamespace to be able to get secrets and configmaps
 	err = utils.SetupRoleBinding(deploy.logger, deploy.kubernetesClient, fv1.SecretConfigMapGetterRB, fn.ObjectMeta.Namespace, fv1.SecretConfigMapGetterCR, fv1.ClusterRole, fv1.FissionFetcherSA, deployNamespace)
 	if err != nil {
@@ -179,6 +194,17 @@ func (deploy *NewDeploy) deleteDeployment(ns string, name string) error {
 	})
 }
 
+// getDeploymentImage returns the deployment image of the package of a
+// function, or an empty string if the package has none.
+func (deploy *NewDeploy) getDeploymentImage(fn *fv1.Function) (string, error) {
+	pkgRef := fn.Spec.Package.PackageRef
+	pkg, err := deploy.fissionClient.CoreV1().Packages(pkgRef.Namespace).Get(pkgRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "error getting package %v of function %v", pkgRef.Name, fn.ObjectMeta.Name)
+	}
+	return pkg.Spec.DeploymentImage, nil
+}
+
 func (deploy *NewDeploy) getDeploymentSpec(fn *fv1.Function, env *fv1.Environment, targetReplicas *int32,
 	deployName string, deployNamespace string, deployLabels map[string]string, deployAnnotations map[string]string) (*appsv1.Deployment, error) {
 
@@ -237,9 +263,23 @@ func (deploy *NewDeploy) getDeploymentSpec(fn *fv1.Function, env *fv1.Environmen
 		return nil, err
 	}
 
+	// functions whose package is built into an image run from it, there is
+	// no deploy archive to fetch then.
+	var deploymentImage string
+	if fn.Spec.Container == nil {
+		deploymentImage, err = deploy.getDeploymentImage(fn)
+		if err != nil {
+			return nil, err
+		}
+	}
+	image := env.Spec.Runtime.Image
+	if len(deploymentImage) > 0 {
+		image = deploymentImage
+	}
+
 	container, err := util.MergeContainer(&apiv1.Container{
 		Name:                   fn.ObjectMeta.Name,
-		Image:                  env.Spec.Runtime.Image,
+		Image:                  image,
 		ImagePullPolicy:        deploy.runtimeImagePullPolicy,
 		TerminationMessagePath: "/dev/termination-log",
 		Lifecycle: &apiv1.Lifecycle{
@@ -270,6 +310,14 @@ func (deploy *NewDeploy) getDeploymentSpec(fn *fv1.Function, env *fv1.Environmen
 	if err != nil {
 		return nil, err
 	}
+	if fn.Spec.Container != nil {
+		err = deploy.setupContainer(fn, container)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		deploy.fetcherConfig.AddProbesToContainer(container)
+	}
 
 	pod := apiv1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
@@ -280,6 +328,7 @@ func (deploy *NewDeploy) getDeploymentSpec(fn *fv1.Function, env *fv1.Environmen
 			Containers:                    []apiv1.Container{*container},
 			ServiceAccountName:            "fission-fetcher",
 			TerminationGracePeriodSeconds: &gracePeriodSeconds,
+			Affinity:                      util.CoLocationAffinity(fn, deployNamespace),
 		},
 	}
 
@@ -309,24 +358,45 @@ func (deploy *NewDeploy) getDeploymentSpec(fn *fv1.Function, env *fv1.Environmen
 	}
 
 	// Order of merging is important here - first fetcher, then containers and lastly pod spec
-	err = deploy.fetcherConfig.AddSpecializingFetcherToPodSpec(
-		&deployment.Spec.Template.Spec,
-		fn.ObjectMeta.Name,
-		fn,
-		env,
-	)
+	if fn.Spec.Container != nil {
+		err = deploy.setupContainerPod(&deployment.Spec.Template.Spec, fn)
+	} else {
+		addFetcher := deploy.fetcherConfig.AddSpecializingFetcherToPodSpec
+		if len(deploymentImage) > 0 {
+			addFetcher = deploy.fetcherConfig.AddImageSpecializingFetcherToPodSpec
+		}
+		err = addFetcher(
+			&deployment.Spec.Template.Spec,
+			fn.ObjectMeta.Name,
+			fn,
+			env,
+		)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	if env.Spec.Runtime.PodSpec != nil {
-		newPodSpec, err := util.MergePodSpec(&deployment.Spec.Template.Spec, env.Spec.Runtime.PodSpec)
+	// the pod spec of the function is merged last to override the one of its environment
+	for _, podSpec := range []*apiv1.PodSpec{env.Spec.Runtime.PodSpec, fn.Spec.PodSpec} {
+		if podSpec == nil {
+			continue
+		}
+		newPodSpec, err := util.StrategicMergePodSpec(&deployment.Spec.Template.Spec, podSpec)
 		if err != nil {
 			return nil, err
 		}
 		deployment.Spec.Template.Spec = *newPodSpec
 	}
 
+	err = deploy.deploymentHooks.MutateDeployment(&hook.Target{
+		ExecutorType: fv1.ExecutorTypeNewdeploy,
+		Environment:  env,
+		Function:     fn,
+	}, deployment)
+	if err != nil {
+		return nil, err
+	}
+
 	return deployment, nil
 }
 
@@ -365,48 +435,22 @@ func (deploy *NewDeploy) getResources(env *fv1.Environment, fn *fv1.Function) ap
 }
 
 func (deploy *NewDeploy) createOrGetHpa(hpaName string, execStrategy *fv1.ExecutionStrategy,
-	depl *appsv1.Deployment, deployLabels map[string]string, deployAnnotations map[string]string) (*asv1.HorizontalPodAutoscaler, error) {
+	depl *appsv1.Deployment, deployLabels map[string]string, deployAnnotations map[string]string) (*asv2.HorizontalPodAutoscaler, error) {
 
 	if depl == nil {
 		return nil, errors.New("failed to create HPA, found empty deployment")
 	}
 
-	minRepl := int32(execStrategy.MinScale)
-	if minRepl == 0 {
-		minRepl = 1
-	}
-	maxRepl := int32(execStrategy.MaxScale)
-	if maxRepl == 0 {
-		maxRepl = minRepl
-	}
-	targetCPU := int32(execStrategy.TargetCPUPercent)
+	hpa := deploy.getHpaSpec(hpaName, execStrategy, depl.ObjectMeta.Name, deployLabels, deployAnnotations)
 
-	hpa := &asv1.HorizontalPodAutoscaler{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:        hpaName,
-			Labels:      deployLabels,
-			Annotations: deployAnnotations,
-		},
-		Spec: asv1.HorizontalPodAutoscalerSpec{
-			ScaleTargetRef: asv1.CrossVersionObjectReference{
-				Kind:       DeploymentKind,
-				Name:       depl.ObjectMeta.Name,
-				APIVersion: DeploymentVersion,
-			},
-			MinReplicas:                    &minRepl,
-			MaxReplicas:                    maxRepl,
-			TargetCPUUtilizationPercentage: &targetCPU,
-		},
-	}
-
-	existingHpa, err := deploy.kubernetesClient.AutoscalingV1().HorizontalPodAutoscalers(depl.ObjectMeta.Namespace).Get(hpaName, metav1.GetOptions{})
+	existingHpa, err := deploy.kubernetesClient.AutoscalingV2beta2().HorizontalPodAutoscalers(depl.ObjectMeta.Namespace).Get(hpaName, metav1.GetOptions{})
 	if err == nil {
 		// to adopt orphan service
 		if existingHpa.Annotations[fv1.EXECUTOR_INSTANCEID_LABEL] != deploy.instanceID {
 			existingHpa.Annotations = hpa.Annotations
 			existingHpa.Labels = hpa.Labels
 			existingHpa.Spec = hpa.Spec
-			existingHpa, err = deploy.kubernetesClient.AutoscalingV1().HorizontalPodAutoscalers(depl.ObjectMeta.Namespace).Update(existingHpa)
+			existingHpa, err = deploy.kubernetesClient.AutoscalingV2beta2().HorizontalPodAutoscalers(depl.ObjectMeta.Namespace).Update(existingHpa)
 			if err != nil {
 				deploy.logger.Warn("error adopting HPA", zap.Error(err),
 					zap.String("HPA", hpaName), zap.String("ns", depl.ObjectMeta.Namespace))
@@ -415,10 +459,10 @@ func (deploy *NewDeploy) createOrGetHpa(hpaName string, execStrategy *fv1.Execut
 		}
 		return existingHpa, err
 	} else if k8s_err.IsNotFound(err) {
-		cHpa, err := deploy.kubernetesClient.AutoscalingV1().HorizontalPodAutoscalers(depl.ObjectMeta.Namespace).Create(hpa)
+		cHpa, err := deploy.kubernetesClient.AutoscalingV2beta2().HorizontalPodAutoscalers(depl.ObjectMeta.Namespace).Create(hpa)
 		if err != nil {
 			if k8s_err.IsAlreadyExists(err) {
-				cHpa, err = deploy.kubernetesClient.AutoscalingV1().HorizontalPodAutoscalers(depl.ObjectMeta.Namespace).Get(hpaName, metav1.GetOptions{})
+				cHpa, err = deploy.kubernetesClient.AutoscalingV2beta2().HorizontalPodAutoscalers(depl.ObjectMeta.Namespace).Get(hpaName, metav1.GetOptions{})
 			}
 			if err != nil {
 				return nil, err
@@ -429,21 +473,99 @@ func (deploy *NewDeploy) createOrGetHpa(hpaName string, execStrategy *fv1.Execut
 	return nil, err
 }
 
-func (deploy *NewDeploy) getHpa(ns, name string) (*asv1.HorizontalPodAutoscaler, error) {
-	return deploy.kubernetesClient.AutoscalingV1().HorizontalPodAutoscalers(ns).Get(name, metav1.GetOptions{})
+func (deploy *NewDeploy) getHpa(ns, name string) (*asv2.HorizontalPodAutoscaler, error) {
+	return deploy.kubernetesClient.AutoscalingV2beta2().HorizontalPodAutoscalers(ns).Get(name, metav1.GetOptions{})
 }
 
-func (deploy *NewDeploy) updateHpa(hpa *asv1.HorizontalPodAutoscaler) error {
-	_, err := deploy.kubernetesClient.AutoscalingV1().HorizontalPodAutoscalers(hpa.ObjectMeta.Namespace).Update(hpa)
+func (deploy *NewDeploy) updateHpa(hpa *asv2.HorizontalPodAutoscaler) error {
+	_, err := deploy.kubernetesClient.AutoscalingV2beta2().HorizontalPodAutoscalers(hpa.ObjectMeta.Namespace).Update(hpa)
 	return err
 }
 
 func (deploy *NewDeploy) deleteHpa(ns string, name string) error {
-	return deploy.kubernetesClient.AutoscalingV1().HorizontalPodAutoscalers(ns).Delete(name, &metav1.DeleteOptions{})
+	return deploy.kubernetesClient.AutoscalingV2beta2().HorizontalPodAutoscalers(ns).Delete(name, &metav1.DeleteOptions{})
+}
+
+func (deploy *NewDeploy) getHpaSpec(hpaName string, execStrategy *fv1.ExecutionStrategy,
+	deployName string, deployLabels map[string]string, deployAnnotations map[string]string) *asv2.HorizontalPodAutoscaler {
+
+	minRepl := int32(execStrategy.MinScale)
+	if minRepl == 0 {
+		minRepl = 1
+	}
+	maxRepl := int32(execStrategy.MaxScale)
+	if maxRepl == 0 {
+		maxRepl = minRepl
+	}
+
+	return &asv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        hpaName,
+			Labels:      deployLabels,
+			Annotations: deployAnnotations,
+		},
+		Spec: asv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: asv2.CrossVersionObjectReference{
+				Kind:       DeploymentKind,
+				Name:       deployName,
+				APIVersion: DeploymentVersion,
+			},
+			MinReplicas: &minRepl,
+			MaxReplicas: maxRepl,
+			Metrics:     hpaMetrics(execStrategy, deployLabels),
+		},
+	}
+}
+
+// hpaMetrics returns the metrics the HPA scales the function on. Functions
+// only setting a target CPU percent keep scaling on CPU utilization like
+// they did with autoscaling/v1. A target concurrency per pod adds the
+// in-flight requests metric exposed by router, which is read from the
+// external metrics API and so requires an adapter (e.g. prometheus-adapter)
+// exposing "fission_function_inflight_requests".
+func hpaMetrics(execStrategy *fv1.ExecutionStrategy, deployLabels map[string]string) []asv2.MetricSpec {
+	var metrics []asv2.MetricSpec
+
+	if execStrategy.TargetCPUPercent > 0 {
+		targetCPU := int32(execStrategy.TargetCPUPercent)
+		metrics = append(metrics, asv2.MetricSpec{
+			Type: asv2.ResourceMetricSourceType,
+			Resource: &asv2.ResourceMetricSource{
+				Name: apiv1.ResourceCPU,
+				Target: asv2.MetricTarget{
+					Type:               asv2.UtilizationMetricType,
+					AverageUtilization: &targetCPU,
+				},
+			},
+		})
+	}
+
+	if execStrategy.TargetConcurrencyPerPod > 0 {
+		metrics = append(metrics, asv2.MetricSpec{
+			Type: asv2.ExternalMetricSourceType,
+			External: &asv2.ExternalMetricSource{
+				Metric: asv2.MetricIdentifier{
+					Name: fv1.FunctionInflightRequestsMetric,
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							"namespace": deployLabels[fv1.FUNCTION_NAMESPACE],
+							"name":      deployLabels[fv1.FUNCTION_NAME],
+						},
+					},
+				},
+				Target: asv2.MetricTarget{
+					Type:         asv2.AverageValueMetricType,
+					AverageValue: resource.NewQuantity(int64(execStrategy.TargetConcurrencyPerPod), resource.DecimalSI),
+				},
+			},
+		})
+	}
+
+	return metrics
 }
 
-func (deploy *NewDeploy) createOrGetSvc(deployLabels map[string]string, deployAnnotations map[string]string, svcName string, svcNamespace string) (*apiv1.Service, error) {
-	service := &apiv1.Service{
+func (deploy *NewDeploy) getSvcSpec(fn *fv1.Function, deployLabels map[string]string, deployAnnotations map[string]string, svcName string) *apiv1.Service {
+	return &apiv1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        svcName,
 			Labels:      deployLabels,
@@ -454,13 +576,17 @@ func (deploy *NewDeploy) createOrGetSvc(deployLabels map[string]string, deployAn
 				{
 					Name:       "http-env",
 					Port:       int32(80),
-					TargetPort: intstr.FromInt(8888),
+					TargetPort: intstr.FromInt(deploy.functionPort(fn)),
 				},
 			},
 			Selector: deployLabels,
 			Type:     apiv1.ServiceTypeClusterIP,
 		},
 	}
+}
+
+func (deploy *NewDeploy) createOrGetSvc(fn *fv1.Function, deployLabels map[string]string, deployAnnotations map[string]string, svcName string, svcNamespace string) (*apiv1.Service, error) {
+	service := deploy.getSvcSpec(fn, deployLabels, deployAnnotations, svcName)
 
 	existingSvc, err := deploy.kubernetesClient.CoreV1().Services(svcNamespace).Get(svcName, metav1.GetOptions{})
 	if err == nil {
@@ -0,0 +1,172 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package newdeploy
+
+import (
+	"reflect"
+	"testing"
+
+	asv2 "k8s.io/api/autoscaling/v2beta2"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	fetcherConfig "github.com/fission/fission/pkg/fetcher/config"
+)
+
+func TestHpaMetrics(t *testing.T) {
+	labels := map[string]string{
+		fv1.FUNCTION_NAMESPACE: "ns",
+		fv1.FUNCTION_NAME:      "hello",
+	}
+	cpu := func(percent int32) asv2.MetricSpec {
+		return asv2.MetricSpec{
+			Type: asv2.ResourceMetricSourceType,
+			Resource: &asv2.ResourceMetricSource{
+				Name: apiv1.ResourceCPU,
+				Target: asv2.MetricTarget{
+					Type:               asv2.UtilizationMetricType,
+					AverageUtilization: &percent,
+				},
+			},
+		}
+	}
+	inflight := func(target int64) asv2.MetricSpec {
+		return asv2.MetricSpec{
+			Type: asv2.ExternalMetricSourceType,
+			External: &asv2.ExternalMetricSource{
+				Metric: asv2.MetricIdentifier{
+					Name: fv1.FunctionInflightRequestsMetric,
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"namespace": "ns", "name": "hello"},
+					},
+				},
+				Target: asv2.MetricTarget{
+					Type:         asv2.AverageValueMetricType,
+					AverageValue: resource.NewQuantity(target, resource.DecimalSI),
+				},
+			},
+		}
+	}
+
+	for _, test := range []struct {
+		name     string
+		strategy fv1.ExecutionStrategy
+		want     []asv2.MetricSpec
+	}{
+		{
+			name:     "no targets leave metrics to kubernetes",
+			strategy: fv1.ExecutionStrategy{},
+		},
+		{
+			name:     "cpu only keeps legacy cpu utilization",
+			strategy: fv1.ExecutionStrategy{TargetCPUPercent: 80},
+			want:     []asv2.MetricSpec{cpu(80)},
+		},
+		{
+			name:     "concurrency only scales on in-flight requests",
+			strategy: fv1.ExecutionStrategy{TargetConcurrencyPerPod: 5},
+			want:     []asv2.MetricSpec{inflight(5)},
+		},
+		{
+			name:     "cpu and concurrency",
+			strategy: fv1.ExecutionStrategy{TargetCPUPercent: 60, TargetConcurrencyPerPod: 10},
+			want:     []asv2.MetricSpec{cpu(60), inflight(10)},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := hpaMetrics(&test.strategy, labels)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("hpaMetrics() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetHpaSpec(t *testing.T) {
+	deploy := &NewDeploy{}
+	for _, test := range []struct {
+		name    string
+		min     int
+		max     int
+		wantMin int32
+		wantMax int32
+	}{
+		{name: "defaults to a single replica", wantMin: 1, wantMax: 1},
+		{name: "max defaults to min", min: 3, wantMin: 3, wantMax: 3},
+		{name: "min and max", min: 2, max: 6, wantMin: 2, wantMax: 6},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			strategy := &fv1.ExecutionStrategy{MinScale: test.min, MaxScale: test.max, TargetCPUPercent: 80}
+			hpa := deploy.getHpaSpec("hpa", strategy, "depl", nil, nil)
+			if *hpa.Spec.MinReplicas != test.wantMin || hpa.Spec.MaxReplicas != test.wantMax {
+				t.Errorf("replicas = (%v, %v), want (%v, %v)",
+					*hpa.Spec.MinReplicas, hpa.Spec.MaxReplicas, test.wantMin, test.wantMax)
+			}
+			if hpa.Spec.ScaleTargetRef.Name != "depl" || hpa.Spec.ScaleTargetRef.Kind != DeploymentKind {
+				t.Errorf("scaleTargetRef = %+v, want deployment depl", hpa.Spec.ScaleTargetRef)
+			}
+			if len(hpa.Spec.Metrics) != 1 || hpa.Spec.Metrics[0].Type != asv2.ResourceMetricSourceType {
+				t.Errorf("metrics = %+v, want cpu utilization", hpa.Spec.Metrics)
+			}
+		})
+	}
+}
+
+func TestSetupContainer(t *testing.T) {
+	deploy := &NewDeploy{fetcherConfig: &fetcherConfig.Config{}}
+	fn := &fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello"},
+		Spec: fv1.FunctionSpec{
+			Container: &fv1.FunctionContainer{
+				Image:   "ghcr.io/acme/hello:1.0",
+				Command: []string{"/hello", "-v"},
+				Port:    8080,
+			},
+		},
+	}
+
+	env := containerEnvironment(fn)
+	if env.Spec.Runtime.Image != fn.Spec.Container.Image {
+		t.Errorf("environment image = %v, want %v", env.Spec.Runtime.Image, fn.Spec.Container.Image)
+	}
+
+	container := &apiv1.Container{
+		Name:      "hello",
+		Lifecycle: &apiv1.Lifecycle{},
+		Ports:     []apiv1.ContainerPort{{Name: "http-env", ContainerPort: 8888}},
+	}
+	if err := deploy.setupContainer(fn, container); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(container.Command, fn.Spec.Container.Command) {
+		t.Errorf("command = %v, want %v", container.Command, fn.Spec.Container.Command)
+	}
+	if container.Ports[0].ContainerPort != 8080 || deploy.functionPort(fn) != 8080 {
+		t.Errorf("ports = (%v, %v), want 8080", container.Ports[0].ContainerPort, deploy.functionPort(fn))
+	}
+	if container.Lifecycle != nil || container.ReadinessProbe == nil || container.ReadinessProbe.TCPSocket == nil {
+		t.Errorf("expected TCP readiness probe and no preStop hook, got %+v", container)
+	}
+
+	// the port defaults to the one of environments
+	fn.Spec.Container.Port = 0
+	if deploy.functionPort(fn) != defaultContainerPort {
+		t.Errorf("port = %v, want %v", deploy.functionPort(fn), defaultContainerPort)
+	}
+}
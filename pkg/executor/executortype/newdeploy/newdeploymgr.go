@@ -19,7 +19,9 @@ package newdeploy
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -43,7 +45,9 @@ import (
 	"github.com/fission/fission/pkg/crd"
 	"github.com/fission/fission/pkg/executor/executortype"
 	"github.com/fission/fission/pkg/executor/fscache"
+	"github.com/fission/fission/pkg/executor/hook"
 	"github.com/fission/fission/pkg/executor/reaper"
+	"github.com/fission/fission/pkg/executor/util"
 	fetcherConfig "github.com/fission/fission/pkg/fetcher/config"
 	"github.com/fission/fission/pkg/throttler"
 	"github.com/fission/fission/pkg/utils"
@@ -61,6 +65,7 @@ type (
 		crdClient        rest.Interface
 		instanceID       string
 		fetcherConfig    *fetcherConfig.Config
+		deploymentHooks  *hook.DeploymentHooks
 
 		runtimeImagePullPolicy apiv1.PullPolicy
 		namespace              string
@@ -88,6 +93,7 @@ func MakeNewDeploy(
 	namespace string,
 	fetcherConfig *fetcherConfig.Config,
 	instanceID string,
+	deploymentHooks *hook.DeploymentHooks,
 ) executortype.ExecutorType {
 	enableIstio := false
 	if len(os.Getenv("ENABLE_ISTIO")) > 0 {
@@ -111,6 +117,7 @@ func MakeNewDeploy(
 		throttler: throttler.MakeThrottler(1 * time.Minute),
 
 		fetcherConfig:          fetcherConfig,
+		deploymentHooks:        deploymentHooks,
 		runtimeImagePullPolicy: utils.GetImagePullPolicy(os.Getenv("RUNTIME_IMAGE_PULL_POLICY")),
 		useIstio:               enableIstio,
 
@@ -184,8 +191,12 @@ func (deploy *NewDeploy) TapService(svcHost string) error {
 // scale deployment to 1 replica if there are no available replicas for function.
 // Return true if no error occurs, return false otherwise.
 func (deploy *NewDeploy) IsValid(fsvc *fscache.FuncSvc) bool {
-	service := strings.Split(fsvc.Address, ".")
-	if len(service) == 0 {
+	host := fsvc.Address
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	service := strings.Split(host, ".")
+	if len(service) < 2 {
 		return false
 	}
 
@@ -223,7 +234,7 @@ func (deploy *NewDeploy) IsValid(fsvc *fscache.FuncSvc) bool {
 // RefreshFuncPods deleted pods related to the function so that new pods are replenished
 func (deploy *NewDeploy) RefreshFuncPods(logger *zap.Logger, f fv1.Function) error {
 
-	env, err := deploy.fissionClient.CoreV1().Environments(f.Spec.Environment.Namespace).Get(f.Spec.Environment.Name, metav1.GetOptions{})
+	env, err := deploy.getEnvironment(&f)
 	if err != nil {
 		return err
 	}
@@ -456,9 +467,7 @@ func (deploy *NewDeploy) deleteFunction(fn *fv1.Function) error {
 }
 
 func (deploy *NewDeploy) fnCreate(fn *fv1.Function) (*fscache.FuncSvc, error) {
-	env, err := deploy.fissionClient.CoreV1().
-		Environments(fn.Spec.Environment.Namespace).
-		Get(fn.Spec.Environment.Name, metav1.GetOptions{})
+	env, err := deploy.getEnvironment(fn)
 	if err != nil {
 		return nil, err
 	}
@@ -479,13 +488,17 @@ func (deploy *NewDeploy) fnCreate(fn *fv1.Function) (*fscache.FuncSvc, error) {
 	// Since newdeploy waits for pods of deployment to be ready,
 	// change the order of kubeObject creation (create service first,
 	// then deployment) to take advantage of waiting time.
-	svc, err := deploy.createOrGetSvc(deployLabels, deployAnnotations, objName, ns)
+	svc, err := deploy.createOrGetSvc(fn, deployLabels, deployAnnotations, objName, ns)
 	if err != nil {
 		deploy.logger.Error("error creating service", zap.Error(err), zap.String("service", objName))
 		go deploy.cleanupNewdeploy(ns, objName) //nolint: errcheck
 		return nil, errors.Wrapf(err, "error creating service %v", objName)
 	}
 	svcAddress := fmt.Sprintf("%v.%v", svc.Name, svc.Namespace)
+	if deploy.fetcherConfig.MTLS() != nil {
+		// the default port of https is not the service port
+		svcAddress = fmt.Sprintf("%v:80", svcAddress)
+	}
 
 	depl, err := deploy.createOrGetDeployment(fn, env, objName, deployLabels, deployAnnotations, ns)
 	if err != nil {
@@ -551,7 +564,8 @@ func (deploy *NewDeploy) fnCreate(fn *fv1.Function) (*fscache.FuncSvc, error) {
 
 func (deploy *NewDeploy) updateFunction(oldFn *fv1.Function, newFn *fv1.Function) error {
 
-	if oldFn.ObjectMeta.ResourceVersion == newFn.ObjectMeta.ResourceVersion {
+	// status updates leave the resources of the function as they are
+	if !crd.SpecChanged(&oldFn.ObjectMeta, &newFn.ObjectMeta) {
 		return nil
 	}
 
@@ -619,9 +633,9 @@ func (deploy *NewDeploy) updateFunction(oldFn *fv1.Function, newFn *fv1.Function
 			hpaChanged = true
 		}
 
-		if newFn.Spec.InvokeStrategy.ExecutionStrategy.TargetCPUPercent != oldFn.Spec.InvokeStrategy.ExecutionStrategy.TargetCPUPercent {
-			targetCpupercent := int32(newFn.Spec.InvokeStrategy.ExecutionStrategy.TargetCPUPercent)
-			hpa.Spec.TargetCPUUtilizationPercentage = &targetCpupercent
+		if newFn.Spec.InvokeStrategy.ExecutionStrategy.TargetCPUPercent != oldFn.Spec.InvokeStrategy.ExecutionStrategy.TargetCPUPercent ||
+			newFn.Spec.InvokeStrategy.ExecutionStrategy.TargetConcurrencyPerPod != oldFn.Spec.InvokeStrategy.ExecutionStrategy.TargetConcurrencyPerPod {
+			hpa.Spec.Metrics = hpaMetrics(&newFn.Spec.InvokeStrategy.ExecutionStrategy, hpa.ObjectMeta.Labels)
 			hpaChanged = true
 		}
 
@@ -636,7 +650,12 @@ func (deploy *NewDeploy) updateFunction(oldFn *fv1.Function, newFn *fv1.Function
 
 	if oldFn.Spec.Environment != newFn.Spec.Environment ||
 		oldFn.Spec.Package.PackageRef != newFn.Spec.Package.PackageRef ||
-		oldFn.Spec.Package.FunctionName != newFn.Spec.Package.FunctionName {
+		oldFn.Spec.Package.FunctionName != newFn.Spec.Package.FunctionName ||
+		!reflect.DeepEqual(oldFn.Spec.Container, newFn.Spec.Container) ||
+		!reflect.DeepEqual(oldFn.Spec.Probes, newFn.Spec.Probes) ||
+		!reflect.DeepEqual(oldFn.Spec.PodSpec, newFn.Spec.PodSpec) ||
+		!reflect.DeepEqual(oldFn.Spec.ExternalSecrets, newFn.Spec.ExternalSecrets) ||
+		!reflect.DeepEqual(oldFn.Spec.ConfigReload, newFn.Spec.ConfigReload) {
 		deployChanged = true
 	}
 
@@ -663,8 +682,7 @@ func (deploy *NewDeploy) updateFunction(oldFn *fv1.Function, newFn *fv1.Function
 	}
 
 	if deployChanged {
-		env, err := deploy.fissionClient.CoreV1().Environments(newFn.Spec.Environment.Namespace).
-			Get(newFn.Spec.Environment.Name, metav1.GetOptions{})
+		env, err := deploy.getEnvironment(newFn)
 		if err != nil {
 			deploy.updateStatus(oldFn, err, "failed to get environment while updating function")
 			return err
@@ -717,6 +735,14 @@ func (deploy *NewDeploy) updateFuncDeployment(fn *fv1.Function, env *fv1.Environ
 		return err
 	}
 
+	if fn.Spec.Container != nil {
+		err = deploy.updateSvcPort(fn, ns, fnObjName)
+		if err != nil {
+			deploy.updateStatus(fn, err, "failed to update service while updating function")
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -757,9 +783,7 @@ func (deploy *NewDeploy) fnDelete(fn *fv1.Function) error {
 
 // getObjName returns a unique name for kubernetes objects of function
 func (deploy *NewDeploy) getObjName(fn *fv1.Function) string {
-	// use meta uuid of function, this ensure we always get the same name for the same function.
-	uid := fn.ObjectMeta.UID[len(fn.ObjectMeta.UID)-17:]
-	return strings.ToLower(fmt.Sprintf("newdeploy-%v-%v-%v", fn.ObjectMeta.Name, fn.ObjectMeta.Namespace, uid))
+	return util.NewdeployObjName(fn)
 }
 
 func (deploy *NewDeploy) getDeployLabels(fnMeta metav1.ObjectMeta, envMeta metav1.ObjectMeta) map[string]string {
@@ -819,7 +843,8 @@ func (deploy *NewDeploy) idleObjectReaper() {
 
 			// For function with the environment that no longer exists, executor
 			// scales down the deployment as usual and prints log to notify user.
-			if _, ok := envList[fsvc.Environment.ObjectMeta.UID]; !ok {
+			// Container functions have no environment.
+			if _, ok := envList[fsvc.Environment.ObjectMeta.UID]; !ok && len(fsvc.Environment.ObjectMeta.Name) > 0 {
 				deploy.logger.Error("function environment no longer exists",
 					zap.String("environment", fsvc.Environment.ObjectMeta.Name),
 					zap.String("function", fsvc.Name))
@@ -166,7 +166,7 @@ func (gpm *GenericPoolManager) makeFuncController(fissionClient *crd.FissionClie
 				oldFunc := oldObj.(*fv1.Function)
 				newFunc := newObj.(*fv1.Function)
 
-				if oldFunc.ObjectMeta.ResourceVersion == newFunc.ObjectMeta.ResourceVersion {
+				if !crd.SpecChanged(&oldFunc.ObjectMeta, &newFunc.ObjectMeta) {
 					return
 				}
 
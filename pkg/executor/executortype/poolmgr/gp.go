@@ -18,11 +18,13 @@ package poolmgr
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"math"
 	"net"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -32,6 +34,7 @@ import (
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	apiv1 "k8s.io/api/core/v1"
 	k8sErrs "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -48,9 +51,11 @@ import (
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/crd"
 	"github.com/fission/fission/pkg/executor/fscache"
+	"github.com/fission/fission/pkg/executor/hook"
 	"github.com/fission/fission/pkg/executor/util"
 	fetcherClient "github.com/fission/fission/pkg/fetcher/client"
 	fetcherConfig "github.com/fission/fission/pkg/fetcher/config"
+	"github.com/fission/fission/pkg/mtls"
 )
 
 type (
@@ -71,7 +76,10 @@ type (
 		metricsClient            *metricsclient.Clientset
 		fissionClient            *crd.FissionClient
 		fetcherConfig            *fetcherConfig.Config
+		fetcherTLSConfig         *tls.Config // set if fetcher serves specialization requests with mTLS
+		deploymentHooks          *hook.DeploymentHooks
 		stopReadyPodControllerCh chan struct{}
+		rolloutStopCh            chan struct{} // closed when the pool is replaced by a gradual rollout
 		readyPodController       cache.Controller
 		readyPodIndexer          cache.Indexer
 		readyPodQueue            workqueue.DelayingInterface
@@ -94,7 +102,8 @@ func MakeGenericPool(
 	fsCache *fscache.FunctionServiceCache,
 	fetcherConfig *fetcherConfig.Config,
 	instanceID string,
-	enableIstio bool) (*GenericPool, error) {
+	enableIstio bool,
+	deploymentHooks *hook.DeploymentHooks) (*GenericPool, error) {
 
 	gpLogger := logger.Named("generic_pool")
 
@@ -124,9 +133,11 @@ func MakeGenericPool(
 		podReadyTimeout:          podReadyTimeout,
 		fsCache:                  fsCache,
 		fetcherConfig:            fetcherConfig,
+		deploymentHooks:          deploymentHooks,
 		useSvc:                   false,       // defaults off -- svc takes a second or more to become routable, slowing cold start
 		useIstio:                 enableIstio, // defaults off -- istio integration requires pod relabeling and it takes a second or more to become routable, slowing cold start
 		stopReadyPodControllerCh: make(chan struct{}),
+		rolloutStopCh:            make(chan struct{}),
 		poolInstanceID:           uniuri.NewLen(8),
 		instanceID:               instanceID,
 		podFSVCMap:               sync.Map{},
@@ -140,6 +151,17 @@ func MakeGenericPool(
 		return nil, errors.Wrapf(err, "error creating fetcher service account in namespace %q", gp.namespace)
 	}
 
+	if mtlsConfig := fetcherConfig.MTLS(); mtlsConfig != nil {
+		err = fetcherConfig.SetupMTLSSecret(gp.kubernetesClient, gp.namespace)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error setting up mTLS secret in namespace %q", gp.namespace)
+		}
+		gp.fetcherTLSConfig, err = mtlsConfig.ClientTLSConfig()
+		if err != nil {
+			return nil, errors.Wrap(err, "error loading mTLS certificate")
+		}
+	}
+
 	// Labels for generic deployment/RS/pods.
 	//gp.labelsForPool = gp.getDeployLabels()
 
@@ -152,6 +174,7 @@ func MakeGenericPool(
 
 	go gp.startReadyPodController()
 	go gp.updateCPUUtilizationSvc()
+	go gp.reportUtilization()
 	return gp, nil
 }
 
@@ -201,10 +224,16 @@ func (gp *GenericPool) updateCPUUtilizationSvc() {
 }
 
 // choosePod picks a ready pod from the pool and relabels it, waiting if necessary.
+// It prefers pods running on preferredNodes, if any of the ready pods does.
 // returns the key and pod API object.
-func (gp *GenericPool) choosePod(newLabels map[string]string) (string, *apiv1.Pod, error) {
+func (gp *GenericPool) choosePod(newLabels map[string]string, preferredNodes map[string]bool) (string, *apiv1.Pod, error) {
 	startTime := time.Now()
 	expoDelay := 100 * time.Millisecond
+	// skip up to all the pods queued right now looking for one on a preferred node
+	skips := 0
+	if len(preferredNodes) > 0 {
+		skips = gp.readyPodQueue.Len()
+	}
 	for {
 		// Retries took too long, error out.
 		if time.Since(startTime) > gp.podReadyTimeout {
@@ -241,6 +270,14 @@ func (gp *GenericPool) choosePod(newLabels map[string]string) (string, *apiv1.Po
 			expoDelay *= 2
 			continue
 		}
+
+		if skips > 0 && !preferredNodes[obj.(*apiv1.Pod).Spec.NodeName] {
+			gp.logger.Debug("pod not on a preferred node, looking for another one", zap.String("key", key))
+			skips--
+			gp.readyPodQueue.Done(key)
+			gp.readyPodQueue.Add(key)
+			continue
+		}
 		chosenPod = obj.(*apiv1.Pod).DeepCopy()
 
 		if gp.env.Spec.AllowedFunctionsPerContainer != fv1.AllowedFunctionsPerContainerInfinite {
@@ -289,6 +326,31 @@ func (gp *GenericPool) choosePod(newLabels map[string]string) (string, *apiv1.Po
 	}
 }
 
+// coLocationNodes returns the nodes running pods of the functions the
+// function is co-located with.
+func (gp *GenericPool) coLocationNodes(fn *fv1.Function) map[string]bool {
+	selector := util.CoLocationSelector(fn)
+	if selector == nil {
+		return nil
+	}
+	podList, err := gp.kubernetesClient.CoreV1().Pods(gp.namespace).List(metav1.ListOptions{
+		LabelSelector: metav1.FormatLabelSelector(selector),
+	})
+	if err != nil {
+		// co-location is a hint only, go on without it
+		gp.logger.Error("error listing pods of co-located functions", zap.Error(err),
+			zap.String("function", fn.ObjectMeta.Name))
+		return nil
+	}
+	nodes := make(map[string]bool)
+	for _, pod := range podList.Items {
+		if len(pod.Spec.NodeName) > 0 && pod.Status.Phase == apiv1.PodRunning {
+			nodes[pod.Spec.NodeName] = true
+		}
+	}
+	return nodes
+}
+
 func (gp *GenericPool) labelsForFunction(metadata *metav1.ObjectMeta) map[string]string {
 	label := gp.getEnvironmentPoolLabels()
 	label[fv1.FUNCTION_NAME] = metadata.Name
@@ -332,13 +394,18 @@ func (gp *GenericPool) getFetcherURL(podIP string) string {
 		return testURL
 	}
 
+	scheme, port := "http", 8000
+	if mtlsConfig := gp.fetcherConfig.MTLS(); mtlsConfig != nil {
+		scheme, port = mtlsConfig.Scheme(), mtls.APIPort
+	}
+
 	isv6 := IsIPv6(podIP)
 	var baseURL string
 
 	if isv6 { // We use bracket if the IP is in IPv6.
-		baseURL = fmt.Sprintf("http://[%v]:8000/", podIP)
+		baseURL = fmt.Sprintf("%v://[%v]:%v/", scheme, podIP, port)
 	} else {
-		baseURL = fmt.Sprintf("http://%v:8000/", podIP)
+		baseURL = fmt.Sprintf("%v://%v:%v/", scheme, podIP, port)
 	}
 	return baseURL
 }
@@ -368,7 +435,11 @@ func (gp *GenericPool) specializePod(ctx context.Context, pod *apiv1.Pod, fn *fv
 
 	// Fetcher will download user function to share volume of pod, and
 	// invoke environment specialize api for pod specialization.
-	err := fetcherClient.MakeClient(gp.logger, fetcherURL).Specialize(ctx, &specializeReq)
+	client := fetcherClient.MakeClient(gp.logger, fetcherURL)
+	if gp.fetcherTLSConfig != nil {
+		client.SetTLSConfig(gp.fetcherTLSConfig)
+	}
+	err := client.Specialize(ctx, &specializeReq)
 	if err != nil {
 		return err
 	}
@@ -454,6 +525,8 @@ func (gp *GenericPool) createPool() error {
 	if err != nil {
 		return err
 	}
+	// pods are probed for the functions they are specialized for
+	gp.fetcherConfig.AddProbesToContainer(container)
 
 	pod := apiv1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
@@ -494,13 +567,21 @@ func (gp *GenericPool) createPool() error {
 	}
 
 	if gp.env.Spec.Runtime.PodSpec != nil {
-		newPodSpec, err := util.MergePodSpec(&deployment.Spec.Template.Spec, gp.env.Spec.Runtime.PodSpec)
+		newPodSpec, err := util.StrategicMergePodSpec(&deployment.Spec.Template.Spec, gp.env.Spec.Runtime.PodSpec)
 		if err != nil {
 			return err
 		}
 		deployment.Spec.Template.Spec = *newPodSpec
 	}
 
+	err = gp.deploymentHooks.MutateDeployment(&hook.Target{
+		ExecutorType: fv1.ExecutorTypePoolmgr,
+		Environment:  gp.env,
+	}, deployment)
+	if err != nil {
+		return err
+	}
+
 	depl, err := gp.kubernetesClient.AppsV1().Deployments(gp.namespace).Get(deployment.Name, metav1.GetOptions{})
 	if err == nil {
 		if depl.Annotations[fv1.EXECUTOR_INSTANCEID_LABEL] != gp.instanceID {
@@ -538,7 +619,7 @@ func (gp *GenericPool) createSvc(name string, labels map[string]string) (*apiv1.
 				{
 					Protocol:   apiv1.ProtocolTCP,
 					Port:       8888,
-					TargetPort: intstr.FromInt(8888),
+					TargetPort: intstr.FromInt(gp.fetcherConfig.FunctionPort()),
 				},
 			},
 			Selector: labels,
@@ -593,7 +674,7 @@ func (gp *GenericPool) getFuncSvc(ctx context.Context, fn *fv1.Function) (*fscac
 		}
 	}
 
-	key, pod, err := gp.choosePod(funcLabels)
+	key, pod, err := gp.choosePod(funcLabels, gp.coLocationNodes(fn))
 	if err != nil {
 		return nil, err
 	}
@@ -629,12 +710,13 @@ func (gp *GenericPool) getFuncSvc(ctx context.Context, fn *fv1.Function) (*fscac
 		svc := utils.GetFunctionIstioServiceName(fn.ObjectMeta.Name, fn.ObjectMeta.Namespace)
 		svcHost = fmt.Sprintf("%v.%v:8888", svc, gp.namespace)
 	} else {
-		svcHost = fmt.Sprintf("%v:8888", pod.Status.PodIP)
+		svcHost = fmt.Sprintf("%v:%v", pod.Status.PodIP, gp.fetcherConfig.FunctionPort())
 	}
 
-	// patch svc-host and resource version to the pod annotations for new executor to adopt the pod
-	patch := fmt.Sprintf(`{"metadata":{"annotations":{"%v":"%v","%v":"%v"}}}`,
-		fv1.ANNOTATION_SVC_HOST, svcHost, fv1.FUNCTION_RESOURCE_VERSION, fn.ObjectMeta.ResourceVersion)
+	// patch svc-host, resource version and generation to the pod annotations for new executor to adopt the pod
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{"%v":"%v","%v":"%v","%v":"%v"}}}`,
+		fv1.ANNOTATION_SVC_HOST, svcHost, fv1.FUNCTION_RESOURCE_VERSION, fn.ObjectMeta.ResourceVersion,
+		fv1.FUNCTION_GENERATION, strconv.FormatInt(fn.ObjectMeta.Generation, 10))
 	p, err := gp.kubernetesClient.CoreV1().Pods(pod.Namespace).Patch(pod.Name, k8sTypes.StrategicMergePatchType, []byte(patch))
 	if err != nil {
 		// just log the error since it won't affect the function serving
@@ -688,7 +770,7 @@ func (gp *GenericPool) getFuncSvc(ctx context.Context, fn *fv1.Function) (*fscac
 		Atime:             time.Now(),
 	}
 
-	gp.podFSVCMap.Store(pod.ObjectMeta.Name, []interface{}{crd.CacheKey(fsvc.Function), fsvc.Address})
+	gp.podFSVCMap.Store(pod.ObjectMeta.Name, []interface{}{crd.SpecCacheKey(fsvc.Function), fsvc.Address})
 	gp.fsCache.AddFunc(*fsvc)
 
 	gp.fsCache.IncreaseColdStarts(fn.ObjectMeta.Name, string(fn.ObjectMeta.UID))
@@ -702,6 +784,29 @@ func (gp *GenericPool) getPercent(cpuUsage resource.Quantity, percentage float64
 	return resource.ParseQuantity(fmt.Sprintf("%dm", val))
 }
 
+// scale sets the number of generic pods of the pool.
+func (gp *GenericPool) scale(replicas int32) error {
+	_, err := gp.kubernetesClient.AppsV1().Deployments(gp.namespace).UpdateScale(gp.deployment.ObjectMeta.Name, &autoscalingv1.Scale{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gp.deployment.ObjectMeta.Name,
+			Namespace: gp.namespace,
+		},
+		Spec: autoscalingv1.ScaleSpec{
+			Replicas: replicas,
+		},
+	})
+	return err
+}
+
+// readyReplicas returns the number of generic pods of the pool ready for specialization.
+func (gp *GenericPool) readyReplicas() (int32, error) {
+	depl, err := gp.kubernetesClient.AppsV1().Deployments(gp.namespace).Get(gp.deployment.ObjectMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		return 0, err
+	}
+	return depl.Status.ReadyReplicas, nil
+}
+
 // destroys the pool -- the deployment, replicaset and pods
 func (gp *GenericPool) destroy() error {
 	close(gp.stopReadyPodControllerCh)
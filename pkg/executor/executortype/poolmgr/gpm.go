@@ -43,6 +43,7 @@ import (
 	"github.com/fission/fission/pkg/crd"
 	"github.com/fission/fission/pkg/executor/executortype"
 	"github.com/fission/fission/pkg/executor/fscache"
+	"github.com/fission/fission/pkg/executor/hook"
 	"github.com/fission/fission/pkg/executor/reaper"
 	fetcherConfig "github.com/fission/fission/pkg/fetcher/config"
 	"github.com/fission/fission/pkg/utils"
@@ -62,6 +63,7 @@ type (
 		logger *zap.Logger
 
 		pools            map[string]*GenericPool
+		retiredPools     map[string]k8sTypes.UID // keys of pools replaced by a gradual rollout
 		kubernetesClient *kubernetes.Clientset
 		metricsClient    *metricsclient.Clientset
 		namespace        string
@@ -72,8 +74,9 @@ type (
 		instanceID     string
 		requestChannel chan *request
 
-		enableIstio   bool
-		fetcherConfig *fetcherConfig.Config
+		enableIstio     bool
+		fetcherConfig   *fetcherConfig.Config
+		deploymentHooks *hook.DeploymentHooks
 
 		funcStore      k8sCache.Store
 		funcController k8sCache.Controller
@@ -82,6 +85,7 @@ type (
 		podInformer    k8sCache.SharedIndexInformer
 
 		defaultIdlePodReapTime time.Duration
+		evictionPolicy         *reaper.EvictionPolicy
 	}
 	request struct {
 		requestType
@@ -102,13 +106,16 @@ func MakeGenericPoolManager(
 	metricsClient *metricsclient.Clientset,
 	functionNamespace string,
 	fetcherConfig *fetcherConfig.Config,
-	instanceID string) executortype.ExecutorType {
+	instanceID string,
+	deploymentHooks *hook.DeploymentHooks,
+	evictionPolicy *reaper.EvictionPolicy) executortype.ExecutorType {
 
 	gpmLogger := logger.Named("generic_pool_manager")
 
 	gpm := &GenericPoolManager{
 		logger:                 gpmLogger,
 		pools:                  make(map[string]*GenericPool),
+		retiredPools:           make(map[string]k8sTypes.UID),
 		kubernetesClient:       kubernetesClient,
 		metricsClient:          metricsClient,
 		namespace:              functionNamespace,
@@ -119,6 +126,8 @@ func MakeGenericPoolManager(
 		requestChannel:         make(chan *request),
 		defaultIdlePodReapTime: 2 * time.Minute,
 		fetcherConfig:          fetcherConfig,
+		deploymentHooks:        deploymentHooks,
+		evictionPolicy:         evictionPolicy,
 	}
 
 	go gpm.service()
@@ -150,6 +159,9 @@ func (gpm *GenericPoolManager) Run(ctx context.Context) {
 	go gpm.pkgController.Run(ctx.Done())
 	go gpm.podInformer.Run(ctx.Done())
 	go gpm.idleObjectReaper()
+	if gpm.evictionPolicy != nil {
+		go gpm.pressureReaper()
+	}
 }
 
 func (gpm *GenericPoolManager) GetTypeName() fv1.ExecutorType {
@@ -370,6 +382,10 @@ func (gpm *GenericPoolManager) AdoptExistingResources() {
 				return
 			}
 
+			// pods specialized by older releases have no generation and
+			// are cached by resource version
+			fnGeneration, _ := strconv.ParseInt(pod.Annotations[fv1.FUNCTION_GENERATION], 10, 64)
+
 			fsvc := fscache.FuncSvc{
 				Name: pod.Name,
 				Function: &metav1.ObjectMeta{
@@ -377,6 +393,7 @@ func (gpm *GenericPoolManager) AdoptExistingResources() {
 					Namespace:       fnNS,
 					UID:             k8sTypes.UID(fnUID),
 					ResourceVersion: fnRV,
+					Generation:      fnGeneration,
 				},
 				Environment: &env,
 				Address:     svcHost,
@@ -445,7 +462,16 @@ func (gpm *GenericPoolManager) service() {
 		case GET_POOL:
 			// just because they are missing in the cache, we end up creating another duplicate pool.
 			var err error
-			pool, ok := gpm.pools[crd.CacheKey(&req.env.ObjectMeta)]
+			key := crd.CacheKey(&req.env.ObjectMeta)
+			pool, ok := gpm.pools[key]
+			if !ok {
+				if _, retired := gpm.retiredPools[key]; retired {
+					// A stale version of an environment rolled out already,
+					// serve the request from the pool of the latest one.
+					pool = gpm.findPool(req.env.ObjectMeta.UID)
+					ok = pool != nil
+				}
+			}
 			if !ok {
 				poolsize := gpm.getEnvPoolsize(req.env)
 				switch req.env.Spec.AllowedFunctionsPerContainer {
@@ -453,6 +479,22 @@ func (gpm *GenericPoolManager) service() {
 					poolsize = 1
 				}
 
+				// With a gradual rollout, the pool of the previous version
+				// of the environment is replaced step by step, the new pool
+				// starts empty and is scaled up by the rollout.
+				var oldKey string
+				var oldPool *GenericPool
+				initialReplicas := poolsize
+				if isGradualRollout(req.env) {
+					for k, p := range gpm.pools {
+						if p.env.ObjectMeta.UID == req.env.ObjectMeta.UID {
+							oldKey, oldPool = k, p
+							initialReplicas = 0
+							break
+						}
+					}
+				}
+
 				// To support backward compatibility, if envs are created in default ns, we go ahead
 				// and create pools in fission-function ns as earlier.
 				ns := gpm.namespace
@@ -461,22 +503,45 @@ func (gpm *GenericPoolManager) service() {
 				}
 
 				pool, err = MakeGenericPool(gpm.logger,
-					gpm.fissionClient, gpm.kubernetesClient, gpm.metricsClient, req.env, poolsize,
-					ns, gpm.namespace, gpm.fsCache, gpm.fetcherConfig, gpm.instanceID, gpm.enableIstio)
+					gpm.fissionClient, gpm.kubernetesClient, gpm.metricsClient, req.env, initialReplicas,
+					ns, gpm.namespace, gpm.fsCache, gpm.fetcherConfig, gpm.instanceID, gpm.enableIstio, gpm.deploymentHooks)
 				if err != nil {
 					req.responseChannel <- &response{error: err}
 					continue
 				}
-				gpm.pools[crd.CacheKey(&req.env.ObjectMeta)] = pool
+				gpm.pools[key] = pool
+
+				if oldPool != nil {
+					delete(gpm.pools, oldKey)
+					gpm.retiredPools[oldKey] = oldPool.env.ObjectMeta.UID
+					// Stops the rollout the old pool is the new pool of, if any.
+					close(oldPool.rolloutStopCh)
+					go gpm.rollout(oldPool, pool, poolsize)
+				}
 			}
 			req.responseChannel <- &response{pool: pool}
 		case CLEANUP_POOLS:
 			latestEnvPoolsize := make(map[string]int)
-			for _, env := range req.envList {
+			latestEnv := make(map[k8sTypes.UID]*fv1.Environment)
+			for i, env := range req.envList {
 				latestEnvPoolsize[crd.CacheKey(&env.ObjectMeta)] = int(gpm.getEnvPoolsize(&env))
+				latestEnv[env.ObjectMeta.UID] = &req.envList[i]
+			}
+			for key, uid := range gpm.retiredPools {
+				if _, ok := latestEnv[uid]; !ok {
+					delete(gpm.retiredPools, key)
+				}
 			}
 			for key, pool := range gpm.pools {
 				poolsize, ok := latestEnvPoolsize[key]
+				if !ok {
+					// Env changed, leave the pool to the rollout started by
+					// the pool of the latest version.
+					env, exists := latestEnv[pool.env.ObjectMeta.UID]
+					if exists && isGradualRollout(env) && gpm.getEnvPoolsize(env) > 0 {
+						continue
+					}
+				}
 				if !ok || poolsize == 0 {
 					// Env no longer exists or pool size changed to zero
 
@@ -492,6 +557,16 @@ func (gpm *GenericPoolManager) service() {
 	}
 }
 
+// findPool returns the pool of the environment with the given UID, if any.
+func (gpm *GenericPoolManager) findPool(uid k8sTypes.UID) *GenericPool {
+	for _, pool := range gpm.pools {
+		if pool.env.ObjectMeta.UID == uid {
+			return pool
+		}
+	}
+	return nil
+}
+
 func (gpm *GenericPoolManager) getPool(env *fv1.Environment) (*GenericPool, error) {
 	c := make(chan *response)
 	gpm.requestChannel <- &request{
@@ -682,3 +757,75 @@ func (gpm *GenericPoolManager) idleObjectReaper() {
 		}
 	}
 }
+
+// pressureReaper evicts idle specialized pods before their idle timeout
+// while pods in the function namespace can't be scheduled for lack of
+// resources, so that new specializations get room. The eviction policy
+// picks the pods of low priority functions with little recent traffic first.
+func (gpm *GenericPoolManager) pressureReaper() {
+	for {
+		time.Sleep(reaper.DefaultEvictionInterval)
+
+		unschedulable := 0
+		for _, item := range gpm.podInformer.GetStore().List() {
+			if reaper.IsUnschedulable(item.(*apiv1.Pod)) {
+				unschedulable++
+			}
+		}
+		if unschedulable == 0 {
+			continue
+		}
+
+		funcSvcs, err := gpm.fsCache.ListOldForPool(gpm.evictionPolicy.MinIdle)
+		if err != nil {
+			gpm.logger.Error("error listing idle pods to evict", zap.Error(err))
+			continue
+		}
+
+		candidates := make([]reaper.EvictionCandidate, 0, len(funcSvcs))
+		for _, fsvc := range funcSvcs {
+			if fsvc.Executor != fv1.ExecutorTypePoolmgr ||
+				fsvc.Environment.Spec.AllowedFunctionsPerContainer == fv1.AllowedFunctionsPerContainerInfinite {
+				continue
+			}
+			priority := fv1.InvocationPriorityStandard
+			item, exists, err := gpm.funcStore.GetByKey(fmt.Sprintf("%s/%s", fsvc.Function.Namespace, fsvc.Function.Name))
+			if err == nil && exists && len(item.(*fv1.Function).Spec.Priority) > 0 {
+				priority = item.(*fv1.Function).Spec.Priority
+			}
+			candidates = append(candidates, reaper.EvictionCandidate{
+				Svc:      fsvc,
+				Priority: priority,
+			})
+		}
+
+		victims := gpm.evictionPolicy.SelectVictims(candidates, time.Now())
+		if len(victims) == 0 {
+			gpm.logger.Info("pods can't be scheduled but no idle pod may be evicted",
+				zap.Int("unschedulable_pods", unschedulable))
+			continue
+		}
+
+		for _, victim := range victims {
+			fsvc := victim.Svc
+			deleted, err := gpm.fsCache.DeleteOldPoolCache(fsvc, gpm.evictionPolicy.MinIdle)
+			if err != nil {
+				gpm.logger.Error("error deleting Kubernetes objects for function service",
+					zap.Error(err),
+					zap.Any("service", fsvc))
+				continue
+			}
+			if !deleted {
+				continue
+			}
+			gpm.logger.Info("evicting idle function pod under node pressure",
+				zap.String("function", fsvc.Function.Name),
+				zap.String("priority", string(victim.Priority)),
+				zap.String("pod", fsvc.Name),
+				zap.Int("unschedulable_pods", unschedulable))
+			for i := range fsvc.KubernetesObjects {
+				reaper.CleanupKubeObject(gpm.logger, gpm.kubernetesClient, &fsvc.KubernetesObjects[i])
+			}
+		}
+	}
+}
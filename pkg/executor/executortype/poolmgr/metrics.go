@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolmgr
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/fission/fission/pkg/utils"
+)
+
+// poolMetricsInterval is how often the utilization of generic pools is
+// reported.
+const poolMetricsInterval = 15 * time.Second
+
+var (
+	// environment, envnamespace: the metadata of the pool's environment
+	// state: idle | specialized
+	poolPods = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "fission_executor_pool_pods",
+			Help: "How many pods of the generic pool of an environment are idle, ready for specialization, or specialized.",
+		},
+		[]string{"environment", "envnamespace", "state"},
+	)
+	poolUtilization = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "fission_executor_pool_utilization_ratio",
+			Help: "The fraction of the pods of the generic pool of an environment that are specialized.",
+		},
+		[]string{"environment", "envnamespace"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(poolPods)
+	prometheus.MustRegister(poolUtilization)
+}
+
+// reportUtilization reports the idle and specialized pods of the pool until
+// the pool is destroyed.
+func (gp *GenericPool) reportUtilization() {
+	ticker := time.NewTicker(poolMetricsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-gp.stopReadyPodControllerCh:
+			gp.deleteUtilization()
+			return
+		case <-ticker.C:
+		}
+
+		idle, specialized, err := gp.countPods()
+		if err != nil {
+			gp.logger.Error("error counting pool pods", zap.Error(err))
+			continue
+		}
+		gp.setUtilization(idle, specialized)
+	}
+}
+
+// setUtilization reports the idle and specialized pods of the pool.
+func (gp *GenericPool) setUtilization(idle int, specialized int) {
+	env, envNamespace := gp.env.ObjectMeta.Name, gp.env.ObjectMeta.Namespace
+	poolPods.WithLabelValues(env, envNamespace, "idle").Set(float64(idle))
+	poolPods.WithLabelValues(env, envNamespace, "specialized").Set(float64(specialized))
+	var utilization float64
+	if idle+specialized > 0 {
+		utilization = float64(specialized) / float64(idle+specialized)
+	}
+	poolUtilization.WithLabelValues(env, envNamespace).Set(utilization)
+}
+
+// deleteUtilization stops reporting the pods of the destroyed pool.
+func (gp *GenericPool) deleteUtilization() {
+	env, envNamespace := gp.env.ObjectMeta.Name, gp.env.ObjectMeta.Namespace
+	poolPods.DeleteLabelValues(env, envNamespace, "idle")
+	poolPods.DeleteLabelValues(env, envNamespace, "specialized")
+	poolUtilization.DeleteLabelValues(env, envNamespace)
+}
+
+// countPods returns how many pods of the pool are ready for specialization,
+// and how many are specialized for functions.
+func (gp *GenericPool) countPods() (idle int, specialized int, err error) {
+	idle = gp.countIdlePods()
+
+	// specialized pods are relabeled out of the pool's deployment
+	selector := gp.getEnvironmentPoolLabels()
+	selector["managed"] = "false"
+	pods, err := gp.kubernetesClient.CoreV1().Pods(gp.namespace).List(metav1.ListOptions{
+		LabelSelector: labels.Set(selector).AsSelector().String(),
+		FieldSelector: "status.phase=Running",
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return idle, len(pods.Items), nil
+}
+
+// countIdlePods returns how many pods of the pool are ready for
+// specialization.
+func (gp *GenericPool) countIdlePods() (idle int) {
+	for _, obj := range gp.readyPodIndexer.List() {
+		if utils.IsReadyPod(obj.(*apiv1.Pod)) {
+			idle++
+		}
+	}
+	return idle
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolmgr
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func makeMetricsTestPod(name string, ip string, ready bool) *apiv1.Pod {
+	return &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "fission-function"},
+		Status: apiv1.PodStatus{
+			PodIP:             ip,
+			ContainerStatuses: []apiv1.ContainerStatus{{Name: "nodejs", Ready: ready}},
+		},
+	}
+}
+
+func TestPoolUtilizationMetrics(t *testing.T) {
+	gp := &GenericPool{
+		env: &fv1.Environment{
+			ObjectMeta: metav1.ObjectMeta{Name: "metrics-nodejs", Namespace: metav1.NamespaceDefault},
+		},
+		readyPodIndexer: cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
+	}
+	idle := poolPods.WithLabelValues("metrics-nodejs", metav1.NamespaceDefault, "idle")
+	specialized := poolPods.WithLabelValues("metrics-nodejs", metav1.NamespaceDefault, "specialized")
+	utilization := poolUtilization.WithLabelValues("metrics-nodejs", metav1.NamespaceDefault)
+
+	// the pool scales up, pods count as idle once ready
+	for _, pod := range []*apiv1.Pod{
+		makeMetricsTestPod("a", "10.0.0.1", true),
+		makeMetricsTestPod("b", "10.0.0.2", true),
+		makeMetricsTestPod("c", "10.0.0.3", true),
+		makeMetricsTestPod("d", "", false),
+		makeMetricsTestPod("e", "10.0.0.5", false),
+	} {
+		if err := gp.readyPodIndexer.Add(pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := gp.countIdlePods(); n != 3 {
+		t.Fatalf("expected 3 idle pods, got %v", n)
+	}
+	gp.setUtilization(gp.countIdlePods(), 0)
+	if testutil.ToFloat64(idle) != 3 || testutil.ToFloat64(specialized) != 0 || testutil.ToFloat64(utilization) != 0 {
+		t.Errorf("expected 3 idle pods and no utilization, got %v, %v and %v",
+			testutil.ToFloat64(idle), testutil.ToFloat64(specialized), testutil.ToFloat64(utilization))
+	}
+
+	// a pod is specialized, and the pool replaces it
+	gp.setUtilization(3, 1)
+	if testutil.ToFloat64(idle) != 3 || testutil.ToFloat64(specialized) != 1 || testutil.ToFloat64(utilization) != 0.25 {
+		t.Errorf("expected 3 idle and 1 specialized pods, got %v, %v and %v",
+			testutil.ToFloat64(idle), testutil.ToFloat64(specialized), testutil.ToFloat64(utilization))
+	}
+
+	// the pool scales down to nothing
+	gp.setUtilization(0, 0)
+	if testutil.ToFloat64(idle) != 0 || testutil.ToFloat64(specialized) != 0 || testutil.ToFloat64(utilization) != 0 {
+		t.Errorf("expected empty pool, got %v, %v and %v",
+			testutil.ToFloat64(idle), testutil.ToFloat64(specialized), testutil.ToFloat64(utilization))
+	}
+
+	// metrics of destroyed pools are removed
+	gp.deleteUtilization()
+	if poolPods.DeleteLabelValues("metrics-nodejs", metav1.NamespaceDefault, "idle") ||
+		poolPods.DeleteLabelValues("metrics-nodejs", metav1.NamespaceDefault, "specialized") ||
+		poolUtilization.DeleteLabelValues("metrics-nodejs", metav1.NamespaceDefault) {
+		t.Error("expected metrics of destroyed pool to be removed")
+	}
+}
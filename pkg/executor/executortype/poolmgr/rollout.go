@@ -0,0 +1,137 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolmgr
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// isGradualRollout returns true if the pool of the environment is replaced
+// step by step when the environment changes.
+func isGradualRollout(env *fv1.Environment) bool {
+	return env.Spec.RolloutStrategy != nil &&
+		env.Spec.RolloutStrategy.Type == fv1.RolloutStrategyGradual
+}
+
+// rolloutStep returns the number of pods of the new and the old pool for
+// the next step of a gradual rollout towards a new pool of target pods,
+// given how many pods of the new pool are ready, and whether the rollout
+// is done.
+func rolloutStep(strategy *fv1.RolloutStrategy, target, newReplicas, newReady, oldReplicas int32) (int32, int32, bool) {
+	surge := strategy.MaxSurge
+	if surge <= 0 {
+		surge = fv1.DefaultRolloutMaxSurge
+	}
+
+	nextNew := newReady + surge
+	if strategy.Canary && newReady == 0 {
+		nextNew = 1
+	}
+	if nextNew > target {
+		nextNew = target
+	}
+	if nextNew < newReplicas {
+		nextNew = newReplicas
+	}
+
+	// The old pool only goes down as far as the ready pods of the new
+	// pool make up for, less the pods allowed to be unavailable.
+	nextOld := target - strategy.MaxUnavailable - newReady
+	if nextOld > oldReplicas {
+		nextOld = oldReplicas
+	}
+	if nextOld < 0 {
+		nextOld = 0
+	}
+
+	return nextNew, nextOld, newReady >= target && nextOld == 0
+}
+
+// rollout moves the generic pods of oldPool to newPool in the steps the
+// rollout strategy of the environment of newPool allows, then destroys
+// oldPool. It stops early, destroying oldPool, if newPool is replaced
+// by another rollout in the meantime.
+func (gpm *GenericPoolManager) rollout(oldPool *GenericPool, newPool *GenericPool, target int32) {
+	strategy := newPool.env.Spec.RolloutStrategy
+	interval := time.Duration(strategy.StepInterval) * time.Second
+	if interval <= 0 {
+		interval = fv1.DefaultRolloutStepInterval * time.Second
+	}
+
+	logger := gpm.logger.With(
+		zap.String("environment", newPool.env.ObjectMeta.Name),
+		zap.String("namespace", newPool.env.ObjectMeta.Namespace),
+		zap.String("old_pool", oldPool.deployment.ObjectMeta.Name),
+		zap.String("new_pool", newPool.deployment.ObjectMeta.Name))
+	logger.Info("starting gradual rollout of environment pool", zap.Int32("poolsize", target))
+
+	defer func() {
+		err := oldPool.destroy()
+		if err != nil {
+			logger.Error("error destroying pool after rollout", zap.Error(err))
+		}
+	}()
+
+	newReplicas := *newPool.deployment.Spec.Replicas
+	oldReplicas := *oldPool.deployment.Spec.Replicas
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		ready, err := newPool.readyReplicas()
+		if err != nil {
+			logger.Error("error getting ready pods of new pool", zap.Error(err))
+		} else {
+			nextNew, nextOld, done := rolloutStep(strategy, target, newReplicas, ready, oldReplicas)
+			if nextNew != newReplicas {
+				err = newPool.scale(nextNew)
+				if err != nil {
+					logger.Error("error scaling up new pool", zap.Error(err), zap.Int32("replicas", nextNew))
+				} else {
+					newReplicas = nextNew
+				}
+			}
+			if nextOld != oldReplicas {
+				err = oldPool.scale(nextOld)
+				if err != nil {
+					logger.Error("error scaling down old pool", zap.Error(err), zap.Int32("replicas", nextOld))
+				} else {
+					oldReplicas = nextOld
+				}
+			}
+			logger.Debug("rollout step",
+				zap.Int32("new_ready", ready),
+				zap.Int32("new_replicas", newReplicas),
+				zap.Int32("old_replicas", oldReplicas))
+			if done {
+				logger.Info("finished gradual rollout of environment pool")
+				return
+			}
+		}
+
+		select {
+		case <-newPool.rolloutStopCh:
+			logger.Info("rollout superseded by a newer version of the environment")
+			return
+		case <-ticker.C:
+		}
+	}
+}
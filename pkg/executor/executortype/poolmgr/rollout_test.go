@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolmgr
+
+import (
+	"testing"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestRolloutStep(t *testing.T) {
+	for _, test := range []struct {
+		name                  string
+		strategy              fv1.RolloutStrategy
+		newReplicas, newReady int32
+		oldReplicas           int32
+		wantNew, wantOld      int32
+		wantDone              bool
+	}{
+		{
+			name:        "first step starts maxSurge pods",
+			strategy:    fv1.RolloutStrategy{MaxSurge: 2},
+			oldReplicas: 5,
+			wantNew:     2,
+			wantOld:     5,
+		},
+		{
+			name:        "default surge is one pod",
+			oldReplicas: 5,
+			wantNew:     1,
+			wantOld:     5,
+		},
+		{
+			name:        "canary starts a single pod",
+			strategy:    fv1.RolloutStrategy{MaxSurge: 3, Canary: true},
+			newReplicas: 1,
+			oldReplicas: 5,
+			wantNew:     1,
+			wantOld:     5,
+		},
+		{
+			name:        "old pool shrinks as new pods become ready",
+			strategy:    fv1.RolloutStrategy{MaxSurge: 2, Canary: true},
+			newReplicas: 1,
+			newReady:    1,
+			oldReplicas: 5,
+			wantNew:     3,
+			wantOld:     4,
+		},
+		{
+			name:        "unavailable pods shrink old pool early",
+			strategy:    fv1.RolloutStrategy{MaxSurge: 1, MaxUnavailable: 2},
+			newReplicas: 1,
+			newReady:    1,
+			oldReplicas: 5,
+			wantNew:     2,
+			wantOld:     2,
+		},
+		{
+			name:        "new pool never exceeds target",
+			strategy:    fv1.RolloutStrategy{MaxSurge: 4},
+			newReplicas: 4,
+			newReady:    4,
+			oldReplicas: 1,
+			wantNew:     5,
+			wantOld:     1,
+		},
+		{
+			name:        "done once new pool is ready",
+			strategy:    fv1.RolloutStrategy{MaxSurge: 4},
+			newReplicas: 5,
+			newReady:    5,
+			oldReplicas: 1,
+			wantNew:     5,
+			wantOld:     0,
+			wantDone:    true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			gotNew, gotOld, gotDone := rolloutStep(&test.strategy, 5, test.newReplicas, test.newReady, test.oldReplicas)
+			if gotNew != test.wantNew || gotOld != test.wantOld || gotDone != test.wantDone {
+				t.Errorf("rolloutStep() = (%v, %v, %v), want (%v, %v, %v)",
+					gotNew, gotOld, gotDone, test.wantNew, test.wantOld, test.wantDone)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ package fscache
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/pkg/errors"
@@ -59,6 +60,10 @@ type (
 
 		Ctime time.Time
 		Atime time.Time
+
+		// Traffic is the number of requests served by the function service,
+		// decayed by half every trafficHalfLife since Atime.
+		Traffic float64
 	}
 
 	// FunctionServiceCache represents the function service cache
@@ -85,6 +90,26 @@ type (
 	}
 )
 
+// trafficHalfLife is how long requests count in the recent traffic of a
+// function service for half of their weight.
+const trafficHalfLife = 5 * time.Minute
+
+// RecentTraffic returns the recent traffic of the function service at a
+// given time.
+func (fsvc *FuncSvc) RecentTraffic(now time.Time) float64 {
+	elapsed := now.Sub(fsvc.Atime)
+	if elapsed <= 0 {
+		return fsvc.Traffic
+	}
+	return fsvc.Traffic * math.Exp2(-float64(elapsed)/float64(trafficHalfLife))
+}
+
+// touch records a request to the function service.
+func (fsvc *FuncSvc) touch(now time.Time) {
+	fsvc.Traffic = fsvc.RecentTraffic(now) + 1
+	fsvc.Atime = now
+}
+
 // IsNotFoundError checks if err is ErrorNotFound.
 func IsNotFoundError(err error) bool {
 	if fe, ok := err.(ferror.Error); ok {
@@ -162,7 +187,7 @@ func (fsc *FunctionServiceCache) service() {
 
 // GetByFunction gets a function service from cache using function key.
 func (fsc *FunctionServiceCache) GetByFunction(m *metav1.ObjectMeta) (*FuncSvc, error) {
-	key := crd.CacheKey(m)
+	key := crd.SpecCacheKey(m)
 
 	fsvcI, err := fsc.byFunction.Get(key)
 	if err != nil {
@@ -171,7 +196,7 @@ func (fsc *FunctionServiceCache) GetByFunction(m *metav1.ObjectMeta) (*FuncSvc,
 
 	// update atime
 	fsvc := fsvcI.(*FuncSvc)
-	fsvc.Atime = time.Now()
+	fsvc.touch(time.Now())
 
 	fsvcCopy := *fsvc
 	return &fsvcCopy, nil
@@ -179,7 +204,7 @@ func (fsc *FunctionServiceCache) GetByFunction(m *metav1.ObjectMeta) (*FuncSvc,
 
 // GetFuncSvc gets a function service from pool cache using function key and returns number of active instances of function pod
 func (fsc *FunctionServiceCache) GetFuncSvc(m *metav1.ObjectMeta, requestsPerPod int) (*FuncSvc, int, error) {
-	key := crd.CacheKey(m)
+	key := crd.SpecCacheKey(m)
 
 	fsvcI, active, err := fsc.connFunctionCache.GetValue(key, requestsPerPod)
 	if err != nil {
@@ -189,7 +214,7 @@ func (fsc *FunctionServiceCache) GetFuncSvc(m *metav1.ObjectMeta, requestsPerPod
 
 	// update atime
 	fsvc := fsvcI.(*FuncSvc)
-	fsvc.Atime = time.Now()
+	fsvc.touch(time.Now())
 
 	fsvcCopy := *fsvc
 	return &fsvcCopy, active, nil
@@ -204,14 +229,14 @@ func (fsc *FunctionServiceCache) GetByFunctionUID(uid types.UID) (*FuncSvc, erro
 
 	m := mI.(metav1.ObjectMeta)
 
-	fsvcI, err := fsc.byFunction.Get(crd.CacheKey(&m))
+	fsvcI, err := fsc.byFunction.Get(crd.SpecCacheKey(&m))
 	if err != nil {
 		return nil, err
 	}
 
 	// update atime
 	fsvc := fsvcI.(*FuncSvc)
-	fsvc.Atime = time.Now()
+	fsvc.touch(time.Now())
 
 	fsvcCopy := *fsvc
 	return &fsvcCopy, nil
@@ -219,7 +244,7 @@ func (fsc *FunctionServiceCache) GetByFunctionUID(uid types.UID) (*FuncSvc, erro
 
 // AddFunc adds a function service to pool cache.
 func (fsc *FunctionServiceCache) AddFunc(fsvc FuncSvc) {
-	fsc.connFunctionCache.SetValue(crd.CacheKey(fsvc.Function), fsvc.Address, &fsvc, fsvc.CPULimit)
+	fsc.connFunctionCache.SetValue(crd.SpecCacheKey(fsvc.Function), fsvc.Address, &fsvc, fsvc.CPULimit)
 	now := time.Now()
 	fsvc.Ctime = now
 	fsvc.Atime = now
@@ -239,7 +264,7 @@ func (fsc *FunctionServiceCache) MarkAvailable(key string, svcHost string) {
 
 // Add adds a function service to cache if it does not exist already.
 func (fsc *FunctionServiceCache) Add(fsvc FuncSvc) (*FuncSvc, error) {
-	existing, err := fsc.byFunction.Set(crd.CacheKey(fsvc.Function), &fsvc)
+	existing, err := fsc.byFunction.Set(crd.SpecCacheKey(fsvc.Function), &fsvc)
 	if err != nil {
 		if IsNameExistError(err) {
 			f := existing.(*FuncSvc)
@@ -302,19 +327,19 @@ func (fsc *FunctionServiceCache) _touchByAddress(address string) error {
 		return err
 	}
 	m := mI.(metav1.ObjectMeta)
-	fsvcI, err := fsc.byFunction.Get(crd.CacheKey(&m))
+	fsvcI, err := fsc.byFunction.Get(crd.SpecCacheKey(&m))
 	if err != nil {
 		return err
 	}
 	fsvc := fsvcI.(*FuncSvc)
-	fsvc.Atime = time.Now()
+	fsvc.touch(time.Now())
 	return nil
 }
 
 // DeleteEntry deletes a function service from cache.
 func (fsc *FunctionServiceCache) DeleteEntry(fsvc *FuncSvc) {
 	msg := "error deleting function service"
-	err := fsc.byFunction.Delete(crd.CacheKey(fsvc.Function))
+	err := fsc.byFunction.Delete(crd.SpecCacheKey(fsvc.Function))
 	if err != nil {
 		fsc.logger.Error(
 			msg,
@@ -348,7 +373,7 @@ func (fsc *FunctionServiceCache) DeleteEntry(fsvc *FuncSvc) {
 
 // DeleteFunctionSvc deletes a function service at key composed of [function][address].
 func (fsc *FunctionServiceCache) DeleteFunctionSvc(fsvc *FuncSvc) {
-	err := fsc.connFunctionCache.DeleteValue(crd.CacheKey(fsvc.Function), fsvc.Address)
+	err := fsc.connFunctionCache.DeleteValue(crd.SpecCacheKey(fsvc.Function), fsvc.Address)
 	if err != nil {
 		fsc.logger.Error(
 			"error deleting function service",
@@ -0,0 +1,175 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+type (
+	// Target describes what a deployment is created for.
+	Target struct {
+		ExecutorType fv1.ExecutorType
+		Environment  *fv1.Environment
+
+		// Function is nil for deployments of generic pools, which
+		// are shared by all functions of the environment.
+		Function *fv1.Function
+	}
+
+	// DeploymentMutator mutates deployments right before executor creates
+	// or updates them, e.g. to add labels, sidecars or volumes.
+	DeploymentMutator interface {
+		// Name returns the unique name of the mutator.
+		Name() string
+
+		// MutateDeployment mutates the deployment in place.
+		MutateDeployment(target *Target, deployment *appsv1.Deployment) error
+	}
+
+	// DeploymentHooks runs all mutators enabled for the executor.
+	DeploymentHooks struct {
+		logger   *zap.Logger
+		mutators []DeploymentMutator
+	}
+)
+
+var (
+	registryLock sync.Mutex
+	registry     = make(map[string]DeploymentMutator)
+)
+
+// Register makes a mutator available to executor. Plugins compiled into
+// the executor binary call it from their init function.
+func Register(mutator DeploymentMutator) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if _, ok := registry[mutator.Name()]; ok {
+		panic("deployment mutator already registered: " + mutator.Name())
+	}
+	registry[mutator.Name()] = mutator
+}
+
+// MakeDeploymentHooks returns hooks running the registered mutators listed in
+// EXECUTOR_DEPLOYMENT_HOOKS (comma separated, all registered ones if empty),
+// followed by the webhook at POD_MUTATION_WEBHOOK_URL if it's set.
+func MakeDeploymentHooks(logger *zap.Logger) (*DeploymentHooks, error) {
+	hooks := &DeploymentHooks{
+		logger: logger.Named("deployment_hooks"),
+	}
+
+	registryLock.Lock()
+	names := make([]string, 0, len(registry))
+	if enabled := os.Getenv("EXECUTOR_DEPLOYMENT_HOOKS"); len(enabled) > 0 {
+		for _, name := range strings.Split(enabled, ",") {
+			name = strings.TrimSpace(name)
+			if _, ok := registry[name]; !ok {
+				registryLock.Unlock()
+				return nil, errors.Errorf("deployment mutator %q is not registered", name)
+			}
+			names = append(names, name)
+		}
+	} else {
+		for name := range registry {
+			names = append(names, name)
+		}
+		// run mutators in a deterministic order
+		sort.Strings(names)
+	}
+	for _, name := range names {
+		hooks.mutators = append(hooks.mutators, registry[name])
+	}
+	registryLock.Unlock()
+
+	if url := os.Getenv("POD_MUTATION_WEBHOOK_URL"); len(url) > 0 {
+		timeout := defaultWebhookTimeout
+		if t := os.Getenv("POD_MUTATION_WEBHOOK_TIMEOUT"); len(t) > 0 {
+			var err error
+			timeout, err = time.ParseDuration(t)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error parsing 'POD_MUTATION_WEBHOOK_TIMEOUT' %v", t)
+			}
+		}
+
+		webhook, err := MakeWebhookMutator(hooks.logger, url, timeout, FailurePolicy(os.Getenv("POD_MUTATION_WEBHOOK_FAILURE_POLICY")))
+		if err != nil {
+			return nil, err
+		}
+		hooks.mutators = append(hooks.mutators, webhook)
+	}
+
+	for _, m := range hooks.mutators {
+		hooks.logger.Info("deployment mutator enabled", zap.String("name", m.Name()))
+	}
+
+	return hooks, nil
+}
+
+// MakeDeploymentHooksWithMutators returns hooks running the given mutators in order.
+func MakeDeploymentHooksWithMutators(logger *zap.Logger, mutators ...DeploymentMutator) *DeploymentHooks {
+	return &DeploymentHooks{
+		logger:   logger.Named("deployment_hooks"),
+		mutators: mutators,
+	}
+}
+
+// MutateDeployment runs all mutators on the deployment. Mutators must not change
+// the name, namespace or pod selector of the deployment since executor relies on
+// them to find the pods of functions.
+func (hooks *DeploymentHooks) MutateDeployment(target *Target, deployment *appsv1.Deployment) error {
+	if hooks == nil {
+		return nil
+	}
+
+	for _, m := range hooks.mutators {
+		name, namespace := deployment.ObjectMeta.Name, deployment.ObjectMeta.Namespace
+		selector := deployment.Spec.Selector.DeepCopy()
+
+		err := m.MutateDeployment(target, deployment)
+		if err != nil {
+			return errors.Wrapf(err, "error running deployment mutator %v", m.Name())
+		}
+
+		if deployment.ObjectMeta.Name != name || deployment.ObjectMeta.Namespace != namespace ||
+			!reflect.DeepEqual(deployment.Spec.Selector, selector) {
+			return errors.Errorf("deployment mutator %v changed name, namespace or selector of deployment %v", m.Name(), name)
+		}
+		if selector != nil {
+			for k, v := range selector.MatchLabels {
+				if deployment.Spec.Template.ObjectMeta.Labels[k] != v {
+					return errors.Errorf("deployment mutator %v removed pod label %v of deployment %v", m.Name(), k, name)
+				}
+			}
+		}
+
+		hooks.logger.Debug("mutated deployment", zap.String("mutator", m.Name()), zap.String("deployment", name))
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+type funcMutator func(deployment *appsv1.Deployment)
+
+func (f funcMutator) Name() string {
+	return "func"
+}
+
+func (f funcMutator) MutateDeployment(target *Target, deployment *appsv1.Deployment) error {
+	f(deployment)
+	return nil
+}
+
+func makeDeployment() *appsv1.Deployment {
+	labels := map[string]string{"functionName": "hello"}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hello",
+			Namespace: "fission-function",
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"functionName": "hello"}},
+			},
+		},
+	}
+}
+
+func TestMutateDeployment(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  funcMutator
+		wantErr bool
+	}{
+		{
+			name: "add label and sidecar",
+			mutate: func(d *appsv1.Deployment) {
+				d.Spec.Template.ObjectMeta.Labels["team"] = "payments"
+				d.Spec.Template.Spec.Containers = append(d.Spec.Template.Spec.Containers, apiv1.Container{Name: "vault-agent"})
+			},
+			wantErr: false,
+		},
+		{
+			name: "change selector",
+			mutate: func(d *appsv1.Deployment) {
+				d.Spec.Selector.MatchLabels["team"] = "payments"
+			},
+			wantErr: true,
+		},
+		{
+			name: "remove pod label",
+			mutate: func(d *appsv1.Deployment) {
+				d.Spec.Template.ObjectMeta.Labels = nil
+			},
+			wantErr: true,
+		},
+		{
+			name: "rename",
+			mutate: func(d *appsv1.Deployment) {
+				d.ObjectMeta.Name = "world"
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := MakeDeploymentHooksWithMutators(zap.NewNop(), tt.mutate)
+			err := hooks.MutateDeployment(&Target{ExecutorType: fv1.ExecutorTypeNewdeploy}, makeDeployment())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MutateDeployment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebhookMutator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &WebhookRequest{}
+		err := json.NewDecoder(r.Body).Decode(req)
+		if err != nil || req.Deployment == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		req.Deployment.Spec.Template.ObjectMeta.Labels["team"] = "payments"
+		json.NewEncoder(w).Encode(WebhookResponse{Deployment: req.Deployment})
+	}))
+	defer server.Close()
+
+	webhook, err := MakeWebhookMutator(zap.NewNop(), server.URL, time.Second, FailurePolicyFail)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deployment := makeDeployment()
+	err = MakeDeploymentHooksWithMutators(zap.NewNop(), webhook).MutateDeployment(&Target{ExecutorType: fv1.ExecutorTypePoolmgr}, deployment)
+	if err != nil {
+		t.Fatalf("error mutating deployment: %v", err)
+	}
+	if deployment.Spec.Template.ObjectMeta.Labels["team"] != "payments" {
+		t.Errorf("expected deployment to be mutated by webhook, got labels %v", deployment.Spec.Template.ObjectMeta.Labels)
+	}
+}
+
+func TestWebhookMutatorFailurePolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	for _, policy := range []FailurePolicy{FailurePolicyFail, FailurePolicyIgnore} {
+		webhook, err := MakeWebhookMutator(zap.NewNop(), server.URL, time.Second, policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = webhook.MutateDeployment(&Target{}, makeDeployment())
+		if (err != nil) != (policy == FailurePolicyFail) {
+			t.Errorf("failure policy %v: unexpected error %v", policy, err)
+		}
+	}
+
+	_, err := MakeWebhookMutator(zap.NewNop(), server.URL, time.Second, "Retry")
+	if err == nil {
+		t.Error("expected error for unsupported failure policy")
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	// FailurePolicyFail fails creating the deployment if the webhook call fails.
+	FailurePolicyFail FailurePolicy = "Fail"
+	// FailurePolicyIgnore creates the deployment without mutation if the webhook call fails.
+	FailurePolicyIgnore FailurePolicy = "Ignore"
+
+	defaultWebhookTimeout = 10 * time.Second
+)
+
+type (
+	// FailurePolicy decides what to do if the webhook call fails.
+	FailurePolicy string
+
+	// WebhookMutator calls out to an external service to mutate deployments.
+	// The deployment is POSTed as a WebhookRequest and the service replies with a
+	// WebhookResponse holding the mutated deployment, or no deployment if nothing
+	// should change.
+	WebhookMutator struct {
+		logger        *zap.Logger
+		url           string
+		httpClient    *http.Client
+		failurePolicy FailurePolicy
+	}
+
+	WebhookRequest struct {
+		ExecutorType fv1.ExecutorType   `json:"executorType"`
+		Environment  *fv1.Environment   `json:"environment"`
+		Function     *fv1.Function      `json:"function,omitempty"`
+		Deployment   *appsv1.Deployment `json:"deployment"`
+	}
+
+	WebhookResponse struct {
+		Deployment *appsv1.Deployment `json:"deployment,omitempty"`
+	}
+)
+
+func MakeWebhookMutator(logger *zap.Logger, url string, timeout time.Duration, failurePolicy FailurePolicy) (*WebhookMutator, error) {
+	switch failurePolicy {
+	case "":
+		failurePolicy = FailurePolicyFail
+	case FailurePolicyFail, FailurePolicyIgnore:
+	default:
+		return nil, errors.Errorf("unsupported webhook failure policy %q, must be one of %v or %v",
+			failurePolicy, FailurePolicyFail, FailurePolicyIgnore)
+	}
+
+	return &WebhookMutator{
+		logger:        logger.Named("pod_mutation_webhook"),
+		url:           url,
+		httpClient:    &http.Client{Timeout: timeout},
+		failurePolicy: failurePolicy,
+	}, nil
+}
+
+func (w *WebhookMutator) Name() string {
+	return "webhook"
+}
+
+func (w *WebhookMutator) MutateDeployment(target *Target, deployment *appsv1.Deployment) error {
+	mutated, err := w.call(target, deployment)
+	if err != nil {
+		if w.failurePolicy == FailurePolicyIgnore {
+			w.logger.Error("error mutating deployment, ignoring as per failure policy",
+				zap.Error(err), zap.String("deployment", deployment.ObjectMeta.Name))
+			return nil
+		}
+		return err
+	}
+
+	if mutated != nil {
+		*deployment = *mutated
+	}
+	return nil
+}
+
+func (w *WebhookMutator) call(target *Target, deployment *appsv1.Deployment) (*appsv1.Deployment, error) {
+	body, err := json.Marshal(WebhookRequest{
+		ExecutorType: target.ExecutorType,
+		Environment:  target.Environment,
+		Function:     target.Function,
+		Deployment:   deployment,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshaling webhook request")
+	}
+
+	resp, err := w.httpClient.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error calling pod mutation webhook %v", w.url)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading webhook response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("pod mutation webhook %v returned status code %v: %s", w.url, resp.StatusCode, string(respBody))
+	}
+
+	result := &WebhookResponse{}
+	err = json.Unmarshal(respBody, result)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding webhook response")
+	}
+	return result.Deployment, nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+var (
+	// namespace, name: the function's metadata
+	// environment: the name of the function's environment
+	// executortype: poolmgr | newdeploy
+	// result: success | failure
+	coldStartDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "fission_executor_cold_start_duration_seconds",
+			Help:    "How long the cold starts of functions took, from specialization request to function service ready.",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+		},
+		[]string{"namespace", "name", "environment", "executortype", "result"},
+	)
+	// priority: the priority of the request that waited
+	specializationQueueWait = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "fission_executor_specialization_queue_wait_seconds",
+			Help:    "How long specialization requests waited for a specialization slot.",
+			Buckets: []float64{0.001, 0.01, 0.1, 0.5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"priority"},
+	)
+	// executortype: poolmgr | newdeploy
+	// result: hit | miss
+	addressCacheLookups = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fission_executor_address_cache_lookups_total",
+			Help: "How many function service addresses were looked up in the cache, by result.",
+		},
+		[]string{"executortype", "result"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(coldStartDuration)
+	prometheus.MustRegister(specializationQueueWait)
+	prometheus.MustRegister(addressCacheLookups)
+}
+
+func observeColdStart(fn *fv1.Function, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	coldStartDuration.WithLabelValues(fn.ObjectMeta.Namespace, fn.ObjectMeta.Name, fn.Spec.Environment.Name,
+		string(fn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType), result).Observe(time.Since(start).Seconds())
+}
+
+func observeSpecializationQueueWait(priority fv1.InvocationPriority, start time.Time) {
+	specializationQueueWait.WithLabelValues(string(priority)).Observe(time.Since(start).Seconds())
+}
+
+func countAddressCacheLookup(executorType fv1.ExecutorType, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	addressCacheLookups.WithLabelValues(string(executorType), result).Inc()
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/executor/executortype"
+	"github.com/fission/fission/pkg/executor/fscache"
+	"github.com/fission/fission/pkg/qos"
+)
+
+// fakeExecutorType serves function services from a cache holding at most
+// one service, and specializes functions unless err is set. Methods not
+// used by the executor API panic.
+type fakeExecutorType struct {
+	executortype.ExecutorType
+
+	cached *fscache.FuncSvc
+	err    error
+}
+
+func (f *fakeExecutorType) GetFuncSvc(ctx context.Context, fn *fv1.Function) (*fscache.FuncSvc, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &fscache.FuncSvc{Name: fn.ObjectMeta.Name, Function: &fn.ObjectMeta, Address: "10.0.0.1:8888"}, nil
+}
+
+func (f *fakeExecutorType) GetFuncSvcFromCache(fn *fv1.Function) (*fscache.FuncSvc, error) {
+	if f.cached == nil {
+		return nil, errors.New("function service not cached")
+	}
+	return f.cached, nil
+}
+
+func (f *fakeExecutorType) DeleteFuncSvcFromCache(fsvc *fscache.FuncSvc) {
+	f.cached = nil
+}
+
+func (f *fakeExecutorType) IsValid(fsvc *fscache.FuncSvc) bool {
+	return true
+}
+
+func makeMetricsTestFunction(name string) *fv1.Function {
+	return &fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceDefault, UID: types.UID(name), Generation: 1},
+		Spec: fv1.FunctionSpec{
+			Environment: fv1.EnvironmentReference{Name: "nodejs", Namespace: metav1.NamespaceDefault},
+			InvokeStrategy: fv1.InvokeStrategy{
+				ExecutionStrategy: fv1.ExecutionStrategy{ExecutorType: fv1.ExecutorTypeNewdeploy},
+			},
+		},
+	}
+}
+
+// histogramCount returns the number of observations of the histogram named
+// name with the given labels.
+func histogramCount(t *testing.T, name string, labels map[string]string) uint64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			matched := 0
+			for _, label := range metric.GetLabel() {
+				if value, ok := labels[label.GetName()]; ok && value == label.GetValue() {
+					matched++
+				}
+			}
+			if matched == len(labels) {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
+
+func coldStarts(t *testing.T, fn *fv1.Function, result string) uint64 {
+	return histogramCount(t, "fission_executor_cold_start_duration_seconds", map[string]string{
+		"namespace":    fn.ObjectMeta.Namespace,
+		"name":         fn.ObjectMeta.Name,
+		"environment":  fn.Spec.Environment.Name,
+		"executortype": string(fv1.ExecutorTypeNewdeploy),
+		"result":       result,
+	})
+}
+
+func queueWaits(t *testing.T, priority fv1.InvocationPriority) uint64 {
+	return histogramCount(t, "fission_executor_specialization_queue_wait_seconds", map[string]string{"priority": string(priority)})
+}
+
+func TestServiceForFunctionMetrics(t *testing.T) {
+	et := &fakeExecutorType{}
+	executor := &Executor{
+		logger:                zap.NewNop(),
+		executorTypes:         map[fv1.ExecutorType]executortype.ExecutorType{fv1.ExecutorTypeNewdeploy: et},
+		requestChan:           make(chan *createFuncServiceRequest),
+		fsCreateWg:            make(map[string]*sync.WaitGroup),
+		specializationLimiter: qos.MakeLimiter(1),
+	}
+	go executor.serveCreateFuncServices()
+
+	getService := func(fn *fv1.Function, priority fv1.InvocationPriority) int {
+		body, err := json.Marshal(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/v2/getServiceForFunction", bytes.NewReader(body))
+		req.Header.Set(fv1.HeaderPriority, string(priority))
+		w := httptest.NewRecorder()
+		executor.getServiceForFunctionAPI(w, req)
+		return w.Code
+	}
+	hits := addressCacheLookups.WithLabelValues(string(fv1.ExecutorTypeNewdeploy), "hit")
+	misses := addressCacheLookups.WithLabelValues(string(fv1.ExecutorTypeNewdeploy), "miss")
+
+	// a cache miss specializes the function
+	fn := makeMetricsTestFunction("metrics-cold")
+	startHits, startMisses := testutil.ToFloat64(hits), testutil.ToFloat64(misses)
+	startWaits := queueWaits(t, fv1.InvocationPriorityInteractive)
+	if code := getService(fn, fv1.InvocationPriorityInteractive); code != http.StatusOK {
+		t.Fatalf("expected function service, got status %v", code)
+	}
+	if testutil.ToFloat64(misses) != startMisses+1 || testutil.ToFloat64(hits) != startHits {
+		t.Errorf("expected a cache miss, got %v hits and %v misses", testutil.ToFloat64(hits)-startHits, testutil.ToFloat64(misses)-startMisses)
+	}
+	if coldStarts(t, fn, "success") != 1 || coldStarts(t, fn, "failure") != 0 {
+		t.Errorf("expected a successful cold start")
+	}
+	if queueWaits(t, fv1.InvocationPriorityInteractive) != startWaits+1 {
+		t.Errorf("expected specialization queue wait of interactive request")
+	}
+
+	// a cache hit doesn't
+	et.cached = &fscache.FuncSvc{Name: fn.ObjectMeta.Name, Function: &fn.ObjectMeta, Address: "10.0.0.1:8888"}
+	if code := getService(fn, fv1.InvocationPriorityInteractive); code != http.StatusOK {
+		t.Fatalf("expected cached function service, got status %v", code)
+	}
+	if testutil.ToFloat64(hits) != startHits+1 || testutil.ToFloat64(misses) != startMisses+1 {
+		t.Errorf("expected a cache hit, got %v hits and %v misses", testutil.ToFloat64(hits)-startHits, testutil.ToFloat64(misses)-startMisses)
+	}
+	if coldStarts(t, fn, "success") != 1 {
+		t.Errorf("expected no cold start on cache hit")
+	}
+
+	// failed specializations are cold starts too
+	et.cached = nil
+	et.err = errors.New("no pods")
+	failed := makeMetricsTestFunction("metrics-failed")
+	if code := getService(failed, fv1.InvocationPriorityBatch); code == http.StatusOK {
+		t.Fatal("expected error specializing function")
+	}
+	if testutil.ToFloat64(misses) != startMisses+2 {
+		t.Errorf("expected a cache miss, got %v misses", testutil.ToFloat64(misses)-startMisses)
+	}
+	if coldStarts(t, failed, "failure") != 1 || coldStarts(t, failed, "success") != 0 {
+		t.Errorf("expected a failed cold start")
+	}
+}
+
+func TestSpecializationQueueWaitMetrics(t *testing.T) {
+	limiter := qos.MakeLimiter(1)
+	executor := &Executor{
+		logger:                zap.NewNop(),
+		executorTypes:         map[fv1.ExecutorType]executortype.ExecutorType{fv1.ExecutorTypeNewdeploy: &fakeExecutorType{}},
+		specializationLimiter: limiter,
+	}
+
+	// the only slot is taken, so the request times out waiting
+	release, err := limiter.Acquire(context.Background(), fv1.InvocationPriorityInteractive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	fn := makeMetricsTestFunction("metrics-queued")
+	startWaits := queueWaits(t, fv1.InvocationPriorityBatch)
+	_, err = executor.createServiceForFunction(ctx, fn, fv1.InvocationPriorityBatch)
+	if err == nil {
+		t.Fatal("expected error waiting for specialization slot")
+	}
+	if queueWaits(t, fv1.InvocationPriorityBatch) != startWaits+1 {
+		t.Errorf("expected specialization queue wait of timed out request")
+	}
+	if coldStarts(t, fn, "success")+coldStarts(t, fn, "failure") != 0 {
+		t.Errorf("expected no cold start without specialization slot")
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quarantine
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sCache "k8s.io/client-go/tools/cache"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// watchPods records the restarts of the containers of the pods serving
+// functions, which crash on their first requests once specialized.
+func (q *Quarantine) watchPods(ctx context.Context) {
+	lw := k8sCache.NewFilteredListWatchFromClient(q.kubernetesClient.CoreV1().RESTClient(), "pods", metav1.NamespaceAll,
+		func(options *metav1.ListOptions) {
+			// only pods specialized for a function
+			options.LabelSelector = fv1.FUNCTION_UID
+		})
+	_, controller := k8sCache.NewInformer(lw, &apiv1.Pod{}, 30*time.Minute, k8sCache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldPod := oldObj.(*apiv1.Pod)
+			pod := newObj.(*apiv1.Pod)
+			crashes := podCrashes(oldPod, pod)
+			if len(crashes) == 0 {
+				return
+			}
+			fn := &metav1.ObjectMeta{
+				Name:      pod.ObjectMeta.Labels[fv1.FUNCTION_NAME],
+				Namespace: pod.ObjectMeta.Labels[fv1.FUNCTION_NAMESPACE],
+				UID:       types.UID(pod.ObjectMeta.Labels[fv1.FUNCTION_UID]),
+			}
+			// newdeploy pods don't tell the generation they serve
+			generation, _ := strconv.ParseInt(pod.ObjectMeta.Annotations[fv1.FUNCTION_GENERATION], 10, 64)
+			for _, crash := range crashes {
+				q.RecordFailure(fn, generation, crash)
+			}
+		},
+	})
+	controller.Run(ctx.Done())
+}
+
+// podCrashes describes the containers of a pod that terminated since the
+// old version of the pod.
+func podCrashes(oldPod *apiv1.Pod, pod *apiv1.Pod) []string {
+	restarts := make(map[string]int32)
+	for _, status := range oldPod.Status.ContainerStatuses {
+		restarts[status.Name] = status.RestartCount
+	}
+
+	var crashes []string
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.RestartCount <= restarts[status.Name] {
+			continue
+		}
+		crash := fmt.Sprintf("container %v of pod %v restarted", status.Name, pod.ObjectMeta.Name)
+		if t := status.LastTerminationState.Terminated; t != nil {
+			crash = fmt.Sprintf("container %v of pod %v exited with code %v", status.Name, pod.ObjectMeta.Name, t.ExitCode)
+			if len(t.Reason) > 0 {
+				crash += fmt.Sprintf(" (%v)", t.Reason)
+			}
+			if len(t.Message) > 0 {
+				crash += ": " + t.Message
+			}
+		}
+		crashes = append(crashes, crash)
+	}
+	return crashes
+}
@@ -0,0 +1,313 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package quarantine detects functions whose pods crash over and over
+// while they are specialized or serve their first requests, and stops
+// specializing them until their spec changes.
+package quarantine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/fnstatus"
+	"github.com/fission/fission/pkg/publisher"
+)
+
+// DefaultWindow is how long crashes count towards quarantining a function.
+const DefaultWindow = 10 * time.Minute
+
+type (
+	// Policy decides when a function is quarantined. A nil policy
+	// quarantines nothing.
+	Policy struct {
+		// MaxFailures is how many crashes within the window quarantine
+		// a function.
+		MaxFailures int
+
+		Window time.Duration
+	}
+
+	// Quarantine counts the crashes of the pods of functions, and refuses
+	// to specialize the functions that crash as often as the policy allows
+	// until their spec changes. A nil quarantine refuses nothing.
+	Quarantine struct {
+		logger           *zap.Logger
+		policy           Policy
+		kubernetesClient kubernetes.Interface
+		statusRecorder   *fnstatus.Recorder
+		webhook          publisher.Publisher
+		now              func() time.Time
+
+		lock      sync.Mutex
+		functions map[types.UID]*record
+	}
+
+	// record is what is known of the crashes of a generation of a function.
+	record struct {
+		meta        metav1.ObjectMeta
+		generation  int64
+		failures    []time.Time
+		quarantined bool
+		lastError   string
+	}
+
+	// Notification is the body of the requests sent to the webhook when a
+	// function is quarantined or released.
+	Notification struct {
+		Function    string `json:"function"`
+		Namespace   string `json:"namespace"`
+		UID         string `json:"uid"`
+		Generation  int64  `json:"generation,omitempty"`
+		Quarantined bool   `json:"quarantined"`
+		Failures    int    `json:"failures,omitempty"`
+		LastError   string `json:"lastError,omitempty"`
+	}
+)
+
+// ParsePolicy returns the policy quarantining functions crashing maxFailures
+// times within window, or nil if maxFailures is empty or 0.
+func ParsePolicy(maxFailures string, window string) (*Policy, error) {
+	if len(maxFailures) == 0 {
+		return nil, nil
+	}
+	policy := &Policy{
+		Window: DefaultWindow,
+	}
+
+	var err error
+	policy.MaxFailures, err = strconv.Atoi(maxFailures)
+	if err != nil || policy.MaxFailures < 0 {
+		return nil, errors.Errorf("invalid max failures %q", maxFailures)
+	}
+	if policy.MaxFailures == 0 {
+		return nil, nil
+	}
+	if len(window) > 0 {
+		policy.Window, err = time.ParseDuration(window)
+		if err != nil || policy.Window <= 0 {
+			return nil, errors.Errorf("invalid window %q", window)
+		}
+	}
+	return policy, nil
+}
+
+// MakeQuarantine returns a quarantine following the policy, or nil if the
+// policy is nil. Functions quarantined and released are notified with
+// events, conditions of their status and requests to the webhook URL, if
+// set.
+func MakeQuarantine(logger *zap.Logger, policy *Policy, kubernetesClient kubernetes.Interface,
+	statusRecorder *fnstatus.Recorder, webhookURL string) *Quarantine {
+	if policy == nil {
+		return nil
+	}
+	logger = logger.Named("quarantine")
+	q := &Quarantine{
+		logger:           logger,
+		policy:           *policy,
+		kubernetesClient: kubernetesClient,
+		statusRecorder:   statusRecorder,
+		now:              time.Now,
+		functions:        make(map[types.UID]*record),
+	}
+	if len(webhookURL) > 0 {
+		q.webhook = publisher.MakeWebhookPublisher(logger, webhookURL)
+	}
+	return q
+}
+
+// Check returns an error if the function is quarantined. A function whose
+// spec changed since it was quarantined is released.
+func (q *Quarantine) Check(fn *metav1.ObjectMeta) error {
+	if q == nil {
+		return nil
+	}
+	q.lock.Lock()
+	rec, ok := q.functions[fn.UID]
+	if !ok {
+		q.lock.Unlock()
+		return nil
+	}
+	if rec.generation == 0 {
+		// crashes of pods not telling their generation count for the
+		// generation the function is at
+		rec.generation = fn.Generation
+	}
+	if fn.Generation > rec.generation {
+		delete(q.functions, fn.UID)
+		q.lock.Unlock()
+		if rec.quarantined {
+			q.notify(fn, rec, false)
+		}
+		return nil
+	}
+	quarantined, failures, lastError := rec.quarantined, len(rec.failures), rec.lastError
+	// functions that stopped crashing are forgotten
+	if !quarantined && q.now().Sub(rec.failures[len(rec.failures)-1]) >= q.policy.Window {
+		delete(q.functions, fn.UID)
+	}
+	q.lock.Unlock()
+
+	if !quarantined {
+		return nil
+	}
+	return errors.Errorf("function %v/%v is quarantined after crashing %v times within %v, update the function to release it: %v",
+		fn.Namespace, fn.Name, failures, q.policy.Window, lastError)
+}
+
+// RecordFailure records a crash of a generation of a function, or of the
+// generation the function is at if 0, and quarantines the function once it
+// crashed as often as the policy allows. Crashes of older generations are
+// ignored.
+func (q *Quarantine) RecordFailure(fn *metav1.ObjectMeta, generation int64, message string) {
+	if q == nil {
+		return
+	}
+	now := q.now()
+
+	q.lock.Lock()
+	rec, ok := q.functions[fn.UID]
+	if !ok || (generation > rec.generation && rec.generation > 0) {
+		rec = &record{generation: generation}
+		q.functions[fn.UID] = rec
+	} else if generation > 0 && generation < rec.generation {
+		q.lock.Unlock()
+		return
+	}
+	if rec.generation == 0 {
+		rec.generation = generation
+	}
+	rec.meta = metav1.ObjectMeta{Name: fn.Name, Namespace: fn.Namespace, UID: fn.UID}
+	rec.lastError = message
+
+	// only the crashes within the window count
+	failures := rec.failures[:0]
+	for _, t := range rec.failures {
+		if now.Sub(t) < q.policy.Window {
+			failures = append(failures, t)
+		}
+	}
+	rec.failures = append(failures, now)
+
+	quarantine := !rec.quarantined && len(rec.failures) >= q.policy.MaxFailures
+	if quarantine {
+		rec.quarantined = true
+	}
+	notified := *rec
+	q.lock.Unlock()
+
+	if quarantine {
+		q.notify(&notified.meta, &notified, true)
+	}
+}
+
+// notify records the Quarantined condition of the function, and sends an
+// event and a request to the webhook.
+func (q *Quarantine) notify(fn *metav1.ObjectMeta, rec *record, quarantined bool) {
+	n := Notification{
+		Function:    fn.Name,
+		Namespace:   fn.Namespace,
+		UID:         string(fn.UID),
+		Generation:  rec.generation,
+		Quarantined: quarantined,
+	}
+
+	var eventType, reason, message string
+	if quarantined {
+		n.Failures, n.LastError = len(rec.failures), rec.lastError
+		eventType, reason = apiv1.EventTypeWarning, "Quarantined"
+		message = fmt.Sprintf("Function crashed %v times within %v and is no longer specialized until it is updated: %v",
+			len(rec.failures), q.policy.Window, rec.lastError)
+		q.statusRecorder.Record(fn, fv1.FunctionQuarantined, apiv1.ConditionTrue, "CrashLoop", message)
+		q.logger.Warn("function quarantined",
+			zap.String("function_name", fn.Name),
+			zap.String("function_namespace", fn.Namespace),
+			zap.Int("failures", len(rec.failures)),
+			zap.String("last_error", rec.lastError))
+	} else {
+		n.Generation = fn.Generation
+		eventType, reason = apiv1.EventTypeNormal, "Released"
+		message = "Function was updated and is specialized again"
+		q.statusRecorder.Record(fn, fv1.FunctionQuarantined, apiv1.ConditionFalse, "SpecChanged", "")
+		q.logger.Info("function released from quarantine",
+			zap.String("function_name", fn.Name),
+			zap.String("function_namespace", fn.Namespace))
+	}
+
+	if err := q.recordEvent(fn, eventType, reason, message); err != nil {
+		q.logger.Error("error recording quarantine event", zap.Error(err),
+			zap.String("function_name", fn.Name),
+			zap.String("function_namespace", fn.Namespace))
+	}
+
+	if q.webhook != nil {
+		body, err := json.Marshal(n)
+		if err != nil {
+			q.logger.Error("error encoding quarantine notification", zap.Error(err))
+			return
+		}
+		q.webhook.Publish(string(body), map[string]string{"Content-Type": "application/json"}, "")
+	}
+}
+
+func (q *Quarantine) recordEvent(fn *metav1.ObjectMeta, eventType, reason, message string) error {
+	if q.kubernetesClient == nil {
+		return nil
+	}
+	now := metav1.NewTime(q.now())
+	_, err := q.kubernetesClient.CoreV1().Events(fn.Namespace).Create(&apiv1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%v.%x", fn.Name, now.UnixNano()),
+			Namespace: fn.Namespace,
+		},
+		InvolvedObject: apiv1.ObjectReference{
+			APIVersion: fv1.CRD_VERSION,
+			Kind:       "Function",
+			Name:       fn.Name,
+			Namespace:  fn.Namespace,
+			UID:        fn.UID,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         apiv1.EventSource{Component: "fission-executor"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	})
+	return err
+}
+
+// Run counts the restarts of the containers of function pods as crashes
+// until the context is done.
+func (q *Quarantine) Run(ctx context.Context) {
+	if q == nil || q.kubernetesClient == nil {
+		return
+	}
+	q.watchPods(ctx)
+}
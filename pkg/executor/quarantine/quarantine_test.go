@@ -0,0 +1,138 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quarantine
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestParsePolicy(t *testing.T) {
+	for _, test := range []struct {
+		maxFailures, window string
+		expected            *Policy
+		err                 bool
+	}{
+		{maxFailures: "", window: "1m"},
+		{maxFailures: "0", window: "1m"},
+		{maxFailures: "3", expected: &Policy{MaxFailures: 3, Window: DefaultWindow}},
+		{maxFailures: "5", window: "1m", expected: &Policy{MaxFailures: 5, Window: time.Minute}},
+		{maxFailures: "-1", err: true},
+		{maxFailures: "many", err: true},
+		{maxFailures: "3", window: "0s", err: true},
+	} {
+		policy, err := ParsePolicy(test.maxFailures, test.window)
+		if (err != nil) != test.err {
+			t.Errorf("%q %q: unexpected error %v", test.maxFailures, test.window, err)
+			continue
+		}
+		if (policy == nil) != (test.expected == nil) || (policy != nil && *policy != *test.expected) {
+			t.Errorf("%q %q: expected policy %v, got %v", test.maxFailures, test.window, test.expected, policy)
+		}
+	}
+}
+
+func TestQuarantine(t *testing.T) {
+	now := time.Now()
+	q := &Quarantine{
+		logger:    zap.NewNop(),
+		policy:    Policy{MaxFailures: 3, Window: time.Minute},
+		now:       func() time.Time { return now },
+		functions: make(map[types.UID]*record),
+	}
+	fn := &metav1.ObjectMeta{Name: "hello", Namespace: "default", UID: "uid", Generation: 2}
+
+	q.RecordFailure(fn, 2, "exit 1")
+	now = now.Add(2 * time.Minute)
+	// the first crash is out of the window
+	q.RecordFailure(fn, 2, "exit 1")
+	q.RecordFailure(fn, 2, "exit 1")
+	if err := q.Check(fn); err != nil {
+		t.Fatalf("expected function not to be quarantined, got %v", err)
+	}
+	// crashes of older generations don't count
+	q.RecordFailure(fn, 1, "exit 1")
+	if err := q.Check(fn); err != nil {
+		t.Fatalf("expected function not to be quarantined, got %v", err)
+	}
+
+	// crashes of pods not telling their generation count
+	q.RecordFailure(fn, 0, "exit 2")
+	if err := q.Check(fn); err == nil {
+		t.Fatal("expected function to be quarantined")
+	}
+	// quarantined functions are not released once the window passes
+	now = now.Add(time.Hour)
+	if err := q.Check(fn); err == nil {
+		t.Fatal("expected function to stay quarantined")
+	}
+
+	updated := *fn
+	updated.Generation = 3
+	if err := q.Check(&updated); err != nil {
+		t.Fatalf("expected updated function to be released, got %v", err)
+	}
+	if len(q.functions) != 0 {
+		t.Errorf("expected released function to be forgotten, got %v records", len(q.functions))
+	}
+
+	// functions that stopped crashing are forgotten
+	q.RecordFailure(&updated, 3, "exit 1")
+	now = now.Add(time.Minute)
+	if err := q.Check(&updated); err != nil || len(q.functions) != 0 {
+		t.Errorf("expected function to be forgotten, got %v and %v records", err, len(q.functions))
+	}
+
+	// a nil quarantine refuses nothing
+	var none *Quarantine
+	none.RecordFailure(fn, 2, "exit 1")
+	if err := none.Check(fn); err != nil {
+		t.Errorf("expected nil quarantine to refuse nothing, got %v", err)
+	}
+}
+
+func TestPodCrashes(t *testing.T) {
+	pod := func(restarts int32, terminated *apiv1.ContainerStateTerminated) *apiv1.Pod {
+		return &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod"},
+			Status: apiv1.PodStatus{
+				ContainerStatuses: []apiv1.ContainerStatus{
+					{Name: "fetcher"},
+					{
+						Name:                 "hello",
+						RestartCount:         restarts,
+						LastTerminationState: apiv1.ContainerState{Terminated: terminated},
+					},
+				},
+			},
+		}
+	}
+
+	if crashes := podCrashes(pod(1, nil), pod(1, nil)); len(crashes) != 0 {
+		t.Errorf("expected no crash, got %v", crashes)
+	}
+	crashes := podCrashes(pod(0, nil), pod(1, &apiv1.ContainerStateTerminated{ExitCode: 1, Reason: "Error", Message: "boom"}))
+	expected := "container hello of pod pod exited with code 1 (Error): boom"
+	if len(crashes) != 1 || crashes[0] != expected {
+		t.Errorf("expected crash %q, got %v", expected, crashes)
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	apiv1 "k8s.io/api/core/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/executor/fscache"
+)
+
+const (
+	// DefaultMaxEvictions is how many pods are evicted at most every
+	// time the cluster is found under pressure.
+	DefaultMaxEvictions = 5
+
+	// DefaultEvictionInterval is how often the cluster is checked for
+	// pressure.
+	DefaultEvictionInterval = 10 * time.Second
+)
+
+// priorityWeights are how much the pods of functions of each priority are
+// worth keeping. Interactive functions need four times the traffic of
+// standard ones to be evicted before them.
+var priorityWeights = map[fv1.InvocationPriority]float64{
+	fv1.InvocationPriorityInteractive: 8,
+	fv1.InvocationPriorityStandard:    2,
+	fv1.InvocationPriorityBatch:       1,
+}
+
+type (
+	// EvictionPolicy decides which idle specialized pods are evicted when
+	// pods of functions can't be scheduled for lack of resources, before
+	// their idle timeout. A nil policy evicts nothing.
+	EvictionPolicy struct {
+		// MinIdle is how long a pod must have served no request to be
+		// evicted.
+		MinIdle time.Duration
+
+		// MaxEvictions is how many pods are evicted at most at a time, to
+		// give the scheduler a chance to use the freed resources before
+		// evicting more.
+		MaxEvictions int
+	}
+
+	// EvictionCandidate is an idle specialized pod of a function.
+	EvictionCandidate struct {
+		Svc      *fscache.FuncSvc
+		Priority fv1.InvocationPriority
+	}
+)
+
+// ParseEvictionPolicy returns the eviction policy for pods idle for minIdle,
+// evicting up to maxEvictions pods at a time, or nil if minIdle is empty.
+func ParseEvictionPolicy(minIdle string, maxEvictions string) (*EvictionPolicy, error) {
+	if len(minIdle) == 0 {
+		return nil, nil
+	}
+	policy := &EvictionPolicy{
+		MaxEvictions: DefaultMaxEvictions,
+	}
+
+	var err error
+	policy.MinIdle, err = time.ParseDuration(minIdle)
+	if err != nil || policy.MinIdle < 0 {
+		return nil, errors.Errorf("invalid min idle time %q", minIdle)
+	}
+	if len(maxEvictions) > 0 {
+		policy.MaxEvictions, err = strconv.Atoi(maxEvictions)
+		if err != nil || policy.MaxEvictions <= 0 {
+			return nil, errors.Errorf("invalid max evictions %q", maxEvictions)
+		}
+	}
+	return policy, nil
+}
+
+// score returns how much a candidate is worth keeping: its priority weight,
+// scaled by its recent traffic.
+func (c *EvictionCandidate) score(now time.Time) float64 {
+	weight, ok := priorityWeights[c.Priority]
+	if !ok {
+		weight = priorityWeights[fv1.InvocationPriorityStandard]
+	}
+	return weight * (1 + c.Svc.RecentTraffic(now))
+}
+
+// SelectVictims returns the candidates to evict, the least worth keeping
+// first. Candidates idle for less than MinIdle are kept.
+func (policy *EvictionPolicy) SelectVictims(candidates []EvictionCandidate, now time.Time) []EvictionCandidate {
+	if policy == nil {
+		return nil
+	}
+
+	victims := make([]EvictionCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if now.Sub(c.Svc.Atime) >= policy.MinIdle {
+			victims = append(victims, c)
+		}
+	}
+	sort.SliceStable(victims, func(i, j int) bool {
+		si, sj := victims[i].score(now), victims[j].score(now)
+		if si != sj {
+			return si < sj
+		}
+		// the longest idle first
+		return victims[i].Svc.Atime.Before(victims[j].Svc.Atime)
+	})
+
+	if len(victims) > policy.MaxEvictions {
+		victims = victims[:policy.MaxEvictions]
+	}
+	return victims
+}
+
+// IsUnschedulable returns true if the scheduler found no node with enough
+// resources for a pod.
+func IsUnschedulable(pod *apiv1.Pod) bool {
+	if pod.Status.Phase != apiv1.PodPending {
+		return false
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == apiv1.PodScheduled && c.Status == apiv1.ConditionFalse &&
+			c.Reason == apiv1.PodReasonUnschedulable {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/executor/fscache"
+)
+
+func TestSelectVictims(t *testing.T) {
+	now := time.Now()
+	candidate := func(name string, priority fv1.InvocationPriority, idle time.Duration, traffic float64) EvictionCandidate {
+		return EvictionCandidate{
+			Svc: &fscache.FuncSvc{
+				Name:    name,
+				Atime:   now.Add(-idle),
+				Traffic: traffic,
+			},
+			Priority: priority,
+		}
+	}
+
+	policy := &EvictionPolicy{MinIdle: time.Minute, MaxEvictions: 3}
+	victims := policy.SelectVictims([]EvictionCandidate{
+		candidate("interactive", fv1.InvocationPriorityInteractive, 10*time.Minute, 0),
+		candidate("busy-standard", fv1.InvocationPriorityStandard, 2*time.Minute, 100),
+		candidate("just-used", fv1.InvocationPriorityBatch, 10*time.Second, 0),
+		candidate("standard", fv1.InvocationPriorityStandard, 5*time.Minute, 0),
+		candidate("batch", fv1.InvocationPriorityBatch, 2*time.Minute, 0),
+		candidate("older-batch", fv1.InvocationPriorityBatch, 20*time.Minute, 0),
+	}, now)
+
+	expected := []string{"older-batch", "batch", "standard"}
+	if len(victims) != len(expected) {
+		t.Fatalf("expected %v victims, got %v", len(expected), len(victims))
+	}
+	for i, name := range expected {
+		if victims[i].Svc.Name != name {
+			t.Errorf("expected victim %v to be %v, got %v", i, name, victims[i].Svc.Name)
+		}
+	}
+
+	var nilPolicy *EvictionPolicy
+	if victims := nilPolicy.SelectVictims([]EvictionCandidate{candidate("batch", fv1.InvocationPriorityBatch, time.Hour, 0)}, now); len(victims) != 0 {
+		t.Fatal("expected nil policy to evict nothing")
+	}
+}
+
+func TestParseEvictionPolicy(t *testing.T) {
+	policy, err := ParseEvictionPolicy("", "")
+	if err != nil || policy != nil {
+		t.Fatalf("expected no policy without min idle time, got %v, %v", policy, err)
+	}
+
+	policy, err = ParseEvictionPolicy("30s", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.MinIdle != 30*time.Second || policy.MaxEvictions != DefaultMaxEvictions {
+		t.Fatalf("unexpected policy %+v", policy)
+	}
+
+	if _, err = ParseEvictionPolicy("30s", "0"); err == nil {
+		t.Fatal("expected error for non-positive max evictions")
+	}
+	if _, err = ParseEvictionPolicy("soon", ""); err == nil {
+		t.Fatal("expected error for invalid min idle time")
+	}
+}
+
+func TestIsUnschedulable(t *testing.T) {
+	pod := &apiv1.Pod{
+		Status: apiv1.PodStatus{
+			Phase: apiv1.PodPending,
+			Conditions: []apiv1.PodCondition{{
+				Type:   apiv1.PodScheduled,
+				Status: apiv1.ConditionFalse,
+				Reason: apiv1.PodReasonUnschedulable,
+			}},
+		},
+	}
+	if !IsUnschedulable(pod) {
+		t.Fatal("expected pod to be unschedulable")
+	}
+	pod.Status.Conditions[0].Status = apiv1.ConditionTrue
+	if IsUnschedulable(pod) {
+		t.Fatal("expected scheduled pod not to be unschedulable")
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// coLocationWeight is the weight of the preferred pod affinity to the pods
+// of co-located functions, the highest possible.
+const coLocationWeight = 100
+
+// CoLocationSelector returns the selector of the pods of the functions the
+// function is co-located with, or nil if there are none.
+func CoLocationSelector(fn *fv1.Function) *metav1.LabelSelector {
+	if len(fn.Spec.CoLocateWith) == 0 {
+		return nil
+	}
+	return &metav1.LabelSelector{
+		MatchLabels: map[string]string{
+			fv1.FUNCTION_NAMESPACE: fn.ObjectMeta.Namespace,
+		},
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{
+				Key:      fv1.FUNCTION_NAME,
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   fn.Spec.CoLocateWith,
+			},
+		},
+	}
+}
+
+// CoLocationAffinity returns the affinity making the scheduler prefer the
+// nodes running pods of the functions the function is co-located with, or
+// nil if there are none.
+func CoLocationAffinity(fn *fv1.Function, podNamespace string) *apiv1.Affinity {
+	selector := CoLocationSelector(fn)
+	if selector == nil {
+		return nil
+	}
+	return &apiv1.Affinity{
+		PodAffinity: &apiv1.PodAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []apiv1.WeightedPodAffinityTerm{
+				{
+					Weight: coLocationWeight,
+					PodAffinityTerm: apiv1.PodAffinityTerm{
+						LabelSelector: selector,
+						Namespaces:    []string{podNamespace},
+						TopologyKey:   apiv1.LabelHostname,
+					},
+				},
+			},
+		},
+	}
+}
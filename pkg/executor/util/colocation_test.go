@@ -0,0 +1,67 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestCoLocationAffinity(t *testing.T) {
+	fn := &fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "resize",
+			Namespace: "pipeline",
+		},
+	}
+	if CoLocationAffinity(fn, "fission-function") != nil {
+		t.Fatal("expected no affinity without co-located functions")
+	}
+
+	fn.Spec.CoLocateWith = []string{"upload", "thumbnail"}
+	affinity := CoLocationAffinity(fn, "fission-function")
+	if affinity == nil || affinity.PodAffinity == nil || len(affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution) != 1 {
+		t.Fatalf("unexpected affinity %v", affinity)
+	}
+	term := affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm
+	if term.TopologyKey != "kubernetes.io/hostname" || len(term.Namespaces) != 1 || term.Namespaces[0] != "fission-function" {
+		t.Errorf("unexpected affinity term %v", term)
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(term.LabelSelector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		labels map[string]string
+		match  bool
+	}{
+		{map[string]string{fv1.FUNCTION_NAME: "upload", fv1.FUNCTION_NAMESPACE: "pipeline"}, true},
+		{map[string]string{fv1.FUNCTION_NAME: "thumbnail", fv1.FUNCTION_NAMESPACE: "pipeline"}, true},
+		{map[string]string{fv1.FUNCTION_NAME: "upload", fv1.FUNCTION_NAMESPACE: "other"}, false},
+		{map[string]string{fv1.FUNCTION_NAME: "resize", fv1.FUNCTION_NAMESPACE: "pipeline"}, false},
+	}
+	for _, test := range tests {
+		if match := selector.Matches(labels.Set(test.labels)); match != test.match {
+			t.Errorf("pod with labels %v: expected match %v, got %v", test.labels, test.match, match)
+		}
+	}
+}
@@ -17,12 +17,15 @@ limitations under the License.
 package util
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/imdario/mergo"
+	"github.com/pkg/errors"
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
 )
 
 // TODO: replace functions here with native kubernetes strategic merge patch.
@@ -54,6 +57,78 @@ func MergeContainer(dst *apiv1.Container, src *apiv1.Container) (*apiv1.Containe
 	return &dstC, errs.ErrorOrNil()
 }
 
+// StrategicMergePodSpec merges patch into a pod spec with the strategic
+// merge patch of Kubernetes, as kubectl patch does. Containers, init
+// containers, volumes, and the env variables, ports and volume mounts of
+// containers are merged by name (mount path and port for the last two),
+// tolerations, image pull secrets and host aliases are appended, as
+// MergePodSpec does, other lists are replaced, and structs like the
+// security context and the affinity are merged field by field. Fields left
+// empty in patch are left as they are.
+func StrategicMergePodSpec(podSpec *apiv1.PodSpec, patch *apiv1.PodSpec) (*apiv1.PodSpec, error) {
+	if patch == nil {
+		return podSpec, nil
+	}
+
+	original, err := json.Marshal(podSpec)
+	if err != nil {
+		return nil, err
+	}
+	// the strategic merge would replace the lists appended below
+	replaced := *patch
+	replaced.ImagePullSecrets = nil
+	replaced.Tolerations = nil
+	replaced.HostAliases = nil
+	patchJSON, err := json.Marshal(&replaced)
+	if err != nil {
+		return nil, err
+	}
+	// lists without omitempty, like containers, are encoded as null when
+	// empty, which would delete them
+	var patchMap map[string]interface{}
+	err = json.Unmarshal(patchJSON, &patchMap)
+	if err != nil {
+		return nil, err
+	}
+	patchJSON, err = json.Marshal(dropNulls(patchMap))
+	if err != nil {
+		return nil, err
+	}
+
+	merged, err := strategicpatch.StrategicMergePatch(original, patchJSON, apiv1.PodSpec{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error merging pod spec")
+	}
+	var mergedSpec apiv1.PodSpec
+	err = json.Unmarshal(merged, &mergedSpec)
+	if err != nil {
+		return nil, err
+	}
+	mergedSpec.ImagePullSecrets = append(mergedSpec.ImagePullSecrets, patch.ImagePullSecrets...)
+	mergedSpec.Tolerations = append(mergedSpec.Tolerations, patch.Tolerations...)
+	mergedSpec.HostAliases = append(mergedSpec.HostAliases, patch.HostAliases...)
+	return &mergedSpec, nil
+}
+
+// dropNulls removes the null fields of a decoded JSON object.
+func dropNulls(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if e == nil {
+				delete(v, k)
+				continue
+			}
+			v[k] = dropNulls(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = dropNulls(e)
+		}
+	}
+	return v
+}
+
 // MergePodSpec updates srcPodSpec with targetPodSpec fields if not empty
 func MergePodSpec(srcPodSpec *apiv1.PodSpec, targetPodSpec *apiv1.PodSpec) (*apiv1.PodSpec, error) {
 	if targetPodSpec == nil {
@@ -331,3 +331,83 @@ func Test_mergeContainerList(t *testing.T) {
 		})
 	}
 }
+
+func Test_strategicMergePodSpec(t *testing.T) {
+	runAsNonRoot := true
+	var runAsUser int64 = 1000
+	podSpec := &apiv1.PodSpec{
+		Containers: []apiv1.Container{
+			{Name: "hello", Image: "node-env", Env: []apiv1.EnvVar{{Name: "env1", Value: "foobar"}}},
+			{Name: "fetcher", Image: "fetcher"},
+		},
+		Volumes:         []apiv1.Volume{{Name: "userfunc"}},
+		SecurityContext: &apiv1.PodSecurityContext{RunAsNonRoot: &runAsNonRoot},
+	}
+	patch := &apiv1.PodSpec{
+		Containers: []apiv1.Container{
+			{Name: "hello", Env: []apiv1.EnvVar{{Name: "env1", Value: "barfoo"}, {Name: "env2", Value: "foobar"}}},
+			{Name: "sidecar", Image: "envoy"},
+		},
+		InitContainers:  []apiv1.Container{{Name: "init", Image: "busybox"}},
+		Volumes:         []apiv1.Volume{{Name: "cache"}},
+		SecurityContext: &apiv1.PodSecurityContext{RunAsUser: &runAsUser},
+		Affinity: &apiv1.Affinity{
+			NodeAffinity: &apiv1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &apiv1.NodeSelector{
+					NodeSelectorTerms: []apiv1.NodeSelectorTerm{{
+						MatchExpressions: []apiv1.NodeSelectorRequirement{
+							{Key: "zone", Operator: apiv1.NodeSelectorOpIn, Values: []string{"a"}},
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	got, err := StrategicMergePodSpec(podSpec, patch)
+	if err != nil {
+		t.Fatalf("StrategicMergePodSpec() error = %v", err)
+	}
+	want := &apiv1.PodSpec{
+		Containers: []apiv1.Container{
+			{Name: "hello", Image: "node-env", Env: []apiv1.EnvVar{{Name: "env1", Value: "barfoo"}, {Name: "env2", Value: "foobar"}}},
+			{Name: "sidecar", Image: "envoy"},
+			{Name: "fetcher", Image: "fetcher"},
+		},
+		InitContainers:  []apiv1.Container{{Name: "init", Image: "busybox"}},
+		Volumes:         []apiv1.Volume{{Name: "cache"}, {Name: "userfunc"}},
+		SecurityContext: &apiv1.PodSecurityContext{RunAsNonRoot: &runAsNonRoot, RunAsUser: &runAsUser},
+		Affinity:        patch.Affinity,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("StrategicMergePodSpec() got = %+v, want %+v", got, want)
+	}
+
+	// tolerations, image pull secrets and host aliases are appended
+	withLists := podSpec.DeepCopy()
+	withLists.Tolerations = []apiv1.Toleration{{Key: "builder", Operator: apiv1.TolerationOpExists}}
+	withLists.ImagePullSecrets = []apiv1.LocalObjectReference{{Name: "registry"}}
+	got, err = StrategicMergePodSpec(withLists, &apiv1.PodSpec{
+		Tolerations:      []apiv1.Toleration{{Key: "gpu", Operator: apiv1.TolerationOpExists}},
+		ImagePullSecrets: []apiv1.LocalObjectReference{{Name: "private"}},
+		HostAliases:      []apiv1.HostAlias{{IP: "10.0.0.1", Hostnames: []string{"db"}}},
+	})
+	if err != nil {
+		t.Fatalf("StrategicMergePodSpec() error = %v", err)
+	}
+	wantLists := withLists.DeepCopy()
+	wantLists.Tolerations = append(wantLists.Tolerations, apiv1.Toleration{Key: "gpu", Operator: apiv1.TolerationOpExists})
+	wantLists.ImagePullSecrets = append(wantLists.ImagePullSecrets, apiv1.LocalObjectReference{Name: "private"})
+	wantLists.HostAliases = []apiv1.HostAlias{{IP: "10.0.0.1", Hostnames: []string{"db"}}}
+	if !reflect.DeepEqual(got, wantLists) {
+		t.Errorf("StrategicMergePodSpec() with lists got = %+v, want %+v", got, wantLists)
+	}
+
+	got, err = StrategicMergePodSpec(podSpec, &apiv1.PodSpec{})
+	if err != nil {
+		t.Fatalf("StrategicMergePodSpec() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, podSpec) {
+		t.Errorf("StrategicMergePodSpec() with empty patch got = %+v, want %+v", got, podSpec)
+	}
+}
@@ -17,10 +17,15 @@ limitations under the License.
 package util
 
 import (
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 )
 
 // ApplyImagePullSecret applies image pull secret to the give pod spec.
@@ -49,3 +54,21 @@ func WaitTimeout(wg *sync.WaitGroup, timeout time.Duration) {
 	case <-time.After(timeout):
 	}
 }
+
+// NewdeployObjName returns the name of the deployment, service and HPA of a
+// newdeploy function. It uses the UID of the function, so the same function
+// always gets the same name.
+func NewdeployObjName(fn *fv1.Function) string {
+	uid := fn.ObjectMeta.UID[len(fn.ObjectMeta.UID)-17:]
+	return strings.ToLower(fmt.Sprintf("newdeploy-%v-%v-%v", fn.ObjectMeta.Name, fn.ObjectMeta.Namespace, uid))
+}
+
+// FunctionObjNamespace returns the namespace the objects of a function run
+// in. For backward compatibility, the ones of functions in the default
+// namespace run in functionNamespace.
+func FunctionObjNamespace(fn *fv1.Function, functionNamespace string) string {
+	if fn.ObjectMeta.Namespace != metav1.NamespaceDefault {
+		return fn.ObjectMeta.Namespace
+	}
+	return functionNamespace
+}
@@ -16,6 +16,10 @@ limitations under the License.
 
 package featureconfig
 
+import (
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
 const (
 	FeatureConfigFile = "/etc/config/config.yaml"
 	CanaryFeature     = "canary"
@@ -31,6 +35,9 @@ type (
 	FeatureConfig struct {
 		// In the future more such feature configs can be added here for each optional feature
 		CanaryConfig CanaryFeatureConfig `json:"canary"`
+
+		// FissionConfig is the spec of the FissionConfig Fission was deployed with.
+		FissionConfig *fv1.FissionConfigSpec `json:"fissionConfig,omitempty"`
 	}
 
 	// specific feature config
@@ -0,0 +1,238 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetcher
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+var (
+	zipSignatures = [][]byte{
+		[]byte("PK\x03\x04"),
+		[]byte("PK\x05\x06"), // empty archive
+		[]byte("PK\x07\x08"), // spanned archive
+	}
+	gzipSignature = []byte{0x1f, 0x8b}
+
+	binarySignatures = [][]byte{
+		[]byte("\x7fELF"),
+		{0xfe, 0xed, 0xfa, 0xce}, // Mach-O 32-bit
+		{0xfe, 0xed, 0xfa, 0xcf}, // Mach-O 64-bit
+		{0xce, 0xfa, 0xed, 0xfe}, // Mach-O 32-bit, little endian
+		{0xcf, 0xfa, 0xed, 0xfe}, // Mach-O 64-bit, little endian
+		[]byte("#!"),             // script with shebang
+	}
+)
+
+// detectArchiveFormat sniffs the format of the file at path. An empty format
+// is returned if the file is none of the known formats, e.g. a single source file.
+func detectArchiveFormat(path string) (fv1.ArchiveFormat, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	header := make([]byte, 4)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	header = header[:n]
+
+	for _, sig := range zipSignatures {
+		if bytes.HasPrefix(header, sig) {
+			return fv1.ArchiveFormatZip, nil
+		}
+	}
+
+	if bytes.HasPrefix(header, gzipSignature) {
+		_, err = f.Seek(0, io.SeekStart)
+		if err != nil {
+			return "", err
+		}
+		if isTarGz(f) {
+			return fv1.ArchiveFormatTarGz, nil
+		}
+		return "", nil
+	}
+
+	for _, sig := range binarySignatures {
+		if bytes.HasPrefix(header, sig) {
+			return fv1.ArchiveFormatBinary, nil
+		}
+	}
+
+	return "", nil
+}
+
+// isTarGz checks whether the gzip stream holds a tar archive.
+func isTarGz(r io.Reader) bool {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return false
+	}
+	defer gz.Close()
+
+	_, err = tar.NewReader(gz).Next()
+	return err == nil
+}
+
+// extractTarGz extracts the tar.gz archive at src into the directory dst,
+// preserving file modes so that executables stay executable.
+func extractTarGz(src string, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return errors.Wrap(err, "error reading gzip stream")
+	}
+	defer gz.Close()
+
+	err = os.MkdirAll(dst, 0755)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "error reading tar archive")
+		}
+
+		target, err := archiveEntryPath(dst, hdr.Name)
+		if err != nil {
+			return err
+		}
+		mode := os.FileMode(hdr.Mode).Perm()
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, mode|0700)
+		case tar.TypeReg, tar.TypeRegA:
+			err = writeArchiveFile(target, tr, mode)
+		case tar.TypeSymlink:
+			// the link must not point outside of the destination
+			if filepath.IsAbs(hdr.Linkname) || !isWithinDir(dst, filepath.Join(filepath.Dir(target), hdr.Linkname)) {
+				return errors.Errorf("symlink %v points outside of archive", hdr.Name)
+			}
+			if err = os.MkdirAll(filepath.Dir(target), 0755); err == nil {
+				err = os.Symlink(hdr.Linkname, target)
+			}
+		case tar.TypeLink:
+			var linkTarget string
+			linkTarget, err = archiveEntryPath(dst, hdr.Linkname)
+			if err == nil {
+				err = os.Link(linkTarget, target)
+			}
+		default:
+			// devices, fifos etc. have no place in a function package
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "error extracting %v", hdr.Name)
+		}
+	}
+}
+
+// archiveEntryPath returns the path of an archive entry extracted into dst,
+// rejecting entries escaping dst, e.g. "../../etc/passwd". Entries below a
+// symlink extracted before are rejected too, as a chain of symlinks, each
+// pointing within dst on its own, can lead outside of it.
+func archiveEntryPath(dst string, name string) (string, error) {
+	target := filepath.Join(dst, name)
+	if !isWithinDir(dst, target) {
+		return "", errors.Errorf("illegal file path in archive: %v", name)
+	}
+	link, err := symlinkParent(dst, target)
+	if err != nil {
+		return "", err
+	}
+	if len(link) > 0 {
+		return "", errors.Errorf("illegal file path in archive: %v is below symlink %v", name, link)
+	}
+	return target, nil
+}
+
+// symlinkParent returns the first directory of path below dst which is a
+// symlink, or "" if there is none.
+func symlinkParent(dst string, path string) (string, error) {
+	rel, err := filepath.Rel(filepath.Clean(dst), filepath.Dir(path))
+	if err != nil || rel == "." {
+		return "", err
+	}
+	dir := filepath.Clean(dst)
+	for _, elem := range strings.Split(rel, string(os.PathSeparator)) {
+		dir = filepath.Join(dir, elem)
+		info, err := os.Lstat(dir)
+		if os.IsNotExist(err) {
+			// the rest is created by the extraction
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return dir, nil
+		}
+	}
+	return "", nil
+}
+
+func isWithinDir(dir string, path string) bool {
+	dir, path = filepath.Clean(dir), filepath.Clean(path)
+	return path == dir || strings.HasPrefix(path, dir+string(os.PathSeparator))
+}
+
+func writeArchiveFile(path string, r io.Reader, mode os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	// never write through a symlink extracted before
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return errors.Errorf("%v is a symlink", path)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	return err
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetcher
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	mode     int64
+	body     string
+	linkname string
+}
+
+func writeTarGz(t *testing.T, path string, entries []tarEntry) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		err = tw.WriteHeader(&tar.Header{
+			Name:     e.name,
+			Typeflag: e.typeflag,
+			Mode:     e.mode,
+			Size:     int64(len(e.body)),
+			Linkname: e.linkname,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetectArchiveFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fetcher-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tarGzPath := filepath.Join(dir, "pkg.tmp")
+	writeTarGz(t, tarGzPath, []tarEntry{{name: "main", typeflag: tar.TypeReg, mode: 0755, body: "hello"}})
+
+	tests := []struct {
+		name     string
+		contents []byte
+		path     string
+		want     fv1.ArchiveFormat
+	}{
+		{name: "zip", contents: []byte("PK\x03\x04rest of zip"), want: fv1.ArchiveFormatZip},
+		{name: "elf", contents: []byte("\x7fELF\x02\x01\x01"), want: fv1.ArchiveFormatBinary},
+		{name: "script", contents: []byte("#!/bin/sh\necho hi\n"), want: fv1.ArchiveFormatBinary},
+		{name: "source", contents: []byte("module.exports = async function() {}"), want: ""},
+		{name: "empty", contents: []byte{}, want: ""},
+		{name: "gzip without tar", contents: []byte{0x1f, 0x8b, 0x08, 0x00}, want: ""},
+		{name: "tar.gz", path: tarGzPath, want: fv1.ArchiveFormatTarGz},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := tt.path
+			if len(path) == 0 {
+				path = filepath.Join(dir, tt.name)
+				if err := ioutil.WriteFile(path, tt.contents, 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := detectArchiveFormat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("detectArchiveFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractTarGz(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fetcher-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "pkg.tar.gz")
+	writeTarGz(t, src, []tarEntry{
+		{name: "bin/", typeflag: tar.TypeDir, mode: 0755},
+		{name: "bin/handler", typeflag: tar.TypeReg, mode: 0755, body: "binary"},
+		{name: "config.json", typeflag: tar.TypeReg, mode: 0644, body: "{}"},
+		{name: "handler", typeflag: tar.TypeSymlink, linkname: "bin/handler"},
+	})
+
+	dst := filepath.Join(dir, "out")
+	if err = extractTarGz(src, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(filepath.Join(dst, "bin/handler"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0100 == 0 {
+		t.Errorf("expected handler to be executable, got mode %v", info.Mode())
+	}
+	contents, err := ioutil.ReadFile(filepath.Join(dst, "handler"))
+	if err != nil || string(contents) != "binary" {
+		t.Errorf("expected symlink to handler, got %q, %v", contents, err)
+	}
+
+	for _, entries := range [][]tarEntry{
+		{{name: "../evil", typeflag: tar.TypeReg, mode: 0644, body: "x"}},
+		{{name: "link", typeflag: tar.TypeSymlink, linkname: "../../etc/passwd"}},
+		{{name: "link", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"}},
+		// each link points within the archive on its own, but together
+		// they lead two levels above it
+		{
+			{name: "deep/s", typeflag: tar.TypeSymlink, linkname: ".."},
+			{name: "deep/s/t", typeflag: tar.TypeSymlink, linkname: "../.."},
+			{name: "deep/s/t/x", typeflag: tar.TypeReg, mode: 0644, body: "x"},
+		},
+		{
+			{name: "s", typeflag: tar.TypeSymlink, linkname: "bin"},
+			{name: "s", typeflag: tar.TypeReg, mode: 0644, body: "x"},
+		},
+	} {
+		writeTarGz(t, src, entries)
+		if err = extractTarGz(src, filepath.Join(dir, "evil", "out")); err == nil {
+			t.Errorf("expected error extracting %v", entries[len(entries)-1].name)
+		}
+		os.RemoveAll(filepath.Join(dir, "evil"))
+	}
+	if _, err = os.Stat(filepath.Join(dir, "x")); !os.IsNotExist(err) {
+		t.Errorf("expected no file written outside of the archive, got %v", err)
+	}
+}
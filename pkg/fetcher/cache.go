@@ -0,0 +1,199 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetcher
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// archiveCacheTmpPrefix marks the files of the cache being written.
+const archiveCacheTmpPrefix = ".tmp-"
+
+var checksumSumRegex = regexp.MustCompile(`^[0-9a-f]+$`)
+
+// archiveCache keeps downloaded archives by checksum in a directory the
+// fetchers of a node share, so that specializing many pods of a function
+// on the same node downloads its archive once. Archives used least
+// recently are removed once the cache grows beyond maxBytes.
+type archiveCache struct {
+	logger   *zap.Logger
+	dir      string
+	maxBytes int64
+}
+
+// makeArchiveCache returns the cache in dir, or nil if dir is empty.
+// maxBytes of 0 leaves the size of the cache unbounded.
+func makeArchiveCache(logger *zap.Logger, dir string, maxBytes int64) (*archiveCache, error) {
+	if len(dir) == 0 {
+		return nil, nil
+	}
+	err := makeVolumeDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &archiveCache{
+		logger:   logger.Named("archive_cache"),
+		dir:      dir,
+		maxBytes: maxBytes,
+	}, nil
+}
+
+// path returns the path of the archive with checksum in the cache, or
+// an empty string if it can't be cached.
+func (c *archiveCache) path(checksum *fv1.Checksum) string {
+	if c == nil || checksum.Type != fv1.ChecksumTypeSHA256 || !checksumSumRegex.MatchString(checksum.Sum) {
+		return ""
+	}
+	return filepath.Join(c.dir, string(checksum.Type)+"-"+checksum.Sum)
+}
+
+// get copies the archive with checksum to dst, returning false if it's
+// not cached.
+func (c *archiveCache) get(checksum *fv1.Checksum, dst string) bool {
+	path := c.path(checksum)
+	if len(path) == 0 {
+		return false
+	}
+	err := copyFile(path, dst)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			c.logger.Error("error copying archive from cache", zap.Error(err), zap.String("path", path))
+		}
+		return false
+	}
+
+	// the modification time tells when the archive was used last
+	now := time.Now()
+	err = os.Chtimes(path, now, now)
+	if err != nil {
+		c.logger.Debug("error touching cached archive", zap.Error(err), zap.String("path", path))
+	}
+	return true
+}
+
+// put adds the archive at src with checksum to the cache. Fetchers
+// adding the same archive at the same time are fine, the archive is
+// renamed into place once complete.
+func (c *archiveCache) put(checksum *fv1.Checksum, src string) {
+	path := c.path(checksum)
+	if len(path) == 0 {
+		return
+	}
+
+	tmp, err := ioutil.TempFile(c.dir, archiveCacheTmpPrefix)
+	if err != nil {
+		c.logger.Error("error creating file in cache", zap.Error(err))
+		return
+	}
+	tmp.Close()
+	err = copyFile(src, tmp.Name())
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		c.logger.Error("error adding archive to cache", zap.Error(err), zap.String("path", path))
+		os.Remove(tmp.Name())
+		return
+	}
+	c.prune()
+}
+
+// remove drops the archive with checksum from the cache.
+func (c *archiveCache) remove(checksum *fv1.Checksum) {
+	path := c.path(checksum)
+	if len(path) == 0 {
+		return
+	}
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		c.logger.Error("error removing archive from cache", zap.Error(err), zap.String("path", path))
+	}
+}
+
+// prune removes the archives used least recently until the cache is
+// within its size.
+func (c *archiveCache) prune() {
+	if c.maxBytes <= 0 {
+		return
+	}
+	files, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		c.logger.Error("error listing cache", zap.Error(err))
+		return
+	}
+
+	var size int64
+	archives := files[:0]
+	for _, fi := range files {
+		if fi.IsDir() || strings.HasPrefix(fi.Name(), archiveCacheTmpPrefix) {
+			continue
+		}
+		size += fi.Size()
+		archives = append(archives, fi)
+	}
+	sort.Slice(archives, func(i, j int) bool {
+		return archives[i].ModTime().Before(archives[j].ModTime())
+	})
+	for _, fi := range archives {
+		if size <= c.maxBytes {
+			return
+		}
+		err := os.Remove(filepath.Join(c.dir, fi.Name()))
+		if err != nil && !os.IsNotExist(err) {
+			c.logger.Error("error removing archive from cache", zap.Error(err), zap.String("archive", fi.Name()))
+			continue
+		}
+		size -= fi.Size()
+	}
+}
+
+// copyFile copies the file at src to dst, which is readable by its
+// owner only.
+func copyFile(src string, dst string) error {
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	if err != nil {
+		w.Close()
+		return err
+	}
+	err = w.Sync()
+	if err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
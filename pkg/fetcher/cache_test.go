@@ -0,0 +1,103 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetcher
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestArchiveCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache, err := makeArchiveCache(zap.NewNop(), filepath.Join(dir, "cache"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeArchive := func(name string, data string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	readArchive := func(path string) string {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	a := &fv1.Checksum{Type: fv1.ChecksumTypeSHA256, Sum: "aaaa"}
+	b := &fv1.Checksum{Type: fv1.ChecksumTypeSHA256, Sum: "bbbb"}
+	c := &fv1.Checksum{Type: fv1.ChecksumTypeSHA256, Sum: "cccc"}
+	dst := filepath.Join(dir, "dst")
+
+	if cache.get(a, dst) {
+		t.Fatal("expected archive not to be cached yet")
+	}
+	cache.put(a, writeArchive("a", "aaaa"))
+	if !cache.get(a, dst) || readArchive(dst) != "aaaa" {
+		t.Fatal("expected archive to be copied from cache")
+	}
+
+	// archives must have a well-formed checksum to be cached
+	cache.put(&fv1.Checksum{Type: fv1.ChecksumTypeSHA256, Sum: "../x"}, writeArchive("x", "x"))
+	if _, err := os.Stat(filepath.Join(dir, "x")); err != nil {
+		t.Fatal(err)
+	}
+	if cache.get(&fv1.Checksum{Type: fv1.ChecksumTypeSHA256, Sum: "../x"}, dst) {
+		t.Error("expected malformed checksum not to be cached")
+	}
+
+	// b is used least recently, so it's removed once c exceeds the size
+	cache.put(b, writeArchive("b", "bbbb"))
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(cache.path(b), past, past)
+	cache.get(a, dst)
+	cache.put(c, writeArchive("c", "cccc"))
+	if cache.get(b, dst) {
+		t.Error("expected least recently used archive to be pruned")
+	}
+	if !cache.get(a, dst) || !cache.get(c, dst) {
+		t.Error("expected recently used archives to be kept")
+	}
+
+	cache.remove(a)
+	if cache.get(a, dst) {
+		t.Error("expected removed archive not to be cached")
+	}
+
+	var disabled *archiveCache
+	if disabled.get(a, dst) {
+		t.Error("expected disabled cache to hold nothing")
+	}
+	disabled.put(a, dst)
+	disabled.remove(a)
+}
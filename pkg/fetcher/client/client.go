@@ -3,6 +3,7 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
@@ -16,6 +17,7 @@ import (
 
 	ferror "github.com/fission/fission/pkg/error"
 	"github.com/fission/fission/pkg/fetcher"
+	"github.com/fission/fission/pkg/tracing"
 )
 
 type (
@@ -31,11 +33,22 @@ func MakeClient(logger *zap.Logger, fetcherUrl string) *Client {
 		logger: logger.Named("fetcher_client"),
 		url:    strings.TrimSuffix(fetcherUrl, "/"),
 		httpClient: &http.Client{
-			Transport: &ochttp.Transport{},
+			Transport: tracing.Transport(&ochttp.Transport{}),
 		},
 	}
 }
 
+// SetTLSConfig makes the client talk to fetcher with the given TLS config,
+// used when fetcher serves requests with mTLS.
+func (c *Client) SetTLSConfig(tlsConfig *tls.Config) {
+	c.httpClient.Transport = tracing.Transport(&ochttp.Transport{
+		Base: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	})
+}
+
 func (c *Client) getSpecializeUrl() string {
 	return c.url + "/specialize"
 }
@@ -6,10 +6,13 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
 	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -17,9 +20,25 @@ import (
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/fetcher"
+	"github.com/fission/fission/pkg/mtls"
+	"github.com/fission/fission/pkg/secretstore"
+	"github.com/fission/fission/pkg/tracing"
 	"github.com/fission/fission/pkg/utils"
 )
 
+const mtlsVolume = "fission-mtls"
+
+// archiveCacheVolume mounts the host directory the fetchers of a node
+// share archives through at archiveCacheMountPath.
+const (
+	archiveCacheVolume    = "fission-archive-cache"
+	archiveCacheMountPath = "/archive-cache"
+)
+
+// DeploymentImagePath is where deployment images keep the deployment archive.
+// It's outside the shared volume, which would hide it in function pods.
+const DeploymentImagePath = "/fission/deployarchive"
+
 type Config struct {
 	fetcherImage           string
 	fetcherImagePullPolicy apiv1.PullPolicy
@@ -35,6 +54,21 @@ type Config struct {
 	serviceAccount string
 
 	jaegerCollectorEndpoint string
+	otlpCollectorEndpoint   string
+
+	// mtls is nil unless fetcher serves requests with mTLS, podNamespace
+	// is the namespace to copy the mTLS certificate secret from.
+	mtls         *mtls.Config
+	podNamespace string
+
+	// archiveCacheHostPath is the host directory fetchers cache archives
+	// in, none if empty. archiveCacheMaxBytes bounds its size.
+	archiveCacheHostPath string
+	archiveCacheMaxBytes int64
+
+	// secretStore configures the secret stores fetcher resolves the
+	// external secrets of functions from.
+	secretStore secretstore.Config
 }
 
 func getFetcherResources() (apiv1.ResourceRequirements, error) {
@@ -80,6 +114,23 @@ func MakeFetcherConfig(sharedMountPath string) (*Config, error) {
 		fetcherImagePullPolicy = "IfNotPresent"
 	}
 
+	mtlsConfig, err := mtls.ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if mtlsConfig != nil && len(mtlsConfig.SecretName) == 0 {
+		return nil, errors.New("mTLS is enabled but no certificate secret is given")
+	}
+
+	var archiveCacheMaxBytes int64
+	if size := os.Getenv("FETCHER_ARCHIVE_CACHE_SIZE"); len(size) > 0 {
+		quantity, err := resource.ParseQuantity(size)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing FETCHER_ARCHIVE_CACHE_SIZE")
+		}
+		archiveCacheMaxBytes = quantity.Value()
+	}
+
 	return &Config{
 		resourceRequirements:    resources,
 		fetcherImage:            fetcherImage,
@@ -88,7 +139,17 @@ func MakeFetcherConfig(sharedMountPath string) (*Config, error) {
 		sharedSecretPath:        "/secrets",
 		sharedCfgMapPath:        "/configs",
 		jaegerCollectorEndpoint: os.Getenv("TRACE_JAEGER_COLLECTOR_ENDPOINT"),
+		otlpCollectorEndpoint:   os.Getenv(tracing.EnvOTLPCollectorEndpoint),
 		serviceAccount:          fv1.FissionFetcherSA,
+		mtls:                    mtlsConfig,
+		podNamespace:            os.Getenv("POD_NAMESPACE"),
+		archiveCacheHostPath:    os.Getenv("FETCHER_ARCHIVE_CACHE_DIR"),
+		archiveCacheMaxBytes:    archiveCacheMaxBytes,
+		secretStore: secretstore.Config{
+			VaultAddr:     os.Getenv("VAULT_ADDR"),
+			VaultAuthPath: os.Getenv("VAULT_AUTH_PATH"),
+			VaultRole:     os.Getenv("VAULT_ROLE"),
+		},
 	}, nil
 }
 
@@ -102,6 +163,59 @@ func (cfg *Config) SetupServiceAccount(kubernetesClient *kubernetes.Clientset, n
 	return nil
 }
 
+// SetupMTLSSecret copies the mTLS certificate secret to the namespace
+// function pods are created in, so that fetcher can mount it. A copy that
+// is out of date, e.g. after the certificate is renewed, gets updated.
+func (cfg *Config) SetupMTLSSecret(kubernetesClient *kubernetes.Clientset, namespace string) error {
+	if cfg.mtls == nil || namespace == cfg.podNamespace {
+		return nil
+	}
+
+	secret, err := kubernetesClient.CoreV1().Secrets(cfg.podNamespace).Get(cfg.mtls.SecretName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "error getting mTLS secret %v in ns %v", cfg.mtls.SecretName, cfg.podNamespace)
+	}
+
+	existing, err := kubernetesClient.CoreV1().Secrets(namespace).Get(cfg.mtls.SecretName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		_, err = kubernetesClient.CoreV1().Secrets(namespace).Create(&apiv1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      cfg.mtls.SecretName,
+				Namespace: namespace,
+			},
+			Type: secret.Type,
+			Data: secret.Data,
+		})
+		if k8serrors.IsAlreadyExists(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "error creating mTLS secret in ns %v", namespace)
+	} else if err != nil {
+		return errors.Wrapf(err, "error getting mTLS secret in ns %v", namespace)
+	}
+
+	if reflect.DeepEqual(existing.Data, secret.Data) {
+		return nil
+	}
+	existing.Data = secret.Data
+	_, err = kubernetesClient.CoreV1().Secrets(namespace).Update(existing)
+	return errors.Wrapf(err, "error updating mTLS secret in ns %v", namespace)
+}
+
+// MTLS returns the mTLS config of fetcher, nil if mTLS is disabled.
+func (cfg *Config) MTLS() *mtls.Config {
+	return cfg.mtls
+}
+
+// FunctionPort returns the port function pods serve function requests on,
+// with mTLS they are served by fetcher instead of the function container.
+func (cfg *Config) FunctionPort() int {
+	if cfg.mtls != nil {
+		return mtls.FunctionPort
+	}
+	return 8888
+}
+
 func (cfg *Config) SharedMountPath() string {
 	return cfg.sharedMountPath
 }
@@ -132,6 +246,9 @@ func (cfg *Config) NewSpecializeRequest(fn *fv1.Function, env *fv1.Environment)
 			Secrets:     fn.Spec.Secrets,
 			ConfigMaps:  fn.Spec.ConfigMaps,
 			KeepArchive: env.Spec.KeepArchive,
+
+			ExternalSecrets: fn.Spec.ExternalSecrets,
+			SecretNamespace: fn.ObjectMeta.Namespace,
 		},
 		LoadReq: fetcher.FunctionLoadRequest{
 			FilePath:         filepath.Join(cfg.sharedMountPath, targetFilename),
@@ -139,15 +256,117 @@ func (cfg *Config) NewSpecializeRequest(fn *fv1.Function, env *fv1.Environment)
 			FunctionMetadata: &fn.ObjectMeta,
 			EnvVersion:       env.Spec.Version,
 		},
+		Probes:       FunctionProbes(fn, env),
+		ConfigReload: fn.Spec.ConfigReload,
+	}
+}
+
+// FunctionProbes returns the probes of a function, the probes of its
+// environment overridden by its own, or nil if there are none.
+func FunctionProbes(fn *fv1.Function, env *fv1.Environment) *fv1.Probes {
+	var probes fv1.Probes
+	if env != nil && env.Spec.Runtime.Probes != nil {
+		probes = *env.Spec.Runtime.Probes
+	}
+	if override := fn.Spec.Probes; override != nil {
+		if override.Startup != nil {
+			probes.Startup = override.Startup
+		}
+		if override.Readiness != nil {
+			probes.Readiness = override.Readiness
+		}
+		if override.Liveness != nil {
+			probes.Liveness = override.Liveness
+		}
+	}
+	if probes == (fv1.Probes{}) {
+		return nil
+	}
+	return &probes
+}
+
+// AddProbesToContainer has kubelet probe the function container through
+// fetcher, which runs the probes of the function loaded into it. The
+// startup probe tells fetcher when the container restarted, to load the
+// function again, and the liveness probe restarts the container once the
+// function fails its probes. Startup probes need Kubernetes 1.18 or later.
+// Containers with probes of their own are left as they are.
+func (cfg *Config) AddProbesToContainer(container *apiv1.Container) {
+	if container.StartupProbe != nil || container.LivenessProbe != nil {
+		return
+	}
+	fetcherPort := intstr.FromInt(8000)
+	container.StartupProbe = &apiv1.Probe{
+		PeriodSeconds: 1,
+		// functions are loaded again within the startup probe
+		FailureThreshold: 600,
+		Handler: apiv1.Handler{
+			HTTPGet: &apiv1.HTTPGetAction{
+				Path: fetcher.StartupProbePath,
+				Port: fetcherPort,
+			},
+		},
+	}
+	container.LivenessProbe = &apiv1.Probe{
+		PeriodSeconds:    5,
+		FailureThreshold: 1,
+		Handler: apiv1.Handler{
+			HTTPGet: &apiv1.HTTPGetAction{
+				Path: fetcher.LivenessProbePath,
+				Port: fetcherPort,
+			},
+		},
 	}
 }
 
+// NewImageSpecializeRequest is NewSpecializeRequest for functions running from
+// the deployment image of their package.
+func (cfg *Config) NewImageSpecializeRequest(fn *fv1.Function, env *fv1.Environment) fetcher.FunctionSpecializeRequest {
+	req := cfg.NewSpecializeRequest(fn, env)
+	req.FetchReq.FetchType = fv1.FETCH_IMAGE
+	req.LoadReq.FilePath = DeploymentImagePath
+	return req
+}
+
+// NewFetchContainer returns a container fetching the archive of a fetch request
+// into the shared volume, then exiting.
+func (cfg *Config) NewFetchContainer(req fetcher.FunctionFetchRequest) (*apiv1.Container, error) {
+	fetchPayload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiv1.Container{
+		Name:                   "fetcher",
+		Command:                cfg.fetcherCommand("-fetch-request", string(fetchPayload)),
+		Image:                  cfg.fetcherImage,
+		ImagePullPolicy:        cfg.fetcherImagePullPolicy,
+		TerminationMessagePath: "/dev/termination-log",
+		Resources:              cfg.resourceRequirements,
+		VolumeMounts: []apiv1.VolumeMount{
+			{
+				Name:      fv1.SharedVolumeUserfunc,
+				MountPath: cfg.sharedMountPath,
+			},
+		},
+	}, nil
+}
+
 func (cfg *Config) AddFetcherToPodSpec(podSpec *apiv1.PodSpec, mainContainerName string) error {
 	return cfg.addFetcherToPodSpecWithCommand(podSpec, mainContainerName, cfg.fetcherCommand())
 }
 
 func (cfg *Config) AddSpecializingFetcherToPodSpec(podSpec *apiv1.PodSpec, mainContainerName string, fn *fv1.Function, env *fv1.Environment) error {
-	specializeReq := cfg.NewSpecializeRequest(fn, env)
+	return cfg.addSpecializingFetcherToPodSpec(podSpec, mainContainerName, cfg.NewSpecializeRequest(fn, env))
+}
+
+// AddImageSpecializingFetcherToPodSpec is AddSpecializingFetcherToPodSpec for
+// pods running the deployment image of the function package.
+func (cfg *Config) AddImageSpecializingFetcherToPodSpec(podSpec *apiv1.PodSpec, mainContainerName string, fn *fv1.Function, env *fv1.Environment) error {
+	return cfg.addSpecializingFetcherToPodSpec(podSpec, mainContainerName, cfg.NewImageSpecializeRequest(fn, env))
+}
+
+func (cfg *Config) addSpecializingFetcherToPodSpec(podSpec *apiv1.PodSpec, mainContainerName string, specializeReq fetcher.FunctionSpecializeRequest) error {
 	specializePayload, err := json.Marshal(specializeReq)
 	if err != nil {
 		return err
@@ -163,12 +382,83 @@ func (cfg *Config) AddSpecializingFetcherToPodSpec(podSpec *apiv1.PodSpec, mainC
 	)
 }
 
+// MountReferencedResources mounts the secrets and configmaps of a function
+// into the main container of a pod without fetcher, where fetcher would
+// have written them.
+func (cfg *Config) MountReferencedResources(podSpec *apiv1.PodSpec, mainContainerName string, fn *fv1.Function) error {
+	var volumes []apiv1.Volume
+	var mounts []apiv1.VolumeMount
+	for i, secret := range fn.Spec.Secrets {
+		name := fmt.Sprintf("secret-%v", i)
+		volumes = append(volumes, apiv1.Volume{
+			Name: name,
+			VolumeSource: apiv1.VolumeSource{
+				Secret: &apiv1.SecretVolumeSource{
+					SecretName: secret.Name,
+				},
+			},
+		})
+		mounts = append(mounts, apiv1.VolumeMount{
+			Name:      name,
+			MountPath: filepath.Join(cfg.sharedSecretPath, secret.Namespace, secret.Name),
+			ReadOnly:  true,
+		})
+	}
+	for i, cfgMap := range fn.Spec.ConfigMaps {
+		name := fmt.Sprintf("configmap-%v", i)
+		volumes = append(volumes, apiv1.Volume{
+			Name: name,
+			VolumeSource: apiv1.VolumeSource{
+				ConfigMap: &apiv1.ConfigMapVolumeSource{
+					LocalObjectReference: apiv1.LocalObjectReference{
+						Name: cfgMap.Name,
+					},
+				},
+			},
+		})
+		mounts = append(mounts, apiv1.VolumeMount{
+			Name:      name,
+			MountPath: filepath.Join(cfg.sharedCfgMapPath, cfgMap.Namespace, cfgMap.Name),
+			ReadOnly:  true,
+		})
+	}
+
+	for ix, container := range podSpec.Containers {
+		if container.Name == mainContainerName {
+			podSpec.Containers[ix].VolumeMounts = append(container.VolumeMounts, mounts...)
+			podSpec.Volumes = append(podSpec.Volumes, volumes...)
+			return nil
+		}
+	}
+	return errors.Errorf("could not find main container '%s' in given PodSpec", mainContainerName)
+}
+
 func (cfg *Config) fetcherCommand(extraArgs ...string) []string {
 	command := []string{"/fetcher",
 		"-secret-dir", cfg.sharedSecretPath,
 		"-cfgmap-dir", cfg.sharedCfgMapPath,
 		"-jaeger-collector-endpoint", cfg.jaegerCollectorEndpoint,
 	}
+	if len(cfg.otlpCollectorEndpoint) > 0 {
+		command = append(command, "-otlp-collector-endpoint", cfg.otlpCollectorEndpoint)
+	}
+	if cfg.mtls != nil {
+		command = append(command, "-mtls-cert-dir", cfg.mtls.CertDir)
+	}
+	if len(cfg.archiveCacheHostPath) > 0 {
+		command = append(command,
+			"-archive-cache-dir", archiveCacheMountPath,
+			"-archive-cache-max-bytes", strconv.FormatInt(cfg.archiveCacheMaxBytes, 10))
+	}
+	if len(cfg.secretStore.VaultAddr) > 0 {
+		command = append(command, "-vault-addr", cfg.secretStore.VaultAddr)
+		if len(cfg.secretStore.VaultAuthPath) > 0 {
+			command = append(command, "-vault-auth-path", cfg.secretStore.VaultAuthPath)
+		}
+		if len(cfg.secretStore.VaultRole) > 0 {
+			command = append(command, "-vault-role", cfg.secretStore.VaultRole)
+		}
+	}
 
 	command = append(command, extraArgs...)
 	command = append(command, cfg.sharedMountPath)
@@ -216,13 +506,50 @@ func (cfg *Config) volumesWithMounts() ([]apiv1.Volume, []apiv1.VolumeMount) {
 
 func (cfg *Config) addFetcherToPodSpecWithCommand(podSpec *apiv1.PodSpec, mainContainerName string, command []string) error {
 	volumes, mounts := cfg.volumesWithMounts()
+	fetcherMounts := mounts
+	if cfg.mtls != nil {
+		// only fetcher gets the certificate, the function container
+		// is reached through it.
+		volumes = append(volumes, apiv1.Volume{
+			Name: mtlsVolume,
+			VolumeSource: apiv1.VolumeSource{
+				Secret: &apiv1.SecretVolumeSource{
+					SecretName: cfg.mtls.SecretName,
+				},
+			},
+		})
+		fetcherMounts = append(fetcherMounts[:len(fetcherMounts):len(fetcherMounts)], apiv1.VolumeMount{
+			Name:      mtlsVolume,
+			MountPath: cfg.mtls.CertDir,
+			ReadOnly:  true,
+		})
+	}
+	if len(cfg.archiveCacheHostPath) > 0 {
+		// only fetcher gets the cache, functions can't tamper with
+		// the archives of others.
+		hostPathType := apiv1.HostPathDirectoryOrCreate
+		volumes = append(volumes, apiv1.Volume{
+			Name: archiveCacheVolume,
+			VolumeSource: apiv1.VolumeSource{
+				HostPath: &apiv1.HostPathVolumeSource{
+					Path: cfg.archiveCacheHostPath,
+					Type: &hostPathType,
+				},
+			},
+		})
+		fetcherMounts = append(fetcherMounts[:len(fetcherMounts):len(fetcherMounts)], apiv1.VolumeMount{
+			Name:      archiveCacheVolume,
+			MountPath: archiveCacheMountPath,
+		})
+	}
+
 	c := apiv1.Container{
 		Name:                   "fetcher",
 		Command:                command,
 		Image:                  cfg.fetcherImage,
 		ImagePullPolicy:        cfg.fetcherImagePullPolicy,
 		TerminationMessagePath: "/dev/termination-log",
-		VolumeMounts:           mounts,
+		VolumeMounts:           fetcherMounts,
 		Resources:              cfg.resourceRequirements,
 		ReadinessProbe: &apiv1.Probe{
 			InitialDelaySeconds: 1,
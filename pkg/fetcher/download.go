@@ -0,0 +1,394 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/fission/fission/pkg/storagesvc/delta"
+	"github.com/fission/fission/pkg/utils"
+)
+
+const (
+	defaultDownloadChunkSize   = 8 << 20
+	defaultDownloadParallelism = 4
+
+	// downloadChunkAttempts is how many times a chunk is requested
+	// before the download fails.
+	downloadChunkAttempts = 5
+
+	// signatureBlockSize is the block size of the signatures used to
+	// verify chunks of archives downloaded from storagesvc.
+	signatureBlockSize = delta.MaxBlockSize
+)
+
+type (
+	// downloader fetches large files in chunks with ranged requests run
+	// in parallel. Progress is kept next to the file, so that a failed
+	// download resumes from the chunks already written when retried.
+	downloader struct {
+		logger      *zap.Logger
+		httpClient  *http.Client
+		chunkSize   int64
+		parallelism int
+	}
+
+	// downloadProgress records the chunks of a download written to disk.
+	downloadProgress struct {
+		URL       string `json:"url"`
+		Size      int64  `json:"size"`
+		ChunkSize int64  `json:"chunkSize"`
+		Done      []bool `json:"done"`
+	}
+
+	// offsetWriter writes sequentially to w from offset on.
+	offsetWriter struct {
+		w      io.WriterAt
+		offset int64
+	}
+)
+
+// makeDownloader returns a downloader configured by the
+// FETCHER_DOWNLOAD_CHUNK_SIZE and FETCHER_DOWNLOAD_PARALLELISM
+// environment variables.
+func makeDownloader(logger *zap.Logger, httpClient *http.Client) *downloader {
+	d := &downloader{
+		logger:      logger,
+		httpClient:  httpClient,
+		chunkSize:   defaultDownloadChunkSize,
+		parallelism: defaultDownloadParallelism,
+	}
+	if v := os.Getenv("FETCHER_DOWNLOAD_CHUNK_SIZE"); len(v) > 0 {
+		size, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || size <= 0 {
+			logger.Warn("ignoring invalid download chunk size", zap.String("value", v))
+		} else {
+			d.chunkSize = size
+		}
+	}
+	if v := os.Getenv("FETCHER_DOWNLOAD_PARALLELISM"); len(v) > 0 {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			logger.Warn("ignoring invalid download parallelism", zap.String("value", v))
+		} else {
+			d.parallelism = n
+		}
+	}
+	return d
+}
+
+// download fetches rawURL to localPath. Files too small to be worth
+// splitting, and servers without support for ranged requests, are
+// downloaded with a single request.
+func (d *downloader) download(ctx context.Context, rawURL string, localPath string) error {
+	size, ranged, err := d.probe(ctx, rawURL)
+	if err != nil {
+		d.logger.Debug("error probing url for ranged download", zap.Error(err), zap.String("url", rawURL))
+	}
+	if err != nil || !ranged || size < 2*d.chunkSize {
+		return utils.DownloadUrl(ctx, d.httpClient, rawURL, localPath)
+	}
+	return d.downloadChunks(ctx, rawURL, localPath, size)
+}
+
+// probe returns the size of the file at rawURL and whether the server
+// accepts ranged requests for it.
+func (d *downloader) probe(ctx context.Context, rawURL string) (int64, bool, error) {
+	req, err := http.NewRequest(http.MethodHead, rawURL, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	resp, err := ctxhttp.Do(ctx, d.httpClient, req)
+	if err != nil {
+		return 0, false, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, false, errors.Errorf("HTTP error %v", resp.StatusCode)
+	}
+	return resp.ContentLength, resp.Header.Get("Accept-Ranges") == "bytes", nil
+}
+
+func (d *downloader) downloadChunks(ctx context.Context, rawURL string, localPath string, size int64) error {
+	logger := d.logger.With(zap.String("url", rawURL), zap.Int64("size", size))
+	sig := d.signature(ctx, rawURL, size)
+
+	progressPath := localPath + ".progress"
+	progress := loadDownloadProgress(progressPath, localPath, rawURL, size, d.chunkSize)
+	flags := os.O_RDWR | os.O_CREATE
+	if progress == nil {
+		progress = &downloadProgress{
+			URL:       rawURL,
+			Size:      size,
+			ChunkSize: d.chunkSize,
+			Done:      make([]bool, (size+d.chunkSize-1)/d.chunkSize),
+		}
+		flags |= os.O_TRUNC
+	} else {
+		logger.Info("resuming download")
+	}
+
+	f, err := os.OpenFile(localPath, flags, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	err = f.Truncate(size)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	chunks := make(chan int)
+	errCh := make(chan error, d.parallelism)
+	for i := 0; i < d.parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range chunks {
+				err := d.downloadChunk(ctx, rawURL, f, chunk, size, sig)
+				if err != nil {
+					errCh <- err
+					cancel()
+					return
+				}
+				mu.Lock()
+				progress.Done[chunk] = true
+				err = progress.save(progressPath)
+				mu.Unlock()
+				if err != nil {
+					logger.Warn("error saving download progress", zap.Error(err))
+				}
+			}
+		}()
+	}
+
+feed:
+	for chunk, done := range progress.Done {
+		if done {
+			continue
+		}
+		select {
+		case chunks <- chunk:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(chunks)
+	wg.Wait()
+	close(errCh)
+
+	if err := <-errCh; err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err = f.Sync()
+	if err != nil {
+		return err
+	}
+	err = os.Remove(progressPath)
+	if err != nil && !os.IsNotExist(err) {
+		logger.Warn("error removing download progress", zap.Error(err))
+	}
+	return nil
+}
+
+// downloadChunk fetches a chunk into f. A failed request is retried from
+// where it stopped, while a chunk failing verification is fetched again
+// as a whole.
+func (d *downloader) downloadChunk(ctx context.Context, rawURL string, f *os.File, chunk int, size int64, sig *delta.Signature) error {
+	start := int64(chunk) * d.chunkSize
+	end := start + d.chunkSize
+	if end > size {
+		end = size
+	}
+
+	offset := start
+	var err error
+	for attempt := 0; attempt < downloadChunkAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(500 * time.Duration(attempt) * time.Millisecond):
+			}
+		}
+
+		var n int64
+		n, err = d.fetchRange(ctx, rawURL, f, offset, end-offset)
+		offset += n
+		if err != nil {
+			d.logger.Debug("error fetching chunk, retrying", zap.Error(err),
+				zap.String("url", rawURL), zap.Int64("offset", offset))
+			continue
+		}
+
+		var ok bool
+		ok, err = verifyChunk(f, sig, start, end)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		err = errors.New("chunk does not match archive signature")
+		d.logger.Warn("chunk does not match archive signature, refetching",
+			zap.String("url", rawURL), zap.Int64("offset", start))
+		offset = start
+	}
+	return errors.Wrapf(err, "error downloading chunk at offset %v", start)
+}
+
+// fetchRange writes length bytes of the file at rawURL from offset to w,
+// returning how many were written.
+func (d *downloader) fetchRange(ctx context.Context, rawURL string, w io.WriterAt, offset int64, length int64) (int64, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%v-%v", offset, offset+length-1))
+	resp, err := ctxhttp.Do(ctx, d.httpClient, req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return 0, errors.Errorf("HTTP error %v", resp.StatusCode)
+	}
+
+	n, err := io.Copy(&offsetWriter{w: w, offset: offset}, io.LimitReader(resp.Body, length))
+	if err == nil && n < length {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// signature returns the delta signature of an archive served by
+// storagesvc, used to verify its chunks as they are downloaded. Other
+// files have no signature; only their checksum as a whole is verified.
+func (d *downloader) signature(ctx context.Context, rawURL string, size int64) *delta.Signature {
+	u, err := url.Parse(rawURL)
+	if err != nil || !strings.HasSuffix(u.Path, "/v1/archive") {
+		return nil
+	}
+	u.Path += "/signature"
+	q := u.Query()
+	q.Set("blocksize", strconv.Itoa(signatureBlockSize))
+	u.RawQuery = q.Encode()
+
+	logger := d.logger.With(zap.String("url", rawURL))
+	resp, err := ctxhttp.Get(ctx, d.httpClient, u.String())
+	if err != nil {
+		logger.Warn("error getting archive signature, chunks won't be verified", zap.Error(err))
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		logger.Warn("error getting archive signature, chunks won't be verified", zap.Int("status_code", resp.StatusCode))
+		return nil
+	}
+
+	sig := &delta.Signature{}
+	err = json.NewDecoder(resp.Body).Decode(sig)
+	if err != nil {
+		logger.Warn("error decoding archive signature, chunks won't be verified", zap.Error(err))
+		return nil
+	}
+	if sig.Size != size || sig.BlockSize != signatureBlockSize {
+		logger.Warn("archive signature doesn't match archive, chunks won't be verified")
+		return nil
+	}
+	return sig
+}
+
+// verifyChunk checks the blocks of the signature lying entirely within
+// [start, end) of r. The blocks straddling chunks are left to the
+// checksum of the whole archive.
+func verifyChunk(r io.ReaderAt, sig *delta.Signature, start int64, end int64) (bool, error) {
+	if sig == nil {
+		return true, nil
+	}
+	bs := int64(sig.BlockSize)
+	buf := make([]byte, bs)
+	for i := (start + bs - 1) / bs; (i+1)*bs <= end; i++ {
+		_, err := r.ReadAt(buf, i*bs)
+		if err != nil {
+			return false, err
+		}
+		if !sig.VerifyBlock(int(i), buf) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// loadDownloadProgress returns the progress of an earlier download of
+// rawURL to localPath, or nil if there's none to resume.
+func loadDownloadProgress(progressPath string, localPath string, rawURL string, size int64, chunkSize int64) *downloadProgress {
+	data, err := ioutil.ReadFile(progressPath)
+	if err != nil {
+		return nil
+	}
+	fi, err := os.Stat(localPath)
+	if err != nil || fi.Size() != size {
+		return nil
+	}
+
+	p := &downloadProgress{}
+	err = json.Unmarshal(data, p)
+	if err != nil || p.URL != rawURL || p.Size != size || p.ChunkSize != chunkSize ||
+		int64(len(p.Done)) != (size+chunkSize-1)/chunkSize {
+		return nil
+	}
+	return p
+}
+
+func (p *downloadProgress) save(path string) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+func (ow *offsetWriter) Write(b []byte) (int, error) {
+	n, err := ow.w.WriteAt(b, ow.offset)
+	ow.offset += int64(n)
+	return n, err
+}
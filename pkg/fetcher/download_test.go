@@ -0,0 +1,219 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/fission/fission/pkg/storagesvc/delta"
+)
+
+// archiveServer serves data like storagesvc, recording the ranges
+// requested and serving corrupt data for the first request of a range
+// in corrupt.
+type archiveServer struct {
+	data    []byte
+	mu      sync.Mutex
+	ranges  map[string]int
+	corrupt map[string]bool
+}
+
+func (as *archiveServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/v1/archive/signature" {
+		sig, err := delta.ComputeSignature(bytes.NewReader(as.data), signatureBlockSize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(sig)
+		return
+	}
+
+	data := as.data
+	rng := r.Header.Get("Range")
+	if len(rng) > 0 {
+		as.mu.Lock()
+		as.ranges[rng]++
+		if as.corrupt[rng] {
+			as.corrupt[rng] = false
+			data = append([]byte{}, as.data...)
+			for i := range data {
+				data[i] ^= 0xff
+			}
+		}
+		as.mu.Unlock()
+	}
+	http.ServeContent(w, r, "archive", time.Time{}, bytes.NewReader(data))
+}
+
+func makeArchiveServer(size int) *archiveServer {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(1)).Read(data)
+	return &archiveServer{
+		data:    data,
+		ranges:  make(map[string]int),
+		corrupt: make(map[string]bool),
+	}
+}
+
+func TestDownloadChunks(t *testing.T) {
+	as := makeArchiveServer(7*signatureBlockSize + 100)
+	srv := httptest.NewServer(as)
+	defer srv.Close()
+
+	// the first chunk is corrupt the first time, and has to be fetched again
+	as.corrupt["bytes=0-2097151"] = true
+
+	dir, err := ioutil.TempDir("", "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	localPath := filepath.Join(dir, "archive")
+
+	d := &downloader{
+		logger:      zap.NewNop(),
+		httpClient:  srv.Client(),
+		chunkSize:   2 * signatureBlockSize,
+		parallelism: 3,
+	}
+	err = d.download(context.Background(), srv.URL+"/v1/archive?id=a", localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, as.data) {
+		t.Error("downloaded file doesn't match archive")
+	}
+	if _, err := os.Stat(localPath + ".progress"); !os.IsNotExist(err) {
+		t.Errorf("expected progress file to be removed, got %v", err)
+	}
+	want := map[string]int{
+		"bytes=0-2097151":       2,
+		"bytes=2097152-4194303": 1,
+		"bytes=4194304-6291455": 1,
+		"bytes=6291456-7340131": 1,
+	}
+	for rng, n := range want {
+		if as.ranges[rng] != n {
+			t.Errorf("range %v requested %v times, want %v", rng, as.ranges[rng], n)
+		}
+	}
+}
+
+func TestDownloadResume(t *testing.T) {
+	as := makeArchiveServer(5*signatureBlockSize + 100)
+	srv := httptest.NewServer(as)
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	localPath := filepath.Join(dir, "archive")
+	url := srv.URL + "/v1/archive?id=a"
+	chunkSize := int64(2 * signatureBlockSize)
+
+	// an earlier download wrote the first and last chunks
+	partial := make([]byte, len(as.data))
+	copy(partial[:chunkSize], as.data)
+	copy(partial[2*chunkSize:], as.data[2*chunkSize:])
+	err = ioutil.WriteFile(localPath, partial, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	progress := &downloadProgress{
+		URL:       url,
+		Size:      int64(len(as.data)),
+		ChunkSize: chunkSize,
+		Done:      []bool{true, false, true},
+	}
+	err = progress.save(localPath + ".progress")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &downloader{
+		logger:      zap.NewNop(),
+		httpClient:  srv.Client(),
+		chunkSize:   chunkSize,
+		parallelism: 2,
+	}
+	err = d.download(context.Background(), url, localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, as.data) {
+		t.Error("downloaded file doesn't match archive")
+	}
+	if len(as.ranges) != 1 || as.ranges["bytes=2097152-4194303"] != 1 {
+		t.Errorf("expected only the missing chunk to be requested, got %v", as.ranges)
+	}
+}
+
+func TestDownloadSmallFile(t *testing.T) {
+	as := makeArchiveServer(1000)
+	srv := httptest.NewServer(as)
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	localPath := filepath.Join(dir, "archive")
+
+	d := makeDownloader(zap.NewNop(), srv.Client())
+	err = d.download(context.Background(), srv.URL+"/v1/archive?id=a", localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, as.data) {
+		t.Error("downloaded file doesn't match archive")
+	}
+	if len(as.ranges) != 0 {
+		t.Errorf("expected a single plain request, got ranges %v", as.ranges)
+	}
+}
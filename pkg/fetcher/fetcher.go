@@ -41,7 +41,10 @@ import (
 	ferror "github.com/fission/fission/pkg/error"
 	"github.com/fission/fission/pkg/error/network"
 	"github.com/fission/fission/pkg/info"
+	"github.com/fission/fission/pkg/secretstore"
+	"github.com/fission/fission/pkg/sourcemap"
 	storageSvcClient "github.com/fission/fission/pkg/storagesvc/client"
+	"github.com/fission/fission/pkg/tracing"
 	"github.com/fission/fission/pkg/utils"
 )
 
@@ -54,6 +57,11 @@ type (
 		fissionClient    *crd.FissionClient
 		kubeClient       *kubernetes.Clientset
 		httpClient       *http.Client
+		downloader       *downloader
+		archiveCache     *archiveCache
+		prober           *prober
+		reloader         *reloader
+		secretProviders  *secretstore.Providers
 	}
 )
 
@@ -61,7 +69,7 @@ func makeVolumeDir(dirPath string) error {
 	return os.MkdirAll(dirPath, os.ModeDir|0750)
 }
 
-func MakeFetcher(logger *zap.Logger, sharedVolumePath string, sharedSecretPath string, sharedConfigPath string) (*Fetcher, error) {
+func MakeFetcher(logger *zap.Logger, sharedVolumePath string, sharedSecretPath string, sharedConfigPath string, archiveCacheDir string, archiveCacheMaxBytes int64, secretStoreConfig secretstore.Config) (*Fetcher, error) {
 	fLogger := logger.Named("fetcher")
 	err := makeVolumeDir(sharedVolumePath)
 	if err != nil {
@@ -76,10 +84,18 @@ func MakeFetcher(logger *zap.Logger, sharedVolumePath string, sharedSecretPath s
 		fLogger.Fatal("error creating shared config directory", zap.Error(err), zap.String("directory", sharedConfigPath))
 	}
 
+	archiveCache, err := makeArchiveCache(fLogger, archiveCacheDir, archiveCacheMaxBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "error making the archive cache")
+	}
+
 	fissionClient, kubeClient, _, _, err := crd.MakeFissionClient()
 	if err != nil {
 		return nil, errors.Wrap(err, "error making the fission / kube client")
 	}
+	httpClient := &http.Client{
+		Transport: tracing.Transport(&ochttp.Transport{}),
+	}
 	return &Fetcher{
 		logger:           fLogger,
 		sharedVolumePath: sharedVolumePath,
@@ -87,9 +103,12 @@ func MakeFetcher(logger *zap.Logger, sharedVolumePath string, sharedSecretPath s
 		sharedConfigPath: sharedConfigPath,
 		fissionClient:    fissionClient,
 		kubeClient:       kubeClient,
-		httpClient: &http.Client{
-			Transport: &ochttp.Transport{},
-		},
+		httpClient:       httpClient,
+		downloader:       makeDownloader(fLogger, httpClient),
+		archiveCache:     archiveCache,
+		prober:           makeProber(fLogger),
+		reloader:         makeReloader(fLogger, kubeClient, sharedSecretPath, sharedConfigPath),
+		secretProviders:  secretstore.MakeProviders(secretStoreConfig),
 	}, nil
 }
 
@@ -170,6 +189,12 @@ func (fetcher *Fetcher) FetchHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), code)
 		return
 	}
+	code, err = fetcher.FetchExternalSecrets(r.Context(), req.SecretNamespace, req.ExternalSecrets)
+	if err != nil {
+		fetcher.logger.Error("error fetching external secrets", zap.Error(err))
+		http.Error(w, err.Error(), code)
+		return
+	}
 
 	fetcher.logger.Info("completed fetch request")
 	// all done
@@ -204,10 +229,51 @@ func (fetcher *Fetcher) SpecializeHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	fetcher.WatchReferencedResources(req)
+
+	// requests are sent to the pod as soon as it's specialized, so
+	// specialization completes once the function is ready for them
+	fetcher.StartProbes(req)
+	err = fetcher.prober.waitReady(r.Context())
+	if err != nil {
+		fetcher.logger.Error("error waiting for the function to be ready", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	// all done
 	w.WriteHeader(http.StatusOK)
 }
 
+// StartProbes starts probing the function loaded by a specialize request.
+func (fetcher *Fetcher) StartProbes(req FunctionSpecializeRequest) {
+	fetcher.prober.start(req.LoadReq, req.Probes, fetcher.loadFunction)
+}
+
+// WatchReferencedResources reloads the secrets and configmaps of the
+// function specialized by a request when they change, if it asks to.
+func (fetcher *Fetcher) WatchReferencedResources(req FunctionSpecializeRequest) {
+	fetcher.reloader.start(req.FetchReq, req.ConfigReload)
+}
+
+// FunctionReady returns whether the function loaded, if any, passes its
+// startup and readiness probes.
+func (fetcher *Fetcher) FunctionReady() bool {
+	return fetcher.prober.functionReady()
+}
+
+// StartupProbeHandler answers the startup probe of the function container.
+func (fetcher *Fetcher) StartupProbeHandler(w http.ResponseWriter, r *http.Request) {
+	fetcher.prober.startupHandler(w, r)
+}
+
+// LivenessProbeHandler answers the liveness probe of the function
+// container, failing for kubelet to restart the container once the
+// function fails its startup or liveness probe.
+func (fetcher *Fetcher) LivenessProbeHandler(w http.ResponseWriter, r *http.Request) {
+	fetcher.prober.livenessHandler(w, r)
+}
+
 // Fetch takes FetchRequest and makes the fetch call
 // It returns the HTTP code and error if any
 func (fetcher *Fetcher) Fetch(ctx context.Context, pkg *fv1.Package, req FunctionFetchRequest) (int, error) {
@@ -229,9 +295,14 @@ func (fetcher *Fetcher) Fetch(ctx context.Context, pkg *fv1.Package, req Functio
 	tmpFile := req.Filename + ".tmp"
 	tmpPath := filepath.Join(fetcher.sharedVolumePath, tmpFile)
 
+	var format fv1.ArchiveFormat
+	// cloned is true if the package was checked out from a Git
+	// repository into a directory, which is placed as is.
+	var cloned bool
+
 	if req.FetchType == fv1.FETCH_URL {
 		// fetch the file and save it to the tmp path
-		err := utils.DownloadUrl(ctx, fetcher.httpClient, req.Url, tmpPath)
+		err := fetcher.downloader.download(ctx, req.Url, tmpPath)
 		if err != nil {
 			e := "failed to download url"
 			fetcher.logger.Error(e, zap.Error(err), zap.String("url", req.Url))
@@ -258,9 +329,18 @@ func (fetcher *Fetcher) Fetch(ctx context.Context, pkg *fv1.Package, req Functio
 		} else {
 			return http.StatusBadRequest, fmt.Errorf("unknown fetch type: %v", req.FetchType)
 		}
+		format = archive.Format
 
-		// get package data as literal or by url
-		if len(archive.Literal) > 0 {
+		// get package data as literal, by url or from a Git repository
+		if archive.Type == fv1.ArchiveTypeGit && archive.Git != nil {
+			err := fetcher.cloneGit(ctx, pkg.ObjectMeta.Namespace, archive.Git, tmpPath)
+			if err != nil {
+				e := "failed to clone repository"
+				fetcher.logger.Error(e, zap.Error(err), zap.String("url", archive.Git.URL))
+				return http.StatusBadRequest, errors.Wrapf(err, "%s %s", e, archive.Git.URL)
+			}
+			cloned = true
+		} else if len(archive.Literal) > 0 {
 			// write pkg.Literal into tmpPath
 			err := ioutil.WriteFile(tmpPath, archive.Literal, 0600)
 			if err != nil {
@@ -269,12 +349,22 @@ func (fetcher *Fetcher) Fetch(ctx context.Context, pkg *fv1.Package, req Functio
 				return http.StatusInternalServerError, errors.Wrapf(err, "%s %s", e, tmpPath)
 			}
 		} else {
-			// download and verify
-			err := utils.DownloadUrl(ctx, fetcher.httpClient, archive.URL, tmpPath)
-			if err != nil {
-				e := "failed to download url"
-				fetcher.logger.Error(e, zap.Error(err), zap.String("url", req.Url))
-				return http.StatusBadRequest, errors.Wrapf(err, "%s %s", e, req.Url)
+			// copy from the archive cache of the node, or download, and verify
+			cached := fetcher.archiveCache.get(&archive.Checksum, tmpPath)
+			if cached {
+				fetcher.logger.Debug("archive copied from cache", zap.String("checksum", archive.Checksum.Sum))
+			} else {
+				var err error
+				if _, _, _, ok := objectStoreLocation(archive.URL); ok {
+					err = fetcher.downloadObject(ctx, pkg.ObjectMeta.Namespace, archive, tmpPath)
+				} else {
+					err = fetcher.downloader.download(ctx, archive.URL, tmpPath)
+				}
+				if err != nil {
+					e := "failed to download url"
+					fetcher.logger.Error(e, zap.Error(err), zap.String("url", req.Url))
+					return http.StatusBadRequest, errors.Wrapf(err, "%s %s", e, req.Url)
+				}
 			}
 
 			// check file integrity only if checksum is not empty.
@@ -289,16 +379,41 @@ func (fetcher *Fetcher) Fetch(ctx context.Context, pkg *fv1.Package, req Functio
 				if err != nil {
 					e := "failed to verify checksum"
 					fetcher.logger.Error(e, zap.Error(err))
+					if cached {
+						// the next attempt downloads the archive again
+						fetcher.archiveCache.remove(&archive.Checksum)
+					}
 					return http.StatusBadRequest, errors.Wrap(err, e)
 				}
+				if !cached {
+					fetcher.archiveCache.put(&archive.Checksum, tmpPath)
+				}
 			}
 		}
 	}
 
-	if archiver.Zip.Match(tmpPath) && !req.KeepArchive {
+	if len(format) == 0 && !cloned {
+		var err error
+		format, err = detectArchiveFormat(tmpPath)
+		if err != nil {
+			e := "failed to detect archive format"
+			fetcher.logger.Error(e, zap.Error(err), zap.String("location", tmpPath))
+			return http.StatusInternalServerError, errors.Wrap(err, e)
+		}
+	}
+
+	if format == fv1.ArchiveFormatBinary {
+		// the binary is the function itself, make it executable
+		err := os.Chmod(tmpPath, 0755)
+		if err != nil {
+			e := "failed to make binary executable"
+			fetcher.logger.Error(e, zap.Error(err), zap.String("location", tmpPath))
+			return http.StatusInternalServerError, errors.Wrap(err, e)
+		}
+	} else if (format == fv1.ArchiveFormatZip || format == fv1.ArchiveFormatTarGz) && !req.KeepArchive {
 		// unarchive tmp file to a tmp unarchive path
 		tmpUnarchivePath := filepath.Join(fetcher.sharedVolumePath, uuid.NewV4().String())
-		err := fetcher.unarchive(tmpPath, tmpUnarchivePath)
+		err := fetcher.unarchive(tmpPath, tmpUnarchivePath, format)
 		if err != nil {
 			fetcher.logger.Error("error unarchive",
 				zap.Error(err),
@@ -423,6 +538,43 @@ func (fetcher *Fetcher) FetchSecretsAndCfgMaps(secrets []fv1.SecretReference, cf
 	return http.StatusOK, nil
 }
 
+// FetchExternalSecrets resolves secrets of external secret stores and
+// writes them as secrets of namespace, where FetchSecretsAndCfgMaps writes
+// kubernetes secrets. It returns the HTTP code and error if any
+func (fetcher *Fetcher) FetchExternalSecrets(ctx context.Context, namespace string, secrets []fv1.ExternalSecretReference) (int, error) {
+	for _, secret := range secrets {
+		data, err := fetcher.secretProviders.Resolve(ctx, &secret)
+		if err != nil {
+			fetcher.logger.Error("error resolving external secret",
+				zap.Error(err),
+				zap.String("secret_name", secret.Name),
+				zap.String("secret_provider", string(secret.Provider)))
+			return http.StatusInternalServerError, err
+		}
+
+		secretDir := filepath.Join(fetcher.sharedSecretPath, namespace, secret.Name)
+		err = os.MkdirAll(secretDir, os.ModeDir|0750)
+		if err != nil {
+			e := "failed to create directory for secret"
+			fetcher.logger.Error(e,
+				zap.Error(err),
+				zap.String("directory", secretDir),
+				zap.String("secret_name", secret.Name))
+			return http.StatusInternalServerError, errors.Wrapf(err, "%s: %s", e, secretDir)
+		}
+		err = writeSecretOrConfigMap(data, secretDir)
+		if err != nil {
+			fetcher.logger.Error("failed to write external secret to file location",
+				zap.Error(err),
+				zap.String("location", secretDir),
+				zap.String("secret_name", secret.Name))
+			return http.StatusInternalServerError, err
+		}
+	}
+
+	return http.StatusOK, nil
+}
+
 func (fetcher *Fetcher) UploadHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		http.Error(w, "only POST is supported on this endpoint", http.StatusMethodNotAllowed)
@@ -456,7 +608,14 @@ func (fetcher *Fetcher) UploadHandler(w http.ResponseWriter, r *http.Request) {
 	srcFilepath := filepath.Join(fetcher.sharedVolumePath, req.Filename)
 	dstFilepath := filepath.Join(fetcher.sharedVolumePath, zipFilename)
 
+	var manifest *sourcemap.Manifest
 	if req.ArchivePackage {
+		// the manifest is a debugging aid, packages without one still work
+		manifest, err = sourcemap.BuildManifest(srcFilepath)
+		if err != nil {
+			fetcher.logger.Error("error building source map manifest", zap.Error(err), zap.String("source", srcFilepath))
+		}
+
 		err = fetcher.archive(srcFilepath, dstFilepath)
 		if err != nil {
 			e := "error archiving zip file"
@@ -498,6 +657,15 @@ func (fetcher *Fetcher) UploadHandler(w http.ResponseWriter, r *http.Request) {
 		Checksum:           *sum,
 	}
 
+	if manifest != nil {
+		sourceMap, err := fetcher.uploadManifest(r.Context(), ssClient, manifest, dstFilepath+".sourcemap.json")
+		if err != nil {
+			fetcher.logger.Error("error uploading source map manifest", zap.Error(err), zap.String("file", dstFilepath))
+		} else {
+			resp.SourceMap = *sourceMap
+		}
+	}
+
 	rBody, err := json.Marshal(resp)
 	if err != nil {
 		e := "error encoding upload response"
@@ -517,6 +685,34 @@ func (fetcher *Fetcher) UploadHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// uploadManifest uploads the source map manifest of a deployment package.
+func (fetcher *Fetcher) uploadManifest(ctx context.Context, ssClient *storageSvcClient.Client,
+	manifest *sourcemap.Manifest, filename string) (*fv1.Archive, error) {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding source map manifest")
+	}
+	err = ioutil.WriteFile(filename, data, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "error writing source map manifest")
+	}
+
+	fileID, err := ssClient.Upload(ctx, filename, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error uploading source map manifest")
+	}
+	sum, err := utils.GetFileChecksum(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fv1.Archive{
+		Type:     fv1.ArchiveTypeUrl,
+		URL:      ssClient.GetUrl(fileID),
+		Checksum: *sum,
+	}, nil
+}
+
 func (fetcher *Fetcher) rename(src string, dst string) error {
 	err := os.Rename(src, dst)
 	if err != nil {
@@ -545,8 +741,16 @@ func (fetcher *Fetcher) archive(src string, dst string) error {
 	return archiver.Zip.Make(dst, files)
 }
 
-// unarchive is a function that unzips a zip file to destination
-func (fetcher *Fetcher) unarchive(src string, dst string) error {
+// unarchive is a function that extracts a zip or tar.gz file to destination
+func (fetcher *Fetcher) unarchive(src string, dst string, format fv1.ArchiveFormat) error {
+	if format == fv1.ArchiveFormatTarGz {
+		err := extractTarGz(src, dst)
+		if err != nil {
+			return errors.Wrap(err, "failed to extract tar.gz file")
+		}
+		return nil
+	}
+
 	err := archiver.Zip.Open(src, dst)
 	if err != nil {
 		return errors.Wrap(err, "failed to unzip file")
@@ -585,6 +789,18 @@ func (fetcher *Fetcher) getPkgInformation(req FunctionFetchRequest) (pkg *fv1.Pa
 	return nil, err
 }
 
+// FetchPackage gets the package a fetch request refers to, and fetches its
+// archive into the shared volume.
+func (fetcher *Fetcher) FetchPackage(ctx context.Context, req FunctionFetchRequest) error {
+	pkg, err := fetcher.getPkgInformation(req)
+	if err != nil {
+		return errors.Wrap(err, "error getting package information")
+	}
+
+	_, err = fetcher.Fetch(ctx, pkg, req)
+	return err
+}
+
 func (fetcher *Fetcher) SpecializePod(ctx context.Context, fetchReq FunctionFetchRequest, loadReq FunctionLoadRequest) error {
 	startTime := time.Now()
 	defer func() {
@@ -592,23 +808,29 @@ func (fetcher *Fetcher) SpecializePod(ctx context.Context, fetchReq FunctionFetc
 		fetcher.logger.Info("specialize request done", zap.Duration("elapsed_time", elapsed))
 	}()
 
-	pkg, err := fetcher.getPkgInformation(fetchReq)
-	if err != nil {
-		return errors.Wrap(err, "error getting package information")
+	// functions run from a deployment image have their archive in place already
+	if fetchReq.FetchType != fv1.FETCH_IMAGE {
+		err := fetcher.FetchPackage(ctx, fetchReq)
+		if err != nil {
+			return errors.Wrap(err, "error fetching deploy package")
+		}
 	}
 
-	_, err = fetcher.Fetch(ctx, pkg, fetchReq)
+	_, err := fetcher.FetchSecretsAndCfgMaps(fetchReq.Secrets, fetchReq.ConfigMaps)
 	if err != nil {
-		return errors.Wrap(err, "error fetching deploy package")
+		return errors.Wrap(err, "error fetching secrets/configs")
 	}
-
-	_, err = fetcher.FetchSecretsAndCfgMaps(fetchReq.Secrets, fetchReq.ConfigMaps)
+	_, err = fetcher.FetchExternalSecrets(ctx, fetchReq.SecretNamespace, fetchReq.ExternalSecrets)
 	if err != nil {
-		return errors.Wrap(err, "error fetching secrets/configs")
+		return errors.Wrap(err, "error fetching external secrets")
 	}
 
-	// Specialize the pod
+	return fetcher.loadFunction(loadReq)
+}
 
+// loadFunction asks the function container to load a function, retrying
+// while the container doesn't accept connections yet.
+func (fetcher *Fetcher) loadFunction(loadReq FunctionLoadRequest) error {
 	maxRetries := 30
 	var contentType string
 	var specializeURL string
@@ -0,0 +1,196 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// gitAskPass answers the username and password prompts of git from the
+// environment, so that credentials aren't passed on the command line.
+const gitAskPass = `#!/bin/sh
+case "$1" in
+Username*) echo "$FETCHER_GIT_USERNAME" ;;
+*) echo "$FETCHER_GIT_PASSWORD" ;;
+esac
+`
+
+// cloneGit checks out the ref of a Git repository into dst, keeping only
+// the sub path of the source. Credentials are read from the auth secret
+// in the namespace of the package.
+func (fetcher *Fetcher) cloneGit(ctx context.Context, namespace string, source *fv1.GitSource, dst string) error {
+	if strings.HasPrefix(source.URL, "-") || strings.HasPrefix(source.Ref, "-") {
+		return errors.Errorf("invalid repository %q or ref %q", source.URL, source.Ref)
+	}
+
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if len(source.AuthSecret) > 0 {
+		secret, err := fetcher.kubeClient.CoreV1().Secrets(namespace).Get(source.AuthSecret, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "error getting auth secret %q of repository", source.AuthSecret)
+		}
+		// credentials are kept out of the shared volume, which functions
+		// can read
+		authDir, err := ioutil.TempDir("", "git-auth")
+		if err != nil {
+			return errors.Wrap(err, "error creating directory for repository credentials")
+		}
+		defer os.RemoveAll(authDir)
+		authEnv, err := GitAuthEnv(fetcher.logger, secret.Data, authDir)
+		if err != nil {
+			return errors.Wrapf(err, "error using auth secret %q of repository", source.AuthSecret)
+		}
+		env = append(env, authEnv...)
+	}
+
+	cloneDir := dst + ".clone"
+	defer os.RemoveAll(cloneDir)
+	err := os.RemoveAll(dst)
+	if err != nil {
+		return errors.Wrap(err, "error removing earlier checkout of repository")
+	}
+	err = os.MkdirAll(cloneDir, 0750)
+	if err != nil {
+		return errors.Wrap(err, "error creating directory to clone repository into")
+	}
+	git := func(args ...string) (string, error) {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = cloneDir
+		cmd.Env = env
+		var out bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		err := cmd.Run()
+		if err != nil {
+			return "", errors.Wrapf(err, "git %v: %v", args[0], strings.TrimSpace(out.String()))
+		}
+		return strings.TrimSpace(out.String()), nil
+	}
+
+	_, err = git("init", "--quiet")
+	if err != nil {
+		return err
+	}
+	_, err = git("remote", "add", "origin", source.URL)
+	if err != nil {
+		return err
+	}
+
+	// fetch only the commit of ref if the server lets us, as with branches
+	// and tags, or else the whole history to find it, as with the commits
+	// of some servers
+	ref := source.Ref
+	if len(ref) == 0 {
+		ref = "HEAD"
+	}
+	_, err = git("fetch", "--quiet", "--depth", "1", "origin", ref)
+	if err == nil {
+		_, err = git("checkout", "--quiet", "FETCH_HEAD")
+	} else if len(source.Ref) > 0 {
+		fetcher.logger.Debug("error fetching ref of repository, fetching its whole history",
+			zap.Error(err), zap.String("ref", source.Ref))
+		_, err = git("fetch", "--quiet", "--tags", "origin")
+		if err == nil {
+			_, err = git("checkout", "--quiet", source.Ref)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	commit, err := git("rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+	fetcher.logger.Info("cloned repository",
+		zap.String("url", source.URL),
+		zap.String("ref", source.Ref),
+		zap.String("commit", commit))
+
+	err = os.RemoveAll(filepath.Join(cloneDir, ".git"))
+	if err != nil {
+		return errors.Wrap(err, "error removing git metadata")
+	}
+
+	src := filepath.Join(cloneDir, filepath.FromSlash(source.SubPath))
+	info, err := os.Stat(src)
+	if err != nil {
+		return errors.Wrapf(err, "error finding sub path %q in repository", source.SubPath)
+	}
+	if !info.IsDir() {
+		return errors.Errorf("sub path %q of repository is not a directory", source.SubPath)
+	}
+	return os.Rename(src, dst)
+}
+
+// GitAuthEnv writes the credentials of a secret to dir, and returns the
+// environment for git to use them. Secrets hold either an SSH private
+// key, with optional known hosts, or a password or token with an optional
+// username.
+func GitAuthEnv(logger *zap.Logger, data map[string][]byte, dir string) ([]string, error) {
+	if key, ok := data["ssh-privatekey"]; ok {
+		keyPath := filepath.Join(dir, "id")
+		err := ioutil.WriteFile(keyPath, key, 0600)
+		if err != nil {
+			return nil, errors.Wrap(err, "error writing SSH private key")
+		}
+		sshCmd := "ssh -i " + keyPath + " -o IdentitiesOnly=yes"
+		if knownHosts, ok := data["known_hosts"]; ok {
+			knownHostsPath := filepath.Join(dir, "known_hosts")
+			err = ioutil.WriteFile(knownHostsPath, knownHosts, 0600)
+			if err != nil {
+				return nil, errors.Wrap(err, "error writing SSH known hosts")
+			}
+			sshCmd += " -o UserKnownHostsFile=" + knownHostsPath + " -o StrictHostKeyChecking=yes"
+		} else {
+			logger.Warn("auth secret of repository has no known_hosts, the host key of the server is not verified")
+			sshCmd += " -o UserKnownHostsFile=/dev/null -o StrictHostKeyChecking=no"
+		}
+		return []string{"GIT_SSH_COMMAND=" + sshCmd}, nil
+	}
+
+	password, ok := data["password"]
+	if !ok {
+		return nil, errors.New("secret has neither ssh-privatekey nor password")
+	}
+	username := "git"
+	if u, ok := data["username"]; ok {
+		username = string(u)
+	}
+	askPassPath := filepath.Join(dir, "askpass")
+	err := ioutil.WriteFile(askPassPath, []byte(gitAskPass), 0700)
+	if err != nil {
+		return nil, errors.Wrap(err, "error writing askpass script")
+	}
+	return []string{
+		"GIT_ASKPASS=" + askPassPath,
+		"FETCHER_GIT_USERNAME=" + username,
+		"FETCHER_GIT_PASSWORD=" + string(password),
+	}, nil
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetcher
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestCloneGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir, err := ioutil.TempDir("", "clone-git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	repo := filepath.Join(dir, "repo")
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(contents string) string {
+		if err := ioutil.WriteFile(filepath.Join(repo, "fn", "hello.js"), []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+		git("add", "-A")
+		git("commit", "--quiet", "-m", contents)
+		return git("rev-parse", "HEAD")
+	}
+	if err := os.MkdirAll(filepath.Join(repo, "fn"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	git("init", "--quiet")
+	first := commit("v1")
+	git("tag", "v1")
+	commit("v2")
+
+	fetcher := &Fetcher{logger: zap.NewNop()}
+	tests := []struct {
+		name     string
+		source   fv1.GitSource
+		file     string
+		contents string
+	}{
+		{"default branch", fv1.GitSource{URL: "file://" + repo}, "fn/hello.js", "v2"},
+		{"tag and sub path", fv1.GitSource{URL: "file://" + repo, Ref: "v1", SubPath: "fn"}, "hello.js", "v1"},
+		{"commit", fv1.GitSource{URL: "file://" + repo, Ref: first, SubPath: "fn/"}, "hello.js", "v1"},
+	}
+	for i, test := range tests {
+		dst := filepath.Join(dir, "dst", strings.Repeat("x", i+1))
+		if err := os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+			t.Fatal(err)
+		}
+		err := fetcher.cloneGit(context.Background(), "default", &test.source, dst)
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dst, test.file))
+		if err != nil || string(data) != test.contents {
+			t.Errorf("%v: expected %q, got %q (%v)", test.name, test.contents, data, err)
+		}
+		if _, err := os.Stat(filepath.Join(dst, ".git")); err == nil {
+			t.Errorf("%v: expected git metadata to be removed", test.name)
+		}
+	}
+
+	err = fetcher.cloneGit(context.Background(), "default", &fv1.GitSource{URL: "file://" + repo, SubPath: "missing"}, filepath.Join(dir, "dst", "missing"))
+	if err == nil {
+		t.Error("expected missing sub path to fail")
+	}
+	err = fetcher.cloneGit(context.Background(), "default", &fv1.GitSource{URL: "file://" + repo, Ref: "--upload-pack=x"}, filepath.Join(dir, "dst", "option"))
+	if err == nil {
+		t.Error("expected ref starting with '-' to be rejected")
+	}
+}
+
+func TestGitAuthEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	env, err := GitAuthEnv(zap.NewNop(), map[string][]byte{"password": []byte("token")}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"GIT_ASKPASS=" + filepath.Join(dir, "askpass"), "FETCHER_GIT_USERNAME=git", "FETCHER_GIT_PASSWORD=token"}
+	if strings.Join(env, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected %v, got %v", expected, env)
+	}
+
+	env, err = GitAuthEnv(zap.NewNop(), map[string][]byte{"ssh-privatekey": []byte("key"), "known_hosts": []byte("hosts")}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(env) != 1 || !strings.Contains(env[0], "StrictHostKeyChecking=yes") {
+		t.Errorf("expected SSH command verifying host keys, got %v", env)
+	}
+	if key, err := ioutil.ReadFile(filepath.Join(dir, "id")); err != nil || string(key) != "key" {
+		t.Errorf("expected private key to be written, got %q (%v)", key, err)
+	}
+
+	_, err = GitAuthEnv(zap.NewNop(), map[string][]byte{"token": []byte("x")}, dir)
+	if err == nil {
+		t.Error("expected secret without credentials to be rejected")
+	}
+}
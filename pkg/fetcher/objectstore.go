@@ -0,0 +1,192 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetcher
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	objectStoreDefaultRegion = "us-east-1"
+
+	// gcsEndpoint is the S3 compatible endpoint of GCS, which takes
+	// the HMAC keys of service accounts.
+	gcsEndpoint = "https://storage.googleapis.com"
+)
+
+// objectStoreLocation returns the bucket or container and the key of an
+// object store URL, or false if the URL isn't one.
+func objectStoreLocation(archiveURL string) (string, string, string, bool) {
+	u, err := url.Parse(archiveURL)
+	if err != nil {
+		return "", "", "", false
+	}
+	switch u.Scheme {
+	case fv1.ArchiveURLSchemeS3, fv1.ArchiveURLSchemeGCS, fv1.ArchiveURLSchemeAzure:
+		return u.Scheme, u.Host, strings.TrimPrefix(u.Path, "/"), true
+	}
+	return "", "", "", false
+}
+
+// downloadObject downloads an archive from the object store of its URL
+// to dst, with the credentials of its auth secret.
+func (fetcher *Fetcher) downloadObject(ctx context.Context, namespace string, archive *fv1.Archive, dst string) error {
+	scheme, bucket, key, ok := objectStoreLocation(archive.URL)
+	if !ok {
+		return errors.Errorf("%q is not an object store URL", archive.URL)
+	}
+
+	secret := make(map[string]string)
+	if len(archive.AuthSecret) > 0 {
+		s, err := fetcher.kubeClient.CoreV1().Secrets(namespace).Get(archive.AuthSecret, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "error getting auth secret %q of archive", archive.AuthSecret)
+		}
+		for k, v := range s.Data {
+			secret[k] = string(v)
+		}
+	}
+
+	f, err := os.Create(dst)
+	if err != nil {
+		return errors.Wrap(err, "error creating file to download archive into")
+	}
+	defer f.Close()
+
+	start := time.Now()
+	if scheme == fv1.ArchiveURLSchemeAzure {
+		err = downloadAzureBlob(ctx, secret, bucket, key, f)
+	} else {
+		err = downloadS3Object(ctx, scheme, secret, bucket, key, f)
+	}
+	if err != nil {
+		return err
+	}
+	fetcher.logger.Info("downloaded archive from object store",
+		zap.String("url", archive.URL),
+		zap.Duration("elapsed_time", time.Since(start)))
+	return nil
+}
+
+// s3Config returns the config of the S3 client of an s3:// or gs:// URL.
+// S3 archives without credentials in the secret are read with the default
+// credential chain of the SDK, like the IAM role of the pod.
+func s3Config(scheme string, secret map[string]string) (*aws.Config, error) {
+	region := secret["region"]
+	if len(region) == 0 {
+		region = objectStoreDefaultRegion
+	}
+	config := &aws.Config{
+		Region:     aws.String(region),
+		HTTPClient: &http.Client{Timeout: 10 * time.Minute},
+	}
+
+	endpoint := secret["endpoint"]
+	if scheme == fv1.ArchiveURLSchemeGCS {
+		endpoint = gcsEndpoint
+	}
+	if len(endpoint) > 0 {
+		// S3 compatible stores, like MinIO, don't serve virtual hosted
+		// buckets
+		config.Endpoint = aws.String(strings.TrimSuffix(endpoint, "/"))
+		config.S3ForcePathStyle = aws.Bool(scheme != fv1.ArchiveURLSchemeGCS)
+	}
+
+	accessKeyID, secretAccessKey := secret["access-key-id"], secret["secret-access-key"]
+	if len(accessKeyID) > 0 || len(secretAccessKey) > 0 {
+		if len(accessKeyID) == 0 || len(secretAccessKey) == 0 {
+			return nil, errors.New("both access-key-id and secret-access-key are required in the auth secret")
+		}
+		config.Credentials = credentials.NewStaticCredentials(accessKeyID, secretAccessKey, secret["session-token"])
+	} else if scheme == fv1.ArchiveURLSchemeGCS {
+		return nil, errors.New("gs:// archives need an auth secret with the access-key-id and secret-access-key of an HMAC key")
+	}
+	return config, nil
+}
+
+func downloadS3Object(ctx context.Context, scheme string, secret map[string]string, bucket string, key string, w io.WriterAt) error {
+	config, err := s3Config(scheme, secret)
+	if err != nil {
+		return err
+	}
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return errors.Wrap(err, "error creating object store session")
+	}
+	_, err = s3manager.NewDownloader(sess).DownloadWithContext(ctx, w, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error downloading %v://%v/%v", scheme, bucket, key)
+	}
+	return nil
+}
+
+func downloadAzureBlob(ctx context.Context, secret map[string]string, container string, name string, w io.Writer) error {
+	account, key := secret["account-name"], secret["account-key"]
+	if len(account) == 0 || len(key) == 0 {
+		return errors.New("azblob:// archives need an auth secret with account-name and account-key")
+	}
+	client, err := storage.NewBasicClient(account, key)
+	if err != nil {
+		return errors.Wrap(err, "error creating azure storage client")
+	}
+	blobService := client.GetBlobService()
+	blob := blobService.GetContainerReference(container).GetBlobReference(name)
+	r, err := blob.Get(nil)
+	if err != nil {
+		return errors.Wrapf(err, "error downloading azblob://%v/%v", container, name)
+	}
+	defer r.Close()
+	_, err = io.Copy(w, &contextReader{ctx: ctx, r: r})
+	if err != nil {
+		return errors.Wrapf(err, "error downloading azblob://%v/%v", container, name)
+	}
+	return nil
+}
+
+// contextReader stops reading once its context is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
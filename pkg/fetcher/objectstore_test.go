@@ -0,0 +1,83 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetcher
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestObjectStoreLocation(t *testing.T) {
+	tests := []struct {
+		url    string
+		scheme string
+		bucket string
+		key    string
+		ok     bool
+	}{
+		{"s3://artifacts/fns/hello.zip", "s3", "artifacts", "fns/hello.zip", true},
+		{"gs://artifacts/hello.zip", "gs", "artifacts", "hello.zip", true},
+		{"azblob://artifacts/hello.zip", "azblob", "artifacts", "hello.zip", true},
+		{"https://artifacts.s3.amazonaws.com/hello.zip?X-Amz-Signature=x", "", "", "", false},
+		{"http://storagesvc.fission/v1/archive?id=x", "", "", "", false},
+	}
+	for _, test := range tests {
+		scheme, bucket, key, ok := objectStoreLocation(test.url)
+		if scheme != test.scheme || bucket != test.bucket || key != test.key || ok != test.ok {
+			t.Errorf("%v: expected %v %v %v %v, got %v %v %v %v", test.url,
+				test.scheme, test.bucket, test.key, test.ok, scheme, bucket, key, ok)
+		}
+	}
+}
+
+func TestS3Config(t *testing.T) {
+	config, err := s3Config("s3", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.StringValue(config.Region) != objectStoreDefaultRegion || config.Endpoint != nil || config.Credentials != nil {
+		t.Errorf("expected default region and credential chain, got %+v", config)
+	}
+
+	config, err = s3Config("s3", map[string]string{
+		"access-key-id":     "id",
+		"secret-access-key": "key",
+		"endpoint":          "http://minio.storage:9000/",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.StringValue(config.Endpoint) != "http://minio.storage:9000" || !aws.BoolValue(config.S3ForcePathStyle) || config.Credentials == nil {
+		t.Errorf("expected path style requests to the endpoint with static credentials, got %+v", config)
+	}
+
+	config, err = s3Config("gs", map[string]string{"access-key-id": "id", "secret-access-key": "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.StringValue(config.Endpoint) != gcsEndpoint {
+		t.Errorf("expected GCS endpoint, got %v", aws.StringValue(config.Endpoint))
+	}
+
+	if _, err := s3Config("gs", map[string]string{}); err == nil {
+		t.Error("expected gs:// archive without credentials to be rejected")
+	}
+	if _, err := s3Config("s3", map[string]string{"access-key-id": "id"}); err == nil {
+		t.Error("expected access key without secret to be rejected")
+	}
+}
@@ -0,0 +1,386 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetcher
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// Paths fetcher answers the probes of kubelet for the function container
+// on.
+const (
+	StartupProbePath  = "/probe/startup"
+	LivenessProbePath = "/probe/liveness"
+)
+
+// The defaults of kubelet for unset probe fields.
+const (
+	defaultProbePeriod           = 10 * time.Second
+	defaultProbeTimeout          = time.Second
+	defaultProbeSuccessThreshold = 1
+	defaultProbeFailureThreshold = 3
+)
+
+type (
+	// prober runs the probes of the function container once a function is
+	// loaded into it. The function container is probed by kubelet through
+	// fetcher: its startup probe tells fetcher when the container restarted,
+	// and its liveness probe fails once the function failed its own startup
+	// or liveness probe, to have kubelet restart the container. Functions
+	// are loaded again into restarted containers.
+	prober struct {
+		logger *zap.Logger
+		client *http.Client
+
+		mu sync.Mutex
+		// loads are the load requests of the functions loaded into the
+		// function container, to load them again after restarts.
+		loads  []FunctionLoadRequest
+		load   func(FunctionLoadRequest) error
+		probes *fv1.Probes
+
+		// started and ready are the results of the startup and
+		// readiness probes of the function.
+		started bool
+		ready   bool
+		// failed is set once the function fails its startup or liveness
+		// probe, until kubelet is told to restart the container.
+		failed bool
+		// containerStarted is set once the function container passed the
+		// startup probe of kubelet, which it only runs for new containers.
+		containerStarted bool
+		// reloading is set from the restart of the function container
+		// until the functions are loaded again.
+		reloading bool
+		restarted chan struct{}
+		// changed is closed and replaced whenever the results change.
+		changed chan struct{}
+	}
+)
+
+func makeProber(logger *zap.Logger) *prober {
+	return &prober{
+		logger: logger.Named("prober"),
+		client: &http.Client{
+			Transport: &http.Transport{
+				// like kubelet, HTTPS probes don't verify certificates
+				TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+				DisableKeepAlives: true,
+			},
+		},
+		restarted: make(chan struct{}, 1),
+		changed:   make(chan struct{}),
+	}
+}
+
+// start starts probing the function container once a function is loaded
+// with loadReq. Functions loaded into the container later keep the probes
+// of the first.
+func (p *prober) start(loadReq FunctionLoadRequest, probes *fv1.Probes, load func(FunctionLoadRequest) error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.loads = append(p.loads, loadReq)
+	if len(p.loads) > 1 {
+		return
+	}
+	if probes == nil {
+		probes = &fv1.Probes{}
+	}
+	p.probes = probes
+	p.load = load
+	go p.run(context.Background())
+}
+
+// run probes the function until ctx is done. Each time the function fails
+// or the container restarts, the function is loaded into the restarted
+// container and probed again.
+func (p *prober) run(ctx context.Context) {
+	for {
+		lifeCtx, cancel := context.WithCancel(ctx)
+		failed := make(chan struct{})
+		var failOnce sync.Once
+		fail := func() {
+			failOnce.Do(func() { close(failed) })
+		}
+
+		p.update(func() {
+			p.started = p.probes.Startup == nil
+			p.ready = p.probes.Readiness == nil
+		})
+		startProbes := func() {
+			p.update(func() { p.started = true })
+			if p.probes.Readiness != nil {
+				go p.probeLoop(lifeCtx, "readiness", p.probes.Readiness, func(ok bool) {
+					p.update(func() { p.ready = ok })
+				})
+			}
+			if p.probes.Liveness != nil {
+				go p.probeLoop(lifeCtx, "liveness", p.probes.Liveness, func(ok bool) {
+					if !ok {
+						fail()
+					}
+				})
+			}
+		}
+		if p.probes.Startup != nil {
+			startupCtx, stop := context.WithCancel(lifeCtx)
+			go p.probeLoop(startupCtx, "startup", p.probes.Startup, func(ok bool) {
+				stop()
+				if ok {
+					startProbes()
+				} else {
+					fail()
+				}
+			})
+		} else {
+			startProbes()
+		}
+
+		select {
+		case <-ctx.Done():
+			cancel()
+			return
+		case <-failed:
+			cancel()
+			p.update(func() {
+				p.failed = true
+				p.ready = false
+			})
+			// kubelet restarts the container on its next liveness probe
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.restarted:
+			}
+		case <-p.restarted:
+			cancel()
+		}
+
+		p.mu.Lock()
+		loads := p.loads
+		p.mu.Unlock()
+		for _, loadReq := range loads {
+			err := p.load(loadReq)
+			if err != nil {
+				// the function is probed all the same, to restart the
+				// container once more if it fails
+				p.logger.Error("error loading function into restarted container", zap.Error(err))
+			}
+		}
+		p.logger.Info("loaded functions into restarted container", zap.Int("functions", len(loads)))
+		p.update(func() { p.reloading = false })
+	}
+}
+
+// probeLoop runs the action of probe periodically, and reports its result
+// each time it changes, after enough consecutive successes or failures,
+// until ctx is done.
+func (p *prober) probeLoop(ctx context.Context, kind string, probe *apiv1.Probe, report func(ok bool)) {
+	period := probeSeconds(probe.PeriodSeconds, defaultProbePeriod)
+	successThreshold := probeThreshold(probe.SuccessThreshold, defaultProbeSuccessThreshold)
+	failureThreshold := probeThreshold(probe.FailureThreshold, defaultProbeFailureThreshold)
+
+	delay := time.Duration(probe.InitialDelaySeconds) * time.Second
+	var successes, failures int32
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = period
+
+		err := p.check(ctx, probe)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			successes++
+			failures = 0
+			if successes == successThreshold {
+				report(true)
+			}
+			continue
+		}
+		successes = 0
+		failures++
+		if failures == failureThreshold {
+			p.logger.Info("function failed probe", zap.String("probe", kind), zap.Error(err))
+			report(false)
+		}
+	}
+}
+
+// check runs the action of probe against the function container.
+func (p *prober) check(ctx context.Context, probe *apiv1.Probe) error {
+	ctx, cancel := context.WithTimeout(ctx, probeSeconds(probe.TimeoutSeconds, defaultProbeTimeout))
+	defer cancel()
+
+	switch {
+	case probe.HTTPGet != nil:
+		action := probe.HTTPGet
+		scheme := strings.ToLower(string(action.Scheme))
+		if len(scheme) == 0 {
+			scheme = "http"
+		}
+		path := action.Path
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		url := fmt.Sprintf("%v://%v%v", scheme, net.JoinHostPort(probeHost(action.Host), action.Port.String()), path)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		for _, header := range action.HTTPHeaders {
+			if strings.EqualFold(header.Name, "Host") {
+				req.Host = header.Value
+				continue
+			}
+			req.Header.Add(header.Name, header.Value)
+		}
+		resp, err := p.client.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+			return errors.Errorf("%v responded with status %v", url, resp.Status)
+		}
+		return nil
+
+	case probe.TCPSocket != nil:
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(probeHost(probe.TCPSocket.Host), probe.TCPSocket.Port.String()))
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+
+	default:
+		return errors.New("probe has no httpGet or tcpSocket action")
+	}
+}
+
+// update changes the results of the probes with f.
+func (p *prober) update(f func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f()
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// functionReady returns whether the function, if any, is started and ready.
+func (p *prober) functionReady() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.loads) == 0 || (p.started && p.ready && !p.failed && !p.reloading)
+}
+
+// waitReady waits for the function to be started and ready, and returns
+// an error if it fails first.
+func (p *prober) waitReady(ctx context.Context) error {
+	for {
+		p.mu.Lock()
+		ready := p.started && p.ready && !p.reloading
+		failed := p.failed
+		changed := p.changed
+		p.mu.Unlock()
+		if ready {
+			return nil
+		}
+		if failed {
+			return errors.New("function failed its startup or liveness probe")
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "error waiting for the function to be ready")
+		case <-changed:
+		}
+	}
+}
+
+// startupHandler answers the startup probe of the function container.
+// Kubelet runs it for new containers only, so a container probed again
+// once functions are loaded is a restarted one, to load them again into.
+func (p *prober) startupHandler(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.containerStarted && len(p.loads) > 0 && !p.reloading {
+		p.containerStarted = false
+		p.reloading = true
+		select {
+		case p.restarted <- struct{}{}:
+		default:
+		}
+	}
+	if p.reloading {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	p.containerStarted = true
+	w.WriteHeader(http.StatusOK)
+}
+
+// livenessHandler answers the liveness probe of the function container,
+// failing once after the function failed.
+func (p *prober) livenessHandler(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failed {
+		p.failed = false
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func probeHost(host string) string {
+	if len(host) == 0 {
+		// the function container shares the network of the pod
+		return "127.0.0.1"
+	}
+	return host
+}
+
+func probeSeconds(seconds int32, defaultDuration time.Duration) time.Duration {
+	if seconds <= 0 {
+		return defaultDuration
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func probeThreshold(threshold int32, defaultThreshold int32) int32 {
+	if threshold <= 0 {
+		return defaultThreshold
+	}
+	return threshold
+}
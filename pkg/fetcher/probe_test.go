@@ -0,0 +1,161 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetcher
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// httpProbe returns a probe of path on server, run every second.
+func httpProbe(t *testing.T, server *httptest.Server, path string, failureThreshold int32) *apiv1.Probe {
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNumber, _ := strconv.Atoi(port)
+	return &apiv1.Probe{
+		PeriodSeconds:    1,
+		FailureThreshold: failureThreshold,
+		Handler: apiv1.Handler{
+			HTTPGet: &apiv1.HTTPGetAction{
+				Path: path,
+				Port: intstr.FromInt(portNumber),
+				HTTPHeaders: []apiv1.HTTPHeader{
+					{Name: "X-Probe", Value: "fission"},
+				},
+			},
+		},
+	}
+}
+
+func probeStatus(handler http.HandlerFunc) int {
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w.Code
+}
+
+func TestProberCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Probe") != "fission" || r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	p := makeProber(zap.NewNop())
+	ctx := context.Background()
+	if err := p.check(ctx, httpProbe(t, server, "healthz", 1)); err != nil {
+		t.Fatalf("expected HTTP check to succeed: %v", err)
+	}
+	if err := p.check(ctx, httpProbe(t, server, "/other", 1)); err == nil {
+		t.Fatal("expected HTTP check to fail on server error")
+	}
+
+	tcpProbe := &apiv1.Probe{
+		Handler: apiv1.Handler{
+			TCPSocket: &apiv1.TCPSocketAction{
+				Port: httpProbe(t, server, "/", 1).HTTPGet.Port,
+			},
+		},
+	}
+	if err := p.check(ctx, tcpProbe); err != nil {
+		t.Fatalf("expected TCP check to succeed: %v", err)
+	}
+	server.Close()
+	if err := p.check(ctx, tcpProbe); err == nil {
+		t.Fatal("expected TCP check to fail once the server is closed")
+	}
+}
+
+func TestProberRestart(t *testing.T) {
+	var healthy int32 = 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	p := makeProber(zap.NewNop())
+	if !p.functionReady() {
+		t.Fatal("expected generic container to be ready")
+	}
+	// kubelet starts the generic container
+	if status := probeStatus(p.startupHandler); status != http.StatusOK {
+		t.Fatalf("expected generic container to start, got %v", status)
+	}
+
+	var loads int32
+	load := func(FunctionLoadRequest) error {
+		atomic.AddInt32(&loads, 1)
+		atomic.StoreInt32(&healthy, 1)
+		return nil
+	}
+	p.start(FunctionLoadRequest{FunctionName: "hello"}, &fv1.Probes{
+		Readiness: httpProbe(t, server, "/ready", 1),
+		Liveness:  httpProbe(t, server, "/live", 2),
+	}, load)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := p.waitReady(ctx); err != nil {
+		t.Fatalf("expected function to be ready: %v", err)
+	}
+
+	// the function fails its liveness probe twice in a row
+	atomic.StoreInt32(&healthy, 0)
+	deadline := time.Now().Add(10 * time.Second)
+	for probeStatus(p.livenessHandler) == http.StatusOK {
+		if time.Now().After(deadline) {
+			t.Fatal("expected liveness probe of the container to fail")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if p.functionReady() {
+		t.Fatal("expected failed function not to be ready")
+	}
+	if status := probeStatus(p.livenessHandler); status != http.StatusOK {
+		t.Fatalf("expected liveness probe to fail once, got %v", status)
+	}
+
+	// kubelet restarts the container, and probes its startup
+	for probeStatus(p.startupHandler) != http.StatusOK {
+		if time.Now().After(deadline) {
+			t.Fatal("expected restarted container to start")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if atomic.LoadInt32(&loads) != 1 {
+		t.Fatalf("expected function to be loaded again once, got %v", loads)
+	}
+	if err := p.waitReady(ctx); err != nil {
+		t.Fatalf("expected function to be ready again: %v", err)
+	}
+}
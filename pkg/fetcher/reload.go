@@ -0,0 +1,295 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// Kinds of the resources reloaded, as told to reload callbacks.
+const (
+	reloadKindSecret    = "secret"
+	reloadKindConfigMap = "configmap"
+)
+
+// reloadTempPrefix prefixes the files new content is written to before
+// being renamed over the file of its key.
+const reloadTempPrefix = ".reload-"
+
+type (
+	// reloader keeps the secrets and configmaps written for the functions
+	// loaded into the pod up to date, for functions reloading them.
+	reloader struct {
+		logger     *zap.Logger
+		client     kubernetes.Interface
+		secretPath string
+		configPath string
+		// functionURL is the address of the function container callbacks
+		// are sent to.
+		functionURL string
+		httpClient  *http.Client
+
+		mu sync.Mutex
+		// callbacks are the callback paths of the functions referencing
+		// each resource watched.
+		callbacks map[reloadTarget][]string
+	}
+
+	// reloadTarget is a secret or configmap reloaded.
+	reloadTarget struct {
+		Kind      string `json:"kind"`
+		Namespace string `json:"namespace"`
+		Name      string `json:"name"`
+	}
+)
+
+func makeReloader(logger *zap.Logger, client kubernetes.Interface, secretPath string, configPath string) *reloader {
+	return &reloader{
+		logger:      logger.Named("reloader"),
+		client:      client,
+		secretPath:  secretPath,
+		configPath:  configPath,
+		functionURL: "http://127.0.0.1:8888",
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		callbacks:   make(map[reloadTarget][]string),
+	}
+}
+
+// start watches the secrets and configmaps of a fetch request, if the
+// function reloads them. Resources watched already for other functions
+// loaded into the pod are watched once.
+func (r *reloader) start(req FunctionFetchRequest, reload *fv1.ConfigReload) {
+	if reload == nil {
+		return
+	}
+	var targets []reloadTarget
+	for _, secret := range req.Secrets {
+		targets = append(targets, reloadTarget{Kind: reloadKindSecret, Namespace: secret.Namespace, Name: secret.Name})
+	}
+	for _, cfgMap := range req.ConfigMaps {
+		targets = append(targets, reloadTarget{Kind: reloadKindConfigMap, Namespace: cfgMap.Namespace, Name: cfgMap.Name})
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, target := range targets {
+		callbacks, watched := r.callbacks[target]
+		if len(reload.CallbackPath) > 0 && !containsString(callbacks, reload.CallbackPath) {
+			callbacks = append(callbacks, reload.CallbackPath)
+		}
+		r.callbacks[target] = callbacks
+		if !watched {
+			go r.watch(target)
+		}
+	}
+}
+
+// watch updates the files of target whenever it changes. The files are
+// compared with the current content, so the first event, for the version
+// written at specialization, and resyncs change nothing.
+func (r *reloader) watch(target reloadTarget) {
+	selector := fields.OneTermEqualSelector("metadata.name", target.Name).String()
+	var listWatch *cache.ListWatch
+	var objType runtime.Object
+	if target.Kind == reloadKindSecret {
+		objType = &apiv1.Secret{}
+		listWatch = &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.FieldSelector = selector
+				return r.client.CoreV1().Secrets(target.Namespace).List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.FieldSelector = selector
+				return r.client.CoreV1().Secrets(target.Namespace).Watch(options)
+			},
+		}
+	} else {
+		objType = &apiv1.ConfigMap{}
+		listWatch = &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.FieldSelector = selector
+				return r.client.CoreV1().ConfigMaps(target.Namespace).List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.FieldSelector = selector
+				return r.client.CoreV1().ConfigMaps(target.Namespace).Watch(options)
+			},
+		}
+	}
+
+	_, controller := cache.NewInformer(listWatch, objType, 0, cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			r.update(target, obj)
+		},
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			r.update(target, newObj)
+		},
+	})
+	// function pods are never unspecialized, the watch lasts as long as
+	// the pod
+	controller.Run(make(chan struct{}))
+}
+
+// update writes the data of obj, the current version of target, and
+// calls back the functions referencing it if anything changed.
+func (r *reloader) update(target reloadTarget, obj interface{}) {
+	var meta metav1.ObjectMeta
+	var data map[string][]byte
+	var dir string
+	switch o := obj.(type) {
+	case *apiv1.Secret:
+		meta = o.ObjectMeta
+		data = o.Data
+		dir = filepath.Join(r.secretPath, target.Namespace, target.Name)
+	case *apiv1.ConfigMap:
+		meta = o.ObjectMeta
+		data = make(map[string][]byte, len(o.Data))
+		for key, val := range o.Data {
+			data[key] = []byte(val)
+		}
+		dir = filepath.Join(r.configPath, target.Namespace, target.Name)
+	default:
+		return
+	}
+	if meta.Name != target.Name || meta.Namespace != target.Namespace {
+		return
+	}
+
+	logger := r.logger.With(zap.String("kind", target.Kind),
+		zap.String("name", target.Name),
+		zap.String("namespace", target.Namespace))
+	changed, err := writeChangedData(data, dir)
+	if err != nil {
+		logger.Error("error reloading", zap.Error(err))
+		return
+	}
+	if !changed {
+		return
+	}
+	logger.Info("reloaded", zap.String("resource_version", meta.ResourceVersion))
+
+	r.mu.Lock()
+	callbacks := r.callbacks[target]
+	r.mu.Unlock()
+	for _, path := range callbacks {
+		err := r.callback(path, target)
+		if err != nil {
+			logger.Error("error calling back function after reload", zap.Error(err), zap.String("path", path))
+		}
+	}
+}
+
+// callback tells the function container at path that target was
+// reloaded.
+func (r *reloader) callback(path string, target reloadTarget) error {
+	body, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+	resp, err := r.httpClient.Post(r.functionURL+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+		return errors.Errorf("function responded with status %v", resp.Status)
+	}
+	return nil
+}
+
+// writeChangedData updates the files of dir to data, a file per key, and
+// returns whether any changed. New content is renamed over the file of its
+// key, which is atomic, and the files of keys removed are removed.
+func writeChangedData(data map[string][]byte, dir string) (bool, error) {
+	err := os.MkdirAll(dir, os.ModeDir|0750)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	for key, val := range data {
+		path := filepath.Join(dir, key)
+		current, err := ioutil.ReadFile(path)
+		if err == nil && bytes.Equal(current, val) {
+			continue
+		}
+		tmp, err := ioutil.TempFile(dir, reloadTempPrefix)
+		if err != nil {
+			return changed, err
+		}
+		_, err = tmp.Write(val)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Chmod(tmp.Name(), 0750)
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), path)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+			return changed, errors.Wrapf(err, "error writing file %s", path)
+		}
+		changed = true
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return changed, err
+	}
+	for _, file := range files {
+		if _, ok := data[file.Name()]; ok || !file.Mode().IsRegular() || strings.HasPrefix(file.Name(), reloadTempPrefix) {
+			continue
+		}
+		err := os.Remove(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetcher
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestWriteChangedData(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	changed, err := writeChangedData(map[string][]byte{"a": []byte("1"), "b": []byte("2")}, dir)
+	if err != nil || !changed {
+		t.Fatalf("expected new data to be written, got %v, %v", changed, err)
+	}
+	changed, err = writeChangedData(map[string][]byte{"a": []byte("1"), "b": []byte("2")}, dir)
+	if err != nil || changed {
+		t.Fatalf("expected same data to change nothing, got %v, %v", changed, err)
+	}
+	changed, err = writeChangedData(map[string][]byte{"a": []byte("3")}, dir)
+	if err != nil || !changed {
+		t.Fatalf("expected changed data to be written, got %v, %v", changed, err)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name() != "a" {
+		t.Fatalf("expected only the file of key a, got %v", files)
+	}
+	data, _ := ioutil.ReadFile(filepath.Join(dir, "a"))
+	if string(data) != "3" {
+		t.Fatalf("expected new content of key a, got %q", data)
+	}
+}
+
+func TestReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	callbacks := make(chan reloadTarget, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/reload" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var target reloadTarget
+		_ = json.NewDecoder(r.Body).Decode(&target)
+		callbacks <- target
+	}))
+	defer server.Close()
+
+	secret := &apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "fn", ResourceVersion: "1"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	}
+	other := &apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "fn", ResourceVersion: "1"},
+		Data:       map[string][]byte{"password": []byte("other")},
+	}
+	client := fake.NewSimpleClientset(secret, other)
+
+	r := makeReloader(zap.NewNop(), client, filepath.Join(dir, "secrets"), filepath.Join(dir, "configs"))
+	r.functionURL = server.URL
+	secretDir := filepath.Join(dir, "secrets", "fn", "db")
+	// fetcher writes the secret when specializing
+	if _, err := writeChangedData(secret.Data, secretDir); err != nil {
+		t.Fatal(err)
+	}
+
+	reload := &fv1.ConfigReload{CallbackPath: "/reload"}
+	req := FunctionFetchRequest{Secrets: []fv1.SecretReference{{Namespace: "fn", Name: "db"}}}
+	r.start(req, reload)
+	// a second function referencing the same secret has the same callback
+	r.start(req, reload)
+
+	// the first event is the version written at specialization
+	select {
+	case target := <-callbacks:
+		t.Fatalf("unexpected callback for %v", target)
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	secret = secret.DeepCopy()
+	secret.ResourceVersion = "2"
+	secret.Data["password"] = []byte("s3cr3t")
+	if _, err := client.CoreV1().Secrets("fn").Update(secret); err != nil {
+		t.Fatal(err)
+	}
+	other = other.DeepCopy()
+	other.ResourceVersion = "2"
+	other.Data["password"] = []byte("changed")
+	if _, err := client.CoreV1().Secrets("fn").Update(other); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case target := <-callbacks:
+		expected := reloadTarget{Kind: reloadKindSecret, Namespace: "fn", Name: "db"}
+		if target != expected {
+			t.Fatalf("expected callback for %v, got %v", expected, target)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected callback after secret changed")
+	}
+	data, _ := ioutil.ReadFile(filepath.Join(secretDir, "password"))
+	if string(data) != "s3cr3t" {
+		t.Fatalf("expected reloaded secret, got %q", data)
+	}
+	select {
+	case target := <-callbacks:
+		t.Fatalf("unexpected callback for %v", target)
+	case <-time.After(500 * time.Millisecond):
+	}
+	if _, err := os.Stat(filepath.Join(dir, "secrets", "fn", "other")); !os.IsNotExist(err) {
+		t.Fatalf("expected secret not referenced not to be written, got %v", err)
+	}
+}
@@ -33,6 +33,14 @@ type (
 	FunctionSpecializeRequest struct {
 		FetchReq FunctionFetchRequest
 		LoadReq  FunctionLoadRequest
+
+		// Probes are run by fetcher against the function container
+		// once the function is loaded.
+		Probes *fv1.Probes `json:"probes,omitempty"`
+
+		// ConfigReload, if set, has fetcher reload the secrets and
+		// configmaps of the function when they change.
+		ConfigReload *fv1.ConfigReload `json:"configReload,omitempty"`
 	}
 
 	FunctionFetchRequest struct {
@@ -44,6 +52,12 @@ type (
 		Secrets       []fv1.SecretReference    `json:"secretList"`
 		ConfigMaps    []fv1.ConfigMapReference `json:"configMapList"`
 		KeepArchive   bool                     `json:"keeparchive"`
+
+		// ExternalSecrets are resolved from their secret stores and
+		// written as secrets of SecretNamespace, the namespace of the
+		// function.
+		ExternalSecrets []fv1.ExternalSecretReference `json:"externalSecretList,omitempty"`
+		SecretNamespace string                        `json:"secretNamespace,omitempty"`
 	}
 
 	FunctionLoadRequest struct {
@@ -81,5 +95,9 @@ type (
 	ArchiveUploadResponse struct {
 		ArchiveDownloadUrl string       `json:"archiveDownloadUrl"`
 		Checksum           fv1.Checksum `json:"checksum"`
+
+		// SourceMap is the source map manifest of the archive, if the
+		// package uploaded has source maps.
+		SourceMap fv1.Archive `json:"sourceMap,omitempty"`
 	}
 )
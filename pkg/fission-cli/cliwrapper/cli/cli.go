@@ -73,5 +73,8 @@ type (
 
 		// Duration returns time duration of given flag.
 		Duration(key string) time.Duration
+
+		// Args returns the positional arguments.
+		Args() []string
 	}
 )
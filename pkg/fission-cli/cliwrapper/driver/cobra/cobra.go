@@ -306,3 +306,7 @@ func (u Cli) Duration(key string) time.Duration {
 	v, _ := u.c.Flags().GetDuration(key)
 	return v
 }
+
+func (u Cli) Args() []string {
+	return u.args
+}
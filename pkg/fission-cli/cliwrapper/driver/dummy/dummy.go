@@ -24,6 +24,9 @@ import (
 
 var _ fCli.Input = &Cli{}
 
+// argsKey is the key positional arguments are kept under, flag names can't contain spaces.
+const argsKey = "positional args"
+
 type Cli struct {
 	c map[string]interface{}
 }
@@ -40,6 +43,11 @@ func (u Cli) Set(Key string, value interface{}) {
 	u.c[Key] = value
 }
 
+// SetArgs sets the positional arguments.
+func (u Cli) SetArgs(args ...string) {
+	u.c[argsKey] = args
+}
+
 func (u Cli) IsSet(key string) bool {
 	_, ok := u.c[key]
 	return ok
@@ -164,3 +172,11 @@ func (u Cli) Duration(key string) time.Duration {
 	}
 	return val.(time.Duration)
 }
+
+func (u Cli) Args() []string {
+	val, ok := u.c[argsKey]
+	if !ok || val == nil {
+		return nil
+	}
+	return val.([]string)
+}
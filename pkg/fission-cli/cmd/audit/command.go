@@ -0,0 +1,47 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"github.com/spf13/cobra"
+
+	wrapper "github.com/fission/fission/pkg/fission-cli/cliwrapper/driver/cobra"
+	"github.com/fission/fission/pkg/fission-cli/flag"
+)
+
+// Commands returns audit commands
+func Commands() *cobra.Command {
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List who created, updated, deleted and restored objects, when, and what changed",
+		Long: "List the most recent changes made to objects through the controller. " +
+			"The controller keeps the latest changes for queries, the full audit log is written to its sinks.",
+		RunE: wrapper.Wrapper(List),
+	}
+	wrapper.SetFlags(listCmd, flag.FlagSet{
+		Optional: []flag.Flag{flag.AuditKind, flag.AuditNamespace, flag.AuditName, flag.AuditUser, flag.AuditSince, flag.AuditLimit},
+	})
+
+	command := &cobra.Command{
+		Use:   "audit",
+		Short: "Audit changes to objects",
+	}
+
+	command.AddCommand(listCmd)
+
+	return command
+}
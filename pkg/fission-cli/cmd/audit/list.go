@@ -0,0 +1,88 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+
+	v1 "github.com/fission/fission/pkg/controller/client/v1"
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
+)
+
+type ListSubCommand struct {
+	cmd.CommandActioner
+}
+
+func List(input cli.Input) error {
+	return (&ListSubCommand{}).do(input)
+}
+
+func (opts *ListSubCommand) do(input cli.Input) error {
+	err := util.RequireServerFeature(opts.Client(), info.FeatureAudit)
+	if err != nil {
+		return err
+	}
+
+	entries, err := opts.Client().V1().Misc().AuditLog(&v1.AuditFilter{
+		Kind:      input.String(flagkey.AuditKind),
+		Namespace: input.String(flagkey.AuditNamespace),
+		Name:      input.String(flagkey.AuditName),
+		User:      input.String(flagkey.AuditUser),
+		Since:     input.String(flagkey.AuditSince),
+		Limit:     input.Int(flagkey.AuditLimit),
+	})
+	if err != nil {
+		return errors.Wrap(err, "error listing audit log")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", "TIME", "USER", "OPERATION", "KIND", "NAMESPACE", "NAME", "STATUS")
+	for _, e := range entries {
+		user := e.User
+		if len(user) == 0 {
+			user = "<unknown>"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", e.Time.Local().Format(time.RFC3339), user,
+			e.Operation, e.Kind, e.Namespace, e.Name, e.Status)
+		if len(e.Error) > 0 {
+			fmt.Fprintf(w, "\t  error: %v\t\t\t\t\t\n", e.Error)
+		}
+		for _, change := range e.Changes {
+			fmt.Fprintf(w, "\t  %v\t%v\t%v\t\t\t\n", change.Path,
+				fmt.Sprintf("old: %v", emptyAsUnset(change.Old)), fmt.Sprintf("new: %v", emptyAsUnset(change.New)))
+		}
+	}
+	w.Flush()
+
+	return nil
+}
+
+func emptyAsUnset(value string) string {
+	if len(value) == 0 {
+		return "<unset>"
+	}
+	return value
+}
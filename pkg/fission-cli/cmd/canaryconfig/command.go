@@ -32,7 +32,9 @@ func Commands() *cobra.Command {
 	}
 	wrapper.SetFlags(createCmd, flag.FlagSet{
 		Required: []flag.Flag{flag.CanaryName, flag.CanaryTriggerName, flag.CanaryNewFunc, flag.CanaryOldFunc},
-		Optional: []flag.Flag{flag.CanaryWeightIncrement, flag.CanaryIncrementInterval, flag.CanaryFailureThreshold, flag.NamespaceFunction},
+		Optional: []flag.Flag{flag.CanaryWeightIncrement, flag.CanaryIncrementInterval, flag.CanaryFailureThreshold, flag.CanaryMatch,
+			flag.CanaryLatencySLO, flag.CanaryQuerySLO, flag.CanaryAnalysisWindow,
+			flag.CanaryMetricsProvider, flag.CanaryMetricsAddress, flag.CanaryMetricsSecret, flag.CanaryMetricsRegion, flag.CanaryMetricsProject, flag.NamespaceFunction},
 	})
 
 	getCmd := &cobra.Command{
@@ -54,7 +56,9 @@ func Commands() *cobra.Command {
 	}
 	wrapper.SetFlags(updateCmd, flag.FlagSet{
 		Required: []flag.Flag{flag.CanaryName},
-		Optional: []flag.Flag{flag.CanaryWeightIncrement, flag.CanaryIncrementInterval, flag.CanaryFailureThreshold, flag.NamespaceCanary},
+		Optional: []flag.Flag{flag.CanaryWeightIncrement, flag.CanaryIncrementInterval, flag.CanaryFailureThreshold, flag.CanaryMatch,
+			flag.CanaryLatencySLO, flag.CanaryQuerySLO, flag.CanaryAnalysisWindow,
+			flag.CanaryMetricsProvider, flag.CanaryMetricsAddress, flag.CanaryMetricsSecret, flag.CanaryMetricsRegion, flag.CanaryMetricsProject, flag.NamespaceCanary},
 	})
 
 	deleteCmd := &cobra.Command{
@@ -18,6 +18,8 @@ package canaryconfig
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -90,6 +92,24 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 		return fmt.Errorf("HTTP Trigger doesn't reference the function %s in Canary Config", oldFunc)
 	}
 
+	var match *fv1.CanaryMatch
+	if input.IsSet(flagkey.CanaryMatch) {
+		match, err = parseMatch(input.String(flagkey.CanaryMatch))
+		if err != nil {
+			return err
+		}
+	}
+
+	slos, err := parseSLOs(input.StringSlice(flagkey.CanaryLatencySLO), input.StringSlice(flagkey.CanaryQuerySLO))
+	if err != nil {
+		return err
+	}
+
+	metricsProvider, err := getMetricsProvider(input, nil)
+	if err != nil {
+		return err
+	}
+
 	// check that the functions exist in the same namespace
 	fnList := []string{newFunc, oldFunc}
 	err = util.CheckFunctionExistence(opts.Client(), fnList, fnNs)
@@ -111,6 +131,10 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 			WeightIncrementDuration: incrementInterval,
 			FailureThreshold:        failureThreshold,
 			FailureType:             fv1.FailureTypeStatusCode,
+			Match:                   match,
+			SLOs:                    slos,
+			AnalysisWindows:         parseAnalysisWindows(input.StringSlice(flagkey.CanaryAnalysisWindow)),
+			MetricsProvider:         metricsProvider,
 		},
 		Status: fv1.CanaryConfigStatus{
 			Status: fv1.CanaryConfigStatusPending,
@@ -129,3 +153,134 @@ func (opts *CreateSubCommand) run(input cli.Input) error {
 	fmt.Printf("canary config '%v' created\n", opts.canary.ObjectMeta.Name)
 	return nil
 }
+
+// parseMatch parses a match rule in the format of "<type>:<name>[=<value>]".
+// A nil rule is returned for "-".
+func parseMatch(rule string) (*fv1.CanaryMatch, error) {
+	if rule == "-" {
+		return nil, nil
+	}
+
+	v := strings.SplitN(rule, ":", 2)
+	if len(v) != 2 {
+		return nil, fmt.Errorf("illegal match rule: %v", rule)
+	}
+
+	match := &fv1.CanaryMatch{
+		Type: fv1.CanaryMatchType(strings.ToLower(v[0])),
+	}
+	nv := strings.SplitN(v[1], "=", 2)
+	match.Name = nv[0]
+	if len(nv) == 2 {
+		match.Value = nv[1]
+	}
+
+	err := match.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "error validating match rule")
+	}
+	return match, nil
+}
+
+// parseSLOs parses latency SLOs in the format of "p<percentile>=<duration>" and
+// query SLOs in the format of "<name>:<threshold>:<query>". A nil list is returned
+// if either of them is "-".
+func parseSLOs(latencySLOs []string, querySLOs []string) ([]fv1.CanarySLO, error) {
+	var slos []fv1.CanarySLO
+
+	for _, rule := range latencySLOs {
+		if rule == "-" {
+			return nil, nil
+		}
+		v := strings.SplitN(rule, "=", 2)
+		if len(v) != 2 || !strings.HasPrefix(strings.ToLower(v[0]), "p") {
+			return nil, fmt.Errorf("illegal latency SLO: %v, should be in the format of p<percentile>=<duration>", rule)
+		}
+		percentile, err := strconv.Atoi(v[0][1:])
+		if err != nil {
+			return nil, fmt.Errorf("illegal percentile in latency SLO: %v", rule)
+		}
+		slos = append(slos, fv1.CanarySLO{
+			Name:       strings.ToLower(v[0]),
+			Type:       fv1.CanarySLOTypeLatency,
+			Percentile: percentile,
+			Threshold:  v[1],
+		})
+	}
+
+	for _, rule := range querySLOs {
+		if rule == "-" {
+			return nil, nil
+		}
+		v := strings.SplitN(rule, ":", 3)
+		if len(v) != 3 {
+			return nil, fmt.Errorf("illegal query SLO: %v, should be in the format of <name>:<threshold>:<query>", rule)
+		}
+		slos = append(slos, fv1.CanarySLO{
+			Name:      v[0],
+			Type:      fv1.CanarySLOTypeQuery,
+			Query:     v[2],
+			Threshold: v[1],
+		})
+	}
+
+	for _, slo := range slos {
+		err := slo.Validate()
+		if err != nil {
+			return nil, errors.Wrap(err, "error validating SLO")
+		}
+	}
+
+	return slos, nil
+}
+
+// parseAnalysisWindows returns the given analysis windows, or nil for "-".
+func parseAnalysisWindows(windows []string) []string {
+	for _, w := range windows {
+		if w == "-" {
+			return nil
+		}
+	}
+	return windows
+}
+
+// getMetricsProvider returns the metrics provider set by flags on top of the existing one.
+// A nil provider is returned if the provider is "-".
+func getMetricsProvider(input cli.Input, provider *fv1.CanaryMetricsProvider) (*fv1.CanaryMetricsProvider, error) {
+	if input.String(flagkey.CanaryMetricsProvider) == "-" {
+		return nil, nil
+	}
+
+	if provider == nil {
+		if !input.IsSet(flagkey.CanaryMetricsProvider) {
+			if input.IsSet(flagkey.CanaryMetricsAddress) || input.IsSet(flagkey.CanaryMetricsSecret) ||
+				input.IsSet(flagkey.CanaryMetricsRegion) || input.IsSet(flagkey.CanaryMetricsProject) {
+				return nil, errors.Errorf("--%v is required to configure metrics provider", flagkey.CanaryMetricsProvider)
+			}
+			return nil, nil
+		}
+		provider = &fv1.CanaryMetricsProvider{}
+	}
+
+	if input.IsSet(flagkey.CanaryMetricsProvider) {
+		provider.Type = fv1.CanaryMetricsProviderType(strings.ToLower(input.String(flagkey.CanaryMetricsProvider)))
+	}
+	if input.IsSet(flagkey.CanaryMetricsAddress) {
+		provider.Address = input.String(flagkey.CanaryMetricsAddress)
+	}
+	if input.IsSet(flagkey.CanaryMetricsSecret) {
+		provider.Secret = input.String(flagkey.CanaryMetricsSecret)
+	}
+	if input.IsSet(flagkey.CanaryMetricsRegion) {
+		provider.Region = input.String(flagkey.CanaryMetricsRegion)
+	}
+	if input.IsSet(flagkey.CanaryMetricsProject) {
+		provider.Project = input.String(flagkey.CanaryMetricsProject)
+	}
+
+	err := provider.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "error validating metrics provider")
+	}
+	return provider, nil
+}
@@ -82,6 +82,32 @@ func (opts *UpdateSubCommand) complete(input cli.Input) error {
 		canaryCfg.Spec.WeightIncrementDuration = incrementInterval
 	}
 
+	if input.IsSet(flagkey.CanaryMatch) {
+		match, err := parseMatch(input.String(flagkey.CanaryMatch))
+		if err != nil {
+			return err
+		}
+		canaryCfg.Spec.Match = match
+	}
+
+	if input.IsSet(flagkey.CanaryLatencySLO) || input.IsSet(flagkey.CanaryQuerySLO) {
+		slos, err := parseSLOs(input.StringSlice(flagkey.CanaryLatencySLO), input.StringSlice(flagkey.CanaryQuerySLO))
+		if err != nil {
+			return err
+		}
+		canaryCfg.Spec.SLOs = slos
+	}
+
+	metricsProvider, err := getMetricsProvider(input, canaryCfg.Spec.MetricsProvider)
+	if err != nil {
+		return err
+	}
+	canaryCfg.Spec.MetricsProvider = metricsProvider
+
+	if input.IsSet(flagkey.CanaryAnalysisWindow) {
+		canaryCfg.Spec.AnalysisWindows = parseAnalysisWindows(input.StringSlice(flagkey.CanaryAnalysisWindow))
+	}
+
 	if updateNeeded {
 		canaryCfg.Status.Status = fv1.CanaryConfigStatusPending
 	}
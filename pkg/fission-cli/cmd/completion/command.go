@@ -0,0 +1,68 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package completion
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const long = `Print the completion script of a shell, to complete fission commands and flags with tab.
+
+  Bash:        source <(fission completion bash)
+  Zsh:         fission completion zsh > "${fpath[1]}/_fission"
+  Fish:        fission completion fish | source
+  PowerShell:  fission completion powershell | Out-String | Invoke-Expression
+`
+
+func Commands() *cobra.Command {
+	command := &cobra.Command{
+		Use:       "completion <bash|zsh|fish|powershell>",
+		Short:     "Print the shell completion script",
+		Long:      long,
+		ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+		Args:      cobra.ExactValidArgs(1),
+		// scripts are generated from the commands, there is no server to
+		// connect to like the root command does for the others.
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+			return nil
+		},
+		// the scripts need the root command, which cli.Input hides
+		RunE: func(c *cobra.Command, args []string) error {
+			return generate(c.Root(), args[0])
+		},
+	}
+
+	return command
+}
+
+// generate prints the completion script of the shell for the command.
+func generate(root *cobra.Command, shell string) error {
+	switch shell {
+	case "bash":
+		return root.GenBashCompletion(os.Stdout)
+	case "zsh":
+		return root.GenZshCompletion(os.Stdout)
+	case "fish":
+		return root.GenFishCompletion(os.Stdout, true)
+	case "powershell":
+		return root.GenPowerShellCompletion(os.Stdout)
+	}
+	return errors.Errorf("unsupported shell %v", shell)
+}
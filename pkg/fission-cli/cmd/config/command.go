@@ -0,0 +1,44 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"github.com/spf13/cobra"
+
+	wrapper "github.com/fission/fission/pkg/fission-cli/cliwrapper/driver/cobra"
+)
+
+// Commands returns config commands
+func Commands() *cobra.Command {
+	viewCmd := &cobra.Command{
+		Use:   "view",
+		Short: "View the config of the Fission components and the settings in effect in each",
+		Long: "Show the FissionConfig the components reconcile, and for each component the settings in effect, " +
+			"changes waiting for a restart of the component and errors applying changes. " +
+			"Change the config with kubectl edit fissionconfig fission in the namespace Fission is installed in.",
+		RunE: wrapper.Wrapper(View),
+	}
+
+	command := &cobra.Command{
+		Use:   "config",
+		Short: "Configure the Fission components",
+	}
+
+	command.AddCommand(viewCmd)
+
+	return command
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	"github.com/fission/fission/pkg/fission-cli/console"
+	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
+)
+
+type ViewSubCommand struct {
+	cmd.CommandActioner
+}
+
+func View(input cli.Input) error {
+	return (&ViewSubCommand{}).do(input)
+}
+
+func (opts *ViewSubCommand) do(input cli.Input) error {
+	err := util.RequireServerFeature(opts.Client(), info.FeatureFissionConfig)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := opts.Client().V1().Misc().FissionConfig()
+	if err != nil {
+		return errors.Wrap(err, "error getting config")
+	}
+
+	spec, err := yaml.Marshal(cfg.Spec)
+	if err != nil {
+		return errors.Wrap(err, "error encoding config")
+	}
+	fmt.Printf("Config (generation %v):\n%v\n", cfg.ObjectMeta.Generation, string(spec))
+
+	if len(cfg.Status.Components) == 0 {
+		console.Info("No component reported its effective config yet")
+		return nil
+	}
+
+	components := make([]string, 0, len(cfg.Status.Components))
+	for name := range cfg.Status.Components {
+		components = append(components, name)
+	}
+	sort.Strings(components)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", "COMPONENT", "SETTING", "EFFECTIVE", "NOTE")
+	for _, component := range components {
+		status := cfg.Status.Components[component]
+		restartRequired := make(map[string]bool)
+		for _, name := range status.RestartRequired {
+			restartRequired[name] = true
+		}
+
+		settings := make([]string, 0, len(status.Effective))
+		for name := range status.Effective {
+			settings = append(settings, name)
+		}
+		sort.Strings(settings)
+		for _, name := range settings {
+			note := ""
+			if restartRequired[name] {
+				note = "changed, takes effect on restart"
+			}
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", component, name, emptyAsUnset(status.Effective[name]), note)
+		}
+	}
+	w.Flush()
+
+	for _, component := range components {
+		status := cfg.Status.Components[component]
+		if status.ObservedGeneration < cfg.ObjectMeta.Generation {
+			console.Warn(fmt.Sprintf("%v hasn't applied generation %v of the config yet", component, cfg.ObjectMeta.Generation))
+		}
+		if len(status.Error) > 0 {
+			console.Errorf("%v failed to apply the config: %v", component, status.Error)
+		}
+	}
+
+	return nil
+}
+
+func emptyAsUnset(value string) string {
+	if len(value) == 0 {
+		return "<unset>"
+	}
+	return value
+}
@@ -0,0 +1,40 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"github.com/spf13/cobra"
+
+	wrapper "github.com/fission/fission/pkg/fission-cli/cliwrapper/driver/cobra"
+	"github.com/fission/fission/pkg/fission-cli/flag"
+)
+
+func Commands() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "dashboard",
+		Short: "Show functions, their state, invocations and builds in the terminal, updated live",
+		Long: "Show the functions of a namespace with the state executor reports, the call and error rates of " +
+			"their triggers, and the latest package builds, refreshed every --interval until q is typed. " +
+			"Without terminal, print them once.",
+		RunE: wrapper.Wrapper(Dashboard),
+	}
+	wrapper.SetFlags(command, flag.FlagSet{
+		Optional: []flag.Flag{flag.DashboardNamespace, flag.DashboardWindow, flag.DashboardInterval},
+	})
+
+	return command
+}
@@ -0,0 +1,397 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+	apiv1 "k8s.io/api/core/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+	"github.com/fission/fission/pkg/info"
+)
+
+const (
+	// maxBuilds is how many of the latest builds are shown.
+	maxBuilds = 10
+
+	// maxErrorLength is how much of the last error of a function is shown.
+	maxErrorLength = 60
+
+	// clearScreen moves the cursor home and clears the terminal.
+	clearScreen = "\033[H\033[2J"
+)
+
+type (
+	DashboardSubCommand struct {
+		cmd.CommandActioner
+	}
+
+	// snapshot is what the dashboard shows at a time.
+	snapshot struct {
+		Namespace string
+		Window    string
+		Time      time.Time
+		Functions []functionRow
+		Builds    []buildRow
+
+		// Errors are the data that couldn't be got, the rest is shown.
+		Errors []string
+	}
+
+	// functionRow is the state of a function and the calls of its triggers.
+	functionRow struct {
+		Name         string
+		Env          string
+		ExecutorType fv1.ExecutorType
+		State        string
+		LastError    string
+
+		// Rate is the calls per second, ErrorRate the percentage of
+		// failed ones, nil without metrics.
+		Rate        *float64
+		ErrorRate   *float64
+		LastSuccess *time.Time
+	}
+
+	// buildRow is the build status of a package.
+	buildRow struct {
+		Package string
+		Env     string
+		Status  fv1.BuildStatus
+		Updated time.Time
+	}
+)
+
+func Dashboard(input cli.Input) error {
+	return (&DashboardSubCommand{}).do(input)
+}
+
+func (opts *DashboardSubCommand) do(input cli.Input) error {
+	ns := input.String(flagkey.DashboardNamespace)
+	window := input.String(flagkey.DashboardWindow)
+	interval := input.Duration(flagkey.DashboardInterval)
+	if interval < time.Second {
+		return errors.Errorf("refresh interval must be at least 1s, got %v", interval)
+	}
+
+	// without terminal, e.g. piped, print the dashboard once
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return render(os.Stdout, opts.snapshot(ns, window), 0)
+	}
+
+	quit := make(chan struct{})
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		<-sigCh
+		close(quit)
+	}()
+
+	// quit on q too, keys are read one at a time in raw mode
+	stdinFd := int(os.Stdin.Fd())
+	if term.IsTerminal(stdinFd) {
+		state, err := term.MakeRaw(stdinFd)
+		if err == nil {
+			defer func() {
+				_ = term.Restore(stdinFd, state)
+			}()
+			go readKeys(sigCh)
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		width, _, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			width = 0
+		}
+		var buf bytes.Buffer
+		err = render(&buf, opts.snapshot(ns, window), width)
+		if err != nil {
+			return err
+		}
+		// raw mode doesn't translate line feeds
+		os.Stdout.WriteString(clearScreen + strings.ReplaceAll(buf.String(), "\n", "\r\n"))
+
+		select {
+		case <-quit:
+			os.Stdout.WriteString("\r\n")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// readKeys signals to quit when q or Ctrl-C, which raw mode doesn't turn
+// into a signal, is typed.
+func readKeys(sigCh chan<- os.Signal) {
+	key := make([]byte, 1)
+	for {
+		n, err := os.Stdin.Read(key)
+		if err != nil {
+			return
+		}
+		if n == 1 && (key[0] == 'q' || key[0] == 'Q' || key[0] == 3) {
+			sigCh <- os.Interrupt
+			return
+		}
+	}
+}
+
+// snapshot gets the functions, packages and trigger metrics of the
+// namespace. What can't be got is reported in the errors of the snapshot.
+func (opts *DashboardSubCommand) snapshot(ns string, window string) *snapshot {
+	s := &snapshot{
+		Namespace: ns,
+		Window:    window,
+		Time:      time.Now(),
+	}
+
+	fns, err := opts.Client().V1().Function().List(ns)
+	if err != nil {
+		s.Errors = append(s.Errors, fmt.Sprintf("error listing functions: %v", err))
+	}
+
+	var sources []info.EventSourceHealth
+	status, err := opts.Client().V1().Misc().Status(ns, window)
+	switch {
+	case err != nil:
+		s.Errors = append(s.Errors, fmt.Sprintf("error getting invocation metrics: %v", err))
+	case len(status.MetricsError) > 0:
+		s.Errors = append(s.Errors, fmt.Sprintf("invocation metrics are unavailable: %v", status.MetricsError))
+	default:
+		sources = status.EventSources
+		s.Window = status.Window
+	}
+	s.Functions = functionRows(fns, sources)
+
+	pkgs, err := opts.Client().V1().Package().List(ns)
+	if err != nil {
+		s.Errors = append(s.Errors, fmt.Sprintf("error listing packages: %v", err))
+	}
+	s.Builds = buildRows(pkgs)
+
+	return s
+}
+
+// functionRows returns the rows of the functions, with the metrics of the
+// triggers invoking them summed up.
+func functionRows(fns []fv1.Function, sources []info.EventSourceHealth) []functionRow {
+	rows := make([]functionRow, 0, len(fns))
+	for i := range fns {
+		fn := &fns[i]
+		row := functionRow{
+			Name:         fn.ObjectMeta.Name,
+			Env:          fn.Spec.Environment.Name,
+			ExecutorType: fn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType,
+			State:        functionState(&fn.Status),
+		}
+		if c := fn.Status.GetCondition(fv1.FunctionLastError); c != nil && c.Status == apiv1.ConditionTrue {
+			row.LastError = c.Message
+		}
+		addMetrics(&row, sources)
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Name < rows[j].Name
+	})
+	return rows
+}
+
+// functionState returns the state of a function from its conditions, the
+// most severe first.
+func functionState(status *fv1.FunctionStatus) string {
+	if c := status.GetCondition(fv1.FunctionQuarantined); c != nil && c.Status == apiv1.ConditionTrue {
+		return "Quarantined"
+	}
+	// the reason tells a pending build from a failed one
+	if c := status.GetCondition(fv1.FunctionPackageBuilt); c != nil && c.Status == apiv1.ConditionFalse {
+		if len(c.Reason) > 0 {
+			return c.Reason
+		}
+		return "PackageNotBuilt"
+	}
+	if c := status.GetCondition(fv1.FunctionPodsReady); c != nil {
+		if c.Status == apiv1.ConditionTrue {
+			return "Ready"
+		}
+		return "NotReady"
+	}
+	// executor hasn't served the function yet
+	return "Idle"
+}
+
+// addMetrics adds the metrics of the triggers invoking the function to its
+// row: the rates summed up, the error rate weighted by them.
+func addMetrics(row *functionRow, sources []info.EventSourceHealth) {
+	var rate, failed float64
+	var found bool
+	for _, s := range sources {
+		if !invokes(s, row.Name) {
+			continue
+		}
+		if s.LastSuccess != nil && (row.LastSuccess == nil || s.LastSuccess.After(*row.LastSuccess)) {
+			lastSuccess := *s.LastSuccess
+			row.LastSuccess = &lastSuccess
+		}
+		if s.FiringRate == nil {
+			continue
+		}
+		found = true
+		rate += *s.FiringRate
+		if s.ErrorRate != nil {
+			failed += *s.FiringRate * *s.ErrorRate / 100
+		}
+	}
+	if !found {
+		return
+	}
+	row.Rate = &rate
+	if rate > 0 {
+		errorRate := failed / rate * 100
+		row.ErrorRate = &errorRate
+	}
+}
+
+// invokes returns whether the trigger invokes the function, alone or among
+// the functions it splits traffic over.
+func invokes(s info.EventSourceHealth, fnName string) bool {
+	for _, f := range strings.Split(s.Function, ",") {
+		if strings.TrimSpace(f) == fnName {
+			return true
+		}
+	}
+	return false
+}
+
+// buildRows returns the rows of the latest builds of the packages, the
+// packages without build left out.
+func buildRows(pkgs []fv1.Package) []buildRow {
+	var rows []buildRow
+	for _, pkg := range pkgs {
+		if pkg.Status.BuildStatus == fv1.BuildStatusNone || len(pkg.Status.BuildStatus) == 0 {
+			continue
+		}
+		rows = append(rows, buildRow{
+			Package: pkg.ObjectMeta.Name,
+			Env:     pkg.Spec.Environment.Name,
+			Status:  pkg.Status.BuildStatus,
+			Updated: pkg.Status.LastUpdateTimestamp.Time,
+		})
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Updated.After(rows[j].Updated)
+	})
+	if len(rows) > maxBuilds {
+		rows = rows[:maxBuilds]
+	}
+	return rows
+}
+
+// render writes the dashboard, its lines cut to width if it's not 0.
+func render(out io.Writer, s *snapshot, width int) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Fission dashboard - namespace %v - %v (q to quit)\n\n", s.Namespace, s.Time.Format(time.RFC1123))
+
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", "FUNCTION", "ENV", "EXECUTOR", "STATE",
+		fmt.Sprintf("RATE(%v)", s.Window), "ERRORS", "LAST_SUCCESS", "LAST_ERROR")
+	for _, f := range s.Functions {
+		rate, errRate, lastSuccess, lastError := "-", "-", "-", "-"
+		if f.Rate != nil {
+			rate = fmt.Sprintf("%.2f/s", *f.Rate)
+		}
+		if f.ErrorRate != nil {
+			errRate = fmt.Sprintf("%.1f%%", *f.ErrorRate)
+		}
+		if f.LastSuccess != nil {
+			lastSuccess = fmt.Sprintf("%v ago", s.Time.Sub(*f.LastSuccess).Round(time.Second))
+		}
+		if len(f.LastError) > 0 {
+			lastError = truncate(strings.Join(strings.Fields(f.LastError), " "), maxErrorLength)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			f.Name, f.Env, f.ExecutorType, f.State, rate, errRate, lastSuccess, lastError)
+	}
+	w.Flush()
+	if len(s.Functions) == 0 {
+		fmt.Fprintln(&buf, "No functions")
+	}
+
+	fmt.Fprintln(&buf)
+	w = tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", "PACKAGE", "ENV", "BUILD", "UPDATED")
+	for _, b := range s.Builds {
+		updated := "-"
+		if !b.Updated.IsZero() {
+			updated = fmt.Sprintf("%v ago", s.Time.Sub(b.Updated).Round(time.Second))
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", b.Package, b.Env, b.Status, updated)
+	}
+	w.Flush()
+	if len(s.Builds) == 0 {
+		fmt.Fprintln(&buf, "No builds")
+	}
+
+	for _, e := range s.Errors {
+		fmt.Fprintf(&buf, "\n%v", e)
+	}
+	if len(s.Errors) > 0 {
+		fmt.Fprintln(&buf)
+	}
+
+	if width <= 0 {
+		_, err := out.Write(buf.Bytes())
+		return err
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		_, err := fmt.Fprintln(out, truncate(line, width))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// truncate cuts s to at most n characters.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n <= 3 {
+		return string(r[:n])
+	}
+	return string(r[:n-3]) + "..."
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/info"
+)
+
+func makeFunction(name string, conditions ...fv1.FunctionCondition) fv1.Function {
+	return fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceDefault},
+		Spec: fv1.FunctionSpec{
+			Environment: fv1.EnvironmentReference{Name: "nodejs"},
+			InvokeStrategy: fv1.InvokeStrategy{
+				ExecutionStrategy: fv1.ExecutionStrategy{ExecutorType: fv1.ExecutorTypePoolmgr},
+			},
+		},
+		Status: fv1.FunctionStatus{Conditions: conditions},
+	}
+}
+
+func float(f float64) *float64 {
+	return &f
+}
+
+func TestFunctionState(t *testing.T) {
+	for _, c := range []struct {
+		name       string
+		conditions []fv1.FunctionCondition
+		state      string
+	}{
+		{name: "no conditions", state: "Idle"},
+		{
+			name:       "ready",
+			conditions: []fv1.FunctionCondition{{Type: fv1.FunctionPodsReady, Status: apiv1.ConditionTrue}},
+			state:      "Ready",
+		},
+		{
+			name: "build running",
+			conditions: []fv1.FunctionCondition{
+				{Type: fv1.FunctionPodsReady, Status: apiv1.ConditionTrue},
+				{Type: fv1.FunctionPackageBuilt, Status: apiv1.ConditionFalse, Reason: "BuildRunning"},
+			},
+			state: "BuildRunning",
+		},
+		{
+			name: "quarantined",
+			conditions: []fv1.FunctionCondition{
+				{Type: fv1.FunctionPodsReady, Status: apiv1.ConditionFalse},
+				{Type: fv1.FunctionQuarantined, Status: apiv1.ConditionTrue},
+			},
+			state: "Quarantined",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			status := fv1.FunctionStatus{Conditions: c.conditions}
+			assert.Equal(t, c.state, functionState(&status))
+		})
+	}
+}
+
+func TestFunctionRows(t *testing.T) {
+	lastSuccess := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	fns := []fv1.Function{
+		makeFunction("world", fv1.FunctionCondition{Type: fv1.FunctionLastError, Status: apiv1.ConditionTrue, Message: "no pods"}),
+		makeFunction("hello"),
+	}
+	sources := []info.EventSourceHealth{
+		{Type: "http", Name: "a", Function: "hello", FiringRate: float(3), ErrorRate: float(0)},
+		{Type: "http", Name: "b", Function: "hello,world", FiringRate: float(1), ErrorRate: float(100), LastSuccess: &lastSuccess},
+		{Type: "time", Name: "c", Function: "other", FiringRate: float(5)},
+	}
+
+	rows := functionRows(fns, sources)
+	assert.Len(t, rows, 2)
+
+	assert.Equal(t, "hello", rows[0].Name)
+	assert.InDelta(t, 4, *rows[0].Rate, 0.001)
+	assert.InDelta(t, 25, *rows[0].ErrorRate, 0.001)
+	assert.Equal(t, lastSuccess, *rows[0].LastSuccess)
+
+	assert.Equal(t, "world", rows[1].Name)
+	assert.InDelta(t, 1, *rows[1].Rate, 0.001)
+	assert.InDelta(t, 100, *rows[1].ErrorRate, 0.001)
+	assert.Equal(t, "no pods", rows[1].LastError)
+
+	rows = functionRows(fns, nil)
+	assert.Nil(t, rows[0].Rate)
+	assert.Nil(t, rows[0].ErrorRate)
+}
+
+func TestBuildRows(t *testing.T) {
+	now := time.Now()
+	var pkgs []fv1.Package
+	for i := 0; i < maxBuilds+2; i++ {
+		pkgs = append(pkgs, fv1.Package{
+			ObjectMeta: metav1.ObjectMeta{Name: string(rune('a' + i))},
+			Status: fv1.PackageStatus{
+				BuildStatus:         fv1.BuildStatusSucceeded,
+				LastUpdateTimestamp: metav1.NewTime(now.Add(time.Duration(i) * time.Minute)),
+			},
+		})
+	}
+	pkgs = append(pkgs, fv1.Package{
+		ObjectMeta: metav1.ObjectMeta{Name: "nobuild"},
+		Status: fv1.PackageStatus{
+			BuildStatus:         fv1.BuildStatusNone,
+			LastUpdateTimestamp: metav1.NewTime(now.Add(time.Hour)),
+		},
+	})
+
+	rows := buildRows(pkgs)
+	assert.Len(t, rows, maxBuilds)
+	// the latest first
+	assert.Equal(t, string(rune('a'+maxBuilds+1)), rows[0].Package)
+}
+
+func TestRender(t *testing.T) {
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	s := &snapshot{
+		Namespace: "default",
+		Window:    "5m",
+		Time:      now,
+		Functions: []functionRow{{
+			Name:         "hello",
+			Env:          "nodejs",
+			ExecutorType: fv1.ExecutorTypePoolmgr,
+			State:        "Ready",
+			Rate:         float(1.5),
+			ErrorRate:    float(10),
+			LastError:    "error\nspanning lines",
+		}},
+		Builds: []buildRow{{Package: "hello-pkg", Env: "nodejs", Status: fv1.BuildStatusFailed, Updated: now.Add(-time.Minute)}},
+		Errors: []string{"invocation metrics are unavailable: no prometheus"},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, render(&buf, s, 0))
+	out := buf.String()
+	assert.Contains(t, out, "RATE(5m)")
+	assert.Contains(t, out, "1.50/s")
+	assert.Contains(t, out, "10.0%")
+	assert.Contains(t, out, "error spanning lines")
+	assert.Contains(t, out, "1m0s ago")
+	assert.Contains(t, out, "invocation metrics are unavailable")
+
+	buf.Reset()
+	assert.NoError(t, render(&buf, s, 20))
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		assert.LessOrEqual(t, len([]rune(line)), 20)
+	}
+}
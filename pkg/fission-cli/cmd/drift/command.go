@@ -0,0 +1,48 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drift
+
+import (
+	"github.com/spf13/cobra"
+
+	wrapper "github.com/fission/fission/pkg/fission-cli/cliwrapper/driver/cobra"
+	"github.com/fission/fission/pkg/fission-cli/flag"
+)
+
+// Commands returns drift commands
+func Commands() *cobra.Command {
+	detectCmd := &cobra.Command{
+		Use:   "detect",
+		Short: "Report kubernetes objects of functions and triggers that differ from their specs",
+		Long: "Compare the deployments, services and HPAs of functions and the ingresses of HTTP triggers " +
+			"with what their current specs imply, reporting changes made behind Fission's back, e.g. with kubectl, " +
+			"missing objects and objects whose function or trigger no longer exists.",
+		RunE: wrapper.Wrapper(Detect),
+	}
+	wrapper.SetFlags(detectCmd, flag.FlagSet{
+		Optional: []flag.Flag{flag.DriftRevert},
+	})
+
+	command := &cobra.Command{
+		Use:   "drift",
+		Short: "Detect drift of generated kubernetes objects",
+	}
+
+	command.AddCommand(detectCmd)
+
+	return command
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drift
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	"github.com/fission/fission/pkg/fission-cli/console"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
+)
+
+type DetectSubCommand struct {
+	cmd.CommandActioner
+}
+
+func Detect(input cli.Input) error {
+	return (&DetectSubCommand{}).do(input)
+}
+
+func (opts *DetectSubCommand) do(input cli.Input) error {
+	revert := input.Bool(flagkey.DriftRevert)
+
+	err := util.RequireServerFeature(opts.Client(), info.FeatureDrift)
+	if err != nil {
+		return err
+	}
+
+	drifts, err := opts.Client().V1().Misc().Drift(revert)
+	if err != nil {
+		return errors.Wrap(err, "error detecting drift")
+	}
+
+	if len(drifts) == 0 {
+		fmt.Println("No drift detected")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", "KIND", "NAMESPACE", "NAME", "OWNER", "REASON", "REVERTED")
+	for _, d := range drifts {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", d.Kind, d.Namespace, d.Name, d.Owner, d.Reason, d.Reverted)
+		for _, field := range d.Fields {
+			fmt.Fprintf(w, "\t\t  %v\t%v\t%v\t\n", field.Path,
+				fmt.Sprintf("expected: %v", emptyAsUnset(field.Expected)), fmt.Sprintf("actual: %v", emptyAsUnset(field.Actual)))
+		}
+	}
+	w.Flush()
+
+	failed := 0
+	for _, d := range drifts {
+		if len(d.Error) > 0 {
+			console.Errorf("Failed to revert %v %v/%v: %v", d.Kind, d.Namespace, d.Name, d.Error)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("failed to revert %v object(s)", failed)
+	}
+	if !revert {
+		console.Info("Run with --revert to bring the objects back in line with their specs")
+	}
+
+	return nil
+}
+
+func emptyAsUnset(value string) string {
+	if len(value) == 0 {
+		return "<unset>"
+	}
+	return value
+}
@@ -31,10 +31,14 @@ func Commands() *cobra.Command {
 	}
 	wrapper.SetFlags(createCmd, flag.FlagSet{
 		Required: []flag.Flag{flag.EnvName, flag.EnvImage},
-		Optional: []flag.Flag{flag.EnvPoolsize, flag.EnvBuilderImage, flag.EnvBuildCmd,
+		Optional: []flag.Flag{flag.EnvPoolsize, flag.EnvBuilderImage, flag.EnvBuildCmd, flag.EnvBuildConcurrency, flag.EnvBuildOutput,
 			flag.RunTimeMinCPU, flag.RunTimeMaxCPU, flag.RunTimeMinMemory, flag.RunTimeMaxMemory,
 			flag.EnvTerminationGracePeriod, flag.EnvVersion, flag.EnvImagePullSecret,
-			flag.EnvExternalNetwork, flag.EnvKeepArchive, flag.NamespaceEnvironment, flag.SpecSave, flag.SpecDry},
+			flag.EnvExternalNetwork, flag.EnvKeepArchive, flag.EnvRollout, flag.EnvRolloutMaxSurge, flag.EnvRolloutMaxUnavailable,
+			flag.EnvRolloutCanary, flag.EnvRolloutStepInterval, flag.HtJWKSURL, flag.HtJWTIssuer, flag.HtJWTAudience,
+			flag.HtJWTClaim, flag.HtJWTClaimHeader, flag.HtOpenAPISpec, flag.HtOpenAPIPath, flag.HtTimeout, flag.HtRetries,
+			flag.HtRetryBackoff, flag.HtRetryOn, flag.HtBreakerThreshold, flag.HtBreakerDuration,
+			flag.NamespaceEnvironment, flag.SpecSave, flag.SpecDry},
 	})
 
 	getCmd := &cobra.Command{
@@ -55,9 +59,13 @@ func Commands() *cobra.Command {
 	wrapper.SetFlags(updateCmd, flag.FlagSet{
 		Required: []flag.Flag{flag.EnvName},
 		Optional: []flag.Flag{flag.EnvImage, flag.EnvPoolsize,
-			flag.EnvBuilderImage, flag.EnvBuildCmd, flag.EnvImagePullSecret,
+			flag.EnvBuilderImage, flag.EnvBuildCmd, flag.EnvBuildConcurrency, flag.EnvBuildOutput, flag.EnvImagePullSecret,
 			flag.RunTimeMinCPU, flag.RunTimeMaxCPU, flag.RunTimeMinMemory, flag.RunTimeMaxMemory,
-			flag.EnvTerminationGracePeriod, flag.EnvKeepArchive, flag.NamespaceEnvironment, flag.EnvExternalNetwork},
+			flag.EnvTerminationGracePeriod, flag.EnvKeepArchive, flag.NamespaceEnvironment, flag.EnvExternalNetwork,
+			flag.EnvRollout, flag.EnvRolloutMaxSurge, flag.EnvRolloutMaxUnavailable, flag.EnvRolloutCanary, flag.EnvRolloutStepInterval,
+			flag.HtJWKSURL, flag.HtJWTIssuer, flag.HtJWTAudience, flag.HtJWTClaim, flag.HtJWTClaimHeader, flag.HtOpenAPISpec,
+			flag.HtOpenAPIPath, flag.HtTimeout, flag.HtRetries, flag.HtRetryBackoff, flag.HtRetryOn, flag.HtBreakerThreshold,
+			flag.HtBreakerDuration},
 	})
 
 	deleteCmd := &cobra.Command{
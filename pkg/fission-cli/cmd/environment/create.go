@@ -26,10 +26,12 @@ import (
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
 	"github.com/fission/fission/pkg/fission-cli/cmd"
+	"github.com/fission/fission/pkg/fission-cli/cmd/httptrigger"
 	"github.com/fission/fission/pkg/fission-cli/cmd/spec"
 	"github.com/fission/fission/pkg/fission-cli/console"
 	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
 	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
 	"github.com/fission/fission/pkg/utils"
 )
 
@@ -89,6 +91,8 @@ func (opts *CreateSubCommand) run(input cli.Input) error {
 		return nil
 	}
 
+	util.WarnUnsupportedFeatures(opts.Client(), "environment", m.Name, info.EnvironmentFeatures(&opts.env.Spec))
+
 	_, err = opts.Client().V1().Environment().Create(opts.env)
 	if err != nil {
 		return errors.Wrap(err, "error creating environment")
@@ -106,6 +110,8 @@ func createEnvironmentFromCmd(input cli.Input) (*fv1.Environment, error) {
 	envImg := input.String(flagkey.EnvImage)
 	envNamespace := input.String(flagkey.NamespaceEnvironment)
 	envBuildCmd := input.String(flagkey.EnvBuildcommand)
+	envBuildConcurrency := input.Int(flagkey.EnvBuildConcurrency)
+	envBuildOutput := input.String(flagkey.EnvBuildOutput)
 	envExternalNetwork := input.Bool(flagkey.EnvExternalNetwork)
 	keepArchive := input.Bool(flagkey.EnvKeeparchive)
 	envGracePeriod := input.Int64(flagkey.EnvGracePeriod)
@@ -159,8 +165,10 @@ func createEnvironmentFromCmd(input cli.Input) (*fv1.Environment, error) {
 				Image: envImg,
 			},
 			Builder: fv1.Builder{
-				Image:   envBuilderImg,
-				Command: envBuildCmd,
+				Image:               envBuilderImg,
+				Command:             envBuildCmd,
+				MaxConcurrentBuilds: envBuildConcurrency,
+				Output:              fv1.BuildOutput(envBuildOutput),
 			},
 			Poolsize:                     poolsize,
 			Resources:                    *resourceReq,
@@ -168,9 +176,15 @@ func createEnvironmentFromCmd(input cli.Input) (*fv1.Environment, error) {
 			TerminationGracePeriod:       envGracePeriod,
 			KeepArchive:                  keepArchive,
 			ImagePullSecret:              pullSecret,
+			RolloutStrategy:              rolloutStrategyFromCmd(nil, input),
 		},
 	}
 
+	env.Spec.HTTPTriggerDefaults, err = triggerDefaultsFromCmd(nil, input)
+	if err != nil {
+		return nil, err
+	}
+
 	err = env.Validate()
 	if err != nil {
 		return nil, fv1.AggregateValidationErrors("Environment", err)
@@ -178,3 +192,89 @@ func createEnvironmentFromCmd(input cli.Input) (*fv1.Environment, error) {
 
 	return env, nil
 }
+
+// rolloutStrategyFromCmd returns the rollout strategy updated with the
+// rollout flags set, or nil if there is no strategy.
+func rolloutStrategyFromCmd(strategy *fv1.RolloutStrategy, input cli.Input) *fv1.RolloutStrategy {
+	if !input.IsSet(flagkey.EnvRollout) && !input.IsSet(flagkey.EnvRolloutMaxSurge) &&
+		!input.IsSet(flagkey.EnvRolloutMaxUnavailable) && !input.IsSet(flagkey.EnvRolloutCanary) &&
+		!input.IsSet(flagkey.EnvRolloutStepInterval) {
+		return strategy
+	}
+	if strategy == nil {
+		strategy = &fv1.RolloutStrategy{}
+	}
+	if input.IsSet(flagkey.EnvRollout) {
+		strategy.Type = fv1.RolloutStrategyType(input.String(flagkey.EnvRollout))
+	}
+	if input.IsSet(flagkey.EnvRolloutMaxSurge) {
+		strategy.MaxSurge = int32(input.Int(flagkey.EnvRolloutMaxSurge))
+	}
+	if input.IsSet(flagkey.EnvRolloutMaxUnavailable) {
+		strategy.MaxUnavailable = int32(input.Int(flagkey.EnvRolloutMaxUnavailable))
+	}
+	if input.IsSet(flagkey.EnvRolloutCanary) {
+		strategy.Canary = input.Bool(flagkey.EnvRolloutCanary)
+	}
+	if input.IsSet(flagkey.EnvRolloutStepInterval) {
+		strategy.StepInterval = input.Int(flagkey.EnvRolloutStepInterval)
+	}
+	return strategy
+}
+
+// triggerDefaultsFromCmd returns the HTTP trigger defaults updated with the
+// trigger middleware flags set, or nil if there are no defaults.
+func triggerDefaultsFromCmd(defaults *fv1.HTTPTriggerDefaults, input cli.Input) (*fv1.HTTPTriggerDefaults, error) {
+	if defaults == nil {
+		defaults = &fv1.HTTPTriggerDefaults{}
+	}
+
+	if input.IsSet(flagkey.HtJWKSURL) || input.IsSet(flagkey.HtJWTIssuer) || input.IsSet(flagkey.HtJWTAudience) ||
+		input.IsSet(flagkey.HtJWTClaim) || input.IsSet(flagkey.HtJWTClaimHeader) {
+		auth, err := httptrigger.GetAuthentication(
+			input.String(flagkey.HtJWKSURL), input.String(flagkey.HtJWTIssuer), input.StringSlice(flagkey.HtJWTAudience),
+			input.StringSlice(flagkey.HtJWTClaim), input.StringSlice(flagkey.HtJWTClaimHeader), defaults.Authentication)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing default authentication configuration")
+		}
+		defaults.Authentication = auth
+	}
+
+	if input.IsSet(flagkey.HtOpenAPISpec) || input.IsSet(flagkey.HtOpenAPIPath) {
+		requestValidation, err := httptrigger.GetRequestValidation(input.String(flagkey.HtOpenAPISpec),
+			input.String(flagkey.HtOpenAPIPath), "", defaults.RequestValidation)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing default request validation configuration")
+		}
+		defaults.RequestValidation = requestValidation
+	}
+
+	if input.IsSet(flagkey.HtTimeout) {
+		defaults.Timeout = input.Int(flagkey.HtTimeout)
+	}
+
+	if input.IsSet(flagkey.HtRetries) || input.IsSet(flagkey.HtRetryBackoff) || input.IsSet(flagkey.HtRetryOn) {
+		retries := -1
+		if input.IsSet(flagkey.HtRetries) {
+			retries = input.Int(flagkey.HtRetries)
+		}
+		retry, err := httptrigger.GetRetryPolicy(retries, input.Int(flagkey.HtRetryBackoff), input.IntSlice(flagkey.HtRetryOn), defaults.Retry)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing default retry policy")
+		}
+		defaults.Retry = retry
+	}
+
+	if input.IsSet(flagkey.HtBreakerThreshold) || input.IsSet(flagkey.HtBreakerDuration) {
+		breaker, err := httptrigger.GetCircuitBreakerPolicy(input.Int(flagkey.HtBreakerThreshold), input.Int(flagkey.HtBreakerDuration), defaults.CircuitBreaker)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing default circuit breaker policy")
+		}
+		defaults.CircuitBreaker = breaker
+	}
+
+	if *defaults == (fv1.HTTPTriggerDefaults{}) {
+		return nil, nil
+	}
+	return defaults, nil
+}
@@ -30,6 +30,8 @@ import (
 	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
 	"github.com/fission/fission/pkg/fission-cli/cmd"
 	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
 	"github.com/fission/fission/pkg/utils"
 )
 
@@ -69,6 +71,8 @@ func (opts *UpdateSubCommand) complete(input cli.Input) error {
 }
 
 func (opts *UpdateSubCommand) run(input cli.Input) error {
+	util.WarnUnsupportedFeatures(opts.Client(), "environment", opts.env.ObjectMeta.Name, info.EnvironmentFeatures(&opts.env.Spec))
+
 	_, err := opts.Client().V1().Environment().Update(opts.env)
 	if err != nil {
 		return errors.Wrap(err, "error updating environment")
@@ -94,6 +98,14 @@ func updateExistingEnvironmentWithCmd(env *fv1.Environment, input cli.Input) (*f
 		env.Spec.Builder.Command = input.String(flagkey.EnvBuildcommand)
 	}
 
+	if input.IsSet(flagkey.EnvBuildConcurrency) {
+		env.Spec.Builder.MaxConcurrentBuilds = input.Int(flagkey.EnvBuildConcurrency)
+	}
+
+	if input.IsSet(flagkey.EnvBuildOutput) {
+		env.Spec.Builder.Output = fv1.BuildOutput(input.String(flagkey.EnvBuildOutput))
+	}
+
 	if env.Spec.Version == 1 && (len(env.Spec.Builder.Image) > 0 || len(env.Spec.Builder.Command) > 0) {
 		e = multierror.Append(e, errors.New("version 1 Environments do not support builders. Must specify --version=2"))
 	}
@@ -117,6 +129,15 @@ func updateExistingEnvironmentWithCmd(env *fv1.Environment, input cli.Input) (*f
 		env.Spec.ImagePullSecret = input.String(flagkey.EnvImagePullSecret)
 	}
 
+	env.Spec.RolloutStrategy = rolloutStrategyFromCmd(env.Spec.RolloutStrategy, input)
+
+	defaults, err := triggerDefaultsFromCmd(env.Spec.HTTPTriggerDefaults, input)
+	if err != nil {
+		e = multierror.Append(e, err)
+	} else {
+		env.Spec.HTTPTriggerDefaults = defaults
+	}
+
 	if input.IsSet(flagkey.RuntimeMincpu) {
 		mincpu := input.Int(flagkey.RuntimeMincpu)
 		cpuRequest, err := resource.ParseQuantity(strconv.Itoa(mincpu) + "m")
@@ -0,0 +1,122 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	"github.com/fission/fission/pkg/fission-cli/console"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/fnanalysis"
+	"github.com/fission/fission/pkg/info"
+)
+
+type AnalyzeSubCommand struct {
+	cmd.CommandActioner
+}
+
+func Analyze(input cli.Input) error {
+	return (&AnalyzeSubCommand{}).do(input)
+}
+
+func (opts *AnalyzeSubCommand) do(input cli.Input) error {
+	err := util.RequireServerFeature(opts.Client(), info.FeatureFnAnalysis)
+	if err != nil {
+		return err
+	}
+
+	report, err := opts.Client().V1().Function().Analyze(&metav1.ObjectMeta{
+		Name:      input.String(flagkey.FnName),
+		Namespace: input.String(flagkey.NamespaceFunction),
+	})
+	if err != nil {
+		return errors.Wrap(err, "error analyzing function")
+	}
+
+	if len(report.Pods) == 0 {
+		console.Info(fmt.Sprintf("Function %v has no running pods to analyze", report.Function))
+		return nil
+	}
+	if !report.MetricsAvailable {
+		console.Warn("The metrics API (metrics-server) is unavailable, usages are not taken into account")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", "POD", "NODE", "RESTARTS", "OOM KILLS", "CPU USED/LIMIT", "MEMORY USED/LIMIT")
+	for _, p := range report.Pods {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", p.Name, p.Node, p.Restarts, p.OOMKills,
+			usedOf(fnanalysis.FormatCPU(p.CPUUsage), fnanalysis.FormatCPU(p.CPULimit), p.CPULimit),
+			usedOf(fnanalysis.FormatMemory(p.MemoryUsage), fnanalysis.FormatMemory(p.MemoryLimit), p.MemoryLimit))
+	}
+	w.Flush()
+	fmt.Println()
+
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", "NODE", "PODS", "CPU USED/REQUESTED/ALLOCATABLE", "MEMORY USED/REQUESTED/ALLOCATABLE", "PRESSURE")
+	for _, n := range report.Nodes {
+		fmt.Fprintf(w, "%v\t%v\t%v/%v/%v\t%v/%v/%v\t%v\n", n.Name, n.Pods,
+			fnanalysis.FormatCPU(n.CPUUsage), fnanalysis.FormatCPU(n.CPURequested), fnanalysis.FormatCPU(n.CPUAllocatable),
+			fnanalysis.FormatMemory(n.MemoryUsage), fnanalysis.FormatMemory(n.MemoryRequested), fnanalysis.FormatMemory(n.MemoryAllocatable),
+			strings.Join(n.Pressure, ","))
+	}
+	w.Flush()
+	fmt.Println()
+
+	if len(report.Findings) == 0 {
+		fmt.Println("No isolation issues found")
+		return nil
+	}
+
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", "SEVERITY", "FINDING", "WHERE", "DETAIL")
+	for _, f := range report.Findings {
+		where := f.Node
+		if len(f.Pod) > 0 {
+			where = "pod " + f.Pod
+		} else if len(where) > 0 {
+			where = "node " + where
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", f.Severity, f.Kind, where, f.Message)
+	}
+	w.Flush()
+
+	if len(report.Recommendations) > 0 {
+		fmt.Println("\nRecommendations:")
+		for _, r := range report.Recommendations {
+			fmt.Printf("  - [%v] %v\n", r.Kind, r.Message)
+		}
+	}
+
+	return nil
+}
+
+// usedOf formats the usage of a resource and its limit, if it has one.
+func usedOf(used, limit string, limitValue int64) string {
+	if limitValue == 0 {
+		return used + "/-"
+	}
+	return used + "/" + limit
+}
@@ -35,7 +35,8 @@ func Commands() *cobra.Command {
 			flag.FnEnvName, flag.FnEntryPoint, flag.FnPkgName,
 			flag.FnExecutorType, flag.FnCfgMap, flag.FnSecret,
 			flag.FnSpecializationTimeout, flag.FnExecutionTimeout,
-			flag.FnIdleTimeout, flag.FnConcurrency, flag.FnRequestsPerPod,
+			flag.FnIdleTimeout, flag.FnConcurrency, flag.FnRequestsPerPod, flag.FnCoLocateWith, flag.FnPriority,
+			flag.FnQuota, flag.FnImage, flag.FnPort, flag.FnImagePullSecret,
 
 			// TODO retired pkg & trigger related flags from function cmd
 			flag.PkgCode, flag.PkgSrcArchive, flag.PkgDeployArchive,
@@ -47,11 +48,38 @@ func Commands() *cobra.Command {
 			// flag for newdeploy to use.
 			flag.RunTimeMinCPU, flag.RunTimeMaxCPU, flag.RunTimeMinMemory,
 			flag.RunTimeMaxMemory, flag.ReplicasMin,
-			flag.ReplicasMax, flag.RunTimeTargetCPU,
+			flag.ReplicasMax, flag.RunTimeTargetCPU, flag.ReplicasTargetConcurrency,
 
 			flag.NamespaceFunction, flag.NamespaceEnvironment, flag.SpecSave, flag.SpecDry},
 	})
 
+	runContainerCmd := &cobra.Command{
+		Use:     "run-container",
+		Aliases: []string{},
+		Short:   "Create a function running a container image, without environment or package",
+		Long: "Create a function from an image serving HTTP requests on its own, like a containerized handler, " +
+			"without repackaging it for an environment. Give the command of the container after '--', " +
+			"e.g. 'fission fn run-container --name hello --image ghcr.io/acme/hello:1.0 --port 8080 -- /hello -v' " +
+			"(default the entrypoint of the image). The function is run by newdeploy.",
+		RunE: wrapper.Wrapper(Create),
+	}
+	wrapper.SetFlags(runContainerCmd, flag.FlagSet{
+		Required: []flag.Flag{flag.FnName, flag.FnImage},
+		Optional: []flag.Flag{
+			flag.FnPort, flag.FnImagePullSecret, flag.FnCfgMap, flag.FnSecret,
+			flag.FnSpecializationTimeout, flag.FnExecutionTimeout,
+			flag.FnIdleTimeout, flag.FnConcurrency, flag.FnRequestsPerPod, flag.FnCoLocateWith, flag.FnPriority,
+			flag.FnQuota,
+
+			flag.HtUrl, flag.HtMethod,
+
+			flag.RunTimeMinCPU, flag.RunTimeMaxCPU, flag.RunTimeMinMemory,
+			flag.RunTimeMaxMemory, flag.ReplicasMin,
+			flag.ReplicasMax, flag.RunTimeTargetCPU, flag.ReplicasTargetConcurrency,
+
+			flag.NamespaceFunction, flag.SpecSave, flag.SpecDry},
+	})
+
 	getCmd := &cobra.Command{
 		Use:     "get",
 		Aliases: []string{},
@@ -86,7 +114,8 @@ func Commands() *cobra.Command {
 			flag.FnEnvName, flag.FnEntryPoint, flag.FnPkgName,
 			flag.FnExecutorType, flag.FnSecret, flag.FnCfgMap,
 			flag.FnSpecializationTimeout, flag.FnExecutionTimeout,
-			flag.FnIdleTimeout, flag.FnConcurrency, flag.FnRequestsPerPod,
+			flag.FnIdleTimeout, flag.FnConcurrency, flag.FnRequestsPerPod, flag.FnCoLocateWith, flag.FnPriority,
+			flag.FnQuota, flag.FnImage, flag.FnPort, flag.FnImagePullSecret,
 
 			flag.PkgCode, flag.PkgSrcArchive, flag.PkgDeployArchive,
 			flag.PkgSrcChecksum, flag.PkgDeployChecksum, flag.PkgInsecure,
@@ -94,7 +123,7 @@ func Commands() *cobra.Command {
 
 			flag.RunTimeMinCPU, flag.RunTimeMaxCPU, flag.RunTimeMinMemory,
 			flag.RunTimeMaxMemory, flag.ReplicasMin, flag.ReplicasMax,
-			flag.RunTimeTargetCPU,
+			flag.RunTimeTargetCPU, flag.ReplicasTargetConcurrency,
 
 			flag.NamespaceFunction, flag.NamespaceEnvironment, flag.SpecSave,
 		},
@@ -119,45 +148,109 @@ func Commands() *cobra.Command {
 		RunE:    wrapper.Wrapper(List),
 	}
 	wrapper.SetFlags(listCmd, flag.FlagSet{
-		Optional: []flag.Flag{flag.NamespaceFunction},
+		Optional: []flag.Flag{flag.NamespaceFunction, flag.FnListLabels, flag.FnListFieldSelector, flag.FnListPageSize, flag.FnListWatch},
 	})
 
 	logsCmd := &cobra.Command{
 		Use:     "log",
 		Aliases: []string{"logs"},
 		Short:   "Display function logs",
-		RunE:    wrapper.Wrapper(Log),
+		Long: "Display the logs of all the pods of a function merged, following the pods started " +
+			"while it runs with --follow. With --dbtype sink, query them by time range from the log sink " +
+			"the logger ships them to instead, including the logs of deleted pods.",
+		RunE: wrapper.Wrapper(Log),
 	}
 	wrapper.SetFlags(logsCmd, flag.FlagSet{
 		Required: []flag.Flag{flag.FnName},
 		Optional: []flag.Flag{
 			flag.FnLogFollow, flag.FnLogReverseQuery, flag.FnLogCount,
-			flag.FnLogDetail, flag.FnLogPod, flag.NamespaceFunction, flag.FnLogDBType},
+			flag.FnLogDetail, flag.FnLogPod, flag.NamespaceFunction, flag.FnLogDBType,
+			flag.FnLogSince, flag.FnLogUntil, flag.FnLogTail, flag.FnLogFilter, flag.FnLogOutput},
 	})
 
 	testCmd := &cobra.Command{
 		Use:     "test",
 		Aliases: []string{},
 		Short:   "Test a function",
-		RunE:    wrapper.Wrapper(Test),
+		Long: "Send a test request to a function. With --local, run the code given with --code in a local container " +
+			"of the environment runtime image instead, and send the request to it, to try changes to the code " +
+			"without deploying them.",
+		RunE: wrapper.Wrapper(Test),
 	}
 	wrapper.SetFlags(testCmd, flag.FlagSet{
-		Required: []flag.Flag{flag.FnName},
-		Optional: []flag.Flag{flag.HtMethod, flag.FnTestHeader, flag.FnTestBody,
+		Optional: []flag.Flag{flag.FnName, flag.HtMethod, flag.FnTestHeader, flag.FnTestBody,
 			flag.FnTestQuery, flag.FnTestTimeout, flag.NamespaceFunction,
 			// for getting log from log database if
 			// we failed to get logs from function pod.
 			flag.FnLogDBType,
+			flag.FnTestLocal, flag.EnvImage, flag.FnEnvName, flag.NamespaceEnvironment,
+			flag.PkgCode, flag.FnEntryPoint, flag.FnTestEnvVersion,
 		},
 	})
 
+	debugAttachCmd := &cobra.Command{
+		Use:     "debug-attach",
+		Aliases: []string{},
+		Short:   "Attach to a debug container added to a running function pod",
+		Long: "Add an ephemeral container with debugging tools to a running pod of the function and attach to it. " +
+			"The container shares the process namespace of the function. Give the command to run after '--', " +
+			"e.g. 'fission fn debug-attach --name hello --image busybox -- sh' (default sh). " +
+			"Requires the EphemeralContainers feature of Kubernetes.",
+		RunE: wrapper.Wrapper(DebugAttach),
+	}
+	wrapper.SetFlags(debugAttachCmd, flag.FlagSet{
+		Required: []flag.Flag{flag.FnName},
+		Optional: []flag.Flag{flag.FnDebugImage, flag.FnLogPod, flag.FnDebugTimeout, flag.NamespaceFunction},
+	})
+
+	analyzeCmd := &cobra.Command{
+		Use:     "analyze",
+		Aliases: []string{},
+		Short:   "Report on how a function is affected by its resource limits and neighbor pods",
+		Long: "Correlate OOM kills, evictions and throttling of the pods of a function with the load of their nodes " +
+			"and of the pods next to them, to tell undersized limits from noisy neighbors, and recommend a " +
+			"resource bump, a dedicated node pool or anti-affinity. Usages need the metrics API (metrics-server).",
+		RunE: wrapper.Wrapper(Analyze),
+	}
+	wrapper.SetFlags(analyzeCmd, flag.FlagSet{
+		Required: []flag.Flag{flag.FnName},
+		Optional: []flag.Flag{flag.NamespaceFunction},
+	})
+
+	invocationsCmd := &cobra.Command{
+		Use:     "invocations",
+		Aliases: []string{},
+		Short:   "List the most recent invocations of a function",
+		Long: "List the most recent invocations of a function router recorded, with the trigger that made them, " +
+			"their duration, status code and error, to debug failures after the fact without tracing. " +
+			"Router keeps the last 100 invocations of each function by default.",
+		RunE: wrapper.Wrapper(Invocations),
+	}
+	wrapper.SetFlags(invocationsCmd, flag.FlagSet{
+		Required: []flag.Flag{flag.FnName},
+		Optional: []flag.Flag{flag.NamespaceFunction, flag.FnInvocationsLimit},
+	})
+
+	topCmd := &cobra.Command{
+		Use:     "top",
+		Aliases: []string{},
+		Short:   "Display the resource usage of functions",
+		Long: "Display the CPU-seconds, memory-GB-seconds, invocations and network egress of functions since their " +
+			"usage has been metered, the ones using the most first. Usage is sampled from metrics-server and the " +
+			"cAdvisor of nodes every 30s by default. Lists functions across all namespaces if no namespace is specified.",
+		RunE: wrapper.Wrapper(Top),
+	}
+	wrapper.SetFlags(topCmd, flag.FlagSet{
+		Optional: []flag.Flag{flag.NamespaceFunction, flag.FnTopSort, flag.FnTopOutput},
+	})
+
 	command := &cobra.Command{
 		Use:     "function",
 		Aliases: []string{"fn"},
 		Short:   "Create, update and manage functions",
 	}
 
-	command.AddCommand(createCmd, getCmd, getmetaCmd, updateCmd, deleteCmd, listCmd, logsCmd, testCmd)
+	command.AddCommand(createCmd, runContainerCmd, getCmd, getmetaCmd, updateCmd, deleteCmd, listCmd, logsCmd, testCmd, debugAttachCmd, analyzeCmd, invocationsCmd, topCmd)
 
 	return command
 }
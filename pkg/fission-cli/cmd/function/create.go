@@ -36,6 +36,8 @@ import (
 	"github.com/fission/fission/pkg/fission-cli/console"
 	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
 	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
+	"github.com/fission/fission/pkg/qos"
 )
 
 const (
@@ -104,6 +106,19 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 
 	requestsPerPod := input.Int(flagkey.FnRequestsPerPod)
 
+	priority := fv1.InvocationPriority(input.String(flagkey.FnPriority))
+	if len(priority) > 0 {
+		err := priority.Validate()
+		if err != nil {
+			return errors.Wrap(err, "error parsing priority")
+		}
+	}
+
+	quotas, err := getInvocationQuotas(input)
+	if err != nil {
+		return err
+	}
+
 	pkgName := input.String(flagkey.FnPackageName)
 
 	secretNames := input.StringSlice(flagkey.FnSecret)
@@ -118,10 +133,26 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 		return err
 	}
 
+	var container *fv1.FunctionContainer
+	if len(input.String(flagkey.FnImage)) > 0 {
+		if len(pkgName) > 0 || len(input.String(flagkey.FnEnvironmentName)) > 0 {
+			return errors.Errorf("--%v can't be used with --%v or --%v, functions run either an image or an environment",
+				flagkey.FnImage, flagkey.FnPackageName, flagkey.FnEnvironmentName)
+		}
+		if invokeStrategy.ExecutionStrategy.ExecutorType != fv1.ExecutorTypeNewdeploy {
+			return errors.Errorf("functions running an image need \"--%v %v\"", flagkey.FnExecutorType, fv1.ExecutorTypeNewdeploy)
+		}
+		container = getContainer(input, nil)
+	}
+
 	var pkgMetadata *metav1.ObjectMeta
 	var envName string
 
-	if len(pkgName) > 0 {
+	switch {
+	case container != nil:
+		// container functions have neither environment nor package
+		envNamespace = ""
+	case len(pkgName) > 0:
 		var pkg *fv1.Package
 
 		if toSpec {
@@ -157,7 +188,7 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 			console.Warn("Function's environment is different than package's environment, package's environment will be used for creating function")
 		}
 		envNamespace = pkg.Spec.Environment.Namespace
-	} else {
+	default:
 		// need to specify environment for creating new package
 		envName = input.String(flagkey.FnEnvironmentName)
 		if len(envName) == 0 {
@@ -223,6 +254,18 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 		}
 	}
 
+	var pkgRef fv1.FunctionPackageRef
+	if pkgMetadata != nil {
+		pkgRef = fv1.FunctionPackageRef{
+			FunctionName: entrypoint,
+			PackageRef: fv1.PackageRef{
+				Namespace:       pkgMetadata.Namespace,
+				Name:            pkgMetadata.Name,
+				ResourceVersion: pkgMetadata.ResourceVersion,
+			},
+		}
+	}
+
 	var secrets []fv1.SecretReference
 	var cfgmaps []fv1.ConfigMapReference
 
@@ -288,22 +331,19 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 				Name:      envName,
 				Namespace: envNamespace,
 			},
-			Package: fv1.FunctionPackageRef{
-				FunctionName: entrypoint,
-				PackageRef: fv1.PackageRef{
-					Namespace:       pkgMetadata.Namespace,
-					Name:            pkgMetadata.Name,
-					ResourceVersion: pkgMetadata.ResourceVersion,
-				},
-			},
-			Secrets:         secrets,
-			ConfigMaps:      cfgmaps,
-			Resources:       *resourceReq,
-			InvokeStrategy:  *invokeStrategy,
-			FunctionTimeout: fnTimeout,
-			IdleTimeout:     &fnIdleTimeout,
-			Concurrency:     fnConcurrency,
-			RequestsPerPod:  requestsPerPod,
+			Package:          pkgRef,
+			Secrets:          secrets,
+			ConfigMaps:       cfgmaps,
+			Resources:        *resourceReq,
+			InvokeStrategy:   *invokeStrategy,
+			FunctionTimeout:  fnTimeout,
+			IdleTimeout:      &fnIdleTimeout,
+			Concurrency:      fnConcurrency,
+			RequestsPerPod:   requestsPerPod,
+			CoLocateWith:     input.StringSlice(flagkey.FnCoLocateWith),
+			Priority:         priority,
+			InvocationQuotas: quotas,
+			Container:        container,
 		},
 	}
 
@@ -327,6 +367,8 @@ func (opts *CreateSubCommand) run(input cli.Input) error {
 		return nil
 	}
 
+	util.WarnUnsupportedFeatures(opts.Client(), "function", opts.function.ObjectMeta.Name, info.FunctionFeatures(&opts.function.Spec))
+
 	_, err := opts.Client().V1().Function().Create(opts.function)
 	if err != nil {
 		return errors.Wrap(err, "error creating function")
@@ -394,7 +436,13 @@ func getInvokeStrategy(input cli.Input, existingInvokeStrategy *fv1.InvokeStrate
 func getExecutionStrategy(input cli.Input) (strategy *fv1.ExecutionStrategy, err error) {
 	var fnExecutor fv1.ExecutorType
 
-	switch input.String(flagkey.FnExecutorType) {
+	executorType := input.String(flagkey.FnExecutorType)
+	// pools are pods of environments, images run by newdeploy
+	if len(input.String(flagkey.FnImage)) > 0 && !input.IsSet(flagkey.FnExecutorType) {
+		executorType = string(fv1.ExecutorTypeNewdeploy)
+	}
+
+	switch executorType {
 	case "":
 		fallthrough
 	case string(fv1.ExecutorTypePoolmgr):
@@ -415,8 +463,9 @@ func getExecutionStrategy(input cli.Input) (strategy *fv1.ExecutionStrategy, err
 	}
 
 	if fnExecutor == fv1.ExecutorTypePoolmgr {
-		if input.IsSet(flagkey.RuntimeTargetcpu) || input.IsSet(flagkey.ReplicasMinscale) || input.IsSet(flagkey.ReplicasMaxscale) {
-			return nil, errors.New("to set target CPU or min/max scale for function, please specify \"--executortype newdeploy\"")
+		if input.IsSet(flagkey.RuntimeTargetcpu) || input.IsSet(flagkey.ReplicasMinscale) || input.IsSet(flagkey.ReplicasMaxscale) ||
+			input.IsSet(flagkey.ReplicasTargetConcurrency) {
+			return nil, errors.New("to set target CPU, target concurrency or min/max scale for function, please specify \"--executortype newdeploy\"")
 		}
 
 		if input.IsSet(flagkey.RuntimeMincpu) || input.IsSet(flagkey.RuntimeMaxcpu) || input.IsSet(flagkey.RuntimeMinmemory) || input.IsSet(flagkey.RuntimeMaxmemory) {
@@ -436,6 +485,14 @@ func getExecutionStrategy(input cli.Input) (strategy *fv1.ExecutionStrategy, err
 			}
 		}
 
+		targetConcurrency := 0
+		if input.IsSet(flagkey.ReplicasTargetConcurrency) {
+			targetConcurrency, err = getTargetConcurrency(input)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		minScale := DEFAULT_MIN_SCALE
 		if input.IsSet(flagkey.ReplicasMinscale) {
 			minScale = input.Int(flagkey.ReplicasMinscale)
@@ -456,11 +513,12 @@ func getExecutionStrategy(input cli.Input) (strategy *fv1.ExecutionStrategy, err
 		// Right now a simple single case strategy implementation
 		// This will potentially get more sophisticated once we have more strategies in place
 		strategy = &fv1.ExecutionStrategy{
-			ExecutorType:          fnExecutor,
-			MinScale:              minScale,
-			MaxScale:              maxScale,
-			TargetCPUPercent:      targetCPU,
-			SpecializationTimeout: specializationTimeout,
+			ExecutorType:            fnExecutor,
+			MinScale:                minScale,
+			MaxScale:                maxScale,
+			TargetCPUPercent:        targetCPU,
+			TargetConcurrencyPerPod: targetConcurrency,
+			SpecializationTimeout:   specializationTimeout,
 		}
 	}
 
@@ -498,8 +556,9 @@ func updateExecutionStrategy(input cli.Input, existingExecutionStrategy *fv1.Exe
 	}
 
 	if fnExecutor == fv1.ExecutorTypePoolmgr {
-		if input.IsSet(flagkey.RuntimeTargetcpu) || input.IsSet(flagkey.ReplicasMinscale) || input.IsSet(flagkey.ReplicasMaxscale) {
-			return nil, errors.New("to set target CPU or min/max scale for function, please specify \"--executortype newdeploy\"")
+		if input.IsSet(flagkey.RuntimeTargetcpu) || input.IsSet(flagkey.ReplicasMinscale) || input.IsSet(flagkey.ReplicasMaxscale) ||
+			input.IsSet(flagkey.ReplicasTargetConcurrency) {
+			return nil, errors.New("to set target CPU, target concurrency or min/max scale for function, please specify \"--executortype newdeploy\"")
 		}
 
 		if input.IsSet(flagkey.RuntimeMincpu) || input.IsSet(flagkey.RuntimeMaxcpu) || input.IsSet(flagkey.RuntimeMinmemory) || input.IsSet(flagkey.RuntimeMaxmemory) {
@@ -511,6 +570,7 @@ func updateExecutionStrategy(input cli.Input, existingExecutionStrategy *fv1.Exe
 		}
 	} else {
 		targetCPU := existingExecutionStrategy.TargetCPUPercent
+		targetConcurrency := existingExecutionStrategy.TargetConcurrencyPerPod
 		minScale := existingExecutionStrategy.MinScale
 		maxScale := existingExecutionStrategy.MaxScale
 
@@ -531,6 +591,13 @@ func updateExecutionStrategy(input cli.Input, existingExecutionStrategy *fv1.Exe
 			}
 		}
 
+		if input.IsSet(flagkey.ReplicasTargetConcurrency) {
+			targetConcurrency, err = getTargetConcurrency(input)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		if input.IsSet(flagkey.ReplicasMinscale) {
 			minScale = input.Int(flagkey.ReplicasMinscale)
 		}
@@ -553,11 +620,12 @@ func updateExecutionStrategy(input cli.Input, existingExecutionStrategy *fv1.Exe
 		// Right now a simple single case strategy implementation
 		// This will potentially get more sophisticated once we have more strategies in place
 		strategy = &fv1.ExecutionStrategy{
-			ExecutorType:          fnExecutor,
-			MinScale:              minScale,
-			MaxScale:              maxScale,
-			TargetCPUPercent:      targetCPU,
-			SpecializationTimeout: specializationTimeout,
+			ExecutorType:            fnExecutor,
+			MinScale:                minScale,
+			MaxScale:                maxScale,
+			TargetCPUPercent:        targetCPU,
+			TargetConcurrencyPerPod: targetConcurrency,
+			SpecializationTimeout:   specializationTimeout,
 		}
 	}
 
@@ -571,3 +639,52 @@ func getTargetCPU(input cli.Input) (int, error) {
 	}
 	return targetCPU, nil
 }
+
+func getTargetConcurrency(input cli.Input) (int, error) {
+	targetConcurrency := input.Int(flagkey.ReplicasTargetConcurrency)
+	if targetConcurrency < 0 {
+		return 0, errors.Errorf("%v must be greater than or equal to 0", flagkey.ReplicasTargetConcurrency)
+	}
+	return targetConcurrency, nil
+}
+
+// getInvocationQuotas returns the invocation quotas given with --quota,
+// at most one per window.
+func getInvocationQuotas(input cli.Input) ([]fv1.InvocationQuota, error) {
+	var quotas []fv1.InvocationQuota
+	for _, s := range input.StringSlice(flagkey.FnQuota) {
+		quota, err := qos.ParseInvocationQuota(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing --%v", flagkey.FnQuota)
+		}
+		for _, q := range quotas {
+			if q.Window == quota.Window {
+				return nil, errors.Errorf("--%v can be specified once per window, got more than one per %v", flagkey.FnQuota, quota.Window)
+			}
+		}
+		quotas = append(quotas, quota)
+	}
+	return quotas, nil
+}
+
+// getContainer returns the container of a function running an image, the
+// existing one updated with the flags set if any.
+func getContainer(input cli.Input, existing *fv1.FunctionContainer) *fv1.FunctionContainer {
+	container := &fv1.FunctionContainer{}
+	if existing != nil {
+		container = existing.DeepCopy()
+	}
+	if input.IsSet(flagkey.FnImage) || existing == nil {
+		container.Image = input.String(flagkey.FnImage)
+	}
+	if input.IsSet(flagkey.FnPort) || existing == nil {
+		container.Port = int32(input.Int(flagkey.FnPort))
+	}
+	if input.IsSet(flagkey.FnImagePullSecret) || existing == nil {
+		container.ImagePullSecret = input.String(flagkey.FnImagePullSecret)
+	}
+	if len(input.Args()) > 0 {
+		container.Command = input.Args()
+	}
+	return container
+}
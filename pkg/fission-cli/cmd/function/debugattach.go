@@ -0,0 +1,309 @@
+/*
+Copyright 2019 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dchest/uniuri"
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+	apiv1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	"github.com/fission/fission/pkg/fission-cli/console"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+	"github.com/fission/fission/pkg/fission-cli/util"
+)
+
+const (
+	// fetcherContainerName is the name of the fetcher sidecar in function pods.
+	fetcherContainerName = "fetcher"
+
+	// defaultFunctionNamespace is the namespace executor creates the pods
+	// of functions in the default namespace in, unless fission is installed
+	// with another one.
+	defaultFunctionNamespace = "fission-function"
+)
+
+type DebugAttachSubCommand struct {
+	cmd.CommandActioner
+}
+
+func DebugAttach(input cli.Input) error {
+	return (&DebugAttachSubCommand{}).do(input)
+}
+
+func (opts *DebugAttachSubCommand) do(input cli.Input) error {
+	fn, err := opts.Client().V1().Function().Get(&metav1.ObjectMeta{
+		Name:      input.String(flagkey.FnName),
+		Namespace: input.String(flagkey.NamespaceFunction),
+	})
+	if err != nil {
+		return errors.Wrap(err, "error getting function")
+	}
+
+	config, kubeClient, err := util.GetKubernetesClient(input.String(flagkey.KubeContext))
+	if err != nil {
+		return err
+	}
+
+	pod, err := getSpecializedPod(kubeClient, fn, getFunctionNamespace(), input.String(flagkey.FnLogPod))
+	if err != nil {
+		return err
+	}
+
+	command := input.Args()
+	if len(command) == 0 {
+		command = []string{"sh"}
+	}
+	// allocate a terminal only if there is one to attach it to
+	stdinFd := int(os.Stdin.Fd())
+	tty := term.IsTerminal(stdinFd)
+	container := debugContainer(pod, "debugger-"+strings.ToLower(uniuri.NewLen(5)),
+		input.String(flagkey.FnDebugImage), command, tty)
+
+	ecs, err := kubeClient.CoreV1().Pods(pod.ObjectMeta.Namespace).GetEphemeralContainers(pod.ObjectMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return errors.New("ephemeral containers are not supported by the cluster, enable the EphemeralContainers feature gate of Kubernetes")
+		}
+		return errors.Wrap(err, "error getting ephemeral containers of pod")
+	}
+	ecs.EphemeralContainers = append(ecs.EphemeralContainers, container)
+	_, err = kubeClient.CoreV1().Pods(pod.ObjectMeta.Namespace).UpdateEphemeralContainers(pod.ObjectMeta.Name, ecs)
+	if err != nil {
+		return errors.Wrap(err, "error adding debug container to pod")
+	}
+
+	console.Info(fmt.Sprintf("Debug container %v added to pod %v/%v, waiting for it to start",
+		container.Name, pod.ObjectMeta.Namespace, pod.ObjectMeta.Name))
+	err = waitForEphemeralContainer(kubeClient, pod, container.Name, input.Duration(flagkey.FnDebugTimeout))
+	if err != nil {
+		return err
+	}
+
+	// Ephemeral containers can't be removed, it's gone with the pod only.
+	attachFlags := "-i"
+	if tty {
+		attachFlags = "-it"
+	}
+	console.Info(fmt.Sprintf("Attaching to debug container %v, it keeps running after detaching until the pod is recycled. "+
+		"Reattach with: kubectl attach %v -n %v %v -c %v",
+		container.Name, attachFlags, pod.ObjectMeta.Namespace, pod.ObjectMeta.Name, container.Name))
+
+	req := kubeClient.CoreV1().RESTClient().Post().
+		Namespace(pod.ObjectMeta.Namespace).
+		Resource("pods").
+		Name(pod.ObjectMeta.Name).
+		SubResource("attach").
+		VersionedParams(attachOptions(container.Name, tty), scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
+	if err != nil {
+		return errors.Wrap(err, "error attaching to debug container")
+	}
+
+	streamOptions := remotecommand.StreamOptions{
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Tty:    tty,
+	}
+	if tty {
+		// the remote terminal echoes and handles control keys
+		state, err := term.MakeRaw(stdinFd)
+		if err != nil {
+			return errors.Wrap(err, "error setting up terminal")
+		}
+		defer func() {
+			_ = term.Restore(stdinFd, state)
+		}()
+		// stderr is part of the terminal output
+		streamOptions.Stderr = nil
+		if width, height, err := term.GetSize(stdinFd); err == nil {
+			streamOptions.TerminalSizeQueue = &fixedSizeQueue{size: &remotecommand.TerminalSize{
+				Width:  uint16(width),
+				Height: uint16(height),
+			}}
+		}
+	}
+	err = executor.Stream(streamOptions)
+	if err != nil {
+		return errors.Wrap(err, "error attaching to debug container")
+	}
+
+	return nil
+}
+
+// debugContainer returns the ephemeral container debugging the function
+// in the pod, with a terminal if tty is true.
+func debugContainer(pod *apiv1.Pod, name string, image string, command []string, tty bool) apiv1.EphemeralContainer {
+	return apiv1.EphemeralContainer{
+		EphemeralContainerCommon: apiv1.EphemeralContainerCommon{
+			Name:                     name,
+			Image:                    image,
+			Command:                  command,
+			Stdin:                    true,
+			TTY:                      tty,
+			TerminationMessagePolicy: apiv1.TerminationMessageFallbackToLogsOnError,
+		},
+		// share the process namespace of the function, so that its
+		// processes and their files can be inspected
+		TargetContainerName: functionContainerName(pod),
+	}
+}
+
+// attachOptions returns the options attaching to the debug container.
+// With a terminal, stderr is part of its output.
+func attachOptions(container string, tty bool) *apiv1.PodAttachOptions {
+	return &apiv1.PodAttachOptions{
+		Container: container,
+		Stdin:     true,
+		Stdout:    true,
+		Stderr:    !tty,
+		TTY:       tty,
+	}
+}
+
+// fixedSizeQueue reports the size of the local terminal once.
+type fixedSizeQueue struct {
+	size *remotecommand.TerminalSize
+}
+
+// Next returns the size once, then nil to stop the size monitoring.
+func (q *fixedSizeQueue) Next() *remotecommand.TerminalSize {
+	size := q.size
+	q.size = nil
+	return size
+}
+
+// getFunctionNamespace returns the namespace executor creates the pods of
+// functions in the default namespace in, from FISSION_FUNCTION_NAMESPACE
+// like the fission components do.
+func getFunctionNamespace() string {
+	if ns := os.Getenv("FISSION_FUNCTION_NAMESPACE"); len(ns) > 0 {
+		return ns
+	}
+	return defaultFunctionNamespace
+}
+
+// functionPodNamespace returns the namespace of the pods of a function.
+func functionPodNamespace(fn *fv1.Function, functionNamespace string) string {
+	// For backward compatibility, executor creates the pods of functions
+	// in the default namespace in the function namespace, otherwise in
+	// the namespace of the function.
+	if fn.ObjectMeta.Namespace != metav1.NamespaceDefault {
+		return fn.ObjectMeta.Namespace
+	}
+	return functionNamespace
+}
+
+// getSpecializedPod returns the named pod of the function, or its newest running pod.
+func getSpecializedPod(kubeClient kubernetes.Interface, fn *fv1.Function, functionNamespace string, podName string) (*apiv1.Pod, error) {
+	podNs := functionPodNamespace(fn, functionNamespace)
+	podList, err := kubeClient.CoreV1().Pods(podNs).List(metav1.ListOptions{
+		LabelSelector: labels.Set{fv1.FUNCTION_UID: string(fn.ObjectMeta.UID)}.AsSelector().String(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing function pods")
+	}
+
+	pods := make([]apiv1.Pod, 0, len(podList.Items))
+	for _, pod := range podList.Items {
+		if pod.Status.Phase != apiv1.PodRunning || pod.ObjectMeta.DeletionTimestamp != nil {
+			continue
+		}
+		if len(podName) > 0 && pod.ObjectMeta.Name != podName {
+			continue
+		}
+		pods = append(pods, pod)
+	}
+	if len(pods) == 0 {
+		if len(podName) > 0 {
+			return nil, errors.Errorf("pod %v of function %v is not running", podName, fn.ObjectMeta.Name)
+		}
+		return nil, errors.Errorf("function %v has no running pods, invoke it to specialize one", fn.ObjectMeta.Name)
+	}
+
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].ObjectMeta.CreationTimestamp.After(pods[j].ObjectMeta.CreationTimestamp.Time)
+	})
+	return &pods[0], nil
+}
+
+// functionContainerName returns the name of the container running the function.
+func functionContainerName(pod *apiv1.Pod) string {
+	for _, c := range pod.Spec.Containers {
+		if c.Name != fetcherContainerName {
+			return c.Name
+		}
+	}
+	return ""
+}
+
+// waitForEphemeralContainer waits for the ephemeral container to run, returning
+// early with an error if it can't start.
+func waitForEphemeralContainer(kubeClient kubernetes.Interface, pod *apiv1.Pod, name string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		p, err := kubeClient.CoreV1().Pods(pod.ObjectMeta.Namespace).Get(pod.ObjectMeta.Name, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrap(err, "error getting pod")
+		}
+
+		for _, status := range p.Status.EphemeralContainerStatuses {
+			if status.Name != name {
+				continue
+			}
+			switch {
+			case status.State.Running != nil:
+				return nil
+			case status.State.Terminated != nil:
+				return errors.Errorf("debug container exited: %v %v",
+					status.State.Terminated.Reason, status.State.Terminated.Message)
+			case status.State.Waiting != nil && isImageError(status.State.Waiting.Reason):
+				return errors.Errorf("debug container can't start: %v %v",
+					status.State.Waiting.Reason, status.State.Waiting.Message)
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return errors.Errorf("timed out waiting for debug container %v to start", name)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func isImageError(reason string) bool {
+	switch reason {
+	case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "ErrImageNeverPull":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func makeFunctionPod(name string, namespace string, uid string, created time.Time, phase apiv1.PodPhase) *apiv1.Pod {
+	return &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			Labels:            map[string]string{fv1.FUNCTION_UID: uid},
+			CreationTimestamp: metav1.Time{Time: created},
+		},
+		Spec: apiv1.PodSpec{
+			Containers: []apiv1.Container{{Name: "nodejs"}, {Name: fetcherContainerName}},
+		},
+		Status: apiv1.PodStatus{Phase: phase},
+	}
+}
+
+func TestGetSpecializedPod(t *testing.T) {
+	now := time.Now()
+	kubeClient := fake.NewSimpleClientset(
+		makeFunctionPod("old", "custom-function", "fn-uid", now.Add(-time.Hour), apiv1.PodRunning),
+		makeFunctionPod("new", "custom-function", "fn-uid", now, apiv1.PodRunning),
+		makeFunctionPod("pending", "custom-function", "fn-uid", now.Add(time.Minute), apiv1.PodPending),
+		makeFunctionPod("other-fn", "custom-function", "other-uid", now.Add(time.Minute), apiv1.PodRunning),
+		makeFunctionPod("wrong-ns", defaultFunctionNamespace, "fn-uid", now.Add(time.Minute), apiv1.PodRunning),
+		makeFunctionPod("team", "team", "team-uid", now, apiv1.PodRunning),
+	)
+	fn := &fv1.Function{ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: metav1.NamespaceDefault, UID: "fn-uid"}}
+
+	// pods of functions in the default namespace are in the function namespace
+	pod, err := getSpecializedPod(kubeClient, fn, "custom-function", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "new", pod.ObjectMeta.Name)
+
+	pod, err = getSpecializedPod(kubeClient, fn, "custom-function", "old")
+	assert.Nil(t, err)
+	assert.Equal(t, "old", pod.ObjectMeta.Name)
+
+	_, err = getSpecializedPod(kubeClient, fn, "custom-function", "pending")
+	assert.NotNil(t, err)
+
+	// pods of functions in other namespaces are in the namespace of the function
+	teamFn := &fv1.Function{ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "team", UID: "team-uid"}}
+	pod, err = getSpecializedPod(kubeClient, teamFn, "custom-function", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "team", pod.ObjectMeta.Name)
+}
+
+func TestGetFunctionNamespace(t *testing.T) {
+	old, set := os.LookupEnv("FISSION_FUNCTION_NAMESPACE")
+	defer func() {
+		if set {
+			os.Setenv("FISSION_FUNCTION_NAMESPACE", old)
+		} else {
+			os.Unsetenv("FISSION_FUNCTION_NAMESPACE")
+		}
+	}()
+
+	os.Unsetenv("FISSION_FUNCTION_NAMESPACE")
+	assert.Equal(t, defaultFunctionNamespace, getFunctionNamespace())
+	os.Setenv("FISSION_FUNCTION_NAMESPACE", "custom-function")
+	assert.Equal(t, "custom-function", getFunctionNamespace())
+}
+
+func TestDebugContainer(t *testing.T) {
+	pod := makeFunctionPod("new", "custom-function", "fn-uid", time.Now(), apiv1.PodRunning)
+
+	for _, tty := range []bool{true, false} {
+		c := debugContainer(pod, "debugger-abcde", "busybox", []string{"sh"}, tty)
+		assert.Equal(t, "nodejs", c.TargetContainerName)
+		assert.True(t, c.Stdin)
+		assert.Equal(t, tty, c.TTY)
+
+		opts := attachOptions(c.Name, tty)
+		assert.Equal(t, c.Name, opts.Container)
+		assert.True(t, opts.Stdin)
+		assert.True(t, opts.Stdout)
+		assert.Equal(t, tty, opts.TTY)
+		// stderr is part of the terminal output
+		assert.Equal(t, !tty, opts.Stderr)
+	}
+}
@@ -347,6 +347,22 @@ func TestGetInvokeStrategy(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name:                   "functions running an image default to newdeploy",
+			testArgs:               map[string]interface{}{flagkey.FnImage: "ghcr.io/acme/hello:1.0"},
+			existingInvokeStrategy: nil,
+			expectedResult: &fv1.InvokeStrategy{
+				StrategyType: fv1.StrategyTypeExecution,
+				ExecutionStrategy: fv1.ExecutionStrategy{
+					ExecutorType:          fv1.ExecutorTypeNewdeploy,
+					MinScale:              DEFAULT_MIN_SCALE,
+					MaxScale:              DEFAULT_MIN_SCALE,
+					TargetCPUPercent:      DEFAULT_TARGET_CPU_PERCENTAGE,
+					SpecializationTimeout: fv1.DefaultSpecializationTimeOut,
+				},
+			},
+			expectError: false,
+		},
 		{
 			name: "specializationtimeout should not be less than 120",
 			testArgs: map[string]interface{}{
@@ -383,3 +399,26 @@ func TestGetInvokeStrategy(t *testing.T) {
 		})
 	}
 }
+
+func TestGetContainer(t *testing.T) {
+	flags := dummy.TestFlagSet()
+	flags.Set(flagkey.FnImage, "ghcr.io/acme/hello:1.0")
+	flags.Set(flagkey.FnPort, 8080)
+	flags.SetArgs("/hello", "-v")
+
+	container := getContainer(flags, nil)
+	assert.Equal(t, fv1.FunctionContainer{
+		Image:   "ghcr.io/acme/hello:1.0",
+		Command: []string{"/hello", "-v"},
+		Port:    8080,
+	}, *container)
+
+	// updates keep what isn't set
+	flags = dummy.TestFlagSet()
+	flags.Set(flagkey.FnImage, "ghcr.io/acme/hello:1.1")
+	updated := getContainer(flags, container)
+	assert.Equal(t, "ghcr.io/acme/hello:1.1", updated.Image)
+	assert.Equal(t, container.Command, updated.Command)
+	assert.Equal(t, int32(8080), updated.Port)
+	assert.Equal(t, "ghcr.io/acme/hello:1.0", container.Image)
+}
@@ -43,6 +43,9 @@ func (opts *GetSubCommand) do(input cli.Input) error {
 	if err != nil {
 		return errors.Wrap(err, "error getting function")
 	}
+	if fn.Spec.Container != nil {
+		return errors.Errorf("function %v runs image %v, it has no source code", fn.ObjectMeta.Name, fn.Spec.Container.Image)
+	}
 
 	pkg, err := opts.Client().V1().Package().Get(&metav1.ObjectMeta{
 		Name:      fn.Spec.Package.PackageRef.Name,
@@ -0,0 +1,77 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	"github.com/fission/fission/pkg/fission-cli/console"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
+)
+
+type InvocationsSubCommand struct {
+	cmd.CommandActioner
+}
+
+func Invocations(input cli.Input) error {
+	return (&InvocationsSubCommand{}).do(input)
+}
+
+func (opts *InvocationsSubCommand) do(input cli.Input) error {
+	err := util.RequireServerFeature(opts.Client(), info.FeatureInvocations)
+	if err != nil {
+		return err
+	}
+
+	m := &metav1.ObjectMeta{
+		Name:      input.String(flagkey.FnName),
+		Namespace: input.String(flagkey.NamespaceFunction),
+	}
+	invocations, err := opts.Client().V1().Function().Invocations(m, input.Int(flagkey.FnInvocationsLimit))
+	if err != nil {
+		return errors.Wrap(err, "error listing function invocations")
+	}
+
+	if len(invocations) == 0 {
+		console.Info(fmt.Sprintf("No invocations of function %v recorded", m.Name))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", "TIME", "TRIGGER", "DURATION", "STATUS", "ERROR")
+	for _, i := range invocations {
+		trigger := "<direct>"
+		if len(i.TriggerName) > 0 {
+			trigger = i.TriggerType + "/" + i.TriggerName
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", i.Time.Local().Format(time.RFC3339), trigger,
+			i.Duration.Round(time.Millisecond), i.StatusCode, i.Error)
+	}
+	w.Flush()
+
+	return nil
+}
@@ -17,13 +17,17 @@ limitations under the License.
 package function
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"text/tabwriter"
 
 	"github.com/pkg/errors"
 
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	v1 "github.com/fission/fission/pkg/controller/client/v1"
 	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
 	"github.com/fission/fission/pkg/fission-cli/cmd"
 	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
@@ -40,39 +44,90 @@ func List(input cli.Input) error {
 func (opts *ListSubCommand) do(input cli.Input) error {
 	ns := input.String(flagkey.NamespaceFunction)
 
-	fns, err := opts.Client().V1().Function().List(ns)
-	if err != nil {
-		return errors.Wrap(err, "error listing functions")
+	// get functions a page at a time, so that listing a lot of
+	// functions doesn't time out, and only with the fields shown.
+	listOpts := &v1.ListOptions{
+		LabelSelector: input.String(flagkey.FnListLabels),
+		FieldSelector: input.String(flagkey.FnListFieldSelector),
+		Fields: []string{
+			"metadata.name",
+			"spec.environment",
+			"spec.InvokeStrategy",
+			"spec.resources",
+			"spec.secrets",
+			"spec.configmaps",
+		},
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	if input.Bool(flagkey.FnListWatch) {
+		return opts.watch(ns, listOpts)
+	}
 
-	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", "NAME", "ENV", "EXECUTORTYPE", "MINSCALE", "MAXSCALE", "MINCPU", "MAXCPU", "MINMEMORY", "MAXMEMORY", "TARGETCPU", "SECRETS", "CONFIGMAPS")
-	for _, f := range fns {
-		secrets := f.Spec.Secrets
-		configMaps := f.Spec.ConfigMaps
-		var secretsList, configMapList []string
-		for _, secret := range secrets {
-			secretsList = append(secretsList, secret.Name)
+	listOpts.Limit = int64(input.Int(flagkey.FnListPageSize))
+	var fns []fv1.Function
+	for {
+		page, continueToken, err := opts.Client().V1().Function().ListPage(ns, listOpts)
+		if err != nil {
+			return errors.Wrap(err, "error listing functions")
 		}
-		for _, configMap := range configMaps {
-			configMapList = append(configMapList, configMap.Name)
+		fns = append(fns, page...)
+		if len(continueToken) == 0 {
+			break
 		}
+		listOpts.Continue = continueToken
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
 
-		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
-			f.ObjectMeta.Name, f.Spec.Environment.Name,
-			f.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType,
-			f.Spec.InvokeStrategy.ExecutionStrategy.MinScale,
-			f.Spec.InvokeStrategy.ExecutionStrategy.MaxScale,
-			f.Spec.Resources.Requests.Cpu().String(),
-			f.Spec.Resources.Limits.Cpu().String(),
-			f.Spec.Resources.Requests.Memory().String(),
-			f.Spec.Resources.Limits.Memory().String(),
-			f.Spec.InvokeStrategy.ExecutionStrategy.TargetCPUPercent,
-			strings.Join(secretsList, ","),
-			strings.Join(configMapList, ","))
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", "NAME", "ENV", "EXECUTORTYPE", "MINSCALE", "MAXSCALE", "MINCPU", "MAXCPU", "MINMEMORY", "MAXMEMORY", "TARGETCPU", "SECRETS", "CONFIGMAPS")
+	for i := range fns {
+		printFunction(w, &fns[i])
 	}
 	w.Flush()
 
 	return nil
 }
+
+// watch prints the existing functions, then the ones added, modified or
+// deleted, until the server ends the watch.
+func (opts *ListSubCommand) watch(ns string, listOpts *v1.ListOptions) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", "EVENT", "NAME", "ENV", "EXECUTORTYPE", "MINSCALE", "MAXSCALE", "MINCPU", "MAXCPU", "MINMEMORY", "MAXMEMORY", "TARGETCPU", "SECRETS", "CONFIGMAPS")
+	w.Flush()
+
+	err := opts.Client().V1().Function().Watch(ns, listOpts, func(event v1.WatchEvent) error {
+		var f fv1.Function
+		err := json.Unmarshal(event.Object, &f)
+		if err != nil {
+			return errors.Wrap(err, "error decoding function")
+		}
+		fmt.Fprintf(w, "%v\t", event.Type)
+		printFunction(w, &f)
+		return w.Flush()
+	})
+	return errors.Wrap(err, "error watching functions")
+}
+
+func printFunction(w io.Writer, f *fv1.Function) {
+	var secretsList, configMapList []string
+	for _, secret := range f.Spec.Secrets {
+		secretsList = append(secretsList, secret.Name)
+	}
+	for _, configMap := range f.Spec.ConfigMaps {
+		configMapList = append(configMapList, configMap.Name)
+	}
+
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+		f.ObjectMeta.Name, f.Spec.Environment.Name,
+		f.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType,
+		f.Spec.InvokeStrategy.ExecutionStrategy.MinScale,
+		f.Spec.InvokeStrategy.ExecutionStrategy.MaxScale,
+		f.Spec.Resources.Requests.Cpu().String(),
+		f.Spec.Resources.Limits.Cpu().String(),
+		f.Spec.Resources.Requests.Memory().String(),
+		f.Spec.Resources.Limits.Memory().String(),
+		f.Spec.InvokeStrategy.ExecutionStrategy.TargetCPUPercent,
+		strings.Join(secretsList, ","),
+		strings.Join(configMapList, ","))
+}
@@ -19,16 +19,22 @@ package function
 import (
 	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"syscall"
 	"time"
 
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
 	"github.com/fission/fission/pkg/fission-cli/cmd"
 	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
 	"github.com/fission/fission/pkg/fission-cli/logdb"
 	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
 )
 
 type LogSubCommand struct {
@@ -41,16 +47,8 @@ func Log(input cli.Input) error {
 
 func (opts *LogSubCommand) do(input cli.Input) error {
 	dbType := input.String(flagkey.FnLogDBType)
-	fnPod := input.String(flagkey.FnLogPod)
 	kubeContext := input.String(flagkey.KubeContext)
 
-	logReverseQuery := !input.Bool(flagkey.FnLogFollow) && input.Bool(flagkey.FnLogReverseQuery)
-
-	recordLimit := input.Int(flagkey.FnLogCount)
-	if recordLimit <= 0 {
-		recordLimit = 1000
-	}
-
 	f, err := opts.Client().V1().Function().Get(&metav1.ObjectMeta{
 		Name:      input.String(flagkey.FnName),
 		Namespace: input.String(flagkey.NamespaceFunction),
@@ -59,6 +57,92 @@ func (opts *LogSubCommand) do(input cli.Input) error {
 		return errors.Wrap(err, "error getting function")
 	}
 
+	switch dbType {
+	case logdb.KUBERNETES:
+	case logdb.SINK:
+		err = util.RequireServerFeature(opts.Client(), info.FeatureLogQuery)
+		if err != nil {
+			return err
+		}
+		return opts.logFromSink(input, f)
+	default:
+		// servers with log sinks query InfluxDB themselves, older ones
+		// only proxy queries to it
+		if dbType == logdb.INFLUXDB && util.RequireServerFeature(opts.Client(), info.FeatureLogQuery) == nil {
+			return opts.logFromSink(input, f)
+		}
+		return opts.logFromDB(input, f)
+	}
+
+	logOpts, err := getLogStreamOptions(input)
+	if err != nil {
+		return err
+	}
+
+	_, kubeClient, err := util.GetKubernetesClient(kubeContext)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if logOpts.follow {
+		// stop following on interrupt, once the streams are closed
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sigCh)
+		go func() {
+			select {
+			case <-sigCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	return streamLogs(ctx, kubeClient, f, getFunctionNamespace(), logOpts)
+}
+
+// getLogStreamOptions returns what logs to print, and how, from the flags.
+func getLogStreamOptions(input cli.Input) (logStreamOptions, error) {
+	logOpts := logStreamOptions{
+		pod:    input.String(flagkey.FnLogPod),
+		follow: input.Bool(flagkey.FnLogFollow),
+		since:  input.Duration(flagkey.FnLogSince),
+		tail:   -1,
+		output: input.String(flagkey.FnLogOutput),
+		detail: input.Bool(flagkey.FnLogDetail),
+	}
+	if input.IsSet(flagkey.FnLogTail) {
+		logOpts.tail = int64(input.Int(flagkey.FnLogTail))
+	}
+	if len(logOpts.output) > 0 && logOpts.output != logOutputJSON {
+		return logOpts, errors.Errorf("output format must be %v or empty, got %v", logOutputJSON, logOpts.output)
+	}
+	if filter := input.String(flagkey.FnLogFilter); len(filter) > 0 {
+		re, err := regexp.Compile(filter)
+		if err != nil {
+			return logOpts, errors.Wrap(err, "error parsing filter")
+		}
+		logOpts.filter = re
+	}
+	return logOpts, nil
+}
+
+// logFromDB prints the logs of the function from the log database, polling
+// it for new ones when following.
+func (opts *LogSubCommand) logFromDB(input cli.Input, f *fv1.Function) error {
+	dbType := input.String(flagkey.FnLogDBType)
+	fnPod := input.String(flagkey.FnLogPod)
+	kubeContext := input.String(flagkey.KubeContext)
+
+	logReverseQuery := !input.Bool(flagkey.FnLogFollow) && input.Bool(flagkey.FnLogReverseQuery)
+
+	recordLimit := input.Int(flagkey.FnLogCount)
+	if recordLimit <= 0 {
+		recordLimit = 1000
+	}
+
 	server, err := util.GetApplicationUrl("application=fission-api", kubeContext)
 	if err != nil {
 		return err
@@ -0,0 +1,164 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+	"github.com/fission/fission/pkg/logstore"
+)
+
+// sinkPollInterval is how often the log sink is queried for new logs when
+// following them.
+const sinkPollInterval = time.Second
+
+// logFromSink prints the logs of the function from the log sink, querying
+// it for new ones when following.
+func (opts *LogSubCommand) logFromSink(input cli.Input, f *fv1.Function) error {
+	logOpts, err := getLogStreamOptions(input)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	// the first query gets the most recent entries
+	query := &logstore.Query{
+		Pod:     logOpts.pod,
+		Limit:   input.Int(flagkey.FnLogCount),
+		Reverse: true,
+	}
+	if logOpts.since > 0 {
+		query.Since = now.Add(-logOpts.since)
+	}
+	if until := input.String(flagkey.FnLogUntil); len(until) > 0 {
+		if logOpts.follow {
+			return errors.New("logs can't be followed until a time")
+		}
+		query.Until, err = parseLogTime(until, now)
+		if err != nil {
+			return err
+		}
+	}
+	reverse := !logOpts.follow && input.Bool(flagkey.FnLogReverseQuery)
+
+	var last sinkCursor
+	for {
+		entries, err := opts.Client().V1().Function().Logs(&f.ObjectMeta, query)
+		if err != nil {
+			return errors.Wrap(err, "error querying function logs")
+		}
+		if query.Reverse && !reverse {
+			for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+				entries[i], entries[j] = entries[j], entries[i]
+			}
+		}
+		for _, e := range entries {
+			if logOpts.follow && !last.advance(&e) {
+				continue
+			}
+			line := sinkLogLine(&e)
+			if logOpts.filter != nil && !logOpts.filter.MatchString(line.Message) {
+				continue
+			}
+			fmt.Println(formatLogLine(line, logOpts.output, logOpts.detail))
+		}
+
+		if !logOpts.follow {
+			return nil
+		}
+		// the next queries get the entries since the last one, as other
+		// entries of the same time may not have been stored yet
+		query.Reverse = false
+		query.Limit = logstore.DefaultLimit
+		if !last.timestamp.IsZero() {
+			query.Since = last.timestamp
+		}
+		time.Sleep(sinkPollInterval)
+	}
+}
+
+// sinkCursor skips the entries of the log sink already printed, as queries
+// starting at the time of the last entry printed return it again.
+type sinkCursor struct {
+	timestamp time.Time
+	// printed are the entries printed at timestamp, without it.
+	printed map[logstore.Entry]bool
+}
+
+// advance returns whether e was not printed yet, and moves the cursor to
+// it if so. Entries are expected in time order.
+func (c *sinkCursor) advance(e *logstore.Entry) bool {
+	key := *e
+	key.Timestamp = time.Time{}
+	switch {
+	case e.Timestamp.Before(c.timestamp):
+		return false
+	case e.Timestamp.Equal(c.timestamp):
+		if c.printed[key] {
+			return false
+		}
+	default:
+		c.timestamp = e.Timestamp
+		c.printed = nil
+	}
+	if c.printed == nil {
+		c.printed = make(map[logstore.Entry]bool)
+	}
+	c.printed[key] = true
+	return true
+}
+
+// sinkLogLine returns the log line of an entry of the log sink.
+func sinkLogLine(e *logstore.Entry) logLine {
+	labels := map[string]string{}
+	for k, v := range map[string]string{
+		fv1.FUNCTION_NAME: e.Function,
+		fv1.FUNCTION_UID:  e.FunctionUID,
+		"stream":          e.Stream,
+	} {
+		if len(v) > 0 {
+			labels[k] = v
+		}
+	}
+	return logLine{
+		Timestamp: e.Timestamp,
+		Namespace: e.Namespace,
+		Pod:       e.Pod,
+		Container: e.Container,
+		Labels:    labels,
+		Message:   e.Message,
+	}
+}
+
+// parseLogTime parses an RFC 3339 time, or a duration relative to now.
+func parseLogTime(s string, now time.Time) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return time.Time{}, errors.Errorf("invalid time %q, expected an RFC 3339 time or a duration", s)
+	}
+	return now.Add(-d), nil
+}
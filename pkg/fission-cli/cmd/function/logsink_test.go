@@ -0,0 +1,57 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fission/fission/pkg/logstore"
+)
+
+func TestSinkCursor(t *testing.T) {
+	t1 := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	t2 := t1.Add(time.Millisecond)
+
+	var c sinkCursor
+	assert.True(t, c.advance(&logstore.Entry{Timestamp: t1, Message: "a"}))
+	assert.True(t, c.advance(&logstore.Entry{Timestamp: t1, Message: "b"}))
+	assert.False(t, c.advance(&logstore.Entry{Timestamp: t1, Message: "a"}))
+	// the same time in another location
+	assert.False(t, c.advance(&logstore.Entry{Timestamp: t1.Local(), Message: "b"}))
+	assert.True(t, c.advance(&logstore.Entry{Timestamp: t2, Message: "a"}))
+	assert.False(t, c.advance(&logstore.Entry{Timestamp: t1, Message: "c"}))
+}
+
+func TestParseLogTime(t *testing.T) {
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	parsed, err := parseLogTime("2021-03-04T04:00:00Z", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 3, 4, 4, 0, 0, 0, time.UTC), parsed)
+
+	parsed, err = parseLogTime("10m", now)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(-10*time.Minute), parsed)
+
+	_, err = parseLogTime("-10m", now)
+	assert.Error(t, err)
+	_, err = parseLogTime("yesterday", now)
+	assert.Error(t, err)
+}
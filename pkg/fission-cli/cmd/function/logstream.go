@@ -0,0 +1,325 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/fission-cli/console"
+)
+
+const (
+	// logOutputJSON prints a JSON object per log line.
+	logOutputJSON = "json"
+
+	// maxLogLineSize is the longest log line read, longer ones end the
+	// stream of the pod.
+	maxLogLineSize = 1024 * 1024
+)
+
+// logLine is a line of the logs of a function pod.
+type logLine struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Namespace string            `json:"namespace"`
+	Pod       string            `json:"pod"`
+	Container string            `json:"container"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Message   string            `json:"message"`
+}
+
+// logStreamOptions are what logs of function pods to print, and how.
+type logStreamOptions struct {
+	pod    string
+	follow bool
+	since  time.Duration
+	// tail is the number of most recent lines to print of each pod, all
+	// of them if negative.
+	tail   int64
+	filter *regexp.Regexp
+	output string
+	detail bool
+}
+
+// logStreamer prints the logs of the pods of a function merged, following
+// the pods added while it runs if asked to.
+type logStreamer struct {
+	kubeClient kubernetes.Interface
+	namespace  string
+	selector   string
+	opts       logStreamOptions
+	lines      chan logLine
+
+	wg        sync.WaitGroup
+	mutex     sync.Mutex
+	streaming map[string]bool
+}
+
+// streamLogs prints the logs of the pods of the function, from all of them
+// at once, until they end or, when following, ctx is done.
+func streamLogs(ctx context.Context, kubeClient kubernetes.Interface, fn *fv1.Function, functionNamespace string, opts logStreamOptions) error {
+	s := &logStreamer{
+		kubeClient: kubeClient,
+		namespace:  functionPodNamespace(fn, functionNamespace),
+		selector:   labels.Set{fv1.FUNCTION_UID: string(fn.ObjectMeta.UID)}.AsSelector().String(),
+		opts:       opts,
+		lines:      make(chan logLine),
+		streaming:  make(map[string]bool),
+	}
+
+	podList, err := kubeClient.CoreV1().Pods(s.namespace).List(metav1.ListOptions{LabelSelector: s.selector})
+	if err != nil {
+		return errors.Wrap(err, "error listing function pods")
+	}
+	for i := range podList.Items {
+		s.startPod(ctx, &podList.Items[i])
+	}
+	if !opts.follow && len(s.streaming) == 0 {
+		return errors.Errorf("function %v has no pods with logs, invoke it to specialize one", fn.ObjectMeta.Name)
+	}
+
+	if opts.follow {
+		s.wg.Add(1)
+		go s.watchPods(ctx, podList.ResourceVersion)
+	}
+	go func() {
+		s.wg.Wait()
+		close(s.lines)
+	}()
+
+	if opts.follow {
+		for line := range s.lines {
+			s.print(line)
+		}
+		return nil
+	}
+
+	// without following, the logs of all pods are there to print in order
+	var lines []logLine
+	for line := range s.lines {
+		lines = append(lines, line)
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Timestamp.Before(lines[j].Timestamp)
+	})
+	for _, line := range lines {
+		s.print(line)
+	}
+	return nil
+}
+
+// watchPods streams the logs of the function pods started after listing
+// them, until ctx is done.
+func (s *logStreamer) watchPods(ctx context.Context, resourceVersion string) {
+	defer s.wg.Done()
+	for {
+		w, err := s.kubeClient.CoreV1().Pods(s.namespace).Watch(metav1.ListOptions{
+			LabelSelector:   s.selector,
+			ResourceVersion: resourceVersion,
+		})
+		if err != nil {
+			console.Errorf("Error watching function pods: %v", err)
+			return
+		}
+		resourceVersion = s.handlePodEvents(ctx, w)
+		w.Stop()
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// handlePodEvents starts streaming the logs of the pods once they run, until
+// the watch ends, returning the last resource version seen.
+func (s *logStreamer) handlePodEvents(ctx context.Context, w watch.Interface) string {
+	var resourceVersion string
+	for {
+		select {
+		case <-ctx.Done():
+			return resourceVersion
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return resourceVersion
+			}
+			pod, ok := event.Object.(*apiv1.Pod)
+			if !ok {
+				continue
+			}
+			resourceVersion = pod.ObjectMeta.ResourceVersion
+			if event.Type == watch.Added || event.Type == watch.Modified {
+				s.startPod(ctx, pod)
+			}
+		}
+	}
+}
+
+// startPod streams the logs of the pod, if it has any and they are not
+// streamed yet.
+func (s *logStreamer) startPod(ctx context.Context, pod *apiv1.Pod) {
+	if len(s.opts.pod) > 0 && pod.ObjectMeta.Name != s.opts.pod {
+		return
+	}
+	// pending pods have no logs yet
+	if pod.Status.Phase == apiv1.PodPending || pod.Status.Phase == apiv1.PodUnknown {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.streaming[pod.ObjectMeta.Name] {
+		return
+	}
+	s.streaming[pod.ObjectMeta.Name] = true
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		err := s.streamPod(ctx, pod)
+		if err != nil && ctx.Err() == nil {
+			console.Errorf("Error streaming logs of pod %v: %v", pod.ObjectMeta.Name, err)
+		}
+	}()
+}
+
+// streamPod sends the lines of the logs of the function container of the
+// pod that pass the filter.
+func (s *logStreamer) streamPod(ctx context.Context, pod *apiv1.Pod) error {
+	container := functionContainerName(pod)
+	stream, err := s.kubeClient.CoreV1().Pods(pod.ObjectMeta.Namespace).
+		GetLogs(pod.ObjectMeta.Name, s.podLogOptions(container)).
+		Stream()
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	// the stream doesn't end with ctx, closing it unblocks the reads
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			stream.Close()
+		case <-done:
+		}
+	}()
+
+	return s.readLines(ctx, stream, func(timestamp time.Time, message string) logLine {
+		return logLine{
+			Timestamp: timestamp,
+			Namespace: pod.ObjectMeta.Namespace,
+			Pod:       pod.ObjectMeta.Name,
+			Container: container,
+			Labels:    pod.ObjectMeta.Labels,
+			Message:   message,
+		}
+	})
+}
+
+// readLines sends the lines read from r that pass the filter, made with
+// newLine, until r ends or ctx is done.
+func (s *logStreamer) readLines(ctx context.Context, r io.Reader, newLine func(time.Time, string) logLine) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLogLineSize)
+	for scanner.Scan() {
+		timestamp, message := parseLogLine(scanner.Text())
+		if s.opts.filter != nil && !s.opts.filter.MatchString(message) {
+			continue
+		}
+		select {
+		case s.lines <- newLine(timestamp, message):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// podLogOptions returns the options to get the logs of the container with.
+func (s *logStreamer) podLogOptions(container string) *apiv1.PodLogOptions {
+	opts := &apiv1.PodLogOptions{
+		Container:  container,
+		Follow:     s.opts.follow,
+		Timestamps: true,
+	}
+	if s.opts.since > 0 {
+		seconds := int64(s.opts.since.Seconds())
+		if seconds < 1 {
+			seconds = 1
+		}
+		opts.SinceSeconds = &seconds
+	}
+	if s.opts.tail >= 0 {
+		tail := s.opts.tail
+		opts.TailLines = &tail
+	}
+	return opts
+}
+
+// parseLogLine splits a log line of Kubernetes into its timestamp and
+// message. Lines without timestamp are returned as is with a zero one.
+func parseLogLine(line string) (time.Time, string) {
+	parts := strings.SplitN(line, " ", 2)
+	timestamp, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, line
+	}
+	if len(parts) < 2 {
+		return timestamp, ""
+	}
+	return timestamp, parts[1]
+}
+
+// print prints a log line in the output format asked for.
+func (s *logStreamer) print(line logLine) {
+	fmt.Println(formatLogLine(line, s.opts.output, s.opts.detail))
+}
+
+// formatLogLine returns the log line in the output format, with the pod
+// it comes from.
+func formatLogLine(line logLine, output string, detail bool) string {
+	switch {
+	case output == logOutputJSON:
+		b, err := json.Marshal(line)
+		if err != nil {
+			return line.Message
+		}
+		return string(b)
+	case detail:
+		return fmt.Sprintf("Timestamp: %s\nNamespace: %s\nPod: %s\nContainer: %s\nLabels: %s\nLog: %s\n---",
+			line.Timestamp.Format(time.RFC3339Nano), line.Namespace, line.Pod, line.Container,
+			labels.Set(line.Labels).String(), line.Message)
+	default:
+		return fmt.Sprintf("[%s] %s: %s", line.Timestamp.Format(time.RFC3339Nano), line.Pod, line.Message)
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLogLine(t *testing.T) {
+	timestamp, message := parseLogLine("2021-03-04T05:06:07.123456789Z hello world")
+	assert.Equal(t, time.Date(2021, 3, 4, 5, 6, 7, 123456789, time.UTC), timestamp)
+	assert.Equal(t, "hello world", message)
+
+	timestamp, message = parseLogLine("no timestamp")
+	assert.True(t, timestamp.IsZero())
+	assert.Equal(t, "no timestamp", message)
+
+	_, message = parseLogLine("2021-03-04T05:06:07Z")
+	assert.Equal(t, "", message)
+}
+
+func TestReadLines(t *testing.T) {
+	s := &logStreamer{
+		opts:  logStreamOptions{filter: regexp.MustCompile("^error")},
+		lines: make(chan logLine, 10),
+	}
+	logs := "2021-03-04T05:06:07Z error: one\n2021-03-04T05:06:08Z info: two\n2021-03-04T05:06:09Z error: three\n"
+	err := s.readLines(context.Background(), strings.NewReader(logs), func(timestamp time.Time, message string) logLine {
+		return logLine{Timestamp: timestamp, Pod: "pod", Message: message}
+	})
+	assert.NoError(t, err)
+	close(s.lines)
+
+	var messages []string
+	for line := range s.lines {
+		messages = append(messages, line.Message)
+	}
+	assert.Equal(t, []string{"error: one", "error: three"}, messages)
+}
+
+func TestFormatLogLine(t *testing.T) {
+	line := logLine{
+		Timestamp: time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		Namespace: "fission-function",
+		Pod:       "nodejs-abc",
+		Container: "nodejs",
+		Labels:    map[string]string{"functionName": "hello"},
+		Message:   "hi",
+	}
+	assert.Equal(t, "[2021-03-04T05:06:07Z] nodejs-abc: hi", formatLogLine(line, "", false))
+	assert.Equal(t, `{"timestamp":"2021-03-04T05:06:07Z","namespace":"fission-function","pod":"nodejs-abc",`+
+		`"container":"nodejs","labels":{"functionName":"hello"},"message":"hi"}`, formatLogLine(line, logOutputJSON, false))
+	assert.Contains(t, formatLogLine(line, "", true), "Labels: functionName=hello\n")
+}
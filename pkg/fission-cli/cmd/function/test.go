@@ -47,6 +47,25 @@ func Test(input cli.Input) error {
 }
 
 func (opts *TestSubCommand) do(input cli.Input) error {
+	var ctx context.Context
+
+	testTimeout := input.Duration(flagkey.FnTestTimeout)
+	if testTimeout <= 0*time.Second {
+		ctx = context.Background()
+	} else {
+		var closeCtx context.CancelFunc
+		ctx, closeCtx = context.WithTimeout(context.Background(), input.Duration(flagkey.FnTestTimeout))
+		defer closeCtx()
+	}
+
+	if input.Bool(flagkey.FnTestLocal) {
+		return opts.testLocal(ctx, input)
+	}
+
+	if len(input.String(flagkey.FnName)) == 0 {
+		return errors.Errorf("need --%v, the name of the function to test", flagkey.FnName)
+	}
+
 	m := &metav1.ObjectMeta{
 		Name:      input.String(flagkey.FnName),
 		Namespace: input.String(flagkey.NamespaceFunction),
@@ -76,36 +95,7 @@ func (opts *TestSubCommand) do(input cli.Input) error {
 
 	console.Verbose(2, "Function test url: %v", functionUrl.String())
 
-	queryParams := input.StringSlice(flagkey.FnTestQuery)
-	if len(queryParams) > 0 {
-		query := url.Values{}
-		for _, q := range queryParams {
-			queryParts := strings.SplitN(q, "=", 2)
-			var key, value string
-			if len(queryParts) == 0 {
-				continue
-			}
-			if len(queryParts) > 0 {
-				key = queryParts[0]
-			}
-			if len(queryParts) > 1 {
-				value = queryParts[1]
-			}
-			query.Set(key, value)
-		}
-		functionUrl.RawQuery = query.Encode()
-	}
-
-	var ctx context.Context
-
-	testTimeout := input.Duration(flagkey.FnTestTimeout)
-	if testTimeout <= 0*time.Second {
-		ctx = context.Background()
-	} else {
-		var closeCtx context.CancelFunc
-		ctx, closeCtx = context.WithTimeout(context.Background(), input.Duration(flagkey.FnTestTimeout))
-		defer closeCtx()
-	}
+	functionUrl.RawQuery = getTestQuery(input.StringSlice(flagkey.FnTestQuery))
 
 	resp, err := doHTTPRequest(ctx, functionUrl.String(),
 		input.StringSlice(flagkey.FnTestHeader),
@@ -140,6 +130,30 @@ func (opts *TestSubCommand) do(input cli.Input) error {
 	return errors.New("error getting function response")
 }
 
+// getTestQuery returns the encoded query of the test request, from the
+// key=value parameters given.
+func getTestQuery(queryParams []string) string {
+	if len(queryParams) == 0 {
+		return ""
+	}
+	query := url.Values{}
+	for _, q := range queryParams {
+		queryParts := strings.SplitN(q, "=", 2)
+		var key, value string
+		if len(queryParts) == 0 {
+			continue
+		}
+		if len(queryParts) > 0 {
+			key = queryParts[0]
+		}
+		if len(queryParts) > 1 {
+			value = queryParts[1]
+		}
+		query.Set(key, value)
+	}
+	return query.Encode()
+}
+
 func doHTTPRequest(ctx context.Context, url string, headers []string, method, body string) (*http.Response, error) {
 	method, err := httptrigger.GetMethod(method)
 	if err != nil {
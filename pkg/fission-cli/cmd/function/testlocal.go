@@ -0,0 +1,317 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fission/fission/pkg/fetcher"
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/console"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+)
+
+const (
+	// localCodePath is where the code of the function is mounted in the
+	// environment container, the path fetcher would store it at.
+	localCodePath = "/userfunc/user"
+
+	// localEnvPort is the port environment containers serve on.
+	localEnvPort = "8888"
+)
+
+// containerRuntimes are the tools to run the environment container with, in
+// order of preference.
+var containerRuntimes = []string{"docker", "podman"}
+
+// localTest is a function run in an environment container on this machine.
+type localTest struct {
+	runtime     string
+	image       string
+	code        string
+	entrypoint  string
+	envVersion  int
+	containerID string
+	address     string
+}
+
+// testLocal runs the code of a function in a local container of the runtime
+// image of its environment, and sends the test request to it, without the
+// cluster.
+func (opts *TestSubCommand) testLocal(ctx context.Context, input cli.Input) error {
+	t, err := opts.getLocalTest(input)
+	if err != nil {
+		return err
+	}
+
+	err = t.start()
+	if t.containerID != "" {
+		defer t.remove()
+	}
+	if err != nil {
+		return err
+	}
+
+	err = t.specialize(ctx)
+	if err != nil {
+		t.printLogs()
+		return err
+	}
+
+	functionUrl := fmt.Sprintf("http://%s/", t.address)
+	if query := getTestQuery(input.StringSlice(flagkey.FnTestQuery)); query != "" {
+		functionUrl += "?" + query
+	}
+	console.Verbose(2, "Function test url: %v", functionUrl)
+
+	resp, err := doHTTPRequest(ctx, functionUrl,
+		input.StringSlice(flagkey.FnTestHeader),
+		input.String(flagkey.HtMethod),
+		input.String(flagkey.FnTestBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "error reading response from function")
+	}
+
+	if resp.StatusCode < 400 {
+		os.Stdout.Write(body)
+		return nil
+	}
+
+	console.Errorf("Error calling function: %d; Please try again or fix the error: %s\n", resp.StatusCode, string(body))
+	t.printLogs()
+	return errors.New("error getting function response")
+}
+
+// getLocalTest gets the image, code and interface version of the function to
+// test from the flags, or the image and version from the environment given.
+func (opts *TestSubCommand) getLocalTest(input cli.Input) (*localTest, error) {
+	code := input.String(flagkey.PkgCode)
+	if len(code) == 0 {
+		return nil, errors.Errorf("need --%v, the local file or directory of the function code", flagkey.PkgCode)
+	}
+	code, err := filepath.Abs(code)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting absolute path of %v", code)
+	}
+	if _, err = os.Stat(code); err != nil {
+		return nil, errors.Wrap(err, "error reading function code")
+	}
+
+	image := input.String(flagkey.EnvImage)
+	envVersion := input.Int(flagkey.FnTestEnvVersion)
+	if len(image) == 0 {
+		envName := input.String(flagkey.FnEnvironmentName)
+		if len(envName) == 0 {
+			return nil, errors.Errorf("need --%v, the runtime image to test the function in, or --%v to get it from",
+				flagkey.EnvImage, flagkey.FnEnvironmentName)
+		}
+		env, err := opts.Client().V1().Environment().Get(&metav1.ObjectMeta{
+			Name:      envName,
+			Namespace: input.String(flagkey.NamespaceEnvironment),
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "error getting environment")
+		}
+		image = env.Spec.Runtime.Image
+		if !input.IsSet(flagkey.FnTestEnvVersion) {
+			envVersion = env.Spec.Version
+		}
+	}
+
+	entrypoint := input.String(flagkey.FnEntrypoint)
+	if envVersion <= 0 {
+		envVersion = 1
+		if len(entrypoint) > 0 {
+			envVersion = 2
+		}
+	}
+	if envVersion < 2 && len(entrypoint) > 0 {
+		console.Warn("The entrypoint is ignored by environments of version 1")
+	}
+
+	runtime, err := getContainerRuntime()
+	if err != nil {
+		return nil, err
+	}
+
+	return &localTest{
+		runtime:    runtime,
+		image:      image,
+		code:       code,
+		entrypoint: entrypoint,
+		envVersion: envVersion,
+	}, nil
+}
+
+// getContainerRuntime returns the first container runtime found in PATH.
+func getContainerRuntime() (string, error) {
+	for _, r := range containerRuntimes {
+		if path, err := exec.LookPath(r); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.Errorf("need one of %v to test functions locally", strings.Join(containerRuntimes, ", "))
+}
+
+// runArgs returns the arguments to start the environment container with, its
+// port published on a free port of the loopback interface.
+func (t *localTest) runArgs() []string {
+	return []string{
+		"run", "--detach",
+		"--publish", "127.0.0.1::" + localEnvPort,
+		"--volume", t.code + ":" + localCodePath + ":ro",
+		t.image,
+	}
+}
+
+// start pulls the image if need be and starts the environment container.
+func (t *localTest) start() error {
+	console.Verbose(2, "Starting %v with %v", t.image, t.code)
+	out, err := t.run(t.runArgs()...)
+	if err != nil {
+		return errors.Wrapf(err, "error starting container of image %v", t.image)
+	}
+	// podman prints pull progress before the ID on stdout
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	t.containerID = strings.TrimSpace(lines[len(lines)-1])
+
+	out, err = t.run("port", t.containerID, localEnvPort)
+	if err != nil {
+		return errors.Wrap(err, "error getting port of container")
+	}
+	t.address, err = parsePort(out)
+	return err
+}
+
+// parsePort returns the address of the loopback interface in the output of
+// the port command of container runtimes, one host:port per line.
+func parsePort(out string) (string, error) {
+	for _, line := range strings.Split(out, "\n") {
+		host, port, err := net.SplitHostPort(strings.TrimSpace(line))
+		if err != nil {
+			continue
+		}
+		if host == "127.0.0.1" || host == "0.0.0.0" {
+			return net.JoinHostPort("127.0.0.1", port), nil
+		}
+	}
+	return "", errors.Errorf("no local port of container in %q", out)
+}
+
+// specializeRequest returns the path and body of the specialize request of
+// the function, like fetcher sends them.
+func (t *localTest) specializeRequest() (string, []byte, error) {
+	if t.envVersion < 2 {
+		return "/specialize", nil, nil
+	}
+	body, err := json.Marshal(fetcher.FunctionLoadRequest{
+		FilePath:     localCodePath,
+		FunctionName: t.entrypoint,
+		URL:          "/",
+		FunctionMetadata: &metav1.ObjectMeta{
+			Name: "local",
+		},
+		EnvVersion: t.envVersion,
+	})
+	return "/v2/specialize", body, err
+}
+
+// specialize loads the function in the environment container, once the
+// container accepts connections.
+func (t *localTest) specialize(ctx context.Context) error {
+	path, body, err := t.specializeRequest()
+	if err != nil {
+		return errors.Wrap(err, "error encoding specialize request")
+	}
+	specializeUrl := fmt.Sprintf("http://%s%s", t.address, path)
+
+	for {
+		req, err := http.NewRequest(http.MethodPost, specializeUrl, bytes.NewReader(body))
+		if err != nil {
+			return errors.Wrap(err, "error creating specialize request")
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+		if err == nil {
+			respBody, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode < 300 {
+				return nil
+			}
+			return errors.Errorf("error specializing function: %d %s", resp.StatusCode, string(respBody))
+		}
+
+		// the published port accepts connections before the server in
+		// the container does, and closes them.
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(err, "error specializing function, environment container not ready")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// printLogs prints the logs of the environment container.
+func (t *localTest) printLogs() {
+	// functions log to both stdout and stderr
+	out, err := exec.Command(t.runtime, "logs", t.containerID).CombinedOutput()
+	if err != nil {
+		console.Errorf("Error getting logs of container: %v", err)
+		return
+	}
+	console.Info(string(out))
+}
+
+// remove removes the environment container.
+func (t *localTest) remove() {
+	_, err := t.run("rm", "--force", t.containerID)
+	if err != nil {
+		console.Warn(fmt.Sprintf("Error removing container %v: %v", t.containerID, err))
+	}
+}
+
+func (t *localTest) run(args ...string) (string, error) {
+	cmd := exec.Command(t.runtime, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return string(out), errors.Wrap(err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fission/fission/pkg/fetcher"
+)
+
+func TestParsePort(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		out     string
+		address string
+		err     bool
+	}{
+		{name: "docker", out: "127.0.0.1:49153\n", address: "127.0.0.1:49153"},
+		{name: "all interfaces", out: "0.0.0.0:49153\n:::49153\n", address: "127.0.0.1:49153"},
+		{name: "ipv6 only", out: "[::1]:49153\n", err: true},
+		{name: "none", out: "", err: true},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			address, err := parsePort(c.out)
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.address, address)
+		})
+	}
+}
+
+func TestSpecializeRequest(t *testing.T) {
+	lt := &localTest{envVersion: 1, entrypoint: "main"}
+	path, body, err := lt.specializeRequest()
+	assert.NoError(t, err)
+	assert.Equal(t, "/specialize", path)
+	assert.Empty(t, body)
+
+	lt.envVersion = 2
+	path, body, err = lt.specializeRequest()
+	assert.NoError(t, err)
+	assert.Equal(t, "/v2/specialize", path)
+
+	req := fetcher.FunctionLoadRequest{}
+	assert.NoError(t, json.Unmarshal(body, &req))
+	assert.Equal(t, localCodePath, req.FilePath)
+	assert.Equal(t, "main", req.FunctionName)
+	assert.Equal(t, 2, req.EnvVersion)
+}
+
+func TestGetTestQuery(t *testing.T) {
+	assert.Equal(t, "", getTestQuery(nil))
+	assert.Equal(t, "a=1&b=", getTestQuery([]string{"a=1", "b"}))
+}
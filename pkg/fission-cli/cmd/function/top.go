@@ -0,0 +1,134 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	"github.com/fission/fission/pkg/fission-cli/console"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
+)
+
+// usageSortKeys are the counters functions can be sorted by.
+var usageSortKeys = map[string]func(u *fv1.ResourceUsage) int64{
+	"cpu":         func(u *fv1.ResourceUsage) int64 { return u.CPUMillicoreSeconds },
+	"memory":      func(u *fv1.ResourceUsage) int64 { return u.MemoryMegabyteSeconds },
+	"invocations": func(u *fv1.ResourceUsage) int64 { return u.Invocations },
+	"egress":      func(u *fv1.ResourceUsage) int64 { return u.EgressBytes },
+}
+
+type TopSubCommand struct {
+	cmd.CommandActioner
+}
+
+func Top(input cli.Input) error {
+	return (&TopSubCommand{}).do(input)
+}
+
+func (opts *TopSubCommand) do(input cli.Input) error {
+	err := util.RequireServerFeature(opts.Client(), info.FeatureFnUsage)
+	if err != nil {
+		return err
+	}
+
+	sortKey, ok := usageSortKeys[input.String(flagkey.FnTopSort)]
+	if !ok {
+		return errors.Errorf("invalid sort %q, must be one of cpu, memory, invocations or egress", input.String(flagkey.FnTopSort))
+	}
+	output := input.String(flagkey.FnTopOutput)
+	if len(output) > 0 && output != "csv" {
+		return errors.Errorf("invalid output format %q, must be empty or csv", output)
+	}
+
+	ns := metav1.NamespaceAll
+	if input.IsSet(flagkey.NamespaceFunction) {
+		ns = input.String(flagkey.NamespaceFunction)
+	}
+	usages, err := opts.Client().V1().Function().Usages(ns)
+	if err != nil {
+		return errors.Wrap(err, "error listing function usage")
+	}
+
+	sort.SliceStable(usages, func(i, j int) bool {
+		return sortKey(&usages[i].Usage) > sortKey(&usages[j].Usage)
+	})
+
+	if output == "csv" {
+		return writeUsagesCSV(usages)
+	}
+
+	if len(usages) == 0 {
+		console.Info("No function usage metered yet")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", "NAME", "NAMESPACE", "CPU-SECONDS", "MEMORY-GB-SECONDS", "INVOCATIONS", "EGRESS", "SINCE")
+	for _, fu := range usages {
+		u := &fu.Usage
+		fmt.Fprintf(w, "%v\t%v\t%.1f\t%.1f\t%v\t%v\t%v\n", fu.ObjectMeta.Name, fu.ObjectMeta.Namespace,
+			float64(u.CPUMillicoreSeconds)/1000, float64(u.MemoryMegabyteSeconds)/1000, u.Invocations,
+			humanize.Bytes(uint64(u.EgressBytes)), u.Since.Local().Format(time.RFC3339))
+	}
+	w.Flush()
+
+	return nil
+}
+
+// writeUsagesCSV writes the raw counters of usages as CSV, for them to be
+// exported to billing tools.
+func writeUsagesCSV(usages []fv1.FunctionUsage) error {
+	w := csv.NewWriter(os.Stdout)
+	err := w.Write([]string{"namespace", "name", "cpu_millicore_seconds", "memory_megabyte_seconds",
+		"invocations", "egress_bytes", "since", "last_updated"})
+	if err != nil {
+		return err
+	}
+	for _, fu := range usages {
+		u := &fu.Usage
+		err = w.Write([]string{
+			fu.ObjectMeta.Namespace,
+			fu.ObjectMeta.Name,
+			strconv.FormatInt(u.CPUMillicoreSeconds, 10),
+			strconv.FormatInt(u.MemoryMegabyteSeconds, 10),
+			strconv.FormatInt(u.Invocations, 10),
+			strconv.FormatInt(u.EgressBytes, 10),
+			u.Since.UTC().Format(time.RFC3339),
+			u.LastUpdated.UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
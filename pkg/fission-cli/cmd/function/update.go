@@ -30,6 +30,7 @@ import (
 	"github.com/fission/fission/pkg/fission-cli/console"
 	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
 	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
 )
 
 type UpdateSubCommand struct {
@@ -163,6 +164,34 @@ func (opts *UpdateSubCommand) complete(input cli.Input) error {
 		function.Spec.RequestsPerPod = input.Int(flagkey.FnRequestsPerPod)
 	}
 
+	if input.IsSet(flagkey.FnCoLocateWith) {
+		function.Spec.CoLocateWith = input.StringSlice(flagkey.FnCoLocateWith)
+		if len(function.Spec.CoLocateWith) == 1 && function.Spec.CoLocateWith[0] == "-" {
+			function.Spec.CoLocateWith = nil
+		}
+	}
+
+	if input.IsSet(flagkey.FnPriority) {
+		priority := fv1.InvocationPriority(input.String(flagkey.FnPriority))
+		err := priority.Validate()
+		if err != nil {
+			return errors.Wrap(err, "error parsing priority")
+		}
+		function.Spec.Priority = priority
+	}
+
+	if input.IsSet(flagkey.FnQuota) {
+		quotas := input.StringSlice(flagkey.FnQuota)
+		if len(quotas) == 1 && quotas[0] == "-" {
+			function.Spec.InvocationQuotas = nil
+		} else {
+			function.Spec.InvocationQuotas, err = getInvocationQuotas(input)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	if len(pkgName) == 0 {
 		pkgName = function.Spec.Package.PackageRef.Name
 	}
@@ -180,6 +209,24 @@ func (opts *UpdateSubCommand) complete(input cli.Input) error {
 
 	function.Spec.Resources = *resReqs
 
+	if function.Spec.Container != nil {
+		if len(pkgName) > 0 || len(envName) > 0 || len(entrypoint) > 0 {
+			return errors.Errorf("function %v runs an image, --%v, --%v and --%v can't be used with it",
+				fnName, flagkey.FnPackageName, flagkey.FnEnvironmentName, flagkey.FnEntrypoint)
+		}
+		if function.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType != fv1.ExecutorTypeNewdeploy {
+			return errors.Errorf("functions running an image need \"--%v %v\"", flagkey.FnExecutorType, fv1.ExecutorTypeNewdeploy)
+		}
+		// the environment namespace flag has a default
+		function.Spec.Environment = fv1.EnvironmentReference{}
+		function.Spec.Container = getContainer(input, function.Spec.Container)
+		opts.function = function
+		return nil
+	}
+	if input.IsSet(flagkey.FnImage) {
+		return errors.Errorf("function %v runs an environment, it can't run an image", fnName)
+	}
+
 	pkg, err := opts.Client().V1().Package().Get(&metav1.ObjectMeta{
 		Namespace: fnNamespace,
 		Name:      pkgName,
@@ -244,6 +291,8 @@ func (opts *UpdateSubCommand) complete(input cli.Input) error {
 }
 
 func (opts *UpdateSubCommand) run(input cli.Input) error {
+	util.WarnUnsupportedFeatures(opts.Client(), "function", opts.function.ObjectMeta.Name, info.FunctionFeatures(&opts.function.Spec))
+
 	_, err := opts.Client().V1().Function().Update(opts.function)
 	if err != nil {
 		return errors.Wrap(err, "error updating function")
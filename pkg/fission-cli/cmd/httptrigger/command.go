@@ -32,8 +32,14 @@ func Commands() *cobra.Command {
 	wrapper.SetFlags(createCmd, flag.FlagSet{
 		Required: []flag.Flag{flag.HtUrl, flag.HtFnName},
 		Optional: []flag.Flag{flag.HtName, flag.HtMethod, flag.HtIngress,
-			flag.HtIngressRule, flag.HtIngressAnnotation, flag.HtIngressTLS,
-			flag.HtFnWeight, flag.HtHost, flag.NamespaceFunction, flag.SpecSave, flag.SpecDry},
+			flag.HtIngressRule, flag.HtIngressAnnotation, flag.HtIngressTLS, flag.HtIngressType, flag.HtIngressGateway,
+			flag.HtFnWeight, flag.HtHost, flag.HtJWKSURL, flag.HtJWTIssuer, flag.HtJWTAudience,
+			flag.HtJWTClaim, flag.HtJWTClaimHeader, flag.HtSessionAffinity, flag.HtSessionName, flag.HtSessionTTL,
+			flag.HtOpenAPISpec, flag.HtOpenAPIPath, flag.HtOpenAPIOperation, flag.HtFlagSource, flag.HtFlagSourceURL,
+			flag.HtFlagKeyHeader, flag.HtFlag, flag.HtTimeout, flag.HtRetries, flag.HtRetryBackoff, flag.HtRetryOn,
+			flag.HtBreakerThreshold, flag.HtBreakerDuration, flag.HtPriority, flag.HtExperimentName, flag.HtExperimentAssignment,
+			flag.HtExperimentKey, flag.HtExperimentExposure, flag.HtExperimentVariant, flag.HtLongPoll, flag.HtLongPollKeepAlive, flag.HtCloudEvents,
+			flag.NamespaceFunction, flag.SpecSave, flag.SpecDry},
 	})
 
 	getCmd := &cobra.Command{
@@ -56,7 +62,13 @@ func Commands() *cobra.Command {
 		Required: []flag.Flag{flag.HtName},
 		Optional: []flag.Flag{flag.HtUrl, flag.HtFnName,
 			flag.HtMethod, flag.HtIngress, flag.HtIngressRule, flag.HtIngressAnnotation,
-			flag.HtIngressTLS, flag.HtFnWeight, flag.HtHost, flag.NamespaceTrigger},
+			flag.HtIngressTLS, flag.HtIngressType, flag.HtIngressGateway, flag.HtFnWeight, flag.HtHost, flag.HtJWKSURL, flag.HtJWTIssuer,
+			flag.HtJWTAudience, flag.HtJWTClaim, flag.HtJWTClaimHeader, flag.HtSessionAffinity,
+			flag.HtSessionName, flag.HtSessionTTL, flag.HtOpenAPISpec, flag.HtOpenAPIPath,
+			flag.HtOpenAPIOperation, flag.HtFlagSource, flag.HtFlagSourceURL, flag.HtFlagKeyHeader, flag.HtFlag,
+			flag.HtTimeout, flag.HtRetries, flag.HtRetryBackoff, flag.HtRetryOn, flag.HtBreakerThreshold,
+			flag.HtBreakerDuration, flag.HtPriority, flag.HtExperimentName, flag.HtExperimentAssignment, flag.HtExperimentKey,
+			flag.HtExperimentExposure, flag.HtExperimentVariant, flag.HtLongPoll, flag.HtLongPollKeepAlive, flag.HtCloudEvents, flag.NamespaceTrigger},
 	})
 
 	deleteCmd := &cobra.Command{
@@ -33,6 +33,7 @@ import (
 	"github.com/fission/fission/pkg/fission-cli/console"
 	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
 	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
 )
 
 type CreateSubCommand struct {
@@ -134,9 +135,117 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 	if err != nil {
 		return errors.Wrap(err, "error parsing ingress configuration")
 	}
+	ingressConfig.Type = fv1.IngressType(input.String(flagkey.HtIngressType))
+	ingressConfig.Gateway = input.String(flagkey.HtIngressGateway)
+	err = ingressConfig.Validate()
+	if err != nil {
+		return errors.Wrap(err, "error parsing ingress configuration")
+	}
 
 	host := input.String(flagkey.HtHost)
 
+	var auth *fv1.HTTPTriggerAuthentication
+	if input.IsSet(flagkey.HtJWKSURL) || input.IsSet(flagkey.HtJWTIssuer) || input.IsSet(flagkey.HtJWTAudience) ||
+		input.IsSet(flagkey.HtJWTClaim) || input.IsSet(flagkey.HtJWTClaimHeader) {
+		auth, err = GetAuthentication(
+			input.String(flagkey.HtJWKSURL), input.String(flagkey.HtJWTIssuer), input.StringSlice(flagkey.HtJWTAudience),
+			input.StringSlice(flagkey.HtJWTClaim), input.StringSlice(flagkey.HtJWTClaimHeader), nil)
+		if err != nil {
+			return errors.Wrap(err, "error parsing authentication configuration")
+		}
+	}
+
+	var affinity *fv1.SessionAffinity
+	if input.IsSet(flagkey.HtSessionAffinity) || input.IsSet(flagkey.HtSessionName) || input.IsSet(flagkey.HtSessionTTL) {
+		affinity, err = GetSessionAffinity(input.String(flagkey.HtSessionAffinity),
+			input.String(flagkey.HtSessionName), input.Int(flagkey.HtSessionTTL), nil)
+		if err != nil {
+			return errors.Wrap(err, "error parsing session affinity configuration")
+		}
+	}
+
+	var requestValidation *fv1.RequestValidation
+	if input.IsSet(flagkey.HtOpenAPISpec) || input.IsSet(flagkey.HtOpenAPIPath) || input.IsSet(flagkey.HtOpenAPIOperation) {
+		requestValidation, err = GetRequestValidation(input.String(flagkey.HtOpenAPISpec),
+			input.String(flagkey.HtOpenAPIPath), input.String(flagkey.HtOpenAPIOperation), nil)
+		if err != nil {
+			return errors.Wrap(err, "error parsing request validation configuration")
+		}
+	}
+
+	var featureFlags *fv1.FeatureFlagSource
+	if input.IsSet(flagkey.HtFlagSource) || input.IsSet(flagkey.HtFlagSourceURL) ||
+		input.IsSet(flagkey.HtFlagKeyHeader) || input.IsSet(flagkey.HtFlag) {
+		featureFlags, err = GetFeatureFlagSource(input.String(flagkey.HtFlagSource), input.String(flagkey.HtFlagSourceURL),
+			input.String(flagkey.HtFlagKeyHeader), input.StringSlice(flagkey.HtFlag), nil)
+		if err != nil {
+			return errors.Wrap(err, "error parsing feature flag configuration")
+		}
+	}
+
+	var retry *fv1.HTTPTriggerRetryPolicy
+	if input.IsSet(flagkey.HtRetries) || input.IsSet(flagkey.HtRetryBackoff) || input.IsSet(flagkey.HtRetryOn) {
+		retries := -1
+		if input.IsSet(flagkey.HtRetries) {
+			retries = input.Int(flagkey.HtRetries)
+		}
+		retry, err = GetRetryPolicy(retries, input.Int(flagkey.HtRetryBackoff), input.IntSlice(flagkey.HtRetryOn), nil)
+		if err != nil {
+			return errors.Wrap(err, "error parsing retry policy")
+		}
+	}
+
+	var breaker *fv1.CircuitBreakerPolicy
+	if input.IsSet(flagkey.HtBreakerThreshold) || input.IsSet(flagkey.HtBreakerDuration) {
+		breaker, err = GetCircuitBreakerPolicy(input.Int(flagkey.HtBreakerThreshold), input.Int(flagkey.HtBreakerDuration), nil)
+		if err != nil {
+			return errors.Wrap(err, "error parsing circuit breaker policy")
+		}
+	}
+
+	var experiment *fv1.Experiment
+	if input.IsSet(flagkey.HtExperimentAssignment) || input.IsSet(flagkey.HtExperimentVariant) {
+		var exposure *int
+		if input.IsSet(flagkey.HtExperimentExposure) {
+			e := input.Int(flagkey.HtExperimentExposure)
+			exposure = &e
+		}
+		experiment, err = GetExperiment(input.String(flagkey.HtExperimentAssignment), input.String(flagkey.HtExperimentKey),
+			input.String(flagkey.HtExperimentName), exposure, input.StringSlice(flagkey.HtExperimentVariant), nil)
+		if err != nil {
+			return errors.Wrap(err, "error parsing experiment")
+		}
+	}
+
+	var longPoll *fv1.LongPollPolicy
+	if input.IsSet(flagkey.HtLongPoll) || input.IsSet(flagkey.HtLongPollKeepAlive) {
+		longPoll, err = GetLongPollPolicy(input.Int(flagkey.HtLongPoll), input.Int(flagkey.HtLongPollKeepAlive), nil)
+		if err != nil {
+			return errors.Wrap(err, "error parsing long poll policy")
+		}
+	}
+
+	timeout := input.Int(flagkey.HtTimeout)
+	if timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+
+	priority := fv1.InvocationPriority(input.String(flagkey.HtPriority))
+	if len(priority) > 0 {
+		err = priority.Validate()
+		if err != nil {
+			return errors.Wrap(err, "error parsing priority")
+		}
+	}
+
+	cloudEvents := fv1.CloudEventsMode(input.String(flagkey.HtCloudEvents))
+	if len(cloudEvents) > 0 {
+		err = cloudEvents.Validate()
+		if err != nil {
+			return errors.Wrap(err, "error parsing CloudEvents mode")
+		}
+	}
+
 	opts.trigger = &fv1.HTTPTrigger{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      triggerName,
@@ -149,6 +258,17 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 			FunctionReference: *functionRef,
 			CreateIngress:     createIngress,
 			IngressConfig:     *ingressConfig,
+			Authentication:    auth,
+			SessionAffinity:   affinity,
+			RequestValidation: requestValidation,
+			FeatureFlags:      featureFlags,
+			Timeout:           timeout,
+			Retry:             retry,
+			CircuitBreaker:    breaker,
+			Priority:          priority,
+			Experiment:        experiment,
+			LongPoll:          longPoll,
+			CloudEvents:       cloudEvents,
 		},
 	}
 
@@ -171,6 +291,8 @@ func (opts *CreateSubCommand) run(input cli.Input) error {
 		return nil
 	}
 
+	util.WarnUnsupportedFeatures(opts.Client(), "HTTP trigger", opts.trigger.ObjectMeta.Name, info.HTTPTriggerFeatures(&opts.trigger.Spec))
+
 	_, err := opts.Client().V1().HTTPTrigger().Create(opts.trigger)
 	if err != nil {
 		return errors.Wrap(err, "create HTTP trigger")
@@ -18,6 +18,7 @@ package httptrigger
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
@@ -136,3 +137,342 @@ func getIngressTLS(secret string) (remove bool, tls string) {
 		return false, secret
 	}
 }
+
+// GetAuthentication returns an HTTPTriggerAuthentication based on user inputs; return error if any.
+// A nil return value with no error means authentication is disabled.
+func GetAuthentication(jwksURL string, issuer string, audiences []string, claims []string,
+	claimHeaders []string, oldAuth *fv1.HTTPTriggerAuthentication) (*fv1.HTTPTriggerAuthentication, error) {
+
+	if jwksURL == "-" {
+		return nil, nil
+	}
+
+	requiredClaims, err := getKeyValuePairs(claims, "jwt claim")
+	if err != nil {
+		return nil, err
+	}
+	claimsToHeaders, err := getKeyValuePairs(claimHeaders, "jwt claim header")
+	if err != nil {
+		return nil, err
+	}
+
+	auth := oldAuth
+	if auth == nil || auth.JWT == nil {
+		if len(jwksURL) == 0 && len(issuer) == 0 {
+			return nil, fmt.Errorf("JWKS URL or issuer is required to enable JWT authentication")
+		}
+		auth = &fv1.HTTPTriggerAuthentication{
+			JWT: &fv1.JWTAuthentication{},
+		}
+	}
+
+	if len(jwksURL) > 0 {
+		auth.JWT.JWKSURL = jwksURL
+	}
+	if len(issuer) > 0 {
+		auth.JWT.Issuer = issuer
+	}
+	if len(audiences) > 0 {
+		auth.JWT.Audiences = audiences
+	}
+	if len(requiredClaims) > 0 {
+		auth.JWT.RequiredClaims = requiredClaims
+	}
+	if len(claimsToHeaders) > 0 {
+		auth.JWT.ClaimsToHeaders = claimsToHeaders
+	}
+
+	return auth, nil
+}
+
+// GetSessionAffinity returns a SessionAffinity based on user inputs; return error if any.
+// A nil return value with no error means session affinity is disabled.
+func GetSessionAffinity(affinityType string, name string, ttl int, oldAffinity *fv1.SessionAffinity) (*fv1.SessionAffinity, error) {
+	if affinityType == "-" {
+		return nil, nil
+	}
+
+	affinity := oldAffinity
+	if affinity == nil {
+		if len(affinityType) == 0 {
+			return nil, fmt.Errorf("session affinity type is required to enable session affinity")
+		}
+		affinity = &fv1.SessionAffinity{}
+	}
+
+	if len(affinityType) > 0 {
+		affinity.Type = fv1.SessionAffinityType(affinityType)
+	}
+	if len(name) > 0 {
+		affinity.Name = name
+	}
+	if ttl > 0 {
+		affinity.TTL = ttl
+	}
+
+	err := affinity.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return affinity, nil
+}
+
+// GetRequestValidation returns a RequestValidation based on user inputs; return error if any.
+// The spec is given as "configmap/<name>" or "package/<name>". A nil return value with no
+// error means request validation is disabled.
+func GetRequestValidation(spec string, path string, operationID string, oldValidation *fv1.RequestValidation) (*fv1.RequestValidation, error) {
+	if spec == "-" {
+		return nil, nil
+	}
+
+	rv := oldValidation
+	if rv == nil {
+		if len(spec) == 0 {
+			return nil, fmt.Errorf("OpenAPI spec is required to enable request validation")
+		}
+		rv = &fv1.RequestValidation{}
+	}
+
+	if len(spec) > 0 {
+		parts := strings.SplitN(spec, "/", 2)
+		if len(parts) != 2 || len(parts[1]) == 0 {
+			return nil, fmt.Errorf("OpenAPI spec must be in the format configmap/<name> or package/<name>, got %q", spec)
+		}
+		switch strings.ToLower(parts[0]) {
+		case "configmap", "cm":
+			rv.ConfigMap, rv.Package = parts[1], ""
+		case "package", "pkg":
+			rv.ConfigMap, rv.Package = "", parts[1]
+		default:
+			return nil, fmt.Errorf("OpenAPI spec must be in the format configmap/<name> or package/<name>, got %q", spec)
+		}
+	}
+	if len(path) > 0 {
+		rv.Path = path
+	}
+	if len(operationID) > 0 {
+		rv.OperationID = operationID
+	}
+
+	err := rv.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return rv, nil
+}
+
+// GetFeatureFlagSource returns the feature flag source of a trigger, updating
+// oldSource with the given values if it's not nil. "-" removes the source.
+func GetFeatureFlagSource(source string, sourceURL string, keyHeader string, flags []string, oldSource *fv1.FeatureFlagSource) (*fv1.FeatureFlagSource, error) {
+	if source == "-" {
+		return nil, nil
+	}
+
+	fs := oldSource
+	if fs == nil {
+		if len(source) == 0 {
+			return nil, fmt.Errorf("feature flag source is required to enable feature flags")
+		}
+		fs = &fv1.FeatureFlagSource{}
+	}
+
+	if len(source) > 0 {
+		parts := strings.SplitN(source, "/", 2)
+		if len(parts) != 2 || len(parts[1]) == 0 {
+			return nil, fmt.Errorf("feature flag source must be in the format launchdarkly/<secret>, unleash/<secret> or configmap/<name>, got %q", source)
+		}
+		switch sourceType := fv1.FeatureFlagSourceType(strings.ToLower(parts[0])); sourceType {
+		case fv1.FeatureFlagSourceTypeLaunchDarkly, fv1.FeatureFlagSourceTypeUnleash:
+			fs.Type, fs.Secret, fs.ConfigMap = sourceType, parts[1], ""
+		case fv1.FeatureFlagSourceTypeConfigMap, "cm":
+			fs.Type, fs.Secret, fs.ConfigMap, fs.URL = fv1.FeatureFlagSourceTypeConfigMap, "", parts[1], ""
+		default:
+			return nil, fmt.Errorf("feature flag source must be in the format launchdarkly/<secret>, unleash/<secret> or configmap/<name>, got %q", source)
+		}
+	}
+	if len(sourceURL) > 0 {
+		fs.URL = sourceURL
+	}
+	if len(keyHeader) > 0 {
+		fs.KeyHeader = keyHeader
+	}
+	if len(flags) > 0 {
+		fs.Flags = flags
+	}
+
+	err := fs.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return fs, nil
+}
+
+// GetRetryPolicy returns the retry policy of a trigger, updating oldPolicy
+// with the given values if it's not nil. Zero retries removes the policy,
+// negative retries keep those of oldPolicy.
+func GetRetryPolicy(retries int, backoff int, retryOn []int, oldPolicy *fv1.HTTPTriggerRetryPolicy) (*fv1.HTTPTriggerRetryPolicy, error) {
+	if retries == 0 {
+		return nil, nil
+	}
+
+	policy := oldPolicy
+	if policy == nil {
+		if retries < 0 {
+			return nil, fmt.Errorf("number of retries is required to enable retries")
+		}
+		policy = &fv1.HTTPTriggerRetryPolicy{}
+	}
+
+	if retries > 0 {
+		policy.MaxRetries = retries
+	}
+	if backoff != 0 {
+		policy.Backoff = backoff
+	}
+	if len(retryOn) > 0 {
+		policy.RetryOn = retryOn
+	}
+
+	err := policy.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// GetCircuitBreakerPolicy returns the circuit breaker policy of a trigger, updating
+// oldPolicy with the given values if it's not nil. A negative threshold removes the policy.
+func GetCircuitBreakerPolicy(threshold int, openDuration int, oldPolicy *fv1.CircuitBreakerPolicy) (*fv1.CircuitBreakerPolicy, error) {
+	if threshold < 0 {
+		return nil, nil
+	}
+
+	policy := oldPolicy
+	if policy == nil {
+		policy = &fv1.CircuitBreakerPolicy{}
+	}
+	if threshold > 0 {
+		policy.ErrorThreshold = threshold
+	}
+	if openDuration != 0 {
+		policy.OpenDuration = openDuration
+	}
+
+	err := policy.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// GetLongPollPolicy returns the long poll policy of a trigger, updating oldPolicy
+// with the given values if it's not nil. A negative timeout removes the policy.
+func GetLongPollPolicy(timeout int, keepAliveInterval int, oldPolicy *fv1.LongPollPolicy) (*fv1.LongPollPolicy, error) {
+	if timeout < 0 {
+		return nil, nil
+	}
+
+	policy := oldPolicy
+	if policy == nil {
+		policy = &fv1.LongPollPolicy{}
+	}
+	if timeout > 0 {
+		policy.Timeout = timeout
+	}
+	if keepAliveInterval != 0 {
+		policy.KeepAliveInterval = keepAliveInterval
+	}
+
+	err := policy.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// GetExperiment returns an Experiment based on user inputs, updating oldExperiment with
+// the given values if it's not nil; return error if any. Variants are given as
+// "name=function[:weight]" and replace the variants of oldExperiment. A nil exposure
+// keeps the old one. A nil return value with no error means the experiment is removed.
+func GetExperiment(assignmentType string, key string, name string, exposure *int, variants []string,
+	oldExperiment *fv1.Experiment) (*fv1.Experiment, error) {
+	if assignmentType == "-" {
+		return nil, nil
+	}
+
+	experiment := oldExperiment
+	if experiment == nil {
+		if len(assignmentType) == 0 {
+			return nil, fmt.Errorf("experiment assignment type is required to start an experiment")
+		}
+		experiment = &fv1.Experiment{}
+	}
+
+	if len(assignmentType) > 0 {
+		experiment.Assignment.Type = fv1.ExperimentAssignmentType(assignmentType)
+	}
+	if len(key) > 0 {
+		experiment.Assignment.Name = key
+	}
+	if len(name) > 0 {
+		experiment.Name = name
+	}
+	if exposure != nil {
+		experiment.Exposure = exposure
+	}
+	if len(variants) > 0 {
+		experiment.Variants = nil
+		for _, v := range variants {
+			variant, err := getExperimentVariant(v)
+			if err != nil {
+				return nil, err
+			}
+			experiment.Variants = append(experiment.Variants, *variant)
+		}
+	}
+
+	err := experiment.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return experiment, nil
+}
+
+func getExperimentVariant(variant string) (*fv1.ExperimentVariant, error) {
+	v := strings.SplitN(variant, "=", 2)
+	if len(v) != 2 || len(v[0]) == 0 || len(v[1]) == 0 {
+		return nil, fmt.Errorf("illegal experiment variant %v, expected name=function[:weight]", variant)
+	}
+	result := &fv1.ExperimentVariant{Name: v[0], Function: v[1]}
+	if i := strings.LastIndex(v[1], ":"); i >= 0 {
+		weight, err := strconv.Atoi(v[1][i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("illegal weight of experiment variant %v: %v", variant, err)
+		}
+		result.Function = v[1][:i]
+		result.Weight = &weight
+	}
+	return result, nil
+}
+
+func getKeyValuePairs(pairs []string, kind string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	m := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		v := strings.SplitN(pair, "=", 2)
+		if len(v) != 2 || len(v[0]) == 0 || len(v[1]) == 0 {
+			return nil, fmt.Errorf("illegal %v: %v", kind, pair)
+		}
+		m[v[0]] = v[1]
+	}
+	return m, nil
+}
@@ -584,3 +584,46 @@ func Test_getIngressTLS(t *testing.T) {
 		})
 	}
 }
+
+func Test_getExperimentVariant(t *testing.T) {
+	weight := 3
+	tests := []struct {
+		name    string
+		variant string
+		want    *fv1.ExperimentVariant
+		wantErr bool
+	}{
+		{
+			name:    "default-weight",
+			variant: "control=fn-a",
+			want:    &fv1.ExperimentVariant{Name: "control", Function: "fn-a"},
+		},
+		{
+			name:    "weight",
+			variant: "treatment=fn-b:3",
+			want:    &fv1.ExperimentVariant{Name: "treatment", Function: "fn-b", Weight: &weight},
+		},
+		{
+			name:    "illegal-weight",
+			variant: "treatment=fn-b:x",
+			wantErr: true,
+		},
+		{
+			name:    "no-function",
+			variant: "treatment",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getExperimentVariant(tt.variant)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getExperimentVariant() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getExperimentVariant() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/fission/fission/pkg/fission-cli/console"
 	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
 	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
 )
 
 type UpdateSubCommand struct {
@@ -95,6 +96,9 @@ func (opts *UpdateSubCommand) complete(input cli.Input) error {
 
 	if input.IsSet(flagkey.HtHost) {
 		ht.Spec.Host = input.String(flagkey.HtHost)
+		if ht.Spec.Host == "-" {
+			ht.Spec.Host = ""
+		}
 	}
 
 	if input.IsSet(flagkey.HtIngressRule) || input.IsSet(flagkey.HtIngressAnnotation) || input.IsSet(flagkey.HtIngressTLS) {
@@ -107,12 +111,137 @@ func (opts *UpdateSubCommand) complete(input cli.Input) error {
 		ht.Spec.IngressConfig = *ingress
 	}
 
+	if input.IsSet(flagkey.HtIngressType) {
+		ht.Spec.IngressConfig.Type = fv1.IngressType(input.String(flagkey.HtIngressType))
+	}
+	if input.IsSet(flagkey.HtIngressGateway) {
+		ht.Spec.IngressConfig.Gateway = input.String(flagkey.HtIngressGateway)
+	}
+	if input.IsSet(flagkey.HtIngressType) || input.IsSet(flagkey.HtIngressGateway) {
+		err := ht.Spec.IngressConfig.Validate()
+		if err != nil {
+			return errors.Wrap(err, "error parsing ingress configuration")
+		}
+	}
+
+	if input.IsSet(flagkey.HtJWKSURL) || input.IsSet(flagkey.HtJWTIssuer) || input.IsSet(flagkey.HtJWTAudience) ||
+		input.IsSet(flagkey.HtJWTClaim) || input.IsSet(flagkey.HtJWTClaimHeader) {
+		auth, err := GetAuthentication(
+			input.String(flagkey.HtJWKSURL), input.String(flagkey.HtJWTIssuer), input.StringSlice(flagkey.HtJWTAudience),
+			input.StringSlice(flagkey.HtJWTClaim), input.StringSlice(flagkey.HtJWTClaimHeader), ht.Spec.Authentication)
+		if err != nil {
+			return errors.Wrap(err, "error parsing authentication configuration")
+		}
+		ht.Spec.Authentication = auth
+	}
+
+	if input.IsSet(flagkey.HtSessionAffinity) || input.IsSet(flagkey.HtSessionName) || input.IsSet(flagkey.HtSessionTTL) {
+		affinity, err := GetSessionAffinity(input.String(flagkey.HtSessionAffinity),
+			input.String(flagkey.HtSessionName), input.Int(flagkey.HtSessionTTL), ht.Spec.SessionAffinity)
+		if err != nil {
+			return errors.Wrap(err, "error parsing session affinity configuration")
+		}
+		ht.Spec.SessionAffinity = affinity
+	}
+
+	if input.IsSet(flagkey.HtOpenAPISpec) || input.IsSet(flagkey.HtOpenAPIPath) || input.IsSet(flagkey.HtOpenAPIOperation) {
+		requestValidation, err := GetRequestValidation(input.String(flagkey.HtOpenAPISpec),
+			input.String(flagkey.HtOpenAPIPath), input.String(flagkey.HtOpenAPIOperation), ht.Spec.RequestValidation)
+		if err != nil {
+			return errors.Wrap(err, "error parsing request validation configuration")
+		}
+		ht.Spec.RequestValidation = requestValidation
+	}
+
+	if input.IsSet(flagkey.HtFlagSource) || input.IsSet(flagkey.HtFlagSourceURL) ||
+		input.IsSet(flagkey.HtFlagKeyHeader) || input.IsSet(flagkey.HtFlag) {
+		featureFlags, err := GetFeatureFlagSource(input.String(flagkey.HtFlagSource), input.String(flagkey.HtFlagSourceURL),
+			input.String(flagkey.HtFlagKeyHeader), input.StringSlice(flagkey.HtFlag), ht.Spec.FeatureFlags)
+		if err != nil {
+			return errors.Wrap(err, "error parsing feature flag configuration")
+		}
+		ht.Spec.FeatureFlags = featureFlags
+	}
+
+	if input.IsSet(flagkey.HtTimeout) {
+		timeout := input.Int(flagkey.HtTimeout)
+		if timeout < 0 {
+			return errors.New("timeout must not be negative")
+		}
+		ht.Spec.Timeout = timeout
+	}
+
+	if input.IsSet(flagkey.HtRetries) || input.IsSet(flagkey.HtRetryBackoff) || input.IsSet(flagkey.HtRetryOn) {
+		retries := -1
+		if input.IsSet(flagkey.HtRetries) {
+			retries = input.Int(flagkey.HtRetries)
+		}
+		retry, err := GetRetryPolicy(retries, input.Int(flagkey.HtRetryBackoff), input.IntSlice(flagkey.HtRetryOn), ht.Spec.Retry)
+		if err != nil {
+			return errors.Wrap(err, "error parsing retry policy")
+		}
+		ht.Spec.Retry = retry
+	}
+
+	if input.IsSet(flagkey.HtBreakerThreshold) || input.IsSet(flagkey.HtBreakerDuration) {
+		breaker, err := GetCircuitBreakerPolicy(input.Int(flagkey.HtBreakerThreshold), input.Int(flagkey.HtBreakerDuration), ht.Spec.CircuitBreaker)
+		if err != nil {
+			return errors.Wrap(err, "error parsing circuit breaker policy")
+		}
+		ht.Spec.CircuitBreaker = breaker
+	}
+
+	if input.IsSet(flagkey.HtPriority) {
+		priority := fv1.InvocationPriority(input.String(flagkey.HtPriority))
+		err := priority.Validate()
+		if err != nil {
+			return errors.Wrap(err, "error parsing priority")
+		}
+		ht.Spec.Priority = priority
+	}
+
+	if input.IsSet(flagkey.HtCloudEvents) {
+		cloudEvents := fv1.CloudEventsMode(input.String(flagkey.HtCloudEvents))
+		if len(cloudEvents) > 0 {
+			err := cloudEvents.Validate()
+			if err != nil {
+				return errors.Wrap(err, "error parsing CloudEvents mode")
+			}
+		}
+		ht.Spec.CloudEvents = cloudEvents
+	}
+
+	if input.IsSet(flagkey.HtExperimentAssignment) || input.IsSet(flagkey.HtExperimentKey) || input.IsSet(flagkey.HtExperimentName) ||
+		input.IsSet(flagkey.HtExperimentExposure) || input.IsSet(flagkey.HtExperimentVariant) {
+		var exposure *int
+		if input.IsSet(flagkey.HtExperimentExposure) {
+			e := input.Int(flagkey.HtExperimentExposure)
+			exposure = &e
+		}
+		experiment, err := GetExperiment(input.String(flagkey.HtExperimentAssignment), input.String(flagkey.HtExperimentKey),
+			input.String(flagkey.HtExperimentName), exposure, input.StringSlice(flagkey.HtExperimentVariant), ht.Spec.Experiment)
+		if err != nil {
+			return errors.Wrap(err, "error parsing experiment")
+		}
+		ht.Spec.Experiment = experiment
+	}
+
+	if input.IsSet(flagkey.HtLongPoll) || input.IsSet(flagkey.HtLongPollKeepAlive) {
+		longPoll, err := GetLongPollPolicy(input.Int(flagkey.HtLongPoll), input.Int(flagkey.HtLongPollKeepAlive), ht.Spec.LongPoll)
+		if err != nil {
+			return errors.Wrap(err, "error parsing long poll policy")
+		}
+		ht.Spec.LongPoll = longPoll
+	}
+
 	opts.trigger = ht
 
 	return nil
 }
 
 func (opts *UpdateSubCommand) run(input cli.Input) error {
+	util.WarnUnsupportedFeatures(opts.Client(), "HTTP trigger", opts.trigger.ObjectMeta.Name, info.HTTPTriggerFeatures(&opts.trigger.Spec))
+
 	_, err := opts.Client().V1().HTTPTrigger().Update(opts.trigger)
 	if err != nil {
 		return errors.Wrap(err, "error updating the HTTP trigger")
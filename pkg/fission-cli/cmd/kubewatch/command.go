@@ -31,9 +31,8 @@ func Commands() *cobra.Command {
 	}
 	wrapper.SetFlags(createCmd, flag.FlagSet{
 		Required: []flag.Flag{flag.KwFnName},
-		Optional: []flag.Flag{flag.KwName, flag.KwObjType, flag.KwNamespace, flag.NamespaceFunction, flag.SpecSave, flag.SpecDry},
-		// TODO: add label selector flag
-		// flag.KwLabelsFlag
+		Optional: []flag.Flag{flag.KwName, flag.KwObjType, flag.KwAPIVersion, flag.KwNamespace, flag.KwLabels,
+			flag.KwFieldSelector, flag.KwFilter, flag.KwCloudEvents, flag.NamespaceFunction, flag.SpecSave, flag.SpecDry},
 	})
 
 	deleteCmd := &cobra.Command{
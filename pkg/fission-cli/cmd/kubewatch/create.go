@@ -22,6 +22,7 @@ import (
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
@@ -60,6 +61,24 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 	namespace := input.String(flagkey.KwNamespace)
 	objType := input.String(flagkey.KwObjType)
 
+	// with a label selector set, only resources with all of its labels are watched
+	var labelSelector map[string]string
+	if input.IsSet(flagkey.KwLabels) {
+		set, err := labels.ConvertSelectorToLabelsMap(input.String(flagkey.KwLabels))
+		if err != nil {
+			return errors.Wrap(err, "error parsing label selector")
+		}
+		labelSelector = set
+	}
+
+	cloudEvents := fv1.CloudEventsMode(input.String(flagkey.KwCloudEvents))
+	if len(cloudEvents) > 0 {
+		err := cloudEvents.Validate()
+		if err != nil {
+			return errors.Wrap(err, "error parsing CloudEvents mode")
+		}
+	}
+
 	if input.Bool(flagkey.SpecSave) {
 		specDir := util.GetSpecDir(input)
 		fr, err := spec.ReadSpecs(specDir)
@@ -88,16 +107,25 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 			Namespace: fnNamespace,
 		},
 		Spec: fv1.KubernetesWatchTriggerSpec{
-			Namespace: namespace,
-			Type:      objType,
-			//LabelSelector: labels,
+			Namespace:     namespace,
+			Type:          objType,
+			APIVersion:    input.String(flagkey.KwAPIVersion),
+			LabelSelector: labelSelector,
+			FieldSelector: input.String(flagkey.KwFieldSelector),
+			Filter:        input.String(flagkey.KwFilter),
 			FunctionReference: fv1.FunctionReference{
 				Name: fnName,
 				Type: fv1.FunctionReferenceTypeFunctionName,
 			},
+			CloudEvents: cloudEvents,
 		},
 	}
 
+	err := opts.watcher.Spec.Validate()
+	if err != nil {
+		return fv1.AggregateValidationErrors("KubernetesWatchTrigger", err)
+	}
+
 	return nil
 }
 
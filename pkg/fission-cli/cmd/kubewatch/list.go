@@ -58,11 +58,11 @@ func (opts *ListSubCommand) run(input cli.Input) error {
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
 
-	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n",
-		"NAME", "NAMESPACE", "OBJTYPE", "LABELS", "FUNCTION_NAME")
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n",
+		"NAME", "NAMESPACE", "APIVERSION", "OBJTYPE", "LABELS", "FUNCTION_NAME")
 	for _, wa := range ws {
-		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n",
-			wa.ObjectMeta.Name, wa.Spec.Namespace, wa.Spec.Type, wa.Spec.LabelSelector, wa.Spec.FunctionReference.Name)
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n",
+			wa.ObjectMeta.Name, wa.Spec.Namespace, wa.Spec.APIVersion, wa.Spec.Type, wa.Spec.LabelSelector, wa.Spec.FunctionReference.Name)
 	}
 	w.Flush()
 
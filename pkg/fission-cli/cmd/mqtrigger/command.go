@@ -35,7 +35,9 @@ func Commands() *cobra.Command {
 			flag.MqtErrorTopic, flag.MqtMaxRetries, flag.MqtMsgContentType,
 			flag.NamespaceFunction, flag.SpecSave, flag.SpecDry, flag.MqtPollingInterval,
 			flag.MqtCooldownPeriod, flag.MqtMinReplicaCount, flag.MqtMaxReplicaCount, flag.MqtSecret,
-			flag.MqtMetadata, flag.MqtKind},
+			flag.MqtMetadata, flag.MqtKind, flag.MqtBatchSize, flag.MqtBatchWindow,
+			flag.MqtMaxConcurrency, flag.MqtTargetLag, flag.MqtBackoff, flag.MqtMaxBackoff,
+			flag.MqtJitter, flag.MqtDeadLetterTopic, flag.MqtCloudEvents},
 	})
 
 	updateCmd := &cobra.Command{
@@ -49,7 +51,9 @@ func Commands() *cobra.Command {
 		Optional: []flag.Flag{flag.MqtFnName, flag.MqtTopic, flag.MqtRespTopic, flag.MqtErrorTopic,
 			flag.MqtMaxRetries, flag.MqtMsgContentType, flag.NamespaceTrigger, flag.MqtPollingInterval,
 			flag.MqtCooldownPeriod, flag.MqtMinReplicaCount, flag.MqtMaxReplicaCount, flag.MqtMetadata,
-			flag.MqtSecret, flag.MqtKind},
+			flag.MqtSecret, flag.MqtKind, flag.MqtBatchSize, flag.MqtBatchWindow,
+			flag.MqtMaxConcurrency, flag.MqtTargetLag, flag.MqtBackoff, flag.MqtMaxBackoff,
+			flag.MqtJitter, flag.MqtDeadLetterTopic, flag.MqtCloudEvents},
 	})
 
 	deleteCmd := &cobra.Command{
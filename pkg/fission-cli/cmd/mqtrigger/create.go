@@ -18,6 +18,7 @@ package mqtrigger
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
@@ -85,16 +86,61 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 		return errors.New("Maximum number of retries must be greater than or equal to 0")
 	}
 
+	deadLetterTopic := input.String(flagkey.MqtDeadLetterTopic)
+	var retryPolicy *fv1.MessageQueueTriggerRetryPolicy
+	if input.IsSet(flagkey.MqtBackoff) || input.IsSet(flagkey.MqtMaxBackoff) ||
+		input.IsSet(flagkey.MqtJitter) || input.IsSet(flagkey.MqtDeadLetterTopic) {
+		retryPolicy = &fv1.MessageQueueTriggerRetryPolicy{
+			Backoff:         input.String(flagkey.MqtBackoff),
+			MaxBackoff:      input.String(flagkey.MqtMaxBackoff),
+			Jitter:          input.Bool(flagkey.MqtJitter),
+			DeadLetterTopic: deadLetterTopic,
+		}
+		if err := retryPolicy.Validate(); err != nil {
+			return errors.Wrap(err, "error validating retry policy")
+		}
+	}
+
 	contentType := input.String(flagkey.MqtMsgContentType)
 	if len(contentType) == 0 {
 		contentType = "application/json"
 	}
 
-	err := checkMQTopicAvailability(mqType, mqtKind, topic, respTopic)
+	batchSize := input.Int(flagkey.MqtBatchSize)
+	if batchSize < 0 {
+		return errors.New("Batch size must be greater than or equal to 0")
+	}
+	batchWindow := input.String(flagkey.MqtBatchWindow)
+	if len(batchWindow) > 0 {
+		if _, err := time.ParseDuration(batchWindow); err != nil {
+			return errors.Wrap(err, "error parsing batch window")
+		}
+	}
+
+	maxConcurrency := input.Int(flagkey.MqtMaxConcurrency)
+	if maxConcurrency < 0 {
+		return errors.New("Max concurrent invocations must be greater than or equal to 0")
+	}
+	var autoscaling *fv1.MessageQueueTriggerAutoscaling
+	if targetLag := input.Int(flagkey.MqtTargetLag); targetLag < 0 {
+		return errors.New("Target lag must be greater than or equal to 0")
+	} else if targetLag > 0 {
+		autoscaling = &fv1.MessageQueueTriggerAutoscaling{TargetLag: int64(targetLag)}
+	}
+
+	err := checkMQTopicAvailability(mqType, mqtKind, topic, respTopic, deadLetterTopic)
 	if err != nil {
 		return err
 	}
 
+	cloudEvents := fv1.CloudEventsMode(input.String(flagkey.MqtCloudEvents))
+	if len(cloudEvents) > 0 {
+		err = cloudEvents.Validate()
+		if err != nil {
+			return errors.Wrap(err, "error parsing CloudEvents mode")
+		}
+	}
+
 	pollingInterval := int32(input.Int(flagkey.MqtPollingInterval))
 	if pollingInterval < 0 {
 		return errors.New("Polling interval must be greater than or equal to 0")
@@ -153,19 +199,25 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 				Type: fv1.FunctionReferenceTypeFunctionName,
 				Name: fnName,
 			},
-			MessageQueueType: mqType,
-			Topic:            topic,
-			ResponseTopic:    respTopic,
-			ErrorTopic:       errorTopic,
-			MaxRetries:       maxRetries,
-			ContentType:      contentType,
-			PollingInterval:  &pollingInterval,
-			CooldownPeriod:   &cooldownPeriod,
-			MinReplicaCount:  &minReplicaCount,
-			MaxReplicaCount:  &maxReplicaCount,
-			Metadata:         metadata,
-			Secret:           secret,
-			MqtKind:          mqtKind,
+			MessageQueueType:         mqType,
+			Topic:                    topic,
+			ResponseTopic:            respTopic,
+			ErrorTopic:               errorTopic,
+			MaxRetries:               maxRetries,
+			RetryPolicy:              retryPolicy,
+			ContentType:              contentType,
+			BatchSize:                batchSize,
+			BatchWindow:              batchWindow,
+			MaxConcurrentInvocations: maxConcurrency,
+			PollingInterval:          &pollingInterval,
+			CooldownPeriod:           &cooldownPeriod,
+			MinReplicaCount:          &minReplicaCount,
+			MaxReplicaCount:          &maxReplicaCount,
+			Autoscaling:              autoscaling,
+			Metadata:                 metadata,
+			Secret:                   secret,
+			MqtKind:                  mqtKind,
+			CloudEvents:              cloudEvents,
 		},
 	}
 
@@ -18,6 +18,7 @@ package mqtrigger
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -68,9 +69,14 @@ func (opts *UpdateSubCommand) complete(input cli.Input) error {
 	metadataParams := input.StringSlice(flagkey.MqtMetadata)
 	secret := input.String(flagkey.MqtSecret)
 	mqtKind := input.String(flagkey.MqtKind)
+	batchSize := input.Int(flagkey.MqtBatchSize)
+	batchWindow := input.String(flagkey.MqtBatchWindow)
+	maxConcurrency := input.Int(flagkey.MqtMaxConcurrency)
+	targetLag := input.Int(flagkey.MqtTargetLag)
+	deadLetterTopic := input.String(flagkey.MqtDeadLetterTopic)
 	// TODO : Find out if we can make a call to checkIfFunctionExists, in the same ns more importantly.
 
-	err = checkMQTopicAvailability(mqt.Spec.MessageQueueType, topic, respTopic)
+	err = checkMQTopicAvailability(mqt.Spec.MessageQueueType, topic, respTopic, deadLetterTopic)
 	if err != nil {
 		return err
 	}
@@ -92,6 +98,30 @@ func (opts *UpdateSubCommand) complete(input cli.Input) error {
 		mqt.Spec.MaxRetries = maxRetries
 		updated = true
 	}
+	if input.IsSet(flagkey.MqtBackoff) || input.IsSet(flagkey.MqtMaxBackoff) ||
+		input.IsSet(flagkey.MqtJitter) || input.IsSet(flagkey.MqtDeadLetterTopic) {
+		retryPolicy := &fv1.MessageQueueTriggerRetryPolicy{}
+		if mqt.Spec.RetryPolicy != nil {
+			retryPolicy = mqt.Spec.RetryPolicy.DeepCopy()
+		}
+		if input.IsSet(flagkey.MqtBackoff) {
+			retryPolicy.Backoff = input.String(flagkey.MqtBackoff)
+		}
+		if input.IsSet(flagkey.MqtMaxBackoff) {
+			retryPolicy.MaxBackoff = input.String(flagkey.MqtMaxBackoff)
+		}
+		if input.IsSet(flagkey.MqtJitter) {
+			retryPolicy.Jitter = input.Bool(flagkey.MqtJitter)
+		}
+		if input.IsSet(flagkey.MqtDeadLetterTopic) {
+			retryPolicy.DeadLetterTopic = deadLetterTopic
+		}
+		if err := retryPolicy.Validate(); err != nil {
+			return errors.Wrap(err, "error validating retry policy")
+		}
+		mqt.Spec.RetryPolicy = retryPolicy
+		updated = true
+	}
 	if len(fnName) > 0 {
 		mqt.Spec.FunctionReference.Name = fnName
 		updated = true
@@ -130,6 +160,51 @@ func (opts *UpdateSubCommand) complete(input cli.Input) error {
 		updated = true
 	}
 
+	if input.IsSet(flagkey.MqtBatchSize) {
+		if batchSize < 0 {
+			return errors.New("Batch size must be greater than or equal to 0")
+		}
+		mqt.Spec.BatchSize = batchSize
+		updated = true
+	}
+	if input.IsSet(flagkey.MqtBatchWindow) {
+		if len(batchWindow) > 0 {
+			if _, err := time.ParseDuration(batchWindow); err != nil {
+				return errors.Wrap(err, "error parsing batch window")
+			}
+		}
+		mqt.Spec.BatchWindow = batchWindow
+		updated = true
+	}
+	if input.IsSet(flagkey.MqtMaxConcurrency) {
+		if maxConcurrency < 0 {
+			return errors.New("Max concurrent invocations must be greater than or equal to 0")
+		}
+		mqt.Spec.MaxConcurrentInvocations = maxConcurrency
+		updated = true
+	}
+	if input.IsSet(flagkey.MqtTargetLag) {
+		if targetLag < 0 {
+			return errors.New("Target lag must be greater than or equal to 0")
+		}
+		mqt.Spec.Autoscaling = nil
+		if targetLag > 0 {
+			mqt.Spec.Autoscaling = &fv1.MessageQueueTriggerAutoscaling{TargetLag: int64(targetLag)}
+		}
+		updated = true
+	}
+	if input.IsSet(flagkey.MqtCloudEvents) {
+		cloudEvents := fv1.CloudEventsMode(input.String(flagkey.MqtCloudEvents))
+		if len(cloudEvents) > 0 {
+			err := cloudEvents.Validate()
+			if err != nil {
+				return errors.Wrap(err, "error parsing CloudEvents mode")
+			}
+		}
+		mqt.Spec.CloudEvents = cloudEvents
+		updated = true
+	}
+
 	if !updated {
 		return errors.New("Nothing changed, see 'help' for more details")
 	}
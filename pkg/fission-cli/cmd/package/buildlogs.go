@@ -0,0 +1,150 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package _package
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/buildlog"
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
+)
+
+// buildLogPollInterval is how often build logs are polled with --follow.
+const buildLogPollInterval = 2 * time.Second
+
+type BuildLogsSubCommand struct {
+	cmd.CommandActioner
+	meta   *metav1.ObjectMeta
+	follow bool
+	all    bool
+}
+
+func BuildLogs(input cli.Input) error {
+	return (&BuildLogsSubCommand{}).do(input)
+}
+
+func (opts *BuildLogsSubCommand) do(input cli.Input) error {
+	err := opts.complete(input)
+	if err != nil {
+		return err
+	}
+	return opts.run(input)
+}
+
+func (opts *BuildLogsSubCommand) complete(input cli.Input) error {
+	opts.meta = &metav1.ObjectMeta{
+		Name:      input.String(flagkey.PkgName),
+		Namespace: input.String(flagkey.NamespacePackage),
+	}
+	opts.follow = input.Bool(flagkey.PkgFollow)
+	opts.all = input.Bool(flagkey.PkgAllBuilds)
+	return nil
+}
+
+func (opts *BuildLogsSubCommand) run(input cli.Input) error {
+	err := util.RequireServerFeature(opts.Client(), info.FeatureBuildLogs)
+	if err != nil {
+		return err
+	}
+
+	records, err := opts.Client().V1().Package().BuildLogs(opts.meta)
+	if err != nil {
+		return errors.Wrapf(err, "error getting build logs of package %v", opts.meta.Name)
+	}
+
+	if opts.all {
+		// oldest build first, like a log
+		for i := len(records) - 1; i >= 0; i-- {
+			printBuildLog(os.Stdout, &records[i])
+		}
+	} else if len(records) > 0 {
+		printBuildLog(os.Stdout, &records[0])
+	}
+
+	if !opts.follow {
+		if len(records) == 0 {
+			fmt.Printf("No build logs kept for package %v\n", opts.meta.Name)
+		}
+		return nil
+	}
+	return opts.followBuilds(records)
+}
+
+// followBuilds prints the logs of builds as they end, until the package
+// has no build pending or running.
+func (opts *BuildLogsSubCommand) followBuilds(records []buildlog.Record) error {
+	// builds already printed, or started but not printed yet
+	printed := make(map[string]bool)
+	for _, r := range records {
+		printed[r.ID] = r.IsFinished()
+	}
+
+	for {
+		pkg, err := opts.Client().V1().Package().Get(opts.meta)
+		if err != nil {
+			return errors.Wrapf(err, "error getting package %v", opts.meta.Name)
+		}
+		records, err = opts.Client().V1().Package().BuildLogs(opts.meta)
+		if err != nil {
+			return errors.Wrapf(err, "error getting build logs of package %v", opts.meta.Name)
+		}
+
+		for i := len(records) - 1; i >= 0; i-- {
+			r := &records[i]
+			done, seen := printed[r.ID]
+			if !seen {
+				fmt.Printf("=== Build %v started at %v ===\n", r.ID, r.StartedAt.Local().Format(time.RFC3339))
+			}
+			if !done && r.IsFinished() {
+				printBuildLog(os.Stdout, r)
+			}
+			printed[r.ID] = r.IsFinished()
+		}
+
+		if pkg.Status.BuildStatus != fv1.BuildStatusPending && pkg.Status.BuildStatus != fv1.BuildStatusRunning &&
+			(len(records) == 0 || records[0].IsFinished()) {
+			return nil
+		}
+		time.Sleep(buildLogPollInterval)
+	}
+}
+
+func printBuildLog(w io.Writer, r *buildlog.Record) {
+	fmt.Fprintf(w, "=== Build %v of package %v (resource version %v) ===\n", r.ID, r.Package, r.ResourceVersion)
+	fmt.Fprintf(w, "Started:  %v\n", r.StartedAt.Local().Format(time.RFC3339))
+	if !r.IsFinished() {
+		fmt.Fprintf(w, "Status:   %v\n\n", r.Status)
+		return
+	}
+	fmt.Fprintf(w, "Finished: %v\n", r.FinishedAt.Local().Format(time.RFC3339))
+	fmt.Fprintf(w, "Status:   %v\n", r.Status)
+	if r.Truncated {
+		fmt.Fprintln(w, "(log truncated, showing its end)")
+	}
+	fmt.Fprintf(w, "%v\n", r.Log)
+}
@@ -33,6 +33,7 @@ func Commands() *cobra.Command {
 		Required: []flag.Flag{flag.PkgEnvironment},
 		Optional: []flag.Flag{flag.PkgName, flag.PkgCode, flag.PkgSrcArchive, flag.PkgDeployArchive,
 			flag.PkgSrcChecksum, flag.PkgDeployChecksum, flag.PkgInsecure, flag.PkgBuildCmd,
+			flag.PkgSrcGit, flag.PkgDeployGit, flag.PkgGitRef, flag.PkgGitSubPath, flag.PkgGitSecret, flag.PkgArchiveSecret,
 			flag.NamespacePackage, flag.NamespaceEnvironment, flag.SpecSave, flag.SpecDry},
 	})
 
@@ -65,6 +66,7 @@ func Commands() *cobra.Command {
 		Required: []flag.Flag{flag.PkgName},
 		Optional: []flag.Flag{flag.PkgEnvironment, flag.PkgCode, flag.PkgSrcArchive, flag.PkgDeployArchive,
 			flag.PkgSrcChecksum, flag.PkgDeployChecksum, flag.PkgInsecure, flag.PkgBuildCmd, flag.PkgForce,
+			flag.PkgSrcGit, flag.PkgDeployGit, flag.PkgGitRef, flag.PkgGitSubPath, flag.PkgGitSecret, flag.PkgArchiveSecret,
 			flag.NamespacePackage, flag.NamespaceEnvironment},
 	})
 
@@ -107,13 +109,35 @@ func Commands() *cobra.Command {
 		Optional: []flag.Flag{flag.NamespacePackage},
 	})
 
+	buildLogsCmd := &cobra.Command{
+		Use:   "buildlogs",
+		Short: "Show build logs of a package",
+		Long:  "Show the log of the last build of a package, or of all the builds kept with --all",
+		RunE:  wrapper.Wrapper(BuildLogs),
+	}
+	wrapper.SetFlags(buildLogsCmd, flag.FlagSet{
+		Required: []flag.Flag{flag.PkgName},
+		Optional: []flag.Flag{flag.PkgFollow, flag.PkgAllBuilds, flag.NamespacePackage},
+	})
+
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare two revisions of a package",
+		Long:  "Compare the build metadata and the archive contents of two revisions of a package, the last two kept by default",
+		RunE:  wrapper.Wrapper(Diff),
+	}
+	wrapper.SetFlags(diffCmd, flag.FlagSet{
+		Required: []flag.Flag{flag.PkgName},
+		Optional: []flag.Flag{flag.PkgRevisions, flag.PkgDiffSource, flag.PkgDiffText, flag.NamespacePackage},
+	})
+
 	command := &cobra.Command{
 		Use:     "package",
 		Aliases: []string{"pkg"},
 		Short:   "Create, update and manage packages",
 	}
 
-	command.AddCommand(createCmd, getSrcCmd, getDeployCmd, updateCmd, deleteCmd, listCmd, infoCmd, rebuildCmd)
+	command.AddCommand(createCmd, getSrcCmd, getDeployCmd, updateCmd, deleteCmd, listCmd, infoCmd, rebuildCmd, buildLogsCmd, diffCmd)
 
 	return command
 }
@@ -35,6 +35,8 @@ import (
 	"github.com/fission/fission/pkg/fission-cli/console"
 	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
 	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
+	"github.com/fission/fission/pkg/utils"
 )
 
 type CreateSubCommand struct {
@@ -78,8 +80,17 @@ func (opts *CreateSubCommand) run(input cli.Input) error {
 		noZip = true
 	}
 
-	if len(srcArchiveFiles) == 0 && len(deployArchiveFiles) == 0 {
-		return errors.Errorf("need --%v or --%v or --%v argument", flagkey.PkgCode, flagkey.PkgSrcArchive, flagkey.PkgDeployArchive)
+	srcGit := input.String(flagkey.PkgSrcGit)
+	deployGit := input.String(flagkey.PkgDeployGit)
+	if len(srcArchiveFiles) == 0 && len(deployArchiveFiles) == 0 && len(srcGit) == 0 && len(deployGit) == 0 {
+		return errors.Errorf("need --%v or --%v or --%v or --%v or --%v argument", flagkey.PkgCode, flagkey.PkgSrcArchive, flagkey.PkgDeployArchive,
+			flagkey.PkgSrcGit, flagkey.PkgDeployGit)
+	}
+	if len(srcArchiveFiles) > 0 && len(srcGit) > 0 {
+		return errors.Errorf("--%v and --%v can't be used together", flagkey.PkgSrcArchive, flagkey.PkgSrcGit)
+	}
+	if len(deployArchiveFiles) > 0 && len(deployGit) > 0 {
+		return errors.Errorf("--%v and --%v can't be used together", flagkey.PkgDeployArchive, flagkey.PkgDeployGit)
 	}
 
 	var specDir, specFile string
@@ -134,7 +145,7 @@ func CreatePackage(input cli.Input, client client.Interface, pkgName string, pkg
 		if len(specFile) > 0 { // we should do this in all cases, i think
 			pkgStatus = fv1.BuildStatusNone
 		}
-		deployment, err := CreateArchive(client, input, deployArchiveFiles, noZip, insecure, deployChecksum, specDir, specFile)
+		deployment, err := CreateArchive(client, input, deployArchiveFiles, noZip, insecure, deployChecksum, specDir, specFile, nil, pkgNamespace)
 		if err != nil {
 			return nil, errors.Wrap(err, "error creating source archive")
 		}
@@ -144,7 +155,7 @@ func CreatePackage(input cli.Input, client client.Interface, pkgName string, pkg
 		}
 	}
 	if len(srcArchiveFiles) > 0 {
-		source, err := CreateArchive(client, input, srcArchiveFiles, false, insecure, srcChecksum, specDir, specFile)
+		source, err := CreateArchive(client, input, srcArchiveFiles, false, insecure, srcChecksum, specDir, specFile, nil, pkgNamespace)
 		if err != nil {
 			return nil, errors.Wrap(err, "error creating deploy archive")
 		}
@@ -155,6 +166,28 @@ func CreatePackage(input cli.Input, client client.Interface, pkgName string, pkg
 		}
 	}
 
+	if deployGit := input.String(flagkey.PkgDeployGit); len(deployGit) > 0 {
+		if len(specFile) > 0 {
+			pkgStatus = fv1.BuildStatusNone
+		}
+		pkgSpec.Deployment = *gitArchive(input, deployGit, nil)
+		if len(pkgName) == 0 {
+			pkgName = util.KubifyName(fmt.Sprintf("%v-%v", path.Base(strings.TrimSuffix(deployGit, ".git")), uniuri.NewLen(4)))
+		}
+	}
+	if srcGit := input.String(flagkey.PkgSrcGit); len(srcGit) > 0 {
+		pkgSpec.Source = *gitArchive(input, srcGit, nil)
+		pkgStatus = fv1.BuildStatusPending
+		if len(pkgName) == 0 {
+			pkgName = util.KubifyName(fmt.Sprintf("%v-%v", path.Base(strings.TrimSuffix(srcGit, ".git")), uniuri.NewLen(4)))
+		}
+	}
+
+	err := setArchiveSecret(input, &pkgSpec)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(buildcmd) > 0 {
 		pkgSpec.BuildCommand = buildcmd
 	}
@@ -199,6 +232,7 @@ func CreatePackage(input cli.Input, client client.Interface, pkgName string, pkg
 		}
 		return &pkg.ObjectMeta, nil
 	} else {
+		util.WarnUnsupportedFeatures(client, "package", pkg.ObjectMeta.Name, info.PackageFeatures(&pkg.Spec))
 		pkgMetadata, err := client.V1().Package().Create(pkg)
 		if err != nil {
 			return nil, errors.Wrap(err, "error creating package")
@@ -207,3 +241,48 @@ func CreatePackage(input cli.Input, client client.Interface, pkgName string, pkg
 		return pkgMetadata, nil
 	}
 }
+
+// setArchiveSecret sets the auth secret of the source and deploy archives
+// of a package that are in object stores.
+func setArchiveSecret(input cli.Input, pkgSpec *fv1.PackageSpec) error {
+	if !input.IsSet(flagkey.PkgArchiveSecret) {
+		return nil
+	}
+	var set bool
+	for _, archive := range []*fv1.Archive{&pkgSpec.Source, &pkgSpec.Deployment} {
+		if utils.IsObjectStoreURL(archive.URL) {
+			archive.AuthSecret = input.String(flagkey.PkgArchiveSecret)
+			set = true
+		}
+	}
+	if !set {
+		return errors.Errorf("--%v needs an s3://, gs:// or azblob:// source or deploy archive", flagkey.PkgArchiveSecret)
+	}
+	return nil
+}
+
+// gitArchive returns a git archive of the repository at url, with the ref,
+// sub path and auth secret given. Those not given are kept from the git
+// archive to update, if any, as is its repository if url is empty.
+func gitArchive(input cli.Input, url string, archive *fv1.Archive) *fv1.Archive {
+	var git fv1.GitSource
+	if archive != nil && archive.Git != nil {
+		git = *archive.Git
+	}
+	if len(url) > 0 {
+		git.URL = url
+	}
+	if input.IsSet(flagkey.PkgGitRef) {
+		git.Ref = input.String(flagkey.PkgGitRef)
+	}
+	if input.IsSet(flagkey.PkgGitSubPath) {
+		git.SubPath = input.String(flagkey.PkgGitSubPath)
+	}
+	if input.IsSet(flagkey.PkgGitSecret) {
+		git.AuthSecret = input.String(flagkey.PkgGitSecret)
+	}
+	return &fv1.Archive{
+		Type: fv1.ArchiveTypeGit,
+		Git:  &git,
+	}
+}
@@ -0,0 +1,547 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package _package
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/audit"
+	"github.com/fission/fission/pkg/controller/client"
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	pkgutil "github.com/fission/fission/pkg/fission-cli/cmd/package/util"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
+	"github.com/fission/fission/pkg/pkgrevision"
+)
+
+const (
+	// maxTextDiffSize is the size of the largest files text diffs are
+	// printed for.
+	maxTextDiffSize = 1024 * 1024
+	// maxTextDiffCells bounds the product of the changed lines of the files
+	// diffed, which the time and memory diffs take grow with.
+	maxTextDiffCells = 4 * 1024 * 1024
+	// diffContext is the number of unchanged lines around changes.
+	diffContext = 3
+
+	fileAdded    = "A"
+	fileDeleted  = "D"
+	fileModified = "M"
+)
+
+// revisionMetadataPaths are the fields of revisions compared besides the
+// contents of the archives.
+var revisionMetadataPaths = []string{
+	"spec.environment",
+	"spec.buildcmd",
+	"spec.buildSteps",
+	"spec.deploymentImage",
+	"spec.source.type",
+	"spec.source.url",
+	"spec.source.checksum",
+	"spec.source.git",
+	"spec.deployment.type",
+	"spec.deployment.url",
+	"spec.deployment.checksum",
+	"spec.deployment.git",
+	"buildStatus",
+}
+
+type (
+	DiffSubCommand struct {
+		cmd.CommandActioner
+		meta      *metav1.ObjectMeta
+		revisions []int
+		source    bool
+		text      bool
+	}
+
+	// archiveFile is a file in an archive.
+	archiveFile struct {
+		size     int64
+		checksum string
+		// contents are kept for files small enough to diff
+		contents []byte
+	}
+
+	// fileChange is a file added, deleted or modified between two archives.
+	fileChange struct {
+		name string
+		op   string
+		old  *archiveFile
+		new  *archiveFile
+	}
+)
+
+func Diff(input cli.Input) error {
+	return (&DiffSubCommand{}).do(input)
+}
+
+func (opts *DiffSubCommand) do(input cli.Input) error {
+	err := opts.complete(input)
+	if err != nil {
+		return err
+	}
+	return opts.run(input)
+}
+
+func (opts *DiffSubCommand) complete(input cli.Input) error {
+	opts.meta = &metav1.ObjectMeta{
+		Name:      input.String(flagkey.PkgName),
+		Namespace: input.String(flagkey.NamespacePackage),
+	}
+	opts.revisions = input.IntSlice(flagkey.PkgRevisions)
+	if len(opts.revisions) != 0 && len(opts.revisions) != 2 {
+		return errors.Errorf("--%v takes two revisions, e.g. --%v 3,5", flagkey.PkgRevisions, flagkey.PkgRevisions)
+	}
+	opts.source = input.Bool(flagkey.PkgDiffSource)
+	opts.text = input.Bool(flagkey.PkgDiffText)
+	return nil
+}
+
+func (opts *DiffSubCommand) run(input cli.Input) error {
+	err := util.RequireServerFeature(opts.Client(), info.FeaturePkgRevisions)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := opts.Client().V1().Package().Revisions(opts.meta)
+	if err != nil {
+		return errors.Wrapf(err, "error getting revisions of package %v", opts.meta.Name)
+	}
+	oldRev, newRev, err := pickRevisions(revisions, opts.revisions)
+	if err != nil {
+		return errors.Wrapf(err, "error comparing revisions of package %v", opts.meta.Name)
+	}
+
+	fmt.Printf("Package %v: revision %v -> %v\n\n", opts.meta.Name, oldRev.Generation, newRev.Generation)
+
+	changes, err := diffRevisionMetadata(oldRev, newRev)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Build metadata:")
+	if len(changes) == 0 {
+		fmt.Println("  unchanged")
+	}
+	for _, c := range changes {
+		fmt.Printf("  %v: %v -> %v\n", c.Path, orNone(c.Old), orNone(c.New))
+	}
+
+	kind, oldArchive, newArchive := "Deploy", &oldRev.Spec.Deployment, &newRev.Spec.Deployment
+	if opts.source {
+		kind, oldArchive, newArchive = "Source", &oldRev.Spec.Source, &newRev.Spec.Source
+	}
+	fmt.Printf("\n%v archive:\n", kind)
+	if oldArchive.Type == fv1.ArchiveTypeGit || newArchive.Type == fv1.ArchiveTypeGit {
+		fmt.Println("  git archives are compared by their repository and ref in the build metadata")
+		return nil
+	}
+
+	oldFiles, err := readArchiveFiles(opts.Client(), oldArchive)
+	if err != nil {
+		return errors.Wrapf(err, "error reading %v archive of revision %v", strings.ToLower(kind), oldRev.Generation)
+	}
+	newFiles, err := readArchiveFiles(opts.Client(), newArchive)
+	if err != nil {
+		return errors.Wrapf(err, "error reading %v archive of revision %v", strings.ToLower(kind), newRev.Generation)
+	}
+
+	fileChanges, unchanged := compareArchives(oldFiles, newFiles)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, c := range fileChanges {
+		switch c.op {
+		case fileAdded:
+			fmt.Fprintf(w, "  %v\t%v\t%v\t%v\n", c.op, c.name, humanize.Bytes(uint64(c.new.size)), shortChecksum(c.new.checksum))
+		case fileDeleted:
+			fmt.Fprintf(w, "  %v\t%v\t%v\t%v\n", c.op, c.name, humanize.Bytes(uint64(c.old.size)), shortChecksum(c.old.checksum))
+		default:
+			fmt.Fprintf(w, "  %v\t%v\t%v -> %v\t%v -> %v\n", c.op, c.name,
+				humanize.Bytes(uint64(c.old.size)), humanize.Bytes(uint64(c.new.size)),
+				shortChecksum(c.old.checksum), shortChecksum(c.new.checksum))
+		}
+	}
+	w.Flush()
+	fmt.Printf("  %v files changed, %v unchanged\n", len(fileChanges), unchanged)
+
+	if !opts.text {
+		return nil
+	}
+	for _, c := range fileChanges {
+		var oldContents, newContents []byte
+		if c.old != nil {
+			oldContents = c.old.contents
+		}
+		if c.new != nil {
+			newContents = c.new.contents
+		}
+		if (c.old != nil && !isText(c.old, oldContents)) || (c.new != nil && !isText(c.new, newContents)) {
+			fmt.Printf("\nBinary or large file %v differs\n", c.name)
+			continue
+		}
+		diff, ok := unifiedDiff(c.name, splitLines(oldContents), splitLines(newContents))
+		if !ok {
+			fmt.Printf("\nFile %v has too many changed lines to diff\n", c.name)
+			continue
+		}
+		fmt.Printf("\n%v", diff)
+	}
+	return nil
+}
+
+// pickRevisions returns the revisions of the given generations, or the last
+// two revisions kept if none are given.
+func pickRevisions(revisions []pkgrevision.Revision, generations []int) (*pkgrevision.Revision, *pkgrevision.Revision, error) {
+	if len(generations) == 0 {
+		if len(revisions) < 2 {
+			return nil, nil, errors.Errorf("%v revisions kept, at least 2 are needed", len(revisions))
+		}
+		return &revisions[1], &revisions[0], nil
+	}
+
+	picked := make([]*pkgrevision.Revision, len(generations))
+	for i, g := range generations {
+		for j := range revisions {
+			if revisions[j].Generation == int64(g) {
+				picked[i] = &revisions[j]
+			}
+		}
+		if picked[i] == nil {
+			kept := make([]string, 0, len(revisions))
+			for _, r := range revisions {
+				kept = append(kept, fmt.Sprint(r.Generation))
+			}
+			return nil, nil, errors.Errorf("revision %v isn't kept, kept revisions are: %v", g, strings.Join(kept, ", "))
+		}
+	}
+	return picked[0], picked[1], nil
+}
+
+// diffRevisionMetadata returns the changes of the build metadata of two
+// revisions.
+func diffRevisionMetadata(oldRev, newRev *pkgrevision.Revision) ([]audit.Change, error) {
+	oldObj, err := json.Marshal(oldRev)
+	if err != nil {
+		return nil, err
+	}
+	newObj, err := json.Marshal(newRev)
+	if err != nil {
+		return nil, err
+	}
+	return audit.Diff(oldObj, newObj, revisionMetadataPaths...)
+}
+
+// readArchiveFiles downloads an archive and returns its files. Archives
+// that are neither zip nor tar.gz are a single file named after the
+// archive.
+func readArchiveFiles(client client.Interface, archive *fv1.Archive) (map[string]*archiveFile, error) {
+	var data []byte
+	switch {
+	case archive.Type == fv1.ArchiveTypeLiteral:
+		data = archive.Literal
+	case len(archive.URL) == 0:
+		return map[string]*archiveFile{}, nil
+	default:
+		reader, err := pkgutil.DownloadArchive(client, archive)
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		data, err = ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, errors.Wrap(err, "error downloading archive")
+		}
+	}
+	return archiveFiles(archive.Format, data)
+}
+
+func archiveFiles(format fv1.ArchiveFormat, data []byte) (map[string]*archiveFile, error) {
+	files := make(map[string]*archiveFile)
+	add := func(name string, r io.Reader) error {
+		contents, err := ioutil.ReadAll(r)
+		if err != nil {
+			return errors.Wrapf(err, "error reading %v in archive", name)
+		}
+		sum := sha256.Sum256(contents)
+		f := &archiveFile{
+			size:     int64(len(contents)),
+			checksum: hex.EncodeToString(sum[:]),
+		}
+		if len(contents) <= maxTextDiffSize {
+			f.contents = contents
+		}
+		files[name] = f
+		return nil
+	}
+
+	switch {
+	case format == fv1.ArchiveFormatZip || (len(format) == 0 && bytes.HasPrefix(data, []byte("PK\x03\x04"))):
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading zip archive")
+		}
+		for _, zf := range zr.File {
+			if zf.FileInfo().IsDir() {
+				continue
+			}
+			r, err := zf.Open()
+			if err != nil {
+				return nil, errors.Wrapf(err, "error reading %v in archive", zf.Name)
+			}
+			err = add(zf.Name, r)
+			r.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+	case format == fv1.ArchiveFormatTarGz || (len(format) == 0 && bytes.HasPrefix(data, []byte{0x1f, 0x8b})):
+		gr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading tar.gz archive")
+		}
+		tr := tar.NewReader(gr)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, errors.Wrap(err, "error reading tar.gz archive")
+			}
+			if hdr.Typeflag != tar.TypeReg {
+				continue
+			}
+			err = add(strings.TrimPrefix(hdr.Name, "./"), tr)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+	default:
+		err := add("(archive)", bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// compareArchives returns the files added, deleted and modified between two
+// archives sorted by name, and the number of unchanged files.
+func compareArchives(oldFiles, newFiles map[string]*archiveFile) ([]fileChange, int) {
+	var changes []fileChange
+	unchanged := 0
+	for name, o := range oldFiles {
+		n, ok := newFiles[name]
+		switch {
+		case !ok:
+			changes = append(changes, fileChange{name: name, op: fileDeleted, old: o})
+		case o.checksum != n.checksum:
+			changes = append(changes, fileChange{name: name, op: fileModified, old: o, new: n})
+		default:
+			unchanged++
+		}
+	}
+	for name, n := range newFiles {
+		if _, ok := oldFiles[name]; !ok {
+			changes = append(changes, fileChange{name: name, op: fileAdded, new: n})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].name < changes[j].name
+	})
+	return changes, unchanged
+}
+
+// isText returns true if the contents of a file kept for diffs look like
+// text.
+func isText(f *archiveFile, contents []byte) bool {
+	return int64(len(contents)) == f.size && utf8.Valid(contents) && bytes.IndexByte(contents, 0) < 0
+}
+
+func splitLines(contents []byte) []string {
+	if len(contents) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(contents), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// unifiedDiff returns the unified diff of the old and new lines of a file,
+// or false if the changed lines are too many to diff.
+func unifiedDiff(name string, oldLines, newLines []string) (string, bool) {
+	// lines around the changes are usually the same, only the changed
+	// middle needs the quadratic diff
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+	oldMid, newMid := oldLines[prefix:len(oldLines)-suffix], newLines[prefix:len(newLines)-suffix]
+	if (len(oldMid)+1)*(len(newMid)+1) > maxTextDiffCells {
+		return "", false
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// oldMid[i:] and newMid[j:]
+	lcs := make([][]int32, len(oldMid)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(newMid)+1)
+	}
+	for i := len(oldMid) - 1; i >= 0; i-- {
+		for j := len(newMid) - 1; j >= 0; j-- {
+			if oldMid[i] == newMid[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// edits of the old lines into the new ones, ' ' for kept lines, with
+	// the indexes of the old and new lines they are at
+	type edit struct {
+		op         byte
+		line       string
+		oldN, newN int
+	}
+	var edits []edit
+	for k := 0; k < prefix; k++ {
+		edits = append(edits, edit{' ', oldLines[k], k, k})
+	}
+	i, j := 0, 0
+	for i < len(oldMid) || j < len(newMid) {
+		switch {
+		case i < len(oldMid) && j < len(newMid) && oldMid[i] == newMid[j]:
+			edits = append(edits, edit{' ', oldMid[i], prefix + i, prefix + j})
+			i++
+			j++
+		case i < len(oldMid) && (j == len(newMid) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', oldMid[i], prefix + i, prefix + j})
+			i++
+		default:
+			edits = append(edits, edit{'+', newMid[j], prefix + i, prefix + j})
+			j++
+		}
+	}
+	for k := 0; k < suffix; k++ {
+		edits = append(edits, edit{' ', oldLines[len(oldLines)-suffix+k], len(oldLines) - suffix + k, len(newLines) - suffix + k})
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%v\n+++ b/%v\n", name, name)
+	for start := 0; start < len(edits); {
+		if edits[start].op == ' ' {
+			start++
+			continue
+		}
+		// a hunk spans changes less than two contexts apart
+		from := start - diffContext
+		if from < 0 {
+			from = 0
+		}
+		end, kept := start, 0
+		for end < len(edits) && kept <= 2*diffContext {
+			if edits[end].op == ' ' {
+				kept++
+			} else {
+				kept = 0
+			}
+			end++
+		}
+		if kept > diffContext {
+			end -= kept - diffContext
+		}
+
+		oldCount, newCount := 0, 0
+		for _, e := range edits[from:end] {
+			if e.op != '+' {
+				oldCount++
+			}
+			if e.op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%v +%v @@\n", hunkRange(edits[from].oldN, oldCount), hunkRange(edits[from].newN, newCount))
+		for _, e := range edits[from:end] {
+			b.WriteByte(e.op)
+			b.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				b.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = end
+	}
+	return b.String(), true
+}
+
+// hunkRange returns the line range of a hunk starting at the zero-based
+// line, in the format of unified diffs.
+func hunkRange(line, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%v,0", line)
+	}
+	if count == 1 {
+		return fmt.Sprint(line + 1)
+	}
+	return fmt.Sprintf("%v,%v", line+1, count)
+}
+
+func shortChecksum(sum string) string {
+	if len(sum) > 12 {
+		return sum[:12]
+	}
+	return sum
+}
+
+func orNone(value string) string {
+	if len(value) == 0 {
+		return "<none>"
+	}
+	return value
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package _package
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/pkgrevision"
+)
+
+func makeZip(t *testing.T, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, contents := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCompareArchives(t *testing.T) {
+	oldFiles, err := archiveFiles("", makeZip(t, map[string]string{
+		"index.js":     "module.exports = 1\n",
+		"package.json": "{}\n",
+		"old.js":       "old\n",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newFiles, err := archiveFiles(fv1.ArchiveFormatZip, makeZip(t, map[string]string{
+		"index.js":     "module.exports = 2\n",
+		"package.json": "{}\n",
+		"new.js":       "new\n",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, unchanged := compareArchives(oldFiles, newFiles)
+	if unchanged != 1 || len(changes) != 3 {
+		t.Fatalf("expected 3 changes and 1 unchanged file, got %+v and %v", changes, unchanged)
+	}
+	expected := []struct{ name, op string }{{"index.js", fileModified}, {"new.js", fileAdded}, {"old.js", fileDeleted}}
+	for i, e := range expected {
+		if changes[i].name != e.name || changes[i].op != e.op {
+			t.Errorf("expected %v %v, got %v %v", e.op, e.name, changes[i].op, changes[i].name)
+		}
+	}
+
+	// archives that aren't zip or tar.gz are a single file
+	files, err := archiveFiles("", []byte("#!/bin/sh\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := files["(archive)"]; !ok || f.size != 10 || !isText(f, f.contents) {
+		t.Errorf("expected a single text file, got %+v", files)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	oldLines := splitLines([]byte("a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"))
+	newLines := splitLines([]byte("a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn"))
+
+	diff, ok := unifiedDiff("fn.js", oldLines, newLines)
+	if !ok {
+		t.Fatal("expected diff")
+	}
+	expected := `--- a/fn.js
++++ b/fn.js
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -11,3 +11,4 @@
+ k
+ l
+ m
++n
+\ No newline at end of file
+`
+	if diff != expected {
+		t.Errorf("expected diff\n%v\ngot\n%v", expected, diff)
+	}
+
+	diff, _ = unifiedDiff("new.js", nil, splitLines([]byte("x\n")))
+	if diff != "--- a/new.js\n+++ b/new.js\n@@ -0,0 +1 @@\n+x\n" {
+		t.Errorf("unexpected diff of added file\n%v", diff)
+	}
+}
+
+func TestPickRevisions(t *testing.T) {
+	revisions := []pkgrevision.Revision{{Generation: 5}, {Generation: 4}, {Generation: 2}}
+
+	oldRev, newRev, err := pickRevisions(revisions, nil)
+	if err != nil || oldRev.Generation != 4 || newRev.Generation != 5 {
+		t.Errorf("expected the last two revisions, got %v, %v (%v)", oldRev, newRev, err)
+	}
+	oldRev, newRev, err = pickRevisions(revisions, []int{2, 5})
+	if err != nil || oldRev.Generation != 2 || newRev.Generation != 5 {
+		t.Errorf("expected revisions 2 and 5, got %v, %v (%v)", oldRev, newRev, err)
+	}
+	if _, _, err = pickRevisions(revisions, []int{3, 5}); err == nil {
+		t.Error("expected revision not kept to be rejected")
+	}
+	if _, _, err = pickRevisions(revisions[:1], nil); err == nil {
+		t.Error("expected a single revision to be rejected")
+	}
+}
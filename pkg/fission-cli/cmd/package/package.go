@@ -46,7 +46,10 @@ import (
 // create an archive upload spec in the specs directory; otherwise
 // upload the archive using client.  noZip avoids zipping the
 // includeFiles, but is ignored if there's more than one includeFile.
-func CreateArchive(client client.Interface, input cli.Input, includeFiles []string, noZip bool, insecure bool, checksum string, specDir string, specFile string) (*fv1.Archive, error) {
+// baseArchive, if not nil, is the previous version of the archive, which
+// large archives are uploaded as a delta against. namespace is the namespace
+// of the package the archive is uploaded for.
+func CreateArchive(client client.Interface, input cli.Input, includeFiles []string, noZip bool, insecure bool, checksum string, specDir string, specFile string, baseArchive *fv1.Archive, namespace string) (*fv1.Archive, error) {
 	// get root dir
 	var rootDir string
 	var err error
@@ -62,8 +65,8 @@ func CreateArchive(client client.Interface, input cli.Input, includeFiles []stri
 
 	// check files existence
 	for _, path := range includeFiles {
-		// ignore http files
-		if utils.IsURL(path) {
+		// ignore http files and files in object stores
+		if utils.IsURL(path) || utils.IsObjectStoreURL(path) {
 			if len(includeFiles) > 1 {
 				// It's intentional to disallow the user to provide file and URL at the same time.
 				return nil, errors.New("unable to create an archive that contains both file and URL")
@@ -101,6 +104,12 @@ func CreateArchive(client client.Interface, input cli.Input, includeFiles []stri
 	}
 
 	if len(fileURL) > 0 {
+		if utils.IsObjectStoreURL(fileURL) && len(checksum) == 0 && !insecure {
+			// the credentials of the object store are only known to fetcher
+			console.Info(fmt.Sprintf("Skipping SHA256 checksum of archive in object store. To verify it, please use --%v / --%v",
+				flagkey.PkgSrcChecksum, flagkey.PkgDeployChecksum))
+			insecure = true
+		}
 		if insecure {
 			return &fv1.Archive{
 				Type: fv1.ArchiveTypeUrl,
@@ -190,7 +199,7 @@ func CreateArchive(client client.Interface, input cli.Input, includeFiles []stri
 	}
 
 	ctx := context.Background()
-	return pkgutil.UploadArchiveFile(ctx, client, archivePath)
+	return pkgutil.UploadArchiveFile(ctx, client, archivePath, namespace, baseArchive)
 }
 
 // makeArchiveFile creates a zip file from the given list of input files,
@@ -29,6 +29,8 @@ import (
 	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
 	"github.com/fission/fission/pkg/fission-cli/cmd"
 	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
 )
 
 type UpdateSubCommand struct {
@@ -126,13 +128,15 @@ func UpdatePackage(input cli.Input, client client.Interface, pkg *fv1.Package) (
 	}
 
 	if input.IsSet(flagkey.PkgBuildCmd) {
+		// the build command replaces the build steps of the package
 		pkg.Spec.BuildCommand = buildcmd
+		pkg.Spec.BuildSteps = nil
 		needToRebuild = true
 		needToUpdate = true
 	}
 
 	if input.IsSet(flagkey.PkgSrcArchive) {
-		srcArchive, err := CreateArchive(client, input, srcArchiveFiles, noZip, insecure, srcChecksum, "", "")
+		srcArchive, err := CreateArchive(client, input, srcArchiveFiles, noZip, insecure, srcChecksum, "", "", &pkg.Spec.Source, pkg.ObjectMeta.Namespace)
 		if err != nil {
 			return nil, errors.Wrap(err, "error creating source archive")
 		}
@@ -148,11 +152,13 @@ func UpdatePackage(input cli.Input, client client.Interface, pkg *fv1.Package) (
 	}
 
 	if input.IsSet(flagkey.PkgDeployArchive) || input.IsSet(flagkey.PkgCode) {
-		deployArchive, err := CreateArchive(client, input, deployArchiveFiles, noZip, insecure, deployChecksum, "", "")
+		deployArchive, err := CreateArchive(client, input, deployArchiveFiles, noZip, insecure, deployChecksum, "", "", &pkg.Spec.Deployment, pkg.ObjectMeta.Namespace)
 		if err != nil {
 			return nil, errors.Wrap(err, "error creating deploy archive")
 		}
 		pkg.Spec.Deployment = *deployArchive
+		// the source maps of a build don't map an uploaded archive
+		pkg.Spec.SourceMap = fv1.Archive{}
 		// Users may update the env, envNS and deploy archive at the same time,
 		// but without the source archive. In this case, we should set needToBuild to false
 		needToRebuild = false
@@ -165,6 +171,30 @@ func UpdatePackage(input cli.Input, client client.Interface, pkg *fv1.Package) (
 		needToUpdate = true
 	}
 
+	gitFlagsSet := input.IsSet(flagkey.PkgGitRef) || input.IsSet(flagkey.PkgGitSubPath) || input.IsSet(flagkey.PkgGitSecret)
+	if input.IsSet(flagkey.PkgSrcGit) || (gitFlagsSet && pkg.Spec.Source.Type == fv1.ArchiveTypeGit) {
+		pkg.Spec.Source = *gitArchive(input, input.String(flagkey.PkgSrcGit), &pkg.Spec.Source)
+		needToRebuild = true
+		needToUpdate = true
+	}
+	if input.IsSet(flagkey.PkgDeployGit) || (gitFlagsSet && pkg.Spec.Deployment.Type == fv1.ArchiveTypeGit) {
+		pkg.Spec.Deployment = *gitArchive(input, input.String(flagkey.PkgDeployGit), &pkg.Spec.Deployment)
+		pkg.Spec.SourceMap = fv1.Archive{}
+		needToRebuild = false
+		needToUpdate = true
+	}
+	if gitFlagsSet && pkg.Spec.Source.Type != fv1.ArchiveTypeGit && pkg.Spec.Deployment.Type != fv1.ArchiveTypeGit {
+		return nil, errors.Errorf("--%v, --%v and --%v need a git source or deploy archive", flagkey.PkgGitRef, flagkey.PkgGitSubPath, flagkey.PkgGitSecret)
+	}
+
+	if input.IsSet(flagkey.PkgArchiveSecret) {
+		err := setArchiveSecret(input, &pkg.Spec)
+		if err != nil {
+			return nil, err
+		}
+		needToUpdate = true
+	}
+
 	if !needToUpdate {
 		return &pkg.ObjectMeta, nil
 	}
@@ -178,6 +208,8 @@ func UpdatePackage(input cli.Input, client client.Interface, pkg *fv1.Package) (
 		}
 	}
 
+	util.WarnUnsupportedFeatures(client, "package", pkg.ObjectMeta.Name, info.PackageFeatures(&pkg.Spec))
+
 	newPkgMeta, err := client.V1().Package().Update(pkg)
 	if err != nil {
 		return nil, errors.Wrap(err, "update package")
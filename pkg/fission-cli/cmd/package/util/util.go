@@ -27,16 +27,23 @@ import (
 	"strings"
 	"text/tabwriter"
 
+	"github.com/dustin/go-humanize"
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 
+	"github.com/fission/fission/pkg/apiauth"
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/controller/client"
+	"github.com/fission/fission/pkg/fission-cli/console"
 	storageSvcClient "github.com/fission/fission/pkg/storagesvc/client"
 	"github.com/fission/fission/pkg/utils"
 )
 
-func UploadArchiveFile(ctx context.Context, client client.Interface, fileName string) (*fv1.Archive, error) {
+// UploadArchiveFile uploads the archive file, either as a literal or to the storage
+// service. If baseArchive was uploaded to the storage service before, the file is
+// uploaded as a delta against it. Large archives are uploaded in parts, in the
+// namespace of the package they are uploaded for.
+func UploadArchiveFile(ctx context.Context, client client.Interface, fileName string, namespace string, baseArchive *fv1.Archive) (*fv1.Archive, error) {
 	var archive fv1.Archive
 
 	size, err := utils.FileSize(fileName)
@@ -53,9 +60,21 @@ func UploadArchiveFile(ctx context.Context, client client.Interface, fileName st
 	} else {
 		u := strings.TrimSuffix(client.ServerURL(), "/") + "/proxy/storage"
 		ssClient := storageSvcClient.MakeClient(u)
+		ssClient.SetBearerToken(os.Getenv(apiauth.TokenEnv))
 
-		// TODO add a progress bar
-		id, err := ssClient.Upload(ctx, fileName, nil)
+		csum, err := utils.GetFileChecksum(fileName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "calculate checksum for file %v", fileName)
+		}
+
+		var id string
+		if baseID := getStorageArchiveID(baseArchive); len(baseID) > 0 {
+			id, err = ssClient.UploadDelta(ctx, fileName, baseID, nil)
+		} else if size >= storageSvcClient.MultipartThreshold {
+			id, err = uploadMultipart(ctx, ssClient, fileName, csum.Sum, namespace)
+		} else {
+			id, err = ssClient.Upload(ctx, fileName, nil)
+		}
 		if err != nil {
 			return nil, errors.Wrapf(err, "error uploading file %v", fileName)
 		}
@@ -73,16 +92,74 @@ func UploadArchiveFile(ctx context.Context, client client.Interface, fileName st
 
 		archive.Type = fv1.ArchiveTypeUrl
 		archive.URL = archiveURL
+		archive.Checksum = *csum
+	}
 
-		csum, err := utils.GetFileChecksum(fileName)
-		if err != nil {
-			return nil, errors.Wrapf(err, "calculate checksum for file %v", fileName)
+	return &archive, nil
+}
+
+// uploadMultipart uploads a large archive in parts, reporting the progress
+// of the upload. An upload interrupted is resumed by the next upload of the
+// same archive, until the storage service expires it.
+func uploadMultipart(ctx context.Context, ssClient *storageSvcClient.Client, fileName string, checksum string, namespace string) (string, error) {
+	stateFile := filepath.Join(os.TempDir(), "fission-upload-"+checksum)
+	progress := uploadProgress(fileName)
+
+	if uploadID, err := ioutil.ReadFile(stateFile); err == nil {
+		id, err := ssClient.ResumeMultipart(ctx, fileName, string(uploadID), progress)
+		if err == nil {
+			os.Remove(stateFile)
+			return id, nil
+		}
+		if errors.Cause(err) != storageSvcClient.ErrUploadNotFound {
+			return "", errors.Wrap(err, "upload interrupted, run the command again to resume it")
 		}
+		console.Verbose(2, "Upload %v of %v expired, starting over", string(uploadID), fileName)
+	}
 
-		archive.Checksum = *csum
+	u, err := ssClient.StartMultipart(ctx, fileName, namespace)
+	if err == storageSvcClient.ErrMultipartUnsupported {
+		return ssClient.Upload(ctx, fileName, nil)
+	} else if err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(stateFile, []byte(u.ID), 0600); err != nil {
+		console.Verbose(2, "Upload of %v can't be resumed: %v", fileName, err)
 	}
 
-	return &archive, nil
+	id, err := ssClient.ResumeMultipart(ctx, fileName, u.ID, progress)
+	if err != nil {
+		return "", errors.Wrap(err, "upload interrupted, run the command again to resume it")
+	}
+	os.Remove(stateFile)
+	return id, nil
+}
+
+// uploadProgress reports the progress of the upload of an archive every 10%.
+func uploadProgress(fileName string) storageSvcClient.Progress {
+	reported := -1
+	return func(uploaded int64, total int64) {
+		percent := int(uploaded * 100 / total)
+		if percent/10 == reported {
+			return
+		}
+		reported = percent / 10
+		fmt.Fprintf(os.Stderr, "Uploading %v: %v%% (%v of %v)\n", filepath.Base(fileName), percent,
+			humanize.Bytes(uint64(uploaded)), humanize.Bytes(uint64(total)))
+	}
+}
+
+// getStorageArchiveID returns the storage service ID of an archive, or an
+// empty string if the archive isn't stored in the storage service.
+func getStorageArchiveID(archive *fv1.Archive) string {
+	if archive == nil || archive.Type != fv1.ArchiveTypeUrl {
+		return ""
+	}
+	u, err := url.Parse(archive.URL)
+	if err != nil || !strings.HasSuffix(u.Path, "/v1/archive") {
+		return ""
+	}
+	return u.Query().Get("id")
 }
 
 func GetContents(filePath string) ([]byte, error) {
@@ -168,12 +245,35 @@ func DownloadStoragesvcURL(client client.Interface, fileUrl string) (io.ReadClos
 
 	// replace in-cluster storage service host with controller server url
 	fileDownloadUrl := strings.TrimSuffix(client.ServerURL(), "/") + "/proxy/storage/" + u.RequestURI()
-	reader, err := DownloadURL(fileDownloadUrl)
+	req, err := http.NewRequest(http.MethodGet, fileDownloadUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv(apiauth.TokenEnv); len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, errors.Wrapf(err, fmt.Sprintf("error downloading from storage service url: %v", fileUrl))
 	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%v - HTTP response returned non 200 status", resp.StatusCode)
+	}
 
-	return reader, nil
+	return resp.Body, nil
+}
+
+// DownloadArchive downloads the contents of a URL archive, through the
+// controller for archives in the storage service.
+func DownloadArchive(client client.Interface, archive *fv1.Archive) (io.ReadCloser, error) {
+	if utils.IsObjectStoreURL(archive.URL) {
+		return nil, errors.Errorf("archive %v is in an object store, only fetcher has its credentials", archive.URL)
+	}
+	if len(getStorageArchiveID(archive)) > 0 {
+		return DownloadStoragesvcURL(client, archive.URL)
+	}
+	return DownloadURL(archive.URL)
 }
 
 // PrintPackageSummary prints package information and build logs.
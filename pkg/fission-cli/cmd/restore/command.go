@@ -0,0 +1,62 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	wrapper "github.com/fission/fission/pkg/fission-cli/cliwrapper/driver/cobra"
+	"github.com/fission/fission/pkg/fission-cli/flag"
+	"github.com/fission/fission/pkg/tombstone"
+)
+
+// Commands returns restore commands
+func Commands() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore deleted objects from their tombstones",
+		Long: "Fission keeps the last spec of deleted functions, packages, environments and triggers " +
+			"for a retention window (7 days by default), restore brings them back.",
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List deleted objects that can be restored",
+		RunE:  wrapper.Wrapper(List),
+	}
+	wrapper.SetFlags(listCmd, flag.FlagSet{
+		Optional: []flag.Flag{flag.RestoreKind, flag.RestoreNamespace},
+	})
+	command.AddCommand(listCmd)
+
+	for _, kind := range tombstone.Kinds {
+		kindCmd := &cobra.Command{
+			Use:   fmt.Sprintf("%v NAME", kind),
+			Short: fmt.Sprintf("Restore a deleted %v", kind),
+			Args:  cobra.ExactArgs(1),
+			RunE:  wrapper.Wrapper(Restore(kind)),
+		}
+		wrapper.SetFlags(kindCmd, flag.FlagSet{
+			Optional: []flag.Flag{flag.RestoreNamespace, flag.RestoreUID},
+		})
+		command.AddCommand(kindCmd)
+	}
+
+	return command
+}
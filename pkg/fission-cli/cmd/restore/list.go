@@ -0,0 +1,62 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
+)
+
+type ListSubCommand struct {
+	cmd.CommandActioner
+}
+
+func List(input cli.Input) error {
+	return (&ListSubCommand{}).do(input)
+}
+
+func (opts *ListSubCommand) do(input cli.Input) error {
+	err := util.RequireServerFeature(opts.Client(), info.FeatureTombstones)
+	if err != nil {
+		return err
+	}
+
+	tombstones, err := opts.Client().V1().Misc().Tombstones(input.String(flagkey.RestoreKind), input.String(flagkey.RestoreNamespace), "")
+	if err != nil {
+		return errors.Wrap(err, "error listing deleted objects")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", "KIND", "NAMESPACE", "NAME", "UID", "DELETED", "EXPIRES")
+	for _, t := range tombstones {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", t.Kind, t.Namespace, t.Name, t.UID,
+			t.DeletedAt.Local().Format(time.RFC3339), t.ExpiresAt.Local().Format(time.RFC3339))
+	}
+	w.Flush()
+
+	return nil
+}
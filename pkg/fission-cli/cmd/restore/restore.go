@@ -0,0 +1,64 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
+	"github.com/fission/fission/pkg/tombstone"
+)
+
+type RestoreSubCommand struct {
+	cmd.CommandActioner
+	kind tombstone.Kind
+}
+
+// Restore returns the action restoring a deleted object of the kind.
+func Restore(kind tombstone.Kind) cmd.CommandAction {
+	return func(input cli.Input) error {
+		return (&RestoreSubCommand{kind: kind}).do(input)
+	}
+}
+
+func (opts *RestoreSubCommand) do(input cli.Input) error {
+	args := input.Args()
+	if len(args) != 1 {
+		return errors.Errorf("need the name of the %v to restore", opts.kind)
+	}
+	name := args[0]
+	namespace := input.String(flagkey.RestoreNamespace)
+
+	err := util.RequireServerFeature(opts.Client(), info.FeatureTombstones)
+	if err != nil {
+		return err
+	}
+
+	m, err := opts.Client().V1().Misc().Restore(string(opts.kind), namespace, name, input.String(flagkey.RestoreUID))
+	if err != nil {
+		return errors.Wrapf(err, "error restoring %v %v", opts.kind, name)
+	}
+
+	fmt.Printf("%v '%v' restored in namespace '%v'\n", opts.kind, m.Name, m.Namespace)
+	return nil
+}
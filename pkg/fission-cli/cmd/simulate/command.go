@@ -0,0 +1,50 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulate
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	wrapper "github.com/fission/fission/pkg/fission-cli/cliwrapper/driver/cobra"
+	"github.com/fission/fission/pkg/fission-cli/console"
+	"github.com/fission/fission/pkg/fission-cli/flag"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+)
+
+// Commands returns the simulate command
+func Commands() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "simulate",
+		Short: "Predict cold starts, pods and queue waits of functions under synthetic traffic",
+		Long: "Replay the traffic profile against a model of the executor, without creating any pods, " +
+			"and report the cold starts, pod counts and queue waits the pool and scale configuration " +
+			"of every function would lead to.",
+		// simulations run offline, without connecting to the server
+		PersistentPreRunE: wrapper.Wrapper(func(input cli.Input) error {
+			console.Verbosity = input.Int(flagkey.Verbosity)
+			return nil
+		}),
+		RunE: wrapper.Wrapper(Simulate),
+	}
+	wrapper.SetFlags(command, flag.FlagSet{
+		Required: []flag.Flag{flag.SimulateProfile},
+		Optional: []flag.Flag{flag.SimulateJSON},
+	})
+
+	return command
+}
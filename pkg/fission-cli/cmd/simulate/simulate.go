@@ -0,0 +1,71 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+	"github.com/fission/fission/pkg/simulator"
+)
+
+type SimulateSubCommand struct {
+	cmd.CommandActioner
+}
+
+func Simulate(input cli.Input) error {
+	return (&SimulateSubCommand{}).do(input)
+}
+
+func (opts *SimulateSubCommand) do(input cli.Input) error {
+	profile, err := simulator.LoadProfile(input.String(flagkey.SimulateProfile))
+	if err != nil {
+		return err
+	}
+
+	report, err := simulator.Simulate(profile)
+	if err != nil {
+		return errors.Wrap(err, "error running simulation")
+	}
+
+	if input.Bool(flagkey.SimulateJSON) {
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "error encoding report")
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+		"FUNCTION", "REQUESTS", "COLD_STARTS", "QUEUED", "MAX_PODS", "AVG_PODS", "WAIT_P50", "WAIT_P99", "WAIT_MAX")
+	for _, fn := range report.Functions {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%.2f\t%v\t%v\t%v\n",
+			fn.Name, fn.Requests, fn.ColdStarts, fn.Queued, fn.MaxPods, fn.AvgPods, fn.QueueWaitP50, fn.QueueWaitP99, fn.QueueWaitMax)
+	}
+	w.Flush()
+
+	return nil
+}
@@ -40,6 +40,7 @@ import (
 	"github.com/fission/fission/pkg/fission-cli/console"
 	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
 	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
 	"github.com/fission/fission/pkg/utils"
 )
 
@@ -67,11 +68,20 @@ func (opts *ApplySubCommand) do(input cli.Input) error {
 func (opts *ApplySubCommand) run(input cli.Input) error {
 	specDir := util.GetSpecDir(input)
 
-	deleteResources := input.Bool(flagkey.SpecDelete)
+	deleteResources := input.Bool(flagkey.SpecDelete) || input.Bool(flagkey.SpecPrune)
+	force := input.Bool(flagkey.SpecForce)
 	watchResources := input.Bool(flagkey.SpecWatch)
 	waitForBuild := input.Bool(flagkey.SpecWait)
 	validateSpecs := util.GetValidationFlag(input)
 
+	if input.Bool(flagkey.SpecDry) {
+		fr, err := readInputSpecs(input, specDir)
+		if err != nil {
+			return errors.Wrap(err, "error reading specs")
+		}
+		return previewApply(opts.Client(), specDir, fr, deleteResources)
+	}
+
 	var watcher *fsnotify.Watcher
 	var pbw *packageBuildWatcher
 
@@ -111,7 +121,7 @@ func (opts *ApplySubCommand) run(input cli.Input) error {
 
 	for {
 		// read all specs
-		fr, err := ReadSpecs(specDir)
+		fr, err := readInputSpecs(input, specDir)
 		if err != nil {
 			return errors.Wrap(err, "error reading specs")
 		}
@@ -124,7 +134,7 @@ func (opts *ApplySubCommand) run(input cli.Input) error {
 		}
 
 		// make changes to the cluster based on the specs
-		pkgMetas, as, err := applyResources(opts.Client(), specDir, fr, deleteResources)
+		pkgMetas, as, err := applyResources(opts.Client(), specDir, fr, deleteResources, force)
 		if err != nil {
 			return errors.Wrap(err, "error applying specs")
 		}
@@ -254,6 +264,13 @@ func pluralize(num int, word string) string {
 
 // applyArchives figures out the set of archives that need to be uploaded, and uploads them.
 func applyArchives(fclient client.Interface, specDir string, fr *FissionResources) error {
+	return resolveArchives(fclient, specDir, fr, true)
+}
+
+// resolveArchives resolves the archive references of packages to the
+// archives on the server with the same content, or to the local archives
+// to upload, uploading them if upload is true.
+func resolveArchives(fclient client.Interface, specDir string, fr *FissionResources, upload bool) error {
 
 	// archive:// URL -> archive map.
 	archiveFiles := make(map[string]fv1.Archive)
@@ -285,6 +302,16 @@ func applyArchives(fclient client.Interface, specDir string, fr *FissionResource
 		}
 	}
 
+	// large archives are uploaded in the namespace of a package using them
+	archiveNamespaces := make(map[string]string)
+	for _, pkg := range fr.Packages {
+		for _, ar := range []fv1.Archive{pkg.Spec.Source, pkg.Spec.Deployment} {
+			if _, ok := archiveNamespaces[ar.URL]; !ok && strings.HasPrefix(ar.URL, ARCHIVE_URL_PREFIX) {
+				archiveNamespaces[ar.URL] = pkg.ObjectMeta.Namespace
+			}
+		}
+	}
+
 	// upload archives that we need to, updating the map
 	for name, ar := range archiveFiles {
 		if ar.Type == fv1.ArchiveTypeLiteral {
@@ -292,19 +319,25 @@ func applyArchives(fclient client.Interface, specDir string, fr *FissionResource
 		}
 		// does the archive exist already?
 		if url, ok := availableArchives[ar.Checksum.Sum]; ok {
-			fmt.Printf("archive %v exists, not uploading\n", name)
+			if upload {
+				fmt.Printf("archive %v exists, not uploading\n", name)
+			}
 			ar.URL = url
 			archiveFiles[name] = ar
-		} else {
+		} else if upload {
 			// doesn't exist, upload
 			fmt.Printf("uploading archive %v\n", name)
 			// ar.URL is actually a local filename at this stage
 			ctx := context.Background()
-			uploadedAr, err := pkgutil.UploadArchiveFile(ctx, fclient, ar.URL)
+			uploadedAr, err := pkgutil.UploadArchiveFile(ctx, fclient, ar.URL, archiveNamespaces[name], nil)
 			if err != nil {
 				return err
 			}
 			archiveFiles[name] = *uploadedAr
+		} else {
+			// not uploaded, the package refers to the local archive by name
+			ar.URL = name
+			archiveFiles[name] = ar
 		}
 	}
 
@@ -326,8 +359,23 @@ func applyArchives(fclient client.Interface, specDir string, fr *FissionResource
 	return nil
 }
 
+// ApplySpecs reads the specs in specDir and applies them as spec apply
+// does, deleting the objects no longer in them if prune is true, for
+// components applying specs on their own, such as the gitops controller.
+func ApplySpecs(fclient client.Interface, specDir string, prune bool) (map[string]ResourceApplyStatus, error) {
+	fr, err := ReadSpecs(specDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading specs")
+	}
+	_, applyStatus, err := applyResources(fclient, specDir, fr, prune, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "error applying specs")
+	}
+	return applyStatus, nil
+}
+
 // applyResources applies the given set of fission resources.
-func applyResources(fclient client.Interface, specDir string, fr *FissionResources, delete bool) (map[string]metav1.ObjectMeta, map[string]ResourceApplyStatus, error) {
+func applyResources(fclient client.Interface, specDir string, fr *FissionResources, delete bool, force bool) (map[string]metav1.ObjectMeta, map[string]ResourceApplyStatus, error) {
 
 	applyStatus := make(map[string]ResourceApplyStatus)
 
@@ -337,13 +385,13 @@ func applyResources(fclient client.Interface, specDir string, fr *FissionResourc
 		return nil, nil, err
 	}
 
-	_, ras, err := applyEnvironments(fclient, fr, delete)
+	_, ras, err := applyEnvironments(fclient, fr, force)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "environment apply failed")
 	}
 	applyStatus["environment"] = *ras
 
-	pkgMeta, ras, err := applyPackages(fclient, fr, delete)
+	pkgMeta, ras, err := applyPackages(fclient, fr, force)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "package apply failed")
 	}
@@ -353,6 +401,9 @@ func applyResources(fclient client.Interface, specDir string, fr *FissionResourc
 	// of the package. This ensures that various caches can invalidate themselves
 	// when the package changes.
 	for i, f := range fr.Functions {
+		if f.Spec.Container != nil {
+			continue
+		}
 		k := mapKey(&metav1.ObjectMeta{
 			Namespace: f.Spec.Package.PackageRef.Namespace,
 			Name:      f.Spec.Package.PackageRef.Name,
@@ -369,36 +420,44 @@ func applyResources(fclient client.Interface, specDir string, fr *FissionResourc
 		fr.Functions[i].Spec.Package.PackageRef.ResourceVersion = m.ResourceVersion
 	}
 
-	_, ras, err = applyFunctions(fclient, fr, delete)
+	_, ras, err = applyFunctions(fclient, fr, force)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "function apply failed")
 	}
 	applyStatus["function"] = *ras
 
-	_, ras, err = applyHTTPTriggers(fclient, fr, delete)
+	_, ras, err = applyHTTPTriggers(fclient, fr, force)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "HTTPTrigger apply failed")
 	}
 	applyStatus["HTTPTrigger"] = *ras
 
-	_, ras, err = applyKubernetesWatchTriggers(fclient, fr, delete)
+	_, ras, err = applyKubernetesWatchTriggers(fclient, fr, force)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "KubernetesWatchTrigger apply failed")
 	}
 	applyStatus["KubernetesWatchTrigger"] = *ras
 
-	_, ras, err = applyTimeTriggers(fclient, fr, delete)
+	_, ras, err = applyTimeTriggers(fclient, fr, force)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "TimeTrigger apply failed")
 	}
 	applyStatus["TimeTrigger"] = *ras
 
-	_, ras, err = applyMessageQueueTriggers(fclient, fr, delete)
+	_, ras, err = applyMessageQueueTriggers(fclient, fr, force)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "MessageQueueTrigger apply failed")
 	}
 	applyStatus["MessageQueueTrigger"] = *ras
 
+	// delete what's no longer in the specs once the rest is applied
+	if delete {
+		err = pruneResources(fclient, fr, applyStatus)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "prune failed")
+		}
+	}
+
 	return pkgMeta, applyStatus, nil
 }
 
@@ -503,25 +562,6 @@ func mapKey(m *metav1.ObjectMeta) string {
 	return fmt.Sprintf("%v:%v", m.Namespace, m.Name)
 }
 
-func applyDeploymentConfig(m *metav1.ObjectMeta, fr *FissionResources) {
-	if m.Annotations == nil {
-		m.Annotations = make(map[string]string)
-	}
-	m.Annotations[FISSION_DEPLOYMENT_NAME_KEY] = fr.DeploymentConfig.Name
-	m.Annotations[FISSION_DEPLOYMENT_UID_KEY] = fr.DeploymentConfig.UID
-}
-
-func hasDeploymentConfig(m *metav1.ObjectMeta, fr *FissionResources) bool {
-	if m.Annotations == nil {
-		return false
-	}
-	uid, ok := m.Annotations[FISSION_DEPLOYMENT_UID_KEY]
-	if ok && uid == fr.DeploymentConfig.UID {
-		return true
-	}
-	return false
-}
-
 func waitForPackageBuild(fclient client.Interface, pkg *fv1.Package) (*fv1.Package, error) {
 	start := time.Now()
 	for {
@@ -543,7 +583,7 @@ func waitForPackageBuild(fclient client.Interface, pkg *fv1.Package) (*fv1.Packa
 	}
 }
 
-func applyPackages(fclient client.Interface, fr *FissionResources, delete bool) (map[string]metav1.ObjectMeta, *ResourceApplyStatus, error) {
+func applyPackages(fclient client.Interface, fr *FissionResources, force bool) (map[string]metav1.ObjectMeta, *ResourceApplyStatus, error) {
 	// get list
 	allObjs, err := fclient.V1().Package().List(metav1.NamespaceAll)
 	if err != nil {
@@ -565,22 +605,23 @@ func applyPackages(fclient client.Interface, fr *FissionResources, delete bool)
 	}
 	metadataMap := make(map[string]metav1.ObjectMeta)
 
-	// desired set. used to compute the set to delete.
-	desired := make(map[string]bool)
-
 	var ras ResourceApplyStatus
 
 	// create or update desired state
 	for _, o := range fr.Packages {
 		// apply deploymentConfig so we can find our objects on future apply invocations
 		applyDeploymentConfig(&o.ObjectMeta, fr)
-
-		// index desired state
-		desired[mapKey(&o.ObjectMeta)] = true
+		util.WarnUnsupportedFeatures(fclient, "package", o.ObjectMeta.Name, info.PackageFeatures(&o.Spec))
 
 		// exists?
 		existingObj, ok := existent[mapKey(&o.ObjectMeta)]
 		if ok {
+			// keep what was changed out of band and not in the specs since
+			err := mergeSpec("package", &existingObj.ObjectMeta, &existingObj.Spec, &o.ObjectMeta, &o.Spec, force)
+			if err != nil {
+				return nil, nil, err
+			}
+
 			// ok, a resource with the same name exists, is it the same?
 			keep := false
 			if reflect.DeepEqual(existingObj.Spec, o.Spec) {
@@ -588,7 +629,8 @@ func applyPackages(fclient client.Interface, fr *FissionResources, delete bool)
 			} else if reflect.DeepEqual(existingObj.Spec.Environment, o.Spec.Environment) &&
 				!reflect.DeepEqual(existingObj.Spec.Source, fv1.Archive{}) &&
 				reflect.DeepEqual(existingObj.Spec.Source, o.Spec.Source) &&
-				existingObj.Spec.BuildCommand == o.Spec.BuildCommand {
+				existingObj.Spec.BuildCommand == o.Spec.BuildCommand &&
+				reflect.DeepEqual(existingObj.Spec.BuildSteps, o.Spec.BuildSteps) {
 
 				keep = true
 			}
@@ -626,6 +668,10 @@ func applyPackages(fclient client.Interface, fr *FissionResources, delete bool)
 			}
 		} else {
 			// create
+			err := recordLastApplied(&o.ObjectMeta, &o.Spec)
+			if err != nil {
+				return nil, nil, err
+			}
 			newmeta, err := fclient.V1().Package().Create(&o)
 			if err != nil {
 				return nil, nil, err
@@ -635,26 +681,10 @@ func applyPackages(fclient client.Interface, fr *FissionResources, delete bool)
 		}
 	}
 
-	// deletes
-	if delete {
-		// objs is already filtered with our UID
-		for _, o := range objs {
-			_, wanted := desired[mapKey(&o.ObjectMeta)]
-			if !wanted {
-				err := fclient.V1().Package().Delete(&o.ObjectMeta)
-				if err != nil {
-					return nil, nil, err
-				}
-				ras.Deleted = append(ras.Deleted, &o.ObjectMeta)
-				fmt.Printf("Deleted %v %v/%v\n", o.TypeMeta.Kind, o.ObjectMeta.Namespace, o.ObjectMeta.Name)
-			}
-		}
-	}
-
 	return metadataMap, &ras, nil
 }
 
-func applyFunctions(fclient client.Interface, fr *FissionResources, delete bool) (map[string]metav1.ObjectMeta, *ResourceApplyStatus, error) {
+func applyFunctions(fclient client.Interface, fr *FissionResources, force bool) (map[string]metav1.ObjectMeta, *ResourceApplyStatus, error) {
 	// get list
 	allObjs, err := fclient.V1().Function().List(metav1.NamespaceAll)
 	if err != nil {
@@ -676,24 +706,25 @@ func applyFunctions(fclient client.Interface, fr *FissionResources, delete bool)
 	}
 	metadataMap := make(map[string]metav1.ObjectMeta)
 
-	// desired set. used to compute the set to delete.
-	desired := make(map[string]bool)
-
 	var ras ResourceApplyStatus
 
 	// create or update desired state
 	for _, o := range fr.Functions {
 		// apply deploymentConfig so we can find our objects on future apply invocations
 		applyDeploymentConfig(&o.ObjectMeta, fr)
-
-		// index desired state
-		desired[mapKey(&o.ObjectMeta)] = true
+		util.WarnUnsupportedFeatures(fclient, "function", o.ObjectMeta.Name, info.FunctionFeatures(&o.Spec))
 
 		// exists?
 		existingObj, ok := existent[mapKey(&o.ObjectMeta)]
 		if ok {
+			// keep what was changed out of band and not in the specs since
+			err := mergeSpec("function", &existingObj.ObjectMeta, &existingObj.Spec, &o.ObjectMeta, &o.Spec, force)
+			if err != nil {
+				return nil, nil, err
+			}
+
 			// ok, a resource with the same name exists, is it the same?
-			if reflect.DeepEqual(existingObj.Spec, o.Spec) {
+			if reflect.DeepEqual(existingObj.Spec, o.Spec) && !metadataChanged(&existingObj.ObjectMeta, &o.ObjectMeta) {
 				// nothing to do on the server
 				metadataMap[mapKey(&o.ObjectMeta)] = existingObj.ObjectMeta
 			} else {
@@ -709,6 +740,10 @@ func applyFunctions(fclient client.Interface, fr *FissionResources, delete bool)
 			}
 		} else {
 			// create
+			err := recordLastApplied(&o.ObjectMeta, &o.Spec)
+			if err != nil {
+				return nil, nil, err
+			}
 			newmeta, err := fclient.V1().Function().Create(&o)
 			if err != nil {
 				return nil, nil, err
@@ -718,26 +753,10 @@ func applyFunctions(fclient client.Interface, fr *FissionResources, delete bool)
 		}
 	}
 
-	// deletes
-	if delete {
-		// objs is already filtered with our UID
-		for _, o := range objs {
-			_, wanted := desired[mapKey(&o.ObjectMeta)]
-			if !wanted {
-				err := fclient.V1().Function().Delete(&o.ObjectMeta)
-				if err != nil {
-					return nil, nil, err
-				}
-				ras.Deleted = append(ras.Deleted, &o.ObjectMeta)
-				fmt.Printf("Deleted %v %v/%v\n", o.TypeMeta.Kind, o.ObjectMeta.Namespace, o.ObjectMeta.Name)
-			}
-		}
-	}
-
 	return metadataMap, &ras, nil
 }
 
-func applyEnvironments(fclient client.Interface, fr *FissionResources, delete bool) (map[string]metav1.ObjectMeta, *ResourceApplyStatus, error) {
+func applyEnvironments(fclient client.Interface, fr *FissionResources, force bool) (map[string]metav1.ObjectMeta, *ResourceApplyStatus, error) {
 	// get list
 	allObjs, err := fclient.V1().Environment().List(metav1.NamespaceAll)
 	if err != nil {
@@ -759,24 +778,25 @@ func applyEnvironments(fclient client.Interface, fr *FissionResources, delete bo
 	}
 	metadataMap := make(map[string]metav1.ObjectMeta)
 
-	// desired set. used to compute the set to delete.
-	desired := make(map[string]bool)
-
 	var ras ResourceApplyStatus
 
 	// create or update desired state
 	for _, o := range fr.Environments {
 		// apply deploymentConfig so we can find our objects on future apply invocations
 		applyDeploymentConfig(&o.ObjectMeta, fr)
-
-		// index desired state
-		desired[mapKey(&o.ObjectMeta)] = true
+		util.WarnUnsupportedFeatures(fclient, "environment", o.ObjectMeta.Name, info.EnvironmentFeatures(&o.Spec))
 
 		// exists?
 		existingObj, ok := existent[mapKey(&o.ObjectMeta)]
 		if ok {
+			// keep what was changed out of band and not in the specs since
+			err := mergeSpec("environment", &existingObj.ObjectMeta, &existingObj.Spec, &o.ObjectMeta, &o.Spec, force)
+			if err != nil {
+				return nil, nil, err
+			}
+
 			// ok, a resource with the same name exists, is it the same?
-			if reflect.DeepEqual(existingObj.Spec, o.Spec) {
+			if reflect.DeepEqual(existingObj.Spec, o.Spec) && !metadataChanged(&existingObj.ObjectMeta, &o.ObjectMeta) {
 				// nothing to do on the server
 				metadataMap[mapKey(&o.ObjectMeta)] = existingObj.ObjectMeta
 			} else {
@@ -792,6 +812,10 @@ func applyEnvironments(fclient client.Interface, fr *FissionResources, delete bo
 			}
 		} else {
 			// create
+			err := recordLastApplied(&o.ObjectMeta, &o.Spec)
+			if err != nil {
+				return nil, nil, err
+			}
 			newmeta, err := fclient.V1().Environment().Create(&o)
 			if err != nil {
 				return nil, nil, err
@@ -801,26 +825,10 @@ func applyEnvironments(fclient client.Interface, fr *FissionResources, delete bo
 		}
 	}
 
-	// deletes
-	if delete {
-		// objs is already filtered with our UID
-		for _, o := range objs {
-			_, wanted := desired[mapKey(&o.ObjectMeta)]
-			if !wanted {
-				err := fclient.V1().Environment().Delete(&o.ObjectMeta)
-				if err != nil {
-					return nil, nil, err
-				}
-				ras.Deleted = append(ras.Deleted, &o.ObjectMeta)
-				fmt.Printf("Deleted %v %v/%v\n", o.TypeMeta.Kind, o.ObjectMeta.Namespace, o.ObjectMeta.Name)
-			}
-		}
-	}
-
 	return metadataMap, &ras, nil
 }
 
-func applyHTTPTriggers(fclient client.Interface, fr *FissionResources, delete bool) (map[string]metav1.ObjectMeta, *ResourceApplyStatus, error) {
+func applyHTTPTriggers(fclient client.Interface, fr *FissionResources, force bool) (map[string]metav1.ObjectMeta, *ResourceApplyStatus, error) {
 	// get list
 	allObjs, err := fclient.V1().HTTPTrigger().List(metav1.NamespaceAll)
 	if err != nil {
@@ -842,24 +850,25 @@ func applyHTTPTriggers(fclient client.Interface, fr *FissionResources, delete bo
 	}
 	metadataMap := make(map[string]metav1.ObjectMeta)
 
-	// desired set. used to compute the set to delete.
-	desired := make(map[string]bool)
-
 	var ras ResourceApplyStatus
 
 	// create or update desired state
 	for _, o := range fr.HttpTriggers {
 		// apply deploymentConfig so we can find our objects on future apply invocations
 		applyDeploymentConfig(&o.ObjectMeta, fr)
-
-		// index desired state
-		desired[mapKey(&o.ObjectMeta)] = true
+		util.WarnUnsupportedFeatures(fclient, "HTTP trigger", o.ObjectMeta.Name, info.HTTPTriggerFeatures(&o.Spec))
 
 		// exists?
 		existingObj, ok := existent[mapKey(&o.ObjectMeta)]
 		if ok {
+			// keep what was changed out of band and not in the specs since
+			err := mergeSpec("HTTP trigger", &existingObj.ObjectMeta, &existingObj.Spec, &o.ObjectMeta, &o.Spec, force)
+			if err != nil {
+				return nil, nil, err
+			}
+
 			// ok, a resource with the same name exists, is it the same?
-			if reflect.DeepEqual(existingObj.Spec, o.Spec) {
+			if reflect.DeepEqual(existingObj.Spec, o.Spec) && !metadataChanged(&existingObj.ObjectMeta, &o.ObjectMeta) {
 				// nothing to do on the server
 				metadataMap[mapKey(&o.ObjectMeta)] = existingObj.ObjectMeta
 			} else {
@@ -875,6 +884,10 @@ func applyHTTPTriggers(fclient client.Interface, fr *FissionResources, delete bo
 			}
 		} else {
 			// create
+			err := recordLastApplied(&o.ObjectMeta, &o.Spec)
+			if err != nil {
+				return nil, nil, err
+			}
 			newmeta, err := fclient.V1().HTTPTrigger().Create(&o)
 			if err != nil {
 				return nil, nil, err
@@ -884,26 +897,10 @@ func applyHTTPTriggers(fclient client.Interface, fr *FissionResources, delete bo
 		}
 	}
 
-	// deletes
-	if delete {
-		// objs is already filtered with our UID
-		for _, o := range objs {
-			_, wanted := desired[mapKey(&o.ObjectMeta)]
-			if !wanted {
-				err := fclient.V1().HTTPTrigger().Delete(&o.ObjectMeta)
-				if err != nil {
-					return nil, nil, err
-				}
-				ras.Deleted = append(ras.Deleted, &o.ObjectMeta)
-				fmt.Printf("Deleted %v %v/%v\n", o.TypeMeta.Kind, o.ObjectMeta.Namespace, o.ObjectMeta.Name)
-			}
-		}
-	}
-
 	return metadataMap, &ras, nil
 }
 
-func applyKubernetesWatchTriggers(fclient client.Interface, fr *FissionResources, delete bool) (map[string]metav1.ObjectMeta, *ResourceApplyStatus, error) {
+func applyKubernetesWatchTriggers(fclient client.Interface, fr *FissionResources, force bool) (map[string]metav1.ObjectMeta, *ResourceApplyStatus, error) {
 	// get list
 	allObjs, err := fclient.V1().KubeWatcher().List(metav1.NamespaceAll)
 	if err != nil {
@@ -925,9 +922,6 @@ func applyKubernetesWatchTriggers(fclient client.Interface, fr *FissionResources
 	}
 	metadataMap := make(map[string]metav1.ObjectMeta)
 
-	// desired set. used to compute the set to delete.
-	desired := make(map[string]bool)
-
 	var ras ResourceApplyStatus
 
 	// create or update desired state
@@ -935,14 +929,17 @@ func applyKubernetesWatchTriggers(fclient client.Interface, fr *FissionResources
 		// apply deploymentConfig so we can find our objects on future apply invocations
 		applyDeploymentConfig(&o.ObjectMeta, fr)
 
-		// index desired state
-		desired[mapKey(&o.ObjectMeta)] = true
-
 		// exists?
 		existingObj, ok := existent[mapKey(&o.ObjectMeta)]
 		if ok {
+			// keep what was changed out of band and not in the specs since
+			err := mergeSpec("Kubernetes watch trigger", &existingObj.ObjectMeta, &existingObj.Spec, &o.ObjectMeta, &o.Spec, force)
+			if err != nil {
+				return nil, nil, err
+			}
+
 			// ok, a resource with the same name exists, is it the same?
-			if reflect.DeepEqual(existingObj.Spec, o.Spec) {
+			if reflect.DeepEqual(existingObj.Spec, o.Spec) && !metadataChanged(&existingObj.ObjectMeta, &o.ObjectMeta) {
 				// nothing to do on the server
 				metadataMap[mapKey(&o.ObjectMeta)] = existingObj.ObjectMeta
 			} else {
@@ -958,6 +955,10 @@ func applyKubernetesWatchTriggers(fclient client.Interface, fr *FissionResources
 			}
 		} else {
 			// create
+			err := recordLastApplied(&o.ObjectMeta, &o.Spec)
+			if err != nil {
+				return nil, nil, err
+			}
 			newmeta, err := fclient.V1().KubeWatcher().Create(&o)
 			if err != nil {
 				return nil, nil, err
@@ -967,26 +968,10 @@ func applyKubernetesWatchTriggers(fclient client.Interface, fr *FissionResources
 		}
 	}
 
-	// deletes
-	if delete {
-		// objs is already filtered with our UID
-		for _, o := range objs {
-			_, wanted := desired[mapKey(&o.ObjectMeta)]
-			if !wanted {
-				err := fclient.V1().KubeWatcher().Delete(&o.ObjectMeta)
-				if err != nil {
-					return nil, nil, err
-				}
-				ras.Deleted = append(ras.Deleted, &o.ObjectMeta)
-				fmt.Printf("Deleted %v %v/%v\n", o.TypeMeta.Kind, o.ObjectMeta.Namespace, o.ObjectMeta.Name)
-			}
-		}
-	}
-
 	return metadataMap, &ras, nil
 }
 
-func applyTimeTriggers(fclient client.Interface, fr *FissionResources, delete bool) (map[string]metav1.ObjectMeta, *ResourceApplyStatus, error) {
+func applyTimeTriggers(fclient client.Interface, fr *FissionResources, force bool) (map[string]metav1.ObjectMeta, *ResourceApplyStatus, error) {
 	// get list
 	allObjs, err := fclient.V1().TimeTrigger().List(metav1.NamespaceAll)
 	if err != nil {
@@ -1008,9 +993,6 @@ func applyTimeTriggers(fclient client.Interface, fr *FissionResources, delete bo
 	}
 	metadataMap := make(map[string]metav1.ObjectMeta)
 
-	// desired set. used to compute the set to delete.
-	desired := make(map[string]bool)
-
 	var ras ResourceApplyStatus
 
 	// create or update desired state
@@ -1018,14 +1000,17 @@ func applyTimeTriggers(fclient client.Interface, fr *FissionResources, delete bo
 		// apply deploymentConfig so we can find our objects on future apply invocations
 		applyDeploymentConfig(&o.ObjectMeta, fr)
 
-		// index desired state
-		desired[mapKey(&o.ObjectMeta)] = true
-
 		// exists?
 		existingObj, ok := existent[mapKey(&o.ObjectMeta)]
 		if ok {
+			// keep what was changed out of band and not in the specs since
+			err := mergeSpec("time trigger", &existingObj.ObjectMeta, &existingObj.Spec, &o.ObjectMeta, &o.Spec, force)
+			if err != nil {
+				return nil, nil, err
+			}
+
 			// ok, a resource with the same name exists, is it the same?
-			if reflect.DeepEqual(existingObj.Spec, o.Spec) {
+			if reflect.DeepEqual(existingObj.Spec, o.Spec) && !metadataChanged(&existingObj.ObjectMeta, &o.ObjectMeta) {
 				// nothing to do on the server
 				metadataMap[mapKey(&o.ObjectMeta)] = existingObj.ObjectMeta
 			} else {
@@ -1041,6 +1026,10 @@ func applyTimeTriggers(fclient client.Interface, fr *FissionResources, delete bo
 			}
 		} else {
 			// create
+			err := recordLastApplied(&o.ObjectMeta, &o.Spec)
+			if err != nil {
+				return nil, nil, err
+			}
 			newmeta, err := fclient.V1().TimeTrigger().Create(&o)
 			if err != nil {
 				return nil, nil, err
@@ -1050,26 +1039,10 @@ func applyTimeTriggers(fclient client.Interface, fr *FissionResources, delete bo
 		}
 	}
 
-	// deletes
-	if delete {
-		// objs is already filtered with our UID
-		for _, o := range objs {
-			_, wanted := desired[mapKey(&o.ObjectMeta)]
-			if !wanted {
-				err := fclient.V1().TimeTrigger().Delete(&o.ObjectMeta)
-				if err != nil {
-					return nil, nil, err
-				}
-				ras.Deleted = append(ras.Deleted, &o.ObjectMeta)
-				fmt.Printf("Deleted %v %v/%v\n", o.TypeMeta.Kind, o.ObjectMeta.Namespace, o.ObjectMeta.Name)
-			}
-		}
-	}
-
 	return metadataMap, &ras, nil
 }
 
-func applyMessageQueueTriggers(fclient client.Interface, fr *FissionResources, delete bool) (map[string]metav1.ObjectMeta, *ResourceApplyStatus, error) {
+func applyMessageQueueTriggers(fclient client.Interface, fr *FissionResources, force bool) (map[string]metav1.ObjectMeta, *ResourceApplyStatus, error) {
 	// get list
 	allObjs, err := fclient.V1().MessageQueueTrigger().List("", metav1.NamespaceAll)
 	if err != nil {
@@ -1091,9 +1064,6 @@ func applyMessageQueueTriggers(fclient client.Interface, fr *FissionResources, d
 	}
 	metadataMap := make(map[string]metav1.ObjectMeta)
 
-	// desired set. used to compute the set to delete.
-	desired := make(map[string]bool)
-
 	var ras ResourceApplyStatus
 
 	// create or update desired state
@@ -1101,14 +1071,17 @@ func applyMessageQueueTriggers(fclient client.Interface, fr *FissionResources, d
 		// apply deploymentConfig so we can find our objects on future apply invocations
 		applyDeploymentConfig(&o.ObjectMeta, fr)
 
-		// index desired state
-		desired[mapKey(&o.ObjectMeta)] = true
-
 		// exists?
 		existingObj, ok := existent[mapKey(&o.ObjectMeta)]
 		if ok {
+			// keep what was changed out of band and not in the specs since
+			err := mergeSpec("message queue trigger", &existingObj.ObjectMeta, &existingObj.Spec, &o.ObjectMeta, &o.Spec, force)
+			if err != nil {
+				return nil, nil, err
+			}
+
 			// ok, a resource with the same name exists, is it the same?
-			if reflect.DeepEqual(existingObj.Spec, o.Spec) {
+			if reflect.DeepEqual(existingObj.Spec, o.Spec) && !metadataChanged(&existingObj.ObjectMeta, &o.ObjectMeta) {
 				// nothing to do on the server
 				metadataMap[mapKey(&o.ObjectMeta)] = existingObj.ObjectMeta
 			} else {
@@ -1124,6 +1097,10 @@ func applyMessageQueueTriggers(fclient client.Interface, fr *FissionResources, d
 			}
 		} else {
 			// create
+			err := recordLastApplied(&o.ObjectMeta, &o.Spec)
+			if err != nil {
+				return nil, nil, err
+			}
 			newmeta, err := fclient.V1().MessageQueueTrigger().Create(&o)
 			if err != nil {
 				return nil, nil, err
@@ -1133,21 +1110,5 @@ func applyMessageQueueTriggers(fclient client.Interface, fr *FissionResources, d
 		}
 	}
 
-	// deletes
-	if delete {
-		// objs is already filtered with our UID
-		for _, o := range objs {
-			_, wanted := desired[mapKey(&o.ObjectMeta)]
-			if !wanted {
-				err := fclient.V1().MessageQueueTrigger().Delete(&o.ObjectMeta)
-				if err != nil {
-					return nil, nil, err
-				}
-				ras.Deleted = append(ras.Deleted, &o.ObjectMeta)
-				fmt.Printf("Deleted %v %v/%v\n", o.TypeMeta.Kind, o.ObjectMeta.Namespace, o.ObjectMeta.Name)
-			}
-		}
-	}
-
 	return metadataMap, &ras, nil
 }
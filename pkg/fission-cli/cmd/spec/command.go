@@ -30,7 +30,7 @@ func Commands() *cobra.Command {
 		RunE:  wrapper.Wrapper(Init),
 	}
 	wrapper.SetFlags(initCmd, flag.FlagSet{
-		Optional: []flag.Flag{flag.SpecName, flag.SpecDeployID, flag.SpecDir},
+		Optional: []flag.Flag{flag.SpecName, flag.SpecDeployID, flag.SpecDir, flag.SpecValues, flag.SpecSet},
 	})
 
 	validateCmd := &cobra.Command{
@@ -39,7 +39,7 @@ func Commands() *cobra.Command {
 		RunE:  wrapper.Wrapper(Validate),
 	}
 	wrapper.SetFlags(validateCmd, flag.FlagSet{
-		Optional: []flag.Flag{flag.SpecDir},
+		Optional: []flag.Flag{flag.SpecDir, flag.SpecValues, flag.SpecSet},
 	})
 
 	applyCmd := &cobra.Command{
@@ -48,7 +48,31 @@ func Commands() *cobra.Command {
 		RunE:  wrapper.Wrapper(Apply),
 	}
 	wrapper.SetFlags(applyCmd, flag.FlagSet{
-		Optional: []flag.Flag{flag.SpecDir, flag.SpecDelete, flag.SpecWait, flag.SpecWatch, flag.SpecValidation},
+		Optional: []flag.Flag{flag.SpecDir, flag.SpecDelete, flag.SpecPrune, flag.SpecApplyDry, flag.SpecWait, flag.SpecWatch, flag.SpecValidation, flag.SpecForce, flag.SpecValues, flag.SpecSet},
+	})
+
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Show what apply would change on the cluster",
+		Long: "Show the resources apply would create, update and delete, with the fields of the updated ones, " +
+			"archive checksums included. Fields changed out of band since the last apply and in the specs " +
+			"too are conflicts, which apply overwrites only with --force.",
+		RunE: wrapper.Wrapper(Diff),
+	}
+	wrapper.SetFlags(diffCmd, flag.FlagSet{
+		Optional: []flag.Flag{flag.SpecDir, flag.SpecDelete, flag.SpecPrune, flag.SpecValues, flag.SpecSet},
+	})
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export resources on the cluster to a new application specification",
+		Long: "Write the Fission resources of a namespace, or of all namespaces, to spec files in a new spec " +
+			"directory, so resources created with the CLI can be managed declaratively. With --adopt, " +
+			"the resources are marked as applied from the specs, for 'fission spec apply' to update them.",
+		RunE: wrapper.Wrapper(Export),
+	}
+	wrapper.SetFlags(exportCmd, flag.FlagSet{
+		Optional: []flag.Flag{flag.SpecExportNamespace, flag.SpecDir, flag.SpecName, flag.SpecDeployID, flag.SpecExportDownload, flag.SpecExportAdopt},
 	})
 
 	destroyCmd := &cobra.Command{
@@ -57,7 +81,7 @@ func Commands() *cobra.Command {
 		RunE:  wrapper.Wrapper(Destroy),
 	}
 	wrapper.SetFlags(destroyCmd, flag.FlagSet{
-		Optional: []flag.Flag{flag.SpecDir},
+		Optional: []flag.Flag{flag.SpecDir, flag.SpecValues, flag.SpecSet},
 	})
 
 	listCmd := &cobra.Command{
@@ -66,7 +90,7 @@ func Commands() *cobra.Command {
 		RunE:  wrapper.Wrapper(List),
 	}
 	wrapper.SetFlags(listCmd, flag.FlagSet{
-		Optional: []flag.Flag{flag.SpecDeployID, flag.SpecDir},
+		Optional: []flag.Flag{flag.SpecDeployID, flag.SpecDir, flag.SpecValues, flag.SpecSet},
 	})
 
 	command := &cobra.Command{
@@ -75,7 +99,7 @@ func Commands() *cobra.Command {
 		Short:   "Manage a declarative application specification",
 	}
 
-	command.AddCommand(initCmd, validateCmd, applyCmd, listCmd, destroyCmd)
+	command.AddCommand(initCmd, validateCmd, diffCmd, applyCmd, exportCmd, listCmd, destroyCmd)
 
 	return command
 }
@@ -42,7 +42,7 @@ func (opts *DestroySubCommand) run(input cli.Input) error {
 	specDir := util.GetSpecDir(input)
 
 	// read everything
-	fr, err := ReadSpecs(specDir)
+	fr, err := readInputSpecs(input, specDir)
 	if err != nil {
 		return errors.Wrap(err, "error reading specs")
 	}
@@ -52,7 +52,7 @@ func (opts *DestroySubCommand) run(input cli.Input) error {
 	emptyFr.DeploymentConfig = fr.DeploymentConfig
 
 	// "apply" the empty state
-	_, _, err = applyResources(opts.Client(), specDir, &emptyFr, true)
+	_, _, err = applyResources(opts.Client(), specDir, &emptyFr, true, false)
 	if err != nil {
 		return errors.Wrap(err, "error deleting resources")
 	}
@@ -0,0 +1,286 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fission/fission/pkg/controller/client"
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+	"github.com/fission/fission/pkg/fission-cli/util"
+)
+
+// maxDiffValueLength is how much of the values of changed fields is shown.
+const maxDiffValueLength = 80
+
+type (
+	DiffSubCommand struct {
+		cmd.CommandActioner
+	}
+
+	// specObject is an object of the specs, or on the server.
+	specObject struct {
+		kind string
+		meta *metav1.ObjectMeta
+		spec interface{}
+	}
+
+	// objectDiff is what apply would do to an object.
+	objectDiff struct {
+		kind      string
+		meta      *metav1.ObjectMeta
+		create    bool
+		delete    bool
+		changes   []specChange
+		conflicts []specConflict
+	}
+)
+
+// Diff shows what apply would change on the server: the objects it would
+// create, update and delete, and the fields of the updated ones, archive
+// checksums included.
+func Diff(input cli.Input) error {
+	return (&DiffSubCommand{}).do(input)
+}
+
+func (opts *DiffSubCommand) do(input cli.Input) error {
+	specDir := util.GetSpecDir(input)
+
+	fr, err := readInputSpecs(input, specDir)
+	if err != nil {
+		return errors.Wrap(err, "error reading specs")
+	}
+
+	return previewApply(opts.Client(), specDir, fr, input.Bool(flagkey.SpecDelete) || input.Bool(flagkey.SpecPrune))
+}
+
+// previewApply prints what applying the specs would change, changing
+// nothing.
+func previewApply(fclient client.Interface, specDir string, fr *FissionResources, prune bool) error {
+	// archives are compared by checksum, without uploading them
+	err := resolveArchives(fclient, specDir, fr, false)
+	if err != nil {
+		return errors.Wrap(err, "error resolving archives")
+	}
+
+	live, err := liveObjects(fclient, fr)
+	if err != nil {
+		return errors.Wrap(err, "error getting resources")
+	}
+
+	diffs, err := diffObjects(desiredObjects(fr), live, prune)
+	if err != nil {
+		return err
+	}
+	printDiffs(os.Stdout, diffs)
+	return nil
+}
+
+// desiredObjects returns the objects of the specs, in the order apply
+// applies them.
+func desiredObjects(fr *FissionResources) []specObject {
+	var objs []specObject
+	for i := range fr.Environments {
+		objs = append(objs, specObject{"environment", &fr.Environments[i].ObjectMeta, &fr.Environments[i].Spec})
+	}
+	for i := range fr.Packages {
+		objs = append(objs, specObject{"package", &fr.Packages[i].ObjectMeta, &fr.Packages[i].Spec})
+	}
+	for i := range fr.Functions {
+		objs = append(objs, specObject{"function", &fr.Functions[i].ObjectMeta, &fr.Functions[i].Spec})
+	}
+	for i := range fr.HttpTriggers {
+		objs = append(objs, specObject{"HTTP trigger", &fr.HttpTriggers[i].ObjectMeta, &fr.HttpTriggers[i].Spec})
+	}
+	for i := range fr.KubernetesWatchTriggers {
+		objs = append(objs, specObject{"Kubernetes watch trigger", &fr.KubernetesWatchTriggers[i].ObjectMeta, &fr.KubernetesWatchTriggers[i].Spec})
+	}
+	for i := range fr.TimeTriggers {
+		objs = append(objs, specObject{"time trigger", &fr.TimeTriggers[i].ObjectMeta, &fr.TimeTriggers[i].Spec})
+	}
+	for i := range fr.MessageQueueTriggers {
+		objs = append(objs, specObject{"message queue trigger", &fr.MessageQueueTriggers[i].ObjectMeta, &fr.MessageQueueTriggers[i].Spec})
+	}
+	return objs
+}
+
+// liveObjects returns the objects on the server applied from the specs,
+// keyed by kind, namespace and name.
+func liveObjects(fclient client.Interface, fr *FissionResources) (map[string]specObject, error) {
+	objs := make(map[string]specObject)
+	add := func(kind string, m *metav1.ObjectMeta, spec interface{}) {
+		if hasDeploymentConfig(m, fr) {
+			objs[objectKey(kind, m)] = specObject{kind, m, spec}
+		}
+	}
+
+	envs, err := fclient.V1().Environment().List(metav1.NamespaceAll)
+	if err != nil {
+		return nil, err
+	}
+	for i := range envs {
+		add("environment", &envs[i].ObjectMeta, &envs[i].Spec)
+	}
+	pkgs, err := fclient.V1().Package().List(metav1.NamespaceAll)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pkgs {
+		add("package", &pkgs[i].ObjectMeta, &pkgs[i].Spec)
+	}
+	fns, err := fclient.V1().Function().List(metav1.NamespaceAll)
+	if err != nil {
+		return nil, err
+	}
+	for i := range fns {
+		add("function", &fns[i].ObjectMeta, &fns[i].Spec)
+	}
+	hts, err := fclient.V1().HTTPTrigger().List(metav1.NamespaceAll)
+	if err != nil {
+		return nil, err
+	}
+	for i := range hts {
+		add("HTTP trigger", &hts[i].ObjectMeta, &hts[i].Spec)
+	}
+	kws, err := fclient.V1().KubeWatcher().List(metav1.NamespaceAll)
+	if err != nil {
+		return nil, err
+	}
+	for i := range kws {
+		add("Kubernetes watch trigger", &kws[i].ObjectMeta, &kws[i].Spec)
+	}
+	tts, err := fclient.V1().TimeTrigger().List(metav1.NamespaceAll)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tts {
+		add("time trigger", &tts[i].ObjectMeta, &tts[i].Spec)
+	}
+	mqts, err := fclient.V1().MessageQueueTrigger().List("", metav1.NamespaceAll)
+	if err != nil {
+		return nil, err
+	}
+	for i := range mqts {
+		add("message queue trigger", &mqts[i].ObjectMeta, &mqts[i].Spec)
+	}
+	return objs, nil
+}
+
+func objectKey(kind string, m *metav1.ObjectMeta) string {
+	return kind + "/" + mapKey(m)
+}
+
+// diffObjects returns what apply would do to the live objects to match the
+// desired ones, the objects left as they are left out.
+func diffObjects(desired []specObject, live map[string]specObject, prune bool) ([]objectDiff, error) {
+	var diffs []objectDiff
+	for _, d := range desired {
+		l, ok := live[objectKey(d.kind, d.meta)]
+		if !ok {
+			diffs = append(diffs, objectDiff{kind: d.kind, meta: d.meta, create: true})
+			continue
+		}
+
+		desiredValue, err := toJSONValue(d.spec)
+		if err != nil {
+			return nil, err
+		}
+		liveValue, err := toJSONValue(l.spec)
+		if err != nil {
+			return nil, err
+		}
+		merged := desiredValue
+		var conflicts []specConflict
+		if applied, ok := getLastApplied(l.meta); ok {
+			merged, conflicts = threeWayMerge(applied, liveValue, desiredValue, ignoredPaths[d.kind])
+		}
+		changes := diffSpec(liveValue, merged, ignoredPaths[d.kind])
+		if len(changes) > 0 || len(conflicts) > 0 {
+			diffs = append(diffs, objectDiff{kind: d.kind, meta: d.meta, changes: changes, conflicts: conflicts})
+		}
+	}
+
+	if prune {
+		for _, o := range prunedObjects(desired, live) {
+			diffs = append(diffs, objectDiff{kind: o.kind, meta: o.meta, delete: true})
+		}
+	}
+	return diffs, nil
+}
+
+// printDiffs prints what apply would do, one object at a time.
+func printDiffs(w io.Writer, diffs []objectDiff) {
+	if len(diffs) == 0 {
+		fmt.Fprintln(w, "Everything up to date.")
+		return
+	}
+
+	var created, updated, deleted, conflicted int
+	for _, d := range diffs {
+		name := fmt.Sprintf("%v %v/%v", d.kind, d.meta.Namespace, d.meta.Name)
+		switch {
+		case d.create:
+			created++
+			fmt.Fprintf(w, "+ %v\n", name)
+		case d.delete:
+			deleted++
+			fmt.Fprintf(w, "- %v\n", name)
+		default:
+			updated++
+			fmt.Fprintf(w, "~ %v\n", name)
+			for _, c := range d.changes {
+				fmt.Fprintf(w, "    %v: %v => %v\n", c.Path, formatDiffValue(c.Old), formatDiffValue(c.New))
+			}
+			if len(d.conflicts) > 0 {
+				conflicted++
+			}
+			for _, c := range d.conflicts {
+				fmt.Fprintf(w, "  ! %v: changed out of band from %v to %v, the specs change it to %v\n",
+					c.Path, formatDiffValue(c.Applied), formatDiffValue(c.Live), formatDiffValue(c.Desired))
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "\n%v to create, %v to update, %v to delete", created, updated, deleted)
+	if conflicted > 0 {
+		fmt.Fprintf(w, ", %v with conflicts apply needs --force for", conflicted)
+	}
+	fmt.Fprintln(w)
+}
+
+func formatDiffValue(v interface{}) string {
+	if v == nil {
+		return "<none>"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	s := string(b)
+	if len(s) > maxDiffValueLength {
+		s = s[:maxDiffValueLength-3] + "..."
+	}
+	return s
+}
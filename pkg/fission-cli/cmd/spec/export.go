@@ -0,0 +1,438 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/controller/client"
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	pkgutil "github.com/fission/fission/pkg/fission-cli/cmd/package/util"
+	spectypes "github.com/fission/fission/pkg/fission-cli/cmd/spec/types"
+	"github.com/fission/fission/pkg/fission-cli/console"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+	"github.com/fission/fission/pkg/fission-cli/util"
+)
+
+// exportArchiveDir is the directory, next to the spec directory, downloaded
+// archives are saved in.
+const exportArchiveDir = "archives"
+
+type (
+	ExportSubCommand struct {
+		cmd.CommandActioner
+	}
+
+	// exporter writes the objects on the server to spec files.
+	exporter struct {
+		fclient   client.Interface
+		specDir   string
+		fr        *FissionResources
+		namespace string
+		download  bool
+		adopt     bool
+
+		// files is the number of spec files written
+		files int
+	}
+)
+
+// Export dumps the Fission objects of a namespace, or all of them, into a new
+// spec directory, in the form 'fission * create --spec' writes them, so
+// objects created imperatively can be managed with the specs.
+func Export(input cli.Input) error {
+	return (&ExportSubCommand{}).do(input)
+}
+
+func (opts *ExportSubCommand) do(input cli.Input) error {
+	specDir := util.GetSpecDir(input)
+
+	// the specs get a deployment config of their own
+	err := Init(input)
+	if err != nil {
+		return err
+	}
+	fr, err := ReadSpecs(specDir)
+	if err != nil {
+		return errors.Wrap(err, "error reading specs")
+	}
+
+	e := &exporter{
+		fclient:   opts.Client(),
+		specDir:   specDir,
+		fr:        fr,
+		namespace: input.String(flagkey.SpecExportNamespace),
+		download:  input.Bool(flagkey.SpecExportDownload),
+		adopt:     input.Bool(flagkey.SpecExportAdopt),
+	}
+	err = e.export()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %v %v to '%v'\n", e.files, pluralize(e.files, "spec file"), specDir)
+	if !e.adopt {
+		fmt.Println("The exported resources are not managed by the specs: " +
+			"export with --adopt for 'fission spec apply' to update them")
+	}
+	return nil
+}
+
+func (e *exporter) export() error {
+	envs, err := e.fclient.V1().Environment().List(e.namespace)
+	if err != nil {
+		return errors.Wrap(err, "error listing environments")
+	}
+	for i := range envs {
+		o := &envs[i]
+		err = e.adoptObject(&o.ObjectMeta, &o.Spec, func() error {
+			_, err := e.fclient.V1().Environment().Update(o)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		obj := fv1.Environment{ObjectMeta: canonicalMeta(&o.ObjectMeta), Spec: o.Spec}
+		err = e.write("env", &o.ObjectMeta, obj)
+		if err != nil {
+			return err
+		}
+	}
+
+	pkgs, err := e.fclient.V1().Package().List(e.namespace)
+	if err != nil {
+		return errors.Wrap(err, "error listing packages")
+	}
+	for i := range pkgs {
+		o := &pkgs[i]
+		err = e.adoptObject(&o.ObjectMeta, &o.Spec, func() error {
+			_, err := e.fclient.V1().Package().Update(o)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		obj := fv1.Package{ObjectMeta: canonicalMeta(&o.ObjectMeta), Spec: o.Spec, Status: canonicalPackageStatus(&o.Spec)}
+
+		// archives downloaded are uploaded again from the specs
+		var resources []interface{}
+		for _, ar := range []struct {
+			kind    string
+			archive *fv1.Archive
+		}{{"source", &obj.Spec.Source}, {"deploy", &obj.Spec.Deployment}} {
+			aus, err := e.exportArchive(&o.ObjectMeta, ar.kind, ar.archive)
+			if err != nil {
+				return err
+			}
+			if aus != nil {
+				resources = append(resources, *aus)
+			}
+		}
+		resources = append(resources, obj)
+		err = e.write("package", &o.ObjectMeta, resources...)
+		if err != nil {
+			return err
+		}
+	}
+
+	fns, err := e.fclient.V1().Function().List(e.namespace)
+	if err != nil {
+		return errors.Wrap(err, "error listing functions")
+	}
+	for i := range fns {
+		o := &fns[i]
+		err = e.adoptObject(&o.ObjectMeta, &o.Spec, func() error {
+			_, err := e.fclient.V1().Function().Update(o)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		obj := fv1.Function{ObjectMeta: canonicalMeta(&o.ObjectMeta), Spec: o.Spec}
+		// apply sets it to the one of the package applied
+		obj.Spec.Package.PackageRef.ResourceVersion = ""
+		err = e.write("function", &o.ObjectMeta, obj)
+		if err != nil {
+			return err
+		}
+	}
+
+	hts, err := e.fclient.V1().HTTPTrigger().List(e.namespace)
+	if err != nil {
+		return errors.Wrap(err, "error listing HTTP triggers")
+	}
+	for i := range hts {
+		o := &hts[i]
+		err = e.adoptObject(&o.ObjectMeta, &o.Spec, func() error {
+			_, err := e.fclient.V1().HTTPTrigger().Update(o)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		obj := fv1.HTTPTrigger{ObjectMeta: canonicalMeta(&o.ObjectMeta), Spec: o.Spec}
+		err = e.write("route", &o.ObjectMeta, obj)
+		if err != nil {
+			return err
+		}
+	}
+
+	kws, err := e.fclient.V1().KubeWatcher().List(e.namespace)
+	if err != nil {
+		return errors.Wrap(err, "error listing Kubernetes watch triggers")
+	}
+	for i := range kws {
+		o := &kws[i]
+		err = e.adoptObject(&o.ObjectMeta, &o.Spec, func() error {
+			_, err := e.fclient.V1().KubeWatcher().Update(o)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		obj := fv1.KubernetesWatchTrigger{ObjectMeta: canonicalMeta(&o.ObjectMeta), Spec: o.Spec}
+		err = e.write("kubewatch", &o.ObjectMeta, obj)
+		if err != nil {
+			return err
+		}
+	}
+
+	tts, err := e.fclient.V1().TimeTrigger().List(e.namespace)
+	if err != nil {
+		return errors.Wrap(err, "error listing time triggers")
+	}
+	for i := range tts {
+		o := &tts[i]
+		err = e.adoptObject(&o.ObjectMeta, &o.Spec, func() error {
+			_, err := e.fclient.V1().TimeTrigger().Update(o)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		obj := fv1.TimeTrigger{ObjectMeta: canonicalMeta(&o.ObjectMeta), Spec: o.Spec}
+		err = e.write("timetrigger", &o.ObjectMeta, obj)
+		if err != nil {
+			return err
+		}
+	}
+
+	mqts, err := e.fclient.V1().MessageQueueTrigger().List("", e.namespace)
+	if err != nil {
+		return errors.Wrap(err, "error listing message queue triggers")
+	}
+	for i := range mqts {
+		o := &mqts[i]
+		err = e.adoptObject(&o.ObjectMeta, &o.Spec, func() error {
+			_, err := e.fclient.V1().MessageQueueTrigger().Update(o)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		obj := fv1.MessageQueueTrigger{ObjectMeta: canonicalMeta(&o.ObjectMeta), Spec: o.Spec}
+		err = e.write("mqtrigger", &o.ObjectMeta, obj)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// adoptObject marks a live object as applied from the specs, with its spec
+// as the last applied one, for apply to update it rather than fail to
+// create it. Objects applied from other specs are left to them.
+func (e *exporter) adoptObject(m *metav1.ObjectMeta, spec interface{}, update func() error) error {
+	if !e.adopt {
+		return nil
+	}
+	if uid, ok := m.Annotations[FISSION_DEPLOYMENT_UID_KEY]; ok && uid != e.fr.DeploymentConfig.UID {
+		console.Warn(fmt.Sprintf("%v/%v is managed by the specs with deployment ID %v, not adopting it",
+			m.Namespace, m.Name, uid))
+		return nil
+	}
+
+	applyDeploymentConfig(m, e.fr)
+	err := recordLastApplied(m, spec)
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(update(), "error adopting %v/%v", m.Namespace, m.Name)
+}
+
+// exportArchive downloads an archive of a package, if asked to, and
+// returns the ArchiveUploadSpec the archive then refers to. Literal and
+// Git archives are part of the package spec, and archives not downloaded
+// keep referring to where they are.
+func (e *exporter) exportArchive(pkgMeta *metav1.ObjectMeta, kind string, ar *fv1.Archive) (*spectypes.ArchiveUploadSpec, error) {
+	if !e.download || ar.Type != fv1.ArchiveTypeUrl || len(ar.URL) == 0 {
+		return nil, nil
+	}
+
+	reader, err := pkgutil.DownloadArchive(e.fclient, ar)
+	if err != nil {
+		console.Warn(fmt.Sprintf("Error downloading %v archive of package %v/%v, keeping its URL: %v",
+			kind, pkgMeta.Namespace, pkgMeta.Name, err))
+		return nil, nil
+	}
+	defer reader.Close()
+
+	// the archive is read whole to tell zip files, uploaded as they are,
+	// from single files
+	contents, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error downloading %v archive of package %v/%v", kind, pkgMeta.Namespace, pkgMeta.Name)
+	}
+
+	name := e.archiveName(pkgMeta, kind)
+	file := filepath.Join(exportArchiveDir, archiveFileName(name, contents))
+	path := filepath.Join(filepath.Clean(e.specDir+"/.."), file)
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return nil, err
+	}
+	err = pkgutil.WriteArchiveToFile(path, bytes.NewReader(contents))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error writing archive %v", path)
+	}
+
+	*ar = fv1.Archive{
+		Type: fv1.ArchiveTypeUrl,
+		URL:  ARCHIVE_URL_PREFIX + name,
+	}
+	return &spectypes.ArchiveUploadSpec{
+		Name:         name,
+		IncludeGlobs: []string{file},
+	}, nil
+}
+
+// archiveName returns the name of the ArchiveUploadSpec of an archive of a
+// package, unique across namespaces when all are exported.
+func (e *exporter) archiveName(pkgMeta *metav1.ObjectMeta, kind string) string {
+	if len(e.namespace) == 0 {
+		return fmt.Sprintf("%v-%v-%v", pkgMeta.Namespace, pkgMeta.Name, kind)
+	}
+	return fmt.Sprintf("%v-%v", pkgMeta.Name, kind)
+}
+
+// archiveFileName returns the name of the file an archive is saved to:
+// zip files get the extension apply tells them with.
+func archiveFileName(name string, contents []byte) string {
+	if bytes.HasPrefix(contents, []byte("PK\x03\x04")) {
+		return name + ".zip"
+	}
+	return name
+}
+
+// write writes resources to a new spec file named after the first object,
+// in a directory per namespace when all are exported.
+func (e *exporter) write(prefix string, m *metav1.ObjectMeta, resources ...interface{}) error {
+	dir := e.specDir
+	if len(e.namespace) == 0 {
+		dir = filepath.Join(dir, m.Namespace)
+	}
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	file := filepath.Join(dir, fmt.Sprintf("%v-%v.yaml", prefix, m.Name))
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrap(err, "couldn't create spec file")
+	}
+	defer f.Close()
+
+	err = writeResources(f, resources...)
+	if err != nil {
+		return errors.Wrapf(err, "error writing spec file %v", file)
+	}
+	e.files++
+	return nil
+}
+
+// writeResources writes resources as YAML documents.
+func writeResources(w io.Writer, resources ...interface{}) error {
+	for i, r := range resources {
+		_, _, data, err := crdToYaml(r)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			_, err = w.Write([]byte("\n---\n"))
+			if err != nil {
+				return err
+			}
+		}
+		_, err = w.Write(data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// canonicalMeta returns the metadata of an object as specs have it: its
+// name, namespace, labels and annotations, without the ones apply sets.
+func canonicalMeta(m *metav1.ObjectMeta) metav1.ObjectMeta {
+	meta := metav1.ObjectMeta{
+		Name:      m.Name,
+		Namespace: m.Namespace,
+	}
+	for k, v := range m.Labels {
+		if k == FISSION_DEPLOYMENT_UID_KEY {
+			continue
+		}
+		if meta.Labels == nil {
+			meta.Labels = make(map[string]string)
+		}
+		meta.Labels[k] = v
+	}
+	for k, v := range m.Annotations {
+		switch k {
+		case FISSION_DEPLOYMENT_NAME_KEY, FISSION_DEPLOYMENT_UID_KEY, FISSION_LAST_APPLIED_KEY:
+			continue
+		}
+		if meta.Annotations == nil {
+			meta.Annotations = make(map[string]string)
+		}
+		meta.Annotations[k] = v
+	}
+	return meta
+}
+
+// canonicalPackageStatus returns the status of a package as 'fission
+// package create --spec' writes it: packages with source archives are
+// pending a build.
+func canonicalPackageStatus(spec *fv1.PackageSpec) fv1.PackageStatus {
+	if !reflect.DeepEqual(spec.Source, fv1.Archive{}) {
+		return fv1.PackageStatus{BuildStatus: fv1.BuildStatusPending}
+	}
+	return fv1.PackageStatus{BuildStatus: fv1.BuildStatusNone}
+}
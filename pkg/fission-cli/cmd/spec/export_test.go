@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	spectypes "github.com/fission/fission/pkg/fission-cli/cmd/spec/types"
+)
+
+func TestCanonicalMeta(t *testing.T) {
+	m := &metav1.ObjectMeta{
+		Name:            "hello",
+		Namespace:       "default",
+		ResourceVersion: "42",
+		UID:             "7b4f4b2e-0a6e-4a36-b8d5-2c1f62ae1b0c",
+		Labels:          map[string]string{"app": "hello", FISSION_DEPLOYMENT_UID_KEY: "uid"},
+		Annotations: map[string]string{
+			FISSION_DEPLOYMENT_NAME_KEY: "app",
+			FISSION_DEPLOYMENT_UID_KEY:  "uid",
+			FISSION_LAST_APPLIED_KEY:    "{}",
+		},
+	}
+	assert.Equal(t, metav1.ObjectMeta{
+		Name:      "hello",
+		Namespace: "default",
+		Labels:    map[string]string{"app": "hello"},
+	}, canonicalMeta(m))
+}
+
+func TestArchiveFileName(t *testing.T) {
+	assert.Equal(t, "hello-deploy.zip", archiveFileName("hello-deploy", []byte("PK\x03\x04rest")))
+	assert.Equal(t, "hello-deploy", archiveFileName("hello-deploy", []byte("module.exports = ...")))
+}
+
+func TestExportWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fission-export")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	pkgMeta := &metav1.ObjectMeta{Name: "hello-pkg", Namespace: "default", ResourceVersion: "42"}
+	pkg := fv1.Package{
+		ObjectMeta: canonicalMeta(pkgMeta),
+		Spec: fv1.PackageSpec{
+			Environment: fv1.EnvironmentReference{Name: "nodejs", Namespace: "default"},
+			Source:      fv1.Archive{Type: fv1.ArchiveTypeUrl, URL: ARCHIVE_URL_PREFIX + "hello-pkg-source"},
+		},
+	}
+	pkg.Status = canonicalPackageStatus(&pkg.Spec)
+	aus := spectypes.ArchiveUploadSpec{Name: "hello-pkg-source", IncludeGlobs: []string{"archives/hello-pkg-source.zip"}}
+
+	e := &exporter{specDir: dir, namespace: "default"}
+	assert.NoError(t, e.write("package", pkgMeta, aus, pkg))
+	// files are never overwritten
+	assert.Error(t, e.write("package", pkgMeta, pkg))
+	assert.Equal(t, 1, e.files)
+
+	fr, err := ReadSpecs(dir)
+	assert.NoError(t, err)
+	assert.Len(t, fr.ArchiveUploadSpecs, 1)
+	assert.Equal(t, aus.IncludeGlobs, fr.ArchiveUploadSpecs[0].IncludeGlobs)
+	assert.Len(t, fr.Packages, 1)
+	assert.Equal(t, "hello-pkg", fr.Packages[0].Name)
+	assert.Equal(t, fv1.BuildStatusPending, fr.Packages[0].Status.BuildStatus)
+	assert.Equal(t, pkg.Spec, fr.Packages[0].Spec)
+}
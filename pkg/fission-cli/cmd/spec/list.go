@@ -31,6 +31,7 @@ import (
 	"github.com/fission/fission/pkg/fission-cli/cmd"
 	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
 	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/timer/schedule"
 )
 
 // ListSubCommand struct
@@ -52,7 +53,7 @@ func (opts *ListSubCommand) run(input cli.Input) error {
 	if len(deployID) == 0 {
 		// get specdir and read the deployID
 		specDir := util.GetSpecDir(input)
-		fr, err := ReadSpecs(specDir)
+		fr, err := readInputSpecs(input, specDir)
 		if err != nil {
 			return errors.Wrap(err, "error reading specs")
 		}
@@ -355,11 +356,11 @@ func ShowTimeTriggers(tts []fv1.TimeTrigger) {
 	if len(tts) > 0 {
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
 		fmt.Fprintf(w, "%v", "Time Triggers:\n")
-		fmt.Fprintf(w, "%v\t%v\t%v\n", "NAME", "CRON", "FUNCTION_NAME")
+		fmt.Fprintf(w, "%v\t%v\t%v\n", "NAME", "SCHEDULE", "FUNCTION_NAME")
 		for _, tt := range tts {
 
 			fmt.Fprintf(w, "%v\t%v\t%v\n",
-				tt.ObjectMeta.Name, tt.Spec.Cron, tt.Spec.FunctionReference.Name)
+				tt.ObjectMeta.Name, schedule.Describe(&tt.Spec), tt.Spec.FunctionReference.Name)
 		}
 		fmt.Fprintf(w, "\n")
 		w.Flush()
@@ -0,0 +1,354 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// FISSION_LAST_APPLIED_KEY is the annotation holding the spec of an
+	// object as last applied, to tell the changes made to the object out
+	// of band from the ones made to the specs since.
+	FISSION_LAST_APPLIED_KEY = "fission-last-applied"
+
+	// maxLastAppliedString is the longest string, like the literal of an
+	// archive, kept as is in the last applied spec; longer ones are kept
+	// as their digest, for annotations to stay small.
+	maxLastAppliedString = 1024
+	digestPrefix         = "sha256:"
+)
+
+type (
+	// specChange is a field of the spec of an object apply changes.
+	specChange struct {
+		Path string
+		Old  interface{}
+		New  interface{}
+	}
+
+	// specConflict is a field of the spec of an object changed both out
+	// of band and in the specs since they were last applied.
+	specConflict struct {
+		Path    string
+		Applied interface{}
+		Live    interface{}
+		Desired interface{}
+	}
+
+	// ConflictError is returned by apply for objects with conflicts, which
+	// apply leaves as they are unless forced.
+	ConflictError struct {
+		Kind      string
+		Meta      metav1.ObjectMeta
+		Conflicts []specConflict
+	}
+)
+
+func (e *ConflictError) Error() string {
+	paths := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		paths = append(paths, c.Path)
+	}
+	return fmt.Sprintf("%v %v/%v was changed out of band since the specs were last applied, at %v; "+
+		"update the specs to match, or apply with --force to overwrite the changes",
+		e.Kind, e.Meta.Namespace, e.Meta.Name, strings.Join(paths, ", "))
+}
+
+// ignoredPaths are the fields of the specs of objects of each kind apply
+// sets itself, which can't conflict.
+var ignoredPaths = map[string][]string{
+	// the resource version of the package of a function changes with
+	// every update of the package
+	"function": {"package.packageref.resourceversion"},
+}
+
+// mergeSpec merges the desired spec of an object into its live spec: the
+// fields changed in the specs since they were last applied are taken from
+// the desired spec, the others are left as they are live, keeping the
+// changes made out of band. Fields changed both ways are conflicts, left
+// to the desired spec only if forced.
+//
+// desiredSpec is replaced by the merged spec, and the desired spec as is
+// recorded in desiredMeta as the last applied one.
+func mergeSpec(kind string, liveMeta *metav1.ObjectMeta, liveSpec interface{},
+	desiredMeta *metav1.ObjectMeta, desiredSpec interface{}, force bool) error {
+
+	desired, err := toJSONValue(desiredSpec)
+	if err != nil {
+		return err
+	}
+	err = setLastApplied(desiredMeta, desired)
+	if err != nil {
+		return err
+	}
+
+	applied, ok := getLastApplied(liveMeta)
+	if !ok {
+		// applied before the last applied specs were recorded, there is
+		// nothing to tell out of band changes with
+		return nil
+	}
+	live, err := toJSONValue(liveSpec)
+	if err != nil {
+		return err
+	}
+
+	merged, conflicts := threeWayMerge(applied, live, desired, ignoredPaths[kind])
+	if len(conflicts) > 0 && !force {
+		return &ConflictError{Kind: kind, Meta: *desiredMeta, Conflicts: conflicts}
+	}
+	return fromJSONValue(merged, desiredSpec)
+}
+
+// recordLastApplied records the desired spec of an object created by apply
+// as the last applied one.
+func recordLastApplied(desiredMeta *metav1.ObjectMeta, desiredSpec interface{}) error {
+	desired, err := toJSONValue(desiredSpec)
+	if err != nil {
+		return err
+	}
+	return setLastApplied(desiredMeta, desired)
+}
+
+// lastAppliedChanged returns whether apply records another last applied
+// spec than the one of the live object.
+func lastAppliedChanged(liveMeta *metav1.ObjectMeta, desiredMeta *metav1.ObjectMeta) bool {
+	return liveMeta.Annotations[FISSION_LAST_APPLIED_KEY] != desiredMeta.Annotations[FISSION_LAST_APPLIED_KEY]
+}
+
+func setLastApplied(m *metav1.ObjectMeta, spec interface{}) error {
+	b, err := json.Marshal(digestLongStrings(spec))
+	if err != nil {
+		return errors.Wrap(err, "error encoding last applied spec")
+	}
+	if m.Annotations == nil {
+		m.Annotations = make(map[string]string)
+	}
+	m.Annotations[FISSION_LAST_APPLIED_KEY] = string(b)
+	return nil
+}
+
+func getLastApplied(m *metav1.ObjectMeta) (interface{}, bool) {
+	s, ok := m.Annotations[FISSION_LAST_APPLIED_KEY]
+	if !ok {
+		return nil, false
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// toJSONValue returns the generic JSON value of v: maps, slices, strings,
+// float64s, bools and nils.
+func toJSONValue(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding spec")
+	}
+	var value interface{}
+	err = json.Unmarshal(b, &value)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding spec")
+	}
+	return value, nil
+}
+
+func fromJSONValue(value interface{}, out interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return errors.Wrap(err, "error encoding merged spec")
+	}
+	// the desired spec may have fields the merged one leaves out
+	reflect.ValueOf(out).Elem().Set(reflect.Zero(reflect.TypeOf(out).Elem()))
+	return errors.Wrap(json.Unmarshal(b, out), "error decoding merged spec")
+}
+
+// digestLongStrings returns the value with its long strings replaced by
+// their digest.
+func digestLongStrings(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		if len(v) > maxLastAppliedString {
+			return fmt.Sprintf("%v%x", digestPrefix, sha256.Sum256([]byte(v)))
+		}
+		return v
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = digestLongStrings(e)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, e := range v {
+			l[i] = digestLongStrings(e)
+		}
+		return l
+	}
+	return v
+}
+
+// threeWayMerge merges the desired value into the live one, given the
+// value last applied, and returns the fields changed both out of band and
+// in the desired value. Maps are merged field by field, other values,
+// lists included, as a whole; the fields at the ignored paths are taken
+// from the desired value.
+func threeWayMerge(applied, live, desired interface{}, ignored []string) (interface{}, []specConflict) {
+	var conflicts []specConflict
+	merged := mergeValue("", applied, live, desired, ignored, &conflicts)
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Path < conflicts[j].Path
+	})
+	return merged, conflicts
+}
+
+func mergeValue(path string, applied, live, desired interface{}, ignored []string, conflicts *[]specConflict) interface{} {
+	liveMap, liveIsMap := live.(map[string]interface{})
+	desiredMap, desiredIsMap := desired.(map[string]interface{})
+	if liveIsMap && desiredIsMap {
+		appliedMap, _ := applied.(map[string]interface{})
+		merged := make(map[string]interface{})
+		for k := range liveMap {
+			merged[k] = nil
+		}
+		for k := range desiredMap {
+			merged[k] = nil
+		}
+		for k := range merged {
+			v := mergeValue(joinPath(path, k), appliedMap[k], liveMap[k], desiredMap[k], ignored, conflicts)
+			if v == nil {
+				delete(merged, k)
+				continue
+			}
+			merged[k] = v
+		}
+		return merged
+	}
+
+	for _, p := range ignored {
+		if p == path {
+			return desired
+		}
+	}
+	// not changed in the specs, keep what's live
+	if equalApplied(applied, desired) {
+		return live
+	}
+	// changed in the specs and out of band in another way; fields the
+	// server set while unset in the specs, like defaults, aren't changes
+	if !isZero(applied) && !equalApplied(applied, live) && !reflect.DeepEqual(live, desired) {
+		*conflicts = append(*conflicts, specConflict{
+			Path:    path,
+			Applied: applied,
+			Live:    digestLongStrings(live),
+			Desired: digestLongStrings(desired),
+		})
+	}
+	return desired
+}
+
+// equalApplied returns whether a value is the one applied, compared as it
+// is recorded.
+func equalApplied(applied interface{}, v interface{}) bool {
+	if isZero(applied) && isZero(v) {
+		return true
+	}
+	return reflect.DeepEqual(applied, digestLongStrings(v))
+}
+
+func isZero(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return len(v) == 0
+	case float64:
+		return v == 0
+	case bool:
+		return !v
+	case map[string]interface{}:
+		for _, e := range v {
+			if !isZero(e) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// diffSpec returns the fields of the live spec that differ from the
+// desired one, except the ignored ones.
+func diffSpec(live, desired interface{}, ignored []string) []specChange {
+	var changes []specChange
+	diffValue("", live, desired, ignored, &changes)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+func diffValue(path string, live, desired interface{}, ignored []string, changes *[]specChange) {
+	for _, p := range ignored {
+		if p == path {
+			return
+		}
+	}
+	liveMap, liveIsMap := live.(map[string]interface{})
+	desiredMap, desiredIsMap := desired.(map[string]interface{})
+	if liveIsMap && desiredIsMap {
+		keys := make(map[string]bool)
+		for k := range liveMap {
+			keys[k] = true
+		}
+		for k := range desiredMap {
+			keys[k] = true
+		}
+		for k := range keys {
+			diffValue(joinPath(path, k), liveMap[k], desiredMap[k], ignored, changes)
+		}
+		return
+	}
+	if (isZero(live) && isZero(desired)) || reflect.DeepEqual(live, desired) {
+		return
+	}
+	*changes = append(*changes, specChange{
+		Path: path,
+		Old:  digestLongStrings(live),
+		New:  digestLongStrings(desired),
+	})
+}
+
+func joinPath(path string, key string) string {
+	if len(path) == 0 {
+		return strings.ToLower(key)
+	}
+	return path + "." + strings.ToLower(key)
+}
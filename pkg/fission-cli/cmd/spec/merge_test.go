@@ -0,0 +1,146 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type testSpec struct {
+	Image    string            `json:"image,omitempty"`
+	Replicas int               `json:"replicas,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Args     []string          `json:"args,omitempty"`
+	Literal  []byte            `json:"literal,omitempty"`
+}
+
+func TestThreeWayMerge(t *testing.T) {
+	for _, c := range []struct {
+		name      string
+		applied   testSpec
+		live      testSpec
+		desired   testSpec
+		merged    testSpec
+		conflicts []string
+	}{
+		{
+			name:    "changed in specs",
+			applied: testSpec{Image: "a", Replicas: 1},
+			live:    testSpec{Image: "a", Replicas: 1},
+			desired: testSpec{Image: "b", Replicas: 1},
+			merged:  testSpec{Image: "b", Replicas: 1},
+		},
+		{
+			name:    "changed out of band",
+			applied: testSpec{Image: "a", Replicas: 1},
+			live:    testSpec{Image: "a", Replicas: 3},
+			desired: testSpec{Image: "b", Replicas: 1},
+			merged:  testSpec{Image: "b", Replicas: 3},
+		},
+		{
+			name:    "set by server",
+			applied: testSpec{Image: "a"},
+			live:    testSpec{Image: "a", Replicas: 1, Labels: map[string]string{"x": "1"}},
+			desired: testSpec{Image: "a", Replicas: 2, Labels: map[string]string{"y": "2"}},
+			merged:  testSpec{Image: "a", Replicas: 2, Labels: map[string]string{"x": "1", "y": "2"}},
+		},
+		{
+			name:    "removed from specs",
+			applied: testSpec{Image: "a", Labels: map[string]string{"x": "1"}},
+			live:    testSpec{Image: "a", Labels: map[string]string{"x": "1"}},
+			desired: testSpec{Image: "a"},
+			merged:  testSpec{Image: "a"},
+		},
+		{
+			name:      "changed both ways",
+			applied:   testSpec{Image: "a", Args: []string{"-v"}},
+			live:      testSpec{Image: "c", Args: []string{"-q"}},
+			desired:   testSpec{Image: "b", Args: []string{"-v", "-x"}},
+			merged:    testSpec{Image: "b", Args: []string{"-v", "-x"}},
+			conflicts: []string{"args", "image"},
+		},
+		{
+			name:    "changed the same way",
+			applied: testSpec{Image: "a"},
+			live:    testSpec{Image: "b"},
+			desired: testSpec{Image: "b"},
+			merged:  testSpec{Image: "b"},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			applied, err := toJSONValue(&c.applied)
+			assert.NoError(t, err)
+			live, err := toJSONValue(&c.live)
+			assert.NoError(t, err)
+			desired, err := toJSONValue(&c.desired)
+			assert.NoError(t, err)
+
+			merged, conflicts := threeWayMerge(applied, live, desired, nil)
+			var spec testSpec
+			assert.NoError(t, fromJSONValue(merged, &spec))
+			assert.Equal(t, c.merged, spec)
+
+			var paths []string
+			for _, conflict := range conflicts {
+				paths = append(paths, conflict.Path)
+			}
+			assert.Equal(t, c.conflicts, paths)
+		})
+	}
+}
+
+func TestMergeSpec(t *testing.T) {
+	literal := []byte(strings.Repeat("x", 2*maxLastAppliedString))
+	liveMeta := &metav1.ObjectMeta{Name: "fn"}
+	assert.NoError(t, recordLastApplied(liveMeta, &testSpec{Image: "a", Replicas: 1, Literal: literal}))
+	// long strings are kept as their digest
+	assert.Less(t, len(liveMeta.Annotations[FISSION_LAST_APPLIED_KEY]), maxLastAppliedString)
+
+	live := testSpec{Image: "a", Replicas: 3, Literal: literal}
+	desired := testSpec{Image: "b", Replicas: 1, Literal: literal}
+	desiredMeta := &metav1.ObjectMeta{Name: "fn"}
+	assert.NoError(t, mergeSpec("test", liveMeta, &live, desiredMeta, &desired, false))
+	assert.Equal(t, testSpec{Image: "b", Replicas: 3, Literal: literal}, desired)
+	assert.True(t, lastAppliedChanged(liveMeta, desiredMeta))
+
+	// the live object changed out of band where the specs change it
+	live = testSpec{Image: "c", Replicas: 1, Literal: literal}
+	desired = testSpec{Image: "b", Replicas: 1, Literal: literal}
+	err := mergeSpec("test", liveMeta, &live, desiredMeta, &desired, false)
+	assert.IsType(t, &ConflictError{}, err)
+	assert.NoError(t, mergeSpec("test", liveMeta, &live, desiredMeta, &desired, true))
+	assert.Equal(t, "b", desired.Image)
+
+	// without last applied spec, the desired one is applied as is
+	desired = testSpec{Image: "b"}
+	assert.NoError(t, mergeSpec("test", &metav1.ObjectMeta{Name: "fn"}, &live, desiredMeta, &desired, false))
+	assert.Equal(t, testSpec{Image: "b"}, desired)
+}
+
+func TestDiffSpec(t *testing.T) {
+	live, err := toJSONValue(&testSpec{Image: "a", Replicas: 1, Labels: map[string]string{"x": "1"}})
+	assert.NoError(t, err)
+	desired, err := toJSONValue(&testSpec{Image: "b", Replicas: 1, Labels: map[string]string{"x": "2"}})
+	assert.NoError(t, err)
+
+	changes := diffSpec(live, desired, []string{"image"})
+	assert.Equal(t, []specChange{{Path: "labels.x", Old: "1", New: "2"}}, changes)
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/fission/fission/pkg/controller/client"
+)
+
+// pruneKinds are the kinds of objects prune deletes, in the order it
+// deletes them: objects before the ones they refer to, for triggers not to
+// be left invoking functions that are gone, and functions packages.
+var pruneKinds = []struct {
+	kind   string
+	status string
+}{
+	{"message queue trigger", "MessageQueueTrigger"},
+	{"time trigger", "TimeTrigger"},
+	{"Kubernetes watch trigger", "KubernetesWatchTrigger"},
+	{"HTTP trigger", "HTTPTrigger"},
+	{"function", "function"},
+	{"package", "package"},
+	{"environment", "environment"},
+}
+
+// applyDeploymentConfig marks an object as applied from the specs, with the
+// deployment UID as an annotation and, when it's a valid label value, a
+// label, so objects of a deployment can be selected with kubectl too.
+func applyDeploymentConfig(m *metav1.ObjectMeta, fr *FissionResources) {
+	if m.Annotations == nil {
+		m.Annotations = make(map[string]string)
+	}
+	m.Annotations[FISSION_DEPLOYMENT_NAME_KEY] = fr.DeploymentConfig.Name
+	m.Annotations[FISSION_DEPLOYMENT_UID_KEY] = fr.DeploymentConfig.UID
+
+	if len(validation.IsValidLabelValue(fr.DeploymentConfig.UID)) == 0 {
+		if m.Labels == nil {
+			m.Labels = make(map[string]string)
+		}
+		m.Labels[FISSION_DEPLOYMENT_UID_KEY] = fr.DeploymentConfig.UID
+	}
+}
+
+// hasDeploymentConfig returns whether an object was applied from the specs,
+// by its label or, for objects applied before labels were, annotation.
+func hasDeploymentConfig(m *metav1.ObjectMeta, fr *FissionResources) bool {
+	if uid, ok := m.Labels[FISSION_DEPLOYMENT_UID_KEY]; ok && uid == fr.DeploymentConfig.UID {
+		return true
+	}
+	if uid, ok := m.Annotations[FISSION_DEPLOYMENT_UID_KEY]; ok && uid == fr.DeploymentConfig.UID {
+		return true
+	}
+	return false
+}
+
+// metadataChanged returns whether apply changes the metadata apply manages
+// of a live object: its last applied spec and deployment label.
+func metadataChanged(liveMeta *metav1.ObjectMeta, desiredMeta *metav1.ObjectMeta) bool {
+	return lastAppliedChanged(liveMeta, desiredMeta) ||
+		liveMeta.Labels[FISSION_DEPLOYMENT_UID_KEY] != desiredMeta.Labels[FISSION_DEPLOYMENT_UID_KEY]
+}
+
+// prunedObjects returns the live objects applied from the specs before and
+// no longer in them, in the order prune deletes them.
+func prunedObjects(desired []specObject, live map[string]specObject) []specObject {
+	wanted := make(map[string]bool)
+	for _, d := range desired {
+		wanted[objectKey(d.kind, d.meta)] = true
+	}
+
+	order := make(map[string]int)
+	for i, k := range pruneKinds {
+		order[k.kind] = i
+	}
+
+	var pruned []specObject
+	for key, l := range live {
+		if !wanted[key] {
+			pruned = append(pruned, l)
+		}
+	}
+	sort.Slice(pruned, func(i, j int) bool {
+		if order[pruned[i].kind] != order[pruned[j].kind] {
+			return order[pruned[i].kind] < order[pruned[j].kind]
+		}
+		return mapKey(pruned[i].meta) < mapKey(pruned[j].meta)
+	})
+	return pruned
+}
+
+// pruneResources deletes the objects applied from the specs before and no
+// longer in them, and adds them to the apply status.
+func pruneResources(fclient client.Interface, fr *FissionResources, applyStatus map[string]ResourceApplyStatus) error {
+	live, err := liveObjects(fclient, fr)
+	if err != nil {
+		return err
+	}
+
+	status := make(map[string]string)
+	for _, k := range pruneKinds {
+		status[k.kind] = k.status
+	}
+
+	for _, o := range prunedObjects(desiredObjects(fr), live) {
+		err := deleteObject(fclient, o)
+		if err != nil {
+			return errors.Wrapf(err, "error deleting %v %v/%v", o.kind, o.meta.Namespace, o.meta.Name)
+		}
+		fmt.Printf("Deleted %v %v/%v\n", o.kind, o.meta.Namespace, o.meta.Name)
+
+		ras := applyStatus[status[o.kind]]
+		ras.Deleted = append(ras.Deleted, o.meta)
+		applyStatus[status[o.kind]] = ras
+	}
+	return nil
+}
+
+func deleteObject(fclient client.Interface, o specObject) error {
+	switch o.kind {
+	case "environment":
+		return fclient.V1().Environment().Delete(o.meta)
+	case "package":
+		return fclient.V1().Package().Delete(o.meta)
+	case "function":
+		return fclient.V1().Function().Delete(o.meta)
+	case "HTTP trigger":
+		return fclient.V1().HTTPTrigger().Delete(o.meta)
+	case "Kubernetes watch trigger":
+		return fclient.V1().KubeWatcher().Delete(o.meta)
+	case "time trigger":
+		return fclient.V1().TimeTrigger().Delete(o.meta)
+	case "message queue trigger":
+		return fclient.V1().MessageQueueTrigger().Delete(o.meta)
+	}
+	return errors.Errorf("unknown kind %v", o.kind)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fission/fission/pkg/fission-cli/cmd/spec/types"
+)
+
+func TestDeploymentConfig(t *testing.T) {
+	fr := &FissionResources{DeploymentConfig: types.DeploymentConfig{
+		Name: "app",
+		UID:  "9c4a2b35-5e3c-4a4f-8f36-7c3d1b0b1a2e",
+	}}
+
+	m := &metav1.ObjectMeta{Name: "fn"}
+	assert.False(t, hasDeploymentConfig(m, fr))
+	applyDeploymentConfig(m, fr)
+	assert.Equal(t, fr.DeploymentConfig.UID, m.Labels[FISSION_DEPLOYMENT_UID_KEY])
+	assert.True(t, hasDeploymentConfig(m, fr))
+
+	// applied before labels were
+	old := &metav1.ObjectMeta{Annotations: map[string]string{FISSION_DEPLOYMENT_UID_KEY: fr.DeploymentConfig.UID}}
+	assert.True(t, hasDeploymentConfig(old, fr))
+	assert.True(t, metadataChanged(old, m))
+
+	// not a valid label value
+	fr.DeploymentConfig.UID = "my app"
+	m = &metav1.ObjectMeta{Name: "fn"}
+	applyDeploymentConfig(m, fr)
+	assert.Empty(t, m.Labels)
+	assert.True(t, hasDeploymentConfig(m, fr))
+}
+
+func TestPrunedObjects(t *testing.T) {
+	obj := func(kind string, name string) specObject {
+		return specObject{kind: kind, meta: &metav1.ObjectMeta{Namespace: "default", Name: name}}
+	}
+	live := make(map[string]specObject)
+	for _, o := range []specObject{
+		obj("environment", "nodejs"),
+		obj("package", "hello-pkg"),
+		obj("function", "hello"),
+		obj("function", "bye"),
+		obj("HTTP trigger", "hello"),
+		obj("time trigger", "nightly"),
+	} {
+		live[objectKey(o.kind, o.meta)] = o
+	}
+	desired := []specObject{obj("function", "hello"), obj("HTTP trigger", "hello")}
+
+	var pruned []string
+	for _, o := range prunedObjects(desired, live) {
+		pruned = append(pruned, objectKey(o.kind, o.meta))
+	}
+	assert.Equal(t, []string{
+		"time trigger/default:nightly",
+		"function/default:bye",
+		"package/default:hello-pkg",
+		"environment/default:nodejs",
+	}, pruned)
+}
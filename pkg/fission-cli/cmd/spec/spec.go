@@ -377,21 +377,24 @@ func (fr *FissionResources) Validate(input cli.Input) ([]string, error) {
 			return f.Spec.Package.PackageRef.Namespace == f.ObjectMeta.Namespace
 		}
 
-		if !packageRefInFuncNs(&f) {
+		switch {
+		case f.Spec.Container != nil:
+			// container functions have no package
+		case !packageRefInFuncNs(&f):
 			result = multierror.Append(result, fmt.Errorf(
 				"%v: function '%v' references a package outside of its namespace %v/%v",
 				fr.SourceMap.Locations["Function"][f.ObjectMeta.Namespace][f.ObjectMeta.Name],
 				f.ObjectMeta.Name,
 				f.Spec.Package.PackageRef.Namespace,
 				f.Spec.Package.PackageRef.Name))
-		} else if !packageRefExists() {
+		case !packageRefExists():
 			result = multierror.Append(result, fmt.Errorf(
 				"%v: function '%v' references unknown package %v/%v",
 				fr.SourceMap.Locations["Function"][f.ObjectMeta.Namespace][f.ObjectMeta.Name],
 				f.ObjectMeta.Name,
 				pkgMeta.Namespace,
 				pkgMeta.Name))
-		} else {
+		default:
 			packages[MapKey(pkgMeta)] = true
 		}
 
@@ -485,7 +488,7 @@ func (fr *FissionResources) Validate(input cli.Input) ([]string, error) {
 	}
 
 	for _, f := range fr.Functions {
-		if _, ok := environments[fmt.Sprintf("%s:%s", f.Spec.Environment.Name, f.Spec.Environment.Namespace)]; !ok {
+		if _, ok := environments[fmt.Sprintf("%s:%s", f.Spec.Environment.Name, f.Spec.Environment.Namespace)]; !ok && f.Spec.Container == nil {
 			warnings = append(warnings, "Environment %s is referenced in function %s but not declared in specs", f.Spec.Environment.Name, f.ObjectMeta.Name)
 		}
 		strategy := f.Spec.InvokeStrategy.ExecutionStrategy
@@ -0,0 +1,312 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+)
+
+type (
+	// specValues are the values spec files are rendered with, as
+	// templates, so one set of specs can be applied to several clusters.
+	specValues struct {
+		// Values are the values of the values files, overridden by the
+		// ones set on the command line.
+		Values map[string]interface{}
+
+		// files are the values files, which aren't specs even if in the
+		// spec directory.
+		files []string
+	}
+)
+
+// getSpecValues returns the values given with --values files, merged in
+// order, and --set key=value pairs, set last.
+func getSpecValues(input cli.Input) (*specValues, error) {
+	sv := &specValues{Values: make(map[string]interface{})}
+
+	for _, file := range input.StringSlice(flagkey.SpecValues) {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading values file %v", file)
+		}
+		values, err := parseValues(b)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing values file %v", file)
+		}
+		mergeValues(sv.Values, values)
+
+		path, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		sv.files = append(sv.files, path)
+	}
+
+	for _, kv := range input.StringSlice(flagkey.SpecSet) {
+		err := setValue(sv.Values, kv)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sv, nil
+}
+
+// readInputSpecs reads the specs in the spec directory, rendered with the
+// values given on the command line.
+func readInputSpecs(input cli.Input, specDir string) (*FissionResources, error) {
+	values, err := getSpecValues(input)
+	if err != nil {
+		return nil, err
+	}
+	return readSpecs(specDir, values)
+}
+
+// isValuesFile returns whether the file at path is one of the values files.
+func (sv *specValues) isValuesFile(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, f := range sv.files {
+		if f == abs {
+			return true
+		}
+	}
+	return false
+}
+
+// parseValues parses a YAML values file. Numbers are kept as they are
+// written, for large ones not to be rendered in exponent notation.
+func parseValues(b []byte) (map[string]interface{}, error) {
+	j, err := yaml.YAMLToJSON(b)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{})
+	if len(bytes.TrimSpace(j)) == 0 || string(bytes.TrimSpace(j)) == "null" {
+		return values, nil
+	}
+	d := json.NewDecoder(bytes.NewReader(j))
+	d.UseNumber()
+	err = d.Decode(&values)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// mergeValues merges src into dst, maps key by key, other values replaced.
+func mergeValues(dst map[string]interface{}, src map[string]interface{}) {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]interface{})
+		dstMap, dstIsMap := dst[k].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeValues(dstMap, srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// setValue sets a value given as key=value, where the key is a dotted path
+// into nested values, like image.tag=1.2.3. Booleans and numbers are set
+// as such, everything else as a string.
+func setValue(values map[string]interface{}, kv string) error {
+	i := strings.Index(kv, "=")
+	if i <= 0 {
+		return errors.Errorf("error parsing value %q: must be key=value", kv)
+	}
+	key, raw := kv[:i], kv[i+1:]
+
+	var value interface{} = raw
+	if b, err := strconv.ParseBool(raw); err == nil {
+		value = b
+	} else if _, err := strconv.ParseFloat(raw, 64); err == nil {
+		value = json.Number(raw)
+	}
+
+	parts := strings.Split(key, ".")
+	for _, part := range parts {
+		if len(part) == 0 {
+			return errors.Errorf("error parsing value %q: empty key", kv)
+		}
+	}
+
+	m := values
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := m[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			m[part] = next
+		}
+		m = next
+	}
+	m[parts[len(parts)-1]] = value
+	return nil
+}
+
+// renderSpec renders a spec file as a template with the values. Files
+// without actions are returned as they are.
+func renderSpec(path string, b []byte, sv *specValues) ([]byte, error) {
+	if !bytes.Contains(b, []byte("{{")) {
+		return b, nil
+	}
+	tmpl, err := template.New(filepath.Base(path)).
+		Option("missingkey=zero").
+		Funcs(templateFuncs()).
+		Parse(string(b))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing template %v", path)
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, sv)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error rendering template %v", path)
+	}
+	// missing values of maps render as "<no value>"
+	return bytes.ReplaceAll(buf.Bytes(), []byte("<no value>"), nil), nil
+}
+
+// templateFuncs are the functions templates can use, a subset of the ones
+// of Sprig, with the same names and arguments, so specs can be shared with
+// Helm charts.
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"env":        os.Getenv,
+		"default":    defaultValue,
+		"required":   required,
+		"empty":      empty,
+		"quote":      func(v interface{}) string { return strconv.Quote(toString(v)) },
+		"squote":     func(v interface{}) string { return "'" + toString(v) + "'" },
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.Replace(s, old, new, -1) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"join":       join,
+		"indent":     indent,
+		"nindent":    func(n int, s string) string { return "\n" + indent(n, s) },
+		"b64enc":     func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"b64dec":     b64dec,
+		"toYaml":     toYAML,
+		"toJson":     toJSON,
+	}
+}
+
+func defaultValue(d interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || empty(given[0]) {
+		return d
+	}
+	return given[0]
+}
+
+func required(msg string, v interface{}) (interface{}, error) {
+	if empty(v) {
+		return nil, errors.New(msg)
+	}
+	return v, nil
+}
+
+func empty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+func toString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func join(sep string, v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return toString(v)
+	}
+	parts := make([]string, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		parts = append(parts, toString(rv.Index(i).Interface()))
+	}
+	return strings.Join(parts, sep)
+}
+
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.Replace(s, "\n", "\n"+pad, -1)
+}
+
+func b64dec(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func toYAML(v interface{}) (string, error) {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(b), "\n"), nil
+}
+
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
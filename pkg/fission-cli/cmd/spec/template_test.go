@@ -0,0 +1,97 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetValue(t *testing.T) {
+	values, err := parseValues([]byte("image:\n  repo: fission/node-env\n  tag: latest\nreplicas: 1000000\n"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, setValue(values, "image.tag=1.2.3"))
+	assert.NoError(t, setValue(values, "debug=true"))
+	assert.NoError(t, setValue(values, "limits.memory=256"))
+	assert.NoError(t, setValue(values, "url=http://a?b=c"))
+	assert.Error(t, setValue(values, "novalue"))
+	assert.Error(t, setValue(values, "a..b=c"))
+
+	assert.Equal(t, map[string]interface{}{
+		"image":    map[string]interface{}{"repo": "fission/node-env", "tag": "1.2.3"},
+		"replicas": json.Number("1000000"),
+		"debug":    true,
+		"limits":   map[string]interface{}{"memory": json.Number("256")},
+		"url":      "http://a?b=c",
+	}, values)
+}
+
+func TestMergeValues(t *testing.T) {
+	dst := map[string]interface{}{
+		"image":     map[string]interface{}{"repo": "a", "tag": "1"},
+		"namespace": "dev",
+	}
+	mergeValues(dst, map[string]interface{}{
+		"image":     map[string]interface{}{"tag": "2"},
+		"namespace": "prod",
+	})
+	assert.Equal(t, map[string]interface{}{
+		"image":     map[string]interface{}{"repo": "a", "tag": "2"},
+		"namespace": "prod",
+	}, dst)
+}
+
+func TestRenderSpec(t *testing.T) {
+	sv := &specValues{Values: map[string]interface{}{
+		"namespace": "stage",
+		"image":     map[string]interface{}{"tag": "1.2.3"},
+		"replicas":  json.Number("1000000"),
+	}}
+	os.Setenv("SPEC_TEST_REGISTRY", "registry.example.com")
+	defer os.Unsetenv("SPEC_TEST_REGISTRY")
+
+	out, err := renderSpec("env.yaml", []byte(`metadata:
+  namespace: {{ .Values.namespace }}
+  owner: {{ .Values.owner | default "fission" | quote }}
+spec:
+  image: {{ env "SPEC_TEST_REGISTRY" }}/node-env:{{ .Values.image.tag }}
+  replicas: {{ .Values.replicas }}
+  labels: {{ .Values.missing }}
+`), sv)
+	assert.NoError(t, err)
+	assert.Equal(t, `metadata:
+  namespace: stage
+  owner: "fission"
+spec:
+  image: registry.example.com/node-env:1.2.3
+  replicas: 1000000
+  labels: 
+`, string(out))
+
+	_, err = renderSpec("env.yaml", []byte(`{{ required "namespace is required" .Values.ns }}`), sv)
+	assert.Error(t, err)
+
+	// files without actions are left as they are
+	in := []byte("metadata:\n  name: <no value>\n")
+	out, err = renderSpec("env.yaml", in, sv)
+	assert.NoError(t, err)
+	assert.Equal(t, in, out)
+}
@@ -54,7 +54,7 @@ func (opts *ValidateSubCommand) run(input cli.Input) error {
 
 	// this will error on parse errors and on duplicates
 	specDir := util.GetSpecDir(input)
-	fr, err := ReadSpecs(specDir)
+	fr, err := readInputSpecs(input, specDir)
 	if err != nil {
 		return errors.Wrap(err, "error reading specs")
 	}
@@ -199,6 +199,12 @@ func isResourceConflicts(deployUID string, specObj fv1.MetadataAccessor, cluster
 // ReadSpecs reads all specs in the specified directory and returns a parsed set of
 // fission resources.
 func ReadSpecs(specDir string) (*FissionResources, error) {
+	return readSpecs(specDir, &specValues{})
+}
+
+// readSpecs reads all specs in the specified directory, rendered as templates
+// with the values.
+func readSpecs(specDir string, values *specValues) (*FissionResources, error) {
 
 	// make sure spec directory exists before continue
 	if _, err := os.Stat(specDir); os.IsNotExist(err) {
@@ -233,12 +239,21 @@ func ReadSpecs(specDir string) (*FissionResources, error) {
 		if !(strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")) {
 			return nil
 		}
+		if values.isValuesFile(path) {
+			return nil
+		}
 		// read
 		b, err := ioutil.ReadFile(path)
 		if err != nil {
 			result = multierror.Append(result, err)
 			return nil
 		}
+		// line numbers of rendered templates are the ones of the output
+		b, err = renderSpec(path, b, values)
+		if err != nil {
+			result = multierror.Append(result, err)
+			return nil
+		}
 		// handle the case where there are multiple YAML docs per file. go-yaml
 		// doesn't support this directly, yet.
 		docs := bytes.Split(b, []byte("\n---"))
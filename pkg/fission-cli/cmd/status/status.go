@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	"github.com/fission/fission/pkg/fission-cli/console"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/info"
+)
+
+type StatusSubCommand struct {
+	cmd.CommandActioner
+}
+
+func Status(input cli.Input) error {
+	return (&StatusSubCommand{}).do(input)
+}
+
+func (opts *StatusSubCommand) do(input cli.Input) error {
+	ns := input.String(flagkey.StatusNamespace)
+	window := input.String(flagkey.StatusWindow)
+
+	err := util.RequireServerFeature(opts.Client(), info.FeatureStatus)
+	if err != nil {
+		return err
+	}
+
+	status, err := opts.Client().V1().Misc().Status(ns, window)
+	if err != nil {
+		return errors.Wrap(err, "error getting event source status")
+	}
+
+	if len(status.MetricsError) > 0 {
+		console.Warn(fmt.Sprintf("Metrics of event sources are unavailable: %v", status.MetricsError))
+	}
+
+	printStatus(os.Stdout, status, time.Now())
+
+	return nil
+}
+
+// printStatus prints a table of the event sources of status, with the time
+// since their last success at now.
+func printStatus(out io.Writer, status *info.NamespaceStatus, now time.Time) {
+	w := tabwriter.NewWriter(out, 0, 0, 1, ' ', 0)
+
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", "TYPE", "NAME", "FUNCTION", "SOURCE",
+		fmt.Sprintf("RATE(%v)", status.Window), "ERRORS", "LAG", "LAST_SUCCESS")
+	for _, s := range status.EventSources {
+		rate, errRate, lag, lastSuccess := "-", "-", "-", "-"
+		if s.FiringRate != nil {
+			rate = fmt.Sprintf("%.2f/s", *s.FiringRate)
+		}
+		if s.ErrorRate != nil {
+			errRate = fmt.Sprintf("%.1f%%", *s.ErrorRate)
+		}
+		if s.Lag != nil {
+			lag = (time.Duration(*s.Lag * float64(time.Second))).Round(time.Millisecond).String()
+		}
+		if s.LastSuccess != nil {
+			lastSuccess = fmt.Sprintf("%v ago", now.Sub(*s.LastSuccess).Round(time.Second))
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			s.Type, s.Name, s.Function, s.Source, rate, errRate, lag, lastSuccess)
+	}
+	w.Flush()
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fission/fission/pkg/info"
+)
+
+func float(f float64) *float64 {
+	return &f
+}
+
+func TestPrintStatus(t *testing.T) {
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	lastSuccess := now.Add(-90 * time.Second)
+	status := &info.NamespaceStatus{
+		Namespace: "default",
+		Window:    "10m",
+		EventSources: []info.EventSourceHealth{
+			{Type: "http", Name: "hello", Function: "hello", Source: "GET /hello",
+				FiringRate: float(2), ErrorRate: float(25), LastSuccess: &lastSuccess},
+			{Type: "messagequeue", Name: "orders", Function: "a:80,b:20", Source: "kafka:orders",
+				FiringRate: float(0.5), Lag: float(1.5)},
+			{Type: "time", Name: "nightly", Function: "report", Source: "@daily"},
+		},
+	}
+
+	var buf bytes.Buffer
+	printStatus(&buf, status, now)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Len(t, lines, 4)
+
+	assert.Equal(t, []string{"TYPE", "NAME", "FUNCTION", "SOURCE", "RATE(10m)", "ERRORS", "LAG", "LAST_SUCCESS"},
+		strings.Fields(lines[0]))
+	assert.Equal(t, []string{"http", "hello", "hello", "GET", "/hello", "2.00/s", "25.0%", "-", "1m30s", "ago"},
+		strings.Fields(lines[1]))
+	assert.Equal(t, []string{"messagequeue", "orders", "a:80,b:20", "kafka:orders", "0.50/s", "-", "1.5s", "-"},
+		strings.Fields(lines[2]))
+	// metrics without data are dashes
+	assert.Equal(t, []string{"time", "nightly", "report", "@daily", "-", "-", "-", "-"},
+		strings.Fields(lines[3]))
+}
@@ -22,7 +22,6 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
-	"github.com/robfig/cron"
 	uuid "github.com/satori/go.uuid"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -33,6 +32,7 @@ import (
 	"github.com/fission/fission/pkg/fission-cli/console"
 	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
 	"github.com/fission/fission/pkg/fission-cli/util"
+	"github.com/fission/fission/pkg/timer/schedule"
 )
 
 type CreateSubCommand struct {
@@ -66,8 +66,28 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 	fnNamespace := input.String(flagkey.NamespaceFunction)
 
 	cronSpec := input.String(flagkey.TtCron)
-	if len(cronSpec) == 0 {
-		return errors.New("Need a cron spec like '0 30 * * * *', '@every 1h30m', or '@hourly'; use --cron")
+	at := input.String(flagkey.TtAt)
+	every := input.String(flagkey.TtEvery)
+	if len(cronSpec) == 0 && len(at) == 0 && len(every) == 0 {
+		return errors.New("Need a cron spec like '0 30 * * * *', '@every 1h30m', or '@hourly', a time to invoke the function once at, or an interval; use --cron, --at or --every")
+	}
+
+	var output *fv1.TimeTriggerOutput
+	if isOutputSet(input) {
+		var err error
+		output, err = GetOutput(input.String(flagkey.TtOutput), input.StringSlice(flagkey.TtEmailTo),
+			input.String(flagkey.TtEmailSubject), input.String(flagkey.TtSMTPSecret), input.Int(flagkey.TtOutputTimeout), nil)
+		if err != nil {
+			return errors.Wrap(err, "error parsing output configuration")
+		}
+	}
+
+	cloudEvents := fv1.CloudEventsMode(input.String(flagkey.TtCloudEvents))
+	if len(cloudEvents) > 0 {
+		err := cloudEvents.Validate()
+		if err != nil {
+			return errors.Wrap(err, "error parsing CloudEvents mode")
+		}
 	}
 
 	if input.Bool(flagkey.SpecSave) {
@@ -98,14 +118,30 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 			Namespace: fnNamespace,
 		},
 		Spec: fv1.TimeTriggerSpec{
-			Cron: cronSpec,
+			Cron:   cronSpec,
+			At:     at,
+			Every:  every,
+			Jitter: input.String(flagkey.TtJitter),
 			FunctionReference: fv1.FunctionReference{
 				Type: fv1.FunctionReferenceTypeFunctionName,
 				Name: fnName,
 			},
+			Output:        output,
+			CloudEvents:   cloudEvents,
+			CatchUpWindow: input.Int(flagkey.TtCatchUpWindow),
 		},
 	}
 
+	_, err := setInvocation(input, &opts.trigger.Spec)
+	if err != nil {
+		return err
+	}
+
+	_, err = schedule.Parse(&opts.trigger.Spec)
+	if err != nil {
+		return errors.Wrap(err, "error parsing schedule")
+	}
+
 	return nil
 }
 
@@ -137,9 +173,9 @@ func (opts *CreateSubCommand) run(input cli.Input) error {
 		return err
 	}
 
-	err = getCronNextNActivationTime(opts.trigger.Spec.Cron, t, 1)
+	err = getNextNActivationTime(&opts.trigger.Spec, t, 1)
 	if err != nil {
-		return errors.Wrap(err, "error passing cron spec examination")
+		return errors.Wrap(err, "error passing schedule examination")
 	}
 
 	return nil
@@ -153,16 +189,28 @@ func getAPITimeInfo(client client.Interface) (time.Time, error) {
 	return serverInfo.ServerTime.CurrentTime, nil
 }
 
-func getCronNextNActivationTime(cronSpec string, serverTime time.Time, round int) error {
-	sched, err := cron.Parse(cronSpec)
+func getNextNActivationTime(spec *fv1.TimeTriggerSpec, serverTime time.Time, round int) error {
+	sched, err := schedule.Parse(spec)
 	if err != nil {
 		return err
 	}
 
+	if len(spec.Timezone) > 0 {
+		location, err := time.LoadLocation(spec.Timezone)
+		if err != nil {
+			return err
+		}
+		serverTime = serverTime.In(location)
+	}
+
 	fmt.Printf("Current Server Time: \t%v\n", serverTime.Format(time.RFC3339))
 
 	for i := 0; i < round; i++ {
 		serverTime = sched.Next(serverTime)
+		if serverTime.IsZero() {
+			fmt.Println("No more invocations")
+			break
+		}
 		fmt.Printf("Next %v invocation: \t%v\n", i+1, serverTime.Format(time.RFC3339))
 	}
 
@@ -26,6 +26,7 @@ import (
 	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
 	"github.com/fission/fission/pkg/fission-cli/cmd"
 	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+	"github.com/fission/fission/pkg/timer/schedule"
 )
 
 type ListSubCommand struct {
@@ -45,10 +46,10 @@ func (opts *ListSubCommand) do(input cli.Input) error {
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
 
-	fmt.Fprintf(w, "%v\t%v\t%v\n", "NAME", "CRON", "FUNCTION_NAME")
+	fmt.Fprintf(w, "%v\t%v\t%v\n", "NAME", "SCHEDULE", "FUNCTION_NAME")
 	for _, tt := range tts {
 		fmt.Fprintf(w, "%v\t%v\t%v\n",
-			tt.ObjectMeta.Name, tt.Spec.Cron, tt.Spec.FunctionReference.Name)
+			tt.ObjectMeta.Name, schedule.Describe(&tt.Spec), tt.Spec.FunctionReference.Name)
 	}
 	w.Flush()
 
@@ -0,0 +1,133 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package timetrigger
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+)
+
+func isOutputSet(input cli.Input) bool {
+	return input.IsSet(flagkey.TtOutput) || input.IsSet(flagkey.TtOutputTimeout) || input.IsSet(flagkey.TtEmailTo) ||
+		input.IsSet(flagkey.TtEmailSubject) || input.IsSet(flagkey.TtSMTPSecret)
+}
+
+// GetOutput returns the output of a time trigger, updating oldOutput with
+// the given values if it's not nil. "-" removes the output.
+func GetOutput(outputType string, emailTo []string, emailSubject string, smtpSecret string,
+	timeout int, oldOutput *fv1.TimeTriggerOutput) (*fv1.TimeTriggerOutput, error) {
+
+	if outputType == "-" {
+		return nil, nil
+	}
+
+	output := oldOutput
+	if output == nil {
+		if len(outputType) == 0 {
+			return nil, fmt.Errorf("output type is required to enable output delivery, use --output")
+		}
+		output = &fv1.TimeTriggerOutput{}
+	}
+
+	if len(outputType) > 0 {
+		output.Type = fv1.TimeTriggerOutputType(outputType)
+	}
+	if timeout != 0 {
+		output.Timeout = timeout
+	}
+
+	if output.Type == fv1.TimeTriggerOutputTypeEmail {
+		if output.Email == nil {
+			output.Email = &fv1.EmailOutput{}
+		}
+		if len(emailTo) > 0 {
+			output.Email.To = emailTo
+		}
+		if len(emailSubject) > 0 {
+			output.Email.Subject = emailSubject
+		}
+		if len(smtpSecret) > 0 {
+			output.Email.SMTPSecret = smtpSecret
+		}
+	} else {
+		if len(emailTo) > 0 || len(emailSubject) > 0 || len(smtpSecret) > 0 {
+			return nil, fmt.Errorf("email settings are only supported by email output")
+		}
+		output.Email = nil
+	}
+
+	err := output.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// setInvocation sets how the function of a time trigger is invoked: the time
+// zone of its cron spec, and the payload, method and headers it's invoked
+// with. It returns whether any of them is given.
+func setInvocation(input cli.Input, spec *fv1.TimeTriggerSpec) (bool, error) {
+	updated := false
+	if input.IsSet(flagkey.TtTimezone) {
+		spec.Timezone = input.String(flagkey.TtTimezone)
+		updated = true
+	}
+	if input.IsSet(flagkey.TtPayload) {
+		spec.Payload = input.String(flagkey.TtPayload)
+		updated = true
+	}
+	if input.IsSet(flagkey.TtMethod) {
+		spec.Method = strings.ToUpper(input.String(flagkey.TtMethod))
+		updated = true
+	}
+	if input.IsSet(flagkey.TtHeader) {
+		headers, err := GetHeaders(input.StringSlice(flagkey.TtHeader))
+		if err != nil {
+			return false, err
+		}
+		spec.Headers = headers
+		updated = true
+	}
+
+	if updated {
+		err := spec.Validate()
+		if err != nil {
+			return false, errors.Wrap(err, "error validating time trigger")
+		}
+	}
+	return updated, nil
+}
+
+// GetHeaders parses headers in format "key: value".
+func GetHeaders(headers []string) (map[string]string, error) {
+	result := make(map[string]string, len(headers))
+	for _, header := range headers {
+		kv := strings.SplitN(header, ":", 2)
+		if len(kv) != 2 || len(strings.TrimSpace(kv[0])) == 0 {
+			return nil, fmt.Errorf("header '%v' is not in format 'key: value'", header)
+		}
+		result[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return result, nil
+}
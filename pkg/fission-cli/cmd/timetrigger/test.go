@@ -19,6 +19,7 @@ package timetrigger
 import (
 	"github.com/pkg/errors"
 
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
 	"github.com/fission/fission/pkg/fission-cli/cmd"
 	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
@@ -38,9 +39,14 @@ func (opts *ShowSubCommand) do(input cli.Input) error {
 
 func (opts *ShowSubCommand) run(flaginput cli.Input) error {
 	round := flaginput.Int(flagkey.TtName)
-	cronSpec := flaginput.String(flagkey.TtCron)
-	if len(cronSpec) == 0 {
-		return errors.New("need a cron spec like '0 30 * * * *', '@every 1h30m', or '@hourly'; use --cron")
+	spec := &fv1.TimeTriggerSpec{
+		Cron:     flaginput.String(flagkey.TtCron),
+		At:       flaginput.String(flagkey.TtAt),
+		Every:    flaginput.String(flagkey.TtEvery),
+		Timezone: flaginput.String(flagkey.TtTimezone),
+	}
+	if len(spec.Cron) == 0 && len(spec.At) == 0 && len(spec.Every) == 0 {
+		return errors.New("need a cron spec like '0 30 * * * *', '@every 1h30m', or '@hourly', a time or an interval; use --cron, --at or --every")
 	}
 
 	t, err := getAPITimeInfo(opts.Client())
@@ -48,9 +54,9 @@ func (opts *ShowSubCommand) run(flaginput cli.Input) error {
 		return err
 	}
 
-	err = getCronNextNActivationTime(cronSpec, t, round)
+	err = getNextNActivationTime(spec, t, round)
 	if err != nil {
-		return errors.Wrap(err, "error passing cron spec examination")
+		return errors.Wrap(err, "error passing schedule examination")
 	}
 
 	return nil
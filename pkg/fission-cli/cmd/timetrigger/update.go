@@ -55,9 +55,19 @@ func (opts *UpdateSubCommand) complete(input cli.Input) error {
 	}
 
 	updated := false
-	newCron := input.String("cron")
-	if len(newCron) != 0 {
+	// a new schedule replaces the one of the trigger
+	newCron := input.String(flagkey.TtCron)
+	newAt := input.String(flagkey.TtAt)
+	newEvery := input.String(flagkey.TtEvery)
+	if len(newCron) != 0 || len(newAt) != 0 || len(newEvery) != 0 {
 		tt.Spec.Cron = newCron
+		tt.Spec.At = newAt
+		tt.Spec.Every = newEvery
+		updated = true
+	}
+
+	if input.IsSet(flagkey.TtJitter) {
+		tt.Spec.Jitter = input.String(flagkey.TtJitter)
 		updated = true
 	}
 
@@ -70,8 +80,41 @@ func (opts *UpdateSubCommand) complete(input cli.Input) error {
 		updated = true
 	}
 
+	if isOutputSet(input) {
+		output, err := GetOutput(input.String(flagkey.TtOutput), input.StringSlice(flagkey.TtEmailTo),
+			input.String(flagkey.TtEmailSubject), input.String(flagkey.TtSMTPSecret), input.Int(flagkey.TtOutputTimeout), tt.Spec.Output)
+		if err != nil {
+			return errors.Wrap(err, "error parsing output configuration")
+		}
+		tt.Spec.Output = output
+		updated = true
+	}
+
+	if input.IsSet(flagkey.TtCloudEvents) {
+		cloudEvents := fv1.CloudEventsMode(input.String(flagkey.TtCloudEvents))
+		if len(cloudEvents) > 0 {
+			err := cloudEvents.Validate()
+			if err != nil {
+				return errors.Wrap(err, "error parsing CloudEvents mode")
+			}
+		}
+		tt.Spec.CloudEvents = cloudEvents
+		updated = true
+	}
+
+	if input.IsSet(flagkey.TtCatchUpWindow) {
+		tt.Spec.CatchUpWindow = input.Int(flagkey.TtCatchUpWindow)
+		updated = true
+	}
+
+	invocationUpdated, err := setInvocation(input, &tt.Spec)
+	if err != nil {
+		return err
+	}
+	updated = updated || invocationUpdated
+
 	if !updated {
-		return errors.New("nothing to update. Use --cron or --function")
+		return errors.New("nothing to update. Use --cron, --at, --every, --jitter, --function, --output, --cloudevents, --timezone, --payload, --method, --header or --catchupwindow")
 	}
 
 	opts.trigger = tt
@@ -92,9 +135,9 @@ func (opts *UpdateSubCommand) run(input cli.Input) error {
 		return err
 	}
 
-	err = getCronNextNActivationTime(opts.trigger.Spec.Cron, t, 1)
+	err = getNextNActivationTime(&opts.trigger.Spec, t, 1)
 	if err != nil {
-		return errors.Wrap(err, "error passing cron spec examination")
+		return errors.Wrap(err, "error passing schedule examination")
 	}
 
 	return nil
@@ -30,7 +30,7 @@ func Commands() *cobra.Command {
 		RunE:  wrapper.Wrapper(Version),
 	}
 	wrapper.SetFlags(command, flag.FlagSet{
-		Optional: []flag.Flag{flag.ClientOnly},
+		Optional: []flag.Flag{flag.ClientOnly, flag.VersionCheck},
 	})
 
 	return command
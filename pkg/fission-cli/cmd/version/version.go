@@ -24,6 +24,7 @@ import (
 
 	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
 	"github.com/fission/fission/pkg/fission-cli/cmd"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
 	"github.com/fission/fission/pkg/fission-cli/util"
 )
 
@@ -42,5 +43,38 @@ func (opts *VersionSubCommand) do(input cli.Input) error {
 		return errors.Wrap(err, "error formatting versions")
 	}
 	fmt.Print(string(bs))
+
+	if !input.Bool(flagkey.VersionCheck) {
+		return nil
+	}
+	return opts.check()
+}
+
+// check prints a report of the compatibility of the CLI with the server,
+// and fails if they are incompatible.
+func (opts *VersionSubCommand) check() error {
+	c, err := util.CheckCompatibility(opts.Client())
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\nCompatibility:")
+	if len(c.VersionSkew) > 0 {
+		fmt.Printf("  Versions: unsupported, %v\n", c.VersionSkew)
+	} else {
+		fmt.Println("  Versions: supported")
+	}
+	if len(c.Unsupported) > 0 {
+		fmt.Println("  Features of the CLI the server lacks:")
+		for _, f := range c.Unsupported {
+			fmt.Printf("    - %v\n", f)
+		}
+	} else {
+		fmt.Println("  Features: the server supports all the features of the CLI")
+	}
+
+	if !c.IsCompatible() {
+		return errors.New("the CLI and the server are not fully compatible, upgrade the older of the two")
+	}
 	return nil
 }
@@ -70,7 +70,8 @@ var (
 	GlobalVerbosity = Flag{Type: Int, Name: flagkey.Verbosity, Short: "v", Usage: "CLI verbosity (0 is quiet, 1 is the default, 2 is verbose)", DefaultValue: 1}
 	GlobalServer    = Flag{Type: String, Name: flagkey.Server, Usage: "Server URL"}
 
-	ClientOnly = Flag{Type: Bool, Name: flagkey.ClientOnly, Usage: "If set, the CLI won't connect to remote server"}
+	ClientOnly   = Flag{Type: Bool, Name: flagkey.ClientOnly, Usage: "If set, the CLI won't connect to remote server"}
+	VersionCheck = Flag{Type: Bool, Name: flagkey.VersionCheck, Usage: "Check the compatibility of the CLI with the server, and fail if they are incompatible"}
 
 	KubeContext = Flag{Type: String, Name: flagkey.KubeContext, Usage: "Kubernetes context to be used for the execution of Fission commands", DefaultValue: ""}
 
@@ -82,12 +83,14 @@ var (
 
 	RunTimeMinCPU    = Flag{Type: Int, Name: flagkey.RuntimeMincpu, Usage: "Minimum CPU to be assigned to pod (In millicore, minimum 1)"}
 	RunTimeMaxCPU    = Flag{Type: Int, Name: flagkey.RuntimeMaxcpu, Usage: "Maximum CPU to be assigned to pod (In millicore, minimum 1)"}
-	RunTimeTargetCPU = Flag{Type: Int, Name: flagkey.RuntimeTargetcpu, Usage: "Target average CPU usage percentage across pods for scaling", DefaultValue: 80}
+	RunTimeTargetCPU = Flag{Type: Int, Name: flagkey.RuntimeTargetcpu, Usage: "Target average CPU usage percentage across pods for scaling", DefaultValue: fv1.DefaultTargetCPUPercent}
 	RunTimeMinMemory = Flag{Type: Int, Name: flagkey.RuntimeMinmemory, Usage: "Minimum memory to be assigned to pod (In megabyte)"}
 	RunTimeMaxMemory = Flag{Type: Int, Name: flagkey.RuntimeMaxmemory, Usage: "Maximum memory to be assigned to pod (In megabyte)"}
 
 	ReplicasMin = Flag{Type: Int, Name: flagkey.ReplicasMinscale, Usage: "Minimum number of pods (Uses resource inputs to configure HPA)", DefaultValue: 1}
-	ReplicasMax = Flag{Type: Int, Name: flagkey.ReplicasMaxscale, Usage: "Maximum number of pods (Uses resource inputs to configure HPA)", DefaultValue: 1}
+	ReplicasMax = Flag{Type: Int, Name: flagkey.ReplicasMaxscale, Usage: "Maximum number of pods (Uses resource inputs to configure HPA)", DefaultValue: fv1.DefaultMaxScale}
+
+	ReplicasTargetConcurrency = Flag{Type: Int, Name: flagkey.ReplicasTargetConcurrency, Usage: "Target average in-flight requests per pod for scaling, 0 to scale on CPU only (Requires an external metrics adapter serving router metrics)"}
 
 	FnName                  = Flag{Type: String, Name: flagkey.FnName, Usage: "Function name"}
 	FnSpecializationTimeout = Flag{Type: Int, Name: flagkey.FnSpecializationTimeout, Aliases: []string{"st"}, Usage: "Timeout for executor to wait for function pod creation", DefaultValue: fv1.DefaultSpecializationTimeOut}
@@ -98,70 +101,160 @@ var (
 	FnSecret                = Flag{Type: StringSlice, Name: flagkey.FnSecret, Usage: "Function access to secret, should be present in the same namespace as the function. You can provide multiple secrets using multiple --secrets flags. In the case of fn update the the secrets will be replaced by the provided list of secrets."}
 	FnCfgMap                = Flag{Type: StringSlice, Name: flagkey.FnCfgMap, Usage: "Function access to configmap, should be present in the same namespace as the function. You can provide multiple configmaps using multiple --configmap flags. In case of fn update the configmaps will be replaced by the provided list of configmaps."}
 	FnExecutorType          = Flag{Type: String, Name: flagkey.FnExecutorType, Usage: "Executor type for execution; one of 'poolmgr', 'newdeploy'", DefaultValue: string(fv1.ExecutorTypePoolmgr)}
-	FnExecutionTimeout      = Flag{Type: Int, Name: flagkey.FnExecutionTimeout, Aliases: []string{"ft"}, Usage: "Maximum time for a request to wait for the response from the function", DefaultValue: 60}
+	FnExecutionTimeout      = Flag{Type: Int, Name: flagkey.FnExecutionTimeout, Aliases: []string{"ft"}, Usage: "Maximum time for a request to wait for the response from the function", DefaultValue: fv1.DEFAULT_FUNCTION_TIMEOUT}
 	FnLogPod                = Flag{Type: String, Name: flagkey.FnLogPod, Usage: "Function pod name (use the latest pod name if unspecified)"}
 	FnLogFollow             = Flag{Type: Bool, Name: flagkey.FnLogFollow, Short: "f", Usage: "Specify if the logs should be streamed"}
 	FnLogDetail             = Flag{Type: Bool, Name: flagkey.FnLogDetail, Short: "d", Usage: "Display detailed information"}
-	FnLogDBType             = Flag{Type: String, Name: flagkey.FnLogDBType, Usage: "Log database type, e.g. influxdb (currently only influxdb is supported)", DefaultValue: "influxdb"}
+	FnLogDBType             = Flag{Type: String, Name: flagkey.FnLogDBType, Usage: "Where to get logs from: 'kubernetes' streams them from the function pods, 'sink' queries the log sink (InfluxDB, Loki or Elasticsearch) through the controller, 'influxdb' the log database of servers without log sinks", DefaultValue: "kubernetes"}
 	FnLogReverseQuery       = Flag{Type: Bool, Name: flagkey.FnLogReverseQuery, Short: "r", Usage: "Specify the log reverse query base on time, it will be invalid if the 'follow' flag is specified"}
-	FnLogCount              = Flag{Type: Int, Name: flagkey.FnLogCount, Usage: "Get N most recent log records", DefaultValue: 20}
+	FnLogCount              = Flag{Type: Int, Name: flagkey.FnLogCount, Usage: "Get N most recent log records (influxdb and sink only)", DefaultValue: 20}
+	FnLogSince              = Flag{Type: Duration, Name: flagkey.FnLogSince, Usage: "Only print logs newer than a relative duration like 5s, 2m, or 3h"}
+	FnLogUntil              = Flag{Type: String, Name: flagkey.FnLogUntil, Usage: "Only print logs older than an RFC 3339 time like 2021-03-01T12:00:00Z, or a relative duration like 5s, 2m, or 3h (sink only)"}
+	FnLogTail               = Flag{Type: Int, Name: flagkey.FnLogTail, Usage: "Number of most recent lines to print of each pod (default all)", DefaultValue: -1}
+	FnLogFilter             = Flag{Type: String, Name: flagkey.FnLogFilter, Usage: "Only print lines matching the regular expression"}
+	FnLogOutput             = Flag{Type: String, Name: flagkey.FnLogOutput, Short: "o", Usage: "Output format: empty for text, or 'json' for a JSON object per line"}
 	FnTestBody              = Flag{Type: String, Name: flagkey.FnTestBody, Short: "b", Usage: "Request body"}
 	FnTestTimeout           = Flag{Type: Duration, Name: flagkey.FnTestTimeout, Short: "t", Usage: "Length of time to wait for the response. If set to zero or negative number, no timeout is set", DefaultValue: 30 * time.Second}
 	FnTestHeader            = Flag{Type: StringSlice, Name: flagkey.FnTestHeader, Short: "H", Usage: "Request headers"}
 	FnTestQuery             = Flag{Type: StringSlice, Name: flagkey.FnTestQuery, Short: "q", Usage: "Request query parameters: -q key1=value1 -q key2=value2"}
-	FnIdleTimeout           = Flag{Type: Int, Name: flagkey.FnIdleTimeout, Usage: "The length of time (in seconds) that a function is idle before pod(s) are eligible for recycling", DefaultValue: 120}
-	FnConcurrency           = Flag{Type: Int, Name: flagkey.FnConcurrency, Aliases: []string{"con"}, Usage: "Maximum number of pods specialized concurrently to serve requests", DefaultValue: 500}
-	FnRequestsPerPod        = Flag{Type: Int, Name: flagkey.FnRequestsPerPod, Aliases: []string{"rpp"}, Usage: "Maximum number of concurrent requests that can be served by a specialized pod", DefaultValue: 1}
+	FnTestLocal             = Flag{Type: Bool, Name: flagkey.FnTestLocal, Usage: "Test the code given with --code in a local container of the runtime image given with --image or of --env, with docker or podman, instead of the function in the cluster"}
+	FnTestEnvVersion        = Flag{Type: Int, Name: flagkey.FnTestEnvVersion, Usage: "Environment API version of the runtime image to test the function in with --local (default that of --env, or 2 with --entrypoint, 1 otherwise)"}
+	FnIdleTimeout           = Flag{Type: Int, Name: flagkey.FnIdleTimeout, Usage: "The length of time (in seconds) that a function is idle before pod(s) are eligible for recycling", DefaultValue: fv1.DefaultIdleTimeout}
+	FnConcurrency           = Flag{Type: Int, Name: flagkey.FnConcurrency, Aliases: []string{"con"}, Usage: "Maximum number of pods specialized concurrently to serve requests", DefaultValue: fv1.DefaultConcurrency}
+	FnRequestsPerPod        = Flag{Type: Int, Name: flagkey.FnRequestsPerPod, Aliases: []string{"rpp"}, Usage: "Maximum number of concurrent requests that can be served by a specialized pod", DefaultValue: fv1.DefaultRequestsPerPod}
+	FnListLabels            = Flag{Type: String, Name: flagkey.FnListLabels, Usage: "Only list functions with matching labels, e.g. app=foo"}
+	FnListFieldSelector     = Flag{Type: String, Name: flagkey.FnListFieldSelector, Usage: "Only list functions with matching fields, e.g. spec.environment.name=nodejs"}
+	FnListPageSize          = Flag{Type: Int, Name: flagkey.FnListPageSize, Usage: "Number of functions to get from the server at a time, all at once if 0", DefaultValue: 500}
+	FnListWatch             = Flag{Type: Bool, Name: flagkey.FnListWatch, Short: "w", Usage: "List functions, then keep printing the ones added, modified or deleted"}
+	FnDebugImage            = Flag{Type: String, Name: flagkey.FnDebugImage, Usage: "Image of the debug container, with the tools to debug the function", DefaultValue: "busybox"}
+	FnDebugTimeout          = Flag{Type: Duration, Name: flagkey.FnDebugTimeout, Usage: "Length of time to wait for the debug container to start", DefaultValue: time.Minute}
+	FnInvocationsLimit      = Flag{Type: Int, Name: flagkey.FnInvocationsLimit, Usage: "Maximum number of invocations to list, the most recent ones are listed, all recorded ones if 0", DefaultValue: 20}
+	FnTopSort               = Flag{Type: String, Name: flagkey.FnTopSort, Usage: "Usage to sort functions by, highest first: cpu|memory|invocations|egress", DefaultValue: "cpu"}
+	FnTopOutput             = Flag{Type: String, Name: flagkey.FnTopOutput, Short: "o", Usage: "Output format: empty for a table, or 'csv' for CSV with the raw counters, e.g. for chargeback"}
+	FnPriority              = Flag{Type: String, Name: flagkey.FnPriority, Usage: "Priority of the function when resources run short: interactive|standard|batch; idle pods of low priority functions are evicted first under node pressure (default standard)"}
+	FnQuota                 = Flag{Type: StringSlice, Name: flagkey.FnQuota, Usage: "Invocation quota of the function as <limit>/<window> with window day|month, e.g. --quota 100000/day; requests beyond it are rejected with 429 until the window ends; can be specified once per window ('-' to remove all)"}
+	FnCoLocateWith          = Flag{Type: StringSlice, Name: flagkey.FnCoLocateWith, Usage: "Function this function calls or is called by, to place their pods on the same nodes if possible; can be specified multiple times ('-' to remove all)"}
+	FnImage                 = Flag{Type: String, Name: flagkey.FnImage, Usage: "Image the function runs instead of an environment and package, serving requests on --port; give its command after '--' (newdeploy only)"}
+	FnPort                  = Flag{Type: Int, Name: flagkey.FnPort, Usage: "Port the image of the function serves requests on", DefaultValue: 8888}
+	FnImagePullSecret       = Flag{Type: String, Name: flagkey.FnImagePullSecret, Usage: "Secret for Kubernetes to pull the image of the function from a private registry"}
 
 	HtName              = Flag{Type: String, Name: flagkey.HtName, Usage: "HTTP trigger name"}
 	HtMethod            = Flag{Type: String, Name: flagkey.HtMethod, Usage: "HTTP Method: GET|POST|PUT|DELETE|HEAD", DefaultValue: http.MethodGet}
 	HtUrl               = Flag{Type: String, Name: flagkey.HtUrl, Usage: "URL pattern (See gorilla/mux supported patterns)"}
-	HtHost              = Flag{Type: String, Name: flagkey.HtHost, Usage: "Use --ingressrule instead", Deprecated: true, Substitute: flagkey.HtIngressRule}
+	HtHost              = Flag{Type: String, Name: flagkey.HtHost, Usage: "Host the trigger serves, e.g. api.example.com or *.example.com for any subdomain; routes on the Host header, use --ingressrule to expose the trigger through an ingress ('-' to remove)"}
 	HtIngress           = Flag{Type: Bool, Name: flagkey.HtIngress, Usage: "Creates ingress with same URL"}
 	HtIngressRule       = Flag{Type: String, Name: flagkey.HtIngressRule, Usage: "Host for Ingress rule: --ingressrule host=path (the format of host/path depends on what ingress controller you used)"}
 	HtIngressAnnotation = Flag{Type: StringSlice, Name: flagkey.HtIngressAnnotation, Usage: "Annotation for Ingress: --ingressannotation key=value (the format of annotation depends on what ingress controller you used)"}
 	HtIngressTLS        = Flag{Type: String, Name: flagkey.HtIngressTLS, Usage: "Name of the Secret contains TLS key and crt for Ingress (the usability of TLS features depends on what ingress controller you used)"}
+	HtIngressType       = Flag{Type: String, Name: flagkey.HtIngressType, Usage: "Type of the object created with --createingress: ingress|httproute for a Gateway API HTTPRoute (default ingress)"}
+	HtIngressGateway    = Flag{Type: String, Name: flagkey.HtIngressGateway, Usage: "Gateway the HTTPRoute attaches to with --ingresstype httproute, as name or namespace/name"}
 	HtFnName            = Flag{Type: StringSlice, Name: flagkey.HtFnName, Usage: "Name(s) of the function for this trigger. (If 2 functions are supplied with this flag, traffic gets routed to them based on weights supplied with --weight flag.)"}
 	HtFnWeight          = Flag{Type: IntSlice, Name: flagkey.HtFnWeight, Usage: "Weight for each function supplied with --function flag, in the same order. Used for canary deployment"}
 	HtFnFilter          = Flag{Type: String, Name: flagkey.HtFilter, Usage: "Name of the function for trigger(s)"}
+	HtJWKSURL           = Flag{Type: String, Name: flagkey.HtJWKSURL, Usage: "URL of the JSON Web Key Set used to verify bearer tokens; enables JWT authentication for the trigger ('-' to disable)"}
+	HtJWTIssuer         = Flag{Type: String, Name: flagkey.HtJWTIssuer, Usage: "Expected issuer (iss claim) of bearer tokens; without a JWKS URL, the key set is discovered from the issuer's OpenID configuration"}
+	HtJWTAudience       = Flag{Type: StringSlice, Name: flagkey.HtJWTAudience, Usage: "Accepted audience (aud claim) of bearer tokens, can be specified multiple times"}
+	HtJWTClaim          = Flag{Type: StringSlice, Name: flagkey.HtJWTClaim, Usage: "Claim bearer tokens must carry: --jwtclaim claim=value"}
+	HtJWTClaimHeader    = Flag{Type: StringSlice, Name: flagkey.HtJWTClaimHeader, Usage: "Pass a token claim to the function as request header: --jwtclaimheader claim=header"}
+	HtSessionAffinity   = Flag{Type: String, Name: flagkey.HtSessionAffinity, Usage: "Pin client sessions to function pods by 'cookie' or 'header' ('-' to disable)"}
+	HtSessionName       = Flag{Type: String, Name: flagkey.HtSessionName, Usage: "Name of the cookie or header carrying the session ID (default cookie \"fission-session\")"}
+	HtSessionTTL        = Flag{Type: Int, Name: flagkey.HtSessionTTL, Usage: "Seconds a session stays pinned to a pod after its last request (default 1800)"}
+	HtOpenAPISpec       = Flag{Type: String, Name: flagkey.HtOpenAPISpec, Usage: "OpenAPI spec requests are validated against before invoking the function: --openapispec configmap/<name> or package/<name> ('-' to disable)"}
+	HtOpenAPIPath       = Flag{Type: String, Name: flagkey.HtOpenAPIPath, Usage: "Key of the OpenAPI spec in the configmap, or path of the spec file in the package (default \"openapi.yaml\")"}
+	HtOpenAPIOperation  = Flag{Type: String, Name: flagkey.HtOpenAPIOperation, Usage: "Operation ID of the OpenAPI operation requests are validated against (default the operation matching URL and method)"}
+	HtFlagSource        = Flag{Type: String, Name: flagkey.HtFlagSource, Usage: "Feature flag source whose flags are passed to the function as request headers: --flagsource launchdarkly/<secret>, unleash/<secret> or configmap/<name> ('-' to disable)"}
+	HtFlagSourceURL     = Flag{Type: String, Name: flagkey.HtFlagSourceURL, Usage: "URL of the LaunchDarkly or Unleash API (default LaunchDarkly \"https://clientsdk.launchdarkly.com\")"}
+	HtFlagKeyHeader     = Flag{Type: String, Name: flagkey.HtFlagKeyHeader, Usage: "Request header carrying the user or tenant key feature flags are evaluated for (default \"X-Fission-User\")"}
+	HtFlag              = Flag{Type: StringSlice, Name: flagkey.HtFlag, Usage: "Feature flag to pass to the function, can be specified multiple times (default all flags of the source)"}
+	HtTimeout           = Flag{Type: Int, Name: flagkey.HtTimeout, Usage: "Timeout in seconds of requests to the trigger, retries included (0 for the function timeout only)"}
+	HtRetries           = Flag{Type: Int, Name: flagkey.HtRetries, Usage: "Times to retry failed requests with an idempotent method or an Idempotency-Key header (0 to disable)"}
+	HtRetryBackoff      = Flag{Type: Int, Name: flagkey.HtRetryBackoff, Usage: "Milliseconds to wait before the first retry, doubled for every further retry (default 100)"}
+	HtRetryOn           = Flag{Type: IntSlice, Name: flagkey.HtRetryOn, Usage: "Response status code that is retried, can be specified multiple times (default 502, 503 and 504)"}
+	HtBreakerThreshold  = Flag{Type: Int, Name: flagkey.HtBreakerThreshold, Usage: "Consecutive failed requests that open the circuit breaker of the trigger; enables the circuit breaker ('-1' to disable, default 5)"}
+	HtBreakerDuration   = Flag{Type: Int, Name: flagkey.HtBreakerDuration, Usage: "Seconds the circuit breaker stays open before probing the function again (default 30)"}
+	HtPriority          = Flag{Type: String, Name: flagkey.HtPriority, Usage: "Priority of requests to the trigger when router or executor are busy: interactive|standard|batch; clients may lower it with the X-Fission-Priority header (default standard)"}
+	HtLongPoll          = Flag{Type: Int, Name: flagkey.HtLongPoll, Usage: "Seconds router holds long polls to the trigger open, without retrying them on timeout; enables long polling ('-1' to disable, 0 for the default 300)"}
+	HtLongPollKeepAlive = Flag{Type: Int, Name: flagkey.HtLongPollKeepAlive, Usage: "Seconds between marks of the pod serving a long poll as in use, so it isn't reclaimed as idle (default 30)"}
+	HtCloudEvents       = Flag{Type: String, Name: flagkey.HtCloudEvents, Usage: "CloudEvents 1.0 mode to invoke the function in: binary|structured|none (default the one router is set up with)"}
+
+	HtExperimentName       = Flag{Type: String, Name: flagkey.HtExperimentName, Usage: "Name of the A/B experiment on the trigger, which salts the assignment of users (default the trigger name)"}
+	HtExperimentAssignment = Flag{Type: String, Name: flagkey.HtExperimentAssignment, Usage: "Assign users to experiment variants by 'cookie' or 'header'; starts an A/B experiment on the trigger ('-' to stop it)"}
+	HtExperimentKey        = Flag{Type: String, Name: flagkey.HtExperimentKey, Usage: "Name of the cookie or header carrying the key users are assigned by (default cookie \"fission-experiment\")"}
+	HtExperimentExposure   = Flag{Type: Int, Name: flagkey.HtExperimentExposure, Usage: "Percentage of users taking part in the experiment (default 100)"}
+	HtExperimentVariant    = Flag{Type: StringSlice, Name: flagkey.HtExperimentVariant, Usage: "Experiment variant, can be specified multiple times and replaces all variants: --experimentvariant name=function[:weight]"}
 
 	TtName   = Flag{Type: String, Name: flagkey.TtName, Usage: "Time Trigger name"}
 	TtCron   = Flag{Type: String, Name: flagkey.TtCron, Usage: "Time trigger cron spec with each asterisk representing respectively second, minute, hour, the day of the month, month and day of the week. Also supports readable formats like '@every 5m', '@hourly'"}
+	TtAt     = Flag{Type: String, Name: flagkey.TtAt, Usage: "Time in RFC3339 format to invoke the function once at, like '2021-06-01T09:00:00Z'"}
+	TtEvery  = Flag{Type: String, Name: flagkey.TtEvery, Usage: "Interval to invoke the function at, like '90s' or '1h30m', aligned so that '1h' invokes it on the hour"}
+	TtJitter = Flag{Type: String, Name: flagkey.TtJitter, Usage: "Max random delay of the invocations, like '30s'"}
 	TtFnName = Flag{Type: String, Name: flagkey.TtFnName, Usage: "Function name"}
 	TtRound  = Flag{Type: Int, Name: flagkey.TtRound, Usage: "Get next N rounds of invocation time", DefaultValue: 1}
 
+	TtOutput        = Flag{Type: String, Name: flagkey.TtOutput, Usage: "Deliver the function response to 'storage' or by 'email' ('-' to disable)"}
+	TtOutputTimeout = Flag{Type: Int, Name: flagkey.TtOutputTimeout, Usage: "Seconds to wait for the function response to deliver (default 300)"}
+	TtEmailTo       = Flag{Type: StringSlice, Name: flagkey.TtEmailTo, Usage: "Recipient of the email output, can be specified multiple times"}
+	TtEmailSubject  = Flag{Type: String, Name: flagkey.TtEmailSubject, Usage: "Subject of the email output (default \"Report of <trigger name>\")"}
+	TtSMTPSecret    = Flag{Type: String, Name: flagkey.TtSMTPSecret, Usage: "Secret holding the SMTP server settings (host, port, username, password and from) for email output"}
+	TtCloudEvents   = Flag{Type: String, Name: flagkey.TtCloudEvents, Usage: "CloudEvents 1.0 mode to invoke the function in: binary|structured|none (default the one router is set up with)"}
+	TtTimezone      = Flag{Type: String, Name: flagkey.TtTimezone, Usage: "IANA time zone of the cron spec, like 'Europe/Paris' (default UTC)"}
+	TtPayload       = Flag{Type: String, Name: flagkey.TtPayload, Usage: "JSON body to invoke the function with, a Go template of .Time, .Trigger, .Namespace and .Timezone, like '{\"date\": \"{{ .Time.Format \"2006-01-02\" }}\"}'"}
+	TtMethod        = Flag{Type: String, Name: flagkey.TtMethod, Usage: "HTTP Method to invoke the function with: GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS (default POST)"}
+	TtHeader        = Flag{Type: StringSlice, Name: flagkey.TtHeader, Usage: "HTTP header to invoke the function with in format 'key: value', can be specified multiple times"}
+	TtCatchUpWindow = Flag{Type: Int, Name: flagkey.TtCatchUpWindow, Usage: "Seconds back to replay the fires missed while timer was down, from the last one (default 0, not replayed)"}
+
 	MqtName            = Flag{Type: String, Name: flagkey.MqtName, Usage: "Message queue trigger name"}
 	MqtFnName          = Flag{Type: String, Name: flagkey.MqtFnName, Usage: "Function name"}
-	MqtMQType          = Flag{Type: String, Name: flagkey.MqtMQType, Usage: "Message queue type, e.g. nats-streaming, azure-storage-queue, kafka", DefaultValue: "nats-streaming"}
+	MqtMQType          = Flag{Type: String, Name: flagkey.MqtMQType, Usage: "Message queue type, e.g. nats-streaming, azure-storage-queue, kafka, rabbitmq, aws-sqs-queue, gcp-pubsub, redis-streams, pulsar", DefaultValue: "nats-streaming"}
 	MqtTopic           = Flag{Type: String, Name: flagkey.MqtTopic, Usage: "Message queue Topic the trigger listens on"}
 	MqtRespTopic       = Flag{Type: String, Name: flagkey.MqtRespTopic, Usage: "Topic that the function response is sent on (response discarded if unspecified)"}
 	MqtErrorTopic      = Flag{Type: String, Name: flagkey.MqtErrorTopic, Usage: "Topic that the function error messages are sent to (errors discarded if unspecified"}
 	MqtMaxRetries      = Flag{Type: Int, Name: flagkey.MqtMaxRetries, Usage: "Maximum number of times the function will be retried upon failure", DefaultValue: 0}
+	MqtBackoff         = Flag{Type: String, Name: flagkey.MqtBackoff, Usage: "How long to wait before retrying the function, doubled for each retry, e.g. 500ms, 10s; retried right away if unspecified"}
+	MqtMaxBackoff      = Flag{Type: String, Name: flagkey.MqtMaxBackoff, Usage: "Maximum time to wait before retrying the function, e.g. 30s, 5m (default 1m)"}
+	MqtJitter          = Flag{Type: Bool, Name: flagkey.MqtJitter, Usage: "If set, wait a random time up to the backoff before retrying the function"}
+	MqtDeadLetterTopic = Flag{Type: String, Name: flagkey.MqtDeadLetterTopic, Usage: "Topic that messages the function failed on after all retries are moved to (messages left to the message queue if unspecified)"}
 	MqtMsgContentType  = Flag{Type: String, Name: flagkey.MqtMsgContentType, Short: "c", Usage: "Content type of messages that publish to the topic", DefaultValue: "application/json"}
+	MqtBatchSize       = Flag{Type: Int, Name: flagkey.MqtBatchSize, Usage: "Maximum number of messages to invoke the function with at once, as a JSON array; messages are passed one at a time unless greater than 1", DefaultValue: 0}
+	MqtBatchWindow     = Flag{Type: String, Name: flagkey.MqtBatchWindow, Usage: "How long to wait for more messages after the first one of a batch, e.g. 500ms, 10s (default 1s)"}
+	MqtMaxConcurrency  = Flag{Type: Int, Name: flagkey.MqtMaxConcurrency, Usage: "Maximum number of function invocations in flight at once per consumer replica; unlimited if 0", DefaultValue: 0}
+	MqtTargetLag       = Flag{Type: Int, Name: flagkey.MqtTargetLag, Usage: "Number of messages waiting to be consumed per consumer replica, to scale the consumers of a fission trigger between the min and max replica count; consumers aren't scaled if 0", DefaultValue: 0}
 	MqtPollingInterval = Flag{Type: Int, Name: flagkey.MqtPollingInterval, Usage: "Interval to check the message source for up/down scaling operation of consumers", DefaultValue: 30}
 	MqtCooldownPeriod  = Flag{Type: Int, Name: flagkey.MqtCooldownPeriod, Usage: "The period to wait after the last trigger reported active before scaling the consumer back to 0", DefaultValue: 300}
 	MqtMinReplicaCount = Flag{Type: Int, Name: flagkey.MqtMinReplicaCount, Usage: "Minimum number of replicas of consumers to scale down to", DefaultValue: 0}
 	MqtMaxReplicaCount = Flag{Type: Int, Name: flagkey.MqtMaxReplicaCount, Usage: "Maximum number of replicas of consumers to scale up to", DefaultValue: 100}
-	MqtMetadata        = Flag{Type: StringSlice, Name: flagkey.MqtMetadata, Usage: "Metadata needed for connecting to source system in format: --metadata key1=value1 --metadata key2=value2"}
+	MqtMetadata        = Flag{Type: StringSlice, Name: flagkey.MqtMetadata, Usage: "Metadata needed for connecting to source system in format: --metadata key1=value1 --metadata key2=value2. For kafka: sasl (plaintext, scram_sha256, scram_sha512, oauthbearer), tls (enable), oauthTokenEndpointUri, scopes, schemaRegistryUrl, with the credentials (username, password, ca, cert, key, schemaRegistryUsername, schemaRegistryPassword) in the secret. For rabbitmq: exchange, exchangeType, bindingKey, prefetch, deadLetterExchange, deadLetterRoutingKey. For aws-sqs-queue: visibilityTimeout, maxMessages, waitTimeSeconds, deadLetterQueue, maxReceiveCount, snsTopicArn. For gcp-pubsub: subscription, ackDeadline, maxMessages, ordering, deadLetterTopic, maxDeliveryAttempts. For redis-streams: consumerGroup, batchSize, claimIdleTime, maxDeliveries, deadLetterStream. For pulsar: subscription, subscriptionType, receiverQueueSize, nackBackoffMin, nackBackoffMax, deadLetterTopic, maxRedeliverCount"}
 	MqtSecret          = Flag{Type: String, Name: flagkey.MqtSecret, Usage: "Name of secret object", DefaultValue: ""}
 	MqtKind            = Flag{Type: String, Name: flagkey.MqtKind, Usage: "Kind of Message Queue Trigger, e.g. fission, keda", DefaultValue: "fission"}
+	MqtCloudEvents     = Flag{Type: String, Name: flagkey.MqtCloudEvents, Usage: "CloudEvents 1.0 mode to invoke the function in: binary|structured|none (default the one router is set up with)"}
 
 	EnvName                   = Flag{Type: String, Name: flagkey.EnvName, Usage: "Environment name"}
 	EnvPoolsize               = Flag{Type: Int, Name: flagkey.EnvPoolsize, Usage: "Size of the pool", DefaultValue: 3}
 	EnvImage                  = Flag{Type: String, Name: flagkey.EnvImage, Usage: "Environment image URL"}
 	EnvBuilderImage           = Flag{Type: String, Name: flagkey.EnvBuilderImage, Usage: "Environment builder image URL"}
 	EnvBuildCmd               = Flag{Type: String, Name: flagkey.EnvBuildcommand, Usage: "Build command for environment builder to build source package"}
+	EnvBuildConcurrency       = Flag{Type: Int, Name: flagkey.EnvBuildConcurrency, Usage: "Max number of packages of the environment built at the same time (builder manager default if 0)"}
+	EnvBuildOutput            = Flag{Type: String, Name: flagkey.EnvBuildOutput, Usage: "What builds produce: 'archive' (default) or 'image', a container image newdeploy functions run from"}
 	EnvKeepArchive            = Flag{Type: Bool, Name: flagkey.EnvKeeparchive, Usage: "Keep the archive instead of extracting it into a directory (mainly for the JVM environment because .jar is one kind of zip archive)"}
 	EnvExternalNetwork        = Flag{Type: Bool, Name: flagkey.EnvExternalNetwork, Usage: "Allow pod to access external network (only works when istio feature is enabled)"}
-	EnvTerminationGracePeriod = Flag{Type: Int64, Name: flagkey.EnvGracePeriod, Aliases: []string{"period"}, Usage: "Grace time (in seconds) for pod to perform connection draining before termination (default value will be used if 0 is given)", DefaultValue: 360}
+	EnvTerminationGracePeriod = Flag{Type: Int64, Name: flagkey.EnvGracePeriod, Aliases: []string{"period"}, Usage: "Grace time (in seconds) for pod to perform connection draining before termination (default value will be used if 0 is given)", DefaultValue: fv1.DefaultTerminationGracePeriod}
 	EnvVersion                = Flag{Type: Int, Name: flagkey.EnvVersion, Usage: "Environment API version (1 means v1 interface)", DefaultValue: 1}
 	EnvImagePullSecret        = Flag{Type: String, Name: flagkey.EnvImagePullSecret, Usage: "Secret for Kubernetes to pull an image from a private registry"}
+	EnvRollout                = Flag{Type: String, Name: flagkey.EnvRollout, Usage: "How the pre-warm pool is replaced when the environment changes: 'recreate' (default) all at once or 'gradual' in steps"}
+	EnvRolloutMaxSurge        = Flag{Type: Int, Name: flagkey.EnvRolloutMaxSurge, Usage: "Number of pods of the new pool started in each step of a gradual rollout (default 1)"}
+	EnvRolloutMaxUnavailable  = Flag{Type: Int, Name: flagkey.EnvRolloutMaxUnavailable, Usage: "Number of pods the pool may be short of its size during a gradual rollout"}
+	EnvRolloutCanary          = Flag{Type: Bool, Name: flagkey.EnvRolloutCanary, Usage: "Start a single pod of the new pool first in a gradual rollout, and go on only once it's ready"}
+	EnvRolloutStepInterval    = Flag{Type: Int, Name: flagkey.EnvRolloutStepInterval, Usage: "Time (in seconds) between the steps of a gradual rollout (default 10)"}
 
-	KwName      = Flag{Type: String, Name: flagkey.KwName, Usage: "Watch name"}
-	KwFnName    = Flag{Type: String, Name: flagkey.KwFnName, Usage: "Function name"}
-	KwNamespace = Flag{Type: String, Name: flagkey.KwNamespace, Aliases: []string{"ns"}, Usage: "Namespace of resource to watch", DefaultValue: metav1.NamespaceDefault}
-	KwObjType   = Flag{Type: String, Name: flagkey.KwObjType, Usage: "Type of resource to watch (Pod, Service, etc.)", DefaultValue: "pod"}
-	KwLabels    = Flag{Type: String, Name: flagkey.KwLabels, Usage: "Label selector of the form a=b,c=d"}
+	KwName          = Flag{Type: String, Name: flagkey.KwName, Usage: "Watch name"}
+	KwFnName        = Flag{Type: String, Name: flagkey.KwFnName, Usage: "Function name"}
+	KwNamespace     = Flag{Type: String, Name: flagkey.KwNamespace, Aliases: []string{"ns"}, Usage: "Namespace of resource to watch, all namespaces if empty", DefaultValue: metav1.NamespaceDefault}
+	KwObjType       = Flag{Type: String, Name: flagkey.KwObjType, Usage: "Type of resource to watch (Pod, Service, etc.), the kind of the resources of --apiversion if set", DefaultValue: "pod"}
+	KwAPIVersion    = Flag{Type: String, Name: flagkey.KwAPIVersion, Usage: "API version of the resources to watch, like cert-manager.io/v1, to watch any kind of resource including custom ones"}
+	KwLabels        = Flag{Type: String, Name: flagkey.KwLabels, Usage: "Label selector of the form a=b,c=d"}
+	KwFieldSelector = Flag{Type: String, Name: flagkey.KwFieldSelector, Usage: "Field selector of the resources to watch, like status.phase=Running"}
+	KwFilter        = Flag{Type: String, Name: flagkey.KwFilter, Usage: "JSONPath expression events are passed to the function for if it finds a value other than null, false, \"False\" or \"\", like '{.status.conditions[?(@.type==\"Ready\")].status}'"}
+	KwCloudEvents   = Flag{Type: String, Name: flagkey.KwCloudEvents, Usage: "CloudEvents 1.0 mode to invoke the function in: binary|structured|none (default the one router is set up with)"}
 
 	PkgName           = Flag{Type: String, Name: flagkey.PkgName, Usage: "Package name"}
 	PkgForce          = Flag{Type: Bool, Name: flagkey.PkgForce, Short: "f", Usage: "Force update a package even if it is used by one or more functions"}
@@ -176,6 +269,17 @@ var (
 	PkgSrcArchive     = Flag{Type: StringSlice, Name: flagkey.PkgSrcArchive, Aliases: []string{"source", "src"}, Usage: "URL or local paths for source archive"}
 	PkgSrcChecksum    = Flag{Type: String, Name: flagkey.PkgSrcChecksum, Usage: "SHA256 checksum of source archive when providing URL"}
 	PkgInsecure       = Flag{Type: Bool, Name: flagkey.PkgInsecure, Usage: "Skip generating SHA256 checksum for file integrity validation"}
+	PkgSrcGit         = Flag{Type: String, Name: flagkey.PkgSrcGit, Usage: "URL of the Git repository of the source archive, cloned at build time"}
+	PkgDeployGit      = Flag{Type: String, Name: flagkey.PkgDeployGit, Usage: "URL of the Git repository of the deploy archive, cloned at specialization time"}
+	PkgGitRef         = Flag{Type: String, Name: flagkey.PkgGitRef, Usage: "Branch, tag or commit of the Git repository to check out (default branch if unspecified)"}
+	PkgGitSubPath     = Flag{Type: String, Name: flagkey.PkgGitSubPath, Usage: "Directory of the Git repository holding the package (whole repository if unspecified)"}
+	PkgGitSecret      = Flag{Type: String, Name: flagkey.PkgGitSecret, Usage: "Secret in the namespace of the package to authenticate to the Git repository with: username and password, or ssh-privatekey and known_hosts"}
+	PkgArchiveSecret  = Flag{Type: String, Name: flagkey.PkgArchiveSecret, Usage: "Secret in the namespace of the package with the credentials of s3://, gs:// or azblob:// archive URLs"}
+	PkgFollow         = Flag{Type: Bool, Name: flagkey.PkgFollow, Short: "f", Usage: "Wait for pending and running builds and print their logs once they end"}
+	PkgAllBuilds      = Flag{Type: Bool, Name: flagkey.PkgAllBuilds, Usage: "Print the logs of all the builds kept, not just the last one"}
+	PkgRevisions      = Flag{Type: IntSlice, Name: flagkey.PkgRevisions, Usage: "Two revisions (generations) of the package to compare, e.g. --revisions 3,5 (default the last two kept)"}
+	PkgDiffSource     = Flag{Type: Bool, Name: flagkey.PkgDiffSource, Usage: "Compare the source archives instead of the deploy archives"}
+	PkgDiffText       = Flag{Type: Bool, Name: flagkey.PkgDiffText, Usage: "Print unified diffs of the changed text files"}
 
 	SpecSave       = Flag{Type: Bool, Name: flagkey.SpecSave, Usage: "Save to the spec directory instead of creating on cluster"}
 	SpecDir        = Flag{Type: String, Name: flagkey.SpecDir, Usage: "Directory to store specs, defaults to ./specs"}
@@ -184,12 +288,44 @@ var (
 	SpecWait       = Flag{Type: Bool, Name: flagkey.SpecWait, Usage: "Wait for package builds"}
 	SpecWatch      = Flag{Type: Bool, Name: flagkey.SpecWatch, Usage: "Watch local files for change, and re-apply specs as necessary"}
 	SpecDelete     = Flag{Type: Bool, Name: flagkey.SpecDelete, Usage: "Allow apply to delete resources that no longer exist in the specification"}
+	SpecPrune      = Flag{Type: Bool, Name: flagkey.SpecPrune, Usage: "Delete the resources applied from the specs before and no longer in them, triggers first; same as --delete"}
 	SpecDry        = Flag{Type: Bool, Name: flagkey.SpecDry, Usage: "View the generated specs"}
+	SpecApplyDry   = Flag{Type: Bool, Name: flagkey.SpecDry, Usage: "Show what apply would create, update and delete, changing nothing"}
 	SpecValidation = Flag{Type: String, Name: flagkey.SpecValidate, Usage: "Turns server side validations of Fission objects on/off"}
+	SpecForce      = Flag{Type: Bool, Name: flagkey.SpecForce, Usage: "Overwrite the fields of resources changed out of band since the specs were last applied and changed in the specs too"}
+	SpecSet        = Flag{Type: StringSlice, Name: flagkey.SpecSet, Usage: "Value to render the spec templates with: --set key=value, where nested keys are dotted: --set image.tag=1.2.3"}
+	SpecValues     = Flag{Type: StringSlice, Name: flagkey.SpecValues, Usage: "YAML file of values to render the spec templates with, later files overriding earlier ones, and --set overriding all"}
+
+	SpecExportNamespace = Flag{Type: String, Name: flagkey.SpecExportNamespace, Aliases: []string{"ns"}, Usage: "Namespace of the resources to export, all namespaces if not set"}
+	SpecExportDownload  = Flag{Type: Bool, Name: flagkey.SpecExportDownload, Usage: "Download the archives of packages next to the specs, for apply to upload them again, instead of referring to them where they are"}
+	SpecExportAdopt     = Flag{Type: Bool, Name: flagkey.SpecExportAdopt, Usage: "Mark the exported resources as applied from the specs, for 'fission spec apply' to manage them"}
 
 	SupportOutput = Flag{Type: String, Name: flagkey.SupportOutput, Short: "o", Usage: "Output directory to save dump archive/files", DefaultValue: flagkey.DefaultSpecOutputDir}
 	SupportNoZip  = Flag{Type: Bool, Name: flagkey.SupportNoZip, Usage: "Save dump information into multiple files instead of single zip file"}
 
+	StatusNamespace = Flag{Type: String, Name: flagkey.StatusNamespace, Aliases: []string{"ns"}, Usage: "Namespace of event sources", DefaultValue: metav1.NamespaceDefault}
+	StatusWindow    = Flag{Type: String, Name: flagkey.StatusWindow, Usage: "Time window firing and error rates are calculated over, ex: 1m, 5m, 1h", DefaultValue: "5m"}
+
+	DashboardNamespace = Flag{Type: String, Name: flagkey.DashboardNamespace, Aliases: []string{"ns"}, Usage: "Namespace of functions and packages", DefaultValue: metav1.NamespaceDefault}
+	DashboardWindow    = Flag{Type: String, Name: flagkey.DashboardWindow, Usage: "Time window call and error rates are calculated over, ex: 1m, 5m, 1h", DefaultValue: "5m"}
+	DashboardInterval  = Flag{Type: Duration, Name: flagkey.DashboardInterval, Usage: "Length of time between refreshes", DefaultValue: 2 * time.Second}
+
+	DriftRevert = Flag{Type: Bool, Name: flagkey.DriftRevert, Usage: "Bring drifted objects back in line with their specs: revert modified objects, recreate missing ones and delete orphaned ones"}
+
+	RestoreNamespace = Flag{Type: String, Name: flagkey.RestoreNamespace, Aliases: []string{"ns"}, Usage: "Namespace of the deleted object", DefaultValue: metav1.NamespaceDefault}
+	RestoreKind      = Flag{Type: String, Name: flagkey.RestoreKind, Usage: "Kind of deleted objects to list, all kinds if not given"}
+	RestoreUID       = Flag{Type: String, Name: flagkey.RestoreUID, Usage: "UID of the deleted object to restore if it was deleted more than once, the most recently deleted one if not given"}
+
+	AuditKind      = Flag{Type: String, Name: flagkey.AuditKind, Usage: "Kind of objects, e.g. function, package, environment, httptrigger"}
+	AuditNamespace = Flag{Type: String, Name: flagkey.AuditNamespace, Aliases: []string{"ns"}, Usage: "Namespace of objects, all namespaces if not given"}
+	AuditName      = Flag{Type: String, Name: flagkey.AuditName, Usage: "Name of objects"}
+	AuditUser      = Flag{Type: String, Name: flagkey.AuditUser, Usage: "User who made the changes"}
+	AuditSince     = Flag{Type: String, Name: flagkey.AuditSince, Usage: "Only changes since the RFC3339 time or the duration ago, ex: 1h, 24h"}
+	AuditLimit     = Flag{Type: Int, Name: flagkey.AuditLimit, Usage: "Maximum number of changes to list, the most recent ones are listed", DefaultValue: 50}
+
+	SimulateProfile = Flag{Type: String, Name: flagkey.SimulateProfile, Usage: "YAML file of the environments, functions and traffic to simulate"}
+	SimulateJSON    = Flag{Type: Bool, Name: flagkey.SimulateJSON, Usage: "Print the report as JSON"}
+
 	CanaryName              = Flag{Type: String, Name: flagkey.CanaryName, Usage: "Name for the canary config"}
 	CanaryTriggerName       = Flag{Type: String, Name: flagkey.CanaryHTTPTriggerName, Usage: "Http trigger that this config references"}
 	CanaryNewFunc           = Flag{Type: String, Name: flagkey.CanaryNewFunc, Aliases: []string{"newfn"}, Usage: "New version of the function"}
@@ -197,4 +333,13 @@ var (
 	CanaryWeightIncrement   = Flag{Type: Int, Name: flagkey.CanaryWeightIncrement, Aliases: []string{"step"}, Usage: "Weight increment step for function", DefaultValue: 20}
 	CanaryIncrementInterval = Flag{Type: String, Name: flagkey.CanaryIncrementInterval, Aliases: []string{"internal"}, Usage: "Weight increment interval, string representation of time.Duration, ex : 1m, 2h, 2d", DefaultValue: "2m"}
 	CanaryFailureThreshold  = Flag{Type: Int, Name: flagkey.CanaryFailureThreshold, Aliases: []string{"threshold"}, Usage: "Threshold in percentage beyond which the new version of the function is considered unstable", DefaultValue: 10}
+	CanaryMatch             = Flag{Type: String, Name: flagkey.CanaryMatch, Usage: "Route requests matching a header, cookie or query parameter to the new function: --match header:X-Canary=true ('-' to remove)"}
+	CanaryLatencySLO        = Flag{Type: StringSlice, Name: flagkey.CanaryLatencySLO, Usage: "Latency percentile the new function must stay below, roll back otherwise: --latency-slo p95=300ms --latency-slo p99=1s ('-' to remove all SLOs)"}
+	CanaryQuerySLO          = Flag{Type: StringSlice, Name: flagkey.CanaryQuerySLO, Usage: "PromQL expression the new function must stay below, roll back otherwise: --query-slo '<name>:<threshold>:<query>'. {{function}}, {{namespace}}, {{path}}, {{method}} and {{window}} in the query are substituted ('-' to remove all SLOs)"}
+	CanaryAnalysisWindow    = Flag{Type: StringSlice, Name: flagkey.CanaryAnalysisWindow, Usage: "Time window the failure threshold and SLOs are evaluated over; a rollback happens only if a criterion is violated in all windows, ex: --analysis-window 1m --analysis-window 5m ('-' to remove)"}
+	CanaryMetricsProvider   = Flag{Type: String, Name: flagkey.CanaryMetricsProvider, Usage: "Metrics backend to analyse the new function with: prometheus | datadog | cloudwatch | stackdriver, defaults to the Prometheus server of Fission ('-' to reset)"}
+	CanaryMetricsAddress    = Flag{Type: String, Name: flagkey.CanaryMetricsAddress, Usage: "Address of the metrics backend API, defaults to the public API endpoint of the provider"}
+	CanaryMetricsSecret     = Flag{Type: String, Name: flagkey.CanaryMetricsSecret, Usage: "Secret holding credentials of the metrics backend, in the same namespace as the canary config"}
+	CanaryMetricsRegion     = Flag{Type: String, Name: flagkey.CanaryMetricsRegion, Usage: "AWS region of CloudWatch"}
+	CanaryMetricsProject    = Flag{Type: String, Name: flagkey.CanaryMetricsProject, Usage: "GCP project of Stackdriver"}
 )
@@ -17,14 +17,16 @@ limitations under the License.
 package flagkey
 
 const (
-	Verbosity   = "verbosity"
-	Server      = "server"
-	ClientOnly  = "client-only"
-	KubeContext = "kube-context"
+	Verbosity    = "verbosity"
+	Server       = "server"
+	ClientOnly   = "client-only"
+	VersionCheck = "check"
+	KubeContext  = "kube-context"
 
 	resourceName = "name"
 	force        = "force"
 	Output       = "output"
+	cloudEvents  = "cloudevents"
 
 	NamespaceFunction    = "fnNamespace"
 	NamespaceEnvironment = "envNamespace"
@@ -38,6 +40,8 @@ const (
 	RuntimeMaxmemory = "maxmemory"
 	RuntimeTargetcpu = "targetcpu"
 
+	ReplicasTargetConcurrency = "targetconcurrency"
+
 	ReplicasMinscale = "minscale"
 	ReplicasMaxscale = "maxscale"
 
@@ -59,12 +63,34 @@ const (
 	FnLogDBType             = "dbtype"
 	FnLogReverseQuery       = "reverse"
 	FnLogCount              = "recordcount"
+	FnLogSince              = "since"
+	FnLogUntil              = "until"
+	FnLogTail               = "tail"
+	FnLogFilter             = "filter"
+	FnLogOutput             = Output
 	FnTestBody              = "body"
 	FnTestHeader            = "header"
 	FnTestQuery             = "query"
+	FnTestLocal             = "local"
+	FnTestEnvVersion        = "envversion"
 	FnIdleTimeout           = "idletimeout"
 	FnConcurrency           = "concurrency"
 	FnRequestsPerPod        = "requestsperpod"
+	FnCoLocateWith          = "colocatewith"
+	FnPriority              = "priority"
+	FnQuota                 = "quota"
+	FnListLabels            = "labels"
+	FnListFieldSelector     = "fieldselector"
+	FnListPageSize          = "pagesize"
+	FnListWatch             = "watch"
+	FnDebugImage            = "image"
+	FnImage                 = "image"
+	FnPort                  = "port"
+	FnImagePullSecret       = "imagepullsecret"
+	FnDebugTimeout          = "timeout"
+	FnInvocationsLimit      = "limit"
+	FnTopSort               = "sort"
+	FnTopOutput             = Output
 
 	HtName              = resourceName
 	HtMethod            = "method"
@@ -74,15 +100,63 @@ const (
 	HtIngressRule       = "ingressrule"
 	HtIngressAnnotation = "ingressannotation"
 	HtIngressTLS        = "ingresstls"
+	HtIngressType       = "ingresstype"
+	HtIngressGateway    = "ingressgateway"
 	HtFnName            = "function"
 	HtFnWeight          = "weight"
+	HtJWKSURL           = "jwksurl"
+	HtJWTIssuer         = "jwtissuer"
+	HtJWTAudience       = "jwtaudience"
+	HtJWTClaim          = "jwtclaim"
+	HtJWTClaimHeader    = "jwtclaimheader"
+	HtSessionAffinity   = "sessionaffinity"
+	HtSessionName       = "sessionname"
+	HtSessionTTL        = "sessionttl"
+	HtOpenAPISpec       = "openapispec"
+	HtOpenAPIPath       = "openapipath"
+	HtOpenAPIOperation  = "openapioperation"
+	HtFlagSource        = "flagsource"
+	HtFlagSourceURL     = "flagsourceurl"
+	HtFlagKeyHeader     = "flagkeyheader"
+	HtFlag              = "flag"
+	HtTimeout           = "timeout"
+	HtRetries           = "retries"
+	HtRetryBackoff      = "retrybackoff"
+	HtRetryOn           = "retryon"
+	HtBreakerThreshold  = "breakerthreshold"
+	HtBreakerDuration   = "breakerduration"
+	HtPriority          = "priority"
+	HtLongPoll          = "longpoll"
+	HtLongPollKeepAlive = "longpollkeepalive"
+	HtCloudEvents       = cloudEvents
 	HtFilter            = HtFnName
 
+	HtExperimentName       = "experimentname"
+	HtExperimentAssignment = "experimentassignment"
+	HtExperimentKey        = "experimentkey"
+	HtExperimentExposure   = "experimentexposure"
+	HtExperimentVariant    = "experimentvariant"
+
 	TtName   = resourceName
 	TtCron   = "cron"
+	TtAt     = "at"
+	TtEvery  = "every"
+	TtJitter = "jitter"
 	TtFnName = "function"
 	TtRound  = "round"
 
+	TtOutput        = Output
+	TtOutputTimeout = "outputtimeout"
+	TtEmailTo       = "emailto"
+	TtEmailSubject  = "emailsubject"
+	TtSMTPSecret    = "smtpsecret"
+	TtCloudEvents   = cloudEvents
+	TtTimezone      = "timezone"
+	TtPayload       = "payload"
+	TtMethod        = "method"
+	TtHeader        = "header"
+	TtCatchUpWindow = "catchupwindow"
+
 	MqtName            = resourceName
 	MqtFnName          = "function"
 	MqtMQType          = "mqtype"
@@ -90,7 +164,15 @@ const (
 	MqtRespTopic       = "resptopic"
 	MqtErrorTopic      = "errortopic"
 	MqtMaxRetries      = "maxretries"
+	MqtBackoff         = "backoff"
+	MqtMaxBackoff      = "maxbackoff"
+	MqtJitter          = "jitter"
+	MqtDeadLetterTopic = "deadlettertopic"
 	MqtMsgContentType  = "contenttype"
+	MqtBatchSize       = "batchsize"
+	MqtBatchWindow     = "batchwindow"
+	MqtMaxConcurrency  = "maxconcurrentinvocations"
+	MqtTargetLag       = "targetlag"
 	MqtPollingInterval = "pollinginterval"
 	MqtCooldownPeriod  = "cooldownperiod"
 	MqtMinReplicaCount = "minreplicacount"
@@ -98,23 +180,35 @@ const (
 	MqtMetadata        = "metadata"
 	MqtSecret          = "secret"
 	MqtKind            = "mqtkind"
+	MqtCloudEvents     = cloudEvents
 
-	EnvName            = resourceName
-	EnvPoolsize        = "poolsize"
-	EnvImage           = "image"
-	EnvBuilderImage    = "builder"
-	EnvBuildcommand    = "buildcmd"
-	EnvKeeparchive     = "keeparchive"
-	EnvExternalNetwork = "externalnetwork"
-	EnvGracePeriod     = "graceperiod"
-	EnvVersion         = "version"
-	EnvImagePullSecret = "imagepullsecret"
-
-	KwName      = resourceName
-	KwFnName    = "function"
-	KwNamespace = "namespace"
-	KwObjType   = "type"
-	KwLabels    = "labels"
+	EnvName                  = resourceName
+	EnvPoolsize              = "poolsize"
+	EnvImage                 = "image"
+	EnvBuilderImage          = "builder"
+	EnvBuildcommand          = "buildcmd"
+	EnvBuildConcurrency      = "buildconcurrency"
+	EnvBuildOutput           = "buildoutput"
+	EnvKeeparchive           = "keeparchive"
+	EnvExternalNetwork       = "externalnetwork"
+	EnvGracePeriod           = "graceperiod"
+	EnvVersion               = "version"
+	EnvImagePullSecret       = "imagepullsecret"
+	EnvRollout               = "rollout"
+	EnvRolloutMaxSurge       = "rolloutmaxsurge"
+	EnvRolloutMaxUnavailable = "rolloutmaxunavailable"
+	EnvRolloutCanary         = "rolloutcanary"
+	EnvRolloutStepInterval   = "rolloutstepinterval"
+
+	KwName          = resourceName
+	KwFnName        = "function"
+	KwNamespace     = "namespace"
+	KwObjType       = "type"
+	KwAPIVersion    = "apiversion"
+	KwLabels        = "labels"
+	KwFieldSelector = "fieldselector"
+	KwFilter        = "filter"
+	KwCloudEvents   = cloudEvents
 
 	PkgName           = resourceName
 	PkgForce          = force
@@ -125,10 +219,21 @@ const (
 	PkgSrcChecksum    = "srcchecksum"
 	PkgDeployChecksum = "deploychecksum"
 	PkgInsecure       = "insecure"
+	PkgSrcGit         = "srcgit"
+	PkgDeployGit      = "deploygit"
+	PkgGitRef         = "gitref"
+	PkgGitSubPath     = "gitsubpath"
+	PkgGitSecret      = "gitsecret"
+	PkgArchiveSecret  = "archivesecret"
 	PkgBuildCmd       = "buildcmd"
 	PkgOutput         = Output
 	PkgStatus         = "status"
 	PkgOrphan         = "orphan"
+	PkgFollow         = "follow"
+	PkgAllBuilds      = "all"
+	PkgRevisions      = "revisions"
+	PkgDiffSource     = "source"
+	PkgDiffText       = "text"
 
 	SpecSave     = "spec"
 	SpecDir      = "specdir"
@@ -137,12 +242,43 @@ const (
 	SpecWait     = "wait"
 	SpecWatch    = "watch"
 	SpecDelete   = "delete"
+	SpecPrune    = "prune"
 	SpecDry      = "dry"
 	SpecValidate = "validation"
+	SpecForce    = force
+	SpecSet      = "set"
+	SpecValues   = "values"
+
+	SpecExportNamespace = "namespace"
+	SpecExportDownload  = "download"
+	SpecExportAdopt     = "adopt"
 
 	SupportOutput = Output
 	SupportNoZip  = "nozip"
 
+	StatusNamespace = "namespace"
+	StatusWindow    = "window"
+
+	DashboardNamespace = "namespace"
+	DashboardWindow    = "window"
+	DashboardInterval  = "interval"
+
+	DriftRevert = "revert"
+
+	RestoreNamespace = "namespace"
+	RestoreKind      = "kind"
+	RestoreUID       = "uid"
+
+	AuditKind      = "kind"
+	AuditNamespace = "namespace"
+	AuditName      = "name"
+	AuditUser      = "user"
+	AuditSince     = "since"
+	AuditLimit     = "limit"
+
+	SimulateProfile = "profile"
+	SimulateJSON    = "json"
+
 	CanaryName              = resourceName
 	CanaryHTTPTriggerName   = "httptrigger"
 	CanaryNewFunc           = "newfunction"
@@ -150,6 +286,15 @@ const (
 	CanaryWeightIncrement   = "increment-step"
 	CanaryIncrementInterval = "increment-interval"
 	CanaryFailureThreshold  = "failure-threshold"
+	CanaryMatch             = "match"
+	CanaryLatencySLO        = "latency-slo"
+	CanaryQuerySLO          = "query-slo"
+	CanaryAnalysisWindow    = "analysis-window"
+	CanaryMetricsProvider   = "metrics-provider"
+	CanaryMetricsAddress    = "metrics-address"
+	CanaryMetricsSecret     = "metrics-secret"
+	CanaryMetricsRegion     = "metrics-region"
+	CanaryMetricsProject    = "metrics-project"
 
 	DefaultSpecOutputDir = "fission-dump"
 )
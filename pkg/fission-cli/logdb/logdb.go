@@ -23,6 +23,14 @@ import (
 
 const (
 	INFLUXDB = "influxdb"
+
+	// KUBERNETES streams the logs from the function pods, with no log
+	// database.
+	KUBERNETES = "kubernetes"
+
+	// SINK queries the logs from the sink the logger ships them to, through
+	// the controller log API.
+	SINK = "sink"
 )
 
 type LogDatabase interface {
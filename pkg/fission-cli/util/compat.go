@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/fission/fission/pkg/controller/client"
+	"github.com/fission/fission/pkg/fission-cli/console"
+	"github.com/fission/fission/pkg/info"
+)
+
+var (
+	serverInfoLock   sync.Mutex
+	cachedServerInfo *info.ServerInfo
+)
+
+// getServerInfo returns the info of the server, asking it only once per
+// command.
+func getServerInfo(client client.Interface) (*info.ServerInfo, error) {
+	serverInfoLock.Lock()
+	defer serverInfoLock.Unlock()
+	if cachedServerInfo == nil {
+		si, err := client.V1().Misc().ServerInfo()
+		if err != nil {
+			return nil, err
+		}
+		cachedServerInfo = si
+	}
+	return cachedServerInfo, nil
+}
+
+// RequireServerFeature returns an error if the server doesn't support a
+// feature of the API an operation needs. Servers that can't be asked are
+// assumed to support it, so that the operation reports the actual error.
+func RequireServerFeature(client client.Interface, feature info.Feature) error {
+	si, err := getServerInfo(client)
+	if err != nil {
+		console.Verbose(2, "Error getting Fission server info: %v", err)
+		return nil
+	}
+	if si.Supports(feature) {
+		return nil
+	}
+	return errors.Errorf("the Fission server (version %q) doesn't support %v, upgrade the server to use it",
+		si.Build.Version, feature)
+}
+
+// WarnUnsupportedFeatures warns about the features of the spec of an object
+// the server doesn't support, which the server would ignore.
+func WarnUnsupportedFeatures(client client.Interface, kind string, name string, features []info.Feature) {
+	if len(features) == 0 {
+		return
+	}
+	si, err := getServerInfo(client)
+	if err != nil {
+		console.Verbose(2, "Error getting Fission server info: %v", err)
+		return
+	}
+	var unsupported []string
+	for _, f := range features {
+		if !si.Supports(f) {
+			unsupported = append(unsupported, string(f))
+		}
+	}
+	if len(unsupported) > 0 {
+		console.Warn(fmt.Sprintf("The Fission server (version %q) doesn't support %v of %v %v, which it will ignore",
+			si.Build.Version, strings.Join(unsupported, ", "), kind, name))
+	}
+}
+
+// CheckCompatibility returns how compatible the CLI is with the server.
+func CheckCompatibility(client client.Interface) (*info.Compatibility, error) {
+	si, err := getServerInfo(client)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting Fission server info")
+	}
+	return info.CheckCompatibility(info.BuildInfo(), si), nil
+}
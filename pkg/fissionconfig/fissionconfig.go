@@ -0,0 +1,392 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fissionconfig applies the FissionConfig of a Fission install to
+// its components. The components keep reading their settings from
+// environment variables, as rendered by the Helm chart; the settings of the
+// FissionConfig override them at startup, and changes are applied while
+// the component runs where it supports it, or once it restarts.
+package fissionconfig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	k8sCache "k8s.io/client-go/tools/cache"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+)
+
+const (
+	// Name is the name of the FissionConfig components reconcile, in the
+	// namespace Fission is installed in.
+	Name = "fission"
+
+	// Components reconciling the FissionConfig.
+	ComponentExecutor   = "executor"
+	ComponentRouter     = "router"
+	ComponentBuilderMgr = "buildermgr"
+
+	// AnnotationDeployedSpec is the checksum of the spec the FissionConfig
+	// was last deployed with.
+	AnnotationDeployedSpec = "fission.io/deployed-spec"
+
+	maxRetries = 5
+)
+
+// ErrRestartRequired is returned by live setters for values they can't
+// apply while the component runs.
+var ErrRestartRequired = errors.New("restart required")
+
+type (
+	// Reconciler applies the FissionConfig to a component. A nil reconciler
+	// applies nothing.
+	Reconciler struct {
+		logger        *zap.Logger
+		fissionClient *crd.FissionClient
+		namespace     string
+		component     string
+
+		// defaults are the values of the settings the component was
+		// deployed with, used for settings the FissionConfig leaves unset.
+		defaults map[string]string
+
+		// live apply changed settings while the component runs, by
+		// setting name.
+		live map[string]func(value string) error
+
+		observedGeneration int64
+	}
+
+	// setting maps a field of the FissionConfig to the environment
+	// variable the component reads it from.
+	setting struct {
+		name  string
+		value func(spec *fv1.FissionConfigSpec) *string
+	}
+)
+
+var componentSettings = map[string][]setting{
+	ComponentExecutor: {
+		{"EXECUTOR_MAX_CONCURRENT_SPECIALIZATIONS", func(spec *fv1.FissionConfigSpec) *string {
+			return intValue(spec.Executor.MaxConcurrentSpecializations)
+		}},
+		{"EXECUTOR_EVICTION_MIN_IDLE", func(spec *fv1.FissionConfigSpec) *string {
+			return spec.Executor.EvictionMinIdle
+		}},
+		{"EXECUTOR_EVICTION_MAX_PODS", func(spec *fv1.FissionConfigSpec) *string {
+			return intValue(spec.Executor.EvictionMaxPods)
+		}},
+		{"POD_READY_TIMEOUT", func(spec *fv1.FissionConfigSpec) *string {
+			return spec.Executor.PodReadyTimeout
+		}},
+	},
+	ComponentRouter: {
+		{"ROUTER_MAX_INFLIGHT_REQUESTS", func(spec *fv1.FissionConfigSpec) *string {
+			return intValue(spec.Router.MaxInflightRequests)
+		}},
+		{"ROUTER_ROUND_TRIP_TIMEOUT", func(spec *fv1.FissionConfigSpec) *string {
+			return spec.Router.RoundTripTimeout
+		}},
+		{"ROUTER_ROUND_TRIP_MAX_RETRIES", func(spec *fv1.FissionConfigSpec) *string {
+			return intValue(spec.Router.RoundTripMaxRetries)
+		}},
+		{"DISPLAY_ACCESS_LOG", func(spec *fv1.FissionConfigSpec) *string {
+			if spec.Router.DisplayAccessLog == nil {
+				return nil
+			}
+			value := strconv.FormatBool(*spec.Router.DisplayAccessLog)
+			return &value
+		}},
+	},
+	ComponentBuilderMgr: {
+		{"BUILDERMGR_MAX_CONCURRENT_BUILDS", func(spec *fv1.FissionConfigSpec) *string {
+			return intValue(spec.BuilderMgr.MaxConcurrentBuilds)
+		}},
+		{"BUILDERMGR_MAX_CONCURRENT_BUILDS_PER_ENV", func(spec *fv1.FissionConfigSpec) *string {
+			return intValue(spec.BuilderMgr.MaxConcurrentBuildsPerEnvironment)
+		}},
+		{"BUILDERMGR_BUILD_CACHE_TTL", func(spec *fv1.FissionConfigSpec) *string {
+			return spec.BuilderMgr.BuildCacheTTL
+		}},
+	},
+}
+
+func intValue(i *int) *string {
+	if i == nil {
+		return nil
+	}
+	value := strconv.Itoa(*i)
+	return &value
+}
+
+// Deploy creates the FissionConfig in namespace with the spec Fission was
+// deployed with, or updates it if that changed since the last deployment.
+// Changes made to the FissionConfig in between are kept until then.
+func Deploy(logger *zap.Logger, fissionClient *crd.FissionClient, namespace string, spec *fv1.FissionConfigSpec) error {
+	if err := spec.Validate(); err != nil {
+		return errors.Wrap(err, "invalid deployed FissionConfig")
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return errors.Wrap(err, "error encoding deployed FissionConfig")
+	}
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	client := fissionClient.CoreV1().FissionConfigs(namespace)
+	cfg, err := client.Get(Name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		_, err = client.Create(&fv1.FissionConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      Name,
+				Namespace: namespace,
+				Annotations: map[string]string{
+					AnnotationDeployedSpec: checksum,
+				},
+			},
+			Spec: *spec,
+		})
+		if err != nil {
+			return errors.Wrap(err, "error creating FissionConfig")
+		}
+		logger.Info("created FissionConfig", zap.String("namespace", namespace))
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "error getting FissionConfig")
+	}
+
+	if cfg.ObjectMeta.Annotations[AnnotationDeployedSpec] == checksum {
+		return nil
+	}
+	if cfg.ObjectMeta.Annotations == nil {
+		cfg.ObjectMeta.Annotations = make(map[string]string)
+	}
+	cfg.ObjectMeta.Annotations[AnnotationDeployedSpec] = checksum
+	cfg.Spec = *spec
+	_, err = client.Update(cfg)
+	if err != nil {
+		return errors.Wrap(err, "error updating FissionConfig")
+	}
+	logger.Info("updated FissionConfig to the deployed spec", zap.String("namespace", namespace))
+	return nil
+}
+
+// MakeReconciler returns a reconciler of the FissionConfig in the namespace
+// of the component, read from POD_NAMESPACE, or nil if that isn't set.
+func MakeReconciler(logger *zap.Logger, fissionClient *crd.FissionClient, component string) *Reconciler {
+	namespace := os.Getenv("POD_NAMESPACE")
+	if len(namespace) == 0 {
+		logger.Info("POD_NAMESPACE not set - FissionConfig is not applied", zap.String("component", component))
+		return nil
+	}
+	r := &Reconciler{
+		logger:        logger.Named("fission_config"),
+		fissionClient: fissionClient,
+		namespace:     namespace,
+		component:     component,
+		defaults:      make(map[string]string),
+		live:          make(map[string]func(value string) error),
+	}
+	for _, s := range componentSettings[component] {
+		r.defaults[s.name] = os.Getenv(s.name)
+	}
+	return r
+}
+
+// Load overrides the settings of the component with the ones of the
+// FissionConfig. It must be called before the component reads them.
+func (r *Reconciler) Load() {
+	if r == nil {
+		return
+	}
+	cfg, err := r.fissionClient.CoreV1().FissionConfigs(r.namespace).Get(Name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return
+	} else if err != nil {
+		r.logger.Error("error getting FissionConfig - using the deployed settings", zap.Error(err))
+		return
+	}
+	if err = cfg.Validate(); err != nil {
+		r.logger.Error("invalid FissionConfig - using the deployed settings", zap.Error(err))
+		return
+	}
+	for name, value := range r.values(&cfg.Spec) {
+		os.Setenv(name, value)
+	}
+}
+
+// SetLive registers the function applying changes of a setting while the
+// component runs. Changes of other settings, or changes the function returns
+// ErrRestartRequired for, take effect once the component restarts.
+func (r *Reconciler) SetLive(name string, apply func(value string) error) {
+	if r == nil {
+		return
+	}
+	r.live[name] = apply
+}
+
+// Run applies changes of the FissionConfig until stopCh is closed.
+func (r *Reconciler) Run(stopCh <-chan struct{}) {
+	if r == nil {
+		return
+	}
+	listWatch := k8sCache.NewListWatchFromClient(r.fissionClient.CoreV1().RESTClient(), "fissionconfigs", r.namespace,
+		fields.OneTermEqualSelector("metadata.name", Name))
+	_, controller := k8sCache.NewInformer(listWatch, &fv1.FissionConfig{}, 0, k8sCache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			r.reconcile(obj.(*fv1.FissionConfig))
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			r.reconcile(obj.(*fv1.FissionConfig))
+		},
+		DeleteFunc: func(obj interface{}) {
+			// back to the deployed settings
+			r.apply(&fv1.FissionConfigSpec{})
+			r.observedGeneration = 0
+		},
+	})
+	controller.Run(stopCh)
+}
+
+// values returns the settings of the component, falling back to the
+// deployed ones for the settings the spec leaves unset.
+func (r *Reconciler) values(spec *fv1.FissionConfigSpec) map[string]string {
+	values := make(map[string]string)
+	for _, s := range componentSettings[r.component] {
+		if v := s.value(spec); v != nil {
+			values[s.name] = *v
+		} else {
+			values[s.name] = r.defaults[s.name]
+		}
+	}
+	return values
+}
+
+func (r *Reconciler) reconcile(cfg *fv1.FissionConfig) {
+	// status updates don't change the generation
+	if cfg.ObjectMeta.Generation == r.observedGeneration {
+		return
+	}
+
+	var status fv1.ComponentConfigStatus
+	if err := cfg.Validate(); err != nil {
+		status = r.status(nil, err)
+	} else {
+		status = r.apply(&cfg.Spec)
+	}
+	status.ObservedGeneration = cfg.ObjectMeta.Generation
+
+	err := r.updateStatus(status)
+	if err != nil {
+		r.logger.Error("error reporting effective config", zap.Error(err))
+		return
+	}
+	r.observedGeneration = cfg.ObjectMeta.Generation
+}
+
+// apply applies the changed settings it can, and returns the status of
+// the component.
+func (r *Reconciler) apply(spec *fv1.FissionConfigSpec) fv1.ComponentConfigStatus {
+	var restartRequired []string
+	var errs []string
+	for name, value := range r.values(spec) {
+		if value == os.Getenv(name) {
+			continue
+		}
+		apply, ok := r.live[name]
+		if !ok {
+			restartRequired = append(restartRequired, name)
+			continue
+		}
+		err := apply(value)
+		if err == ErrRestartRequired {
+			restartRequired = append(restartRequired, name)
+			continue
+		} else if err != nil {
+			errs = append(errs, errors.Wrapf(err, "error applying %v=%q", name, value).Error())
+			continue
+		}
+		os.Setenv(name, value)
+		r.logger.Info("applied config change", zap.String("setting", name), zap.String("value", value))
+	}
+	if len(restartRequired) > 0 {
+		r.logger.Info("config changes take effect once the component restarts", zap.Strings("settings", restartRequired))
+	}
+
+	var err error
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		err = errors.New(strings.Join(errs, "; "))
+	}
+	return r.status(restartRequired, err)
+}
+
+// status returns the settings in effect, that is the current values of the
+// environment variables of the component.
+func (r *Reconciler) status(restartRequired []string, err error) fv1.ComponentConfigStatus {
+	status := fv1.ComponentConfigStatus{
+		Effective: make(map[string]string),
+		UpdatedAt: metav1.NewTime(time.Now()),
+	}
+	for _, s := range componentSettings[r.component] {
+		status.Effective[s.name] = os.Getenv(s.name)
+	}
+	if len(restartRequired) > 0 {
+		sort.Strings(restartRequired)
+		status.RestartRequired = restartRequired
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	return status
+}
+
+func (r *Reconciler) updateStatus(status fv1.ComponentConfigStatus) error {
+	client := r.fissionClient.CoreV1().FissionConfigs(r.namespace)
+	var err error
+	for i := 0; i < maxRetries; i++ {
+		var cfg *fv1.FissionConfig
+		cfg, err = client.Get(Name, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if cfg.Status.Components == nil {
+			cfg.Status.Components = make(map[string]fv1.ComponentConfigStatus)
+		}
+		cfg.Status.Components[r.component] = status
+
+		_, err = client.UpdateStatus(cfg)
+		if !k8serrors.IsConflict(err) {
+			return err
+		}
+	}
+	return err
+}
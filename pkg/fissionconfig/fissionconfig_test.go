@@ -0,0 +1,160 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fissionconfig
+
+import (
+	"os"
+	"testing"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/generated/clientset/versioned/fake"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestReconciler(t *testing.T) {
+	os.Setenv("POD_NAMESPACE", "fission")
+	defer os.Unsetenv("POD_NAMESPACE")
+	os.Setenv("BUILDERMGR_MAX_CONCURRENT_BUILDS_PER_ENV", "5")
+	os.Setenv("BUILDERMGR_BUILD_CACHE_TTL", "168h")
+	defer os.Unsetenv("BUILDERMGR_MAX_CONCURRENT_BUILDS_PER_ENV")
+	defer os.Unsetenv("BUILDERMGR_BUILD_CACHE_TTL")
+	defer os.Unsetenv("BUILDERMGR_MAX_CONCURRENT_BUILDS")
+
+	cfg := &fv1.FissionConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       Name,
+			Namespace:  "fission",
+			Generation: 1,
+		},
+		Spec: fv1.FissionConfigSpec{
+			BuilderMgr: fv1.BuilderMgrConfig{
+				MaxConcurrentBuilds: intPtr(20),
+			},
+		},
+	}
+	cs := fake.NewSimpleClientset(cfg)
+	r := MakeReconciler(zap.NewNop(), &crd.FissionClient{Interface: cs}, ComponentBuilderMgr)
+
+	r.Load()
+	if v := os.Getenv("BUILDERMGR_MAX_CONCURRENT_BUILDS"); v != "20" {
+		t.Fatalf("expected config to override deployed setting, got %q", v)
+	}
+	if v := os.Getenv("BUILDERMGR_BUILD_CACHE_TTL"); v != "168h" {
+		t.Fatalf("expected unset setting to keep deployed value, got %q", v)
+	}
+
+	var applied string
+	r.SetLive("BUILDERMGR_MAX_CONCURRENT_BUILDS_PER_ENV", func(value string) error {
+		applied = value
+		return nil
+	})
+
+	cfg.ObjectMeta.Generation = 2
+	cfg.Spec.BuilderMgr.MaxConcurrentBuildsPerEnvironment = intPtr(2)
+	cfg.Spec.BuilderMgr.BuildCacheTTL = stringPtr("1h")
+	r.reconcile(cfg)
+
+	if applied != "2" {
+		t.Fatalf("expected live setting to be applied, got %q", applied)
+	}
+	got, err := cs.CoreV1().FissionConfigs("fission").Get(Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, ok := got.Status.Components[ComponentBuilderMgr]
+	if !ok {
+		t.Fatalf("expected status of %v, got %v", ComponentBuilderMgr, got.Status)
+	}
+	if status.ObservedGeneration != 2 {
+		t.Fatalf("expected observed generation 2, got %v", status.ObservedGeneration)
+	}
+	if status.Effective["BUILDERMGR_MAX_CONCURRENT_BUILDS_PER_ENV"] != "2" ||
+		status.Effective["BUILDERMGR_BUILD_CACHE_TTL"] != "168h" {
+		t.Fatalf("unexpected effective settings %v", status.Effective)
+	}
+	if len(status.RestartRequired) != 1 || status.RestartRequired[0] != "BUILDERMGR_BUILD_CACHE_TTL" {
+		t.Fatalf("expected build cache TTL to require a restart, got %v", status.RestartRequired)
+	}
+
+	// invalid configs aren't applied
+	cfg.ObjectMeta.Generation = 3
+	cfg.Spec.BuilderMgr.MaxConcurrentBuildsPerEnvironment = intPtr(-1)
+	r.reconcile(cfg)
+	if applied != "2" {
+		t.Fatalf("expected invalid config not to be applied, got %q", applied)
+	}
+	got, _ = cs.CoreV1().FissionConfigs("fission").Get(Name, metav1.GetOptions{})
+	if len(got.Status.Components[ComponentBuilderMgr].Error) == 0 {
+		t.Fatal("expected error to be reported for invalid config")
+	}
+}
+
+func TestDeploy(t *testing.T) {
+	cs := fake.NewSimpleClientset()
+	fc := &crd.FissionClient{Interface: cs}
+	spec := &fv1.FissionConfigSpec{
+		Router: fv1.RouterConfig{MaxInflightRequests: intPtr(100)},
+	}
+
+	if err := Deploy(zap.NewNop(), fc, "fission", spec); err != nil {
+		t.Fatal(err)
+	}
+
+	// changes made after the deployment are kept while the deployed spec
+	// stays the same
+	cfg, err := cs.CoreV1().FissionConfigs("fission").Get(Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Spec.Router.MaxInflightRequests = intPtr(50)
+	if _, err = cs.CoreV1().FissionConfigs("fission").Update(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err = Deploy(zap.NewNop(), fc, "fission", spec); err != nil {
+		t.Fatal(err)
+	}
+	cfg, _ = cs.CoreV1().FissionConfigs("fission").Get(Name, metav1.GetOptions{})
+	if *cfg.Spec.Router.MaxInflightRequests != 50 {
+		t.Fatalf("expected edited spec to be kept, got %v", *cfg.Spec.Router.MaxInflightRequests)
+	}
+
+	// a new deployed spec replaces them
+	spec.Router.MaxInflightRequests = intPtr(200)
+	if err = Deploy(zap.NewNop(), fc, "fission", spec); err != nil {
+		t.Fatal(err)
+	}
+	cfg, _ = cs.CoreV1().FissionConfigs("fission").Get(Name, metav1.GetOptions{})
+	if *cfg.Spec.Router.MaxInflightRequests != 200 {
+		t.Fatalf("expected newly deployed spec, got %v", *cfg.Spec.Router.MaxInflightRequests)
+	}
+
+	spec.Router.MaxInflightRequests = intPtr(-1)
+	if err = Deploy(zap.NewNop(), fc, "fission", spec); err == nil {
+		t.Fatal("expected invalid spec to be rejected")
+	}
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fnanalysis
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const metricsAPI = "/apis/metrics.k8s.io/v1beta1"
+
+type (
+	// Analyzer reports on the compute isolation of functions, correlating
+	// the states and events of their pods with the load of the nodes they
+	// run on and of the pods next to them.
+	Analyzer struct {
+		logger    *zap.Logger
+		k8sClient kubernetes.Interface
+
+		// getMetrics gets a path of the metrics API
+		getMetrics func(path string) ([]byte, error)
+	}
+
+	// metricsUsage is the usage of a pod container or node in the metrics API.
+	metricsUsage struct {
+		Metadata metav1.ObjectMeta  `json:"metadata"`
+		Usage    apiv1.ResourceList `json:"usage"`
+	}
+
+	podMetrics struct {
+		Metadata   metav1.ObjectMeta `json:"metadata"`
+		Containers []metricsUsage    `json:"containers"`
+	}
+
+	podMetricsList struct {
+		Items []podMetrics `json:"items"`
+	}
+
+	nodeMetricsList struct {
+		Items []metricsUsage `json:"items"`
+	}
+)
+
+// MakeAnalyzer returns an analyzer using the metrics API of the cluster
+// (metrics-server) when it's available.
+func MakeAnalyzer(logger *zap.Logger, k8sClient kubernetes.Interface) *Analyzer {
+	return &Analyzer{
+		logger:    logger.Named("function_analyzer"),
+		k8sClient: k8sClient,
+		getMetrics: func(path string) ([]byte, error) {
+			return k8sClient.CoreV1().RESTClient().Get().AbsPath(metricsAPI + path).DoRaw()
+		},
+	}
+}
+
+// Analyze returns the compute isolation report of a function.
+func (a *Analyzer) Analyze(fn *metav1.ObjectMeta) (*Report, error) {
+	s, err := a.snapshot(fn)
+	if err != nil {
+		return nil, err
+	}
+	return analyze(fn, s), nil
+}
+
+// snapshot gets the cluster state around the pods of a function.
+func (a *Analyzer) snapshot(fn *metav1.ObjectMeta) (*snapshot, error) {
+	selector := labels.Set{
+		fv1.FUNCTION_NAME:      fn.Name,
+		fv1.FUNCTION_NAMESPACE: fn.Namespace,
+	}.AsSelector().String()
+	pods, err := a.k8sClient.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing function pods")
+	}
+
+	s := &snapshot{
+		nodes:    make(map[string]*apiv1.Node),
+		nodePods: make(map[string][]apiv1.Pod),
+	}
+	for _, pod := range pods.Items {
+		// pods being deleted were specialized for an older function version
+		if pod.ObjectMeta.DeletionTimestamp != nil {
+			continue
+		}
+		s.pods = append(s.pods, pod)
+
+		events, err := a.listEvents(pod.ObjectMeta.Namespace, "Pod", pod.ObjectMeta.Name)
+		if err != nil {
+			return nil, err
+		}
+		s.events = append(s.events, events...)
+
+		nodeName := pod.Spec.NodeName
+		if len(nodeName) == 0 {
+			continue
+		}
+		if _, ok := s.nodes[nodeName]; ok {
+			continue
+		}
+
+		node, err := a.k8sClient.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting node %v", nodeName)
+		}
+		s.nodes[nodeName] = node
+
+		nodePods, err := a.k8sClient.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "error listing pods of node %v", nodeName)
+		}
+		s.nodePods[nodeName] = nodePods.Items
+
+		events, err = a.listEvents(metav1.NamespaceAll, "Node", nodeName)
+		if err != nil {
+			return nil, err
+		}
+		s.events = append(s.events, events...)
+	}
+
+	// reports are still useful without usages
+	err = a.getUsages(s)
+	if err != nil {
+		a.logger.Info("metrics API unavailable, analyzing without usages", zap.Error(err))
+		s.podUsage = nil
+		s.nodeUsage = nil
+	}
+
+	return s, nil
+}
+
+func (a *Analyzer) listEvents(namespace string, kind string, name string) ([]apiv1.Event, error) {
+	events, err := a.k8sClient.CoreV1().Events(namespace).List(metav1.ListOptions{
+		FieldSelector: fields.Set{
+			"involvedObject.kind": kind,
+			"involvedObject.name": name,
+		}.AsSelector().String(),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error listing events of %v %v", kind, name)
+	}
+	return events.Items, nil
+}
+
+// getUsages gets the usages of the nodes running pods of the function, and
+// of all pods, from the metrics API.
+func (a *Analyzer) getUsages(s *snapshot) error {
+	if len(s.nodes) == 0 {
+		return nil
+	}
+
+	body, err := a.getMetrics("/pods")
+	if err != nil {
+		return errors.Wrap(err, "error getting pod metrics")
+	}
+	var pods podMetricsList
+	err = json.Unmarshal(body, &pods)
+	if err != nil {
+		return errors.Wrap(err, "error decoding pod metrics")
+	}
+
+	body, err = a.getMetrics("/nodes")
+	if err != nil {
+		return errors.Wrap(err, "error getting node metrics")
+	}
+	var nodes nodeMetricsList
+	err = json.Unmarshal(body, &nodes)
+	if err != nil {
+		return errors.Wrap(err, "error decoding node metrics")
+	}
+
+	s.podUsage = make(map[string]usage)
+	for _, pm := range pods.Items {
+		var u usage
+		for _, c := range pm.Containers {
+			u.cpu += c.Usage.Cpu().MilliValue()
+			u.memory += c.Usage.Memory().Value()
+		}
+		s.podUsage[fmt.Sprintf("%v/%v", pm.Metadata.Namespace, pm.Metadata.Name)] = u
+	}
+
+	s.nodeUsage = make(map[string]usage)
+	for _, nm := range nodes.Items {
+		if _, ok := s.nodes[nm.Metadata.Name]; !ok {
+			continue
+		}
+		s.nodeUsage[nm.Metadata.Name] = usage{
+			cpu:    nm.Usage.Cpu().MilliValue(),
+			memory: nm.Usage.Memory().Value(),
+		}
+	}
+	return nil
+}
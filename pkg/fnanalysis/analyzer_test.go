@@ -0,0 +1,170 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fnanalysis
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	testPodMetrics = `{"items": [
+		{"metadata": {"name": "hello-abc", "namespace": "fission-function"}, "containers": [{"name": "hello", "usage": {"cpu": "100m", "memory": "60Mi"}}]},
+		{"metadata": {"name": "hog", "namespace": "batch"}, "containers": [{"name": "hog", "usage": {"cpu": "1500m", "memory": "1Gi"}}]}
+	]}`
+	testNodeMetrics = `{"items": [
+		{"metadata": {"name": "node-1"}, "usage": {"cpu": "1800m", "memory": "3Gi"}}
+	]}`
+)
+
+func resources(cpu, memory string) apiv1.ResourceList {
+	return apiv1.ResourceList{
+		apiv1.ResourceCPU:    resource.MustParse(cpu),
+		apiv1.ResourceMemory: resource.MustParse(memory),
+	}
+}
+
+func makeTestAnalyzer(withMetrics bool) *Analyzer {
+	fnPod := &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hello-abc",
+			Namespace: "fission-function",
+			Labels: map[string]string{
+				fv1.FUNCTION_NAME:      "hello",
+				fv1.FUNCTION_NAMESPACE: metav1.NamespaceDefault,
+			},
+		},
+		Spec: apiv1.PodSpec{
+			NodeName: "node-1",
+			Containers: []apiv1.Container{{
+				Name:      "hello",
+				Resources: apiv1.ResourceRequirements{Requests: resources("100m", "64Mi"), Limits: resources("200m", "128Mi")},
+			}},
+		},
+		Status: apiv1.PodStatus{
+			Phase: apiv1.PodRunning,
+			ContainerStatuses: []apiv1.ContainerStatus{{
+				Name:                 "hello",
+				RestartCount:         2,
+				LastTerminationState: apiv1.ContainerState{Terminated: &apiv1.ContainerStateTerminated{Reason: "OOMKilled"}},
+			}},
+		},
+	}
+	hog := &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "hog", Namespace: "batch"},
+		Spec: apiv1.PodSpec{
+			NodeName: "node-1",
+			Containers: []apiv1.Container{{
+				Name:      "hog",
+				Resources: apiv1.ResourceRequirements{Requests: resources("100m", "128Mi")},
+			}},
+		},
+		Status: apiv1.PodStatus{Phase: apiv1.PodRunning},
+	}
+	node := &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: apiv1.NodeStatus{
+			Allocatable: resources("2", "4Gi"),
+		},
+	}
+
+	a := MakeAnalyzer(zap.NewNop(), fake.NewSimpleClientset(fnPod, hog, node))
+	a.getMetrics = func(path string) ([]byte, error) {
+		if !withMetrics {
+			return nil, errors.New("the server could not find the requested resource")
+		}
+		if path == "/pods" {
+			return []byte(testPodMetrics), nil
+		}
+		return []byte(testNodeMetrics), nil
+	}
+	return a
+}
+
+func hasFinding(r *Report, kind FindingKind) bool {
+	return len(r.findingsOf(kind)) > 0
+}
+
+func recommendation(r *Report, kind RecommendationKind) string {
+	for _, rec := range r.Recommendations {
+		if rec.Kind == kind {
+			return rec.Message
+		}
+	}
+	return ""
+}
+
+func TestAnalyzeNoisyNeighbor(t *testing.T) {
+	a := makeTestAnalyzer(true)
+	r, err := a.Analyze(&metav1.ObjectMeta{Name: "hello", Namespace: metav1.NamespaceDefault})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.MetricsAvailable {
+		t.Fatal("expected metrics to be used")
+	}
+	if len(r.Pods) != 1 || r.Pods[0].OOMKills != 1 || r.Pods[0].MemoryLimit != 128*1024*1024 {
+		t.Fatalf("unexpected pod reports %+v", r.Pods)
+	}
+	if len(r.Nodes) != 1 || r.Nodes[0].Pods != 2 || r.Nodes[0].CPUUsage != 1800 {
+		t.Fatalf("unexpected node reports %+v", r.Nodes)
+	}
+	for _, kind := range []FindingKind{FindingOOMKilled, FindingNodeSaturated, FindingNoisyNeighbor} {
+		if !hasFinding(r, kind) {
+			t.Errorf("expected a %v finding in %+v", kind, r.Findings)
+		}
+	}
+
+	if msg := recommendation(r, RecommendAntiAffinity); !strings.Contains(msg, "batch/hog") {
+		t.Errorf("expected anti-affinity with the noisy neighbor, got %q", msg)
+	}
+	if len(recommendation(r, RecommendDedicatedPool)) == 0 {
+		t.Error("expected a dedicated pool to be recommended")
+	}
+	// the neighbor is to blame, not the limits of the function
+	if msg := recommendation(r, RecommendResourceBump); len(msg) > 0 {
+		t.Errorf("expected no resource bump, got %q", msg)
+	}
+}
+
+func TestAnalyzeWithoutMetrics(t *testing.T) {
+	a := makeTestAnalyzer(false)
+	r, err := a.Analyze(&metav1.ObjectMeta{Name: "hello", Namespace: metav1.NamespaceDefault})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.MetricsAvailable {
+		t.Fatal("expected metrics to be unavailable")
+	}
+	if hasFinding(r, FindingNoisyNeighbor) || hasFinding(r, FindingNodeSaturated) {
+		t.Fatalf("expected no usage based findings without metrics, got %+v", r.Findings)
+	}
+	if msg := recommendation(r, RecommendResourceBump); !strings.Contains(msg, "--maxmemory 192") {
+		t.Errorf("expected the memory limit to be raised by half, got %q", msg)
+	}
+}
@@ -0,0 +1,520 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fnanalysis
+
+import (
+	"fmt"
+	"sort"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+const (
+	FindingOOMKilled         FindingKind = "OOMKilled"
+	FindingEvicted           FindingKind = "Evicted"
+	FindingCPUThrottled      FindingKind = "CPUThrottled"
+	FindingMemoryNearLimit   FindingKind = "MemoryNearLimit"
+	FindingNodePressure      FindingKind = "NodePressure"
+	FindingNodeSaturated     FindingKind = "NodeSaturated"
+	FindingNodeOvercommitted FindingKind = "NodeOvercommitted"
+	FindingNoisyNeighbor     FindingKind = "NoisyNeighbor"
+)
+
+const (
+	RecommendResourceBump  RecommendationKind = "resource-bump"
+	RecommendDedicatedPool RecommendationKind = "dedicated-pool"
+	RecommendAntiAffinity  RecommendationKind = "anti-affinity"
+)
+
+const (
+	// nearLimitRatio is how close to its limit the usage of a pod is
+	// considered throttled (CPU) or about to be OOM killed (memory).
+	nearLimitRatio = 0.9
+
+	// saturatedRatio is the share of the allocatable resources of a node
+	// in use over which the node is considered saturated.
+	saturatedRatio = 0.85
+
+	// overcommitRatio is how much the limits of the pods of a node may
+	// exceed its allocatable resources before it's considered overcommitted.
+	overcommitRatio = 1.5
+
+	// noisyRequestRatio is how many times its requests a neighbor uses to
+	// be considered noisy, noisyNodeShare is the share of the node a
+	// neighbor without requests uses to be.
+	noisyRequestRatio = 2.0
+	noisyNodeShare    = 0.2
+
+	// maxNeighbors is how many of the busiest neighbors of each node are
+	// reported.
+	maxNeighbors = 5
+)
+
+type (
+	Severity           string
+	FindingKind        string
+	RecommendationKind string
+
+	// Report is the compute isolation report of a function: how its pods
+	// and the nodes they run on fare, what looks wrong and what to do
+	// about it.
+	Report struct {
+		Function  string      `json:"function"`
+		Namespace string      `json:"namespace"`
+		Generated metav1.Time `json:"generated"`
+
+		// MetricsAvailable is false if the metrics API (metrics-server)
+		// couldn't be reached, and the report only relies on pod states,
+		// events and resource requests.
+		MetricsAvailable bool `json:"metricsAvailable"`
+
+		Pods            []PodReport      `json:"pods,omitempty"`
+		Nodes           []NodeReport     `json:"nodes,omitempty"`
+		Findings        []Finding        `json:"findings,omitempty"`
+		Recommendations []Recommendation `json:"recommendations,omitempty"`
+	}
+
+	// PodReport is how a pod of the function fares. CPU is in millicores,
+	// memory in bytes, and usages are 0 without metrics.
+	PodReport struct {
+		Name        string `json:"name"`
+		Namespace   string `json:"namespace"`
+		Node        string `json:"node"`
+		Restarts    int32  `json:"restarts"`
+		OOMKills    int    `json:"oomKills"`
+		CPUUsage    int64  `json:"cpuUsage"`
+		CPULimit    int64  `json:"cpuLimit"`
+		MemoryUsage int64  `json:"memoryUsage"`
+		MemoryLimit int64  `json:"memoryLimit"`
+	}
+
+	// NodeReport is how loaded a node running pods of the function is.
+	NodeReport struct {
+		Name              string     `json:"name"`
+		Pods              int        `json:"pods"`
+		CPUAllocatable    int64      `json:"cpuAllocatable"`
+		CPURequested      int64      `json:"cpuRequested"`
+		CPULimits         int64      `json:"cpuLimits"`
+		CPUUsage          int64      `json:"cpuUsage"`
+		MemoryAllocatable int64      `json:"memoryAllocatable"`
+		MemoryRequested   int64      `json:"memoryRequested"`
+		MemoryLimits      int64      `json:"memoryLimits"`
+		MemoryUsage       int64      `json:"memoryUsage"`
+		Pressure          []string   `json:"pressure,omitempty"`
+		Neighbors         []Neighbor `json:"neighbors,omitempty"`
+	}
+
+	// Neighbor is a busy pod of another workload on a node.
+	Neighbor struct {
+		Name          string `json:"name"`
+		Namespace     string `json:"namespace"`
+		CPUUsage      int64  `json:"cpuUsage"`
+		CPURequest    int64  `json:"cpuRequest"`
+		MemoryUsage   int64  `json:"memoryUsage"`
+		MemoryRequest int64  `json:"memoryRequest"`
+		Noisy         bool   `json:"noisy"`
+	}
+
+	// Finding is something that looks wrong with a pod of the function or
+	// a node it runs on.
+	Finding struct {
+		Severity Severity    `json:"severity"`
+		Kind     FindingKind `json:"kind"`
+		Pod      string      `json:"pod,omitempty"`
+		Node     string      `json:"node,omitempty"`
+		Message  string      `json:"message"`
+	}
+
+	// Recommendation is a change that addresses findings.
+	Recommendation struct {
+		Kind    RecommendationKind `json:"kind"`
+		Message string             `json:"message"`
+	}
+
+	// usage is the CPU (millicores) and memory (bytes) a pod or node uses.
+	usage struct {
+		cpu    int64
+		memory int64
+	}
+
+	// snapshot is the cluster state a report is made of.
+	snapshot struct {
+		// pods of the function
+		pods []apiv1.Pod
+		// nodes running them, and all pods on these nodes
+		nodes    map[string]*apiv1.Node
+		nodePods map[string][]apiv1.Pod
+		// events of the pods of the function and of their nodes
+		events []apiv1.Event
+		// usages by "namespace/name" of pods and by name of nodes, nil
+		// without metrics
+		podUsage  map[string]usage
+		nodeUsage map[string]usage
+	}
+)
+
+// analyze makes the report of a function out of a snapshot.
+func analyze(fn *metav1.ObjectMeta, s *snapshot) *Report {
+	r := &Report{
+		Function:         fn.Name,
+		Namespace:        fn.Namespace,
+		Generated:        metav1.Now(),
+		MetricsAvailable: s.podUsage != nil,
+	}
+
+	own := make(map[string]bool)
+	for _, pod := range s.pods {
+		own[podKey(&pod)] = true
+	}
+
+	// nodes with trouble that may come from neighbors
+	troubled := make(map[string]bool)
+	// the function itself asks for too little
+	underProvisioned := usage{}
+
+	for _, pod := range s.pods {
+		pr := podReport(&pod, s.podUsage)
+		r.Pods = append(r.Pods, pr)
+
+		if pr.OOMKills > 0 {
+			r.addFinding(SeverityCritical, FindingOOMKilled, pr.Name, pr.Node,
+				fmt.Sprintf("containers were OOM killed %v time(s), memory limit %v", pr.OOMKills, FormatMemory(pr.MemoryLimit)))
+			troubled[pr.Node] = true
+			underProvisioned.memory = maxInt64(underProvisioned.memory, pr.MemoryLimit)
+		}
+		if pr.CPULimit > 0 && float64(pr.CPUUsage) >= nearLimitRatio*float64(pr.CPULimit) {
+			r.addFinding(SeverityWarning, FindingCPUThrottled, pr.Name, pr.Node,
+				fmt.Sprintf("uses %v of its %v CPU limit and is likely throttled", FormatCPU(pr.CPUUsage), FormatCPU(pr.CPULimit)))
+			troubled[pr.Node] = true
+			underProvisioned.cpu = maxInt64(underProvisioned.cpu, pr.CPULimit)
+		}
+		if pr.MemoryLimit > 0 && float64(pr.MemoryUsage) >= nearLimitRatio*float64(pr.MemoryLimit) {
+			r.addFinding(SeverityWarning, FindingMemoryNearLimit, pr.Name, pr.Node,
+				fmt.Sprintf("uses %v of its %v memory limit", FormatMemory(pr.MemoryUsage), FormatMemory(pr.MemoryLimit)))
+			underProvisioned.memory = maxInt64(underProvisioned.memory, pr.MemoryLimit)
+		}
+	}
+
+	for _, e := range s.events {
+		switch {
+		case e.InvolvedObject.Kind == "Pod" && e.Reason == "Evicted":
+			r.addFinding(SeverityCritical, FindingEvicted, e.InvolvedObject.Name, "", e.Message)
+		case e.InvolvedObject.Kind == "Node" && (e.Reason == "OOMKilling" || e.Reason == "SystemOOM"):
+			r.addFinding(SeverityWarning, FindingNodePressure, "", e.InvolvedObject.Name,
+				fmt.Sprintf("the kernel killed processes running out of memory: %v", e.Message))
+			troubled[e.InvolvedObject.Name] = true
+		}
+	}
+
+	functionNodes := make(map[string]int)
+	for _, pod := range s.pods {
+		functionNodes[pod.Spec.NodeName]++
+	}
+
+	var noisy []Neighbor
+	for _, name := range sortedKeys(functionNodes) {
+		node, ok := s.nodes[name]
+		if !ok {
+			continue
+		}
+		nr := nodeReport(node, s.nodePods[name], s.nodeUsage, s.podUsage, own)
+
+		if len(nr.Pressure) > 0 {
+			r.addFinding(SeverityCritical, FindingNodePressure, "", name,
+				fmt.Sprintf("the node reports %v", joinWords(nr.Pressure)))
+			troubled[name] = true
+		}
+		if s.nodeUsage != nil && (saturated(nr.CPUUsage, nr.CPUAllocatable) || saturated(nr.MemoryUsage, nr.MemoryAllocatable)) {
+			r.addFinding(SeverityWarning, FindingNodeSaturated, "", name,
+				fmt.Sprintf("the node uses %v of %v CPU and %v of %v memory",
+					FormatCPU(nr.CPUUsage), FormatCPU(nr.CPUAllocatable), FormatMemory(nr.MemoryUsage), FormatMemory(nr.MemoryAllocatable)))
+			troubled[name] = true
+		}
+		if overcommitted(nr.CPULimits, nr.CPUAllocatable) || overcommitted(nr.MemoryLimits, nr.MemoryAllocatable) {
+			r.addFinding(SeverityInfo, FindingNodeOvercommitted, "", name,
+				fmt.Sprintf("the limits of its pods add up to %v CPU and %v memory, for %v CPU and %v memory allocatable",
+					FormatCPU(nr.CPULimits), FormatMemory(nr.MemoryLimits), FormatCPU(nr.CPUAllocatable), FormatMemory(nr.MemoryAllocatable)))
+		}
+
+		if troubled[name] {
+			for _, n := range nr.Neighbors {
+				if !n.Noisy {
+					continue
+				}
+				noisy = append(noisy, n)
+				r.addFinding(SeverityWarning, FindingNoisyNeighbor, "", name,
+					fmt.Sprintf("pod %v/%v uses %v CPU (requests %v) and %v memory (requests %v)",
+						n.Namespace, n.Name, FormatCPU(n.CPUUsage), FormatCPU(n.CPURequest),
+						FormatMemory(n.MemoryUsage), FormatMemory(n.MemoryRequest)))
+			}
+		}
+
+		r.Nodes = append(r.Nodes, nr)
+	}
+
+	r.recommend(fn, underProvisioned, noisy, troubled, functionNodes)
+	return r
+}
+
+func (r *Report) addFinding(severity Severity, kind FindingKind, pod, node, message string) {
+	r.Findings = append(r.Findings, Finding{
+		Severity: severity,
+		Kind:     kind,
+		Pod:      pod,
+		Node:     node,
+		Message:  message,
+	})
+}
+
+// recommend adds the recommendations addressing the findings of a report.
+func (r *Report) recommend(fn *metav1.ObjectMeta, underProvisioned usage, noisy []Neighbor,
+	troubled map[string]bool, functionNodes map[string]int) {
+	// limits that are hit with no one else to blame are too low
+	if len(noisy) == 0 {
+		if underProvisioned.memory > 0 {
+			r.Recommendations = append(r.Recommendations, Recommendation{
+				Kind: RecommendResourceBump,
+				Message: fmt.Sprintf("raise the memory limit of the function, e.g. fission fn update --name %v --maxmemory %v",
+					fn.Name, bumped(underProvisioned.memory)/(1024*1024)),
+			})
+		}
+		if underProvisioned.cpu > 0 {
+			r.Recommendations = append(r.Recommendations, Recommendation{
+				Kind: RecommendResourceBump,
+				Message: fmt.Sprintf("raise the CPU limit of the function, e.g. fission fn update --name %v --maxcpu %v",
+					fn.Name, bumped(underProvisioned.cpu)),
+			})
+		}
+	}
+
+	if len(noisy) > 0 {
+		var names []string
+		for _, n := range noisy {
+			names = append(names, fmt.Sprintf("%v/%v", n.Namespace, n.Name))
+		}
+		r.Recommendations = append(r.Recommendations, Recommendation{
+			Kind: RecommendAntiAffinity,
+			Message: fmt.Sprintf("keep the function away from %v with a podAntiAffinity on their labels in the podspec of its environment",
+				joinWords(names)),
+		})
+	}
+
+	if len(troubled) > 0 && (len(noisy) > 0 || len(r.findingsOf(FindingNodePressure, FindingNodeSaturated)) > 0) {
+		r.Recommendations = append(r.Recommendations, Recommendation{
+			Kind: RecommendDedicatedPool,
+			Message: "run the environment of the function on a dedicated node pool, with a nodeSelector and a " +
+				"toleration for a taint of the pool in the podspec of the environment",
+		})
+	}
+
+	// replicas piled up on a troubled node suffer together
+	for node, n := range functionNodes {
+		if n > 1 && troubled[node] && len(functionNodes) == 1 {
+			r.Recommendations = append(r.Recommendations, Recommendation{
+				Kind: RecommendAntiAffinity,
+				Message: fmt.Sprintf("all %v pods of the function run on node %v, spread them across nodes with a podAntiAffinity "+
+					"on the %v label in the podspec of the environment", n, node, fv1.FUNCTION_NAME),
+			})
+		}
+	}
+}
+
+func (r *Report) findingsOf(kinds ...FindingKind) []Finding {
+	var found []Finding
+	for _, f := range r.Findings {
+		for _, k := range kinds {
+			if f.Kind == k {
+				found = append(found, f)
+			}
+		}
+	}
+	return found
+}
+
+func podReport(pod *apiv1.Pod, podUsage map[string]usage) PodReport {
+	pr := PodReport{
+		Name:      pod.ObjectMeta.Name,
+		Namespace: pod.ObjectMeta.Namespace,
+		Node:      pod.Spec.NodeName,
+	}
+	for _, c := range pod.Spec.Containers {
+		pr.CPULimit += c.Resources.Limits.Cpu().MilliValue()
+		pr.MemoryLimit += c.Resources.Limits.Memory().Value()
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		pr.Restarts += cs.RestartCount
+		if t := cs.LastTerminationState.Terminated; t != nil && t.Reason == "OOMKilled" {
+			pr.OOMKills++
+		}
+		if t := cs.State.Terminated; t != nil && t.Reason == "OOMKilled" {
+			pr.OOMKills++
+		}
+	}
+	if u, ok := podUsage[podKey(pod)]; ok {
+		pr.CPUUsage = u.cpu
+		pr.MemoryUsage = u.memory
+	}
+	return pr
+}
+
+func nodeReport(node *apiv1.Node, pods []apiv1.Pod, nodeUsage map[string]usage, podUsage map[string]usage,
+	own map[string]bool) NodeReport {
+	nr := NodeReport{
+		Name:              node.ObjectMeta.Name,
+		CPUAllocatable:    node.Status.Allocatable.Cpu().MilliValue(),
+		MemoryAllocatable: node.Status.Allocatable.Memory().Value(),
+	}
+	if u, ok := nodeUsage[nr.Name]; ok {
+		nr.CPUUsage = u.cpu
+		nr.MemoryUsage = u.memory
+	}
+	for _, cond := range node.Status.Conditions {
+		switch cond.Type {
+		case apiv1.NodeMemoryPressure, apiv1.NodeDiskPressure, apiv1.NodePIDPressure:
+			if cond.Status == apiv1.ConditionTrue {
+				nr.Pressure = append(nr.Pressure, string(cond.Type))
+			}
+		}
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase == apiv1.PodSucceeded || pod.Status.Phase == apiv1.PodFailed {
+			continue
+		}
+		nr.Pods++
+
+		var n Neighbor
+		var cpuLimit, memoryLimit resource.Quantity
+		for _, c := range pod.Spec.Containers {
+			n.CPURequest += c.Resources.Requests.Cpu().MilliValue()
+			n.MemoryRequest += c.Resources.Requests.Memory().Value()
+			cpuLimit.Add(*c.Resources.Limits.Cpu())
+			memoryLimit.Add(*c.Resources.Limits.Memory())
+		}
+		nr.CPURequested += n.CPURequest
+		nr.MemoryRequested += n.MemoryRequest
+		nr.CPULimits += cpuLimit.MilliValue()
+		nr.MemoryLimits += memoryLimit.Value()
+
+		u, ok := podUsage[podKey(pod)]
+		if !ok || own[podKey(pod)] {
+			continue
+		}
+		n.Name = pod.ObjectMeta.Name
+		n.Namespace = pod.ObjectMeta.Namespace
+		n.CPUUsage = u.cpu
+		n.MemoryUsage = u.memory
+		n.Noisy = noisy(n.CPUUsage, n.CPURequest, nr.CPUAllocatable) || noisy(n.MemoryUsage, n.MemoryRequest, nr.MemoryAllocatable)
+		nr.Neighbors = append(nr.Neighbors, n)
+	}
+
+	// the busiest neighbors, by their share of the node
+	share := func(n Neighbor) float64 {
+		return ratio(n.CPUUsage, nr.CPUAllocatable) + ratio(n.MemoryUsage, nr.MemoryAllocatable)
+	}
+	sort.SliceStable(nr.Neighbors, func(i, j int) bool {
+		return share(nr.Neighbors[i]) > share(nr.Neighbors[j])
+	})
+	if len(nr.Neighbors) > maxNeighbors {
+		nr.Neighbors = nr.Neighbors[:maxNeighbors]
+	}
+	return nr
+}
+
+// noisy returns true if a pod uses much more than it requests, or a large
+// share of the node if it requests nothing.
+func noisy(used, requested, allocatable int64) bool {
+	if requested > 0 {
+		return float64(used) > noisyRequestRatio*float64(requested) && ratio(used, allocatable) > noisyNodeShare/2
+	}
+	return ratio(used, allocatable) > noisyNodeShare
+}
+
+func saturated(used, allocatable int64) bool {
+	return ratio(used, allocatable) >= saturatedRatio
+}
+
+func overcommitted(limits, allocatable int64) bool {
+	return ratio(limits, allocatable) > overcommitRatio
+}
+
+func ratio(a, b int64) float64 {
+	if b <= 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+// bumped returns a limit raised by half, rounded up.
+func bumped(limit int64) int64 {
+	return (limit*3 + 1) / 2
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func podKey(pod *apiv1.Pod) string {
+	return pod.ObjectMeta.Namespace + "/" + pod.ObjectMeta.Name
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinWords(words []string) string {
+	switch len(words) {
+	case 0:
+		return ""
+	case 1:
+		return words[0]
+	}
+	s := words[0]
+	for _, w := range words[1 : len(words)-1] {
+		s += ", " + w
+	}
+	return s + " and " + words[len(words)-1]
+}
+
+// FormatCPU formats millicores the way the report does.
+func FormatCPU(milli int64) string {
+	return fmt.Sprintf("%vm", milli)
+}
+
+// FormatMemory formats bytes the way the report does.
+func FormatMemory(bytes int64) string {
+	return fmt.Sprintf("%vMi", bytes/(1024*1024))
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fnstatus
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+)
+
+// DefaultInterval is how often recorded conditions are written.
+const DefaultInterval = 10 * time.Second
+
+// maxMessageLength bounds the messages of conditions, like the build log
+// of a failed build, as they are only hints of what went wrong.
+const maxMessageLength = 1024
+
+type (
+	// Recorder records the conditions of functions and writes them to the
+	// status of the functions periodically, so that frequent observations
+	// like invocations turn into one status update per interval at most.
+	Recorder struct {
+		logger        *zap.Logger
+		fissionClient *crd.FissionClient
+		interval      time.Duration
+
+		lock    sync.Mutex
+		pending map[types.NamespacedName]*pendingStatus
+	}
+
+	// pendingStatus is the latest condition of each type recorded for a
+	// function since the last write.
+	pendingStatus struct {
+		uid        types.UID
+		conditions map[fv1.FunctionConditionType]fv1.FunctionCondition
+	}
+)
+
+// MakeRecorder returns a recorder writing conditions every interval.
+func MakeRecorder(logger *zap.Logger, fissionClient *crd.FissionClient, interval time.Duration) *Recorder {
+	return &Recorder{
+		logger:        logger.Named("function_status"),
+		fissionClient: fissionClient,
+		interval:      interval,
+		pending:       make(map[types.NamespacedName]*pendingStatus),
+	}
+}
+
+// Record records a condition of a function observed now. A nil recorder
+// records nothing.
+func (r *Recorder) Record(fn *metav1.ObjectMeta, condType fv1.FunctionConditionType,
+	status apiv1.ConditionStatus, reason, message string) {
+	if r == nil {
+		return
+	}
+	if len(message) > maxMessageLength {
+		// the end of logs and errors is usually the most telling part
+		message = "..." + message[len(message)-maxMessageLength:]
+	}
+	cond := fv1.FunctionCondition{
+		Type:    condType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+		// the status is stored with a precision of a second
+		LastUpdateTime: metav1.Now().Rfc3339Copy(),
+	}
+
+	key := types.NamespacedName{Namespace: fn.Namespace, Name: fn.Name}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	p, ok := r.pending[key]
+	if !ok || p.uid != fn.UID {
+		p = &pendingStatus{
+			uid:        fn.UID,
+			conditions: make(map[fv1.FunctionConditionType]fv1.FunctionCondition),
+		}
+		r.pending[key] = p
+	}
+	p.conditions[condType] = cond
+}
+
+// Run writes the recorded conditions every interval until ctx is done.
+func (r *Recorder) Run(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			r.Flush()
+			return
+		case <-ticker.C:
+			r.Flush()
+		}
+	}
+}
+
+// Flush writes the conditions recorded since the last write. Conditions
+// of functions whose status fails to update are kept for the next write,
+// unless newer ones were recorded meanwhile.
+func (r *Recorder) Flush() {
+	r.lock.Lock()
+	pending := r.pending
+	r.pending = make(map[types.NamespacedName]*pendingStatus)
+	r.lock.Unlock()
+
+	for key, p := range pending {
+		err := r.write(key, p)
+		if err == nil {
+			continue
+		}
+		r.logger.Error("error updating function status",
+			zap.String("function", key.Name),
+			zap.String("namespace", key.Namespace),
+			zap.Error(err))
+		r.requeue(key, p)
+	}
+}
+
+func (r *Recorder) write(key types.NamespacedName, p *pendingStatus) error {
+	fn, err := r.fissionClient.CoreV1().Functions(key.Namespace).Get(key.Name, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	// the function was recreated since the conditions were observed
+	if len(p.uid) > 0 && fn.ObjectMeta.UID != p.uid {
+		return nil
+	}
+
+	changed := false
+	for _, cond := range p.conditions {
+		if fn.Status.SetCondition(cond) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	_, err = r.fissionClient.CoreV1().Functions(key.Namespace).UpdateStatus(fn)
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (r *Recorder) requeue(key types.NamespacedName, p *pendingStatus) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	current, ok := r.pending[key]
+	if !ok {
+		r.pending[key] = p
+		return
+	}
+	if current.uid != p.uid {
+		return
+	}
+	for condType, cond := range p.conditions {
+		if _, ok := current.conditions[condType]; !ok {
+			current.conditions[condType] = cond
+		}
+	}
+}
+
+// RecordPackage records whether the package of a function is built.
+func (r *Recorder) RecordPackage(fn *metav1.ObjectMeta, pkg *fv1.Package) {
+	status, reason := packageBuilt(pkg.Status.BuildStatus)
+	message := ""
+	if pkg.Status.BuildStatus == fv1.BuildStatusFailed {
+		message = pkg.Status.BuildLog
+	}
+	r.Record(fn, fv1.FunctionPackageBuilt, status, reason, message)
+}
+
+// packageBuilt returns the status and reason of the PackageBuilt condition
+// of a package build status.
+func packageBuilt(buildStatus fv1.BuildStatus) (apiv1.ConditionStatus, string) {
+	switch buildStatus {
+	case fv1.BuildStatusSucceeded:
+		return apiv1.ConditionTrue, "BuildSucceeded"
+	case fv1.BuildStatusNone:
+		return apiv1.ConditionTrue, "NoBuildNeeded"
+	case fv1.BuildStatusPending:
+		return apiv1.ConditionFalse, "BuildPending"
+	case fv1.BuildStatusRunning:
+		return apiv1.ConditionFalse, "BuildRunning"
+	case fv1.BuildStatusFailed:
+		return apiv1.ConditionFalse, "BuildFailed"
+	}
+	return apiv1.ConditionUnknown, "UnknownBuildStatus"
+}
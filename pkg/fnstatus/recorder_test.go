@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fnstatus
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/generated/clientset/versioned/fake"
+)
+
+func countStatusUpdates(cs *fake.Clientset) int {
+	n := 0
+	for _, action := range cs.Actions() {
+		if action.GetVerb() == "update" && action.GetSubresource() == "status" {
+			n++
+		}
+	}
+	return n
+}
+
+func TestRecorder(t *testing.T) {
+	fn := &fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hello",
+			Namespace: metav1.NamespaceDefault,
+			UID:       "3ac1b9ad-1ba4-4e2b-a1b8-7b3d5ff2e6c4",
+		},
+	}
+	cs := fake.NewSimpleClientset(fn)
+	r := MakeRecorder(zap.NewNop(), &crd.FissionClient{Interface: cs}, time.Second)
+
+	r.Record(&fn.ObjectMeta, fv1.FunctionPodsReady, apiv1.ConditionFalse, "ServiceFailed", "no pods")
+	r.Record(&fn.ObjectMeta, fv1.FunctionPodsReady, apiv1.ConditionTrue, "ServiceReady", "")
+	r.Flush()
+
+	got, err := cs.CoreV1().Functions(fn.ObjectMeta.Namespace).Get(fn.ObjectMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cond := got.Status.GetCondition(fv1.FunctionPodsReady)
+	if cond == nil || cond.Status != apiv1.ConditionTrue || cond.Reason != "ServiceReady" {
+		t.Fatalf("expected the latest PodsReady condition, got %v", got.Status)
+	}
+	if updates := countStatusUpdates(cs); updates != 1 {
+		t.Fatalf("expected conditions to be written at once, got %v updates", updates)
+	}
+
+	// the same observation again doesn't update the function
+	r.Record(&fn.ObjectMeta, fv1.FunctionPodsReady, apiv1.ConditionTrue, "ServiceReady", "")
+	r.Flush()
+	if updates := countStatusUpdates(cs); updates != 1 {
+		t.Fatalf("expected no update for an unchanged condition, got %v updates", updates)
+	}
+
+	// conditions of a recreated function are dropped
+	recreated := fn.ObjectMeta
+	recreated.UID = "0f1e2d3c-4b5a-6978-8796-a5b4c3d2e1f0"
+	r.Record(&recreated, fv1.FunctionLastError, apiv1.ConditionTrue, "ServiceFailed", "boom")
+	r.Flush()
+	if updates := countStatusUpdates(cs); updates != 1 {
+		t.Fatalf("expected no update for another function, got %v updates", updates)
+	}
+
+	pkg := &fv1.Package{
+		Status: fv1.PackageStatus{
+			BuildStatus: fv1.BuildStatusFailed,
+			BuildLog:    strings.Repeat("x", 2*maxMessageLength) + "error: build failed",
+		},
+	}
+	r.RecordPackage(&fn.ObjectMeta, pkg)
+	r.Flush()
+	got, err = cs.CoreV1().Functions(fn.ObjectMeta.Namespace).Get(fn.ObjectMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cond = got.Status.GetCondition(fv1.FunctionPackageBuilt)
+	if cond == nil || cond.Status != apiv1.ConditionFalse || cond.Reason != "BuildFailed" {
+		t.Fatalf("expected a failed PackageBuilt condition, got %v", got.Status)
+	}
+	if len(cond.Message) > maxMessageLength+3 || !strings.HasSuffix(cond.Message, "error: build failed") {
+		t.Fatalf("expected the end of the build log, got %q", cond.Message)
+	}
+
+	var nilRecorder *Recorder
+	nilRecorder.Record(&fn.ObjectMeta, fv1.FunctionPodsReady, apiv1.ConditionTrue, "ServiceReady", "")
+}
+
+func TestSetCondition(t *testing.T) {
+	status := &fv1.FunctionStatus{}
+	t0 := metav1.NewTime(time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC))
+	t1 := metav1.NewTime(t0.Add(time.Minute))
+	t2 := metav1.NewTime(t0.Add(2 * time.Minute))
+
+	if !status.SetCondition(fv1.FunctionCondition{Type: fv1.FunctionPodsReady, Status: apiv1.ConditionFalse, LastUpdateTime: t0}) {
+		t.Fatal("expected a new condition to change the status")
+	}
+	if status.SetCondition(fv1.FunctionCondition{Type: fv1.FunctionPodsReady, Status: apiv1.ConditionFalse, LastUpdateTime: t1}) {
+		t.Fatal("expected the same condition not to change the status")
+	}
+	if !status.SetCondition(fv1.FunctionCondition{Type: fv1.FunctionPodsReady, Status: apiv1.ConditionTrue, LastUpdateTime: t2}) {
+		t.Fatal("expected a transition to change the status")
+	}
+	if cond := status.GetCondition(fv1.FunctionPodsReady); !cond.LastTransitionTime.Equal(&t2) {
+		t.Fatalf("expected transition time %v, got %v", t2, cond.LastTransitionTime)
+	}
+	if status.SetCondition(fv1.FunctionCondition{Type: fv1.FunctionPodsReady, Status: apiv1.ConditionFalse, LastUpdateTime: t1}) {
+		t.Fatal("expected an older observation to be ignored")
+	}
+}
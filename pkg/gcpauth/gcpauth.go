@@ -0,0 +1,279 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gcpauth authorizes requests to Google Cloud APIs with OAuth2
+// access tokens, without the weight of the client libraries. Tokens are
+// static ones, ones of service account keys, or ones of the service
+// account of the pod from the GCE metadata server, which is the Google
+// service account bound to it with Workload Identity on GKE.
+package gcpauth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// MetadataURL is the address of the GCE metadata server.
+	MetadataURL = "http://metadata.google.internal/computeMetadata/v1"
+
+	defaultTokenURI = "https://oauth2.googleapis.com/token"
+)
+
+type (
+	// Credentials are what access tokens are got with: a static token, or
+	// a service account key exchanged for tokens of a scope, or else the
+	// service account of the pod.
+	Credentials struct {
+		AccessToken string
+		Key         *ServiceAccountKey
+		Scope       string
+		// MetadataURL is the address of the metadata server, MetadataURL
+		// if empty.
+		MetadataURL string
+	}
+
+	// TokenRoundTripper authorizes requests with an access token of its
+	// credentials, cached until a minute before it expires.
+	TokenRoundTripper struct {
+		transport   http.RoundTripper
+		credentials Credentials
+
+		lock   sync.Mutex
+		token  string
+		expiry time.Time
+	}
+
+	// ServiceAccountKey is the JSON key of a service account.
+	ServiceAccountKey struct {
+		ProjectID    string `json:"project_id"`
+		ClientEmail  string `json:"client_email"`
+		PrivateKeyID string `json:"private_key_id"`
+		PrivateKey   string `json:"private_key"`
+		TokenURI     string `json:"token_uri"`
+
+		privateKey *rsa.PrivateKey
+	}
+
+	accessToken struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+)
+
+// MakeTokenRoundTripper returns a round tripper authorizing the requests
+// it sends with transport with access tokens of credentials.
+func MakeTokenRoundTripper(transport http.RoundTripper, credentials Credentials) *TokenRoundTripper {
+	if len(credentials.MetadataURL) == 0 {
+		credentials.MetadataURL = MetadataURL
+	}
+	return &TokenRoundTripper{
+		transport:   transport,
+		credentials: credentials,
+	}
+}
+
+func (rt *TokenRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := rt.getToken(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// RoundTrip must not modify the original request
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	r.Header.Set("Authorization", "Bearer "+token)
+
+	return rt.transport.RoundTrip(r)
+}
+
+// getToken returns the token to authorize req with, getting a new one with
+// the context of req if the cached one is about to expire.
+func (rt *TokenRoundTripper) getToken(req *http.Request) (string, error) {
+	if len(rt.credentials.AccessToken) > 0 {
+		return rt.credentials.AccessToken, nil
+	}
+
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	// refresh the token a minute before it expires
+	if len(rt.token) > 0 && time.Now().Add(time.Minute).Before(rt.expiry) {
+		return rt.token, nil
+	}
+
+	var tokenReq *http.Request
+	var err error
+	source := "GCE metadata server"
+	if rt.credentials.Key != nil {
+		source = "service account key"
+		tokenReq, err = rt.credentials.Key.tokenRequest(rt.credentials.Scope, time.Now())
+	} else {
+		tokenReq, err = metadataRequest(rt.credentials.MetadataURL, "/instance/service-accounts/default/token")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := rt.transport.RoundTrip(tokenReq.WithContext(req.Context()))
+	if err != nil {
+		return "", errors.Wrapf(err, "error getting access token from %v", source)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("error getting access token from %v, status code: %v", source, resp.StatusCode)
+	}
+
+	token := &accessToken{}
+	err = json.NewDecoder(resp.Body).Decode(token)
+	if err != nil {
+		return "", errors.Wrapf(err, "error decoding access token from %v", source)
+	}
+	if len(token.AccessToken) == 0 {
+		return "", errors.Errorf("%v returned no access token", source)
+	}
+
+	rt.token = token.AccessToken
+	rt.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return rt.token, nil
+}
+
+// ParseServiceAccountKey parses the JSON key of a service account.
+func ParseServiceAccountKey(data []byte) (*ServiceAccountKey, error) {
+	key := &ServiceAccountKey{}
+	err := json.Unmarshal(data, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding service account key")
+	}
+	if len(key.ClientEmail) == 0 || len(key.PrivateKey) == 0 {
+		return nil, errors.New("client_email and private_key are required in service account key")
+	}
+	if len(key.TokenURI) == 0 {
+		key.TokenURI = defaultTokenURI
+	}
+
+	block, _ := pem.Decode([]byte(key.PrivateKey))
+	if block == nil {
+		return nil, errors.New("error decoding private key of service account key")
+	}
+	var parsed interface{}
+	parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing private key of service account key")
+		}
+	}
+	var ok bool
+	key.privateKey, ok = parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key of service account key is not an RSA key")
+	}
+	return key, nil
+}
+
+// tokenRequest returns a request for an access token of scope in exchange
+// for a JWT signed with the key, as of now.
+func (key *ServiceAccountKey) tokenRequest(scope string, now time.Time) (*http.Request, error) {
+	encode := func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return base64.RawURLEncoding.EncodeToString(b), nil
+	}
+	header, err := encode(map[string]string{"alg": "RS256", "typ": "JWT", "kid": key.PrivateKeyID})
+	if err != nil {
+		return nil, err
+	}
+	claims, err := encode(map[string]interface{}{
+		"iss":   key.ClientEmail,
+		"scope": scope,
+		"aud":   key.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	unsigned := header + "." + claims
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key.privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return nil, errors.Wrap(err, "error signing token request")
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)},
+	}
+	req, err := http.NewRequest(http.MethodPost, key.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+// MetadataProject returns the project of the pod from the GCE metadata
+// server.
+func MetadataProject(transport http.RoundTripper) (string, error) {
+	req, err := metadataRequest(MetadataURL, "/project/project-id")
+	if err != nil {
+		return "", err
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return "", errors.Wrap(err, "error getting project from GCE metadata server")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("error getting project from GCE metadata server, status code: %v", resp.StatusCode)
+	}
+	project, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "error reading project from GCE metadata server")
+	}
+	return strings.TrimSpace(string(project)), nil
+}
+
+func metadataRequest(metadataURL string, path string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, metadataURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	return req, nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcpauth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceAccountKeyToken(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	keyJSON, err := json.Marshal(map[string]string{
+		"project_id":   "test-project",
+		"client_email": "mqtrigger@test-project.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+	})
+	require.NoError(t, err)
+
+	key, err := ParseServiceAccountKey(keyJSON)
+	require.NoError(t, err)
+	require.Equal(t, "test-project", key.ProjectID)
+
+	req, err := key.tokenRequest("https://www.googleapis.com/auth/pubsub", time.Unix(1614600000, 0))
+	require.NoError(t, err)
+	require.Equal(t, "https://oauth2.googleapis.com/token", req.URL.String())
+	require.NoError(t, req.ParseForm())
+	parts := strings.Split(req.PostForm.Get("assertion"), ".")
+	require.Len(t, parts, 3)
+
+	claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"iss": "mqtrigger@test-project.iam.gserviceaccount.com",
+		"scope": "https://www.googleapis.com/auth/pubsub",
+		"aud": "https://oauth2.googleapis.com/token",
+		"iat": 1614600000,
+		"exp": 1614603600
+	}`, string(claims))
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	require.NoError(t, rsa.VerifyPKCS1v15(&privateKey.PublicKey, crypto.SHA256, digest[:], signature))
+
+	_, err = ParseServiceAccountKey([]byte(`{"client_email": "mqtrigger@test-project.iam.gserviceaccount.com"}`))
+	require.Error(t, err)
+}
+
+func readAll(t *testing.T, resp *http.Response) string {
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestMetadataToken(t *testing.T) {
+	tokenRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/metadata/instance/service-accounts/default/token" {
+			require.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+			tokenRequests++
+			fmt.Fprintf(w, `{"access_token": "token-%v", "expires_in": 3600}`, tokenRequests)
+			return
+		}
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	rt := MakeTokenRoundTripper(http.DefaultTransport, Credentials{MetadataURL: server.URL + "/metadata"})
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, server.URL+"/api", nil)
+		req.RequestURI = ""
+		resp, err := rt.RoundTrip(req)
+		require.NoError(t, err)
+		require.Equal(t, "Bearer token-1", readAll(t, resp))
+		require.Empty(t, req.Header.Get("Authorization"), "expected original request not to be modified")
+	}
+	require.Equal(t, 1, tokenRequests, "expected token to be cached until it expires")
+
+	static := MakeTokenRoundTripper(http.DefaultTransport, Credentials{AccessToken: "static", MetadataURL: server.URL + "/metadata"})
+	req := httptest.NewRequest(http.MethodGet, server.URL+"/api", nil)
+	req.RequestURI = ""
+	resp, err := static.RoundTrip(req)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer static", readAll(t, resp))
+	require.Equal(t, 1, tokenRequests)
+}
@@ -20,20 +20,27 @@ package v1
 
 import (
 	v1 "github.com/fission/fission/pkg/apis/core/v1"
-	"github.com/fission/fission/pkg/apis/genclient/clientset/versioned/scheme"
+	"github.com/fission/fission/pkg/generated/clientset/versioned/scheme"
 	rest "k8s.io/client-go/rest"
 )
 
 type CoreV1Interface interface {
 	RESTClient() rest.Interface
+	BucketTriggersGetter
 	CanaryConfigsGetter
+	DatabaseTriggersGetter
 	EnvironmentsGetter
+	FissionConfigsGetter
+	FissionSourcesGetter
 	FunctionsGetter
+	FunctionUsagesGetter
 	HTTPTriggersGetter
+	InvocationHistoriesGetter
 	KubernetesWatchTriggersGetter
 	MessageQueueTriggersGetter
 	PackagesGetter
 	TimeTriggersGetter
+	WorkflowsGetter
 }
 
 // CoreV1Client is used to interact with features provided by the fission.io group.
@@ -41,22 +48,46 @@ type CoreV1Client struct {
 	restClient rest.Interface
 }
 
+func (c *CoreV1Client) BucketTriggers(namespace string) BucketTriggerInterface {
+	return newBucketTriggers(c, namespace)
+}
+
 func (c *CoreV1Client) CanaryConfigs(namespace string) CanaryConfigInterface {
 	return newCanaryConfigs(c, namespace)
 }
 
+func (c *CoreV1Client) DatabaseTriggers(namespace string) DatabaseTriggerInterface {
+	return newDatabaseTriggers(c, namespace)
+}
+
 func (c *CoreV1Client) Environments(namespace string) EnvironmentInterface {
 	return newEnvironments(c, namespace)
 }
 
+func (c *CoreV1Client) FissionConfigs(namespace string) FissionConfigInterface {
+	return newFissionConfigs(c, namespace)
+}
+
+func (c *CoreV1Client) FissionSources(namespace string) FissionSourceInterface {
+	return newFissionSources(c, namespace)
+}
+
 func (c *CoreV1Client) Functions(namespace string) FunctionInterface {
 	return newFunctions(c, namespace)
 }
 
+func (c *CoreV1Client) FunctionUsages(namespace string) FunctionUsageInterface {
+	return newFunctionUsages(c, namespace)
+}
+
 func (c *CoreV1Client) HTTPTriggers(namespace string) HTTPTriggerInterface {
 	return newHTTPTriggers(c, namespace)
 }
 
+func (c *CoreV1Client) InvocationHistories(namespace string) InvocationHistoryInterface {
+	return newInvocationHistories(c, namespace)
+}
+
 func (c *CoreV1Client) KubernetesWatchTriggers(namespace string) KubernetesWatchTriggerInterface {
 	return newKubernetesWatchTriggers(c, namespace)
 }
@@ -73,6 +104,10 @@ func (c *CoreV1Client) TimeTriggers(namespace string) TimeTriggerInterface {
 	return newTimeTriggers(c, namespace)
 }
 
+func (c *CoreV1Client) Workflows(namespace string) WorkflowInterface {
+	return newWorkflows(c, namespace)
+}
+
 // NewForConfig creates a new CoreV1Client for the given config.
 func NewForConfig(c *rest.Config) (*CoreV1Client, error) {
 	config := *c
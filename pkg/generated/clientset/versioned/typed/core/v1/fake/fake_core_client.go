@@ -19,7 +19,7 @@ limitations under the License.
 package fake
 
 import (
-	v1 "github.com/fission/fission/pkg/apis/genclient/clientset/versioned/typed/core/v1"
+	v1 "github.com/fission/fission/pkg/generated/clientset/versioned/typed/core/v1"
 	rest "k8s.io/client-go/rest"
 	testing "k8s.io/client-go/testing"
 )
@@ -28,22 +28,46 @@ type FakeCoreV1 struct {
 	*testing.Fake
 }
 
+func (c *FakeCoreV1) BucketTriggers(namespace string) v1.BucketTriggerInterface {
+	return &FakeBucketTriggers{c, namespace}
+}
+
 func (c *FakeCoreV1) CanaryConfigs(namespace string) v1.CanaryConfigInterface {
 	return &FakeCanaryConfigs{c, namespace}
 }
 
+func (c *FakeCoreV1) DatabaseTriggers(namespace string) v1.DatabaseTriggerInterface {
+	return &FakeDatabaseTriggers{c, namespace}
+}
+
 func (c *FakeCoreV1) Environments(namespace string) v1.EnvironmentInterface {
 	return &FakeEnvironments{c, namespace}
 }
 
+func (c *FakeCoreV1) FissionConfigs(namespace string) v1.FissionConfigInterface {
+	return &FakeFissionConfigs{c, namespace}
+}
+
+func (c *FakeCoreV1) FissionSources(namespace string) v1.FissionSourceInterface {
+	return &FakeFissionSources{c, namespace}
+}
+
 func (c *FakeCoreV1) Functions(namespace string) v1.FunctionInterface {
 	return &FakeFunctions{c, namespace}
 }
 
+func (c *FakeCoreV1) FunctionUsages(namespace string) v1.FunctionUsageInterface {
+	return &FakeFunctionUsages{c, namespace}
+}
+
 func (c *FakeCoreV1) HTTPTriggers(namespace string) v1.HTTPTriggerInterface {
 	return &FakeHTTPTriggers{c, namespace}
 }
 
+func (c *FakeCoreV1) InvocationHistories(namespace string) v1.InvocationHistoryInterface {
+	return &FakeInvocationHistories{c, namespace}
+}
+
 func (c *FakeCoreV1) KubernetesWatchTriggers(namespace string) v1.KubernetesWatchTriggerInterface {
 	return &FakeKubernetesWatchTriggers{c, namespace}
 }
@@ -60,6 +84,10 @@ func (c *FakeCoreV1) TimeTriggers(namespace string) v1.TimeTriggerInterface {
 	return &FakeTimeTriggers{c, namespace}
 }
 
+func (c *FakeCoreV1) Workflows(namespace string) v1.WorkflowInterface {
+	return &FakeWorkflows{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeCoreV1) RESTClient() rest.Interface {
@@ -22,7 +22,7 @@ import (
 	"time"
 
 	v1 "github.com/fission/fission/pkg/apis/core/v1"
-	scheme "github.com/fission/fission/pkg/apis/genclient/clientset/versioned/scheme"
+	scheme "github.com/fission/fission/pkg/generated/clientset/versioned/scheme"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	types "k8s.io/apimachinery/pkg/types"
 	watch "k8s.io/apimachinery/pkg/watch"
@@ -39,6 +39,7 @@ type FunctionsGetter interface {
 type FunctionInterface interface {
 	Create(*v1.Function) (*v1.Function, error)
 	Update(*v1.Function) (*v1.Function, error)
+	UpdateStatus(*v1.Function) (*v1.Function, error)
 	Delete(name string, options *metav1.DeleteOptions) error
 	DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error
 	Get(name string, options metav1.GetOptions) (*v1.Function, error)
@@ -132,6 +133,22 @@ func (c *functions) Update(_function *v1.Function) (result *v1.Function, err err
 	return
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *functions) UpdateStatus(_function *v1.Function) (result *v1.Function, err error) {
+	result = &v1.Function{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("functions").
+		Name(_function.Name).
+		SubResource("status").
+		Body(_function).
+		Do().
+		Into(result)
+	return
+}
+
 // Delete takes name of the _function and deletes it. Returns an error if one occurs.
 func (c *functions) Delete(name string, options *metav1.DeleteOptions) error {
 	return c.client.Delete().
@@ -18,14 +18,26 @@ limitations under the License.
 
 package v1
 
+type BucketTriggerExpansion interface{}
+
 type CanaryConfigExpansion interface{}
 
+type DatabaseTriggerExpansion interface{}
+
 type EnvironmentExpansion interface{}
 
+type FissionConfigExpansion interface{}
+
+type FissionSourceExpansion interface{}
+
 type FunctionExpansion interface{}
 
+type FunctionUsageExpansion interface{}
+
 type HTTPTriggerExpansion interface{}
 
+type InvocationHistoryExpansion interface{}
+
 type KubernetesWatchTriggerExpansion interface{}
 
 type MessageQueueTriggerExpansion interface{}
@@ -33,3 +45,5 @@ type MessageQueueTriggerExpansion interface{}
 type PackageExpansion interface{}
 
 type TimeTriggerExpansion interface{}
+
+type WorkflowExpansion interface{}
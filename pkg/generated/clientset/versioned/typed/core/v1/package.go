@@ -22,7 +22,7 @@ import (
 	"time"
 
 	v1 "github.com/fission/fission/pkg/apis/core/v1"
-	scheme "github.com/fission/fission/pkg/apis/genclient/clientset/versioned/scheme"
+	scheme "github.com/fission/fission/pkg/generated/clientset/versioned/scheme"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	types "k8s.io/apimachinery/pkg/types"
 	watch "k8s.io/apimachinery/pkg/watch"
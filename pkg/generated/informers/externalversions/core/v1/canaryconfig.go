@@ -22,9 +22,9 @@ import (
 	time "time"
 
 	corev1 "github.com/fission/fission/pkg/apis/core/v1"
-	versioned "github.com/fission/fission/pkg/apis/genclient/clientset/versioned"
-	internalinterfaces "github.com/fission/fission/pkg/apis/genclient/informers/externalversions/internalinterfaces"
-	v1 "github.com/fission/fission/pkg/apis/genclient/listers/core/v1"
+	versioned "github.com/fission/fission/pkg/generated/clientset/versioned"
+	internalinterfaces "github.com/fission/fission/pkg/generated/informers/externalversions/internalinterfaces"
+	v1 "github.com/fission/fission/pkg/generated/listers/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	watch "k8s.io/apimachinery/pkg/watch"
@@ -19,19 +19,31 @@ limitations under the License.
 package v1
 
 import (
-	internalinterfaces "github.com/fission/fission/pkg/apis/genclient/informers/externalversions/internalinterfaces"
+	internalinterfaces "github.com/fission/fission/pkg/generated/informers/externalversions/internalinterfaces"
 )
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// BucketTriggers returns a BucketTriggerInformer.
+	BucketTriggers() BucketTriggerInformer
 	// CanaryConfigs returns a CanaryConfigInformer.
 	CanaryConfigs() CanaryConfigInformer
+	// DatabaseTriggers returns a DatabaseTriggerInformer.
+	DatabaseTriggers() DatabaseTriggerInformer
 	// Environments returns a EnvironmentInformer.
 	Environments() EnvironmentInformer
+	// FissionConfigs returns a FissionConfigInformer.
+	FissionConfigs() FissionConfigInformer
+	// FissionSources returns a FissionSourceInformer.
+	FissionSources() FissionSourceInformer
 	// Functions returns a FunctionInformer.
 	Functions() FunctionInformer
+	// FunctionUsages returns a FunctionUsageInformer.
+	FunctionUsages() FunctionUsageInformer
 	// HTTPTriggers returns a HTTPTriggerInformer.
 	HTTPTriggers() HTTPTriggerInformer
+	// InvocationHistories returns a InvocationHistoryInformer.
+	InvocationHistories() InvocationHistoryInformer
 	// KubernetesWatchTriggers returns a KubernetesWatchTriggerInformer.
 	KubernetesWatchTriggers() KubernetesWatchTriggerInformer
 	// MessageQueueTriggers returns a MessageQueueTriggerInformer.
@@ -40,6 +52,8 @@ type Interface interface {
 	Packages() PackageInformer
 	// TimeTriggers returns a TimeTriggerInformer.
 	TimeTriggers() TimeTriggerInformer
+	// Workflows returns a WorkflowInformer.
+	Workflows() WorkflowInformer
 }
 
 type version struct {
@@ -53,26 +67,56 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// BucketTriggers returns a BucketTriggerInformer.
+func (v *version) BucketTriggers() BucketTriggerInformer {
+	return &_bucketTriggerInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // CanaryConfigs returns a CanaryConfigInformer.
 func (v *version) CanaryConfigs() CanaryConfigInformer {
 	return &_canaryConfigInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// DatabaseTriggers returns a DatabaseTriggerInformer.
+func (v *version) DatabaseTriggers() DatabaseTriggerInformer {
+	return &_databaseTriggerInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // Environments returns a EnvironmentInformer.
 func (v *version) Environments() EnvironmentInformer {
 	return &_environmentInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// FissionConfigs returns a FissionConfigInformer.
+func (v *version) FissionConfigs() FissionConfigInformer {
+	return &_fissionConfigInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// FissionSources returns a FissionSourceInformer.
+func (v *version) FissionSources() FissionSourceInformer {
+	return &_fissionSourceInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // Functions returns a FunctionInformer.
 func (v *version) Functions() FunctionInformer {
 	return &_functionInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// FunctionUsages returns a FunctionUsageInformer.
+func (v *version) FunctionUsages() FunctionUsageInformer {
+	return &_functionUsageInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // HTTPTriggers returns a HTTPTriggerInformer.
 func (v *version) HTTPTriggers() HTTPTriggerInformer {
 	return &_hTTPTriggerInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// InvocationHistories returns a InvocationHistoryInformer.
+func (v *version) InvocationHistories() InvocationHistoryInformer {
+	return &_invocationHistoryInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // KubernetesWatchTriggers returns a KubernetesWatchTriggerInformer.
 func (v *version) KubernetesWatchTriggers() KubernetesWatchTriggerInformer {
 	return &_kubernetesWatchTriggerInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -92,3 +136,8 @@ func (v *version) Packages() PackageInformer {
 func (v *version) TimeTriggers() TimeTriggerInformer {
 	return &_timeTriggerInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// Workflows returns a WorkflowInformer.
+func (v *version) Workflows() WorkflowInformer {
+	return &_workflowInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
@@ -53,14 +53,26 @@ func (f *genericInformer) Lister() cache.GenericLister {
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
 	// Group=fission.io, Version=v1
+	case v1.SchemeGroupVersion.WithResource("buckettriggers"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Core().V1().BucketTriggers().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("canaryconfigs"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Core().V1().CanaryConfigs().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("databasetriggers"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Core().V1().DatabaseTriggers().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("environments"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Core().V1().Environments().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("fissionconfigs"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Core().V1().FissionConfigs().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("fissionsources"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Core().V1().FissionSources().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("functions"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Core().V1().Functions().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("functionusages"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Core().V1().FunctionUsages().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("httptriggers"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Core().V1().HTTPTriggers().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("invocationhistories"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Core().V1().InvocationHistories().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("kuberneteswatchtriggers"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Core().V1().KubernetesWatchTriggers().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("messagequeuetriggers"):
@@ -69,6 +81,8 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Core().V1().Packages().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("timetriggers"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Core().V1().TimeTriggers().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("workflows"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Core().V1().Workflows().Informer()}, nil
 
 	}
 
@@ -18,6 +18,14 @@ limitations under the License.
 
 package v1
 
+// BucketTriggerListerExpansion allows custom methods to be added to
+// BucketTriggerLister.
+type BucketTriggerListerExpansion interface{}
+
+// BucketTriggerNamespaceListerExpansion allows custom methods to be added to
+// BucketTriggerNamespaceLister.
+type BucketTriggerNamespaceListerExpansion interface{}
+
 // CanaryConfigListerExpansion allows custom methods to be added to
 // CanaryConfigLister.
 type CanaryConfigListerExpansion interface{}
@@ -26,6 +34,14 @@ type CanaryConfigListerExpansion interface{}
 // CanaryConfigNamespaceLister.
 type CanaryConfigNamespaceListerExpansion interface{}
 
+// DatabaseTriggerListerExpansion allows custom methods to be added to
+// DatabaseTriggerLister.
+type DatabaseTriggerListerExpansion interface{}
+
+// DatabaseTriggerNamespaceListerExpansion allows custom methods to be added to
+// DatabaseTriggerNamespaceLister.
+type DatabaseTriggerNamespaceListerExpansion interface{}
+
 // EnvironmentListerExpansion allows custom methods to be added to
 // EnvironmentLister.
 type EnvironmentListerExpansion interface{}
@@ -34,6 +50,22 @@ type EnvironmentListerExpansion interface{}
 // EnvironmentNamespaceLister.
 type EnvironmentNamespaceListerExpansion interface{}
 
+// FissionConfigListerExpansion allows custom methods to be added to
+// FissionConfigLister.
+type FissionConfigListerExpansion interface{}
+
+// FissionConfigNamespaceListerExpansion allows custom methods to be added to
+// FissionConfigNamespaceLister.
+type FissionConfigNamespaceListerExpansion interface{}
+
+// FissionSourceListerExpansion allows custom methods to be added to
+// FissionSourceLister.
+type FissionSourceListerExpansion interface{}
+
+// FissionSourceNamespaceListerExpansion allows custom methods to be added to
+// FissionSourceNamespaceLister.
+type FissionSourceNamespaceListerExpansion interface{}
+
 // FunctionListerExpansion allows custom methods to be added to
 // FunctionLister.
 type FunctionListerExpansion interface{}
@@ -42,6 +74,14 @@ type FunctionListerExpansion interface{}
 // FunctionNamespaceLister.
 type FunctionNamespaceListerExpansion interface{}
 
+// FunctionUsageListerExpansion allows custom methods to be added to
+// FunctionUsageLister.
+type FunctionUsageListerExpansion interface{}
+
+// FunctionUsageNamespaceListerExpansion allows custom methods to be added to
+// FunctionUsageNamespaceLister.
+type FunctionUsageNamespaceListerExpansion interface{}
+
 // HTTPTriggerListerExpansion allows custom methods to be added to
 // HTTPTriggerLister.
 type HTTPTriggerListerExpansion interface{}
@@ -50,6 +90,14 @@ type HTTPTriggerListerExpansion interface{}
 // HTTPTriggerNamespaceLister.
 type HTTPTriggerNamespaceListerExpansion interface{}
 
+// InvocationHistoryListerExpansion allows custom methods to be added to
+// InvocationHistoryLister.
+type InvocationHistoryListerExpansion interface{}
+
+// InvocationHistoryNamespaceListerExpansion allows custom methods to be added to
+// InvocationHistoryNamespaceLister.
+type InvocationHistoryNamespaceListerExpansion interface{}
+
 // KubernetesWatchTriggerListerExpansion allows custom methods to be added to
 // KubernetesWatchTriggerLister.
 type KubernetesWatchTriggerListerExpansion interface{}
@@ -81,3 +129,11 @@ type TimeTriggerListerExpansion interface{}
 // TimeTriggerNamespaceListerExpansion allows custom methods to be added to
 // TimeTriggerNamespaceLister.
 type TimeTriggerNamespaceListerExpansion interface{}
+
+// WorkflowListerExpansion allows custom methods to be added to
+// WorkflowLister.
+type WorkflowListerExpansion interface{}
+
+// WorkflowNamespaceListerExpansion allows custom methods to be added to
+// WorkflowNamespaceLister.
+type WorkflowNamespaceListerExpansion interface{}
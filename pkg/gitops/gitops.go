@@ -0,0 +1,319 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gitops applies the Fission specs of the Git repositories of
+// FissionSources. Each source is checked out into a local cache every
+// interval, and whenever its branch moves to a new commit, or the source
+// changes, the specs are applied as spec apply does, uploading archives
+// through the controller. The outcome is reported in the status of the
+// FissionSource, and failed syncs are retried at the next interval.
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/controller/client"
+	"github.com/fission/fission/pkg/controller/client/rest"
+	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/fetcher"
+	"github.com/fission/fission/pkg/fission-cli/cmd/spec"
+	"github.com/fission/fission/pkg/utils"
+)
+
+const (
+	// DefaultInterval is how often the branch of a source is checked for
+	// changes if the source doesn't say.
+	DefaultInterval = time.Minute
+
+	// DefaultPath is the spec directory in the repository if the source
+	// doesn't say.
+	DefaultPath = "specs"
+
+	// pollInterval is how often sources are listed to find the ones due.
+	pollInterval = 10 * time.Second
+
+	// syncTimeout bounds the git commands of a sync.
+	syncTimeout = 5 * time.Minute
+)
+
+type (
+	// GitOps syncs the FissionSources of all namespaces.
+	GitOps struct {
+		logger           *zap.Logger
+		fissionClient    *crd.FissionClient
+		kubeClient       kubernetes.Interface
+		controllerClient client.Interface
+
+		// cacheDir holds a checkout of each source, by namespace and name.
+		cacheDir string
+
+		// checked is when each source was last checked, by namespace and
+		// name.
+		checked map[string]time.Time
+	}
+)
+
+// Start syncs FissionSources in the background, applying their specs
+// through the controller at controllerUrl.
+func Start(logger *zap.Logger, controllerUrl string) error {
+	fissionClient, kubeClient, _, _, err := crd.MakeFissionClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to get fission or kubernetes client")
+	}
+
+	err = fissionClient.WaitForCRDs()
+	if err != nil {
+		return errors.Wrap(err, "error waiting for CRDs")
+	}
+
+	cacheDir, err := ioutil.TempDir("", "gitops")
+	if err != nil {
+		return errors.Wrap(err, "error creating directory for repositories")
+	}
+
+	g := &GitOps{
+		logger:           logger.Named("gitops"),
+		fissionClient:    fissionClient,
+		kubeClient:       kubeClient,
+		controllerClient: client.MakeClientset(rest.NewRESTClient(controllerUrl)),
+		cacheDir:         cacheDir,
+		checked:          make(map[string]time.Time),
+	}
+	go g.run()
+	return nil
+}
+
+func (g *GitOps) run() {
+	for {
+		sources, err := g.fissionClient.CoreV1().FissionSources(metav1.NamespaceAll).List(metav1.ListOptions{})
+		if err != nil {
+			if utils.IsNetworkError(err) {
+				g.logger.Info("encountered a network error - will retry", zap.Error(err))
+			} else {
+				g.logger.Error("failed to get fission source list", zap.Error(err))
+			}
+			time.Sleep(pollInterval)
+			continue
+		}
+
+		now := time.Now()
+		seen := make(map[string]bool)
+		for i := range sources.Items {
+			source := &sources.Items[i]
+			key := sourceKey(source)
+			seen[key] = true
+			if !due(source, g.checked[key], now) {
+				continue
+			}
+			g.checked[key] = now
+			g.sync(source)
+		}
+
+		// drop the checkouts of deleted sources
+		for key := range g.checked {
+			if !seen[key] {
+				delete(g.checked, key)
+				err := os.RemoveAll(filepath.Join(g.cacheDir, key))
+				if err != nil {
+					g.logger.Error("error removing checkout of deleted fission source", zap.Error(err), zap.String("source", key))
+				}
+			}
+		}
+
+		time.Sleep(pollInterval)
+	}
+}
+
+// sync applies the specs of a source if its branch moved to a new commit,
+// the source changed, or the last sync failed.
+func (g *GitOps) sync(source *fv1.FissionSource) {
+	logger := g.logger.With(zap.String("source", sourceKey(source)))
+
+	err := source.Validate()
+	if err != nil {
+		g.setStatus(source, fv1.FissionSourceFailed, source.Status.Revision, fmt.Sprintf("invalid fission source: %v", err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+	defer cancel()
+
+	dir := filepath.Join(g.cacheDir, sourceKey(source))
+	revision, err := g.checkout(ctx, source, dir)
+	if err != nil {
+		logger.Error("error checking out repository", zap.Error(err))
+		g.setStatus(source, fv1.FissionSourceFailed, source.Status.Revision, fmt.Sprintf("error checking out repository: %v", err))
+		return
+	}
+
+	if revision == source.Status.Revision &&
+		source.Status.SyncStatus == fv1.FissionSourceSynced &&
+		source.Status.ObservedGeneration == source.Generation {
+		return
+	}
+
+	specPath := source.Spec.Path
+	if len(specPath) == 0 {
+		specPath = DefaultPath
+	}
+	logger.Info("applying specs", zap.String("revision", revision), zap.String("path", specPath))
+	applyStatus, err := spec.ApplySpecs(g.controllerClient, filepath.Join(dir, filepath.FromSlash(specPath)), source.Spec.Prune)
+	if err != nil {
+		logger.Error("error applying specs", zap.Error(err), zap.String("revision", revision))
+		g.setStatus(source, fv1.FissionSourceFailed, source.Status.Revision, fmt.Sprintf("error applying revision %v: %v", revision, err))
+		return
+	}
+	g.setStatus(source, fv1.FissionSourceSynced, revision, applySummary(applyStatus))
+}
+
+// checkout fetches the branch of a source into dir, reusing the checkout
+// of earlier syncs, and returns the commit checked out.
+func (g *GitOps) checkout(ctx context.Context, source *fv1.FissionSource, dir string) (string, error) {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if len(source.Spec.AuthSecret) > 0 {
+		secret, err := g.kubeClient.CoreV1().Secrets(source.Namespace).Get(source.Spec.AuthSecret, metav1.GetOptions{})
+		if err != nil {
+			return "", errors.Wrapf(err, "error getting auth secret %q of repository", source.Spec.AuthSecret)
+		}
+		// credentials are kept out of the checkout
+		authDir, err := ioutil.TempDir("", "git-auth")
+		if err != nil {
+			return "", errors.Wrap(err, "error creating directory for repository credentials")
+		}
+		defer os.RemoveAll(authDir)
+		authEnv, err := fetcher.GitAuthEnv(g.logger, secret.Data, authDir)
+		if err != nil {
+			return "", errors.Wrapf(err, "error using auth secret %q of repository", source.Spec.AuthSecret)
+		}
+		env = append(env, authEnv...)
+	}
+	return fetch(ctx, env, source.Spec.URL, source.Spec.Branch, dir)
+}
+
+// fetch checks out the latest commit of branch of the repository at url
+// into dir, the default branch if branch is empty, and returns the commit.
+func fetch(ctx context.Context, env []string, url string, branch string, dir string) (string, error) {
+	git := func(args ...string) (string, error) {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = dir
+		cmd.Env = env
+		var out bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		err := cmd.Run()
+		if err != nil {
+			return "", errors.Wrapf(err, "git %v: %v", args[0], strings.TrimSpace(out.String()))
+		}
+		return strings.TrimSpace(out.String()), nil
+	}
+
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	if os.IsNotExist(err) {
+		err = os.MkdirAll(dir, 0750)
+		if err != nil {
+			return "", errors.Wrap(err, "error creating directory to clone repository into")
+		}
+		_, err = git("init", "--quiet")
+		if err != nil {
+			return "", err
+		}
+		_, err = git("remote", "add", "origin", url)
+	} else if err == nil {
+		_, err = git("remote", "set-url", "origin", url)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	ref := branch
+	if len(ref) == 0 {
+		ref = "HEAD"
+	}
+	_, err = git("fetch", "--quiet", "--depth", "1", "origin", ref)
+	if err != nil {
+		return "", err
+	}
+	_, err = git("checkout", "--quiet", "--force", "FETCH_HEAD")
+	if err != nil {
+		return "", err
+	}
+	// leave nothing of earlier checkouts behind for the specs to pick up
+	_, err = git("clean", "--quiet", "-d", "--force", "-x")
+	if err != nil {
+		return "", err
+	}
+	return git("rev-parse", "HEAD")
+}
+
+func (g *GitOps) setStatus(source *fv1.FissionSource, syncStatus fv1.FissionSourceSyncStatus, revision string, message string) {
+	source = source.DeepCopy()
+	source.Status = fv1.FissionSourceStatus{
+		ObservedGeneration: source.Generation,
+		SyncStatus:         syncStatus,
+		Revision:           revision,
+		Message:            message,
+		LastSyncTime:       metav1.Now(),
+	}
+	_, err := g.fissionClient.CoreV1().FissionSources(source.Namespace).UpdateStatus(source)
+	if err != nil {
+		g.logger.Error("error updating status of fission source", zap.Error(err), zap.String("source", sourceKey(source)))
+	}
+}
+
+// due returns whether a source last checked at checked is to be checked
+// at now: once its interval passed, or right away if it changed.
+func due(source *fv1.FissionSource, checked time.Time, now time.Time) bool {
+	if source.Status.ObservedGeneration != source.Generation {
+		return true
+	}
+	interval := DefaultInterval
+	if len(source.Spec.Interval) > 0 {
+		if d, err := time.ParseDuration(source.Spec.Interval); err == nil && d > 0 {
+			interval = d
+		}
+	}
+	return now.Sub(checked) >= interval
+}
+
+// applySummary describes what a sync applied, e.g. "2 created, 1 updated,
+// 0 deleted".
+func applySummary(applyStatus map[string]spec.ResourceApplyStatus) string {
+	var created, updated, deleted int
+	for _, ras := range applyStatus {
+		created += len(ras.Created)
+		updated += len(ras.Updated)
+		deleted += len(ras.Deleted)
+	}
+	return fmt.Sprintf("%v created, %v updated, %v deleted", created, updated, deleted)
+}
+
+func sourceKey(source *fv1.FissionSource) string {
+	return filepath.Join(source.Namespace, source.Name)
+}
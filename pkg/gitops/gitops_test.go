@@ -0,0 +1,147 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitops
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/fission-cli/cmd/spec"
+)
+
+func TestFetch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir, err := ioutil.TempDir("", "gitops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	repo := filepath.Join(dir, "repo")
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(contents string) string {
+		if err := ioutil.WriteFile(filepath.Join(repo, "specs", "env.yaml"), []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+		git("add", "-A")
+		git("commit", "--quiet", "-m", contents)
+		return git("rev-parse", "HEAD")
+	}
+	if err := os.MkdirAll(filepath.Join(repo, "specs"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	git("init", "--quiet")
+	git("checkout", "--quiet", "-b", "main")
+	first := commit("v1")
+
+	checkout := filepath.Join(dir, "checkout")
+	revision, err := fetch(context.Background(), os.Environ(), "file://"+repo, "main", checkout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revision != first {
+		t.Errorf("expected revision %v, got %v", first, revision)
+	}
+
+	// later fetches reuse the checkout, leaving nothing else behind
+	if err := ioutil.WriteFile(filepath.Join(checkout, "specs", "stale.yaml"), []byte("stale"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	second := commit("v2")
+	revision, err = fetch(context.Background(), os.Environ(), "file://"+repo, "", checkout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revision != second {
+		t.Errorf("expected revision %v, got %v", second, revision)
+	}
+	contents, err := ioutil.ReadFile(filepath.Join(checkout, "specs", "env.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != "v2" {
+		t.Errorf("expected contents v2, got %v", string(contents))
+	}
+	if _, err := os.Stat(filepath.Join(checkout, "specs", "stale.yaml")); !os.IsNotExist(err) {
+		t.Errorf("expected stale file to be removed, got %v", err)
+	}
+
+	_, err = fetch(context.Background(), os.Environ(), "file://"+repo, "missing", checkout)
+	if err == nil {
+		t.Error("expected error fetching missing branch")
+	}
+}
+
+func TestDue(t *testing.T) {
+	now := time.Now()
+	source := &fv1.FissionSource{
+		ObjectMeta: metav1.ObjectMeta{Generation: 2},
+		Spec:       fv1.FissionSourceSpec{Interval: "5m"},
+		Status:     fv1.FissionSourceStatus{ObservedGeneration: 2},
+	}
+
+	tests := []struct {
+		name     string
+		checked  time.Time
+		interval string
+		observed int64
+		due      bool
+	}{
+		{"never checked", time.Time{}, "5m", 2, true},
+		{"within interval", now.Add(-time.Minute), "5m", 2, false},
+		{"interval passed", now.Add(-5 * time.Minute), "5m", 2, true},
+		{"default interval", now.Add(-time.Minute), "", 2, true},
+		{"source changed", now, "5m", 1, true},
+	}
+	for _, test := range tests {
+		source.Spec.Interval = test.interval
+		source.Status.ObservedGeneration = test.observed
+		if got := due(source, test.checked, now); got != test.due {
+			t.Errorf("%v: expected due %v, got %v", test.name, test.due, got)
+		}
+	}
+}
+
+func TestApplySummary(t *testing.T) {
+	m := &metav1.ObjectMeta{Name: "hello"}
+	summary := applySummary(map[string]spec.ResourceApplyStatus{
+		"function":    {Created: []*metav1.ObjectMeta{m}, Updated: []*metav1.ObjectMeta{m}},
+		"HTTPTrigger": {Created: []*metav1.ObjectMeta{m}, Deleted: []*metav1.ObjectMeta{m}},
+	})
+	if summary != "2 created, 1 updated, 1 deleted" {
+		t.Errorf("unexpected summary %q", summary)
+	}
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package info
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/utils"
+)
+
+// Feature is an optional part of the controller API or of the specs of
+// Fission objects. Servers list the features they support in ServerInfo,
+// so that clients can tell what an older server doesn't support.
+type Feature string
+
+// Features of the controller API.
+const (
+	FeatureListPaging     Feature = "list-paging"
+	FeatureListWatch      Feature = "list-watch"
+	FeatureStatus         Feature = "event-source-status"
+	FeatureDrift          Feature = "drift"
+	FeatureTombstones     Feature = "tombstones"
+	FeatureAudit          Feature = "audit"
+	FeatureAPIAuth        Feature = "api-auth"
+	FeatureDeltaUpload    Feature = "delta-upload"
+	FeatureFunctionStatus Feature = "function-status"
+	FeatureBuildLogs      Feature = "package-build-logs"
+	FeatureFissionConfig  Feature = "fission-config"
+	FeatureFnAnalysis     Feature = "function-analysis"
+	FeaturePkgRevisions   Feature = "package-revisions"
+	FeatureInvocations    Feature = "function-invocations"
+	FeatureFnUsage        Feature = "function-usage"
+	FeatureLogQuery       Feature = "function-log-query"
+)
+
+// Features of the specs of objects. Servers lacking them keep the fields
+// of objects that use them, but ignore them.
+const (
+	FeatureHTTPTriggerHost              Feature = "httptrigger-host"
+	FeatureHTTPTriggerAuthentication    Feature = "httptrigger-authentication"
+	FeatureHTTPTriggerSessionAffinity   Feature = "httptrigger-session-affinity"
+	FeatureHTTPTriggerRequestValidation Feature = "httptrigger-request-validation"
+	FeatureHTTPTriggerFeatureFlags      Feature = "httptrigger-feature-flags"
+	FeatureHTTPTriggerPolicies          Feature = "httptrigger-policies"
+	FeatureHTTPTriggerPriority          Feature = "httptrigger-priority"
+	FeatureHTTPTriggerExperiment        Feature = "httptrigger-experiment"
+	FeatureEnvironmentImageBuild        Feature = "environment-image-build"
+	FeaturePackageBuildSteps            Feature = "package-build-steps"
+	FeatureEnvironmentRollout           Feature = "environment-rollout"
+	FeatureEnvironmentTriggerDefaults   Feature = "environment-httptrigger-defaults"
+	FeatureFunctionInvocationQuota      Feature = "function-invocation-quota"
+	FeaturePackageGitArchive            Feature = "package-git-archive"
+	FeatureHTTPTriggerLongPoll          Feature = "httptrigger-long-poll"
+	FeaturePackageObjectStoreArchive    Feature = "package-object-store-archive"
+	FeatureFunctionContainer            Feature = "function-container"
+	FeatureCloudEvents                  Feature = "cloudevents"
+)
+
+// SupportedFeatures are the features of this build.
+var SupportedFeatures = []Feature{
+	FeatureListPaging,
+	FeatureListWatch,
+	FeatureStatus,
+	FeatureDrift,
+	FeatureTombstones,
+	FeatureAudit,
+	FeatureAPIAuth,
+	FeatureDeltaUpload,
+	FeatureFunctionStatus,
+	FeatureBuildLogs,
+	FeatureFissionConfig,
+	FeatureFnAnalysis,
+	FeaturePkgRevisions,
+	FeatureInvocations,
+	FeatureFnUsage,
+	FeatureLogQuery,
+	FeatureHTTPTriggerHost,
+	FeatureHTTPTriggerAuthentication,
+	FeatureHTTPTriggerSessionAffinity,
+	FeatureHTTPTriggerRequestValidation,
+	FeatureHTTPTriggerFeatureFlags,
+	FeatureHTTPTriggerPolicies,
+	FeatureHTTPTriggerPriority,
+	FeatureHTTPTriggerExperiment,
+	FeatureEnvironmentImageBuild,
+	FeaturePackageBuildSteps,
+	FeatureEnvironmentRollout,
+	FeatureEnvironmentTriggerDefaults,
+	FeatureFunctionInvocationQuota,
+	FeaturePackageGitArchive,
+	FeatureHTTPTriggerLongPoll,
+	FeaturePackageObjectStoreArchive,
+	FeatureFunctionContainer,
+	FeatureCloudEvents,
+}
+
+// Supports returns true if the server supports a feature. Servers older
+// than feature negotiation list no features, and support none of them.
+func (info *ServerInfo) Supports(feature Feature) bool {
+	for _, f := range info.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// HTTPTriggerFeatures returns the features the spec of an HTTP trigger
+// uses.
+func HTTPTriggerFeatures(spec *fv1.HTTPTriggerSpec) []Feature {
+	var features []Feature
+	if len(spec.Host) > 0 {
+		features = append(features, FeatureHTTPTriggerHost)
+	}
+	if spec.Authentication != nil {
+		features = append(features, FeatureHTTPTriggerAuthentication)
+	}
+	if spec.SessionAffinity != nil {
+		features = append(features, FeatureHTTPTriggerSessionAffinity)
+	}
+	if spec.RequestValidation != nil {
+		features = append(features, FeatureHTTPTriggerRequestValidation)
+	}
+	if spec.FeatureFlags != nil {
+		features = append(features, FeatureHTTPTriggerFeatureFlags)
+	}
+	if spec.Timeout > 0 || spec.Retry != nil || spec.CircuitBreaker != nil {
+		features = append(features, FeatureHTTPTriggerPolicies)
+	}
+	if len(spec.Priority) > 0 {
+		features = append(features, FeatureHTTPTriggerPriority)
+	}
+	if spec.Experiment != nil {
+		features = append(features, FeatureHTTPTriggerExperiment)
+	}
+	if spec.LongPoll != nil {
+		features = append(features, FeatureHTTPTriggerLongPoll)
+	}
+	if len(spec.CloudEvents) > 0 {
+		features = append(features, FeatureCloudEvents)
+	}
+	return features
+}
+
+// EnvironmentFeatures returns the features the spec of an environment uses.
+func EnvironmentFeatures(spec *fv1.EnvironmentSpec) []Feature {
+	var features []Feature
+	if spec.Builder.Output == fv1.BuildOutputImage {
+		features = append(features, FeatureEnvironmentImageBuild)
+	}
+	if spec.RolloutStrategy != nil {
+		features = append(features, FeatureEnvironmentRollout)
+	}
+	if spec.HTTPTriggerDefaults != nil {
+		features = append(features, FeatureEnvironmentTriggerDefaults)
+	}
+	return features
+}
+
+// PackageFeatures returns the features the spec of a package uses.
+func PackageFeatures(spec *fv1.PackageSpec) []Feature {
+	var features []Feature
+	if len(spec.BuildSteps) > 0 {
+		features = append(features, FeaturePackageBuildSteps)
+	}
+	if spec.Source.Type == fv1.ArchiveTypeGit || spec.Deployment.Type == fv1.ArchiveTypeGit {
+		features = append(features, FeaturePackageGitArchive)
+	}
+	if utils.IsObjectStoreURL(spec.Source.URL) || utils.IsObjectStoreURL(spec.Deployment.URL) {
+		features = append(features, FeaturePackageObjectStoreArchive)
+	}
+	return features
+}
+
+// FunctionFeatures returns the features the spec of a function uses.
+func FunctionFeatures(spec *fv1.FunctionSpec) []Feature {
+	var features []Feature
+	if len(spec.InvocationQuotas) > 0 {
+		features = append(features, FeatureFunctionInvocationQuota)
+	}
+	if spec.Container != nil {
+		features = append(features, FeatureFunctionContainer)
+	}
+	return features
+}
+
+// Compatibility is how compatible a client is with a server.
+type Compatibility struct {
+	ClientVersion string `json:"clientVersion"`
+	ServerVersion string `json:"serverVersion"`
+
+	// VersionSkew describes why the versions of the client and server
+	// are not supported together, and is empty if they are.
+	VersionSkew string `json:"versionSkew,omitempty"`
+
+	// Unsupported are the features of the client the server lacks.
+	Unsupported []Feature `json:"unsupported,omitempty"`
+}
+
+// maxMinorSkew is how many minor versions clients and servers may be apart.
+const maxMinorSkew = 1
+
+// CheckCompatibility returns how compatible a client of the given build
+// is with a server.
+func CheckCompatibility(client BuildMeta, server *ServerInfo) *Compatibility {
+	c := &Compatibility{
+		ClientVersion: client.Version,
+		ServerVersion: server.Build.Version,
+	}
+
+	clientMajor, clientMinor, clientOK := parseVersion(client.Version)
+	serverMajor, serverMinor, serverOK := parseVersion(server.Build.Version)
+	// development builds have no version to compare
+	if clientOK && serverOK {
+		if clientMajor != serverMajor {
+			c.VersionSkew = fmt.Sprintf("major versions differ: client %v, server %v", clientMajor, serverMajor)
+		} else if skew := clientMinor - serverMinor; skew > maxMinorSkew || -skew > maxMinorSkew {
+			c.VersionSkew = fmt.Sprintf("minor versions are more than %v apart: client %v.%v, server %v.%v",
+				maxMinorSkew, clientMajor, clientMinor, serverMajor, serverMinor)
+		}
+	}
+
+	for _, f := range SupportedFeatures {
+		if !server.Supports(f) {
+			c.Unsupported = append(c.Unsupported, f)
+		}
+	}
+	return c
+}
+
+// IsCompatible returns true if the client and server versions are
+// supported together and the server has all the features of the client.
+func (c *Compatibility) IsCompatible() bool {
+	return len(c.VersionSkew) == 0 && len(c.Unsupported) == 0
+}
+
+// parseVersion returns the major and minor numbers of a version like
+// "1.10.0" or "v1.10.0-rc1".
+func parseVersion(version string) (int, int, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err := strconv.Atoi(strings.SplitN(parts[1], "-", 2)[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package info
+
+import (
+	"encoding/json"
+	"testing"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	current := &ServerInfo{
+		Build:    BuildMeta{Version: "1.10.0"},
+		Features: SupportedFeatures,
+	}
+	// servers older than feature negotiation list no features
+	legacy := &ServerInfo{
+		Build: BuildMeta{Version: "1.9.0"},
+	}
+
+	tests := []struct {
+		name         string
+		client       string
+		server       *ServerInfo
+		skew         bool
+		unsupported  int
+		isCompatible bool
+	}{
+		{"same version", "1.10.0", current, false, 0, true},
+		{"one minor apart", "v1.11.0-rc1", current, false, 0, true},
+		{"two minors apart", "1.12.0", current, true, 0, false},
+		{"major versions differ", "2.0.0", current, true, 0, false},
+		{"development build", "", current, false, 0, true},
+		{"legacy server", "1.10.0", legacy, false, len(SupportedFeatures), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := CheckCompatibility(BuildMeta{Version: test.client}, test.server)
+			if (len(c.VersionSkew) > 0) != test.skew {
+				t.Errorf("expected version skew %v, got %q", test.skew, c.VersionSkew)
+			}
+			if len(c.Unsupported) != test.unsupported {
+				t.Errorf("expected %v unsupported features, got %v", test.unsupported, c.Unsupported)
+			}
+			if c.IsCompatible() != test.isCompatible {
+				t.Errorf("expected compatible to be %v", test.isCompatible)
+			}
+		})
+	}
+}
+
+func TestServerInfoFeatures(t *testing.T) {
+	// features survive the round trip through the controller API
+	data, err := json.Marshal(ApiInfo())
+	if err != nil {
+		t.Fatal(err)
+	}
+	si := &ServerInfo{}
+	if err = json.Unmarshal(data, si); err != nil {
+		t.Fatal(err)
+	}
+	if !si.Supports(FeatureBuildLogs) {
+		t.Fatalf("expected server to support %v, got %v", FeatureBuildLogs, si.Features)
+	}
+	if si.Supports(Feature("time-travel")) {
+		t.Fatal("expected server not to support unknown feature")
+	}
+}
+
+func TestHTTPTriggerFeatures(t *testing.T) {
+	spec := &fv1.HTTPTriggerSpec{
+		RelativeURL: "/hello",
+		Method:      "GET",
+	}
+	if features := HTTPTriggerFeatures(spec); len(features) != 0 {
+		t.Fatalf("expected plain trigger to use no features, got %v", features)
+	}
+
+	spec.Host = "*.example.com"
+	spec.Timeout = 30
+	features := HTTPTriggerFeatures(spec)
+	if len(features) != 2 || features[0] != FeatureHTTPTriggerHost || features[1] != FeatureHTTPTriggerPolicies {
+		t.Fatalf("expected host and policies features, got %v", features)
+	}
+}
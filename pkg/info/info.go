@@ -42,6 +42,8 @@ type (
 	ServerInfo struct {
 		Build      BuildMeta `json:"Build,omitempty"`
 		ServerTime Time      `json:"ServerTime,omitempty"`
+		// Features are the optional features the server supports.
+		Features []Feature `json:"Features,omitempty"`
 	}
 
 	// Versions is a container of versions of the client (and its plugins) and server (and its plugins).
@@ -77,6 +79,7 @@ func ApiInfo() ServerInfo {
 	return ServerInfo{
 		Build:      BuildInfo(),
 		ServerTime: TimeInfo(),
+		Features:   SupportedFeatures,
 	}
 }
 
@@ -0,0 +1,62 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package info
+
+import "time"
+
+type (
+	// EventSourceHealth is the health of a trigger, calculated from the metrics
+	// router reports for function calls made by the trigger. Metrics are left
+	// empty if there is no data for the trigger.
+	EventSourceHealth struct {
+		// Type is one of http, messagequeue, time and kubewatch
+		Type string `json:"type"`
+		Name string `json:"name"`
+
+		// Function is the function(s) the trigger invokes
+		Function string `json:"function"`
+
+		// Source describes where events come from, e.g. the route of a http
+		// trigger or the topic of a message queue trigger.
+		Source string `json:"source,omitempty"`
+
+		// FiringRate is the number of function calls per second
+		FiringRate *float64 `json:"firingRate,omitempty"`
+
+		// ErrorRate is the percentage of failed function calls
+		ErrorRate *float64 `json:"errorRate,omitempty"`
+
+		// Lag is the delay in seconds between the last event being
+		// produced and router receiving it
+		Lag *float64 `json:"lag,omitempty"`
+
+		LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	}
+
+	// NamespaceStatus is the health of all event sources in a namespace.
+	NamespaceStatus struct {
+		Namespace string `json:"namespace"`
+
+		// Window is the time window rates are calculated over
+		Window string `json:"window"`
+
+		// MetricsError is set if metrics of event sources are unavailable
+		MetricsError string `json:"metricsError,omitempty"`
+
+		EventSources []EventSourceHealth `json:"eventSources"`
+	}
+)
@@ -0,0 +1,507 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package jwt verifies JSON Web Tokens signed with keys of JSON Web Key Sets.
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// jwksRefreshInterval is how long a fetched key set is considered fresh.
+	jwksRefreshInterval = 10 * time.Minute
+
+	// jwksMinRefetchInterval limits how often a key set is refetched
+	// when a token refers to an unknown key id.
+	jwksMinRefetchInterval = 30 * time.Second
+
+	// clockSkew is the leeway allowed when checking "exp" and "nbf".
+	clockSkew = time.Minute
+
+	// discoveryRetryInterval limits how often the discovery of an OpenID
+	// Connect issuer is retried after it failed.
+	discoveryRetryInterval = 30 * time.Second
+)
+
+type (
+	// Verifier verifies JSON Web Tokens with key sets fetched from JWKS URLs.
+	// Key sets and discovered key set URLs are cached, so a Verifier should
+	// be shared.
+	Verifier struct {
+		logger     *zap.Logger
+		httpClient *http.Client
+
+		// lock guards the maps only, it's never held while fetching
+		lock        sync.Mutex
+		keySets     map[string]*jwkSet
+		discoveries map[string]*discovery
+		fetches     map[string]*fetch
+	}
+
+	jwkSet struct {
+		keys      map[string]crypto.PublicKey
+		fetchedAt time.Time
+	}
+
+	// discovery is the key set URL of an OpenID Connect issuer, or the
+	// error its discovery failed with.
+	discovery struct {
+		jwksURL      string
+		err          error
+		discoveredAt time.Time
+	}
+
+	// fetch is a request in flight, shared by all callers needing it.
+	fetch struct {
+		done   chan struct{}
+		result interface{}
+		err    error
+	}
+
+	jsonWebKey struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+
+	jwtHeader struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+)
+
+func MakeVerifier(logger *zap.Logger) *Verifier {
+	return &Verifier{
+		logger:      logger,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		keySets:     make(map[string]*jwkSet),
+		discoveries: make(map[string]*discovery),
+		fetches:     make(map[string]*fetch),
+	}
+}
+
+// BearerToken returns the bearer token in the Authorization header of the request.
+func BearerToken(request *http.Request) (string, error) {
+	authHeader := request.Header.Get("Authorization")
+	if len(authHeader) < 7 || !strings.EqualFold(authHeader[:7], "Bearer ") {
+		return "", errors.New("missing bearer token")
+	}
+	return strings.TrimSpace(authHeader[7:]), nil
+}
+
+// IsJWT returns true if the token looks like a JSON Web Token.
+func IsJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// Verify checks the signature of the token with the key set at jwksURL and
+// that the token is not expired. It returns the claims of a valid token;
+// checking issuer, audience and other claims is left to the caller.
+func (v *Verifier) Verify(token string, jwksURL string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header jwtHeader
+	err := decodeSegment(parts[0], &header)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding token header")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding token signature")
+	}
+
+	key, err := v.getKey(jwksURL, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	err = verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := make(map[string]interface{})
+	err = decodeSegment(parts[1], &claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding token claims")
+	}
+
+	err = validateTime(claims, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// getKey returns the public key with the given key id from the key set
+// at jwksURL, fetching the key set if it's absent or stale.
+func (v *Verifier) getKey(jwksURL string, kid string) (crypto.PublicKey, error) {
+	v.lock.Lock()
+	set, ok := v.keySets[jwksURL]
+	v.lock.Unlock()
+
+	if !ok || time.Since(set.fetchedAt) > jwksRefreshInterval ||
+		(findKey(set, kid) == nil && time.Since(set.fetchedAt) > jwksMinRefetchInterval) {
+		newSet, err := v.do("jwks "+jwksURL, func() (interface{}, error) {
+			set, err := v.fetchKeySet(jwksURL)
+			if err != nil {
+				return nil, err
+			}
+			v.lock.Lock()
+			v.keySets[jwksURL] = set
+			v.lock.Unlock()
+			return set, nil
+		})
+		if err != nil {
+			if !ok {
+				return nil, err
+			}
+			// keep serving with the stale key set
+			v.logger.Error("error refreshing key set", zap.Error(err), zap.String("url", jwksURL))
+		} else {
+			set = newSet.(*jwkSet)
+		}
+	}
+
+	key := findKey(set, kid)
+	if key == nil {
+		return nil, errors.Errorf("no key found for key id %q", kid)
+	}
+	return key, nil
+}
+
+// DiscoverJWKSURL returns the key set URL of an OpenID Connect issuer, found
+// with its discovery document. Discovered URLs are cached, failures are
+// retried at most every discoveryRetryInterval.
+func (v *Verifier) DiscoverJWKSURL(issuer string) (string, error) {
+	v.lock.Lock()
+	d, ok := v.discoveries[issuer]
+	v.lock.Unlock()
+
+	if ok && (d.err == nil || time.Since(d.discoveredAt) < discoveryRetryInterval) {
+		return d.jwksURL, d.err
+	}
+
+	result, err := v.do("discovery "+issuer, func() (interface{}, error) {
+		jwksURL, err := v.discover(issuer)
+		d := &discovery{
+			jwksURL:      jwksURL,
+			err:          err,
+			discoveredAt: time.Now(),
+		}
+		v.lock.Lock()
+		v.discoveries[issuer] = d
+		v.lock.Unlock()
+		return jwksURL, err
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.(string), nil
+}
+
+// do calls fn unless a call with the same key is in flight already, in
+// which case it waits for that call and returns its result instead.
+func (v *Verifier) do(key string, fn func() (interface{}, error)) (interface{}, error) {
+	v.lock.Lock()
+	f, ok := v.fetches[key]
+	if ok {
+		v.lock.Unlock()
+		<-f.done
+		return f.result, f.err
+	}
+	f = &fetch{done: make(chan struct{})}
+	v.fetches[key] = f
+	v.lock.Unlock()
+
+	f.result, f.err = fn()
+
+	v.lock.Lock()
+	delete(v.fetches, key)
+	v.lock.Unlock()
+	close(f.done)
+	return f.result, f.err
+}
+
+func findKey(set *jwkSet, kid string) crypto.PublicKey {
+	if set == nil {
+		return nil
+	}
+	if key, ok := set.keys[kid]; ok {
+		return key
+	}
+	// a token without "kid" can only be matched if the set holds exactly one key
+	if len(kid) == 0 && len(set.keys) == 1 {
+		for _, key := range set.keys {
+			return key
+		}
+	}
+	return nil
+}
+
+func (v *Verifier) fetchKeySet(jwksURL string) (*jwkSet, error) {
+	resp, err := v.httpClient.Get(jwksURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error fetching key set from %v", jwksURL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("error fetching key set from %v: %v", jwksURL, resp.Status)
+	}
+
+	var body struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error decoding key set from %v", jwksURL)
+	}
+
+	set := &jwkSet{
+		keys:      make(map[string]crypto.PublicKey),
+		fetchedAt: time.Now(),
+	}
+	for _, jwk := range body.Keys {
+		if len(jwk.Use) > 0 && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			v.logger.Warn("skipping unsupported key", zap.Error(err), zap.String("kid", jwk.Kid), zap.String("url", jwksURL))
+			continue
+		}
+		set.keys[jwk.Kid] = key
+	}
+	return set, nil
+}
+
+func (v *Verifier) discover(issuer string) (string, error) {
+	discoveryURL := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	resp, err := v.httpClient.Get(discoveryURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "error discovering OIDC provider %v", issuer)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("error discovering OIDC provider %v: %v", issuer, resp.Status)
+	}
+
+	var doc struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&doc)
+	if err != nil {
+		return "", errors.Wrapf(err, "error decoding discovery document of OIDC provider %v", issuer)
+	}
+	if doc.Issuer != issuer {
+		return "", errors.Errorf("OIDC provider %v reports a different issuer %v", issuer, doc.Issuer)
+	}
+	if len(doc.JWKSURI) == 0 {
+		return "", errors.Errorf("OIDC provider %v has no key set", issuer)
+	}
+	return doc.JWKSURI, nil
+}
+
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, errors.Errorf("unsupported key type %q", jwk.Kty)
+	}
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed []byte, signature []byte) error {
+	if len(alg) != 5 {
+		return errors.Errorf("unsupported signing algorithm %q", alg)
+	}
+
+	var hash crypto.Hash
+	switch alg[len(alg)-3:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return errors.Errorf("unsupported signing algorithm %q", alg)
+	}
+	hasher := hash.New()
+	hasher.Write(signed)
+	digest := hasher.Sum(nil)
+
+	switch alg[:2] {
+	case "RS", "PS":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.Errorf("key type mismatch for algorithm %q", alg)
+		}
+		if alg[:2] == "RS" {
+			return rsa.VerifyPKCS1v15(rsaKey, hash, digest, signature)
+		}
+		return rsa.VerifyPSS(rsaKey, hash, digest, signature, nil)
+	case "ES":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.Errorf("key type mismatch for algorithm %q", alg)
+		}
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid signature length")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	default:
+		return errors.Errorf("unsupported signing algorithm %q", alg)
+	}
+}
+
+func validateTime(claims map[string]interface{}, now time.Time) error {
+	if exp, ok := claims["exp"].(float64); ok {
+		if now.After(time.Unix(int64(exp), 0).Add(clockSkew)) {
+			return errors.New("token is expired")
+		}
+	}
+	if nbf, ok := claims["nbf"].(float64); ok {
+		if now.Add(clockSkew).Before(time.Unix(int64(nbf), 0)) {
+			return errors.New("token is not valid yet")
+		}
+	}
+	return nil
+}
+
+// AudienceMatches returns true if the "aud" claim holds any of the audiences.
+func AudienceMatches(aud interface{}, audiences []string) bool {
+	var tokenAudiences []string
+	switch v := aud.(type) {
+	case string:
+		tokenAudiences = []string{v}
+	case []interface{}:
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				tokenAudiences = append(tokenAudiences, s)
+			}
+		}
+	}
+	for _, a := range tokenAudiences {
+		for _, expected := range audiences {
+			if a == expected {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ClaimToString returns the string form of a claim value, lists are joined with commas.
+func ClaimToString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case []interface{}:
+		values := make([]string, 0, len(val))
+		for _, item := range val {
+			values = append(values, ClaimToString(item))
+		}
+		return strings.Join(values, ",")
+	case map[string]interface{}:
+		b, _ := json.Marshal(val)
+		return string(b)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
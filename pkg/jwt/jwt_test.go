@@ -0,0 +1,145 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func keySetHandler(t *testing.T, fetches *int32, release <-chan struct{}) http.HandlerFunc {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(fetches, 1)
+		if release != nil {
+			<-release
+		}
+		fmt.Fprintf(w, `{"keys":[{"kid":"k1","kty":"RSA","use":"sig","n":"%v","e":"%v"}]}`,
+			base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()))
+	}
+}
+
+func TestGetKeyFetchesOutsideLock(t *testing.T) {
+	var slowFetches, fastFetches int32
+	release := make(chan struct{})
+	slow := httptest.NewServer(keySetHandler(t, &slowFetches, release))
+	defer slow.Close()
+	fast := httptest.NewServer(keySetHandler(t, &fastFetches, nil))
+	defer fast.Close()
+
+	v := MakeVerifier(zap.NewNop())
+
+	// concurrent requests for the same key set share a single fetch
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := v.getKey(slow.URL, "k1")
+			errs <- err
+		}()
+	}
+
+	// other key sets are fetched meanwhile
+	for atomic.LoadInt32(&slowFetches) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := v.getKey(fast.URL, "k1"); err != nil {
+		t.Fatalf("expected key of other key set while a fetch is in flight, got %v", err)
+	}
+
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if n := atomic.LoadInt32(&slowFetches); n != 1 {
+		t.Errorf("expected concurrent requests to share one fetch, got %v fetches", n)
+	}
+
+	// cached key sets aren't fetched again
+	if _, err := v.getKey(fast.URL, "k1"); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&fastFetches); n != 1 {
+		t.Errorf("expected cached key set, got %v fetches", n)
+	}
+}
+
+func TestDiscoverJWKSURL(t *testing.T) {
+	var issuer string
+	discoveries := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		discoveries++
+		switch r.URL.Path {
+		case "/good/.well-known/openid-configuration":
+			fmt.Fprintf(w, `{"issuer":"%v/good","jwks_uri":"%v/keys"}`, issuer, issuer)
+		case "/spoofed/.well-known/openid-configuration":
+			fmt.Fprintf(w, `{"issuer":"%v/good","jwks_uri":"%v/keys"}`, issuer, issuer)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	issuer = server.URL
+
+	v := MakeVerifier(zap.NewNop())
+	for i := 0; i < 2; i++ {
+		jwksURL, err := v.DiscoverJWKSURL(issuer + "/good")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if jwksURL != issuer+"/keys" {
+			t.Errorf("expected key set URL of the discovery document, got %q", jwksURL)
+		}
+	}
+	if discoveries != 1 {
+		t.Errorf("expected discovered key set URL to be cached, got %v discoveries", discoveries)
+	}
+
+	if _, err := v.DiscoverJWKSURL(issuer + "/spoofed"); err == nil {
+		t.Error("expected discovery document of another issuer to be rejected")
+	}
+	// failures aren't retried right away
+	for i := 0; i < 2; i++ {
+		if _, err := v.DiscoverJWKSURL(issuer + "/missing"); err == nil {
+			t.Error("expected issuer without discovery document to fail")
+		}
+	}
+	if discoveries != 3 {
+		t.Errorf("expected failed discovery not to be retried right away, got %v discoveries", discoveries)
+	}
+}
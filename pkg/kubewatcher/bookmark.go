@@ -0,0 +1,144 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubewatcher
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	// bookmarkInterval is the shortest interval the resource version of a
+	// watch is bookmarked at, so that busy watches don't update their
+	// trigger for every event.
+	bookmarkInterval = 10 * time.Second
+
+	maxBookmarkAttempts = 5
+
+	// maxDeliveredEvents bounds the events remembered per watch to
+	// de-duplicate deliveries with.
+	maxDeliveredEvents = 10000
+)
+
+type (
+	// deliveredEvents remembers the events delivered of a watch by the UID
+	// and resource version of their objects, forgetting the oldest ones
+	// past its size.
+	deliveredEvents struct {
+		keys map[string]struct{}
+		ring []string
+		next int
+	}
+)
+
+func makeDeliveredEvents(size int) *deliveredEvents {
+	return &deliveredEvents{
+		keys: make(map[string]struct{}, size),
+		ring: make([]string, size),
+	}
+}
+
+// add records the event of an object, returning false if one of it at the
+// same resource version was recorded already.
+func (d *deliveredEvents) add(obj runtime.Object) bool {
+	m, err := meta.Accessor(obj)
+	if err != nil || len(m.GetUID()) == 0 || len(m.GetResourceVersion()) == 0 {
+		// events of objects that can't be told apart are delivered
+		return true
+	}
+	key := fmt.Sprintf("%v/%v", m.GetUID(), m.GetResourceVersion())
+	if _, ok := d.keys[key]; ok {
+		return false
+	}
+
+	if len(d.ring[d.next]) > 0 {
+		delete(d.keys, d.ring[d.next])
+	}
+	d.ring[d.next] = key
+	d.next = (d.next + 1) % len(d.ring)
+	d.keys[key] = struct{}{}
+	return true
+}
+
+// bookmarkedResourceVersion returns the resource version a watch trigger
+// was bookmarked at.
+func bookmarkedResourceVersion(w *fv1.KubernetesWatchTrigger) string {
+	return w.ObjectMeta.Annotations[fv1.ANNOTATION_KUBEWATCHER_RESOURCE_VERSION]
+}
+
+// bookmark records the resource version of the last event of the watch in
+// the annotations of its trigger, at most once per bookmarkInterval unless
+// forced.
+func (ws *watchSubscription) bookmark(force bool) {
+	if ws.fissionClient == nil || len(ws.lastResourceVersion) == 0 ||
+		ws.lastResourceVersion == ws.bookmarkedResourceVersion {
+		return
+	}
+	if !force && time.Since(ws.lastBookmarkTime) < bookmarkInterval {
+		return
+	}
+
+	err := ws.saveBookmark(ws.lastResourceVersion)
+	if err != nil {
+		ws.logger.Error("error bookmarking resource version of watch", zap.Error(err),
+			zap.String("watch_name", ws.watch.ObjectMeta.Name), zap.String("resource_version", ws.lastResourceVersion))
+		return
+	}
+	ws.bookmarkedResourceVersion = ws.lastResourceVersion
+	ws.lastBookmarkTime = time.Now()
+}
+
+func (ws *watchSubscription) saveBookmark(resourceVersion string) error {
+	triggers := ws.fissionClient.CoreV1().KubernetesWatchTriggers(ws.watch.ObjectMeta.Namespace)
+	for i := 0; i < maxBookmarkAttempts; i++ {
+		trigger, err := triggers.Get(ws.watch.ObjectMeta.Name, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			// the trigger is being removed
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "error getting watch trigger")
+		}
+		if trigger.ObjectMeta.UID != ws.watch.ObjectMeta.UID {
+			// the trigger was recreated, and is watched from scratch
+			return nil
+		}
+
+		if trigger.ObjectMeta.Annotations == nil {
+			trigger.ObjectMeta.Annotations = make(map[string]string)
+		}
+		trigger.ObjectMeta.Annotations[fv1.ANNOTATION_KUBEWATCHER_RESOURCE_VERSION] = resourceVersion
+		_, err = triggers.Update(trigger)
+		if k8serrors.IsConflict(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrap(err, "error updating watch trigger")
+		}
+		return nil
+	}
+	return errors.Errorf("watch trigger kept being updated in %v attempts", maxBookmarkAttempts)
+}
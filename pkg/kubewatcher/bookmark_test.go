@@ -0,0 +1,55 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubewatcher
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestDeliveredEvents(t *testing.T) {
+	pod := func(uid string, rv string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: types.UID(uid), ResourceVersion: rv}}
+	}
+
+	d := makeDeliveredEvents(2)
+	if !d.add(pod("a", "1")) || !d.add(pod("a", "2")) {
+		t.Fatal("expected new events to be delivered")
+	}
+	if d.add(pod("a", "1")) {
+		t.Error("expected event delivered already to be skipped")
+	}
+
+	// the oldest event is forgotten past the size
+	if !d.add(pod("b", "3")) {
+		t.Fatal("expected new event to be delivered")
+	}
+	if !d.add(pod("a", "1")) {
+		t.Error("expected forgotten event to be delivered")
+	}
+	if d.add(pod("b", "3")) {
+		t.Error("expected event delivered already to be skipped")
+	}
+
+	// objects without UID or resource version can't be told apart
+	if !d.add(pod("", "")) || !d.add(pod("", "")) {
+		t.Error("expected events of objects without UID to be delivered")
+	}
+}
@@ -30,12 +30,19 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/util/jsonpath"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
 	ferror "github.com/fission/fission/pkg/error"
 	"github.com/fission/fission/pkg/publisher"
 	"github.com/fission/fission/pkg/utils"
@@ -52,8 +59,11 @@ type (
 		logger           *zap.Logger
 		watches          map[types.UID]watchSubscription
 		kubernetesClient *kubernetes.Clientset
+		dynamicClient    dynamic.Interface
+		restMapper       *restmapper.DeferredDiscoveryRESTMapper
 		requestChannel   chan *kubeWatcherRequest
 		publisher        publisher.Publisher
+		fissionClient    *crd.FissionClient
 	}
 
 	watchSubscription struct {
@@ -63,7 +73,17 @@ type (
 		lastResourceVersion string
 		stopped             *int32
 		kubernetesClient    *kubernetes.Clientset
+		dynamicClient       dynamic.Interface
+		restMapper          *restmapper.DeferredDiscoveryRESTMapper
+		filter              *jsonpath.JSONPath
 		publisher           publisher.Publisher
+		fissionClient       *crd.FissionClient
+
+		// the events delivered, and the resource version bookmarked in the
+		// trigger to resume watching from after restarts
+		delivered                 *deliveredEvents
+		bookmarkedResourceVersion string
+		lastBookmarkTime          time.Time
 	}
 
 	kubeWatcherRequest struct {
@@ -76,12 +96,16 @@ type (
 	}
 )
 
-func MakeKubeWatcher(logger *zap.Logger, kubernetesClient *kubernetes.Clientset, publisher publisher.Publisher) *KubeWatcher {
+func MakeKubeWatcher(logger *zap.Logger, kubernetesClient *kubernetes.Clientset, dynamicClient dynamic.Interface,
+	restMapper *restmapper.DeferredDiscoveryRESTMapper, publisher publisher.Publisher, fissionClient *crd.FissionClient) *KubeWatcher {
 	kw := &KubeWatcher{
 		logger:           logger.Named("kube_watcher"),
 		watches:          make(map[types.UID]watchSubscription),
 		kubernetesClient: kubernetesClient,
+		dynamicClient:    dynamicClient,
+		restMapper:       restMapper,
 		publisher:        publisher,
+		fissionClient:    fissionClient,
 		requestChannel:   make(chan *kubeWatcherRequest),
 	}
 	go kw.svc()
@@ -148,36 +172,70 @@ func printKubernetesObject(obj runtime.Object, w io.Writer) error {
 	return err
 }
 
-func createKubernetesWatch(kubeClient *kubernetes.Clientset, w *fv1.KubernetesWatchTrigger, resourceVersion string) (watch.Interface, error) {
+func (ws *watchSubscription) createKubernetesWatch(resourceVersion string) (watch.Interface, error) {
+	w := &ws.watch
 	var wi watch.Interface
 	var err error
 	var watchTimeoutSec int64 = 120
 
-	// TODO populate labelselector and fieldselector
+	// bookmark events advance the resource version of watches of
+	// resources that rarely change, so that it doesn't expire
 	listOptions := metav1.ListOptions{
-		ResourceVersion: resourceVersion,
-		TimeoutSeconds:  &watchTimeoutSec,
+		ResourceVersion:     resourceVersion,
+		TimeoutSeconds:      &watchTimeoutSec,
+		AllowWatchBookmarks: true,
+		LabelSelector:       labels.SelectorFromSet(labels.Set(w.Spec.LabelSelector)).String(),
+		FieldSelector:       w.Spec.FieldSelector,
+	}
+
+	// resources of other API versions than the core types are watched
+	// with the dynamic client
+	if len(w.Spec.APIVersion) > 0 {
+		return ws.createDynamicWatch(listOptions)
 	}
 
-	// TODO handle the full list of types
 	switch strings.ToUpper(w.Spec.Type) {
 	case "POD":
-		wi, err = kubeClient.CoreV1().Pods(w.Spec.Namespace).Watch(listOptions)
+		wi, err = ws.kubernetesClient.CoreV1().Pods(w.Spec.Namespace).Watch(listOptions)
 	case "SERVICE":
-		wi, err = kubeClient.CoreV1().Services(w.Spec.Namespace).Watch(listOptions)
+		wi, err = ws.kubernetesClient.CoreV1().Services(w.Spec.Namespace).Watch(listOptions)
 	case "REPLICATIONCONTROLLER":
-		wi, err = kubeClient.CoreV1().ReplicationControllers(w.Spec.Namespace).Watch(listOptions)
+		wi, err = ws.kubernetesClient.CoreV1().ReplicationControllers(w.Spec.Namespace).Watch(listOptions)
 	case "JOB":
-		wi, err = kubeClient.BatchV1().Jobs(w.Spec.Namespace).Watch(listOptions)
+		wi, err = ws.kubernetesClient.BatchV1().Jobs(w.Spec.Namespace).Watch(listOptions)
 	default:
 		err = errors.NewBadRequest(fmt.Sprintf("Error: unknown obj type '%v'", w.Spec.Type))
 	}
 	return wi, err
 }
 
+// createDynamicWatch watches the resources of the kind and API version of
+// the trigger, mapped to their resource with the discovery API.
+func (ws *watchSubscription) createDynamicWatch(listOptions metav1.ListOptions) (watch.Interface, error) {
+	gv, err := schema.ParseGroupVersion(ws.watch.Spec.APIVersion)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("Error: invalid API version '%v': %v", ws.watch.Spec.APIVersion, err))
+	}
+	if ws.dynamicClient == nil || ws.restMapper == nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("Error: watching resources of API version '%v' is not supported", gv))
+	}
+
+	mapping, err := ws.restMapper.RESTMapping(schema.GroupKind{Group: gv.Group, Kind: ws.watch.Spec.Type}, gv.Version)
+	if err != nil {
+		// the kind may be of a CRD created after discovery was cached
+		ws.restMapper.Reset()
+		return nil, err
+	}
+
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return ws.dynamicClient.Resource(mapping.Resource).Namespace(ws.watch.Spec.Namespace).Watch(listOptions)
+	}
+	return ws.dynamicClient.Resource(mapping.Resource).Watch(listOptions)
+}
+
 func (kw *KubeWatcher) addWatch(w *fv1.KubernetesWatchTrigger) error {
 	kw.logger.Info("adding watch", zap.String("name", w.ObjectMeta.Name), zap.Any("function", w.Spec.FunctionReference))
-	ws, err := MakeWatchSubscription(kw.logger.Named("watchsubscription"), w, kw.kubernetesClient, kw.publisher)
+	ws, err := MakeWatchSubscription(kw.logger.Named("watchsubscription"), w, kw.kubernetesClient, kw.dynamicClient, kw.restMapper, kw.publisher, kw.fissionClient)
 	if err != nil {
 		return err
 	}
@@ -197,16 +255,33 @@ func (kw *KubeWatcher) removeWatch(w *fv1.KubernetesWatchTrigger) error {
 	return nil
 }
 
-func MakeWatchSubscription(logger *zap.Logger, w *fv1.KubernetesWatchTrigger, kubeClient *kubernetes.Clientset, publisher publisher.Publisher) (*watchSubscription, error) {
+func MakeWatchSubscription(logger *zap.Logger, w *fv1.KubernetesWatchTrigger, kubeClient *kubernetes.Clientset,
+	dynamicClient dynamic.Interface, restMapper *restmapper.DeferredDiscoveryRESTMapper, publisher publisher.Publisher,
+	fissionClient *crd.FissionClient) (*watchSubscription, error) {
 	var stopped int32 = 0
 	ws := &watchSubscription{
-		logger:              logger.Named("watch_subscription"),
-		watch:               *w,
-		kubeWatch:           nil,
-		stopped:             &stopped,
-		kubernetesClient:    kubeClient,
-		publisher:           publisher,
-		lastResourceVersion: "",
+		logger:           logger.Named("watch_subscription"),
+		watch:            *w,
+		kubeWatch:        nil,
+		stopped:          &stopped,
+		kubernetesClient: kubeClient,
+		dynamicClient:    dynamicClient,
+		restMapper:       restMapper,
+		publisher:        publisher,
+		fissionClient:    fissionClient,
+		delivered:        makeDeliveredEvents(maxDeliveredEvents),
+		// resume from the last event seen before kubewatcher restarted,
+		// rather than getting all resources as added again
+		lastResourceVersion:       bookmarkedResourceVersion(w),
+		bookmarkedResourceVersion: bookmarkedResourceVersion(w),
+	}
+
+	if len(w.Spec.Filter) > 0 {
+		filter, err := parseFilter(w.Spec.Filter)
+		if err != nil {
+			return nil, err
+		}
+		ws.filter = filter
 	}
 
 	err := ws.restartWatch()
@@ -226,7 +301,15 @@ func (ws *watchSubscription) restartWatch() error {
 			zap.String("namespace", ws.watch.Spec.Namespace),
 			zap.String("type", ws.watch.Spec.Type),
 			zap.String("last_resource_version", ws.lastResourceVersion))
-		wi, err := createKubernetesWatch(ws.kubernetesClient, &ws.watch, ws.lastResourceVersion)
+		wi, err := ws.createKubernetesWatch(ws.lastResourceVersion)
+		if (errors.IsResourceExpired(err) || errors.IsGone(err)) && len(ws.lastResourceVersion) > 0 {
+			// the events since the bookmark are compacted away, start from
+			// the beginning and skip the events delivered already
+			ws.logger.Warn("resource version of watch expired - restarting from the beginning",
+				zap.String("watch_name", ws.watch.ObjectMeta.Name), zap.String("last_resource_version", ws.lastResourceVersion))
+			ws.lastResourceVersion = ""
+			continue
+		}
 		if err != nil {
 			retries--
 			if retries > 0 {
@@ -259,6 +342,9 @@ func (ws *watchSubscription) eventDispatchLoop() {
 		if ws.isStopped() {
 			break
 		}
+		// bookmark the events handled so far
+		ws.bookmark(false)
+
 		ev, more := <-ws.kubeWatch.ResultChan()
 		if !more {
 			if ws.isStopped() {
@@ -268,6 +354,7 @@ func (ws *watchSubscription) eventDispatchLoop() {
 			} else {
 				// watch closed due to timeout, restart it.
 				ws.logger.Warn("watch timed out - restarting", zap.String("watch_name", ws.watch.ObjectMeta.Name))
+				ws.bookmark(true)
 				err := ws.restartWatch()
 				if err != nil {
 					ws.logger.Panic("failed to restart watch", zap.Error(err), zap.String("watch_name", ws.watch.ObjectMeta.Name))
@@ -279,7 +366,8 @@ func (ws *watchSubscription) eventDispatchLoop() {
 		if ev.Type == watch.Error {
 			e := errors.FromObject(ev.Object)
 			ws.logger.Warn("watch error - retrying after one second", zap.Error(e), zap.String("watch_name", ws.watch.ObjectMeta.Name))
-			// Start from the beginning to get around "too old resource version"
+			// Start from the beginning to get around "too old resource version",
+			// the events delivered already are skipped
 			ws.lastResourceVersion = ""
 			time.Sleep(time.Second)
 			err := ws.restartWatch()
@@ -295,6 +383,29 @@ func (ws *watchSubscription) eventDispatchLoop() {
 			ws.lastResourceVersion = rv
 		}
 
+		// bookmark events only carry the resource version the watch is at
+		if ev.Type == watch.Bookmark {
+			continue
+		}
+
+		// the events of resources got again after restarts are delivered once
+		if !ws.delivered.add(ev.Object) {
+			ws.logger.Debug("skipping event delivered already", zap.String("watch_name", ws.watch.ObjectMeta.Name),
+				zap.String("event_type", string(ev.Type)), zap.String("resource_version", rv))
+			continue
+		}
+
+		if ws.filter != nil {
+			match, err := matchFilter(ws.filter, ev.Object)
+			if err != nil {
+				ws.logger.Error("error applying filter to object", zap.Error(err), zap.String("watch_name", ws.watch.ObjectMeta.Name))
+				continue
+			}
+			if !match {
+				continue
+			}
+		}
+
 		// Serialize the object
 		var buf bytes.Buffer
 		err = printKubernetesObject(ev.Object, &buf)
@@ -307,7 +418,11 @@ func (ws *watchSubscription) eventDispatchLoop() {
 		headers := map[string]string{
 			"Content-Type":             "application/json",
 			"X-Kubernetes-Event-Type":  string(ev.Type),
-			"X-Kubernetes-Object-Type": reflect.TypeOf(ev.Object).Elem().Name(),
+			"X-Kubernetes-Object-Type": objectKind(ev.Object),
+			fv1.HeaderTriggerType:      fv1.TriggerTypeKubeWatch,
+			fv1.HeaderTriggerName:      ws.watch.ObjectMeta.Name,
+			fv1.HeaderCloudEventsMode:  string(ws.watch.Spec.CloudEvents),
+			fv1.HeaderEventTime:        time.Now().UTC().Format(time.RFC3339Nano),
 		}
 
 		// TODO support other function ref types. Or perhaps delegate to router?
@@ -326,6 +441,67 @@ func (ws *watchSubscription) eventDispatchLoop() {
 	}
 }
 
+// parseFilter parses the JSONPath filter of a watch. Missing keys are no
+// match rather than errors, as resources often omit empty fields.
+func parseFilter(filter string) (*jsonpath.JSONPath, error) {
+	j := jsonpath.New("filter").AllowMissingKeys(true)
+	err := j.Parse(filter)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("Error: invalid filter '%v': %v", filter, err))
+	}
+	return j, nil
+}
+
+// matchFilter returns whether a filter finds a value in an object other
+// than null, false, "False" or an empty string.
+func matchFilter(filter *jsonpath.JSONPath, obj runtime.Object) (bool, error) {
+	var data map[string]interface{}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		data = u.Object
+	} else {
+		var err error
+		data, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	results, err := filter.FindResults(data)
+	if err != nil {
+		return false, err
+	}
+	for _, values := range results {
+		for _, v := range values {
+			if !v.IsValid() || !v.CanInterface() {
+				continue
+			}
+			switch value := v.Interface().(type) {
+			case nil:
+			case bool:
+				if value {
+					return true, nil
+				}
+			case string:
+				if len(value) > 0 && value != "False" {
+					return true, nil
+				}
+			default:
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// objectKind returns the kind of an object. Typed objects decoded from
+// watches have no type meta, so the name of their type is used instead.
+func objectKind(obj runtime.Object) string {
+	if kind := obj.GetObjectKind().GroupVersionKind().Kind; len(kind) > 0 {
+		return kind
+	}
+	return reflect.TypeOf(obj).Elem().Name()
+}
+
 func (ws *watchSubscription) stop() {
 	atomic.StoreInt32(ws.stopped, 1)
 	ws.kubeWatch.Stop()
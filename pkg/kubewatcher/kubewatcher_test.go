@@ -0,0 +1,93 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubewatcher
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestMatchFilter(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionFalse},
+				{Type: corev1.PodScheduled, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+	certificate := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata":   map[string]interface{}{"name": "web"},
+		"spec":       map[string]interface{}{"isCA": false, "renewBefore": nil},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "True"},
+			},
+		},
+	}}
+
+	for _, test := range []struct {
+		filter   string
+		obj      runtime.Object
+		expected bool
+	}{
+		{filter: `{.status.phase}`, obj: pod, expected: true},
+		{filter: `{.status.conditions[?(@.type=="Ready")].status}`, obj: pod, expected: false},
+		{filter: `{.status.conditions[?(@.type=="PodScheduled")].status}`, obj: pod, expected: true},
+		{filter: `{.status.message}`, obj: pod, expected: false},
+		{filter: `{.status.conditions[?(@.type=="Ready")].status}`, obj: certificate, expected: true},
+		{filter: `{.spec.isCA}`, obj: certificate, expected: false},
+		{filter: `{.spec.renewBefore}`, obj: certificate, expected: false},
+		{filter: `{.spec.secretName}`, obj: certificate, expected: false},
+	} {
+		filter, err := parseFilter(test.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		match, err := matchFilter(filter, test.obj)
+		if err != nil {
+			t.Fatalf("error applying filter %v: %v", test.filter, err)
+		}
+		if match != test.expected {
+			t.Errorf("expected filter %v to match %v, got %v", test.filter, test.expected, match)
+		}
+	}
+
+	if _, err := parseFilter(`{.status.conditions[`); err == nil {
+		t.Error("expected error parsing invalid filter")
+	}
+}
+
+func TestObjectKind(t *testing.T) {
+	if kind := objectKind(&corev1.Pod{}); kind != "Pod" {
+		t.Errorf("expected kind Pod, got %v", kind)
+	}
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("cert-manager.io/v1")
+	u.SetKind("Certificate")
+	if kind := objectKind(u); kind != "Certificate" {
+		t.Errorf("expected kind Certificate, got %v", kind)
+	}
+}
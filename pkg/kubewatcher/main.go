@@ -19,6 +19,8 @@ package kubewatcher
 import (
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/restmapper"
 
 	"github.com/fission/fission/pkg/crd"
 	"github.com/fission/fission/pkg/publisher"
@@ -35,8 +37,16 @@ func Start(logger *zap.Logger, routerUrl string) error {
 		return errors.Wrap(err, "error waiting for CRDs")
 	}
 
+	dynamicClient, err := crd.GetDynamicClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to get dynamic kubernetes client")
+	}
+	// discovery is cached and reset when a kind can't be mapped, for
+	// watches of CRDs created after kubewatcher started
+	restMapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(kubeClient.Discovery()))
+
 	poster := publisher.MakeWebhookPublisher(logger, routerUrl)
-	kubeWatch := MakeKubeWatcher(logger, kubeClient, poster)
+	kubeWatch := MakeKubeWatcher(logger, kubeClient, dynamicClient, restMapper, poster, fissionClient)
 	MakeWatchSync(logger, fissionClient, kubeWatch)
 
 	return nil
@@ -0,0 +1,118 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// elasticsearchIndex is the index the logger writes to.
+	elasticsearchIndex = "fission-function-logs"
+
+	elasticsearchTimeKey = "@timestamp"
+)
+
+type (
+	// elasticsearchStore queries the logs the logger writes to
+	// Elasticsearch.
+	elasticsearchStore struct {
+		url      string
+		index    string
+		username string
+		password string
+		client   *http.Client
+	}
+
+	elasticsearchResponse struct {
+		Hits struct {
+			Hits []struct {
+				Source map[string]interface{} `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+)
+
+func (s *elasticsearchStore) Query(q *Query) ([]Entry, error) {
+	// match_phrase matches UIDs whether they are mapped as keywords or as
+	// analyzed text
+	filters := []interface{}{
+		map[string]interface{}{"match_phrase": map[string]string{"function_uid": q.FunctionUID}},
+	}
+	if len(q.Pod) > 0 {
+		filters = append(filters, map[string]interface{}{"match_phrase": map[string]string{"pod": q.Pod}})
+	}
+	timeRange := map[string]string{}
+	if !q.Since.IsZero() {
+		timeRange["gte"] = q.Since.UTC().Format(time.RFC3339Nano)
+	}
+	if !q.Until.IsZero() {
+		timeRange["lte"] = q.Until.UTC().Format(time.RFC3339Nano)
+	}
+	if len(timeRange) > 0 {
+		filters = append(filters, map[string]interface{}{
+			"range": map[string]interface{}{elasticsearchTimeKey: timeRange},
+		})
+	}
+	order := "asc"
+	if q.Reverse {
+		order = "desc"
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"size": q.limit(),
+		"sort": []interface{}{
+			map[string]interface{}{elasticsearchTimeKey: map[string]string{"order": order}},
+		},
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{"filter": filters},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url+"/"+s.index+"/_search", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var resp elasticsearchResponse
+	err = do(s.client, req, s.username, s.password, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		t, _ := hit.Source[elasticsearchTimeKey].(string)
+		timestamp, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid Elasticsearch entry time %q", t)
+		}
+		entries = append(entries, recordEntry(hit.Source, timestamp))
+	}
+	return entries, nil
+}
+
+func (s *elasticsearchStore) String() string {
+	return SinkElasticsearch
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logstore
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// influxDBDatabase is the database the logger writes to.
+const influxDBDatabase = "fissionFunctionLog"
+
+type (
+	// influxDBStore queries the logs the logger writes to InfluxDB.
+	influxDBStore struct {
+		url      string
+		username string
+		password string
+		client   *http.Client
+	}
+
+	influxDBResponse struct {
+		Results []struct {
+			Series []struct {
+				Columns []string        `json:"columns"`
+				Values  [][]interface{} `json:"values"`
+			} `json:"series"`
+			Error string `json:"error"`
+		} `json:"results"`
+		Error string `json:"error"`
+	}
+)
+
+func (s *influxDBStore) Query(q *Query) ([]Entry, error) {
+	// the function UID is a tag, the older logger wrote it as funcuid
+	conditions := []string{`("kubernetes_labels_functionUid" = $funcuid OR "funcuid" = $funcuid)`}
+	params := map[string]interface{}{
+		"funcuid": q.FunctionUID,
+	}
+	if len(q.Pod) > 0 {
+		conditions = append(conditions, `("pod" = $pod OR "kubernetes_pod_name" = $pod)`)
+		params["pod"] = q.Pod
+	}
+	if !q.Since.IsZero() {
+		conditions = append(conditions, `time >= $since`)
+		params["since"] = q.Since.UnixNano()
+	}
+	if !q.Until.IsZero() {
+		conditions = append(conditions, `time <= $until`)
+		params["until"] = q.Until.UnixNano()
+	}
+	order := "ASC"
+	if q.Reverse {
+		order = "DESC"
+	}
+	command := "SELECT * FROM /^log*/ WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY time " + order + " LIMIT " + strconv.Itoa(q.limit())
+
+	encodedParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	values := url.Values{}
+	values.Set("db", influxDBDatabase)
+	values.Set("q", command)
+	values.Set("params", string(encodedParams))
+	values.Set("epoch", "ns")
+	req, err := http.NewRequest(http.MethodGet, s.url+"/query?"+values.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp influxDBResponse
+	err = do(s.client, req, s.username, s.password, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Error) > 0 {
+		return nil, errors.Errorf("error querying InfluxDB: %v", resp.Error)
+	}
+
+	var entries []Entry
+	for _, result := range resp.Results {
+		if len(result.Error) > 0 {
+			return nil, errors.Errorf("error querying InfluxDB: %v", result.Error)
+		}
+		for _, series := range result.Series {
+			for _, row := range series.Values {
+				record := make(map[string]interface{}, len(row))
+				var timestamp time.Time
+				for i, v := range row {
+					if i >= len(series.Columns) {
+						break
+					}
+					if series.Columns[i] == "time" {
+						if n, ok := v.(json.Number); ok {
+							ns, _ := n.Int64()
+							timestamp = time.Unix(0, ns)
+						}
+						continue
+					}
+					record[series.Columns[i]] = v
+				}
+				entries = append(entries, recordEntry(record, timestamp))
+			}
+		}
+	}
+	// each measurement is a series of its own
+	return sortEntries(entries, q.Reverse, q.limit()), nil
+}
+
+func (s *influxDBStore) String() string {
+	return SinkInfluxDB
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package logstore queries the logs of functions from the sink the logger
+// DaemonSet ships them to. The logger tags each line of the stdout and
+// stderr of function containers with the function, function_namespace,
+// function_uid, pod, namespace and container fields, whatever the sink.
+package logstore
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	SinkInfluxDB      = "influxdb"
+	SinkLoki          = "loki"
+	SinkElasticsearch = "elasticsearch"
+	// SinkStdout writes logs to the standard output of the logger, for a
+	// log collector of the cluster to pick them up. They are not queryable.
+	SinkStdout = "stdout"
+
+	// DefaultLimit is how many entries are returned by queries with no
+	// limit.
+	DefaultLimit = 1000
+
+	queryTimeout = 30 * time.Second
+)
+
+type (
+	// Store queries the logs of functions stored in a sink.
+	Store interface {
+		Query(q *Query) ([]Entry, error)
+		String() string
+	}
+
+	// Query selects the log entries of a function.
+	Query struct {
+		FunctionUID string
+		// Pod, if set, only selects the entries of a pod of the function.
+		Pod string
+		// Since and Until bound the time of entries, inclusively. Zero
+		// values leave the range open, as far as the sink allows.
+		Since time.Time
+		Until time.Time
+		Limit int
+		// Reverse returns the most recent entries first.
+		Reverse bool
+	}
+
+	// Entry is a line a function container wrote.
+	Entry struct {
+		Timestamp time.Time `json:"timestamp"`
+		Message   string    `json:"message"`
+		// Stream is stdout or stderr.
+		Stream      string `json:"stream,omitempty"`
+		Function    string `json:"function,omitempty"`
+		FunctionUID string `json:"functionUID,omitempty"`
+		// Namespace is the namespace of the pod.
+		Namespace string `json:"namespace,omitempty"`
+		Pod       string `json:"pod,omitempty"`
+		Container string `json:"container,omitempty"`
+	}
+)
+
+// MakeStore returns the store querying the logs shipped to sink, at url or
+// the default address of the sink if empty, authenticating with username
+// and password if set. It returns nil for the stdout sink, whose logs are
+// not queryable.
+func MakeStore(sink string, url string, username string, password string) (Store, error) {
+	client := &http.Client{Timeout: queryTimeout}
+	switch sink {
+	case SinkInfluxDB:
+		if len(url) == 0 {
+			url = "http://influxdb:8086"
+		}
+		return &influxDBStore{
+			url:      strings.TrimSuffix(strings.TrimSuffix(url, "/"), "/query"),
+			username: username,
+			password: password,
+			client:   client,
+		}, nil
+
+	case SinkLoki:
+		if len(url) == 0 {
+			url = "http://loki:3100"
+		}
+		return &lokiStore{
+			url:      strings.TrimSuffix(url, "/"),
+			username: username,
+			password: password,
+			client:   client,
+		}, nil
+
+	case SinkElasticsearch:
+		if len(url) == 0 {
+			url = "http://elasticsearch:9200"
+		}
+		return &elasticsearchStore{
+			url:      strings.TrimSuffix(url, "/"),
+			index:    elasticsearchIndex,
+			username: username,
+			password: password,
+			client:   client,
+		}, nil
+
+	case SinkStdout, "":
+		return nil, nil
+
+	default:
+		return nil, errors.Errorf("unknown log sink %q, expected influxdb, loki, elasticsearch or stdout", sink)
+	}
+}
+
+// limit returns the limit of q, or the default one if unset.
+func (q *Query) limit() int {
+	if q.Limit <= 0 {
+		return DefaultLimit
+	}
+	return q.Limit
+}
+
+// sortEntries sorts entries by time, the most recent first if reverse, and
+// keeps the first limit ones.
+func sortEntries(entries []Entry, reverse bool, limit int) []Entry {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if reverse {
+			return entries[i].Timestamp.After(entries[j].Timestamp)
+		}
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// recordEntry returns the entry of a record the logger shipped.
+func recordEntry(record map[string]interface{}, timestamp time.Time) Entry {
+	field := func(keys ...string) string {
+		for _, k := range keys {
+			if v, ok := record[k].(string); ok && len(v) > 0 {
+				return v
+			}
+		}
+		return ""
+	}
+	return Entry{
+		Timestamp:   timestamp,
+		Message:     strings.TrimSuffix(field("log", "message"), "\n"),
+		Stream:      field("stream"),
+		Function:    field("function", "kubernetes_labels_functionName"),
+		FunctionUID: field("function_uid", "kubernetes_labels_functionUid"),
+		Namespace:   field("namespace", "kubernetes_namespace_name"),
+		Pod:         field("pod", "kubernetes_pod_name"),
+		Container:   field("container", "kubernetes_container_name"),
+	}
+}
+
+// do sends req with the credentials of a store, and decodes the JSON
+// response into v.
+func do(client *http.Client, req *http.Request, username string, password string, v interface{}) error {
+	if len(username) > 0 {
+		req.SetBasicAuth(username, password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("log sink responded with status %v", resp.Status)
+	}
+	// numbers are decoded as is, timestamps in nanoseconds don't fit
+	// float64
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	err = decoder.Decode(v)
+	if err != nil {
+		return errors.Wrap(err, "error decoding log sink response")
+	}
+	return nil
+}
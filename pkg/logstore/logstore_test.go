@@ -0,0 +1,168 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logstore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMakeStore(t *testing.T) {
+	for _, sink := range []string{SinkInfluxDB, SinkLoki, SinkElasticsearch} {
+		store, err := MakeStore(sink, "", "", "")
+		if err != nil {
+			t.Fatalf("error making %v store: %v", sink, err)
+		}
+		if store.String() != sink {
+			t.Fatalf("expected %v store, got %v", sink, store)
+		}
+	}
+
+	store, err := MakeStore(SinkStdout, "", "", "")
+	if err != nil || store != nil {
+		t.Fatalf("expected no stdout store, got %v, %v", store, err)
+	}
+	_, err = MakeStore("syslog", "", "", "")
+	if err == nil {
+		t.Fatal("expected error making store of unknown sink")
+	}
+
+	store, _ = MakeStore(SinkInfluxDB, "http://influx:8086/query", "", "")
+	if url := store.(*influxDBStore).url; url != "http://influx:8086" {
+		t.Fatalf("expected query path trimmed from url, got %v", url)
+	}
+}
+
+func TestInfluxDBQuery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		q := r.URL.Query()
+		if q.Get("db") != influxDBDatabase || q.Get("epoch") != "ns" {
+			t.Errorf("unexpected query %v", r.URL.RawQuery)
+		}
+		if !strings.Contains(q.Get("q"), "ORDER BY time DESC LIMIT 2") {
+			t.Errorf("unexpected command %v", q.Get("q"))
+		}
+		var params map[string]interface{}
+		err := json.Unmarshal([]byte(q.Get("params")), &params)
+		if err != nil || params["funcuid"] != "uid" || params["pod"] != "pod-a" {
+			t.Errorf("unexpected params %v", q.Get("params"))
+		}
+		w.Write([]byte(`{"results":[{"series":[
+			{"name":"log","columns":["time","kubernetes_labels_functionUid","kubernetes_pod_name","log","stream"],
+			 "values":[[1600000000000000001,"uid","pod-a","first\n","stdout"],[1600000000000000003,"uid","pod-a","third\n","stderr"]]},
+			{"name":"log.2","columns":["time","function_uid","pod","log"],
+			 "values":[[1600000000000000002,"uid","pod-a","second\n"]]}
+		]}]}`))
+	}))
+	defer server.Close()
+
+	store, _ := MakeStore(SinkInfluxDB, server.URL, "user", "pass")
+	entries, err := store.Query(&Query{FunctionUID: "uid", Pod: "pod-a", Limit: 2, Reverse: true})
+	if err != nil {
+		t.Fatalf("error querying InfluxDB: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Message != "third" || entries[1].Message != "second" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if entries[0].Timestamp.UnixNano() != 1600000000000000003 || entries[0].Stream != "stderr" ||
+		entries[0].Pod != "pod-a" || entries[0].FunctionUID != "uid" {
+		t.Fatalf("unexpected entry %+v", entries[0])
+	}
+
+	store, _ = MakeStore(SinkInfluxDB, server.URL, "", "")
+	_, err = store.Query(&Query{FunctionUID: "uid"})
+	if err == nil {
+		t.Fatal("expected error querying InfluxDB without credentials")
+	}
+}
+
+func TestLokiQuery(t *testing.T) {
+	since := time.Unix(0, 1600000000000000000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/loki/api/v1/query_range" || q.Get("query") != `{function_uid="uid"}` ||
+			q.Get("start") != "1600000000000000000" || q.Get("direction") != "forward" {
+			t.Errorf("unexpected request %v", r.URL)
+		}
+		w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[
+			{"stream":{"function_uid":"uid","pod":"pod-a","stream":"stdout"},
+			 "values":[["1600000000000000001","{\"log\":\"first\\n\",\"function\":\"hello\"}"],["1600000000000000003","third"]]},
+			{"stream":{"function_uid":"uid","pod":"pod-b","stream":"stderr"},
+			 "values":[["1600000000000000002","{\"log\":\"second\\n\"}"]]}
+		]}}`))
+	}))
+	defer server.Close()
+
+	store, _ := MakeStore(SinkLoki, server.URL+"/", "", "")
+	entries, err := store.Query(&Query{FunctionUID: "uid", Since: since})
+	if err != nil {
+		t.Fatalf("error querying Loki: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", entries)
+	}
+	for i, expected := range []Entry{
+		{Message: "first", Function: "hello", Pod: "pod-a", Stream: "stdout"},
+		{Message: "second", Pod: "pod-b", Stream: "stderr"},
+		{Message: "third", Pod: "pod-a", Stream: "stdout"},
+	} {
+		e := entries[i]
+		if e.Message != expected.Message || e.Function != expected.Function ||
+			e.Pod != expected.Pod || e.Stream != expected.Stream || e.FunctionUID != "uid" {
+			t.Fatalf("unexpected entry %v: %+v", i, e)
+		}
+	}
+}
+
+func TestElasticsearchQuery(t *testing.T) {
+	until := time.Date(2021, 1, 2, 3, 4, 5, 6, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/"+elasticsearchIndex+"/_search" {
+			t.Errorf("unexpected request %v %v", r.Method, r.URL)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		for _, s := range []string{`"size":1000`, `"order":"asc"`, `"function_uid":"uid"`, `"lte":"2021-01-02T03:04:05.000000006Z"`} {
+			if !strings.Contains(string(body), s) {
+				t.Errorf("expected %v in request %s", s, body)
+			}
+		}
+		w.Write([]byte(`{"hits":{"hits":[
+			{"_source":{"@timestamp":"2021-01-02T03:04:05.000000001Z","log":"hello\n","function_uid":"uid","container":"hello"}}
+		]}}`))
+	}))
+	defer server.Close()
+
+	store, _ := MakeStore(SinkElasticsearch, server.URL, "", "")
+	entries, err := store.Query(&Query{FunctionUID: "uid", Until: until})
+	if err != nil {
+		t.Fatalf("error querying Elasticsearch: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Message != "hello" || entries[0].Container != "hello" ||
+		!entries[0].Timestamp.Equal(until.Add(-5)) {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// lokiStore queries the logs the logger pushes to Loki, as JSON lines
+	// labeled with the function, its namespace and UID, the pod, the
+	// container and the stream.
+	lokiStore struct {
+		url      string
+		username string
+		password string
+		client   *http.Client
+	}
+
+	lokiResponse struct {
+		Status string `json:"status"`
+		Data   struct {
+			Result []struct {
+				Stream map[string]string `json:"stream"`
+				// Values are the [<time in ns>, <line>] pairs of the
+				// stream.
+				Values [][2]string `json:"values"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+)
+
+func (s *lokiStore) Query(q *Query) ([]Entry, error) {
+	selector := fmt.Sprintf(`{function_uid=%q`, q.FunctionUID)
+	if len(q.Pod) > 0 {
+		selector += fmt.Sprintf(`,pod=%q`, q.Pod)
+	}
+	selector += "}"
+
+	values := url.Values{}
+	values.Set("query", selector)
+	values.Set("limit", strconv.Itoa(q.limit()))
+	// Loki queries the last hour with no start
+	if !q.Since.IsZero() {
+		values.Set("start", strconv.FormatInt(q.Since.UnixNano(), 10))
+	}
+	// the end is exclusive
+	if !q.Until.IsZero() {
+		values.Set("end", strconv.FormatInt(q.Until.UnixNano()+1, 10))
+	}
+	if q.Reverse {
+		values.Set("direction", "backward")
+	} else {
+		values.Set("direction", "forward")
+	}
+	req, err := http.NewRequest(http.MethodGet, s.url+"/loki/api/v1/query_range?"+values.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp lokiResponse
+	err = do(s.client, req, s.username, s.password, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status != "success" {
+		return nil, errors.Errorf("error querying Loki: status %q", resp.Status)
+	}
+
+	var entries []Entry
+	for _, stream := range resp.Data.Result {
+		for _, value := range stream.Values {
+			ns, err := strconv.ParseInt(value[0], 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid Loki entry time %q", value[0])
+			}
+			record := make(map[string]interface{}, len(stream.Stream)+1)
+			for k, v := range stream.Stream {
+				record[k] = v
+			}
+			// lines are JSON records, unless the logger was set up to
+			// push raw lines
+			if json.Unmarshal([]byte(value[1]), &record) != nil {
+				record["log"] = value[1]
+			}
+			entries = append(entries, recordEntry(record, time.Unix(0, ns)))
+		}
+	}
+	// the entries of each stream are sorted, not across streams
+	return sortEntries(entries, q.Reverse, q.limit()), nil
+}
+
+func (s *lokiStore) String() string {
+	return SinkLoki
+}
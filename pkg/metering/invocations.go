@@ -0,0 +1,137 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	genClientset "github.com/fission/fission/pkg/generated/clientset/versioned"
+)
+
+type (
+	// InvocationCounter counts the invocations of functions, and adds them
+	// to the usage of the functions every interval. A nil counter counts
+	// nothing.
+	InvocationCounter struct {
+		logger        *zap.Logger
+		fissionClient genClientset.Interface
+		interval      time.Duration
+
+		lock sync.Mutex
+		// counts are the invocations counted since the last sync, by
+		// function UID.
+		counts map[k8stypes.UID]*invocationCount
+	}
+
+	invocationCount struct {
+		function metav1.ObjectMeta
+		count    int64
+	}
+)
+
+// MakeInvocationCounter returns an invocation counter adding the
+// invocations of functions to their usage every interval, or nil if
+// interval is 0.
+func MakeInvocationCounter(logger *zap.Logger, fissionClient genClientset.Interface, interval time.Duration) *InvocationCounter {
+	if interval == 0 {
+		return nil
+	}
+	return &InvocationCounter{
+		logger:        logger.Named("invocation_counter"),
+		fissionClient: fissionClient,
+		interval:      interval,
+		counts:        make(map[k8stypes.UID]*invocationCount),
+	}
+}
+
+// Record counts an invocation of fn.
+func (c *InvocationCounter) Record(fn *fv1.Function) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ic, ok := c.counts[fn.ObjectMeta.UID]
+	if !ok {
+		ic = &invocationCount{
+			function: metav1.ObjectMeta{
+				Name:      fn.ObjectMeta.Name,
+				Namespace: fn.ObjectMeta.Namespace,
+				UID:       fn.ObjectMeta.UID,
+			},
+		}
+		c.counts[fn.ObjectMeta.UID] = ic
+	}
+	ic.count++
+}
+
+// Run adds the invocations counted to the usage of functions until ctx is
+// done.
+func (c *InvocationCounter) Run(ctx context.Context) {
+	if c == nil {
+		return
+	}
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := c.sync(time.Now())
+		if err != nil {
+			c.logger.Error("error adding invocations to function usage", zap.Error(err))
+		}
+	}
+}
+
+// Flush adds the invocations counted since the last sync to the usage of
+// functions, so that they are not lost on shutdown.
+func (c *InvocationCounter) Flush(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	return c.sync(time.Now())
+}
+
+// sync adds the invocations counted since the last sync to the usage of
+// functions. The invocations of functions that failed to be added are
+// dropped.
+func (c *InvocationCounter) sync(now time.Time) error {
+	c.lock.Lock()
+	counts := c.counts
+	c.counts = make(map[k8stypes.UID]*invocationCount)
+	c.lock.Unlock()
+
+	var lastErr error
+	for _, ic := range counts {
+		err := AddUsage(c.fissionClient, &ic.function, &fv1.ResourceUsage{Invocations: ic.count}, now)
+		if err != nil {
+			lastErr = errors.Wrapf(err, "error adding invocations of function %v/%v", ic.function.Namespace, ic.function.Name)
+		}
+	}
+	return lastErr
+}
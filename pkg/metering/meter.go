@@ -0,0 +1,278 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/expfmt"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	genClientset "github.com/fission/fission/pkg/generated/clientset/versioned"
+)
+
+const (
+	// DefaultInterval is how often the usage of functions is sampled and
+	// added to their FunctionUsage.
+	DefaultInterval = 30 * time.Second
+
+	// egressMetric is the cAdvisor counter of the bytes sent by pods, by
+	// network interface.
+	egressMetric = "container_network_transmit_bytes_total"
+)
+
+type (
+	// Meter samples the CPU and memory usage of the pods of functions from
+	// metrics-server, and the bytes they sent from the cAdvisor of their
+	// nodes, and adds them to the usage of the functions. A nil meter
+	// meters nothing.
+	Meter struct {
+		logger           *zap.Logger
+		fissionClient    genClientset.Interface
+		kubernetesClient kubernetes.Interface
+		metricsClient    metricsclient.Interface
+		interval         time.Duration
+
+		// sent are the bytes sent by each pod at the last sample, by
+		// namespace/name, for the egress to be counted as deltas.
+		sent map[string]int64
+	}
+
+	functionUsage struct {
+		function metav1.ObjectMeta
+		usage    fv1.ResourceUsage
+	}
+)
+
+// ParseInterval parses how often the usage of functions is sampled, 0 to
+// disable metering.
+func ParseInterval(s string) (time.Duration, error) {
+	if len(s) == 0 {
+		return DefaultInterval, nil
+	}
+	interval, err := time.ParseDuration(s)
+	if err != nil || interval < 0 {
+		return DefaultInterval, errors.Errorf("invalid metering interval %q", s)
+	}
+	return interval, nil
+}
+
+// MakeMeter returns a meter sampling the usage of functions every
+// interval, or nil if interval is 0.
+func MakeMeter(logger *zap.Logger, fissionClient genClientset.Interface, kubernetesClient kubernetes.Interface,
+	metricsClient metricsclient.Interface, interval time.Duration) *Meter {
+	if interval == 0 {
+		return nil
+	}
+	return &Meter{
+		logger:           logger.Named("meter"),
+		fissionClient:    fissionClient,
+		kubernetesClient: kubernetesClient,
+		metricsClient:    metricsClient,
+		interval:         interval,
+		sent:             make(map[string]int64),
+	}
+}
+
+// Run samples the usage of functions until ctx is done.
+func (m *Meter) Run(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := m.sample(time.Now())
+		if err != nil {
+			m.logger.Error("error metering function usage", zap.Error(err))
+		}
+	}
+}
+
+// sample adds the usage of the running pods of functions over the last
+// interval to the usage of their functions.
+func (m *Meter) sample(now time.Time) error {
+	pods, err := m.kubernetesClient.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+		LabelSelector: fv1.FUNCTION_UID,
+		FieldSelector: "status.phase=Running",
+	})
+	if err != nil {
+		return errors.Wrap(err, "error listing function pods")
+	}
+
+	podsByKey := make(map[string]*apiv1.Pod, len(pods.Items))
+	nodes := make(map[string]bool)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		podsByKey[podKey(pod.ObjectMeta.Namespace, pod.ObjectMeta.Name)] = pod
+		if len(pod.Spec.NodeName) > 0 {
+			nodes[pod.Spec.NodeName] = true
+		}
+	}
+
+	usages := make(map[k8stypes.UID]*functionUsage)
+	usageOf := func(pod *apiv1.Pod) *functionUsage {
+		uid := k8stypes.UID(pod.ObjectMeta.Labels[fv1.FUNCTION_UID])
+		fu, ok := usages[uid]
+		if !ok {
+			fu = &functionUsage{
+				function: metav1.ObjectMeta{
+					Name:      pod.ObjectMeta.Labels[fv1.FUNCTION_NAME],
+					Namespace: pod.ObjectMeta.Labels[fv1.FUNCTION_NAMESPACE],
+					UID:       uid,
+				},
+			}
+			usages[uid] = fu
+		}
+		return fu
+	}
+
+	// the usage reported by metrics-server is assumed to have lasted the
+	// whole interval
+	seconds := int64(m.interval / time.Second)
+	podMetrics, err := m.metricsClient.MetricsV1beta1().PodMetricses(metav1.NamespaceAll).List(metav1.ListOptions{
+		LabelSelector: fv1.FUNCTION_UID,
+	})
+	if err != nil {
+		m.logger.Warn("error listing function pod metrics - CPU and memory are not metered", zap.Error(err))
+	} else {
+		for _, pm := range podMetrics.Items {
+			pod, ok := podsByKey[podKey(pm.ObjectMeta.Namespace, pm.ObjectMeta.Name)]
+			if !ok {
+				continue
+			}
+			var milliCPU, memory int64
+			for _, c := range pm.Containers {
+				milliCPU += c.Usage.Cpu().MilliValue()
+				memory += c.Usage.Memory().Value()
+			}
+			fu := usageOf(pod)
+			fu.usage.CPUMillicoreSeconds += milliCPU * seconds
+			fu.usage.MemoryMegabyteSeconds += memory / 1e6 * seconds
+		}
+	}
+
+	sent := make(map[string]int64)
+	for node := range nodes {
+		data, err := m.kubernetesClient.CoreV1().RESTClient().Get().
+			Resource("nodes").Name(node).SubResource("proxy").Suffix("metrics/cadvisor").DoRaw()
+		if err != nil {
+			m.logger.Warn("error getting cAdvisor metrics - egress of its pods is not metered",
+				zap.String("node", node), zap.Error(err))
+			continue
+		}
+		nodeSent, err := parseSentBytes(bytes.NewReader(data))
+		if err != nil {
+			m.logger.Warn("error parsing cAdvisor metrics - egress of its pods is not metered",
+				zap.String("node", node), zap.Error(err))
+			continue
+		}
+		for key, n := range nodeSent {
+			sent[key] = n
+		}
+	}
+	for key, n := range sent {
+		pod, ok := podsByKey[key]
+		if !ok {
+			delete(sent, key)
+			continue
+		}
+		// the first sample of a pod is the baseline of the next ones, and
+		// a counter going back means the pod was restarted
+		if last, ok := m.sent[key]; ok && n >= last {
+			usageOf(pod).usage.EgressBytes += n - last
+		}
+	}
+	m.sent = sent
+
+	var lastErr error
+	for _, fu := range usages {
+		if len(fu.function.Name) == 0 || len(fu.function.Namespace) == 0 {
+			continue
+		}
+		err := AddUsage(m.fissionClient, &fu.function, &fu.usage, now)
+		if err != nil {
+			lastErr = errors.Wrapf(err, "error adding usage of function %v/%v", fu.function.Namespace, fu.function.Name)
+		}
+	}
+	return lastErr
+}
+
+// parseSentBytes returns the bytes sent by each pod, by namespace/name, from
+// the cAdvisor metrics of a node.
+func parseSentBytes(r io.Reader) (map[string]int64, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// the network of a pod is reported by its sandbox and by the cgroup
+	// of the pod as a whole, which are the same counters
+	byInterface := make(map[string]map[string]int64)
+	for _, metric := range families[egressMetric].GetMetric() {
+		var namespace, pod, iface string
+		for _, label := range metric.GetLabel() {
+			switch label.GetName() {
+			case "namespace":
+				namespace = label.GetValue()
+			case "pod", "pod_name":
+				pod = label.GetValue()
+			case "interface":
+				iface = label.GetValue()
+			}
+		}
+		if len(namespace) == 0 || len(pod) == 0 {
+			continue
+		}
+		key := podKey(namespace, pod)
+		if byInterface[key] == nil {
+			byInterface[key] = make(map[string]int64)
+		}
+		n := int64(metric.GetCounter().GetValue())
+		if n > byInterface[key][iface] {
+			byInterface[key][iface] = n
+		}
+	}
+
+	sent := make(map[string]int64, len(byInterface))
+	for key, ifaces := range byInterface {
+		for _, n := range ifaces {
+			sent[key] += n
+		}
+	}
+	return sent, nil
+}
+
+func podKey(namespace string, name string) string {
+	return namespace + "/" + name
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metering accounts for the resources functions use: executor
+// meters the CPU, memory and network egress of their pods, router counts
+// their invocations, and both add them to the FunctionUsage of each
+// function.
+package metering
+
+import (
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	genClientset "github.com/fission/fission/pkg/generated/clientset/versioned"
+)
+
+const addUsageRetries = 5
+
+// AddUsage adds usage to the FunctionUsage of a function, creating it if
+// needed. The usage of a deleted function of the same name is reset.
+func AddUsage(fissionClient genClientset.Interface, fn *metav1.ObjectMeta, usage *fv1.ResourceUsage, now time.Time) error {
+	client := fissionClient.CoreV1().FunctionUsages(fn.Namespace)
+
+	var err error
+	for i := 0; i < addUsageRetries; i++ {
+		var fu *fv1.FunctionUsage
+		fu, err = client.Get(fn.Name, metav1.GetOptions{})
+		exists := err == nil
+		if k8serrors.IsNotFound(err) {
+			fu = &fv1.FunctionUsage{}
+		} else if err != nil {
+			return err
+		}
+
+		if !ownedBy(&fu.ObjectMeta, fn.UID) {
+			fu.Usage = fv1.ResourceUsage{Since: metav1.NewTime(now)}
+		}
+		fu.ObjectMeta = metav1.ObjectMeta{
+			Name:            fn.Name,
+			Namespace:       fn.Namespace,
+			ResourceVersion: fu.ObjectMeta.ResourceVersion,
+			Labels:          fu.ObjectMeta.Labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: fv1.SchemeGroupVersion.String(),
+					Kind:       "Function",
+					Name:       fn.Name,
+					UID:        fn.UID,
+				},
+			},
+		}
+		addUsage(&fu.Usage, usage)
+		fu.Usage.LastUpdated = metav1.NewTime(now)
+
+		if !exists {
+			_, err = client.Create(fu)
+		} else {
+			_, err = client.Update(fu)
+		}
+		if err == nil {
+			return nil
+		}
+		if !k8serrors.IsConflict(err) && !k8serrors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// addUsage adds the counters of usage to total.
+func addUsage(total *fv1.ResourceUsage, usage *fv1.ResourceUsage) {
+	total.CPUMillicoreSeconds += usage.CPUMillicoreSeconds
+	total.MemoryMegabyteSeconds += usage.MemoryMegabyteSeconds
+	total.Invocations += usage.Invocations
+	total.EgressBytes += usage.EgressBytes
+}
+
+// ownedBy returns whether an object is owned by the object of a UID.
+func ownedBy(m *metav1.ObjectMeta, uid k8stypes.UID) bool {
+	for _, ref := range m.OwnerReferences {
+		if ref.UID == uid {
+			return true
+		}
+	}
+	return false
+}
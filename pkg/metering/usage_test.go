@@ -0,0 +1,131 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/generated/clientset/versioned/fake"
+)
+
+func TestAddUsage(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	fn := &metav1.ObjectMeta{Name: "hello", Namespace: metav1.NamespaceDefault, UID: "5e0b7a2e-1b1c-4c39-8f4e-2a7d0f3f0c11"}
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	stored := func() *fv1.FunctionUsage {
+		fu, err := client.CoreV1().FunctionUsages(fn.Namespace).Get(fn.Name, metav1.GetOptions{})
+		assert.NoError(t, err)
+		return fu
+	}
+
+	assert.NoError(t, AddUsage(client, fn, &fv1.ResourceUsage{CPUMillicoreSeconds: 3000, Invocations: 2}, start))
+	assert.NoError(t, AddUsage(client, fn, &fv1.ResourceUsage{MemoryMegabyteSeconds: 640, Invocations: 1, EgressBytes: 512}, start.Add(time.Minute)))
+	fu := stored()
+	assert.Equal(t, fv1.ResourceUsage{
+		Since:                 metav1.NewTime(start),
+		LastUpdated:           metav1.NewTime(start.Add(time.Minute)),
+		CPUMillicoreSeconds:   3000,
+		MemoryMegabyteSeconds: 640,
+		Invocations:           3,
+		EgressBytes:           512,
+	}, fu.Usage)
+	assert.True(t, ownedBy(&fu.ObjectMeta, fn.UID))
+
+	// a function recreated with the same name starts over
+	recreated := fn.DeepCopy()
+	recreated.UID = "0c9d1f6a-7a43-4d1c-9d0e-6f1b8a2b3c44"
+	assert.NoError(t, AddUsage(client, recreated, &fv1.ResourceUsage{Invocations: 1}, start.Add(time.Hour)))
+	fu = stored()
+	assert.Equal(t, int64(1), fu.Usage.Invocations)
+	assert.Zero(t, fu.Usage.CPUMillicoreSeconds)
+	assert.Equal(t, start.Add(time.Hour), fu.Usage.Since.Time.UTC())
+	assert.False(t, ownedBy(&fu.ObjectMeta, fn.UID))
+}
+
+func TestInvocationCounter(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	c := MakeInvocationCounter(zap.NewNop(), client, time.Minute)
+	fn := &fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: metav1.NamespaceDefault, UID: "5e0b7a2e-1b1c-4c39-8f4e-2a7d0f3f0c11"},
+	}
+
+	c.Record(fn)
+	c.Record(fn)
+	assert.NoError(t, c.sync(time.Now()))
+	c.Record(fn)
+	assert.NoError(t, c.sync(time.Now()))
+	// nothing counted since the last sync adds nothing
+	assert.NoError(t, c.sync(time.Now()))
+
+	fu, err := client.CoreV1().FunctionUsages(fn.ObjectMeta.Namespace).Get(fn.ObjectMeta.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), fu.Usage.Invocations)
+
+	// nothing is counted with metering disabled
+	var disabled *InvocationCounter
+	disabled.Record(fn)
+	assert.NoError(t, disabled.Flush(context.Background()))
+	assert.Nil(t, MakeInvocationCounter(zap.NewNop(), client, 0))
+}
+
+func TestParseSentBytes(t *testing.T) {
+	metrics := `# HELP container_network_transmit_bytes_total Cumulative count of bytes transmitted
+# TYPE container_network_transmit_bytes_total counter
+container_network_transmit_bytes_total{container="",id="/kubepods/pod1",interface="eth0",namespace="fission-function",pod="hello-abc"} 1000 1614600000000
+container_network_transmit_bytes_total{container="POD",id="/kubepods/pod1/sandbox",interface="eth0",namespace="fission-function",pod="hello-abc"} 990 1614600000000
+container_network_transmit_bytes_total{container="POD",id="/kubepods/pod1/sandbox",interface="eth1",namespace="fission-function",pod="hello-abc"} 24 1614600000000
+container_network_transmit_bytes_total{container="POD",id="/kubepods/pod2/sandbox",interface="eth0",namespace="fission-function",pod_name="world-def"} 7 1614600000000
+container_network_transmit_bytes_total{id="/",interface="eth0"} 123456 1614600000000
+# HELP container_network_receive_bytes_total Cumulative count of bytes received
+# TYPE container_network_receive_bytes_total counter
+container_network_receive_bytes_total{container="",id="/kubepods/pod1",interface="eth0",namespace="fission-function",pod="hello-abc"} 5000 1614600000000
+`
+	sent, err := parseSentBytes(strings.NewReader(metrics))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{
+		"fission-function/hello-abc": 1024,
+		"fission-function/world-def": 7,
+	}, sent)
+
+	_, err = parseSentBytes(strings.NewReader("not metrics {"))
+	assert.Error(t, err)
+}
+
+func TestParseInterval(t *testing.T) {
+	interval, err := ParseInterval("")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultInterval, interval)
+
+	interval, err = ParseInterval("0s")
+	assert.NoError(t, err)
+	assert.Zero(t, interval)
+
+	interval, err = ParseInterval("1m")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, interval)
+
+	_, err = ParseInterval("-1m")
+	assert.Error(t, err)
+}
@@ -0,0 +1,304 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtrigger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/mqtrigger/messageQueue"
+)
+
+const (
+	// ConsumerTriggerEnv is set on the consumers of an autoscaled trigger
+	// to the trigger, as namespace/name, for them to consume its messages
+	// only.
+	ConsumerTriggerEnv = "MESSAGE_QUEUE_TRIGGER"
+
+	// consumerTriggerLabel labels the consumer deployments with the UID of
+	// their trigger.
+	consumerTriggerLabel = "messagequeuetrigger"
+
+	// defaults of the scaling settings of a trigger, the same as KEDA's
+	defaultPollingInterval = 30
+	defaultCooldownPeriod  = 300
+	defaultMinReplicaCount = 0
+	defaultMaxReplicaCount = 100
+)
+
+type (
+	// ConsumerScaler runs the consumers of autoscaled triggers in
+	// deployments of their own, cloned from the deployment of the message
+	// queue trigger manager, and scales them with the lag of the triggers.
+	ConsumerScaler struct {
+		logger      *zap.Logger
+		kubeClient  kubernetes.Interface
+		namespace   string
+		mqType      fv1.MessageQueueType
+		lagReporter messageQueue.LagReporter
+		// scaling is the scaling state by trigger UID
+		scaling map[string]*consumerScaling
+	}
+
+	consumerScaling struct {
+		lastPoll    time.Time
+		lastScaleUp time.Time
+	}
+)
+
+// IsAutoscaled returns whether the consumers of a trigger are autoscaled.
+func IsAutoscaled(trigger *fv1.MessageQueueTrigger) bool {
+	return trigger.Spec.Autoscaling != nil
+}
+
+// MakeConsumerScaler returns a ConsumerScaler of the consumer deployments in
+// namespace, the namespace of the message queue trigger manager.
+func MakeConsumerScaler(logger *zap.Logger, kubeClient kubernetes.Interface, namespace string,
+	mqType fv1.MessageQueueType, lagReporter messageQueue.LagReporter) *ConsumerScaler {
+	return &ConsumerScaler{
+		logger:      logger.Named("consumer_scaler"),
+		kubeClient:  kubeClient,
+		namespace:   namespace,
+		mqType:      mqType,
+		lagReporter: lagReporter,
+		scaling:     make(map[string]*consumerScaling),
+	}
+}
+
+// Sync creates the consumer deployments of new autoscaled triggers, scales
+// those of the others whose polling interval has passed, and deletes those
+// of the triggers no longer autoscaled, by cache key.
+func (cs *ConsumerScaler) Sync(triggers map[string]*fv1.MessageQueueTrigger) error {
+	deployments, err := cs.kubeClient.AppsV1().Deployments(cs.namespace).List(metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("svc=mqtrigger,messagequeue=%v,%v", cs.mqType, consumerTriggerLabel),
+	})
+	if err != nil {
+		return errors.Wrap(err, "error listing consumer deployments")
+	}
+	consumers := make(map[string]*appsv1.Deployment)
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		consumers[d.ObjectMeta.Labels[consumerTriggerLabel]] = d
+	}
+
+	now := time.Now()
+	autoscaled := make(map[string]bool)
+	for _, trigger := range triggers {
+		uid := string(trigger.ObjectMeta.UID)
+		autoscaled[uid] = true
+
+		d, ok := consumers[uid]
+		if !ok {
+			err = cs.create(trigger)
+			if err != nil {
+				cs.logger.Error("failed to create consumer deployment", zap.Error(err), zap.String("trigger_name", trigger.ObjectMeta.Name))
+			}
+			continue
+		}
+		err = cs.scale(trigger, d, now)
+		if err != nil {
+			cs.logger.Error("failed to scale consumer deployment", zap.Error(err), zap.String("trigger_name", trigger.ObjectMeta.Name))
+		}
+	}
+
+	// the deployments of triggers deleted while the manager was down too
+	for uid, d := range consumers {
+		if autoscaled[uid] {
+			continue
+		}
+		deletePolicy := metav1.DeletePropagationForeground
+		err = cs.kubeClient.AppsV1().Deployments(cs.namespace).Delete(d.ObjectMeta.Name, &metav1.DeleteOptions{
+			PropagationPolicy: &deletePolicy,
+		})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			cs.logger.Error("failed to delete consumer deployment", zap.Error(err), zap.String("deployment", d.ObjectMeta.Name))
+			continue
+		}
+		delete(cs.scaling, uid)
+		cs.logger.Info("consumer deployment deleted", zap.String("deployment", d.ObjectMeta.Name))
+	}
+	return nil
+}
+
+// create creates the consumer deployment of a trigger, with a replica at
+// least, for the message queue to track the position of its consumers.
+func (cs *ConsumerScaler) create(trigger *fv1.MessageQueueTrigger) error {
+	template, err := cs.managerDeployment()
+	if err != nil {
+		return err
+	}
+	_, max, _, _ := scalingSettings(trigger)
+	min := int32(1)
+	if max < min {
+		min = max
+	}
+	if trigger.Spec.MinReplicaCount != nil && *trigger.Spec.MinReplicaCount > min {
+		min = *trigger.Spec.MinReplicaCount
+	}
+
+	d := consumerDeployment(template, trigger, min)
+	_, err = cs.kubeClient.AppsV1().Deployments(cs.namespace).Create(d)
+	if err != nil {
+		return err
+	}
+	cs.logger.Info("consumer deployment created", zap.String("deployment", d.ObjectMeta.Name), zap.String("trigger_name", trigger.ObjectMeta.Name))
+	return nil
+}
+
+// scale scales the consumer deployment of a trigger with its lag, once its
+// polling interval has passed.
+func (cs *ConsumerScaler) scale(trigger *fv1.MessageQueueTrigger, d *appsv1.Deployment, now time.Time) error {
+	uid := string(trigger.ObjectMeta.UID)
+	state, ok := cs.scaling[uid]
+	if !ok {
+		// a restarted manager waits a cooldown period before scaling down
+		state = &consumerScaling{lastScaleUp: now}
+		cs.scaling[uid] = state
+	}
+	min, max, pollingInterval, cooldownPeriod := scalingSettings(trigger)
+	if now.Sub(state.lastPoll) < pollingInterval {
+		return nil
+	}
+	state.lastPoll = now
+
+	lag, err := cs.lagReporter.Lag(trigger)
+	if err != nil {
+		return errors.Wrap(err, "error getting lag of trigger")
+	}
+	replicas := desiredReplicas(lag, trigger.Spec.Autoscaling.TargetLag, min, max)
+	current := int32(1)
+	if d.Spec.Replicas != nil {
+		current = *d.Spec.Replicas
+	}
+	if replicas == current || (replicas < current && now.Sub(state.lastScaleUp) < cooldownPeriod) {
+		return nil
+	}
+
+	d.Spec.Replicas = &replicas
+	_, err = cs.kubeClient.AppsV1().Deployments(cs.namespace).Update(d)
+	if err != nil {
+		return err
+	}
+	if replicas > current {
+		state.lastScaleUp = now
+	}
+	cs.logger.Info("consumer deployment scaled",
+		zap.String("deployment", d.ObjectMeta.Name),
+		zap.String("trigger_name", trigger.ObjectMeta.Name),
+		zap.Int64("lag", lag),
+		zap.Int32("from_replicas", current),
+		zap.Int32("to_replicas", replicas))
+	return nil
+}
+
+// managerDeployment returns the deployment of the message queue trigger
+// manager, the template of the consumer deployments.
+func (cs *ConsumerScaler) managerDeployment() (*appsv1.Deployment, error) {
+	deployments, err := cs.kubeClient.AppsV1().Deployments(cs.namespace).List(metav1.ListOptions{
+		LabelSelector: labels.Set{"svc": "mqtrigger", "messagequeue": string(cs.mqType)}.String(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing message queue trigger deployments")
+	}
+	for i := range deployments.Items {
+		if _, ok := deployments.Items[i].ObjectMeta.Labels[consumerTriggerLabel]; !ok {
+			return &deployments.Items[i], nil
+		}
+	}
+	return nil, errors.Errorf("no deployment of the %v message queue trigger manager in namespace %q", cs.mqType, cs.namespace)
+}
+
+// consumerDeployment returns the consumer deployment of a trigger, cloned
+// from the deployment of the message queue trigger manager.
+func consumerDeployment(template *appsv1.Deployment, trigger *fv1.MessageQueueTrigger, replicas int32) *appsv1.Deployment {
+	uid := string(trigger.ObjectMeta.UID)
+	consumerLabels := map[string]string{
+		"svc":                "mqtrigger",
+		"messagequeue":       template.ObjectMeta.Labels["messagequeue"],
+		consumerTriggerLabel: uid,
+	}
+
+	podTemplate := template.Spec.Template.DeepCopy()
+	podTemplate.ObjectMeta.Labels = consumerLabels
+	for i := range podTemplate.Spec.Containers {
+		podTemplate.Spec.Containers[i].Env = append(podTemplate.Spec.Containers[i].Env, apiv1.EnvVar{
+			Name:  ConsumerTriggerEnv,
+			Value: crd.CacheKey(&trigger.ObjectMeta),
+		})
+	}
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("%v-%v", template.ObjectMeta.Name, uid),
+			Labels: consumerLabels,
+			Annotations: map[string]string{
+				"fission.io/messagequeuetrigger": crd.CacheKey(&trigger.ObjectMeta),
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: consumerLabels,
+			},
+			Template: *podTemplate,
+		},
+	}
+}
+
+// scalingSettings returns the scaling settings of a trigger, or their
+// defaults.
+func scalingSettings(trigger *fv1.MessageQueueTrigger) (min int32, max int32, pollingInterval time.Duration, cooldownPeriod time.Duration) {
+	min, max = defaultMinReplicaCount, defaultMaxReplicaCount
+	pollingInterval, cooldownPeriod = defaultPollingInterval*time.Second, defaultCooldownPeriod*time.Second
+	if trigger.Spec.MinReplicaCount != nil {
+		min = *trigger.Spec.MinReplicaCount
+	}
+	if trigger.Spec.MaxReplicaCount != nil {
+		max = *trigger.Spec.MaxReplicaCount
+	}
+	if trigger.Spec.PollingInterval != nil {
+		pollingInterval = time.Duration(*trigger.Spec.PollingInterval) * time.Second
+	}
+	if trigger.Spec.CooldownPeriod != nil {
+		cooldownPeriod = time.Duration(*trigger.Spec.CooldownPeriod) * time.Second
+	}
+	return min, max, pollingInterval, cooldownPeriod
+}
+
+// desiredReplicas returns the replicas to consume lag messages, targetLag
+// per replica, between min and max.
+func desiredReplicas(lag int64, targetLag int64, min int32, max int32) int32 {
+	replicas := (lag + targetLag - 1) / targetLag
+	if replicas < int64(min) {
+		return min
+	}
+	if replicas > int64(max) {
+		return max
+	}
+	return int32(replicas)
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtrigger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+type fakeLagReporter struct {
+	lag int64
+}
+
+func (r *fakeLagReporter) Lag(trigger *fv1.MessageQueueTrigger) (int64, error) {
+	return r.lag, nil
+}
+
+func TestDesiredReplicas(t *testing.T) {
+	tests := []struct {
+		lag      int64
+		min, max int32
+		want     int32
+	}{
+		{lag: 0, min: 0, max: 10, want: 0},
+		{lag: 0, min: 2, max: 10, want: 2},
+		{lag: 1, min: 0, max: 10, want: 1},
+		{lag: 100, min: 0, max: 10, want: 1},
+		{lag: 101, min: 0, max: 10, want: 2},
+		{lag: 5000, min: 0, max: 10, want: 10},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, desiredReplicas(tt.lag, 100, tt.min, tt.max), "lag %v", tt.lag)
+	}
+}
+
+func TestConsumerScaler(t *testing.T) {
+	labels := map[string]string{"svc": "mqtrigger", "messagequeue": "redis-streams"}
+	manager := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "mqtrigger-redis-streams", Namespace: "fission", Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: apiv1.PodSpec{
+					Containers: []apiv1.Container{{Name: "mqtrigger", Image: "fission/fission-bundle"}},
+				},
+			},
+		},
+	}
+	kubeClient := fake.NewSimpleClientset(manager)
+	lagReporter := &fakeLagReporter{}
+	cs := MakeConsumerScaler(zap.NewNop(), kubeClient, "fission", fv1.MessageQueueTypeRedisStreams, lagReporter)
+
+	pollingInterval, cooldownPeriod, maxReplicaCount := int32(0), int32(0), int32(5)
+	trigger := &fv1.MessageQueueTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "default", UID: "0d5c6ab5"},
+		Spec: fv1.MessageQueueTriggerSpec{
+			MessageQueueType: fv1.MessageQueueTypeRedisStreams,
+			Topic:            "orders",
+			PollingInterval:  &pollingInterval,
+			CooldownPeriod:   &cooldownPeriod,
+			MaxReplicaCount:  &maxReplicaCount,
+			Autoscaling:      &fv1.MessageQueueTriggerAutoscaling{TargetLag: 10},
+		},
+	}
+	triggers := map[string]*fv1.MessageQueueTrigger{"default/orders": trigger}
+
+	replicas := func() int32 {
+		d, err := kubeClient.AppsV1().Deployments("fission").Get("mqtrigger-redis-streams-0d5c6ab5", metav1.GetOptions{})
+		assert.NoError(t, err)
+		return *d.Spec.Replicas
+	}
+
+	// the consumers start with a replica, consuming the trigger only
+	assert.NoError(t, cs.Sync(triggers))
+	d, err := kubeClient.AppsV1().Deployments("fission").Get("mqtrigger-redis-streams-0d5c6ab5", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), *d.Spec.Replicas)
+	assert.Equal(t, "0d5c6ab5", d.Spec.Template.ObjectMeta.Labels[consumerTriggerLabel])
+	assert.Equal(t, []apiv1.EnvVar{{Name: ConsumerTriggerEnv, Value: "default/orders"}}, d.Spec.Template.Spec.Containers[0].Env)
+
+	lagReporter.lag = 35
+	assert.NoError(t, cs.Sync(triggers))
+	assert.Equal(t, int32(4), replicas())
+
+	lagReporter.lag = 1000
+	assert.NoError(t, cs.Sync(triggers))
+	assert.Equal(t, maxReplicaCount, replicas())
+
+	// scaling down waits for the cooldown period after scaling up
+	cooldownPeriod = 3600
+	lagReporter.lag = 0
+	assert.NoError(t, cs.Sync(triggers))
+	assert.Equal(t, maxReplicaCount, replicas())
+
+	cs.scaling["0d5c6ab5"].lastScaleUp = time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, cs.Sync(triggers))
+	assert.Equal(t, int32(0), replicas())
+
+	// the consumers of triggers no longer autoscaled are deleted
+	assert.NoError(t, cs.Sync(nil))
+	_, err = kubeClient.AppsV1().Deployments("fission").Get("mqtrigger-redis-streams-0d5c6ab5", metav1.GetOptions{})
+	assert.Error(t, err)
+	_, err = kubeClient.AppsV1().Deployments("fission").Get("mqtrigger-redis-streams", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
@@ -49,7 +49,8 @@ func init() {
 const (
 	// AzureQueuePollingInterval is the polling interval (default is 1 minute).
 	AzureQueuePollingInterval = time.Minute
-	// AzureQueueRetryLimit is the limit for attempts to retry invoking a function.
+	// AzureQueueRetryLimit is the limit for attempts to retry invoking a function,
+	// unless the trigger sets a retry policy.
 	AzureQueueRetryLimit = 3
 	// AzureMessageFetchCount is the number of messages to fetch at a time.
 	AzureMessageFetchCount = 10
@@ -76,10 +77,14 @@ type AzureStorageConnection struct {
 // AzureQueueSubscription represents an Azure storage message queue subscription.
 type AzureQueueSubscription struct {
 	queue           AzureQueue
+	triggerName     string
 	queueName       string
 	outputQueueName string
 	functionURL     string
 	contentType     string
+	cloudEvents     fv1.CloudEventsMode
+	limiter         messageQueue.Limiter
+	retry           *messageQueue.RetryPolicy
 	unsubscribe     chan bool
 	done            chan bool
 }
@@ -226,8 +231,17 @@ func (asc AzureStorageConnection) Subscribe(trigger *fv1.MessageQueueTrigger) (m
 		return nil, fmt.Errorf("unsupported function reference type (%v) for trigger %q", trigger.Spec.FunctionReference.Type, trigger.ObjectMeta.Name)
 	}
 
+	retry, err := messageQueue.NewRetryPolicy(trigger)
+	if err != nil {
+		return nil, err
+	}
+	if trigger.Spec.RetryPolicy == nil {
+		retry.MaxAttempts = AzureQueueRetryLimit + 1
+	}
+
 	subscription := &AzureQueueSubscription{
 		queue:           asc.service.GetQueue(trigger.Spec.Topic),
+		triggerName:     trigger.ObjectMeta.Name,
 		queueName:       trigger.Spec.Topic,
 		outputQueueName: trigger.Spec.ResponseTopic,
 		// with the addition of multi-tenancy, the users can create functions in any namespace. however,
@@ -235,6 +249,9 @@ func (asc AzureStorageConnection) Subscribe(trigger *fv1.MessageQueueTrigger) (m
 		// so essentially, function namespace = trigger namespace.
 		functionURL: asc.routerURL + "/" + strings.TrimPrefix(utils.UrlForFunction(trigger.Spec.FunctionReference.Name, trigger.ObjectMeta.Namespace), "/"),
 		contentType: trigger.Spec.ContentType,
+		cloudEvents: trigger.Spec.CloudEvents,
+		limiter:     messageQueue.NewLimiter(trigger),
+		retry:       retry,
 		unsubscribe: make(chan bool),
 		done:        make(chan bool),
 	}
@@ -310,8 +327,10 @@ func pollAzureQueueSubscription(conn AzureStorageConnection, sub *AzureQueueSubs
 
 		wg.Add(len(messages))
 		for _, msg := range messages {
+			sub.limiter.Acquire()
 			go func(conn AzureStorageConnection, sub *AzureQueueSubscription, msg AzureMessage) {
 				defer wg.Done()
+				defer sub.limiter.Release()
 				invokeTriggeredFunction(conn, sub, msg)
 			}(conn, sub, msg)
 		}
@@ -323,9 +342,10 @@ func invokeTriggeredFunction(conn AzureStorageConnection, sub *AzureQueueSubscri
 
 	conn.logger.Info("making HTTP request to invoke function", zap.String("function_url", sub.functionURL))
 
-	for i := 0; i <= AzureQueueRetryLimit; i++ {
+	for i := 0; i < sub.retry.MaxAttempts; i++ {
 		if i > 0 {
 			conn.logger.Info("retrying function invocation", zap.Int("retry", i), zap.String("function_url", sub.functionURL))
+			time.Sleep(sub.retry.Delay(i))
 		}
 		request, err := http.NewRequest("POST", sub.functionURL, bytes.NewReader(message.Bytes()))
 		if err != nil {
@@ -340,7 +360,14 @@ func invokeTriggeredFunction(conn AzureStorageConnection, sub *AzureQueueSubscri
 		if i > 0 {
 			request.Header.Set("X-Fission-MQTrigger-RetryCount", strconv.Itoa(i))
 		}
+		request.Header.Set(messageQueue.HeaderAttempt, strconv.Itoa(i+1))
 		request.Header.Set("Content-Type", sub.contentType)
+		request.Header.Set(fv1.HeaderTriggerType, fv1.TriggerTypeMessageQueue)
+		request.Header.Set(fv1.HeaderTriggerName, sub.triggerName)
+		if len(sub.cloudEvents) > 0 {
+			request.Header.Set(fv1.HeaderCloudEventsMode, string(sub.cloudEvents))
+		}
+		request.Header.Set(fv1.HeaderPriority, string(fv1.InvocationPriorityBatch))
 
 		response, err := conn.httpClient.Do(request)
 		if err != nil {
@@ -389,10 +416,14 @@ func invokeTriggeredFunction(conn AzureStorageConnection, sub *AzureQueueSubscri
 	}
 
 	conn.logger.Error("function invocation retired too many times - moving message to poison queue",
-		zap.Int("retry_limit", AzureQueueRetryLimit),
+		zap.Int("retry_limit", sub.retry.MaxAttempts-1),
 		zap.String("function_url", sub.functionURL))
 
+	// the dead-letter topic of the retry policy of the trigger, if any
 	poisonQueueName := sub.queueName + AzurePoisonQueueSuffix
+	if len(sub.retry.DeadLetterTopic) > 0 {
+		poisonQueueName = sub.retry.DeadLetterTopic
+	}
 	poisonQueue := conn.service.GetQueue(poisonQueueName)
 	err := poisonQueue.Create(nil)
 	if err != nil {
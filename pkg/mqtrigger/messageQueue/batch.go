@@ -0,0 +1,290 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messageQueue
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/utils"
+)
+
+const defaultBatchWindow = time.Second
+
+type (
+	// BatchMessage is a message of a batch, along with how to settle it
+	// once the function has been invoked with the batch.
+	BatchMessage struct {
+		Body []byte
+		// Ack settles the message after the function handled the batch.
+		Ack func()
+		// Nack settles the message after the function failed on the batch.
+		Nack func()
+	}
+
+	// PublishFunc publishes body to a topic of a trigger: the response of
+	// the function to a batch to the response topic, or its error to the
+	// error topic.
+	PublishFunc func(topic string, body []byte, contentType string) error
+
+	// Batcher invokes the function of a trigger with the messages added to
+	// it in batches, of up to the batch size of the trigger or the messages
+	// added within the batch window after the first one, one batch at a
+	// time. Adding messages blocks while a full batch waits to be invoked.
+	Batcher struct {
+		logger  *zap.Logger
+		trigger *fv1.MessageQueueTrigger
+		url     string
+		size    int
+		window  time.Duration
+		retry   *RetryPolicy
+		publish PublishFunc
+
+		lock       sync.Mutex
+		pending    []BatchMessage
+		generation int
+		closed     bool
+		batches    chan []BatchMessage
+		done       chan struct{}
+	}
+)
+
+// IsBatched returns whether the function of a trigger is invoked with
+// batches of messages.
+func IsBatched(trigger *fv1.MessageQueueTrigger) bool {
+	return trigger.Spec.BatchSize > 1
+}
+
+// NewBatcher returns a Batcher of the messages of a trigger, publishing to
+// its response and error topics with publish.
+func NewBatcher(logger *zap.Logger, routerUrl string, trigger *fv1.MessageQueueTrigger, publish PublishFunc) (*Batcher, error) {
+	// Support other function ref types
+	if trigger.Spec.FunctionReference.Type != fv1.FunctionReferenceTypeFunctionName {
+		return nil, errors.Errorf("unsupported function reference type %q for trigger", trigger.Spec.FunctionReference.Type)
+	}
+
+	window := defaultBatchWindow
+	if len(trigger.Spec.BatchWindow) > 0 {
+		var err error
+		window, err = time.ParseDuration(trigger.Spec.BatchWindow)
+		if err != nil || window <= 0 {
+			return nil, errors.Errorf("not a valid batch window: %q", trigger.Spec.BatchWindow)
+		}
+	}
+
+	retry, err := NewRetryPolicy(trigger)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Batcher{
+		logger:  logger.Named("batcher").With(zap.String("trigger", trigger.ObjectMeta.Name)),
+		trigger: trigger,
+		url:     routerUrl + "/" + strings.TrimPrefix(utils.UrlForFunction(trigger.Spec.FunctionReference.Name, trigger.ObjectMeta.Namespace), "/"),
+		size:    trigger.Spec.BatchSize,
+		window:  window,
+		retry:   retry,
+		publish: publish,
+		batches: make(chan []BatchMessage),
+		done:    make(chan struct{}),
+	}
+	go b.run()
+	return b, nil
+}
+
+// Add adds a message to the open batch, invoking the function with the
+// batch if it's full.
+func (b *Batcher) Add(msg BatchMessage) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		// left for redelivery
+		if msg.Nack != nil {
+			msg.Nack()
+		}
+		return
+	}
+
+	b.pending = append(b.pending, msg)
+	if len(b.pending) == 1 {
+		generation := b.generation
+		time.AfterFunc(b.window, func() {
+			b.lock.Lock()
+			defer b.lock.Unlock()
+			// the batch may have been full before its window
+			if !b.closed && b.generation == generation {
+				b.flush()
+			}
+		})
+	}
+	if len(b.pending) >= b.size {
+		b.flush()
+	}
+}
+
+// Close invokes the function with the open batch, and waits for the
+// batches handed over to be handled.
+func (b *Batcher) Close() {
+	b.lock.Lock()
+	if !b.closed {
+		b.closed = true
+		if len(b.pending) > 0 {
+			b.flush()
+		}
+		close(b.batches)
+	}
+	b.lock.Unlock()
+	<-b.done
+}
+
+// flush hands the open batch over to be invoked, with the lock held.
+func (b *Batcher) flush() {
+	b.batches <- b.pending
+	b.pending = nil
+	b.generation++
+}
+
+func (b *Batcher) run() {
+	defer close(b.done)
+	for batch := range b.batches {
+		b.invoke(batch)
+	}
+}
+
+// invoke invokes the function with a batch, and settles its messages.
+func (b *Batcher) invoke(batch []BatchMessage) {
+	trigger := b.trigger
+
+	body, err := BatchBody(batch)
+	if err != nil {
+		b.fail(batch, []byte(fmt.Sprintf("error encoding batch: %v", err)))
+		return
+	}
+
+	headers := map[string]string{
+		"X-Fission-MQTrigger-Topic":      trigger.Spec.Topic,
+		"X-Fission-MQTrigger-RespTopic":  trigger.Spec.ResponseTopic,
+		"X-Fission-MQTrigger-ErrorTopic": trigger.Spec.ErrorTopic,
+		"X-Fission-MQTrigger-BatchSize":  strconv.Itoa(len(batch)),
+		"Content-Type":                   "application/json",
+		fv1.HeaderTriggerType:            fv1.TriggerTypeMessageQueue,
+		fv1.HeaderTriggerName:            trigger.ObjectMeta.Name,
+		fv1.HeaderCloudEventsMode:        string(trigger.Spec.CloudEvents),
+		fv1.HeaderPriority:               string(fv1.InvocationPriorityBatch),
+	}
+
+	b.logger.Debug("making HTTP request", zap.String("url", b.url), zap.Int("batch_size", len(batch)))
+
+	resp, err := b.retry.Invoke(b.logger, func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", b.url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		return req, nil
+	})
+	if err != nil {
+		b.fail(batch, []byte(fmt.Sprintf("request exceed retries: %v: %v", b.retry.MaxAttempts-1, err)))
+		return
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		b.fail(batch, []byte(fmt.Sprintf("error reading function invocation response: %v", err)))
+		return
+	}
+	b.logger.Debug("got response from function invocation",
+		zap.String("function_url", b.url),
+		zap.String("body", string(respBody)))
+
+	if resp.StatusCode != http.StatusOK {
+		b.fail(batch, respBody)
+		return
+	}
+
+	if len(trigger.Spec.ResponseTopic) > 0 {
+		err = b.publish(trigger.Spec.ResponseTopic, respBody, resp.Header.Get("Content-Type"))
+		if err != nil {
+			b.logger.Error("failed to publish message with function invocation response to topic",
+				zap.Error(err),
+				zap.String("topic", trigger.Spec.ResponseTopic))
+		}
+	}
+	for _, msg := range batch {
+		if msg.Ack != nil {
+			msg.Ack()
+		}
+	}
+}
+
+// fail publishes the error of a batch the function failed on to the error
+// topic, and settles its messages: acked once published to the dead-letter
+// topic, or else nacked.
+func (b *Batcher) fail(batch []BatchMessage, body []byte) {
+	b.logger.Error("function invocation failed for batch",
+		zap.String("function_url", b.url),
+		zap.Int("batch_size", len(batch)),
+		zap.String("error", string(body)))
+
+	if len(b.trigger.Spec.ErrorTopic) > 0 && len(body) > 0 {
+		err := b.publish(b.trigger.Spec.ErrorTopic, body, "")
+		if err != nil {
+			b.logger.Error("failed to publish function invocation error to error topic",
+				zap.Error(err),
+				zap.String("topic", b.trigger.Spec.ErrorTopic))
+		}
+	}
+
+	for _, msg := range batch {
+		if b.retry.DeadLetter(b.logger, msg.Body, b.trigger.Spec.ContentType, b.publish) {
+			if msg.Ack != nil {
+				msg.Ack()
+			}
+		} else if msg.Nack != nil {
+			msg.Nack()
+		}
+	}
+}
+
+// BatchBody returns the payload of a batch: a JSON array of the bodies of
+// its messages, as is if JSON, or else as strings.
+func BatchBody(batch []BatchMessage) ([]byte, error) {
+	items := make([]interface{}, 0, len(batch))
+	for _, msg := range batch {
+		if json.Valid(msg.Body) {
+			items = append(items, json.RawMessage(msg.Body))
+		} else {
+			items = append(items, string(msg.Body))
+		}
+	}
+	return json.Marshal(items)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messageQueue
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestBatchBody(t *testing.T) {
+	body, err := BatchBody([]BatchMessage{
+		{Body: []byte(`{"id": 1}`)},
+		{Body: []byte(`42`)},
+		{Body: []byte(`plain text`)},
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"id": 1}, 42, "plain text"]`, string(body))
+}
+
+func TestBatcher(t *testing.T) {
+	var lock sync.Mutex
+	var batches []string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		lock.Lock()
+		defer lock.Unlock()
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		batches = append(batches, string(body))
+		w.WriteHeader(status)
+		w.Write([]byte("done"))
+	}))
+	defer server.Close()
+
+	trigger := &fv1.MessageQueueTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "default"},
+		Spec: fv1.MessageQueueTriggerSpec{
+			FunctionReference: fv1.FunctionReference{Type: fv1.FunctionReferenceTypeFunctionName, Name: "process"},
+			Topic:             "orders",
+			ResponseTopic:     "processed",
+			ErrorTopic:        "failed",
+			BatchSize:         3,
+			BatchWindow:       "100ms",
+		},
+	}
+	require.True(t, IsBatched(trigger))
+
+	published := make(map[string][]string)
+	b, err := NewBatcher(zap.NewNop(), server.URL, trigger, func(topic string, body []byte, contentType string) error {
+		lock.Lock()
+		defer lock.Unlock()
+		published[topic] = append(published[topic], string(body))
+		return nil
+	})
+	require.NoError(t, err)
+
+	var acked, nacked int
+	add := func(body string) {
+		b.Add(BatchMessage{
+			Body: []byte(body),
+			Ack:  func() { lock.Lock(); acked++; lock.Unlock() },
+			Nack: func() { lock.Lock(); nacked++; lock.Unlock() },
+		})
+	}
+
+	// a full batch is invoked right away, the rest after the window
+	for _, body := range []string{"1", "2", "3", "4"} {
+		add(body)
+	}
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return acked == 4
+	}, time.Second, 10*time.Millisecond)
+
+	lock.Lock()
+	require.Equal(t, []string{"[1,2,3]", "[4]"}, batches)
+	require.Equal(t, []string{"done", "done"}, published["processed"])
+	status = http.StatusInternalServerError
+	lock.Unlock()
+
+	// a failed batch is nacked, and the open batch is invoked on close
+	add("5")
+	b.Close()
+	lock.Lock()
+	require.Equal(t, 1, nacked)
+	require.Equal(t, []string{"done"}, published["failed"])
+	lock.Unlock()
+
+	// messages added after close are nacked
+	add("6")
+	lock.Lock()
+	defer lock.Unlock()
+	require.Equal(t, 2, nacked)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcppubsub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const pubsubScope = "https://www.googleapis.com/auth/pubsub"
+
+type (
+	// client calls the REST API of Pub/Sub, the way the Stackdriver metrics
+	// provider of canary deployments calls Cloud Monitoring, without the
+	// weight of the client libraries.
+	client struct {
+		endpoint   string
+		httpClient *http.Client
+	}
+
+	// apiError is an error response of the API.
+	apiError struct {
+		StatusCode int
+		Message    string
+	}
+)
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("pubsub api error, status code: %v: %v", e.StatusCode, e.Message)
+}
+
+func isNotFound(err error) bool {
+	e, ok := errors.Cause(err).(*apiError)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+// do calls the API, with in encoded as the body if not nil, decoding the
+// response into out if not nil.
+func (c *client) do(ctx context.Context, method string, path string, in interface{}, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return errors.Wrap(err, "error encoding request")
+		}
+	}
+	req, err := http.NewRequest(method, c.endpoint+"/v1/"+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "error reading response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		e := &apiError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(respBody))}
+		var status struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(respBody, &status) == nil && len(status.Error.Message) > 0 {
+			e.Message = status.Error.Message
+		}
+		return e
+	}
+	if out != nil {
+		err = json.Unmarshal(respBody, out)
+		if err != nil {
+			return errors.Wrap(err, "error decoding response")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,804 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcppubsub
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/gcpauth"
+	"github.com/fission/fission/pkg/mqtrigger/factory"
+	"github.com/fission/fission/pkg/mqtrigger/messageQueue"
+	"github.com/fission/fission/pkg/mqtrigger/validator"
+	"github.com/fission/fission/pkg/utils"
+)
+
+func init() {
+	factory.Register(fv1.MessageQueueTypeGCPPubSub, &Factory{})
+	validator.Register(fv1.MessageQueueTypeGCPPubSub, IsTopicValid)
+}
+
+// Settings of a trigger, in the metadata of the trigger. The topic of a
+// trigger is the Pub/Sub topic it consumes through a pull subscription, by
+// name in the project of the trigger manager or as
+// projects/<project>/topics/<topic>; the response and error topics are
+// Pub/Sub topics too.
+const (
+	// MetadataSubscription is the subscription to pull messages from. It's
+	// created if it doesn't exist, and removed once the trigger is deleted
+	// if the trigger manager created it. fission-<namespace>-<trigger> if
+	// empty.
+	MetadataSubscription = "subscription"
+	// MetadataAckDeadline is how many seconds a message is kept from
+	// redelivery once pulled. It's extended for as long as the function
+	// runs, so it only bounds how soon a message is redelivered after the
+	// trigger is gone.
+	MetadataAckDeadline = "ackDeadline"
+	// MetadataMaxMessages is how many messages are pulled, and invoked
+	// with, at a time.
+	MetadataMaxMessages = "maxMessages"
+	// MetadataOrdering is whether messages with the same ordering key are
+	// delivered in the order they're published, "true" or "false". It's
+	// set on subscriptions created only, as Pub/Sub doesn't allow to
+	// change it.
+	MetadataOrdering = "ordering"
+	// MetadataDeadLetterTopic is the topic messages the function fails on
+	// MetadataMaxDeliveryAttempts times are forwarded to by Pub/Sub. The
+	// Pub/Sub service account needs to be allowed to publish to it, and to
+	// subscribe to the subscription.
+	MetadataDeadLetterTopic = "deadLetterTopic"
+	// MetadataMaxDeliveryAttempts is how many times a message is delivered
+	// before it's forwarded to the dead-letter topic, from 5 to 100.
+	MetadataMaxDeliveryAttempts = "maxDeliveryAttempts"
+
+	defaultEndpoint            = "https://pubsub.googleapis.com"
+	defaultAckDeadline         = 60
+	defaultMaxMessages         = 10
+	defaultMaxDeliveryAttempts = 5
+
+	// managedLabel labels the subscriptions the trigger manager created,
+	// to remove them with their triggers.
+	managedLabel = "managed-by"
+	managedValue = "fission"
+
+	// pullTimeout bounds a pull waiting for messages.
+	pullTimeout = time.Minute
+
+	// retryInterval is how long to wait to pull messages again after an
+	// error.
+	retryInterval = 5 * time.Second
+)
+
+var (
+	// names of topics and subscriptions start with a letter and don't start
+	// with "goog"
+	resourceNameRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9\-_.~+%]{2,254}$`)
+	projectIDRegex    = regexp.MustCompile(`^[a-z][a-z0-9\-]{4,28}[a-z0-9]$|^[a-z0-9.\-:]+:[a-z][a-z0-9\-]{4,28}[a-z0-9]$`)
+)
+
+type (
+	PubSub struct {
+		logger    *zap.Logger
+		client    *client
+		project   string
+		routerUrl string
+	}
+
+	// subscriptionConfig is the subscription and pull settings of a
+	// trigger.
+	subscriptionConfig struct {
+		subscription        string
+		ackDeadline         int
+		maxMessages         int
+		ordering            bool
+		deadLetterTopic     string
+		maxDeliveryAttempts int
+
+		// set tells the settings set in the metadata apart from defaults
+		set map[string]bool
+	}
+
+	// subscription is the puller of the subscription of a trigger.
+	subscription struct {
+		path          string
+		topic         string
+		responseTopic string
+		errorTopic    string
+		// deadLetterTopic is the dead-letter topic of the retry policy of
+		// the trigger, if any.
+		deadLetterTopic string
+		retry           *messageQueue.RetryPolicy
+		// managed is whether the trigger manager created the subscription.
+		managed bool
+		// batcher is set if the function of the trigger is invoked with
+		// batches of messages.
+		batcher *messageQueue.Batcher
+		limiter messageQueue.Limiter
+
+		cancel context.CancelFunc
+		done   chan struct{}
+	}
+
+	// Resources of the REST API.
+	pubsubSubscription struct {
+		Name                  string            `json:"name,omitempty"`
+		Topic                 string            `json:"topic,omitempty"`
+		AckDeadlineSeconds    int               `json:"ackDeadlineSeconds,omitempty"`
+		EnableMessageOrdering bool              `json:"enableMessageOrdering,omitempty"`
+		DeadLetterPolicy      *deadLetterPolicy `json:"deadLetterPolicy,omitempty"`
+		Labels                map[string]string `json:"labels,omitempty"`
+	}
+
+	deadLetterPolicy struct {
+		DeadLetterTopic     string `json:"deadLetterTopic,omitempty"`
+		MaxDeliveryAttempts int    `json:"maxDeliveryAttempts,omitempty"`
+	}
+
+	pubsubMessage struct {
+		Data        string            `json:"data,omitempty"`
+		Attributes  map[string]string `json:"attributes,omitempty"`
+		MessageID   string            `json:"messageId,omitempty"`
+		PublishTime string            `json:"publishTime,omitempty"`
+		OrderingKey string            `json:"orderingKey,omitempty"`
+	}
+
+	receivedMessage struct {
+		AckID           string        `json:"ackId"`
+		Message         pubsubMessage `json:"message"`
+		DeliveryAttempt int           `json:"deliveryAttempt"`
+	}
+
+	Factory struct{}
+)
+
+func (factory *Factory) Create(logger *zap.Logger, mqCfg messageQueue.Config, routerUrl string) (messageQueue.MessageQueue, error) {
+	return New(logger, mqCfg, routerUrl)
+}
+
+// New returns a client of the Pub/Sub API at the URL of the config, that of
+// Google Cloud if empty, in the project of the GOOGLE_CLOUD_PROJECT
+// environment variable, of the service account key, or of the pod from the
+// GCE metadata server, in that order. The client authenticates with the
+// "access-token" secret, or the "service-account-key" secret, a JSON key
+// of a service account, if set, or else as the service account of the pod,
+// bound to a Google service account with Workload Identity on GKE.
+func New(logger *zap.Logger, mqCfg messageQueue.Config, routerUrl string) (messageQueue.MessageQueue, error) {
+	if len(routerUrl) == 0 {
+		return nil, errors.New("the router URL is empty")
+	}
+
+	endpoint := strings.TrimSuffix(mqCfg.Url, "/")
+	if len(endpoint) == 0 {
+		endpoint = defaultEndpoint
+	}
+
+	credentials := gcpauth.Credentials{
+		AccessToken: strings.TrimSpace(string(mqCfg.Secrets["access-token"])),
+		Scope:       pubsubScope,
+	}
+	if data, ok := mqCfg.Secrets["service-account-key"]; ok {
+		key, err := gcpauth.ParseServiceAccountKey(data)
+		if err != nil {
+			return nil, err
+		}
+		credentials.Key = key
+	}
+
+	project := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if len(project) == 0 && credentials.Key != nil {
+		project = credentials.Key.ProjectID
+	}
+	if len(project) == 0 {
+		var err error
+		project, err = gcpauth.MetadataProject(http.DefaultTransport)
+		if err != nil {
+			return nil, errors.Wrap(err, "the project is not set, set it with the GOOGLE_CLOUD_PROJECT environment variable")
+		}
+	}
+
+	return PubSub{
+		logger:    logger.Named("gcppubsub"),
+		client:    &client{endpoint: endpoint, httpClient: &http.Client{Transport: gcpauth.MakeTokenRoundTripper(http.DefaultTransport, credentials)}},
+		project:   project,
+		routerUrl: routerUrl,
+	}, nil
+}
+
+func (ps PubSub) Subscribe(trigger *fv1.MessageQueueTrigger) (messageQueue.Subscription, error) {
+	if !IsTopicValid(trigger.Spec.Topic) {
+		return nil, fmt.Errorf("not a valid topic: %q", trigger.Spec.Topic)
+	}
+	cfg, err := parseConfig(trigger)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &subscription{
+		path:    resourcePath(ps.project, "subscriptions", cfg.subscription),
+		topic:   resourcePath(ps.project, "topics", trigger.Spec.Topic),
+		limiter: messageQueue.NewLimiter(trigger),
+		done:    make(chan struct{}),
+	}
+	if len(trigger.Spec.ResponseTopic) > 0 {
+		sub.responseTopic = resourcePath(ps.project, "topics", trigger.Spec.ResponseTopic)
+	}
+	if len(trigger.Spec.ErrorTopic) > 0 {
+		sub.errorTopic = resourcePath(ps.project, "topics", trigger.Spec.ErrorTopic)
+	}
+	sub.retry, err = messageQueue.NewRetryPolicy(trigger)
+	if err != nil {
+		return nil, err
+	}
+	if len(sub.retry.DeadLetterTopic) > 0 {
+		sub.deadLetterTopic = resourcePath(ps.project, "topics", sub.retry.DeadLetterTopic)
+	}
+
+	sub.managed, err = ps.ensureSubscription(sub, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if messageQueue.IsBatched(trigger) {
+		sub.batcher, err = messageQueue.NewBatcher(ps.logger, ps.routerUrl, trigger, func(topic string, body []byte, contentType string) error {
+			path := sub.errorTopic
+			switch topic {
+			case trigger.Spec.ResponseTopic:
+				path = sub.responseTopic
+			case sub.retry.DeadLetterTopic:
+				path = sub.deadLetterTopic
+			}
+			return ps.publish(path, body, contentType, "")
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var ctx context.Context
+	ctx, sub.cancel = context.WithCancel(context.Background())
+	go ps.pull(ctx, trigger, cfg, sub)
+
+	ps.logger.Info("subscribed to topic",
+		zap.String("topic", sub.topic),
+		zap.String("subscription", sub.path),
+		zap.Int("ack_deadline", cfg.ackDeadline),
+		zap.Bool("ordering", cfg.ordering),
+		zap.String("dead_letter_topic", cfg.deadLetterTopic),
+		zap.String("trigger", trigger.ObjectMeta.Name))
+	return sub, nil
+}
+
+// Unsubscribe stops pulling messages for a trigger, once the messages
+// pulled are handled. The subscription is kept, for messages published
+// while the trigger manager restarts to wait in it; Remove removes it.
+func (ps PubSub) Unsubscribe(triggerSub messageQueue.Subscription) error {
+	sub := triggerSub.(*subscription)
+	sub.cancel()
+	<-sub.done
+	if sub.batcher != nil {
+		sub.batcher.Close()
+	}
+	return nil
+}
+
+// Remove removes the subscription of a deleted trigger, if the trigger
+// manager created it.
+func (ps PubSub) Remove(triggerSub messageQueue.Subscription) error {
+	sub := triggerSub.(*subscription)
+	if !sub.managed {
+		return nil
+	}
+	err := ps.client.do(context.Background(), http.MethodDelete, sub.path, nil, nil)
+	if err != nil && !isNotFound(err) {
+		return errors.Wrapf(err, "error removing subscription %q", sub.path)
+	}
+	return nil
+}
+
+// ensureSubscription creates the subscription of a trigger if it doesn't
+// exist, or else updates the settings of the trigger on it, and returns
+// whether the trigger manager created it. Settings of subscriptions created
+// otherwise are updated only if set in the metadata of the trigger.
+func (ps PubSub) ensureSubscription(sub *subscription, cfg *subscriptionConfig) (bool, error) {
+	ctx := context.Background()
+	var dlp *deadLetterPolicy
+	if len(cfg.deadLetterTopic) > 0 {
+		dlp = &deadLetterPolicy{
+			DeadLetterTopic:     resourcePath(ps.project, "topics", cfg.deadLetterTopic),
+			MaxDeliveryAttempts: cfg.maxDeliveryAttempts,
+		}
+	}
+
+	existing := &pubsubSubscription{}
+	err := ps.client.do(ctx, http.MethodGet, sub.path, nil, existing)
+	if isNotFound(err) {
+		err = ps.client.do(ctx, http.MethodPut, sub.path, &pubsubSubscription{
+			Topic:                 sub.topic,
+			AckDeadlineSeconds:    cfg.ackDeadline,
+			EnableMessageOrdering: cfg.ordering,
+			DeadLetterPolicy:      dlp,
+			Labels:                map[string]string{managedLabel: managedValue},
+		}, nil)
+		if err != nil {
+			return false, errors.Wrapf(err, "error creating subscription %q to topic %q", sub.path, sub.topic)
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "error getting subscription %q", sub.path)
+	}
+
+	if existing.Topic != sub.topic {
+		return false, errors.Errorf("subscription %q is to topic %q, not %q", sub.path, existing.Topic, sub.topic)
+	}
+	if existing.EnableMessageOrdering != cfg.ordering && cfg.set[MetadataOrdering] {
+		ps.logger.Warn("ordering of existing subscription can't be changed, recreate the subscription to change it",
+			zap.String("subscription", sub.path),
+			zap.Bool("ordering", existing.EnableMessageOrdering))
+	}
+
+	managed := existing.Labels[managedLabel] == managedValue
+	var mask []string
+	if managed || cfg.set[MetadataAckDeadline] {
+		mask = append(mask, "ackDeadlineSeconds")
+	}
+	if managed || cfg.set[MetadataDeadLetterTopic] {
+		mask = append(mask, "deadLetterPolicy")
+	}
+	if len(mask) > 0 {
+		err = ps.client.do(ctx, http.MethodPatch, sub.path, map[string]interface{}{
+			"subscription": &pubsubSubscription{
+				AckDeadlineSeconds: cfg.ackDeadline,
+				DeadLetterPolicy:   dlp,
+			},
+			"updateMask": strings.Join(mask, ","),
+		}, nil)
+		if err != nil {
+			return false, errors.Wrapf(err, "error updating subscription %q", sub.path)
+		}
+	}
+	return managed, nil
+}
+
+// pull pulls messages of the subscription of a trigger until ctx is done,
+// handling each batch before pulling the next. With ordering, the messages
+// of an ordering key are handled one at a time, in order.
+func (ps PubSub) pull(ctx context.Context, trigger *fv1.MessageQueueTrigger, cfg *subscriptionConfig, sub *subscription) {
+	defer close(sub.done)
+	for {
+		var out struct {
+			ReceivedMessages []receivedMessage `json:"receivedMessages"`
+		}
+		pullCtx, cancel := context.WithTimeout(ctx, pullTimeout)
+		err := ps.client.do(pullCtx, http.MethodPost, sub.path+":pull", map[string]interface{}{
+			"maxMessages": cfg.maxMessages,
+		}, &out)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			if pullCtx.Err() == context.DeadlineExceeded {
+				continue
+			}
+			ps.logger.Error("failed to pull messages from subscription",
+				zap.Error(err),
+				zap.String("subscription", sub.path),
+				zap.String("trigger", trigger.ObjectMeta.Name))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryInterval):
+			}
+			continue
+		}
+
+		if sub.batcher != nil {
+			// batches are invoked one at a time, in order
+			for _, msg := range out.ReceivedMessages {
+				ps.addToBatch(trigger, cfg, sub, msg)
+			}
+			continue
+		}
+
+		var wg sync.WaitGroup
+		for _, msgs := range orderedGroups(out.ReceivedMessages, cfg.ordering) {
+			wg.Add(1)
+			// the messages of a group are handled one at a time
+			sub.limiter.Acquire()
+			go func(msgs []receivedMessage) {
+				defer wg.Done()
+				defer sub.limiter.Release()
+				for i, msg := range msgs {
+					if !ps.msgHandler(trigger, cfg, sub, msg) {
+						// the rest are redelivered after the failed one
+						ps.nack(trigger, sub, msgs[i+1:])
+						return
+					}
+				}
+			}(msgs)
+		}
+		wg.Wait()
+	}
+}
+
+// msgHandler invokes the function of a trigger with a message, retried
+// with the retry policy of the trigger, extending the ack deadline of the
+// message while the function runs, and acks the message once the function
+// succeeds. Messages the function fails on are handled by fail. Nacked
+// ones are redelivered by Pub/Sub, which forwards them to the dead-letter
+// topic of the subscription after its max delivery attempts. It returns
+// whether the function succeeded.
+func (ps PubSub) msgHandler(trigger *fv1.MessageQueueTrigger, cfg *subscriptionConfig, sub *subscription, msg receivedMessage) bool {
+	// Support other function ref types
+	if trigger.Spec.FunctionReference.Type != fv1.FunctionReferenceTypeFunctionName {
+		ps.logger.Fatal("unsupported function reference type for trigger",
+			zap.Any("function_reference_type", trigger.Spec.FunctionReference.Type),
+			zap.String("trigger", trigger.ObjectMeta.Name))
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go ps.extendAckDeadline(trigger, cfg, sub, msg, stop)
+
+	url := ps.routerUrl + "/" + strings.TrimPrefix(utils.UrlForFunction(trigger.Spec.FunctionReference.Name, trigger.ObjectMeta.Namespace), "/")
+	ps.logger.Debug("making HTTP request", zap.String("url", url))
+
+	fissionHeaders := map[string]string{
+		"X-Fission-MQTrigger-Topic":      trigger.Spec.Topic,
+		"X-Fission-MQTrigger-RespTopic":  trigger.Spec.ResponseTopic,
+		"X-Fission-MQTrigger-ErrorTopic": trigger.Spec.ErrorTopic,
+		"Content-Type":                   trigger.Spec.ContentType,
+		fv1.HeaderTriggerType:            fv1.TriggerTypeMessageQueue,
+		fv1.HeaderTriggerName:            trigger.ObjectMeta.Name,
+		fv1.HeaderCloudEventsMode:        string(trigger.Spec.CloudEvents),
+		fv1.HeaderPriority:               string(fv1.InvocationPriorityBatch),
+	}
+	if publishTime, err := time.Parse(time.RFC3339Nano, msg.Message.PublishTime); err == nil {
+		fissionHeaders[fv1.HeaderEventTime] = publishTime.UTC().Format(time.RFC3339Nano)
+	}
+
+	body, err := base64.StdEncoding.DecodeString(msg.Message.Data)
+	if err != nil {
+		ps.fail(trigger, sub, msg, url, []byte(fmt.Sprintf("error decoding message data: %v", err)))
+		return false
+	}
+
+	resp, err := sub.retry.Invoke(ps.logger.With(zap.String("trigger", trigger.ObjectMeta.Name)), func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		// the attributes of the message, then ours
+		for k, v := range msg.Message.Attributes {
+			req.Header.Set(k, v)
+		}
+		for k, v := range fissionHeaders {
+			req.Header.Set(k, v)
+		}
+		return req, nil
+	})
+	if err != nil {
+		ps.fail(trigger, sub, msg, url, []byte(fmt.Sprintf("request exceed retries: %v: %v", sub.retry.MaxAttempts-1, err)))
+		return false
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		ps.fail(trigger, sub, msg, url, []byte(fmt.Sprintf("error reading function invocation response: %v", err)))
+		return false
+	}
+	ps.logger.Debug("got response from function invocation",
+		zap.String("function_url", url),
+		zap.String("trigger", trigger.ObjectMeta.Name),
+		zap.String("body", string(respBody)))
+
+	if resp.StatusCode != http.StatusOK {
+		ps.fail(trigger, sub, msg, url, respBody)
+		return false
+	}
+
+	if len(sub.responseTopic) > 0 {
+		err = ps.publish(sub.responseTopic, respBody, resp.Header.Get("Content-Type"), msg.Message.OrderingKey)
+		if err != nil {
+			ps.logger.Error("failed to publish message with function invocation response to topic",
+				zap.Error(err),
+				zap.String("topic", trigger.Spec.ResponseTopic),
+				zap.String("trigger", trigger.ObjectMeta.Name))
+		}
+	}
+
+	// Trigger acks message only if a request was processed successfully
+	err = ps.client.do(context.Background(), http.MethodPost, sub.path+":acknowledge", map[string]interface{}{
+		"ackIds": []string{msg.AckID},
+	}, nil)
+	if err != nil {
+		ps.logger.Error("failed to ack message after successful function invocation from trigger",
+			zap.Error(err),
+			zap.String("function_url", url),
+			zap.String("trigger", trigger.ObjectMeta.Name))
+	}
+	return true
+}
+
+// addToBatch adds a message to the open batch of a trigger, extending the
+// ack deadline of the message until the batch is handled. Messages of
+// batches the function handled are acked, and those of batches it failed on
+// are nacked, like the messages handled one at a time.
+func (ps PubSub) addToBatch(trigger *fv1.MessageQueueTrigger, cfg *subscriptionConfig, sub *subscription, msg receivedMessage) {
+	body, err := base64.StdEncoding.DecodeString(msg.Message.Data)
+	if err != nil {
+		ps.fail(trigger, sub, msg, "", []byte(fmt.Sprintf("error decoding message data: %v", err)))
+		return
+	}
+
+	stop := make(chan struct{})
+	go ps.extendAckDeadline(trigger, cfg, sub, msg, stop)
+
+	sub.batcher.Add(messageQueue.BatchMessage{
+		Body: body,
+		Ack: func() {
+			close(stop)
+			err := ps.client.do(context.Background(), http.MethodPost, sub.path+":acknowledge", map[string]interface{}{
+				"ackIds": []string{msg.AckID},
+			}, nil)
+			if err != nil {
+				ps.logger.Error("failed to ack message of batch after successful function invocation from trigger",
+					zap.Error(err),
+					zap.String("message_id", msg.Message.MessageID),
+					zap.String("trigger", trigger.ObjectMeta.Name))
+			}
+		},
+		Nack: func() {
+			close(stop)
+			ps.nack(trigger, sub, []receivedMessage{msg})
+		},
+	})
+}
+
+// extendAckDeadline extends the ack deadline of a message every half
+// deadline until stop is closed, so the message isn't redelivered while the
+// function runs.
+func (ps PubSub) extendAckDeadline(trigger *fv1.MessageQueueTrigger, cfg *subscriptionConfig, sub *subscription, msg receivedMessage, stop chan struct{}) {
+	ticker := time.NewTicker(time.Duration(cfg.ackDeadline) * time.Second / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		err := ps.modifyAckDeadline(sub, []receivedMessage{msg}, cfg.ackDeadline)
+		if err != nil {
+			ps.logger.Warn("failed to extend ack deadline of message",
+				zap.Error(err),
+				zap.String("message_id", msg.Message.MessageID),
+				zap.String("trigger", trigger.ObjectMeta.Name))
+		}
+	}
+}
+
+// fail publishes the error of a message the function failed on to the
+// error topic. The message itself is acked once published to the
+// dead-letter topic of the retry policy of the trigger, if any, or else
+// nacked.
+func (ps PubSub) fail(trigger *fv1.MessageQueueTrigger, sub *subscription, msg receivedMessage, url string, body []byte) {
+	ps.logger.Error("function invocation failed, nacking message",
+		zap.String("function_url", url),
+		zap.String("trigger", trigger.ObjectMeta.Name),
+		zap.Int("delivery_attempt", msg.DeliveryAttempt),
+		zap.String("error", string(body)))
+
+	if len(sub.errorTopic) > 0 && len(body) > 0 {
+		err := ps.publish(sub.errorTopic, body, "", "")
+		if err != nil {
+			ps.logger.Error("failed to publish function invocation error to error topic",
+				zap.Error(err),
+				zap.String("topic", trigger.Spec.ErrorTopic),
+				zap.String("function_url", url),
+				zap.String("trigger", trigger.ObjectMeta.Name))
+		}
+	}
+
+	body, err := base64.StdEncoding.DecodeString(msg.Message.Data)
+	if err == nil && sub.retry.DeadLetter(ps.logger, body, trigger.Spec.ContentType, func(topic string, body []byte, contentType string) error {
+		return ps.publish(sub.deadLetterTopic, body, contentType, msg.Message.OrderingKey)
+	}) {
+		ps.ack(trigger, sub, msg)
+		return
+	}
+	ps.nack(trigger, sub, []receivedMessage{msg})
+}
+
+// ack acks a message, for Pub/Sub not to redeliver it.
+func (ps PubSub) ack(trigger *fv1.MessageQueueTrigger, sub *subscription, msg receivedMessage) {
+	err := ps.client.do(context.Background(), http.MethodPost, sub.path+":acknowledge", map[string]interface{}{
+		"ackIds": []string{msg.AckID},
+	}, nil)
+	if err != nil {
+		ps.logger.Error("failed to ack message",
+			zap.Error(err),
+			zap.String("message_id", msg.Message.MessageID),
+			zap.String("trigger", trigger.ObjectMeta.Name))
+	}
+}
+
+// nack makes messages available for redelivery right away.
+func (ps PubSub) nack(trigger *fv1.MessageQueueTrigger, sub *subscription, msgs []receivedMessage) {
+	if len(msgs) == 0 {
+		return
+	}
+	err := ps.modifyAckDeadline(sub, msgs, 0)
+	if err != nil {
+		ps.logger.Error("failed to nack messages",
+			zap.Error(err),
+			zap.Int("messages", len(msgs)),
+			zap.String("trigger", trigger.ObjectMeta.Name))
+	}
+}
+
+func (ps PubSub) modifyAckDeadline(sub *subscription, msgs []receivedMessage, ackDeadline int) error {
+	ackIDs := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		ackIDs = append(ackIDs, msg.AckID)
+	}
+	return ps.client.do(context.Background(), http.MethodPost, sub.path+":modifyAckDeadline", map[string]interface{}{
+		"ackIds":             ackIDs,
+		"ackDeadlineSeconds": ackDeadline,
+	}, nil)
+}
+
+// publish publishes body to a topic, with its content type as an attribute
+// if set.
+func (ps PubSub) publish(topic string, body []byte, contentType string, orderingKey string) error {
+	msg := pubsubMessage{
+		Data:        base64.StdEncoding.EncodeToString(body),
+		OrderingKey: orderingKey,
+	}
+	if len(contentType) > 0 {
+		msg.Attributes = map[string]string{"Content-Type": contentType}
+	}
+	return ps.client.do(context.Background(), http.MethodPost, topic+":publish", map[string]interface{}{
+		"messages": []pubsubMessage{msg},
+	}, nil)
+}
+
+// orderedGroups groups messages to handle, in order within a group. With
+// ordering, the messages of an ordering key are grouped together; every
+// other message is a group of its own.
+func orderedGroups(msgs []receivedMessage, ordering bool) [][]receivedMessage {
+	var groups [][]receivedMessage
+	byKey := make(map[string]int)
+	for _, msg := range msgs {
+		key := msg.Message.OrderingKey
+		if !ordering || len(key) == 0 {
+			groups = append(groups, []receivedMessage{msg})
+			continue
+		}
+		if i, ok := byKey[key]; ok {
+			groups[i] = append(groups[i], msg)
+			continue
+		}
+		byKey[key] = len(groups)
+		groups = append(groups, []receivedMessage{msg})
+	}
+	return groups
+}
+
+// parseConfig reads the subscription and pull settings of a trigger from
+// its metadata.
+func parseConfig(trigger *fv1.MessageQueueTrigger) (*subscriptionConfig, error) {
+	metadata := trigger.Spec.Metadata
+	cfg := &subscriptionConfig{
+		subscription:        metadata[MetadataSubscription],
+		ackDeadline:         defaultAckDeadline,
+		maxMessages:         defaultMaxMessages,
+		deadLetterTopic:     metadata[MetadataDeadLetterTopic],
+		maxDeliveryAttempts: defaultMaxDeliveryAttempts,
+		set:                 make(map[string]bool),
+	}
+	for k := range metadata {
+		cfg.set[k] = true
+	}
+
+	if len(cfg.subscription) == 0 {
+		cfg.subscription = fmt.Sprintf("fission-%v-%v", trigger.ObjectMeta.Namespace, trigger.ObjectMeta.Name)
+	}
+	if !isResourceValid("subscriptions", cfg.subscription) {
+		return nil, errors.Errorf("not a valid subscription: %q", cfg.subscription)
+	}
+	if len(cfg.deadLetterTopic) > 0 && !IsTopicValid(cfg.deadLetterTopic) {
+		return nil, errors.Errorf("not a valid dead-letter topic: %q", cfg.deadLetterTopic)
+	}
+
+	parse := func(key string, min int, max int, value *int) error {
+		v, ok := metadata[key]
+		if !ok {
+			return nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < min || n > max {
+			return errors.Errorf("%v must be a number from %v to %v, got %q", key, min, max, v)
+		}
+		*value = n
+		return nil
+	}
+	err := parse(MetadataAckDeadline, 10, 600, &cfg.ackDeadline)
+	if err != nil {
+		return nil, err
+	}
+	err = parse(MetadataMaxMessages, 1, 1000, &cfg.maxMessages)
+	if err != nil {
+		return nil, err
+	}
+	err = parse(MetadataMaxDeliveryAttempts, 5, 100, &cfg.maxDeliveryAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	if v, ok := metadata[MetadataOrdering]; ok {
+		cfg.ordering, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, errors.Errorf("ordering must be true or false, got %q", v)
+		}
+	}
+
+	return cfg, nil
+}
+
+// resourcePath returns the path of a topic or subscription, of the project
+// unless name is a path already.
+func resourcePath(project string, collection string, name string) string {
+	if strings.HasPrefix(name, "projects/") {
+		return name
+	}
+	return fmt.Sprintf("projects/%v/%v/%v", project, collection, name)
+}
+
+// IsTopicValid returns whether topic is the name of a topic, or its path
+// projects/<project>/topics/<topic>.
+func IsTopicValid(topic string) bool {
+	return isResourceValid("topics", topic)
+}
+
+// isResourceValid returns whether name is the name of a topic or
+// subscription, or its path.
+func isResourceValid(collection string, name string) bool {
+	if strings.HasPrefix(name, "projects/") {
+		parts := strings.Split(name, "/")
+		if len(parts) != 4 || parts[2] != collection || !projectIDRegex.MatchString(parts[1]) {
+			return false
+		}
+		name = parts[3]
+	}
+	return resourceNameRegex.MatchString(name) && !strings.HasPrefix(name, "goog")
+}
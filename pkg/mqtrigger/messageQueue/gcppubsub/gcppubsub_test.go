@@ -0,0 +1,305 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcppubsub
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/mqtrigger/messageQueue"
+)
+
+// fakePubSub serves the subscriptions of the REST API, recording the
+// requests other than gets.
+type fakePubSub struct {
+	lock          sync.Mutex
+	subscriptions map[string]map[string]interface{}
+	requests      []string
+	bodies        []map[string]interface{}
+}
+
+func (f *fakePubSub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	body := make(map[string]interface{})
+	data, _ := ioutil.ReadAll(r.Body)
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &body)
+	}
+	if r.Method != http.MethodGet {
+		f.requests = append(f.requests, r.Method+" "+path)
+		f.bodies = append(f.bodies, body)
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		sub, ok := f.subscriptions[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error": {"code": 404, "message": "Resource not found"}}`)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(sub)
+		return
+	case http.MethodPut:
+		f.subscriptions[path] = body
+	case http.MethodDelete:
+		delete(f.subscriptions, path)
+	}
+	fmt.Fprint(w, `{}`)
+}
+
+func newTestPubSub(t *testing.T, handler http.Handler, routerUrl string) (PubSub, func()) {
+	server := httptest.NewServer(handler)
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+	return PubSub{
+		logger:    logger,
+		client:    &client{endpoint: server.URL, httpClient: server.Client()},
+		project:   "test-project",
+		routerUrl: routerUrl,
+	}, server.Close
+}
+
+func TestParseConfig(t *testing.T) {
+	trigger := &fv1.MessageQueueTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "default"},
+		Spec:       fv1.MessageQueueTriggerSpec{Topic: "orders"},
+	}
+
+	cfg, err := parseConfig(trigger)
+	require.NoError(t, err)
+	require.Equal(t, &subscriptionConfig{
+		subscription:        "fission-default-orders",
+		ackDeadline:         defaultAckDeadline,
+		maxMessages:         defaultMaxMessages,
+		maxDeliveryAttempts: defaultMaxDeliveryAttempts,
+		set:                 map[string]bool{},
+	}, cfg)
+
+	trigger.Spec.Metadata = map[string]string{
+		MetadataSubscription:        "orders-sub",
+		MetadataAckDeadline:         "120",
+		MetadataMaxMessages:         "1",
+		MetadataOrdering:            "true",
+		MetadataDeadLetterTopic:     "projects/other-project/topics/orders-dlq",
+		MetadataMaxDeliveryAttempts: "10",
+	}
+	cfg, err = parseConfig(trigger)
+	require.NoError(t, err)
+	require.Equal(t, "orders-sub", cfg.subscription)
+	require.Equal(t, 120, cfg.ackDeadline)
+	require.Equal(t, 1, cfg.maxMessages)
+	require.True(t, cfg.ordering)
+	require.Equal(t, "projects/other-project/topics/orders-dlq", cfg.deadLetterTopic)
+	require.Equal(t, 10, cfg.maxDeliveryAttempts)
+	require.True(t, cfg.set[MetadataAckDeadline])
+
+	for _, metadata := range []map[string]string{
+		{MetadataSubscription: "goog-orders"},
+		{MetadataAckDeadline: "5"},
+		{MetadataMaxMessages: "0"},
+		{MetadataOrdering: "sometimes"},
+		{MetadataDeadLetterTopic: "projects/p/subscriptions/orders"},
+		{MetadataMaxDeliveryAttempts: "4"},
+	} {
+		trigger.Spec.Metadata = metadata
+		_, err = parseConfig(trigger)
+		require.Error(t, err, "metadata %v", metadata)
+	}
+}
+
+func TestEnsureSubscription(t *testing.T) {
+	fake := &fakePubSub{subscriptions: map[string]map[string]interface{}{
+		"projects/test-project/subscriptions/theirs": {
+			"topic":                 "projects/test-project/topics/orders",
+			"enableMessageOrdering": false,
+		},
+	}}
+	ps, stop := newTestPubSub(t, fake, "")
+	defer stop()
+
+	sub := &subscription{
+		path:  "projects/test-project/subscriptions/fission-default-orders",
+		topic: "projects/test-project/topics/orders",
+	}
+	cfg := &subscriptionConfig{
+		ackDeadline:         30,
+		ordering:            true,
+		deadLetterTopic:     "orders-dlq",
+		maxDeliveryAttempts: 5,
+		set:                 map[string]bool{},
+	}
+
+	// created, with the settings of the trigger
+	managed, err := ps.ensureSubscription(sub, cfg)
+	require.NoError(t, err)
+	require.True(t, managed)
+	require.Equal(t, []string{"PUT " + sub.path}, fake.requests)
+	created := fake.subscriptions[sub.path]
+	require.Equal(t, float64(30), created["ackDeadlineSeconds"])
+	require.Equal(t, true, created["enableMessageOrdering"])
+	require.Equal(t, map[string]interface{}{
+		"deadLetterTopic":     "projects/test-project/topics/orders-dlq",
+		"maxDeliveryAttempts": float64(5),
+	}, created["deadLetterPolicy"])
+
+	// updated once it exists
+	managed, err = ps.ensureSubscription(sub, cfg)
+	require.NoError(t, err)
+	require.True(t, managed)
+	require.Equal(t, "PATCH "+sub.path, fake.requests[1])
+	require.Equal(t, "ackDeadlineSeconds,deadLetterPolicy", fake.bodies[1]["updateMask"])
+
+	// only settings in the metadata of the trigger are updated on
+	// subscriptions created otherwise
+	theirs := &subscription{path: "projects/test-project/subscriptions/theirs", topic: sub.topic}
+	managed, err = ps.ensureSubscription(theirs, &subscriptionConfig{ackDeadline: 30, set: map[string]bool{}})
+	require.NoError(t, err)
+	require.False(t, managed)
+	require.Len(t, fake.requests, 2)
+	_, err = ps.ensureSubscription(theirs, &subscriptionConfig{ackDeadline: 30, set: map[string]bool{MetadataAckDeadline: true}})
+	require.NoError(t, err)
+	require.Equal(t, "ackDeadlineSeconds", fake.bodies[2]["updateMask"])
+
+	// subscriptions to other topics are refused
+	_, err = ps.ensureSubscription(&subscription{path: theirs.path, topic: "projects/test-project/topics/other"}, cfg)
+	require.Error(t, err)
+
+	// only the subscriptions created are removed
+	require.NoError(t, ps.Remove(theirs))
+	require.Contains(t, fake.subscriptions, theirs.path)
+	require.NoError(t, ps.Remove(&subscription{path: sub.path, managed: true}))
+	require.NotContains(t, fake.subscriptions, sub.path)
+}
+
+func TestMsgHandler(t *testing.T) {
+	router := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("X-Order") != "42" {
+			t.Errorf("expected message attributes as headers, got %v", r.Header)
+		}
+		if string(body) == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, "failed")
+			return
+		}
+		fmt.Fprint(w, "done")
+	}))
+	defer router.Close()
+
+	fake := &fakePubSub{subscriptions: map[string]map[string]interface{}{}}
+	ps, stop := newTestPubSub(t, fake, router.URL)
+	defer stop()
+
+	trigger := &fv1.MessageQueueTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "default"},
+		Spec: fv1.MessageQueueTriggerSpec{
+			FunctionReference: fv1.FunctionReference{Type: fv1.FunctionReferenceTypeFunctionName, Name: "process"},
+			Topic:             "orders",
+			ResponseTopic:     "orders-done",
+			ErrorTopic:        "orders-failed",
+		},
+	}
+	sub := &subscription{
+		path:          "projects/test-project/subscriptions/orders",
+		responseTopic: "projects/test-project/topics/orders-done",
+		errorTopic:    "projects/test-project/topics/orders-failed",
+		retry:         &messageQueue.RetryPolicy{MaxAttempts: 1},
+	}
+	cfg := &subscriptionConfig{ackDeadline: 60}
+	message := func(ackID string, data string) receivedMessage {
+		return receivedMessage{AckID: ackID, Message: pubsubMessage{
+			Data:        base64.StdEncoding.EncodeToString([]byte(data)),
+			Attributes:  map[string]string{"X-Order": "42"},
+			PublishTime: time.Now().Format(time.RFC3339Nano),
+		}}
+	}
+
+	require.True(t, ps.msgHandler(trigger, cfg, sub, message("ack-1", "order")))
+	require.Equal(t, []string{
+		"POST projects/test-project/topics/orders-done:publish",
+		"POST projects/test-project/subscriptions/orders:acknowledge",
+	}, fake.requests)
+	require.Equal(t, []interface{}{"ack-1"}, fake.bodies[1]["ackIds"])
+
+	require.False(t, ps.msgHandler(trigger, cfg, sub, message("ack-2", "fail")))
+	require.Equal(t, []string{
+		"POST projects/test-project/topics/orders-failed:publish",
+		"POST projects/test-project/subscriptions/orders:modifyAckDeadline",
+	}, fake.requests[2:])
+	require.Equal(t, []interface{}{"ack-2"}, fake.bodies[3]["ackIds"])
+	require.Equal(t, float64(0), fake.bodies[3]["ackDeadlineSeconds"])
+
+	// messages dead-lettered are acked
+	sub.retry.DeadLetterTopic = "orders-dead"
+	sub.deadLetterTopic = "projects/test-project/topics/orders-dead"
+	require.False(t, ps.msgHandler(trigger, cfg, sub, message("ack-3", "fail")))
+	require.Equal(t, []string{
+		"POST projects/test-project/topics/orders-failed:publish",
+		"POST projects/test-project/topics/orders-dead:publish",
+		"POST projects/test-project/subscriptions/orders:acknowledge",
+	}, fake.requests[4:])
+	require.Equal(t, []interface{}{"ack-3"}, fake.bodies[6]["ackIds"])
+}
+
+func TestOrderedGroups(t *testing.T) {
+	msg := func(id string, key string) receivedMessage {
+		return receivedMessage{AckID: id, Message: pubsubMessage{OrderingKey: key}}
+	}
+	msgs := []receivedMessage{msg("1", "a"), msg("2", "b"), msg("3", "a"), msg("4", ""), msg("5", "")}
+
+	ids := func(groups [][]receivedMessage) [][]string {
+		var result [][]string
+		for _, group := range groups {
+			var g []string
+			for _, m := range group {
+				g = append(g, m.AckID)
+			}
+			result = append(result, g)
+		}
+		return result
+	}
+	require.Equal(t, [][]string{{"1", "3"}, {"2"}, {"4"}, {"5"}}, ids(orderedGroups(msgs, true)))
+	require.Equal(t, [][]string{{"1"}, {"2"}, {"3"}, {"4"}, {"5"}}, ids(orderedGroups(msgs, false)))
+}
+
+func TestIsTopicValid(t *testing.T) {
+	require.True(t, IsTopicValid("orders"))
+	require.True(t, IsTopicValid("orders.v1_created~%2B"))
+	require.True(t, IsTopicValid("projects/my-project/topics/orders"))
+	require.False(t, IsTopicValid("or"))
+	require.False(t, IsTopicValid("1orders"))
+	require.False(t, IsTopicValid("google-orders"))
+	require.False(t, IsTopicValid("projects/my-project/subscriptions/orders"))
+	require.False(t, IsTopicValid("projects/My_Project/topics/orders"))
+}
@@ -0,0 +1,299 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sarama "github.com/Shopify/sarama"
+	"github.com/pkg/errors"
+	"github.com/xdg/scram"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// The authentication settings of a trigger, the same as those of the Kafka
+// scaler of KEDA, are read from the secret of the trigger, or from its
+// metadata for those other than credentials. Triggers without them
+// connect as set for the trigger manager.
+const (
+	// MetadataSASL is the SASL mechanism to authenticate with: plaintext,
+	// scram_sha256, scram_sha512, oauthbearer, or none.
+	MetadataSASL = "sasl"
+	// MetadataUsername and MetadataPassword are the SASL credentials, the
+	// client ID and secret of the OAuth client credentials grant with
+	// oauthbearer.
+	MetadataUsername = "username"
+	MetadataPassword = "password"
+	// MetadataTLS is whether to connect with TLS: enable or disable.
+	MetadataTLS = "tls"
+	// MetadataCA is the PEM CA certificate to verify brokers with, the
+	// system ones if empty.
+	MetadataCA = "ca"
+	// MetadataCert and MetadataKey are the PEM client certificate and key
+	// to authenticate with mutual TLS.
+	MetadataCert = "cert"
+	MetadataKey  = "key"
+	// MetadataOAuthTokenEndpointUri is the token endpoint of the OAuth
+	// server to get access tokens from with oauthbearer.
+	MetadataOAuthTokenEndpointUri = "oauthTokenEndpointUri"
+	// MetadataScopes is the comma-separated scopes of the access tokens.
+	MetadataScopes = "scopes"
+
+	saslNone         = "none"
+	saslPlaintext    = "plaintext"
+	saslSCRAMSHA256  = "scram_sha256"
+	saslSCRAMSHA512  = "scram_sha512"
+	saslOAuthBearer  = "oauthbearer"
+	tokenExpiryDelta = time.Minute
+)
+
+type (
+	// authConfig is the authentication settings of a trigger.
+	authConfig struct {
+		sasl     string
+		username string
+		password string
+		tls      bool
+		ca       []byte
+		cert     []byte
+		key      []byte
+		tokenUrl string
+		scopes   []string
+	}
+
+	// scramClient is the SCRAM conversation of sarama with a broker.
+	scramClient struct {
+		*scram.ClientConversation
+		hashGenerator scram.HashGeneratorFcn
+	}
+
+	// tokenProvider gets access tokens with the OAuth client credentials
+	// grant, reusing them until they're about to expire.
+	tokenProvider struct {
+		httpClient   *http.Client
+		tokenUrl     string
+		clientID     string
+		clientSecret string
+		scopes       []string
+
+		lock   sync.Mutex
+		token  string
+		expiry time.Time
+	}
+)
+
+// parseAuth reads the authentication settings of a trigger, nil if it has
+// none.
+func (kafka Kafka) parseAuth(trigger *fv1.MessageQueueTrigger) (*authConfig, error) {
+	settings := make(map[string]string)
+	for _, k := range []string{MetadataSASL, MetadataTLS, MetadataOAuthTokenEndpointUri, MetadataScopes} {
+		if v, ok := trigger.Spec.Metadata[k]; ok {
+			settings[k] = v
+		}
+	}
+	if len(trigger.Spec.Secret) > 0 {
+		if kafka.secretGetter == nil {
+			return nil, errors.Errorf("can't read secret %q of trigger", trigger.Spec.Secret)
+		}
+		data, err := kafka.secretGetter(trigger.ObjectMeta.Namespace, trigger.Spec.Secret)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading secret %q of trigger", trigger.Spec.Secret)
+		}
+		for k, v := range data {
+			settings[k] = string(v)
+		}
+	}
+	if len(settings[MetadataSASL]) == 0 && len(settings[MetadataTLS]) == 0 {
+		return nil, nil
+	}
+
+	auth := &authConfig{
+		sasl:     strings.TrimSpace(settings[MetadataSASL]),
+		username: strings.TrimSpace(settings[MetadataUsername]),
+		password: strings.TrimSpace(settings[MetadataPassword]),
+		ca:       []byte(settings[MetadataCA]),
+		cert:     []byte(settings[MetadataCert]),
+		key:      []byte(settings[MetadataKey]),
+		tokenUrl: strings.TrimSpace(settings[MetadataOAuthTokenEndpointUri]),
+	}
+	if len(auth.sasl) == 0 {
+		auth.sasl = saslNone
+	}
+	switch auth.sasl {
+	case saslNone:
+	case saslPlaintext, saslSCRAMSHA256, saslSCRAMSHA512:
+		if len(auth.username) == 0 || len(auth.password) == 0 {
+			return nil, errors.Errorf("%v authentication needs %v and %v", auth.sasl, MetadataUsername, MetadataPassword)
+		}
+	case saslOAuthBearer:
+		if len(auth.username) == 0 || len(auth.password) == 0 || len(auth.tokenUrl) == 0 {
+			return nil, errors.Errorf("%v authentication needs %v, %v and %v", auth.sasl, MetadataUsername, MetadataPassword, MetadataOAuthTokenEndpointUri)
+		}
+	default:
+		return nil, errors.Errorf("not a valid %v: %q", MetadataSASL, auth.sasl)
+	}
+
+	switch tlsSetting := strings.TrimSpace(settings[MetadataTLS]); tlsSetting {
+	case "enable":
+		auth.tls = true
+	case "", "disable":
+	default:
+		return nil, errors.Errorf("not a valid %v: %q", MetadataTLS, tlsSetting)
+	}
+	if (len(auth.cert) > 0) != (len(auth.key) > 0) {
+		return nil, errors.Errorf("mutual TLS needs both %v and %v", MetadataCert, MetadataKey)
+	}
+
+	for _, scope := range strings.Split(settings[MetadataScopes], ",") {
+		if scope = strings.TrimSpace(scope); len(scope) > 0 {
+			auth.scopes = append(auth.scopes, scope)
+		}
+	}
+	return auth, nil
+}
+
+// configure sets the authentication settings on a config, in place of those
+// of the trigger manager.
+func (auth *authConfig) configure(config *sarama.Config) error {
+	config.Net.TLS.Enable = auth.tls
+	config.Net.TLS.Config = nil
+	if auth.tls {
+		tlsConfig := &tls.Config{}
+		if len(auth.ca) > 0 {
+			caCertPool := x509.NewCertPool()
+			if !caCertPool.AppendCertsFromPEM(auth.ca) {
+				return errors.New("error parsing CA certificate")
+			}
+			tlsConfig.RootCAs = caCertPool
+		}
+		if len(auth.cert) > 0 {
+			cert, err := tls.X509KeyPair(auth.cert, auth.key)
+			if err != nil {
+				return errors.Wrap(err, "error parsing client certificate")
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		if skipVerify, err := strconv.ParseBool(os.Getenv("INSECURE_SKIP_VERIFY")); err == nil {
+			tlsConfig.InsecureSkipVerify = skipVerify
+		}
+		config.Net.TLS.Config = tlsConfig
+	}
+
+	if auth.sasl == saslNone {
+		return nil
+	}
+	config.Net.SASL.Enable = true
+	config.Net.SASL.Handshake = true
+	if config.Version.IsAtLeast(sarama.V1_0_0_0) {
+		config.Net.SASL.Version = sarama.SASLHandshakeV1
+	}
+	switch auth.sasl {
+	case saslPlaintext:
+		config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		config.Net.SASL.User = auth.username
+		config.Net.SASL.Password = auth.password
+	case saslSCRAMSHA256, saslSCRAMSHA512:
+		config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+		hashGenerator := scram.HashGeneratorFcn(sha256.New)
+		if auth.sasl == saslSCRAMSHA512 {
+			config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+			hashGenerator = sha512.New
+		}
+		config.Net.SASL.User = auth.username
+		config.Net.SASL.Password = auth.password
+		config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{hashGenerator: hashGenerator}
+		}
+	case saslOAuthBearer:
+		config.Net.SASL.Mechanism = sarama.SASLTypeOAuth
+		config.Net.SASL.TokenProvider = &tokenProvider{
+			httpClient:   &http.Client{Timeout: 30 * time.Second},
+			tokenUrl:     auth.tokenUrl,
+			clientID:     auth.username,
+			clientSecret: auth.password,
+			scopes:       auth.scopes,
+		}
+	}
+	return nil
+}
+
+func (c *scramClient) Begin(userName, password, authzID string) error {
+	client, err := c.hashGenerator.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	c.ClientConversation = client.NewConversation()
+	return nil
+}
+
+// Token returns an access token, getting a new one once the last one is
+// about to expire.
+func (tp *tokenProvider) Token() (*sarama.AccessToken, error) {
+	tp.lock.Lock()
+	defer tp.lock.Unlock()
+	if len(tp.token) > 0 && time.Now().Before(tp.expiry) {
+		return &sarama.AccessToken{Token: tp.token}, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(tp.scopes) > 0 {
+		form.Set("scope", strings.Join(tp.scopes, " "))
+	}
+	req, err := http.NewRequest(http.MethodPost, tp.tokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(tp.clientID), url.QueryEscape(tp.clientSecret))
+
+	resp, err := tp.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting access token")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("error getting access token, status code: %v", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&token)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding access token")
+	}
+	if len(token.AccessToken) == 0 {
+		return nil, errors.New("no access token in response of token endpoint")
+	}
+
+	tp.token = token.AccessToken
+	tp.expiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - tokenExpiryDelta)
+	return &sarama.AccessToken{Token: tp.token}, nil
+}
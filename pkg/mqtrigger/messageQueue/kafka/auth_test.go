@@ -0,0 +1,124 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sarama "github.com/Shopify/sarama"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestParseAuth(t *testing.T) {
+	secrets := map[string]map[string][]byte{
+		"default/scram": {
+			MetadataSASL:     []byte("scram_sha512"),
+			MetadataUsername: []byte("fission"),
+			MetadataPassword: []byte("s3cr3t\n"),
+		},
+		"default/no-password": {
+			MetadataSASL:     []byte("plaintext"),
+			MetadataUsername: []byte("fission"),
+		},
+	}
+	kafka := Kafka{
+		version: sarama.V1_0_0_0,
+		secretGetter: func(namespace string, name string) (map[string][]byte, error) {
+			data, ok := secrets[namespace+"/"+name]
+			if !ok {
+				return nil, fmt.Errorf("secret %q not found", name)
+			}
+			return data, nil
+		},
+	}
+	trigger := func(secret string, metadata map[string]string) *fv1.MessageQueueTrigger {
+		return &fv1.MessageQueueTrigger{
+			ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "default"},
+			Spec:       fv1.MessageQueueTriggerSpec{Secret: secret, Metadata: metadata},
+		}
+	}
+
+	// triggers without authentication settings connect as the manager
+	auth, err := kafka.parseAuth(trigger("", nil))
+	require.NoError(t, err)
+	require.Nil(t, auth)
+
+	auth, err = kafka.parseAuth(trigger("scram", map[string]string{MetadataTLS: "enable"}))
+	require.NoError(t, err)
+	require.Equal(t, &authConfig{sasl: saslSCRAMSHA512, username: "fission", password: "s3cr3t", tls: true, ca: []byte{}, cert: []byte{}, key: []byte{}}, auth)
+
+	config := sarama.NewConfig()
+	config.Version = kafka.version
+	require.NoError(t, auth.configure(config))
+	require.True(t, config.Net.TLS.Enable)
+	require.True(t, config.Net.SASL.Enable)
+	require.Equal(t, sarama.SASLMechanism(sarama.SASLTypeSCRAMSHA512), config.Net.SASL.Mechanism)
+	require.Equal(t, sarama.SASLHandshakeV1, config.Net.SASL.Version)
+	require.NotNil(t, config.Net.SASL.SCRAMClientGeneratorFunc())
+
+	_, err = kafka.parseAuth(trigger("no-password", nil))
+	require.Error(t, err)
+	_, err = kafka.parseAuth(trigger("missing", nil))
+	require.Error(t, err)
+	_, err = kafka.parseAuth(trigger("", map[string]string{MetadataSASL: "gssapi"}))
+	require.Error(t, err)
+	_, err = kafka.parseAuth(trigger("", map[string]string{MetadataTLS: "yes"}))
+	require.Error(t, err)
+}
+
+func TestTokenProvider(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		clientID, clientSecret, _ := r.BasicAuth()
+		if clientID != "fission" || clientSecret != "s3cr3t" ||
+			r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "kafka read" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token": "token-%v", "expires_in": 3600}`, requests)
+	}))
+	defer server.Close()
+
+	tp := &tokenProvider{
+		httpClient:   server.Client(),
+		tokenUrl:     server.URL,
+		clientID:     "fission",
+		clientSecret: "s3cr3t",
+		scopes:       []string{"kafka", "read"},
+	}
+	token, err := tp.Token()
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token.Token)
+
+	// tokens are reused until they're about to expire
+	token, err = tp.Token()
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token.Token)
+	require.Equal(t, 1, requests)
+
+	tp.clientSecret = "wrong"
+	tp.token = ""
+	_, err = tp.Token()
+	require.Error(t, err)
+}
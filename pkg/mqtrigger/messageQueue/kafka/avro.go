@@ -0,0 +1,62 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"github.com/linkedin/goavro/v2"
+	"github.com/pkg/errors"
+)
+
+// avroMaxBlock bounds the items and bytes of the blocks of Avro arrays,
+// maps, strings and bytes, which goavro allocates before decoding them.
+// A block can't hold more than a message, and messages are at most 1MB
+// with the default settings of brokers.
+const avroMaxBlock = 1 << 20
+
+func init() {
+	goavro.MaxBlockCount = avroMaxBlock
+	goavro.MaxBlockSize = avroMaxBlock
+}
+
+type (
+	// avroSchema is a parsed Avro schema.
+	avroSchema struct {
+		codec *goavro.Codec
+	}
+)
+
+func parseAvroSchema(schema string) (*avroSchema, error) {
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing Avro schema")
+	}
+	return &avroSchema{codec: codec}, nil
+}
+
+// decode decodes a value in the Avro binary encoding to the JSON encoding
+// of Avro: unions are objects with the value keyed by its type, unless
+// null, and bytes and fixed are strings of their code points.
+func (s *avroSchema) decode(data []byte) ([]byte, error) {
+	native, rest, err := s.codec.NativeFromBinary(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errors.Errorf("%v bytes left after decoding", len(rest))
+	}
+	return s.codec.TextualFromNative(nil, native)
+}
@@ -26,6 +26,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	sarama "github.com/Shopify/sarama"
 	cluster "github.com/bsm/sarama-cluster"
@@ -60,6 +61,16 @@ type (
 		version   sarama.KafkaVersion
 		authKeys  map[string][]byte
 		tls       bool
+		// secretGetter reads the secrets of triggers with their own
+		// authentication settings.
+		secretGetter messageQueue.SecretGetter
+	}
+
+	// batchSubscription is the subscription of a trigger whose function
+	// is invoked with batches of messages.
+	batchSubscription struct {
+		consumer *cluster.Consumer
+		batcher  *messageQueue.Batcher
 	}
 
 	Factory struct{}
@@ -89,6 +100,8 @@ func New(logger *zap.Logger, mqCfg messageQueue.Config, routerUrl string) (messa
 		routerUrl: routerUrl,
 		brokers:   strings.Split(mqCfg.Url, ","),
 		version:   kafkaVersion,
+
+		secretGetter: mqCfg.SecretGetter,
 	}
 
 	if tls, _ := strconv.ParseBool(os.Getenv("TLS_ENABLED")); tls {
@@ -115,6 +128,15 @@ func (kafka Kafka) Subscribe(trigger *fv1.MessageQueueTrigger) (messageQueue.Sub
 	kafka.logger.Info("inside kakfa subscribe", zap.Any("trigger", trigger))
 	kafka.logger.Info("brokers set", zap.Strings("brokers", kafka.brokers))
 
+	retry, err := messageQueue.NewRetryPolicy(trigger)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := kafka.newSchemaRegistry(trigger)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create new consumer
 	consumerConfig := cluster.NewConfig()
 	consumerConfig.Consumer.Return.Errors = true
@@ -128,18 +150,14 @@ func (kafka Kafka) Subscribe(trigger *fv1.MessageQueueTrigger) (messageQueue.Sub
 	producerConfig.Producer.Return.Successes = true
 	producerConfig.Version = kafka.version
 
-	// Setup TLS for both producer and consumer
-	if kafka.tls {
-		consumerConfig.Net.TLS.Enable = true
-		producerConfig.Net.TLS.Enable = true
-		tlsConfig, err := kafka.getTLSConfig()
-
-		if err != nil {
-			return nil, err
-		}
-
-		producerConfig.Net.TLS.Config = tlsConfig
-		consumerConfig.Net.TLS.Config = tlsConfig
+	// Setup authentication for both producer and consumer
+	err = kafka.configureAuth(&consumerConfig.Config, trigger)
+	if err != nil {
+		return nil, err
+	}
+	err = kafka.configureAuth(producerConfig, trigger)
+	if err != nil {
+		return nil, err
 	}
 
 	consumer, err := cluster.NewConsumer(kafka.brokers, string(trigger.ObjectMeta.UID), []string{trigger.Spec.Topic}, consumerConfig)
@@ -181,17 +199,83 @@ func (kafka Kafka) Subscribe(trigger *fv1.MessageQueueTrigger) (messageQueue.Sub
 		}
 	}()
 
+	if messageQueue.IsBatched(trigger) {
+		batcher, err := messageQueue.NewBatcher(kafka.logger, kafka.routerUrl, trigger, func(topic string, body []byte, contentType string) error {
+			_, _, err := producer.SendMessage(&sarama.ProducerMessage{
+				Topic: topic,
+				Value: sarama.ByteEncoder(body),
+			})
+			return err
+		})
+		if err != nil {
+			consumer.Close()
+			return nil, err
+		}
+
+		// consume messages in batches, marking every message of a batch
+		// as processed once the function handled it
+		go func() {
+			for msg := range consumer.Messages() {
+				msg := msg
+				body := msg.Value
+				if registry != nil {
+					// messages failing to deserialize are passed as is
+					value, _, err := registry.deserialize(msg.Value)
+					if err != nil {
+						kafka.logger.Error("error deserializing message", zap.Error(err),
+							zap.String("trigger", trigger.ObjectMeta.Name))
+					} else {
+						body = value
+					}
+				}
+				batcher.Add(messageQueue.BatchMessage{
+					Body: body,
+					Ack:  func() { consumer.MarkOffset(msg, "") },
+				})
+			}
+		}()
+
+		return &batchSubscription{consumer: consumer, batcher: batcher}, nil
+	}
+
 	// consume messages
+	limiter := messageQueue.NewLimiter(trigger)
 	go func() {
 		for msg := range consumer.Messages() {
 			kafka.logger.Debug("calling message handler", zap.String("message", string(msg.Value[:])))
-			go kafkaMsgHandler(&kafka, producer, trigger, msg, consumer)
+			limiter.Acquire()
+			go func(msg *sarama.ConsumerMessage) {
+				defer limiter.Release()
+				kafkaMsgHandler(&kafka, producer, trigger, retry, registry, msg, consumer)
+			}(msg)
 		}
 	}()
 
 	return consumer, nil
 }
 
+// configureAuth sets up the authentication of a trigger on a config: its
+// own, if set, or else TLS with the certificates of the trigger manager if
+// enabled.
+func (kafka Kafka) configureAuth(config *sarama.Config, trigger *fv1.MessageQueueTrigger) error {
+	auth, err := kafka.parseAuth(trigger)
+	if err != nil {
+		return err
+	}
+	if auth != nil {
+		return auth.configure(config)
+	}
+	if kafka.tls {
+		tlsConfig, err := kafka.getTLSConfig()
+		if err != nil {
+			return err
+		}
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = tlsConfig
+	}
+	return nil
+}
+
 func (kafka Kafka) getTLSConfig() (*tls.Config, error) {
 	tlsConfig := tls.Config{}
 	cert, err := tls.X509KeyPair(kafka.authKeys["userCert"], kafka.authKeys["userKey"])
@@ -218,10 +302,74 @@ func (kafka Kafka) getTLSConfig() (*tls.Config, error) {
 }
 
 func (kafka Kafka) Unsubscribe(subscription messageQueue.Subscription) error {
+	if sub, ok := subscription.(*batchSubscription); ok {
+		// the offsets of the batches handled are committed on close
+		sub.batcher.Close()
+		return sub.consumer.Close()
+	}
 	return subscription.(*cluster.Consumer).Close()
 }
 
-func kafkaMsgHandler(kafka *Kafka, producer sarama.SyncProducer, trigger *fv1.MessageQueueTrigger, msg *sarama.ConsumerMessage, consumer *cluster.Consumer) {
+// Lag returns the messages of the topic of a trigger after the offsets
+// committed by its consumer group, summed over the partitions of the topic.
+func (kafka Kafka) Lag(trigger *fv1.MessageQueueTrigger) (int64, error) {
+	config := sarama.NewConfig()
+	config.Version = kafka.version
+	err := kafka.configureAuth(config, trigger)
+	if err != nil {
+		return 0, err
+	}
+
+	client, err := sarama.NewClient(kafka.brokers, config)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	group := string(trigger.ObjectMeta.UID)
+	partitions, err := client.Partitions(trigger.Spec.Topic)
+	if err != nil {
+		return 0, err
+	}
+	coordinator, err := client.Coordinator(group)
+	if err != nil {
+		return 0, err
+	}
+	req := &sarama.OffsetFetchRequest{Version: 1, ConsumerGroup: group}
+	for _, partition := range partitions {
+		req.AddPartition(trigger.Spec.Topic, partition)
+	}
+	resp, err := coordinator.FetchOffset(req)
+	if err != nil {
+		return 0, err
+	}
+
+	var lag int64
+	for _, partition := range partitions {
+		block := resp.GetBlock(trigger.Spec.Topic, partition)
+		if block == nil {
+			return 0, errors.Errorf("no offset of partition %v of topic %q", partition, trigger.Spec.Topic)
+		}
+		if block.Err != sarama.ErrNoError {
+			return 0, block.Err
+		}
+		// the group consumes partitions without a committed offset from
+		// the newest messages
+		if block.Offset < 0 {
+			continue
+		}
+		newest, err := client.GetOffset(trigger.Spec.Topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return 0, err
+		}
+		if newest > block.Offset {
+			lag += newest - block.Offset
+		}
+	}
+	return lag, nil
+}
+
+func kafkaMsgHandler(kafka *Kafka, producer sarama.SyncProducer, trigger *fv1.MessageQueueTrigger, retry *messageQueue.RetryPolicy, registry *schemaRegistry, msg *sarama.ConsumerMessage, consumer *cluster.Consumer) {
 	var value string = string(msg.Value[:])
 	// Support other function ref types
 	if trigger.Spec.FunctionReference.Type != fv1.FunctionReferenceTypeFunctionName {
@@ -239,53 +387,21 @@ func kafkaMsgHandler(kafka *Kafka, producer sarama.SyncProducer, trigger *fv1.Me
 		"X-Fission-MQTrigger-RespTopic":  trigger.Spec.ResponseTopic,
 		"X-Fission-MQTrigger-ErrorTopic": trigger.Spec.ErrorTopic,
 		"Content-Type":                   trigger.Spec.ContentType,
+		fv1.HeaderTriggerType:            fv1.TriggerTypeMessageQueue,
+		fv1.HeaderTriggerName:            trigger.ObjectMeta.Name,
+		fv1.HeaderCloudEventsMode:        string(trigger.Spec.CloudEvents),
+		fv1.HeaderPriority:               string(fv1.InvocationPriorityBatch),
 	}
-
-	// Create request
-	req, err := http.NewRequest("POST", url, strings.NewReader(value))
-	if err != nil {
-		kafka.logger.Error("failed to create HTTP request to invoke function",
-			zap.Error(err),
-			zap.String("function_url", url))
-		return
+	// timestamps are only available since Kafka v0.10
+	if !msg.Timestamp.IsZero() {
+		fissionHeaders[fv1.HeaderEventTime] = msg.Timestamp.UTC().Format(time.RFC3339Nano)
 	}
 
-	// Set the headers came from Kafka record
-	// Using Header.Add() as msg.Headers may have keys with more than one value
-	if kafka.version.IsAtLeast(sarama.V0_11_0_0) {
-		for _, h := range msg.Headers {
-			req.Header.Add(string(h.Key), string(h.Value))
-		}
-	} else {
+	if !kafka.version.IsAtLeast(sarama.V0_11_0_0) {
 		kafka.logger.Warn("headers are not supported by current Kafka version, needs v0.11+: no record headers to add in HTTP request",
 			zap.Any("current_version", kafka.version))
 	}
 
-	for k, v := range fissionHeaders {
-		req.Header.Set(k, v)
-	}
-
-	// Make the request
-	var resp *http.Response
-	for attempt := 0; attempt <= trigger.Spec.MaxRetries; attempt++ {
-		// Make the request
-		resp, err = http.DefaultClient.Do(req)
-		if err != nil {
-			kafka.logger.Error("sending function invocation request failed",
-				zap.Error(err),
-				zap.String("function_url", url),
-				zap.String("trigger", trigger.ObjectMeta.Name))
-			continue
-		}
-		if resp == nil {
-			continue
-		}
-		if err == nil && resp.StatusCode == http.StatusOK {
-			// Success, quit retrying
-			break
-		}
-	}
-
 	generateErrorHeaders := func(errString string) []sarama.RecordHeader {
 		var errorHeaders []sarama.RecordHeader
 		if kafka.version.IsAtLeast(sarama.V0_11_0_0) {
@@ -300,11 +416,62 @@ func kafkaMsgHandler(kafka *Kafka, producer sarama.SyncProducer, trigger *fv1.Me
 		return errorHeaders
 	}
 
-	if resp == nil {
-		errorString := fmt.Sprintf("request exceed retries: %v", trigger.Spec.MaxRetries)
+	// the message itself is marked as processed once dead-lettered
+	deadLetter := func() {
+		if retry.DeadLetter(kafka.logger, msg.Value, trigger.Spec.ContentType, func(topic string, body []byte, contentType string) error {
+			_, _, err := producer.SendMessage(&sarama.ProducerMessage{
+				Topic: topic,
+				Value: sarama.ByteEncoder(body),
+			})
+			return err
+		}) {
+			consumer.MarkOffset(msg, "")
+		}
+	}
+
+	// messages serialized with a schema of the registry are passed as JSON
+	if registry != nil {
+		deserialized, id, err := registry.deserialize(msg.Value)
+		if err != nil {
+			errorString := "deserialization error: " + err.Error()
+			errorHandler(kafka.logger, trigger, producer, url, err, generateErrorHeaders(errorString))
+			deadLetter()
+			return
+		}
+		if id >= 0 {
+			value = string(deserialized)
+			fissionHeaders[HeaderSchemaId] = strconv.Itoa(id)
+			fissionHeaders["Content-Type"] = "application/json"
+		}
+	}
+
+	// Make the request
+	resp, err := retry.Invoke(kafka.logger.With(zap.String("trigger", trigger.ObjectMeta.Name)), func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", url, strings.NewReader(value))
+		if err != nil {
+			return nil, err
+		}
+
+		// Set the headers came from Kafka record
+		// Using Header.Add() as msg.Headers may have keys with more than one value
+		if kafka.version.IsAtLeast(sarama.V0_11_0_0) {
+			for _, h := range msg.Headers {
+				req.Header.Add(string(h.Key), string(h.Value))
+			}
+		}
+
+		for k, v := range fissionHeaders {
+			req.Header.Set(k, v)
+		}
+		return req, nil
+	})
+
+	if err != nil {
+		errorString := fmt.Sprintf("request exceed retries: %v", retry.MaxAttempts-1)
 		errorHeaders := generateErrorHeaders(errorString)
 		errorHandler(kafka.logger, trigger, producer, url,
 			fmt.Errorf(errorString), errorHeaders)
+		deadLetter()
 		return
 	}
 	defer resp.Body.Close()
@@ -320,6 +487,7 @@ func kafkaMsgHandler(kafka *Kafka, producer sarama.SyncProducer, trigger *fv1.Me
 		errorHeaders := generateErrorHeaders(errorString)
 		errorHandler(kafka.logger, trigger, producer, url,
 			errors.Wrapf(err, errorString), errorHeaders)
+		deadLetter()
 		return
 	}
 	if resp.StatusCode != 200 {
@@ -327,6 +495,7 @@ func kafkaMsgHandler(kafka *Kafka, producer sarama.SyncProducer, trigger *fv1.Me
 		errorHeaders := generateErrorHeaders(errorString)
 		errorHandler(kafka.logger, trigger, producer, url,
 			fmt.Errorf("request returned failure: %v", resp.StatusCode), errorHeaders)
+		deadLetter()
 		return
 	}
 	if len(trigger.Spec.ResponseTopic) > 0 {
@@ -0,0 +1,125 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type (
+	// protoSchema is a parsed .proto schema.
+	protoSchema struct {
+		file protoreflect.FileDescriptor
+	}
+)
+
+// parseProtoSchema parses a .proto schema named name, and the schemas it
+// imports, by their import paths. The well-known types of
+// google/protobuf/ needn't be in imports.
+func parseProtoSchema(name string, schema string, imports map[string]string) (*protoSchema, error) {
+	files := map[string]string{name: schema}
+	for path, src := range imports {
+		files[path] = src
+	}
+	parser := protoparse.Parser{Accessor: protoparse.FileContentsFromMap(files)}
+	fds, err := parser.ParseFiles(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing Protobuf schema")
+	}
+	file, err := protoFileDescriptor(fds[0], new(protoregistry.Files))
+	if err != nil {
+		return nil, errors.Wrap(err, "error building Protobuf schema")
+	}
+	return &protoSchema{file: file}, nil
+}
+
+// protoFileDescriptor returns the descriptor of a parsed file, registering
+// it in files after its dependencies.
+func protoFileDescriptor(fd *desc.FileDescriptor, files *protoregistry.Files) (protoreflect.FileDescriptor, error) {
+	if file, err := files.FindFileByPath(fd.GetName()); err == nil {
+		return file, nil
+	}
+	for _, dep := range fd.GetDependencies() {
+		if _, err := protoFileDescriptor(dep, files); err != nil {
+			return nil, err
+		}
+	}
+	file, err := protodesc.NewFile(fd.AsFileDescriptorProto(), files)
+	if err != nil {
+		return nil, err
+	}
+	return file, files.RegisterFile(file)
+}
+
+// decode decodes a message serialized with the schema, prefixed with the
+// indexes of its message type: the count of indexes, then the indexes, as
+// zigzag varints, with a count of 0 for the first message. The message is
+// returned in the JSON mapping of proto3.
+func (s *protoSchema) decode(data []byte) ([]byte, error) {
+	count, n := binary.Varint(data)
+	if n <= 0 || count < 0 || count > int64(len(data)-n) {
+		return nil, errors.New("not a valid count of message indexes")
+	}
+	data = data[n:]
+	indexes := []int64{0}
+	if count > 0 {
+		indexes = make([]int64, count)
+		for i := range indexes {
+			indexes[i], n = binary.Varint(data)
+			if n <= 0 {
+				return nil, errors.New("not a valid message index")
+			}
+			data = data[n:]
+		}
+	}
+
+	messages := s.file.Messages()
+	var md protoreflect.MessageDescriptor
+	for _, i := range indexes {
+		if i < 0 || i >= int64(messages.Len()) {
+			return nil, errors.Errorf("message index %v out of range", i)
+		}
+		md = messages.Get(int(i))
+		messages = md.Messages()
+	}
+
+	msg := dynamicpb.NewMessage(md)
+	err := proto.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, msg)
+	if err != nil {
+		return nil, err
+	}
+	body, err := protojson.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	// protojson randomizes its whitespace
+	var buf bytes.Buffer
+	err = json.Compact(&buf, body)
+	return buf.Bytes(), err
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	// MetadataSchemaRegistryUrl is the URL of a Confluent compatible
+	// schema registry. Messages serialized with a schema of the registry
+	// are deserialized to JSON before being passed to the function: Avro
+	// ones to the JSON encoding of Avro, Protobuf ones to the JSON mapping
+	// of proto3.
+	MetadataSchemaRegistryUrl = "schemaRegistryUrl"
+	// MetadataSchemaRegistryUsername and MetadataSchemaRegistryPassword
+	// are the basic auth credentials of the schema registry, read from the
+	// secret of the trigger, e.g. the API key and secret on Confluent
+	// Cloud.
+	MetadataSchemaRegistryUsername = "schemaRegistryUsername"
+	MetadataSchemaRegistryPassword = "schemaRegistryPassword"
+
+	// HeaderSchemaId is the header of the ID of the schema a message was
+	// serialized with.
+	HeaderSchemaId = "X-Fission-MQTrigger-SchemaId"
+
+	schemaTypeAvro     = "AVRO"
+	schemaTypeProtobuf = "PROTOBUF"
+	schemaTypeJSON     = "JSON"
+
+	// magicByte starts the messages serialized with a schema of the
+	// registry, followed by the schema ID as a 4-byte big-endian integer.
+	magicByte = 0
+)
+
+type (
+	// schemaRegistry deserializes the messages of a trigger with the
+	// schemas of a registry, caching the schemas by ID, as they never
+	// change.
+	schemaRegistry struct {
+		httpClient *http.Client
+		url        string
+		username   string
+		password   string
+
+		lock    sync.Mutex
+		schemas map[int]*registrySchema
+	}
+
+	// registrySchema is a parsed schema of the registry.
+	registrySchema struct {
+		schemaType string
+		avro       *avroSchema
+		protobuf   *protoSchema
+	}
+
+	// registryResult is a schema as returned by the registry.
+	registryResult struct {
+		Schema     string              `json:"schema"`
+		SchemaType string              `json:"schemaType"`
+		References []registryReference `json:"references"`
+	}
+
+	// registryReference is a reference of a schema to a schema of a
+	// subject, by the name it's imported with.
+	registryReference struct {
+		Name    string `json:"name"`
+		Subject string `json:"subject"`
+		Version int    `json:"version"`
+	}
+)
+
+// newSchemaRegistry returns the schema registry of a trigger, nil if it
+// has none.
+func (kafka Kafka) newSchemaRegistry(trigger *fv1.MessageQueueTrigger) (*schemaRegistry, error) {
+	url := strings.TrimSuffix(strings.TrimSpace(trigger.Spec.Metadata[MetadataSchemaRegistryUrl]), "/")
+	if len(url) == 0 {
+		return nil, nil
+	}
+	registry := &schemaRegistry{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		url:        url,
+		schemas:    make(map[int]*registrySchema),
+	}
+	if len(trigger.Spec.Secret) > 0 && kafka.secretGetter != nil {
+		data, err := kafka.secretGetter(trigger.ObjectMeta.Namespace, trigger.Spec.Secret)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading secret %q of trigger", trigger.Spec.Secret)
+		}
+		registry.username = strings.TrimSpace(string(data[MetadataSchemaRegistryUsername]))
+		registry.password = strings.TrimSpace(string(data[MetadataSchemaRegistryPassword]))
+	}
+	return registry, nil
+}
+
+// deserialize returns a message as JSON, and the ID of the schema it was
+// serialized with. Messages not serialized with a schema of the registry
+// are returned as is, with a schema ID of -1.
+func (registry *schemaRegistry) deserialize(value []byte) ([]byte, int, error) {
+	if len(value) < 5 || value[0] != magicByte {
+		return value, -1, nil
+	}
+	id := int(binary.BigEndian.Uint32(value[1:5]))
+	schema, err := registry.schema(id)
+	if err != nil {
+		return nil, id, err
+	}
+
+	payload := value[5:]
+	var body []byte
+	switch schema.schemaType {
+	case schemaTypeAvro:
+		body, err = schema.avro.decode(payload)
+	case schemaTypeProtobuf:
+		body, err = schema.protobuf.decode(payload)
+	case schemaTypeJSON:
+		return payload, id, nil
+	}
+	if err != nil {
+		return nil, id, errors.Wrapf(err, "error deserializing message with %v schema %v", schema.schemaType, id)
+	}
+	return body, id, nil
+}
+
+// schema returns the schema of an ID, getting it from the registry unless
+// cached.
+func (registry *schemaRegistry) schema(id int) (*registrySchema, error) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	if schema, ok := registry.schemas[id]; ok {
+		return schema, nil
+	}
+
+	var result registryResult
+	err := registry.get(fmt.Sprintf("/schemas/ids/%v", id), &result)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting schema %v", id)
+	}
+
+	schema := &registrySchema{schemaType: result.SchemaType}
+	if len(schema.schemaType) == 0 {
+		// schemas registered before other types were supported
+		schema.schemaType = schemaTypeAvro
+	}
+	if len(result.References) > 0 && schema.schemaType != schemaTypeProtobuf {
+		return nil, errors.Errorf("%v schema %v references other schemas, which is only supported for Protobuf", schema.schemaType, id)
+	}
+	switch schema.schemaType {
+	case schemaTypeAvro:
+		schema.avro, err = parseAvroSchema(result.Schema)
+	case schemaTypeProtobuf:
+		imports := make(map[string]string)
+		err = registry.references(result.References, imports)
+		if err == nil {
+			schema.protobuf, err = parseProtoSchema(fmt.Sprintf("schema-%v.proto", id), result.Schema, imports)
+		}
+	case schemaTypeJSON:
+	default:
+		err = errors.Errorf("unsupported schema type %q", schema.schemaType)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing schema %v", id)
+	}
+
+	registry.schemas[id] = schema
+	return schema, nil
+}
+
+// references gets the schemas referenced, and the ones they reference in
+// turn, into imports by the names they're imported with.
+func (registry *schemaRegistry) references(refs []registryReference, imports map[string]string) error {
+	for _, ref := range refs {
+		if _, ok := imports[ref.Name]; ok {
+			continue
+		}
+		var result registryResult
+		err := registry.get(fmt.Sprintf("/subjects/%v/versions/%v", url.PathEscape(ref.Subject), ref.Version), &result)
+		if err != nil {
+			return errors.Wrapf(err, "error getting schema %q referenced as %q", ref.Subject, ref.Name)
+		}
+		imports[ref.Name] = result.Schema
+		err = registry.references(result.References, imports)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// get decodes the response of the registry to a GET of path into v.
+func (registry *schemaRegistry) get(path string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, registry.url+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if len(registry.username) > 0 {
+		req.SetBasicAuth(registry.username, registry.password)
+	}
+	resp, err := registry.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("status code: %v", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
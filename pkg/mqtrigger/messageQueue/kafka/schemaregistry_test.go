@@ -0,0 +1,204 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	testAvroSchema = `{
+		"type": "record",
+		"name": "Order",
+		"namespace": "shop",
+		"fields": [
+			{"name": "id", "type": "long"},
+			{"name": "item", "type": "string"},
+			{"name": "tags", "type": {"type": "array", "items": "string"}},
+			{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["PENDING", "SHIPPED"]}},
+			{"name": "note", "type": ["null", "string"]},
+			{"name": "previous", "type": ["null", "Order"]}
+		]
+	}`
+	testProtoSchema = `
+		syntax = "proto3";
+		package shop;
+
+		import "shop/status.proto";
+
+		// Other is not the message of the test
+		message Other { string x = 1; }
+
+		message Order {
+			message Line {
+				string sku = 1;
+				int32 qty = 2;
+			}
+			int64 order_id = 1;
+			repeated Line lines = 2;
+			Status status = 3 [deprecated = true];
+			map<string, int32> totals = 4;
+			repeated int32 codes = 5;
+		}`
+	testProtoImport = `
+		syntax = "proto3";
+		package shop;
+
+		enum Status {
+			UNKNOWN = 0;
+			SHIPPED = 1;
+		}`
+)
+
+func TestSchemaRegistry(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		username, password, _ := r.BasicAuth()
+		if username != "fission" || password != "s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var schema map[string]interface{}
+		switch r.URL.Path {
+		case "/schemas/ids/1":
+			schema = map[string]interface{}{"schema": testAvroSchema}
+		case "/schemas/ids/2":
+			schema = map[string]interface{}{
+				"schema":     testProtoSchema,
+				"schemaType": "PROTOBUF",
+				"references": []map[string]interface{}{{"name": "shop/status.proto", "subject": "shop-status", "version": 1}},
+			}
+		case "/subjects/shop-status/versions/1":
+			schema = map[string]interface{}{"schema": testProtoImport, "schemaType": "PROTOBUF"}
+		case "/schemas/ids/3":
+			schema = map[string]interface{}{"schema": `{"type": "object"}`, "schemaType": "JSON"}
+		case "/schemas/ids/5":
+			schema = map[string]interface{}{
+				"schema":     testAvroSchema,
+				"references": []map[string]interface{}{{"name": "shop.Status", "subject": "shop-status", "version": 1}},
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(schema)
+	}))
+	defer server.Close()
+
+	kafka := Kafka{
+		secretGetter: func(namespace string, name string) (map[string][]byte, error) {
+			return map[string][]byte{
+				MetadataSchemaRegistryUsername: []byte("fission"),
+				MetadataSchemaRegistryPassword: []byte("s3cr3t"),
+			}, nil
+		},
+	}
+	trigger := &fv1.MessageQueueTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "default"},
+		Spec: fv1.MessageQueueTriggerSpec{
+			Secret:   "registry",
+			Metadata: map[string]string{MetadataSchemaRegistryUrl: server.URL + "/"},
+		},
+	}
+
+	registry, err := kafka.newSchemaRegistry(&fv1.MessageQueueTrigger{})
+	require.NoError(t, err)
+	require.Nil(t, registry)
+	registry, err = kafka.newSchemaRegistry(trigger)
+	require.NoError(t, err)
+	require.NotNil(t, registry)
+
+	for _, test := range []struct {
+		name  string
+		value []byte
+		body  string
+		id    int
+	}{
+		{
+			name: "avro",
+			value: []byte{0, 0, 0, 0, 1,
+				0x54,                // id
+				0x06, 'p', 'e', 'n', // item
+				0x02, 0x02, 'a', 0x00, // tags
+				0x02,                 // status
+				0x02, 0x04, 'h', 'i', // note
+				0x00, // previous
+			},
+			body: `{"id":42,"item":"pen","note":{"string":"hi"},"previous":null,"status":"SHIPPED","tags":["a"]}`,
+			id:   1,
+		},
+		{
+			name: "protobuf",
+			value: []byte{0, 0, 0, 0, 2,
+				0x02, 0x02, // message indexes
+				0x08, 0x2a, // order_id
+				0x12, 0x05, 0x0a, 0x01, 'a', 0x10, 0x02, // lines
+				0x18, 0x01, // status
+				0x22, 0x06, 0x0a, 0x02, 'e', 'u', 0x10, 0x07, // totals
+				0x2a, 0x02, 0x01, 0x02, // codes
+			},
+			body: `{"codes":[1,2],"lines":[{"qty":2,"sku":"a"}],"orderId":"42","status":"SHIPPED","totals":{"eu":7}}`,
+			id:   2,
+		},
+		{
+			name:  "json",
+			value: append([]byte{0, 0, 0, 0, 3}, `{"id":42}`...),
+			body:  `{"id":42}`,
+			id:    3,
+		},
+		{
+			name:  "no schema",
+			value: []byte(`{"id":42}`),
+			body:  `{"id":42}`,
+			id:    -1,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			body, id, err := registry.deserialize(test.value)
+			require.NoError(t, err)
+			require.JSONEq(t, test.body, string(body))
+			require.Equal(t, test.id, id)
+		})
+	}
+
+	// schemas are got from the registry once
+	_, _, err = registry.deserialize([]byte{0, 0, 0, 0, 1, 0x54, 0x00, 0x00, 0x00, 0x00, 0x00})
+	require.NoError(t, err)
+	require.Equal(t, 4, requests)
+
+	for name, value := range map[string][]byte{
+		"truncated":      {0, 0, 0, 0, 1, 0x54},
+		"trailing bytes": {0, 0, 0, 0, 1, 0x54, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		// tags claiming 2^40 items
+		"block count":     {0, 0, 0, 0, 1, 0x54, 0x00, 0x80, 0x80, 0x80, 0x80, 0x80, 0x40, 'a'},
+		"message index":   {0, 0, 0, 0, 2, 0x02, 0x06},
+		"unknown schema":  {0, 0, 0, 0, 4, 0x54},
+		"avro references": {0, 0, 0, 0, 5, 0x54},
+	} {
+		_, _, err = registry.deserialize(value)
+		require.Error(t, err, name)
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messageQueue
+
+import (
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// Limiter bounds the invocations of the function of a trigger in flight to
+// the max concurrent invocations of the trigger. Connectors acquire it
+// before handing a message over to be handled, so messages wait in the
+// message queue rather than in memory. The nil Limiter, of triggers without
+// a limit, never blocks.
+type Limiter chan struct{}
+
+// NewLimiter returns the Limiter of a trigger.
+func NewLimiter(trigger *fv1.MessageQueueTrigger) Limiter {
+	if trigger.Spec.MaxConcurrentInvocations <= 0 {
+		return nil
+	}
+	return make(Limiter, trigger.Spec.MaxConcurrentInvocations)
+}
+
+// Acquire waits for an invocation to be let in flight.
+func (l Limiter) Acquire() {
+	if l != nil {
+		l <- struct{}{}
+	}
+}
+
+// Release lets the next invocation in flight, once one is done.
+func (l Limiter) Release() {
+	if l != nil {
+		<-l
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messageQueue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestLimiter(t *testing.T) {
+	// triggers without a limit are never blocked
+	trigger := &fv1.MessageQueueTrigger{}
+	limiter := NewLimiter(trigger)
+	require.Nil(t, limiter)
+	limiter.Acquire()
+	limiter.Release()
+
+	trigger.Spec.MaxConcurrentInvocations = 2
+	limiter = NewLimiter(trigger)
+	limiter.Acquire()
+	limiter.Acquire()
+
+	acquired := make(chan struct{})
+	go func() {
+		limiter.Acquire()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired beyond the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	limiter.Release()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("not acquired once released")
+	}
+}
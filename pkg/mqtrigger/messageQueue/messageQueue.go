@@ -27,10 +27,30 @@ type (
 		MQType  string
 		Url     string
 		Secrets map[string][]byte
+		// SecretGetter reads the secrets of triggers, for message queues
+		// that authenticate each trigger with its own.
+		SecretGetter SecretGetter
 	}
 
+	// SecretGetter returns the data of a secret, by namespace and name.
+	SecretGetter func(namespace string, name string) (map[string][]byte, error)
+
 	MessageQueue interface {
 		Subscribe(trigger *fv1.MessageQueueTrigger) (Subscription, error)
 		Unsubscribe(triggerSub Subscription) error
 	}
+
+	// Remover is implemented by message queues that set up resources for a
+	// trigger besides its subscription, to remove them once the trigger is
+	// deleted. Triggers are only unsubscribed from on shutdown.
+	Remover interface {
+		Remove(triggerSub Subscription) error
+	}
+
+	// LagReporter is implemented by message queues that can tell how many
+	// messages of a trigger are waiting to be consumed, for the consumers of
+	// the trigger to be scaled with it.
+	LagReporter interface {
+		Lag(trigger *fv1.MessageQueueTrigger) (int64, error)
+	}
 )
@@ -23,6 +23,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	nsUtil "github.com/nats-io/nats-streaming-server/util"
 	ns "github.com/nats-io/stan.go"
@@ -69,6 +70,13 @@ type (
 		routerUrl string
 	}
 
+	// batchSubscription is the subscription of a trigger whose function
+	// is invoked with batches of messages.
+	batchSubscription struct {
+		subscription ns.Subscription
+		batcher      *messageQueue.Batcher
+	}
+
 	Factory struct{}
 )
 
@@ -115,7 +123,55 @@ func (nats Nats) Subscribe(trigger *fv1.MessageQueueTrigger) (messageQueue.Subsc
 		// trigger could choose to ack message or simply drop it depend on the response of function pod.
 		ns.SetManualAckMode(),
 	}
-	sub, err := nats.nsConn.Subscribe(subj, msgHandler(&nats, trigger), opts...)
+
+	retry, err := messageQueue.NewRetryPolicy(trigger)
+	if err != nil {
+		return nil, err
+	}
+
+	if messageQueue.IsBatched(trigger) {
+		batcher, err := messageQueue.NewBatcher(nats.logger, nats.routerUrl, trigger, func(topic string, body []byte, contentType string) error {
+			return nats.nsConn.Publish(topic, body)
+		})
+		if err != nil {
+			return nil, err
+		}
+		// messages of batches the function failed on are left unacked,
+		// to be redelivered
+		sub, err := nats.nsConn.Subscribe(subj, func(msg *ns.Msg) {
+			batcher.Add(messageQueue.BatchMessage{
+				Body: msg.Data,
+				Ack: func() {
+					err := msg.Ack()
+					if err != nil {
+						nats.logger.Error("failed to ack message of batch",
+							zap.Error(err),
+							zap.String("trigger", trigger.ObjectMeta.Name))
+					}
+				},
+			})
+		}, opts...)
+		if err != nil {
+			batcher.Close()
+			return nil, err
+		}
+		return &batchSubscription{subscription: sub, batcher: batcher}, nil
+	}
+
+	// messages are handled one at a time, unless the trigger sets a limit
+	// of concurrent invocations
+	handler := msgHandler(&nats, trigger, retry)
+	if limiter := messageQueue.NewLimiter(trigger); limiter != nil {
+		handle := handler
+		handler = func(msg *ns.Msg) {
+			limiter.Acquire()
+			go func() {
+				defer limiter.Release()
+				handle(msg)
+			}()
+		}
+	}
+	sub, err := nats.nsConn.Subscribe(subj, handler, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -123,10 +179,15 @@ func (nats Nats) Subscribe(trigger *fv1.MessageQueueTrigger) (messageQueue.Subsc
 }
 
 func (nats Nats) Unsubscribe(subscription messageQueue.Subscription) error {
+	if sub, ok := subscription.(*batchSubscription); ok {
+		// messages of the batches handled are acked before closing
+		sub.batcher.Close()
+		return sub.subscription.Close()
+	}
 	return subscription.(ns.Subscription).Close()
 }
 
-func msgHandler(nats *Nats, trigger *fv1.MessageQueueTrigger) func(*ns.Msg) {
+func msgHandler(nats *Nats, trigger *fv1.MessageQueueTrigger, retry *messageQueue.RetryPolicy) func(*ns.Msg) {
 	return func(msg *ns.Msg) {
 
 		// Support other function ref types
@@ -147,46 +208,45 @@ func msgHandler(nats *Nats, trigger *fv1.MessageQueueTrigger) func(*ns.Msg) {
 			"X-Fission-MQTrigger-RespTopic":  trigger.Spec.ResponseTopic,
 			"X-Fission-MQTrigger-ErrorTopic": trigger.Spec.ErrorTopic,
 			"Content-Type":                   trigger.Spec.ContentType,
+			fv1.HeaderTriggerType:            fv1.TriggerTypeMessageQueue,
+			fv1.HeaderTriggerName:            trigger.ObjectMeta.Name,
+			fv1.HeaderCloudEventsMode:        string(trigger.Spec.CloudEvents),
+			fv1.HeaderEventTime:              time.Unix(0, msg.Timestamp).UTC().Format(time.RFC3339Nano),
+			fv1.HeaderPriority:               string(fv1.InvocationPriorityBatch),
 		}
 
-		// Create request
-		req, err := http.NewRequest("POST", url, bytes.NewReader(msg.Data))
-
-		if err != nil {
-			nats.logger.Error("failed to create HTTP request to invoke function",
-				zap.Error(err),
-				zap.String("function_url", url))
-			return
-		}
-
-		for k, v := range headers {
-			req.Header.Set(k, v)
-		}
-
-		var resp *http.Response
-		for attempt := 0; attempt <= trigger.Spec.MaxRetries; attempt++ {
-			// Make the request
-			resp, err = http.DefaultClient.Do(req)
+		// the message itself is acked once dead-lettered, or else left
+		// unacked, to be redelivered
+		deadLetter := func() {
+			if !retry.DeadLetter(nats.logger, msg.Data, trigger.Spec.ContentType, func(topic string, body []byte, contentType string) error {
+				return nats.nsConn.Publish(topic, body)
+			}) {
+				return
+			}
+			err := msg.Ack()
 			if err != nil {
-				nats.logger.Error("sending function invocation request failed",
+				nats.logger.Error("failed to ack message after publishing it to dead-letter topic",
 					zap.Error(err),
-					zap.String("function_url", url),
 					zap.String("trigger", trigger.ObjectMeta.Name))
-				continue
-			}
-			if resp == nil {
-				continue
-			}
-			if err == nil && resp.StatusCode == http.StatusOK {
-				// Success, quit retrying
-				break
 			}
 		}
 
-		if resp == nil {
+		resp, err := retry.Invoke(nats.logger.With(zap.String("trigger", trigger.ObjectMeta.Name)), func() (*http.Request, error) {
+			req, err := http.NewRequest("POST", url, bytes.NewReader(msg.Data))
+			if err != nil {
+				return nil, err
+			}
+			for k, v := range headers {
+				req.Header.Set(k, v)
+			}
+			return req, nil
+		})
+		if err != nil {
 			nats.logger.Warn("every function invocation retry failed; final retry gave empty response",
+				zap.Error(err),
 				zap.String("function_url", url),
 				zap.String("trigger", trigger.ObjectMeta.Name))
+			deadLetter()
 			return
 		}
 
@@ -198,6 +258,7 @@ func msgHandler(nats *Nats, trigger *fv1.MessageQueueTrigger) func(*ns.Msg) {
 				zap.Error(err),
 				zap.String("function_url", url),
 				zap.String("trigger", trigger.ObjectMeta.Name))
+			deadLetter()
 			return
 		}
 
@@ -211,10 +272,9 @@ func msgHandler(nats *Nats, trigger *fv1.MessageQueueTrigger) func(*ns.Msg) {
 						zap.String("topic", trigger.Spec.ErrorTopic),
 						zap.String("function_url", url),
 						zap.String("trigger", trigger.ObjectMeta.Name))
-					// TODO: We will ack this message after max retries to prevent re-processing but
-					// this may cause message loss
 				}
 			}
+			deadLetter()
 			return
 		}
 
@@ -0,0 +1,940 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pulsar consumes Pulsar topics through the WebSocket API of the
+// brokers, served along with their admin REST API, which the schemas of
+// topics are looked up with.
+package pulsar
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/mqtrigger/factory"
+	"github.com/fission/fission/pkg/mqtrigger/messageQueue"
+	"github.com/fission/fission/pkg/mqtrigger/validator"
+	"github.com/fission/fission/pkg/utils"
+)
+
+func init() {
+	factory.Register(fv1.MessageQueueTypePulsar, &Factory{})
+	validator.Register(fv1.MessageQueueTypePulsar, IsTopicValid)
+}
+
+// Settings of a trigger, in the metadata of the trigger. The topic of a
+// trigger is the topic it consumes, as persistent://<tenant>/<namespace>/<topic>,
+// or by name in the public/default namespace; the response and error
+// topics are topics too.
+const (
+	// MetadataSubscription is the subscription to consume the topic with.
+	// fission-<namespace>-<trigger> if empty.
+	MetadataSubscription = "subscription"
+	// MetadataSubscriptionType is the type of the subscription: Shared,
+	// Failover, Exclusive or Key_Shared. Shared if empty. Messages of
+	// Failover and Exclusive subscriptions are handled one at a time, in
+	// order, and those of Key_Shared ones in order by key.
+	MetadataSubscriptionType = "subscriptionType"
+	// MetadataReceiverQueueSize is how many messages are received ahead
+	// of being handled.
+	MetadataReceiverQueueSize = "receiverQueueSize"
+	// MetadataNackBackoffMin is how long a message the function failed on
+	// is held before it's negatively acked the first time, as a duration,
+	// e.g. "1s". The time doubles with every redelivery.
+	MetadataNackBackoffMin = "nackBackoffMin"
+	// MetadataNackBackoffMax is the most a message the function failed on
+	// is held before it's negatively acked.
+	MetadataNackBackoffMax = "nackBackoffMax"
+	// MetadataDeadLetterTopic is the topic messages the function fails on
+	// MetadataMaxRedeliverCount times are published to by the broker.
+	MetadataDeadLetterTopic = "deadLetterTopic"
+	// MetadataMaxRedeliverCount is how many times a message is redelivered
+	// before it's published to the dead-letter topic.
+	MetadataMaxRedeliverCount = "maxRedeliverCount"
+
+	SubscriptionTypeShared    = "Shared"
+	SubscriptionTypeFailover  = "Failover"
+	SubscriptionTypeExclusive = "Exclusive"
+	SubscriptionTypeKeyShared = "Key_Shared"
+
+	defaultReceiverQueueSize = 10
+	defaultNackBackoffMin    = time.Second
+	defaultNackBackoffMax    = time.Minute
+	defaultMaxRedeliverCount = 5
+
+	// retryInterval is how long to wait to reconnect after the connection
+	// of a consumer is lost.
+	retryInterval = 5 * time.Second
+)
+
+var (
+	topicNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_.=:-]+$`)
+	topicRegex     = regexp.MustCompile(`^(persistent|non-persistent)://[a-zA-Z0-9_.=:-]+/[a-zA-Z0-9_.=:-]+/[a-zA-Z0-9_.=:-]+$`)
+)
+
+type (
+	Pulsar struct {
+		logger     *zap.Logger
+		httpUrl    string
+		wsUrl      string
+		header     http.Header
+		httpClient *http.Client
+		dialer     *websocket.Dialer
+		routerUrl  string
+	}
+
+	// subscriptionConfig is the subscription settings of a trigger.
+	subscriptionConfig struct {
+		subscription      string
+		subscriptionType  string
+		receiverQueueSize int
+		nackBackoffMin    time.Duration
+		nackBackoffMax    time.Duration
+		deadLetterTopic   string
+		maxRedeliverCount int
+	}
+
+	// subscription is the consumer of the topic of a trigger, along with
+	// the producers of its response and error topics.
+	subscription struct {
+		topic      string
+		cfg        *subscriptionConfig
+		schemaType string
+
+		responseProducer   *producer
+		errorProducer      *producer
+		deadLetterProducer *producer
+		retry              *messageQueue.RetryPolicy
+		// batcher is set if the function of the trigger is invoked with
+		// batches of messages.
+		batcher *messageQueue.Batcher
+		limiter messageQueue.Limiter
+
+		lock     sync.Mutex
+		conn     *websocket.Conn
+		stopped  bool
+		stop     chan struct{}
+		done     chan struct{}
+		inFlight sync.WaitGroup
+
+		// previous is the done channel of the last message of each
+		// ordering key, for the next one to wait for.
+		previous map[string]chan struct{}
+	}
+
+	// producer publishes to a topic, connecting on first use, and again
+	// after the connection is lost.
+	producer struct {
+		pulsar Pulsar
+		topic  string
+
+		lock    sync.Mutex
+		conn    *websocket.Conn
+		context int
+	}
+
+	// Frames of the WebSocket API.
+	consumerMessage struct {
+		MessageID       string            `json:"messageId"`
+		Payload         string            `json:"payload"`
+		Properties      map[string]string `json:"properties"`
+		PublishTime     string            `json:"publishTime"`
+		RedeliveryCount int               `json:"redeliveryCount"`
+		Key             string            `json:"key"`
+	}
+
+	consumerCommand struct {
+		Type      string `json:"type,omitempty"`
+		MessageID string `json:"messageId"`
+	}
+
+	producerMessage struct {
+		Payload    string            `json:"payload"`
+		Properties map[string]string `json:"properties,omitempty"`
+		Key        string            `json:"key,omitempty"`
+		Context    string            `json:"context"`
+	}
+
+	producerResult struct {
+		Result    string `json:"result"`
+		ErrorMsg  string `json:"errorMsg"`
+		MessageID string `json:"messageId"`
+		Context   string `json:"context"`
+	}
+
+	Factory struct{}
+)
+
+func (factory *Factory) Create(logger *zap.Logger, mqCfg messageQueue.Config, routerUrl string) (messageQueue.MessageQueue, error) {
+	return New(logger, mqCfg, routerUrl)
+}
+
+// New returns a client of the web service of the Pulsar broker, or proxy,
+// at the URL of the config, e.g. http://pulsar-broker.pulsar:8080, with
+// the WebSocket API enabled. The client authenticates with the "token"
+// secret if set, and trusts the "caCert" secret over TLS.
+func New(logger *zap.Logger, mqCfg messageQueue.Config, routerUrl string) (messageQueue.MessageQueue, error) {
+	if len(routerUrl) == 0 || len(mqCfg.Url) == 0 {
+		return nil, errors.New("the router URL or MQ URL is empty")
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(mqCfg.Url, "/"))
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing pulsar URL")
+	}
+	httpUrl := u.String()
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	default:
+		return nil, errors.Errorf("pulsar URL must be an http or https URL of the web service, got %q", mqCfg.Url)
+	}
+
+	header := http.Header{}
+	if token, ok := mqCfg.Secrets["token"]; ok {
+		header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	var tlsConfig *tls.Config
+	if caCert, ok := mqCfg.Secrets["caCert"]; ok {
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("error parsing CA certificate")
+		}
+		tlsConfig = &tls.Config{RootCAs: caCertPool}
+	}
+
+	return Pulsar{
+		logger:  logger.Named("pulsar"),
+		httpUrl: httpUrl,
+		wsUrl:   u.String(),
+		header:  header,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+		},
+		dialer: &websocket.Dialer{
+			HandshakeTimeout: 30 * time.Second,
+			TLSClientConfig:  tlsConfig,
+			Proxy:            http.ProxyFromEnvironment,
+		},
+		routerUrl: routerUrl,
+	}, nil
+}
+
+func (p Pulsar) Subscribe(trigger *fv1.MessageQueueTrigger) (messageQueue.Subscription, error) {
+	if !IsTopicValid(trigger.Spec.Topic) {
+		return nil, fmt.Errorf("not a valid topic: %q", trigger.Spec.Topic)
+	}
+	cfg, err := parseConfig(trigger)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &subscription{
+		topic:    fullTopic(trigger.Spec.Topic),
+		cfg:      cfg,
+		limiter:  messageQueue.NewLimiter(trigger),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		previous: make(map[string]chan struct{}),
+	}
+	sub.schemaType, err = p.getSchemaType(sub.topic)
+	if err != nil {
+		return nil, err
+	}
+	if len(trigger.Spec.ResponseTopic) > 0 {
+		sub.responseProducer = &producer{pulsar: p, topic: fullTopic(trigger.Spec.ResponseTopic)}
+	}
+	if len(trigger.Spec.ErrorTopic) > 0 {
+		sub.errorProducer = &producer{pulsar: p, topic: fullTopic(trigger.Spec.ErrorTopic)}
+	}
+	sub.retry, err = messageQueue.NewRetryPolicy(trigger)
+	if err != nil {
+		return nil, err
+	}
+	if len(sub.retry.DeadLetterTopic) > 0 {
+		sub.deadLetterProducer = &producer{pulsar: p, topic: fullTopic(sub.retry.DeadLetterTopic)}
+	}
+	if messageQueue.IsBatched(trigger) {
+		sub.batcher, err = messageQueue.NewBatcher(p.logger, p.routerUrl, trigger, func(topic string, body []byte, contentType string) error {
+			pr := sub.errorProducer
+			switch topic {
+			case trigger.Spec.ResponseTopic:
+				pr = sub.responseProducer
+			case sub.retry.DeadLetterTopic:
+				pr = sub.deadLetterProducer
+			}
+			return pr.send(body, contentType, "")
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the first connection is made here, for errors to fail the
+	// subscription
+	conn, err := p.connect(sub)
+	if err != nil {
+		return nil, err
+	}
+	go p.consume(trigger, sub, conn)
+
+	p.logger.Info("subscribed to topic",
+		zap.String("topic", sub.topic),
+		zap.String("subscription", cfg.subscription),
+		zap.String("subscription_type", cfg.subscriptionType),
+		zap.String("schema_type", sub.schemaType),
+		zap.String("trigger", trigger.ObjectMeta.Name))
+	return sub, nil
+}
+
+// Unsubscribe closes the consumer of a trigger, once the messages received
+// are handled. Messages not acked are redelivered to the other consumers of
+// the subscription, or to the next one.
+func (p Pulsar) Unsubscribe(triggerSub messageQueue.Subscription) error {
+	sub := triggerSub.(*subscription)
+	sub.lock.Lock()
+	sub.stopped = true
+	close(sub.stop)
+	if sub.conn != nil {
+		// acks are written until the connection is closed
+		_ = sub.conn.SetReadDeadline(time.Now())
+	}
+	sub.lock.Unlock()
+	<-sub.done
+	if sub.batcher != nil {
+		sub.batcher.Close()
+	}
+
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+	if sub.conn != nil {
+		sub.conn.Close()
+	}
+	sub.responseProducer.close()
+	sub.errorProducer.close()
+	sub.deadLetterProducer.close()
+	return nil
+}
+
+// Lag returns the backlog of the subscription of a trigger, the messages of
+// its topic not acked yet.
+func (p Pulsar) Lag(trigger *fv1.MessageQueueTrigger) (int64, error) {
+	cfg, err := parseConfig(trigger)
+	if err != nil {
+		return 0, err
+	}
+	topic := fullTopic(trigger.Spec.Topic)
+
+	// the stats of partitioned topics are aggregated over their partitions
+	var stats struct {
+		Subscriptions map[string]struct {
+			MsgBacklog int64 `json:"msgBacklog"`
+		} `json:"subscriptions"`
+	}
+	found, err := p.getStats(topic, "stats", &stats)
+	if err == nil && !found {
+		found, err = p.getStats(topic, "partitioned-stats", &stats)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, errors.Errorf("topic %q not found", topic)
+	}
+	// the subscription is created with the consumer
+	return stats.Subscriptions[cfg.subscription].MsgBacklog, nil
+}
+
+// connect opens the consumer of a subscription.
+func (p Pulsar) connect(sub *subscription) (*websocket.Conn, error) {
+	cfg := sub.cfg
+	query := url.Values{
+		"subscriptionType":  {cfg.subscriptionType},
+		"receiverQueueSize": {strconv.Itoa(cfg.receiverQueueSize)},
+		// failed messages are held for their backoff before they're
+		// negatively acked, to be redelivered right away
+		"negativeAckRedeliveryDelay": {"0"},
+	}
+	if len(cfg.deadLetterTopic) > 0 {
+		query.Set("deadLetterTopic", fullTopic(cfg.deadLetterTopic))
+		query.Set("maxRedeliverCount", strconv.Itoa(cfg.maxRedeliverCount))
+	}
+	u := fmt.Sprintf("%v/ws/v2/consumer/%v/%v?%v", p.wsUrl, topicPath(sub.topic), url.PathEscape(cfg.subscription), query.Encode())
+
+	conn, resp, err := p.dialer.Dial(u, p.header)
+	if err != nil {
+		if resp != nil {
+			return nil, errors.Wrapf(err, "error connecting consumer of topic %q, status code: %v", sub.topic, resp.StatusCode)
+		}
+		return nil, errors.Wrapf(err, "error connecting consumer of topic %q", sub.topic)
+	}
+
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+	if sub.stopped {
+		conn.Close()
+		return nil, errors.New("unsubscribed")
+	}
+	if sub.conn != nil {
+		sub.conn.Close()
+	}
+	sub.conn = conn
+	return conn, nil
+}
+
+// consume handles the messages of the consumer of a trigger until
+// unsubscribed, reconnecting when the connection is lost.
+func (p Pulsar) consume(trigger *fv1.MessageQueueTrigger, sub *subscription, conn *websocket.Conn) {
+	defer close(sub.done)
+	logger := p.logger.With(zap.String("topic", sub.topic), zap.String("trigger", trigger.ObjectMeta.Name))
+
+	for {
+		for {
+			var msg consumerMessage
+			err := conn.ReadJSON(&msg)
+			if err != nil {
+				select {
+				case <-sub.stop:
+				default:
+					logger.Error("connection of consumer lost", zap.Error(err))
+				}
+				break
+			}
+			if sub.batcher != nil {
+				p.addToBatch(trigger, sub, conn, msg)
+				continue
+			}
+			p.dispatch(trigger, sub, conn, msg)
+		}
+
+		// acks are only accepted on the connection messages were
+		// received on, so messages in flight are redelivered anyway
+		sub.inFlight.Wait()
+
+		for {
+			select {
+			case <-sub.stop:
+				return
+			case <-time.After(retryInterval):
+			}
+			var err error
+			conn, err = p.connect(sub)
+			if err == nil {
+				logger.Info("reconnected consumer")
+				break
+			}
+			logger.Error("failed to reconnect consumer", zap.Error(err))
+		}
+	}
+}
+
+// dispatch handles a message in the background, after the message before it
+// with the same ordering key.
+func (p Pulsar) dispatch(trigger *fv1.MessageQueueTrigger, sub *subscription, conn *websocket.Conn, msg consumerMessage) {
+	key, ordered := orderingKey(sub.cfg.subscriptionType, msg.Key)
+
+	var previous chan struct{}
+	done := make(chan struct{})
+	if ordered {
+		sub.lock.Lock()
+		previous = sub.previous[key]
+		sub.previous[key] = done
+		sub.lock.Unlock()
+	}
+
+	sub.inFlight.Add(1)
+	sub.limiter.Acquire()
+	go func() {
+		defer sub.inFlight.Done()
+		defer sub.limiter.Release()
+		defer func() {
+			close(done)
+			if ordered {
+				sub.lock.Lock()
+				if sub.previous[key] == done {
+					delete(sub.previous, key)
+				}
+				sub.lock.Unlock()
+			}
+		}()
+		if previous != nil {
+			<-previous
+		}
+
+		command := consumerCommand{MessageID: msg.MessageID}
+		if !p.msgHandler(trigger, sub, msg) {
+			delay := nackDelay(sub.cfg, msg.RedeliveryCount)
+			select {
+			case <-sub.stop:
+				// redelivered once the connection is closed
+				return
+			case <-time.After(delay):
+			}
+			command.Type = "negativeAcknowledge"
+		}
+
+		sub.lock.Lock()
+		err := conn.WriteJSON(command)
+		sub.lock.Unlock()
+		if err != nil {
+			p.logger.Error("failed to ack message",
+				zap.Error(err),
+				zap.String("type", command.Type),
+				zap.String("trigger", trigger.ObjectMeta.Name))
+		}
+	}()
+}
+
+// addToBatch adds a message to the open batch of a trigger. Messages of
+// batches the function handled are acked, and those of batches it failed on
+// are negatively acked after their backoff, like the messages handled one at
+// a time. Batches are invoked one at a time, in order.
+func (p Pulsar) addToBatch(trigger *fv1.MessageQueueTrigger, sub *subscription, conn *websocket.Conn, msg consumerMessage) {
+	write := func(command consumerCommand) {
+		sub.lock.Lock()
+		err := conn.WriteJSON(command)
+		sub.lock.Unlock()
+		if err != nil {
+			p.logger.Error("failed to ack message of batch",
+				zap.Error(err),
+				zap.String("type", command.Type),
+				zap.String("trigger", trigger.ObjectMeta.Name))
+		}
+	}
+	nack := func() {
+		go func() {
+			select {
+			case <-sub.stop:
+				// redelivered once the connection is closed
+				return
+			case <-time.After(nackDelay(sub.cfg, msg.RedeliveryCount)):
+			}
+			write(consumerCommand{Type: "negativeAcknowledge", MessageID: msg.MessageID})
+		}()
+	}
+
+	body, err := base64.StdEncoding.DecodeString(msg.Payload)
+	if err != nil {
+		p.fail(trigger, sub, msg, "", []byte(fmt.Sprintf("error decoding message payload: %v", err)))
+		nack()
+		return
+	}
+	sub.batcher.Add(messageQueue.BatchMessage{
+		Body: body,
+		Ack:  func() { write(consumerCommand{MessageID: msg.MessageID}) },
+		Nack: nack,
+	})
+}
+
+// msgHandler invokes the function of a trigger with a message, retried with
+// the retry policy of the trigger, and returns whether to ack the message:
+// whether the function succeeded, or the message was dead-lettered. The
+// payload is passed as is, with the content type of the schema of the topic
+// unless the trigger sets one. Messages the function fails on are handled by
+// fail.
+func (p Pulsar) msgHandler(trigger *fv1.MessageQueueTrigger, sub *subscription, msg consumerMessage) bool {
+	// Support other function ref types
+	if trigger.Spec.FunctionReference.Type != fv1.FunctionReferenceTypeFunctionName {
+		p.logger.Fatal("unsupported function reference type for trigger",
+			zap.Any("function_reference_type", trigger.Spec.FunctionReference.Type),
+			zap.String("trigger", trigger.ObjectMeta.Name))
+	}
+
+	url := p.routerUrl + "/" + strings.TrimPrefix(utils.UrlForFunction(trigger.Spec.FunctionReference.Name, trigger.ObjectMeta.Namespace), "/")
+	p.logger.Debug("making HTTP request", zap.String("url", url))
+
+	contentType := trigger.Spec.ContentType
+	if len(contentType) == 0 {
+		contentType = schemaContentType(sub.schemaType)
+	}
+	fissionHeaders := map[string]string{
+		"X-Fission-MQTrigger-Topic":      trigger.Spec.Topic,
+		"X-Fission-MQTrigger-RespTopic":  trigger.Spec.ResponseTopic,
+		"X-Fission-MQTrigger-ErrorTopic": trigger.Spec.ErrorTopic,
+		"X-Fission-MQTrigger-SchemaType": sub.schemaType,
+		"X-Fission-MQTrigger-Key":        msg.Key,
+		"Content-Type":                   contentType,
+		fv1.HeaderTriggerType:            fv1.TriggerTypeMessageQueue,
+		fv1.HeaderTriggerName:            trigger.ObjectMeta.Name,
+		fv1.HeaderCloudEventsMode:        string(trigger.Spec.CloudEvents),
+		fv1.HeaderPriority:               string(fv1.InvocationPriorityBatch),
+	}
+	if publishTime, ok := parsePublishTime(msg.PublishTime); ok {
+		fissionHeaders[fv1.HeaderEventTime] = publishTime.UTC().Format(time.RFC3339Nano)
+	}
+
+	body, err := base64.StdEncoding.DecodeString(msg.Payload)
+	if err != nil {
+		return p.fail(trigger, sub, msg, url, []byte(fmt.Sprintf("error decoding message payload: %v", err)))
+	}
+
+	resp, err := sub.retry.Invoke(p.logger.With(zap.String("trigger", trigger.ObjectMeta.Name)), func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		// the properties of the message, then ours
+		for k, v := range msg.Properties {
+			req.Header.Set(k, v)
+		}
+		for k, v := range fissionHeaders {
+			req.Header.Set(k, v)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return p.fail(trigger, sub, msg, url, []byte(fmt.Sprintf("request exceed retries: %v: %v", sub.retry.MaxAttempts-1, err)))
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return p.fail(trigger, sub, msg, url, []byte(fmt.Sprintf("error reading function invocation response: %v", err)))
+	}
+	p.logger.Debug("got response from function invocation",
+		zap.String("function_url", url),
+		zap.String("trigger", trigger.ObjectMeta.Name),
+		zap.String("body", string(respBody)))
+
+	if resp.StatusCode != http.StatusOK {
+		return p.fail(trigger, sub, msg, url, respBody)
+	}
+
+	if sub.responseProducer != nil {
+		err = sub.responseProducer.send(respBody, resp.Header.Get("Content-Type"), msg.Key)
+		if err != nil {
+			p.logger.Error("failed to publish message with function invocation response to topic",
+				zap.Error(err),
+				zap.String("topic", trigger.Spec.ResponseTopic),
+				zap.String("trigger", trigger.ObjectMeta.Name))
+		}
+	}
+	return true
+}
+
+// fail publishes the error of a message the function failed on to the
+// error topic, and the message itself to the dead-letter topic of the retry
+// policy of the trigger, if any. It returns whether the message was, to be
+// acked then, or else negatively acked.
+func (p Pulsar) fail(trigger *fv1.MessageQueueTrigger, sub *subscription, msg consumerMessage, url string, body []byte) bool {
+	p.logger.Error("function invocation failed",
+		zap.String("function_url", url),
+		zap.String("trigger", trigger.ObjectMeta.Name),
+		zap.Int("redelivery_count", msg.RedeliveryCount),
+		zap.String("error", string(body)))
+
+	if sub.errorProducer != nil && len(body) > 0 {
+		err := sub.errorProducer.send(body, "", msg.Key)
+		if err != nil {
+			p.logger.Error("failed to publish function invocation error to error topic",
+				zap.Error(err),
+				zap.String("topic", trigger.Spec.ErrorTopic),
+				zap.String("function_url", url),
+				zap.String("trigger", trigger.ObjectMeta.Name))
+		}
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(msg.Payload)
+	if err != nil {
+		return false
+	}
+	return sub.retry.DeadLetter(p.logger, payload, trigger.Spec.ContentType, func(topic string, body []byte, contentType string) error {
+		return sub.deadLetterProducer.send(body, contentType, msg.Key)
+	})
+}
+
+// getSchemaType returns the type of the schema of a topic, e.g. JSON or
+// AVRO, BYTES for topics without one.
+func (p Pulsar) getSchemaType(topic string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%v/admin/v2/schemas/%v/schema", p.httpUrl, strings.SplitN(topicPath(topic), "/", 2)[1]), nil)
+	if err != nil {
+		return "", err
+	}
+	for k, v := range p.header {
+		req.Header[k] = v
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "error getting schema of topic %q", topic)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "BYTES", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("error getting schema of topic %q, status code: %v", topic, resp.StatusCode)
+	}
+	var schema struct {
+		Type string `json:"type"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&schema)
+	if err != nil {
+		return "", errors.Wrapf(err, "error decoding schema of topic %q", topic)
+	}
+	return schema.Type, nil
+}
+
+// getStats decodes the stats of a topic into v, returning whether the topic
+// was found.
+func (p Pulsar) getStats(topic string, stats string, v interface{}) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%v/admin/v2/%v/%v", p.httpUrl, topicPath(topic), stats), nil)
+	if err != nil {
+		return false, err
+	}
+	for k, v := range p.header {
+		req.Header[k] = v
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return false, errors.Wrapf(err, "error getting stats of topic %q", topic)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, errors.Errorf("error getting stats of topic %q, status code: %v", topic, resp.StatusCode)
+	}
+	err = json.NewDecoder(resp.Body).Decode(v)
+	if err != nil {
+		return false, errors.Wrapf(err, "error decoding stats of topic %q", topic)
+	}
+	return true, nil
+}
+
+// send publishes body to the topic of the producer, with its content type
+// as a property if set, and waits for the broker to persist it.
+func (pr *producer) send(body []byte, contentType string, key string) error {
+	pr.lock.Lock()
+	defer pr.lock.Unlock()
+
+	if pr.conn == nil {
+		u := fmt.Sprintf("%v/ws/v2/producer/%v", pr.pulsar.wsUrl, topicPath(pr.topic))
+		conn, _, err := pr.pulsar.dialer.Dial(u, pr.pulsar.header)
+		if err != nil {
+			return errors.Wrapf(err, "error connecting producer of topic %q", pr.topic)
+		}
+		pr.conn = conn
+	}
+
+	pr.context++
+	msg := producerMessage{
+		Payload: base64.StdEncoding.EncodeToString(body),
+		Key:     key,
+		Context: strconv.Itoa(pr.context),
+	}
+	if len(contentType) > 0 {
+		msg.Properties = map[string]string{"Content-Type": contentType}
+	}
+
+	var result producerResult
+	err := pr.conn.WriteJSON(msg)
+	if err == nil {
+		err = pr.conn.ReadJSON(&result)
+	}
+	if err != nil {
+		// connect again next time
+		pr.conn.Close()
+		pr.conn = nil
+		return err
+	}
+	if result.Result != "ok" {
+		return errors.Errorf("error publishing to topic %q: %v: %v", pr.topic, result.Result, result.ErrorMsg)
+	}
+	return nil
+}
+
+func (pr *producer) close() {
+	if pr == nil {
+		return
+	}
+	pr.lock.Lock()
+	defer pr.lock.Unlock()
+	if pr.conn != nil {
+		pr.conn.Close()
+		pr.conn = nil
+	}
+}
+
+// parseConfig reads the subscription settings of a trigger from its
+// metadata.
+func parseConfig(trigger *fv1.MessageQueueTrigger) (*subscriptionConfig, error) {
+	metadata := trigger.Spec.Metadata
+	cfg := &subscriptionConfig{
+		subscription:      metadata[MetadataSubscription],
+		subscriptionType:  metadata[MetadataSubscriptionType],
+		receiverQueueSize: defaultReceiverQueueSize,
+		nackBackoffMin:    defaultNackBackoffMin,
+		nackBackoffMax:    defaultNackBackoffMax,
+		deadLetterTopic:   metadata[MetadataDeadLetterTopic],
+		maxRedeliverCount: defaultMaxRedeliverCount,
+	}
+
+	if len(cfg.subscription) == 0 {
+		cfg.subscription = fmt.Sprintf("fission-%v-%v", trigger.ObjectMeta.Namespace, trigger.ObjectMeta.Name)
+	}
+	if len(cfg.subscriptionType) == 0 {
+		cfg.subscriptionType = SubscriptionTypeShared
+	}
+	switch cfg.subscriptionType {
+	case SubscriptionTypeShared, SubscriptionTypeFailover, SubscriptionTypeExclusive, SubscriptionTypeKeyShared:
+	default:
+		return nil, errors.Errorf("unsupported subscription type %q, must be one of Shared, Failover, Exclusive or Key_Shared", cfg.subscriptionType)
+	}
+	if len(cfg.deadLetterTopic) > 0 && !IsTopicValid(cfg.deadLetterTopic) {
+		return nil, errors.Errorf("not a valid dead-letter topic: %q", cfg.deadLetterTopic)
+	}
+
+	parseInt := func(key string, value *int) error {
+		v, ok := metadata[key]
+		if !ok {
+			return nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return errors.Errorf("%v must be a number greater than 0, got %q", key, v)
+		}
+		*value = n
+		return nil
+	}
+	parseDuration := func(key string, value *time.Duration) error {
+		v, ok := metadata[key]
+		if !ok {
+			return nil
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return errors.Errorf("%v must be a duration, got %q", key, v)
+		}
+		*value = d
+		return nil
+	}
+	err := parseInt(MetadataReceiverQueueSize, &cfg.receiverQueueSize)
+	if err != nil {
+		return nil, err
+	}
+	err = parseInt(MetadataMaxRedeliverCount, &cfg.maxRedeliverCount)
+	if err != nil {
+		return nil, err
+	}
+	err = parseDuration(MetadataNackBackoffMin, &cfg.nackBackoffMin)
+	if err != nil {
+		return nil, err
+	}
+	err = parseDuration(MetadataNackBackoffMax, &cfg.nackBackoffMax)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.nackBackoffMax < cfg.nackBackoffMin {
+		return nil, errors.Errorf("nackBackoffMax %v is less than nackBackoffMin %v", cfg.nackBackoffMax, cfg.nackBackoffMin)
+	}
+
+	return cfg, nil
+}
+
+// orderingKey returns the key to order a message by in a subscription of a
+// type, and whether it's ordered at all.
+func orderingKey(subscriptionType string, key string) (string, bool) {
+	switch subscriptionType {
+	case SubscriptionTypeExclusive, SubscriptionTypeFailover:
+		return "", true
+	case SubscriptionTypeKeyShared:
+		return key, true
+	}
+	return "", false
+}
+
+// nackDelay returns how long a message redelivered redeliveryCount times is
+// held before it's negatively acked.
+func nackDelay(cfg *subscriptionConfig, redeliveryCount int) time.Duration {
+	delay := cfg.nackBackoffMin
+	for i := 0; i < redeliveryCount && delay < cfg.nackBackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > cfg.nackBackoffMax {
+		delay = cfg.nackBackoffMax
+	}
+	return delay
+}
+
+// schemaContentType returns the content type of payloads of a schema type.
+func schemaContentType(schemaType string) string {
+	switch schemaType {
+	case "JSON":
+		return "application/json"
+	case "STRING":
+		return "text/plain"
+	case "AVRO":
+		return "avro/binary"
+	case "PROTOBUF", "PROTOBUF_NATIVE":
+		return "application/x-protobuf"
+	}
+	return "application/octet-stream"
+}
+
+// parsePublishTime parses the publish time of a message, e.g.
+// 2021-03-01T12:00:00.123Z, or 2021-03-01 12:00:00.123 of older brokers.
+func parsePublishTime(publishTime string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.000"} {
+		if t, err := time.Parse(layout, publishTime); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// fullTopic returns the full name of a topic, in the public/default
+// namespace if given by name.
+func fullTopic(topic string) string {
+	if strings.Contains(topic, "://") {
+		return topic
+	}
+	return "persistent://public/default/" + topic
+}
+
+// topicPath returns the path of a full topic name in URLs, e.g.
+// persistent/public/default/orders.
+func topicPath(topic string) string {
+	return strings.Replace(topic, "://", "/", 1)
+}
+
+// IsTopicValid returns whether topic is the full name of a topic, or a
+// name in the public/default namespace.
+func IsTopicValid(topic string) bool {
+	return topicRegex.MatchString(topic) || topicNameRegex.MatchString(topic)
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pulsar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/mqtrigger/messageQueue"
+)
+
+func TestParseConfig(t *testing.T) {
+	trigger := &fv1.MessageQueueTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "default"},
+		Spec:       fv1.MessageQueueTriggerSpec{Topic: "orders"},
+	}
+
+	cfg, err := parseConfig(trigger)
+	require.NoError(t, err)
+	require.Equal(t, &subscriptionConfig{
+		subscription:      "fission-default-orders",
+		subscriptionType:  SubscriptionTypeShared,
+		receiverQueueSize: defaultReceiverQueueSize,
+		nackBackoffMin:    defaultNackBackoffMin,
+		nackBackoffMax:    defaultNackBackoffMax,
+		maxRedeliverCount: defaultMaxRedeliverCount,
+	}, cfg)
+
+	trigger.Spec.Metadata = map[string]string{
+		MetadataSubscription:      "billing",
+		MetadataSubscriptionType:  SubscriptionTypeKeyShared,
+		MetadataReceiverQueueSize: "100",
+		MetadataNackBackoffMin:    "500ms",
+		MetadataNackBackoffMax:    "5m",
+		MetadataDeadLetterTopic:   "persistent://shop/orders/created-dlq",
+		MetadataMaxRedeliverCount: "3",
+	}
+	cfg, err = parseConfig(trigger)
+	require.NoError(t, err)
+	require.Equal(t, &subscriptionConfig{
+		subscription:      "billing",
+		subscriptionType:  SubscriptionTypeKeyShared,
+		receiverQueueSize: 100,
+		nackBackoffMin:    500 * time.Millisecond,
+		nackBackoffMax:    5 * time.Minute,
+		deadLetterTopic:   "persistent://shop/orders/created-dlq",
+		maxRedeliverCount: 3,
+	}, cfg)
+
+	for _, metadata := range []map[string]string{
+		{MetadataSubscriptionType: "shared"},
+		{MetadataReceiverQueueSize: "0"},
+		{MetadataNackBackoffMin: "soon"},
+		{MetadataNackBackoffMin: "1m", MetadataNackBackoffMax: "1s"},
+		{MetadataDeadLetterTopic: "orders dead"},
+		{MetadataMaxRedeliverCount: "-1"},
+	} {
+		trigger.Spec.Metadata = metadata
+		_, err = parseConfig(trigger)
+		require.Error(t, err, "metadata %v", metadata)
+	}
+}
+
+func TestNackDelay(t *testing.T) {
+	cfg := &subscriptionConfig{nackBackoffMin: time.Second, nackBackoffMax: 10 * time.Second}
+	for redeliveryCount, delay := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second} {
+		require.Equal(t, delay, nackDelay(cfg, redeliveryCount), "redelivery count %v", redeliveryCount)
+	}
+	require.Equal(t, 10*time.Second, nackDelay(cfg, 1000))
+}
+
+func TestOrderingKey(t *testing.T) {
+	_, ordered := orderingKey(SubscriptionTypeShared, "customer-1")
+	require.False(t, ordered)
+
+	key, ordered := orderingKey(SubscriptionTypeKeyShared, "customer-1")
+	require.True(t, ordered)
+	require.Equal(t, "customer-1", key)
+
+	for _, subscriptionType := range []string{SubscriptionTypeExclusive, SubscriptionTypeFailover} {
+		key, ordered = orderingKey(subscriptionType, "customer-1")
+		require.True(t, ordered)
+		require.Empty(t, key)
+	}
+}
+
+func TestTopics(t *testing.T) {
+	require.Equal(t, "persistent://public/default/orders", fullTopic("orders"))
+	require.Equal(t, "non-persistent://shop/orders/created", fullTopic("non-persistent://shop/orders/created"))
+	require.Equal(t, "persistent/public/default/orders", topicPath(fullTopic("orders")))
+
+	require.True(t, IsTopicValid("orders"))
+	require.True(t, IsTopicValid("persistent://shop/orders/created"))
+	require.False(t, IsTopicValid(""))
+	require.False(t, IsTopicValid("persistent://shop/created"))
+	require.False(t, IsTopicValid("new orders"))
+}
+
+func TestGetSchemaType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/admin/v2/schemas/shop/orders/created/schema":
+			w.Write([]byte(`{"version": 0, "type": "JSON", "data": "{}"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	mq, err := New(zap.NewNop(), messageQueue.Config{
+		Url:     server.URL,
+		Secrets: map[string][]byte{"token": []byte("secret\n")},
+	}, "http://router.fission")
+	require.NoError(t, err)
+	p := mq.(Pulsar)
+
+	schemaType, err := p.getSchemaType(fullTopic("persistent://shop/orders/created"))
+	require.NoError(t, err)
+	require.Equal(t, "JSON", schemaType)
+	require.Equal(t, "application/json", schemaContentType(schemaType))
+
+	schemaType, err = p.getSchemaType(fullTopic("orders"))
+	require.NoError(t, err)
+	require.Equal(t, "BYTES", schemaType)
+	require.Equal(t, "application/octet-stream", schemaContentType(schemaType))
+}
+
+func TestLag(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/admin/v2/persistent/public/default/orders/stats":
+			w.Write([]byte(`{"subscriptions": {"fission-default-orders": {"msgBacklog": 42}}}`))
+		case "/admin/v2/persistent/shop/orders/created/partitioned-stats":
+			w.Write([]byte(`{"subscriptions": {"billing": {"msgBacklog": 7}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	mq, err := New(zap.NewNop(), messageQueue.Config{Url: server.URL}, "http://router.fission")
+	require.NoError(t, err)
+	p := mq.(Pulsar)
+
+	trigger := &fv1.MessageQueueTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "default"},
+		Spec:       fv1.MessageQueueTriggerSpec{Topic: "orders"},
+	}
+	lag, err := p.Lag(trigger)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), lag)
+
+	trigger.Spec.Topic = "persistent://shop/orders/created"
+	trigger.Spec.Metadata = map[string]string{MetadataSubscription: "billing"}
+	lag, err = p.Lag(trigger)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), lag)
+
+	trigger.Spec.Topic = "persistent://shop/orders/cancelled"
+	_, err = p.Lag(trigger)
+	require.Error(t, err)
+}
+
+func TestParsePublishTime(t *testing.T) {
+	for _, publishTime := range []string{"2021-03-01T12:00:00.123Z", "2021-03-01 12:00:00.123"} {
+		parsed, ok := parsePublishTime(publishTime)
+		require.True(t, ok, publishTime)
+		require.Equal(t, time.Date(2021, 3, 1, 12, 0, 0, 123000000, time.UTC), parsed.UTC())
+	}
+	_, ok := parsePublishTime("")
+	require.False(t, ok)
+}
@@ -0,0 +1,537 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rabbitmq
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/streadway/amqp"
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/mqtrigger/factory"
+	"github.com/fission/fission/pkg/mqtrigger/messageQueue"
+	"github.com/fission/fission/pkg/mqtrigger/validator"
+	"github.com/fission/fission/pkg/utils"
+)
+
+func init() {
+	factory.Register(fv1.MessageQueueTypeRabbitMQ, &Factory{})
+	validator.Register(fv1.MessageQueueTypeRabbitMQ, IsTopicValid)
+}
+
+// Settings of a trigger, in the metadata of the trigger. The topic of a
+// trigger is the queue it consumes; the queue is declared durable if it
+// doesn't exist, and bound to the exchange if one is set. The response
+// and error topics are queues too, published to through the default
+// exchange.
+const (
+	// MetadataExchange is the exchange to bind the queue to, declared
+	// durable if it doesn't exist. The queue is left unbound if empty.
+	MetadataExchange = "exchange"
+	// MetadataExchangeType is the type of the exchange: direct, fanout,
+	// topic or headers. topic if empty.
+	MetadataExchangeType = "exchangeType"
+	// MetadataBindingKey is the key to bind the queue to the exchange
+	// with, the topic if empty.
+	MetadataBindingKey = "bindingKey"
+	// MetadataPrefetch is how many messages are invoked with at a time.
+	MetadataPrefetch = "prefetch"
+	// MetadataDeadLetterExchange is the exchange messages the function
+	// failed on are published to by the broker. Failed messages are
+	// dropped if empty.
+	MetadataDeadLetterExchange = "deadLetterExchange"
+	// MetadataDeadLetterRoutingKey is the routing key failed messages are
+	// dead-lettered with, their own if empty.
+	MetadataDeadLetterRoutingKey = "deadLetterRoutingKey"
+
+	defaultExchangeType = amqp.ExchangeTopic
+	defaultPrefetch     = 10
+)
+
+type (
+	RabbitMQ struct {
+		logger    *zap.Logger
+		conn      *amqp.Connection
+		routerUrl string
+	}
+
+	// subscriptionConfig is the queue, exchange and consumer settings of
+	// a trigger.
+	subscriptionConfig struct {
+		exchange             string
+		exchangeType         string
+		bindingKey           string
+		prefetch             int
+		deadLetterExchange   string
+		deadLetterRoutingKey string
+	}
+
+	// subscription is a consumer of the queue of a trigger, on a channel
+	// of its own for its prefetch to apply to it alone.
+	subscription struct {
+		channel     *amqp.Channel
+		consumerTag string
+		batcher     *messageQueue.Batcher
+	}
+
+	Factory struct{}
+)
+
+func (factory *Factory) Create(logger *zap.Logger, mqCfg messageQueue.Config, routerUrl string) (messageQueue.MessageQueue, error) {
+	return New(logger, mqCfg, routerUrl)
+}
+
+// New connects to the broker at the URL of the config, or the "url"
+// secret, which keeps credentials out of the environment. Connections to
+// amqps URLs trust the "caCert" secret, and authenticate with the
+// "userCert" and "userKey" secrets, if set.
+func New(logger *zap.Logger, mqCfg messageQueue.Config, routerUrl string) (messageQueue.MessageQueue, error) {
+	url := mqCfg.Url
+	if u, ok := mqCfg.Secrets["url"]; ok {
+		url = strings.TrimSpace(string(u))
+	}
+	if len(routerUrl) == 0 || len(url) == 0 {
+		return nil, errors.New("the router URL or MQ URL is empty")
+	}
+
+	tlsConfig, err := getTLSConfig(mqCfg.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	var conn *amqp.Connection
+	if tlsConfig != nil {
+		conn, err = amqp.DialTLS(url, tlsConfig)
+	} else {
+		conn, err = amqp.Dial(url)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error connecting to rabbitmq")
+	}
+
+	rabbitmq := RabbitMQ{
+		logger:    logger.Named("rabbitmq"),
+		conn:      conn,
+		routerUrl: routerUrl,
+	}
+
+	// As with NATS, the trigger manager can't tell a lost connection, so
+	// the pod is restarted to reconnect and subscribe again.
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		if err := <-closed; err != nil {
+			rabbitmq.logger.Fatal("connection lost", zap.Error(err))
+		}
+	}()
+
+	return rabbitmq, nil
+}
+
+func getTLSConfig(secrets map[string][]byte) (*tls.Config, error) {
+	caCert, hasCA := secrets["caCert"]
+	userCert, hasCert := secrets["userCert"]
+	userKey := secrets["userKey"]
+	if !hasCA && !hasCert {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if hasCA {
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("error parsing CA certificate")
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+	if hasCert {
+		cert, err := tls.X509KeyPair(userCert, userKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing user certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func (rabbitmq RabbitMQ) Subscribe(trigger *fv1.MessageQueueTrigger) (messageQueue.Subscription, error) {
+	if !IsTopicValid(trigger.Spec.Topic) {
+		return nil, fmt.Errorf("not a valid topic: %q", trigger.Spec.Topic)
+	}
+	cfg, err := parseConfig(trigger)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := rabbitmq.conn.Channel()
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening channel")
+	}
+	sub, err := rabbitmq.consume(ch, trigger, cfg)
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+	rabbitmq.logger.Info("subscribed to queue",
+		zap.String("queue", trigger.Spec.Topic),
+		zap.String("exchange", cfg.exchange),
+		zap.String("binding_key", cfg.bindingKey),
+		zap.Int("prefetch", cfg.prefetch),
+		zap.String("trigger", trigger.ObjectMeta.Name))
+	return sub, nil
+}
+
+func (rabbitmq RabbitMQ) consume(ch *amqp.Channel, trigger *fv1.MessageQueueTrigger, cfg *subscriptionConfig) (*subscription, error) {
+	retry, err := messageQueue.NewRetryPolicy(trigger)
+	if err != nil {
+		return nil, err
+	}
+
+	prefetch := cfg.prefetch
+	if messageQueue.IsBatched(trigger) && trigger.Spec.BatchSize > prefetch {
+		// for batches to fill up
+		prefetch = trigger.Spec.BatchSize
+	}
+	err = ch.Qos(prefetch, 0, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "error setting prefetch")
+	}
+
+	if len(cfg.exchange) > 0 {
+		err = ch.ExchangeDeclare(cfg.exchange, cfg.exchangeType, true, false, false, false, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error declaring exchange %q", cfg.exchange)
+		}
+	}
+
+	args := amqp.Table{}
+	if len(cfg.deadLetterExchange) > 0 {
+		args["x-dead-letter-exchange"] = cfg.deadLetterExchange
+	}
+	if len(cfg.deadLetterRoutingKey) > 0 {
+		args["x-dead-letter-routing-key"] = cfg.deadLetterRoutingKey
+	}
+	_, err = ch.QueueDeclare(trigger.Spec.Topic, true, false, false, false, args)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error declaring queue %q", trigger.Spec.Topic)
+	}
+
+	if len(cfg.exchange) > 0 {
+		err = ch.QueueBind(trigger.Spec.Topic, cfg.bindingKey, cfg.exchange, false, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error binding queue %q to exchange %q", trigger.Spec.Topic, cfg.exchange)
+		}
+	}
+
+	consumerTag := string(trigger.ObjectMeta.UID)
+	deliveries, err := ch.Consume(trigger.Spec.Topic, consumerTag, false, false, false, false, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error consuming queue %q", trigger.Spec.Topic)
+	}
+
+	if messageQueue.IsBatched(trigger) {
+		batcher, err := messageQueue.NewBatcher(rabbitmq.logger, rabbitmq.routerUrl, trigger, func(topic string, body []byte, contentType string) error {
+			return publish(ch, topic, body, http.Header{"Content-Type": {contentType}})
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// messages of batches the function failed on are rejected, for
+		// the broker to dead-letter them
+		go func() {
+			for d := range deliveries {
+				d := d
+				batcher.Add(messageQueue.BatchMessage{
+					Body: d.Body,
+					Ack:  func() { rabbitmq.settle(trigger, d.Ack(false)) },
+					Nack: func() { rabbitmq.settle(trigger, d.Nack(false, false)) },
+				})
+			}
+		}()
+
+		return &subscription{channel: ch, consumerTag: consumerTag, batcher: batcher}, nil
+	}
+
+	// the prefetch bounds the messages handled at a time, along with the
+	// max concurrent invocations of the trigger
+	limiter := messageQueue.NewLimiter(trigger)
+	go func() {
+		for d := range deliveries {
+			limiter.Acquire()
+			go func(d amqp.Delivery) {
+				defer limiter.Release()
+				rabbitmq.msgHandler(ch, trigger, retry, d)
+			}(d)
+		}
+	}()
+
+	return &subscription{channel: ch, consumerTag: consumerTag}, nil
+}
+
+func (rabbitmq RabbitMQ) Unsubscribe(triggerSub messageQueue.Subscription) error {
+	sub := triggerSub.(*subscription)
+	err := sub.channel.Cancel(sub.consumerTag, false)
+	if err != nil {
+		return err
+	}
+	if sub.batcher != nil {
+		sub.batcher.Close()
+	}
+	// messages in flight are redelivered to the next consumer
+	return sub.channel.Close()
+}
+
+// Lag returns the number of messages ready in the queue of a trigger, not
+// counting those delivered and waiting to be acked.
+func (rabbitmq RabbitMQ) Lag(trigger *fv1.MessageQueueTrigger) (int64, error) {
+	// a failed inspect closes the channel, so it gets a channel of its own
+	ch, err := rabbitmq.conn.Channel()
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+	queue, err := ch.QueueInspect(trigger.Spec.Topic)
+	if err != nil {
+		return 0, err
+	}
+	return int64(queue.Messages), nil
+}
+
+// msgHandler invokes the function of a trigger with a message, retried with
+// the retry policy of the trigger, and acks the message once the function
+// succeeds. Messages the function fails on are published to the error
+// topic, and to the dead-letter topic or else rejected, for the broker to
+// dead-letter them.
+func (rabbitmq RabbitMQ) msgHandler(ch *amqp.Channel, trigger *fv1.MessageQueueTrigger, retry *messageQueue.RetryPolicy, d amqp.Delivery) {
+	// Support other function ref types
+	if trigger.Spec.FunctionReference.Type != fv1.FunctionReferenceTypeFunctionName {
+		rabbitmq.logger.Fatal("unsupported function reference type for trigger",
+			zap.Any("function_reference_type", trigger.Spec.FunctionReference.Type),
+			zap.String("trigger", trigger.ObjectMeta.Name))
+	}
+
+	url := rabbitmq.routerUrl + "/" + strings.TrimPrefix(utils.UrlForFunction(trigger.Spec.FunctionReference.Name, trigger.ObjectMeta.Namespace), "/")
+	rabbitmq.logger.Debug("making HTTP request", zap.String("url", url))
+
+	fissionHeaders := map[string]string{
+		"X-Fission-MQTrigger-Topic":      trigger.Spec.Topic,
+		"X-Fission-MQTrigger-RespTopic":  trigger.Spec.ResponseTopic,
+		"X-Fission-MQTrigger-ErrorTopic": trigger.Spec.ErrorTopic,
+		"Content-Type":                   trigger.Spec.ContentType,
+		fv1.HeaderTriggerType:            fv1.TriggerTypeMessageQueue,
+		fv1.HeaderTriggerName:            trigger.ObjectMeta.Name,
+		fv1.HeaderCloudEventsMode:        string(trigger.Spec.CloudEvents),
+		fv1.HeaderPriority:               string(fv1.InvocationPriorityBatch),
+	}
+	// the timestamp is only set if the publisher set it
+	if !d.Timestamp.IsZero() {
+		fissionHeaders[fv1.HeaderEventTime] = d.Timestamp.UTC().Format(time.RFC3339Nano)
+	}
+
+	resp, err := retry.Invoke(rabbitmq.logger.With(zap.String("trigger", trigger.ObjectMeta.Name)), func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(d.Body))
+		if err != nil {
+			return nil, err
+		}
+		// the headers of the message, then ours
+		for k, v := range d.Headers {
+			if s, ok := headerValue(v); ok {
+				req.Header.Set(k, s)
+			}
+		}
+		for k, v := range fissionHeaders {
+			req.Header.Set(k, v)
+		}
+		return req, nil
+	})
+	if err != nil {
+		rabbitmq.reject(ch, trigger, retry, d, url, []byte(fmt.Sprintf("request exceed retries: %v: %v", retry.MaxAttempts-1, err)), nil)
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		rabbitmq.reject(ch, trigger, retry, d, url, []byte(fmt.Sprintf("error reading function invocation response: %v", err)), nil)
+		return
+	}
+	rabbitmq.logger.Debug("got response from function invocation",
+		zap.String("function_url", url),
+		zap.String("trigger", trigger.ObjectMeta.Name),
+		zap.String("body", string(body)))
+
+	if resp.StatusCode != http.StatusOK {
+		rabbitmq.reject(ch, trigger, retry, d, url, body, resp.Header)
+		return
+	}
+
+	if len(trigger.Spec.ResponseTopic) > 0 {
+		err = publish(ch, trigger.Spec.ResponseTopic, body, resp.Header)
+		if err != nil {
+			rabbitmq.logger.Error("failed to publish message with function invocation response to topic",
+				zap.Error(err),
+				zap.String("topic", trigger.Spec.ResponseTopic),
+				zap.String("trigger", trigger.ObjectMeta.Name))
+		}
+	}
+
+	// Trigger acks message only if a request was processed successfully
+	err = d.Ack(false)
+	if err != nil {
+		rabbitmq.logger.Error("failed to ack message after successful function invocation from trigger",
+			zap.Error(err),
+			zap.String("function_url", url),
+			zap.String("trigger", trigger.ObjectMeta.Name))
+	}
+}
+
+// reject publishes the error of a message the function failed on to the
+// error topic, and the message to the dead-letter topic of the trigger, or
+// else rejects the message without requeueing it, so it's dead-lettered if
+// the queue has a dead-letter exchange.
+func (rabbitmq RabbitMQ) reject(ch *amqp.Channel, trigger *fv1.MessageQueueTrigger, retry *messageQueue.RetryPolicy, d amqp.Delivery, url string, body []byte, header http.Header) {
+	rabbitmq.logger.Error("function invocation failed, rejecting message",
+		zap.String("function_url", url),
+		zap.String("trigger", trigger.ObjectMeta.Name),
+		zap.String("error", string(body)))
+
+	if len(trigger.Spec.ErrorTopic) > 0 && len(body) > 0 {
+		err := publish(ch, trigger.Spec.ErrorTopic, body, header)
+		if err != nil {
+			rabbitmq.logger.Error("failed to publish function invocation error to error topic",
+				zap.Error(err),
+				zap.String("topic", trigger.Spec.ErrorTopic),
+				zap.String("function_url", url),
+				zap.String("trigger", trigger.ObjectMeta.Name))
+		}
+	}
+
+	deadLettered := retry.DeadLetter(rabbitmq.logger, d.Body, d.ContentType, func(topic string, body []byte, contentType string) error {
+		return publish(ch, topic, body, http.Header{"Content-Type": {contentType}})
+	})
+	if deadLettered {
+		err := d.Ack(false)
+		if err != nil {
+			rabbitmq.logger.Error("failed to ack message after publishing it to dead-letter topic",
+				zap.Error(err),
+				zap.String("trigger", trigger.ObjectMeta.Name))
+		}
+		return
+	}
+
+	err := d.Nack(false, false)
+	if err != nil {
+		rabbitmq.logger.Error("failed to reject message after failed function invocation from trigger",
+			zap.Error(err),
+			zap.String("function_url", url),
+			zap.String("trigger", trigger.ObjectMeta.Name))
+	}
+}
+
+// settle logs the error of acking or rejecting a message of a batch.
+func (rabbitmq RabbitMQ) settle(trigger *fv1.MessageQueueTrigger, err error) {
+	if err != nil {
+		rabbitmq.logger.Error("failed to settle message of batch",
+			zap.Error(err),
+			zap.String("trigger", trigger.ObjectMeta.Name))
+	}
+}
+
+// publish publishes body to the queue named topic through the default
+// exchange, with the headers of the function response.
+func publish(ch *amqp.Channel, topic string, body []byte, header http.Header) error {
+	headers := amqp.Table{}
+	for k, v := range header {
+		if len(v) > 0 {
+			headers[k] = v[0]
+		}
+	}
+	return ch.Publish("", topic, false, false, amqp.Publishing{
+		Headers:      headers,
+		ContentType:  header.Get("Content-Type"),
+		Body:         body,
+		Timestamp:    time.Now(),
+		DeliveryMode: amqp.Persistent,
+	})
+}
+
+// parseConfig reads the queue, exchange and consumer settings of a trigger
+// from its metadata.
+func parseConfig(trigger *fv1.MessageQueueTrigger) (*subscriptionConfig, error) {
+	metadata := trigger.Spec.Metadata
+	cfg := &subscriptionConfig{
+		exchange:             metadata[MetadataExchange],
+		exchangeType:         metadata[MetadataExchangeType],
+		bindingKey:           metadata[MetadataBindingKey],
+		prefetch:             defaultPrefetch,
+		deadLetterExchange:   metadata[MetadataDeadLetterExchange],
+		deadLetterRoutingKey: metadata[MetadataDeadLetterRoutingKey],
+	}
+
+	if len(cfg.exchangeType) == 0 {
+		cfg.exchangeType = defaultExchangeType
+	}
+	switch cfg.exchangeType {
+	case amqp.ExchangeDirect, amqp.ExchangeFanout, amqp.ExchangeTopic, amqp.ExchangeHeaders:
+	default:
+		return nil, errors.Errorf("unsupported exchange type %q, must be one of direct, fanout, topic or headers", cfg.exchangeType)
+	}
+	if len(cfg.bindingKey) == 0 {
+		cfg.bindingKey = trigger.Spec.Topic
+	}
+
+	if p, ok := metadata[MetadataPrefetch]; ok {
+		prefetch, err := strconv.Atoi(p)
+		if err != nil || prefetch <= 0 {
+			return nil, errors.Errorf("prefetch must be a number greater than 0, got %q", p)
+		}
+		cfg.prefetch = prefetch
+	}
+
+	return cfg, nil
+}
+
+// headerValue returns the value of a message header to set on requests, for
+// the types of values HTTP headers can hold.
+func headerValue(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	case bool, int8, int16, int32, int64, float32, float64:
+		return fmt.Sprint(v), true
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano), true
+	}
+	return "", false
+}
+
+// IsTopicValid returns whether topic is a valid name of a queue: at most 255
+// bytes, and not in the "amq." namespace reserved by the broker.
+func IsTopicValid(topic string) bool {
+	return len(topic) > 0 && len(topic) <= 255 && !strings.HasPrefix(topic, "amq.")
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rabbitmq
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestParseConfig(t *testing.T) {
+	trigger := &fv1.MessageQueueTrigger{Spec: fv1.MessageQueueTriggerSpec{Topic: "orders"}}
+
+	cfg, err := parseConfig(trigger)
+	require.NoError(t, err)
+	require.Equal(t, &subscriptionConfig{
+		exchangeType: "topic",
+		bindingKey:   "orders",
+		prefetch:     defaultPrefetch,
+	}, cfg)
+
+	trigger.Spec.Metadata = map[string]string{
+		MetadataExchange:             "events",
+		MetadataExchangeType:         "direct",
+		MetadataBindingKey:           "order.created",
+		MetadataPrefetch:             "50",
+		MetadataDeadLetterExchange:   "events.dlx",
+		MetadataDeadLetterRoutingKey: "orders.failed",
+	}
+	cfg, err = parseConfig(trigger)
+	require.NoError(t, err)
+	require.Equal(t, &subscriptionConfig{
+		exchange:             "events",
+		exchangeType:         "direct",
+		bindingKey:           "order.created",
+		prefetch:             50,
+		deadLetterExchange:   "events.dlx",
+		deadLetterRoutingKey: "orders.failed",
+	}, cfg)
+
+	for _, metadata := range []map[string]string{
+		{MetadataExchangeType: "x-delayed-message"},
+		{MetadataPrefetch: "0"},
+		{MetadataPrefetch: "many"},
+	} {
+		trigger.Spec.Metadata = metadata
+		_, err = parseConfig(trigger)
+		require.Error(t, err, "metadata %v", metadata)
+	}
+}
+
+func TestHeaderValue(t *testing.T) {
+	for _, test := range []struct {
+		value    interface{}
+		expected string
+		ok       bool
+	}{
+		{"abc", "abc", true},
+		{[]byte("abc"), "abc", true},
+		{int32(42), "42", true},
+		{true, "true", true},
+		{time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC), "2021-03-01T12:00:00Z", true},
+		{map[string]interface{}{"a": "b"}, "", false},
+	} {
+		s, ok := headerValue(test.value)
+		require.Equal(t, test.ok, ok, "value %v", test.value)
+		require.Equal(t, test.expected, s, "value %v", test.value)
+	}
+}
+
+func TestIsTopicValid(t *testing.T) {
+	require.True(t, IsTopicValid("orders"))
+	require.True(t, IsTopicValid("orders.created-v1"))
+	require.False(t, IsTopicValid(""))
+	require.False(t, IsTopicValid("amq.gen-123"))
+	require.False(t, IsTopicValid(strings.Repeat("q", 256)))
+}
@@ -0,0 +1,700 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redisstreams
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/mqtrigger/factory"
+	"github.com/fission/fission/pkg/mqtrigger/messageQueue"
+	"github.com/fission/fission/pkg/mqtrigger/validator"
+	"github.com/fission/fission/pkg/utils"
+)
+
+func init() {
+	factory.Register(fv1.MessageQueueTypeRedisStreams, &Factory{})
+	validator.Register(fv1.MessageQueueTypeRedisStreams, IsTopicValid)
+}
+
+// Settings of a trigger, in the metadata of the trigger. The topic of a
+// trigger is the stream it consumes through a consumer group, created with
+// the stream if they don't exist; the response and error topics are
+// streams too.
+//
+// The body of an entry is its "body" field, and its other fields are set
+// as headers; entries without a body field are invoked with all of their
+// fields encoded as a JSON object. Responses and errors are added to their
+// streams as the body field of entries.
+const (
+	// MetadataConsumerGroup is the consumer group to read the stream with.
+	// fission-<namespace>-<trigger> if empty.
+	MetadataConsumerGroup = "consumerGroup"
+	// MetadataBatchSize is how many entries are read, and invoked with, at
+	// a time.
+	MetadataBatchSize = "batchSize"
+	// MetadataClaimIdleTime is how long an entry is pending with a consumer
+	// of the group before it's claimed from it, as a duration, e.g. "5m".
+	// Entries of consumers that crashed, and entries the function failed
+	// on, are retried then.
+	MetadataClaimIdleTime = "claimIdleTime"
+	// MetadataMaxDeliveries is how many times an entry is delivered before
+	// it's given up on: added to the dead-letter stream if set, and acked.
+	MetadataMaxDeliveries = "maxDeliveries"
+	// MetadataDeadLetterStream is the stream entries given up on are added
+	// to. They're dropped if empty.
+	MetadataDeadLetterStream = "deadLetterStream"
+
+	bodyField = "body"
+
+	defaultBatchSize     = 10
+	defaultClaimIdleTime = 5 * time.Minute
+	defaultMaxDeliveries = 5
+
+	// readBlock is how long a read waits for new entries, which bounds
+	// how long unsubscribing takes too.
+	readBlock = 5 * time.Second
+
+	// retryInterval is how long to wait to read entries again after an
+	// error.
+	retryInterval = 5 * time.Second
+
+	// maxLagCount bounds the entries counted not delivered yet to a
+	// consumer group, when the server doesn't report its lag.
+	maxLagCount = 10000
+)
+
+type (
+	RedisStreams struct {
+		logger    *zap.Logger
+		client    *redis.Client
+		consumer  string
+		routerUrl string
+	}
+
+	// subscriptionConfig is the consumer group settings of a trigger.
+	subscriptionConfig struct {
+		group            string
+		batchSize        int64
+		claimIdleTime    time.Duration
+		maxDeliveries    int64
+		deadLetterStream string
+	}
+
+	// subscription is the reader of the stream of a trigger.
+	subscription struct {
+		// batcher is set if the function of the trigger is invoked with
+		// batches of entries.
+		batcher *messageQueue.Batcher
+		limiter messageQueue.Limiter
+		retry   *messageQueue.RetryPolicy
+
+		stop chan struct{}
+		done chan struct{}
+	}
+
+	Factory struct{}
+)
+
+func (factory *Factory) Create(logger *zap.Logger, mqCfg messageQueue.Config, routerUrl string) (messageQueue.MessageQueue, error) {
+	return New(logger, mqCfg, routerUrl)
+}
+
+// New connects to the Redis server at the URL of the config, or the "url"
+// secret, with the "password" secret if set. Connections to rediss URLs
+// trust the "caCert" secret if set. Entries are read as the consumer named
+// after the host, so the pending entries of a crashed trigger manager are
+// claimed by the next one.
+func New(logger *zap.Logger, mqCfg messageQueue.Config, routerUrl string) (messageQueue.MessageQueue, error) {
+	url := mqCfg.Url
+	if u, ok := mqCfg.Secrets["url"]; ok {
+		url = strings.TrimSpace(string(u))
+	}
+	if len(routerUrl) == 0 || len(url) == 0 {
+		return nil, errors.New("the router URL or MQ URL is empty")
+	}
+
+	opt, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing redis URL")
+	}
+	if password, ok := mqCfg.Secrets["password"]; ok {
+		opt.Password = strings.TrimSpace(string(password))
+	}
+	if caCert, ok := mqCfg.Secrets["caCert"]; ok && opt.TLSConfig != nil {
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("error parsing CA certificate")
+		}
+		opt.TLSConfig.RootCAs = caCertPool
+	}
+	// reads block for longer than the default read timeout
+	opt.ReadTimeout = readBlock + 10*time.Second
+
+	client := redis.NewClient(opt)
+	err = client.Ping().Err()
+	if err != nil {
+		client.Close()
+		return nil, errors.Wrap(err, "error connecting to redis")
+	}
+
+	consumer, err := os.Hostname()
+	if err != nil {
+		client.Close()
+		return nil, errors.Wrap(err, "error getting hostname to name consumer after")
+	}
+
+	return RedisStreams{
+		logger:    logger.Named("redis_streams"),
+		client:    client,
+		consumer:  consumer,
+		routerUrl: routerUrl,
+	}, nil
+}
+
+func (r RedisStreams) Subscribe(trigger *fv1.MessageQueueTrigger) (messageQueue.Subscription, error) {
+	if !IsTopicValid(trigger.Spec.Topic) {
+		return nil, fmt.Errorf("not a valid topic: %q", trigger.Spec.Topic)
+	}
+	cfg, err := parseConfig(trigger)
+	if err != nil {
+		return nil, err
+	}
+
+	// new consumer groups read the entries added from now on
+	err = r.client.XGroupCreateMkStream(trigger.Spec.Topic, cfg.group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, errors.Wrapf(err, "error creating consumer group %q of stream %q", cfg.group, trigger.Spec.Topic)
+	}
+
+	sub := &subscription{
+		limiter: messageQueue.NewLimiter(trigger),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	sub.retry, err = messageQueue.NewRetryPolicy(trigger)
+	if err != nil {
+		return nil, err
+	}
+	if messageQueue.IsBatched(trigger) {
+		sub.batcher, err = messageQueue.NewBatcher(r.logger, r.routerUrl, trigger, func(topic string, body []byte, contentType string) error {
+			return r.add(topic, map[string]interface{}{bodyField: body})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	go r.read(trigger, cfg, sub)
+
+	r.logger.Info("subscribed to stream",
+		zap.String("stream", trigger.Spec.Topic),
+		zap.String("group", cfg.group),
+		zap.String("consumer", r.consumer),
+		zap.Int64("batch_size", cfg.batchSize),
+		zap.String("trigger", trigger.ObjectMeta.Name))
+	return sub, nil
+}
+
+// Unsubscribe stops reading the stream of a trigger, once the entries read
+// are handled. The consumer group is kept, as entries pending with it are
+// claimed by the next consumer.
+func (r RedisStreams) Unsubscribe(triggerSub messageQueue.Subscription) error {
+	sub := triggerSub.(*subscription)
+	close(sub.stop)
+	<-sub.done
+	if sub.batcher != nil {
+		sub.batcher.Close()
+	}
+	return nil
+}
+
+// Lag returns the number of entries of the stream of a trigger its consumer
+// group is yet to handle: those pending with its consumers, and those not
+// delivered yet.
+func (r RedisStreams) Lag(trigger *fv1.MessageQueueTrigger) (int64, error) {
+	cfg, err := parseConfig(trigger)
+	if err != nil {
+		return 0, err
+	}
+
+	reply, err := r.client.Do("XINFO", "GROUPS", trigger.Spec.Topic).Result()
+	if err != nil {
+		return 0, errors.Wrapf(err, "error getting consumer groups of stream %q", trigger.Spec.Topic)
+	}
+	group, ok := groupInfo(reply, cfg.group)
+	if !ok {
+		// the group reads the entries added once it's created
+		return 0, nil
+	}
+	pending, _ := group["pending"].(int64)
+
+	// reported by Redis 7, unless entries were deleted
+	if lag, ok := group["lag"].(int64); ok {
+		return pending + lag, nil
+	}
+
+	lastDelivered, _ := group["last-delivered-id"].(string)
+	next, ok := nextEntryID(lastDelivered)
+	if !ok {
+		return 0, errors.Errorf("unexpected last delivered id %q of consumer group %q", lastDelivered, cfg.group)
+	}
+	entries, err := r.client.XRangeN(trigger.Spec.Topic, next, "+", maxLagCount).Result()
+	if err != nil {
+		return 0, errors.Wrapf(err, "error reading stream %q", trigger.Spec.Topic)
+	}
+	return pending + int64(len(entries)), nil
+}
+
+// read handles the entries of the stream of a trigger until unsubscribed:
+// the entries idle for longer than the claim idle time with any consumer of
+// the group, then the new entries, a batch at a time.
+func (r RedisStreams) read(trigger *fv1.MessageQueueTrigger, cfg *subscriptionConfig, sub *subscription) {
+	defer close(sub.done)
+	logger := r.logger.With(zap.String("stream", trigger.Spec.Topic), zap.String("trigger", trigger.ObjectMeta.Name))
+
+	var lastClaim time.Time
+	for {
+		select {
+		case <-sub.stop:
+			return
+		default:
+		}
+
+		// pending entries are checked every half claim idle time
+		if time.Since(lastClaim) >= cfg.claimIdleTime/2 {
+			lastClaim = time.Now()
+			msgs, deliveries, err := r.claim(trigger, cfg)
+			if err != nil {
+				logger.Error("failed to claim pending entries", zap.Error(err))
+			} else if len(msgs) > 0 {
+				logger.Info("claimed pending entries", zap.Int("entries", len(msgs)))
+				r.handleBatch(trigger, cfg, sub, msgs, deliveries)
+				// there may be more
+				lastClaim = time.Time{}
+				continue
+			}
+		}
+
+		streams, err := r.client.XReadGroup(&redis.XReadGroupArgs{
+			Group:    cfg.group,
+			Consumer: r.consumer,
+			Streams:  []string{trigger.Spec.Topic, ">"},
+			Count:    cfg.batchSize,
+			Block:    readBlock,
+		}).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			logger.Error("failed to read entries", zap.Error(err))
+			select {
+			case <-sub.stop:
+				return
+			case <-time.After(retryInterval):
+			}
+			continue
+		}
+		for _, stream := range streams {
+			r.handleBatch(trigger, cfg, sub, stream.Messages, nil)
+		}
+	}
+}
+
+// claim claims the entries of the stream of a trigger pending for longer
+// than the claim idle time, and returns them with how many times each was
+// delivered before.
+func (r RedisStreams) claim(trigger *fv1.MessageQueueTrigger, cfg *subscriptionConfig) ([]redis.XMessage, map[string]int64, error) {
+	pending, err := r.client.XPendingExt(&redis.XPendingExtArgs{
+		Stream: trigger.Spec.Topic,
+		Group:  cfg.group,
+		Start:  "-",
+		End:    "+",
+		Count:  cfg.batchSize,
+	}).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deliveries := make(map[string]int64)
+	var ids []string
+	for _, p := range pending {
+		if p.Idle >= cfg.claimIdleTime {
+			ids = append(ids, p.Id)
+			deliveries[p.Id] = p.RetryCount
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil, nil
+	}
+
+	// entries claimed by another consumer since are left out
+	msgs, err := r.client.XClaim(&redis.XClaimArgs{
+		Stream:   trigger.Spec.Topic,
+		Group:    cfg.group,
+		Consumer: r.consumer,
+		MinIdle:  cfg.claimIdleTime,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	return msgs, deliveries, nil
+}
+
+// handleBatch handles entries concurrently, or adds them to the open batch
+// of the trigger, giving up on the ones delivered the max deliveries
+// already.
+func (r RedisStreams) handleBatch(trigger *fv1.MessageQueueTrigger, cfg *subscriptionConfig, sub *subscription, msgs []redis.XMessage, deliveries map[string]int64) {
+	var wg sync.WaitGroup
+	for _, msg := range msgs {
+		if deliveries[msg.ID] >= cfg.maxDeliveries {
+			r.giveUp(trigger, cfg, msg, deliveries[msg.ID])
+			continue
+		}
+		if sub.batcher != nil {
+			r.addToBatch(trigger, cfg, sub, msg)
+			continue
+		}
+		wg.Add(1)
+		sub.limiter.Acquire()
+		go func(msg redis.XMessage) {
+			defer wg.Done()
+			defer sub.limiter.Release()
+			r.msgHandler(trigger, cfg, sub, msg)
+		}(msg)
+	}
+	wg.Wait()
+}
+
+// msgHandler invokes the function of a trigger with an entry, retried with
+// the retry policy of the trigger, and acks the entry once the function
+// succeeds. Entries the function fails on are handled by fail, and those
+// left pending are claimed and retried once the claim idle time passes.
+func (r RedisStreams) msgHandler(trigger *fv1.MessageQueueTrigger, cfg *subscriptionConfig, sub *subscription, msg redis.XMessage) {
+	// Support other function ref types
+	if trigger.Spec.FunctionReference.Type != fv1.FunctionReferenceTypeFunctionName {
+		r.logger.Fatal("unsupported function reference type for trigger",
+			zap.Any("function_reference_type", trigger.Spec.FunctionReference.Type),
+			zap.String("trigger", trigger.ObjectMeta.Name))
+	}
+
+	url := r.routerUrl + "/" + strings.TrimPrefix(utils.UrlForFunction(trigger.Spec.FunctionReference.Name, trigger.ObjectMeta.Namespace), "/")
+	r.logger.Debug("making HTTP request", zap.String("url", url))
+
+	fissionHeaders := map[string]string{
+		"X-Fission-MQTrigger-Topic":      trigger.Spec.Topic,
+		"X-Fission-MQTrigger-RespTopic":  trigger.Spec.ResponseTopic,
+		"X-Fission-MQTrigger-ErrorTopic": trigger.Spec.ErrorTopic,
+		"Content-Type":                   trigger.Spec.ContentType,
+		fv1.HeaderTriggerType:            fv1.TriggerTypeMessageQueue,
+		fv1.HeaderTriggerName:            trigger.ObjectMeta.Name,
+		fv1.HeaderCloudEventsMode:        string(trigger.Spec.CloudEvents),
+		fv1.HeaderPriority:               string(fv1.InvocationPriorityBatch),
+	}
+	if added, ok := entryTime(msg.ID); ok {
+		fissionHeaders[fv1.HeaderEventTime] = added.UTC().Format(time.RFC3339Nano)
+	}
+
+	body, headers, err := entryBody(msg.Values)
+	if err != nil {
+		r.fail(trigger, cfg, sub, msg, url, []byte(fmt.Sprintf("error encoding entry: %v", err)))
+		return
+	}
+
+	resp, err := sub.retry.Invoke(r.logger.With(zap.String("trigger", trigger.ObjectMeta.Name)), func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		// the fields of the entry, then ours
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		for k, v := range fissionHeaders {
+			req.Header.Set(k, v)
+		}
+		return req, nil
+	})
+	if err != nil {
+		r.fail(trigger, cfg, sub, msg, url, []byte(fmt.Sprintf("request exceed retries: %v: %v", sub.retry.MaxAttempts-1, err)))
+		return
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		r.fail(trigger, cfg, sub, msg, url, []byte(fmt.Sprintf("error reading function invocation response: %v", err)))
+		return
+	}
+	r.logger.Debug("got response from function invocation",
+		zap.String("function_url", url),
+		zap.String("trigger", trigger.ObjectMeta.Name),
+		zap.String("body", string(respBody)))
+
+	if resp.StatusCode != http.StatusOK {
+		r.fail(trigger, cfg, sub, msg, url, respBody)
+		return
+	}
+
+	if len(trigger.Spec.ResponseTopic) > 0 {
+		err = r.add(trigger.Spec.ResponseTopic, map[string]interface{}{bodyField: respBody})
+		if err != nil {
+			r.logger.Error("failed to publish message with function invocation response to topic",
+				zap.Error(err),
+				zap.String("topic", trigger.Spec.ResponseTopic),
+				zap.String("trigger", trigger.ObjectMeta.Name))
+		}
+	}
+
+	// Trigger acks entry only if a request was processed successfully
+	err = r.client.XAck(trigger.Spec.Topic, cfg.group, msg.ID).Err()
+	if err != nil {
+		r.logger.Error("failed to ack entry after successful function invocation from trigger",
+			zap.Error(err),
+			zap.String("function_url", url),
+			zap.String("trigger", trigger.ObjectMeta.Name))
+	}
+}
+
+// addToBatch adds an entry to the open batch of a trigger. Entries of
+// batches the function handled are acked, and those of batches it failed on
+// are left pending, like the entries handled one at a time.
+func (r RedisStreams) addToBatch(trigger *fv1.MessageQueueTrigger, cfg *subscriptionConfig, sub *subscription, msg redis.XMessage) {
+	// the other fields of entries with a body are only passed, as headers,
+	// to functions invoked with an entry at a time
+	body, _, err := entryBody(msg.Values)
+	if err != nil {
+		r.fail(trigger, cfg, sub, msg, "", []byte(fmt.Sprintf("error encoding entry: %v", err)))
+		return
+	}
+
+	sub.batcher.Add(messageQueue.BatchMessage{
+		Body: body,
+		Ack: func() {
+			err := r.client.XAck(trigger.Spec.Topic, cfg.group, msg.ID).Err()
+			if err != nil {
+				r.logger.Error("failed to ack entry of batch after successful function invocation from trigger",
+					zap.Error(err),
+					zap.String("entry", msg.ID),
+					zap.String("trigger", trigger.ObjectMeta.Name))
+			}
+		},
+	})
+}
+
+// fail adds the error of an entry the function failed on to the error
+// topic. The entry itself is added to the dead-letter topic of the retry
+// policy of the trigger and acked, if set, or else left pending.
+func (r RedisStreams) fail(trigger *fv1.MessageQueueTrigger, cfg *subscriptionConfig, sub *subscription, msg redis.XMessage, url string, body []byte) {
+	r.logger.Error("function invocation failed",
+		zap.String("function_url", url),
+		zap.String("trigger", trigger.ObjectMeta.Name),
+		zap.String("entry", msg.ID),
+		zap.String("error", string(body)))
+
+	if len(trigger.Spec.ErrorTopic) > 0 && len(body) > 0 {
+		err := r.add(trigger.Spec.ErrorTopic, map[string]interface{}{bodyField: body})
+		if err != nil {
+			r.logger.Error("failed to publish function invocation error to error topic",
+				zap.Error(err),
+				zap.String("topic", trigger.Spec.ErrorTopic),
+				zap.String("function_url", url),
+				zap.String("trigger", trigger.ObjectMeta.Name))
+		}
+	}
+
+	// the entry as is, with all its fields
+	deadLettered := sub.retry.DeadLetter(r.logger, nil, "", func(topic string, _ []byte, _ string) error {
+		return r.add(topic, msg.Values)
+	})
+	if !deadLettered {
+		return
+	}
+	err := r.client.XAck(trigger.Spec.Topic, cfg.group, msg.ID).Err()
+	if err != nil {
+		r.logger.Error("failed to ack entry after adding it to dead-letter topic",
+			zap.Error(err),
+			zap.String("entry", msg.ID),
+			zap.String("trigger", trigger.ObjectMeta.Name))
+	}
+}
+
+// giveUp adds an entry delivered the max deliveries to the dead-letter
+// stream, if set, and acks it.
+func (r RedisStreams) giveUp(trigger *fv1.MessageQueueTrigger, cfg *subscriptionConfig, msg redis.XMessage, deliveries int64) {
+	r.logger.Error("giving up on entry delivered max deliveries",
+		zap.String("trigger", trigger.ObjectMeta.Name),
+		zap.String("entry", msg.ID),
+		zap.Int64("deliveries", deliveries),
+		zap.String("dead_letter_stream", cfg.deadLetterStream))
+
+	if len(cfg.deadLetterStream) > 0 {
+		err := r.add(cfg.deadLetterStream, msg.Values)
+		if err != nil {
+			// left pending, to be given up on again
+			r.logger.Error("failed to add entry to dead-letter stream",
+				zap.Error(err),
+				zap.String("entry", msg.ID),
+				zap.String("trigger", trigger.ObjectMeta.Name))
+			return
+		}
+	}
+
+	err := r.client.XAck(trigger.Spec.Topic, cfg.group, msg.ID).Err()
+	if err != nil {
+		r.logger.Error("failed to ack entry given up on",
+			zap.Error(err),
+			zap.String("entry", msg.ID),
+			zap.String("trigger", trigger.ObjectMeta.Name))
+	}
+}
+
+func (r RedisStreams) add(stream string, values map[string]interface{}) error {
+	return r.client.XAdd(&redis.XAddArgs{
+		Stream: stream,
+		Values: values,
+	}).Err()
+}
+
+// parseConfig reads the consumer group settings of a trigger from its
+// metadata.
+func parseConfig(trigger *fv1.MessageQueueTrigger) (*subscriptionConfig, error) {
+	metadata := trigger.Spec.Metadata
+	cfg := &subscriptionConfig{
+		group:            metadata[MetadataConsumerGroup],
+		batchSize:        defaultBatchSize,
+		claimIdleTime:    defaultClaimIdleTime,
+		maxDeliveries:    defaultMaxDeliveries,
+		deadLetterStream: metadata[MetadataDeadLetterStream],
+	}
+
+	if len(cfg.group) == 0 {
+		cfg.group = fmt.Sprintf("fission-%v-%v", trigger.ObjectMeta.Namespace, trigger.ObjectMeta.Name)
+	}
+
+	if v, ok := metadata[MetadataBatchSize]; ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			return nil, errors.Errorf("batchSize must be a number greater than 0, got %q", v)
+		}
+		cfg.batchSize = n
+	}
+	if v, ok := metadata[MetadataClaimIdleTime]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < time.Second {
+			return nil, errors.Errorf("claimIdleTime must be a duration of at least 1s, got %q", v)
+		}
+		cfg.claimIdleTime = d
+	}
+	if v, ok := metadata[MetadataMaxDeliveries]; ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			return nil, errors.Errorf("maxDeliveries must be a number greater than 0, got %q", v)
+		}
+		cfg.maxDeliveries = n
+	}
+
+	return cfg, nil
+}
+
+// entryBody returns the body of an entry to invoke the function with, and
+// its other fields as headers.
+func entryBody(values map[string]interface{}) ([]byte, map[string]string, error) {
+	body, ok := values[bodyField]
+	if !ok {
+		b, err := json.Marshal(values)
+		return b, nil, err
+	}
+	headers := make(map[string]string)
+	for k, v := range values {
+		if k != bodyField {
+			headers[k] = fmt.Sprint(v)
+		}
+	}
+	return []byte(fmt.Sprint(body)), headers, nil
+}
+
+// entryTime returns when an entry was added, from the milliseconds of its
+// ID, e.g. 1614600000123-0.
+func entryTime(id string) (time.Time, bool) {
+	ms, err := strconv.ParseInt(strings.SplitN(id, "-", 2)[0], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)), true
+}
+
+// groupInfo returns the fields of a consumer group from the reply to XINFO
+// GROUPS, a list of groups each with a list of field names and values.
+func groupInfo(reply interface{}, name string) (map[string]interface{}, bool) {
+	groups, _ := reply.([]interface{})
+	for _, g := range groups {
+		fields, _ := g.([]interface{})
+		group := make(map[string]interface{}, len(fields)/2)
+		for i := 0; i+1 < len(fields); i += 2 {
+			if k, ok := fields[i].(string); ok {
+				group[k] = fields[i+1]
+			}
+		}
+		if group["name"] == name {
+			return group, true
+		}
+	}
+	return nil, false
+}
+
+// nextEntryID returns the least ID after an entry ID, e.g. 1614600000123-1
+// after 1614600000123-0.
+func nextEntryID(id string) (string, bool) {
+	parts := strings.SplitN(id, "-", 2)
+	if len(parts) != 2 {
+		return "", false
+	}
+	ms, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return "", false
+	}
+	seq, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return "", false
+	}
+	if seq == math.MaxUint64 {
+		return fmt.Sprintf("%v-0", ms+1), true
+	}
+	return fmt.Sprintf("%v-%v", ms, seq+1), true
+}
+
+// IsTopicValid returns whether topic is a stream key, non-empty and without
+// whitespace.
+func IsTopicValid(topic string) bool {
+	return len(topic) > 0 && !strings.ContainsAny(topic, " \t\r\n")
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redisstreams
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestParseConfig(t *testing.T) {
+	trigger := &fv1.MessageQueueTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "default"},
+		Spec:       fv1.MessageQueueTriggerSpec{Topic: "orders"},
+	}
+
+	cfg, err := parseConfig(trigger)
+	require.NoError(t, err)
+	require.Equal(t, &subscriptionConfig{
+		group:         "fission-default-orders",
+		batchSize:     defaultBatchSize,
+		claimIdleTime: defaultClaimIdleTime,
+		maxDeliveries: defaultMaxDeliveries,
+	}, cfg)
+
+	trigger.Spec.Metadata = map[string]string{
+		MetadataConsumerGroup:    "billing",
+		MetadataBatchSize:        "100",
+		MetadataClaimIdleTime:    "30s",
+		MetadataMaxDeliveries:    "3",
+		MetadataDeadLetterStream: "orders-dead",
+	}
+	cfg, err = parseConfig(trigger)
+	require.NoError(t, err)
+	require.Equal(t, &subscriptionConfig{
+		group:            "billing",
+		batchSize:        100,
+		claimIdleTime:    30 * time.Second,
+		maxDeliveries:    3,
+		deadLetterStream: "orders-dead",
+	}, cfg)
+
+	for _, metadata := range []map[string]string{
+		{MetadataBatchSize: "0"},
+		{MetadataClaimIdleTime: "500ms"},
+		{MetadataClaimIdleTime: "soon"},
+		{MetadataMaxDeliveries: "-1"},
+	} {
+		trigger.Spec.Metadata = metadata
+		_, err = parseConfig(trigger)
+		require.Error(t, err, "metadata %v", metadata)
+	}
+}
+
+func TestEntryBody(t *testing.T) {
+	body, headers, err := entryBody(map[string]interface{}{"body": `{"id": 42}`, "X-Source": "checkout"})
+	require.NoError(t, err)
+	require.Equal(t, `{"id": 42}`, string(body))
+	require.Equal(t, map[string]string{"X-Source": "checkout"}, headers)
+
+	body, headers, err = entryBody(map[string]interface{}{"id": "42", "source": "checkout"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id": "42", "source": "checkout"}`, string(body))
+	require.Empty(t, headers)
+}
+
+func TestEntryTime(t *testing.T) {
+	added, ok := entryTime("1614600000123-0")
+	require.True(t, ok)
+	require.Equal(t, time.Date(2021, 3, 1, 12, 0, 0, 123000000, time.UTC), added.UTC())
+
+	_, ok = entryTime("latest")
+	require.False(t, ok)
+}
+
+func TestGroupInfo(t *testing.T) {
+	reply := []interface{}{
+		[]interface{}{"name", "fission-default-audit", "consumers", int64(1), "pending", int64(0), "last-delivered-id", "1614600000123-0"},
+		[]interface{}{"name", "fission-default-orders", "consumers", int64(2), "pending", int64(3), "last-delivered-id", "1614600000123-4"},
+	}
+	group, ok := groupInfo(reply, "fission-default-orders")
+	require.True(t, ok)
+	require.Equal(t, int64(3), group["pending"])
+	require.Equal(t, "1614600000123-4", group["last-delivered-id"])
+
+	_, ok = groupInfo(reply, "billing")
+	require.False(t, ok)
+}
+
+func TestNextEntryID(t *testing.T) {
+	next, ok := nextEntryID("1614600000123-4")
+	require.True(t, ok)
+	require.Equal(t, "1614600000123-5", next)
+
+	next, ok = nextEntryID("1614600000123-18446744073709551615")
+	require.True(t, ok)
+	require.Equal(t, "1614600000124-0", next)
+
+	_, ok = nextEntryID("latest")
+	require.False(t, ok)
+}
+
+func TestIsTopicValid(t *testing.T) {
+	require.True(t, IsTopicValid("orders"))
+	require.True(t, IsTopicValid("shop:orders:{eu}"))
+	require.False(t, IsTopicValid(""))
+	require.False(t, IsTopicValid("new orders"))
+}
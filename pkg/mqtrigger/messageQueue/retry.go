@@ -0,0 +1,165 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messageQueue
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	// HeaderAttempt is the header of the number of the attempt to invoke
+	// the function with a message, starting from 1.
+	HeaderAttempt = "X-Fission-MQTrigger-Attempt"
+
+	defaultMaxBackoff = time.Minute
+)
+
+// RetryPolicy is how the function of a trigger is retried on a message it
+// fails on, the same for all message queues: up to the max attempts, with
+// an exponential backoff between attempts, after which the message is
+// published to the dead-letter topic.
+type RetryPolicy struct {
+	MaxAttempts     int
+	Backoff         time.Duration
+	MaxBackoff      time.Duration
+	Jitter          bool
+	DeadLetterTopic string
+}
+
+// NewRetryPolicy returns the RetryPolicy of a trigger.
+func NewRetryPolicy(trigger *fv1.MessageQueueTrigger) (*RetryPolicy, error) {
+	policy := &RetryPolicy{
+		MaxAttempts: trigger.Spec.MaxRetries + 1,
+		MaxBackoff:  defaultMaxBackoff,
+	}
+	spec := trigger.Spec.RetryPolicy
+	if spec == nil {
+		return policy, nil
+	}
+
+	if spec.MaxAttempts > 0 {
+		policy.MaxAttempts = spec.MaxAttempts
+	}
+	if len(spec.Backoff) > 0 {
+		d, err := time.ParseDuration(spec.Backoff)
+		if err != nil || d < 0 {
+			return nil, errors.Errorf("not a valid backoff: %q", spec.Backoff)
+		}
+		policy.Backoff = d
+	}
+	if len(spec.MaxBackoff) > 0 {
+		d, err := time.ParseDuration(spec.MaxBackoff)
+		if err != nil || d < 0 {
+			return nil, errors.Errorf("not a valid max backoff: %q", spec.MaxBackoff)
+		}
+		if d > 0 {
+			policy.MaxBackoff = d
+		}
+	}
+	policy.Jitter = spec.Jitter
+	policy.DeadLetterTopic = spec.DeadLetterTopic
+	return policy, nil
+}
+
+// Delay returns how long to wait before a retry, numbered from 1: the
+// backoff doubled for each retry before it, up to the max backoff, or a
+// random duration up to it with jitter.
+func (p *RetryPolicy) Delay(retry int) time.Duration {
+	if p.Backoff <= 0 || retry < 1 {
+		return 0
+	}
+	delay := p.Backoff
+	for i := 1; i < retry && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if p.Jitter {
+		delay = time.Duration(rand.Int63n(int64(delay) + 1))
+	}
+	return delay
+}
+
+// Invoke sends the requests made by newRequest to invoke the function of a
+// trigger, up to the max attempts until the function responds with 200 OK,
+// waiting the backoff between attempts. It returns the last response, for
+// the caller to close, or the last error if there was none.
+func (p *RetryPolicy) Invoke(logger *zap.Logger, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	var resp *http.Response
+	var err error
+	for attempt := 1; attempt <= p.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(p.Delay(attempt - 1))
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+			resp = nil
+		}
+
+		var req *http.Request
+		req, err = newRequest()
+		if err != nil {
+			logger.Error("failed to create HTTP request to invoke function", zap.Error(err))
+			break
+		}
+		req.Header.Set(HeaderAttempt, strconv.Itoa(attempt))
+
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			logger.Error("sending function invocation request failed",
+				zap.Error(err),
+				zap.String("function_url", req.URL.String()),
+				zap.Int("attempt", attempt))
+			continue
+		}
+		if resp.StatusCode == http.StatusOK {
+			// Success, quit retrying
+			break
+		}
+	}
+
+	if resp == nil && err == nil {
+		err = errors.New("no response")
+	}
+	return resp, err
+}
+
+// DeadLetter publishes a message the function failed on to the dead-letter
+// topic with publish, returning whether it was, to be acked then.
+func (p *RetryPolicy) DeadLetter(logger *zap.Logger, body []byte, contentType string, publish PublishFunc) bool {
+	if len(p.DeadLetterTopic) == 0 {
+		return false
+	}
+	err := publish(p.DeadLetterTopic, body, contentType)
+	if err != nil {
+		logger.Error("failed to publish message to dead-letter topic",
+			zap.Error(err),
+			zap.String("topic", p.DeadLetterTopic))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messageQueue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestNewRetryPolicy(t *testing.T) {
+	// triggers without a retry policy are retried max retries times, right
+	// away
+	trigger := &fv1.MessageQueueTrigger{Spec: fv1.MessageQueueTriggerSpec{MaxRetries: 2}}
+	policy, err := NewRetryPolicy(trigger)
+	require.NoError(t, err)
+	require.Equal(t, &RetryPolicy{MaxAttempts: 3, MaxBackoff: defaultMaxBackoff}, policy)
+
+	trigger.Spec.RetryPolicy = &fv1.MessageQueueTriggerRetryPolicy{
+		MaxAttempts:     5,
+		Backoff:         "100ms",
+		MaxBackoff:      "1s",
+		Jitter:          true,
+		DeadLetterTopic: "orders-dead",
+	}
+	policy, err = NewRetryPolicy(trigger)
+	require.NoError(t, err)
+	require.Equal(t, &RetryPolicy{
+		MaxAttempts:     5,
+		Backoff:         100 * time.Millisecond,
+		MaxBackoff:      time.Second,
+		Jitter:          true,
+		DeadLetterTopic: "orders-dead",
+	}, policy)
+
+	trigger.Spec.RetryPolicy.Backoff = "soon"
+	_, err = NewRetryPolicy(trigger)
+	require.Error(t, err)
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := &RetryPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	require.Equal(t, time.Duration(0), policy.Delay(0))
+	require.Equal(t, 100*time.Millisecond, policy.Delay(1))
+	require.Equal(t, 200*time.Millisecond, policy.Delay(2))
+	require.Equal(t, 800*time.Millisecond, policy.Delay(4))
+	require.Equal(t, time.Second, policy.Delay(5))
+	require.Equal(t, time.Second, policy.Delay(100))
+
+	policy.Jitter = true
+	for i := 0; i < 10; i++ {
+		require.True(t, policy.Delay(3) <= 400*time.Millisecond)
+	}
+}
+
+func TestRetryPolicyInvoke(t *testing.T) {
+	var attempts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts = append(attempts, r.Header.Get(HeaderAttempt))
+		if len(attempts) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	newRequest := func() (*http.Request, error) {
+		return http.NewRequest("POST", server.URL, nil)
+	}
+
+	policy := &RetryPolicy{MaxAttempts: 5, Backoff: time.Millisecond, MaxBackoff: time.Second}
+	resp, err := policy.Invoke(zap.NewNop(), newRequest)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, []string{"1", "2", "3"}, attempts)
+
+	// the last response is returned once out of attempts
+	attempts = nil
+	policy.MaxAttempts = 2
+	resp, err = policy.Invoke(zap.NewNop(), newRequest)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	require.Equal(t, []string{"1", "2"}, attempts)
+}
+
+func TestRetryPolicyDeadLetter(t *testing.T) {
+	var published []string
+	publish := func(topic string, body []byte, contentType string) error {
+		published = append(published, topic+": "+string(body))
+		return nil
+	}
+
+	policy := &RetryPolicy{}
+	require.False(t, policy.DeadLetter(zap.NewNop(), []byte("order"), "", publish))
+	require.Empty(t, published)
+
+	policy.DeadLetterTopic = "orders-dead"
+	require.True(t, policy.DeadLetter(zap.NewNop(), []byte("order"), "", publish))
+	require.Equal(t, []string{"orders-dead: order"}, published)
+}
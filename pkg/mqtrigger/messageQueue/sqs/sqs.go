@@ -0,0 +1,788 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/mqtrigger/factory"
+	"github.com/fission/fission/pkg/mqtrigger/messageQueue"
+	"github.com/fission/fission/pkg/mqtrigger/validator"
+	"github.com/fission/fission/pkg/utils"
+)
+
+func init() {
+	factory.Register(fv1.MessageQueueTypeSQS, &Factory{})
+	validator.Register(fv1.MessageQueueTypeSQS, IsTopicValid)
+}
+
+// Settings of a trigger, in the metadata of the trigger. The topic of a
+// trigger is the queue it consumes, by name or URL; the response and error
+// topics are queues too.
+const (
+	// MetadataVisibilityTimeout is how many seconds a message is hidden
+	// from other consumers once received. It's extended for as long as
+	// the function runs, so it only bounds how soon a message is received
+	// again after the trigger is gone.
+	MetadataVisibilityTimeout = "visibilityTimeout"
+	// MetadataMaxMessages is how many messages are received, and invoked
+	// with, at a time, from 1 to 10.
+	MetadataMaxMessages = "maxMessages"
+	// MetadataWaitTimeSeconds is how long receiving waits for messages to
+	// arrive, up to 20 seconds.
+	MetadataWaitTimeSeconds = "waitTimeSeconds"
+	// MetadataDeadLetterQueue is the queue, by name or URL, messages the
+	// function fails on MetadataMaxReceiveCount times are moved to by SQS.
+	// The redrive policy of the queue is left as is if empty.
+	MetadataDeadLetterQueue = "deadLetterQueue"
+	// MetadataMaxReceiveCount is how many times a message is received
+	// before it's moved to the dead-letter queue.
+	MetadataMaxReceiveCount = "maxReceiveCount"
+	// MetadataSNSTopicArn is the ARN of an SNS topic to subscribe the
+	// queue to. The subscription is created if it doesn't exist, and
+	// removed once the trigger is deleted.
+	MetadataSNSTopicArn = "snsTopicArn"
+
+	defaultVisibilityTimeout = 30
+	defaultMaxMessages       = 10
+	defaultWaitTimeSeconds   = 20
+	defaultMaxReceiveCount   = 5
+
+	// retryInterval is how long to wait to receive messages again after
+	// an error.
+	retryInterval = 5 * time.Second
+)
+
+var queueNameRegex = regexp.MustCompile(`^([a-zA-Z0-9_-]{1,80}|[a-zA-Z0-9_-]{1,75}\.fifo)$`)
+
+type (
+	SQS struct {
+		logger    *zap.Logger
+		sqsClient sqsiface.SQSAPI
+		snsClient snsiface.SNSAPI
+		routerUrl string
+	}
+
+	// subscriptionConfig is the receive, redrive and SNS settings of a
+	// trigger.
+	subscriptionConfig struct {
+		visibilityTimeout int64
+		maxMessages       int64
+		waitTimeSeconds   int64
+		deadLetterQueue   string
+		maxReceiveCount   int
+		snsTopicArn       string
+	}
+
+	// subscription is the poller of the queue of a trigger, along with the
+	// URLs of its queues, resolved once.
+	subscription struct {
+		queueUrl         string
+		responseQueueUrl string
+		errorQueueUrl    string
+		// deadLetterQueueUrl is the queue of the dead-letter topic of the
+		// retry policy of the trigger, if any.
+		deadLetterQueueUrl string
+		retry              *messageQueue.RetryPolicy
+		// snsSubscriptionArn is the subscription of the queue to the SNS
+		// topic of the trigger, if any.
+		snsSubscriptionArn string
+		// batcher is set if the function of the trigger is invoked with
+		// batches of messages.
+		batcher *messageQueue.Batcher
+		limiter messageQueue.Limiter
+
+		cancel context.CancelFunc
+		done   chan struct{}
+	}
+
+	Factory struct{}
+)
+
+func (factory *Factory) Create(logger *zap.Logger, mqCfg messageQueue.Config, routerUrl string) (messageQueue.MessageQueue, error) {
+	return New(logger, mqCfg, routerUrl)
+}
+
+// New returns clients of SQS and SNS in the region of the AWS_REGION
+// environment variable, at the URL of the config if set, for SQS
+// compatible services or VPC endpoints. The clients use the
+// "access-key-id", "secret-access-key" and "session-token" secrets if set,
+// or else the default credential chain of the SDK, which takes the role of
+// the service account of the pod with IAM roles for service accounts.
+func New(logger *zap.Logger, mqCfg messageQueue.Config, routerUrl string) (messageQueue.MessageQueue, error) {
+	if len(routerUrl) == 0 {
+		return nil, errors.New("the router URL is empty")
+	}
+
+	// the HTTP timeout is to outlast long polling
+	config := &aws.Config{
+		HTTPClient: &http.Client{Timeout: time.Minute},
+	}
+	if len(mqCfg.Url) > 0 {
+		config.Endpoint = aws.String(strings.TrimSuffix(mqCfg.Url, "/"))
+	}
+	accessKeyID := strings.TrimSpace(string(mqCfg.Secrets["access-key-id"]))
+	secretAccessKey := strings.TrimSpace(string(mqCfg.Secrets["secret-access-key"]))
+	if len(accessKeyID) > 0 || len(secretAccessKey) > 0 {
+		if len(accessKeyID) == 0 || len(secretAccessKey) == 0 {
+			return nil, errors.New("both access-key-id and secret-access-key are required in the secrets of the message queue")
+		}
+		config.Credentials = credentials.NewStaticCredentials(accessKeyID, secretAccessKey,
+			strings.TrimSpace(string(mqCfg.Secrets["session-token"])))
+	}
+
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating aws session")
+	}
+	if len(aws.StringValue(sess.Config.Region)) == 0 {
+		return nil, errors.New("the AWS region is not set, set it with the AWS_REGION environment variable")
+	}
+
+	return SQS{
+		logger:    logger.Named("sqs"),
+		sqsClient: sqs.New(sess),
+		snsClient: sns.New(sess),
+		routerUrl: routerUrl,
+	}, nil
+}
+
+func (s SQS) Subscribe(trigger *fv1.MessageQueueTrigger) (messageQueue.Subscription, error) {
+	if !IsTopicValid(trigger.Spec.Topic) {
+		return nil, fmt.Errorf("not a valid topic: %q", trigger.Spec.Topic)
+	}
+	cfg, err := parseConfig(trigger)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &subscription{
+		limiter: messageQueue.NewLimiter(trigger),
+		done:    make(chan struct{}),
+	}
+	sub.retry, err = messageQueue.NewRetryPolicy(trigger)
+	if err != nil {
+		return nil, err
+	}
+	sub.queueUrl, err = s.getQueueUrl(trigger.Spec.Topic)
+	if err != nil {
+		return nil, err
+	}
+	if len(trigger.Spec.ResponseTopic) > 0 {
+		sub.responseQueueUrl, err = s.getQueueUrl(trigger.Spec.ResponseTopic)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(trigger.Spec.ErrorTopic) > 0 {
+		sub.errorQueueUrl, err = s.getQueueUrl(trigger.Spec.ErrorTopic)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(sub.retry.DeadLetterTopic) > 0 {
+		sub.deadLetterQueueUrl, err = s.getQueueUrl(sub.retry.DeadLetterTopic)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cfg.deadLetterQueue) > 0 {
+		err = s.setRedrivePolicy(sub.queueUrl, cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(cfg.snsTopicArn) > 0 {
+		sub.snsSubscriptionArn, err = s.subscribeTopic(sub.queueUrl, cfg.snsTopicArn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if messageQueue.IsBatched(trigger) {
+		sub.batcher, err = messageQueue.NewBatcher(s.logger, s.routerUrl, trigger, func(topic string, body []byte, contentType string) error {
+			queueUrl := sub.errorQueueUrl
+			switch topic {
+			case trigger.Spec.ResponseTopic:
+				queueUrl = sub.responseQueueUrl
+			case sub.retry.DeadLetterTopic:
+				queueUrl = sub.deadLetterQueueUrl
+			}
+			return s.send(queueUrl, body, contentType)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var ctx context.Context
+	ctx, sub.cancel = context.WithCancel(context.Background())
+	go s.poll(ctx, trigger, cfg, sub)
+
+	s.logger.Info("subscribed to queue",
+		zap.String("queue", sub.queueUrl),
+		zap.Int64("visibility_timeout", cfg.visibilityTimeout),
+		zap.Int64("max_messages", cfg.maxMessages),
+		zap.String("sns_topic", cfg.snsTopicArn),
+		zap.String("trigger", trigger.ObjectMeta.Name))
+	return sub, nil
+}
+
+// Unsubscribe stops receiving messages for a trigger, once the messages
+// received are handled. The SNS subscription of the queue is kept, for
+// messages published while the trigger manager restarts to wait in the
+// queue; Remove removes it.
+func (s SQS) Unsubscribe(triggerSub messageQueue.Subscription) error {
+	sub := triggerSub.(*subscription)
+	sub.cancel()
+	<-sub.done
+	if sub.batcher != nil {
+		sub.batcher.Close()
+	}
+	return nil
+}
+
+// Remove removes the SNS subscription of the queue of a deleted trigger.
+func (s SQS) Remove(triggerSub messageQueue.Subscription) error {
+	sub := triggerSub.(*subscription)
+	if len(sub.snsSubscriptionArn) == 0 {
+		return nil
+	}
+	_, err := s.snsClient.Unsubscribe(&sns.UnsubscribeInput{
+		SubscriptionArn: aws.String(sub.snsSubscriptionArn),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error removing sns subscription %q", sub.snsSubscriptionArn)
+	}
+	return nil
+}
+
+// Lag returns the approximate number of messages visible in the queue of a
+// trigger, waiting to be received.
+func (s SQS) Lag(trigger *fv1.MessageQueueTrigger) (int64, error) {
+	queueUrl, err := s.getQueueUrl(trigger.Spec.Topic)
+	if err != nil {
+		return 0, err
+	}
+	count, err := s.getQueueAttribute(queueUrl, sqs.QueueAttributeNameApproximateNumberOfMessages)
+	if err != nil {
+		return 0, err
+	}
+	lag, err := strconv.ParseInt(count, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error parsing number of messages %q of queue %q", count, queueUrl)
+	}
+	return lag, nil
+}
+
+// poll receives messages of the queue of a trigger with long polling,
+// until ctx is done, handling each batch before receiving the next.
+func (s SQS) poll(ctx context.Context, trigger *fv1.MessageQueueTrigger, cfg *subscriptionConfig, sub *subscription) {
+	defer close(sub.done)
+	for {
+		out, err := s.sqsClient.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:              aws.String(sub.queueUrl),
+			MaxNumberOfMessages:   aws.Int64(cfg.maxMessages),
+			WaitTimeSeconds:       aws.Int64(cfg.waitTimeSeconds),
+			VisibilityTimeout:     aws.Int64(cfg.visibilityTimeout),
+			AttributeNames:        aws.StringSlice([]string{sqs.MessageSystemAttributeNameSentTimestamp}),
+			MessageAttributeNames: aws.StringSlice([]string{"All"}),
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			s.logger.Error("failed to receive messages from queue",
+				zap.Error(err),
+				zap.String("queue", sub.queueUrl),
+				zap.String("trigger", trigger.ObjectMeta.Name))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryInterval):
+			}
+			continue
+		}
+
+		if sub.batcher != nil {
+			for _, msg := range out.Messages {
+				s.addToBatch(trigger, cfg, sub, msg)
+			}
+			continue
+		}
+
+		var wg sync.WaitGroup
+		for _, msg := range out.Messages {
+			wg.Add(1)
+			sub.limiter.Acquire()
+			go func(msg *sqs.Message) {
+				defer wg.Done()
+				defer sub.limiter.Release()
+				s.msgHandler(trigger, cfg, sub, msg)
+			}(msg)
+		}
+		wg.Wait()
+	}
+}
+
+// msgHandler invokes the function of a trigger with a message, keeping the
+// message hidden while the function runs, and deletes the message once the
+// function succeeds. Messages the function fails on are published to the
+// error topic and left in the queue, to be received again once their
+// visibility timeout passes, and moved to the dead-letter queue by SQS
+// after the max receive count of the redrive policy of the queue.
+func (s SQS) msgHandler(trigger *fv1.MessageQueueTrigger, cfg *subscriptionConfig, sub *subscription, msg *sqs.Message) {
+	// Support other function ref types
+	if trigger.Spec.FunctionReference.Type != fv1.FunctionReferenceTypeFunctionName {
+		s.logger.Fatal("unsupported function reference type for trigger",
+			zap.Any("function_reference_type", trigger.Spec.FunctionReference.Type),
+			zap.String("trigger", trigger.ObjectMeta.Name))
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go s.extendVisibility(trigger, cfg, sub, msg, stop)
+
+	url := s.routerUrl + "/" + strings.TrimPrefix(utils.UrlForFunction(trigger.Spec.FunctionReference.Name, trigger.ObjectMeta.Namespace), "/")
+	s.logger.Debug("making HTTP request", zap.String("url", url))
+
+	fissionHeaders := map[string]string{
+		"X-Fission-MQTrigger-Topic":      trigger.Spec.Topic,
+		"X-Fission-MQTrigger-RespTopic":  trigger.Spec.ResponseTopic,
+		"X-Fission-MQTrigger-ErrorTopic": trigger.Spec.ErrorTopic,
+		"Content-Type":                   trigger.Spec.ContentType,
+		fv1.HeaderTriggerType:            fv1.TriggerTypeMessageQueue,
+		fv1.HeaderTriggerName:            trigger.ObjectMeta.Name,
+		fv1.HeaderCloudEventsMode:        string(trigger.Spec.CloudEvents),
+		fv1.HeaderPriority:               string(fv1.InvocationPriorityBatch),
+	}
+	if eventTime, ok := sentTime(msg.Attributes); ok {
+		fissionHeaders[fv1.HeaderEventTime] = eventTime.UTC().Format(time.RFC3339Nano)
+	}
+
+	body := []byte(aws.StringValue(msg.Body))
+	resp, err := sub.retry.Invoke(s.logger.With(zap.String("trigger", trigger.ObjectMeta.Name)), func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		// the attributes of the message, then ours
+		for k, v := range messageHeaders(msg.MessageAttributes) {
+			req.Header.Set(k, v)
+		}
+		for k, v := range fissionHeaders {
+			req.Header.Set(k, v)
+		}
+		return req, nil
+	})
+	if err != nil {
+		s.fail(trigger, sub, msg, url, []byte(fmt.Sprintf("request exceed retries: %v: %v", sub.retry.MaxAttempts-1, err)))
+		return
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		s.fail(trigger, sub, msg, url, []byte(fmt.Sprintf("error reading function invocation response: %v", err)))
+		return
+	}
+	s.logger.Debug("got response from function invocation",
+		zap.String("function_url", url),
+		zap.String("trigger", trigger.ObjectMeta.Name),
+		zap.String("body", string(respBody)))
+
+	if resp.StatusCode != http.StatusOK {
+		s.fail(trigger, sub, msg, url, respBody)
+		return
+	}
+
+	if len(sub.responseQueueUrl) > 0 {
+		err = s.send(sub.responseQueueUrl, respBody, resp.Header.Get("Content-Type"))
+		if err != nil {
+			s.logger.Error("failed to publish message with function invocation response to topic",
+				zap.Error(err),
+				zap.String("topic", trigger.Spec.ResponseTopic),
+				zap.String("trigger", trigger.ObjectMeta.Name))
+		}
+	}
+
+	// Trigger deletes message only if a request was processed successfully
+	_, err = s.sqsClient.DeleteMessage(&sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(sub.queueUrl),
+		ReceiptHandle: msg.ReceiptHandle,
+	})
+	if err != nil {
+		s.logger.Error("failed to delete message after successful function invocation from trigger",
+			zap.Error(err),
+			zap.String("function_url", url),
+			zap.String("trigger", trigger.ObjectMeta.Name))
+	}
+}
+
+// addToBatch adds a message to the open batch of a trigger, keeping the
+// message hidden until the batch is handled. Messages of batches the
+// function handled are deleted, and those of batches it failed on are left
+// in the queue, like the messages handled one at a time.
+func (s SQS) addToBatch(trigger *fv1.MessageQueueTrigger, cfg *subscriptionConfig, sub *subscription, msg *sqs.Message) {
+	stop := make(chan struct{})
+	go s.extendVisibility(trigger, cfg, sub, msg, stop)
+
+	sub.batcher.Add(messageQueue.BatchMessage{
+		Body: []byte(aws.StringValue(msg.Body)),
+		Ack: func() {
+			close(stop)
+			_, err := s.sqsClient.DeleteMessage(&sqs.DeleteMessageInput{
+				QueueUrl:      aws.String(sub.queueUrl),
+				ReceiptHandle: msg.ReceiptHandle,
+			})
+			if err != nil {
+				s.logger.Error("failed to delete message of batch after successful function invocation from trigger",
+					zap.Error(err),
+					zap.String("trigger", trigger.ObjectMeta.Name))
+			}
+		},
+		Nack: func() { close(stop) },
+	})
+}
+
+// extendVisibility extends the visibility timeout of a message every half
+// timeout until stop is closed, so the message isn't received again while
+// the function runs.
+func (s SQS) extendVisibility(trigger *fv1.MessageQueueTrigger, cfg *subscriptionConfig, sub *subscription, msg *sqs.Message, stop chan struct{}) {
+	ticker := time.NewTicker(time.Duration(cfg.visibilityTimeout) * time.Second / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		_, err := s.sqsClient.ChangeMessageVisibility(&sqs.ChangeMessageVisibilityInput{
+			QueueUrl:          aws.String(sub.queueUrl),
+			ReceiptHandle:     msg.ReceiptHandle,
+			VisibilityTimeout: aws.Int64(cfg.visibilityTimeout),
+		})
+		if err != nil {
+			s.logger.Warn("failed to extend visibility timeout of message",
+				zap.Error(err),
+				zap.String("message_id", aws.StringValue(msg.MessageId)),
+				zap.String("trigger", trigger.ObjectMeta.Name))
+		}
+	}
+}
+
+// fail publishes the error of a message the function failed on to the
+// error topic. The message itself is moved to the dead-letter topic of the
+// trigger, if any, or else left in the queue.
+func (s SQS) fail(trigger *fv1.MessageQueueTrigger, sub *subscription, msg *sqs.Message, url string, body []byte) {
+	s.logger.Error("function invocation failed",
+		zap.String("function_url", url),
+		zap.String("trigger", trigger.ObjectMeta.Name),
+		zap.String("error", string(body)))
+
+	if len(sub.errorQueueUrl) > 0 && len(body) > 0 {
+		err := s.send(sub.errorQueueUrl, body, "")
+		if err != nil {
+			s.logger.Error("failed to publish function invocation error to error topic",
+				zap.Error(err),
+				zap.String("topic", trigger.Spec.ErrorTopic),
+				zap.String("function_url", url),
+				zap.String("trigger", trigger.ObjectMeta.Name))
+		}
+	}
+
+	deadLettered := sub.retry.DeadLetter(s.logger, []byte(aws.StringValue(msg.Body)), trigger.Spec.ContentType, func(topic string, body []byte, contentType string) error {
+		return s.send(sub.deadLetterQueueUrl, body, contentType)
+	})
+	if !deadLettered {
+		return
+	}
+	_, err := s.sqsClient.DeleteMessage(&sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(sub.queueUrl),
+		ReceiptHandle: msg.ReceiptHandle,
+	})
+	if err != nil {
+		s.logger.Error("failed to delete message after publishing it to dead-letter topic",
+			zap.Error(err),
+			zap.String("trigger", trigger.ObjectMeta.Name))
+	}
+}
+
+// send sends body to the queue at queueUrl, with its content type as an
+// attribute if set.
+func (s SQS) send(queueUrl string, body []byte, contentType string) error {
+	input := &sqs.SendMessageInput{
+		QueueUrl:    aws.String(queueUrl),
+		MessageBody: aws.String(string(body)),
+	}
+	if len(contentType) > 0 {
+		input.MessageAttributes = map[string]*sqs.MessageAttributeValue{
+			"Content-Type": {
+				DataType:    aws.String("String"),
+				StringValue: aws.String(contentType),
+			},
+		}
+	}
+	_, err := s.sqsClient.SendMessage(input)
+	return err
+}
+
+// getQueueUrl returns the URL of a queue by name, or the queue URL itself.
+func (s SQS) getQueueUrl(queue string) (string, error) {
+	if isQueueUrl(queue) {
+		return queue, nil
+	}
+	out, err := s.sqsClient.GetQueueUrl(&sqs.GetQueueUrlInput{QueueName: aws.String(queue)})
+	if err != nil {
+		return "", errors.Wrapf(err, "error getting url of queue %q", queue)
+	}
+	return aws.StringValue(out.QueueUrl), nil
+}
+
+// getQueueAttribute returns an attribute of the queue at queueUrl, empty if
+// it isn't set.
+func (s SQS) getQueueAttribute(queueUrl string, name string) (string, error) {
+	out, err := s.sqsClient.GetQueueAttributes(&sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(queueUrl),
+		AttributeNames: aws.StringSlice([]string{name}),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "error getting attribute %v of queue %q", name, queueUrl)
+	}
+	return aws.StringValue(out.Attributes[name]), nil
+}
+
+// setRedrivePolicy sets the redrive policy of the queue at queueUrl to move
+// messages received the max receive count of a trigger to its dead-letter
+// queue.
+func (s SQS) setRedrivePolicy(queueUrl string, cfg *subscriptionConfig) error {
+	dlqUrl, err := s.getQueueUrl(cfg.deadLetterQueue)
+	if err != nil {
+		return err
+	}
+	dlqArn, err := s.getQueueAttribute(dlqUrl, sqs.QueueAttributeNameQueueArn)
+	if err != nil {
+		return err
+	}
+	policy, err := redrivePolicy(dlqArn, cfg.maxReceiveCount)
+	if err != nil {
+		return err
+	}
+	_, err = s.sqsClient.SetQueueAttributes(&sqs.SetQueueAttributesInput{
+		QueueUrl:   aws.String(queueUrl),
+		Attributes: aws.StringMap(map[string]string{sqs.QueueAttributeNameRedrivePolicy: policy}),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error setting redrive policy of queue %q", queueUrl)
+	}
+	return nil
+}
+
+// subscribeTopic subscribes the queue at queueUrl to an SNS topic with raw
+// message delivery, for functions to get messages as published, and
+// returns the subscription. SNS returns the existing subscription if the
+// queue is subscribed already. The topic is allowed to send messages to
+// the queue if the queue has no access policy; a policy of its own is left
+// as is, for it to allow the topic.
+func (s SQS) subscribeTopic(queueUrl string, topicArn string) (string, error) {
+	queueArn, err := s.getQueueAttribute(queueUrl, sqs.QueueAttributeNameQueueArn)
+	if err != nil {
+		return "", err
+	}
+	policy, err := s.getQueueAttribute(queueUrl, sqs.QueueAttributeNamePolicy)
+	if err != nil {
+		return "", err
+	}
+	if len(policy) == 0 {
+		policy, err = queuePolicy(queueArn, topicArn)
+		if err != nil {
+			return "", err
+		}
+		_, err = s.sqsClient.SetQueueAttributes(&sqs.SetQueueAttributesInput{
+			QueueUrl:   aws.String(queueUrl),
+			Attributes: aws.StringMap(map[string]string{sqs.QueueAttributeNamePolicy: policy}),
+		})
+		if err != nil {
+			return "", errors.Wrapf(err, "error allowing sns topic %q to send to queue %q", topicArn, queueUrl)
+		}
+	} else if !strings.Contains(policy, topicArn) {
+		s.logger.Warn("access policy of queue doesn't mention sns topic, messages are delivered only if it allows the topic",
+			zap.String("queue", queueUrl),
+			zap.String("sns_topic", topicArn))
+	}
+
+	out, err := s.snsClient.Subscribe(&sns.SubscribeInput{
+		TopicArn:              aws.String(topicArn),
+		Protocol:              aws.String("sqs"),
+		Endpoint:              aws.String(queueArn),
+		Attributes:            aws.StringMap(map[string]string{"RawMessageDelivery": "true"}),
+		ReturnSubscriptionArn: aws.Bool(true),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "error subscribing queue %q to sns topic %q", queueUrl, topicArn)
+	}
+	return aws.StringValue(out.SubscriptionArn), nil
+}
+
+// parseConfig reads the receive, redrive and SNS settings of a trigger from
+// its metadata.
+func parseConfig(trigger *fv1.MessageQueueTrigger) (*subscriptionConfig, error) {
+	metadata := trigger.Spec.Metadata
+	cfg := &subscriptionConfig{
+		visibilityTimeout: defaultVisibilityTimeout,
+		maxMessages:       defaultMaxMessages,
+		waitTimeSeconds:   defaultWaitTimeSeconds,
+		deadLetterQueue:   metadata[MetadataDeadLetterQueue],
+		maxReceiveCount:   defaultMaxReceiveCount,
+		snsTopicArn:       metadata[MetadataSNSTopicArn],
+	}
+
+	parse := func(key string, min int64, max int64, value *int64) error {
+		v, ok := metadata[key]
+		if !ok {
+			return nil
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < min || n > max {
+			return errors.Errorf("%v must be a number from %v to %v, got %q", key, min, max, v)
+		}
+		*value = n
+		return nil
+	}
+	// the visibility timeout is extended every half timeout, so it's at
+	// least 2 seconds
+	err := parse(MetadataVisibilityTimeout, 2, 43200, &cfg.visibilityTimeout)
+	if err != nil {
+		return nil, err
+	}
+	err = parse(MetadataMaxMessages, 1, 10, &cfg.maxMessages)
+	if err != nil {
+		return nil, err
+	}
+	err = parse(MetadataWaitTimeSeconds, 0, 20, &cfg.waitTimeSeconds)
+	if err != nil {
+		return nil, err
+	}
+	maxReceiveCount := int64(cfg.maxReceiveCount)
+	err = parse(MetadataMaxReceiveCount, 1, 1000, &maxReceiveCount)
+	if err != nil {
+		return nil, err
+	}
+	cfg.maxReceiveCount = int(maxReceiveCount)
+
+	if len(cfg.deadLetterQueue) > 0 && !IsTopicValid(cfg.deadLetterQueue) {
+		return nil, errors.Errorf("not a valid dead-letter queue: %q", cfg.deadLetterQueue)
+	}
+	if len(cfg.snsTopicArn) > 0 && !strings.HasPrefix(cfg.snsTopicArn, "arn:") {
+		return nil, errors.Errorf("not a valid sns topic arn: %q", cfg.snsTopicArn)
+	}
+
+	return cfg, nil
+}
+
+// redrivePolicy returns the redrive policy of a queue moving messages
+// received maxReceiveCount times to the queue deadLetterTargetArn.
+func redrivePolicy(deadLetterTargetArn string, maxReceiveCount int) (string, error) {
+	policy, err := json.Marshal(struct {
+		DeadLetterTargetArn string `json:"deadLetterTargetArn"`
+		MaxReceiveCount     int    `json:"maxReceiveCount,string"`
+	}{deadLetterTargetArn, maxReceiveCount})
+	if err != nil {
+		return "", errors.Wrap(err, "error encoding redrive policy")
+	}
+	return string(policy), nil
+}
+
+// queuePolicy returns an access policy of the queue queueArn allowing the
+// SNS topic topicArn to send messages to it.
+func queuePolicy(queueArn string, topicArn string) (string, error) {
+	policy, err := json.Marshal(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{{
+			"Effect":    "Allow",
+			"Principal": map[string]string{"Service": "sns.amazonaws.com"},
+			"Action":    "sqs:SendMessage",
+			"Resource":  queueArn,
+			"Condition": map[string]interface{}{
+				"ArnEquals": map[string]string{"aws:SourceArn": topicArn},
+			},
+		}},
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "error encoding queue policy")
+	}
+	return string(policy), nil
+}
+
+// messageHeaders returns the string and number attributes of a message, to
+// set on requests as headers. Binary attributes are left out.
+func messageHeaders(attributes map[string]*sqs.MessageAttributeValue) map[string]string {
+	headers := make(map[string]string)
+	for k, v := range attributes {
+		if v == nil || v.StringValue == nil {
+			continue
+		}
+		dataType := aws.StringValue(v.DataType)
+		if strings.HasPrefix(dataType, "String") || strings.HasPrefix(dataType, "Number") {
+			headers[k] = aws.StringValue(v.StringValue)
+		}
+	}
+	return headers
+}
+
+// sentTime returns when a message was sent, from its SentTimestamp system
+// attribute.
+func sentTime(attributes map[string]*string) (time.Time, bool) {
+	ms, err := strconv.ParseInt(aws.StringValue(attributes[sqs.MessageSystemAttributeNameSentTimestamp]), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)), true
+}
+
+func isQueueUrl(queue string) bool {
+	return strings.HasPrefix(queue, "https://") || strings.HasPrefix(queue, "http://")
+}
+
+// IsTopicValid returns whether topic is the name of a queue, up to 80
+// alphanumeric characters, hyphens and underscores, with a .fifo suffix
+// for FIFO queues, or the URL of a queue.
+func IsTopicValid(topic string) bool {
+	return queueNameRegex.MatchString(topic) || isQueueUrl(topic)
+}
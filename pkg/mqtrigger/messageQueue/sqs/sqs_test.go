@@ -0,0 +1,123 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/stretchr/testify/require"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestParseConfig(t *testing.T) {
+	trigger := &fv1.MessageQueueTrigger{Spec: fv1.MessageQueueTriggerSpec{Topic: "orders"}}
+
+	cfg, err := parseConfig(trigger)
+	require.NoError(t, err)
+	require.Equal(t, &subscriptionConfig{
+		visibilityTimeout: defaultVisibilityTimeout,
+		maxMessages:       defaultMaxMessages,
+		waitTimeSeconds:   defaultWaitTimeSeconds,
+		maxReceiveCount:   defaultMaxReceiveCount,
+	}, cfg)
+
+	trigger.Spec.Metadata = map[string]string{
+		MetadataVisibilityTimeout: "120",
+		MetadataMaxMessages:       "1",
+		MetadataWaitTimeSeconds:   "0",
+		MetadataDeadLetterQueue:   "orders-dlq",
+		MetadataMaxReceiveCount:   "3",
+		MetadataSNSTopicArn:       "arn:aws:sns:us-east-1:123456789012:orders",
+	}
+	cfg, err = parseConfig(trigger)
+	require.NoError(t, err)
+	require.Equal(t, &subscriptionConfig{
+		visibilityTimeout: 120,
+		maxMessages:       1,
+		waitTimeSeconds:   0,
+		deadLetterQueue:   "orders-dlq",
+		maxReceiveCount:   3,
+		snsTopicArn:       "arn:aws:sns:us-east-1:123456789012:orders",
+	}, cfg)
+
+	for _, metadata := range []map[string]string{
+		{MetadataVisibilityTimeout: "1"},
+		{MetadataMaxMessages: "11"},
+		{MetadataWaitTimeSeconds: "21"},
+		{MetadataMaxReceiveCount: "0"},
+		{MetadataMaxMessages: "many"},
+		{MetadataDeadLetterQueue: "orders dlq"},
+		{MetadataSNSTopicArn: "orders"},
+	} {
+		trigger.Spec.Metadata = metadata
+		_, err = parseConfig(trigger)
+		require.Error(t, err, "metadata %v", metadata)
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	policy, err := redrivePolicy("arn:aws:sqs:us-east-1:123456789012:orders-dlq", 3)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"deadLetterTargetArn":"arn:aws:sqs:us-east-1:123456789012:orders-dlq","maxReceiveCount":"3"}`, policy)
+
+	policy, err = queuePolicy("arn:aws:sqs:us-east-1:123456789012:orders", "arn:aws:sns:us-east-1:123456789012:orders")
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"Version": "2012-10-17",
+		"Statement": [{
+			"Effect": "Allow",
+			"Principal": {"Service": "sns.amazonaws.com"},
+			"Action": "sqs:SendMessage",
+			"Resource": "arn:aws:sqs:us-east-1:123456789012:orders",
+			"Condition": {"ArnEquals": {"aws:SourceArn": "arn:aws:sns:us-east-1:123456789012:orders"}}
+		}]
+	}`, policy)
+}
+
+func TestMessageHeaders(t *testing.T) {
+	headers := messageHeaders(map[string]*sqs.MessageAttributeValue{
+		"X-Order-Id": {DataType: aws.String("String"), StringValue: aws.String("42")},
+		"X-Amount":   {DataType: aws.String("Number.float"), StringValue: aws.String("9.5")},
+		"X-Blob":     {DataType: aws.String("Binary"), BinaryValue: []byte{1}},
+	})
+	require.Equal(t, map[string]string{"X-Order-Id": "42", "X-Amount": "9.5"}, headers)
+}
+
+func TestSentTime(t *testing.T) {
+	sent, ok := sentTime(map[string]*string{sqs.MessageSystemAttributeNameSentTimestamp: aws.String("1614600000123")})
+	require.True(t, ok)
+	require.Equal(t, time.Date(2021, 3, 1, 12, 0, 0, 123000000, time.UTC), sent.UTC())
+
+	_, ok = sentTime(nil)
+	require.False(t, ok)
+}
+
+func TestIsTopicValid(t *testing.T) {
+	require.True(t, IsTopicValid("orders"))
+	require.True(t, IsTopicValid("orders_v1-created.fifo"))
+	require.True(t, IsTopicValid("https://sqs.us-east-1.amazonaws.com/123456789012/orders"))
+	require.True(t, IsTopicValid(strings.Repeat("q", 80)))
+	require.False(t, IsTopicValid(""))
+	require.False(t, IsTopicValid("orders.created"))
+	require.False(t, IsTopicValid(strings.Repeat("q", 81)))
+	require.False(t, IsTopicValid(strings.Repeat("q", 76)+".fifo"))
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package mqtrigger
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -45,6 +46,13 @@ type (
 		fissionClient    *crd.FissionClient
 		messageQueueType fv1.MessageQueueType
 		messageQueue     messageQueue.MessageQueue
+		// scaler, if set, runs the consumers of autoscaled triggers
+		scaler *ConsumerScaler
+		// consumerTrigger, if set, is the cache key of the autoscaled
+		// trigger the manager is a consumer of
+		consumerTrigger string
+		// syncDone is closed once triggers are no longer synced
+		syncDone chan struct{}
 	}
 
 	triggerSubscription struct {
@@ -64,7 +72,8 @@ type (
 )
 
 func MakeMessageQueueTriggerManager(logger *zap.Logger,
-	fissionClient *crd.FissionClient, mqType fv1.MessageQueueType, messageQueue messageQueue.MessageQueue) *MessageQueueTriggerManager {
+	fissionClient *crd.FissionClient, mqType fv1.MessageQueueType, messageQueue messageQueue.MessageQueue,
+	scaler *ConsumerScaler, consumerTrigger string) *MessageQueueTriggerManager {
 	mqTriggerMgr := MessageQueueTriggerManager{
 		logger:           logger.Named("message_queue_trigger_manager"),
 		reqChan:          make(chan request),
@@ -72,13 +81,40 @@ func MakeMessageQueueTriggerManager(logger *zap.Logger,
 		fissionClient:    fissionClient,
 		messageQueueType: mqType,
 		messageQueue:     messageQueue,
+		scaler:           scaler,
+		consumerTrigger:  consumerTrigger,
+		syncDone:         make(chan struct{}),
 	}
 	return &mqTriggerMgr
 }
 
-func (mqt *MessageQueueTriggerManager) Run() {
+// Run syncs the subscriptions with the triggers until the context is done.
+func (mqt *MessageQueueTriggerManager) Run(ctx context.Context) {
 	go mqt.service()
-	go mqt.syncTriggers()
+	go mqt.syncTriggers(ctx)
+}
+
+// Stop unsubscribes from the topics of all triggers once triggers are no
+// longer synced, so that no more messages are consumed. Messages not
+// acknowledged by then are delivered again by the message queue.
+func (mqt *MessageQueueTriggerManager) Stop(ctx context.Context) error {
+	select {
+	case <-mqt.syncDone:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	var lastErr error
+	for _, triggerSub := range *mqt.getAllTriggers() {
+		err := mqt.messageQueue.Unsubscribe(triggerSub.subscription)
+		if err != nil {
+			mqt.logger.Warn("failed to unsubscribe from message queue trigger", zap.Error(err), zap.String("trigger_name", triggerSub.trigger.ObjectMeta.Name))
+			lastErr = err
+			continue
+		}
+		mqt.delTrigger(&triggerSub.trigger.ObjectMeta)
+	}
+	return lastErr
 }
 
 func (mqt *MessageQueueTriggerManager) service() {
@@ -138,8 +174,13 @@ func (mqt *MessageQueueTriggerManager) delTrigger(m *metav1.ObjectMeta) {
 	}
 }
 
-func (mqt *MessageQueueTriggerManager) syncTriggers() {
+func (mqt *MessageQueueTriggerManager) syncTriggers(ctx context.Context) {
+	defer close(mqt.syncDone)
 	for {
+		if ctx.Err() != nil {
+			return
+		}
+
 		// get new set of triggers
 		newTriggers, err := mqt.fissionClient.CoreV1().MessageQueueTriggers(metav1.NamespaceAll).List(metav1.ListOptions{})
 		if err != nil {
@@ -158,6 +199,27 @@ func (mqt *MessageQueueTriggerManager) syncTriggers() {
 			}
 		}
 
+		if len(mqt.consumerTrigger) > 0 {
+			// the consumers of an autoscaled trigger consume its messages only
+			for key := range newTriggerMap {
+				if key != mqt.consumerTrigger {
+					delete(newTriggerMap, key)
+				}
+			}
+		} else if mqt.scaler != nil {
+			autoscaledTriggers := make(map[string]*fv1.MessageQueueTrigger)
+			for key, trigger := range newTriggerMap {
+				if IsAutoscaled(trigger) {
+					autoscaledTriggers[key] = trigger
+					delete(newTriggerMap, key)
+				}
+			}
+			err = mqt.scaler.Sync(autoscaledTriggers)
+			if err != nil {
+				mqt.logger.Warn("failed to sync consumers of autoscaled message queue triggers", zap.Error(err))
+			}
+		}
+
 		// get current set of triggers
 		currentTriggers := mqt.getAllTriggers()
 
@@ -198,11 +260,21 @@ func (mqt *MessageQueueTriggerManager) syncTriggers() {
 				mqt.logger.Warn("failed to unsubscribe from message queue trigger", zap.Error(err), zap.String("trigger_name", triggerSub.trigger.ObjectMeta.Name))
 				continue
 			}
+			if remover, ok := mqt.messageQueue.(messageQueue.Remover); ok {
+				err = remover.Remove(triggerSub.subscription)
+				if err != nil {
+					mqt.logger.Warn("failed to remove resources of message queue trigger", zap.Error(err), zap.String("trigger_name", triggerSub.trigger.ObjectMeta.Name))
+				}
+			}
 			mqt.delTrigger(&triggerSub.trigger.ObjectMeta)
 			mqt.logger.Info("message queue trigger deleted", zap.String("trigger_name", triggerSub.trigger.ObjectMeta.Name))
 		}
 
 		// TODO replace with a watch
-		time.Sleep(3 * time.Second)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(3 * time.Second):
+		}
 	}
 }
@@ -0,0 +1,221 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mtls sets up mutually authenticated TLS between router,
+// executor and function pods. All components share one certificate,
+// issued either by the CA built into the helm chart or by cert-manager,
+// and stored in a secret with "tls.crt", "tls.key" and "ca.crt", the
+// layout both of them use. Each side only accepts peers presenting a
+// certificate signed by that CA.
+package mtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ServerName is the DNS name in the shared certificate. Peers are
+	// dialed by pod IP or service name, so clients verify this name
+	// instead of the address they connect to.
+	ServerName = "fission-mtls"
+
+	// APIPort is the port fetcher and executor serve their API on with mTLS.
+	APIPort = 8443
+	// FunctionPort is the port fetcher serves function requests on with
+	// mTLS, it forwards them to the function container.
+	FunctionPort = 8444
+
+	// DefaultCertDir is where the certificate secret is mounted.
+	DefaultCertDir = "/etc/fission/mtls"
+
+	CertFile = "tls.crt"
+	KeyFile  = "tls.key"
+	CAFile   = "ca.crt"
+
+	envEnabled    = "FISSION_MTLS_ENABLED"
+	envCertDir    = "FISSION_MTLS_CERT_DIR"
+	envSecretName = "FISSION_MTLS_SECRET"
+)
+
+type (
+	// Config locates the certificate used for mTLS. A nil Config means
+	// mTLS is disabled.
+	Config struct {
+		// CertDir is the directory containing the certificate, its key and the CA.
+		CertDir string
+
+		// SecretName is the secret holding the certificate in the fission
+		// namespace, executor copies it to function namespaces so that
+		// fetcher can mount it.
+		SecretName string
+
+		keyPairOnce sync.Once
+		keyPair     *keyPairReloader
+	}
+
+	// keyPairReloader loads the key pair again once the certificate file
+	// changes, so certificates rotated by cert-manager are picked up
+	// without restarting.
+	keyPairReloader struct {
+		certFile string
+		keyFile  string
+
+		lock    sync.Mutex
+		cert    *tls.Certificate
+		modTime time.Time
+	}
+)
+
+// ConfigFromEnv returns the mTLS config from the environment, or nil if
+// mTLS is not enabled.
+func ConfigFromEnv() (*Config, error) {
+	enabledStr := os.Getenv(envEnabled)
+	if len(enabledStr) == 0 {
+		return nil, nil
+	}
+	enabled, err := strconv.ParseBool(enabledStr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse '%v'", envEnabled)
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	certDir := os.Getenv(envCertDir)
+	if len(certDir) == 0 {
+		certDir = DefaultCertDir
+	}
+	return &Config{
+		CertDir:    certDir,
+		SecretName: os.Getenv(envSecretName),
+	}, nil
+}
+
+// Scheme returns the URL scheme to use for requests to fetcher, function
+// pods and executor.
+func (c *Config) Scheme() string {
+	if c == nil {
+		return "http"
+	}
+	return "https"
+}
+
+// ServerTLSConfig returns a TLS config for servers that requires clients
+// to present a certificate signed by the CA.
+func (c *Config) ServerTLSConfig() (*tls.Config, error) {
+	pool, err := c.certPool()
+	if err != nil {
+		return nil, err
+	}
+	keyPair, err := c.getKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return keyPair.get()
+		},
+	}, nil
+}
+
+// ClientTLSConfig returns a TLS config for clients that presents the
+// certificate and only trusts servers with a certificate signed by the CA.
+func (c *Config) ClientTLSConfig() (*tls.Config, error) {
+	pool, err := c.certPool()
+	if err != nil {
+		return nil, err
+	}
+	keyPair, err := c.getKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: ServerName,
+		RootCAs:    pool,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return keyPair.get()
+		},
+	}, nil
+}
+
+func (c *Config) certPool() (*x509.CertPool, error) {
+	caFile := filepath.Join(c.CertDir, CAFile)
+	pem, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading CA certificate %v", caFile)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.Errorf("no CA certificate found in %v", caFile)
+	}
+	return pool, nil
+}
+
+// getKeyPair returns the key pair reloader shared by the server and client
+// configs, after making sure the key pair can be loaded.
+func (c *Config) getKeyPair() (*keyPairReloader, error) {
+	c.keyPairOnce.Do(func() {
+		c.keyPair = &keyPairReloader{
+			certFile: filepath.Join(c.CertDir, CertFile),
+			keyFile:  filepath.Join(c.CertDir, KeyFile),
+		}
+	})
+	if _, err := c.keyPair.get(); err != nil {
+		return nil, err
+	}
+	return c.keyPair, nil
+}
+
+func (r *keyPairReloader) get() (*tls.Certificate, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	info, err := os.Stat(r.certFile)
+	if err != nil {
+		if r.cert != nil {
+			// the secret volume is being updated, keep the loaded certificate
+			return r.cert, nil
+		}
+		return nil, errors.Wrapf(err, "error reading certificate %v", r.certFile)
+	}
+	if r.cert != nil && info.ModTime().Equal(r.modTime) {
+		return r.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		if r.cert != nil {
+			return r.cert, nil
+		}
+		return nil, errors.Wrapf(err, "error loading key pair %v", r.certFile)
+	}
+	r.cert = &cert
+	r.modTime = info.ModTime()
+	return r.cert, nil
+}
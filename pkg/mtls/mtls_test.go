@@ -0,0 +1,240 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCerts writes a CA and a certificate for ServerName signed by it to dir.
+func writeCerts(t *testing.T, dir string, serial int64) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "fission-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial + 1),
+		Subject:      pkix.Name{CommonName: ServerName},
+		DNSNames:     []string{ServerName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]*pem.Block{
+		CAFile:   {Type: "CERTIFICATE", Bytes: caDER},
+		CertFile: {Type: "CERTIFICATE", Bytes: certDER},
+		KeyFile:  {Type: "EC PRIVATE KEY", Bytes: keyDER},
+	}
+	for name, block := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(block), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	defer os.Unsetenv(envEnabled)
+	defer os.Unsetenv(envCertDir)
+
+	os.Unsetenv(envEnabled)
+	cfg, err := ConfigFromEnv()
+	if err != nil || cfg != nil {
+		t.Errorf("expected mTLS to be disabled by default, got %v, %v", cfg, err)
+	}
+	if cfg.Scheme() != "http" {
+		t.Errorf("expected http scheme with mTLS disabled, got %v", cfg.Scheme())
+	}
+
+	os.Setenv(envEnabled, "not-a-bool")
+	if _, err = ConfigFromEnv(); err == nil {
+		t.Error("expected error for invalid value")
+	}
+
+	os.Setenv(envEnabled, "true")
+	cfg, err = ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg == nil || cfg.CertDir != DefaultCertDir {
+		t.Fatalf("expected default cert dir, got %v", cfg)
+	}
+	if cfg.Scheme() != "https" {
+		t.Errorf("expected https scheme with mTLS enabled, got %v", cfg.Scheme())
+	}
+
+	os.Setenv(envCertDir, "/tmp/certs")
+	cfg, err = ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.CertDir != "/tmp/certs" {
+		t.Errorf("expected cert dir from env, got %v", cfg.CertDir)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeCerts(t, dir, 1)
+
+	cfg := &Config{CertDir: dir}
+	serverConfig, err := cfg.ServerTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientConfig, err := cfg.ClientTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = serverConfig
+	server.StartTLS()
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig}}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected request with client certificate to succeed: %v", err)
+	}
+	resp.Body.Close()
+
+	// a client trusting the CA but without a certificate is rejected
+	noCertConfig := &tls.Config{ServerName: ServerName, RootCAs: clientConfig.RootCAs}
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: noCertConfig}}
+	resp, err = client.Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected request without client certificate to fail")
+	}
+
+	// a client with a certificate from another CA is rejected
+	otherDir, err := ioutil.TempDir("", "mtls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(otherDir)
+	writeCerts(t, otherDir, 10)
+	otherConfig, err := (&Config{CertDir: otherDir}).ClientTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherConfig.RootCAs = clientConfig.RootCAs
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: otherConfig}}
+	resp, err = client.Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected request with certificate from another CA to fail")
+	}
+}
+
+func TestKeyPairReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeCerts(t, dir, 1)
+
+	r := &keyPairReloader{
+		certFile: filepath.Join(dir, CertFile),
+		keyFile:  filepath.Join(dir, KeyFile),
+	}
+	first, err := r.get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := r.get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != again {
+		t.Error("expected unchanged certificate to be cached")
+	}
+
+	writeCerts(t, dir, 20)
+	later := time.Now().Add(time.Minute)
+	if err = os.Chtimes(r.certFile, later, later); err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := r.get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated == first {
+		t.Error("expected rotated certificate to be loaded")
+	}
+
+	// a missing certificate keeps the loaded one
+	os.Remove(r.certFile)
+	kept, err := r.get()
+	if err != nil || kept != rotated {
+		t.Errorf("expected loaded certificate to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pkgrevision keeps the specs of the last generations of packages
+// in config maps owned by the packages, so that the archives and build
+// metadata of package revisions can be compared.
+package pkgrevision
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	k8sCache "k8s.io/client-go/tools/cache"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+)
+
+const (
+	// LabelRevision marks the config maps package revisions are stored in.
+	LabelRevision = "fission.io/pkgrevision"
+	// LabelPackageUID is the UID of the package. Package names may be too
+	// long for label values.
+	LabelPackageUID = "fission.io/pkgrevision-package-uid"
+
+	// DefaultHistory is how many generations of a package are kept. Build
+	// status changes are generations of their own.
+	DefaultHistory = 20
+
+	dataKey = "revision.json"
+)
+
+type (
+	// Revision is a generation of a package.
+	Revision struct {
+		Package   string `json:"package"`
+		Namespace string `json:"namespace"`
+		UID       string `json:"uid"`
+		// Generation of the package, which numbers its revisions.
+		Generation      int64     `json:"generation"`
+		ResourceVersion string    `json:"resourceVersion"`
+		RecordedAt      time.Time `json:"recordedAt"`
+
+		Spec        fv1.PackageSpec `json:"spec"`
+		BuildStatus fv1.BuildStatus `json:"buildStatus,omitempty"`
+	}
+
+	// Store stores package revisions in config maps.
+	Store struct {
+		logger           *zap.Logger
+		fissionClient    *crd.FissionClient
+		kubernetesClient kubernetes.Interface
+		history          int
+	}
+)
+
+// MakeStore returns a store keeping the last history generations of each
+// package.
+func MakeStore(logger *zap.Logger, fissionClient *crd.FissionClient, kubernetesClient kubernetes.Interface, history int) *Store {
+	if history <= 0 {
+		history = DefaultHistory
+	}
+	return &Store{
+		logger:           logger.Named("package_revision_store"),
+		fissionClient:    fissionClient,
+		kubernetesClient: kubernetesClient,
+		history:          history,
+	}
+}
+
+// ConfigMapName returns the name of the config map a revision is stored in.
+func (r *Revision) ConfigMapName() string {
+	return fmt.Sprintf("pkgrevision-%v-%v", r.UID, r.Generation)
+}
+
+// ArchiveURLs returns the URLs of the archives of the revision.
+func (r *Revision) ArchiveURLs() []string {
+	var urls []string
+	for _, archive := range []fv1.Archive{r.Spec.Source, r.Spec.Deployment} {
+		if len(archive.URL) > 0 {
+			urls = append(urls, archive.URL)
+		}
+	}
+	return urls
+}
+
+func (r *Revision) configMap(pkg *fv1.Package) (*apiv1.ConfigMap, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding package revision")
+	}
+	return &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.ConfigMapName(),
+			Namespace: r.Namespace,
+			// revisions are deleted along with their package
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(pkg, fv1.SchemeGroupVersion.WithKind("Package")),
+			},
+			Labels: map[string]string{
+				LabelRevision:   "true",
+				LabelPackageUID: r.UID,
+			},
+		},
+		Data: map[string]string{
+			dataKey: string(data),
+		},
+	}, nil
+}
+
+// FromConfigMap returns the package revision stored in a config map.
+func FromConfigMap(cm *apiv1.ConfigMap) (*Revision, error) {
+	data, ok := cm.Data[dataKey]
+	if !ok {
+		return nil, errors.Errorf("config map %v/%v has no package revision", cm.ObjectMeta.Namespace, cm.ObjectMeta.Name)
+	}
+	r := &Revision{}
+	err := json.Unmarshal([]byte(data), r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error decoding package revision in config map %v/%v", cm.ObjectMeta.Namespace, cm.ObjectMeta.Name)
+	}
+	return r, nil
+}
+
+// Record keeps the current generation of a package, and drops the oldest
+// generations beyond the history.
+func (s *Store) Record(pkg *fv1.Package) error {
+	if pkg.ObjectMeta.Generation == 0 {
+		// servers not tracking generations have no revisions to number
+		return nil
+	}
+	r := &Revision{
+		Package:         pkg.ObjectMeta.Name,
+		Namespace:       pkg.ObjectMeta.Namespace,
+		UID:             string(pkg.ObjectMeta.UID),
+		Generation:      pkg.ObjectMeta.Generation,
+		ResourceVersion: pkg.ObjectMeta.ResourceVersion,
+		RecordedAt:      time.Now().UTC(),
+		Spec:            pkg.Spec,
+		BuildStatus:     pkg.Status.BuildStatus,
+	}
+	cm, err := r.configMap(pkg)
+	if err != nil {
+		return err
+	}
+	_, err = s.kubernetesClient.CoreV1().ConfigMaps(r.Namespace).Create(cm)
+	if kerrors.IsAlreadyExists(err) {
+		// packages have no status subresource, so a generation never
+		// changes once recorded
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "error recording revision of package %v/%v", r.Namespace, r.Package)
+	}
+
+	s.prune(pkg)
+	return nil
+}
+
+// List returns the kept revisions of a package, the most recent first.
+func (s *Store) List(pkg *fv1.Package) ([]Revision, error) {
+	cms, err := s.kubernetesClient.CoreV1().ConfigMaps(pkg.ObjectMeta.Namespace).List(metav1.ListOptions{
+		LabelSelector: labels.Set{
+			LabelRevision:   "true",
+			LabelPackageUID: string(pkg.ObjectMeta.UID),
+		}.AsSelector().String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := []Revision{}
+	for i := range cms.Items {
+		r, err := FromConfigMap(&cms.Items[i])
+		if err != nil {
+			s.logger.Error("ignoring invalid package revision", zap.Error(err))
+			continue
+		}
+		revisions = append(revisions, *r)
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Generation > revisions[j].Generation
+	})
+	return revisions, nil
+}
+
+func (s *Store) prune(pkg *fv1.Package) {
+	revisions, err := s.List(pkg)
+	if err != nil {
+		s.logger.Error("error listing package revisions to prune", zap.Error(err),
+			zap.String("package", pkg.ObjectMeta.Name), zap.String("namespace", pkg.ObjectMeta.Namespace))
+		return
+	}
+	for i := s.history; i < len(revisions); i++ {
+		name := revisions[i].ConfigMapName()
+		err = s.kubernetesClient.CoreV1().ConfigMaps(pkg.ObjectMeta.Namespace).Delete(name, &metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			s.logger.Error("error deleting old package revision", zap.Error(err), zap.String("config_map", name))
+		}
+	}
+}
+
+// Run records the generations of packages as they are created and updated,
+// no matter if through Fission or with kubectl, until stopCh is closed.
+func (s *Store) Run(stopCh <-chan struct{}) {
+	record := func(obj interface{}) {
+		pkg, ok := obj.(*fv1.Package)
+		if !ok {
+			return
+		}
+		if err := s.Record(pkg); err != nil {
+			s.logger.Error("error recording package revision", zap.Error(err),
+				zap.String("package", pkg.ObjectMeta.Name), zap.String("namespace", pkg.ObjectMeta.Namespace))
+		}
+	}
+	lw := k8sCache.NewListWatchFromClient(s.fissionClient.CoreV1().RESTClient(), "packages", metav1.NamespaceAll, fields.Everything())
+	_, controller := k8sCache.NewInformer(lw, &fv1.Package{}, 0, k8sCache.ResourceEventHandlerFuncs{
+		AddFunc: record,
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			oldPkg, ok := oldObj.(*fv1.Package)
+			if ok && oldPkg.ObjectMeta.Generation == newObj.(*fv1.Package).ObjectMeta.Generation {
+				return
+			}
+			record(newObj)
+		},
+	})
+	controller.Run(stopCh)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkgrevision
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestStore(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	s := MakeStore(zap.NewNop(), nil, kubeClient, 2)
+
+	pkg := &fv1.Package{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "hello",
+			Namespace:  metav1.NamespaceDefault,
+			UID:        "0b6c5e2a-8f3a-4a57-9e2b-5d3c1f0e7a91",
+			Generation: 1,
+		},
+		Spec: fv1.PackageSpec{
+			Deployment: fv1.Archive{
+				Type: fv1.ArchiveTypeUrl,
+				URL:  "http://storagesvc.fission/v1/archive?id=one",
+			},
+		},
+	}
+	record := func(generation int64, buildcmd string) {
+		pkg.ObjectMeta.Generation = generation
+		pkg.Spec.BuildCommand = buildcmd
+		if err := s.Record(pkg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	record(1, "build.sh")
+	record(2, "build.sh --prod")
+	// generations are only recorded once
+	record(2, "ignored")
+
+	revisions, err := s.List(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[0].Generation != 2 || revisions[0].Spec.BuildCommand != "build.sh --prod" {
+		t.Fatalf("expected the 2 generations, the most recent first, got %+v", revisions)
+	}
+	if urls := revisions[1].ArchiveURLs(); len(urls) != 1 || urls[0] != pkg.Spec.Deployment.URL {
+		t.Fatalf("expected the archive of the revision, got %v", urls)
+	}
+
+	cm, err := kubeClient.CoreV1().ConfigMaps(pkg.ObjectMeta.Namespace).Get(revisions[0].ConfigMapName(), metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cm.ObjectMeta.OwnerReferences) != 1 || cm.ObjectMeta.OwnerReferences[0].UID != pkg.ObjectMeta.UID {
+		t.Fatalf("expected revision to be owned by its package, got %v", cm.ObjectMeta.OwnerReferences)
+	}
+
+	// the oldest generation is dropped beyond the history
+	record(3, "build.sh")
+	revisions, err = s.List(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[0].Generation != 3 || revisions[1].Generation != 2 {
+		t.Fatalf("expected the last 2 generations, got %+v", revisions)
+	}
+}
@@ -24,5 +24,9 @@ type (
 		// publisher: it's a URL in the case of a webhook publisher, or a queue
 		// name in a queue-based publisher such as NATS.
 		Publish(body string, headers map[string]string, target string)
+
+		// PublishWithMethod publishes an request like Publish, with an HTTP
+		// method other than POST for publishers sending HTTP requests.
+		PublishWithMethod(method string, body string, headers map[string]string, target string)
 	}
 )
@@ -39,6 +39,7 @@ type (
 		baseURL string
 	}
 	publishRequest struct {
+		method     string
 		body       string
 		headers    map[string]string
 		target     string
@@ -63,8 +64,14 @@ func MakeWebhookPublisher(logger *zap.Logger, baseURL string) *WebhookPublisher
 
 // Publish sends a request to the target with payload having given body and headers
 func (p *WebhookPublisher) Publish(body string, headers map[string]string, target string) {
+	p.PublishWithMethod(http.MethodPost, body, headers, target)
+}
+
+// PublishWithMethod sends a request like Publish with the given HTTP method
+func (p *WebhookPublisher) PublishWithMethod(method string, body string, headers map[string]string, target string) {
 	// serializing the request gives user a guarantee that the request is sent in sequence order
 	p.requestChannel <- &publishRequest{
+		method:     method,
 		body:       body,
 		headers:    headers,
 		target:     target,
@@ -98,7 +105,7 @@ func (p *WebhookPublisher) makeHTTPRequest(r *publishRequest) {
 	buf.WriteString(r.body)
 
 	// Create request
-	req, err := http.NewRequest(http.MethodPost, url, &buf)
+	req, err := http.NewRequest(r.method, url, &buf)
 	if err != nil {
 		fields = append(fields, zap.Error(err))
 		return
@@ -0,0 +1,325 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package qos
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// Level is how much a process is over its resource budget.
+type Level int
+
+const (
+	// LevelNormal means the process is within its budget.
+	LevelNormal Level = iota
+	// LevelHigh means the process has used up its budget, batch
+	// operations are shed.
+	LevelHigh
+	// LevelCritical means the process is well over its budget, only
+	// interactive operations are let through.
+	LevelCritical
+)
+
+const (
+	// criticalPressure is the usage to budget ratio from which the
+	// pressure is critical.
+	criticalPressure = 1.2
+	// recoveryFactor is how far below a level threshold the pressure has to
+	// drop to leave the level, so that the level doesn't flap around it.
+	recoveryFactor = 0.9
+
+	// DefaultGuardInterval is the default interval between two samples of
+	// the resource usage.
+	DefaultGuardInterval = 2 * time.Second
+)
+
+var (
+	guardPressure = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "fission_guardrail_pressure",
+			Help: "Highest ratio of memory or goroutine usage to the budget of the component",
+		},
+		[]string{"component"},
+	)
+	guardLevel = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "fission_guardrail_level",
+			Help: "Resource pressure level of the component: 0 normal, 1 high, 2 critical",
+		},
+		[]string{"component"},
+	)
+	guardShed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fission_guardrail_shed_total",
+			Help: "Count of operations rejected because the component was over its resource budget",
+		},
+		[]string{"component", "priority"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(guardPressure)
+	prometheus.MustRegister(guardLevel)
+	prometheus.MustRegister(guardShed)
+}
+
+type (
+	// Budget is the resource budget of a process. Zero fields aren't limited.
+	Budget struct {
+		// MaxMemoryBytes is the memory the process may hold from the
+		// operating system.
+		MaxMemoryBytes uint64
+		// MaxGoroutines is the number of goroutines the process may run.
+		MaxGoroutines int
+	}
+
+	// Guard samples the memory and goroutine usage of the process and
+	// compares it with a budget. Over budget, it sheds operations with
+	// a low priority so that the process isn't OOM killed in a traffic
+	// storm, and notifies watchers of the level changes to let them cut
+	// down their own work.
+	Guard struct {
+		logger    *zap.Logger
+		component string
+		budget    Budget
+		interval  time.Duration
+
+		lock     sync.RWMutex
+		level    Level
+		watchers []func(Level)
+	}
+)
+
+// ParseBudget parses a memory budget given as a quantity like "512Mi" and
+// a goroutine budget given as a number. Empty values aren't limited.
+func ParseBudget(memory, goroutines string) (Budget, error) {
+	var budget Budget
+	if len(memory) > 0 {
+		q, err := resource.ParseQuantity(memory)
+		if err != nil {
+			return Budget{}, errors.Wrapf(err, "error parsing memory budget %q", memory)
+		}
+		if q.Sign() < 0 {
+			return Budget{}, errors.Errorf("memory budget %q is negative", memory)
+		}
+		budget.MaxMemoryBytes = uint64(q.Value())
+	}
+	if len(goroutines) > 0 {
+		n, err := strconv.Atoi(goroutines)
+		if err != nil {
+			return Budget{}, errors.Wrapf(err, "error parsing goroutine budget %q", goroutines)
+		}
+		if n < 0 {
+			return Budget{}, errors.Errorf("goroutine budget %q is negative", goroutines)
+		}
+		budget.MaxGoroutines = n
+	}
+	return budget, nil
+}
+
+// MakeGuard returns a guard of the given component sampling its usage every
+// interval, or nil if the budget doesn't limit anything. A nil guard never
+// sheds anything.
+func MakeGuard(logger *zap.Logger, component string, budget Budget, interval time.Duration) *Guard {
+	if budget.MaxMemoryBytes == 0 && budget.MaxGoroutines == 0 {
+		return nil
+	}
+	if interval <= 0 {
+		interval = DefaultGuardInterval
+	}
+	return &Guard{
+		logger:    logger,
+		component: component,
+		budget:    budget,
+		interval:  interval,
+	}
+}
+
+// Watch registers a function called with the new level whenever the level
+// changes. It must be called before Run.
+func (g *Guard) Watch(watcher func(Level)) {
+	if g == nil {
+		return
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.watchers = append(g.watchers, watcher)
+}
+
+// Run samples the resource usage until the context is done.
+func (g *Guard) Run(ctx context.Context) {
+	if g == nil {
+		return
+	}
+	g.logger.Info("resource guard started",
+		zap.String("component", g.component),
+		zap.Uint64("max_memory_bytes", g.budget.MaxMemoryBytes),
+		zap.Int("max_goroutines", g.budget.MaxGoroutines),
+		zap.Duration("interval", g.interval))
+
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+	for {
+		g.sample()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (g *Guard) sample() {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	// memory released to the operating system doesn't count against the
+	// container memory limit
+	memory := m.Sys - m.HeapReleased
+	goroutines := runtime.NumGoroutine()
+	pressure := g.budget.pressure(memory, goroutines)
+
+	g.lock.Lock()
+	old := g.level
+	g.level = nextLevel(old, pressure)
+	level := g.level
+	watchers := g.watchers
+	g.lock.Unlock()
+
+	guardPressure.WithLabelValues(g.component).Set(pressure)
+	guardLevel.WithLabelValues(g.component).Set(float64(level))
+
+	if level == old {
+		return
+	}
+	fields := []zap.Field{
+		zap.String("component", g.component),
+		zap.Int("level", int(level)),
+		zap.Float64("pressure", pressure),
+		zap.Uint64("memory_bytes", memory),
+		zap.Int("goroutines", goroutines),
+	}
+	if level > old {
+		g.logger.Warn("resource usage over budget, shedding low priority operations", fields...)
+	} else {
+		g.logger.Info("resource pressure decreased", fields...)
+	}
+	for _, w := range watchers {
+		w(level)
+	}
+}
+
+// Level returns the level of the last sample.
+func (g *Guard) Level() Level {
+	if g == nil {
+		return LevelNormal
+	}
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.level
+}
+
+// Shed returns whether an operation with the given priority has to be
+// rejected at the current level, and counts it if so.
+func (g *Guard) Shed(priority fv1.InvocationPriority) bool {
+	if g == nil || !shedAt(g.Level(), priority) {
+		return false
+	}
+	guardShed.WithLabelValues(g.component, string(priority)).Inc()
+	return true
+}
+
+// RetryAfter returns how long a client of a shed operation should wait
+// before trying again, which is until the next sample of the usage.
+func (g *Guard) RetryAfter() time.Duration {
+	if g == nil {
+		return 0
+	}
+	return g.interval
+}
+
+// pressure returns the highest ratio of usage to budget.
+func (b Budget) pressure(memory uint64, goroutines int) float64 {
+	var pressure float64
+	if b.MaxMemoryBytes > 0 {
+		pressure = float64(memory) / float64(b.MaxMemoryBytes)
+	}
+	if b.MaxGoroutines > 0 {
+		if p := float64(goroutines) / float64(b.MaxGoroutines); p > pressure {
+			pressure = p
+		}
+	}
+	return pressure
+}
+
+// nextLevel returns the level for a pressure, given the current level.
+func nextLevel(current Level, pressure float64) Level {
+	thresholds := []float64{
+		LevelHigh:     1,
+		LevelCritical: criticalPressure,
+	}
+	level := LevelNormal
+	for l := LevelHigh; l <= LevelCritical; l++ {
+		threshold := thresholds[l]
+		if l <= current {
+			// stay in a level until the pressure is clearly below it
+			threshold *= recoveryFactor
+		}
+		if pressure >= threshold {
+			level = l
+		}
+	}
+	return level
+}
+
+// shedAt returns whether operations with the given priority are shed at a
+// level. Interactive operations are never shed.
+func shedAt(level Level, priority fv1.InvocationPriority) bool {
+	switch level {
+	case LevelHigh:
+		return Rank(priority) >= Rank(fv1.InvocationPriorityBatch)
+	case LevelCritical:
+		return Rank(priority) >= Rank(fv1.InvocationPriorityStandard)
+	default:
+		return false
+	}
+}
+
+// CapacityAt returns the capacity of a limiter with the given full capacity
+// at a level: halved when high and down to a single operation when critical.
+func CapacityAt(capacity int, level Level) int {
+	switch level {
+	case LevelHigh:
+		capacity /= 2
+	case LevelCritical:
+		capacity = 1
+	}
+	if capacity < 1 {
+		capacity = 1
+	}
+	return capacity
+}
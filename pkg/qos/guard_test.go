@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package qos
+
+import (
+	"testing"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestBudgetPressure(t *testing.T) {
+	b := Budget{MaxMemoryBytes: 1000, MaxGoroutines: 100}
+	if p := b.pressure(500, 80); p != 0.8 {
+		t.Errorf("expected goroutine pressure 0.8, got %v", p)
+	}
+	if p := b.pressure(1500, 10); p != 1.5 {
+		t.Errorf("expected memory pressure 1.5, got %v", p)
+	}
+	if p := (Budget{MaxGoroutines: 100}).pressure(1<<40, 50); p != 0.5 {
+		t.Errorf("expected unlimited memory to be ignored, got pressure %v", p)
+	}
+}
+
+func TestNextLevel(t *testing.T) {
+	tests := []struct {
+		current  Level
+		pressure float64
+		expected Level
+	}{
+		{LevelNormal, 0.5, LevelNormal},
+		{LevelNormal, 0.95, LevelNormal},
+		{LevelNormal, 1, LevelHigh},
+		{LevelNormal, 1.3, LevelCritical},
+		// a level is left only clearly below its threshold
+		{LevelHigh, 0.95, LevelHigh},
+		{LevelHigh, 0.85, LevelNormal},
+		{LevelCritical, 1.1, LevelCritical},
+		{LevelCritical, 1.05, LevelHigh},
+		{LevelCritical, 0.5, LevelNormal},
+	}
+	for _, test := range tests {
+		if l := nextLevel(test.current, test.pressure); l != test.expected {
+			t.Errorf("level %v with pressure %v: expected %v, got %v", test.current, test.pressure, test.expected, l)
+		}
+	}
+}
+
+func TestShedAt(t *testing.T) {
+	expected := map[Level][]fv1.InvocationPriority{
+		LevelNormal:   nil,
+		LevelHigh:     {fv1.InvocationPriorityBatch},
+		LevelCritical: {fv1.InvocationPriorityStandard, fv1.InvocationPriorityBatch},
+	}
+	for level, shed := range expected {
+		for _, p := range priorities {
+			want := false
+			for _, s := range shed {
+				want = want || s == p
+			}
+			if got := shedAt(level, p); got != want {
+				t.Errorf("level %v, priority %v: expected shed %v, got %v", level, p, want, got)
+			}
+		}
+	}
+
+	var g *Guard
+	if g.Shed(fv1.InvocationPriorityBatch) {
+		t.Error("expected a nil guard not to shed anything")
+	}
+}
+
+func TestCapacityAt(t *testing.T) {
+	if c := CapacityAt(10, LevelNormal); c != 10 {
+		t.Errorf("expected full capacity, got %v", c)
+	}
+	if c := CapacityAt(10, LevelHigh); c != 5 {
+		t.Errorf("expected half capacity, got %v", c)
+	}
+	if c := CapacityAt(1, LevelHigh); c != 1 {
+		t.Errorf("expected capacity of at least 1, got %v", c)
+	}
+	if c := CapacityAt(10, LevelCritical); c != 1 {
+		t.Errorf("expected capacity 1, got %v", c)
+	}
+}
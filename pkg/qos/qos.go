@@ -0,0 +1,187 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package qos
+
+import (
+	"context"
+	"sync"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// priorities lists the priority classes from the highest to the lowest.
+var priorities = []fv1.InvocationPriority{
+	fv1.InvocationPriorityInteractive,
+	fv1.InvocationPriorityStandard,
+	fv1.InvocationPriorityBatch,
+}
+
+type (
+	// Limiter limits the number of operations running at the same time,
+	// like requests in router or pod specializations in executor. Once the
+	// limit is reached, operations wait in a queue for each priority class,
+	// and a finished operation lets the oldest waiting operation with the
+	// highest priority start. Low priority operations may wait as long as
+	// there are higher priority ones, the context of an operation bounds
+	// its wait.
+	Limiter struct {
+		lock     sync.Mutex
+		capacity int
+		running  int
+		queues   [][]*waiter
+	}
+
+	waiter struct {
+		ready chan struct{}
+		// started is set under the limiter lock once the waiter got a slot.
+		started bool
+	}
+)
+
+// MakeLimiter returns a limiter running up to capacity operations at the same
+// time, or nil if capacity isn't positive. A nil limiter doesn't limit anything.
+func MakeLimiter(capacity int) *Limiter {
+	if capacity <= 0 {
+		return nil
+	}
+	return &Limiter{
+		capacity: capacity,
+		queues:   make([][]*waiter, len(priorities)),
+	}
+}
+
+// Acquire waits until an operation with the given priority may start, and
+// returns the function to call once it's done. It returns the context error
+// if the context is done before that.
+func (l *Limiter) Acquire(ctx context.Context, priority fv1.InvocationPriority) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	l.lock.Lock()
+	if l.running < l.capacity {
+		l.running++
+		l.lock.Unlock()
+		return l.release, nil
+	}
+	w := &waiter{ready: make(chan struct{})}
+	rank := Rank(priority)
+	l.queues[rank] = append(l.queues[rank], w)
+	l.lock.Unlock()
+
+	select {
+	case <-w.ready:
+		return l.release, nil
+	case <-ctx.Done():
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		if w.started {
+			// got a slot at the same time, hand it on
+			l.releaseLocked()
+			return nil, ctx.Err()
+		}
+		queue := l.queues[rank]
+		for i := range queue {
+			if queue[i] == w {
+				l.queues[rank] = append(queue[:i], queue[i+1:]...)
+				break
+			}
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// Waiting returns the number of operations waiting with the given priority.
+func (l *Limiter) Waiting(priority fv1.InvocationPriority) int {
+	if l == nil {
+		return 0
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return len(l.queues[Rank(priority)])
+}
+
+func (l *Limiter) release() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.releaseLocked()
+}
+
+// SetCapacity changes the number of operations running at the same time.
+// Operations already running beyond a lowered capacity finish normally, but
+// no waiting operation starts until the running ones are back under it.
+func (l *Limiter) SetCapacity(capacity int) {
+	if l == nil {
+		return
+	}
+	if capacity < 1 {
+		capacity = 1
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.capacity = capacity
+	for l.running < l.capacity && l.startNextLocked() {
+		l.running++
+	}
+}
+
+func (l *Limiter) releaseLocked() {
+	if l.running > l.capacity || !l.startNextLocked() {
+		l.running--
+	}
+}
+
+// startNextLocked starts the oldest waiting operation with the highest
+// priority, and returns false if there is none.
+func (l *Limiter) startNextLocked() bool {
+	for rank, queue := range l.queues {
+		if len(queue) > 0 {
+			// the slot goes to the waiter directly
+			w := queue[0]
+			l.queues[rank] = queue[1:]
+			w.started = true
+			close(w.ready)
+			return true
+		}
+	}
+	return false
+}
+
+// Rank returns the rank of a priority, 0 being the highest. Unknown
+// priorities rank like the standard priority.
+func Rank(priority fv1.InvocationPriority) int {
+	for i, p := range priorities {
+		if p == priority {
+			return i
+		}
+	}
+	return Rank(fv1.InvocationPriorityStandard)
+}
+
+// Priority returns the priority of a request, as asked for in its priority
+// header, limited to maxPriority. Requests that don't ask for a valid
+// priority get maxPriority, which defaults to the standard priority.
+func Priority(requested string, maxPriority fv1.InvocationPriority) fv1.InvocationPriority {
+	if len(maxPriority) == 0 {
+		maxPriority = fv1.InvocationPriorityStandard
+	}
+	p := fv1.InvocationPriority(requested)
+	if p.Validate() != nil || Rank(p) < Rank(maxPriority) {
+		return maxPriority
+	}
+	return p
+}
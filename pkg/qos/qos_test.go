@@ -0,0 +1,127 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package qos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestLimiter(t *testing.T) {
+	l := MakeLimiter(1)
+	release, err := l.Acquire(context.Background(), fv1.InvocationPriorityBatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan fv1.InvocationPriority, 3)
+	acquire := func(p fv1.InvocationPriority) {
+		r, err := l.Acquire(context.Background(), p)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		started <- p
+		r()
+	}
+
+	// queue a batch, then a standard and an interactive operation
+	for _, p := range []fv1.InvocationPriority{fv1.InvocationPriorityBatch, fv1.InvocationPriorityStandard, fv1.InvocationPriorityInteractive} {
+		go acquire(p)
+		for l.Waiting(p) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// a canceled operation leaves the queue
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx, fv1.InvocationPriorityInteractive); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if n := l.Waiting(fv1.InvocationPriorityInteractive); n != 1 {
+		t.Fatalf("expected 1 waiting interactive operation, got %v", n)
+	}
+
+	release()
+	expected := []fv1.InvocationPriority{fv1.InvocationPriorityInteractive, fv1.InvocationPriorityStandard, fv1.InvocationPriorityBatch}
+	for _, p := range expected {
+		if s := <-started; s != p {
+			t.Fatalf("expected %v operation to start, got %v", p, s)
+		}
+	}
+
+	// all slots are free again
+	if _, err := l.Acquire(context.Background(), fv1.InvocationPriorityBatch); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLimiterSetCapacity(t *testing.T) {
+	l := MakeLimiter(2)
+	r1, _ := l.Acquire(context.Background(), fv1.InvocationPriorityStandard)
+	r2, _ := l.Acquire(context.Background(), fv1.InvocationPriorityStandard)
+
+	// lowering the capacity keeps the running operations
+	l.SetCapacity(1)
+	started := make(chan struct{})
+	go func() {
+		r, err := l.Acquire(context.Background(), fv1.InvocationPriorityStandard)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		close(started)
+		r()
+	}()
+	for l.Waiting(fv1.InvocationPriorityStandard) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// one operation finishing doesn't free a slot under the new capacity
+	r1()
+	if n := l.Waiting(fv1.InvocationPriorityStandard); n != 1 {
+		t.Fatalf("expected 1 waiting operation, got %v", n)
+	}
+
+	// raising the capacity starts the waiting operation
+	l.SetCapacity(2)
+	<-started
+	r2()
+}
+
+func TestPriority(t *testing.T) {
+	tests := []struct {
+		requested string
+		max       fv1.InvocationPriority
+		expected  fv1.InvocationPriority
+	}{
+		{"", "", fv1.InvocationPriorityStandard},
+		{"batch", "", fv1.InvocationPriorityBatch},
+		{"interactive", "", fv1.InvocationPriorityStandard},
+		{"interactive", fv1.InvocationPriorityInteractive, fv1.InvocationPriorityInteractive},
+		{"urgent", fv1.InvocationPriorityBatch, fv1.InvocationPriorityBatch},
+	}
+	for _, test := range tests {
+		if p := Priority(test.requested, test.max); p != test.expected {
+			t.Errorf("priority %q with max %q: expected %v, got %v", test.requested, test.max, test.expected, p)
+		}
+	}
+}
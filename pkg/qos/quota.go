@@ -0,0 +1,221 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package qos
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+type (
+	// QuotaTracker counts invocations against invocation quotas, by key
+	// like a function or a namespace, and window. The counts of several
+	// trackers, like the ones of router replicas, are kept in sync
+	// through a store: Unsynced returns the invocations to add to the
+	// store, and Synced takes the counts of the store once they are.
+	QuotaTracker struct {
+		lock  sync.Mutex
+		now   func() time.Time
+		usage map[string]*quotaUsage
+	}
+
+	// QuotaLimit is a quota of a key an invocation is checked against.
+	QuotaLimit struct {
+		Key   string
+		Quota fv1.InvocationQuota
+	}
+
+	// QuotaUsage is the count of invocations in a window.
+	QuotaUsage struct {
+		WindowStart time.Time `json:"windowStart"`
+		WindowEnd   time.Time `json:"windowEnd"`
+		Count       int64     `json:"count"`
+	}
+
+	quotaUsage struct {
+		QuotaUsage
+		// unsynced is how many invocations of Count the store lacks.
+		unsynced int64
+	}
+)
+
+// ParseInvocationQuota parses a quota given as "<limit>/<window>", like
+// "100000/day".
+func ParseInvocationQuota(s string) (fv1.InvocationQuota, error) {
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return fv1.InvocationQuota{}, errors.Errorf("invocation quota %q must be <limit>/<window>", s)
+	}
+	limit, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return fv1.InvocationQuota{}, errors.Wrapf(err, "error parsing limit of invocation quota %q", s)
+	}
+	quota := fv1.InvocationQuota{
+		Limit:  limit,
+		Window: fv1.QuotaWindow(parts[1]),
+	}
+	return quota, quota.Validate()
+}
+
+// WindowBounds returns the start and end of the window containing t.
+func WindowBounds(window fv1.QuotaWindow, t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	switch window {
+	case fv1.QuotaWindowMonth:
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	default:
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 0, 1)
+	}
+}
+
+// MakeQuotaTracker returns a tracker counting no invocations yet.
+func MakeQuotaTracker() *QuotaTracker {
+	return &QuotaTracker{
+		now:   time.Now,
+		usage: make(map[string]*quotaUsage),
+	}
+}
+
+// Allow counts an invocation against limits unless one of them is
+// exhausted. In that case it returns false and when invocations are
+// allowed again, that is once all exhausted windows ended.
+func (t *QuotaTracker) Allow(limits []QuotaLimit) (bool, time.Time) {
+	if len(limits) == 0 {
+		return true, time.Time{}
+	}
+	now := t.now()
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var retryAt time.Time
+	usage := make([]*quotaUsage, len(limits))
+	for i, l := range limits {
+		usage[i] = t.current(l, now)
+		if usage[i].Count >= l.Quota.Limit && usage[i].WindowEnd.After(retryAt) {
+			retryAt = usage[i].WindowEnd
+		}
+	}
+	if !retryAt.IsZero() {
+		return false, retryAt
+	}
+	for _, u := range usage {
+		u.Count++
+		u.unsynced++
+	}
+	return true, time.Time{}
+}
+
+// current returns the usage of the window of the limit containing now,
+// dropping the usage of an earlier window.
+func (t *QuotaTracker) current(l QuotaLimit, now time.Time) *quotaUsage {
+	key := usageKey(l.Key, l.Quota.Window)
+	u, ok := t.usage[key]
+	if !ok || !now.Before(u.WindowEnd) {
+		start, end := WindowBounds(l.Quota.Window, now)
+		u = &quotaUsage{QuotaUsage: QuotaUsage{WindowStart: start, WindowEnd: end}}
+		t.usage[key] = u
+	}
+	return u
+}
+
+// Unsynced returns the invocations counted since they were last synced,
+// by key.
+func (t *QuotaTracker) Unsynced() map[string]QuotaUsage {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	unsynced := make(map[string]QuotaUsage)
+	for key, u := range t.usage {
+		if u.unsynced > 0 {
+			unsynced[key] = QuotaUsage{
+				WindowStart: u.WindowStart,
+				WindowEnd:   u.WindowEnd,
+				Count:       u.unsynced,
+			}
+		}
+	}
+	return unsynced
+}
+
+// Synced takes the counts of the store, once the unsynced invocations
+// returned by Unsynced were added to it.
+func (t *QuotaTracker) Synced(unsynced map[string]QuotaUsage, stored map[string]QuotaUsage) {
+	now := t.now()
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for key, s := range unsynced {
+		if u, ok := t.usage[key]; ok && u.WindowStart.Equal(s.WindowStart) {
+			u.unsynced -= s.Count
+		}
+	}
+	for key, s := range stored {
+		if !now.Before(s.WindowEnd) {
+			continue
+		}
+		u, ok := t.usage[key]
+		if !ok || u.WindowStart.Before(s.WindowStart) {
+			t.usage[key] = &quotaUsage{QuotaUsage: s}
+		} else if u.WindowStart.Equal(s.WindowStart) {
+			u.Count = s.Count + u.unsynced
+		}
+	}
+	for key, u := range t.usage {
+		if !now.Before(u.WindowEnd) {
+			delete(t.usage, key)
+		}
+	}
+}
+
+// MergeQuotaUsage adds the unsynced invocations to the counts of the
+// store, dropping the counts of ended windows.
+func MergeQuotaUsage(stored map[string]QuotaUsage, unsynced map[string]QuotaUsage, now time.Time) map[string]QuotaUsage {
+	merged := make(map[string]QuotaUsage, len(stored))
+	for key, s := range stored {
+		if now.Before(s.WindowEnd) {
+			merged[key] = s
+		}
+	}
+	for key, u := range unsynced {
+		if !now.Before(u.WindowEnd) {
+			continue
+		}
+		s, ok := merged[key]
+		switch {
+		case !ok || s.WindowStart.Before(u.WindowStart):
+			merged[key] = u
+		case s.WindowStart.Equal(u.WindowStart):
+			s.Count += u.Count
+			merged[key] = s
+		}
+	}
+	return merged
+}
+
+func usageKey(key string, window fv1.QuotaWindow) string {
+	return key + "/" + string(window)
+}
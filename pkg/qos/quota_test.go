@@ -0,0 +1,150 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package qos
+
+import (
+	"testing"
+	"time"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestParseInvocationQuota(t *testing.T) {
+	quota, err := ParseInvocationQuota("100000/day")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quota.Limit != 100000 || quota.Window != fv1.QuotaWindowDay {
+		t.Errorf("expected 100000 per day, got %v per %v", quota.Limit, quota.Window)
+	}
+	for _, s := range []string{"100000", "x/day", "0/day", "10/week"} {
+		if _, err := ParseInvocationQuota(s); err == nil {
+			t.Errorf("expected %q to be rejected", s)
+		}
+	}
+}
+
+func TestWindowBounds(t *testing.T) {
+	now := time.Date(2021, 2, 14, 15, 4, 5, 0, time.UTC)
+	start, end := WindowBounds(fv1.QuotaWindowDay, now)
+	if !start.Equal(time.Date(2021, 2, 14, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2021, 2, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected day window %v - %v", start, end)
+	}
+	start, end = WindowBounds(fv1.QuotaWindowMonth, now)
+	if !start.Equal(time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected month window %v - %v", start, end)
+	}
+}
+
+func TestQuotaTrackerAllow(t *testing.T) {
+	now := time.Date(2021, 2, 14, 15, 0, 0, 0, time.UTC)
+	tracker := MakeQuotaTracker()
+	tracker.now = func() time.Time { return now }
+
+	limits := []QuotaLimit{
+		{Key: "function/default/hello", Quota: fv1.InvocationQuota{Limit: 2, Window: fv1.QuotaWindowDay}},
+		{Key: "namespace/default", Quota: fv1.InvocationQuota{Limit: 3, Window: fv1.QuotaWindowMonth}},
+	}
+	for i := 0; i < 2; i++ {
+		if ok, _ := tracker.Allow(limits); !ok {
+			t.Fatalf("expected invocation %v to be allowed", i)
+		}
+	}
+	ok, retryAt := tracker.Allow(limits)
+	if ok {
+		t.Fatal("expected invocation beyond the daily quota to be rejected")
+	}
+	if !retryAt.Equal(time.Date(2021, 2, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected retry at the end of the day, got %v", retryAt)
+	}
+
+	// the daily quota is renewed the next day, the monthly one is not
+	now = now.Add(24 * time.Hour)
+	if ok, _ := tracker.Allow(limits); !ok {
+		t.Fatal("expected invocation to be allowed on the next day")
+	}
+	ok, retryAt = tracker.Allow(limits)
+	if ok {
+		t.Fatal("expected invocation beyond the monthly quota to be rejected")
+	}
+	if !retryAt.Equal(time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected retry at the end of the month, got %v", retryAt)
+	}
+
+	if ok, _ := tracker.Allow(nil); !ok {
+		t.Error("expected invocation without quotas to be allowed")
+	}
+}
+
+func TestQuotaTrackerSync(t *testing.T) {
+	now := time.Date(2021, 2, 14, 15, 0, 0, 0, time.UTC)
+	start, end := WindowBounds(fv1.QuotaWindowDay, now)
+	limits := []QuotaLimit{
+		{Key: "function/default/hello", Quota: fv1.InvocationQuota{Limit: 5, Window: fv1.QuotaWindowDay}},
+	}
+	key := usageKey(limits[0].Key, fv1.QuotaWindowDay)
+
+	a := MakeQuotaTracker()
+	a.now = func() time.Time { return now }
+	b := MakeQuotaTracker()
+	b.now = func() time.Time { return now }
+
+	stored := make(map[string]QuotaUsage)
+	sync := func(tracker *QuotaTracker) {
+		unsynced := tracker.Unsynced()
+		stored = MergeQuotaUsage(stored, unsynced, now)
+		tracker.Synced(unsynced, stored)
+	}
+
+	a.Allow(limits)
+	a.Allow(limits)
+	b.Allow(limits)
+	sync(a)
+	// an invocation counted while a sync is in progress is kept
+	unsynced := b.Unsynced()
+	b.Allow(limits)
+	stored = MergeQuotaUsage(stored, unsynced, now)
+	b.Synced(unsynced, stored)
+
+	if c := stored[key].Count; c != 3 {
+		t.Errorf("expected 3 stored invocations, got %v", c)
+	}
+	if c := b.usage[key].Count; c != 4 {
+		t.Errorf("expected 4 invocations known to b, got %v", c)
+	}
+	sync(b)
+	sync(a)
+	if c := a.usage[key].Count; c != 4 {
+		t.Errorf("expected a to take the invocations of b, got %v", c)
+	}
+	if ok, _ := a.Allow(limits); !ok {
+		t.Fatal("expected fifth invocation to be allowed")
+	}
+	if ok, _ := a.Allow(limits); ok {
+		t.Error("expected invocation beyond the quota across trackers to be rejected")
+	}
+
+	// ended windows are dropped
+	stored["function/default/old/day"] = QuotaUsage{WindowStart: start.AddDate(0, 0, -1), WindowEnd: start, Count: 1}
+	merged := MergeQuotaUsage(stored, nil, now)
+	if _, ok := merged["function/default/old/day"]; ok {
+		t.Error("expected usage of an ended window to be dropped")
+	}
+	if merged[key].WindowEnd != end {
+		t.Errorf("expected usage of the current window to be kept")
+	}
+}
@@ -0,0 +1,541 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+//
+// Async invocations
+//
+// POST /fission-async/[<namespace>/]<trigger> enqueues the request for the
+// HTTP trigger, and returns the ID of the invocation right away. Workers of
+// all router replicas take the invocations from the queue and replay them
+// through the router, so that the trigger is invoked as if it was called
+// directly, retrying failed invocations. The result of an invocation,
+// with the response of the function, is served at
+// GET /fission-async/results/<id> by every replica for a while.
+//
+// Invocations are delivered at least once: if a router stops while
+// invoking a function, the invocation is taken by another worker.
+//
+
+type (
+	// asyncInvoker enqueues and invokes async invocations, and keeps
+	// their results.
+	asyncInvoker struct {
+		logger *zap.Logger
+		queue  asyncQueue
+		config asyncConfig
+		// lookup returns the HTTP trigger of a namespace by name.
+		lookup func(namespace string, name string) *fv1.HTTPTrigger
+		// handler is the router replaying invocations.
+		handler http.Handler
+
+		lock    sync.Mutex
+		results map[string]*asyncResult
+	}
+
+	asyncConfig struct {
+		// maxRetries is how many times failed invocations are retried.
+		maxRetries int
+		// workers is how many invocations a router replica invokes at
+		// the same time.
+		workers int
+		// resultTTL is how long results are kept.
+		resultTTL time.Duration
+		// retryDelay is the delay before the first retry, doubled for
+		// each retry after it up to maxAsyncRetryDelay.
+		retryDelay time.Duration
+	}
+
+	// asyncInvocation is a request enqueued for a trigger.
+	asyncInvocation struct {
+		ID         string      `json:"id"`
+		Namespace  string      `json:"namespace"`
+		Trigger    string      `json:"trigger"`
+		Host       string      `json:"host"`
+		RawQuery   string      `json:"rawQuery,omitempty"`
+		RemoteAddr string      `json:"remoteAddr,omitempty"`
+		Header     http.Header `json:"header"`
+		Body       []byte      `json:"body,omitempty"`
+		// Attempt is the number of the next attempt, from 1, and
+		// NotBefore when it's due.
+		Attempt    int       `json:"attempt"`
+		NotBefore  time.Time `json:"notBefore,omitempty"`
+		EnqueuedAt time.Time `json:"enqueuedAt"`
+	}
+
+	asyncStatus string
+
+	// asyncResult is the status of an invocation, with the response of
+	// the function once done.
+	asyncResult struct {
+		ID       string      `json:"id"`
+		Status   asyncStatus `json:"status"`
+		Attempts int         `json:"attempts"`
+		Error    string      `json:"error,omitempty"`
+		// StatusCode, Header and Body are the response of the last
+		// attempt, if any.
+		StatusCode int         `json:"statusCode,omitempty"`
+		Header     http.Header `json:"header,omitempty"`
+		Body       []byte      `json:"body,omitempty"`
+		UpdatedAt  time.Time   `json:"updatedAt"`
+	}
+
+	// asyncResponseRecorder keeps the response of a replayed invocation,
+	// up to maxAsyncBodyBytes of its body.
+	asyncResponseRecorder struct {
+		header http.Header
+		status int
+		body   bytes.Buffer
+	}
+)
+
+const (
+	asyncStatusQueued    asyncStatus = "queued"
+	asyncStatusSucceeded asyncStatus = "succeeded"
+	asyncStatusFailed    asyncStatus = "failed"
+)
+
+const (
+	// maxAsyncBodyBytes limits the bodies of requests and responses,
+	// which are stored in messages of the queue.
+	maxAsyncBodyBytes = 512 << 10
+
+	// asyncInvocationTimeout limits how long an attempt takes.
+	asyncInvocationTimeout = 5 * time.Minute
+
+	maxAsyncRetryDelay = time.Minute
+
+	asyncResultPruneInterval = time.Minute
+
+	headerAsyncID      = "X-Fission-Async-Id"
+	headerAsyncAttempt = "X-Fission-Async-Attempt"
+	headerAsyncStatus  = "X-Fission-Async-Status"
+)
+
+var defaultAsyncConfig = asyncConfig{
+	maxRetries: 3,
+	workers:    10,
+	resultTTL:  24 * time.Hour,
+	retryDelay: time.Second,
+}
+
+// parseAsyncConfig returns the config of async invocations, with the
+// defaults for the settings that are empty. It returns the defaults with
+// an error if any setting is invalid.
+func parseAsyncConfig(maxRetries string, workers string, resultTTL string) (asyncConfig, error) {
+	config := defaultAsyncConfig
+	var err error
+	if len(maxRetries) > 0 {
+		config.maxRetries, err = strconv.Atoi(maxRetries)
+		if err != nil || config.maxRetries < 0 {
+			return defaultAsyncConfig, errors.Errorf("invalid max retries of async invocations %q", maxRetries)
+		}
+	}
+	if len(workers) > 0 {
+		config.workers, err = strconv.Atoi(workers)
+		if err != nil || config.workers <= 0 {
+			return defaultAsyncConfig, errors.Errorf("invalid number of async invocation workers %q", workers)
+		}
+	}
+	if len(resultTTL) > 0 {
+		config.resultTTL, err = time.ParseDuration(resultTTL)
+		if err != nil || config.resultTTL <= 0 {
+			return defaultAsyncConfig, errors.Errorf("invalid TTL of async invocation results %q", resultTTL)
+		}
+	}
+	return config, nil
+}
+
+func makeAsyncInvoker(logger *zap.Logger, queue asyncQueue, lookup func(string, string) *fv1.HTTPTrigger, config asyncConfig) *asyncInvoker {
+	return &asyncInvoker{
+		logger:  logger,
+		queue:   queue,
+		config:  config,
+		lookup:  lookup,
+		results: make(map[string]*asyncResult),
+	}
+}
+
+// lookupTrigger returns the HTTP trigger of a namespace by name, or nil if
+// there's none.
+func (ts *HTTPTriggerSet) lookupTrigger(namespace string, name string) *fv1.HTTPTrigger {
+	obj, exists, err := ts.triggerStore.GetByKey(namespace + "/" + name)
+	if err != nil || !exists {
+		return nil
+	}
+	return obj.(*fv1.HTTPTrigger)
+}
+
+// run invokes the invocations enqueued by replaying them through handler,
+// and keeps the results published, until ctx is done.
+func (ai *asyncInvoker) run(ctx context.Context, handler http.Handler) {
+	ai.handler = handler
+
+	results, err := ai.queue.watchResults(ai.config.resultTTL, ai.recordResult)
+	if err != nil {
+		ai.logger.Error("error watching async invocation results", zap.Error(err))
+	} else {
+		defer results.Close()
+	}
+
+	consumer, err := ai.queue.consume(ai.config.workers, func(data []byte) bool {
+		return ai.handle(ctx, data)
+	})
+	if err != nil {
+		ai.logger.Error("error consuming async invocations, they aren't invoked by this router", zap.Error(err))
+	} else {
+		defer consumer.Close()
+	}
+
+	ticker := time.NewTicker(asyncResultPruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ai.pruneResults()
+		}
+	}
+}
+
+// enqueueHandler enqueues an invocation of the trigger of the request.
+func (ai *asyncInvoker) enqueueHandler(w http.ResponseWriter, r *http.Request) {
+	if ai == nil {
+		http.Error(w, "async invocations are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	if len(namespace) == 0 {
+		namespace = metav1.NamespaceDefault
+	}
+	trigger := ai.lookup(namespace, vars["trigger"])
+	if trigger == nil {
+		http.Error(w, "trigger not found", http.StatusNotFound)
+		return
+	}
+	// invocations are replayed at the URL of the trigger
+	if strings.Contains(trigger.Spec.RelativeURL, "{") {
+		http.Error(w, "triggers with path variables can't be invoked asynchronously", http.StatusBadRequest)
+		return
+	}
+	host := r.Host
+	if len(trigger.Spec.Host) > 0 {
+		if !strings.HasPrefix(trigger.Spec.Host, "*.") {
+			host = trigger.Spec.Host
+		} else if !matchHost(strings.ToLower(trigger.Spec.Host), requestHost(r)) {
+			http.Error(w, "request host doesn't match trigger host", http.StatusBadRequest)
+			return
+		}
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxAsyncBodyBytes+1))
+	if err != nil {
+		http.Error(w, "error reading request body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxAsyncBodyBytes {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	now := time.Now()
+	inv := &asyncInvocation{
+		ID:         uuid.NewV4().String(),
+		Namespace:  namespace,
+		Trigger:    trigger.ObjectMeta.Name,
+		Host:       host,
+		RawQuery:   r.URL.RawQuery,
+		RemoteAddr: r.RemoteAddr,
+		Header:     copyHeader(r.Header),
+		Body:       body,
+		Attempt:    1,
+		EnqueuedAt: now,
+	}
+	data, err := json.Marshal(inv)
+	if err == nil {
+		err = ai.queue.enqueue(data)
+	}
+	if err != nil {
+		ai.logger.Error("error enqueuing async invocation", zap.Error(err),
+			zap.String("trigger", trigger.ObjectMeta.Name), zap.String("namespace", namespace))
+		http.Error(w, "error enqueuing invocation", http.StatusServiceUnavailable)
+		return
+	}
+
+	result := &asyncResult{ID: inv.ID, Status: asyncStatusQueued, UpdatedAt: now}
+	ai.publishResult(result)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/fission-async/results/"+inv.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(result)
+}
+
+// resultHandler serves the result of an invocation: its status while
+// queued, the response of the function once done.
+func (ai *asyncInvoker) resultHandler(w http.ResponseWriter, r *http.Request) {
+	if ai == nil {
+		http.Error(w, "async invocations are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	ai.lock.Lock()
+	result, ok := ai.results[mux.Vars(r)["id"]]
+	ai.lock.Unlock()
+	if !ok {
+		http.Error(w, "invocation not found", http.StatusNotFound)
+		return
+	}
+
+	if result.Status == asyncStatusQueued || result.StatusCode == 0 {
+		status := http.StatusAccepted
+		if result.Status == asyncStatusFailed {
+			status = http.StatusBadGateway
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(headerAsyncStatus, string(result.Status))
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(&asyncResult{
+			ID:        result.ID,
+			Status:    result.Status,
+			Attempts:  result.Attempts,
+			Error:     result.Error,
+			UpdatedAt: result.UpdatedAt,
+		})
+		return
+	}
+
+	for k, v := range result.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set(headerAsyncStatus, string(result.Status))
+	w.WriteHeader(result.StatusCode)
+	w.Write(result.Body)
+}
+
+// handle invokes an invocation taken from the queue. It returns false if
+// the invocation is to be delivered again.
+func (ai *asyncInvoker) handle(ctx context.Context, data []byte) bool {
+	var inv asyncInvocation
+	err := json.Unmarshal(data, &inv)
+	if err != nil {
+		ai.logger.Error("skipping malformed async invocation", zap.Error(err))
+		return true
+	}
+	logger := ai.logger.With(zap.String("id", inv.ID),
+		zap.String("trigger", inv.Trigger), zap.String("namespace", inv.Namespace))
+
+	// retries wait for their delay
+	if delay := time.Until(inv.NotBefore); delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	result := &asyncResult{ID: inv.ID, Attempts: inv.Attempt}
+	trigger := ai.lookup(inv.Namespace, inv.Trigger)
+	if trigger == nil {
+		result.Status = asyncStatusFailed
+		result.Error = "trigger not found"
+		ai.publishResult(result)
+		return true
+	}
+
+	rec, err := ai.invoke(ctx, trigger, &inv)
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.StatusCode = rec.status
+		result.Header = rec.header
+		result.Body = rec.body.Bytes()
+	}
+
+	switch {
+	case err == nil && rec.status < 400:
+		result.Status = asyncStatusSucceeded
+	case err == nil && rec.status < 500 && rec.status != http.StatusTooManyRequests:
+		// the request itself is wrong, retrying won't help
+		result.Status = asyncStatusFailed
+	case inv.Attempt > ai.config.maxRetries:
+		logger.Info("async invocation failed", zap.Int("attempts", inv.Attempt), zap.Int("status", result.StatusCode))
+		result.Status = asyncStatusFailed
+	default:
+		// the retry is enqueued for any worker to take once due
+		retry := inv
+		retry.Attempt++
+		retry.NotBefore = time.Now().Add(ai.retryDelay(inv.Attempt))
+		data, err := json.Marshal(&retry)
+		if err == nil {
+			err = ai.queue.enqueue(data)
+		}
+		if err != nil {
+			logger.Error("error enqueuing retry of async invocation", zap.Error(err))
+			return false
+		}
+		result.Status = asyncStatusQueued
+	}
+	ai.publishResult(result)
+	return true
+}
+
+// invoke replays an invocation through the router at the URL of its
+// trigger.
+func (ai *asyncInvoker) invoke(ctx context.Context, trigger *fv1.HTTPTrigger, inv *asyncInvocation) (*asyncResponseRecorder, error) {
+	url := trigger.Spec.RelativeURL
+	if len(inv.RawQuery) > 0 {
+		url += "?" + inv.RawQuery
+	}
+	req, err := http.NewRequest(trigger.Spec.Method, url, bytes.NewReader(inv.Body))
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating request")
+	}
+	ctx, cancel := context.WithTimeout(ctx, asyncInvocationTimeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	req.Header = copyHeader(inv.Header)
+	req.Header.Set(headerAsyncID, inv.ID)
+	req.Header.Set(headerAsyncAttempt, strconv.Itoa(inv.Attempt))
+	req.Host = inv.Host
+	req.RemoteAddr = inv.RemoteAddr
+
+	rec := &asyncResponseRecorder{header: make(http.Header)}
+	ai.handler.ServeHTTP(rec, req)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec, nil
+}
+
+func (ai *asyncInvoker) retryDelay(attempt int) time.Duration {
+	delay := ai.config.retryDelay
+	for i := 1; i < attempt && delay < maxAsyncRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxAsyncRetryDelay {
+		delay = maxAsyncRetryDelay
+	}
+	return delay
+}
+
+// publishResult keeps the result of an invocation, and publishes it for
+// the other router replicas.
+func (ai *asyncInvoker) publishResult(result *asyncResult) {
+	result.UpdatedAt = time.Now()
+	ai.storeResult(result)
+
+	data, err := json.Marshal(result)
+	if err == nil {
+		err = ai.queue.publishResult(data)
+	}
+	if err != nil {
+		ai.logger.Error("error publishing async invocation result", zap.Error(err), zap.String("id", result.ID))
+	}
+}
+
+// recordResult keeps a result published.
+func (ai *asyncInvoker) recordResult(data []byte) {
+	var result asyncResult
+	err := json.Unmarshal(data, &result)
+	if err != nil {
+		ai.logger.Error("skipping malformed async invocation result", zap.Error(err))
+		return
+	}
+	if time.Since(result.UpdatedAt) > ai.config.resultTTL {
+		return
+	}
+	ai.storeResult(&result)
+}
+
+func (ai *asyncInvoker) storeResult(result *asyncResult) {
+	ai.lock.Lock()
+	defer ai.lock.Unlock()
+	// results of invocations delivered again don't replace the final
+	// result of the first delivery
+	if prev, ok := ai.results[result.ID]; ok && prev.Status != asyncStatusQueued && result.Status == asyncStatusQueued {
+		return
+	}
+	ai.results[result.ID] = result
+}
+
+// pruneResults drops the results older than their TTL.
+func (ai *asyncInvoker) pruneResults() {
+	ai.lock.Lock()
+	defer ai.lock.Unlock()
+	for id, result := range ai.results {
+		if time.Since(result.UpdatedAt) > ai.config.resultTTL {
+			delete(ai.results, id)
+		}
+	}
+}
+
+func copyHeader(header http.Header) http.Header {
+	c := make(http.Header, len(header))
+	for k, v := range header {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
+
+func (rec *asyncResponseRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *asyncResponseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+}
+
+func (rec *asyncResponseRecorder) Write(b []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	if n := maxAsyncBodyBytes - rec.body.Len(); n > 0 {
+		if len(b) < n {
+			n = len(b)
+		}
+		rec.body.Write(b[:n])
+	}
+	return len(b), nil
+}
+
+func (rec *asyncResponseRecorder) Flush() {}
@@ -0,0 +1,271 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// fakeAsyncQueue is an asyncQueue in memory.
+type fakeAsyncQueue struct {
+	invocations chan []byte
+
+	lock     sync.Mutex
+	results  [][]byte
+	watchers []func([]byte)
+}
+
+func newFakeAsyncQueue() *fakeAsyncQueue {
+	return &fakeAsyncQueue{invocations: make(chan []byte, 16)}
+}
+
+func (q *fakeAsyncQueue) enqueue(data []byte) error {
+	q.invocations <- data
+	return nil
+}
+
+func (q *fakeAsyncQueue) consume(n int, handle func(data []byte) bool) (io.Closer, error) {
+	done := make(chan struct{})
+	for i := 0; i < n; i++ {
+		go func() {
+			for {
+				select {
+				case data := <-q.invocations:
+					if !handle(data) {
+						q.invocations <- data
+					}
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+	return closerFunc(func() error {
+		close(done)
+		return nil
+	}), nil
+}
+
+func (q *fakeAsyncQueue) publishResult(data []byte) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.results = append(q.results, data)
+	for _, watch := range q.watchers {
+		watch(data)
+	}
+	return nil
+}
+
+func (q *fakeAsyncQueue) watchResults(since time.Duration, handle func(data []byte)) (io.Closer, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for _, data := range q.results {
+		handle(data)
+	}
+	q.watchers = append(q.watchers, handle)
+	return closerFunc(func() error { return nil }), nil
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+func makeTestAsyncInvoker(queue asyncQueue, triggers ...*fv1.HTTPTrigger) *asyncInvoker {
+	config := defaultAsyncConfig
+	config.maxRetries = 2
+	config.retryDelay = time.Millisecond
+	return makeAsyncInvoker(zap.NewNop(), queue, func(namespace string, name string) *fv1.HTTPTrigger {
+		for _, t := range triggers {
+			if t.ObjectMeta.Namespace == namespace && t.ObjectMeta.Name == name {
+				return t
+			}
+		}
+		return nil
+	}, config)
+}
+
+func makeTestAsyncTrigger(name string, relativeURL string) *fv1.HTTPTrigger {
+	return &fv1.HTTPTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceDefault},
+		Spec:       fv1.HTTPTriggerSpec{RelativeURL: relativeURL, Method: "POST"},
+	}
+}
+
+func asyncRouter(ai *asyncInvoker) *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/fission-async/results/{id}", ai.resultHandler).Methods("GET")
+	r.HandleFunc("/fission-async/{namespace}/{trigger}", ai.enqueueHandler).Methods("POST")
+	r.HandleFunc("/fission-async/{trigger}", ai.enqueueHandler).Methods("POST")
+	return r
+}
+
+func getAsyncResult(t *testing.T, r http.Handler, id string) *httptest.ResponseRecorder {
+	var rec *httptest.ResponseRecorder
+	for i := 0; i < 100; i++ {
+		rec = httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest("GET", "/fission-async/results/"+id, nil))
+		if rec.Code != http.StatusAccepted {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return rec
+}
+
+func TestAsyncInvocation(t *testing.T) {
+	var calls int32
+	functions := mux.NewRouter()
+	functions.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, "name=x", r.URL.RawQuery)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.NotEmpty(t, r.Header.Get(headerAsyncID))
+		// the function fails once
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello " + string(body)))
+	}).Methods("POST")
+
+	queue := newFakeAsyncQueue()
+	ai := makeTestAsyncInvoker(queue, makeTestAsyncTrigger("hello", "/hello"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ai.run(ctx, functions)
+	r := asyncRouter(ai)
+
+	req := httptest.NewRequest("POST", "/fission-async/hello?name=x", strings.NewReader("world"))
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	queued := &asyncResult{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), queued))
+	assert.Equal(t, asyncStatusQueued, queued.Status)
+	assert.Equal(t, "/fission-async/results/"+queued.ID, rec.Header().Get("Location"))
+
+	rec = getAsyncResult(t, r, queued.ID)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "hello world", rec.Body.String())
+	assert.Equal(t, "text/plain", rec.Header().Get("Content-Type"))
+	assert.Equal(t, string(asyncStatusSucceeded), rec.Header().Get(headerAsyncStatus))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// results are served by the replicas watching them too
+	replica := makeTestAsyncInvoker(queue)
+	_, err := queue.watchResults(time.Hour, replica.recordResult)
+	assert.NoError(t, err)
+	rec = getAsyncResult(t, asyncRouter(replica), queued.ID)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestAsyncInvocationFailures(t *testing.T) {
+	var calls int32
+	functions := mux.NewRouter()
+	functions.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}).Methods("POST")
+	functions.HandleFunc("/invalid", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}).Methods("POST")
+
+	ai := makeTestAsyncInvoker(newFakeAsyncQueue(),
+		makeTestAsyncTrigger("broken", "/broken"),
+		makeTestAsyncTrigger("invalid", "/invalid"),
+		makeTestAsyncTrigger("users", "/users/{id}"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ai.run(ctx, functions)
+	r := asyncRouter(ai)
+
+	enqueue := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest("POST", path, nil))
+		return rec
+	}
+	result := func(rec *httptest.ResponseRecorder) *asyncResult {
+		result := &asyncResult{}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), result))
+		return result
+	}
+
+	assert.Equal(t, http.StatusNotFound, enqueue("/fission-async/missing").Code)
+	assert.Equal(t, http.StatusNotFound, enqueue("/fission-async/other/broken").Code)
+	assert.Equal(t, http.StatusBadRequest, enqueue("/fission-async/users").Code)
+
+	// failures are retried up to the max retries
+	rec := getAsyncResult(t, r, result(enqueue("/fission-async/default/broken")).ID)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, string(asyncStatusFailed), rec.Header().Get(headerAsyncStatus))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// but not invalid requests
+	rec = getAsyncResult(t, r, result(enqueue("/fission-async/invalid")).ID)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/fission-async/results/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// async invocations are unavailable unless enabled
+	rec = httptest.NewRecorder()
+	asyncRouter(nil).ServeHTTP(rec, httptest.NewRequest("POST", "/fission-async/broken", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestParseAsyncConfig(t *testing.T) {
+	config, err := parseAsyncConfig("", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, defaultAsyncConfig, config)
+
+	config, err = parseAsyncConfig("5", "2", "1h")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, config.maxRetries)
+	assert.Equal(t, 2, config.workers)
+	assert.Equal(t, time.Hour, config.resultTTL)
+
+	for _, c := range [][3]string{{"-1", "", ""}, {"", "0", ""}, {"", "", "1d"}} {
+		config, err = parseAsyncConfig(c[0], c[1], c[2])
+		assert.Error(t, err)
+		assert.Equal(t, defaultAsyncConfig, config)
+	}
+}
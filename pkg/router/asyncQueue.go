@@ -0,0 +1,158 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"time"
+
+	ns "github.com/nats-io/stan.go"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type (
+	// asyncQueue persists async invocations until a worker handled them,
+	// and the results of invocations for every router replica to serve.
+	asyncQueue interface {
+		// enqueue persists an invocation.
+		enqueue(data []byte) error
+
+		// consume calls handle with the invocations enqueued, from n
+		// workers. Invocations handle doesn't ack are delivered again.
+		consume(n int, handle func(data []byte) bool) (io.Closer, error)
+
+		// publishResult publishes the result of an invocation.
+		publishResult(data []byte) error
+
+		// watchResults calls handle with the results published since
+		// the given time ago, then with the ones published later.
+		watchResults(since time.Duration, handle func(data []byte)) (io.Closer, error)
+	}
+
+	// natsAsyncQueue is an asyncQueue on NATS streaming.
+	natsAsyncQueue struct {
+		logger *zap.Logger
+		conn   ns.Conn
+	}
+)
+
+const (
+	asyncInvocationChannel = "fission.async.invocations"
+	asyncResultChannel     = "fission.async.results"
+
+	// asyncQueueGroup is the durable queue group of the workers of all
+	// router replicas, each invocation is delivered to one of them.
+	asyncQueueGroup = "fission-router-async"
+
+	// asyncAckWait is how long an invocation is left to a worker before
+	// being delivered again, longer than any invocation with its retry
+	// delay.
+	asyncAckWait = asyncInvocationTimeout + maxAsyncRetryDelay + 30*time.Second
+)
+
+var invalidClientIDChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// makeNatsAsyncQueue connects to the NATS streaming cluster at url, as a
+// client named after the router pod.
+func makeNatsAsyncQueue(logger *zap.Logger, url string, clusterID string) (*natsAsyncQueue, error) {
+	if len(clusterID) == 0 {
+		return nil, errors.New("NATS streaming cluster ID is not set")
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting hostname")
+	}
+	clientID := "router-" + invalidClientIDChars.ReplaceAllString(hostname, "-")
+
+	q := &natsAsyncQueue{logger: logger.Named("nats")}
+	q.conn, err = ns.Connect(clusterID, clientID, ns.NatsURL(url),
+		ns.SetConnectionLostHandler(func(conn ns.Conn, reason error) {
+			q.logger.Error("connection to NATS streaming lost, async invocations fail until router restarts", zap.Error(reason))
+		}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "error connecting to NATS streaming")
+	}
+	return q, nil
+}
+
+func (q *natsAsyncQueue) enqueue(data []byte) error {
+	return q.conn.Publish(asyncInvocationChannel, data)
+}
+
+func (q *natsAsyncQueue) consume(n int, handle func(data []byte) bool) (io.Closer, error) {
+	msgs := make(chan *ns.Msg)
+	done := make(chan struct{})
+	for i := 0; i < n; i++ {
+		go func() {
+			for {
+				select {
+				case msg := <-msgs:
+					if !handle(msg.Data) {
+						continue
+					}
+					err := msg.Ack()
+					if err != nil {
+						q.logger.Error("error acking async invocation", zap.Error(err))
+					}
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	// messages are handed to the workers as they're free, and no more
+	// are delivered than there are workers
+	sub, err := q.conn.QueueSubscribe(asyncInvocationChannel, asyncQueueGroup, func(msg *ns.Msg) {
+		select {
+		case msgs <- msg:
+		case <-done:
+		}
+	}, ns.DurableName(asyncQueueGroup), ns.SetManualAckMode(), ns.AckWait(asyncAckWait), ns.MaxInflight(n))
+	if err != nil {
+		close(done)
+		return nil, err
+	}
+	return &natsConsumer{sub: sub, done: done}, nil
+}
+
+func (q *natsAsyncQueue) publishResult(data []byte) error {
+	return q.conn.Publish(asyncResultChannel, data)
+}
+
+func (q *natsAsyncQueue) watchResults(since time.Duration, handle func(data []byte)) (io.Closer, error) {
+	return q.conn.Subscribe(asyncResultChannel, func(msg *ns.Msg) {
+		handle(msg.Data)
+	}, ns.StartAtTimeDelta(since))
+}
+
+// natsConsumer stops the workers of a subscription once closed. The
+// durable subscription is kept for the invocations enqueued meanwhile,
+// and the ones not acked are delivered again.
+type natsConsumer struct {
+	sub  ns.Subscription
+	done chan struct{}
+}
+
+func (c *natsConsumer) Close() error {
+	close(c.done)
+	return c.sub.Close()
+}
@@ -0,0 +1,350 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	cloudEventsSpecVersion = "1.0"
+	// cloudEventsHeaderPrefix is the prefix of the headers of the
+	// attributes of events in binary mode.
+	cloudEventsHeaderPrefix = "Ce-"
+	// cloudEventsContentType is the content type of events in structured
+	// mode.
+	cloudEventsContentType = "application/cloudevents+json"
+	// cloudEventsTypePrefix is the prefix of the type of the events of
+	// Fission triggers, followed by the trigger type.
+	cloudEventsTypePrefix = "io.fission."
+)
+
+// cloudEvent is a CloudEvents 1.0 event: its context attributes, with the
+// content type of its data among them, and its data.
+type cloudEvent struct {
+	attributes map[string]string
+	data       []byte
+}
+
+// parseCloudEventsMode parses the CloudEvents mode router invokes functions
+// in, none if empty.
+func parseCloudEventsMode(mode string) (fv1.CloudEventsMode, error) {
+	if len(mode) == 0 {
+		return fv1.CloudEventsModeNone, nil
+	}
+	m := fv1.CloudEventsMode(strings.ToLower(strings.TrimSpace(mode)))
+	if err := m.Validate(); err != nil {
+		return fv1.CloudEventsModeNone, err
+	}
+	return m, nil
+}
+
+// cloudEventsMode returns the CloudEvents mode to invoke the function in:
+// the one of the HTTP trigger, or the one event sources ask for with the
+// X-Fission-CloudEvents-Mode header on internal routes, or else the one of
+// router.
+func (fh functionHandler) cloudEventsMode(req *http.Request) fv1.CloudEventsMode {
+	requested := fv1.CloudEventsMode(req.Header.Get(fv1.HeaderCloudEventsMode))
+	req.Header.Del(fv1.HeaderCloudEventsMode)
+
+	if fh.httpTrigger != nil {
+		requested = fh.httpTrigger.Spec.CloudEvents
+	}
+	if len(requested) > 0 && requested.Validate() == nil {
+		return requested
+	}
+	if len(fh.cloudEvents) > 0 {
+		return fh.cloudEvents
+	}
+	return fv1.CloudEventsModeNone
+}
+
+// setCloudEvent converts a request to a CloudEvent in the CloudEvents mode
+// of the function. Requests that are CloudEvents already, in either mode,
+// are converted to the mode of the function, and others are wrapped in an
+// event of the trigger they are made by.
+func (fh functionHandler) setCloudEvent(req *http.Request) error {
+	mode := fh.cloudEventsMode(req)
+	if mode == fv1.CloudEventsModeNone {
+		return nil
+	}
+
+	incoming := cloudEventsModeOf(req)
+	if incoming == mode {
+		return nil
+	}
+
+	var event *cloudEvent
+	var err error
+	switch incoming {
+	case fv1.CloudEventsModeBinary:
+		event, err = readBinaryCloudEvent(req)
+	case fv1.CloudEventsModeStructured:
+		event, err = readStructuredCloudEvent(req)
+	default:
+		event = fh.newCloudEvent(req)
+		if mode == fv1.CloudEventsModeStructured {
+			event.data, err = readBody(req)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if mode == fv1.CloudEventsModeBinary {
+		writeBinaryCloudEvent(req, event)
+		return nil
+	}
+	return writeStructuredCloudEvent(req, event)
+}
+
+// cloudEventsModeOf returns the CloudEvents mode of a request, empty if it
+// isn't a CloudEvent.
+func cloudEventsModeOf(req *http.Request) fv1.CloudEventsMode {
+	if len(req.Header.Get(cloudEventsHeaderPrefix+"Specversion")) > 0 {
+		return fv1.CloudEventsModeBinary
+	}
+	if mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type")); err == nil && mediaType == cloudEventsContentType {
+		return fv1.CloudEventsModeStructured
+	}
+	return ""
+}
+
+// newCloudEvent returns the event of the trigger a request is made by, with
+// the request body as its data. Its source is the trigger, or the function
+// for requests made by none.
+func (fh functionHandler) newCloudEvent(req *http.Request) *cloudEvent {
+	triggerType, namespace, name := "function", fh.function.ObjectMeta.Namespace, fh.function.ObjectMeta.Name
+	if labels := fh.getTriggerLabels(req); labels != nil {
+		triggerType, namespace, name = labels.triggerType, labels.namespace, labels.name
+	}
+
+	eventTime := time.Now().UTC().Format(time.RFC3339Nano)
+	if t, err := time.Parse(time.RFC3339Nano, req.Header.Get(fv1.HeaderEventTime)); err == nil {
+		eventTime = t.UTC().Format(time.RFC3339Nano)
+	}
+
+	event := &cloudEvent{
+		attributes: map[string]string{
+			"specversion": cloudEventsSpecVersion,
+			"id":          uuid.NewV4().String(),
+			"source":      fmt.Sprintf("/fission/%v/%v/%v", triggerType, namespace, name),
+			"type":        cloudEventsTypePrefix + triggerType,
+			"time":        eventTime,
+		},
+	}
+	if topic := req.Header.Get("X-Fission-MQTrigger-Topic"); len(topic) > 0 {
+		event.attributes["subject"] = topic
+	} else if key := req.Header.Get("X-Fission-Bucket-Key"); len(key) > 0 {
+		event.attributes["subject"] = key
+	} else if table := req.Header.Get("X-Fission-Database-Table"); len(table) > 0 {
+		event.attributes["subject"] = table
+	} else if step := req.Header.Get("X-Fission-Workflow-Step"); len(step) > 0 {
+		event.attributes["subject"] = step
+	} else if triggerType == fv1.TriggerTypeHTTP {
+		event.attributes["subject"] = req.URL.Path
+	}
+	if contentType := req.Header.Get("Content-Type"); len(contentType) > 0 {
+		event.attributes["datacontenttype"] = contentType
+	}
+	return event
+}
+
+func readBinaryCloudEvent(req *http.Request) (*cloudEvent, error) {
+	event := &cloudEvent{attributes: make(map[string]string)}
+	for k, v := range req.Header {
+		if !strings.HasPrefix(k, cloudEventsHeaderPrefix) || len(v) == 0 {
+			continue
+		}
+		value, err := url.PathUnescape(v[0])
+		if err != nil {
+			value = v[0]
+		}
+		event.attributes[strings.ToLower(strings.TrimPrefix(k, cloudEventsHeaderPrefix))] = value
+	}
+	if contentType := req.Header.Get("Content-Type"); len(contentType) > 0 {
+		event.attributes["datacontenttype"] = contentType
+	}
+
+	var err error
+	event.data, err = readBody(req)
+	return event, err
+}
+
+func readStructuredCloudEvent(req *http.Request) (*cloudEvent, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(body, &fields)
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed CloudEvent")
+	}
+
+	event := &cloudEvent{attributes: make(map[string]string)}
+	for k, v := range fields {
+		if k == "data" || k == "data_base64" {
+			continue
+		}
+		// attributes are strings, or numbers or booleans of extensions
+		var s string
+		if json.Unmarshal(v, &s) != nil {
+			s = string(v)
+		}
+		event.attributes[k] = s
+	}
+
+	if data, ok := fields["data_base64"]; ok {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, errors.Wrap(err, "malformed data_base64 of CloudEvent")
+		}
+		event.data, err = base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, errors.Wrap(err, "malformed data_base64 of CloudEvent")
+		}
+	} else if data, ok := fields["data"]; ok {
+		// data is JSON, or a string of data of another content type
+		var s string
+		if !isJSONContentType(event.attributes["datacontenttype"]) && json.Unmarshal(data, &s) == nil {
+			event.data = []byte(s)
+		} else {
+			event.data = data
+			if len(event.attributes["datacontenttype"]) == 0 {
+				event.attributes["datacontenttype"] = "application/json"
+			}
+		}
+	}
+	if event.attributes["specversion"] != cloudEventsSpecVersion {
+		return nil, errors.Errorf("unsupported CloudEvents spec version %q", event.attributes["specversion"])
+	}
+	return event, nil
+}
+
+// writeBinaryCloudEvent sets an event on a request in binary mode, with its
+// attributes in headers and its data as the body.
+func writeBinaryCloudEvent(req *http.Request, event *cloudEvent) {
+	for k := range req.Header {
+		if strings.HasPrefix(k, cloudEventsHeaderPrefix) {
+			req.Header.Del(k)
+		}
+	}
+	req.Header.Del("Content-Type")
+	for k, v := range event.attributes {
+		if k == "datacontenttype" {
+			req.Header.Set("Content-Type", v)
+			continue
+		}
+		req.Header.Set(cloudEventsHeaderPrefix+k, escapeHeaderValue(v))
+	}
+	if event.data != nil {
+		setBody(req, event.data)
+	}
+}
+
+// writeStructuredCloudEvent sets an event on a request in structured mode,
+// as a whole in the JSON body. Data is passed as JSON if it is, as a string
+// if text, or else base64 encoded.
+func writeStructuredCloudEvent(req *http.Request, event *cloudEvent) error {
+	fields := make(map[string]interface{}, len(event.attributes)+1)
+	for k, v := range event.attributes {
+		fields[k] = v
+	}
+	switch {
+	case len(event.data) == 0:
+	case json.Valid(event.data) && isJSONContentType(event.attributes["datacontenttype"]):
+		fields["data"] = json.RawMessage(event.data)
+	case utf8.Valid(event.data):
+		fields["data"] = string(event.data)
+	default:
+		fields["data_base64"] = base64.StdEncoding.EncodeToString(event.data)
+	}
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return errors.Wrap(err, "error encoding CloudEvent")
+	}
+
+	for k := range req.Header {
+		if strings.HasPrefix(k, cloudEventsHeaderPrefix) {
+			req.Header.Del(k)
+		}
+	}
+	req.Header.Set("Content-Type", cloudEventsContentType+"; charset=UTF-8")
+	setBody(req, body)
+	return nil
+}
+
+// isJSONContentType returns whether data of a content type is JSON, which
+// it is if unset.
+func isJSONContentType(contentType string) bool {
+	if len(contentType) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// escapeHeaderValue percent-encodes the characters of an attribute the
+// CloudEvents HTTP binding has encoded in headers.
+func escapeHeaderValue(v string) string {
+	var sb strings.Builder
+	for _, b := range []byte(v) {
+		if b <= ' ' || b > '~' || b == '"' || b == '%' {
+			fmt.Fprintf(&sb, "%%%02X", b)
+			continue
+		}
+		sb.WriteByte(b)
+	}
+	return sb.String()
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return []byte{}, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading request body")
+	}
+	return body, nil
+}
+
+func setBody(req *http.Request, body []byte) {
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.Header.Del("Content-Length")
+}
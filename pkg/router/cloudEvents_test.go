@@ -0,0 +1,158 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestParseCloudEventsMode(t *testing.T) {
+	mode, err := parseCloudEventsMode("")
+	require.NoError(t, err)
+	assert.Equal(t, fv1.CloudEventsModeNone, mode)
+
+	mode, err = parseCloudEventsMode(" Binary ")
+	require.NoError(t, err)
+	assert.Equal(t, fv1.CloudEventsModeBinary, mode)
+
+	_, err = parseCloudEventsMode("batch")
+	assert.Error(t, err)
+}
+
+func TestSetCloudEvent(t *testing.T) {
+	fn := &fv1.Function{ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"}}
+
+	t.Run("none", func(t *testing.T) {
+		fh := functionHandler{function: fn}
+		req := httptest.NewRequest("POST", "/fission-function/hello", strings.NewReader(`{"id":42}`))
+		req.Header.Set("Content-Type", "application/json")
+		require.NoError(t, fh.setCloudEvent(req))
+		assert.Empty(t, req.Header.Get("Ce-Specversion"))
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	})
+
+	t.Run("message queue trigger in binary mode", func(t *testing.T) {
+		fh := functionHandler{function: fn, cloudEvents: fv1.CloudEventsModeStructured}
+		req := httptest.NewRequest("POST", "/fission-function/hello", strings.NewReader(`{"id":42}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(fv1.HeaderTriggerType, fv1.TriggerTypeMessageQueue)
+		req.Header.Set(fv1.HeaderTriggerName, "orders")
+		req.Header.Set(fv1.HeaderEventTime, "2021-06-01T10:00:00Z")
+		req.Header.Set(fv1.HeaderCloudEventsMode, string(fv1.CloudEventsModeBinary))
+		req.Header.Set("X-Fission-MQTrigger-Topic", "orders")
+		require.NoError(t, fh.setCloudEvent(req))
+
+		assert.Empty(t, req.Header.Get(fv1.HeaderCloudEventsMode))
+		assert.Equal(t, "1.0", req.Header.Get("Ce-Specversion"))
+		assert.NotEmpty(t, req.Header.Get("Ce-Id"))
+		assert.Equal(t, "io.fission.messagequeue", req.Header.Get("Ce-Type"))
+		assert.Equal(t, "/fission/messagequeue/default/orders", req.Header.Get("Ce-Source"))
+		assert.Equal(t, "orders", req.Header.Get("Ce-Subject"))
+		assert.Equal(t, "2021-06-01T10:00:00Z", req.Header.Get("Ce-Time"))
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		body, _ := ioutil.ReadAll(req.Body)
+		assert.Equal(t, `{"id":42}`, string(body))
+	})
+
+	t.Run("function in structured mode", func(t *testing.T) {
+		fh := functionHandler{function: fn, cloudEvents: fv1.CloudEventsModeStructured}
+		req := httptest.NewRequest("POST", "/fission-function/hello", strings.NewReader(`{"id":42}`))
+		req.Header.Set("Content-Type", "application/json")
+		require.NoError(t, fh.setCloudEvent(req))
+
+		assert.Equal(t, "application/cloudevents+json; charset=UTF-8", req.Header.Get("Content-Type"))
+		var event map[string]interface{}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&event))
+		assert.Equal(t, "1.0", event["specversion"])
+		assert.Equal(t, "io.fission.function", event["type"])
+		assert.Equal(t, "/fission/function/default/hello", event["source"])
+		assert.Equal(t, "application/json", event["datacontenttype"])
+		assert.Equal(t, map[string]interface{}{"id": float64(42)}, event["data"])
+	})
+
+	t.Run("structured event to binary mode of trigger", func(t *testing.T) {
+		trigger := &fv1.HTTPTrigger{
+			ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "default"},
+			Spec:       fv1.HTTPTriggerSpec{CloudEvents: fv1.CloudEventsModeBinary},
+		}
+		fh := functionHandler{function: fn, httpTrigger: trigger, cloudEvents: fv1.CloudEventsModeStructured}
+		req := httptest.NewRequest("POST", "/orders", strings.NewReader(`{
+			"specversion": "1.0",
+			"id": "1",
+			"source": "/shop",
+			"type": "order.created",
+			"datacontenttype": "text/plain",
+			"priority": 3,
+			"data": "new order"
+		}`))
+		req.Header.Set("Content-Type", "application/cloudevents+json")
+		// clients can't pick the mode of HTTP triggers
+		req.Header.Set(fv1.HeaderCloudEventsMode, string(fv1.CloudEventsModeStructured))
+		require.NoError(t, fh.setCloudEvent(req))
+
+		assert.Equal(t, "1", req.Header.Get("Ce-Id"))
+		assert.Equal(t, "/shop", req.Header.Get("Ce-Source"))
+		assert.Equal(t, "order.created", req.Header.Get("Ce-Type"))
+		assert.Equal(t, "3", req.Header.Get("Ce-Priority"))
+		assert.Equal(t, "text/plain", req.Header.Get("Content-Type"))
+		body, _ := ioutil.ReadAll(req.Body)
+		assert.Equal(t, "new order", string(body))
+		assert.EqualValues(t, len("new order"), req.ContentLength)
+	})
+
+	t.Run("binary event to structured mode", func(t *testing.T) {
+		fh := functionHandler{function: fn, cloudEvents: fv1.CloudEventsModeStructured}
+		req := httptest.NewRequest("POST", "/fission-function/hello", strings.NewReader("\xff\x00"))
+		req.Header.Set("Ce-Specversion", "1.0")
+		req.Header.Set("Ce-Id", "1")
+		req.Header.Set("Ce-Source", "/shop")
+		req.Header.Set("Ce-Type", "order.created")
+		req.Header.Set("Ce-Subject", "%22big%22 order")
+		req.Header.Set("Content-Type", "application/octet-stream")
+		require.NoError(t, fh.setCloudEvent(req))
+
+		assert.Empty(t, req.Header.Get("Ce-Id"))
+		var event map[string]interface{}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&event))
+		assert.Equal(t, "1", event["id"])
+		assert.Equal(t, `"big" order`, event["subject"])
+		assert.Equal(t, "application/octet-stream", event["datacontenttype"])
+		assert.Equal(t, "/wA=", event["data_base64"])
+		assert.NotContains(t, event, "data")
+	})
+
+	t.Run("malformed event", func(t *testing.T) {
+		fh := functionHandler{function: fn, cloudEvents: fv1.CloudEventsModeBinary}
+		req := httptest.NewRequest("POST", "/fission-function/hello", strings.NewReader(`{"specversion": "0.3"}`))
+		req.Header.Set("Content-Type", "application/cloudevents+json")
+		assert.Error(t, fh.setCloudEvent(req))
+	})
+}
+
+func TestEscapeHeaderValue(t *testing.T) {
+	assert.Equal(t, "order%20%22%C3%A9%22%25", escapeHeaderValue(`order "é"%`))
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"hash/fnv"
+	"net/http"
+
+	"github.com/dchest/uniuri"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// getExperimentKey returns the key users are assigned to experiment variants by.
+// For cookie assignment a new key is issued, and its cookie set on the response,
+// if the request has none. An empty key means the request doesn't take part.
+func getExperimentKey(assignment *fv1.ExperimentAssignment, w http.ResponseWriter, r *http.Request) string {
+	switch assignment.Type {
+	case fv1.ExperimentAssignmentTypeCookie:
+		name := assignment.Name
+		if len(name) == 0 {
+			name = fv1.DefaultExperimentCookie
+		}
+		if cookie, err := r.Cookie(name); err == nil && len(cookie.Value) > 0 {
+			return cookie.Value
+		}
+		key := uniuri.NewLen(24)
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Value:    key,
+			Path:     "/",
+			HttpOnly: true,
+		})
+		return key
+	case fv1.ExperimentAssignmentTypeHeader:
+		return r.Header.Get(assignment.Name)
+	}
+	return ""
+}
+
+// experimentBucket hashes the user key into [0, n). The experiment name and
+// purpose salt the hash, so that exposure and variant are independent of
+// each other and of other experiments the user takes part in.
+func experimentBucket(experiment string, purpose string, key string, n int) int {
+	h := fnv.New64a()
+	h.Write([]byte(experiment))
+	h.Write([]byte{0})
+	h.Write([]byte(purpose))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	return int(h.Sum64() % uint64(n))
+}
+
+// assignExperimentVariant returns the variant the user with the key is assigned to,
+// nil if the user is outside of the exposure of the experiment. The same key is
+// always assigned to the same variant as long as the variants don't change.
+func assignExperimentVariant(experiment *fv1.Experiment, name string, key string) *fv1.ExperimentVariant {
+	exposure := 100
+	if experiment.Exposure != nil {
+		exposure = *experiment.Exposure
+	}
+	if experimentBucket(name, "exposure", key, 100) >= exposure {
+		return nil
+	}
+
+	totalWeight := 0
+	for _, variant := range experiment.Variants {
+		totalWeight += variantWeight(&variant)
+	}
+	if totalWeight <= 0 {
+		return nil
+	}
+
+	bucket := experimentBucket(name, "variant", key, totalWeight)
+	for i := range experiment.Variants {
+		bucket -= variantWeight(&experiment.Variants[i])
+		if bucket < 0 {
+			return &experiment.Variants[i]
+		}
+	}
+	return nil
+}
+
+func variantWeight(variant *fv1.ExperimentVariant) int {
+	if variant.Weight == nil {
+		return 1
+	}
+	return *variant.Weight
+}
+
+// experimentName returns the name of the experiment on the trigger.
+func experimentName(trigger *fv1.HTTPTrigger) string {
+	if len(trigger.Spec.Experiment.Name) > 0 {
+		return trigger.Spec.Experiment.Name
+	}
+	return trigger.ObjectMeta.Name
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestAssignExperimentVariant(t *testing.T) {
+	experiment := &fv1.Experiment{
+		Assignment: fv1.ExperimentAssignment{Type: fv1.ExperimentAssignmentTypeHeader, Name: "X-User"},
+		Variants: []fv1.ExperimentVariant{
+			{Name: "control", Function: "fn-a"},
+			{Name: "treatment", Function: "fn-b", Weight: intPtr(3)},
+		},
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 4000; i++ {
+		key := fmt.Sprintf("user-%v", i)
+		variant := assignExperimentVariant(experiment, "checkout", key)
+		if variant == nil {
+			t.Fatalf("user %v not assigned with full exposure", key)
+		}
+		if again := assignExperimentVariant(experiment, "checkout", key); again.Name != variant.Name {
+			t.Fatalf("user %v assigned to %v and %v", key, variant.Name, again.Name)
+		}
+		counts[variant.Name]++
+	}
+	if counts["control"] < 800 || counts["control"] > 1200 {
+		t.Errorf("expected about 1000 users in control, got %v", counts["control"])
+	}
+
+	// users exposed at 20% must stay in their variant at 50%
+	experiment.Exposure = intPtr(20)
+	exposed := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("user-%v", i)
+		if variant := assignExperimentVariant(experiment, "checkout", key); variant != nil {
+			exposed[key] = variant.Name
+		}
+	}
+	if len(exposed) < 150 || len(exposed) > 250 {
+		t.Errorf("expected about 200 users exposed, got %v", len(exposed))
+	}
+	experiment.Exposure = intPtr(50)
+	for key, name := range exposed {
+		variant := assignExperimentVariant(experiment, "checkout", key)
+		if variant == nil || variant.Name != name {
+			t.Errorf("user %v left variant %v when raising exposure", key, name)
+		}
+	}
+
+	experiment.Exposure = intPtr(0)
+	if variant := assignExperimentVariant(experiment, "checkout", "user-1"); variant != nil {
+		t.Errorf("user assigned to %v with no exposure", variant.Name)
+	}
+}
+
+func TestGetExperimentKey(t *testing.T) {
+	assignment := &fv1.ExperimentAssignment{Type: fv1.ExperimentAssignmentTypeCookie}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	key := getExperimentKey(assignment, w, r)
+	if len(key) == 0 {
+		t.Fatal("expected a key to be issued")
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != fv1.DefaultExperimentCookie || cookies[0].Value != key {
+		t.Fatalf("expected cookie %v=%v, got %v", fv1.DefaultExperimentCookie, key, cookies)
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(cookies[0])
+	if got := getExperimentKey(assignment, w, r); got != key {
+		t.Errorf("expected key %v from cookie, got %v", key, got)
+	}
+	if len(w.Result().Cookies()) != 0 {
+		t.Error("expected no new cookie for a known user")
+	}
+
+	assignment = &fv1.ExperimentAssignment{Type: fv1.ExperimentAssignmentTypeHeader, Name: "X-User"}
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	if got := getExperimentKey(assignment, httptest.NewRecorder(), r); len(got) != 0 {
+		t.Errorf("expected no key without header, got %v", got)
+	}
+	r.Header.Set("X-User", "alice")
+	if got := getExperimentKey(assignment, httptest.NewRecorder(), r); got != "alice" {
+		t.Errorf("expected key alice, got %v", got)
+	}
+}
@@ -0,0 +1,466 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	// maxCachedFlagSets bounds the number of flag sets evaluated by remote
+	// flag services that are cached, one per source and key.
+	maxCachedFlagSets = 10000
+
+	// maxFlagResponseSize limits the size of flag service responses.
+	maxFlagResponseSize = 4 << 20
+
+	// featureFlagTokenKey is the key of the flag service credential in the source secret.
+	featureFlagTokenKey = "token"
+)
+
+type (
+	// featureFlagEvaluator evaluates the feature flags of requests to HTTP
+	// triggers with a flag source. It's shared by all function handlers so
+	// that cached flags survive router rebuilds.
+	featureFlagEvaluator struct {
+		logger     *zap.Logger
+		kubeClient *kubernetes.Clientset
+		httpClient *http.Client
+		lock       sync.Mutex
+
+		// configMaps caches parsed flag ConfigMaps, which are evaluated
+		// locally for every key.
+		configMaps map[string]*cachedFlagConfigMap
+		// tokens caches the credentials of remote flag services.
+		tokens map[string]*cachedFlagToken
+		// flagSets caches the flags evaluated by remote flag services.
+		flagSets map[string]*cachedFlagSet
+	}
+
+	cachedFlagConfigMap struct {
+		flags    map[string]*configMapFlag
+		loadedAt time.Time
+	}
+
+	cachedFlagToken struct {
+		token    string
+		loadedAt time.Time
+	}
+
+	cachedFlagSet struct {
+		flags    map[string]string
+		loadedAt time.Time
+	}
+
+	// configMapFlag is a flag read from a ConfigMap. Keys listed in Targets
+	// get the value of their target, the others are spread over the variants
+	// of Rollout by percentage and get Value if they fall in none of them.
+	configMapFlag struct {
+		Value   interface{}            `json:"value"`
+		Targets map[string]interface{} `json:"targets,omitempty"`
+		Rollout map[string]int         `json:"rollout,omitempty"`
+	}
+
+	// launchDarklyFlag is a flag evaluated by the LaunchDarkly client-side SDK API.
+	launchDarklyFlag struct {
+		Value interface{} `json:"value"`
+	}
+
+	// unleashFlags is the response of the Unleash frontend API,
+	// which only lists the toggles enabled for the context.
+	unleashFlags struct {
+		Toggles []struct {
+			Name    string `json:"name"`
+			Enabled bool   `json:"enabled"`
+			Variant struct {
+				Name    string `json:"name"`
+				Enabled bool   `json:"enabled"`
+			} `json:"variant"`
+		} `json:"toggles"`
+	}
+)
+
+func makeFeatureFlagEvaluator(logger *zap.Logger, kubeClient *kubernetes.Clientset) *featureFlagEvaluator {
+	return &featureFlagEvaluator{
+		logger:     logger.Named("feature_flags"),
+		kubeClient: kubeClient,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		configMaps: make(map[string]*cachedFlagConfigMap),
+		tokens:     make(map[string]*cachedFlagToken),
+		flagSets:   make(map[string]*cachedFlagSet),
+	}
+}
+
+// evaluate returns the flags of the trigger's source for the user or tenant of the request.
+func (e *featureFlagEvaluator) evaluate(trigger *fv1.HTTPTrigger, request *http.Request) (map[string]string, error) {
+	source := trigger.Spec.FeatureFlags
+	namespace := trigger.ObjectMeta.Namespace
+	key := request.Header.Get(getFeatureFlagKeyHeader(source))
+
+	var flags map[string]string
+	switch source.Type {
+	case fv1.FeatureFlagSourceTypeConfigMap:
+		configMapFlags, err := e.getConfigMapFlags(namespace, source)
+		if err != nil {
+			return nil, err
+		}
+		flags = make(map[string]string, len(configMapFlags))
+		for name, flag := range configMapFlags {
+			flags[name] = flag.evaluate(name, key)
+		}
+	case fv1.FeatureFlagSourceTypeLaunchDarkly, fv1.FeatureFlagSourceTypeUnleash:
+		var err error
+		flags, err = e.getRemoteFlags(namespace, source, key)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Errorf("unsupported feature flag source type %v", source.Type)
+	}
+
+	if len(source.Flags) == 0 {
+		return flags, nil
+	}
+	selected := make(map[string]string, len(source.Flags))
+	for _, name := range source.Flags {
+		if v, ok := flags[name]; ok {
+			selected[name] = v
+		} else if source.Type == fv1.FeatureFlagSourceTypeUnleash {
+			// unleash leaves out disabled toggles
+			selected[name] = "false"
+		}
+	}
+	return selected, nil
+}
+
+func (e *featureFlagEvaluator) getConfigMapFlags(namespace string, source *fv1.FeatureFlagSource) (map[string]*configMapFlag, error) {
+	cacheKey := fmt.Sprintf("%v/%v", namespace, source.ConfigMap)
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	cached, ok := e.configMaps[cacheKey]
+	if ok && time.Since(cached.loadedAt) < getFeatureFlagCacheTTL(source) {
+		return cached.flags, nil
+	}
+
+	cm, err := e.kubeClient.CoreV1().ConfigMaps(namespace).Get(source.ConfigMap, metav1.GetOptions{})
+	if err == nil {
+		var flags map[string]*configMapFlag
+		flags, err = parseConfigMapFlags(cm.Data)
+		if err == nil {
+			e.configMaps[cacheKey] = &cachedFlagConfigMap{flags: flags, loadedAt: time.Now()}
+			return flags, nil
+		}
+	}
+	err = errors.Wrapf(err, "error loading feature flags from configmap %v", source.ConfigMap)
+	if !ok {
+		return nil, err
+	}
+	// keep using the previous flags
+	e.logger.Error("error reloading feature flags", zap.Error(err), zap.String("configmap", cacheKey))
+	cached.loadedAt = time.Now()
+	return cached.flags, nil
+}
+
+func (e *featureFlagEvaluator) getRemoteFlags(namespace string, source *fv1.FeatureFlagSource, key string) (map[string]string, error) {
+	sourceKey := fmt.Sprintf("%v/%v/%v/%v", source.Type, source.URL, namespace, source.Secret)
+	cacheKey := sourceKey + "/" + key
+	ttl := getFeatureFlagCacheTTL(source)
+
+	e.lock.Lock()
+	cached, ok := e.flagSets[cacheKey]
+	e.lock.Unlock()
+	if ok && time.Since(cached.loadedAt) < ttl {
+		return cached.flags, nil
+	}
+
+	token, err := e.getToken(sourceKey, namespace, source)
+	if err != nil {
+		return nil, err
+	}
+
+	var flags map[string]string
+	if source.Type == fv1.FeatureFlagSourceTypeLaunchDarkly {
+		flags, err = e.fetchLaunchDarklyFlags(source, token, key)
+	} else {
+		flags, err = e.fetchUnleashFlags(source, token, key)
+	}
+	if err != nil {
+		if !ok {
+			return nil, err
+		}
+		// keep using the previous flags of the key
+		e.logger.Error("error reloading feature flags", zap.Error(err), zap.String("source", sourceKey))
+		return cached.flags, nil
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if len(e.flagSets) >= maxCachedFlagSets {
+		for k, v := range e.flagSets {
+			if time.Since(v.loadedAt) >= ttl {
+				delete(e.flagSets, k)
+			}
+		}
+		if len(e.flagSets) >= maxCachedFlagSets {
+			e.flagSets = make(map[string]*cachedFlagSet)
+		}
+	}
+	e.flagSets[cacheKey] = &cachedFlagSet{flags: flags, loadedAt: time.Now()}
+	return flags, nil
+}
+
+func (e *featureFlagEvaluator) getToken(sourceKey string, namespace string, source *fv1.FeatureFlagSource) (string, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	cached, ok := e.tokens[sourceKey]
+	if ok && time.Since(cached.loadedAt) < getFeatureFlagCacheTTL(source) {
+		return cached.token, nil
+	}
+
+	secret, err := e.kubeClient.CoreV1().Secrets(namespace).Get(source.Secret, metav1.GetOptions{})
+	if err != nil {
+		if ok {
+			e.logger.Error("error reloading feature flag service credential", zap.Error(err), zap.String("source", sourceKey))
+			cached.loadedAt = time.Now()
+			return cached.token, nil
+		}
+		return "", errors.Wrapf(err, "error getting secret %v", source.Secret)
+	}
+	token, ok := secret.Data[featureFlagTokenKey]
+	if !ok {
+		return "", errors.Errorf("secret %v has no key %v", source.Secret, featureFlagTokenKey)
+	}
+
+	e.tokens[sourceKey] = &cachedFlagToken{token: string(token), loadedAt: time.Now()}
+	return string(token), nil
+}
+
+func (e *featureFlagEvaluator) fetchLaunchDarklyFlags(source *fv1.FeatureFlagSource, clientSideID string, key string) (map[string]string, error) {
+	baseURL := source.URL
+	if len(baseURL) == 0 {
+		baseURL = fv1.DefaultLaunchDarklyURL
+	}
+	if len(key) == 0 {
+		// LaunchDarkly requires a user key
+		key = "anonymous"
+	}
+	user, err := json.Marshal(map[string]interface{}{"key": key, "anonymous": key == "anonymous"})
+	if err != nil {
+		return nil, err
+	}
+	u := fmt.Sprintf("%v/sdk/evalx/%v/users/%v", strings.TrimSuffix(baseURL, "/"),
+		url.PathEscape(clientSideID), base64.RawURLEncoding.EncodeToString(user))
+
+	ldFlags := make(map[string]launchDarklyFlag)
+	if err := e.getJSON(u, nil, &ldFlags); err != nil {
+		return nil, errors.Wrap(err, "error evaluating LaunchDarkly flags")
+	}
+
+	flags := make(map[string]string, len(ldFlags))
+	for name, flag := range ldFlags {
+		flags[name] = flagValueToString(flag.Value)
+	}
+	return flags, nil
+}
+
+func (e *featureFlagEvaluator) fetchUnleashFlags(source *fv1.FeatureFlagSource, token string, key string) (map[string]string, error) {
+	u := strings.TrimSuffix(source.URL, "/") + "/api/frontend"
+	if len(key) > 0 {
+		u += "?userId=" + url.QueryEscape(key)
+	}
+
+	var toggles unleashFlags
+	if err := e.getJSON(u, http.Header{"Authorization": []string{token}}, &toggles); err != nil {
+		return nil, errors.Wrap(err, "error evaluating Unleash flags")
+	}
+
+	flags := make(map[string]string, len(toggles.Toggles))
+	for _, toggle := range toggles.Toggles {
+		switch {
+		case !toggle.Enabled:
+			flags[toggle.Name] = "false"
+		case toggle.Variant.Enabled && toggle.Variant.Name != "disabled":
+			flags[toggle.Name] = toggle.Variant.Name
+		default:
+			flags[toggle.Name] = "true"
+		}
+	}
+	return flags, nil
+}
+
+func (e *featureFlagEvaluator) getJSON(u string, header http.Header, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	for k, values := range header {
+		req.Header[k] = values
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected response status %v", resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxFlagResponseSize)).Decode(v)
+}
+
+// parseConfigMapFlags parses the flags of a ConfigMap. A value that isn't
+// a YAML object with a "value" is the value of the flag for every key.
+func parseConfigMapFlags(data map[string]string) (map[string]*configMapFlag, error) {
+	flags := make(map[string]*configMapFlag, len(data))
+	for name, raw := range data {
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal([]byte(raw), &obj); err != nil || obj == nil || obj["value"] == nil {
+			flags[name] = &configMapFlag{Value: raw}
+			continue
+		}
+
+		flag := &configMapFlag{}
+		if err := yaml.Unmarshal([]byte(raw), flag); err != nil {
+			return nil, errors.Wrapf(err, "error parsing flag %v", name)
+		}
+		total := 0
+		for variant, percentage := range flag.Rollout {
+			if percentage < 0 {
+				return nil, errors.Errorf("flag %v: rollout percentage of %v must not be negative", name, variant)
+			}
+			total += percentage
+		}
+		if total > 100 {
+			return nil, errors.Errorf("flag %v: rollout percentages add up to more than 100", name)
+		}
+		flags[name] = flag
+	}
+	return flags, nil
+}
+
+// evaluate returns the value of the flag for the key. A key is always in
+// the same rollout bucket of a flag, so it keeps its variant while the
+// rollout percentage grows.
+func (flag *configMapFlag) evaluate(name string, key string) string {
+	if len(key) == 0 {
+		return flagValueToString(flag.Value)
+	}
+	if v, ok := flag.Targets[key]; ok {
+		return flagValueToString(v)
+	}
+	if len(flag.Rollout) > 0 {
+		hash := fnv.New32a()
+		hash.Write([]byte(name + "/" + key))
+		bucket := int(hash.Sum32() % 100)
+
+		variants := make([]string, 0, len(flag.Rollout))
+		for variant := range flag.Rollout {
+			variants = append(variants, variant)
+		}
+		sort.Strings(variants)
+
+		limit := 0
+		for _, variant := range variants {
+			limit += flag.Rollout[variant]
+			if bucket < limit {
+				return variant
+			}
+		}
+	}
+	return flagValueToString(flag.Value)
+}
+
+// setFeatureFlagsToHeader passes the evaluated flags to the function.
+func setFeatureFlagsToHeader(flags map[string]string, source *fv1.FeatureFlagSource, request *http.Request) {
+	prefix := http.CanonicalHeaderKey(getFeatureFlagHeaderPrefix(source))
+
+	// Remove flag headers a client might have set to force a flag value.
+	for header := range request.Header {
+		if strings.HasPrefix(header, prefix) {
+			request.Header.Del(header)
+		}
+	}
+
+	for name, value := range flags {
+		request.Header.Set(prefix+flagHeaderName(name), value)
+	}
+}
+
+// flagHeaderName replaces the characters of a flag name that aren't allowed in header names.
+func flagHeaderName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' {
+			return r
+		}
+		return '-'
+	}, name)
+}
+
+func flagValueToString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	default:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+}
+
+func getFeatureFlagKeyHeader(source *fv1.FeatureFlagSource) string {
+	if len(source.KeyHeader) > 0 {
+		return source.KeyHeader
+	}
+	return fv1.DefaultFeatureFlagKeyHeader
+}
+
+func getFeatureFlagHeaderPrefix(source *fv1.FeatureFlagSource) string {
+	if len(source.HeaderPrefix) > 0 {
+		return source.HeaderPrefix
+	}
+	return fv1.DefaultFeatureFlagHeaderPrefix
+}
+
+func getFeatureFlagCacheTTL(source *fv1.FeatureFlagSource) time.Duration {
+	if source.CacheTTL > 0 {
+		return time.Duration(source.CacheTTL) * time.Second
+	}
+	return fv1.DefaultFeatureFlagCacheTTL * time.Second
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestConfigMapFlags(t *testing.T) {
+	flags, err := parseConfigMapFlags(map[string]string{
+		"new-ui":   "on",
+		"limits":   `{"max": 5}`,
+		"checkout": "value: v1\ntargets:\n  alice: v2\nrollout:\n  v2: 30\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := flags["new-ui"].evaluate("new-ui", "bob"); v != "on" {
+		t.Errorf("new-ui = %q, want on", v)
+	}
+	if v := flags["limits"].evaluate("limits", "bob"); v != `{"max": 5}` {
+		t.Errorf("limits = %q, want the raw value", v)
+	}
+	if v := flags["checkout"].evaluate("checkout", "alice"); v != "v2" {
+		t.Errorf("checkout for target alice = %q, want v2", v)
+	}
+	if v := flags["checkout"].evaluate("checkout", ""); v != "v1" {
+		t.Errorf("checkout without key = %q, want v1", v)
+	}
+
+	// about 30% of the keys should get the rollout variant, consistently
+	rolledOut := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("user-%v", i)
+		v := flags["checkout"].evaluate("checkout", key)
+		if v != flags["checkout"].evaluate("checkout", key) {
+			t.Fatalf("key %v got different variants", key)
+		}
+		if v == "v2" {
+			rolledOut++
+		}
+	}
+	if rolledOut < 250 || rolledOut > 350 {
+		t.Errorf("%v of 1000 keys rolled out, want about 300", rolledOut)
+	}
+
+	if _, err := parseConfigMapFlags(map[string]string{"bad": "value: a\nrollout:\n  b: 60\n  c: 50\n"}); err == nil {
+		t.Error("expected error for rollout over 100%")
+	}
+}
+
+func TestSetFeatureFlagsToHeader(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Fission-Flag-Forced", "true")
+	r.Header.Set("X-Other", "kept")
+
+	setFeatureFlagsToHeader(map[string]string{"new_ui": "on"}, &fv1.FeatureFlagSource{}, r)
+
+	if v := r.Header.Get("X-Fission-Flag-New-Ui"); v != "on" {
+		t.Errorf("flag header = %q, want on", v)
+	}
+	if v := r.Header.Get("X-Fission-Flag-Forced"); v != "" {
+		t.Errorf("client flag header wasn't removed: %q", v)
+	}
+	if v := r.Header.Get("X-Other"); v != "kept" {
+		t.Errorf("unrelated header = %q, want kept", v)
+	}
+}
+
+func TestRemoteFlags(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/sdk/evalx/client-id/users/"):
+			fmt.Fprint(w, `{"new-ui": {"value": true}, "color": {"value": "blue"}}`)
+		case r.URL.Path == "/api/frontend" && r.Header.Get("Authorization") == "token" && r.URL.Query().Get("userId") == "alice":
+			fmt.Fprint(w, `{"toggles": [
+				{"name": "new-ui", "enabled": true, "variant": {"name": "disabled", "enabled": false}},
+				{"name": "color", "enabled": true, "variant": {"name": "blue", "enabled": true}}
+			]}`)
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	e := makeFeatureFlagEvaluator(zap.NewNop(), nil)
+	source := &fv1.FeatureFlagSource{URL: ts.URL}
+
+	flags, err := e.fetchLaunchDarklyFlags(source, "client-id", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flags["new-ui"] != "true" || flags["color"] != "blue" {
+		t.Errorf("unexpected LaunchDarkly flags %v", flags)
+	}
+
+	flags, err = e.fetchUnleashFlags(source, "token", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flags["new-ui"] != "true" || flags["color"] != "blue" {
+		t.Errorf("unexpected Unleash flags %v", flags)
+	}
+
+	if _, err = e.fetchUnleashFlags(source, "wrong", "alice"); err == nil {
+		t.Error("expected error for rejected request")
+	}
+}
@@ -19,6 +19,7 @@ package router
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -27,18 +28,24 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
 	"go.opencensus.io/plugin/ochttp"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8stypes "k8s.io/apimachinery/pkg/types"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	ferror "github.com/fission/fission/pkg/error"
 	"github.com/fission/fission/pkg/error/network"
 	executorClient "github.com/fission/fission/pkg/executor/client"
+	"github.com/fission/fission/pkg/metering"
+	"github.com/fission/fission/pkg/qos"
 	"github.com/fission/fission/pkg/throttler"
+	"github.com/fission/fission/pkg/tracing"
 )
 
 const (
@@ -63,6 +70,19 @@ type (
 		svcAddrUpdateThrottler   *throttler.Throttler
 		functionTimeoutMap       map[k8stypes.UID]int
 		unTapServiceTimeout      time.Duration
+		jwtAuthenticator         *jwtAuthenticator
+		sessionAffinity          *sessionAffinityManager
+		requestValidator         *requestValidator
+		featureFlags             *featureFlagEvaluator
+		circuitBreakers          *circuitBreakerSet
+		requestLimiter           *qos.Limiter
+		guard                    *qos.Guard
+		svcDiscovery             *serviceDiscovery
+		slowRequests             *slowRequestRecorder
+		invocationQuotas         *invocationQuotas
+		invocationHistory        *invocationHistory
+		invocationCounter        *metering.InvocationCounter
+		cloudEvents              fv1.CloudEventsMode
 	}
 
 	tsRoundTripperParams struct {
@@ -83,6 +103,10 @@ type (
 		// Try to get a new one from executor.
 		// Default svcAddrRetryCount is 5.
 		svcAddrRetryCount int
+
+		// tlsConfig is set if function pods serve requests with mTLS,
+		// router presents its certificate to them.
+		tlsConfig *tls.Config
 	}
 
 	// RetryingRoundTripper is a layer on top of http.DefaultTransport, with retries.
@@ -94,6 +118,11 @@ type (
 		serviceURL       *url.URL
 		urlFromCache     bool
 		totalRetry       int
+		session          *requestSession
+		priority         fv1.InvocationPriority
+		trace            *requestTrace
+		// keepAlive is set for long polls.
+		keepAlive *podKeepAlive
 	}
 
 	// To keep the request body open during retries, we create an interface with Close operation being a no-op.
@@ -149,7 +178,9 @@ func (roundTripper *RetryingRoundTripper) RoundTrip(req *http.Request) (*http.Re
 	// set the timeout for transport context
 	roundTripper.addForwardedHostHeader(req)
 	transport := roundTripper.getDefaultTransport()
-	ocRoundTripper := &ochttp.Transport{Base: transport}
+	// the trace context is passed on to the function in the request
+	// headers
+	ocRoundTripper := tracing.Transport(&ochttp.Transport{Base: transport})
 
 	executingTimeout := roundTripper.funcHandler.tsRoundTripperParams.timeout
 
@@ -184,9 +215,16 @@ func (roundTripper *RetryingRoundTripper) RoundTrip(req *http.Request) (*http.Re
 	for i := 0; i < roundTripper.funcHandler.tsRoundTripperParams.maxRetries; i++ {
 		// set service url of target service of request only when
 		// trying to get new service url from cache/executor.
-		if retryCounter == 0 {
+		//
+		// requests of a pinned session skip executor and go to the same pod.
+		if retryCounter == 0 && !roundTripper.routeToPinnedPod(req) {
 			// get function service url from cache or executor
-			roundTripper.serviceURL, err = roundTripper.funcHandler.getServiceEntryFromExecutor()
+			specializeStart := time.Now()
+			specializeCtx, span := tracing.StartSpan(req.Context(), "specialize",
+				attribute.String("function", fnMeta.Name), attribute.String("namespace", fnMeta.Namespace))
+			roundTripper.serviceURL, err = roundTripper.funcHandler.getServiceEntryFromExecutor(specializeCtx, roundTripper.priority)
+			tracing.EndSpan(span, err)
+			roundTripper.trace.record(stageSpecialize, specializeStart)
 			if err != nil {
 				// We might want a specific error code or header for fission failures as opposed to
 				// user function bugs.
@@ -217,20 +255,13 @@ func (roundTripper *RetryingRoundTripper) RoundTrip(req *http.Request) (*http.Re
 
 			// modify the request to reflect the service url
 			// this service url comes from executor response
-			req.URL.Scheme = roundTripper.serviceURL.Scheme
-			req.URL.Host = roundTripper.serviceURL.Host
-
-			// To keep the function run container simple, it
-			// doesn't do any routing.  In the future if we have
-			// multiple functions per container, we could use the
-			// function metadata here.
-			// leave the query string intact (req.URL.RawQuery)
-			req.URL.Path = "/"
-
-			// Overwrite request host with internal host,
-			// or request will be blocked in some situations
-			// (e.g. istio-proxy)
-			req.Host = roundTripper.serviceURL.Host
+			targetURL := roundTripper.serviceURL
+			roundTripper.urlFromCache = false
+			if roundTripper.session != nil {
+				targetURL = roundTripper.funcHandler.sessionAffinity.pin(roundTripper.funcHandler.function, roundTripper.session, roundTripper.serviceURL).podURL
+			}
+			roundTripper.keepAlive.serving(roundTripper.serviceURL)
+			roundTripper.setTargetURL(req, targetURL)
 		}
 
 		// over-riding default settings.
@@ -245,7 +276,9 @@ func (roundTripper *RetryingRoundTripper) RoundTrip(req *http.Request) (*http.Re
 		newReq := roundTripper.setContext(req)
 
 		// forward the request to the function service
+		executeStart := time.Now()
 		resp, err := ocRoundTripper.RoundTrip(newReq)
+		roundTripper.trace.record(stageExecute, executeStart)
 		if err == nil {
 			// return response back to user
 			return resp, nil
@@ -282,6 +315,11 @@ func (roundTripper *RetryingRoundTripper) RoundTrip(req *http.Request) (*http.Re
 			resp.Body.Close()
 		}
 
+		// the pod of the session is unreachable, pin the session to a new one
+		if roundTripper.session != nil {
+			roundTripper.funcHandler.sessionAffinity.unpin(fnMeta, roundTripper.session)
+		}
+
 		// Check whether an error is an timeout error ("dial tcp i/o timeout").
 		if isNetTimeoutErr {
 			roundTripper.logger.Debug("request errored out - backing off before retrying",
@@ -309,6 +347,42 @@ func (roundTripper *RetryingRoundTripper) RoundTrip(req *http.Request) (*http.Re
 	return nil, e
 }
 
+// routeToPinnedPod sends the request to the pod its session is pinned to, if any.
+// It returns false if the request doesn't belong to a pinned session.
+func (roundTripper *RetryingRoundTripper) routeToPinnedPod(req *http.Request) bool {
+	if roundTripper.session == nil {
+		return false
+	}
+
+	target := roundTripper.funcHandler.sessionAffinity.lookup(&roundTripper.funcHandler.function.ObjectMeta, roundTripper.session)
+	if target == nil {
+		return false
+	}
+
+	roundTripper.serviceURL = target.serviceURL
+	roundTripper.urlFromCache = true
+	roundTripper.setTargetURL(req, target.podURL)
+	return true
+}
+
+// setTargetURL modifies the request to be sent to the target url.
+func (roundTripper *RetryingRoundTripper) setTargetURL(req *http.Request, targetURL *url.URL) {
+	req.URL.Scheme = targetURL.Scheme
+	req.URL.Host = targetURL.Host
+
+	// To keep the function run container simple, it
+	// doesn't do any routing.  In the future if we have
+	// multiple functions per container, we could use the
+	// function metadata here.
+	// leave the query string intact (req.URL.RawQuery)
+	req.URL.Path = "/"
+
+	// Overwrite request host with internal host,
+	// or request will be blocked in some situations
+	// (e.g. istio-proxy)
+	req.Host = targetURL.Host
+}
+
 // getDefaultTransport returns a pointer to new copy of http.Transport object to prevent
 // the value of http.DefaultTransport from being changed by goroutines.
 func (roundTripper RetryingRoundTripper) getDefaultTransport() *http.Transport {
@@ -325,6 +399,7 @@ func (roundTripper RetryingRoundTripper) getDefaultTransport() *http.Transport {
 		// You can change it by setting environment variable "ROUTER_ROUND_TRIP_DISABLE_KEEP_ALIVE"
 		// of router or helm variable "disableKeepAlive" before installation to false.
 		DisableKeepAlives: roundTripper.funcHandler.tsRoundTripperParams.disableKeepAlive,
+		TLSClientConfig:   roundTripper.funcHandler.tsRoundTripperParams.tlsConfig,
 	}
 }
 
@@ -358,9 +433,62 @@ func (fh *functionHandler) tapService(fn *fv1.Function, serviceURL *url.URL) {
 }
 
 func (fh functionHandler) handler(responseWriter http.ResponseWriter, request *http.Request) {
+	trace := fh.slowRequests.trace(request)
+
+	if fh.httpTrigger != nil && fh.httpTrigger.Spec.Authentication != nil && fh.httpTrigger.Spec.Authentication.JWT != nil {
+		jwtConfig := fh.httpTrigger.Spec.Authentication.JWT
+		claims, err := fh.jwtAuthenticator.authenticate(request, jwtConfig)
+		if err != nil {
+			fh.logger.Debug("request authentication failed",
+				zap.Error(err),
+				zap.String("trigger", fh.httpTrigger.ObjectMeta.Name),
+				zap.String("path", request.URL.Path))
+			responseWriter.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(responseWriter, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		setAuthClaimsToHeader(claims, jwtConfig, request)
+	}
+
+	if fh.httpTrigger != nil && fh.httpTrigger.Spec.RequestValidation != nil {
+		problems, err := fh.requestValidator.validate(fh.httpTrigger, request)
+		if err != nil {
+			fh.logger.Error("error validating request",
+				zap.Error(err),
+				zap.String("trigger", fh.httpTrigger.ObjectMeta.Name))
+			http.Error(responseWriter, "error validating request", http.StatusInternalServerError)
+			return
+		}
+		if len(problems) > 0 {
+			fh.logger.Debug("request rejected by validation",
+				zap.Strings("problems", problems),
+				zap.String("trigger", fh.httpTrigger.ObjectMeta.Name),
+				zap.String("path", request.URL.Path))
+			writeRequestValidationError(responseWriter, problems)
+			return
+		}
+	}
+
+	if fh.httpTrigger != nil && fh.httpTrigger.Spec.FeatureFlags != nil {
+		flags, err := fh.featureFlags.evaluate(fh.httpTrigger, request)
+		if err != nil {
+			// Flags are best effort, the function falls back to
+			// its defaults for flags it doesn't get.
+			fh.logger.Error("error evaluating feature flags",
+				zap.Error(err),
+				zap.String("trigger", fh.httpTrigger.ObjectMeta.Name))
+		}
+		setFeatureFlagsToHeader(flags, fh.httpTrigger.Spec.FeatureFlags, request)
+	}
+
 	if fh.httpTrigger != nil && fh.httpTrigger.Spec.FunctionReference.Type == fv1.FunctionReferenceTypeFunctionWeights {
 		// canary deployment. need to determine the function to send request to now
-		fn := getCanaryBackend(fh.functionMap, fh.fnWeightDistributionList)
+		var fn *fv1.Function
+		if route := fh.httpTrigger.Spec.FunctionReference.MatchRoute; route != nil && matchRequest(&route.Match, request) {
+			fn = fh.functionMap[route.Function]
+		} else {
+			fn = getCanaryBackend(fh.functionMap, fh.fnWeightDistributionList)
+		}
 		if fn == nil {
 			fh.logger.Error("could not get canary backend",
 				zap.Any("fnMap", fh.functionMap),
@@ -372,12 +500,53 @@ func (fh functionHandler) handler(responseWriter http.ResponseWriter, request *h
 		fh.logger.Debug("chosen function backend's metadata", zap.Any("metadata", fh.function))
 	}
 
+	if fh.httpTrigger != nil && fh.httpTrigger.Spec.Experiment != nil {
+		experiment := fh.httpTrigger.Spec.Experiment
+		name := experimentName(fh.httpTrigger)
+		if key := getExperimentKey(&experiment.Assignment, responseWriter, request); len(key) > 0 {
+			if variant := assignExperimentVariant(experiment, name, key); variant != nil {
+				if fn := fh.functionMap[variant.Function]; fn != nil {
+					fh.function = fn
+					request.Header.Set(fv1.HeaderExperiment, name)
+					request.Header.Set(fv1.HeaderExperimentVariant, variant.Name)
+					experimentExposed(fh.httpTrigger.ObjectMeta.Namespace, fh.httpTrigger.ObjectMeta.Name, name, variant.Name)
+				} else {
+					fh.logger.Error("function of experiment variant not found",
+						zap.String("trigger", fh.httpTrigger.ObjectMeta.Name),
+						zap.String("variant", variant.Name),
+						zap.String("function", variant.Function))
+				}
+			}
+		}
+	}
+
+	if ok, retryAt := fh.invocationQuotas.allow(fh.function); !ok {
+		fh.logger.Debug("invocation quota exceeded, rejecting request",
+			zap.String("function", fh.function.ObjectMeta.Name),
+			zap.Time("retry_at", retryAt))
+		responseWriter.Header().Set("Retry-After", strconv.Itoa(int(time.Until(retryAt).Seconds())+1))
+		http.Error(responseWriter, "invocation quota exceeded", http.StatusTooManyRequests)
+		return
+	}
+
+	requestFinished := functionRequestStarted(fh.function.ObjectMeta.Namespace, fh.function.ObjectMeta.Name)
+	defer requestFinished()
+
 	// url path
 	setPathInfoToHeader(request)
 
 	// system params
 	setFunctionMetadataToHeader(&fh.function.ObjectMeta, request)
 
+	// CloudEvents in the mode of the function
+	if err := fh.setCloudEvent(request); err != nil {
+		fh.logger.Debug("error converting request to CloudEvent",
+			zap.Error(err),
+			zap.String("function", fh.function.ObjectMeta.Name))
+		http.Error(responseWriter, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	director := func(req *http.Request) {
 		if _, ok := req.Header["User-Agent"]; !ok {
 			// explicitly disable User-Agent so it's not set to default value
@@ -385,6 +554,51 @@ func (fh functionHandler) handler(responseWriter http.ResponseWriter, request *h
 		}
 	}
 
+	var breaker *circuitBreaker
+	if fh.httpTrigger != nil && fh.httpTrigger.Spec.CircuitBreaker != nil && fh.circuitBreakers != nil {
+		breaker = fh.circuitBreakers.get(fh.httpTrigger)
+		if ok, retryAfter := breaker.allow(time.Now()); !ok {
+			fh.logger.Debug("circuit breaker open, rejecting request",
+				zap.String("trigger", fh.httpTrigger.ObjectMeta.Name),
+				zap.Duration("retry_after", retryAfter))
+			responseWriter.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			http.Error(responseWriter, "circuit breaker open", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	if fh.httpTrigger != nil && fh.httpTrigger.Spec.Timeout > 0 {
+		ctx, cancel := context.WithTimeout(request.Context(), time.Duration(fh.httpTrigger.Spec.Timeout)*time.Second)
+		defer cancel()
+		request = request.WithContext(ctx)
+	}
+
+	// let the function know the priority of the request too
+	priority := requestPriority(fh.httpTrigger, request)
+	request.Header.Set(fv1.HeaderPriority, string(priority))
+
+	if fh.guard.Shed(priority) {
+		fh.logger.Debug("router over its resource budget, shedding request",
+			zap.String("priority", string(priority)),
+			zap.String("function", fh.function.ObjectMeta.Name))
+		responseWriter.Header().Set("Retry-After", strconv.Itoa(int(fh.guard.RetryAfter().Seconds())))
+		http.Error(responseWriter, "router overloaded", http.StatusServiceUnavailable)
+		return
+	}
+
+	queueStart := time.Now()
+	release, err := fh.requestLimiter.Acquire(request.Context(), priority)
+	trace.record(stageQueue, queueStart)
+	if err != nil {
+		fh.logger.Debug("request canceled while waiting for a free request slot",
+			zap.Error(err),
+			zap.String("priority", string(priority)),
+			zap.String("function", fh.function.ObjectMeta.Name))
+		http.Error(responseWriter, "router busy", http.StatusServiceUnavailable)
+		return
+	}
+	defer release()
+
 	fnTimeout := fh.functionTimeoutMap[fh.function.ObjectMeta.GetUID()]
 	if fnTimeout == 0 {
 		fnTimeout = fv1.DEFAULT_FUNCTION_TIMEOUT
@@ -394,16 +608,64 @@ func (fh functionHandler) handler(responseWriter http.ResponseWriter, request *h
 		logger:      fh.logger.Named("roundtripper"),
 		funcHandler: &fh,
 		funcTimeout: time.Duration(fnTimeout) * time.Second,
+		priority:    priority,
+		trace:       trace,
+	}
+
+	if fh.httpTrigger != nil && fh.httpTrigger.Spec.SessionAffinity != nil && fh.sessionAffinity != nil {
+		rrt.session = getRequestSession(fh.httpTrigger.Spec.SessionAffinity, responseWriter, request)
+	}
+
+	var longPoll bool
+	if fh.httpTrigger != nil && fh.httpTrigger.Spec.LongPoll != nil {
+		// hold the poll open for its own timeout, and keep the pod from
+		// being reclaimed as idle meanwhile
+		longPoll = true
+		timeout, interval := longPollSettings(fh.httpTrigger.Spec.LongPoll)
+		rrt.funcTimeout = timeout
+		rrt.keepAlive = &podKeepAlive{}
+		done := make(chan struct{})
+		defer close(done)
+		go rrt.keepAlive.run(func(serviceURL *url.URL) {
+			fh.tapService(fh.function, serviceURL)
+		}, interval, done)
+	}
+
+	var transport http.RoundTripper = rrt
+	if fh.httpTrigger != nil && fh.httpTrigger.Spec.Retry != nil && fh.httpTrigger.Spec.Retry.MaxRetries > 0 {
+		transport = &retryingTriggerRoundTripper{
+			logger: fh.logger.Named("retry"),
+			base:   rrt,
+			policy: fh.httpTrigger.Spec.Retry,
+			// a poll timing out without an event is a normal outcome
+			noRetryOnTimeout: longPoll,
+		}
 	}
 
 	start := time.Now()
 
+	errorHandler := fh.getProxyErrorHandler(start, rrt)
+	if breaker != nil {
+		proxyErrorHandler := errorHandler
+		errorHandler = func(rw http.ResponseWriter, req *http.Request, err error) {
+			// a client closing the connection says nothing about the function
+			if err != context.Canceled {
+				breaker.record(time.Now(), false)
+			}
+			proxyErrorHandler(rw, req, err)
+		}
+	}
+
 	proxy := &httputil.ReverseProxy{
 		Director:     director,
-		Transport:    rrt,
-		ErrorHandler: fh.getProxyErrorHandler(start, rrt),
+		Transport:    transport,
+		ErrorHandler: errorHandler,
 		ModifyResponse: func(resp *http.Response) error {
-			go fh.collectFunctionMetric(start, rrt, request, resp)
+			trace.setStatus(resp.StatusCode)
+			if breaker != nil {
+				breaker.record(time.Now(), resp.StatusCode < http.StatusInternalServerError)
+			}
+			go fh.collectFunctionMetric(start, rrt, request, resp, nil)
 			return nil
 		},
 	}
@@ -422,6 +684,7 @@ func (fh functionHandler) handler(responseWriter http.ResponseWriter, request *h
 	}()
 
 	proxy.ServeHTTP(responseWriter, request)
+	fh.slowRequests.finish(&fh, request, trace)
 }
 
 // findCeil picks a function from the functionWeightDistribution list based on the
@@ -456,6 +719,42 @@ func getCanaryBackend(fnMap map[string]*fv1.Function, fnWtDistributionList []fun
 	return fnMap[fnName]
 }
 
+// matchRequest checks whether the request carries the header, cookie
+// or query parameter described by the match rule.
+func matchRequest(match *fv1.CanaryMatch, request *http.Request) bool {
+	var values []string
+
+	switch match.Type {
+	case fv1.CanaryMatchTypeHeader:
+		values = request.Header[http.CanonicalHeaderKey(match.Name)]
+	case fv1.CanaryMatchTypeCookie:
+		cookie, err := request.Cookie(match.Name)
+		if err != nil {
+			return false
+		}
+		values = []string{cookie.Value}
+	case fv1.CanaryMatchTypeQuery:
+		values = request.URL.Query()[match.Name]
+	}
+
+	for _, v := range values {
+		if len(match.Value) == 0 || v == match.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// requestPriority returns the priority of a request to the trigger, or to
+// the function directly if trigger is nil.
+func requestPriority(trigger *fv1.HTTPTrigger, request *http.Request) fv1.InvocationPriority {
+	var maxPriority fv1.InvocationPriority
+	if trigger != nil {
+		maxPriority = trigger.Spec.Priority
+	}
+	return qos.Priority(request.Header.Get(fv1.HeaderPriority), maxPriority)
+}
+
 // addForwardedHostHeader add "forwarded host" to request header
 func (roundTripper RetryingRoundTripper) addForwardedHostHeader(req *http.Request) {
 	// for more detailed information, please visit:
@@ -517,8 +816,9 @@ func (fh functionHandler) unTapService(fn *fv1.Function, serviceUrl *url.URL) er
 	return nil
 }
 
-// getServiceEntryFromExecutor returns service url entry returns from executor
-func (fh functionHandler) getServiceEntryFromExecutor() (*url.URL, error) {
+// getServiceEntryFromExecutor returns service url entry returns from executor.
+// The specialization is part of the trace of ctx, but is not cancelled with it.
+func (fh functionHandler) getServiceEntryFromExecutor(ctx context.Context, priority fv1.InvocationPriority) (*url.URL, error) {
 	// send a request to executor to specialize a new pod
 	fh.logger.Debug("function timeout specified", zap.Int("timeout", fh.function.Spec.FunctionTimeout))
 	timeout := 30 * time.Second
@@ -526,9 +826,9 @@ func (fh functionHandler) getServiceEntryFromExecutor() (*url.URL, error) {
 		timeout = time.Second * time.Duration(fh.function.Spec.FunctionTimeout)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(tracing.Detach(ctx), timeout)
 	defer cancel()
-	service, err := fh.executor.GetServiceForFunction(ctx, fh.function)
+	service, err := fh.executor.GetServiceForFunction(ctx, fh.function, priority)
 	if err != nil {
 		statusCode, errMsg := ferror.GetHTTPError(err)
 		fh.logger.Error("error from GetServiceForFunction",
@@ -536,11 +836,22 @@ func (fh functionHandler) getServiceEntryFromExecutor() (*url.URL, error) {
 			zap.String("error_message", errMsg),
 			zap.Any("function", fh.function),
 			zap.Int("status_code", statusCode))
-		return nil, err
+		if statusCode < http.StatusInternalServerError || !fh.svcDiscovery.supports(fh.function) {
+			return nil, err
+		}
+		// executor may be down, find the service of the function without it
+		service, err = fh.getServiceFromDiscovery()
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// parse the address into url
-	serviceURL, err := url.Parse(fmt.Sprintf("http://%v", service))
+	scheme := "http"
+	if fh.tsRoundTripperParams.tlsConfig != nil {
+		scheme = "https"
+	}
+	serviceURL, err := url.Parse(fmt.Sprintf("%v://%v", scheme, service))
 	if err != nil {
 		fh.logger.Error("error parsing service url",
 			zap.Error(err),
@@ -551,6 +862,30 @@ func (fh functionHandler) getServiceEntryFromExecutor() (*url.URL, error) {
 	return serviceURL, nil
 }
 
+// getServiceFromDiscovery returns the service address of the function found
+// by service discovery, without executor.
+func (fh functionHandler) getServiceFromDiscovery() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), svcDiscoveryTimeout)
+	defer cancel()
+	service, err := fh.svcDiscovery.getServiceAddress(ctx, fh.function)
+	if err != nil {
+		fh.logger.Error("error finding function service without executor",
+			zap.Error(err),
+			zap.String("function_name", fh.function.ObjectMeta.Name),
+			zap.String("function_namespace", fh.function.ObjectMeta.Namespace))
+		return "", err
+	}
+	if fh.tsRoundTripperParams.tlsConfig != nil {
+		// the default port of https is not the service port
+		service = fmt.Sprintf("%v:80", service)
+	}
+	fh.logger.Warn("executor unavailable, sending request to the function service found by service discovery",
+		zap.String("function_name", fh.function.ObjectMeta.Name),
+		zap.String("function_namespace", fh.function.ObjectMeta.Namespace),
+		zap.String("service", service))
+	return service, nil
+}
+
 // getProxyErrorHandler returns a reverse proxy error handler
 func (fh functionHandler) getProxyErrorHandler(start time.Time, rrt *RetryingRoundTripper) func(rw http.ResponseWriter, req *http.Request, err error) {
 	return func(rw http.ResponseWriter, req *http.Request, err error) {
@@ -576,10 +911,11 @@ func (fh functionHandler) getProxyErrorHandler(start time.Time, rrt *RetryingRou
 			fh.logger.Error(msg, zap.Error(err), zap.Any("function", fh.function), zap.Any("request_header", req.Header), zap.Any("code", code))
 		}
 
+		rrt.trace.setStatus(status)
 		go fh.collectFunctionMetric(start, rrt, req, &http.Response{
 			StatusCode:    status,
 			ContentLength: req.ContentLength,
-		})
+		}, err)
 
 		// TODO: return error message that contains traceable UUID back to user. Issue #693
 		rw.WriteHeader(status)
@@ -595,7 +931,10 @@ func (fh functionHandler) getProxyErrorHandler(start time.Time, rrt *RetryingRou
 	}
 }
 
-func (fh functionHandler) collectFunctionMetric(start time.Time, rrt *RetryingRoundTripper, req *http.Request, resp *http.Response) {
+// collectFunctionMetric records the metrics of an invocation and adds it to
+// the invocation history, with the error router got if the function didn't
+// respond.
+func (fh functionHandler) collectFunctionMetric(start time.Time, rrt *RetryingRoundTripper, req *http.Request, resp *http.Response, invocationErr error) {
 	duration := time.Since(start)
 
 	// Metrics stuff
@@ -618,6 +957,34 @@ func (fh functionHandler) collectFunctionMetric(start time.Time, rrt *RetryingRo
 	functionCallCompleted(funcMetricLabels, httpMetricLabels,
 		duration, duration, resp.ContentLength)
 
+	invocation := fv1.InvocationRecord{
+		Time:       metav1.NewTime(start),
+		Duration:   metav1.Duration{Duration: duration},
+		StatusCode: resp.StatusCode,
+	}
+	if invocationErr != nil {
+		invocation.Error = invocationErr.Error()
+	}
+
+	if triggerMetricLabels := fh.getTriggerLabels(req); triggerMetricLabels != nil {
+		invocation.TriggerType = triggerMetricLabels.triggerType
+		invocation.TriggerName = triggerMetricLabels.name
+
+		var eventTime time.Time
+		t := req.Header.Get(fv1.HeaderEventTime)
+		if len(t) == 0 {
+			// the time of CloudEvents in binary mode
+			t = req.Header.Get(cloudEventsHeaderPrefix + "Time")
+		}
+		if len(t) > 0 {
+			// ignore malformed time, lag is just not reported
+			eventTime, _ = time.Parse(time.RFC3339Nano, t)
+		}
+		triggerCallCompleted(triggerMetricLabels, resp.StatusCode, start, eventTime)
+	}
+	fh.invocationHistory.record(fh.function, invocation)
+	fh.invocationCounter.Record(fh.function)
+
 	// tapService before invoking roundTrip for the serviceUrl
 	if rrt.urlFromCache {
 		fh.tapService(fh.function, rrt.serviceURL)
@@ -627,3 +994,33 @@ func (fh functionHandler) collectFunctionMetric(start time.Time, rrt *RetryingRo
 		zap.Int("retry", rrt.totalRetry), zap.Duration("total-time", duration),
 		zap.Int64("content-length", resp.ContentLength))
 }
+
+// getTriggerLabels returns the metric labels of the trigger the request is made by, or
+// nil if it's unknown. Internal function routes rely on the headers set by event sources.
+func (fh functionHandler) getTriggerLabels(req *http.Request) *triggerLabels {
+	if fh.httpTrigger != nil {
+		return &triggerLabels{
+			triggerType: fv1.TriggerTypeHTTP,
+			namespace:   fh.httpTrigger.ObjectMeta.Namespace,
+			name:        fh.httpTrigger.ObjectMeta.Name,
+		}
+	}
+
+	triggerType := req.Header.Get(fv1.HeaderTriggerType)
+	triggerName := req.Header.Get(fv1.HeaderTriggerName)
+	if len(triggerName) == 0 {
+		return nil
+	}
+
+	switch triggerType {
+	case fv1.TriggerTypeMessageQueue, fv1.TriggerTypeTime, fv1.TriggerTypeKubeWatch, fv1.TriggerTypeBucket, fv1.TriggerTypeDatabase, fv1.TriggerTypeWorkflow:
+		// triggers can only be created in the same namespace as the function
+		return &triggerLabels{
+			triggerType: triggerType,
+			namespace:   fh.function.ObjectMeta.Namespace,
+			name:        triggerName,
+		}
+	default:
+		return nil
+	}
+}
@@ -67,3 +67,55 @@ func TestProxyErrorHandler(t *testing.T) {
 	errHandler(respRecorder, req, errors.New("dummy"))
 	assert.Equal(t, http.StatusInternalServerError, respRecorder.Code)
 }
+
+func TestMatchRequest(t *testing.T) {
+	header := func(name, value string) fv1.CanaryMatch {
+		return fv1.CanaryMatch{Type: fv1.CanaryMatchTypeHeader, Name: name, Value: value}
+	}
+	cookie := func(name, value string) fv1.CanaryMatch {
+		return fv1.CanaryMatch{Type: fv1.CanaryMatchTypeCookie, Name: name, Value: value}
+	}
+	query := func(name, value string) fv1.CanaryMatch {
+		return fv1.CanaryMatch{Type: fv1.CanaryMatchTypeQuery, Name: name, Value: value}
+	}
+
+	tests := []struct {
+		name    string
+		match   fv1.CanaryMatch
+		url     string
+		headers map[string]string
+		cookies map[string]string
+		want    bool
+	}{
+		{"header value", header("X-Canary", "yes"), "/", map[string]string{"X-Canary": "yes"}, nil, true},
+		{"header name is case insensitive", header("x-canary", "yes"), "/", map[string]string{"X-CANARY": "yes"}, nil, true},
+		{"header with other value", header("X-Canary", "yes"), "/", map[string]string{"X-Canary": "no"}, nil, false},
+		{"header with any value", header("X-Canary", ""), "/", map[string]string{"X-Canary": "no"}, nil, true},
+		{"missing header", header("X-Canary", ""), "/", nil, nil, false},
+		{"cookie value", cookie("beta", "1"), "/", nil, map[string]string{"beta": "1"}, true},
+		{"cookie with other value", cookie("beta", "1"), "/", nil, map[string]string{"beta": "0"}, false},
+		{"cookie with any value", cookie("beta", ""), "/", nil, map[string]string{"beta": "0"}, true},
+		{"missing cookie", cookie("beta", ""), "/", nil, map[string]string{"other": "1"}, false},
+		{"cookie name is case sensitive", cookie("beta", ""), "/", nil, map[string]string{"Beta": "1"}, false},
+		{"query value", query("canary", "true"), "/?canary=true", nil, nil, true},
+		{"repeated query parameter", query("canary", "true"), "/?canary=false&canary=true", nil, nil, true},
+		{"query with other value", query("canary", "true"), "/?canary=false", nil, nil, false},
+		{"query with any value", query("canary", ""), "/?canary", nil, nil, true},
+		{"missing query parameter", query("canary", ""), "/?other=true", nil, nil, false},
+		{"header is not a query parameter", query("canary", ""), "/", map[string]string{"canary": "true"}, nil, false},
+		{"unknown type", fv1.CanaryMatch{Type: "path", Name: "canary"}, "/?canary=true", nil, nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://example.com"+test.url, nil)
+			for name, value := range test.headers {
+				req.Header.Set(name, value)
+			}
+			for name, value := range test.cookies {
+				req.AddCookie(&http.Cookie{Name: name, Value: value})
+			}
+			assert.Equal(t, test.want, matchRequest(&test.match, req))
+		})
+	}
+}
@@ -111,6 +111,13 @@ func (frr *functionReferenceResolver) resolve(trigger fv1.HTTPTrigger) (*resolve
 		return nil, errors.Errorf("unrecognized function reference type %v", trigger.Spec.FunctionReference.Type)
 	}
 
+	if trigger.Spec.Experiment != nil {
+		err = frr.resolveExperiment(nfr.namespace, trigger.Spec.Experiment, rr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// cache resolve result
 	frr.refCache.Set(nfr, *rr) //nolint: errcheck
 
@@ -186,6 +193,30 @@ func (frr *functionReferenceResolver) resolveByFunctionWeights(namespace string,
 	return &rr, nil
 }
 
+// resolveExperiment adds the functions of the experiment variants to the function map,
+// so that changes to them invalidate the resolve result as well.
+func (frr *functionReferenceResolver) resolveExperiment(namespace string, experiment *fv1.Experiment, rr *resolveResult) error {
+	for _, variant := range experiment.Variants {
+		if _, ok := rr.functionMap[variant.Function]; ok {
+			continue
+		}
+		obj, isExist, err := frr.store.Get(&fv1.Function{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      variant.Function,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if !isExist {
+			return errors.Errorf("function %v of experiment variant %v does not exist", variant.Function, variant.Name)
+		}
+		rr.functionMap[variant.Function] = obj.(*fv1.Function)
+	}
+	return nil
+}
+
 func (frr *functionReferenceResolver) delete(namespace string, triggerName, triggerRV string) error {
 	nfr := namespacedTriggerReference{
 		namespace:              namespace,
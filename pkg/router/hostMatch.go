@@ -0,0 +1,85 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// hostMatcher returns a mux matcher for requests to the host of an HTTP
+// trigger. Unlike mux's Host matcher, it ignores the port and the case of
+// the request host, and supports wildcard hosts.
+func hostMatcher(host string) mux.MatcherFunc {
+	host = strings.ToLower(host)
+	return func(r *http.Request, rm *mux.RouteMatch) bool {
+		return matchHost(host, requestHost(r))
+	}
+}
+
+// matchHost reports whether host matches the lowercase host of a trigger.
+// A wildcard host like "*.example.com" matches the hosts with exactly one
+// more label, "api.example.com" but neither "example.com" nor
+// "v1.api.example.com".
+func matchHost(pattern string, host string) bool {
+	if !strings.HasPrefix(pattern, "*.") {
+		return host == pattern
+	}
+	suffix := pattern[1:]
+	if !strings.HasSuffix(host, suffix) {
+		return false
+	}
+	label := host[:len(host)-len(suffix)]
+	return len(label) > 0 && !strings.Contains(label, ".")
+}
+
+// requestHost returns the lowercase host of the request without port.
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// hostPriority ranks the host of a trigger, routes of triggers with a lower
+// rank are tried first.
+func hostPriority(host string) int {
+	switch {
+	case len(host) == 0:
+		return 2
+	case strings.HasPrefix(host, "*."):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// sortTriggersByHost orders triggers so that requests are routed to the
+// trigger with the most specific host: exact hosts come first, then wildcard
+// hosts and last triggers for any host. The order is kept otherwise.
+func sortTriggersByHost(triggers []fv1.HTTPTrigger) {
+	sort.SliceStable(triggers, func(i, j int) bool {
+		return hostPriority(triggers[i].Spec.Host) < hostPriority(triggers[j].Spec.Host)
+	})
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestHostMatcher(t *testing.T) {
+	tests := []struct {
+		pattern string
+		host    string
+		match   bool
+	}{
+		{"api.example.com", "api.example.com", true},
+		{"api.example.com", "API.Example.com:8888", true},
+		{"api.example.com", "api.example.com.", true},
+		{"api.example.com", "www.example.com", false},
+		{"*.example.com", "api.example.com", true},
+		{"*.Example.com", "tenant-a.example.com:80", true},
+		{"*.example.com", "example.com", false},
+		{"*.example.com", "v1.api.example.com", false},
+		{"*.example.com", "apiexample.com", false},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", "http://"+test.host+"/", nil)
+		if match := hostMatcher(test.pattern)(req, nil); match != test.match {
+			t.Errorf("host %q matching %q: expected %v, got %v", test.host, test.pattern, test.match, match)
+		}
+	}
+}
+
+func TestSortTriggersByHost(t *testing.T) {
+	hosts := []string{"", "*.example.com", "api.example.com", "", "www.example.com"}
+	triggers := make([]fv1.HTTPTrigger, len(hosts))
+	for i, host := range hosts {
+		triggers[i].Spec.Host = host
+		triggers[i].ObjectMeta.Name = string(rune('a' + i))
+	}
+
+	sortTriggersByHost(triggers)
+
+	expected := []string{"c", "e", "b", "a", "d"}
+	for i, trigger := range triggers {
+		if trigger.ObjectMeta.Name != expected[i] {
+			t.Fatalf("unexpected order at %v: expected %v, got %v", i, expected[i], trigger.ObjectMeta.Name)
+		}
+	}
+}
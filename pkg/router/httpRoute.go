@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"reflect"
+
+	"go.uber.org/zap"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/router/util"
+)
+
+// usesHTTPRoute reports whether router creates a Gateway API HTTPRoute for the trigger.
+func usesHTTPRoute(trigger *fv1.HTTPTrigger) bool {
+	return trigger.Spec.CreateIngress && trigger.Spec.IngressConfig.Type == fv1.IngressTypeHTTPRoute
+}
+
+func createHTTPRoute(logger *zap.Logger, trigger *fv1.HTTPTrigger, dynamicClient dynamic.Interface) {
+	if !usesHTTPRoute(trigger) {
+		return
+	}
+	if dynamicClient == nil {
+		logger.Error("no dynamic client to create HTTPRoute for trigger", zap.String("trigger", trigger.ObjectMeta.Name))
+		return
+	}
+	_, err := dynamicClient.Resource(util.HTTPRouteGVR).Namespace(podNamespace).Create(util.GetHTTPRouteSpec(podNamespace, trigger), v1.CreateOptions{})
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
+		logger.Error("failed to create HTTPRoute", zap.Error(err), zap.String("trigger", trigger.ObjectMeta.Name))
+		return
+	}
+	logger.Debug("created HTTPRoute successfully for trigger", zap.String("trigger", trigger.ObjectMeta.Name))
+}
+
+func deleteHTTPRoute(logger *zap.Logger, trigger *fv1.HTTPTrigger, dynamicClient dynamic.Interface) {
+	if !usesHTTPRoute(trigger) || dynamicClient == nil {
+		return
+	}
+	err := dynamicClient.Resource(util.HTTPRouteGVR).Namespace(podNamespace).Delete(trigger.ObjectMeta.Name, &v1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		logger.Error("failed to delete HTTPRoute for trigger", zap.Error(err), zap.String("trigger", trigger.ObjectMeta.Name))
+	}
+}
+
+// updateHTTPRoute brings the HTTPRoute of a trigger in line with the new
+// version of the trigger, creating it if it's missing.
+func updateHTTPRoute(logger *zap.Logger, oldT *fv1.HTTPTrigger, newT *fv1.HTTPTrigger, dynamicClient dynamic.Interface) {
+	if !usesHTTPRoute(oldT) && !usesHTTPRoute(newT) {
+		return
+	}
+
+	if !usesHTTPRoute(oldT) && usesHTTPRoute(newT) {
+		createHTTPRoute(logger, newT, dynamicClient)
+		return
+	}
+
+	if !usesHTTPRoute(newT) && usesHTTPRoute(oldT) {
+		deleteHTTPRoute(logger, oldT, dynamicClient)
+		return
+	}
+
+	if dynamicClient == nil {
+		return
+	}
+
+	routeClient := dynamicClient.Resource(util.HTTPRouteGVR).Namespace(podNamespace)
+	route, err := routeClient.Get(oldT.ObjectMeta.Name, v1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			createHTTPRoute(logger, newT, dynamicClient)
+			return
+		}
+		logger.Error("failed to get HTTPRoute when updating trigger",
+			zap.Error(err),
+			zap.String("trigger", oldT.ObjectMeta.Name))
+		return
+	}
+	newRoute := util.GetHTTPRouteSpec(podNamespace, newT)
+
+	changes := false
+
+	annotations := route.GetAnnotations()
+	for k, v := range newRoute.GetAnnotations() {
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		if annotations[k] != v {
+			annotations[k] = v
+			changes = true
+		}
+	}
+	route.SetAnnotations(annotations)
+
+	if !reflect.DeepEqual(route.GetLabels(), newRoute.GetLabels()) {
+		route.SetLabels(newRoute.GetLabels())
+		changes = true
+	}
+
+	if !reflect.DeepEqual(route.Object["spec"], newRoute.Object["spec"]) {
+		logger.Debug("HTTPRoute spec",
+			zap.Any("old_trigger", route.Object["spec"]), zap.Any("new_trigger", newRoute.Object["spec"]))
+		route.Object["spec"] = newRoute.Object["spec"]
+		changes = true
+	}
+
+	if changes {
+		_, err = routeClient.Update(route, v1.UpdateOptions{})
+		if err != nil {
+			logger.Error("failed to update HTTPRoute for trigger", zap.Error(err), zap.String("trigger", oldT.ObjectMeta.Name))
+			return
+		}
+		logger.Debug("updated HTTPRoute successfully for trigger", zap.String("trigger", newT.ObjectMeta.Name))
+	}
+}
@@ -19,6 +19,7 @@ package router
 import (
 	"context"
 	"net/http"
+	"reflect"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -26,6 +27,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	k8sCache "k8s.io/client-go/tools/cache"
@@ -33,10 +35,27 @@ import (
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/crd"
 	executorClient "github.com/fission/fission/pkg/executor/client"
+	"github.com/fission/fission/pkg/metering"
+	"github.com/fission/fission/pkg/qos"
 	"github.com/fission/fission/pkg/throttler"
 	"github.com/fission/fission/pkg/utils"
 )
 
+const (
+	// minRouterUpdateInterval limits how often the router is rebuilt, so that
+	// bursts of trigger and function updates are applied in a single rebuild.
+	minRouterUpdateInterval = 200 * time.Millisecond
+
+	// staleRouteGracePeriod is how long router keeps serving the previous
+	// version of a trigger whose function reference can't be resolved, e.g.
+	// because the trigger update was seen before the function it refers to.
+	staleRouteGracePeriod = time.Minute
+
+	// ingressResyncPeriod is how often router reconciles the Ingresses
+	// and HTTPRoutes of triggers.
+	ingressResyncPeriod = 5 * time.Minute
+)
+
 // HTTPTriggerSet represents an HTTP trigger set
 type HTTPTriggerSet struct {
 	*functionServiceMap
@@ -54,11 +73,59 @@ type HTTPTriggerSet struct {
 	functions                  []fv1.Function
 	funcStore                  k8sCache.Store
 	funcController             k8sCache.Controller
+	envStore                   k8sCache.Store
+	envController              k8sCache.Controller
 	updateRouterRequestChannel chan struct{}
 	tsRoundTripperParams       *tsRoundTripperParams
 	isDebugEnv                 bool
 	svcAddrUpdateThrottler     *throttler.Throttler
 	unTapServiceTimeout        time.Duration
+	jwtAuthenticator           *jwtAuthenticator
+	sessionAffinity            *sessionAffinityManager
+	requestValidator           *requestValidator
+	featureFlags               *featureFlagEvaluator
+	circuitBreakers            *circuitBreakerSet
+	// requestLimiter, if set, limits the number of requests router
+	// proxies at the same time, serving the ones of high priority first.
+	requestLimiter *qos.Limiter
+	// guard, if set, sheds requests of low priority while router is
+	// over its memory or goroutine budget.
+	guard *qos.Guard
+	// svcDiscovery, if set, finds the services of newdeploy functions
+	// while executor is unavailable.
+	svcDiscovery *serviceDiscovery
+	// slowRequests, if set, records the requests slower than its
+	// threshold for offline analysis.
+	slowRequests *slowRequestRecorder
+	// invocationQuotas enforces the invocation quotas of functions and
+	// namespaces.
+	invocationQuotas *invocationQuotas
+	// invocationHistory, if set, records the invocations of functions in
+	// their histories.
+	invocationHistory *invocationHistory
+	// invocationCounter, if set, adds the invocations of functions to
+	// their usage.
+	invocationCounter *metering.InvocationCounter
+	// cloudEvents is the CloudEvents mode functions are invoked in, unless
+	// set otherwise by their triggers.
+	cloudEvents    fv1.CloudEventsMode
+	useEncodedPath bool
+	// dynamicClient creates the Gateway API HTTPRoutes of triggers.
+	dynamicClient dynamic.Interface
+	// asyncInvoker, if set, enqueues the requests at /fission-async and
+	// invokes their triggers later.
+	asyncInvoker *asyncInvoker
+
+	// routes holds the trigger routes of the current router by trigger UID.
+	// It's only accessed by the goroutine rebuilding the router.
+	routes map[types.UID]*triggerRoute
+}
+
+// triggerRoute is the handler of a trigger that was successfully resolved.
+type triggerRoute struct {
+	trigger    fv1.HTTPTrigger
+	handler    *functionHandler
+	resolvedAt time.Time
 }
 
 func makeHTTPTriggerSet(logger *zap.Logger, fmap *functionServiceMap, fissionClient *crd.FissionClient,
@@ -72,11 +139,16 @@ func makeHTTPTriggerSet(logger *zap.Logger, fmap *functionServiceMap, fissionCli
 		kubeClient:                 kubeClient,
 		executor:                   executor,
 		crdClient:                  crdClient,
-		updateRouterRequestChannel: make(chan struct{}, 10), // use buffer channel
+		updateRouterRequestChannel: make(chan struct{}, 1),
 		tsRoundTripperParams:       params,
 		isDebugEnv:                 isDebugEnv,
 		svcAddrUpdateThrottler:     actionThrottler,
 		unTapServiceTimeout:        unTapServiceTimeout,
+		jwtAuthenticator:           makeJWTAuthenticator(logger),
+		sessionAffinity:            makeSessionAffinityManager(logger, kubeClient),
+		requestValidator:           makeRequestValidator(logger, fissionClient, kubeClient),
+		featureFlags:               makeFeatureFlagEvaluator(logger, kubeClient),
+		circuitBreakers:            makeCircuitBreakerSet(),
 	}
 	var tStore, fnStore k8sCache.Store
 	var tController, fnController k8sCache.Controller
@@ -88,6 +160,7 @@ func makeHTTPTriggerSet(logger *zap.Logger, fmap *functionServiceMap, fissionCli
 		fnStore, fnController = httpTriggerSet.initFunctionController()
 		httpTriggerSet.funcStore = fnStore
 		httpTriggerSet.funcController = fnController
+		httpTriggerSet.envStore, httpTriggerSet.envController = httpTriggerSet.initEnvironmentController()
 	}
 	return httpTriggerSet, tStore, fnStore
 }
@@ -96,6 +169,10 @@ func (ts *HTTPTriggerSet) subscribeRouter(ctx context.Context, mr *mutableRouter
 	ts.resolver = resolver
 	ts.mutableRouter = mr
 
+	if ts.asyncInvoker != nil {
+		// async invocations are replayed through the router
+		go ts.asyncInvoker.run(ctx, mr)
+	}
 	if ts.fissionClient == nil {
 		// Used in tests only.
 		mr.updateRouter(ts.getRouter(nil))
@@ -106,6 +183,28 @@ func (ts *HTTPTriggerSet) subscribeRouter(ctx context.Context, mr *mutableRouter
 	go ts.syncTriggers()
 	go ts.runWatcher(ctx, ts.funcController)
 	go ts.runWatcher(ctx, ts.triggerController)
+	go ts.runWatcher(ctx, ts.envController)
+	go ts.reconcileIngresses(ctx)
+}
+
+// reconcileIngresses periodically brings the Ingresses and HTTPRoutes of
+// triggers in line with the triggers, recreating the ones deleted or
+// modified behind router's back.
+func (ts *HTTPTriggerSet) reconcileIngresses(ctx context.Context) {
+	ticker := time.NewTicker(ingressResyncPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, obj := range ts.triggerStore.List() {
+			trigger := obj.(*fv1.HTTPTrigger)
+			updateIngress(ts.logger, trigger, trigger, ts.kubeClient)
+			updateHTTPRoute(ts.logger, trigger, trigger, ts.dynamicClient)
+		}
+	}
 }
 
 func defaultHomeHandler(w http.ResponseWriter, r *http.Request) {
@@ -118,11 +217,29 @@ func routerHealthHandler(w http.ResponseWriter, r *http.Request) {
 
 func (ts *HTTPTriggerSet) getRouter(fnTimeoutMap map[types.UID]int) *mux.Router {
 	muxRouter := mux.NewRouter()
+	if ts.useEncodedPath {
+		muxRouter.UseEncodedPath()
+	}
+
+	// Async invocations of triggers, ahead of the triggers for them not to
+	// be shadowed by catch-all trigger URLs.
+	muxRouter.HandleFunc("/fission-async/results/{id}", ts.asyncInvoker.resultHandler).Methods("GET")
+	muxRouter.HandleFunc("/fission-async/{namespace}/{trigger}", ts.asyncInvoker.enqueueHandler).Methods("POST")
+	muxRouter.HandleFunc("/fission-async/{trigger}", ts.asyncInvoker.enqueueHandler).Methods("POST")
 
 	// HTTP triggers setup by the user
 	homeHandled := false
-	for i := range ts.triggers {
-		trigger := ts.triggers[i]
+	routes := make(map[types.UID]*triggerRoute, len(ts.triggers))
+	staleRoutes := false
+
+	// mux tries routes in the order they're added, add the routes of
+	// triggers with the most specific hosts first.
+	triggers := make([]fv1.HTTPTrigger, len(ts.triggers))
+	copy(triggers, ts.triggers)
+	sortTriggersByHost(triggers)
+
+	for i := range triggers {
+		trigger := triggers[i]
 
 		// resolve function reference
 		rr, err := ts.resolver.resolve(trigger)
@@ -131,6 +248,20 @@ func (ts *HTTPTriggerSet) getRouter(fnTimeoutMap map[types.UID]int) *mux.Router
 			// the trigger's status.
 			go ts.updateTriggerStatusFailed(&trigger, err)
 
+			// Keep serving the previous version of the trigger for a while
+			// rather than failing its requests, the function it refers to
+			// may just not have been seen yet.
+			if route, ok := ts.routes[trigger.ObjectMeta.UID]; ok && time.Since(route.resolvedAt) < staleRouteGracePeriod {
+				ts.logger.Warn("serving previous version of trigger with unresolvable function reference",
+					zap.Error(err),
+					zap.String("trigger", trigger.ObjectMeta.Name),
+					zap.String("namespace", trigger.ObjectMeta.Namespace))
+				homeHandled = addTriggerRoute(muxRouter, &route.trigger, route.handler) || homeHandled
+				routes[trigger.ObjectMeta.UID] = route
+				staleRoutes = true
+				continue
+			}
+
 			// Ignore this route and let it 404.
 			continue
 		}
@@ -140,6 +271,8 @@ func (ts *HTTPTriggerSet) getRouter(fnTimeoutMap map[types.UID]int) *mux.Router
 			ts.logger.Panic("resolve result type not implemented", zap.Any("type", rr.resolveResultType))
 		}
 
+		applyTriggerDefaults(&trigger.Spec, ts.triggerDefaults(rr.functionMap))
+
 		fh := &functionHandler{
 			logger:                   ts.logger.Named(trigger.ObjectMeta.Name),
 			fmap:                     ts.functionServiceMap,
@@ -152,6 +285,19 @@ func (ts *HTTPTriggerSet) getRouter(fnTimeoutMap map[types.UID]int) *mux.Router
 			svcAddrUpdateThrottler:   ts.svcAddrUpdateThrottler,
 			functionTimeoutMap:       fnTimeoutMap,
 			unTapServiceTimeout:      ts.unTapServiceTimeout,
+			jwtAuthenticator:         ts.jwtAuthenticator,
+			sessionAffinity:          ts.sessionAffinity,
+			requestValidator:         ts.requestValidator,
+			featureFlags:             ts.featureFlags,
+			circuitBreakers:          ts.circuitBreakers,
+			requestLimiter:           ts.requestLimiter,
+			guard:                    ts.guard,
+			svcDiscovery:             ts.svcDiscovery,
+			slowRequests:             ts.slowRequests,
+			invocationQuotas:         ts.invocationQuotas,
+			invocationHistory:        ts.invocationHistory,
+			invocationCounter:        ts.invocationCounter,
+			cloudEvents:              ts.cloudEvents,
 		}
 
 		// The functionHandler for HTTP trigger with fn reference type "FunctionReferenceTypeFunctionName",
@@ -162,20 +308,22 @@ func (ts *HTTPTriggerSet) getRouter(fnTimeoutMap map[types.UID]int) *mux.Router
 		// deployment. For more details, please check "handler" function of functionHandler.
 
 		if rr.resolveResultType == resolveResultSingleFunction {
-			for _, fn := range fh.functionMap {
-				fh.function = fn
-			}
+			// the function map holds experiment variants too
+			fh.function = fh.functionMap[trigger.Spec.FunctionReference.Name]
 		}
 
-		ht := muxRouter.HandleFunc(trigger.Spec.RelativeURL, fh.handler)
-		ht.Methods(trigger.Spec.Method)
-		if trigger.Spec.Host != "" {
-			ht.Host(trigger.Spec.Host)
-		}
-		if trigger.Spec.RelativeURL == "/" && trigger.Spec.Method == "GET" {
-			homeHandled = true
+		homeHandled = addTriggerRoute(muxRouter, &trigger, fh) || homeHandled
+		routes[trigger.ObjectMeta.UID] = &triggerRoute{
+			trigger:    trigger,
+			handler:    fh,
+			resolvedAt: time.Now(),
 		}
 	}
+	ts.routes = routes
+	if staleRoutes {
+		// drop the stale routes once their grace period is over
+		time.AfterFunc(staleRouteGracePeriod, ts.syncTriggers)
+	}
 	if !homeHandled {
 		//
 		// This adds a no-op handler that returns 200-OK to make sure that the
@@ -201,6 +349,14 @@ func (ts *HTTPTriggerSet) getRouter(fnTimeoutMap map[types.UID]int) *mux.Router
 			svcAddrUpdateThrottler: ts.svcAddrUpdateThrottler,
 			functionTimeoutMap:     fnTimeoutMap,
 			unTapServiceTimeout:    ts.unTapServiceTimeout,
+			requestLimiter:         ts.requestLimiter,
+			guard:                  ts.guard,
+			svcDiscovery:           ts.svcDiscovery,
+			slowRequests:           ts.slowRequests,
+			invocationQuotas:       ts.invocationQuotas,
+			invocationHistory:      ts.invocationHistory,
+			invocationCounter:      ts.invocationCounter,
+			cloudEvents:            ts.cloudEvents,
 		}
 		muxRouter.HandleFunc(utils.UrlForFunction(fn.ObjectMeta.Name, fn.ObjectMeta.Namespace), fh.handler)
 	}
@@ -211,6 +367,18 @@ func (ts *HTTPTriggerSet) getRouter(fnTimeoutMap map[types.UID]int) *mux.Router
 	return muxRouter
 }
 
+// addTriggerRoute adds the route of a trigger to the router, and
+// reports whether the trigger handles "GET /" for any host.
+func addTriggerRoute(muxRouter *mux.Router, trigger *fv1.HTTPTrigger, fh *functionHandler) bool {
+	ht := muxRouter.HandleFunc(trigger.Spec.RelativeURL, fh.handler)
+	ht.Methods(trigger.Spec.Method)
+	if trigger.Spec.Host != "" {
+		ht.MatcherFunc(hostMatcher(trigger.Spec.Host))
+		return false
+	}
+	return trigger.Spec.RelativeURL == "/" && trigger.Spec.Method == "GET"
+}
+
 func (ts *HTTPTriggerSet) updateTriggerStatusFailed(ht *fv1.HTTPTrigger, err error) {
 	// TODO
 }
@@ -223,12 +391,14 @@ func (ts *HTTPTriggerSet) initTriggerController() (k8sCache.Store, k8sCache.Cont
 			AddFunc: func(obj interface{}) {
 				trigger := obj.(*fv1.HTTPTrigger)
 				go createIngress(ts.logger, trigger, ts.kubeClient)
+				go createHTTPRoute(ts.logger, trigger, ts.dynamicClient)
 				ts.syncTriggers()
 			},
 			DeleteFunc: func(obj interface{}) {
 				ts.syncTriggers()
 				trigger := obj.(*fv1.HTTPTrigger)
 				go deleteIngress(ts.logger, trigger, ts.kubeClient)
+				go deleteHTTPRoute(ts.logger, trigger, ts.dynamicClient)
 			},
 			UpdateFunc: func(oldObj interface{}, newObj interface{}) {
 				oldTrigger := oldObj.(*fv1.HTTPTrigger)
@@ -239,6 +409,7 @@ func (ts *HTTPTriggerSet) initTriggerController() (k8sCache.Store, k8sCache.Cont
 				}
 
 				go updateIngress(ts.logger, oldTrigger, newTrigger, ts.kubeClient)
+				go updateHTTPRoute(ts.logger, oldTrigger, newTrigger, ts.dynamicClient)
 				ts.syncTriggers()
 			},
 		})
@@ -260,7 +431,8 @@ func (ts *HTTPTriggerSet) initFunctionController() (k8sCache.Store, k8sCache.Con
 				oldFn := oldObj.(*fv1.Function)
 				fn := newObj.(*fv1.Function)
 
-				if oldFn.ObjectMeta.ResourceVersion == fn.ObjectMeta.ResourceVersion {
+				// status updates don't change how functions are served
+				if !crd.SpecChanged(&oldFn.ObjectMeta, &fn.ObjectMeta) {
 					return
 				}
 
@@ -284,38 +456,91 @@ func (ts *HTTPTriggerSet) initFunctionController() (k8sCache.Store, k8sCache.Con
 	return store, controller
 }
 
+// initEnvironmentController watches environments for the HTTP trigger
+// defaults inherited by the triggers of their functions.
+func (ts *HTTPTriggerSet) initEnvironmentController() (k8sCache.Store, k8sCache.Controller) {
+	resyncPeriod := 30 * time.Second
+	listWatch := k8sCache.NewListWatchFromClient(ts.crdClient, "environments", metav1.NamespaceAll, fields.Everything())
+	store, controller := k8sCache.NewInformer(listWatch, &fv1.Environment{}, resyncPeriod,
+		k8sCache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				if obj.(*fv1.Environment).Spec.HTTPTriggerDefaults != nil {
+					ts.syncTriggers()
+				}
+			},
+			DeleteFunc: func(obj interface{}) {
+				ts.syncTriggers()
+			},
+			UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+				oldEnv := oldObj.(*fv1.Environment)
+				env := newObj.(*fv1.Environment)
+				if oldEnv.ObjectMeta.ResourceVersion == env.ObjectMeta.ResourceVersion ||
+					reflect.DeepEqual(oldEnv.Spec.HTTPTriggerDefaults, env.Spec.HTTPTriggerDefaults) {
+					return
+				}
+				ts.syncTriggers()
+			},
+		})
+	return store, controller
+}
+
 func (ts *HTTPTriggerSet) runWatcher(ctx context.Context, controller k8sCache.Controller) {
 	go func() {
 		controller.Run(ctx.Done())
 	}()
 }
 
+// syncTriggers requests a router rebuild. It never blocks: a pending
+// request covers all changes seen until the rebuild starts.
 func (ts *HTTPTriggerSet) syncTriggers() {
-	ts.updateRouterRequestChannel <- struct{}{}
+	requestUpdate(ts.updateRouterRequestChannel)
 }
 
 func (ts *HTTPTriggerSet) updateRouter() {
-	for range ts.updateRouterRequestChannel {
-		// get triggers
-		latestTriggers := ts.triggerStore.List()
-		triggers := make([]fv1.HTTPTrigger, len(latestTriggers))
-		for _, t := range latestTriggers {
-			triggers = append(triggers, *t.(*fv1.HTTPTrigger))
-		}
-		ts.triggers = triggers
-
-		// get functions
-		latestFunctions := ts.funcStore.List()
-		functionTimeout := make(map[types.UID]int, len(latestFunctions))
-		functions := make([]fv1.Function, len(latestFunctions))
-		for _, f := range latestFunctions {
-			fn := *f.(*fv1.Function)
-			functionTimeout[fn.ObjectMeta.UID] = fn.Spec.FunctionTimeout
-			functions = append(functions, *f.(*fv1.Function))
-		}
-		ts.functions = functions
+	throttleUpdates(ts.updateRouterRequestChannel, minRouterUpdateInterval, ts.rebuildRouter)
+}
+
+func (ts *HTTPTriggerSet) rebuildRouter() {
+	// get triggers
+	latestTriggers := ts.triggerStore.List()
+	triggers := make([]fv1.HTTPTrigger, 0, len(latestTriggers))
+	for _, t := range latestTriggers {
+		triggers = append(triggers, *t.(*fv1.HTTPTrigger))
+	}
+	ts.triggers = triggers
+	ts.circuitBreakers.prune(triggers)
+
+	// get functions
+	latestFunctions := ts.funcStore.List()
+	functionTimeout := make(map[types.UID]int, len(latestFunctions))
+	functions := make([]fv1.Function, 0, len(latestFunctions))
+	for _, f := range latestFunctions {
+		fn := *f.(*fv1.Function)
+		functionTimeout[fn.ObjectMeta.UID] = fn.Spec.FunctionTimeout
+		functions = append(functions, *f.(*fv1.Function))
+	}
+	ts.functions = functions
+
+	// make a new router and use it; requests in flight
+	// are finished by the router that accepted them, which is drained
+	ts.mutableRouter.updateRouter(ts.getRouter(functionTimeout))
+}
+
+// requestUpdate adds a request to an update request channel with a buffer
+// of one, unless there is a pending request already.
+func requestUpdate(requests chan<- struct{}) {
+	select {
+	case requests <- struct{}{}:
+	default:
+	}
+}
 
-		// make a new router and use it
-		ts.mutableRouter.updateRouter(ts.getRouter(functionTimeout))
+// throttleUpdates calls update for the requests of the channel until it's
+// closed, at most once per interval. Requests made meanwhile are applied
+// by a single call once the interval is over.
+func throttleUpdates(requests <-chan struct{}, interval time.Duration, update func()) {
+	for range requests {
+		update()
+		time.Sleep(interval)
 	}
 }
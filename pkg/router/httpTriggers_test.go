@@ -0,0 +1,77 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestThrottleUpdates(t *testing.T) {
+	const interval = 50 * time.Millisecond
+
+	var lock sync.Mutex
+	var updates []time.Time
+	requests := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		throttleUpdates(requests, interval, func() {
+			lock.Lock()
+			updates = append(updates, time.Now())
+			lock.Unlock()
+		})
+		close(done)
+	}()
+
+	// a burst of requests
+	start := time.Now()
+	for time.Since(start) < 4*interval {
+		requestUpdate(requests)
+		time.Sleep(time.Millisecond)
+	}
+	lastRequest := time.Now()
+	time.Sleep(3 * interval)
+	close(requests)
+	<-done
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(updates) < 2 || len(updates) > 6 {
+		t.Fatalf("expected a burst of requests to be throttled to one update per interval, got %v updates", len(updates))
+	}
+	for i := 1; i < len(updates); i++ {
+		if gap := updates[i].Sub(updates[i-1]); gap < interval {
+			t.Errorf("expected updates at least %v apart, got %v", interval, gap)
+		}
+	}
+	// requests made while throttled are applied eventually
+	if last := updates[len(updates)-1]; last.Before(lastRequest.Add(-interval)) {
+		t.Errorf("expected the last request of the burst to be applied, last update %v before it", lastRequest.Sub(last))
+	}
+}
+
+func TestRequestUpdate(t *testing.T) {
+	requests := make(chan struct{}, 1)
+	for i := 0; i < 3; i++ {
+		// never blocks
+		requestUpdate(requests)
+	}
+	if len(requests) != 1 {
+		t.Errorf("expected pending requests to be coalesced, got %v", len(requests))
+	}
+}
@@ -39,7 +39,7 @@ func init() {
 }
 
 func createIngress(logger *zap.Logger, trigger *fv1.HTTPTrigger, kubeClient *kubernetes.Clientset) {
-	if !trigger.Spec.CreateIngress {
+	if !util.UsesIngress(trigger) {
 		return
 	}
 	_, err := kubeClient.ExtensionsV1beta1().Ingresses(podNamespace).Create(util.GetIngressSpec(podNamespace, trigger))
@@ -51,7 +51,7 @@ func createIngress(logger *zap.Logger, trigger *fv1.HTTPTrigger, kubeClient *kub
 }
 
 func deleteIngress(logger *zap.Logger, trigger *fv1.HTTPTrigger, kubeClient *kubernetes.Clientset) {
-	if !trigger.Spec.CreateIngress {
+	if !util.UsesIngress(trigger) {
 		return
 	}
 
@@ -71,16 +71,16 @@ func deleteIngress(logger *zap.Logger, trigger *fv1.HTTPTrigger, kubeClient *kub
 }
 
 func updateIngress(logger *zap.Logger, oldT *fv1.HTTPTrigger, newT *fv1.HTTPTrigger, kubeClient *kubernetes.Clientset) {
-	if !oldT.Spec.CreateIngress && !newT.Spec.CreateIngress {
+	if !util.UsesIngress(oldT) && !util.UsesIngress(newT) {
 		return
 	}
 
-	if !oldT.Spec.CreateIngress && newT.Spec.CreateIngress {
+	if !util.UsesIngress(oldT) && util.UsesIngress(newT) {
 		createIngress(logger, newT, kubeClient)
 		return
 	}
 
-	if !newT.Spec.CreateIngress && oldT.Spec.CreateIngress {
+	if !util.UsesIngress(newT) && util.UsesIngress(oldT) {
 		deleteIngress(logger, oldT, kubeClient)
 		return
 	}
@@ -89,6 +89,7 @@ func updateIngress(logger *zap.Logger, oldT *fv1.HTTPTrigger, newT *fv1.HTTPTrig
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
 			createIngress(logger, newT, kubeClient)
+			return
 		}
 		logger.Error("failed to get ingress when updating trigger",
 			zap.Error(err),
@@ -0,0 +1,245 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	genClientset "github.com/fission/fission/pkg/generated/clientset/versioned"
+)
+
+const (
+	// invocationHistorySyncInterval is how often the invocations recorded
+	// are stored in the histories of functions.
+	invocationHistorySyncInterval = 10 * time.Second
+
+	invocationHistorySyncRetries = 5
+)
+
+type (
+	// invocationHistory records the invocations of functions, and stores
+	// the most recent ones in the InvocationHistory of each function,
+	// merged with the ones of the other router replicas.
+	invocationHistory struct {
+		logger        *zap.Logger
+		fissionClient genClientset.Interface
+		// size is how many invocations are kept per function.
+		size int
+
+		lock sync.Mutex
+		// unsynced are the invocations recorded since the last sync, by
+		// function UID.
+		unsynced map[k8stypes.UID]*functionInvocations
+	}
+
+	functionInvocations struct {
+		function    metav1.ObjectMeta
+		invocations []fv1.InvocationRecord
+	}
+)
+
+// parseInvocationHistorySize parses how many invocations are kept per
+// function, 0 to keep none.
+func parseInvocationHistorySize(s string) (int, error) {
+	if len(s) == 0 {
+		return fv1.DefaultInvocationHistorySize, nil
+	}
+	size, err := strconv.Atoi(s)
+	if err != nil || size < 0 {
+		return fv1.DefaultInvocationHistorySize, errors.Errorf("invalid invocation history size %q", s)
+	}
+	return size, nil
+}
+
+// makeInvocationHistory returns an invocation history keeping size
+// invocations per function, or nil if size is 0.
+func makeInvocationHistory(logger *zap.Logger, fissionClient genClientset.Interface, size int) *invocationHistory {
+	if size == 0 {
+		return nil
+	}
+	return &invocationHistory{
+		logger:        logger,
+		fissionClient: fissionClient,
+		size:          size,
+		unsynced:      make(map[k8stypes.UID]*functionInvocations),
+	}
+}
+
+// record records an invocation of fn, to be stored at the next sync.
+func (h *invocationHistory) record(fn *fv1.Function, invocation fv1.InvocationRecord) {
+	if h == nil {
+		return
+	}
+	if len(invocation.Error) > fv1.MaxInvocationErrorLength {
+		invocation.Error = invocation.Error[:fv1.MaxInvocationErrorLength]
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	fi, ok := h.unsynced[fn.ObjectMeta.UID]
+	if !ok {
+		fi = &functionInvocations{
+			function: metav1.ObjectMeta{
+				Name:      fn.ObjectMeta.Name,
+				Namespace: fn.ObjectMeta.Namespace,
+				UID:       fn.ObjectMeta.UID,
+			},
+		}
+		h.unsynced[fn.ObjectMeta.UID] = fi
+	}
+	fi.invocations = append(fi.invocations, invocation)
+	// only the most recent ones would be kept anyway
+	if len(fi.invocations) > h.size {
+		fi.invocations = fi.invocations[len(fi.invocations)-h.size:]
+	}
+}
+
+// run stores the invocations recorded in the histories of functions until
+// ctx is done.
+func (h *invocationHistory) run(ctx context.Context) {
+	if h == nil {
+		return
+	}
+	ticker := time.NewTicker(invocationHistorySyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := h.sync()
+		if err != nil {
+			h.logger.Error("error storing invocation history", zap.Error(err))
+		}
+	}
+}
+
+// flush stores the invocations recorded since the last sync, so that they
+// are not lost when router shuts down.
+func (h *invocationHistory) flush(ctx context.Context) error {
+	if h == nil {
+		return nil
+	}
+	return h.sync()
+}
+
+// sync stores the invocations recorded since the last sync. The
+// invocations of functions that failed to be stored are dropped.
+func (h *invocationHistory) sync() error {
+	h.lock.Lock()
+	unsynced := h.unsynced
+	h.unsynced = make(map[k8stypes.UID]*functionInvocations)
+	h.lock.Unlock()
+
+	var lastErr error
+	for _, fi := range unsynced {
+		err := h.store(fi)
+		if err != nil {
+			lastErr = errors.Wrapf(err, "error storing invocations of function %v/%v", fi.function.Namespace, fi.function.Name)
+		}
+	}
+	return lastErr
+}
+
+// store adds invocations to the history of their function, keeping the
+// most recent ones.
+func (h *invocationHistory) store(fi *functionInvocations) error {
+	client := h.fissionClient.CoreV1().InvocationHistories(fi.function.Namespace)
+
+	var err error
+	for i := 0; i < invocationHistorySyncRetries; i++ {
+		var history *fv1.InvocationHistory
+		history, err = client.Get(fi.function.Name, metav1.GetOptions{})
+		exists := err == nil
+		if k8serrors.IsNotFound(err) {
+			history = &fv1.InvocationHistory{}
+		} else if err != nil {
+			return err
+		}
+
+		stored := history.Invocations
+		if !ownedBy(&history.ObjectMeta, fi.function.UID) {
+			// the history of a deleted function of the same name
+			stored = nil
+		}
+		history.ObjectMeta = metav1.ObjectMeta{
+			Name:            fi.function.Name,
+			Namespace:       fi.function.Namespace,
+			ResourceVersion: history.ObjectMeta.ResourceVersion,
+			Labels:          history.ObjectMeta.Labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: fv1.SchemeGroupVersion.String(),
+					Kind:       "Function",
+					Name:       fi.function.Name,
+					UID:        fi.function.UID,
+				},
+			},
+		}
+		history.Invocations = mergeInvocations(stored, fi.invocations, h.size)
+
+		if !exists {
+			_, err = client.Create(history)
+		} else {
+			_, err = client.Update(history)
+		}
+		if err == nil {
+			return nil
+		}
+		if !k8serrors.IsConflict(err) && !k8serrors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// ownedBy returns whether an object is owned by the object of a UID.
+func ownedBy(m *metav1.ObjectMeta, uid k8stypes.UID) bool {
+	for _, ref := range m.OwnerReferences {
+		if ref.UID == uid {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeInvocations returns the size most recent invocations of stored and
+// recorded, the most recent first.
+func mergeInvocations(stored []fv1.InvocationRecord, recorded []fv1.InvocationRecord, size int) []fv1.InvocationRecord {
+	merged := make([]fv1.InvocationRecord, 0, len(stored)+len(recorded))
+	merged = append(merged, stored...)
+	merged = append(merged, recorded...)
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Time.After(merged[j].Time.Time)
+	})
+	if len(merged) > size {
+		merged = merged[:size]
+	}
+	return merged
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/generated/clientset/versioned/fake"
+)
+
+func TestInvocationHistory(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	h := makeInvocationHistory(zap.NewNop(), client, 3)
+	fn := &fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: metav1.NamespaceDefault, UID: "5e0b7a2e-1b1c-4c39-8f4e-2a7d0f3f0c11"},
+	}
+
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	invocation := func(i int, statusCode int) fv1.InvocationRecord {
+		return fv1.InvocationRecord{
+			Time:       metav1.NewTime(start.Add(time.Duration(i) * time.Second)),
+			Duration:   metav1.Duration{Duration: time.Millisecond},
+			StatusCode: statusCode,
+		}
+	}
+	stored := func() []fv1.InvocationRecord {
+		history, err := client.CoreV1().InvocationHistories(fn.ObjectMeta.Namespace).Get(fn.ObjectMeta.Name, metav1.GetOptions{})
+		assert.NoError(t, err)
+		return history.Invocations
+	}
+
+	failed := invocation(1, 502)
+	failed.Error = strings.Repeat("x", fv1.MaxInvocationErrorLength+10)
+	h.record(fn, invocation(0, 200))
+	h.record(fn, failed)
+	assert.NoError(t, h.sync())
+
+	invocations := stored()
+	assert.Len(t, invocations, 2)
+	assert.Equal(t, 502, invocations[0].StatusCode)
+	assert.Len(t, invocations[0].Error, fv1.MaxInvocationErrorLength)
+
+	// the most recent invocations are kept, across syncs
+	h.record(fn, invocation(3, 200))
+	h.record(fn, invocation(2, 404))
+	assert.NoError(t, h.sync())
+	invocations = stored()
+	assert.Len(t, invocations, 3)
+	for i, code := range []int{200, 404, 502} {
+		assert.Equal(t, code, invocations[i].StatusCode)
+	}
+
+	// a function recreated with the same name starts a new history
+	recreated := fn.DeepCopy()
+	recreated.ObjectMeta.UID = "0c9d1f6a-7a43-4d1c-9d0e-6f1b8a2b3c44"
+	h.record(recreated, invocation(4, 201))
+	assert.NoError(t, h.sync())
+	invocations = stored()
+	assert.Len(t, invocations, 1)
+	assert.Equal(t, 201, invocations[0].StatusCode)
+
+	// nothing is recorded with no history
+	var disabled *invocationHistory
+	disabled.record(fn, invocation(5, 200))
+	assert.Nil(t, makeInvocationHistory(zap.NewNop(), client, 0))
+}
+
+func TestParseInvocationHistorySize(t *testing.T) {
+	size, err := parseInvocationHistorySize("")
+	assert.NoError(t, err)
+	assert.Equal(t, fv1.DefaultInvocationHistorySize, size)
+
+	size, err = parseInvocationHistorySize("0")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, size)
+
+	_, err = parseInvocationHistorySize("-1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/qos"
+)
+
+const (
+	// quotaUsageConfigMap keeps the invocation counts of all router
+	// replicas, so that quotas hold across replicas and restarts.
+	quotaUsageConfigMap = "fission-router-quota-usage"
+	quotaUsageDataKey   = "usage.json"
+
+	// quotaSyncInterval is how often invocation counts are synced.
+	// Replicas may let through the invocations of an interval beyond
+	// a quota between syncs.
+	quotaSyncInterval = 10 * time.Second
+
+	quotaSyncRetries = 5
+)
+
+// invocationQuotas enforces the invocation quotas of functions and
+// namespaces.
+type invocationQuotas struct {
+	logger  *zap.Logger
+	tracker *qos.QuotaTracker
+
+	// namespaceQuotas are the quotas of all functions of a namespace
+	// together, by namespace.
+	namespaceQuotas map[string][]fv1.InvocationQuota
+
+	// kubeClient and namespace locate the config map counts are kept
+	// in, counts are kept in memory only if kubeClient is nil.
+	kubeClient kubernetes.Interface
+	namespace  string
+}
+
+// parseNamespaceQuotas parses the quotas of namespaces given as a comma
+// separated list of <namespace>=<limit>/<window>, like
+// "team-a=100000/day,team-a=2000000/month".
+func parseNamespaceQuotas(s string) (map[string][]fv1.InvocationQuota, error) {
+	quotas := make(map[string][]fv1.InvocationQuota)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			return nil, errors.Errorf("namespace quota %q must be <namespace>=<limit>/<window>", item)
+		}
+		quota, err := qos.ParseInvocationQuota(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range quotas[parts[0]] {
+			if q.Window == quota.Window {
+				return nil, errors.Errorf("namespace %q has more than one quota per %v", parts[0], quota.Window)
+			}
+		}
+		quotas[parts[0]] = append(quotas[parts[0]], quota)
+	}
+	return quotas, nil
+}
+
+func makeInvocationQuotas(logger *zap.Logger, kubeClient kubernetes.Interface, namespace string, namespaceQuotas map[string][]fv1.InvocationQuota) *invocationQuotas {
+	return &invocationQuotas{
+		logger:          logger,
+		tracker:         qos.MakeQuotaTracker(),
+		namespaceQuotas: namespaceQuotas,
+		kubeClient:      kubeClient,
+		namespace:       namespace,
+	}
+}
+
+// allow counts an invocation of fn unless fn or its namespace used up a
+// quota. In that case it returns false and when invocations are allowed
+// again.
+func (q *invocationQuotas) allow(fn *fv1.Function) (bool, time.Time) {
+	if q == nil {
+		return true, time.Time{}
+	}
+	var limits []qos.QuotaLimit
+	for _, quota := range fn.Spec.InvocationQuotas {
+		limits = append(limits, qos.QuotaLimit{
+			Key:   "function/" + fn.ObjectMeta.Namespace + "/" + fn.ObjectMeta.Name,
+			Quota: quota,
+		})
+	}
+	for _, quota := range q.namespaceQuotas[fn.ObjectMeta.Namespace] {
+		limits = append(limits, qos.QuotaLimit{
+			Key:   "namespace/" + fn.ObjectMeta.Namespace,
+			Quota: quota,
+		})
+	}
+	return q.tracker.Allow(limits)
+}
+
+// run syncs the invocation counts with the other router replicas until
+// ctx is done.
+func (q *invocationQuotas) run(ctx context.Context) {
+	if q == nil || q.kubeClient == nil {
+		return
+	}
+	ticker := time.NewTicker(quotaSyncInterval)
+	defer ticker.Stop()
+	for {
+		err := q.sync()
+		if err != nil {
+			q.logger.Error("error syncing invocation quota usage", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// flush syncs the invocations counted since the last sync, so that they are
+// not lost when router shuts down.
+func (q *invocationQuotas) flush(ctx context.Context) error {
+	if q == nil || q.kubeClient == nil {
+		return nil
+	}
+	return q.sync()
+}
+
+// sync adds the invocations counted since the last sync to the config
+// map, and takes the counts of all replicas from it.
+func (q *invocationQuotas) sync() error {
+	client := q.kubeClient.CoreV1().ConfigMaps(q.namespace)
+	unsynced := q.tracker.Unsynced()
+
+	var err error
+	for i := 0; i < quotaSyncRetries; i++ {
+		var cm *apiv1.ConfigMap
+		cm, err = client.Get(quotaUsageConfigMap, metav1.GetOptions{})
+		exists := err == nil
+		if k8serrors.IsNotFound(err) {
+			cm = &apiv1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      quotaUsageConfigMap,
+					Namespace: q.namespace,
+				},
+			}
+		} else if err != nil {
+			return errors.Wrap(err, "error getting quota usage config map")
+		}
+
+		stored := make(map[string]qos.QuotaUsage)
+		if data, ok := cm.Data[quotaUsageDataKey]; ok {
+			err = json.Unmarshal([]byte(data), &stored)
+			if err != nil {
+				q.logger.Error("error decoding quota usage, resetting it", zap.Error(err))
+				stored = make(map[string]qos.QuotaUsage)
+			}
+		}
+		if len(unsynced) == 0 && exists {
+			q.tracker.Synced(unsynced, stored)
+			return nil
+		}
+
+		merged := qos.MergeQuotaUsage(stored, unsynced, time.Now())
+		var data []byte
+		data, err = json.Marshal(merged)
+		if err != nil {
+			return errors.Wrap(err, "error encoding quota usage")
+		}
+		cm.Data = map[string]string{quotaUsageDataKey: string(data)}
+
+		if !exists {
+			_, err = client.Create(cm)
+		} else {
+			_, err = client.Update(cm)
+		}
+		if err == nil {
+			q.tracker.Synced(unsynced, merged)
+			return nil
+		}
+		if !k8serrors.IsConflict(err) && !k8serrors.IsAlreadyExists(err) {
+			return errors.Wrap(err, "error updating quota usage config map")
+		}
+	}
+	return errors.Wrap(err, "error updating quota usage config map")
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestParseNamespaceQuotas(t *testing.T) {
+	quotas, err := parseNamespaceQuotas("team-a=100/day, team-a=2000/month,team-b=5/day")
+	assert.NoError(t, err)
+	assert.Equal(t, []fv1.InvocationQuota{
+		{Limit: 100, Window: fv1.QuotaWindowDay},
+		{Limit: 2000, Window: fv1.QuotaWindowMonth},
+	}, quotas["team-a"])
+	assert.Len(t, quotas["team-b"], 1)
+
+	quotas, err = parseNamespaceQuotas("")
+	assert.NoError(t, err)
+	assert.Empty(t, quotas)
+
+	for _, s := range []string{"team-a", "=5/day", "team-a=5", "team-a=5/day,team-a=6/day"} {
+		_, err = parseNamespaceQuotas(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestInvocationQuotas(t *testing.T) {
+	fn := &fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"},
+		Spec: fv1.FunctionSpec{
+			InvocationQuotas: []fv1.InvocationQuota{{Limit: 2, Window: fv1.QuotaWindowDay}},
+		},
+	}
+	other := &fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+	}
+
+	kubeClient := fake.NewSimpleClientset()
+	q := makeInvocationQuotas(zap.NewNop(), kubeClient, "fission",
+		map[string][]fv1.InvocationQuota{"default": {{Limit: 3, Window: fv1.QuotaWindowMonth}}})
+
+	ok, _ := q.allow(fn)
+	assert.True(t, ok)
+	ok, _ = q.allow(fn)
+	assert.True(t, ok)
+	ok, retryAt := q.allow(fn)
+	assert.False(t, ok, "expected function quota to be exhausted")
+	assert.False(t, retryAt.IsZero())
+
+	ok, _ = q.allow(other)
+	assert.True(t, ok)
+	ok, _ = q.allow(other)
+	assert.False(t, ok, "expected namespace quota to be exhausted")
+
+	// usage is kept across restarts through the config map
+	assert.NoError(t, q.sync())
+	restarted := makeInvocationQuotas(zap.NewNop(), kubeClient, "fission", nil)
+	assert.NoError(t, restarted.sync())
+	ok, _ = restarted.allow(fn)
+	assert.False(t, ok, "expected function quota to be exhausted after restart")
+
+	var disabled *invocationQuotas
+	ok, _ = disabled.allow(fn)
+	assert.True(t, ok)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/jwt"
+)
+
+const (
+	// HEADERS_FISSION_AUTH_SUBJECT is the request header carrying the "sub" claim of a verified token
+	HEADERS_FISSION_AUTH_SUBJECT = "X-Fission-Auth-Subject"
+)
+
+type (
+	// jwtAuthenticator verifies JSON Web Tokens with key sets
+	// fetched from the JWKS URL configured in HTTP triggers, or
+	// discovered from their OpenID Connect issuer.
+	// It's shared by all function handlers so that key sets
+	// survive router rebuilds.
+	jwtAuthenticator struct {
+		verifier *jwt.Verifier
+	}
+)
+
+func makeJWTAuthenticator(logger *zap.Logger) *jwtAuthenticator {
+	return &jwtAuthenticator{
+		verifier: jwt.MakeVerifier(logger.Named("jwt_authenticator")),
+	}
+}
+
+// authenticate extracts the bearer token from the request and verifies it
+// against the given config. It returns the claims of a valid token.
+func (auth *jwtAuthenticator) authenticate(request *http.Request, config *fv1.JWTAuthentication) (map[string]interface{}, error) {
+	token, err := jwt.BearerToken(request)
+	if err != nil {
+		return nil, err
+	}
+	return auth.verify(token, config)
+}
+
+func (auth *jwtAuthenticator) verify(token string, config *fv1.JWTAuthentication) (map[string]interface{}, error) {
+	jwksURL := config.JWKSURL
+	if len(jwksURL) == 0 {
+		var err error
+		jwksURL, err = auth.verifier.DiscoverJWKSURL(config.Issuer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	claims, err := auth.verifier.Verify(token, jwksURL)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateClaims(claims, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func validateClaims(claims map[string]interface{}, config *fv1.JWTAuthentication) error {
+	if len(config.Issuer) > 0 && claims["iss"] != config.Issuer {
+		return errors.Errorf("unexpected issuer %v", claims["iss"])
+	}
+
+	if len(config.Audiences) > 0 && !jwt.AudienceMatches(claims["aud"], config.Audiences) {
+		return errors.Errorf("unexpected audience %v", claims["aud"])
+	}
+
+	for name, value := range config.RequiredClaims {
+		v, ok := claims[name]
+		if !ok || jwt.ClaimToString(v) != value {
+			return errors.Errorf("claim %q does not match required value", name)
+		}
+	}
+
+	return nil
+}
+
+// setAuthClaimsToHeader passes the claims of a verified token to the
+// function with the header names configured in the trigger.
+func setAuthClaimsToHeader(claims map[string]interface{}, config *fv1.JWTAuthentication, request *http.Request) {
+	// Remove headers a client might have set to spoof an identity.
+	request.Header.Del(HEADERS_FISSION_AUTH_SUBJECT)
+	for _, header := range config.ClaimsToHeaders {
+		request.Header.Del(header)
+	}
+
+	if sub, ok := claims["sub"]; ok {
+		request.Header.Set(HEADERS_FISSION_AUTH_SUBJECT, jwt.ClaimToString(sub))
+	}
+	for claim, header := range config.ClaimsToHeaders {
+		if v, ok := claims[claim]; ok {
+			request.Header.Set(header, jwt.ClaimToString(v))
+		}
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func signTestToken(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	assert.Nil(t, err)
+	payload, err := json.Marshal(claims)
+	assert.Nil(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hasher := crypto.SHA256.New()
+	hasher.Write([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hasher.Sum(nil))
+	assert.Nil(t, err)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"keys":[{"kid":"k1","kty":"RSA","use":"sig","n":"%v","e":"%v"}]}`,
+			base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()))
+	}))
+	defer jwksServer.Close()
+
+	config := &fv1.JWTAuthentication{
+		JWKSURL:         jwksServer.URL,
+		Issuer:          "https://issuer.example.com",
+		Audiences:       []string{"fission"},
+		RequiredClaims:  map[string]string{"role": "admin"},
+		ClaimsToHeaders: map[string]string{"email": "X-User-Email"},
+	}
+	auth := makeJWTAuthenticator(zap.NewNop())
+
+	claims := map[string]interface{}{
+		"sub":   "user-1",
+		"iss":   "https://issuer.example.com",
+		"aud":   []string{"other", "fission"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"role":  "admin",
+		"email": "user@example.com",
+	}
+
+	req := httptest.NewRequest("GET", "http://foobar.com", nil)
+	req.Header.Set("Authorization", "Bearer "+signTestToken(t, key, "k1", claims))
+	req.Header.Set("X-User-Email", "spoofed@example.com")
+	verified, err := auth.authenticate(req, config)
+	assert.Nil(t, err)
+
+	setAuthClaimsToHeader(verified, config, req)
+	assert.Equal(t, "user-1", req.Header.Get(HEADERS_FISSION_AUTH_SUBJECT))
+	assert.Equal(t, "user@example.com", req.Header.Get("X-User-Email"))
+
+	// missing token
+	req = httptest.NewRequest("GET", "http://foobar.com", nil)
+	_, err = auth.authenticate(req, config)
+	assert.NotNil(t, err)
+
+	// tampered signature
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	_, err = auth.verify(signTestToken(t, otherKey, "k1", claims), config)
+	assert.NotNil(t, err)
+
+	// expired token
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	_, err = auth.verify(signTestToken(t, key, "k1", claims), config)
+	assert.NotNil(t, err)
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+
+	// wrong audience
+	claims["aud"] = "other"
+	_, err = auth.verify(signTestToken(t, key, "k1", claims), config)
+	assert.NotNil(t, err)
+	claims["aud"] = "fission"
+
+	// required claim mismatch
+	claims["role"] = "viewer"
+	_, err = auth.verify(signTestToken(t, key, "k1", claims), config)
+	assert.NotNil(t, err)
+}
+
+func TestJWTAuthenticatorDiscovery(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	discoveries := 0
+	var issuer string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			discoveries++
+			fmt.Fprintf(w, `{"issuer":"%v","jwks_uri":"%v/keys"}`, issuer, issuer)
+		case "/keys":
+			fmt.Fprintf(w, `{"keys":[{"kid":"k1","kty":"RSA","use":"sig","n":"%v","e":"%v"}]}`,
+				base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	issuer = server.URL
+
+	// no JWKS URL, the key set is found from the issuer
+	config := &fv1.JWTAuthentication{Issuer: issuer}
+	auth := makeJWTAuthenticator(zap.NewNop())
+	claims := map[string]interface{}{
+		"sub": "user-1",
+		"iss": issuer,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for i := 0; i < 2; i++ {
+		_, err = auth.verify(signTestToken(t, key, "k1", claims), config)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 1, discoveries, "discovered key set URL is cached")
+
+	// a token of another issuer is rejected
+	claims["iss"] = "https://other.example.com"
+	_, err = auth.verify(signTestToken(t, key, "k1", claims), config)
+	assert.NotNil(t, err)
+
+	// discovery of an issuer without a discovery document fails
+	_, err = auth.verify(signTestToken(t, key, "k1", claims), &fv1.JWTAuthentication{Issuer: issuer + "/missing"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"net/url"
+	"sync"
+	"time"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// podKeepAlive keeps the pod serving a long poll from being reclaimed as
+// idle, by tapping it at an interval while the poll is open.
+type podKeepAlive struct {
+	lock       sync.Mutex
+	serviceURL *url.URL
+}
+
+// longPollSettings returns how long a poll of the policy is held open, and
+// the interval at which its pod is kept alive.
+func longPollSettings(policy *fv1.LongPollPolicy) (time.Duration, time.Duration) {
+	timeout := time.Duration(policy.Timeout) * time.Second
+	if policy.Timeout == 0 {
+		timeout = fv1.DefaultLongPollTimeout * time.Second
+	}
+	interval := time.Duration(policy.KeepAliveInterval) * time.Second
+	if policy.KeepAliveInterval == 0 {
+		interval = fv1.DefaultLongPollKeepAliveInterval * time.Second
+	}
+	return timeout, interval
+}
+
+// serving records the service the poll was sent to.
+func (ka *podKeepAlive) serving(serviceURL *url.URL) {
+	if ka == nil {
+		return
+	}
+	ka.lock.Lock()
+	ka.serviceURL = serviceURL
+	ka.lock.Unlock()
+}
+
+func (ka *podKeepAlive) current() *url.URL {
+	ka.lock.Lock()
+	defer ka.lock.Unlock()
+	return ka.serviceURL
+}
+
+// run taps the service of the poll at every interval until done is closed.
+func (ka *podKeepAlive) run(tap func(*url.URL), interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if serviceURL := ka.current(); serviceURL != nil {
+				tap(serviceURL)
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestLongPollSettings(t *testing.T) {
+	timeout, interval := longPollSettings(&fv1.LongPollPolicy{})
+	assert.Equal(t, fv1.DefaultLongPollTimeout*time.Second, timeout)
+	assert.Equal(t, fv1.DefaultLongPollKeepAliveInterval*time.Second, interval)
+
+	timeout, interval = longPollSettings(&fv1.LongPollPolicy{Timeout: 60, KeepAliveInterval: 5})
+	assert.Equal(t, 60*time.Second, timeout)
+	assert.Equal(t, 5*time.Second, interval)
+}
+
+func TestPodKeepAlive(t *testing.T) {
+	var nilKeepAlive *podKeepAlive
+	nilKeepAlive.serving(&url.URL{Host: "ignored"})
+
+	taps := make(chan *url.URL, 10)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	ka := &podKeepAlive{}
+	go func() {
+		ka.run(func(u *url.URL) { taps <- u }, 10*time.Millisecond, done)
+		close(stopped)
+	}()
+
+	// nothing is tapped before the poll is sent to a service
+	time.Sleep(30 * time.Millisecond)
+	assert.Empty(t, taps)
+
+	serviceURL := &url.URL{Scheme: "http", Host: "10.0.0.1:8888"}
+	ka.serving(serviceURL)
+	select {
+	case u := <-taps:
+		assert.Equal(t, serviceURL, u)
+	case <-time.After(time.Second):
+		t.Fatal("expected service to be tapped")
+	}
+
+	close(done)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("expected keep-alive to stop")
+	}
+}
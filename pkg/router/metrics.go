@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 )
 
 var globalFunctionCallCount uint64
@@ -37,6 +39,17 @@ type (
 		method string
 		code   int
 	}
+
+	// triggerLabels is the set of metrics labels that relate to
+	// the trigger a function call is made by.
+	//
+	// triggerType is one of http, messagequeue, time and kubewatch
+	// namespace and name are the metadata of the trigger.
+	triggerLabels struct {
+		triggerType string
+		namespace   string
+		name        string
+	}
 )
 
 var (
@@ -74,6 +87,17 @@ var (
 		},
 		labelsStrings,
 	)
+	// Function call duration histogram, unlike the summary above, can be
+	// aggregated across router instances and used to compute any quantile
+	// (e.g. p95 for canary latency SLOs).
+	functionCallLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "fission_function_latency_seconds",
+			Help:    "Histogram of the Fission function call duration.",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+		[]string{"namespace", "name", "path", "method"},
+	)
 	functionCallOverhead = prometheus.NewSummaryVec(
 		prometheus.SummaryOpts{
 			Name:       "fission_function_overhead_seconds",
@@ -90,14 +114,80 @@ var (
 		},
 		labelsStrings,
 	)
+
+	// Function in-flight requests
+	// namespace: function namespace
+	// name: function name
+	// newdeploy functions with TargetConcurrencyPerPod set scale on this metric.
+	functionInflightRequests = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: fv1.FunctionInflightRequestsMetric,
+			Help: "Number of requests being processed by the Fission function.",
+		},
+		[]string{"namespace", "name"},
+	)
+
+	// Trigger calls
+	// type: trigger type
+	// namespace: trigger namespace
+	// name: trigger name
+	triggerLabelsStrings = []string{"type", "namespace", "name"}
+	triggerCalls         = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fv1.TriggerCallsMetric,
+			Help: "Count of function calls made by Fission triggers",
+		},
+		triggerLabelsStrings,
+	)
+	triggerCallErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fv1.TriggerErrorsMetric,
+			Help: "Count of failed function calls made by Fission triggers",
+		},
+		triggerLabelsStrings,
+	)
+	triggerLastSuccess = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: fv1.TriggerLastSuccessMetric,
+			Help: "Unix time of the last successful function call made by Fission triggers",
+		},
+		triggerLabelsStrings,
+	)
+	triggerLag = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: fv1.TriggerLagMetric,
+			Help: "Delay between an event being produced and router receiving it, for the last event of Fission triggers",
+		},
+		triggerLabelsStrings,
+	)
+
+	// Experiment exposures
+	// namespace: trigger namespace
+	// trigger: trigger name
+	// experiment: experiment name
+	// variant: variant the request was sent to
+	experimentExposures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fission_experiment_exposures_total",
+			Help: "Count of requests sent to A/B experiment variants",
+		},
+		[]string{"namespace", "trigger", "experiment", "variant"},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(functionCalls)
 	prometheus.MustRegister(functionCallErrors)
 	prometheus.MustRegister(functionCallDuration)
+	prometheus.MustRegister(functionCallLatency)
 	prometheus.MustRegister(functionCallOverhead)
 	prometheus.MustRegister(functionCallResponseSize)
+	prometheus.MustRegister(functionInflightRequests)
+	prometheus.MustRegister(triggerCalls)
+	prometheus.MustRegister(triggerCallErrors)
+	prometheus.MustRegister(triggerLastSuccess)
+	prometheus.MustRegister(triggerLag)
+	prometheus.MustRegister(experimentExposures)
 }
 
 func labelsToStrings(f *functionLabels, h *httpLabels) []string {
@@ -136,9 +226,41 @@ func functionCallCompleted(f *functionLabels, h *httpLabels, overhead, duration
 
 	// duration summary
 	functionCallDuration.WithLabelValues(l...).Observe(float64(duration.Nanoseconds()) / 1e9)
+	functionCallLatency.WithLabelValues(f.namespace, f.name, h.path, h.method).Observe(float64(duration.Nanoseconds()) / 1e9)
 
 	// Response size.  -1 means the size unknown, in which case we don't report it.
 	if respSize != -1 {
 		functionCallResponseSize.WithLabelValues(l...).Observe(float64(respSize))
 	}
 }
+
+// functionRequestStarted increases the in-flight request count of a function
+// and returns a function to be called once the request finishes.
+func functionRequestStarted(namespace, name string) func() {
+	gauge := functionInflightRequests.WithLabelValues(namespace, name)
+	gauge.Inc()
+	return gauge.Dec
+}
+
+// triggerCallCompleted records a function call made by a trigger. eventTime is the
+// time the event was produced at, and the zero time if unknown.
+func triggerCallCompleted(t *triggerLabels, code int, start time.Time, eventTime time.Time) {
+	l := []string{t.triggerType, t.namespace, t.name}
+
+	triggerCalls.WithLabelValues(l...).Inc()
+
+	if code >= 400 {
+		triggerCallErrors.WithLabelValues(l...).Inc()
+	} else {
+		triggerLastSuccess.WithLabelValues(l...).Set(float64(time.Now().Unix()))
+	}
+
+	if !eventTime.IsZero() {
+		triggerLag.WithLabelValues(l...).Set(start.Sub(eventTime).Seconds())
+	}
+}
+
+// experimentExposed records a request sent to a variant of an experiment.
+func experimentExposed(namespace, trigger, experiment, variant string) {
+	experimentExposures.WithLabelValues(namespace, trigger, experiment, variant).Inc()
+}
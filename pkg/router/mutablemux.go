@@ -18,40 +18,149 @@ package router
 
 import (
 	"net/http"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 )
 
+const (
+	// routerDrainTimeout is how long a replaced router generation is
+	// waited for before it's reported as not drained.
+	routerDrainTimeout = 10 * time.Minute
+)
+
 //
 // mutableRouter wraps the mux router, and allows the router to be
 // atomically changed.
 //
+// Every router is a generation. A request is served to completion by
+// the generation that accepted it, even if the router is replaced
+// meanwhile, so updating the router never drops in-flight requests.
+// A replaced generation is drained: it accepts no new requests, and is
+// released once its in-flight requests are over.
+//
 
-type mutableRouter struct {
-	logger *zap.Logger
-	router atomic.Value // mux.Router
-}
+type (
+	mutableRouter struct {
+		logger     *zap.Logger
+		generation uint64
+		router     atomic.Value // *routerGeneration
+	}
+
+	routerGeneration struct {
+		id     uint64
+		router *mux.Router
+
+		lock     sync.Mutex
+		inflight int
+		replaced bool
+		// drained is closed once the generation is replaced and its
+		// in-flight requests are over.
+		drained chan struct{}
+	}
+)
 
 func newMutableRouter(logger *zap.Logger, handler *mux.Router) *mutableRouter {
 	mr := mutableRouter{
 		logger: logger.Named("mutable_router"),
 	}
-	mr.router.Store(handler)
+	mr.router.Store(newRouterGeneration(0, handler))
 	return &mr
 }
 
+func newRouterGeneration(id uint64, router *mux.Router) *routerGeneration {
+	return &routerGeneration{
+		id:      id,
+		router:  router,
+		drained: make(chan struct{}),
+	}
+}
+
 func (mr *mutableRouter) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
-	// Atomically grab the underlying mux router and call it.
-	routerValue := mr.router.Load()
-	router, ok := routerValue.(*mux.Router)
+	for {
+		// Atomically grab the current generation and call its router.
+		gen := mr.currentGeneration()
+		if !gen.acquire() {
+			// drained already, the router was replaced since
+			continue
+		}
+		defer gen.release()
+		gen.router.ServeHTTP(responseWriter, request)
+		return
+	}
+}
+
+func (mr *mutableRouter) currentGeneration() *routerGeneration {
+	gen, ok := mr.router.Load().(*routerGeneration)
 	if !ok {
 		mr.logger.Panic("invalid router type")
 	}
-	router.ServeHTTP(responseWriter, request)
+	return gen
 }
 
+// updateRouter makes newHandler serve new requests, and drains the
+// router it replaces in the background.
 func (mr *mutableRouter) updateRouter(newHandler *mux.Router) {
-	mr.router.Store(newHandler)
+	gen := newRouterGeneration(atomic.AddUint64(&mr.generation, 1), newHandler)
+	old := mr.currentGeneration()
+	mr.router.Store(gen)
+	old.replace()
+	go mr.drain(old)
+}
+
+// drain waits for the in-flight requests of a replaced generation to be over.
+func (mr *mutableRouter) drain(gen *routerGeneration) {
+	start := time.Now()
+	timer := time.NewTimer(routerDrainTimeout)
+	defer timer.Stop()
+	select {
+	case <-gen.drained:
+		mr.logger.Debug("replaced router drained",
+			zap.Uint64("generation", gen.id),
+			zap.Duration("elapsed", time.Since(start)))
+	case <-timer.C:
+		mr.logger.Warn("replaced router still serving requests",
+			zap.Uint64("generation", gen.id),
+			zap.Int("inflight", gen.inflightRequests()))
+	}
+}
+
+// acquire adds a request to the generation, unless it's drained already.
+func (gen *routerGeneration) acquire() bool {
+	gen.lock.Lock()
+	defer gen.lock.Unlock()
+	if gen.replaced && gen.inflight == 0 {
+		return false
+	}
+	gen.inflight++
+	return true
+}
+
+// release removes a request added by acquire.
+func (gen *routerGeneration) release() {
+	gen.lock.Lock()
+	defer gen.lock.Unlock()
+	gen.inflight--
+	if gen.replaced && gen.inflight == 0 {
+		close(gen.drained)
+	}
+}
+
+// replace marks the generation replaced, for it to be drained.
+func (gen *routerGeneration) replace() {
+	gen.lock.Lock()
+	defer gen.lock.Unlock()
+	gen.replaced = true
+	if gen.inflight == 0 {
+		close(gen.drained)
+	}
+}
+
+func (gen *routerGeneration) inflightRequests() int {
+	gen.lock.Lock()
+	defer gen.lock.Unlock()
+	return gen.inflight
 }
@@ -17,8 +17,10 @@ limitations under the License.
 package router
 
 import (
+	"io/ioutil"
 	"log"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -109,3 +111,63 @@ func TestMutableMux(t *testing.T) {
 	q <- true
 	time.Sleep(100 * time.Millisecond)
 }
+
+func TestMutableMuxDrain(t *testing.T) {
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	oldRouter := mux.NewRouter()
+	oldRouter.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-finish
+		OldHandler(w, r)
+	})
+	mr := newMutableRouter(zap.NewNop(), oldRouter)
+	oldGen := mr.currentGeneration()
+
+	server := httptest.NewServer(mr)
+	defer server.Close()
+
+	oldResponse := make(chan string)
+	go func() {
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			oldResponse <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		oldResponse <- string(body)
+	}()
+	<-started
+
+	newRouter := mux.NewRouter()
+	newRouter.HandleFunc("/", NewHandler)
+	mr.updateRouter(newRouter)
+
+	// new requests are served by the new router while the old one drains
+	testRequest(server.URL, "new handler")
+	select {
+	case <-oldGen.drained:
+		t.Fatal("expected replaced router not to be drained with a request in flight")
+	default:
+	}
+	if oldGen.acquire() {
+		oldGen.release()
+	} else {
+		t.Fatal("expected replaced router to accept requests until drained")
+	}
+
+	close(finish)
+	if body := <-oldResponse; body != "old handler" {
+		t.Fatalf("expected in-flight request to be finished by the old router, got %q", body)
+	}
+	select {
+	case <-oldGen.drained:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected replaced router to be drained")
+	}
+	if oldGen.acquire() {
+		t.Fatal("expected drained router not to accept requests")
+	}
+	testRequest(server.URL, "new handler")
+}
@@ -0,0 +1,800 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"mime"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxValidatedBodySize limits the size of request bodies router reads for validation.
+	maxValidatedBodySize = 10 << 20
+
+	// maxSchemaDepth guards against schemas referring to themselves without consuming any data.
+	maxSchemaDepth = 64
+)
+
+var (
+	openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+	// muxVarPattern matches path variables with a regexp, e.g. "{id:[0-9]+}".
+	muxVarPattern = regexp.MustCompile(`\{([^{}:]+):[^{}]*\}`)
+)
+
+type (
+	// openAPISpec is an OpenAPI document, either Swagger 2.0 or OpenAPI 3,
+	// with its operations indexed for request validation. Schemas are kept
+	// as decoded JSON and only the keywords relevant to validating requests
+	// are implemented.
+	openAPISpec struct {
+		doc          map[string]interface{}
+		basePath     string
+		operations   map[string]*openAPIOperation
+		operationIDs map[string]*openAPIOperation
+		patterns     map[string]*regexp.Regexp
+	}
+
+	openAPIOperation struct {
+		spec       *openAPISpec
+		parameters []*openAPIParameter
+		body       *openAPIRequestBody
+	}
+
+	openAPIParameter struct {
+		name     string
+		in       string
+		required bool
+		schema   map[string]interface{}
+	}
+
+	openAPIRequestBody struct {
+		required bool
+		// content maps media types to the schema of the body.
+		// A nil schema accepts any body of the media type.
+		content map[string]map[string]interface{}
+	}
+)
+
+// parseOpenAPISpec parses an OpenAPI document in JSON.
+func parseOpenAPISpec(data []byte) (*openAPISpec, error) {
+	doc := make(map[string]interface{})
+	err := json.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding OpenAPI spec")
+	}
+
+	swagger2 := doc["swagger"] == "2.0"
+	if version, _ := doc["openapi"].(string); !swagger2 && !strings.HasPrefix(version, "3.") {
+		return nil, errors.New("unsupported OpenAPI version, expecting swagger 2.0 or openapi 3.x")
+	}
+
+	s := &openAPISpec{
+		doc:          doc,
+		operations:   make(map[string]*openAPIOperation),
+		operationIDs: make(map[string]*openAPIOperation),
+		patterns:     make(map[string]*regexp.Regexp),
+	}
+	if basePath, ok := doc["basePath"].(string); ok && basePath != "/" {
+		s.basePath = strings.TrimSuffix(basePath, "/")
+	}
+
+	paths, _ := doc["paths"].(map[string]interface{})
+	visited := make(map[string]bool)
+	for path, item := range paths {
+		pathItem, err := s.resolveObject(item)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing path %v", path)
+		}
+		for _, method := range openAPIMethods {
+			opObj, ok := pathItem[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			op, err := s.parseOperation(pathItem, opObj, swagger2)
+			if err == nil {
+				err = op.compileSchemas(visited)
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "error parsing operation %v %v", strings.ToUpper(method), path)
+			}
+			s.operations[strings.ToUpper(method)+" "+path] = op
+			if id, ok := opObj["operationId"].(string); ok && len(id) > 0 {
+				s.operationIDs[id] = op
+			}
+		}
+	}
+
+	return s, nil
+}
+
+func (s *openAPISpec) parseOperation(pathItem map[string]interface{}, opObj map[string]interface{}, swagger2 bool) (*openAPIOperation, error) {
+	op := &openAPIOperation{spec: s}
+
+	// parameters of the operation override those of the path with the same name and location
+	index := make(map[string]int)
+	for _, list := range []interface{}{pathItem["parameters"], opObj["parameters"]} {
+		items, _ := list.([]interface{})
+		for _, item := range items {
+			p, err := s.resolveObject(item)
+			if err != nil {
+				return nil, err
+			}
+			name, _ := p["name"].(string)
+			in, _ := p["in"].(string)
+			required, _ := p["required"].(bool)
+
+			switch in {
+			case "body":
+				schema, _ := p["schema"].(map[string]interface{})
+				op.body = &openAPIRequestBody{
+					required: required,
+					content:  make(map[string]map[string]interface{}),
+				}
+				for _, mediaType := range s.consumes(opObj) {
+					op.body.content[mediaType] = schema
+				}
+				continue
+			case "formData":
+				// form parameters are not validated
+				continue
+			case "header":
+				// as in OpenAPI 3, these are described by other means than parameters
+				switch http.CanonicalHeaderKey(name) {
+				case "Accept", "Content-Type", "Authorization":
+					continue
+				}
+			}
+
+			param := &openAPIParameter{
+				name:     name,
+				in:       in,
+				required: required || in == "path",
+			}
+			if schema, ok := p["schema"].(map[string]interface{}); ok {
+				param.schema = schema
+			} else if swagger2 {
+				// Swagger 2.0 describes the type of non-body parameters inline
+				param.schema = make(map[string]interface{})
+				for k, v := range p {
+					switch k {
+					case "name", "in", "required", "description", "collectionFormat", "allowEmptyValue":
+					default:
+						param.schema[k] = v
+					}
+				}
+			}
+
+			key := in + ":" + name
+			if i, ok := index[key]; ok {
+				op.parameters[i] = param
+			} else {
+				index[key] = len(op.parameters)
+				op.parameters = append(op.parameters, param)
+			}
+		}
+	}
+
+	if rb, ok := opObj["requestBody"]; ok {
+		body, err := s.resolveObject(rb)
+		if err != nil {
+			return nil, err
+		}
+		required, _ := body["required"].(bool)
+		op.body = &openAPIRequestBody{
+			required: required,
+			content:  make(map[string]map[string]interface{}),
+		}
+		content, _ := body["content"].(map[string]interface{})
+		for mediaType, media := range content {
+			mediaObj, _ := media.(map[string]interface{})
+			schema, _ := mediaObj["schema"].(map[string]interface{})
+			op.body.content[mediaType] = schema
+		}
+	}
+
+	return op, nil
+}
+
+// consumes returns the media types accepted by a Swagger 2.0 operation.
+func (s *openAPISpec) consumes(opObj map[string]interface{}) []string {
+	list, ok := opObj["consumes"].([]interface{})
+	if !ok {
+		list, _ = s.doc["consumes"].([]interface{})
+	}
+	var mediaTypes []string
+	for _, item := range list {
+		if mediaType, ok := item.(string); ok {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+	}
+	if len(mediaTypes) == 0 {
+		mediaTypes = []string{"application/json"}
+	}
+	return mediaTypes
+}
+
+// compileSchemas checks that all references in the schemas of the operation
+// resolve and compiles their patterns, so that validating requests can't fail
+// on a broken spec.
+func (op *openAPIOperation) compileSchemas(visited map[string]bool) error {
+	for _, p := range op.parameters {
+		if err := op.spec.compileSchema(p.schema, visited); err != nil {
+			return err
+		}
+	}
+	if op.body != nil {
+		for _, schema := range op.body.content {
+			if err := op.spec.compileSchema(schema, visited); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *openAPISpec) compileSchema(schema interface{}, visited map[string]bool) error {
+	m, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	if ref, ok := m["$ref"].(string); ok {
+		if visited[ref] {
+			return nil
+		}
+		visited[ref] = true
+		target, err := s.resolveRef(ref)
+		if err != nil {
+			return err
+		}
+		return s.compileSchema(target, visited)
+	}
+
+	if pattern, ok := m["pattern"].(string); ok {
+		if _, ok := s.patterns[pattern]; !ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return errors.Wrapf(err, "invalid pattern %q", pattern)
+			}
+			s.patterns[pattern] = re
+		}
+	}
+
+	var subschemas []interface{}
+	for _, key := range []string{"items", "additionalProperties", "not"} {
+		subschemas = append(subschemas, m[key])
+	}
+	if props, ok := m["properties"].(map[string]interface{}); ok {
+		for _, prop := range props {
+			subschemas = append(subschemas, prop)
+		}
+	}
+	for _, key := range []string{"allOf", "anyOf", "oneOf", "items"} {
+		if list, ok := m[key].([]interface{}); ok {
+			subschemas = append(subschemas, list...)
+		}
+	}
+
+	for _, sub := range subschemas {
+		if err := s.compileSchema(sub, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveRef resolves a local reference, e.g. "#/components/schemas/Pet".
+func (s *openAPISpec) resolveRef(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, errors.Errorf("unsupported reference %q, only local references are supported", ref)
+	}
+
+	var node interface{} = s.doc
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("unresolvable reference %q", ref)
+		}
+		if node, ok = m[token]; !ok {
+			return nil, errors.Errorf("unresolvable reference %q", ref)
+		}
+	}
+	return node, nil
+}
+
+// resolveObject follows the "$ref" of an object, if any.
+func (s *openAPISpec) resolveObject(v interface{}) (map[string]interface{}, error) {
+	for i := 0; i < maxSchemaDepth; i++ {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("expecting an object")
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return m, nil
+		}
+		var err error
+		if v, err = s.resolveRef(ref); err != nil {
+			return nil, err
+		}
+	}
+	return nil, errors.New("too many nested references")
+}
+
+// findOperation returns the operation with the given ID or, if no ID is
+// given, the operation matching the relative URL and method of a trigger.
+func (s *openAPISpec) findOperation(operationID string, relativeURL string, method string) *openAPIOperation {
+	if len(operationID) > 0 {
+		return s.operationIDs[operationID]
+	}
+
+	if len(method) == 0 {
+		method = http.MethodGet
+	}
+	path := muxVarPattern.ReplaceAllString(relativeURL, "{$1}")
+	if op, ok := s.operations[strings.ToUpper(method)+" "+path]; ok {
+		return op
+	}
+	if len(s.basePath) > 0 && strings.HasPrefix(path, s.basePath+"/") {
+		return s.operations[strings.ToUpper(method)+" "+strings.TrimPrefix(path, s.basePath)]
+	}
+	return nil
+}
+
+// validateRequest validates the parameters and body of a request against
+// the operation, returning a description of every violation found.
+// pathVars holds the values of the path variables of the request.
+func (op *openAPIOperation) validateRequest(r *http.Request, pathVars map[string]string) []string {
+	var problems []string
+
+	for _, p := range op.parameters {
+		values := p.values(r, pathVars)
+		if len(values) == 0 {
+			if p.required {
+				problems = append(problems, fmt.Sprintf("missing required %v parameter %q", p.in, p.name))
+			}
+			continue
+		}
+		if p.schema == nil {
+			continue
+		}
+
+		name := fmt.Sprintf("%v parameter %q", p.in, p.name)
+		value, err := op.spec.parseParameter(p.schema, values)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%v: %v", name, err))
+			continue
+		}
+		problems = append(problems, op.spec.validateValue(p.schema, value, name, 0)...)
+	}
+
+	if op.body != nil {
+		problems = append(problems, op.validateBody(r)...)
+	}
+
+	return problems
+}
+
+func (p *openAPIParameter) values(r *http.Request, pathVars map[string]string) []string {
+	switch p.in {
+	case "path":
+		if v, ok := pathVars[p.name]; ok {
+			return []string{v}
+		}
+	case "query":
+		return r.URL.Query()[p.name]
+	case "header":
+		return r.Header[http.CanonicalHeaderKey(p.name)]
+	case "cookie":
+		if c, err := r.Cookie(p.name); err == nil {
+			return []string{c.Value}
+		}
+	}
+	return nil
+}
+
+// validateBody validates the request body against the schema of its media type.
+// Only JSON bodies are validated against schemas; the body of the request is
+// replaced so that it can still be sent to the function.
+func (op *openAPIOperation) validateBody(r *http.Request) []string {
+	contentType := r.Header.Get("Content-Type")
+	if len(contentType) == 0 && r.ContentLength <= 0 {
+		if op.body.required {
+			return []string{"request body is required"}
+		}
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid content type %q", contentType)}
+	}
+	schema, ok := op.body.schemaFor(mediaType)
+	if !ok {
+		return []string{fmt.Sprintf("unsupported content type %q", mediaType)}
+	}
+	if schema == nil || !isJSONMediaType(mediaType) {
+		return nil
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxValidatedBodySize+1))
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return []string{fmt.Sprintf("error reading request body: %v", err)}
+	}
+	if len(body) > maxValidatedBodySize {
+		return []string{fmt.Sprintf("request body exceeds %v bytes", maxValidatedBodySize)}
+	}
+	if len(body) == 0 {
+		if op.body.required {
+			return []string{"request body is required"}
+		}
+		return nil
+	}
+
+	var value interface{}
+	err = json.Unmarshal(body, &value)
+	if err != nil {
+		return []string{fmt.Sprintf("request body is not valid JSON: %v", err)}
+	}
+	return op.spec.validateValue(schema, value, "body", 0)
+}
+
+// schemaFor returns the schema of a media type, matching
+// wildcards like "application/*" and "*/*" as well.
+func (body *openAPIRequestBody) schemaFor(mediaType string) (map[string]interface{}, bool) {
+	if len(body.content) == 0 {
+		return nil, true
+	}
+	candidates := []string{mediaType, "*/*"}
+	if i := strings.Index(mediaType, "/"); i > 0 {
+		candidates = []string{mediaType, mediaType[:i] + "/*", "*/*"}
+	}
+	for _, candidate := range candidates {
+		if schema, ok := body.content[candidate]; ok {
+			return schema, true
+		}
+	}
+	return nil, false
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// parseParameter converts the raw values of a parameter to the type of its schema.
+func (s *openAPISpec) parseParameter(schema map[string]interface{}, values []string) (interface{}, error) {
+	schema, err := s.resolveObject(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if schemaType(schema) != "array" {
+		return parseScalar(schema, values[0])
+	}
+
+	if len(values) == 1 {
+		values = strings.Split(values[0], ",")
+	}
+	items := s.resolvedItems(schema["items"])
+	arr := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		parsed, err := parseScalar(items, v)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, parsed)
+	}
+	return arr, nil
+}
+
+func (s *openAPISpec) resolvedItems(v interface{}) map[string]interface{} {
+	m, err := s.resolveObject(v)
+	if err != nil {
+		return nil
+	}
+	return m
+}
+
+func parseScalar(schema map[string]interface{}, raw string) (interface{}, error) {
+	switch schemaType(schema) {
+	case "integer":
+		i, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, errors.New("must be an integer")
+		}
+		return float64(i), nil
+	case "number":
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, errors.New("must be a number")
+		}
+		return f, nil
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, errors.New("must be a boolean")
+		}
+		return b, nil
+	}
+	return raw, nil
+}
+
+// schemaType returns the type of a schema, ignoring "null" in a list of types.
+func schemaType(schema map[string]interface{}) string {
+	for _, t := range schemaTypes(schema) {
+		if t != "null" {
+			return t
+		}
+	}
+	return ""
+}
+
+func schemaTypes(schema map[string]interface{}) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var types []string
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// validateValue validates a decoded JSON value against a schema. path
+// describes the location of the value in error messages.
+func (s *openAPISpec) validateValue(schema interface{}, value interface{}, path string, depth int) []string {
+	if depth > maxSchemaDepth {
+		return nil
+	}
+	m, err := s.resolveObject(schema)
+	if err != nil {
+		return []string{fmt.Sprintf("%v: %v", path, err)}
+	}
+
+	var problems []string
+	fail := func(format string, args ...interface{}) {
+		problems = append(problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if value == nil && m["nullable"] == true {
+		return nil
+	}
+	if types := schemaTypes(m); len(types) > 0 && !typeMatches(types, value) {
+		fail("must be of type %v", strings.Join(types, " or "))
+		return problems
+	}
+	if enum, ok := m["enum"].([]interface{}); ok && !containsValue(enum, value) {
+		fail("must be one of %v", formatValues(enum))
+	}
+
+	switch v := value.(type) {
+	case string:
+		n := float64(utf8.RuneCountInString(v))
+		if min, ok := m["minLength"].(float64); ok && n < min {
+			fail("must be at least %v characters long", min)
+		}
+		if max, ok := m["maxLength"].(float64); ok && n > max {
+			fail("must be at most %v characters long", max)
+		}
+		if pattern, ok := m["pattern"].(string); ok {
+			if re := s.patterns[pattern]; re != nil && !re.MatchString(v) {
+				fail("must match pattern %q", pattern)
+			}
+		}
+
+	case float64:
+		// exclusiveMinimum/exclusiveMaximum are booleans in Swagger 2.0 and
+		// OpenAPI 3.0, and numbers in OpenAPI 3.1
+		if min, ok := m["minimum"].(float64); ok {
+			if m["exclusiveMinimum"] == true && v <= min {
+				fail("must be greater than %v", min)
+			} else if v < min {
+				fail("must be greater than or equal to %v", min)
+			}
+		}
+		if min, ok := m["exclusiveMinimum"].(float64); ok && v <= min {
+			fail("must be greater than %v", min)
+		}
+		if max, ok := m["maximum"].(float64); ok {
+			if m["exclusiveMaximum"] == true && v >= max {
+				fail("must be less than %v", max)
+			} else if v > max {
+				fail("must be less than or equal to %v", max)
+			}
+		}
+		if max, ok := m["exclusiveMaximum"].(float64); ok && v >= max {
+			fail("must be less than %v", max)
+		}
+		if multipleOf, ok := m["multipleOf"].(float64); ok && multipleOf > 0 {
+			if q := v / multipleOf; q != math.Trunc(q) {
+				fail("must be a multiple of %v", multipleOf)
+			}
+		}
+
+	case []interface{}:
+		n := float64(len(v))
+		if min, ok := m["minItems"].(float64); ok && n < min {
+			fail("must have at least %v items", min)
+		}
+		if max, ok := m["maxItems"].(float64); ok && n > max {
+			fail("must have at most %v items", max)
+		}
+		if m["uniqueItems"] == true && hasDuplicates(v) {
+			fail("must not have duplicate items")
+		}
+		switch items := m["items"].(type) {
+		case map[string]interface{}:
+			for i, item := range v {
+				problems = append(problems, s.validateValue(items, item, fmt.Sprintf("%v[%v]", path, i), depth+1)...)
+			}
+		case []interface{}:
+			for i := 0; i < len(items) && i < len(v); i++ {
+				problems = append(problems, s.validateValue(items[i], v[i], fmt.Sprintf("%v[%v]", path, i), depth+1)...)
+			}
+		}
+
+	case map[string]interface{}:
+		n := float64(len(v))
+		if min, ok := m["minProperties"].(float64); ok && n < min {
+			fail("must have at least %v properties", min)
+		}
+		if max, ok := m["maxProperties"].(float64); ok && n > max {
+			fail("must have at most %v properties", max)
+		}
+		required, _ := m["required"].([]interface{})
+		for _, item := range required {
+			if name, ok := item.(string); ok {
+				if _, ok := v[name]; !ok {
+					fail("missing required property %q", name)
+				}
+			}
+		}
+		props, _ := m["properties"].(map[string]interface{})
+		for _, name := range sortedKeys(v) {
+			propPath := path + "." + name
+			if prop, ok := props[name]; ok {
+				problems = append(problems, s.validateValue(prop, v[name], propPath, depth+1)...)
+				continue
+			}
+			switch additional := m["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					fail("unexpected property %q", name)
+				}
+			case map[string]interface{}:
+				problems = append(problems, s.validateValue(additional, v[name], propPath, depth+1)...)
+			}
+		}
+	}
+
+	if allOf, ok := m["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			problems = append(problems, s.validateValue(sub, value, path, depth+1)...)
+		}
+	}
+	if anyOf, ok := m["anyOf"].([]interface{}); ok && s.countMatches(anyOf, value, path, depth) == 0 {
+		fail("must match at least one schema of anyOf")
+	}
+	if oneOf, ok := m["oneOf"].([]interface{}); ok && s.countMatches(oneOf, value, path, depth) != 1 {
+		fail("must match exactly one schema of oneOf")
+	}
+	if not, ok := m["not"]; ok && len(s.validateValue(not, value, path, depth+1)) == 0 {
+		fail("must not match schema of not")
+	}
+
+	return problems
+}
+
+func (s *openAPISpec) countMatches(schemas []interface{}, value interface{}, path string, depth int) int {
+	matches := 0
+	for _, sub := range schemas {
+		if len(s.validateValue(sub, value, path, depth+1)) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+func typeMatches(types []string, value interface{}) bool {
+	actual := jsonType(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return ""
+}
+
+func containsValue(list []interface{}, value interface{}) bool {
+	for _, item := range list {
+		if reflect.DeepEqual(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDuplicates(list []interface{}) bool {
+	for i := range list {
+		if containsValue(list[i+1:], list[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatValues(values []interface{}) string {
+	formatted := make([]string, 0, len(values))
+	for _, v := range values {
+		b, _ := json.Marshal(v)
+		formatted = append(formatted, string(b))
+	}
+	return "[" + strings.Join(formatted, ", ") + "]"
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
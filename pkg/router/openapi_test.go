@@ -0,0 +1,195 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testOpenAPI3Spec = `{
+  "openapi": "3.0.0",
+  "paths": {
+    "/users/{id}": {
+      "parameters": [{"name": "id", "in": "path", "schema": {"type": "integer", "minimum": 1}}],
+      "put": {
+        "operationId": "updateUser",
+        "parameters": [{"name": "dryRun", "in": "query", "schema": {"type": "boolean"}}],
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}
+        }
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "User": {
+        "type": "object",
+        "required": ["name"],
+        "additionalProperties": false,
+        "properties": {
+          "name": {"type": "string", "minLength": 1, "pattern": "^[a-z]+$"},
+          "role": {"type": "string", "enum": ["admin", "member"]},
+          "tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+          "manager": {"$ref": "#/components/schemas/User"},
+          "nickname": {"type": "string", "nullable": true}
+        }
+      }
+    }
+  }
+}`
+
+const testSwagger2Spec = `{
+  "swagger": "2.0",
+  "basePath": "/api",
+  "paths": {
+    "/items": {
+      "get": {
+        "parameters": [
+          {"name": "limit", "in": "query", "required": true, "type": "integer", "maximum": 100},
+          {"name": "ids", "in": "query", "type": "array", "items": {"type": "integer"}}
+        ]
+      },
+      "post": {
+        "parameters": [{"name": "item", "in": "body", "required": true, "schema": {"type": "object", "required": ["sku"]}}]
+      }
+    }
+  }
+}`
+
+func TestOpenAPIRequestValidation(t *testing.T) {
+	oas3, err := parseOpenAPISpec([]byte(testOpenAPI3Spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	swagger2, err := parseOpenAPISpec([]byte(testSwagger2Spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		op       *openAPIOperation
+		method   string
+		url      string
+		pathVars map[string]string
+		body     string
+		problems []string
+	}{
+		{
+			name:     "valid body",
+			op:       oas3.findOperation("", "/users/{id:[0-9]+}", "PUT"),
+			method:   http.MethodPut,
+			url:      "/users/1?dryRun=true",
+			pathVars: map[string]string{"id": "1"},
+			body:     `{"name": "alice", "role": "admin", "manager": {"name": "bob"}, "nickname": null}`,
+		},
+		{
+			name:     "invalid parameters",
+			op:       oas3.findOperation("updateUser", "", ""),
+			method:   http.MethodPut,
+			url:      "/users/0?dryRun=maybe",
+			pathVars: map[string]string{"id": "0"},
+			body:     `{"name": "alice"}`,
+			problems: []string{
+				`path parameter "id": must be greater than or equal to 1`,
+				`query parameter "dryRun": must be a boolean`,
+			},
+		},
+		{
+			name:     "invalid body",
+			op:       oas3.findOperation("updateUser", "", ""),
+			method:   http.MethodPut,
+			url:      "/users/1",
+			pathVars: map[string]string{"id": "1"},
+			body:     `{"role": "owner", "tags": ["a", 1, "c"], "manager": {"name": "Bob"}, "age": 3}`,
+			problems: []string{
+				`body: missing required property "name"`,
+				`body: unexpected property "age"`,
+				`body.manager.name: must match pattern "^[a-z]+$"`,
+				`body.role: must be one of ["admin", "member"]`,
+				`body.tags: must have at most 2 items`,
+				`body.tags[1]: must be of type string`,
+			},
+		},
+		{
+			name:     "missing body",
+			op:       oas3.findOperation("updateUser", "", ""),
+			method:   http.MethodPut,
+			url:      "/users/1",
+			pathVars: map[string]string{"id": "1"},
+			problems: []string{"request body is required"},
+		},
+		{
+			name:   "swagger 2 query parameters",
+			op:     swagger2.findOperation("", "/api/items", "GET"),
+			method: http.MethodGet,
+			url:    "/api/items?limit=200&ids=1,x",
+			problems: []string{
+				`query parameter "limit": must be less than or equal to 100`,
+				`query parameter "ids": must be an integer`,
+			},
+		},
+		{
+			name:     "swagger 2 body",
+			op:       swagger2.findOperation("", "/items", "POST"),
+			method:   http.MethodPost,
+			url:      "/api/items",
+			body:     `{"name": "pen"}`,
+			problems: []string{`body: missing required property "sku"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.op == nil {
+				t.Fatal("operation not found")
+			}
+			r := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			if len(tt.body) > 0 {
+				r.Header.Set("Content-Type", "application/json")
+			}
+
+			problems := tt.op.validateRequest(r, tt.pathVars)
+			if strings.Join(problems, "\n") != strings.Join(tt.problems, "\n") {
+				t.Errorf("validateRequest() = %q, want %q", problems, tt.problems)
+			}
+
+			// the body must still be readable by the function
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil || string(body) != tt.body {
+				t.Errorf("request body = %q, %v, want %q", body, err, tt.body)
+			}
+		})
+	}
+}
+
+func TestParseOpenAPISpecErrors(t *testing.T) {
+	for _, spec := range []string{
+		`{"info": {}}`,
+		`{"openapi": "3.0.0", "paths": {"/": {"get": {"parameters": [{"$ref": "#/components/parameters/missing"}]}}}}`,
+		`{"openapi": "3.0.0", "paths": {"/": {"get": {"parameters": [{"name": "q", "in": "query", "schema": {"pattern": "("}}]}}}}`,
+	} {
+		if _, err := parseOpenAPISpec([]byte(spec)); err == nil {
+			t.Errorf("expected error parsing %v", spec)
+		}
+	}
+}
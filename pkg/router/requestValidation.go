@@ -0,0 +1,261 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+)
+
+const (
+	// openAPISpecRefreshInterval is how long a loaded spec is used before it's reloaded.
+	openAPISpecRefreshInterval = time.Minute
+
+	// maxOpenAPIPackageSize limits the size of package archives router downloads to read a spec from.
+	maxOpenAPIPackageSize = 64 << 20
+)
+
+type (
+	// requestValidator validates requests to HTTP triggers against the
+	// OpenAPI specs they reference. It's shared by all function handlers
+	// so that loaded specs survive router rebuilds.
+	requestValidator struct {
+		logger        *zap.Logger
+		fissionClient *crd.FissionClient
+		kubeClient    *kubernetes.Clientset
+		httpClient    *http.Client
+		lock          sync.Mutex
+		specs         map[string]*loadedOpenAPISpec
+	}
+
+	loadedOpenAPISpec struct {
+		spec     *openAPISpec
+		loadedAt time.Time
+	}
+
+	// requestValidationError is the response body of a request rejected by validation.
+	requestValidationError struct {
+		Error   string   `json:"error"`
+		Details []string `json:"details"`
+	}
+)
+
+func makeRequestValidator(logger *zap.Logger, fissionClient *crd.FissionClient, kubeClient *kubernetes.Clientset) *requestValidator {
+	return &requestValidator{
+		logger:        logger.Named("request_validator"),
+		fissionClient: fissionClient,
+		kubeClient:    kubeClient,
+		httpClient:    &http.Client{Timeout: 30 * time.Second},
+		specs:         make(map[string]*loadedOpenAPISpec),
+	}
+}
+
+// validate validates the request against the OpenAPI operation of the trigger.
+// It returns the problems found in an invalid request, or an error if the
+// spec can't be loaded or has no operation for the trigger.
+func (rv *requestValidator) validate(trigger *fv1.HTTPTrigger, request *http.Request) ([]string, error) {
+	config := trigger.Spec.RequestValidation
+
+	spec, err := rv.getSpec(trigger.ObjectMeta.Namespace, config)
+	if err != nil {
+		return nil, err
+	}
+
+	op := spec.findOperation(config.OperationID, trigger.Spec.RelativeURL, trigger.Spec.Method)
+	if op == nil {
+		if len(config.OperationID) > 0 {
+			return nil, errors.Errorf("no operation with id %q in OpenAPI spec", config.OperationID)
+		}
+		return nil, errors.Errorf("no operation for %v %v in OpenAPI spec", trigger.Spec.Method, trigger.Spec.RelativeURL)
+	}
+
+	return op.validateRequest(request, mux.Vars(request)), nil
+}
+
+// getSpec returns the spec referenced by the config, loading it if it's absent or stale.
+func (rv *requestValidator) getSpec(namespace string, config *fv1.RequestValidation) (*openAPISpec, error) {
+	specPath := config.Path
+	if len(specPath) == 0 {
+		specPath = fv1.DefaultOpenAPISpecPath
+	}
+	key := fmt.Sprintf("configmap/%v/%v/%v", namespace, config.ConfigMap, specPath)
+	if len(config.Package) > 0 {
+		key = fmt.Sprintf("package/%v/%v/%v", namespace, config.Package, specPath)
+	}
+
+	rv.lock.Lock()
+	defer rv.lock.Unlock()
+
+	loaded, ok := rv.specs[key]
+	if ok && time.Since(loaded.loadedAt) < openAPISpecRefreshInterval {
+		return loaded.spec, nil
+	}
+
+	spec, err := rv.loadSpec(namespace, config, specPath)
+	if err != nil {
+		if !ok {
+			return nil, err
+		}
+		// keep validating with the previous spec
+		rv.logger.Error("error reloading OpenAPI spec", zap.Error(err), zap.String("spec", key))
+		loaded.loadedAt = time.Now()
+		return loaded.spec, nil
+	}
+
+	rv.specs[key] = &loadedOpenAPISpec{
+		spec:     spec,
+		loadedAt: time.Now(),
+	}
+	return spec, nil
+}
+
+func (rv *requestValidator) loadSpec(namespace string, config *fv1.RequestValidation, specPath string) (*openAPISpec, error) {
+	var data []byte
+
+	if len(config.ConfigMap) > 0 {
+		cm, err := rv.kubeClient.CoreV1().ConfigMaps(namespace).Get(config.ConfigMap, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting configmap %v", config.ConfigMap)
+		}
+		if d, ok := cm.Data[specPath]; ok {
+			data = []byte(d)
+		} else if d, ok := cm.BinaryData[specPath]; ok {
+			data = d
+		} else {
+			return nil, errors.Errorf("configmap %v has no key %v", config.ConfigMap, specPath)
+		}
+	} else {
+		pkg, err := rv.fissionClient.CoreV1().Packages(namespace).Get(config.Package, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting package %v", config.Package)
+		}
+		data, err = rv.readPackageFile(&pkg.Spec.Deployment, specPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading OpenAPI spec from package %v", config.Package)
+		}
+	}
+
+	// JSON is valid YAML, so both formats are accepted
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding OpenAPI spec")
+	}
+	return parseOpenAPISpec(jsonData)
+}
+
+// readPackageFile reads a file from the deployment archive of a package. A
+// package that isn't a zip or tar.gz archive is treated as the file itself.
+func (rv *requestValidator) readPackageFile(archive *fv1.Archive, name string) ([]byte, error) {
+	var contents []byte
+	switch archive.Type {
+	case fv1.ArchiveTypeLiteral:
+		contents = archive.Literal
+	case fv1.ArchiveTypeUrl:
+		resp, err := rv.httpClient.Get(archive.URL)
+		if err != nil {
+			return nil, errors.Wrap(err, "error downloading package archive")
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.Errorf("error downloading package archive: %v", resp.Status)
+		}
+		contents, err = ioutil.ReadAll(io.LimitReader(resp.Body, maxOpenAPIPackageSize))
+		if err != nil {
+			return nil, errors.Wrap(err, "error downloading package archive")
+		}
+	default:
+		return nil, errors.Errorf("unsupported archive type %v", archive.Type)
+	}
+
+	name = path.Clean(name)
+	switch {
+	case bytes.HasPrefix(contents, []byte("PK")):
+		zr, err := zip.NewReader(bytes.NewReader(contents), int64(len(contents)))
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading zip archive")
+		}
+		for _, f := range zr.File {
+			if path.Clean(f.Name) != name {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return ioutil.ReadAll(rc)
+		}
+	case archive.Format == fv1.ArchiveFormatTarGz:
+		gz, err := gzip.NewReader(bytes.NewReader(contents))
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading gzip stream")
+		}
+		defer gz.Close()
+		tr := tar.NewReader(gz)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, errors.Wrap(err, "error reading tar archive")
+			}
+			if hdr.Typeflag == tar.TypeReg && path.Clean(hdr.Name) == name {
+				return ioutil.ReadAll(tr)
+			}
+		}
+	default:
+		return contents, nil
+	}
+
+	return nil, errors.Errorf("no file %v in package archive", name)
+}
+
+// writeRequestValidationError rejects a request that failed validation.
+func writeRequestValidationError(w http.ResponseWriter, problems []string) {
+	body, err := json.Marshal(requestValidationError{
+		Error:   "request validation failed",
+		Details: problems,
+	})
+	if err != nil {
+		http.Error(w, "request validation failed", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(body)
+}
@@ -41,6 +41,7 @@ package router
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"os"
@@ -53,10 +54,17 @@ import (
 	"go.opencensus.io/plugin/ochttp"
 	"go.opencensus.io/trace"
 	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
 
 	"github.com/fission/fission/pkg/crd"
 	executorClient "github.com/fission/fission/pkg/executor/client"
+	"github.com/fission/fission/pkg/fissionconfig"
+	"github.com/fission/fission/pkg/metering"
+	"github.com/fission/fission/pkg/mtls"
+	"github.com/fission/fission/pkg/qos"
+	"github.com/fission/fission/pkg/shutdown"
 	"github.com/fission/fission/pkg/throttler"
+	"github.com/fission/fission/pkg/tracing"
 )
 
 // request url ---[mux]---> Function(name,uid) ----[fmap]----> k8s service url
@@ -68,6 +76,7 @@ func router(ctx context.Context, logger *zap.Logger, httpTriggerSet *HTTPTrigger
 
 	// see issue https://github.com/fission/fission/issues/1317
 	useEncodedPath, _ := strconv.ParseBool(os.Getenv("USE_ENCODED_PATH"))
+	httpTriggerSet.useEncodedPath = useEncodedPath
 	if useEncodedPath {
 		mr = newMutableRouter(logger, mux.NewRouter().UseEncodedPath())
 	} else {
@@ -78,13 +87,18 @@ func router(ctx context.Context, logger *zap.Logger, httpTriggerSet *HTTPTrigger
 	return mr
 }
 
-func serve(ctx context.Context, logger *zap.Logger, port int, tracingSamplingRate float64,
+func serve(ctx context.Context, logger *zap.Logger, coordinator *shutdown.Coordinator, port int, tracingSamplingRate float64,
 	httpTriggerSet *HTTPTriggerSet, resolver *functionReferenceResolver, displayAccessLog bool) {
 	mr := router(ctx, logger, httpTriggerSet, resolver)
 	url := fmt.Sprintf(":%v", port)
 
-	err := http.ListenAndServe(url, &ochttp.Handler{
-		Handler: mr,
+	// requests are traced with both OpenCensus and OpenTelemetry, the
+	// latter continuing the W3C trace context of the caller
+	handler := tracing.Handler(mr, "router", func(r *http.Request) bool {
+		return r.URL.Path != "/router-healthz"
+	})
+	server := &http.Server{Addr: url, Handler: &ochttp.Handler{
+		Handler: handler,
 		GetStartOptions: func(r *http.Request) trace.StartOptions {
 			// do not trace router healthz endpoint
 			if strings.Compare(r.URL.Path, "/router-healthz") == 0 {
@@ -100,7 +114,10 @@ func serve(ctx context.Context, logger *zap.Logger, port int, tracingSamplingRat
 				Sampler: trace.ProbabilitySampler(tracingSamplingRate),
 			}
 		},
-	})
+	}}
+	// on shutdown, router stops accepting connections and waits for the
+	// requests it proxies
+	err := coordinator.Serve("router", server, server.ListenAndServe)
 	if err != nil {
 		logger.Error(
 			"HTTP server error",
@@ -109,16 +126,18 @@ func serve(ctx context.Context, logger *zap.Logger, port int, tracingSamplingRat
 	}
 }
 
-func serveMetric(logger *zap.Logger) {
+func serveMetric(logger *zap.Logger, coordinator *shutdown.Coordinator) {
 	// Expose the registered metrics via HTTP.
 	http.Handle("/metrics", promhttp.Handler())
-	err := http.ListenAndServe(metricAddr, nil)
-
-	logger.Fatal("done listening on metrics endpoint", zap.Error(err))
+	err := coordinator.ServeMetrics(&http.Server{Addr: metricAddr})
+	if err != nil {
+		logger.Fatal("done listening on metrics endpoint", zap.Error(err))
+	}
 }
 
-// Start starts a router
-func Start(logger *zap.Logger, port int, executorURL string) {
+// Start starts a router, and returns once it is shut down by the
+// coordinator.
+func Start(logger *zap.Logger, coordinator *shutdown.Coordinator, port int, executorURL string) {
 	_ = MakeAnalytics("")
 
 	fmap := makeFunctionServiceMap(logger, time.Minute)
@@ -133,8 +152,27 @@ func Start(logger *zap.Logger, port int, executorURL string) {
 		logger.Fatal("error waiting for CRDs", zap.Error(err))
 	}
 
+	// the FissionConfig overrides the settings router was deployed with
+	configReconciler := fissionconfig.MakeReconciler(logger, fissionClient, fissionconfig.ComponentRouter)
+	configReconciler.Load()
+
 	executor := executorClient.MakeClient(logger, executorURL)
 
+	// with mTLS, router talks to executor and function pods over TLS and
+	// presents a certificate signed by the same CA.
+	mtlsConfig, err := mtls.ConfigFromEnv()
+	if err != nil {
+		logger.Fatal("error reading mTLS config", zap.Error(err))
+	}
+	var tlsConfig *tls.Config
+	if mtlsConfig != nil {
+		tlsConfig, err = mtlsConfig.ClientTLSConfig()
+		if err != nil {
+			logger.Fatal("error loading mTLS certificate", zap.Error(err), zap.String("directory", mtlsConfig.CertDir))
+		}
+		executor.SetTLSConfig(tlsConfig)
+	}
+
 	timeoutStr := os.Getenv("ROUTER_ROUND_TRIP_TIMEOUT")
 	timeout, err := time.ParseDuration(timeoutStr)
 	if err != nil {
@@ -238,6 +276,16 @@ func Start(logger *zap.Logger, port int, executorURL string) {
 			zap.Bool("default", displayAccessLog))
 	}
 
+	// maxInflightRequests limits the requests router proxies at the same time,
+	// requests beyond it wait for a free slot by priority.
+	maxInflightRequestsStr := os.Getenv("ROUTER_MAX_INFLIGHT_REQUESTS")
+	maxInflightRequests, err := strconv.Atoi(maxInflightRequestsStr)
+	if err != nil && len(maxInflightRequestsStr) > 0 {
+		logger.Error("failed to parse max in-flight requests from 'ROUTER_MAX_INFLIGHT_REQUESTS' - requests are not limited",
+			zap.Error(err),
+			zap.String("value", maxInflightRequestsStr))
+	}
+
 	triggers, _, fnStore := makeHTTPTriggerSet(logger.Named("triggerset"), fmap, fissionClient, kubeClient, executor, fissionClient.CoreV1().RESTClient(), &tsRoundTripperParams{
 		timeout:           timeout,
 		timeoutExponent:   timeoutExponent,
@@ -245,14 +293,149 @@ func Start(logger *zap.Logger, port int, executorURL string) {
 		keepAliveTime:     keepAliveTime,
 		maxRetries:        maxRetries,
 		svcAddrRetryCount: svcAddrRetryCount,
+		tlsConfig:         tlsConfig,
 	}, isDebugEnv, unTapServiceTimeout, throttler.MakeThrottler(svcAddrUpdateTimeout))
+	triggers.requestLimiter = qos.MakeLimiter(maxInflightRequests)
+	configReconciler.SetLive("ROUTER_MAX_INFLIGHT_REQUESTS", func(value string) error {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		// limits can't be lifted or added to an unlimited router
+		if triggers.requestLimiter == nil || limit <= 0 {
+			return fissionconfig.ErrRestartRequired
+		}
+		triggers.requestLimiter.SetCapacity(limit)
+		return nil
+	})
+
+	// the guard sheds batch, then standard priority requests while router
+	// uses more memory or goroutines than its budget.
+	budget, err := qos.ParseBudget(os.Getenv("ROUTER_MEMORY_BUDGET"), os.Getenv("ROUTER_GOROUTINE_BUDGET"))
+	if err != nil {
+		logger.Error("failed to parse resource budget from 'ROUTER_MEMORY_BUDGET' and 'ROUTER_GOROUTINE_BUDGET' - requests are not shed",
+			zap.Error(err))
+	}
+	triggers.guard = qos.MakeGuard(logger.Named("guard"), "router", budget, qos.DefaultGuardInterval)
+
+	// svcDiscovery lets router find the services of newdeploy functions
+	// while executor is unavailable.
+	svcDiscoveryMode := os.Getenv("ROUTER_SVC_DISCOVERY_FALLBACK")
+	functionNamespace := os.Getenv("FISSION_FUNCTION_NAMESPACE")
+	if len(functionNamespace) == 0 {
+		functionNamespace = "fission-function"
+	}
+	triggers.svcDiscovery, err = makeServiceDiscovery(svcDiscoveryMode, kubeClient, functionNamespace)
+	if err != nil {
+		logger.Error("failed to parse service discovery fallback from 'ROUTER_SVC_DISCOVERY_FALLBACK' - functions are only found through executor",
+			zap.Error(err),
+			zap.String("value", svcDiscoveryMode))
+	}
+
+	// slowRequests records the requests slower than a threshold, with
+	// their sanitized headers, body snippet and timing breakdown.
+	slowRequestThreshold := os.Getenv("ROUTER_SLOW_REQUEST_THRESHOLD")
+	triggers.slowRequests, err = makeSlowRequestRecorder(logger.Named("slow_requests"), slowRequestThreshold,
+		os.Getenv("ROUTER_SLOW_REQUEST_BODY_BYTES"), os.Getenv("ROUTER_SLOW_REQUEST_SINK"))
+	if err != nil {
+		logger.Error("failed to set up slow request recording from 'ROUTER_SLOW_REQUEST_THRESHOLD', 'ROUTER_SLOW_REQUEST_BODY_BYTES' and 'ROUTER_SLOW_REQUEST_SINK' - slow requests are not recorded",
+			zap.Error(err),
+			zap.String("threshold", slowRequestThreshold))
+	}
+
+	// invocationQuotas limit the invocations of functions, as set in
+	// their specs, and of namespaces, as set by the operator. Counts are
+	// kept in a config map in the namespace of router.
+	namespaceQuotasStr := os.Getenv("ROUTER_NAMESPACE_INVOCATION_QUOTAS")
+	namespaceQuotas, err := parseNamespaceQuotas(namespaceQuotasStr)
+	if err != nil {
+		logger.Error("failed to parse namespace invocation quotas from 'ROUTER_NAMESPACE_INVOCATION_QUOTAS' - namespaces are not limited",
+			zap.Error(err),
+			zap.String("value", namespaceQuotasStr))
+	}
+	var quotaKubeClient kubernetes.Interface
+	podNamespace := os.Getenv("POD_NAMESPACE")
+	if len(podNamespace) > 0 {
+		quotaKubeClient = kubeClient
+	} else {
+		logger.Info("POD_NAMESPACE not set - invocation quota usage is not kept across router replicas and restarts")
+	}
+	triggers.invocationQuotas = makeInvocationQuotas(logger.Named("invocation_quotas"), quotaKubeClient, podNamespace, namespaceQuotas)
+
+	// invocationHistory keeps the most recent invocations of each
+	// function in its InvocationHistory, for fission fn invocations.
+	invocationHistorySizeStr := os.Getenv("ROUTER_INVOCATION_HISTORY_SIZE")
+	invocationHistorySize, err := parseInvocationHistorySize(invocationHistorySizeStr)
+	if err != nil {
+		logger.Error("failed to parse invocation history size from 'ROUTER_INVOCATION_HISTORY_SIZE' - set to the default value",
+			zap.Error(err),
+			zap.String("value", invocationHistorySizeStr),
+			zap.Int("default", invocationHistorySize))
+	}
+	triggers.invocationHistory = makeInvocationHistory(logger.Named("invocation_history"), fissionClient, invocationHistorySize)
+
+	// invocationCounter adds the invocations of each function to its
+	// FunctionUsage, for fission fn top.
+	meteringIntervalStr := os.Getenv("ROUTER_METERING_INTERVAL")
+	meteringInterval, err := metering.ParseInterval(meteringIntervalStr)
+	if err != nil {
+		logger.Error("failed to parse metering interval from 'ROUTER_METERING_INTERVAL' - set to the default value",
+			zap.Error(err),
+			zap.String("value", meteringIntervalStr),
+			zap.Duration("default", meteringInterval))
+	}
+	triggers.invocationCounter = metering.MakeInvocationCounter(logger, fissionClient, meteringInterval)
+
+	// cloudEvents is the CloudEvents mode functions are invoked in, by
+	// default: binary, structured, or none.
+	cloudEventsModeStr := os.Getenv("ROUTER_CLOUDEVENTS_MODE")
+	triggers.cloudEvents, err = parseCloudEventsMode(cloudEventsModeStr)
+	if err != nil {
+		logger.Error("failed to parse CloudEvents mode from 'ROUTER_CLOUDEVENTS_MODE' - requests are passed as is",
+			zap.Error(err),
+			zap.String("value", cloudEventsModeStr))
+	}
+
+	// asyncInvoker enqueues the requests at /fission-async to NATS
+	// streaming, for the workers of all router replicas to invoke.
+	asyncNatsURL := os.Getenv("ROUTER_ASYNC_NATS_URL")
+	if len(asyncNatsURL) > 0 {
+		asyncConfig, err := parseAsyncConfig(os.Getenv("ROUTER_ASYNC_MAX_RETRIES"),
+			os.Getenv("ROUTER_ASYNC_WORKERS"), os.Getenv("ROUTER_ASYNC_RESULT_TTL"))
+		if err != nil {
+			logger.Error("failed to parse async invocation config from 'ROUTER_ASYNC_MAX_RETRIES', 'ROUTER_ASYNC_WORKERS' and 'ROUTER_ASYNC_RESULT_TTL' - set to the default values",
+				zap.Error(err))
+		}
+		queue, err := makeNatsAsyncQueue(logger, asyncNatsURL, os.Getenv("ROUTER_ASYNC_NATS_CLUSTER_ID"))
+		if err != nil {
+			logger.Error("failed to set up async invocations from 'ROUTER_ASYNC_NATS_URL' and 'ROUTER_ASYNC_NATS_CLUSTER_ID' - async invocations are not enabled",
+				zap.Error(err))
+		} else {
+			triggers.asyncInvoker = makeAsyncInvoker(logger.Named("async_invoker"), queue, triggers.lookupTrigger, asyncConfig)
+		}
+	}
+
+	dynamicClient, err := crd.GetDynamicClient()
+	if err != nil {
+		logger.Error("error creating dynamic client, HTTPRoutes of triggers won't be created", zap.Error(err))
+	}
+	triggers.dynamicClient = dynamicClient
 
 	resolver := makeFunctionReferenceResolver(fnStore)
 
-	go serveMetric(logger)
+	go serveMetric(logger, coordinator)
 
 	logger.Info("starting router", zap.Int("port", port))
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	serve(ctx, logger, port, tracingSamplingRate, triggers, resolver, displayAccessLog)
+	go triggers.guard.Run(ctx)
+	go triggers.invocationQuotas.run(ctx)
+	go triggers.invocationHistory.run(ctx)
+	go triggers.invocationCounter.Run(ctx)
+	go configReconciler.Run(ctx.Done())
+	// the invocations counted while draining still count against quotas
+	coordinator.OnFlush("invocation quotas", triggers.invocationQuotas.flush)
+	coordinator.OnFlush("invocation history", triggers.invocationHistory.flush)
+	coordinator.OnFlush("invocation counts", triggers.invocationCounter.Flush)
+	serve(ctx, logger, coordinator, port, tracingSamplingRate, triggers, resolver, displayAccessLog)
 }
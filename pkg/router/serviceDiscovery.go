@@ -0,0 +1,119 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/executor/util"
+)
+
+type (
+	// svcDiscoveryMode is how router finds the service of a function
+	// when executor can't tell it.
+	svcDiscoveryMode string
+
+	// serviceDiscovery finds the services of newdeploy functions without
+	// executor, so that their invocations survive short executor outages.
+	// Poolmgr functions still need executor to specialize pods.
+	serviceDiscovery struct {
+		mode              svcDiscoveryMode
+		kubeClient        kubernetes.Interface
+		functionNamespace string
+		// lookupHost resolves host names, net.DefaultResolver.LookupHost
+		// but for tests.
+		lookupHost func(ctx context.Context, host string) ([]string, error)
+	}
+)
+
+const (
+	// svcDiscoveryTimeout bounds the lookup of a function service.
+	svcDiscoveryTimeout = 5 * time.Second
+
+	// svcDiscoveryDNS resolves the service name executor gives to the
+	// objects of a function through the cluster DNS.
+	svcDiscoveryDNS svcDiscoveryMode = "dns"
+	// svcDiscoveryLabels looks the service up by the labels executor sets
+	// on the objects of a function.
+	svcDiscoveryLabels svcDiscoveryMode = "labels"
+)
+
+// makeServiceDiscovery returns the service discovery of the given mode, or
+// nil if mode is empty.
+func makeServiceDiscovery(mode string, kubeClient kubernetes.Interface, functionNamespace string) (*serviceDiscovery, error) {
+	switch svcDiscoveryMode(mode) {
+	case "":
+		return nil, nil
+	case svcDiscoveryDNS, svcDiscoveryLabels:
+	default:
+		return nil, errors.Errorf("unknown service discovery mode %q, must be %q or %q", mode, svcDiscoveryDNS, svcDiscoveryLabels)
+	}
+	return &serviceDiscovery{
+		mode:              svcDiscoveryMode(mode),
+		kubeClient:        kubeClient,
+		functionNamespace: functionNamespace,
+		lookupHost:        net.DefaultResolver.LookupHost,
+	}, nil
+}
+
+// supports returns whether the service of a function can be found without
+// executor.
+func (sd *serviceDiscovery) supports(fn *fv1.Function) bool {
+	return sd != nil && fn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType == fv1.ExecutorTypeNewdeploy
+}
+
+// getServiceAddress returns the address of the service of a newdeploy
+// function, in the same "name.namespace" form executor gives.
+func (sd *serviceDiscovery) getServiceAddress(ctx context.Context, fn *fv1.Function) (string, error) {
+	namespace := util.FunctionObjNamespace(fn, sd.functionNamespace)
+
+	switch sd.mode {
+	case svcDiscoveryDNS:
+		host := fmt.Sprintf("%v.%v", util.NewdeployObjName(fn), namespace)
+		// make sure the service exists before sending the request to it
+		if _, err := sd.lookupHost(ctx, host); err != nil {
+			return "", errors.Wrapf(err, "error resolving service of function %v", fn.ObjectMeta.Name)
+		}
+		return host, nil
+
+	case svcDiscoveryLabels:
+		selector := labels.Set{
+			fv1.EXECUTOR_TYPE: string(fv1.ExecutorTypeNewdeploy),
+			fv1.FUNCTION_UID:  string(fn.ObjectMeta.UID),
+		}.AsSelector().String()
+		services, err := sd.kubeClient.CoreV1().Services(namespace).List(metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return "", errors.Wrapf(err, "error listing services of function %v", fn.ObjectMeta.Name)
+		}
+		if len(services.Items) == 0 {
+			return "", errors.Errorf("no service found for function %v", fn.ObjectMeta.Name)
+		}
+		svc := services.Items[0]
+		return fmt.Sprintf("%v.%v", svc.ObjectMeta.Name, svc.ObjectMeta.Namespace), nil
+	}
+
+	return "", errors.Errorf("unknown service discovery mode %q", sd.mode)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestServiceDiscovery(t *testing.T) {
+	fn := &fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hello",
+			Namespace: metav1.NamespaceDefault,
+			UID:       "3ac1b9ad-1ba4-4e2b-a1b8-7b3d5ff2e6c4",
+		},
+		Spec: fv1.FunctionSpec{
+			InvokeStrategy: fv1.InvokeStrategy{
+				ExecutionStrategy: fv1.ExecutionStrategy{ExecutorType: fv1.ExecutorTypeNewdeploy},
+			},
+		},
+	}
+
+	sd, err := makeServiceDiscovery("dns", nil, "fission-function")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resolved string
+	sd.lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		resolved = host
+		return []string{"10.0.0.1"}, nil
+	}
+	expected := "newdeploy-hello-default-a1b8-7b3d5ff2e6c4.fission-function"
+	if addr, err := sd.getServiceAddress(context.Background(), fn); err != nil || addr != expected {
+		t.Fatalf("expected address %v, got %v, %v", expected, addr, err)
+	}
+	if resolved != expected {
+		t.Fatalf("expected %v to be resolved, got %v", expected, resolved)
+	}
+
+	sd.lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		return nil, errors.New("no such host")
+	}
+	if _, err := sd.getServiceAddress(context.Background(), fn); err == nil {
+		t.Fatal("expected an error for a service that doesn't resolve")
+	}
+
+	kubeClient := fake.NewSimpleClientset(&apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hello-svc",
+			Namespace: "fission-function",
+			Labels: map[string]string{
+				fv1.EXECUTOR_TYPE: string(fv1.ExecutorTypeNewdeploy),
+				fv1.FUNCTION_UID:  string(fn.ObjectMeta.UID),
+			},
+		},
+	})
+	sd, err = makeServiceDiscovery("labels", kubeClient, "fission-function")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr, err := sd.getServiceAddress(context.Background(), fn); err != nil || addr != "hello-svc.fission-function" {
+		t.Fatalf("expected address hello-svc.fission-function, got %v, %v", addr, err)
+	}
+
+	// poolmgr functions need executor to specialize pods
+	fn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType = fv1.ExecutorTypePoolmgr
+	if sd.supports(fn) {
+		t.Fatal("expected poolmgr functions not to be supported")
+	}
+
+	if _, err := makeServiceDiscovery("consul", nil, "fission-function"); err == nil {
+		t.Fatal("expected an error for an unknown mode")
+	}
+	if sd, err := makeServiceDiscovery("", nil, "fission-function"); err != nil || sd != nil {
+		t.Fatalf("expected no service discovery without a mode, got %v, %v", sd, err)
+	}
+}
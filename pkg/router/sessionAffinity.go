@@ -0,0 +1,242 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dchest/uniuri"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+type (
+	// sessionAffinityManager pins client sessions to function pods. It's shared
+	// by all function handlers so that sessions survive router rebuilds.
+	//
+	// Pods of newdeploy functions are picked by rendezvous hashing of the session
+	// ID over the endpoints of the function service, so all router replicas agree
+	// on the pod of a session. Pods of poolmgr functions are chosen by executor,
+	// so the pin is local to the router replica serving the first request.
+	sessionAffinityManager struct {
+		logger     *zap.Logger
+		kubeClient *kubernetes.Clientset
+		lock       sync.Mutex
+		sessions   map[sessionKey]*sessionTarget
+	}
+
+	sessionKey struct {
+		function metadataKey
+		session  string
+	}
+
+	// sessionTarget is the pod a session is pinned to.
+	sessionTarget struct {
+		// podURL is the address requests are sent to.
+		podURL *url.URL
+		// serviceURL is the address returned by executor, which
+		// is tapped to keep the function service alive.
+		serviceURL *url.URL
+		ttl        time.Duration
+		lastUsed   time.Time
+	}
+
+	// requestSession is the session of a request to a trigger with session affinity.
+	requestSession struct {
+		id  string
+		ttl time.Duration
+	}
+)
+
+func makeSessionAffinityManager(logger *zap.Logger, kubeClient *kubernetes.Clientset) *sessionAffinityManager {
+	m := &sessionAffinityManager{
+		logger:     logger.Named("session_affinity"),
+		kubeClient: kubeClient,
+		sessions:   make(map[sessionKey]*sessionTarget),
+	}
+	go m.expiryService()
+	return m
+}
+
+// getRequestSession returns the session of the request. For cookie affinity a new
+// session is started, and its cookie set on the response, if the request has none.
+// nil is returned if the request doesn't belong to any session.
+func getRequestSession(affinity *fv1.SessionAffinity, w http.ResponseWriter, r *http.Request) *requestSession {
+	ttl := time.Duration(affinity.TTL) * time.Second
+	if affinity.TTL == 0 {
+		ttl = fv1.DefaultSessionAffinityTTL * time.Second
+	}
+
+	var id string
+	switch affinity.Type {
+	case fv1.SessionAffinityTypeCookie:
+		name := affinity.Name
+		if len(name) == 0 {
+			name = fv1.DefaultSessionAffinityCookie
+		}
+		if cookie, err := r.Cookie(name); err == nil && len(cookie.Value) > 0 {
+			id = cookie.Value
+		} else {
+			id = uniuri.NewLen(24)
+			http.SetCookie(w, &http.Cookie{
+				Name:     name,
+				Value:    id,
+				Path:     "/",
+				HttpOnly: true,
+			})
+		}
+	case fv1.SessionAffinityTypeHeader:
+		id = r.Header.Get(affinity.Name)
+	}
+
+	if len(id) == 0 {
+		return nil
+	}
+	return &requestSession{id: id, ttl: ttl}
+}
+
+// lookup returns the pod the session is pinned to, or nil if it's not pinned.
+func (m *sessionAffinityManager) lookup(fn *metav1.ObjectMeta, session *requestSession) *sessionTarget {
+	key := sessionKey{function: *keyFromMetadata(fn), session: session.id}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	target, ok := m.sessions[key]
+	if !ok {
+		return nil
+	}
+	if time.Since(target.lastUsed) > target.ttl {
+		delete(m.sessions, key)
+		return nil
+	}
+	target.lastUsed = time.Now()
+	return target
+}
+
+// pin pins the session to a pod behind the service executor returned for the function.
+func (m *sessionAffinityManager) pin(fn *fv1.Function, session *requestSession, serviceURL *url.URL) *sessionTarget {
+	target := &sessionTarget{
+		podURL:     serviceURL,
+		serviceURL: serviceURL,
+		ttl:        session.ttl,
+		lastUsed:   time.Now(),
+	}
+
+	// poolmgr already returns the address of a specialized pod
+	if fn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType == fv1.ExecutorTypeNewdeploy {
+		podURL, err := m.getPodURL(serviceURL, session.id)
+		if err != nil {
+			// fall back to the service, the session is just not sticky
+			m.logger.Debug("error getting pod of function service, session is not pinned",
+				zap.Error(err), zap.String("function", fn.ObjectMeta.Name))
+			return target
+		}
+		target.podURL = podURL
+	}
+
+	m.lock.Lock()
+	m.sessions[sessionKey{function: *keyFromMetadata(&fn.ObjectMeta), session: session.id}] = target
+	m.lock.Unlock()
+
+	return target
+}
+
+// unpin removes the pin of the session, e.g. when the pod is gone.
+func (m *sessionAffinityManager) unpin(fn *metav1.ObjectMeta, session *requestSession) {
+	m.lock.Lock()
+	delete(m.sessions, sessionKey{function: *keyFromMetadata(fn), session: session.id})
+	m.lock.Unlock()
+}
+
+// getPodURL picks the pod of the session from the ready endpoints of a function service.
+func (m *sessionAffinityManager) getPodURL(serviceURL *url.URL, sessionID string) (*url.URL, error) {
+	if m.kubeClient == nil {
+		return nil, errors.New("no kubernetes client")
+	}
+
+	// service address of newdeploy functions is "<name>.<namespace>"
+	parts := strings.Split(serviceURL.Hostname(), ".")
+	if len(parts) < 2 {
+		return nil, errors.Errorf("unexpected function service address %v", serviceURL.Host)
+	}
+
+	endpoints, err := m.kubeClient.CoreV1().Endpoints(parts[1]).Get(parts[0], metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting endpoints of service %v", serviceURL.Host)
+	}
+
+	var addresses []string
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Ports) == 0 {
+			continue
+		}
+		for _, addr := range subset.Addresses {
+			addresses = append(addresses, net.JoinHostPort(addr.IP, fmt.Sprint(subset.Ports[0].Port)))
+		}
+	}
+	if len(addresses) == 0 {
+		return nil, errors.Errorf("no ready endpoints for service %v", serviceURL.Host)
+	}
+
+	return &url.URL{
+		Scheme: serviceURL.Scheme,
+		Host:   pickSessionAddress(sessionID, addresses),
+	}, nil
+}
+
+// pickSessionAddress picks an address for the session with rendezvous hashing,
+// so that only the sessions of a removed pod move when pods come and go.
+func pickSessionAddress(sessionID string, addresses []string) string {
+	var picked string
+	var maxScore uint64
+	for _, addr := range addresses {
+		h := fnv.New64a()
+		h.Write([]byte(sessionID))
+		h.Write([]byte{0})
+		h.Write([]byte(addr))
+		if score := h.Sum64(); len(picked) == 0 || score > maxScore {
+			picked, maxScore = addr, score
+		}
+	}
+	return picked
+}
+
+// expiryService removes expired sessions periodically.
+func (m *sessionAffinityManager) expiryService() {
+	for {
+		time.Sleep(time.Minute)
+		m.lock.Lock()
+		for key, target := range m.sessions {
+			if time.Since(target.lastUsed) > target.ttl {
+				delete(m.sessions, key)
+			}
+		}
+		m.lock.Unlock()
+	}
+}
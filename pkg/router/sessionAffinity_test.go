@@ -0,0 +1,82 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestPickSessionAddress(t *testing.T) {
+	addresses := []string{"10.0.0.1:8888", "10.0.0.2:8888", "10.0.0.3:8888", "10.0.0.4:8888"}
+
+	picked := make(map[string]string)
+	for i := 0; i < 100; i++ {
+		session := fmt.Sprintf("session-%v", i)
+		picked[session] = pickSessionAddress(session, addresses)
+		if pickSessionAddress(session, addresses) != picked[session] {
+			t.Fatalf("session %v picked different addresses", session)
+		}
+	}
+
+	// only sessions of the removed address should move
+	removed := addresses[1]
+	for session, addr := range picked {
+		newAddr := pickSessionAddress(session, []string{addresses[0], addresses[2], addresses[3]})
+		if addr != removed && newAddr != addr {
+			t.Errorf("session %v moved from %v to %v", session, addr, newAddr)
+		}
+	}
+}
+
+func TestGetRequestSession(t *testing.T) {
+	cookieAffinity := &fv1.SessionAffinity{Type: fv1.SessionAffinityTypeCookie}
+
+	// new session for request without cookie
+	w := httptest.NewRecorder()
+	session := getRequestSession(cookieAffinity, w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if session == nil {
+		t.Fatal("expected a new session")
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != fv1.DefaultSessionAffinityCookie || cookies[0].Value != session.id {
+		t.Fatalf("expected session cookie to be set, got %v", cookies)
+	}
+
+	// existing session
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: fv1.DefaultSessionAffinityCookie, Value: "abc"})
+	w = httptest.NewRecorder()
+	session = getRequestSession(cookieAffinity, w, r)
+	if session == nil || session.id != "abc" || len(w.Result().Cookies()) != 0 {
+		t.Fatalf("expected existing session abc, got %v", session)
+	}
+
+	headerAffinity := &fv1.SessionAffinity{Type: fv1.SessionAffinityTypeHeader, Name: "X-User"}
+	if getRequestSession(headerAffinity, httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil)) != nil {
+		t.Error("expected no session for request without header")
+	}
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-User", "alice")
+	if session = getRequestSession(headerAffinity, httptest.NewRecorder(), r); session == nil || session.id != "alice" {
+		t.Errorf("expected session alice, got %v", session)
+	}
+}
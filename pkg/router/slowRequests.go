@@ -0,0 +1,298 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type (
+	// slowRequestRecorder writes the requests taking longer than a
+	// threshold to a dedicated sink, one JSON record per line, for
+	// offline analysis of slow functions.
+	slowRequestRecorder struct {
+		logger    *zap.Logger
+		threshold time.Duration
+		// bodyBytes is how much of the request body is kept.
+		bodyBytes int
+
+		mu   sync.Mutex
+		sink io.Writer
+	}
+
+	// requestStage is a stage of a request router times.
+	requestStage int
+
+	// requestTrace times the stages of a proxied request. A nil trace
+	// records nothing.
+	requestTrace struct {
+		start  time.Time
+		stages [numRequestStages]time.Duration
+		status int
+		body   *bodySnippet
+	}
+
+	// bodySnippet keeps the first bytes read from a request body.
+	bodySnippet struct {
+		io.ReadCloser
+		max       int
+		data      []byte
+		truncated bool
+	}
+
+	// slowRequest is the record of a slow request.
+	slowRequest struct {
+		Time          time.Time           `json:"time"`
+		Namespace     string              `json:"namespace"`
+		Function      string              `json:"function"`
+		Trigger       string              `json:"trigger,omitempty"`
+		Method        string              `json:"method"`
+		URL           string              `json:"url"`
+		Status        int                 `json:"status"`
+		Headers       map[string][]string `json:"headers"`
+		Body          string              `json:"body,omitempty"`
+		BodyTruncated bool                `json:"bodyTruncated,omitempty"`
+		Timing        slowRequestTiming   `json:"timing"`
+	}
+
+	// slowRequestTiming breaks the time of a slow request down in
+	// milliseconds.
+	slowRequestTiming struct {
+		// Total is the time from router receiving the request to
+		// the response being written.
+		Total float64 `json:"totalMs"`
+		// Queue is the time waiting for a free request slot.
+		Queue float64 `json:"queueMs"`
+		// Specialize is the time getting a function pod from executor.
+		Specialize float64 `json:"specializeMs"`
+		// Execute is the time the function took to respond.
+		Execute float64 `json:"executeMs"`
+		// Proxy is the rest, spent in router itself, backing off
+		// between retries and copying the response.
+		Proxy float64 `json:"proxyMs"`
+	}
+)
+
+const (
+	stageQueue requestStage = iota
+	stageSpecialize
+	stageExecute
+	numRequestStages
+)
+
+// defaultSlowRequestBodyBytes is how much of the request body is kept
+// if not configured.
+const defaultSlowRequestBodyBytes = 512
+
+const redacted = "REDACTED"
+
+// makeSlowRequestRecorder returns a recorder of the requests slower than
+// threshold writing to the file at sinkPath, or to stdout if empty. It
+// returns nil if threshold is empty.
+func makeSlowRequestRecorder(logger *zap.Logger, threshold string, bodyBytes string, sinkPath string) (*slowRequestRecorder, error) {
+	if len(threshold) == 0 {
+		return nil, nil
+	}
+	t, err := time.ParseDuration(threshold)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing slow request threshold")
+	}
+	if t <= 0 {
+		return nil, errors.Errorf("slow request threshold must be positive, got %v", threshold)
+	}
+
+	b := defaultSlowRequestBodyBytes
+	if len(bodyBytes) > 0 {
+		b, err = strconv.Atoi(bodyBytes)
+		if err != nil || b < 0 {
+			return nil, errors.Errorf("invalid size of slow request body snippet %q", bodyBytes)
+		}
+	}
+
+	var sink io.Writer = os.Stdout
+	if len(sinkPath) > 0 {
+		f, err := os.OpenFile(sinkPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, errors.Wrap(err, "error opening slow request sink")
+		}
+		sink = f
+	}
+
+	return &slowRequestRecorder{
+		logger:    logger,
+		threshold: t,
+		bodyBytes: b,
+		sink:      sink,
+	}, nil
+}
+
+// trace starts timing request, keeping the start of its body as the
+// proxy reads it. It returns nil if the recorder is disabled.
+func (r *slowRequestRecorder) trace(request *http.Request) *requestTrace {
+	if r == nil {
+		return nil
+	}
+	t := &requestTrace{start: time.Now()}
+	if r.bodyBytes > 0 && request.Body != nil && request.Body != http.NoBody {
+		t.body = &bodySnippet{ReadCloser: request.Body, max: r.bodyBytes}
+		request.Body = t.body
+	}
+	return t
+}
+
+// finish records the traced request if it was slow.
+func (r *slowRequestRecorder) finish(fh *functionHandler, request *http.Request, t *requestTrace) {
+	if r == nil || t == nil {
+		return
+	}
+	total := time.Since(t.start)
+	if total < r.threshold {
+		return
+	}
+
+	record := &slowRequest{
+		Time:      t.start,
+		Namespace: fh.function.ObjectMeta.Namespace,
+		Function:  fh.function.ObjectMeta.Name,
+		Method:    request.Method,
+		URL:       sanitizeURL(request.URL),
+		Status:    t.status,
+		Headers:   sanitizeHeaders(request.Header),
+		Timing:    t.timing(total),
+	}
+	if fh.httpTrigger != nil {
+		record.Trigger = fh.httpTrigger.ObjectMeta.Name
+	}
+	if t.body != nil {
+		record.Body = string(t.body.data)
+		record.BodyTruncated = t.body.truncated
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		r.logger.Error("error marshaling slow request", zap.Error(err))
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.sink.Write(append(data, '\n'))
+	if err != nil {
+		r.logger.Error("error writing slow request", zap.Error(err))
+	}
+}
+
+// record adds the time since start to stage.
+func (t *requestTrace) record(stage requestStage, start time.Time) {
+	if t == nil {
+		return
+	}
+	t.stages[stage] += time.Since(start)
+}
+
+// setStatus records the status code of the response.
+func (t *requestTrace) setStatus(status int) {
+	if t == nil {
+		return
+	}
+	t.status = status
+}
+
+func (t *requestTrace) timing(total time.Duration) slowRequestTiming {
+	proxy := total
+	for _, d := range t.stages {
+		proxy -= d
+	}
+	if proxy < 0 {
+		proxy = 0
+	}
+	return slowRequestTiming{
+		Total:      milliseconds(total),
+		Queue:      milliseconds(t.stages[stageQueue]),
+		Specialize: milliseconds(t.stages[stageSpecialize]),
+		Execute:    milliseconds(t.stages[stageExecute]),
+		Proxy:      milliseconds(proxy),
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func (s *bodySnippet) Read(p []byte) (int, error) {
+	n, err := s.ReadCloser.Read(p)
+	if room := s.max - len(s.data); n > room {
+		s.data = append(s.data, p[:room]...)
+		s.truncated = true
+	} else {
+		s.data = append(s.data, p[:n]...)
+	}
+	return n, err
+}
+
+// isSensitive returns true if the header or query parameter name may
+// carry credentials.
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	switch name {
+	case "authorization", "proxy-authorization", "cookie", "set-cookie":
+		return true
+	}
+	for _, s := range []string{"token", "secret", "password", "key", "auth", "session"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeHeaders returns a copy of header with the values of sensitive
+// headers redacted.
+func sanitizeHeaders(header http.Header) map[string][]string {
+	sanitized := make(map[string][]string, len(header))
+	for name, values := range header {
+		if isSensitive(name) {
+			sanitized[name] = []string{redacted}
+		} else {
+			sanitized[name] = append([]string{}, values...)
+		}
+	}
+	return sanitized
+}
+
+// sanitizeURL returns the path and query of u with the values of
+// sensitive query parameters redacted.
+func sanitizeURL(u *url.URL) string {
+	query := u.Query()
+	for name := range query {
+		if isSensitive(name) {
+			query[name] = []string{redacted}
+		}
+	}
+	sanitized := url.URL{Path: u.Path, RawQuery: query.Encode()}
+	return sanitized.String()
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestSlowRequestRecorder(t *testing.T) {
+	sink := &bytes.Buffer{}
+	recorder := &slowRequestRecorder{
+		logger:    zap.NewNop(),
+		threshold: 10 * time.Millisecond,
+		bodyBytes: 8,
+		sink:      sink,
+	}
+	fh := &functionHandler{
+		function:    &fv1.Function{ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"}},
+		httpTrigger: &fv1.HTTPTrigger{ObjectMeta: metav1.ObjectMeta{Name: "hello-trigger"}},
+	}
+
+	request := httptest.NewRequest("POST", "/hello?name=x&api_key=secret", strings.NewReader("0123456789abcdef"))
+	request.Header.Set("Authorization", "Bearer secret")
+	request.Header.Set("Content-Type", "text/plain")
+
+	trace := recorder.trace(request)
+	body, err := ioutil.ReadAll(request.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef", string(body), "the proxy must still read the whole body")
+
+	trace.stages[stageQueue] = 2 * time.Millisecond
+	trace.stages[stageSpecialize] = 3 * time.Millisecond
+	trace.stages[stageExecute] = 4 * time.Millisecond
+	trace.setStatus(http.StatusOK)
+
+	// fast requests are not recorded
+	recorder.finish(fh, request, trace)
+	assert.Zero(t, sink.Len())
+
+	trace.start = trace.start.Add(-time.Second)
+	recorder.finish(fh, request, trace)
+
+	record := &slowRequest{}
+	assert.NoError(t, json.Unmarshal(sink.Bytes(), record))
+	assert.Equal(t, "hello", record.Function)
+	assert.Equal(t, "hello-trigger", record.Trigger)
+	assert.Equal(t, http.StatusOK, record.Status)
+	assert.Equal(t, "/hello?api_key=REDACTED&name=x", record.URL)
+	assert.Equal(t, []string{redacted}, record.Headers["Authorization"])
+	assert.Equal(t, []string{"text/plain"}, record.Headers["Content-Type"])
+	assert.Equal(t, "01234567", record.Body)
+	assert.True(t, record.BodyTruncated)
+	assert.Equal(t, 2.0, record.Timing.Queue)
+	assert.Equal(t, 3.0, record.Timing.Specialize)
+	assert.Equal(t, 4.0, record.Timing.Execute)
+	assert.InDelta(t, record.Timing.Total-9, record.Timing.Proxy, 0.001)
+}
+
+func TestMakeSlowRequestRecorder(t *testing.T) {
+	recorder, err := makeSlowRequestRecorder(zap.NewNop(), "", "", "")
+	assert.NoError(t, err)
+	assert.Nil(t, recorder, "recording is disabled without threshold")
+	assert.Nil(t, recorder.trace(httptest.NewRequest("GET", "/", nil)))
+
+	recorder, err = makeSlowRequestRecorder(zap.NewNop(), "2s", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Second, recorder.threshold)
+	assert.Equal(t, defaultSlowRequestBodyBytes, recorder.bodyBytes)
+
+	for _, args := range [][2]string{{"soon", ""}, {"-1s", ""}, {"1s", "lots"}, {"1s", "-1"}} {
+		_, err = makeSlowRequestRecorder(zap.NewNop(), args[0], args[1], "")
+		assert.Error(t, err, "threshold %q, body bytes %q", args[0], args[1])
+	}
+}
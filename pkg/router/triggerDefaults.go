@@ -0,0 +1,77 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"fmt"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// environmentKey returns the key of the environment of a function in the
+// environment store.
+func environmentKey(fn *fv1.Function) string {
+	return fmt.Sprintf("%v/%v", fn.Spec.Environment.Namespace, fn.Spec.Environment.Name)
+}
+
+// triggerDefaults returns the HTTP trigger defaults of the environment of
+// the functions of a trigger, or nil if the functions don't share an
+// environment, or it has no defaults.
+func (ts *HTTPTriggerSet) triggerDefaults(functionMap map[string]*fv1.Function) *fv1.HTTPTriggerDefaults {
+	if ts.envStore == nil {
+		return nil
+	}
+	key := ""
+	for _, fn := range functionMap {
+		fnKey := environmentKey(fn)
+		if len(key) > 0 && key != fnKey {
+			return nil
+		}
+		key = fnKey
+	}
+	if len(key) == 0 {
+		return nil
+	}
+	obj, exists, err := ts.envStore.GetByKey(key)
+	if err != nil || !exists {
+		return nil
+	}
+	return obj.(*fv1.Environment).Spec.HTTPTriggerDefaults
+}
+
+// applyTriggerDefaults sets the middleware settings a trigger leaves unset
+// to the defaults of its environment.
+func applyTriggerDefaults(spec *fv1.HTTPTriggerSpec, defaults *fv1.HTTPTriggerDefaults) {
+	if defaults == nil {
+		return
+	}
+	if spec.Authentication == nil {
+		spec.Authentication = defaults.Authentication
+	}
+	if spec.RequestValidation == nil {
+		spec.RequestValidation = defaults.RequestValidation
+	}
+	if spec.Timeout == 0 {
+		spec.Timeout = defaults.Timeout
+	}
+	if spec.Retry == nil {
+		spec.Retry = defaults.Retry
+	}
+	if spec.CircuitBreaker == nil {
+		spec.CircuitBreaker = defaults.CircuitBreaker
+	}
+}
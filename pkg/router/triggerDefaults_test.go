@@ -0,0 +1,94 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sCache "k8s.io/client-go/tools/cache"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestApplyTriggerDefaults(t *testing.T) {
+	envAuth := &fv1.HTTPTriggerAuthentication{JWT: &fv1.JWTAuthentication{Issuer: "https://env.example.com"}}
+	triggerAuth := &fv1.HTTPTriggerAuthentication{JWT: &fv1.JWTAuthentication{Issuer: "https://trigger.example.com"}}
+	defaults := &fv1.HTTPTriggerDefaults{
+		Authentication:    envAuth,
+		RequestValidation: &fv1.RequestValidation{ConfigMap: "api"},
+		Timeout:           30,
+		Retry:             &fv1.HTTPTriggerRetryPolicy{MaxRetries: 2},
+		CircuitBreaker:    &fv1.CircuitBreakerPolicy{ErrorThreshold: 3},
+	}
+
+	spec := fv1.HTTPTriggerSpec{}
+	applyTriggerDefaults(&spec, defaults)
+	assert.Equal(t, envAuth, spec.Authentication)
+	assert.Equal(t, defaults.RequestValidation, spec.RequestValidation)
+	assert.Equal(t, 30, spec.Timeout)
+	assert.Equal(t, defaults.Retry, spec.Retry)
+	assert.Equal(t, defaults.CircuitBreaker, spec.CircuitBreaker)
+
+	// settings of the trigger override the defaults
+	spec = fv1.HTTPTriggerSpec{Authentication: triggerAuth, Timeout: 5}
+	applyTriggerDefaults(&spec, defaults)
+	assert.Equal(t, triggerAuth, spec.Authentication)
+	assert.Equal(t, 5, spec.Timeout)
+	assert.Equal(t, defaults.Retry, spec.Retry)
+
+	spec = fv1.HTTPTriggerSpec{}
+	applyTriggerDefaults(&spec, nil)
+	assert.Equal(t, fv1.HTTPTriggerSpec{}, spec)
+}
+
+func TestTriggerDefaults(t *testing.T) {
+	defaults := &fv1.HTTPTriggerDefaults{Timeout: 30}
+	store := k8sCache.NewStore(k8sCache.MetaNamespaceKeyFunc)
+	for _, env := range []*fv1.Environment{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nodejs"}, Spec: fv1.EnvironmentSpec{HTTPTriggerDefaults: defaults}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "go"}},
+	} {
+		assert.Nil(t, store.Add(env))
+	}
+	ts := &HTTPTriggerSet{envStore: store}
+
+	fn := func(env string) *fv1.Function {
+		f := &fv1.Function{}
+		f.Spec.Environment = fv1.EnvironmentReference{Namespace: "default", Name: env}
+		return f
+	}
+
+	tests := []struct {
+		name      string
+		functions map[string]*fv1.Function
+		want      *fv1.HTTPTriggerDefaults
+	}{
+		{"single function", map[string]*fv1.Function{"a": fn("nodejs")}, defaults},
+		{"functions of one environment", map[string]*fv1.Function{"a": fn("nodejs"), "b": fn("nodejs")}, defaults},
+		{"functions of different environments", map[string]*fv1.Function{"a": fn("nodejs"), "b": fn("go")}, nil},
+		{"environment without defaults", map[string]*fv1.Function{"a": fn("go")}, nil},
+		{"missing environment", map[string]*fv1.Function{"a": fn("python")}, nil},
+		{"no functions", nil, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, ts.triggerDefaults(test.functions))
+		})
+	}
+}
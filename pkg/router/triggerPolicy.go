@@ -0,0 +1,283 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	// maxRetryBodySize limits the size of request bodies router keeps in
+	// memory to replay on retries. Larger requests aren't retried.
+	maxRetryBodySize = 1 << 20
+
+	// maxRetryBackoff caps the backoff between retries.
+	maxRetryBackoff = 10 * time.Second
+
+	// HEADERS_IDEMPOTENCY_KEY marks a request as safe to retry whatever its method.
+	HEADERS_IDEMPOTENCY_KEY = "Idempotency-Key"
+)
+
+// defaultRetryOn are the status codes retried if a retry policy lists none.
+var defaultRetryOn = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+type (
+	// circuitBreakerSet holds the circuit breakers of HTTP triggers. It's
+	// shared by all function handlers so that circuit state survives router
+	// rebuilds.
+	circuitBreakerSet struct {
+		lock     sync.Mutex
+		breakers map[k8stypes.UID]*circuitBreaker
+	}
+
+	// circuitBreaker tracks the consecutive failures of requests to a trigger.
+	circuitBreaker struct {
+		lock           sync.Mutex
+		errorThreshold int
+		openDuration   time.Duration
+		failures       int
+		// openedAt is when the circuit opened, zero if it's closed.
+		openedAt time.Time
+		// probeStartedAt is when the request probing a half-open
+		// circuit was let through, zero if there is none.
+		probeStartedAt time.Time
+	}
+
+	// retryingTriggerRoundTripper retries requests to an HTTP trigger
+	// according to its retry policy. Every attempt goes through the
+	// RetryingRoundTripper, which deals with unreachable function pods.
+	retryingTriggerRoundTripper struct {
+		logger *zap.Logger
+		base   http.RoundTripper
+		policy *fv1.HTTPTriggerRetryPolicy
+		// noRetryOnTimeout stops requests that timed out from being
+		// retried, as with long polls.
+		noRetryOnTimeout bool
+	}
+
+	// replayBody is the body of a request too large to be retried, made
+	// of the part read to find out and the rest of the original body.
+	replayBody struct {
+		io.Reader
+		io.Closer
+	}
+)
+
+func makeCircuitBreakerSet() *circuitBreakerSet {
+	return &circuitBreakerSet{
+		breakers: make(map[k8stypes.UID]*circuitBreaker),
+	}
+}
+
+// get returns the circuit breaker of the trigger, configured with its current policy.
+func (cbs *circuitBreakerSet) get(trigger *fv1.HTTPTrigger) *circuitBreaker {
+	cbs.lock.Lock()
+	cb, ok := cbs.breakers[trigger.ObjectMeta.UID]
+	if !ok {
+		cb = &circuitBreaker{}
+		cbs.breakers[trigger.ObjectMeta.UID] = cb
+	}
+	cbs.lock.Unlock()
+
+	policy := trigger.Spec.CircuitBreaker
+	cb.lock.Lock()
+	cb.errorThreshold = policy.ErrorThreshold
+	if cb.errorThreshold == 0 {
+		cb.errorThreshold = fv1.DefaultCircuitBreakerErrorThreshold
+	}
+	cb.openDuration = time.Duration(policy.OpenDuration) * time.Second
+	if policy.OpenDuration == 0 {
+		cb.openDuration = fv1.DefaultCircuitBreakerOpenDuration * time.Second
+	}
+	cb.lock.Unlock()
+
+	return cb
+}
+
+// prune drops the circuit breakers of triggers that no longer exist.
+func (cbs *circuitBreakerSet) prune(triggers []fv1.HTTPTrigger) {
+	exists := make(map[k8stypes.UID]bool, len(triggers))
+	for _, t := range triggers {
+		exists[t.ObjectMeta.UID] = true
+	}
+
+	cbs.lock.Lock()
+	defer cbs.lock.Unlock()
+	for uid := range cbs.breakers {
+		if !exists[uid] {
+			delete(cbs.breakers, uid)
+		}
+	}
+}
+
+// allow reports whether a request may be sent to the function, and otherwise
+// how long the circuit stays open. Once the open duration is over, a single
+// request is let through to probe the function; another one is let through if
+// the probe doesn't report back within the open duration.
+func (cb *circuitBreaker) allow(now time.Time) (bool, time.Duration) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	if cb.openedAt.IsZero() {
+		return true, 0
+	}
+	if wait := cb.openedAt.Add(cb.openDuration).Sub(now); wait > 0 {
+		return false, wait
+	}
+	if !cb.probeStartedAt.IsZero() && now.Sub(cb.probeStartedAt) < cb.openDuration {
+		return false, cb.probeStartedAt.Add(cb.openDuration).Sub(now)
+	}
+	cb.probeStartedAt = now
+	return true, 0
+}
+
+// record reports the outcome of a request let through by allow.
+func (cb *circuitBreaker) record(now time.Time, success bool) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	if success {
+		cb.failures = 0
+		cb.openedAt = time.Time{}
+		cb.probeStartedAt = time.Time{}
+		return
+	}
+
+	cb.failures++
+	if !cb.probeStartedAt.IsZero() || cb.failures >= cb.errorThreshold {
+		// open the circuit, or keep it open if the probe failed
+		cb.openedAt = now
+		cb.probeStartedAt = time.Time{}
+	}
+}
+
+// RoundTrip sends the request, retrying it while it fails and retries are left.
+func (rt *retryingTriggerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isIdempotentRequest(req) {
+		return rt.base.RoundTrip(req)
+	}
+
+	// keep the body to replay it on retries
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = ioutil.ReadAll(io.LimitReader(req.Body, maxRetryBodySize+1))
+		if err != nil {
+			req.Body.Close()
+			return nil, err
+		}
+		if len(body) > maxRetryBodySize {
+			req.Body = &replayBody{Reader: io.MultiReader(bytes.NewReader(body), req.Body), Closer: req.Body}
+			return rt.base.RoundTrip(req)
+		}
+		req.Body.Close()
+	}
+
+	backoff := time.Duration(rt.policy.Backoff) * time.Millisecond
+	if rt.policy.Backoff == 0 {
+		backoff = fv1.DefaultRetryBackoff * time.Millisecond
+	}
+
+	for attempt := 0; ; attempt++ {
+		if body != nil {
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		resp, err := rt.base.RoundTrip(req)
+		if attempt >= rt.policy.MaxRetries || req.Context().Err() != nil || !rt.shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		if resp != nil {
+			// drain the response so the connection can be reused
+			io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096)) //nolint: errcheck
+			resp.Body.Close()
+			rt.logger.Debug("retrying request after retryable response",
+				zap.Int("status_code", resp.StatusCode),
+				zap.Int("attempt", attempt+1),
+				zap.Duration("backoff", backoff))
+		} else {
+			rt.logger.Debug("retrying request after error",
+				zap.Error(err),
+				zap.Int("attempt", attempt+1),
+				zap.Duration("backoff", backoff))
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+func (rt *retryingTriggerRoundTripper) shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		if rt.noRetryOnTimeout && isTimeoutError(err) {
+			return false
+		}
+		return err != context.Canceled
+	}
+	if rt.noRetryOnTimeout && resp.StatusCode == http.StatusGatewayTimeout {
+		return false
+	}
+	retryOn := rt.policy.RetryOn
+	if len(retryOn) == 0 {
+		retryOn = defaultRetryOn
+	}
+	for _, code := range retryOn {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// isTimeoutError reports whether the request failed because it timed out.
+func isTimeoutError(err error) bool {
+	if err == context.DeadlineExceeded {
+		return true
+	}
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
+
+// isIdempotentRequest reports whether the request can be sent more than once
+// without changing its effect, because of its method or idempotency key.
+func isIdempotentRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return len(req.Header.Get(HEADERS_IDEMPOTENCY_KEY)) > 0
+}
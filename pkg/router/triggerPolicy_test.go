@@ -0,0 +1,164 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	trigger := &fv1.HTTPTrigger{}
+	trigger.ObjectMeta.UID = "trigger-uid"
+	trigger.Spec.CircuitBreaker = &fv1.CircuitBreakerPolicy{ErrorThreshold: 3, OpenDuration: 10}
+
+	cbs := makeCircuitBreakerSet()
+	cb := cbs.get(trigger)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := cb.allow(now); !ok {
+			t.Fatalf("request %v rejected before reaching the error threshold", i)
+		}
+		cb.record(now, false)
+	}
+	if ok, wait := cb.allow(now.Add(time.Second)); ok || wait != 9*time.Second {
+		t.Fatalf("expected open circuit for 9s, got allowed %v, wait %v", ok, wait)
+	}
+
+	// a single probe is let through after the open duration
+	now = now.Add(11 * time.Second)
+	if ok, _ := cb.allow(now); !ok {
+		t.Fatal("expected probe to be let through")
+	}
+	if ok, _ := cb.allow(now); ok {
+		t.Fatal("expected a single probe")
+	}
+
+	// a failed probe opens the circuit again
+	cb.record(now, false)
+	if ok, _ := cb.allow(now.Add(time.Second)); ok {
+		t.Fatal("expected open circuit after failed probe")
+	}
+
+	// a successful probe closes it
+	now = now.Add(11 * time.Second)
+	if ok, _ := cb.allow(now); !ok {
+		t.Fatal("expected probe to be let through")
+	}
+	cb.record(now, true)
+	if ok, _ := cb.allow(now); !ok {
+		t.Fatal("expected closed circuit after successful probe")
+	}
+
+	if cbs.get(trigger) != cb {
+		t.Error("expected the same breaker for the trigger")
+	}
+	cbs.prune(nil)
+	if cbs.get(trigger) == cb {
+		t.Error("expected breaker of removed trigger to be pruned")
+	}
+}
+
+func TestRetryingTriggerRoundTripper(t *testing.T) {
+	var attempts int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := ioutil.ReadAll(r.Body)
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	rt := &retryingTriggerRoundTripper{
+		logger: zap.NewNop(),
+		base:   http.DefaultTransport,
+		policy: &fv1.HTTPTriggerRetryPolicy{MaxRetries: 3, Backoff: 1},
+	}
+
+	req := httptest.NewRequest(http.MethodPut, ts.URL, strings.NewReader("payload"))
+	req.RequestURI = ""
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "payload" || attempts != 3 {
+		t.Errorf("got status %v, body %q after %v attempts", resp.StatusCode, body, attempts)
+	}
+
+	// requests that aren't idempotent are sent once
+	attempts = 0
+	req = httptest.NewRequest(http.MethodPost, ts.URL, strings.NewReader("payload"))
+	req.RequestURI = ""
+	resp, err = rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || attempts != 1 {
+		t.Errorf("got status %v after %v attempts", resp.StatusCode, attempts)
+	}
+
+	// unless they carry an idempotency key
+	attempts = 0
+	req = httptest.NewRequest(http.MethodPost, ts.URL, strings.NewReader("payload"))
+	req.RequestURI = ""
+	req.Header.Set(HEADERS_IDEMPOTENCY_KEY, "abc")
+	resp, err = rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || attempts != 3 {
+		t.Errorf("got status %v after %v attempts", resp.StatusCode, attempts)
+	}
+}
+
+func TestRetryingTriggerRoundTripperLongPoll(t *testing.T) {
+	rt := &retryingTriggerRoundTripper{
+		logger:           zap.NewNop(),
+		policy:           &fv1.HTTPTriggerRetryPolicy{MaxRetries: 3},
+		noRetryOnTimeout: true,
+	}
+	if rt.shouldRetry(nil, context.DeadlineExceeded) {
+		t.Error("expected timed out poll not to be retried")
+	}
+	if rt.shouldRetry(&http.Response{StatusCode: http.StatusGatewayTimeout}, nil) {
+		t.Error("expected poll timed out by the function not to be retried")
+	}
+	if !rt.shouldRetry(&http.Response{StatusCode: http.StatusServiceUnavailable}, nil) {
+		t.Error("expected unavailable function to be retried")
+	}
+	rt.noRetryOnTimeout = false
+	if !rt.shouldRetry(nil, context.DeadlineExceeded) {
+		t.Error("expected timed out request to be retried")
+	}
+}
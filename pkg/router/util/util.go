@@ -17,26 +17,31 @@ limitations under the License.
 package util
 
 import (
+	"strings"
+
 	"k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 )
 
-func GetIngressSpec(namespace string, trigger *fv1.HTTPTrigger) *v1beta1.Ingress {
-	// TODO: remove backward compatibility
-	host, path := trigger.Spec.Host, trigger.Spec.RelativeURL
-	if len(trigger.Spec.IngressConfig.Host) > 0 && len(trigger.Spec.IngressConfig.Path) > 0 {
-		host, path = trigger.Spec.IngressConfig.Host, trigger.Spec.IngressConfig.Path
-	}
+// HTTPRouteGVR is the resource of Gateway API HTTPRoutes.
+var HTTPRouteGVR = schema.GroupVersionResource{
+	Group:    "gateway.networking.k8s.io",
+	Version:  "v1beta1",
+	Resource: "httproutes",
+}
 
-	// In Ingress, to accept requests from all host, the host field will
-	// be an empty string instead of "*" shown in kubectl. So replace it
-	// with empty string
-	if host == "*" {
-		host = "" // wildcard Ingress host
-	}
+// UsesIngress reports whether router creates an Ingress for the trigger.
+func UsesIngress(trigger *fv1.HTTPTrigger) bool {
+	return trigger.Spec.CreateIngress && trigger.Spec.IngressConfig.Type != fv1.IngressTypeHTTPRoute
+}
+
+func GetIngressSpec(namespace string, trigger *fv1.HTTPTrigger) *v1beta1.Ingress {
+	host, path := getIngressHostPath(trigger)
 
 	var ingTLS []v1beta1.IngressTLS
 	if len(trigger.Spec.IngressConfig.TLS) > 0 {
@@ -88,6 +93,99 @@ func GetIngressSpec(namespace string, trigger *fv1.HTTPTrigger) *v1beta1.Ingress
 	return ing
 }
 
+// GetHTTPRouteSpec returns the Gateway API HTTPRoute routing the requests
+// to the trigger from its Gateway to router.
+func GetHTTPRouteSpec(namespace string, trigger *fv1.HTTPTrigger) *unstructured.Unstructured {
+	host, path := getIngressHostPath(trigger)
+
+	parentRef := map[string]interface{}{
+		"group": HTTPRouteGVR.Group,
+		"kind":  "Gateway",
+		"name":  trigger.Spec.IngressConfig.Gateway,
+	}
+	if i := strings.Index(trigger.Spec.IngressConfig.Gateway, "/"); i >= 0 {
+		parentRef["namespace"] = trigger.Spec.IngressConfig.Gateway[:i]
+		parentRef["name"] = trigger.Spec.IngressConfig.Gateway[i+1:]
+	}
+
+	spec := map[string]interface{}{
+		"parentRefs": []interface{}{parentRef},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"matches": []interface{}{
+					map[string]interface{}{
+						"path": map[string]interface{}{
+							"type":  "PathPrefix",
+							"value": routePathPrefix(path),
+						},
+					},
+				},
+				"backendRefs": []interface{}{
+					map[string]interface{}{
+						"group":  "",
+						"kind":   "Service",
+						"name":   "router",
+						"port":   int64(80),
+						"weight": int64(1),
+					},
+				},
+			},
+		},
+	}
+	if len(host) > 0 {
+		spec["hostnames"] = []interface{}{host}
+	}
+
+	route := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": HTTPRouteGVR.GroupVersion().String(),
+			"kind":       "HTTPRoute",
+			"spec":       spec,
+		},
+	}
+	// Like the Ingress, the HTTPRoute lives in the namespace of router,
+	// as routes may only refer to services of other namespaces if those
+	// allow it with a ReferenceGrant.
+	route.SetName(trigger.ObjectMeta.Name)
+	route.SetNamespace(namespace)
+	route.SetLabels(GetDeployLabels(trigger))
+	route.SetAnnotations(trigger.Spec.IngressConfig.Annotations)
+	return route
+}
+
+// getIngressHostPath returns the host and path requests to the trigger
+// are routed from, an empty host matching all hosts.
+func getIngressHostPath(trigger *fv1.HTTPTrigger) (string, string) {
+	// TODO: remove backward compatibility
+	host, path := trigger.Spec.Host, trigger.Spec.RelativeURL
+	if len(trigger.Spec.IngressConfig.Host) > 0 && len(trigger.Spec.IngressConfig.Path) > 0 {
+		host, path = trigger.Spec.IngressConfig.Host, trigger.Spec.IngressConfig.Path
+	}
+
+	// In Ingress, to accept requests from all host, the host field will
+	// be an empty string instead of "*" shown in kubectl. So replace it
+	// with empty string
+	if host == "*" {
+		host = "" // wildcard Ingress host
+	}
+	return host, path
+}
+
+// routePathPrefix returns the path prefix of a trigger URL for an HTTPRoute
+// path match, which doesn't support the path variables of trigger URLs.
+func routePathPrefix(path string) string {
+	if i := strings.Index(path, "{"); i >= 0 {
+		path = path[:i]
+	}
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	if len(path) == 0 {
+		path = "/"
+	}
+	return path
+}
+
 func GetDeployLabels(trigger *fv1.HTTPTrigger) map[string]string {
 	// TODO: support function weight
 	return map[string]string{
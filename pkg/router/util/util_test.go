@@ -567,3 +567,59 @@ func TestGetDeployLabels(t *testing.T) {
 		})
 	}
 }
+
+func TestGetHTTPRouteSpec(t *testing.T) {
+	trigger := &fv1.HTTPTrigger{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "bar",
+		},
+		Spec: fv1.HTTPTriggerSpec{
+			RelativeURL: "/foo/{id}",
+			FunctionReference: fv1.FunctionReference{
+				Name: "foofunc",
+			},
+			IngressConfig: fv1.IngressConfig{
+				Host:    "*.example.com",
+				Path:    "/foo/{id}",
+				Type:    fv1.IngressTypeHTTPRoute,
+				Gateway: "gateways/public",
+			},
+		},
+	}
+
+	route := GetHTTPRouteSpec("fission", trigger)
+	if route.GetName() != "foo" || route.GetNamespace() != "fission" || route.GetKind() != "HTTPRoute" {
+		t.Fatalf("unexpected route metadata: %v", route.Object)
+	}
+	if !reflect.DeepEqual(route.GetLabels(), GetDeployLabels(trigger)) {
+		t.Errorf("unexpected labels %v", route.GetLabels())
+	}
+
+	spec := route.Object["spec"].(map[string]interface{})
+	if !reflect.DeepEqual(spec["hostnames"], []interface{}{"*.example.com"}) {
+		t.Errorf("unexpected hostnames %v", spec["hostnames"])
+	}
+	parentRef := spec["parentRefs"].([]interface{})[0].(map[string]interface{})
+	if parentRef["namespace"] != "gateways" || parentRef["name"] != "public" {
+		t.Errorf("unexpected parent ref %v", parentRef)
+	}
+	rule := spec["rules"].([]interface{})[0].(map[string]interface{})
+	match := rule["matches"].([]interface{})[0].(map[string]interface{})
+	if path := match["path"].(map[string]interface{}); path["value"] != "/foo" {
+		t.Errorf("unexpected path match %v", path)
+	}
+
+	// an HTTPRoute for all hosts has no hostnames
+	trigger.Spec.IngressConfig.Host = "*"
+	trigger.Spec.IngressConfig.Gateway = "public"
+	route = GetHTTPRouteSpec("fission", trigger)
+	spec = route.Object["spec"].(map[string]interface{})
+	if _, ok := spec["hostnames"]; ok {
+		t.Errorf("unexpected hostnames %v", spec["hostnames"])
+	}
+	parentRef = spec["parentRefs"].([]interface{})[0].(map[string]interface{})
+	if _, ok := parentRef["namespace"]; ok || parentRef["name"] != "public" {
+		t.Errorf("unexpected parent ref %v", parentRef)
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstore
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+type (
+	// awsProvider reads secrets from AWS Secrets Manager, with the
+	// default credentials of the SDK. With IAM roles for service
+	// accounts, these are short-lived credentials of the role bound to
+	// the service account of the pod.
+	awsProvider struct{}
+)
+
+func (p *awsProvider) Resolve(ctx context.Context, ref *fv1.ExternalSecretReference) (map[string][]byte, error) {
+	config := aws.NewConfig()
+	// secrets referenced by ARN are read from their region, others from
+	// the region of the environment
+	if a, err := arn.Parse(ref.Path); err == nil {
+		config = config.WithRegion(a.Region)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *config,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := secretsmanager.New(sess).GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(strings.TrimSpace(ref.Path)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.SecretString != nil {
+		return secretData([]byte(*out.SecretString)), nil
+	}
+	return map[string][]byte{ValueKey: out.SecretBinary}, nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstore
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const gcpSecretManagerURL = "https://secretmanager.googleapis.com/v1"
+
+type (
+	// gcpProvider reads secret versions from GCP Secret Manager, with
+	// short-lived access tokens the metadata server of GKE nodes hands
+	// out for the service account bound to the kubernetes one of the pod
+	// with workload identity.
+	gcpProvider struct {
+		apiURL string
+		client *http.Client
+	}
+)
+
+func (p *gcpProvider) Resolve(ctx context.Context, ref *fv1.ExternalSecretReference) (map[string][]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, p.apiURL+"/"+strings.TrimPrefix(ref.Path, "/")+":access", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := responseError(resp); err != nil {
+		return nil, err
+	}
+	var version struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&version)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding secret version")
+	}
+	value, err := base64.StdEncoding.DecodeString(version.Payload.Data)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding secret payload")
+	}
+	return secretData(value), nil
+}
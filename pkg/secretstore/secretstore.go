@@ -0,0 +1,177 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package secretstore resolves the external secrets of functions from the
+// secret stores keeping them. Fetcher authenticates to the stores with
+// short-lived credentials of the pod it runs in, e.g. the token of its
+// service account for Vault, so no long-lived credentials of the stores
+// need to be kept in the cluster.
+package secretstore
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/gcpauth"
+)
+
+const (
+	// ValueKey is the key of secrets that aren't JSON objects.
+	ValueKey = "value"
+
+	// serviceAccountTokenPath is where kubernetes mounts the token of the
+	// service account of the pod.
+	serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+	requestTimeout = 30 * time.Second
+)
+
+// validKey matches the keys of kubernetes secrets, which become file
+// names.
+var validKey = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+type (
+	// Provider resolves the secrets of an external secret store.
+	Provider interface {
+		// Resolve returns the data of the secret ref refers to, by key.
+		Resolve(ctx context.Context, ref *fv1.ExternalSecretReference) (map[string][]byte, error)
+	}
+
+	// Config configures the providers of the secret stores.
+	Config struct {
+		// VaultAddr is the address of Vault, e.g.
+		// "https://vault.vault:8200". Vault secrets can't be resolved
+		// without it.
+		VaultAddr string
+		// VaultAuthPath is the mount path of the kubernetes auth method
+		// of Vault, "kubernetes" if empty.
+		VaultAuthPath string
+		// VaultRole is the role to log in as for secrets without one.
+		VaultRole string
+	}
+
+	// Providers resolves external secrets with the provider of their
+	// store.
+	Providers struct {
+		providers map[fv1.SecretProvider]Provider
+	}
+)
+
+// MakeProviders returns the providers of the secret stores configured by
+// cfg.
+func MakeProviders(cfg Config) *Providers {
+	client := &http.Client{Timeout: requestTimeout}
+	providers := map[fv1.SecretProvider]Provider{
+		fv1.SecretProviderAWS: &awsProvider{},
+		fv1.SecretProviderGCP: &gcpProvider{
+			apiURL: gcpSecretManagerURL,
+			client: &http.Client{
+				Timeout:   requestTimeout,
+				Transport: gcpauth.MakeTokenRoundTripper(http.DefaultTransport, gcpauth.Credentials{}),
+			},
+		},
+	}
+	if len(cfg.VaultAddr) > 0 {
+		authPath := cfg.VaultAuthPath
+		if len(authPath) == 0 {
+			authPath = "kubernetes"
+		}
+		providers[fv1.SecretProviderVault] = &vaultProvider{
+			addr:      strings.TrimSuffix(cfg.VaultAddr, "/"),
+			authPath:  strings.Trim(authPath, "/"),
+			role:      cfg.VaultRole,
+			tokenPath: serviceAccountTokenPath,
+			client:    client,
+		}
+	}
+	return &Providers{providers: providers}
+}
+
+// Resolve returns the data of an external secret, from the provider of
+// its store.
+func (p *Providers) Resolve(ctx context.Context, ref *fv1.ExternalSecretReference) (map[string][]byte, error) {
+	provider, ok := p.providers[ref.Provider]
+	if !ok {
+		return nil, errors.Errorf("secret provider %q is not configured", ref.Provider)
+	}
+	data, err := provider.Resolve(ctx, ref)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error resolving %v secret %q", ref.Provider, ref.Path)
+	}
+	for key := range data {
+		if !validKey.MatchString(key) || key == "." || key == ".." {
+			return nil, errors.Errorf("%v secret %q has invalid key %q", ref.Provider, ref.Path, key)
+		}
+	}
+	return data, nil
+}
+
+// secretData returns the data of a secret kept as a whole, a key per
+// field of JSON objects or a single ValueKey otherwise.
+func secretData(value []byte) map[string][]byte {
+	var fields map[string]interface{}
+	if json.Unmarshal(value, &fields) != nil || fields == nil {
+		return map[string][]byte{ValueKey: value}
+	}
+	return fieldData(fields)
+}
+
+// fieldData returns the data of the fields of a secret, with values other
+// than strings encoded as JSON.
+func fieldData(fields map[string]interface{}) map[string][]byte {
+	data := make(map[string][]byte, len(fields))
+	for key, value := range fields {
+		if s, ok := value.(string); ok {
+			data[key] = []byte(s)
+			continue
+		}
+		encoded, _ := json.Marshal(value)
+		data[key] = encoded
+	}
+	return data
+}
+
+// responseError returns the error of a response with a status other than
+// 2xx, if any.
+func responseError(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	var body struct {
+		// Errors are the errors of Vault.
+		Errors []string `json:"errors"`
+		// Error is the error of Google APIs.
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	message := strings.Join(body.Errors, ", ")
+	if len(message) == 0 {
+		message = body.Error.Message
+	}
+	if len(message) == 0 {
+		return errors.Errorf("%v responded with status %v", resp.Request.URL.Host, resp.Status)
+	}
+	return errors.Errorf("%v responded with status %v: %v", resp.Request.URL.Host, resp.Status, message)
+}
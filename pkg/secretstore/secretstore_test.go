@@ -0,0 +1,164 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstore
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/gcpauth"
+)
+
+func TestSecretData(t *testing.T) {
+	for value, expected := range map[string]map[string][]byte{
+		`{"user":"admin","port":5432}`: {"user": []byte("admin"), "port": []byte("5432")},
+		`hunter2`:                      {ValueKey: []byte("hunter2")},
+		`["a","b"]`:                    {ValueKey: []byte(`["a","b"]`)},
+		`null`:                         {ValueKey: []byte(`null`)},
+	} {
+		if data := secretData([]byte(value)); !reflect.DeepEqual(data, expected) {
+			t.Errorf("expected %q for %v, got %q", expected, value, data)
+		}
+	}
+}
+
+func TestVaultResolve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secretstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tokenPath := filepath.Join(dir, "token")
+	err = ioutil.WriteFile(tokenPath, []byte("sa-token\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revoked := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/k8s/login":
+			var login map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&login)
+			if login["jwt"] != "sa-token" || login["role"] != "fn-role" {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"errors":["permission denied"]}`))
+				return
+			}
+			w.Write([]byte(`{"auth":{"client_token":"client-token"}}`))
+		case "/v1/secret/data/db":
+			if r.Header.Get("X-Vault-Token") != "client-token" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`{"data":{"data":{"user":"admin","password":"hunter2"},"metadata":{"version":3}}}`))
+		case "/v1/auth/token/revoke-self":
+			revoked = r.Header.Get("X-Vault-Token") == "client-token"
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	providers := MakeProviders(Config{VaultAddr: server.URL + "/", VaultAuthPath: "/k8s/", VaultRole: "default-role"})
+	providers.providers[fv1.SecretProviderVault].(*vaultProvider).tokenPath = tokenPath
+
+	ref := &fv1.ExternalSecretReference{Name: "db", Provider: fv1.SecretProviderVault, Path: "secret/data/db", Role: "fn-role"}
+	data, err := providers.Resolve(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("error resolving Vault secret: %v", err)
+	}
+	expected := map[string][]byte{"user": []byte("admin"), "password": []byte("hunter2")}
+	if !reflect.DeepEqual(data, expected) {
+		t.Fatalf("expected %q, got %q", expected, data)
+	}
+	if !revoked {
+		t.Fatal("expected Vault token to be revoked")
+	}
+
+	// the default role is denied
+	ref.Role = ""
+	_, err = providers.Resolve(context.Background(), ref)
+	if err == nil {
+		t.Fatal("expected error logging in as the default role")
+	}
+}
+
+func TestGCPResolve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/metadata/instance/service-accounts/default/token":
+			if r.Header.Get("Metadata-Flavor") != "Google" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`{"access_token":"access-token","expires_in":3599,"token_type":"Bearer"}`))
+		case "/v1/projects/p/secrets/api-key/versions/latest:access":
+			if r.Header.Get("Authorization") != "Bearer access-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			// "s3cr3t"
+			w.Write([]byte(`{"name":"projects/p/secrets/api-key/versions/2","payload":{"data":"czNjcjN0"}}`))
+		case "/v1/projects/p/secrets/bad-key/versions/latest:access":
+			// {"../x":"y"}
+			w.Write([]byte(`{"payload":{"data":"eyIuLi94IjoieSJ9"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"message":"Secret not found"}}`))
+		}
+	}))
+	defer server.Close()
+
+	providers := MakeProviders(Config{})
+	gcp := providers.providers[fv1.SecretProviderGCP].(*gcpProvider)
+	gcp.client.Transport = gcpauth.MakeTokenRoundTripper(http.DefaultTransport, gcpauth.Credentials{MetadataURL: server.URL + "/metadata"})
+	gcp.apiURL = server.URL + "/v1"
+
+	data, err := providers.Resolve(context.Background(), &fv1.ExternalSecretReference{
+		Name: "api-key", Provider: fv1.SecretProviderGCP, Path: "projects/p/secrets/api-key/versions/latest"})
+	if err != nil {
+		t.Fatalf("error resolving GCP secret: %v", err)
+	}
+	if string(data[ValueKey]) != "s3cr3t" || len(data) != 1 {
+		t.Fatalf("unexpected data %q", data)
+	}
+
+	_, err = providers.Resolve(context.Background(), &fv1.ExternalSecretReference{
+		Name: "bad-key", Provider: fv1.SecretProviderGCP, Path: "projects/p/secrets/bad-key/versions/latest"})
+	if err == nil {
+		t.Fatal("expected error resolving secret with a key escaping its directory")
+	}
+	_, err = providers.Resolve(context.Background(), &fv1.ExternalSecretReference{
+		Name: "missing", Provider: fv1.SecretProviderGCP, Path: "projects/p/secrets/missing/versions/latest"})
+	if err == nil {
+		t.Fatal("expected error resolving missing secret")
+	}
+	_, err = providers.Resolve(context.Background(), &fv1.ExternalSecretReference{
+		Name: "db", Provider: fv1.SecretProviderVault, Path: "secret/data/db"})
+	if err == nil {
+		t.Fatal("expected error resolving Vault secret without Vault address")
+	}
+}
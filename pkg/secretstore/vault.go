@@ -0,0 +1,129 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+type (
+	// vaultProvider reads secrets from Vault. It logs in with the
+	// kubernetes auth method for each secret, and revokes the token once
+	// the secret is read, so tokens live no longer than a specialization.
+	vaultProvider struct {
+		addr     string
+		authPath string
+		role     string
+		// tokenPath is the file of the service account token to log in
+		// with.
+		tokenPath string
+		client    *http.Client
+	}
+
+	vaultResponse struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+		Data map[string]interface{} `json:"data"`
+	}
+)
+
+func (p *vaultProvider) Resolve(ctx context.Context, ref *fv1.ExternalSecretReference) (map[string][]byte, error) {
+	role := ref.Role
+	if len(role) == 0 {
+		role = p.role
+	}
+	if len(role) == 0 {
+		return nil, errors.New("no Vault role to log in as")
+	}
+	jwt, err := ioutil.ReadFile(p.tokenPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading service account token")
+	}
+
+	var login vaultResponse
+	err = p.do(ctx, http.MethodPost, "auth/"+p.authPath+"/login", "", map[string]string{
+		"role": role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	}, &login)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error logging in to Vault as role %q", role)
+	}
+	token := login.Auth.ClientToken
+	defer func() {
+		// the token expires with its TTL all the same if this fails
+		_ = p.do(context.Background(), http.MethodPost, "auth/token/revoke-self", token, nil, nil)
+	}()
+
+	var secret vaultResponse
+	err = p.do(ctx, http.MethodGet, strings.TrimPrefix(ref.Path, "/"), token, nil, &secret)
+	if err != nil {
+		return nil, err
+	}
+	fields := secret.Data
+	// the KV engine v2 nests the fields of secrets along with their
+	// metadata
+	if nested, ok := fields["data"].(map[string]interface{}); ok {
+		if _, ok := fields["metadata"]; ok {
+			fields = nested
+		}
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("secret has no data")
+	}
+	return fieldData(fields), nil
+}
+
+// do sends a request to the Vault API at path, with the token if set, and
+// decodes the response into out if not nil.
+func (p *vaultProvider) do(ctx context.Context, method string, path string, token string, in interface{}, out interface{}) error {
+	var body bytes.Buffer
+	if in != nil {
+		err := json.NewEncoder(&body).Encode(in)
+		if err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, p.addr+"/v1/"+path, &body)
+	if err != nil {
+		return err
+	}
+	if len(token) > 0 {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	resp, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := responseError(resp); err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
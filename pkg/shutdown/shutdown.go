@@ -0,0 +1,224 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package shutdown coordinates the graceful shutdown of fission components.
+// Once a component is asked to terminate, it stops accepting work, drains
+// the work in flight within a grace period, flushes its metrics and traces
+// and exits.
+package shutdown
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultGracePeriod is how long in-flight work may take to drain. It
+	// leaves room for flushing within the default termination grace
+	// period of 30 seconds of pods.
+	DefaultGracePeriod = 25 * time.Second
+
+	// flushTimeout is how long flushing may take once the work is drained
+	// or the grace period is over.
+	flushTimeout = 3 * time.Second
+)
+
+type (
+	// Hook stops a part of a component. It returns once the part stopped,
+	// or the context is done.
+	Hook func(ctx context.Context) error
+
+	// Coordinator runs the hooks registered by the parts of a component
+	// when the component is asked to terminate.
+	Coordinator struct {
+		logger      *zap.Logger
+		gracePeriod time.Duration
+
+		// ctx is cancelled once the shutdown starts
+		ctx    context.Context
+		cancel context.CancelFunc
+		once   sync.Once
+		done   chan struct{}
+
+		lock    sync.Mutex
+		drains  []namedHook
+		flushes []namedHook
+	}
+
+	namedHook struct {
+		name string
+		hook Hook
+	}
+)
+
+// MakeCoordinator returns a coordinator draining work within the grace
+// period set in SHUTDOWN_GRACE_PERIOD, or DefaultGracePeriod.
+func MakeCoordinator(logger *zap.Logger) *Coordinator {
+	logger = logger.Named("shutdown")
+
+	gracePeriod := DefaultGracePeriod
+	if value := os.Getenv("SHUTDOWN_GRACE_PERIOD"); len(value) > 0 {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			logger.Error("failed to parse shutdown grace period from 'SHUTDOWN_GRACE_PERIOD' - set to the default value",
+				zap.String("value", value),
+				zap.Duration("default", gracePeriod))
+		} else {
+			gracePeriod = d
+		}
+	}
+	return makeCoordinator(logger, gracePeriod)
+}
+
+func makeCoordinator(logger *zap.Logger, gracePeriod time.Duration) *Coordinator {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Coordinator{
+		logger:      logger,
+		gracePeriod: gracePeriod,
+		ctx:         ctx,
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+}
+
+// Context returns a context cancelled once the shutdown starts, for the
+// loops taking new work to stop.
+func (c *Coordinator) Context() context.Context {
+	return c.ctx
+}
+
+// ShuttingDown returns true once the shutdown started.
+func (c *Coordinator) ShuttingDown() bool {
+	return c.ctx.Err() != nil
+}
+
+// Done returns a channel closed once the shutdown is over.
+func (c *Coordinator) Done() <-chan struct{} {
+	return c.done
+}
+
+// OnShutdown registers a hook that stops taking new work and waits for the
+// work in flight. Hooks run concurrently until the grace period is over.
+func (c *Coordinator) OnShutdown(name string, hook Hook) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.drains = append(c.drains, namedHook{name: name, hook: hook})
+}
+
+// OnFlush registers a hook that flushes metrics, traces or other records,
+// run once the work in flight is drained.
+func (c *Coordinator) OnFlush(name string, hook Hook) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.flushes = append(c.flushes, namedHook{name: name, hook: hook})
+}
+
+// Serve serves HTTP with the server until the shutdown, which stops the
+// server from accepting connections and waits for the requests in flight.
+// It returns nil once the server is shut down.
+func (c *Coordinator) Serve(name string, server *http.Server, listen func() error) error {
+	c.OnShutdown(name, server.Shutdown)
+	err := listen()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// ServeMetrics is like Serve, but keeps serving until the flush, so that
+// metrics can be scraped while the work in flight drains.
+func (c *Coordinator) ServeMetrics(server *http.Server) error {
+	c.OnFlush("metrics", server.Shutdown)
+	err := server.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// WaitForSignal shuts down once the process receives SIGTERM or SIGINT.
+func (c *Coordinator) WaitForSignal() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+	sig := <-sigCh
+	signal.Stop(sigCh)
+
+	c.logger.Info("received signal, shutting down",
+		zap.String("signal", sig.String()),
+		zap.Duration("grace_period", c.gracePeriod))
+	c.Shutdown()
+}
+
+// Shutdown cancels the context of the coordinator, runs the shutdown hooks
+// until they return or the grace period is over, then runs the flush hooks.
+// It only runs once, later calls wait for the first one.
+func (c *Coordinator) Shutdown() {
+	c.once.Do(func() {
+		c.cancel()
+
+		c.lock.Lock()
+		drains, flushes := c.drains, c.flushes
+		c.lock.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.gracePeriod)
+		c.run(ctx, drains)
+		cancel()
+
+		ctx, cancel = context.WithTimeout(context.Background(), flushTimeout)
+		c.run(ctx, flushes)
+		cancel()
+
+		c.logger.Info("shutdown complete")
+		close(c.done)
+	})
+	<-c.done
+}
+
+// run runs the hooks concurrently, and returns once they all returned or
+// the context is done.
+func (c *Coordinator) run(ctx context.Context, hooks []namedHook) {
+	var wg sync.WaitGroup
+	for _, h := range hooks {
+		wg.Add(1)
+		go func(h namedHook) {
+			defer wg.Done()
+			start := time.Now()
+			if err := h.hook(ctx); err != nil {
+				c.logger.Error("error shutting down", zap.String("part", h.name), zap.Error(err))
+				return
+			}
+			c.logger.Info("shut down", zap.String("part", h.name), zap.Duration("took", time.Since(start)))
+		}(h)
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		c.logger.Warn("shutdown hooks did not return in time", zap.Error(ctx.Err()))
+	}
+}
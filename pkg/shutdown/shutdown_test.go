@@ -0,0 +1,75 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shutdown
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestShutdown(t *testing.T) {
+	c := makeCoordinator(zap.NewNop(), 100*time.Millisecond)
+
+	var lock sync.Mutex
+	var order []string
+	record := func(name string) {
+		lock.Lock()
+		defer lock.Unlock()
+		order = append(order, name)
+	}
+
+	c.OnFlush("traces", func(ctx context.Context) error {
+		record("traces")
+		return nil
+	})
+	c.OnShutdown("server", func(ctx context.Context) error {
+		if !c.ShuttingDown() {
+			t.Error("expected context to be cancelled before the hooks run")
+		}
+		record("server")
+		return nil
+	})
+	c.OnShutdown("builds", func(ctx context.Context) error {
+		// in-flight work beyond the grace period is abandoned
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	c.Shutdown()
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("expected shutdown to end after the grace period, took %v", took)
+	}
+	// later calls return right away
+	c.Shutdown()
+
+	select {
+	case <-c.Done():
+	default:
+		t.Fatal("expected shutdown to be done")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(order) != 2 || order[0] != "server" || order[1] != "traces" {
+		t.Errorf("expected flush hooks to run after the shutdown hooks, got %v", order)
+	}
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	ArrivalPoisson = "poisson"
+	ArrivalUniform = "uniform"
+
+	defaultPoolsize       = 3
+	defaultPodStartup     = 10 * time.Second
+	defaultSpecialization = time.Second
+)
+
+type (
+	// Duration is a time.Duration given as a string like "200ms" or "5m".
+	Duration struct {
+		time.Duration
+	}
+
+	// Profile describes the environments and functions of a simulation
+	// and the traffic they get.
+	Profile struct {
+		// Duration of the traffic. The simulation goes on until the requests
+		// sent in it are served.
+		Duration Duration `json:"duration"`
+
+		// Seed of the random arrivals of requests, the same seed replays the
+		// same traffic. Defaults to 1.
+		Seed int64 `json:"seed,omitempty"`
+
+		Environments []EnvironmentProfile `json:"environments"`
+		Functions    []FunctionProfile    `json:"functions"`
+	}
+
+	// EnvironmentProfile is the pool and pod timing of an environment.
+	EnvironmentProfile struct {
+		Name string `json:"name"`
+
+		// Poolsize is the number of generic pods kept warm for poolmgr
+		// functions. Defaults to 3.
+		Poolsize *int `json:"poolsize,omitempty"`
+
+		// PodStartup is the time a new pod takes to become ready, from
+		// scheduling and image pulls to the runtime listening. Defaults to 10s.
+		PodStartup Duration `json:"podStartup,omitempty"`
+
+		// Specialization is the time a ready pod takes to load a function.
+		// Defaults to 1s.
+		Specialization Duration `json:"specialization,omitempty"`
+	}
+
+	// FunctionProfile is the scale configuration of a function and its
+	// traffic.
+	FunctionProfile struct {
+		Name         string           `json:"name"`
+		Environment  string           `json:"environment"`
+		ExecutorType fv1.ExecutorType `json:"executorType,omitempty"`
+
+		// ExecutionTime is how long the function takes to serve a request.
+		ExecutionTime Duration `json:"executionTime"`
+
+		// IdleTimeout after which idle pods are reclaimed. Defaults to 120s.
+		IdleTimeout *Duration `json:"idleTimeout,omitempty"`
+
+		// Concurrency is the maximum number of pods of a poolmgr function.
+		Concurrency int `json:"concurrency,omitempty"`
+
+		// RequestsPerPod is the number of requests a pod serves at once.
+		RequestsPerPod int `json:"requestsPerPod,omitempty"`
+
+		// MinScale and MaxScale bound the pods of a newdeploy function.
+		MinScale int `json:"minScale,omitempty"`
+		MaxScale int `json:"maxScale,omitempty"`
+
+		Traffic []TrafficPhase `json:"traffic"`
+	}
+
+	// TrafficPhase is a period of traffic to a function. Phases may
+	// overlap, their rates add up.
+	TrafficPhase struct {
+		Start Duration `json:"start"`
+		End   Duration `json:"end"`
+
+		// Rate in requests per second at the start of the phase.
+		Rate float64 `json:"rate"`
+
+		// EndRate, if set, ramps the rate linearly up or down to it by the
+		// end of the phase.
+		EndRate *float64 `json:"endRate,omitempty"`
+
+		// Arrival of requests, "poisson" for random arrivals at the rate
+		// or "uniform" for evenly spaced ones. Defaults to "poisson".
+		Arrival string `json:"arrival,omitempty"`
+	}
+)
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return errors.Errorf("duration %s must be a string like \"5m\"", b)
+	}
+	d.Duration, err = time.ParseDuration(s)
+	return err
+}
+
+// LoadProfile reads a profile from a YAML or JSON file, and fills in its
+// defaults.
+func LoadProfile(path string) (*Profile, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading profile")
+	}
+	profile := &Profile{}
+	err = yaml.Unmarshal(b, profile)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing profile")
+	}
+	profile.SetDefaults()
+	err = profile.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid profile")
+	}
+	return profile, nil
+}
+
+// SetDefaults fills the unset fields of the profile with the defaults of
+// functions and environments.
+func (p *Profile) SetDefaults() {
+	if p.Seed == 0 {
+		p.Seed = 1
+	}
+	for i := range p.Environments {
+		env := &p.Environments[i]
+		if env.Poolsize == nil {
+			poolsize := defaultPoolsize
+			env.Poolsize = &poolsize
+		}
+		if env.PodStartup.Duration == 0 {
+			env.PodStartup.Duration = defaultPodStartup
+		}
+		if env.Specialization.Duration == 0 {
+			env.Specialization.Duration = defaultSpecialization
+		}
+	}
+	for i := range p.Functions {
+		fn := &p.Functions[i]
+		if len(fn.ExecutorType) == 0 {
+			fn.ExecutorType = fv1.ExecutorTypePoolmgr
+		}
+		if fn.IdleTimeout == nil {
+			fn.IdleTimeout = &Duration{fv1.DefaultIdleTimeout * time.Second}
+		}
+		if fn.Concurrency == 0 {
+			fn.Concurrency = fv1.DefaultConcurrency
+		}
+		if fn.RequestsPerPod == 0 {
+			fn.RequestsPerPod = fv1.DefaultRequestsPerPod
+		}
+		if fn.ExecutorType == fv1.ExecutorTypeNewdeploy && fn.MaxScale == 0 {
+			fn.MaxScale = fv1.DefaultMaxScale
+			if fn.MinScale > fn.MaxScale {
+				fn.MaxScale = fn.MinScale
+			}
+		}
+		for j := range fn.Traffic {
+			if len(fn.Traffic[j].Arrival) == 0 {
+				fn.Traffic[j].Arrival = ArrivalPoisson
+			}
+		}
+	}
+}
+
+// Validate checks a profile with its defaults filled in.
+func (p *Profile) Validate() error {
+	result := &multierror.Error{}
+
+	if p.Duration.Duration <= 0 {
+		result = multierror.Append(result, errors.New("duration must be positive"))
+	}
+	if len(p.Functions) == 0 {
+		result = multierror.Append(result, errors.New("at least one function is required"))
+	}
+
+	envs := make(map[string]bool)
+	for _, env := range p.Environments {
+		if envs[env.Name] {
+			result = multierror.Append(result, errors.Errorf("environment %q is defined more than once", env.Name))
+		}
+		envs[env.Name] = true
+		if *env.Poolsize < 0 || env.PodStartup.Duration < 0 || env.Specialization.Duration < 0 {
+			result = multierror.Append(result, errors.Errorf("environment %q: poolsize and times must not be negative", env.Name))
+		}
+	}
+
+	fns := make(map[string]bool)
+	for _, fn := range p.Functions {
+		if fns[fn.Name] {
+			result = multierror.Append(result, errors.Errorf("function %q is defined more than once", fn.Name))
+		}
+		fns[fn.Name] = true
+		if !envs[fn.Environment] {
+			result = multierror.Append(result, errors.Errorf("function %q: environment %q is not defined", fn.Name, fn.Environment))
+		}
+		switch fn.ExecutorType {
+		case fv1.ExecutorTypePoolmgr, fv1.ExecutorTypeNewdeploy:
+		default:
+			result = multierror.Append(result, errors.Errorf("function %q: executor type %q is not supported", fn.Name, fn.ExecutorType))
+		}
+		if fn.ExecutionTime.Duration <= 0 {
+			result = multierror.Append(result, errors.Errorf("function %q: execution time must be positive", fn.Name))
+		}
+		if fn.IdleTimeout.Duration < 0 || fn.Concurrency < 0 || fn.RequestsPerPod < 0 || fn.MinScale < 0 {
+			result = multierror.Append(result, errors.Errorf("function %q: idle timeout, concurrency, requests per pod and min scale must not be negative", fn.Name))
+		}
+		if fn.MaxScale < fn.MinScale {
+			result = multierror.Append(result, errors.Errorf("function %q: max scale must not be less than min scale", fn.Name))
+		}
+		for i, phase := range fn.Traffic {
+			if phase.Start.Duration < 0 || phase.End.Duration <= phase.Start.Duration {
+				result = multierror.Append(result, errors.Errorf("function %q: traffic phase %v must end after it starts", fn.Name, i))
+			}
+			if phase.Rate < 0 || (phase.EndRate != nil && *phase.EndRate < 0) {
+				result = multierror.Append(result, errors.Errorf("function %q: traffic phase %v must not have a negative rate", fn.Name, i))
+			}
+			if phase.Arrival != ArrivalPoisson && phase.Arrival != ArrivalUniform {
+				result = multierror.Append(result, errors.Errorf("function %q: traffic phase %v has unsupported arrival %q", fn.Name, i, phase.Arrival))
+			}
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+// rate returns the rate of the phase at t, zero outside of it.
+func (phase *TrafficPhase) rate(t time.Duration) float64 {
+	if t < phase.Start.Duration || t >= phase.End.Duration {
+		return 0
+	}
+	if phase.EndRate == nil {
+		return phase.Rate
+	}
+	progress := float64(t-phase.Start.Duration) / float64(phase.End.Duration-phase.Start.Duration)
+	return phase.Rate + (*phase.EndRate-phase.Rate)*progress
+}
@@ -0,0 +1,397 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package simulator replays synthetic traffic against a model of the
+// executor, without any pods, to predict the cold starts, pods and queue
+// waits of a pool and scale configuration.
+//
+// The model follows how executor serves functions: poolmgr functions
+// specialize generic pods taken from the pool of their environment, which
+// is refilled as pods are taken, and newdeploy functions start pods of
+// their own between min and max scale. Requests wait in a queue when no
+// pod has a free slot, and executor starts as many pods as the queue
+// needs. Pods idle for the idle timeout of their function are reclaimed.
+// Scaling up is modeled as immediate, so the predictions of newdeploy
+// functions, which scale on the metrics of HPAs, are a lower bound.
+package simulator
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+type (
+	// Report is the outcome of a simulation.
+	Report struct {
+		Functions []FunctionReport `json:"functions"`
+	}
+
+	// FunctionReport is the outcome of a simulation for a function.
+	FunctionReport struct {
+		Name     string `json:"name"`
+		Requests int    `json:"requests"`
+
+		// ColdStarts is the number of pods started or specialized for
+		// requests, as opposed to the ones of min scale.
+		ColdStarts int `json:"coldStarts"`
+
+		// Queued is the number of requests that waited for a pod.
+		Queued int `json:"queued"`
+
+		// MaxPods and AvgPods count pods, starting ones included, over
+		// the duration of the traffic.
+		MaxPods int     `json:"maxPods"`
+		AvgPods float64 `json:"avgPods"`
+
+		QueueWaitP50 time.Duration `json:"queueWaitP50"`
+		QueueWaitP95 time.Duration `json:"queueWaitP95"`
+		QueueWaitP99 time.Duration `json:"queueWaitP99"`
+		QueueWaitMax time.Duration `json:"queueWaitMax"`
+	}
+
+	eventKind int
+
+	event struct {
+		at    time.Duration
+		seq   int
+		kind  eventKind
+		fn    *functionState
+		pod   *pod
+		phase *phaseState
+	}
+
+	eventQueue []*event
+
+	environmentState struct {
+		profile *EnvironmentProfile
+		// ready is the number of generic pods in the pool, and refills
+		// when the pods replacing the ones taken from it are ready.
+		ready   int
+		refills []time.Duration
+	}
+
+	functionState struct {
+		profile *FunctionProfile
+		env     *environmentState
+		pods    []*pod
+		// starting is the number of pods not ready yet.
+		starting int
+		// queue holds when the waiting requests arrived.
+		queue []time.Duration
+
+		report FunctionReport
+		waits  []time.Duration
+		// podTime is the sum of pod count times duration, and lastUpdate
+		// when it was last added to.
+		podTime    float64
+		lastUpdate time.Duration
+	}
+
+	pod struct {
+		ready     bool
+		removed   bool
+		busy      int
+		idleSince time.Duration
+	}
+
+	phaseState struct {
+		fn    *functionState
+		phase *TrafficPhase
+		// work is the area under the rate curve of the phase the next
+		// request arrives at.
+		work float64
+	}
+
+	simulation struct {
+		profile *Profile
+		rand    *rand.Rand
+		events  eventQueue
+		seq     int
+		now     time.Duration
+	}
+)
+
+const (
+	eventArrival eventKind = iota
+	eventCompletion
+	eventPodReady
+	eventReap
+)
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at != q[j].at {
+		return q[i].at < q[j].at
+	}
+	return q[i].seq < q[j].seq
+}
+func (q eventQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*event)) }
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+// Simulate replays the traffic of a profile, with its defaults filled in,
+// and reports its predicted outcome for every function.
+func Simulate(profile *Profile) (*Report, error) {
+	err := profile.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	sim := &simulation{
+		profile: profile,
+		rand:    rand.New(rand.NewSource(profile.Seed)),
+	}
+
+	envs := make(map[string]*environmentState)
+	for i := range profile.Environments {
+		env := &profile.Environments[i]
+		envs[env.Name] = &environmentState{profile: env, ready: *env.Poolsize}
+	}
+
+	fns := make([]*functionState, len(profile.Functions))
+	for i := range profile.Functions {
+		fnProfile := &profile.Functions[i]
+		fn := &functionState{
+			profile: fnProfile,
+			env:     envs[fnProfile.Environment],
+			report:  FunctionReport{Name: fnProfile.Name},
+		}
+		fns[i] = fn
+
+		if fnProfile.ExecutorType == fv1.ExecutorTypeNewdeploy {
+			for j := 0; j < fnProfile.MinScale; j++ {
+				fn.pods = append(fn.pods, &pod{ready: true})
+			}
+			fn.report.MaxPods = len(fn.pods)
+		}
+		for j := range fnProfile.Traffic {
+			sim.nextArrival(&phaseState{fn: fn, phase: &fnProfile.Traffic[j]})
+		}
+	}
+
+	for sim.events.Len() > 0 {
+		e := heap.Pop(&sim.events).(*event)
+		sim.now = e.at
+		e.fn.updatePodTime(sim.now, profile.Duration.Duration)
+
+		switch e.kind {
+		case eventArrival:
+			e.fn.report.Requests++
+			e.fn.queue = append(e.fn.queue, sim.now)
+			sim.nextArrival(e.phase)
+		case eventCompletion:
+			e.pod.busy--
+			if e.pod.busy == 0 {
+				sim.idle(e.fn, e.pod)
+			}
+		case eventPodReady:
+			e.pod.ready = true
+			e.fn.starting--
+			sim.idle(e.fn, e.pod)
+		case eventReap:
+			sim.reap(e.fn, e.pod)
+		}
+		sim.dispatch(e.fn)
+	}
+
+	report := &Report{}
+	for _, fn := range fns {
+		fn.updatePodTime(profile.Duration.Duration, profile.Duration.Duration)
+		fn.report.AvgPods = fn.podTime / float64(profile.Duration.Duration)
+		sort.Slice(fn.waits, func(i, j int) bool { return fn.waits[i] < fn.waits[j] })
+		fn.report.QueueWaitP50 = percentile(fn.waits, 0.50)
+		fn.report.QueueWaitP95 = percentile(fn.waits, 0.95)
+		fn.report.QueueWaitP99 = percentile(fn.waits, 0.99)
+		fn.report.QueueWaitMax = percentile(fn.waits, 1)
+		report.Functions = append(report.Functions, fn.report)
+	}
+	return report, nil
+}
+
+func (sim *simulation) schedule(e *event) {
+	sim.seq++
+	e.seq = sim.seq
+	heap.Push(&sim.events, e)
+}
+
+// nextArrival schedules the next request of a phase, if it arrives before
+// the end of the phase and of the traffic. Requests arrive when the area
+// under the rate curve grows by one, or by a random amount with a mean of
+// one for Poisson arrivals.
+func (sim *simulation) nextArrival(ps *phaseState) {
+	phase := ps.phase
+	if phase.Arrival == ArrivalPoisson {
+		ps.work += sim.rand.ExpFloat64()
+	} else {
+		ps.work++
+	}
+
+	// solve work = a*s + b*s^2/2 for the time s into the phase, with the
+	// rate a at its start and its slope b
+	a := phase.Rate
+	b := 0.0
+	length := (phase.End.Duration - phase.Start.Duration).Seconds()
+	if phase.EndRate != nil {
+		b = (*phase.EndRate - a) / length
+	}
+	d := a*a + 2*b*ps.work
+	if d < 0 || a+math.Sqrt(d) <= 0 {
+		// the rate drops to zero before
+		return
+	}
+	s := 2 * ps.work / (a + math.Sqrt(d))
+	if s >= length {
+		return
+	}
+
+	at := phase.Start.Duration + time.Duration(s*float64(time.Second))
+	if at >= sim.profile.Duration.Duration {
+		return
+	}
+	sim.schedule(&event{at: at, kind: eventArrival, fn: ps.fn, phase: ps})
+}
+
+// dispatch sends the queued requests of a function to the free slots of
+// its pods, and starts the pods the rest of them need.
+func (sim *simulation) dispatch(fn *functionState) {
+	for len(fn.queue) > 0 {
+		p := fn.freePod()
+		if p == nil {
+			break
+		}
+		wait := sim.now - fn.queue[0]
+		fn.queue = fn.queue[1:]
+		fn.waits = append(fn.waits, wait)
+		if wait > 0 {
+			fn.report.Queued++
+		}
+		p.busy++
+		sim.schedule(&event{at: sim.now + fn.profile.ExecutionTime.Duration, kind: eventCompletion, fn: fn, pod: p})
+	}
+
+	maxPods := fn.profile.Concurrency
+	if fn.profile.ExecutorType == fv1.ExecutorTypeNewdeploy {
+		maxPods = fn.profile.MaxScale
+	}
+	rpp := fn.profile.RequestsPerPod
+	for len(fn.queue) > fn.starting*rpp && len(fn.pods) < maxPods {
+		sim.startPod(fn)
+	}
+}
+
+// startPod starts a pod of a function. Poolmgr functions take a pod from
+// the pool, waiting for one to be refilled if it's empty, and specialize
+// it. Newdeploy functions start a pod of their own.
+func (sim *simulation) startPod(fn *functionState) {
+	env := fn.env
+	readyAt := sim.now + env.profile.PodStartup.Duration
+	if fn.profile.ExecutorType == fv1.ExecutorTypePoolmgr {
+		for len(env.refills) > 0 && env.refills[0] <= sim.now {
+			env.ready++
+			env.refills = env.refills[1:]
+		}
+		// the pool deployment replaces the pod taken
+		env.refills = append(env.refills, sim.now+env.profile.PodStartup.Duration)
+		if env.ready > 0 {
+			env.ready--
+			readyAt = sim.now
+		} else {
+			readyAt = env.refills[0]
+			env.refills = env.refills[1:]
+		}
+	}
+	readyAt += env.profile.Specialization.Duration
+
+	p := &pod{}
+	fn.pods = append(fn.pods, p)
+	fn.starting++
+	fn.report.ColdStarts++
+	if len(fn.pods) > fn.report.MaxPods {
+		fn.report.MaxPods = len(fn.pods)
+	}
+	sim.schedule(&event{at: readyAt, kind: eventPodReady, fn: fn, pod: p})
+}
+
+// idle marks a pod as idle, to be reclaimed if it stays so for the idle
+// timeout.
+func (sim *simulation) idle(fn *functionState, p *pod) {
+	p.idleSince = sim.now
+	sim.schedule(&event{at: sim.now + fn.profile.IdleTimeout.Duration, kind: eventReap, fn: fn, pod: p})
+}
+
+// reap removes a pod idle for the idle timeout, keeping the min scale of
+// newdeploy functions.
+func (sim *simulation) reap(fn *functionState, p *pod) {
+	if p.removed || p.busy > 0 || sim.now-p.idleSince < fn.profile.IdleTimeout.Duration {
+		return
+	}
+	if fn.profile.ExecutorType == fv1.ExecutorTypeNewdeploy && len(fn.pods) <= fn.profile.MinScale {
+		return
+	}
+	p.removed = true
+	for i := range fn.pods {
+		if fn.pods[i] == p {
+			fn.pods = append(fn.pods[:i], fn.pods[i+1:]...)
+			break
+		}
+	}
+}
+
+// freePod returns a ready pod with a free slot, preferring busier ones so
+// that idle pods can be reclaimed.
+func (fn *functionState) freePod() *pod {
+	var free *pod
+	for _, p := range fn.pods {
+		if p.ready && p.busy < fn.profile.RequestsPerPod && (free == nil || p.busy > free.busy) {
+			free = p
+		}
+	}
+	return free
+}
+
+// updatePodTime adds the pods of the function since the last update, up
+// to the end of the traffic.
+func (fn *functionState) updatePodTime(now time.Duration, end time.Duration) {
+	if now > end {
+		now = end
+	}
+	if now > fn.lastUpdate {
+		fn.podTime += float64(len(fn.pods)) * float64(now-fn.lastUpdate)
+		fn.lastUpdate = now
+	}
+}
+
+// percentile returns the p-th percentile of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func makeProfile(poolsize int, fn FunctionProfile) *Profile {
+	fn.Name = "fn"
+	fn.Environment = "env"
+	if fn.ExecutionTime.Duration == 0 {
+		fn.ExecutionTime.Duration = 100 * time.Millisecond
+	}
+	profile := &Profile{
+		Duration: Duration{10 * time.Second},
+		Environments: []EnvironmentProfile{{
+			Name:     "env",
+			Poolsize: &poolsize,
+		}},
+		Functions: []FunctionProfile{fn},
+	}
+	profile.SetDefaults()
+	return profile
+}
+
+func simulate(t *testing.T, profile *Profile) FunctionReport {
+	report, err := Simulate(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report.Functions[0]
+}
+
+func TestSimulatePoolmgr(t *testing.T) {
+	// a request every 2s, the first one waits for a pod of the pool to
+	// be specialized
+	report := simulate(t, makeProfile(3, FunctionProfile{
+		Traffic: []TrafficPhase{{End: Duration{10 * time.Second}, Rate: 0.5, Arrival: ArrivalUniform}},
+	}))
+	if report.Requests != 4 || report.ColdStarts != 1 || report.Queued != 1 || report.MaxPods != 1 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if report.QueueWaitP50 != 0 || report.QueueWaitMax != time.Second {
+		t.Errorf("expected a single wait of 1s, got %+v", report)
+	}
+
+	// with an empty pool the pod has to be started too
+	report = simulate(t, makeProfile(0, FunctionProfile{
+		Traffic: []TrafficPhase{{End: Duration{10 * time.Second}, Rate: 0.2, Arrival: ArrivalUniform}},
+	}))
+	if report.Requests != 1 || report.QueueWaitMax != 11*time.Second || report.ColdStarts != 1 {
+		t.Errorf("expected a wait of 11s, got %+v", report)
+	}
+
+	// idle pods are reclaimed and started again
+	report = simulate(t, makeProfile(3, FunctionProfile{
+		IdleTimeout: &Duration{time.Second},
+		Traffic:     []TrafficPhase{{End: Duration{10 * time.Second}, Rate: 0.25, Arrival: ArrivalUniform}},
+	}))
+	if report.Requests != 2 || report.ColdStarts != 2 || report.Queued != 2 {
+		t.Errorf("expected every request to cold start, got %+v", report)
+	}
+}
+
+func TestSimulateNewdeploy(t *testing.T) {
+	report := simulate(t, makeProfile(3, FunctionProfile{
+		ExecutorType: fv1.ExecutorTypeNewdeploy,
+		MinScale:     1,
+		MaxScale:     3,
+		Traffic:      []TrafficPhase{{End: Duration{10 * time.Second}, Rate: 0.5, Arrival: ArrivalUniform}},
+	}))
+	if report.ColdStarts != 0 || report.Queued != 0 || report.MaxPods != 1 || report.AvgPods != 1 {
+		t.Errorf("expected the pod of min scale to serve all requests, got %+v", report)
+	}
+
+	// concurrent requests beyond the capacity of the pods scale up to
+	// max scale
+	report = simulate(t, makeProfile(3, FunctionProfile{
+		ExecutorType:  fv1.ExecutorTypeNewdeploy,
+		MinScale:      1,
+		MaxScale:      3,
+		ExecutionTime: Duration{5 * time.Second},
+		Traffic:       []TrafficPhase{{End: Duration{10 * time.Second}, Rate: 5, Arrival: ArrivalUniform}},
+	}))
+	if report.ColdStarts != 2 || report.MaxPods != 3 {
+		t.Errorf("expected scaling to max scale, got %+v", report)
+	}
+}
+
+func TestSimulateTraffic(t *testing.T) {
+	// a ramp from 0 to 1.1 requests per second sends 5.5 requests
+	report := simulate(t, makeProfile(3, FunctionProfile{
+		Traffic: []TrafficPhase{{End: Duration{10 * time.Second}, Rate: 0, EndRate: func(f float64) *float64 { return &f }(1.1), Arrival: ArrivalUniform}},
+	}))
+	if report.Requests != 5 {
+		t.Errorf("expected 5 requests, got %v", report.Requests)
+	}
+
+	// random arrivals are replayed for the same seed
+	profile := makeProfile(3, FunctionProfile{
+		Traffic: []TrafficPhase{{End: Duration{10 * time.Second}, Rate: 100}},
+	})
+	first := simulate(t, profile)
+	second := simulate(t, profile)
+	if first != second {
+		t.Errorf("expected the same report for the same seed, got %+v and %+v", first, second)
+	}
+	if first.Requests < 800 || first.Requests > 1200 {
+		t.Errorf("expected about 1000 requests, got %v", first.Requests)
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "simulator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "traffic.yaml")
+	err = ioutil.WriteFile(path, []byte(`
+duration: 1m
+environments:
+- name: nodejs
+  podStartup: 5s
+functions:
+- name: hello
+  environment: nodejs
+  executionTime: 200ms
+  traffic:
+  - end: 1m
+    rate: 10
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile, err := LoadProfile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Duration.Duration != time.Minute || profile.Environments[0].PodStartup.Duration != 5*time.Second ||
+		*profile.Environments[0].Poolsize != defaultPoolsize || profile.Functions[0].ExecutorType != fv1.ExecutorTypePoolmgr ||
+		profile.Functions[0].Traffic[0].Arrival != ArrivalPoisson {
+		t.Errorf("unexpected profile %+v", profile)
+	}
+
+	err = ioutil.WriteFile(path, []byte(`
+duration: 1m
+functions:
+- name: hello
+  environment: missing
+  executionTime: 200ms
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadProfile(path); err == nil {
+		t.Error("expected function of a missing environment to be rejected")
+	}
+}
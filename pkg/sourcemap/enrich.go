@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sourcemap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// maxLineSize is the size of the longest log line enriched, longer lines are
+// copied as they are.
+const maxLineSize = 1 << 20
+
+// frameRegexp matches the file positions of stack frames, like
+// "at handler (/userfunc/deployarchive/dist/index.js:1:2345)".
+var frameRegexp = regexp.MustCompile(`(?:file://)?([^\s():]+):(\d+):(\d+)`)
+
+// Enrich appends the original position to the positions of a log line it
+// knows the source of, e.g. "dist/index.js:1:2345 [src/handler.ts:12:5]".
+func (m *Manifest) Enrich(line string) string {
+	return frameRegexp.ReplaceAllStringFunc(line, func(frame string) string {
+		match := frameRegexp.FindStringSubmatch(frame)
+		l, err := strconv.Atoi(match[2])
+		if err != nil {
+			return frame
+		}
+		c, err := strconv.Atoi(match[3])
+		if err != nil {
+			return frame
+		}
+		pos, ok := m.Resolve(match[1], l, c)
+		if !ok {
+			return frame
+		}
+		return fmt.Sprintf("%v [%v:%v:%v]", frame, pos.Source, pos.Line, pos.Column)
+	})
+}
+
+// Copy copies logs from src to dst, enriching their stack frames.
+func (m *Manifest) Copy(dst io.Writer, src io.Reader) error {
+	r := bufio.NewReaderSize(src, 64*1024)
+	w := bufio.NewWriter(dst)
+
+	for {
+		line, err := r.ReadString('\n')
+		if len(line) > 0 {
+			if len(line) <= maxLineSize {
+				line = m.Enrich(line)
+			}
+			_, werr := w.WriteString(line)
+			if werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return w.Flush()
+		}
+		if err != nil {
+			w.Flush()
+			return err
+		}
+	}
+}
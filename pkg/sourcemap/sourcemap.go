@@ -0,0 +1,295 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sourcemap maps positions in the files of deployment archives back
+// to the original sources they were built from, using the source maps
+// (revision 3) builds output next to generated files.
+package sourcemap
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ManifestVersion is the version of the manifest format.
+	ManifestVersion = 1
+
+	sourceMapExt = ".map"
+)
+
+type (
+	// Manifest holds the source maps of a deployment archive, keyed by the
+	// path of the generated file they map, relative to the archive root.
+	Manifest struct {
+		Version    int                   `json:"version"`
+		SourceMaps map[string]*SourceMap `json:"sourceMaps"`
+	}
+
+	// SourceMap maps the positions of a generated file to its sources, whose
+	// paths are relative to the archive root.
+	SourceMap struct {
+		Sources  []string `json:"sources"`
+		Mappings string   `json:"mappings"`
+
+		// lines are the decoded mappings of each generated line.
+		lines [][]segment
+	}
+
+	// Position is a position in a source, lines and columns starting at 1.
+	Position struct {
+		Source string
+		Line   int
+		Column int
+	}
+
+	// segment maps a generated column to a source position, all starting at 0.
+	segment struct {
+		column       int
+		source       int
+		sourceLine   int
+		sourceColumn int
+	}
+
+	// rawSourceMap is a revision 3 source map file.
+	rawSourceMap struct {
+		Version    int      `json:"version"`
+		File       string   `json:"file"`
+		SourceRoot string   `json:"sourceRoot"`
+		Sources    []string `json:"sources"`
+		Mappings   string   `json:"mappings"`
+	}
+)
+
+// BuildManifest returns the manifest of the source maps in a deployment
+// package directory, or nil if it has none.
+func BuildManifest(dir string) (*Manifest, error) {
+	m := &Manifest{
+		Version:    ManifestVersion,
+		SourceMaps: make(map[string]*SourceMap),
+	}
+
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), sourceMapExt) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return errors.Wrapf(err, "error reading source map %v", rel)
+		}
+
+		var raw rawSourceMap
+		err = json.Unmarshal(data, &raw)
+		if err != nil || raw.Version != 3 {
+			// not a source map, or one we don't support
+			return nil
+		}
+		file, sm := raw.resolve(filepath.ToSlash(rel))
+		m.SourceMaps[file] = sm
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error walking deployment package")
+	}
+
+	if len(m.SourceMaps) == 0 {
+		return nil, nil
+	}
+	return m, nil
+}
+
+// resolve returns the path of the file a source map at mapPath maps, and the
+// source map with the paths of its sources relative to the archive root.
+func (raw *rawSourceMap) resolve(mapPath string) (string, *SourceMap) {
+	dir := path.Dir(mapPath)
+
+	file := strings.TrimSuffix(mapPath, sourceMapExt)
+	if len(raw.File) > 0 {
+		file = path.Join(dir, raw.File)
+	}
+
+	sources := make([]string, len(raw.Sources))
+	for i, src := range raw.Sources {
+		// bundlers prefix sources with pseudo schemes, like webpack:///./src/index.ts
+		if j := strings.Index(src, "://"); j >= 0 {
+			sources[i] = path.Clean(strings.TrimLeft(src[j+3:], "/"))
+			continue
+		}
+		if path.IsAbs(src) {
+			sources[i] = path.Clean(src)
+			continue
+		}
+		sources[i] = path.Join(dir, raw.SourceRoot, src)
+	}
+
+	return path.Clean(file), &SourceMap{
+		Sources:  sources,
+		Mappings: raw.Mappings,
+	}
+}
+
+// Parse decodes a manifest.
+func Parse(data []byte) (*Manifest, error) {
+	var m Manifest
+	err := json.Unmarshal(data, &m)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding source map manifest")
+	}
+	if m.Version != ManifestVersion {
+		return nil, errors.Errorf("unsupported source map manifest version %v", m.Version)
+	}
+
+	for file, sm := range m.SourceMaps {
+		sm.lines, err = decodeMappings(sm.Mappings)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding source map of %v", file)
+		}
+	}
+	return &m, nil
+}
+
+// Resolve returns the original position of a position of a file in the
+// deployment archive. The file path may be absolute, e.g. where the archive is
+// extracted in function pods, it matches the longest relative path of the
+// manifest it ends with.
+func (m *Manifest) Resolve(file string, line int, column int) (Position, bool) {
+	sm, ok := m.lookup(file)
+	if !ok || line < 1 || line > len(sm.lines) {
+		return Position{}, false
+	}
+
+	segments := sm.lines[line-1]
+	i := sort.Search(len(segments), func(i int) bool {
+		return segments[i].column > column-1
+	})
+	if i == 0 {
+		return Position{}, false
+	}
+	seg := segments[i-1]
+	if seg.source < 0 || seg.source >= len(sm.Sources) {
+		return Position{}, false
+	}
+
+	return Position{
+		Source: sm.Sources[seg.source],
+		Line:   seg.sourceLine + 1,
+		Column: seg.sourceColumn + 1,
+	}, true
+}
+
+func (m *Manifest) lookup(file string) (*SourceMap, bool) {
+	file = filepath.ToSlash(file)
+	if sm, ok := m.SourceMaps[file]; ok {
+		return sm, true
+	}
+
+	var match string
+	for f := range m.SourceMaps {
+		if strings.HasSuffix(file, "/"+f) && len(f) > len(match) {
+			match = f
+		}
+	}
+	if len(match) == 0 {
+		return nil, false
+	}
+	return m.SourceMaps[match], true
+}
+
+// decodeMappings decodes the mappings of a source map into segments sorted by
+// column for each generated line. Segments without a source are dropped.
+func decodeMappings(mappings string) ([][]segment, error) {
+	var lines [][]segment
+	var source, sourceLine, sourceColumn int
+
+	for _, l := range strings.Split(mappings, ";") {
+		var segments []segment
+		column := 0
+		for _, s := range strings.Split(l, ",") {
+			if len(s) == 0 {
+				continue
+			}
+			fields, err := decodeVLQ(s)
+			if err != nil {
+				return nil, err
+			}
+
+			// fields are relative to the previous segment, columns only within the line
+			column += fields[0]
+			if len(fields) < 4 {
+				continue
+			}
+			source += fields[1]
+			sourceLine += fields[2]
+			sourceColumn += fields[3]
+			segments = append(segments, segment{
+				column:       column,
+				source:       source,
+				sourceLine:   sourceLine,
+				sourceColumn: sourceColumn,
+			})
+		}
+		sort.SliceStable(segments, func(i, j int) bool {
+			return segments[i].column < segments[j].column
+		})
+		lines = append(lines, segments)
+	}
+	return lines, nil
+}
+
+// decodeVLQ decodes the base64 VLQ fields of a segment.
+func decodeVLQ(s string) ([]int, error) {
+	var fields []int
+	value, shift := 0, uint(0)
+	for i := 0; i < len(s); i++ {
+		digit := strings.IndexByte(base64Chars, s[i])
+		if digit < 0 {
+			return nil, errors.Errorf("invalid mapping segment %q", s)
+		}
+		value += (digit & 0x1f) << shift
+		if digit&0x20 != 0 {
+			shift += 5
+			continue
+		}
+
+		// the lowest bit is the sign
+		if value&1 != 0 {
+			fields = append(fields, -(value >> 1))
+		} else {
+			fields = append(fields, value>>1)
+		}
+		value, shift = 0, 0
+	}
+	if shift != 0 {
+		return nil, errors.Errorf("truncated mapping segment %q", s)
+	}
+	return fields, nil
+}
+
+const base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
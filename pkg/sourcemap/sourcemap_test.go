@@ -0,0 +1,205 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sourcemap
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/utils"
+)
+
+// testSourceMap maps column 0 of line 1 to line 1 of the source, column 10
+// of line 1 to line 3 column 3, and line 2 to line 4 column 3.
+const testSourceMap = `{
+	"version": 3,
+	"file": "index.js",
+	"sources": ["webpack:///./src/handler.ts"],
+	"mappings": "AAAA,UAEE;AACA"
+}`
+
+func writeTestPackage(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "sourcemap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"dist/index.js":     "...",
+		"dist/index.js.map": testSourceMap,
+		"dist/vendor.map":   "not a source map",
+		"package.json":      "{}",
+	} {
+		p := filepath.Join(dir, name)
+		err = os.MkdirAll(filepath.Dir(p), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(p, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func buildTestManifest(t *testing.T) []byte {
+	dir := writeTestPackage(t)
+	defer os.RemoveAll(dir)
+
+	m, err := BuildManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil || len(m.SourceMaps) != 1 {
+		t.Fatalf("expected the source map of dist/index.js, got %+v", m)
+	}
+	sm, ok := m.SourceMaps["dist/index.js"]
+	if !ok {
+		t.Fatalf("expected the source map to be keyed by its generated file, got %v", m.SourceMaps)
+	}
+	if sm.Sources[0] != "src/handler.ts" {
+		t.Fatalf("expected the bundler scheme to be removed from sources, got %v", sm.Sources)
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDecodeVLQ(t *testing.T) {
+	for s, expected := range map[string][]int{
+		"AAAA": {0, 0, 0, 0},
+		"UAEE": {10, 0, 2, 2},
+		"D":    {-1},
+		"gB":   {16},
+	} {
+		fields, err := decodeVLQ(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fields) != len(expected) {
+			t.Fatalf("expected %v for %q, got %v", expected, s, fields)
+		}
+		for i := range fields {
+			if fields[i] != expected[i] {
+				t.Errorf("expected %v for %q, got %v", expected, s, fields)
+			}
+		}
+	}
+
+	if _, err := decodeVLQ("g"); err == nil {
+		t.Error("expected truncated segments to fail")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	m, err := Parse(buildTestManifest(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		file         string
+		line, column int
+		expected     Position
+	}{
+		{"dist/index.js", 1, 1, Position{"src/handler.ts", 1, 1}},
+		{"dist/index.js", 1, 15, Position{"src/handler.ts", 3, 3}},
+		{"/userfunc/deployarchive/dist/index.js", 2, 5, Position{"src/handler.ts", 4, 3}},
+	} {
+		pos, ok := m.Resolve(test.file, test.line, test.column)
+		if !ok || pos != test.expected {
+			t.Errorf("expected %v for %v:%v:%v, got %v", test.expected, test.file, test.line, test.column, pos)
+		}
+	}
+
+	if _, ok := m.Resolve("dist/other.js", 1, 1); ok {
+		t.Error("expected files without source map not to resolve")
+	}
+	if _, ok := m.Resolve("dist/index.js", 3, 1); ok {
+		t.Error("expected lines without mappings not to resolve")
+	}
+}
+
+func TestEnrich(t *testing.T) {
+	m, err := Parse(buildTestManifest(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs := "TypeError: x is undefined\n" +
+		"    at handler (/userfunc/deployarchive/dist/index.js:1:15)\n" +
+		"    at /usr/src/app/server.js:86:5\n"
+	var out bytes.Buffer
+	err = m.Copy(&out, strings.NewReader(logs))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(out.String(), "\n")
+	if lines[0] != "TypeError: x is undefined" {
+		t.Errorf("expected lines without frames to be kept, got %q", lines[0])
+	}
+	if lines[1] != "    at handler (/userfunc/deployarchive/dist/index.js:1:15 [src/handler.ts:3:3])" {
+		t.Errorf("expected the frame to be enriched, got %q", lines[1])
+	}
+	if lines[2] != "    at /usr/src/app/server.js:86:5" {
+		t.Errorf("expected frames of the runtime to be kept, got %q", lines[2])
+	}
+}
+
+func TestStore(t *testing.T) {
+	data := buildTestManifest(t)
+	sum, err := utils.GetChecksum(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := MakeStore(1)
+	var downloads int
+	s.download = func(url string) ([]byte, error) {
+		downloads++
+		return data, nil
+	}
+
+	archive := fv1.Archive{Type: fv1.ArchiveTypeUrl, URL: "http://storagesvc/v1/archive?id=a", Checksum: *sum}
+	for i := 0; i < 2; i++ {
+		m, err := s.Get(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := m.Resolve("dist/index.js", 1, 1); !ok {
+			t.Fatal("expected the manifest to be parsed")
+		}
+	}
+	if downloads != 1 {
+		t.Fatalf("expected the manifest to be downloaded once, got %v downloads", downloads)
+	}
+
+	archive.Checksum.Sum = "0123"
+	if _, err = s.Get(archive); err == nil {
+		t.Fatal("expected a checksum mismatch")
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sourcemap
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/utils"
+)
+
+// DefaultStoreSize is how many manifests stores keep.
+const DefaultStoreSize = 64
+
+type (
+	// Store downloads the manifests of packages, keeping the most recently
+	// used ones.
+	Store struct {
+		mu        sync.Mutex
+		size      int
+		manifests map[string]*Manifest
+		used      []string
+
+		// download downloads the manifest at a URL
+		download func(url string) ([]byte, error)
+	}
+)
+
+// MakeStore returns a store keeping up to size manifests.
+func MakeStore(size int) *Store {
+	client := &http.Client{Timeout: 30 * time.Second}
+	return &Store{
+		size:      size,
+		manifests: make(map[string]*Manifest),
+		download: func(url string) ([]byte, error) {
+			resp, err := client.Get(url)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return nil, errors.Errorf("unexpected status %v", resp.Status)
+			}
+			return ioutil.ReadAll(resp.Body)
+		},
+	}
+}
+
+// Get returns the manifest stored in an archive.
+func (s *Store) Get(archive fv1.Archive) (*Manifest, error) {
+	key := archive.URL
+	if len(archive.Checksum.Sum) > 0 {
+		key = archive.Checksum.Sum
+	}
+
+	s.mu.Lock()
+	m, ok := s.manifests[key]
+	if ok {
+		s.use(key)
+	}
+	s.mu.Unlock()
+	if ok {
+		return m, nil
+	}
+
+	data := archive.Literal
+	if archive.Type == fv1.ArchiveTypeUrl {
+		var err error
+		data, err = s.download(archive.URL)
+		if err != nil {
+			return nil, errors.Wrap(err, "error downloading source map manifest")
+		}
+	}
+	if len(archive.Checksum.Sum) > 0 {
+		sum, err := utils.GetChecksum(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if sum.Sum != archive.Checksum.Sum {
+			return nil, errors.New("source map manifest checksum mismatch")
+		}
+	}
+
+	m, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.manifests[key]; !ok {
+		s.manifests[key] = m
+		s.use(key)
+	}
+	for len(s.used) > s.size {
+		delete(s.manifests, s.used[0])
+		s.used = s.used[1:]
+	}
+	return m, nil
+}
+
+// use marks a manifest as the most recently used one.
+func (s *Store) use(key string) {
+	for i, k := range s.used {
+		if k == key {
+			s.used = append(s.used[:i], s.used[i+1:]...)
+			break
+		}
+	}
+	s.used = append(s.used, key)
+}
@@ -17,36 +17,52 @@ limitations under the License.
 package storagesvc
 
 import (
+	"sort"
 	"time"
 
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 
+	"github.com/fission/fission/pkg/buildcache"
 	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/pkgrevision"
+	"github.com/fission/fission/pkg/tombstone"
 )
 
 type ArchivePruner struct {
 	logger        *zap.Logger
 	crdClient     *crd.FissionClient
+	kubeClient    *kubernetes.Clientset
 	archiveChan   chan string
 	stowClient    *StowClient
 	pruneInterval time.Duration
+	// dryRun only logs the archives that would be deleted
+	dryRun bool
+	// revisionRetention is how many of the latest revisions of a package
+	// keep their archives, 0 for all revisions kept
+	revisionRetention int
 }
 
 const defaultPruneInterval int = 60 // in minutes
 
-func MakeArchivePruner(logger *zap.Logger, stowClient *StowClient, pruneInterval time.Duration) (*ArchivePruner, error) {
-	crdClient, _, _, _, err := crd.MakeFissionClient()
+func MakeArchivePruner(logger *zap.Logger, stowClient *StowClient, pruneInterval time.Duration,
+	dryRun bool, revisionRetention int) (*ArchivePruner, error) {
+	crdClient, kubeClient, _, _, err := crd.MakeFissionClient()
 	if err != nil {
 		return nil, err
 	}
 
 	return &ArchivePruner{
-		logger:        logger.Named("archive_pruner"),
-		crdClient:     crdClient,
-		archiveChan:   make(chan string),
-		stowClient:    stowClient,
-		pruneInterval: pruneInterval,
+		logger:            logger.Named("archive_pruner"),
+		crdClient:         crdClient,
+		kubeClient:        kubeClient,
+		archiveChan:       make(chan string),
+		stowClient:        stowClient,
+		pruneInterval:     pruneInterval,
+		dryRun:            dryRun,
+		revisionRetention: revisionRetention,
 	}, nil
 }
 
@@ -56,13 +72,22 @@ func (pruner *ArchivePruner) pruneArchives() {
 	for archiveID := range pruner.archiveChan {
 		pruner.logger.Info("sending delete request for archive",
 			zap.String("archive_id", archiveID))
-		if err := pruner.stowClient.removeFileByID(archiveID); err != nil {
+		err := pruner.stowClient.removeFileByID(archiveID)
+		if err == errArchiveInUse {
+			pruner.logger.Info("keeping archive referenced again recently",
+				zap.String("archive_id", archiveID))
+			archivesPruned.WithLabelValues("kept").Inc()
+			continue
+		} else if err != nil {
 			// logging the error and continuing with other deletions.
 			// hopefully this archive will be deleted in the next iteration.
 			pruner.logger.Error("ignoring error while deleting archive",
 				zap.Error(err),
 				zap.String("archive_id", archiveID))
+			archivesPruned.WithLabelValues("failed").Inc()
+			continue
 		}
+		archivesPruned.WithLabelValues("deleted").Inc()
 	}
 }
 
@@ -74,7 +99,7 @@ func (pruner *ArchivePruner) insertArchive(archiveID string) {
 // A user may have deleted pkgs with kubectl or fission cli. That only deletes crd.Package objects from kubernetes
 // and not the archives that are referenced by them, leaving the archives as orphans.
 // getOrphanArchives reaps the orphaned archives.
-func (pruner *ArchivePruner) getOrphanArchives() {
+func (pruner *ArchivePruner) getOrphanArchives() error {
 	pruner.logger.Info("getting orphan archives")
 	archivesRefByPkgs := make([]string, 0)
 	var archiveID string
@@ -82,8 +107,7 @@ func (pruner *ArchivePruner) getOrphanArchives() {
 	// get all pkgs from kubernetes
 	pkgList, err := pruner.crdClient.CoreV1().Packages(metav1.NamespaceAll).List(metav1.ListOptions{})
 	if err != nil {
-		pruner.logger.Error("error getting package list from kubernetes", zap.Error(err))
-		return
+		return errors.Wrap(err, "error getting package list from kubernetes")
 	}
 
 	// extract archives referenced by these pkgs
@@ -91,25 +115,90 @@ func (pruner *ArchivePruner) getOrphanArchives() {
 		if pkg.Spec.Deployment.URL != "" {
 			archiveID, err = getQueryParamValue(pkg.Spec.Deployment.URL, "id")
 			if err != nil {
-				pruner.logger.Error("error extracting value of archiveID from deployment url",
-					zap.Error(err),
-					zap.String("url", pkg.Spec.Deployment.URL))
-				return
+				return errors.Wrapf(err, "error extracting value of archiveID from deployment url %q", pkg.Spec.Deployment.URL)
 			}
 			archivesRefByPkgs = append(archivesRefByPkgs, archiveID)
 		}
 		if pkg.Spec.Source.URL != "" {
 			archiveID, err = getQueryParamValue(pkg.Spec.Source.URL, "id")
 			if err != nil {
-				pruner.logger.Error("error extracting value of archiveID from source url",
-					zap.Error(err),
-					zap.String("url", pkg.Spec.Source.URL))
-				return
+				return errors.Wrapf(err, "error extracting value of archiveID from source url %q", pkg.Spec.Source.URL)
+			}
+			archivesRefByPkgs = append(archivesRefByPkgs, archiveID)
+		}
+	}
+
+	// archives of deleted packages are kept as long as their tombstones, to be able to restore them
+	cms, err := pruner.kubeClient.CoreV1().ConfigMaps(metav1.NamespaceAll).List(metav1.ListOptions{
+		LabelSelector: tombstone.LabelTombstone + "=true",
+	})
+	if err != nil {
+		return errors.Wrap(err, "error getting tombstones from kubernetes")
+	}
+	for i := range cms.Items {
+		t, err := tombstone.FromConfigMap(&cms.Items[i])
+		if err != nil {
+			pruner.logger.Error("ignoring invalid tombstone", zap.Error(err))
+			continue
+		}
+		for _, url := range t.ArchiveURLs {
+			archiveID, err = getQueryParamValue(url, "id")
+			if err != nil {
+				return errors.Wrapf(err, "error extracting value of archiveID from tombstone archive url %q", url)
+			}
+			archivesRefByPkgs = append(archivesRefByPkgs, archiveID)
+		}
+	}
+
+	// archives of past package revisions are kept as long as the revisions, to be able to compare them,
+	// unless the revisions are older than the retention
+	cms, err = pruner.kubeClient.CoreV1().ConfigMaps(metav1.NamespaceAll).List(metav1.ListOptions{
+		LabelSelector: pkgrevision.LabelRevision + "=true",
+	})
+	if err != nil {
+		return errors.Wrap(err, "error getting package revisions from kubernetes")
+	}
+	revisions := make([]pkgrevision.Revision, 0, len(cms.Items))
+	for i := range cms.Items {
+		r, err := pkgrevision.FromConfigMap(&cms.Items[i])
+		if err != nil {
+			pruner.logger.Error("ignoring invalid package revision", zap.Error(err))
+			continue
+		}
+		revisions = append(revisions, *r)
+	}
+	for _, r := range retainedRevisions(revisions, pruner.revisionRetention) {
+		for _, url := range r.ArchiveURLs() {
+			archiveID, err = getQueryParamValue(url, "id")
+			if err != nil {
+				return errors.Wrapf(err, "error extracting value of archiveID from package revision archive url %q", url)
 			}
 			archivesRefByPkgs = append(archivesRefByPkgs, archiveID)
 		}
 	}
 
+	// deploy archives of cached builds are kept until the cache entries expire
+	entries, err := buildcache.List(pruner.kubeClient)
+	if err != nil {
+		return errors.Wrap(err, "error getting build cache entries from kubernetes")
+	}
+	now := time.Now()
+	for _, e := range entries {
+		if e.IsExpired(now) {
+			continue
+		}
+		archiveID, err = getQueryParamValue(e.Deployment.URL, "id")
+		if err != nil {
+			return errors.Wrapf(err, "error extracting value of archiveID from build cache archive url %q", e.Deployment.URL)
+		}
+		archivesRefByPkgs = append(archivesRefByPkgs, archiveID)
+	}
+
+	// packages reference content-addressed archives through references of
+	// their own, archives are kept while any of their references is used
+	for i, id := range archivesRefByPkgs {
+		archivesRefByPkgs[i] = archiveItemID(id)
+	}
 	pruner.logger.Debug("archives referenced by packagese", zap.Strings("archives", archivesRefByPkgs))
 
 	// get all archives on storage
@@ -117,8 +206,7 @@ func (pruner *ArchivePruner) getOrphanArchives() {
 	// need to filter them out.
 	archivesInStorage, err := pruner.stowClient.getItemIDsWithFilter(pruner.stowClient.filterItemCreatedAMinuteAgo, time.Now())
 	if err != nil {
-		pruner.logger.Error("error getting items from storage", zap.Error(err))
-		return
+		return errors.Wrap(err, "error getting items from storage")
 	}
 	pruner.logger.Debug("archives in storage", zap.Strings("archives", archivesInStorage))
 
@@ -127,21 +215,66 @@ func (pruner *ArchivePruner) getOrphanArchives() {
 	orphanedArchives := getDifferenceOfLists(archivesInStorage, archivesRefByPkgs)
 	pruner.logger.Debug("orphan archives", zap.Strings("archives", orphanedArchives))
 
+	archivesStored.Set(float64(len(archivesInStorage)))
+	archivesReferenced.Set(float64(len(archivesInStorage) - len(orphanedArchives)))
+	archivesOrphaned.Set(float64(len(orphanedArchives)))
+
+	if pruner.dryRun {
+		for _, archiveID = range orphanedArchives {
+			pruner.logger.Info("dry run, not deleting orphan archive", zap.String("archive_id", archiveID))
+		}
+		return nil
+	}
+
 	// send each orphan archive away for deletion
 	for _, archiveID = range orphanedArchives {
 		pruner.insertArchive(archiveID)
 	}
+	return nil
+}
+
+// retainedRevisions returns the revisions within the retention of their
+// package, which is the number of latest revisions of each package kept,
+// or all revisions if 0.
+func retainedRevisions(revisions []pkgrevision.Revision, retention int) []pkgrevision.Revision {
+	if retention <= 0 {
+		return revisions
+	}
+	byPackage := make(map[string][]pkgrevision.Revision)
+	for _, r := range revisions {
+		byPackage[r.UID] = append(byPackage[r.UID], r)
+	}
+	retained := make([]pkgrevision.Revision, 0, len(revisions))
+	for _, pkgRevisions := range byPackage {
+		sort.Slice(pkgRevisions, func(i, j int) bool {
+			return pkgRevisions[i].Generation > pkgRevisions[j].Generation
+		})
+		if len(pkgRevisions) > retention {
+			pkgRevisions = pkgRevisions[:retention]
+		}
+		retained = append(retained, pkgRevisions...)
+	}
+	return retained
 }
 
 // Start starts a go routine that listens to a channel for archive IDs that need to deleted.
 // Also wakes up at regular intervals to make a list of archive IDs that need to be reaped
 // and sends them over to the channel for deletion
 func (pruner *ArchivePruner) Start() {
+	if pruner.dryRun {
+		pruner.logger.Info("archive pruner runs in dry run mode, orphan archives are only logged")
+	}
 	ticker := time.NewTicker(pruner.pruneInterval * time.Minute)
 	go pruner.pruneArchives()
 	for range ticker.C {
 		// This method fetches unused archive IDs and sends them to archiveChannel for deletion
 		// silencing the errors, hoping they go away in next iteration.
-		pruner.getOrphanArchives()
+		if err := pruner.getOrphanArchives(); err != nil {
+			pruner.logger.Error("error getting orphan archives", zap.Error(err))
+			archivePrunerRuns.WithLabelValues("failed").Inc()
+			continue
+		}
+		archivePrunerRuns.WithLabelValues("succeeded").Inc()
+		archivePrunerLastRun.SetToCurrentTime()
 	}
 }
@@ -0,0 +1,59 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storagesvc
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/fission/fission/pkg/pkgrevision"
+)
+
+func TestRetainedRevisions(t *testing.T) {
+	revisions := []pkgrevision.Revision{
+		{UID: "a", Generation: 1},
+		{UID: "a", Generation: 3},
+		{UID: "a", Generation: 2},
+		{UID: "b", Generation: 7},
+	}
+
+	if retained := retainedRevisions(revisions, 0); len(retained) != len(revisions) {
+		t.Errorf("expected all revisions to be retained without a retention, got %+v", retained)
+	}
+
+	retained := retainedRevisions(revisions, 2)
+	sort.Slice(retained, func(i, j int) bool {
+		if retained[i].UID != retained[j].UID {
+			return retained[i].UID < retained[j].UID
+		}
+		return retained[i].Generation > retained[j].Generation
+	})
+	expected := []pkgrevision.Revision{
+		{UID: "a", Generation: 3},
+		{UID: "a", Generation: 2},
+		{UID: "b", Generation: 7},
+	}
+	if len(retained) != len(expected) {
+		t.Fatalf("expected the last 2 revisions of each package, got %+v", retained)
+	}
+	for i := range expected {
+		if retained[i].UID != expected[i].UID || retained[i].Generation != expected[i].Generation {
+			t.Errorf("expected revision %v of %v, got %v of %v",
+				expected[i].Generation, expected[i].UID, retained[i].Generation, retained[i].UID)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storagesvc
+
+import (
+	"os"
+
+	"github.com/graymeta/stow"
+	"github.com/graymeta/stow/azure"
+)
+
+// azureStorage stores archives in a container of an Azure storage account.
+type azureStorage struct {
+	storageType   StorageType
+	containerName string
+	subDir        string
+	accountName   string
+	accountKey    string
+}
+
+// NewAzureStorage returns a storage for an Azure Blob Storage container.
+func NewAzureStorage() StorageBackend {
+	return azureStorage{
+		storageType:   StorageTypeAzure,
+		containerName: os.Getenv("STORAGE_AZURE_CONTAINER_NAME"),
+		subDir:        os.Getenv("STORAGE_AZURE_SUB_DIR"),
+		accountName:   os.Getenv("STORAGE_AZURE_ACCOUNT_NAME"),
+		accountKey:    os.Getenv("STORAGE_AZURE_ACCOUNT_KEY"),
+	}
+}
+
+func (as azureStorage) getStorageType() StorageType {
+	return as.storageType
+}
+
+func (as azureStorage) getContainerName() string {
+	return as.containerName
+}
+
+func (as azureStorage) getSubDir() string {
+	return as.subDir
+}
+
+func (as azureStorage) dial() (stow.Location, error) {
+	return stow.Dial(azure.Kind, stow.ConfigMap{
+		azure.ConfigAccount: as.accountName,
+		azure.ConfigKey:     as.accountKey,
+	})
+}
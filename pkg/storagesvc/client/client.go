@@ -19,6 +19,8 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -34,6 +36,8 @@ import (
 	"golang.org/x/net/context/ctxhttp"
 
 	"github.com/fission/fission/pkg/storagesvc"
+	"github.com/fission/fission/pkg/storagesvc/delta"
+	"github.com/fission/fission/pkg/tracing"
 )
 
 type (
@@ -41,6 +45,13 @@ type (
 		url        string
 		httpClient *http.Client
 	}
+
+	// bearerTokenTransport authenticates requests with a bearer token, for
+	// clients reaching the storage service through the controller proxy.
+	bearerTokenTransport struct {
+		token string
+		next  http.RoundTripper
+	}
 )
 
 // Client creates a storage service client.
@@ -48,11 +59,32 @@ func MakeClient(url string) *Client {
 	return &Client{
 		url: strings.TrimSuffix(url, "/") + "/v1",
 		httpClient: &http.Client{
-			Transport: &ochttp.Transport{},
+			Transport: tracing.Transport(&ochttp.Transport{}),
 		},
 	}
 }
 
+// SetBearerToken makes the client authenticate its requests with the token,
+// which the controller requires if API authentication is enabled.
+func (c *Client) SetBearerToken(token string) {
+	if len(token) == 0 {
+		return
+	}
+	c.httpClient.Transport = &bearerTokenTransport{token: token, next: c.httpClient.Transport}
+}
+
+func (t *bearerTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the request
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header)+1)
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	r.Header.Set("Authorization", "Bearer "+t.token)
+	return t.next.RoundTrip(r)
+}
+
 // Upload sends the local file pointed to by filePath to the storage
 // service, along with the metadata.  It returns a file ID that can be
 // used to retrieve the file.
@@ -113,6 +145,85 @@ func (c *Client) Upload(ctx context.Context, filePath string, metadata *map[stri
 	return ur.ID, nil
 }
 
+// UploadDelta sends the local file pointed to by filePath to the storage service
+// as a delta against the file pointed to by baseID, which the storage service
+// applies to rebuild the file. It returns the ID of the rebuilt file.
+//
+// It falls back to a full upload if the base file can't be used, e.g. it was
+// pruned, or if the file shares too little with it to make a delta worthwhile.
+func (c *Client) UploadDelta(ctx context.Context, filePath string, baseID string, metadata *map[string]string) (string, error) {
+	sig, err := c.GetSignature(ctx, baseID)
+	if err != nil {
+		return c.Upload(ctx, filePath, metadata)
+	}
+
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	buf := &bytes.Buffer{}
+	_, err = delta.ComputeDelta(sig, data, buf)
+	if err != nil {
+		return "", errors.Wrap(err, "error computing delta")
+	}
+	if buf.Len() > len(data)*9/10 {
+		return c.Upload(ctx, filePath, metadata)
+	}
+
+	sum := sha256.Sum256(data)
+	req, err := http.NewRequest(http.MethodPost,
+		fmt.Sprintf("%v/archive/delta?base=%v", c.url, url.QueryEscape(baseID)), buf)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-File-Size", fmt.Sprintf("%v", len(data)))
+	req.Header.Set("X-File-Checksum", hex.EncodeToString(sum[:]))
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := ctxhttp.Do(ctx, c.httpClient, req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		// e.g. the base was pruned meanwhile, or the storage
+		// service doesn't support delta uploads
+		return c.Upload(ctx, filePath, metadata)
+	}
+
+	var ur storagesvc.UploadResponse
+	err = json.Unmarshal(body, &ur)
+	if err != nil {
+		return "", err
+	}
+
+	return ur.ID, nil
+}
+
+// GetSignature returns the delta signature of the file pointed to by ID.
+func (c *Client) GetSignature(ctx context.Context, id string) (*delta.Signature, error) {
+	resp, err := ctxhttp.Get(ctx, c.httpClient, fmt.Sprintf("%v/archive/signature?id=%v", c.url, url.QueryEscape(id)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("HTTP error %v", resp.StatusCode)
+	}
+
+	sig := &delta.Signature{}
+	err = json.NewDecoder(resp.Body).Decode(sig)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding signature")
+	}
+	return sig, nil
+}
+
 // GetUrl returns an HTTP URL that can be used to download the file pointed to by ID
 func (c *Client) GetUrl(id string) string {
 	return fmt.Sprintf("%v/archive?id=%v", c.url, url.PathEscape(id))
@@ -0,0 +1,297 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/fission/fission/pkg/storagesvc"
+)
+
+const (
+	// MultipartThreshold is the size from which archives are better
+	// uploaded in parts.
+	MultipartThreshold int64 = 32 << 20
+
+	// maxPartAttempts is how many times a part is uploaded before the
+	// upload fails.
+	maxPartAttempts = 5
+)
+
+var (
+	// ErrMultipartUnsupported is returned by storage services older than
+	// multipart uploads, to which files are uploaded in a single request.
+	ErrMultipartUnsupported = errors.New("storage service doesn't support multipart uploads")
+
+	// ErrUploadNotFound is returned for uploads completed, aborted or
+	// expired.
+	ErrUploadNotFound = errors.New("upload not found")
+)
+
+// Progress is called with the bytes of a file uploaded so far.
+type Progress func(uploaded int64, total int64)
+
+// UploadMultipart uploads the local file pointed to by filePath in parts,
+// retrying the parts that fail. It returns a file ID like Upload.
+func (c *Client) UploadMultipart(ctx context.Context, filePath string, namespace string, progress Progress) (string, error) {
+	u, err := c.StartMultipart(ctx, filePath, namespace)
+	if err != nil {
+		return "", err
+	}
+	return c.ResumeMultipart(ctx, filePath, u.ID, progress)
+}
+
+// StartMultipart starts the multipart upload of the local file pointed to
+// by filePath, without uploading any part. The upload belongs to the given
+// namespace, only users allowed to write in it may continue it.
+func (c *Client) StartMultipart(ctx context.Context, filePath string, namespace string) (*storagesvc.MultipartUpload, error) {
+	size, checksum, err := fileChecksum(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("partSize", fmt.Sprintf("%v", storagesvc.DefaultPartSize))
+	if len(namespace) > 0 {
+		query.Set("namespace", namespace)
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%v/archive/uploads?%v", c.url, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-File-Size", fmt.Sprintf("%v", size))
+	req.Header.Set("X-File-Checksum", checksum)
+
+	resp, err := ctxhttp.Do(ctx, c.httpClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		return nil, ErrMultipartUnsupported
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, readError(resp, "error starting multipart upload")
+	}
+
+	u := &storagesvc.MultipartUpload{}
+	if err := json.NewDecoder(resp.Body).Decode(u); err != nil {
+		return nil, errors.Wrap(err, "error decoding upload")
+	}
+	return u, nil
+}
+
+// GetUpload returns the multipart upload with its parts uploaded so far.
+func (c *Client) GetUpload(ctx context.Context, uploadID string) (*storagesvc.MultipartUpload, error) {
+	resp, err := ctxhttp.Get(ctx, c.httpClient, c.uploadURL(uploadID, ""))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrUploadNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError(resp, "error getting upload")
+	}
+
+	u := &storagesvc.MultipartUpload{}
+	if err := json.NewDecoder(resp.Body).Decode(u); err != nil {
+		return nil, errors.Wrap(err, "error decoding upload")
+	}
+	return u, nil
+}
+
+// ResumeMultipart uploads the parts of the local file pointed to by
+// filePath missing from the multipart upload, and completes the upload.
+func (c *Client) ResumeMultipart(ctx context.Context, filePath string, uploadID string, progress Progress) (string, error) {
+	u, err := c.GetUpload(ctx, uploadID)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if fi.Size() != u.Size {
+		return "", errors.Errorf("file of %v bytes doesn't match upload %v of %v bytes", fi.Size(), uploadID, u.Size)
+	}
+
+	uploaded := make(map[int]bool, len(u.Parts))
+	for _, p := range u.Parts {
+		uploaded[p.Number] = true
+	}
+	uploadedBytes := u.UploadedBytes
+	if progress != nil {
+		progress(uploadedBytes, u.Size)
+	}
+
+	for number := 1; number <= u.PartCount(); number++ {
+		if uploaded[number] {
+			continue
+		}
+		part := io.NewSectionReader(f, int64(number-1)*u.PartSize, u.PartLength(number))
+		if err := c.uploadPart(ctx, uploadID, number, part); err != nil {
+			return "", errors.Wrapf(err, "error uploading part %v of %v of upload %v", number, u.PartCount(), uploadID)
+		}
+		uploadedBytes += part.Size()
+		if progress != nil {
+			progress(uploadedBytes, u.Size)
+		}
+	}
+
+	return c.completeMultipart(ctx, uploadID)
+}
+
+// AbortMultipart ends the multipart upload without storing the file.
+func (c *Client) AbortMultipart(ctx context.Context, uploadID string) error {
+	req, err := http.NewRequest(http.MethodDelete, c.uploadURL(uploadID, ""), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := ctxhttp.Do(ctx, c.httpClient, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return readError(resp, "error aborting upload")
+	}
+	return nil
+}
+
+// uploadPart uploads a part, retrying with a growing delay as long as the
+// storage service isn't reachable or fails.
+func (c *Client) uploadPart(ctx context.Context, uploadID string, number int, part *io.SectionReader) error {
+	h := sha256.New()
+	if _, err := io.Copy(h, part); err != nil {
+		return err
+	}
+	checksum := hex.EncodeToString(h.Sum(nil))
+
+	var err error
+	for attempt := 1; attempt <= maxPartAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt-1) * time.Second):
+			}
+		}
+
+		var req *http.Request
+		req, err = http.NewRequest(http.MethodPut, c.uploadURL(uploadID, fmt.Sprintf("/parts/%v", number)),
+			io.NewSectionReader(part, 0, part.Size()))
+		if err != nil {
+			return err
+		}
+		req.ContentLength = part.Size()
+		req.Header.Set("Content-Type", "application/octet-stream")
+		req.Header.Set("X-Part-Checksum", checksum)
+
+		var resp *http.Response
+		resp, err = ctxhttp.Do(ctx, c.httpClient, req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
+		}
+		if resp.StatusCode == http.StatusOK {
+			resp.Body.Close()
+			return nil
+		}
+		err = readError(resp, "error uploading part")
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return ErrUploadNotFound
+		}
+		// parts corrupted on the way fail their checksum, other client
+		// errors won't go away
+		if resp.StatusCode < http.StatusInternalServerError && resp.StatusCode != http.StatusBadRequest {
+			return err
+		}
+	}
+	return err
+}
+
+func (c *Client) completeMultipart(ctx context.Context, uploadID string) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, c.uploadURL(uploadID, "/complete"), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := ctxhttp.Do(ctx, c.httpClient, req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", ErrUploadNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", readError(resp, "error completing upload")
+	}
+
+	var ur storagesvc.UploadResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ur); err != nil {
+		return "", err
+	}
+	return ur.ID, nil
+}
+
+func (c *Client) uploadURL(uploadID string, suffix string) string {
+	return fmt.Sprintf("%v/archive/uploads/%v%v", c.url, url.PathEscape(uploadID), suffix)
+}
+
+// readError returns an error with the body of the failed response.
+func readError(resp *http.Response, msg string) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	return errors.Errorf("%v: %v %v", msg, resp.Status, string(body))
+}
+
+func fileChecksum(filePath string) (int64, string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
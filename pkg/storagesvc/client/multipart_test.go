@@ -0,0 +1,139 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fission/fission/pkg/storagesvc"
+)
+
+// fakeUploads serves multipart uploads of a single file, failing the first
+// attempt of the parts in failures.
+type fakeUploads struct {
+	lock     sync.Mutex
+	upload   storagesvc.MultipartUpload
+	parts    map[int][]byte
+	failures map[int]bool
+	archive  []byte
+}
+
+func (f *fakeUploads) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/archive/uploads")
+	switch {
+	case r.Method == http.MethodPost && path == "":
+		size, _ := strconv.ParseInt(r.Header.Get("X-File-Size"), 10, 64)
+		f.upload = storagesvc.MultipartUpload{ID: "upload", Size: size, PartSize: 4, Namespace: r.URL.Query().Get("namespace")}
+		f.parts = make(map[int][]byte)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(f.upload)
+	case r.Method == http.MethodGet && path == "/upload":
+		u := f.upload
+		for number, data := range f.parts {
+			u.Parts = append(u.Parts, storagesvc.UploadedPart{Number: number, Size: int64(len(data))})
+			u.UploadedBytes += int64(len(data))
+		}
+		json.NewEncoder(w).Encode(u)
+	case r.Method == http.MethodPut && strings.HasPrefix(path, "/upload/parts/"):
+		number, _ := strconv.Atoi(strings.TrimPrefix(path, "/upload/parts/"))
+		if f.failures[number] {
+			delete(f.failures, number)
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		f.parts[number], _ = ioutil.ReadAll(r.Body)
+	case r.Method == http.MethodPost && path == "/upload/complete":
+		var archive []byte
+		for number := 1; number <= f.upload.PartCount(); number++ {
+			archive = append(archive, f.parts[number]...)
+		}
+		f.archive = archive
+		json.NewEncoder(w).Encode(storagesvc.UploadResponse{ID: "archive"})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestUploadMultipart(t *testing.T) {
+	content := []byte("multipart upload")
+	file, err := ioutil.TempFile("", "storagesvc_test_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	fake := &fakeUploads{failures: map[int]bool{2: true}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	c := MakeClient(srv.URL)
+
+	var progress []int64
+	id, err := c.UploadMultipart(context.Background(), file.Name(), "fn", func(uploaded, total int64) {
+		if total != int64(len(content)) {
+			t.Errorf("expected total of %v bytes, got %v", len(content), total)
+		}
+		progress = append(progress, uploaded)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "archive" || !bytes.Equal(fake.archive, content) {
+		t.Errorf("expected archive %q, got %v %q", content, id, fake.archive)
+	}
+	if len(progress) != 5 || progress[4] != int64(len(content)) {
+		t.Errorf("expected progress after each of the 4 parts, got %v", progress)
+	}
+	if fake.upload.Namespace != "fn" {
+		t.Errorf("expected upload in namespace fn, got %q", fake.upload.Namespace)
+	}
+
+	// uploads resume from the parts uploaded already
+	if _, err := c.StartMultipart(context.Background(), file.Name(), ""); err != nil {
+		t.Fatal(err)
+	}
+	fake.parts[1], fake.parts[2] = content[:4], content[4:8]
+	progress = nil
+	if _, err := c.ResumeMultipart(context.Background(), file.Name(), "upload", func(uploaded, total int64) {
+		progress = append(progress, uploaded)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(progress) != 3 || progress[0] != 8 {
+		t.Errorf("expected upload to resume from 8 bytes, got %v", progress)
+	}
+
+	if _, err := c.GetUpload(context.Background(), "expired"); err != ErrUploadNotFound {
+		t.Errorf("expected upload not to be found, got %v", err)
+	}
+}
@@ -0,0 +1,320 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storagesvc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"go.uber.org/zap"
+)
+
+// Archives are stored once per content: they are named after the SHA256
+// checksum of their content. Each upload gets a reference of its own to the
+// archive, whose ID is the ID of the archive followed by a random UUID, and
+// a small item next to each archive records its references. Archives are
+// only served through their references, and deleting a reference only drops
+// that reference; the archive is removed with its last reference. Archives
+// uploaded before were named with a random UUID and are deleted right away.
+const (
+	contentPrefix = "sha256-"
+	refsSuffix    = ".refs"
+	// refSeparator separates the ID of the archive from the reference in
+	// the ID of a reference.
+	refSeparator = "."
+	// refGracePeriod is how long archives are kept by the pruner after a
+	// reference was added, as the package referencing them may not be
+	// created yet.
+	refGracePeriod = time.Minute
+)
+
+// errArchiveInUse is returned when pruning an archive that was referenced
+// again less than refGracePeriod ago.
+var errArchiveInUse = errors.New("archive referenced again recently")
+
+// contentRefs are the references to an archive, with the time each was
+// added.
+type contentRefs map[string]time.Time
+
+// contentName returns the name of the archive with the given checksum.
+func contentName(subDir string, checksum string) string {
+	return path.Join(subDir, contentPrefix+checksum)
+}
+
+func baseName(id string) string {
+	return path.Base(strings.Replace(id, "\\", "/", -1))
+}
+
+func isChecksum(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil && len(s) == sha256.Size*2
+}
+
+// contentChecksum returns the checksum of a content-addressed archive from
+// the ID of its item, or false if the item is not a content-addressed
+// archive.
+func contentChecksum(itemID string) (string, bool) {
+	base := baseName(itemID)
+	if !strings.HasPrefix(base, contentPrefix) {
+		return "", false
+	}
+	checksum := strings.TrimPrefix(base, contentPrefix)
+	if !isChecksum(checksum) {
+		return "", false
+	}
+	return checksum, true
+}
+
+// parseContentRef returns the checksum of the archive and the reference
+// from the ID of a reference to a content-addressed archive, or false if
+// fileId is not one.
+func parseContentRef(fileId string) (string, string, bool) {
+	base := baseName(fileId)
+	if !strings.HasPrefix(base, contentPrefix) {
+		return "", "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(base, contentPrefix), refSeparator, 2)
+	if len(parts) != 2 || !isChecksum(parts[0]) {
+		return "", "", false
+	}
+	if _, err := uuid.FromString(parts[1]); err != nil {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// isContentItem returns true for the items of content-addressed archives
+// and of their references, which are only reached through references.
+func isContentItem(itemID string) bool {
+	return strings.HasPrefix(baseName(itemID), contentPrefix)
+}
+
+// isRefsItem returns true for the items recording the references of
+// archives, which are not archives themselves.
+func isRefsItem(itemID string) bool {
+	return isContentItem(itemID) && strings.HasSuffix(itemID, refsSuffix)
+}
+
+// archiveItemID returns the ID of the item storing the archive with the
+// given ID, which is the ID of a reference for content-addressed archives.
+func archiveItemID(fileId string) string {
+	if _, ref, ok := parseContentRef(fileId); ok {
+		return strings.TrimSuffix(fileId, refSeparator+ref)
+	}
+	return fileId
+}
+
+// item returns the item storing the file with the given ID. Content-addressed
+// archives are only returned for the ID of one of their references, so that
+// knowing the checksum of an archive doesn't give access to it.
+func (client *StowClient) item(fileId string) (stow.Item, error) {
+	if checksum, ref, ok := parseContentRef(fileId); ok {
+		name := contentName(client.config.storage.getSubDir(), checksum)
+		client.refsLock.Lock()
+		refs, err := client.getRefs(name)
+		client.refsLock.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := refs[ref]; !ok {
+			return nil, ErrNotFound
+		}
+		fileId = name
+	} else if isContentItem(fileId) {
+		return nil, ErrNotFound
+	}
+
+	item, err := client.container.Item(fileId)
+	if err != nil {
+		if err == stow.ErrNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, ErrRetrievingItem
+	}
+	return item, nil
+}
+
+// putFile stores the file under the checksum of its content, unless an
+// archive with the same content is stored already, and adds a new reference
+// to it. It returns the ID of the reference and whether the archive was
+// stored already.
+func (client *StowClient) putFile(file io.ReadSeeker, fileSize int64) (string, bool, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", false, errors.Wrap(err, "error computing checksum of file")
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", false, errors.Wrap(err, "error rewinding file")
+	}
+	checksum := hex.EncodeToString(h.Sum(nil))
+	name := contentName(client.config.storage.getSubDir(), checksum)
+	ref := uuid.NewV4().String()
+
+	client.refsLock.Lock()
+	item, err := client.container.Item(name)
+	if err == nil {
+		// the pruner doesn't delete archives referenced again recently, so
+		// the archive is kept until the package of the upload is created
+		err = client.addRef(name, ref)
+		client.refsLock.Unlock()
+		if err != nil {
+			return "", false, err
+		}
+		client.logger.Debug("archive stored already", zap.String("file", name))
+		return item.ID() + refSeparator + ref, true, nil
+	}
+	client.refsLock.Unlock()
+	if err != stow.ErrNotFound {
+		return "", false, ErrRetrievingItem
+	}
+
+	// uploads of the same content racing each other write the same bytes,
+	// and each adds a reference
+	item, err = client.container.Put(name, file, fileSize, nil)
+	if err != nil {
+		client.logger.Error("error writing file on storage",
+			zap.Error(err),
+			zap.String("file", name))
+		return "", false, ErrWritingFile
+	}
+
+	client.refsLock.Lock()
+	defer client.refsLock.Unlock()
+	if err := client.addRef(name, ref); err != nil {
+		return "", false, err
+	}
+	client.logger.Debug("successfully wrote file on storage", zap.String("file", name))
+	return item.ID() + refSeparator + ref, false, nil
+}
+
+// releaseFile drops the reference to the archive with the given ID, and
+// deletes the archive with its last reference. Other references to the
+// archive are kept, whoever deletes the reference.
+func (client *StowClient) releaseFile(fileId string) error {
+	checksum, ref, ok := parseContentRef(fileId)
+	if !ok {
+		if isContentItem(fileId) {
+			return ErrNotFound
+		}
+		return client.container.RemoveItem(fileId)
+	}
+	name := contentName(client.config.storage.getSubDir(), checksum)
+
+	client.refsLock.Lock()
+	defer client.refsLock.Unlock()
+	refs, err := client.getRefs(name)
+	if err != nil {
+		return err
+	}
+	if _, ok := refs[ref]; !ok {
+		return ErrNotFound
+	}
+	delete(refs, ref)
+	if len(refs) > 0 {
+		return client.setRefs(name, refs)
+	}
+	return client.removeContent(archiveItemID(fileId), name)
+}
+
+// pruneContent deletes a content-addressed archive and its references,
+// unless a reference was added less than refGracePeriod ago. Older
+// references may be left by packages deleted without deleting their
+// archives.
+func (client *StowClient) pruneContent(itemID string, checksum string) error {
+	name := contentName(client.config.storage.getSubDir(), checksum)
+
+	client.refsLock.Lock()
+	defer client.refsLock.Unlock()
+	refs, err := client.getRefs(name)
+	if err != nil {
+		return err
+	}
+	since := time.Now().Add(-refGracePeriod)
+	for _, added := range refs {
+		if added.After(since) {
+			return errArchiveInUse
+		}
+	}
+	return client.removeContent(itemID, name)
+}
+
+// removeContent deletes a content-addressed archive and its references.
+func (client *StowClient) removeContent(itemID string, name string) error {
+	if err := client.container.RemoveItem(itemID); err != nil {
+		return err
+	}
+	if err := client.container.RemoveItem(name + refsSuffix); err != nil && err != stow.ErrNotFound {
+		client.logger.Error("error deleting references of deleted archive", zap.Error(err), zap.String("file", name))
+	}
+	return nil
+}
+
+// addRef adds a reference to the archive.
+func (client *StowClient) addRef(name string, ref string) error {
+	refs, err := client.getRefs(name)
+	if err != nil {
+		return err
+	}
+	refs[ref] = time.Now()
+	return client.setRefs(name, refs)
+}
+
+// getRefs returns the references to the archive. Archives whose references
+// are missing or invalid have none; the archive pruner deletes them once no
+// package references them.
+func (client *StowClient) getRefs(name string) (contentRefs, error) {
+	refs := make(contentRefs)
+	item, err := client.container.Item(name + refsSuffix)
+	if err == stow.ErrNotFound {
+		return refs, nil
+	} else if err != nil {
+		return nil, ErrRetrievingItem
+	}
+	f, err := item.Open()
+	if err != nil {
+		return nil, ErrOpeningItem
+	}
+	defer f.Close()
+	err = json.NewDecoder(f).Decode(&refs)
+	if err != nil {
+		client.logger.Error("ignoring invalid references of archive", zap.Error(err), zap.String("file", name))
+		return make(contentRefs), nil
+	}
+	return refs, nil
+}
+
+// setRefs writes the references to the archive.
+func (client *StowClient) setRefs(name string, refs contentRefs) error {
+	data, err := json.Marshal(refs)
+	if err != nil {
+		return err
+	}
+	_, err = client.container.Put(name+refsSuffix, strings.NewReader(string(data)), int64(len(data)), nil)
+	if err != nil {
+		client.logger.Error("error writing references of archive", zap.Error(err), zap.String("file", name))
+		return ErrWritingFile
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storagesvc
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graymeta/stow"
+	"go.uber.org/zap"
+)
+
+func TestContentRefs(t *testing.T) {
+	checksum := strings.Repeat("ab", 32)
+	ref := "0b1c3c1e-6d8f-4a3b-9d0e-3f5f1b2c4d6e"
+	for _, test := range []struct {
+		id      string
+		ok      bool
+		isRef   bool
+		isItem  bool
+		isRefs  bool
+		archive string
+	}{
+		{"/fission/fission-functions/sha256-" + checksum, true, false, true, false, "/fission/fission-functions/sha256-" + checksum},
+		{"/fission/fission-functions/sha256-" + checksum + "." + ref, true, true, true, false, "/fission/fission-functions/sha256-" + checksum},
+		{"fission/sha256-" + checksum + refsSuffix, false, false, true, true, "fission/sha256-" + checksum + refsSuffix},
+		{"sha256-" + checksum + ".not-a-ref", false, false, true, false, "sha256-" + checksum + ".not-a-ref"},
+		{"sha256-abab", false, false, true, false, "sha256-abab"},
+		{"/fission/fission-functions/" + ref, false, false, false, false, "/fission/fission-functions/" + ref},
+	} {
+		c, ok := contentChecksum(test.id)
+		if ok != (test.ok && !test.isRef) || (ok && c != checksum) {
+			t.Errorf("contentChecksum(%q) = (%q, %v)", test.id, c, ok)
+		}
+		c, r, ok := parseContentRef(test.id)
+		if ok != test.isRef || (ok && (c != checksum || r != ref)) {
+			t.Errorf("parseContentRef(%q) = (%q, %q, %v), want ok %v", test.id, c, r, ok, test.isRef)
+		}
+		if isContentItem(test.id) != test.isItem {
+			t.Errorf("isContentItem(%q) = %v, want %v", test.id, !test.isItem, test.isItem)
+		}
+		if isRefsItem(test.id) != test.isRefs {
+			t.Errorf("isRefsItem(%q) = %v, want %v", test.id, !test.isRefs, test.isRefs)
+		}
+		if a := archiveItemID(test.id); a != test.archive {
+			t.Errorf("archiveItemID(%q) = %q, want %q", test.id, a, test.archive)
+		}
+	}
+}
+
+func TestContentStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storagesvc-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	client, err := MakeStowClient(zap.NewNop(), NewLocalStorage(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	put := func(content string) (string, bool) {
+		id, deduplicated, err := client.putFile(strings.NewReader(content), int64(len(content)))
+		if err != nil {
+			t.Fatalf("error storing file: %v", err)
+		}
+		return id, deduplicated
+	}
+	exists := func(id string) bool {
+		_, err := client.container.Item(id)
+		if err != nil && err != stow.ErrNotFound {
+			t.Fatalf("error getting item: %v", err)
+		}
+		return err == nil
+	}
+	read := func(id string) (string, error) {
+		var buf bytes.Buffer
+		err := client.copyFileToStream(id, &buf)
+		return buf.String(), err
+	}
+
+	id, deduplicated := put("vendored dependencies")
+	if deduplicated {
+		t.Error("expected first upload not to be deduplicated")
+	}
+	other, _ := put("other archive")
+	id2, deduplicated := put("vendored dependencies")
+	if !deduplicated || id2 == id || archiveItemID(id2) != archiveItemID(id) {
+		t.Fatalf("expected upload of the same content to get its own reference to archive %v, got %v", id, id2)
+	}
+	if content, err := read(id2); err != nil || content != "vendored dependencies" {
+		t.Fatalf("expected archive through its reference, got %q, %v", content, err)
+	}
+	// archives are only served through their references
+	if _, err := read(archiveItemID(id)); err != ErrNotFound {
+		t.Errorf("expected archive not to be served by its checksum, got %v", err)
+	}
+
+	// references aren't listed as archives
+	ids, err := client.getItemIDsWithFilter(func(stow.Item, interface{}) bool { return false }, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 {
+		t.Errorf("expected 2 archives stored, got %v", ids)
+	}
+
+	// a reference is only released once, and the archive isn't deleted by
+	// its checksum
+	if err := client.releaseFile(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.releaseFile(id); err != ErrNotFound {
+		t.Fatalf("expected released reference not to be found, got %v", err)
+	}
+	if err := client.releaseFile(archiveItemID(id)); err != ErrNotFound {
+		t.Fatalf("expected archive not to be deleted by its checksum, got %v", err)
+	}
+	if !exists(archiveItemID(id)) {
+		t.Fatal("expected archive to be kept while referenced")
+	}
+	if _, err := read(id); err != ErrNotFound {
+		t.Errorf("expected released reference not to serve the archive, got %v", err)
+	}
+	if err := client.releaseFile(id2); err != nil {
+		t.Fatal(err)
+	}
+	if exists(archiveItemID(id)) || exists(archiveItemID(id)+refsSuffix) {
+		t.Error("expected archive and its references to be deleted with the last reference")
+	}
+
+	// the pruner keeps archives referenced again recently, and deletes
+	// them whatever their older references
+	put("other archive")
+	if err := client.removeFileByID(archiveItemID(other)); err != errArchiveInUse {
+		t.Fatalf("expected archive referenced again recently to be kept, got %v", err)
+	}
+	name := contentName(client.config.storage.getSubDir(), strings.TrimPrefix(baseName(archiveItemID(other)), contentPrefix))
+	refs, err := client.getRefs(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ref := range refs {
+		refs[ref] = time.Now().Add(-2 * refGracePeriod)
+	}
+	if err := client.setRefs(name, refs); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.removeFileByID(archiveItemID(other)); err != nil {
+		t.Fatal(err)
+	}
+	if exists(archiveItemID(other)) || exists(archiveItemID(other)+refsSuffix) {
+		t.Error("expected pruned archive and its references to be deleted")
+	}
+}
@@ -0,0 +1,313 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package delta implements rsync-style binary deltas between archives, so that
+// a new version of a large archive can be uploaded as the changes against the
+// previously uploaded one.
+//
+// The receiver holding the base archive computes its Signature, a weak rolling
+// and a strong checksum per fixed-size block. The sender scans the new archive
+// for blocks of the base with the signature and encodes the new archive as
+// instructions to copy base blocks, interleaved with literal data. The receiver
+// rebuilds the new archive by applying the delta to the base.
+package delta
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultBlockSize is the block size of signatures if none is requested.
+	DefaultBlockSize = 16 << 10
+
+	// MinBlockSize and MaxBlockSize bound the block size of signatures.
+	MinBlockSize = 512
+	MaxBlockSize = 1 << 20
+
+	// maxLiteralSize limits the size of a single literal in a delta.
+	maxLiteralSize = 4 << 20
+
+	opCopy    = 'C'
+	opLiteral = 'L'
+	opEnd     = 'E'
+)
+
+var magic = []byte("FDELTA1\n")
+
+type (
+	// Signature describes the blocks of a base archive.
+	Signature struct {
+		BlockSize int     `json:"blockSize"`
+		Size      int64   `json:"size"`
+		Blocks    []Block `json:"blocks"`
+	}
+
+	// Block holds the checksums of a block of the base archive.
+	// Only full blocks are listed, the trailing partial block of
+	// the archive is never matched.
+	Block struct {
+		Weak   uint32 `json:"weak"`
+		Strong string `json:"strong"`
+	}
+
+	// rollingChecksum is the rsync weak checksum, which can be
+	// updated in constant time when the window moves by a byte.
+	rollingChecksum struct {
+		a, b uint32
+		n    uint32
+	}
+)
+
+// ComputeSignature reads the base archive from r and computes its signature.
+func ComputeSignature(r io.Reader, blockSize int) (*Signature, error) {
+	if blockSize < MinBlockSize || blockSize > MaxBlockSize {
+		return nil, errors.Errorf("block size must be between %v and %v", MinBlockSize, MaxBlockSize)
+	}
+
+	sig := &Signature{BlockSize: blockSize}
+	buf := make([]byte, blockSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		sig.Size += int64(n)
+		if n == blockSize {
+			sig.Blocks = append(sig.Blocks, Block{
+				Weak:   newRollingChecksum(buf).sum(),
+				Strong: strongChecksum(buf),
+			})
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return sig, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading base archive")
+		}
+	}
+}
+
+// VerifyBlock reports whether block matches the block of the base archive
+// at index. Blocks the signature doesn't list never match.
+func (sig *Signature) VerifyBlock(index int, block []byte) bool {
+	if index < 0 || index >= len(sig.Blocks) || len(block) != sig.BlockSize {
+		return false
+	}
+	return strongChecksum(block) == sig.Blocks[index].Strong
+}
+
+// ComputeDelta encodes data as a delta against the base archive with the
+// given signature and writes it to w. It returns the number of bytes of
+// data sent as literals, i.e. not found in the base.
+func ComputeDelta(sig *Signature, data []byte, w io.Writer) (int64, error) {
+	if sig.BlockSize < MinBlockSize || sig.BlockSize > MaxBlockSize {
+		return 0, errors.Errorf("invalid signature block size %v", sig.BlockSize)
+	}
+
+	enc := &encoder{w: bufio.NewWriter(w), copyStart: -1}
+	enc.write(magic)
+	enc.writeUvarint(uint64(sig.BlockSize))
+
+	index := make(map[uint32][]int, len(sig.Blocks))
+	for i, block := range sig.Blocks {
+		index[block.Weak] = append(index[block.Weak], i)
+	}
+
+	bs := sig.BlockSize
+	literalStart := 0
+	var rc *rollingChecksum
+	if len(data) >= bs {
+		rc = newRollingChecksum(data[:bs])
+	}
+
+	for i := 0; i+bs <= len(data); {
+		if candidates, ok := index[rc.sum()]; ok {
+			strong := strongChecksum(data[i : i+bs])
+			matched := -1
+			for _, c := range candidates {
+				if sig.Blocks[c].Strong == strong {
+					matched = c
+					break
+				}
+			}
+			if matched >= 0 {
+				enc.literal(data[literalStart:i])
+				enc.copyBlock(matched)
+				i += bs
+				literalStart = i
+				if i+bs <= len(data) {
+					rc = newRollingChecksum(data[i : i+bs])
+				}
+				continue
+			}
+		}
+		if i+bs < len(data) {
+			rc.roll(data[i], data[i+bs])
+		}
+		i++
+	}
+	enc.literal(data[literalStart:])
+	enc.flushCopy()
+	enc.write([]byte{opEnd})
+
+	if enc.err != nil {
+		return 0, enc.err
+	}
+	return enc.literalBytes, enc.w.Flush()
+}
+
+// ApplyDelta rebuilds an archive by applying the delta read from r to the
+// base archive, and writes it to w.
+func ApplyDelta(base io.ReaderAt, r io.Reader, w io.Writer) error {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(br, header); err != nil || string(header) != string(magic) {
+		return errors.New("not a delta")
+	}
+	blockSize, err := binary.ReadUvarint(br)
+	if err != nil {
+		return errors.Wrap(err, "error reading delta header")
+	}
+	if blockSize < MinBlockSize || blockSize > MaxBlockSize {
+		return errors.Errorf("invalid delta block size %v", blockSize)
+	}
+
+	for {
+		op, err := br.ReadByte()
+		if err != nil {
+			return errors.Wrap(err, "error reading delta")
+		}
+
+		switch op {
+		case opCopy:
+			start, err := binary.ReadUvarint(br)
+			if err != nil {
+				return errors.Wrap(err, "error reading delta")
+			}
+			count, err := binary.ReadUvarint(br)
+			if err != nil {
+				return errors.Wrap(err, "error reading delta")
+			}
+			size := int64(count * blockSize)
+			n, err := io.Copy(w, io.NewSectionReader(base, int64(start*blockSize), size))
+			if err != nil {
+				return errors.Wrap(err, "error copying base blocks")
+			}
+			if n != size {
+				return errors.Errorf("delta refers to blocks %v-%v beyond the end of the base archive", start, start+count-1)
+			}
+		case opLiteral:
+			size, err := binary.ReadUvarint(br)
+			if err != nil {
+				return errors.Wrap(err, "error reading delta")
+			}
+			if size > maxLiteralSize {
+				return errors.Errorf("delta literal of %v bytes exceeds limit", size)
+			}
+			if _, err = io.CopyN(w, br, int64(size)); err != nil {
+				return errors.Wrap(err, "error reading delta literal")
+			}
+		case opEnd:
+			return nil
+		default:
+			return errors.Errorf("unknown delta operation %q", op)
+		}
+	}
+}
+
+// encoder writes delta operations, merging copies of consecutive blocks.
+type encoder struct {
+	w            *bufio.Writer
+	err          error
+	copyStart    int
+	copyCount    int
+	literalBytes int64
+}
+
+func (e *encoder) write(p []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(p)
+	}
+}
+
+func (e *encoder) writeUvarint(v uint64) {
+	buf := make([]byte, binary.MaxVarintLen64)
+	e.write(buf[:binary.PutUvarint(buf, v)])
+}
+
+func (e *encoder) copyBlock(block int) {
+	if e.copyStart >= 0 && e.copyStart+e.copyCount == block {
+		e.copyCount++
+		return
+	}
+	e.flushCopy()
+	e.copyStart, e.copyCount = block, 1
+}
+
+func (e *encoder) flushCopy() {
+	if e.copyStart < 0 {
+		return
+	}
+	e.write([]byte{opCopy})
+	e.writeUvarint(uint64(e.copyStart))
+	e.writeUvarint(uint64(e.copyCount))
+	e.copyStart, e.copyCount = -1, 0
+}
+
+func (e *encoder) literal(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	e.flushCopy()
+	e.literalBytes += int64(len(data))
+	for len(data) > 0 {
+		n := len(data)
+		if n > maxLiteralSize {
+			n = maxLiteralSize
+		}
+		e.write([]byte{opLiteral})
+		e.writeUvarint(uint64(n))
+		e.write(data[:n])
+		data = data[n:]
+	}
+}
+
+func strongChecksum(block []byte) string {
+	sum := sha256.Sum256(block)
+	return hex.EncodeToString(sum[:16])
+}
+
+func newRollingChecksum(block []byte) *rollingChecksum {
+	rc := &rollingChecksum{n: uint32(len(block))}
+	for i, c := range block {
+		rc.a += uint32(c)
+		rc.b += uint32(len(block)-i) * uint32(c)
+	}
+	return rc
+}
+
+func (rc *rollingChecksum) roll(out byte, in byte) {
+	rc.a += uint32(in) - uint32(out)
+	rc.b += rc.a - rc.n*uint32(out)
+}
+
+func (rc *rollingChecksum) sum() uint32 {
+	return rc.a&0xffff | rc.b<<16
+}
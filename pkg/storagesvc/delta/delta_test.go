@@ -0,0 +1,122 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package delta
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func randomBytes(r *rand.Rand, n int) []byte {
+	b := make([]byte, n)
+	r.Read(b)
+	return b
+}
+
+func TestDeltaRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	base := randomBytes(r, 200*1024+123)
+
+	var inserted []byte
+	inserted = append(inserted, base[:50000]...)
+	inserted = append(inserted, []byte("some new code")...)
+	inserted = append(inserted, base[50000:]...)
+
+	tests := []struct {
+		name       string
+		data       []byte
+		maxLiteral int64
+	}{
+		{name: "unchanged", data: base, maxLiteral: int64(len(base) % MinBlockSize)},
+		{name: "insertion", data: inserted, maxLiteral: 2*MinBlockSize + 13},
+		{name: "appended", data: append(append([]byte{}, base...), randomBytes(r, 1000)...), maxLiteral: 1000 + MinBlockSize},
+		{name: "unrelated", data: randomBytes(r, 10000), maxLiteral: 10000},
+		{name: "empty", data: []byte{}, maxLiteral: 0},
+	}
+
+	sig, err := ComputeSignature(bytes.NewReader(base), MinBlockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig.Size != int64(len(base)) || len(sig.Blocks) != len(base)/MinBlockSize {
+		t.Fatalf("unexpected signature size %v with %v blocks", sig.Size, len(sig.Blocks))
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta := &bytes.Buffer{}
+			literal, err := ComputeDelta(sig, tt.data, delta)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if literal > tt.maxLiteral {
+				t.Errorf("expected at most %v literal bytes, got %v", tt.maxLiteral, literal)
+			}
+
+			result := &bytes.Buffer{}
+			err = ApplyDelta(bytes.NewReader(base), delta, result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(result.Bytes(), tt.data) {
+				t.Errorf("applied delta doesn't reproduce data")
+			}
+		})
+	}
+}
+
+func TestApplyDeltaErrors(t *testing.T) {
+	base := bytes.Repeat([]byte("x"), MinBlockSize)
+
+	for name, delta := range map[string][]byte{
+		"bad magic":      []byte("NOTDELTA"),
+		"truncated":      append(append([]byte{}, magic...), 0x80, 0x04, opLiteral, 10, 'a'),
+		"copy past base": append(append([]byte{}, magic...), 0x80, 0x04, opCopy, 1, 1, opEnd),
+		"unknown op":     append(append([]byte{}, magic...), 0x80, 0x04, 'X'),
+	} {
+		if err := ApplyDelta(bytes.NewReader(base), bytes.NewReader(delta), &bytes.Buffer{}); err == nil {
+			t.Errorf("%v: expected error", name)
+		}
+	}
+}
+
+func TestVerifyBlock(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	data := randomBytes(r, 3*MinBlockSize+100)
+	sig, err := ComputeSignature(bytes.NewReader(data), MinBlockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if !sig.VerifyBlock(i, data[i*MinBlockSize:(i+1)*MinBlockSize]) {
+			t.Errorf("expected block %v to match", i)
+		}
+	}
+	if sig.VerifyBlock(1, data[:MinBlockSize]) {
+		t.Error("expected block of another index not to match")
+	}
+	corrupt := append([]byte{}, data[:MinBlockSize]...)
+	corrupt[10] ^= 1
+	if sig.VerifyBlock(0, corrupt) {
+		t.Error("expected corrupt block not to match")
+	}
+	if sig.VerifyBlock(3, data[3*MinBlockSize:]) {
+		t.Error("expected trailing partial block not to match")
+	}
+}
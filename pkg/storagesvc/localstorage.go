@@ -5,7 +5,6 @@ import (
 
 	"github.com/graymeta/stow"
 	_ "github.com/graymeta/stow/local"
-	uuid "github.com/satori/go.uuid"
 )
 
 type localStorage struct {
@@ -15,7 +14,7 @@ type localStorage struct {
 }
 
 // NewLocalStorage return new local storage struct
-func NewLocalStorage(localPath string) Storage {
+func NewLocalStorage(localPath string) StorageBackend {
 	subdir := os.Getenv("SUBDIR")
 	if len(subdir) == 0 {
 		subdir = "fission-functions"
@@ -36,10 +35,8 @@ func (ls localStorage) SetLocalPath(path string) {
 	ls.localPath = path
 }
 
-func (ls localStorage) getUploadFileName() string {
-	// This is not the item ID (that's returned by Put)
-	// should we just use handler.Filename? what are the constraints here?
-	return uuid.NewV4().String()
+func (ls localStorage) getSubDir() string {
+	return ""
 }
 
 func (ls localStorage) getContainerName() string {
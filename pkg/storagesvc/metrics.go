@@ -0,0 +1,100 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storagesvc
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	archivesStored = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fission_archives_stored",
+			Help: "How many archives were in storage at the last pruning, except those just uploaded.",
+		},
+	)
+	archivesReferenced = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fission_archives_referenced",
+			Help: "How many archives were referenced by packages, tombstones, package revisions and cached builds at the last pruning.",
+		},
+	)
+	archivesOrphaned = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fission_archives_orphaned",
+			Help: "How many archives were found unreferenced at the last pruning, deleted unless in dry run mode.",
+		},
+	)
+	// result: deleted, kept or failed
+	archivesPruned = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fission_archives_pruned_total",
+			Help: "How many unreferenced archives were deleted, kept as referenced again recently, or failed to be deleted.",
+		},
+		[]string{"result"},
+	)
+	// result: succeeded or failed
+	archivePrunerRuns = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fission_archive_pruner_runs_total",
+			Help: "How many times the archive pruner looked for unreferenced archives.",
+		},
+		[]string{"result"},
+	)
+	// deduplicated: true or false
+	archivesUploaded = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fission_archives_uploaded_total",
+			Help: "How many archives were uploaded, and whether an archive with the same content was stored already.",
+		},
+		[]string{"deduplicated"},
+	)
+	archiveBytesDeduplicated = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "fission_archive_bytes_deduplicated_total",
+			Help: "How many bytes of uploaded archives were not stored again, as archives with the same content were stored already.",
+		},
+	)
+	archivePrunerLastRun = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fission_archive_pruner_last_run_timestamp_seconds",
+			Help: "When the archive pruner last looked for unreferenced archives successfully.",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(archivesStored)
+	prometheus.MustRegister(archivesReferenced)
+	prometheus.MustRegister(archivesOrphaned)
+	prometheus.MustRegister(archivesPruned)
+	prometheus.MustRegister(archivePrunerRuns)
+	prometheus.MustRegister(archivePrunerLastRun)
+	prometheus.MustRegister(archivesUploaded)
+	prometheus.MustRegister(archiveBytesDeduplicated)
+}
+
+// countUpload counts an uploaded archive, and its bytes not stored again if
+// it was deduplicated.
+func countUpload(deduplicated bool, size int64) {
+	archivesUploaded.WithLabelValues(strconv.FormatBool(deduplicated)).Inc()
+	if deduplicated {
+		archiveBytesDeduplicated.Add(float64(size))
+	}
+}
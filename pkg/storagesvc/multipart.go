@@ -0,0 +1,456 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storagesvc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultPartSize is the size of the parts of multipart uploads, unless
+	// the client asks for another one.
+	DefaultPartSize int64 = 8 << 20
+	// MaxParts is the max number of parts of a multipart upload.
+	MaxParts = 10000
+
+	// DefaultUploadTTL is how long unfinished multipart uploads are kept
+	// after they were started, for clients to resume them.
+	DefaultUploadTTL = 24 * time.Hour
+
+	uploadFile = "upload.json"
+	partPrefix = "part-"
+)
+
+type (
+	// MultipartUpload is an archive uploaded in parts, which clients
+	// upload in any order and retry until all are uploaded. It tells the
+	// parts uploaded so far, for clients to report the progress of the
+	// upload and resume it.
+	MultipartUpload struct {
+		ID       string `json:"id"`
+		Size     int64  `json:"size"`
+		PartSize int64  `json:"partSize"`
+		// Checksum is the SHA256 checksum of the archive, checked once
+		// all parts are uploaded if set.
+		Checksum string `json:"checksum,omitempty"`
+		// Namespace is the namespace the upload was started in, which
+		// the controller authorizes the requests of the upload in.
+		Namespace string    `json:"namespace,omitempty"`
+		StartedAt time.Time `json:"startedAt"`
+
+		Parts         []UploadedPart `json:"parts"`
+		UploadedBytes int64          `json:"uploadedBytes"`
+	}
+
+	// UploadedPart is a part of a multipart upload, numbered from 1.
+	UploadedPart struct {
+		Number int   `json:"number"`
+		Size   int64 `json:"size"`
+	}
+
+	// uploadManager keeps the parts of multipart uploads in a directory per
+	// upload until they are complete, so that they survive failed requests
+	// and restarts of the storage service if the directory is persistent.
+	uploadManager struct {
+		logger        *zap.Logger
+		dir           string
+		ttl           time.Duration
+		storageClient *StowClient
+
+		// lock prevents uploads from being completed and aborted at the
+		// same time
+		lock sync.Mutex
+	}
+)
+
+// PartCount returns the number of parts of the upload.
+func (u *MultipartUpload) PartCount() int {
+	if u.PartSize <= 0 {
+		return 0
+	}
+	return int((u.Size + u.PartSize - 1) / u.PartSize)
+}
+
+// PartLength returns the size of the part with the given number, of which
+// only the last one may be smaller than the part size.
+func (u *MultipartUpload) PartLength(number int) int64 {
+	if number < u.PartCount() {
+		return u.PartSize
+	}
+	return u.Size - int64(number-1)*u.PartSize
+}
+
+func makeUploadManager(logger *zap.Logger, storageClient *StowClient, dir string, ttl time.Duration) (*uploadManager, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrapf(err, "error creating multipart upload directory %v", dir)
+	}
+	return &uploadManager{
+		logger:        logger.Named("multipart_upload"),
+		dir:           dir,
+		ttl:           ttl,
+		storageClient: storageClient,
+	}, nil
+}
+
+func (m *uploadManager) uploadDir(id string) (string, error) {
+	// IDs are UUIDs, don't let them escape the upload directory
+	if _, err := uuid.FromString(id); err != nil {
+		return "", ErrNotFound
+	}
+	return filepath.Join(m.dir, id), nil
+}
+
+// start starts a multipart upload of an archive of the given size.
+func (m *uploadManager) start(size int64, partSize int64, checksum string, namespace string) (*MultipartUpload, error) {
+	if size <= 0 {
+		return nil, errors.New("archive size must be positive")
+	}
+	if partSize <= 0 {
+		partSize = DefaultPartSize
+	}
+	u := &MultipartUpload{
+		ID:        uuid.NewV4().String(),
+		Size:      size,
+		PartSize:  partSize,
+		Checksum:  strings.ToLower(checksum),
+		Namespace: namespace,
+		StartedAt: time.Now().UTC(),
+		Parts:     []UploadedPart{},
+	}
+	if u.PartCount() > MaxParts {
+		return nil, errors.Errorf("archive of %v bytes needs more than %v parts of %v bytes", size, MaxParts, partSize)
+	}
+
+	dir, _ := m.uploadDir(u.ID)
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "error creating upload directory")
+	}
+	data, err := json.Marshal(u)
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, uploadFile), data, 0600); err != nil {
+		os.RemoveAll(dir)
+		return nil, errors.Wrap(err, "error writing upload")
+	}
+	return u, nil
+}
+
+// get returns the upload with the parts uploaded so far.
+func (m *uploadManager) get(id string) (*MultipartUpload, error) {
+	dir, err := m.uploadDir(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, uploadFile))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "error reading upload")
+	}
+	u := &MultipartUpload{}
+	if err := json.Unmarshal(data, u); err != nil {
+		return nil, errors.Wrap(err, "error decoding upload")
+	}
+
+	u.Parts = []UploadedPart{}
+	for number := 1; number <= u.PartCount(); number++ {
+		fi, err := os.Stat(filepath.Join(dir, partPrefix+strconv.Itoa(number)))
+		if err != nil {
+			continue
+		}
+		u.Parts = append(u.Parts, UploadedPart{Number: number, Size: fi.Size()})
+		u.UploadedBytes += fi.Size()
+	}
+	return u, nil
+}
+
+// putPart writes a part of the upload. Parts uploaded again replace the
+// previous ones, so that failed parts can be retried.
+func (m *uploadManager) putPart(id string, number int, r io.Reader, checksum string) error {
+	u, err := m.get(id)
+	if err != nil {
+		return err
+	}
+	if number < 1 || number > u.PartCount() {
+		return errors.Errorf("part number must be between 1 and %v", u.PartCount())
+	}
+	dir, _ := m.uploadDir(id)
+
+	tmp, err := ioutil.TempFile(dir, "tmp-")
+	if err != nil {
+		return errors.Wrap(err, "error creating part")
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+	// read one more byte than expected to catch oversized parts
+	expected := u.PartLength(number)
+	n, err := io.Copy(io.MultiWriter(tmp, h), io.LimitReader(r, expected+1))
+	if err != nil {
+		return errors.Wrap(err, "error writing part")
+	}
+	if n != expected {
+		return errors.Errorf("part %v must be %v bytes, got %v", number, expected, n)
+	}
+	if len(checksum) > 0 && !strings.EqualFold(hex.EncodeToString(h.Sum(nil)), checksum) {
+		return errors.Errorf("part %v doesn't match its checksum", number)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "error writing part")
+	}
+	// parts are either entirely uploaded or missing
+	return os.Rename(tmp.Name(), filepath.Join(dir, partPrefix+strconv.Itoa(number)))
+}
+
+// complete assembles the parts of the upload into the archive, stores it
+// and ends the upload.
+func (m *uploadManager) complete(id string) (string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	u, err := m.get(id)
+	if err != nil {
+		return "", err
+	}
+	if len(u.Parts) != u.PartCount() {
+		return "", errors.Errorf("%v of %v parts uploaded", len(u.Parts), u.PartCount())
+	}
+	dir, _ := m.uploadDir(id)
+
+	archive, err := ioutil.TempFile(dir, "archive-")
+	if err != nil {
+		return "", errors.Wrap(err, "error creating archive")
+	}
+	defer removeTempFile(archive)
+
+	h := sha256.New()
+	for number := 1; number <= u.PartCount(); number++ {
+		if err := appendFile(io.MultiWriter(archive, h), filepath.Join(dir, partPrefix+strconv.Itoa(number))); err != nil {
+			return "", errors.Wrapf(err, "error assembling part %v", number)
+		}
+	}
+	if len(u.Checksum) > 0 && hex.EncodeToString(h.Sum(nil)) != u.Checksum {
+		return "", errors.New("archive assembled from the parts doesn't match its checksum")
+	}
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return "", errors.Wrap(err, "error reading archive")
+	}
+
+	fileID, deduplicated, err := m.storageClient.putFile(archive, u.Size)
+	if err != nil {
+		return "", err
+	}
+	countUpload(deduplicated, u.Size)
+	if err := os.RemoveAll(dir); err != nil {
+		m.logger.Error("error removing parts of completed upload", zap.Error(err), zap.String("upload_id", id))
+	}
+	return fileID, nil
+}
+
+// abort ends the upload and removes its parts.
+func (m *uploadManager) abort(id string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	dir, err := m.uploadDir(id)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	return os.RemoveAll(dir)
+}
+
+// removeExpired removes the uploads started longer than the TTL ago.
+func (m *uploadManager) removeExpired(now time.Time) {
+	entries, err := ioutil.ReadDir(m.dir)
+	if err != nil {
+		m.logger.Error("error listing multipart uploads", zap.Error(err))
+		return
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		u, err := m.get(e.Name())
+		// uploads left without a record can't be resumed
+		if err == nil && now.Sub(u.StartedAt) < m.ttl {
+			continue
+		}
+		if err != nil && now.Sub(e.ModTime()) < m.ttl {
+			continue
+		}
+		m.logger.Info("removing expired multipart upload", zap.String("upload_id", e.Name()))
+		m.lock.Lock()
+		err = os.RemoveAll(filepath.Join(m.dir, e.Name()))
+		m.lock.Unlock()
+		if err != nil {
+			m.logger.Error("error removing expired multipart upload", zap.Error(err), zap.String("upload_id", e.Name()))
+		}
+	}
+}
+
+// run removes expired uploads until the process exits.
+func (m *uploadManager) run() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		m.removeExpired(now)
+	}
+}
+
+func appendFile(w io.Writer, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// startUploadHandler starts a multipart upload of an archive of the size in
+// the "X-File-Size" header, with parts of the size in the "partSize" query
+// param. The archive is checked against the "X-File-Checksum" header, if
+// set, once complete. The upload belongs to the namespace in the
+// "namespace" query param, the default one if unset.
+func (ss *StorageService) startUploadHandler(w http.ResponseWriter, r *http.Request) {
+	fileSize, err := ss.getFileSize(r, "multipart upload")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var partSize int64
+	if ps := r.URL.Query().Get("partSize"); len(ps) > 0 {
+		partSize, err = strconv.ParseInt(ps, 10, 64)
+		if err != nil {
+			http.Error(w, "bad `partSize' query param", http.StatusBadRequest)
+			return
+		}
+	}
+
+	namespace := r.URL.Query().Get("namespace")
+	if len(namespace) == 0 {
+		namespace = metav1.NamespaceDefault
+	}
+
+	u, err := ss.uploads.start(fileSize, partSize, r.Header.Get("X-File-Checksum"), namespace)
+	if err != nil {
+		ss.logger.Error("error starting multipart upload", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ss.logger.Debug("started multipart upload",
+		zap.String("upload_id", u.ID),
+		zap.Int64("size", u.Size),
+		zap.Int("parts", u.PartCount()))
+	ss.writeUpload(w, http.StatusCreated, u)
+}
+
+// getUploadHandler returns the upload with its parts uploaded so far.
+func (ss *StorageService) getUploadHandler(w http.ResponseWriter, r *http.Request) {
+	u, err := ss.uploads.get(mux.Vars(r)["id"])
+	if err != nil {
+		ss.writeUploadError(w, err)
+		return
+	}
+	ss.writeUpload(w, http.StatusOK, u)
+}
+
+// putPartHandler uploads a part of an upload, checked against the
+// "X-Part-Checksum" header if set.
+func (ss *StorageService) putPartHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	number, err := strconv.Atoi(mux.Vars(r)["number"])
+	if err != nil {
+		http.Error(w, "bad part number", http.StatusBadRequest)
+		return
+	}
+	err = ss.uploads.putPart(id, number, r.Body, r.Header.Get("X-Part-Checksum"))
+	if err != nil {
+		ss.logger.Error("error uploading part", zap.Error(err), zap.String("upload_id", id), zap.Int("part", number))
+		ss.writeUploadError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// completeUploadHandler stores the archive assembled from the parts of an
+// upload, and responds like a single request upload.
+func (ss *StorageService) completeUploadHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	fileID, err := ss.uploads.complete(id)
+	if err != nil {
+		ss.logger.Error("error completing multipart upload", zap.Error(err), zap.String("upload_id", id))
+		ss.writeUploadError(w, err)
+		return
+	}
+	ss.writeUploadResponse(w, fileID, id)
+}
+
+// abortUploadHandler ends an upload without storing its archive.
+func (ss *StorageService) abortUploadHandler(w http.ResponseWriter, r *http.Request) {
+	if err := ss.uploads.abort(mux.Vars(r)["id"]); err != nil {
+		ss.writeUploadError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ss *StorageService) writeUpload(w http.ResponseWriter, status int, u *MultipartUpload) {
+	resp, err := json.Marshal(u)
+	if err != nil {
+		http.Error(w, "Error marshaling response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(resp); err != nil {
+		ss.logger.Error("error writing HTTP response", zap.Error(err), zap.String("upload_id", u.ID))
+	}
+}
+
+func (ss *StorageService) writeUploadError(w http.ResponseWriter, err error) {
+	switch err {
+	case ErrNotFound:
+		http.Error(w, "Upload not found", http.StatusNotFound)
+	case ErrWritingFile, ErrRetrievingItem:
+		http.Error(w, "Error saving archive", http.StatusInternalServerError)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
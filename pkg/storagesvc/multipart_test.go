@@ -0,0 +1,135 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storagesvc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestMultipartUpload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storagesvc-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	client, err := MakeStowClient(zap.NewNop(), NewLocalStorage(filepath.Join(dir, "archives")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := makeUploadManager(zap.NewNop(), client, filepath.Join(dir, "uploads"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := "0123456789"
+	sum := sha256.Sum256([]byte(content))
+	u, err := m.start(int64(len(content)), 4, hex.EncodeToString(sum[:]), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.PartCount() != 3 || u.PartLength(3) != 2 {
+		t.Fatalf("expected 3 parts, the last of 2 bytes, got %v parts", u.PartCount())
+	}
+
+	if err := m.putPart(u.ID, 2, strings.NewReader("45678"), ""); err == nil {
+		t.Error("expected oversized part to be rejected")
+	}
+	if err := m.putPart(u.ID, 4, strings.NewReader("89"), ""); err == nil {
+		t.Error("expected part out of the upload to be rejected")
+	}
+	if err := m.putPart(u.ID, 3, strings.NewReader("89"), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	progress, err := m.get(u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(progress.Parts) != 1 || progress.Parts[0].Number != 3 || progress.UploadedBytes != 2 {
+		t.Errorf("expected part 3 uploaded, got %+v", progress)
+	}
+	if _, err := m.complete(u.ID); err == nil {
+		t.Fatal("expected upload with missing parts not to complete")
+	}
+
+	partSum := sha256.Sum256([]byte("0123"))
+	if err := m.putPart(u.ID, 1, strings.NewReader("0123"), "00"); err == nil {
+		t.Error("expected part not matching its checksum to be rejected")
+	}
+	if err := m.putPart(u.ID, 1, strings.NewReader("0123"), hex.EncodeToString(partSum[:])); err != nil {
+		t.Fatal(err)
+	}
+	// failed parts are uploaded again
+	if err := m.putPart(u.ID, 2, strings.NewReader("xxxx"), ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.putPart(u.ID, 2, strings.NewReader("4567"), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	fileID, err := m.complete(u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := client.copyFileToStream(fileID, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != content {
+		t.Errorf("expected archive %q, got %q", content, buf.String())
+	}
+	if _, err := m.get(u.ID); err != ErrNotFound {
+		t.Errorf("expected completed upload to be removed, got %v", err)
+	}
+
+	// uploads not matching their checksum aren't stored
+	u, err = m.start(4, 4, hex.EncodeToString(sum[:]), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.putPart(u.ID, 1, strings.NewReader("0123"), ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.complete(u.ID); err == nil {
+		t.Error("expected archive not matching its checksum to be rejected")
+	}
+
+	// unfinished uploads expire
+	m.removeExpired(time.Now().Add(30 * time.Minute))
+	if _, err := m.get(u.ID); err != nil {
+		t.Errorf("expected upload to be kept until it expires, got %v", err)
+	}
+	m.removeExpired(time.Now().Add(2 * time.Hour))
+	if _, err := m.get(u.ID); err != ErrNotFound {
+		t.Errorf("expected expired upload to be removed, got %v", err)
+	}
+
+	if _, err := m.get("../archives"); err != ErrNotFound {
+		t.Errorf("expected invalid upload ID to be rejected, got %v", err)
+	}
+}
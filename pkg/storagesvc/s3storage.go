@@ -2,14 +2,21 @@ package storagesvc
 
 import (
 	"os"
-	"path"
+	"strconv"
 
 	"github.com/graymeta/stow"
 	"github.com/graymeta/stow/s3"
-	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// gcsEndpoint is the S3 compatible endpoint of GCS, which takes the
+	// HMAC keys of service accounts.
+	gcsEndpoint = "storage.googleapis.com"
 )
 
 type (
+	// s3Storage stores archives in S3 or a store compatible with it, like
+	// MinIO or GCS.
 	s3Storage struct {
 		storageType     StorageType
 		endpoint        string
@@ -18,11 +25,12 @@ type (
 		accessKeyID     string
 		secretAccessKey string
 		region          string
+		disableSSL      bool
 	}
 )
 
 // NewS3Storage returns a new s3 storage struct
-func NewS3Storage(args ...string) Storage {
+func NewS3Storage(args ...string) StorageBackend {
 	endpoint := os.Getenv("STORAGE_S3_ENDPOINT")
 	bucketName := os.Getenv("STORAGE_S3_BUCKET_NAME")
 	subDir := os.Getenv("STORAGE_S3_SUB_DIR")
@@ -30,6 +38,13 @@ func NewS3Storage(args ...string) Storage {
 	secretAccessKey := os.Getenv("STORAGE_S3_SECRET_ACCESS_KEY")
 	region := os.Getenv("STORAGE_S3_REGION")
 
+	// SSL used to be always disabled, which the in-cluster stores this
+	// was meant for don't serve
+	disableSSL, err := strconv.ParseBool(os.Getenv("STORAGE_S3_DISABLE_SSL"))
+	if err != nil {
+		disableSSL = true
+	}
+
 	return s3Storage{
 		endpoint:        endpoint,
 		storageType:     StorageTypeS3,
@@ -38,6 +53,33 @@ func NewS3Storage(args ...string) Storage {
 		accessKeyID:     accessKeyID,
 		secretAccessKey: secretAccessKey,
 		region:          region,
+		disableSSL:      disableSSL,
+	}
+}
+
+// NewMinioStorage returns a storage for a MinIO server, configured like
+// S3 storage with the endpoint of the server.
+func NewMinioStorage() StorageBackend {
+	storage := NewS3Storage().(s3Storage)
+	storage.storageType = StorageTypeMinio
+	if len(storage.region) == 0 {
+		// MinIO ignores the region, but the SDK requires one
+		storage.region = "us-east-1"
+	}
+	return storage
+}
+
+// NewGCSStorage returns a storage for a GCS bucket, accessed through its
+// S3 compatible API with the HMAC key of a service account.
+func NewGCSStorage() StorageBackend {
+	return s3Storage{
+		storageType:     StorageTypeGCS,
+		endpoint:        gcsEndpoint,
+		bucketName:      os.Getenv("STORAGE_GCS_BUCKET_NAME"),
+		subDir:          os.Getenv("STORAGE_GCS_SUB_DIR"),
+		accessKeyID:     os.Getenv("STORAGE_GCS_ACCESS_KEY_ID"),
+		secretAccessKey: os.Getenv("STORAGE_GCS_SECRET_ACCESS_KEY"),
+		region:          "auto",
 	}
 }
 
@@ -49,9 +91,8 @@ func (ss s3Storage) getContainerName() string {
 	return ss.bucketName
 }
 
-func (ss s3Storage) getUploadFileName() string {
-	uploadName := uuid.NewV4().String()
-	return path.Join(ss.subDir, uploadName)
+func (ss s3Storage) getSubDir() string {
+	return ss.subDir
 }
 
 func (ss s3Storage) dial() (stow.Location, error) {
@@ -61,7 +102,7 @@ func (ss s3Storage) dial() (stow.Location, error) {
 		s3.ConfigAccessKeyID: ss.accessKeyID,
 		s3.ConfigSecretKey:   ss.secretAccessKey,
 		s3.ConfigRegion:      ss.region,
-		s3.ConfigDisableSSL:  "true",
+		s3.ConfigDisableSSL:  strconv.FormatBool(ss.disableSSL),
 	}
 	return stow.Dial(kind, config)
 }
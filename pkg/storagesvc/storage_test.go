@@ -53,3 +53,64 @@ func TestNewLocalStorage(t *testing.T) {
 		t.Errorf("Incorrect storageType field. Got: %s, Want %s", storage.storageType, StorageTypeLocal)
 	}
 }
+
+func TestParseByteRange(t *testing.T) {
+	tests := []struct {
+		header         string
+		offset, length int64
+		ok             bool
+	}{
+		{"bytes=0-99", 0, 100, true},
+		{"bytes=100-199", 100, 100, true},
+		{"bytes=900-", 900, 100, true},
+		{"bytes=900-5000", 900, 100, true},
+		{"bytes=-10", 990, 10, true},
+		{"bytes=-5000", 0, 1000, true},
+		{"bytes=1000-", 0, 0, false},
+		{"bytes=10-5", 0, 0, false},
+		{"bytes=0-9,20-29", 0, 0, false},
+		{"bytes=-0", 0, 0, false},
+		{"bytes=a-b", 0, 0, false},
+		{"items=0-9", 0, 0, false},
+	}
+	for _, test := range tests {
+		offset, length, err := parseByteRange(test.header, 1000)
+		if (err == nil) != test.ok {
+			t.Errorf("parseByteRange(%q): unexpected error %v", test.header, err)
+			continue
+		}
+		if test.ok && (offset != test.offset || length != test.length) {
+			t.Errorf("parseByteRange(%q) = (%v, %v), want (%v, %v)", test.header, offset, length, test.offset, test.length)
+		}
+	}
+}
+
+func TestMakeStorageBackend(t *testing.T) {
+	os.Setenv("STORAGE_GCS_BUCKET_NAME", "archives")
+	os.Setenv("STORAGE_AZURE_CONTAINER_NAME", "archives")
+	os.Setenv("STORAGE_AZURE_SUB_DIR", "fission")
+
+	for _, storageType := range []StorageType{StorageTypeLocal, StorageTypeS3, StorageTypeMinio, StorageTypeGCS, StorageTypeAzure} {
+		storage, err := MakeStorageBackend(storageType, "/fission")
+		if err != nil {
+			t.Errorf("Unexpected error making %s storage: %v", storageType, err)
+			continue
+		}
+		if storage.getStorageType() != storageType {
+			t.Errorf("Incorrect storage type. Got: %s, Want %s", storage.getStorageType(), storageType)
+		}
+	}
+
+	gcs := NewGCSStorage().(s3Storage)
+	if gcs.endpoint != gcsEndpoint || gcs.disableSSL || gcs.getContainerName() != "archives" {
+		t.Errorf("Incorrect gcs storage %+v", gcs)
+	}
+	azure := NewAzureStorage().(azureStorage)
+	if azure.getContainerName() != "archives" || azure.getSubDir() != "fission" {
+		t.Errorf("Incorrect azure storage %+v", azure)
+	}
+
+	if _, err := MakeStorageBackend("ftp", "/fission"); err == nil {
+		t.Error("Expected unknown storage type to be rejected")
+	}
+}
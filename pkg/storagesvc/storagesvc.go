@@ -17,28 +17,40 @@ limitations under the License.
 package storagesvc
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/graymeta/stow"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opencensus.io/plugin/ochttp"
 	"go.uber.org/zap"
+
+	"github.com/fission/fission/pkg/storagesvc/delta"
+	"github.com/fission/fission/pkg/tracing"
 )
 
 type (
-	// Storage is an interface to force storage level details implementation.
-	Storage interface {
+	// StorageBackend is where the storage service keeps archives. Backends
+	// configure a stow location, which the stow client reads and writes
+	// archives through.
+	StorageBackend interface {
 		getStorageType() StorageType
 		dial() (stow.Location, error)
-		// getSubDir() string
+		// getSubDir is the directory archives are kept in, so that
+		// buckets can be shared with other data.
+		getSubDir() string
 		getContainerName() string
-		getUploadFileName() string
 	}
 
 	// StorageService is a struct to hold all things for storage service
@@ -46,6 +58,8 @@ type (
 		logger        *zap.Logger
 		storageClient *StowClient
 		port          int
+		// uploads keeps the parts of multipart uploads until complete
+		uploads *uploadManager
 	}
 
 	UploadResponse struct {
@@ -53,9 +67,23 @@ type (
 	}
 )
 
-// Functions handling storage interface
-func getStorageType(storage Storage) string {
-	return string(storage.getStorageType())
+// MakeStorageBackend returns the backend of the storage type, configured
+// from the environment. Local storage keeps archives under localPath.
+func MakeStorageBackend(storageType StorageType, localPath string) (StorageBackend, error) {
+	switch storageType {
+	case StorageTypeLocal:
+		return NewLocalStorage(localPath), nil
+	case StorageTypeS3:
+		return NewS3Storage(), nil
+	case StorageTypeMinio:
+		return NewMinioStorage(), nil
+	case StorageTypeGCS:
+		return NewGCSStorage(), nil
+	case StorageTypeAzure:
+		return NewAzureStorage(), nil
+	}
+	return nil, errors.Errorf("unknown storage type %q, must be one of %v, %v, %v, %v or %v", storageType,
+		StorageTypeLocal, StorageTypeS3, StorageTypeMinio, StorageTypeGCS, StorageTypeAzure)
 }
 
 func getStorageLocation(config *storageConfig) (stow.Location, error) {
@@ -81,21 +109,9 @@ func (ss *StorageService) uploadHandler(w http.ResponseWriter, r *http.Request)
 	// encoded file in the HTTP request. So we require an
 	// "X-File-Size" header in bytes.
 
-	fileSizeS, ok := r.Header["X-File-Size"]
-	if !ok {
-		ss.logger.Error("upload is missing the 'X-File-Size' header",
-			zap.String("filename", handler.Filename))
-		http.Error(w, "missing X-File-Size header", http.StatusBadRequest)
-		return
-	}
-
-	fileSize, err := strconv.Atoi(fileSizeS[0])
+	fileSize, err := ss.getFileSize(r, handler.Filename)
 	if err != nil {
-		ss.logger.Error("error parsing 'X-File-Size' header",
-			zap.Error(err),
-			zap.Strings("header", fileSizeS),
-			zap.String("filename", handler.Filename))
-		http.Error(w, "missing or bad X-File-Size header", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -103,7 +119,7 @@ func (ss *StorageService) uploadHandler(w http.ResponseWriter, r *http.Request)
 	ss.logger.Debug("handling upload",
 		zap.String("filename", handler.Filename))
 
-	id, err := ss.storageClient.putFile(file, int64(fileSize))
+	id, deduplicated, err := ss.storageClient.putFile(file, fileSize)
 	if err != nil {
 		ss.logger.Error("error saving uploaded file",
 			zap.Error(err),
@@ -111,8 +127,35 @@ func (ss *StorageService) uploadHandler(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "Error saving uploaded file", http.StatusInternalServerError)
 		return
 	}
+	countUpload(deduplicated, fileSize)
+
+	ss.writeUploadResponse(w, id, handler.Filename)
+}
+
+// getFileSize returns the size of the uploaded file from the "X-File-Size" header.
+func (ss *StorageService) getFileSize(r *http.Request, filename string) (int64, error) {
+	fileSizeS, ok := r.Header["X-File-Size"]
+	if !ok {
+		ss.logger.Error("upload is missing the 'X-File-Size' header",
+			zap.String("filename", filename))
+		return 0, errors.New("missing X-File-Size header")
+	}
 
-	// respond with an ID that can be used to retrieve the file
+	fileSize, err := strconv.ParseInt(fileSizeS[0], 10, 64)
+	if err != nil {
+		ss.logger.Error("error parsing 'X-File-Size' header",
+			zap.Error(err),
+			zap.Strings("header", fileSizeS),
+			zap.String("filename", filename))
+		return 0, errors.New("missing or bad X-File-Size header")
+	}
+	return fileSize, nil
+}
+
+func (ss *StorageService) writeUploadResponse(w http.ResponseWriter, id string, filename string) {
+	// respond with an ID that can be used to retrieve the file. Whether
+	// the archive was deduplicated is not told, as that would tell
+	// uploaders whether anyone uploaded the same content.
 	ur := &UploadResponse{
 		ID: id,
 	}
@@ -120,7 +163,7 @@ func (ss *StorageService) uploadHandler(w http.ResponseWriter, r *http.Request)
 	if err != nil {
 		ss.logger.Error("error marshaling uploaded file response",
 			zap.Error(err),
-			zap.String("filename", handler.Filename))
+			zap.String("filename", filename))
 		http.Error(w, "Error marshaling response", http.StatusInternalServerError)
 		return
 	}
@@ -129,10 +172,134 @@ func (ss *StorageService) uploadHandler(w http.ResponseWriter, r *http.Request)
 		ss.logger.Error(
 			"error writing HTTP response",
 			zap.Error(err),
-			zap.String("filename", handler.Filename),
+			zap.String("filename", filename),
 		)
 	}
+}
+
+// Handle delta uploads. The body of the request is a delta against the
+// archive with ID in the "base" query param; the archive rebuilt from it
+// is stored only if its size and checksum match the "X-File-Size" and
+// "X-File-Checksum" headers.
+func (ss *StorageService) deltaUploadHandler(w http.ResponseWriter, r *http.Request) {
+	baseID := r.URL.Query().Get("base")
+	if len(baseID) == 0 {
+		http.Error(w, "missing `base' query param", http.StatusBadRequest)
+		return
+	}
+	fileSize, err := ss.getFileSize(r, baseID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	checksum := r.Header.Get("X-File-Checksum")
+	if len(checksum) == 0 {
+		http.Error(w, "missing X-File-Checksum header", http.StatusBadRequest)
+		return
+	}
+
+	base, err := ss.storageClient.copyFileToTempFile(baseID)
+	if err != nil {
+		ss.logger.Error("error getting base archive of delta upload", zap.Error(err), zap.String("base", baseID))
+		if err == ErrNotFound {
+			http.Error(w, "Error retrieving base archive: not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Error retrieving base archive", http.StatusInternalServerError)
+		}
+		return
+	}
+	defer removeTempFile(base)
+
+	result, err := ioutil.TempFile("", "storagesvc-delta-")
+	if err != nil {
+		ss.logger.Error("error creating temp file", zap.Error(err))
+		http.Error(w, "Error applying delta", http.StatusInternalServerError)
+		return
+	}
+	defer removeTempFile(result)
+
+	h := sha256.New()
+	err = delta.ApplyDelta(base, r.Body, io.MultiWriter(result, h))
+	if err != nil {
+		ss.logger.Error("error applying delta", zap.Error(err), zap.String("base", baseID))
+		http.Error(w, fmt.Sprintf("Error applying delta: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	resultSize, err := result.Seek(0, io.SeekCurrent)
+	if err != nil {
+		http.Error(w, "Error applying delta", http.StatusInternalServerError)
+		return
+	}
+	if resultSize != fileSize || hex.EncodeToString(h.Sum(nil)) != checksum {
+		ss.logger.Error("archive rebuilt from delta doesn't match the expected size and checksum",
+			zap.String("base", baseID),
+			zap.Int64("size", resultSize),
+			zap.Int64("expected_size", fileSize))
+		http.Error(w, "Archive rebuilt from delta doesn't match the expected size and checksum", http.StatusBadRequest)
+		return
+	}
+
+	_, err = result.Seek(0, io.SeekStart)
+	if err != nil {
+		http.Error(w, "Error saving archive", http.StatusInternalServerError)
+		return
+	}
+	id, deduplicated, err := ss.storageClient.putFile(result, fileSize)
+	if err != nil {
+		ss.logger.Error("error saving archive rebuilt from delta", zap.Error(err), zap.String("base", baseID))
+		http.Error(w, "Error saving archive", http.StatusInternalServerError)
+		return
+	}
+	countUpload(deduplicated, fileSize)
+
+	ss.logger.Debug("saved archive rebuilt from delta",
+		zap.String("base", baseID),
+		zap.String("id", id),
+		zap.Int64("delta_size", r.ContentLength),
+		zap.Int64("size", fileSize))
+	ss.writeUploadResponse(w, id, baseID)
+}
+
+// signatureHandler returns the delta signature of an archive, which
+// clients use to compute a delta of a new version of the archive.
+func (ss *StorageService) signatureHandler(w http.ResponseWriter, r *http.Request) {
+	fileId, err := ss.getIdFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	blockSize := delta.DefaultBlockSize
+	if bs := r.URL.Query().Get("blocksize"); len(bs) > 0 {
+		blockSize, err = strconv.Atoi(bs)
+		if err != nil {
+			http.Error(w, "bad `blocksize' query param", http.StatusBadRequest)
+			return
+		}
+	}
 
+	sig, err := ss.storageClient.getFileSignature(fileId, blockSize)
+	if err != nil {
+		ss.logger.Error("error computing signature of file", zap.Error(err), zap.String("file_id", fileId))
+		if err == ErrNotFound {
+			http.Error(w, "Error retrieving item: not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Error computing signature", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp, err := json.Marshal(sig)
+	if err != nil {
+		http.Error(w, "Error marshaling response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(resp)
+	if err != nil {
+		ss.logger.Error("error writing HTTP response", zap.Error(err), zap.String("file_id", fileId))
+	}
 }
 
 func (ss *StorageService) getIdFromRequest(r *http.Request) (string, error) {
@@ -152,8 +319,12 @@ func (ss *StorageService) deleteHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = ss.storageClient.removeFileByID(fileId)
-	if err != nil {
+	// archives shared by several uploads are only deleted with the last one
+	err = ss.storageClient.releaseFile(fileId)
+	if err == ErrNotFound {
+		http.Error(w, "Error deleting item: not found", http.StatusNotFound)
+		return
+	} else if err != nil {
 		msg := fmt.Sprintf("Error deleting item: %v", err)
 		http.Error(w, msg, http.StatusInternalServerError)
 		return
@@ -161,6 +332,9 @@ func (ss *StorageService) deleteHandler(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusOK)
 }
 
+// downloadHandler serves an archive. Clients may download a single byte
+// range of it with a "Range" header, e.g. to fetch large archives in
+// parallel chunks, or resume a download that failed.
 func (ss *StorageService) downloadHandler(w http.ResponseWriter, r *http.Request) {
 	// get id from request
 	fileId, err := ss.getIdFromRequest(r)
@@ -169,54 +343,97 @@ func (ss *StorageService) downloadHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Get the file (called "item" in stow's jargon), open it,
-	// stream it to response
-	err = ss.storageClient.copyFileToStream(fileId, w)
+	size, err := ss.storageClient.getFileSize(fileId)
 	if err != nil {
-		ss.logger.Error("error getting file from storage client", zap.Error(err), zap.String("file_id", fileId))
-		if err == ErrNotFound {
-			http.Error(w, "Error retrieving item: not found", http.StatusNotFound)
-		} else if err == ErrRetrievingItem {
-			http.Error(w, "Error retrieving item", http.StatusBadRequest)
-		} else if err == ErrOpeningItem {
-			http.Error(w, "Error opening item", http.StatusBadRequest)
-		} else if err == ErrWritingFileIntoResponse {
-			http.Error(w, "Error writing response", http.StatusInternalServerError)
+		ss.writeDownloadError(w, fileId, err)
+		return
+	}
+
+	offset, length := int64(0), size
+	status := http.StatusOK
+	if rangeHeader := r.Header.Get("Range"); len(rangeHeader) > 0 {
+		offset, length, err = parseByteRange(rangeHeader, size)
+		if err != nil {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%v", size))
+			http.Error(w, err.Error(), http.StatusRequestedRangeNotSatisfiable)
+			return
 		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %v-%v/%v", offset, offset+length-1, size))
+		status = http.StatusPartialContent
+	}
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
 		return
 	}
+
+	// Get the file (called "item" in stow's jargon), open it,
+	// stream it to response
+	if status == http.StatusOK {
+		err = ss.storageClient.copyFileToStream(fileId, w)
+	} else {
+		w.WriteHeader(status)
+		err = ss.storageClient.copyFileRangeToStream(fileId, offset, length, w)
+	}
+	if err != nil {
+		ss.writeDownloadError(w, fileId, err)
+	}
+}
+
+func (ss *StorageService) writeDownloadError(w http.ResponseWriter, fileId string, err error) {
+	ss.logger.Error("error getting file from storage client", zap.Error(err), zap.String("file_id", fileId))
+	if err == ErrNotFound {
+		http.Error(w, "Error retrieving item: not found", http.StatusNotFound)
+	} else if err == ErrRetrievingItem {
+		http.Error(w, "Error retrieving item", http.StatusBadRequest)
+	} else if err == ErrOpeningItem {
+		http.Error(w, "Error opening item", http.StatusBadRequest)
+	} else if err == ErrWritingFileIntoResponse {
+		http.Error(w, "Error writing response", http.StatusInternalServerError)
+	}
 }
 
 func (ss *StorageService) healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-func MakeStorageService(logger *zap.Logger, storageClient *StowClient, port int) *StorageService {
+func MakeStorageService(logger *zap.Logger, storageClient *StowClient, uploads *uploadManager, port int) *StorageService {
 	return &StorageService{
 		logger:        logger.Named("storage_service"),
 		storageClient: storageClient,
 		port:          port,
+		uploads:       uploads,
 	}
 }
 
 func (ss *StorageService) Start(port int) {
 	r := mux.NewRouter()
 	r.HandleFunc("/v1/archive", ss.uploadHandler).Methods("POST")
-	r.HandleFunc("/v1/archive", ss.downloadHandler).Methods("GET")
+	r.HandleFunc("/v1/archive", ss.downloadHandler).Methods("GET", "HEAD")
 	r.HandleFunc("/v1/archive", ss.deleteHandler).Methods("DELETE")
+	r.HandleFunc("/v1/archive/delta", ss.deltaUploadHandler).Methods("POST")
+	r.HandleFunc("/v1/archive/signature", ss.signatureHandler).Methods("GET")
+	r.HandleFunc("/v1/archive/uploads", ss.startUploadHandler).Methods("POST")
+	r.HandleFunc("/v1/archive/uploads/{id}", ss.getUploadHandler).Methods("GET")
+	r.HandleFunc("/v1/archive/uploads/{id}", ss.abortUploadHandler).Methods("DELETE")
+	r.HandleFunc("/v1/archive/uploads/{id}/parts/{number}", ss.putPartHandler).Methods("PUT")
+	r.HandleFunc("/v1/archive/uploads/{id}/complete", ss.completeUploadHandler).Methods("POST")
 	r.HandleFunc("/healthz", ss.healthHandler).Methods("GET")
+	r.Handle("/metrics", promhttp.Handler()).Methods("GET")
 
 	address := fmt.Sprintf(":%v", port)
 
 	err := http.ListenAndServe(address, &ochttp.Handler{
-		Handler: r,
+		Handler: tracing.Handler(r, "storagesvc"),
 	})
 
 	ss.logger.Fatal("done listening", zap.Error(err))
 }
 
 // Start runs storage service
-func Start(logger *zap.Logger, storage Storage, port int) error {
+func Start(logger *zap.Logger, storage StorageBackend, port int) error {
 	enablePruner := true
 	// create a storage client
 	storageClient, err := MakeStowClient(logger, storage)
@@ -224,8 +441,31 @@ func Start(logger *zap.Logger, storage Storage, port int) error {
 		return errors.Wrap(err, "Error creating stowClient")
 	}
 
+	// parts of multipart uploads are kept in a persistent directory if
+	// set, so that uploads can be resumed after restarts
+	uploadDir := os.Getenv("MULTIPART_UPLOAD_DIR")
+	if len(uploadDir) == 0 {
+		uploadDir = filepath.Join(os.TempDir(), "storagesvc-uploads")
+	}
+	uploadTTL := DefaultUploadTTL
+	if ttl := os.Getenv("MULTIPART_UPLOAD_TTL"); len(ttl) > 0 {
+		uploadTTL, err = time.ParseDuration(ttl)
+		if err != nil {
+			logger.Error("failed to parse multipart upload TTL from 'MULTIPART_UPLOAD_TTL' - set to the default value",
+				zap.Error(err),
+				zap.String("value", ttl),
+				zap.Duration("default", DefaultUploadTTL))
+			uploadTTL = DefaultUploadTTL
+		}
+	}
+	uploads, err := makeUploadManager(logger, storageClient, uploadDir, uploadTTL)
+	if err != nil {
+		return errors.Wrap(err, "Error creating multipart upload manager")
+	}
+	go uploads.run()
+
 	// create http handlers
-	storageService := MakeStorageService(logger, storageClient, port)
+	storageService := MakeStorageService(logger, storageClient, uploads, port)
 	go storageService.Start(port)
 
 	// enablePruner prevents storagesvc unit test from needing to talk to kubernetes
@@ -235,7 +475,17 @@ func Start(logger *zap.Logger, storage Storage, port int) error {
 		if err != nil {
 			pruneInterval = defaultPruneInterval
 		}
-		pruner, err := MakeArchivePruner(logger, storageClient, time.Duration(pruneInterval))
+		// in dry run mode, orphan archives are logged and counted but kept
+		dryRun, _ := strconv.ParseBool(os.Getenv("PRUNE_DRY_RUN"))
+		// the archives of package revisions beyond the retention are pruned
+		revisionRetentionStr := os.Getenv("PRUNE_REVISION_RETENTION")
+		revisionRetention, err := strconv.Atoi(revisionRetentionStr)
+		if err != nil && len(revisionRetentionStr) > 0 {
+			logger.Error("failed to parse package revision retention from 'PRUNE_REVISION_RETENTION' - archives of all revisions are kept",
+				zap.Error(err),
+				zap.String("value", revisionRetentionStr))
+		}
+		pruner, err := MakeArchivePruner(logger, storageClient, time.Duration(pruneInterval), dryRun, revisionRetention)
 		if err != nil {
 			return errors.Wrap(err, "Error creating archivePruner")
 		}
@@ -18,14 +18,17 @@ package storagesvc
 
 import (
 	"io"
-	"mime/multipart"
+	"io/ioutil"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/graymeta/stow"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+
+	"github.com/fission/fission/pkg/storagesvc/delta"
 )
 
 type (
@@ -33,7 +36,7 @@ type (
 	StorageType string
 
 	storageConfig struct {
-		storage Storage
+		storage StorageBackend
 	}
 
 	//StowClient is the wraper client for stow (Cloud storage abstraction package)
@@ -42,6 +45,9 @@ type (
 		config    *storageConfig
 		location  stow.Location
 		container stow.Container
+
+		// refsLock serializes the updates of the references of archives
+		refsLock sync.Mutex
 	}
 )
 
@@ -50,6 +56,12 @@ const (
 	StorageTypeLocal StorageType = "local"
 	// StorageTypeS3 is a constant to hold S3 storage type name literal
 	StorageTypeS3 StorageType = "s3"
+	// StorageTypeMinio is a MinIO server, or another S3 compatible store
+	StorageTypeMinio StorageType = "minio"
+	// StorageTypeGCS is a GCS bucket
+	StorageTypeGCS StorageType = "gcs"
+	// StorageTypeAzure is an Azure Blob Storage container
+	StorageTypeAzure StorageType = "azure"
 	// PaginationSize is a constant to hold no of pages
 	PaginationSize int = 10
 )
@@ -63,12 +75,7 @@ var (
 )
 
 // MakeStowClient create a new StowClient for given storage
-func MakeStowClient(logger *zap.Logger, storage Storage) (*StowClient, error) {
-	storageType := getStorageType(storage)
-	if strings.Compare(storageType, "local") == 1 && strings.Compare(storageType, "s3") == 1 {
-		return nil, errors.New("Storage types other than 'local' and 's3' are not implemented")
-	}
-
+func MakeStowClient(logger *zap.Logger, storage StorageBackend) (*StowClient, error) {
 	config := &storageConfig{
 		storage: storage,
 	}
@@ -85,7 +92,8 @@ func MakeStowClient(logger *zap.Logger, storage Storage) (*StowClient, error) {
 	stowClient.location = loc
 
 	con, err := loc.CreateContainer(config.storage.getContainerName())
-	if err != nil && (os.IsExist(err) || strings.Contains(err.Error(), "BucketAlreadyOwnedByYou")) {
+	if err != nil && (os.IsExist(err) || strings.Contains(err.Error(), "BucketAlreadyOwnedByYou") ||
+		strings.Contains(err.Error(), "ContainerAlreadyExists")) {
 		var cons []stow.Container
 		var cursor string
 
@@ -109,32 +117,11 @@ func MakeStowClient(logger *zap.Logger, storage Storage) (*StowClient, error) {
 	return stowClient, nil
 }
 
-// putFile writes the file on the storage
-func (client *StowClient) putFile(file multipart.File, fileSize int64) (string, error) {
-	uploadName := client.config.storage.getUploadFileName()
-
-	// save the file to the storage backend
-	item, err := client.container.Put(uploadName, file, int64(fileSize), nil)
-	if err != nil {
-		client.logger.Error("error writing file on storage",
-			zap.Error(err),
-			zap.String("file", uploadName))
-		return "", ErrWritingFile
-	}
-
-	client.logger.Debug("successfully wrote file on storage", zap.String("file", uploadName))
-	return item.ID(), nil
-}
-
 // copyFileToStream gets the file contents into a stream
 func (client *StowClient) copyFileToStream(fileId string, w io.Writer) error {
-	item, err := client.container.Item(fileId)
+	item, err := client.item(fileId)
 	if err != nil {
-		if err == stow.ErrNotFound {
-			return ErrNotFound
-		} else {
-			return ErrRetrievingItem
-		}
+		return err
 	}
 
 	f, err := item.Open()
@@ -152,8 +139,103 @@ func (client *StowClient) copyFileToStream(fileId string, w io.Writer) error {
 	return nil
 }
 
-// removeFileByID deletes the file from storage
+// copyFileRangeToStream writes length bytes of the file with the given ID,
+// starting at offset, to w.
+func (client *StowClient) copyFileRangeToStream(fileId string, offset int64, length int64, w io.Writer) error {
+	f, err := client.openFile(fileId)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// only some stow backends open items as seekable files, skip to the
+	// offset of the others
+	if seeker, ok := f.(io.Seeker); ok {
+		_, err = seeker.Seek(offset, io.SeekStart)
+	} else {
+		_, err = io.CopyN(ioutil.Discard, f, offset)
+	}
+	if err != nil {
+		return ErrOpeningItem
+	}
+
+	_, err = io.CopyN(w, f, length)
+	if err != nil {
+		return ErrWritingFileIntoResponse
+	}
+	return nil
+}
+
+// getFileSize returns the size of the file with the given ID.
+func (client *StowClient) getFileSize(fileId string) (int64, error) {
+	item, err := client.item(fileId)
+	if err != nil {
+		return 0, err
+	}
+	size, err := item.Size()
+	if err != nil {
+		return 0, ErrRetrievingItem
+	}
+	return size, nil
+}
+
+// openFile opens the file with the given ID for reading.
+func (client *StowClient) openFile(fileId string) (io.ReadCloser, error) {
+	item, err := client.item(fileId)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := item.Open()
+	if err != nil {
+		return nil, ErrOpeningItem
+	}
+	return f, nil
+}
+
+// copyFileToTempFile copies the file into a temp file, for random access.
+// The caller removes the temp file after use.
+func (client *StowClient) copyFileToTempFile(fileId string) (*os.File, error) {
+	f, err := client.openFile(fileId)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tmp, err := ioutil.TempFile("", "storagesvc-")
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating temp file")
+	}
+	_, err = io.Copy(tmp, f)
+	if err != nil {
+		removeTempFile(tmp)
+		return nil, errors.Wrapf(err, "error copying file %v", fileId)
+	}
+	return tmp, nil
+}
+
+// getFileSignature computes the delta signature of the file.
+func (client *StowClient) getFileSignature(fileId string, blockSize int) (*delta.Signature, error) {
+	f, err := client.openFile(fileId)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return delta.ComputeSignature(f, blockSize)
+}
+
+func removeTempFile(f *os.File) {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// removeFileByID deletes the item from storage. Content-addressed archives
+// are deleted with their references, unless referenced again recently.
 func (client *StowClient) removeFileByID(itemID string) error {
+	if checksum, ok := contentChecksum(itemID); ok {
+		return client.pruneContent(itemID, checksum)
+	}
 	return client.container.RemoveItem(itemID)
 }
 
@@ -168,13 +250,23 @@ func (client *StowClient) getItemIDsWithFilter(filterFunc filter, filterFuncPara
 
 	archiveIDList := make([]string, 0)
 
+	// only archives are pruned from buckets shared with other data
+	prefix := stow.NoPrefix
+	if subDir := strings.Trim(client.config.storage.getSubDir(), "/"); len(subDir) > 0 {
+		prefix = subDir + "/"
+	}
+
 	for {
-		items, cursor, err = client.container.Items(stow.NoPrefix, cursor, PaginationSize)
+		items, cursor, err = client.container.Items(prefix, cursor, PaginationSize)
 		if err != nil {
 			return nil, errors.Wrap(err, "error getting items from container")
 		}
 
 		for _, item := range items {
+			// references aren't archives, they go with their archive
+			if isRefsItem(item.ID()) {
+				continue
+			}
 			isItemFilterable := filterFunc(item, filterFuncParam)
 			if isItemFilterable {
 				continue
@@ -192,7 +284,7 @@ func (client *StowClient) getItemIDsWithFilter(filterFunc filter, filterFuncPara
 
 // filterItemCreatedAMinuteAgo is one type of filter function that filters out items created less than a minute ago.
 // More filter functions can be written if needed, as long as they are of type filter
-func (client StowClient) filterItemCreatedAMinuteAgo(item stow.Item, currentTime interface{}) bool {
+func (client *StowClient) filterItemCreatedAMinuteAgo(item stow.Item, currentTime interface{}) bool {
 	itemLastModTime, _ := item.LastMod()
 	if currentTime.(time.Time).Sub(itemLastModTime) < 1*time.Minute {
 
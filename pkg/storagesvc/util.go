@@ -18,6 +18,8 @@ package storagesvc
 
 import (
 	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -51,3 +53,48 @@ func getDifferenceOfLists(firstList []string, secondList []string) []string {
 
 	return differenceList
 }
+
+// parseByteRange returns the offset and length of the single byte range
+// of a "Range" request header for a file of the given size.
+func parseByteRange(header string, size int64) (int64, int64, error) {
+	const prefix = "bytes="
+	if !strings.HasPrefix(header, prefix) || strings.Contains(header, ",") {
+		return 0, 0, errors.Errorf("unsupported range %q", header)
+	}
+	bounds := strings.SplitN(strings.TrimPrefix(header, prefix), "-", 2)
+	if len(bounds) != 2 {
+		return 0, 0, errors.Errorf("invalid range %q", header)
+	}
+	first, last := strings.TrimSpace(bounds[0]), strings.TrimSpace(bounds[1])
+	if size == 0 {
+		return 0, 0, errors.Errorf("range %q not satisfiable for an empty file", header)
+	}
+
+	if len(first) == 0 {
+		// suffix range of the last n bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, errors.Errorf("invalid range %q", header)
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, n, nil
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, errors.Errorf("range %q not satisfiable for size %v", header, size)
+	}
+	end := size - 1
+	if len(last) > 0 {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, errors.Errorf("invalid range %q", header)
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	return start, end - start + 1, nil
+}
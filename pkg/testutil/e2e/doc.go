@@ -0,0 +1,38 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package e2e is a harness for end-to-end tests of Fission, for forks and
+// plugins to run conformance suites against a cluster running their
+// changes. It creates Fission objects, waits for them to get ready,
+// invokes functions through the router and scrapes the logs of function
+// pods and Fission components.
+//
+// It's configured by the same environment variables as the shell tests in
+// test/, FISSION_ROUTER being the address of the router. Tests are skipped
+// if it isn't set.
+//
+//	func TestHello(t *testing.T) {
+//		f := e2e.NewFramework(t)
+//		defer f.Cleanup()
+//
+//		env := f.Environment(f.Name("nodejs"), "fission/node-env")
+//		pkg := f.LiteralPackage(f.Name("hello"), env.ObjectMeta.Name, []byte(code))
+//		fn := f.Function(f.Name("hello"), env.ObjectMeta.Name, pkg.ObjectMeta.Name, "")
+//		f.Create(env, pkg, fn)
+//
+//		f.ExpectResponse(http.MethodGet, f.FunctionURL(fn.ObjectMeta.Name), nil, "hello")
+//	}
+package e2e
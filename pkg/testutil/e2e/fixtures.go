@@ -0,0 +1,182 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// The fixtures below return objects in the test namespace, labeled with
+// the test ID and with the defaults the controller would fill. They are
+// only created by Create, so that tests can change them before.
+
+func (f *Framework) objectMeta(name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: f.config.Namespace,
+		Labels:    map[string]string{TestIDLabel: f.TestID},
+	}
+}
+
+// Environment returns an environment running functions in the given image.
+func (f *Framework) Environment(name, image string) *fv1.Environment {
+	env := &fv1.Environment{
+		ObjectMeta: f.objectMeta(name),
+		Spec: fv1.EnvironmentSpec{
+			Runtime: fv1.Runtime{Image: image},
+		},
+	}
+	env.Spec.SetDefaults()
+	return env
+}
+
+// EnvironmentWithBuilder returns an environment running functions in the
+// given image and building their source packages in builderImage.
+func (f *Framework) EnvironmentWithBuilder(name, image, builderImage string) *fv1.Environment {
+	env := &fv1.Environment{
+		ObjectMeta: f.objectMeta(name),
+		Spec: fv1.EnvironmentSpec{
+			Runtime: fv1.Runtime{Image: image},
+			Builder: fv1.Builder{Image: builderImage},
+		},
+	}
+	env.Spec.SetDefaults()
+	return env
+}
+
+// LiteralPackage returns a package deploying code as is.
+func (f *Framework) LiteralPackage(name, env string, code []byte) *fv1.Package {
+	return &fv1.Package{
+		ObjectMeta: f.objectMeta(name),
+		Spec: fv1.PackageSpec{
+			Environment: fv1.EnvironmentReference{Name: env, Namespace: f.config.Namespace},
+			Deployment: fv1.Archive{
+				Type:    fv1.ArchiveTypeLiteral,
+				Literal: code,
+			},
+		},
+	}
+}
+
+// SourcePackage returns a package built from a source archive by the
+// builder of its environment.
+func (f *Framework) SourcePackage(name, env string, archive []byte) *fv1.Package {
+	return &fv1.Package{
+		ObjectMeta: f.objectMeta(name),
+		Spec: fv1.PackageSpec{
+			Environment: fv1.EnvironmentReference{Name: env, Namespace: f.config.Namespace},
+			Source: fv1.Archive{
+				Type:    fv1.ArchiveTypeLiteral,
+				Literal: archive,
+			},
+		},
+	}
+}
+
+// Function returns a poolmgr function of a package. The entrypoint can be
+// empty for environments loading the package as the function.
+func (f *Framework) Function(name, env, pkg, entrypoint string) *fv1.Function {
+	fn := &fv1.Function{
+		ObjectMeta: f.objectMeta(name),
+		Spec: fv1.FunctionSpec{
+			Environment: fv1.EnvironmentReference{Name: env},
+			Package: fv1.FunctionPackageRef{
+				PackageRef:   fv1.PackageRef{Name: pkg},
+				FunctionName: entrypoint,
+			},
+		},
+	}
+	fn.Spec.SetDefaults(f.config.Namespace)
+	return fn
+}
+
+// NewdeployFunction returns a function of a package run by newdeploy with
+// the given min and max scale.
+func (f *Framework) NewdeployFunction(name, env, pkg, entrypoint string, minScale, maxScale int) *fv1.Function {
+	fn := f.Function(name, env, pkg, entrypoint)
+	es := &fn.Spec.InvokeStrategy.ExecutionStrategy
+	es.ExecutorType = fv1.ExecutorTypeNewdeploy
+	es.MinScale = minScale
+	es.MaxScale = maxScale
+	fn.Spec.SetDefaults(f.config.Namespace)
+	return fn
+}
+
+// HTTPTrigger returns a route from a method and URL to a function.
+func (f *Framework) HTTPTrigger(name, fn, method, relativeURL string) *fv1.HTTPTrigger {
+	return &fv1.HTTPTrigger{
+		ObjectMeta: f.objectMeta(name),
+		Spec: fv1.HTTPTriggerSpec{
+			RelativeURL: relativeURL,
+			Method:      method,
+			FunctionReference: fv1.FunctionReference{
+				Type: fv1.FunctionReferenceTypeFunctionName,
+				Name: fn,
+			},
+		},
+	}
+}
+
+// TimeTrigger returns a trigger invoking a function on a cron schedule.
+func (f *Framework) TimeTrigger(name, fn, cron string) *fv1.TimeTrigger {
+	return &fv1.TimeTrigger{
+		ObjectMeta: f.objectMeta(name),
+		Spec: fv1.TimeTriggerSpec{
+			Cron: cron,
+			FunctionReference: fv1.FunctionReference{
+				Type: fv1.FunctionReferenceTypeFunctionName,
+				Name: fn,
+			},
+		},
+	}
+}
+
+// MessageQueueTrigger returns a trigger invoking a function with the
+// messages of a topic.
+func (f *Framework) MessageQueueTrigger(name, fn string, mqType fv1.MessageQueueType, topic, respTopic string) *fv1.MessageQueueTrigger {
+	return &fv1.MessageQueueTrigger{
+		ObjectMeta: f.objectMeta(name),
+		Spec: fv1.MessageQueueTriggerSpec{
+			FunctionReference: fv1.FunctionReference{
+				Type: fv1.FunctionReferenceTypeFunctionName,
+				Name: fn,
+			},
+			MessageQueueType: mqType,
+			Topic:            topic,
+			ResponseTopic:    respTopic,
+			ContentType:      "application/json",
+		},
+	}
+}
+
+// KubernetesWatchTrigger returns a trigger invoking a function when
+// objects of a type change in a namespace.
+func (f *Framework) KubernetesWatchTrigger(name, fn, namespace, objType string) *fv1.KubernetesWatchTrigger {
+	return &fv1.KubernetesWatchTrigger{
+		ObjectMeta: f.objectMeta(name),
+		Spec: fv1.KubernetesWatchTriggerSpec{
+			Namespace: namespace,
+			Type:      objType,
+			FunctionReference: fv1.FunctionReference{
+				Type: fv1.FunctionReferenceTypeFunctionName,
+				Name: fn,
+			},
+		},
+	}
+}
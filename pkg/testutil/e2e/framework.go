@@ -0,0 +1,316 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+)
+
+// TestIDLabel is the label of the objects created by a test, set to the
+// ID of the test.
+const TestIDLabel = "e2e.fission.io/test-id"
+
+type (
+	// Config is the cluster tests run against.
+	Config struct {
+		// RouterURL is the address of the router, like "127.0.0.1:8888".
+		RouterURL string
+		// Namespace is where test objects are created.
+		Namespace         string
+		FissionNamespace  string
+		FunctionNamespace string
+		BuilderNamespace  string
+		// Timeout bounds the waits for objects to get ready and for
+		// functions to respond as expected.
+		Timeout time.Duration
+		// NoCleanup keeps the objects created by tests, for debugging.
+		NoCleanup bool
+	}
+
+	// Framework runs a test against a cluster, and deletes the objects the
+	// test created once it's done.
+	Framework struct {
+		tb     testing.TB
+		config *Config
+
+		// TestID is part of the names of the objects of the test, and the
+		// value of their TestIDLabel.
+		TestID string
+
+		FissionClient *crd.FissionClient
+		KubeClient    kubernetes.Interface
+
+		httpClient *http.Client
+
+		lock    sync.Mutex
+		created []runtime.Object
+	}
+)
+
+// ConfigFromEnv returns the config set by the FISSION_ROUTER,
+// TEST_NAMESPACE, FISSION_NAMESPACE, FISSION_FUNCTION_NAMESPACE,
+// FISSION_BUILDER_NAMESPACE, TEST_TIMEOUT and TEST_NOCLEANUP environment
+// variables.
+func ConfigFromEnv() (*Config, error) {
+	config := &Config{
+		RouterURL:         os.Getenv("FISSION_ROUTER"),
+		Namespace:         getEnv("TEST_NAMESPACE", metav1.NamespaceDefault),
+		FissionNamespace:  getEnv("FISSION_NAMESPACE", "fission"),
+		FunctionNamespace: getEnv("FISSION_FUNCTION_NAMESPACE", "fission-function"),
+		BuilderNamespace:  getEnv("FISSION_BUILDER_NAMESPACE", "fission-builder"),
+		Timeout:           3 * time.Minute,
+		// like the shell tests, any value keeps the objects
+		NoCleanup: len(os.Getenv("TEST_NOCLEANUP")) > 0,
+	}
+	if val := os.Getenv("TEST_TIMEOUT"); len(val) > 0 {
+		timeout, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing TEST_TIMEOUT")
+		}
+		config.Timeout = timeout
+	}
+	return config, nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if val := os.Getenv(key); len(val) > 0 {
+		return val
+	}
+	return defaultValue
+}
+
+// NewFramework returns a framework for a test, configured by the
+// environment. The test is skipped if FISSION_ROUTER isn't set.
+func NewFramework(tb testing.TB) *Framework {
+	tb.Helper()
+	config, err := ConfigFromEnv()
+	if err != nil {
+		tb.Fatalf("error loading e2e config: %v", err)
+	}
+	if len(config.RouterURL) == 0 {
+		tb.Skip("FISSION_ROUTER not set, skipping end-to-end test")
+	}
+	fissionClient, kubeClient, _, _, err := crd.MakeFissionClient()
+	if err != nil {
+		tb.Fatalf("error making clients: %v", err)
+	}
+	return MakeFramework(tb, config, fissionClient, kubeClient)
+}
+
+// MakeFramework returns a framework for a test using the given clients.
+func MakeFramework(tb testing.TB, config *Config, fissionClient *crd.FissionClient, kubeClient kubernetes.Interface) *Framework {
+	return &Framework{
+		tb:            tb,
+		config:        config,
+		TestID:        strconv.Itoa(10000 + rand.New(rand.NewSource(time.Now().UnixNano())).Intn(90000)),
+		FissionClient: fissionClient,
+		KubeClient:    kubeClient,
+		httpClient:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Config returns the config of the framework.
+func (f *Framework) Config() *Config {
+	return f.config
+}
+
+// Name returns a name for an object of the test.
+func (f *Framework) Name(prefix string) string {
+	return fmt.Sprintf("%v-%v", prefix, f.TestID)
+}
+
+// Logf logs a step of the test.
+func (f *Framework) Logf(format string, args ...interface{}) {
+	f.tb.Helper()
+	f.tb.Logf("%v %v", time.Now().Format("2006/01/02:15:04:05"), fmt.Sprintf(format, args...))
+}
+
+// Create creates objects in order, and fails the test if any of them
+// can't be created. The objects are updated with what the cluster
+// returned, and deleted by Cleanup.
+func (f *Framework) Create(objs ...runtime.Object) {
+	f.tb.Helper()
+	for _, obj := range objs {
+		if err := f.create(obj); err != nil {
+			f.tb.Fatalf("error creating %T: %v", obj, err)
+		}
+		f.lock.Lock()
+		f.created = append(f.created, obj)
+		f.lock.Unlock()
+	}
+}
+
+func (f *Framework) create(obj runtime.Object) error {
+	client := f.FissionClient.CoreV1()
+	var err error
+	switch o := obj.(type) {
+	case *fv1.Environment:
+		var created *fv1.Environment
+		created, err = client.Environments(f.namespace(&o.ObjectMeta)).Create(o)
+		if err == nil {
+			*o = *created
+		}
+	case *fv1.Package:
+		var created *fv1.Package
+		created, err = client.Packages(f.namespace(&o.ObjectMeta)).Create(o)
+		if err == nil {
+			*o = *created
+		}
+	case *fv1.Function:
+		var created *fv1.Function
+		created, err = client.Functions(f.namespace(&o.ObjectMeta)).Create(o)
+		if err == nil {
+			*o = *created
+		}
+	case *fv1.HTTPTrigger:
+		var created *fv1.HTTPTrigger
+		created, err = client.HTTPTriggers(f.namespace(&o.ObjectMeta)).Create(o)
+		if err == nil {
+			*o = *created
+		}
+	case *fv1.TimeTrigger:
+		var created *fv1.TimeTrigger
+		created, err = client.TimeTriggers(f.namespace(&o.ObjectMeta)).Create(o)
+		if err == nil {
+			*o = *created
+		}
+	case *fv1.MessageQueueTrigger:
+		var created *fv1.MessageQueueTrigger
+		created, err = client.MessageQueueTriggers(f.namespace(&o.ObjectMeta)).Create(o)
+		if err == nil {
+			*o = *created
+		}
+	case *fv1.KubernetesWatchTrigger:
+		var created *fv1.KubernetesWatchTrigger
+		created, err = client.KubernetesWatchTriggers(f.namespace(&o.ObjectMeta)).Create(o)
+		if err == nil {
+			*o = *created
+		}
+	case *apiv1.ConfigMap:
+		var created *apiv1.ConfigMap
+		created, err = f.KubeClient.CoreV1().ConfigMaps(f.namespace(&o.ObjectMeta)).Create(o)
+		if err == nil {
+			*o = *created
+		}
+	case *apiv1.Secret:
+		var created *apiv1.Secret
+		created, err = f.KubeClient.CoreV1().Secrets(f.namespace(&o.ObjectMeta)).Create(o)
+		if err == nil {
+			*o = *created
+		}
+	default:
+		return errors.Errorf("unsupported object type %T", obj)
+	}
+	return err
+}
+
+// namespace defaults the namespace of an object to the test namespace.
+func (f *Framework) namespace(meta *metav1.ObjectMeta) string {
+	if len(meta.Namespace) == 0 {
+		meta.Namespace = f.config.Namespace
+	}
+	return meta.Namespace
+}
+
+// Cleanup deletes the objects created by the test, the last created first,
+// unless NoCleanup is set. It logs the logs of the functions and Fission
+// components of failed tests first.
+func (f *Framework) Cleanup() {
+	f.tb.Helper()
+	if f.tb.Failed() {
+		f.DumpLogs()
+	}
+	if f.config.NoCleanup {
+		f.Logf("TEST_NOCLEANUP is set; not cleaning up test %v", f.TestID)
+		return
+	}
+
+	f.lock.Lock()
+	created := f.created
+	f.created = nil
+	f.lock.Unlock()
+
+	for i := len(created) - 1; i >= 0; i-- {
+		err := f.delete(created[i])
+		if err != nil && !k8serrors.IsNotFound(err) {
+			f.tb.Errorf("error deleting %T: %v", created[i], err)
+		}
+	}
+}
+
+func (f *Framework) delete(obj runtime.Object) error {
+	client := f.FissionClient.CoreV1()
+	switch o := obj.(type) {
+	case *fv1.Environment:
+		return client.Environments(o.ObjectMeta.Namespace).Delete(o.ObjectMeta.Name, nil)
+	case *fv1.Package:
+		return client.Packages(o.ObjectMeta.Namespace).Delete(o.ObjectMeta.Name, nil)
+	case *fv1.Function:
+		return client.Functions(o.ObjectMeta.Namespace).Delete(o.ObjectMeta.Name, nil)
+	case *fv1.HTTPTrigger:
+		return client.HTTPTriggers(o.ObjectMeta.Namespace).Delete(o.ObjectMeta.Name, nil)
+	case *fv1.TimeTrigger:
+		return client.TimeTriggers(o.ObjectMeta.Namespace).Delete(o.ObjectMeta.Name, nil)
+	case *fv1.MessageQueueTrigger:
+		return client.MessageQueueTriggers(o.ObjectMeta.Namespace).Delete(o.ObjectMeta.Name, nil)
+	case *fv1.KubernetesWatchTrigger:
+		return client.KubernetesWatchTriggers(o.ObjectMeta.Namespace).Delete(o.ObjectMeta.Name, nil)
+	case *apiv1.ConfigMap:
+		return f.KubeClient.CoreV1().ConfigMaps(o.ObjectMeta.Namespace).Delete(o.ObjectMeta.Name, nil)
+	case *apiv1.Secret:
+		return f.KubeClient.CoreV1().Secrets(o.ObjectMeta.Namespace).Delete(o.ObjectMeta.Name, nil)
+	}
+	return errors.Errorf("unsupported object type %T", obj)
+}
+
+// WaitFor polls cond every second until it returns true, and fails the
+// test if it doesn't within the timeout or returns an error.
+func (f *Framework) WaitFor(what string, cond func() (bool, error)) {
+	f.tb.Helper()
+	f.Logf("waiting for %v", what)
+	deadline := time.Now().Add(f.config.Timeout)
+	for {
+		ok, err := cond()
+		if err != nil {
+			f.tb.Fatalf("error waiting for %v: %v", what, err)
+		}
+		if ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			f.tb.Fatalf("timed out waiting for %v after %v", what, f.config.Timeout)
+		}
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/generated/clientset/versioned/fake"
+)
+
+func makeTestFramework(t *testing.T, routerURL string) (*Framework, *fake.Clientset, *kubefake.Clientset) {
+	cs := fake.NewSimpleClientset()
+	kubeClient := kubefake.NewSimpleClientset()
+	config := &Config{
+		RouterURL:         routerURL,
+		Namespace:         "e2e",
+		FissionNamespace:  "fission",
+		FunctionNamespace: "fission-function",
+		BuilderNamespace:  "fission-builder",
+		Timeout:           5 * time.Second,
+	}
+	return MakeFramework(t, config, &crd.FissionClient{Interface: cs}, kubeClient), cs, kubeClient
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	for key, val := range map[string]string{
+		"FISSION_ROUTER": "127.0.0.1:8888",
+		"TEST_NAMESPACE": "e2e",
+		"TEST_TIMEOUT":   "90s",
+		"TEST_NOCLEANUP": "1",
+	} {
+		old, ok := os.LookupEnv(key)
+		os.Setenv(key, val)
+		if ok {
+			defer os.Setenv(key, old)
+		} else {
+			defer os.Unsetenv(key)
+		}
+	}
+
+	config, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.RouterURL != "127.0.0.1:8888" || config.Namespace != "e2e" ||
+		config.Timeout != 90*time.Second || !config.NoCleanup {
+		t.Fatalf("config doesn't match the environment: %+v", config)
+	}
+	if config.FissionNamespace != "fission" || config.FunctionNamespace != "fission-function" {
+		t.Fatalf("expected default Fission namespaces, got %+v", config)
+	}
+
+	os.Setenv("TEST_TIMEOUT", "soon")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Fatal("expected an error for an invalid TEST_TIMEOUT")
+	}
+}
+
+func TestCreateAndCleanup(t *testing.T) {
+	f, cs, kubeClient := makeTestFramework(t, "")
+
+	env := f.Environment(f.Name("nodejs"), "fission/node-env")
+	pkg := f.LiteralPackage(f.Name("hello"), env.ObjectMeta.Name, []byte("module.exports = () => 'hello'"))
+	fn := f.Function(f.Name("hello"), env.ObjectMeta.Name, pkg.ObjectMeta.Name, "")
+	ht := f.HTTPTrigger(f.Name("hello"), fn.ObjectMeta.Name, http.MethodGet, "/hello")
+	secret := &apiv1.Secret{ObjectMeta: metav1.ObjectMeta{Name: f.Name("secret")}}
+	f.Create(env, pkg, fn, ht, secret)
+
+	got, err := cs.CoreV1().Functions("e2e").Get(fn.ObjectMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ObjectMeta.Labels[TestIDLabel] != f.TestID {
+		t.Fatalf("expected function to be labeled with test ID %v, got %v", f.TestID, got.ObjectMeta.Labels)
+	}
+	if got.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType != fv1.ExecutorTypePoolmgr {
+		t.Fatalf("expected function to be defaulted, got %+v", got.Spec.InvokeStrategy)
+	}
+	if secret.ObjectMeta.Namespace != "e2e" {
+		t.Fatalf("expected secret to be created in the test namespace, got %q", secret.ObjectMeta.Namespace)
+	}
+
+	// objects deleted by the test are skipped
+	err = cs.CoreV1().HTTPTriggers("e2e").Delete(ht.ObjectMeta.Name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Cleanup()
+
+	if _, err := cs.CoreV1().Environments("e2e").Get(env.ObjectMeta.Name, metav1.GetOptions{}); !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected environment to be deleted, got %v", err)
+	}
+	if _, err := kubeClient.CoreV1().Secrets("e2e").Get(secret.ObjectMeta.Name, metav1.GetOptions{}); !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected secret to be deleted, got %v", err)
+	}
+
+	// the last created object is deleted first
+	var deleted []string
+	for _, action := range cs.Actions() {
+		if action.GetVerb() == "delete" {
+			deleted = append(deleted, action.GetResource().Resource)
+		}
+	}
+	expected := []string{"httptriggers", "httptriggers", "functions", "packages", "environments"}
+	if strings.Join(deleted, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected deletions %v, got %v", expected, deleted)
+	}
+}
+
+func TestExpectResponse(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/fission-function/e2e/hello" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// the first request comes before the function is specialized
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("hello, world"))
+	}))
+	defer server.Close()
+
+	f, _, _ := makeTestFramework(t, server.URL)
+	resp := f.ExpectResponse(http.MethodGet, f.FunctionURL("hello"), nil, "hello")
+	if string(resp.Body) != "hello, world" || calls != 2 {
+		t.Fatalf("expected a retry until the function responds, got %q after %v calls", resp.Body, calls)
+	}
+}
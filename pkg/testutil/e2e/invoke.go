@@ -0,0 +1,117 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/fission/fission/pkg/utils"
+)
+
+// Response is the response of an invocation.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// routerURL returns the URL of a path on the router.
+func (f *Framework) routerURL(path string) string {
+	base := f.config.RouterURL
+	if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+		base = "http://" + base
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// FunctionURL returns the path of the internal route of a function of the
+// test namespace, which exists without any HTTP trigger.
+func (f *Framework) FunctionURL(fn string) string {
+	return utils.UrlForFunction(fn, f.config.Namespace)
+}
+
+// Invoke sends a request to a path of the router, like the relative URL of
+// an HTTP trigger or the FunctionURL of a function.
+func (f *Framework) Invoke(method, path string, body []byte, header http.Header) (*Response, error) {
+	req, err := http.NewRequest(method, f.routerURL(path), bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "error making request")
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error sending request to %v", path)
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading response of %v", path)
+	}
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       respBody,
+	}, nil
+}
+
+// ExpectResponse sends a request to a path of the router until it gets a
+// 200 response containing expect, like test_response of the shell tests.
+// The test fails if it gets none within the timeout.
+func (f *Framework) ExpectResponse(method, path string, body []byte, expect string) *Response {
+	f.tb.Helper()
+	var resp *Response
+	f.WaitFor(fmt.Sprintf("%v %v to respond with %q", method, path, expect), func() (bool, error) {
+		var err error
+		resp, err = f.Invoke(method, path, body, nil)
+		if err != nil {
+			f.Logf("%v, retrying", err)
+			return false, nil
+		}
+		if resp.StatusCode != http.StatusOK || !bytes.Contains(resp.Body, []byte(expect)) {
+			f.Logf("got status %v and body %q, retrying", resp.StatusCode, resp.Body)
+			return false, nil
+		}
+		return true, nil
+	})
+	return resp
+}
+
+// ExpectStatus sends a request to a path of the router until it gets a
+// response of the given status code. The test fails if it gets none
+// within the timeout.
+func (f *Framework) ExpectStatus(method, path string, body []byte, statusCode int) *Response {
+	f.tb.Helper()
+	var resp *Response
+	f.WaitFor(fmt.Sprintf("%v %v to respond with status %v", method, path, statusCode), func() (bool, error) {
+		var err error
+		resp, err = f.Invoke(method, path, body, nil)
+		if err != nil {
+			f.Logf("%v, retrying", err)
+			return false, nil
+		}
+		return resp.StatusCode == statusCode, nil
+	})
+	return resp
+}
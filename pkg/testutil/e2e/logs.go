@@ -0,0 +1,128 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// components are the Fission components whose logs are dumped for failed
+// tests, by the "svc" label of their pods.
+var components = []string{"controller", "executor", "router", "buildermgr", "storagesvc"}
+
+// functionPods returns the pods of a function of the test namespace.
+func (f *Framework) functionPods(fn string) ([]apiv1.Pod, error) {
+	selector := labels.Set{
+		fv1.FUNCTION_NAME:      fn,
+		fv1.FUNCTION_NAMESPACE: f.config.Namespace,
+	}.AsSelector().String()
+	pods, err := f.KubeClient.CoreV1().Pods(f.config.FunctionNamespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error listing pods of function %v", fn)
+	}
+	return pods.Items, nil
+}
+
+// podsLogs returns the logs of all the containers of pods, each preceded
+// by the name of its pod and container.
+func (f *Framework) podsLogs(pods []apiv1.Pod) (string, error) {
+	var b strings.Builder
+	for _, pod := range pods {
+		for _, c := range pod.Spec.Containers {
+			logs, err := f.KubeClient.CoreV1().Pods(pod.ObjectMeta.Namespace).
+				GetLogs(pod.ObjectMeta.Name, &apiv1.PodLogOptions{Container: c.Name}).DoRaw()
+			if err != nil {
+				return b.String(), errors.Wrapf(err, "error getting logs of container %v of pod %v", c.Name, pod.ObjectMeta.Name)
+			}
+			fmt.Fprintf(&b, "--- logs of pod %v: container %v ---\n%s\n", pod.ObjectMeta.Name, c.Name, logs)
+		}
+	}
+	return b.String(), nil
+}
+
+// FunctionLogs returns the logs of the pods of a function of the test
+// namespace.
+func (f *Framework) FunctionLogs(fn string) (string, error) {
+	pods, err := f.functionPods(fn)
+	if err != nil {
+		return "", err
+	}
+	return f.podsLogs(pods)
+}
+
+// ComponentLogs returns the logs of a Fission component, like "router" or
+// "executor".
+func (f *Framework) ComponentLogs(component string) (string, error) {
+	selector := labels.Set{"svc": component}.AsSelector().String()
+	pods, err := f.KubeClient.CoreV1().Pods(f.config.FissionNamespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return "", errors.Wrapf(err, "error listing pods of %v", component)
+	}
+	return f.podsLogs(pods.Items)
+}
+
+// WaitForFunctionLog waits for the logs of a function of the test
+// namespace to contain a string, e.g. one logged by an invocation from a
+// trigger.
+func (f *Framework) WaitForFunctionLog(fn, expect string) {
+	f.tb.Helper()
+	f.WaitFor(fmt.Sprintf("logs of function %v to contain %q", fn, expect), func() (bool, error) {
+		logs, err := f.FunctionLogs(fn)
+		if err != nil {
+			f.Logf("%v, retrying", err)
+			return false, nil
+		}
+		return strings.Contains(logs, expect), nil
+	})
+}
+
+// DumpLogs logs the logs of the functions created by the test and of the
+// Fission components, like dump_logs of the shell tests.
+func (f *Framework) DumpLogs() {
+	f.tb.Helper()
+	f.lock.Lock()
+	var fns []string
+	for _, obj := range f.created {
+		if fn, ok := obj.(*fv1.Function); ok {
+			fns = append(fns, fn.ObjectMeta.Name)
+		}
+	}
+	f.lock.Unlock()
+
+	for _, fn := range fns {
+		logs, err := f.FunctionLogs(fn)
+		if err != nil {
+			f.Logf("error getting logs of function %v: %v", fn, err)
+		}
+		f.tb.Logf("--- logs of function %v ---\n%v", fn, logs)
+	}
+	for _, component := range components {
+		logs, err := f.ComponentLogs(component)
+		if err != nil {
+			f.Logf("error getting logs of %v: %v", component, err)
+		}
+		f.tb.Logf("--- logs of %v ---\n%v", component, logs)
+	}
+}
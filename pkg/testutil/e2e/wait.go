@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// WaitForBuild waits for the build of a package of the test namespace to
+// end with the given status. It fails the test early if the build ends
+// with another status.
+func (f *Framework) WaitForBuild(pkg string, status fv1.BuildStatus) *fv1.Package {
+	f.tb.Helper()
+	var p *fv1.Package
+	f.WaitFor(fmt.Sprintf("package %v to build with status %v", pkg, status), func() (bool, error) {
+		var err error
+		p, err = f.FissionClient.CoreV1().Packages(f.config.Namespace).Get(pkg, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		switch p.Status.BuildStatus {
+		case status:
+			return true, nil
+		case fv1.BuildStatusSucceeded, fv1.BuildStatusFailed:
+			return false, fmt.Errorf("build ended with status %v: %v", p.Status.BuildStatus, p.Status.BuildLog)
+		}
+		return false, nil
+	})
+	return p
+}
+
+// WaitForBuilder waits for the builder pods of an environment of the test
+// namespace to be ready.
+func (f *Framework) WaitForBuilder(env string) {
+	f.tb.Helper()
+	selector := labels.Set{
+		"envName":      env,
+		"envNamespace": f.config.Namespace,
+	}.AsSelector().String()
+	f.WaitFor(fmt.Sprintf("builder of environment %v", env), func() (bool, error) {
+		pods, err := f.KubeClient.CoreV1().Pods(f.config.BuilderNamespace).List(metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return false, err
+		}
+		for _, pod := range pods.Items {
+			if podReady(&pod) {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+func podReady(pod *apiv1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == apiv1.PodReady {
+			return cond.Status == apiv1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// WaitForFunctionCondition waits for a condition of the status of a
+// function of the test namespace to have the given status.
+func (f *Framework) WaitForFunctionCondition(fn string, condType fv1.FunctionConditionType, status apiv1.ConditionStatus) *fv1.Function {
+	f.tb.Helper()
+	var function *fv1.Function
+	f.WaitFor(fmt.Sprintf("condition %v of function %v to be %v", condType, fn, status), func() (bool, error) {
+		var err error
+		function, err = f.FissionClient.CoreV1().Functions(f.config.Namespace).Get(fn, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		cond := function.Status.GetCondition(condType)
+		return cond != nil && cond.Status == status, nil
+	})
+	return function
+}
+
+// WaitForDeletion waits for the pods of a function of the test namespace
+// to be gone, e.g. after its idle timeout.
+func (f *Framework) WaitForDeletion(fn string) {
+	f.tb.Helper()
+	f.WaitFor(fmt.Sprintf("pods of function %v to be deleted", fn), func() (bool, error) {
+		pods, err := f.functionPods(fn)
+		if err != nil {
+			return false, err
+		}
+		return len(pods) == 0, nil
+	})
+}
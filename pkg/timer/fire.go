@@ -0,0 +1,129 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package timer
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron"
+	"go.uber.org/zap"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	// maxCatchUpFires limits the missed fires of a trigger replayed at once.
+	maxCatchUpFires = 100
+
+	maxClaimAttempts = 5
+)
+
+// claimFire records the fire of a trigger scheduled at the given time in its
+// annotations, unless one scheduled at or after it is recorded already. The
+// update is conditional on the resource version of the trigger, so that of
+// the replicas of timer racing to fire a trigger only one claims the fire.
+func (timer *Timer) claimFire(t *fv1.TimeTrigger, scheduled time.Time) (bool, error) {
+	if timer.fissionClient == nil {
+		return true, nil
+	}
+
+	triggers := timer.fissionClient.CoreV1().TimeTriggers(t.ObjectMeta.Namespace)
+	for i := 0; i < maxClaimAttempts; i++ {
+		trigger, err := triggers.Get(t.ObjectMeta.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, errors.Wrap(err, "error getting time trigger")
+		}
+
+		last, ok := lastFireTime(trigger)
+		if ok && !last.Before(scheduled) {
+			return false, nil
+		}
+
+		if trigger.ObjectMeta.Annotations == nil {
+			trigger.ObjectMeta.Annotations = make(map[string]string)
+		}
+		trigger.ObjectMeta.Annotations[fv1.ANNOTATION_TIMER_LAST_FIRE_TIME] = scheduled.UTC().Format(time.RFC3339)
+		_, err = triggers.Update(trigger)
+		if k8serrors.IsConflict(err) {
+			continue
+		}
+		if err != nil {
+			return false, errors.Wrap(err, "error updating time trigger")
+		}
+		return true, nil
+	}
+	return false, errors.Errorf("time trigger kept being updated in %v attempts", maxClaimAttempts)
+}
+
+// catchUp fires the fires of a trigger missed since the last recorded one,
+// within its catch-up window, in order.
+func (timer *Timer) catchUp(t fv1.TimeTrigger, sched cron.Schedule, location *time.Location) {
+	last, ok := lastFireTime(&t)
+	if !ok {
+		return
+	}
+	missed := missedFires(sched, last.In(location), time.Now().In(location),
+		time.Duration(t.Spec.CatchUpWindow)*time.Second)
+	if len(missed) == 0 {
+		return
+	}
+
+	timer.logger.Info("catching up on missed fires of time trigger",
+		zap.String("trigger", t.ObjectMeta.Name), zap.String("namespace", t.ObjectMeta.Namespace),
+		zap.Time("last_fire", last), zap.Int("missed", len(missed)))
+	for _, scheduled := range missed {
+		timer.fire(t, scheduled)
+	}
+}
+
+// lastFireTime returns the scheduled time of the last recorded fire of a trigger.
+func lastFireTime(t *fv1.TimeTrigger) (time.Time, bool) {
+	last, err := time.Parse(time.RFC3339, t.ObjectMeta.Annotations[fv1.ANNOTATION_TIMER_LAST_FIRE_TIME])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return last, true
+}
+
+// missedFires returns the times a schedule fires at after the last fire up
+// to now, that are within the window before now. The last of them are
+// returned if there are more than maxCatchUpFires.
+func missedFires(sched cron.Schedule, last time.Time, now time.Time, window time.Duration) []time.Time {
+	// fires before the window aren't iterated through, frequent ones of a
+	// trigger not fired for long are many
+	start := now.Add(-window)
+	if last.After(start) {
+		start = last
+	} else {
+		start = start.Add(-time.Second)
+	}
+
+	var missed []time.Time
+	for next := sched.Next(start); !next.IsZero() && !next.After(now); next = sched.Next(next) {
+		if next.Before(now.Add(-window)) {
+			continue
+		}
+		missed = append(missed, next)
+		if len(missed) > maxCatchUpFires {
+			missed = missed[1:]
+		}
+	}
+	return missed
+}
@@ -17,15 +17,33 @@ limitations under the License.
 package timer
 
 import (
+	"context"
+	"os"
+	"time"
+
 	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
 	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 
 	"github.com/fission/fission/pkg/crd"
 	"github.com/fission/fission/pkg/publisher"
 )
 
-func Start(logger *zap.Logger, routerUrl string) error {
-	fissionClient, _, _, _, err := crd.MakeFissionClient()
+const (
+	// leaderElectionLockName is the name of the config map replicas of
+	// timer elect the one firing time triggers with.
+	leaderElectionLockName = "fission-timer"
+
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+)
+
+func Start(logger *zap.Logger, routerUrl string, storageSvcUrl string) error {
+	fissionClient, kubeClient, _, _, err := crd.MakeFissionClient()
 	if err != nil {
 		return errors.Wrap(err, "failed to get fission or kubernetes client")
 	}
@@ -35,8 +53,61 @@ func Start(logger *zap.Logger, routerUrl string) error {
 		return errors.Wrap(err, "error waiting for CRDs")
 	}
 
-	poster := publisher.MakeWebhookPublisher(logger, routerUrl)
-	MakeTimerSync(logger, fissionClient, MakeTimer(logger, poster))
+	run := func() {
+		poster := publisher.MakeWebhookPublisher(logger, routerUrl)
+		output := makeOutputDeliverer(logger, routerUrl, storageSvcUrl, fissionClient, kubeClient)
+		MakeTimerSync(logger, fissionClient, MakeTimer(logger, poster, output, fissionClient))
+	}
+
+	namespace := os.Getenv("POD_NAMESPACE")
+	if len(namespace) == 0 {
+		logger.Info("POD_NAMESPACE not set, firing time triggers without leader election")
+		run()
+		return nil
+	}
+	return runAsLeader(logger, kubeClient, namespace, run)
+}
+
+// runAsLeader runs timer once elected the leader of its replicas, for one of
+// them to fire time triggers. A replica losing the leadership exits, to start
+// over as a candidate.
+func runAsLeader(logger *zap.Logger, kubeClient kubernetes.Interface, namespace string, run func()) error {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return errors.Wrap(err, "error getting hostname")
+	}
+	id := hostname + "_" + uuid.NewV4().String()
+
+	lock, err := resourcelock.New(resourcelock.ConfigMapsResourceLock, namespace, leaderElectionLockName,
+		kubeClient.CoreV1(), kubeClient.CoordinationV1(), resourcelock.ResourceLockConfig{Identity: id})
+	if err != nil {
+		return errors.Wrap(err, "error creating leader election lock")
+	}
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: leaseDuration,
+		RenewDeadline: renewDeadline,
+		RetryPeriod:   retryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				logger.Info("elected leader of timer replicas", zap.String("id", id))
+				run()
+			},
+			OnStoppedLeading: func() {
+				logger.Fatal("lost leadership of timer replicas", zap.String("id", id))
+			},
+			OnNewLeader: func(identity string) {
+				if identity != id {
+					logger.Info("timer replica elected leader", zap.String("leader", identity))
+				}
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "error creating leader elector")
+	}
 
+	go elector.Run(context.Background())
 	return nil
 }
@@ -0,0 +1,350 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package timer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+	storageSvcClient "github.com/fission/fission/pkg/storagesvc/client"
+	"github.com/fission/fission/pkg/utils"
+)
+
+const (
+	// maxOutputSize limits the size of function responses timer delivers.
+	maxOutputSize = 64 << 20
+
+	defaultSMTPPort = "587"
+)
+
+type (
+	// outputDeliverer invokes the functions of time triggers with an output,
+	// and delivers their responses to the storage service or by email.
+	outputDeliverer struct {
+		logger        *zap.Logger
+		routerURL     string
+		httpClient    *http.Client
+		fissionClient *crd.FissionClient
+		kubeClient    *kubernetes.Clientset
+		storageClient *storageSvcClient.Client
+	}
+
+	// functionOutput is the response of a function invoked by a time trigger.
+	functionOutput struct {
+		contentType string
+		body        []byte
+		time        time.Time
+	}
+
+	smtpSettings struct {
+		host     string
+		port     string
+		username string
+		password string
+		from     string
+	}
+)
+
+func makeOutputDeliverer(logger *zap.Logger, routerURL string, storageSvcURL string,
+	fissionClient *crd.FissionClient, kubeClient *kubernetes.Clientset) *outputDeliverer {
+	return &outputDeliverer{
+		logger:        logger.Named("output_deliverer"),
+		routerURL:     strings.TrimSuffix(routerURL, "/"),
+		httpClient:    &http.Client{},
+		fissionClient: fissionClient,
+		kubeClient:    kubeClient,
+		storageClient: storageSvcClient.MakeClient(storageSvcURL),
+	}
+}
+
+// invokeAndDeliver invokes the function of the trigger with the payload and
+// delivers its response.
+func (od *outputDeliverer) invokeAndDeliver(t *fv1.TimeTrigger, payload string, headers map[string]string) {
+	logger := od.logger.With(zap.String("trigger", t.ObjectMeta.Name), zap.String("namespace", t.ObjectMeta.Namespace))
+
+	timeout := time.Duration(t.Spec.Output.Timeout) * time.Second
+	if t.Spec.Output.Timeout == 0 {
+		timeout = fv1.DefaultTimeTriggerOutputTimeout * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	output, err := od.invoke(ctx, t, payload, headers)
+	if err != nil {
+		logger.Error("error invoking function of time trigger with output", zap.Error(err))
+		return
+	}
+
+	switch t.Spec.Output.Type {
+	case fv1.TimeTriggerOutputTypeStorage:
+		err = od.deliverToStorage(ctx, t, output)
+	case fv1.TimeTriggerOutputTypeEmail:
+		err = od.deliverByEmail(t, output)
+	default:
+		err = errors.Errorf("unsupported output type %v", t.Spec.Output.Type)
+	}
+	if err != nil {
+		logger.Error("error delivering output of time trigger", zap.Error(err))
+		return
+	}
+	logger.Info("delivered output of time trigger",
+		zap.String("type", string(t.Spec.Output.Type)),
+		zap.Int("size", len(output.body)))
+}
+
+func (od *outputDeliverer) invoke(ctx context.Context, t *fv1.TimeTrigger, payload string, headers map[string]string) (*functionOutput, error) {
+	url := od.routerURL + utils.UrlForFunction(t.Spec.FunctionReference.Name, t.ObjectMeta.Namespace)
+	req, err := http.NewRequest(triggerMethod(t), url, strings.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := od.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "error sending request to function")
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxOutputSize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading function response")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, errors.Errorf("function returned status %v: %v", resp.StatusCode, string(truncate(body, 512)))
+	}
+	if len(body) > maxOutputSize {
+		return nil, errors.Errorf("function response exceeds the %v bytes limit", maxOutputSize)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if len(contentType) == 0 {
+		contentType = http.DetectContentType(body)
+	}
+	return &functionOutput{
+		contentType: contentType,
+		body:        body,
+		time:        time.Now().UTC(),
+	}, nil
+}
+
+// deliverToStorage uploads the output to the storage service, and records
+// its ID in the annotations of the trigger.
+func (od *outputDeliverer) deliverToStorage(ctx context.Context, t *fv1.TimeTrigger, output *functionOutput) error {
+	tmpFile, err := ioutil.TempFile("", "timer-output-")
+	if err != nil {
+		return errors.Wrap(err, "error creating temporary file")
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.Write(output.body)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrap(err, "error writing temporary file")
+	}
+
+	id, err := od.storageClient.Upload(ctx, tmpFile.Name(), &map[string]string{
+		"trigger":     t.ObjectMeta.Name,
+		"namespace":   t.ObjectMeta.Namespace,
+		"contentType": output.contentType,
+	})
+	if err != nil {
+		return errors.Wrap(err, "error uploading output to storage service")
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				fv1.ANNOTATION_TIMER_LAST_OUTPUT_ID:   id,
+				fv1.ANNOTATION_TIMER_LAST_OUTPUT_TIME: output.time.Format(time.RFC3339),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = od.fissionClient.CoreV1().TimeTriggers(t.ObjectMeta.Namespace).Patch(t.ObjectMeta.Name, k8stypes.MergePatchType, patch)
+	if err != nil {
+		return errors.Wrapf(err, "error recording output %v in time trigger", id)
+	}
+	return nil
+}
+
+func (od *outputDeliverer) deliverByEmail(t *fv1.TimeTrigger, output *functionOutput) error {
+	config := t.Spec.Output.Email
+	settings, err := od.getSMTPSettings(t.ObjectMeta.Namespace, config.SMTPSecret)
+	if err != nil {
+		return err
+	}
+
+	subject := config.Subject
+	if len(subject) == 0 {
+		subject = fmt.Sprintf("Report of %v", t.ObjectMeta.Name)
+	}
+	msg, err := buildReportEmail(settings.from, config.To, subject, t.ObjectMeta.Name, output)
+	if err != nil {
+		return errors.Wrap(err, "error building email")
+	}
+
+	var auth smtp.Auth
+	if len(settings.username) > 0 {
+		auth = smtp.PlainAuth("", settings.username, settings.password, settings.host)
+	}
+	err = smtp.SendMail(net.JoinHostPort(settings.host, settings.port), auth, settings.from, config.To, msg)
+	if err != nil {
+		return errors.Wrap(err, "error sending email")
+	}
+	return nil
+}
+
+func (od *outputDeliverer) getSMTPSettings(namespace string, secretName string) (*smtpSettings, error) {
+	secret, err := od.kubeClient.CoreV1().Secrets(namespace).Get(secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting SMTP secret %v", secretName)
+	}
+
+	settings := &smtpSettings{
+		host:     string(secret.Data["host"]),
+		port:     string(secret.Data["port"]),
+		username: string(secret.Data["username"]),
+		password: string(secret.Data["password"]),
+		from:     string(secret.Data["from"]),
+	}
+	if len(settings.host) == 0 || len(settings.from) == 0 {
+		return nil, errors.Errorf("SMTP secret %v must have the keys host and from", secretName)
+	}
+	if len(settings.port) == 0 {
+		settings.port = defaultSMTPPort
+	}
+	return settings, nil
+}
+
+// buildReportEmail builds a MIME message carrying the output of a trigger.
+// Text outputs are the body of the message, others are attached to it.
+func buildReportEmail(from string, to []string, subject string, triggerName string, output *functionOutput) ([]byte, error) {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %v\r\n", from)
+	fmt.Fprintf(&msg, "To: %v\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %v\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %v\r\n", output.time.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+
+	mediaType, _, err := mime.ParseMediaType(output.contentType)
+	if err == nil && strings.HasPrefix(mediaType, "text/") {
+		fmt.Fprintf(&msg, "Content-Type: %v\r\n", output.contentType)
+		msg.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+		writeBase64Lines(&msg, output.body)
+		return msg.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&msg)
+	fmt.Fprintf(&msg, "Content-Type: multipart/mixed; boundary=%v\r\n\r\n", mw.Boundary())
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(part, "Output of time trigger %v at %v is attached.\r\n", triggerName, output.time.Format(time.RFC3339))
+
+	fileName := fmt.Sprintf("%v-%v%v", triggerName, output.time.Format("20060102-150405"), extensionForContentType(output.contentType))
+	part, err = mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {output.contentType},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": fileName})},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var encoded bytes.Buffer
+	writeBase64Lines(&encoded, output.body)
+	if _, err = part.Write(encoded.Bytes()); err != nil {
+		return nil, err
+	}
+
+	if err = mw.Close(); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
+
+// writeBase64Lines writes data in base64, in lines of 76 characters as required by MIME.
+func writeBase64Lines(w *bytes.Buffer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		w.WriteString(encoded[:76])
+		w.WriteString("\r\n")
+		encoded = encoded[76:]
+	}
+	if len(encoded) > 0 {
+		w.WriteString(encoded)
+		w.WriteString("\r\n")
+	}
+}
+
+func extensionForContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	switch mediaType {
+	case "application/json":
+		return ".json"
+	case "application/pdf":
+		return ".pdf"
+	case "application/zip":
+		return ".zip"
+	case "application/octet-stream":
+		return ".bin"
+	}
+	if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+func truncate(b []byte, n int) []byte {
+	if len(b) > n {
+		return b[:n]
+	}
+	return b
+}
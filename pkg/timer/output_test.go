@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package timer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildReportEmail(t *testing.T) {
+	now := time.Date(2020, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	// text output is the body of the message
+	msg, err := buildReportEmail("reports@example.com", []string{"a@example.com", "b@example.com"}, "Daily report",
+		"daily", &functionOutput{contentType: "text/html; charset=utf-8", body: []byte("<h1>Report</h1>"), time: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := mail.ReadMessage(bytes.NewReader(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Header.Get("To") != "a@example.com, b@example.com" || m.Header.Get("Subject") != "Daily report" {
+		t.Errorf("unexpected headers %v", m.Header)
+	}
+	if m.Header.Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Errorf("unexpected content type %q", m.Header.Get("Content-Type"))
+	}
+	body, _ := ioutil.ReadAll(base64.NewDecoder(base64.StdEncoding, m.Body))
+	if string(body) != "<h1>Report</h1>" {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	// other outputs are attached
+	data := bytes.Repeat([]byte{0, 1, 2, 3}, 100)
+	msg, err = buildReportEmail("reports@example.com", []string{"a@example.com"}, "Daily report",
+		"daily", &functionOutput{contentType: "application/pdf", body: data, time: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err = mail.ReadMessage(bytes.NewReader(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("unexpected content type %q", m.Header.Get("Content-Type"))
+	}
+	mr := multipart.NewReader(m.Body, params["boundary"])
+	if _, err = mr.NextPart(); err != nil {
+		t.Fatal(err)
+	}
+	attachment, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attachment.FileName() != "daily-20200501-080000.pdf" {
+		t.Errorf("unexpected attachment name %q", attachment.FileName())
+	}
+	encoded, _ := ioutil.ReadAll(attachment)
+	for _, line := range strings.Split(strings.TrimSpace(string(encoded)), "\r\n") {
+		if len(line) > 76 {
+			t.Fatalf("base64 line longer than 76 characters: %q", line)
+		}
+	}
+	decoded, _ := ioutil.ReadAll(base64.NewDecoder(base64.StdEncoding, bytes.NewReader(encoded)))
+	if !bytes.Equal(decoded, data) {
+		t.Error("attachment doesn't match output")
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+type (
+	// atSchedule fires once at a time.
+	atSchedule struct {
+		at time.Time
+	}
+
+	// everySchedule fires at every multiple of an interval since the zero
+	// time, so that the fires of all replicas of timer are aligned.
+	everySchedule struct {
+		every time.Duration
+	}
+)
+
+// Parse returns the schedule of a time trigger: its cron spec, the time to
+// fire it once at, or the interval to fire it at.
+func Parse(spec *fv1.TimeTriggerSpec) (cron.Schedule, error) {
+	switch {
+	case len(spec.Cron) > 0:
+		return cron.Parse(spec.Cron)
+	case len(spec.At) > 0:
+		at, err := time.Parse(time.RFC3339, spec.At)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing time %q", spec.At)
+		}
+		return atSchedule{at: at}, nil
+	case len(spec.Every) > 0:
+		every, err := time.ParseDuration(spec.Every)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing interval %q", spec.Every)
+		}
+		if every < time.Second {
+			return nil, errors.Errorf("interval %q is shorter than 1s", spec.Every)
+		}
+		return everySchedule{every: every}, nil
+	}
+	return nil, errors.New("time trigger has no cron, at or every schedule")
+}
+
+// Describe returns the schedule of a time trigger in short, like
+// "0 30 * * * *", "at 2021-06-01T09:00:00Z" or "every 1h".
+func Describe(spec *fv1.TimeTriggerSpec) string {
+	switch {
+	case len(spec.At) > 0:
+		return "at " + spec.At
+	case len(spec.Every) > 0:
+		return "every " + spec.Every
+	}
+	return spec.Cron
+}
+
+// Jitter returns a random delay up to the jitter of a time trigger.
+func Jitter(spec *fv1.TimeTriggerSpec) time.Duration {
+	if len(spec.Jitter) == 0 {
+		return 0
+	}
+	jitter, err := time.ParseDuration(spec.Jitter)
+	if err != nil || jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(jitter)))
+}
+
+// Next returns the time to fire at, or the zero time once fired.
+func (s atSchedule) Next(t time.Time) time.Time {
+	if t.Before(s.at) {
+		return s.at.In(t.Location())
+	}
+	return time.Time{}
+}
+
+// Next returns the next multiple of the interval after t.
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Truncate(s.every).Add(s.every)
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Date(2021, 6, 1, 8, 59, 30, 0, time.UTC)
+
+	for _, test := range []struct {
+		name string
+		spec fv1.TimeTriggerSpec
+		next []time.Time
+	}{
+		{
+			name: "cron",
+			spec: fv1.TimeTriggerSpec{Cron: "0 0 9 * * *"},
+			next: []time.Time{
+				time.Date(2021, 6, 1, 9, 0, 0, 0, time.UTC),
+				time.Date(2021, 6, 2, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "at",
+			spec: fv1.TimeTriggerSpec{At: "2021-06-01T11:00:00+02:00"},
+			next: []time.Time{
+				time.Date(2021, 6, 1, 9, 0, 0, 0, time.UTC),
+				{},
+			},
+		},
+		{
+			name: "every",
+			spec: fv1.TimeTriggerSpec{Every: "20m"},
+			next: []time.Time{
+				time.Date(2021, 6, 1, 9, 0, 0, 0, time.UTC),
+				time.Date(2021, 6, 1, 9, 20, 0, 0, time.UTC),
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := Parse(&test.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			next := now
+			for _, expected := range test.next {
+				next = s.Next(next)
+				if !next.Equal(expected) {
+					t.Fatalf("expected next fire at %v, got %v", expected, next)
+				}
+			}
+		})
+	}
+
+	for _, spec := range []fv1.TimeTriggerSpec{
+		{},
+		{At: "tomorrow"},
+		{Every: "500ms"},
+	} {
+		if _, err := Parse(&spec); err == nil {
+			t.Errorf("expected error parsing schedule of %+v", spec)
+		}
+	}
+}
+
+func TestJitter(t *testing.T) {
+	if Jitter(&fv1.TimeTriggerSpec{}) != 0 {
+		t.Error("expected no jitter")
+	}
+	for i := 0; i < 100; i++ {
+		jitter := Jitter(&fv1.TimeTriggerSpec{Jitter: "10s"})
+		if jitter < 0 || jitter >= 10*time.Second {
+			t.Fatalf("jitter %v out of range", jitter)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	for _, test := range []struct {
+		spec     fv1.TimeTriggerSpec
+		expected string
+	}{
+		{spec: fv1.TimeTriggerSpec{Cron: "@hourly"}, expected: "@hourly"},
+		{spec: fv1.TimeTriggerSpec{At: "2021-06-01T09:00:00Z"}, expected: "at 2021-06-01T09:00:00Z"},
+		{spec: fv1.TimeTriggerSpec{Every: "1h", Timezone: "Europe/Paris"}, expected: "every 1h"},
+	} {
+		if d := Describe(&test.spec); d != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, d)
+		}
+	}
+}
@@ -17,12 +17,21 @@ limitations under the License.
 package timer
 
 import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
 	"github.com/robfig/cron"
 	"go.uber.org/zap"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/crd"
 	"github.com/fission/fission/pkg/publisher"
+	"github.com/fission/fission/pkg/timer/schedule"
 	"github.com/fission/fission/pkg/utils"
 )
 
@@ -38,6 +47,8 @@ type (
 		triggers       map[string]*timerTriggerWithCron
 		requestChannel chan *timerRequest
 		publisher      *publisher.Publisher
+		output         *outputDeliverer
+		fissionClient  *crd.FissionClient
 	}
 
 	timerRequest struct {
@@ -52,14 +63,25 @@ type (
 		trigger fv1.TimeTrigger
 		cron    *cron.Cron
 	}
+
+	// payloadData is the data payload templates of time triggers are
+	// executed with.
+	payloadData struct {
+		Time      time.Time
+		Trigger   string
+		Namespace string
+		Timezone  string
+	}
 )
 
-func MakeTimer(logger *zap.Logger, publisher publisher.Publisher) *Timer {
+func MakeTimer(logger *zap.Logger, publisher publisher.Publisher, output *outputDeliverer, fissionClient *crd.FissionClient) *Timer {
 	timer := &Timer{
 		logger:         logger.Named("timer"),
 		triggers:       make(map[string]*timerTriggerWithCron),
 		requestChannel: make(chan *timerRequest),
 		publisher:      &publisher,
+		output:         output,
+		fissionClient:  fissionClient,
 	}
 	go timer.svc()
 	return timer
@@ -93,8 +115,9 @@ func (timer *Timer) syncCron(triggers []fv1.TimeTrigger) error {
 	for _, t := range triggers {
 		triggerMap[crd.CacheKey(&t.ObjectMeta)] = true
 		if item, ok := timer.triggers[crd.CacheKey(&t.ObjectMeta)]; ok {
-			// update cron if the cron spec changed
-			if item.trigger.Spec.Cron != t.Spec.Cron {
+			// update cron if the spec changed, as crons invoke functions with
+			// the spec they are created with
+			if !reflect.DeepEqual(item.trigger.Spec, t.Spec) {
 				// if there is an cron running, stop it
 				if item.cron != nil {
 					item.cron.Stop()
@@ -126,18 +149,122 @@ func (timer *Timer) syncCron(triggers []fv1.TimeTrigger) error {
 }
 
 func (timer *Timer) newCron(t fv1.TimeTrigger) *cron.Cron {
-	c := cron.New()
-	c.AddFunc(t.Spec.Cron, func() { //nolint: errCheck
-		headers := map[string]string{
-			"X-Fission-Timer-Name": t.ObjectMeta.Name,
+	logger := timer.logger.With(zap.String("trigger", t.ObjectMeta.Name), zap.String("namespace", t.ObjectMeta.Namespace))
+
+	location := time.UTC
+	if len(t.Spec.Timezone) > 0 {
+		loc, err := time.LoadLocation(t.Spec.Timezone)
+		if err != nil {
+			logger.Error("error loading time zone of time trigger, using UTC", zap.String("timezone", t.Spec.Timezone), zap.Error(err))
+		} else {
+			location = loc
 		}
+	}
 
-		// with the addition of multi-tenancy, the users can create functions in any namespace. however,
-		// the triggers can only be created in the same namespace as the function.
-		// so essentially, function namespace = trigger namespace.
-		(*timer.publisher).Publish("", headers, utils.UrlForFunction(t.Spec.FunctionReference.Name, t.ObjectMeta.Namespace))
-	})
+	sched, err := schedule.Parse(&t.Spec)
+	if err != nil {
+		logger.Error("error parsing schedule of time trigger", zap.Error(err))
+		return nil
+	}
+
+	c := cron.NewWithLocation(location)
+	c.Schedule(sched, cron.FuncJob(func() {
+		// cron runs jobs at the second they are scheduled at
+		scheduled := time.Now().In(location).Truncate(time.Second)
+		if jitter := schedule.Jitter(&t.Spec); jitter > 0 {
+			time.Sleep(jitter)
+		}
+		timer.fire(t, scheduled)
+	}))
 	c.Start()
-	timer.logger.Info("added new cron for time trigger", zap.String("trigger", t.ObjectMeta.Name))
+	logger.Info("added new cron for time trigger", zap.String("location", location.String()))
+
+	if t.Spec.CatchUpWindow > 0 {
+		go timer.catchUp(t, sched, location)
+	}
 	return c
 }
+
+// fire invokes the function of a trigger for its fire scheduled at the
+// given time, once it's claimed.
+func (timer *Timer) fire(t fv1.TimeTrigger, scheduled time.Time) {
+	logger := timer.logger.With(zap.String("trigger", t.ObjectMeta.Name), zap.String("namespace", t.ObjectMeta.Namespace),
+		zap.Time("scheduled", scheduled))
+
+	claimed, err := timer.claimFire(&t, scheduled)
+	if err != nil {
+		logger.Error("error recording fire of time trigger, skipping it", zap.Error(err))
+		return
+	}
+	if !claimed {
+		logger.Debug("time trigger fired already by another replica")
+		return
+	}
+
+	body, err := renderPayload(&t, scheduled)
+	if err != nil {
+		logger.Error("error rendering payload of time trigger", zap.Error(err))
+		return
+	}
+
+	headers := make(map[string]string, len(t.Spec.Headers)+7)
+	if len(body) > 0 {
+		headers["Content-Type"] = "application/json"
+	}
+	for k, v := range t.Spec.Headers {
+		headers[k] = v
+	}
+	headers["X-Fission-Timer-Name"] = t.ObjectMeta.Name
+	headers[fv1.HeaderTriggerType] = fv1.TriggerTypeTime
+	headers[fv1.HeaderTriggerName] = t.ObjectMeta.Name
+	headers[fv1.HeaderCloudEventsMode] = string(t.Spec.CloudEvents)
+	headers[fv1.HeaderEventTime] = scheduled.UTC().Format(time.RFC3339Nano)
+	headers[fv1.HeaderPriority] = string(fv1.InvocationPriorityBatch)
+
+	// the response of a trigger with output is delivered, so the
+	// function is invoked directly instead of through the publisher
+	if t.Spec.Output != nil && timer.output != nil {
+		timer.output.invokeAndDeliver(&t, body, headers)
+		return
+	}
+
+	// with the addition of multi-tenancy, the users can create functions in any namespace. however,
+	// the triggers can only be created in the same namespace as the function.
+	// so essentially, function namespace = trigger namespace.
+	(*timer.publisher).PublishWithMethod(triggerMethod(&t), body, headers, utils.UrlForFunction(t.Spec.FunctionReference.Name, t.ObjectMeta.Namespace))
+}
+
+// renderPayload executes the payload template of a trigger for an
+// invocation at the given time, in the time zone of the trigger.
+func renderPayload(t *fv1.TimeTrigger, now time.Time) (string, error) {
+	if len(t.Spec.Payload) == 0 {
+		return "", nil
+	}
+
+	tmpl, err := template.New("payload").Parse(t.Spec.Payload)
+	if err != nil {
+		return "", errors.Wrap(err, "error parsing payload template")
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, payloadData{
+		Time:      now,
+		Trigger:   t.ObjectMeta.Name,
+		Namespace: t.ObjectMeta.Namespace,
+		Timezone:  now.Location().String(),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "error executing payload template")
+	}
+	if !json.Valid(buf.Bytes()) {
+		return "", errors.Errorf("payload is not valid JSON: %v", buf.String())
+	}
+	return buf.String(), nil
+}
+
+// triggerMethod returns the HTTP method to invoke the function of a trigger with.
+func triggerMethod(t *fv1.TimeTrigger) string {
+	if len(t.Spec.Method) == 0 {
+		return http.MethodPost
+	}
+	return t.Spec.Method
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package timer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/timer/schedule"
+)
+
+func TestRenderPayload(t *testing.T) {
+	location, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+	now := time.Date(2021, 3, 31, 23, 30, 0, 0, time.UTC).In(location)
+
+	trigger := &fv1.TimeTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "daily", Namespace: "reports"},
+	}
+
+	// no payload invokes the function with an empty body
+	payload, err := renderPayload(trigger, now)
+	if err != nil || payload != "" {
+		t.Errorf("unexpected payload %q, error %v", payload, err)
+	}
+
+	trigger.Spec.Payload = `{"date": "{{ .Time.Format "2006-01-02" }}", "at": "{{ .Time.Format "15:04" }}", ` +
+		`"trigger": "{{ .Namespace }}/{{ .Trigger }}", "tz": "{{ .Timezone }}"}`
+	payload, err = renderPayload(trigger, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"date": "2021-04-01", "at": "08:30", "trigger": "reports/daily", "tz": "Asia/Tokyo"}`
+	if payload != expected {
+		t.Errorf("expected payload %q, got %q", expected, payload)
+	}
+
+	// payloads must be JSON once executed
+	trigger.Spec.Payload = `{"date": {{ .Time.Format "2006-01-02" }}}`
+	if _, err = renderPayload(trigger, now); err == nil {
+		t.Error("expected error for payload that is not JSON")
+	}
+
+	trigger.Spec.Payload = `{"date": "{{ .Date }}"}`
+	if _, err = renderPayload(trigger, now); err == nil {
+		t.Error("expected error for unknown template field")
+	}
+}
+
+func TestMissedFires(t *testing.T) {
+	now := time.Date(2021, 4, 1, 12, 0, 30, 0, time.UTC)
+	hourly, err := schedule.Parse(&fv1.TimeTriggerSpec{Every: "1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	everySecond, err := schedule.Parse(&fv1.TimeTriggerSpec{Every: "1s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fires after the last one up to now
+	missed := missedFires(hourly, now.Add(-3*time.Hour), now, 24*time.Hour)
+	expected := []time.Time{
+		time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC),
+		time.Date(2021, 4, 1, 11, 0, 0, 0, time.UTC),
+		time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(missed, expected) {
+		t.Errorf("expected missed fires %v, got %v", expected, missed)
+	}
+
+	// within the window
+	missed = missedFires(hourly, now.Add(-3*time.Hour), now, 90*time.Minute)
+	if !reflect.DeepEqual(missed, expected[1:]) {
+		t.Errorf("expected missed fires %v, got %v", expected[1:], missed)
+	}
+
+	// none missed
+	missed = missedFires(hourly, time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC), now, 24*time.Hour)
+	if len(missed) != 0 {
+		t.Errorf("expected no missed fires, got %v", missed)
+	}
+
+	// the last ones of too many
+	missed = missedFires(everySecond, now.Add(-24*time.Hour), now, time.Hour)
+	if len(missed) != maxCatchUpFires || !missed[len(missed)-1].Equal(now) {
+		t.Errorf("expected the last %v fires up to %v, got %v of them up to %v",
+			maxCatchUpFires, now, len(missed), missed[len(missed)-1])
+	}
+}
+
+func TestLastFireTime(t *testing.T) {
+	trigger := &fv1.TimeTrigger{}
+	if _, ok := lastFireTime(trigger); ok {
+		t.Error("expected no last fire time")
+	}
+
+	trigger.ObjectMeta.Annotations = map[string]string{fv1.ANNOTATION_TIMER_LAST_FIRE_TIME: "2021-04-01T12:00:00Z"}
+	last, ok := lastFireTime(trigger)
+	if !ok || !last.Equal(time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected last fire time %v", last)
+	}
+}
@@ -0,0 +1,373 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tombstone
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	k8sCache "k8s.io/client-go/tools/cache"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+	ferror "github.com/fission/fission/pkg/error"
+)
+
+// gcInterval is how often expired tombstones are deleted.
+const gcInterval = time.Hour
+
+// Keeper keeps tombstones of deleted objects in config maps and restores
+// objects from them.
+type Keeper struct {
+	logger           *zap.Logger
+	fissionClient    *crd.FissionClient
+	kubernetesClient *kubernetes.Clientset
+	namespace        string
+	retention        time.Duration
+}
+
+// MakeKeeper returns a keeper storing tombstones in the given namespace for
+// the retention window. Tombstones are not kept if retention is zero.
+func MakeKeeper(logger *zap.Logger, fissionClient *crd.FissionClient, kubernetesClient *kubernetes.Clientset,
+	namespace string, retention time.Duration) *Keeper {
+	return &Keeper{
+		logger:           logger.Named("tombstone_keeper"),
+		fissionClient:    fissionClient,
+		kubernetesClient: kubernetesClient,
+		namespace:        namespace,
+		retention:        retention,
+	}
+}
+
+// Run keeps tombstones of objects deleted, no matter if through Fission or
+// with kubectl, and deletes expired ones until stopCh is closed.
+func (k *Keeper) Run(stopCh <-chan struct{}) {
+	if k.retention <= 0 {
+		k.logger.Info("tombstones of deleted objects are disabled")
+		return
+	}
+
+	for _, kind := range Kinds {
+		go k.watchDeletes(kind, stopCh)
+	}
+
+	ticker := time.NewTicker(gcInterval)
+	defer ticker.Stop()
+	for {
+		k.deleteExpired()
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (k *Keeper) watchDeletes(kind Kind, stopCh <-chan struct{}) {
+	resource, objType := kind.resource()
+	lw := k8sCache.NewListWatchFromClient(k.fissionClient.CoreV1().RESTClient(), resource, metav1.NamespaceAll, fields.Everything())
+	_, controller := k8sCache.NewInformer(lw, objType, 0, k8sCache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			if state, ok := obj.(k8sCache.DeletedFinalStateUnknown); ok {
+				obj = state.Obj
+			}
+			k.keep(kind, obj)
+		},
+	})
+	controller.Run(stopCh)
+}
+
+func (k *Keeper) keep(kind Kind, obj interface{}) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		k.logger.Error("error getting metadata of deleted object", zap.Error(err), zap.String("kind", string(kind)))
+		return
+	}
+	logger := k.logger.With(zap.String("kind", string(kind)),
+		zap.String("name", accessor.GetName()), zap.String("namespace", accessor.GetNamespace()))
+
+	t, err := MakeTombstone(kind, accessor, time.Now(), k.retention)
+	if err != nil {
+		logger.Error("error making tombstone", zap.Error(err))
+		return
+	}
+	cm, err := t.ConfigMap(k.namespace)
+	if err != nil {
+		logger.Error("error encoding tombstone", zap.Error(err))
+		return
+	}
+	_, err = k.kubernetesClient.CoreV1().ConfigMaps(k.namespace).Create(cm)
+	if err != nil && !kerrors.IsAlreadyExists(err) {
+		logger.Error("error keeping tombstone", zap.Error(err))
+		return
+	}
+	logger.Info("kept tombstone of deleted object", zap.Time("expires_at", t.ExpiresAt))
+}
+
+func (k *Keeper) deleteExpired() {
+	tombstones, err := k.List("", "", "")
+	if err != nil {
+		k.logger.Error("error listing tombstones", zap.Error(err))
+		return
+	}
+	now := time.Now()
+	for _, t := range tombstones {
+		if !t.IsExpired(now) {
+			continue
+		}
+		err = k.kubernetesClient.CoreV1().ConfigMaps(k.namespace).Delete(t.ConfigMapName(), &metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			k.logger.Error("error deleting expired tombstone", zap.Error(err), zap.String("config_map", t.ConfigMapName()))
+		}
+	}
+}
+
+// List returns the tombstones of the given kind, namespace and name, most
+// recently deleted first. Empty arguments match all.
+func (k *Keeper) List(kind Kind, namespace string, name string) ([]Tombstone, error) {
+	selector := map[string]string{LabelTombstone: "true"}
+	if len(kind) > 0 {
+		selector[LabelKind] = string(kind)
+	}
+	cms, err := k.kubernetesClient.CoreV1().ConfigMaps(k.namespace).List(metav1.ListOptions{
+		LabelSelector: labels.Set(selector).AsSelector().String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tombstones := []Tombstone{}
+	for i := range cms.Items {
+		t, err := FromConfigMap(&cms.Items[i])
+		if err != nil {
+			k.logger.Error("ignoring invalid tombstone", zap.Error(err))
+			continue
+		}
+		if (len(namespace) > 0 && t.Namespace != namespace) || (len(name) > 0 && t.Name != name) {
+			continue
+		}
+		tombstones = append(tombstones, *t)
+	}
+	sort.Slice(tombstones, func(i, j int) bool {
+		return tombstones[i].DeletedAt.After(tombstones[j].DeletedAt)
+	})
+	return tombstones, nil
+}
+
+// Restore recreates an object from its most recent tombstone, or the
+// tombstone with the given UID, and drops the tombstone. The package of a
+// function is restored as well if it was deleted too.
+func (k *Keeper) Restore(kind Kind, namespace string, name string, uid string) (*metav1.ObjectMeta, error) {
+	t, err := k.find(kind, namespace, name, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := k.restore(t)
+	if err != nil {
+		return nil, err
+	}
+
+	err = k.kubernetesClient.CoreV1().ConfigMaps(k.namespace).Delete(t.ConfigMapName(), &metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		k.logger.Error("error deleting tombstone of restored object", zap.Error(err), zap.String("config_map", t.ConfigMapName()))
+	}
+	return m, nil
+}
+
+func (k *Keeper) find(kind Kind, namespace string, name string, uid string) (*Tombstone, error) {
+	tombstones, err := k.List(kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tombstones {
+		if len(uid) == 0 || tombstones[i].UID == uid {
+			return &tombstones[i], nil
+		}
+	}
+	return nil, ferror.MakeError(ferror.ErrorNotFound, fmt.Sprintf("no tombstone of %v %v/%v", kind, namespace, name))
+}
+
+func (k *Keeper) restore(t *Tombstone) (*metav1.ObjectMeta, error) {
+	client := k.fissionClient.CoreV1()
+
+	switch t.Kind {
+	case KindFunction:
+		fn := &fv1.Function{}
+		if err := t.Decode(fn); err != nil {
+			return nil, err
+		}
+		resetObjectMeta(&fn.ObjectMeta)
+		if err := k.restoreFunctionPackage(fn); err != nil {
+			return nil, err
+		}
+		fn, err := client.Functions(fn.ObjectMeta.Namespace).Create(fn)
+		if err != nil {
+			return nil, err
+		}
+		return &fn.ObjectMeta, nil
+
+	case KindPackage:
+		pkg := &fv1.Package{}
+		if err := t.Decode(pkg); err != nil {
+			return nil, err
+		}
+		resetObjectMeta(&pkg.ObjectMeta)
+		pkg, err := client.Packages(pkg.ObjectMeta.Namespace).Create(pkg)
+		if err != nil {
+			return nil, err
+		}
+		return &pkg.ObjectMeta, nil
+
+	case KindEnvironment:
+		env := &fv1.Environment{}
+		if err := t.Decode(env); err != nil {
+			return nil, err
+		}
+		resetObjectMeta(&env.ObjectMeta)
+		env, err := client.Environments(env.ObjectMeta.Namespace).Create(env)
+		if err != nil {
+			return nil, err
+		}
+		return &env.ObjectMeta, nil
+
+	case KindHTTPTrigger:
+		ht := &fv1.HTTPTrigger{}
+		if err := t.Decode(ht); err != nil {
+			return nil, err
+		}
+		resetObjectMeta(&ht.ObjectMeta)
+		ht, err := client.HTTPTriggers(ht.ObjectMeta.Namespace).Create(ht)
+		if err != nil {
+			return nil, err
+		}
+		return &ht.ObjectMeta, nil
+
+	case KindMessageQueueTrigger:
+		mqt := &fv1.MessageQueueTrigger{}
+		if err := t.Decode(mqt); err != nil {
+			return nil, err
+		}
+		resetObjectMeta(&mqt.ObjectMeta)
+		mqt, err := client.MessageQueueTriggers(mqt.ObjectMeta.Namespace).Create(mqt)
+		if err != nil {
+			return nil, err
+		}
+		return &mqt.ObjectMeta, nil
+
+	case KindTimeTrigger:
+		tt := &fv1.TimeTrigger{}
+		if err := t.Decode(tt); err != nil {
+			return nil, err
+		}
+		resetObjectMeta(&tt.ObjectMeta)
+		tt, err := client.TimeTriggers(tt.ObjectMeta.Namespace).Create(tt)
+		if err != nil {
+			return nil, err
+		}
+		return &tt.ObjectMeta, nil
+
+	case KindKubernetesWatchTrigger:
+		w := &fv1.KubernetesWatchTrigger{}
+		if err := t.Decode(w); err != nil {
+			return nil, err
+		}
+		resetObjectMeta(&w.ObjectMeta)
+		w, err := client.KubernetesWatchTriggers(w.ObjectMeta.Namespace).Create(w)
+		if err != nil {
+			return nil, err
+		}
+		return &w.ObjectMeta, nil
+	}
+
+	return nil, ferror.MakeError(ferror.ErrorInvalidArgument, fmt.Sprintf("tombstones are not kept for kind %v", t.Kind))
+}
+
+// restoreFunctionPackage restores the package of a function from its
+// tombstone if the package doesn't exist, and points the function to the
+// current version of the package.
+func (k *Keeper) restoreFunctionPackage(fn *fv1.Function) error {
+	ref := &fn.Spec.Package.PackageRef
+	if len(ref.Name) == 0 {
+		return nil
+	}
+
+	pkg, err := k.fissionClient.CoreV1().Packages(ref.Namespace).Get(ref.Name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		t, findErr := k.find(KindPackage, ref.Namespace, ref.Name, "")
+		if findErr != nil {
+			return ferror.MakeError(ferror.ErrorNotFound,
+				fmt.Sprintf("package %v/%v of the function doesn't exist and has no tombstone", ref.Namespace, ref.Name))
+		}
+		var m *metav1.ObjectMeta
+		m, err = k.restore(t)
+		if err != nil {
+			return err
+		}
+		err = k.kubernetesClient.CoreV1().ConfigMaps(k.namespace).Delete(t.ConfigMapName(), &metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			k.logger.Error("error deleting tombstone of restored package", zap.Error(err), zap.String("config_map", t.ConfigMapName()))
+		}
+		ref.ResourceVersion = m.ResourceVersion
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	ref.ResourceVersion = pkg.ObjectMeta.ResourceVersion
+	return nil
+}
+
+// resetObjectMeta drops the metadata kubernetes set on the deleted object,
+// as well as owners that may be gone.
+func resetObjectMeta(m *metav1.ObjectMeta) {
+	*m = metav1.ObjectMeta{
+		Name:        m.Name,
+		Namespace:   m.Namespace,
+		Labels:      m.Labels,
+		Annotations: m.Annotations,
+	}
+}
+
+func (kind Kind) resource() (string, runtime.Object) {
+	switch kind {
+	case KindFunction:
+		return "functions", &fv1.Function{}
+	case KindPackage:
+		return "packages", &fv1.Package{}
+	case KindEnvironment:
+		return "environments", &fv1.Environment{}
+	case KindHTTPTrigger:
+		return "httptriggers", &fv1.HTTPTrigger{}
+	case KindMessageQueueTrigger:
+		return "messagequeuetriggers", &fv1.MessageQueueTrigger{}
+	case KindTimeTrigger:
+		return "timetriggers", &fv1.TimeTrigger{}
+	case KindKubernetesWatchTrigger:
+		return "kuberneteswatchtriggers", &fv1.KubernetesWatchTrigger{}
+	}
+	return "", nil
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tombstone keeps the last spec of deleted Fission objects for a
+// retention window, so that objects deleted by mistake can be restored.
+package tombstone
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	KindFunction               Kind = "function"
+	KindPackage                Kind = "package"
+	KindEnvironment            Kind = "environment"
+	KindHTTPTrigger            Kind = "httptrigger"
+	KindMessageQueueTrigger    Kind = "mqtrigger"
+	KindTimeTrigger            Kind = "timetrigger"
+	KindKubernetesWatchTrigger Kind = "watch"
+
+	// LabelTombstone marks the config maps tombstones are stored in.
+	LabelTombstone = "fission.io/tombstone"
+	// LabelKind is the kind of the deleted object.
+	LabelKind = "fission.io/tombstone-kind"
+
+	dataKey = "tombstone.json"
+)
+
+// Kinds are the kinds of objects tombstones are kept for.
+var Kinds = []Kind{
+	KindFunction,
+	KindPackage,
+	KindEnvironment,
+	KindHTTPTrigger,
+	KindMessageQueueTrigger,
+	KindTimeTrigger,
+	KindKubernetesWatchTrigger,
+}
+
+type (
+	Kind string
+
+	// Tombstone is the last spec of a deleted object.
+	Tombstone struct {
+		Kind      Kind      `json:"kind"`
+		Namespace string    `json:"namespace"`
+		Name      string    `json:"name"`
+		UID       string    `json:"uid"`
+		DeletedAt time.Time `json:"deletedAt"`
+		ExpiresAt time.Time `json:"expiresAt"`
+
+		// ArchiveURLs are the archives in the storage service the object
+		// references, which are kept as long as the tombstone.
+		ArchiveURLs []string `json:"archiveUrls,omitempty"`
+
+		// Object is the JSON form of the object as it was when deleted.
+		Object json.RawMessage `json:"object"`
+	}
+)
+
+// IsValid returns true if tombstones are kept for objects of the kind.
+func (kind Kind) IsValid() bool {
+	for _, k := range Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// MakeTombstone returns the tombstone of an object deleted at the given
+// time, which expires after the retention window.
+func MakeTombstone(kind Kind, obj metav1.Object, deletedAt time.Time, retention time.Duration) (*Tombstone, error) {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding deleted object")
+	}
+
+	t := &Tombstone{
+		Kind:      kind,
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		UID:       string(obj.GetUID()),
+		DeletedAt: deletedAt.UTC(),
+		ExpiresAt: deletedAt.Add(retention).UTC(),
+		Object:    raw,
+	}
+	if pkg, ok := obj.(*fv1.Package); ok {
+		for _, archive := range []fv1.Archive{pkg.Spec.Source, pkg.Spec.Deployment} {
+			if len(archive.URL) > 0 {
+				t.ArchiveURLs = append(t.ArchiveURLs, archive.URL)
+			}
+		}
+	}
+	return t, nil
+}
+
+// ConfigMapName returns the name of the config map the tombstone is stored in,
+// objects of the same name deleted at different times get a tombstone each.
+func (t *Tombstone) ConfigMapName() string {
+	return "tombstone-" + t.UID
+}
+
+// ConfigMap returns the config map to store the tombstone in.
+func (t *Tombstone) ConfigMap(namespace string) (*apiv1.ConfigMap, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      t.ConfigMapName(),
+			Namespace: namespace,
+			Labels: map[string]string{
+				LabelTombstone: "true",
+				LabelKind:      string(t.Kind),
+			},
+		},
+		Data: map[string]string{
+			dataKey: string(data),
+		},
+	}, nil
+}
+
+// FromConfigMap returns the tombstone stored in a config map.
+func FromConfigMap(cm *apiv1.ConfigMap) (*Tombstone, error) {
+	data, ok := cm.Data[dataKey]
+	if !ok {
+		return nil, errors.Errorf("config map %v/%v has no tombstone", cm.ObjectMeta.Namespace, cm.ObjectMeta.Name)
+	}
+	t := &Tombstone{}
+	err := json.Unmarshal([]byte(data), t)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error decoding tombstone in config map %v/%v", cm.ObjectMeta.Namespace, cm.ObjectMeta.Name)
+	}
+	return t, nil
+}
+
+// IsExpired returns true if the retention window of the tombstone has passed.
+func (t *Tombstone) IsExpired(now time.Time) bool {
+	return now.After(t.ExpiresAt)
+}
+
+// Decode decodes the deleted object into obj.
+func (t *Tombstone) Decode(obj interface{}) error {
+	err := json.Unmarshal(t.Object, obj)
+	if err != nil {
+		return errors.Wrapf(err, "error decoding deleted %v %v/%v", t.Kind, t.Namespace, t.Name)
+	}
+	return nil
+}
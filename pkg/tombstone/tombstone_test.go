@@ -0,0 +1,79 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tombstone
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestTombstone(t *testing.T) {
+	pkg := &fv1.Package{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hello-pkg",
+			Namespace: "bar",
+			UID:       "0b9a5d9c-6d0f-4d9a-9c3b-1e4f5d6a7b8c",
+		},
+		Spec: fv1.PackageSpec{
+			Environment: fv1.EnvironmentReference{Name: "nodejs", Namespace: "bar"},
+			Source:      fv1.Archive{Type: fv1.ArchiveTypeUrl, URL: "http://storagesvc/v1/archive?id=source"},
+			Deployment:  fv1.Archive{Type: fv1.ArchiveTypeLiteral, Literal: []byte("module.exports = 1")},
+		},
+	}
+	deletedAt := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tombstone, err := MakeTombstone(KindPackage, pkg, deletedAt, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tombstone.ArchiveURLs, []string{"http://storagesvc/v1/archive?id=source"}) {
+		t.Errorf("unexpected archive URLs %v", tombstone.ArchiveURLs)
+	}
+	if tombstone.IsExpired(deletedAt.Add(23*time.Hour)) || !tombstone.IsExpired(deletedAt.Add(25*time.Hour)) {
+		t.Errorf("tombstone expected to expire at %v, expires at %v", deletedAt.Add(24*time.Hour), tombstone.ExpiresAt)
+	}
+
+	cm, err := tombstone.ConfigMap("fission")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cm.ObjectMeta.Name != "tombstone-"+string(pkg.ObjectMeta.UID) || cm.ObjectMeta.Labels[LabelKind] != string(KindPackage) {
+		t.Errorf("unexpected config map metadata %+v", cm.ObjectMeta)
+	}
+
+	stored, err := FromConfigMap(cm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name != pkg.ObjectMeta.Name || stored.Namespace != pkg.ObjectMeta.Namespace || !stored.DeletedAt.Equal(deletedAt) {
+		t.Errorf("unexpected tombstone %+v", stored)
+	}
+
+	restored := &fv1.Package{}
+	err = stored.Decode(restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restored.Spec, pkg.Spec) {
+		t.Errorf("expected package spec %+v, got %+v", pkg.Spec, restored.Spec)
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing instruments Fission components with OpenTelemetry.
+// The W3C trace context (traceparent and tracestate headers) is propagated
+// across components and to functions, and spans are exported to an OTLP
+// collector.
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpgrpc"
+	"go.opentelemetry.io/otel/propagation"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// EnvOTLPCollectorEndpoint is the environment variable holding the
+	// host:port of the OTLP gRPC collector spans are exported to.
+	EnvOTLPCollectorEndpoint = "TRACE_OTLP_COLLECTOR_ENDPOINT"
+
+	tracerName = "github.com/fission/fission"
+)
+
+func init() {
+	// the trace context is propagated even by components not exporting
+	// spans, so that traces are not broken by them
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// Init exports the spans of a service to the OTLP collector at endpoint.
+// The traces started by the service are sampled at samplingRate, the ones
+// started upstream as they were upstream. It returns a function flushing
+// the spans not exported yet, to be called on shutdown.
+func Init(ctx context.Context, serviceName string, endpoint string, samplingRate float64) (func(context.Context) error, error) {
+	// the collector is dialed with gRPC, without a scheme
+	endpoint = strings.TrimPrefix(strings.TrimPrefix(endpoint, "http://"), "https://")
+	exporter, err := otlp.NewExporter(ctx, otlpgrpc.NewDriver(
+		otlpgrpc.WithInsecure(),
+		otlpgrpc.WithEndpoint(endpoint),
+	))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating OTLP exporter for collector %q", endpoint)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(samplingRate))),
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(sdkresource.NewWithAttributes(
+			semconv.ServiceNameKey.String(serviceName),
+			attribute.Bool("fission", true),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Handler traces the requests served by h, continuing the traces of their
+// trace context.
+func Handler(h http.Handler, operation string, filters ...otelhttp.Filter) http.Handler {
+	opts := make([]otelhttp.Option, 0, len(filters))
+	for _, f := range filters {
+		opts = append(opts, otelhttp.WithFilter(f))
+	}
+	return otelhttp.NewHandler(h, operation, opts...)
+}
+
+// Transport traces the requests sent with base, http.DefaultTransport if
+// nil, and sets their trace context headers.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(base)
+}
+
+// StartSpan starts a span of an operation, child of the span of ctx if any.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan ends span, recording err if the operation failed.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Detach returns a context carrying the span of ctx, but not its deadline
+// or cancellation, for operations that outlive the request of ctx.
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+}
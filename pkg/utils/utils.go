@@ -199,6 +199,17 @@ func IsURL(str string) bool {
 	return strings.HasPrefix(str, "http://") || strings.HasPrefix(str, "https://")
 }
 
+// IsObjectStoreURL reports whether str is an s3://, gs:// or azblob:// URL,
+// which fetcher reads from object stores.
+func IsObjectStoreURL(str string) bool {
+	for _, scheme := range []string{fv1.ArchiveURLSchemeS3, fv1.ArchiveURLSchemeGCS, fv1.ArchiveURLSchemeAzure} {
+		if strings.HasPrefix(str, scheme+"://") {
+			return true
+		}
+	}
+	return false
+}
+
 func DownloadUrl(ctx context.Context, httpClient *http.Client, url string, localPath string) error {
 	resp, err := ctxhttp.Get(ctx, httpClient, url)
 	if err != nil {
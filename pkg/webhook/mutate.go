@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+type (
+	// defaulter fills the defaults of functions and environments.
+	defaulter struct {
+		policy *Policy
+	}
+
+	// patchOperation is an operation of a JSON patch.
+	patchOperation struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value,omitempty"`
+	}
+)
+
+// mutate decodes an object of the given kind and returns the JSON patch
+// filling its defaults, or nil if it has nothing to fill.
+func (d *defaulter) mutate(kind, namespace string, raw []byte) ([]byte, error) {
+	var spec, defaulted interface{}
+	switch kind {
+	case "Function":
+		fn := &fv1.Function{}
+		if err := json.Unmarshal(raw, fn); err != nil {
+			return nil, errors.Wrapf(err, "error decoding %v", kind)
+		}
+		if len(fn.ObjectMeta.Namespace) > 0 {
+			namespace = fn.ObjectMeta.Namespace
+		}
+		spec = fn.Spec.DeepCopy()
+		fn.Spec.SetDefaults(namespace)
+		d.policy.setDefaultRequests(&fn.Spec.Resources)
+		defaulted = fn.Spec
+	case "Environment":
+		env := &fv1.Environment{}
+		if err := json.Unmarshal(raw, env); err != nil {
+			return nil, errors.Wrapf(err, "error decoding %v", kind)
+		}
+		spec = env.Spec.DeepCopy()
+		env.Spec.SetDefaults()
+		d.policy.setDefaultRequests(&env.Spec.Resources)
+		defaulted = env.Spec
+	default:
+		return nil, nil
+	}
+
+	before, err := json.Marshal(spec)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding spec")
+	}
+	after, err := json.Marshal(defaulted)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding defaulted spec")
+	}
+	if bytes.Equal(before, after) {
+		return nil, nil
+	}
+
+	// replacing the whole spec keeps the patch simple, "add" replaces
+	// the spec if there is one already
+	return json.Marshal([]patchOperation{{
+		Op:    "add",
+		Path:  "/spec",
+		Value: defaulted,
+	}})
+}
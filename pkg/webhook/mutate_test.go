@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// applyPatch decodes the single spec replacement of a patch into spec.
+func applyPatch(t *testing.T, patch []byte, spec interface{}) {
+	var ops []struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 1 || ops[0].Op != "add" || ops[0].Path != "/spec" {
+		t.Fatalf("unexpected patch %s", patch)
+	}
+	if err := json.Unmarshal(ops[0].Value, spec); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMutateFunction(t *testing.T) {
+	d := &defaulter{policy: &Policy{
+		DefaultRequests: apiv1.ResourceList{
+			apiv1.ResourceCPU:    resource.MustParse("100m"),
+			apiv1.ResourceMemory: resource.MustParse("128Mi"),
+		},
+	}}
+
+	fn := &fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"},
+		Spec: fv1.FunctionSpec{
+			Environment: fv1.EnvironmentReference{Name: "nodejs"},
+			Resources: apiv1.ResourceRequirements{
+				Limits: apiv1.ResourceList{apiv1.ResourceMemory: resource.MustParse("64Mi")},
+			},
+		},
+	}
+	patch, err := d.mutate("Function", "default", mustMarshal(t, fn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := fv1.FunctionSpec{}
+	applyPatch(t, patch, &spec)
+
+	es := spec.InvokeStrategy.ExecutionStrategy
+	if es.ExecutorType != fv1.ExecutorTypePoolmgr {
+		t.Errorf("expected executor type %v, got %v", fv1.ExecutorTypePoolmgr, es.ExecutorType)
+	}
+	if spec.Environment.Namespace != "default" {
+		t.Errorf("expected environment namespace default, got %q", spec.Environment.Namespace)
+	}
+	if spec.IdleTimeout == nil || *spec.IdleTimeout != fv1.DefaultIdleTimeout {
+		t.Errorf("expected idle timeout %v, got %v", fv1.DefaultIdleTimeout, spec.IdleTimeout)
+	}
+	if spec.FunctionTimeout != fv1.DEFAULT_FUNCTION_TIMEOUT {
+		t.Errorf("expected function timeout %v, got %v", fv1.DEFAULT_FUNCTION_TIMEOUT, spec.FunctionTimeout)
+	}
+	cpu := spec.Resources.Requests[apiv1.ResourceCPU]
+	if cpu.String() != "100m" {
+		t.Errorf("expected cpu request 100m, got %v", cpu.String())
+	}
+	// default requests don't go over limits
+	memory := spec.Resources.Requests[apiv1.ResourceMemory]
+	if memory.String() != "64Mi" {
+		t.Errorf("expected memory request 64Mi, got %v", memory.String())
+	}
+
+	fn.Spec = spec
+	if patch, err := d.mutate("Function", "default", mustMarshal(t, fn)); err != nil || patch != nil {
+		t.Fatalf("expected no patch for a defaulted function, got %s, %v", patch, err)
+	}
+}
+
+func TestMutateEnvironment(t *testing.T) {
+	d := &defaulter{policy: &Policy{}}
+
+	env := &fv1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "default"},
+		Spec: fv1.EnvironmentSpec{
+			Runtime: fv1.Runtime{Image: "fission/node-env"},
+			Builder: fv1.Builder{Image: "fission/node-builder"},
+		},
+	}
+	patch, err := d.mutate("Environment", "default", mustMarshal(t, env))
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := fv1.EnvironmentSpec{}
+	applyPatch(t, patch, &spec)
+
+	if spec.Version != 2 {
+		t.Errorf("expected version 2 for an environment with a builder, got %v", spec.Version)
+	}
+	if spec.Builder.Command != fv1.DefaultBuildCommand {
+		t.Errorf("expected builder command %v, got %q", fv1.DefaultBuildCommand, spec.Builder.Command)
+	}
+	if spec.TerminationGracePeriod != fv1.DefaultTerminationGracePeriod {
+		t.Errorf("expected termination grace period %v, got %v", fv1.DefaultTerminationGracePeriod, spec.TerminationGracePeriod)
+	}
+
+	if patch, err := d.mutate("HTTPTrigger", "default", []byte("{}")); err != nil || patch != nil {
+		t.Fatalf("expected no patch for other kinds, got %s, %v", patch, err)
+	}
+	if _, err := d.mutate("Environment", "default", []byte("{")); err == nil {
+		t.Fatal("expected an error for an invalid object")
+	}
+}
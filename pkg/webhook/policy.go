@@ -0,0 +1,179 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// Policy is the cluster policy Fission objects are validated against on
+// top of their own validation, and the defaults they get.
+type Policy struct {
+	// AllowedImages are the patterns the images of environments have to
+	// match, like "ghcr.io/fission/*". Any image is allowed if empty.
+	AllowedImages []string
+	// MaxCPU and MaxMemory, if set, are the largest CPU and memory
+	// requests and limits of functions and environments.
+	MaxCPU    *resource.Quantity
+	MaxMemory *resource.Quantity
+	// DefaultRequests are the resource requests functions and environments
+	// without ones get.
+	DefaultRequests apiv1.ResourceList
+}
+
+// PolicyFromEnv returns the policy set by the WEBHOOK_ALLOWED_IMAGES,
+// WEBHOOK_MAX_CPU, WEBHOOK_MAX_MEMORY, WEBHOOK_DEFAULT_CPU_REQUEST and
+// WEBHOOK_DEFAULT_MEMORY_REQUEST environment variables.
+func PolicyFromEnv() (*Policy, error) {
+	policy := &Policy{
+		DefaultRequests: apiv1.ResourceList{},
+	}
+	for _, pattern := range strings.Split(os.Getenv("WEBHOOK_ALLOWED_IMAGES"), ",") {
+		pattern = strings.TrimSpace(pattern)
+		if len(pattern) == 0 {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, errors.Wrapf(err, "error parsing allowed image pattern %q", pattern)
+		}
+		policy.AllowedImages = append(policy.AllowedImages, pattern)
+	}
+	for _, limit := range []struct {
+		env   string
+		value **resource.Quantity
+	}{
+		{"WEBHOOK_MAX_CPU", &policy.MaxCPU},
+		{"WEBHOOK_MAX_MEMORY", &policy.MaxMemory},
+	} {
+		val := os.Getenv(limit.env)
+		if len(val) == 0 {
+			continue
+		}
+		q, err := resource.ParseQuantity(val)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing %v", limit.env)
+		}
+		*limit.value = &q
+	}
+	for _, request := range []struct {
+		env  string
+		name apiv1.ResourceName
+	}{
+		{"WEBHOOK_DEFAULT_CPU_REQUEST", apiv1.ResourceCPU},
+		{"WEBHOOK_DEFAULT_MEMORY_REQUEST", apiv1.ResourceMemory},
+	} {
+		val := os.Getenv(request.env)
+		if len(val) == 0 {
+			continue
+		}
+		q, err := resource.ParseQuantity(val)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing %v", request.env)
+		}
+		policy.DefaultRequests[request.name] = q
+	}
+	return policy, nil
+}
+
+// setDefaultRequests sets the default requests of the resources without a
+// request, but no more than their limit.
+func (p *Policy) setDefaultRequests(resources *apiv1.ResourceRequirements) {
+	for name, request := range p.DefaultRequests {
+		if _, ok := resources.Requests[name]; ok {
+			continue
+		}
+		if limit, ok := resources.Limits[name]; ok && request.Cmp(limit) > 0 {
+			request = limit
+		}
+		if resources.Requests == nil {
+			resources.Requests = apiv1.ResourceList{}
+		}
+		resources.Requests[name] = request.DeepCopy()
+	}
+}
+
+// checkImage returns an error if an image doesn't match any of the allowed
+// patterns.
+func (p *Policy) checkImage(field, image string) error {
+	if len(p.AllowedImages) == 0 || len(image) == 0 {
+		return nil
+	}
+	for _, pattern := range p.AllowedImages {
+		if ok, _ := path.Match(pattern, image); ok {
+			return nil
+		}
+	}
+	return fv1.MakeValidationErr(fv1.ErrorInvalidValue, field, image,
+		fmt.Sprintf("image not allowed, allowed images: %v", strings.Join(p.AllowedImages, ", ")))
+}
+
+// checkPodSpecImages checks the images of all the containers of a pod spec.
+func (p *Policy) checkPodSpecImages(field string, spec *apiv1.PodSpec) error {
+	if spec == nil {
+		return nil
+	}
+	result := &multierror.Error{}
+	for i, c := range spec.InitContainers {
+		result = multierror.Append(result, p.checkImage(fmt.Sprintf("%v.InitContainers[%v].Image", field, i), c.Image))
+	}
+	for i, c := range spec.Containers {
+		result = multierror.Append(result, p.checkImage(fmt.Sprintf("%v.Containers[%v].Image", field, i), c.Image))
+	}
+	return result.ErrorOrNil()
+}
+
+// checkResources returns an error if resource requests or limits are over
+// the policy, or if requests are over limits, which Kubernetes would only
+// refuse once the pods are created.
+func (p *Policy) checkResources(field string, resources apiv1.ResourceRequirements) error {
+	result := &multierror.Error{}
+	for _, r := range []struct {
+		name apiv1.ResourceName
+		max  *resource.Quantity
+	}{
+		{apiv1.ResourceCPU, p.MaxCPU},
+		{apiv1.ResourceMemory, p.MaxMemory},
+	} {
+		request, hasRequest := resources.Requests[r.name]
+		limit, hasLimit := resources.Limits[r.name]
+		if r.max != nil {
+			if hasRequest && request.Cmp(*r.max) > 0 {
+				result = multierror.Append(result, fv1.MakeValidationErr(fv1.ErrorInvalidValue,
+					fmt.Sprintf("%v.Requests.%v", field, r.name), request.String(), fmt.Sprintf("over the maximum of %v", r.max.String())))
+			}
+			if hasLimit && limit.Cmp(*r.max) > 0 {
+				result = multierror.Append(result, fv1.MakeValidationErr(fv1.ErrorInvalidValue,
+					fmt.Sprintf("%v.Limits.%v", field, r.name), limit.String(), fmt.Sprintf("over the maximum of %v", r.max.String())))
+			}
+		}
+		if hasRequest && hasLimit && request.Cmp(limit) > 0 {
+			result = multierror.Append(result, fv1.MakeValidationErr(fv1.ErrorInvalidValue,
+				fmt.Sprintf("%v.Requests.%v", field, r.name), request.String(), fmt.Sprintf("over the limit of %v", limit.String())))
+		}
+	}
+	return result.ErrorOrNil()
+}
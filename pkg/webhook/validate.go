@@ -0,0 +1,273 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+)
+
+type (
+	// referenceChecker tells whether the objects referenced by other
+	// objects exist.
+	referenceChecker interface {
+		functionExists(namespace, name string) (bool, error)
+		environmentExists(namespace, name string) (bool, error)
+		packageExists(namespace, name string) (bool, error)
+	}
+
+	clientReferenceChecker struct {
+		fissionClient *crd.FissionClient
+	}
+
+	// validator validates Fission objects: their own validation, the
+	// cluster policy, and, if references is set, that the objects they
+	// reference exist.
+	validator struct {
+		policy     *Policy
+		references referenceChecker
+	}
+)
+
+func (c *clientReferenceChecker) functionExists(namespace, name string) (bool, error) {
+	_, err := c.fissionClient.CoreV1().Functions(namespace).Get(name, metav1.GetOptions{})
+	return exists(err)
+}
+
+func (c *clientReferenceChecker) environmentExists(namespace, name string) (bool, error) {
+	_, err := c.fissionClient.CoreV1().Environments(namespace).Get(name, metav1.GetOptions{})
+	return exists(err)
+}
+
+func (c *clientReferenceChecker) packageExists(namespace, name string) (bool, error) {
+	_, err := c.fissionClient.CoreV1().Packages(namespace).Get(name, metav1.GetOptions{})
+	return exists(err)
+}
+
+func exists(err error) (bool, error) {
+	if err == nil {
+		return true, nil
+	}
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// validate decodes an object of the given kind and validates it. Objects
+// without a namespace are in the namespace of the request. Kinds Fission
+// doesn't validate are always valid.
+func (v *validator) validate(kind, namespace string, raw []byte) error {
+	var obj interface {
+		metav1.Object
+		Validate() error
+	}
+	switch kind {
+	case "Function":
+		obj = &fv1.Function{}
+	case "Package":
+		obj = &fv1.Package{}
+	case "Environment":
+		obj = &fv1.Environment{}
+	case "HTTPTrigger":
+		obj = &fv1.HTTPTrigger{}
+	case "KubernetesWatchTrigger":
+		obj = &fv1.KubernetesWatchTrigger{}
+	case "TimeTrigger":
+		obj = &fv1.TimeTrigger{}
+	case "MessageQueueTrigger":
+		obj = &fv1.MessageQueueTrigger{}
+	case "FissionSource":
+		obj = &fv1.FissionSource{}
+	case "BucketTrigger":
+		obj = &fv1.BucketTrigger{}
+	case "DatabaseTrigger":
+		obj = &fv1.DatabaseTrigger{}
+	case "Workflow":
+		obj = &fv1.Workflow{}
+	default:
+		return nil
+	}
+	if err := json.Unmarshal(raw, obj); err != nil {
+		return errors.Wrapf(err, "error decoding %v", kind)
+	}
+	if len(obj.GetNamespace()) == 0 {
+		obj.SetNamespace(namespace)
+	}
+	if obj.GetDeletionTimestamp() != nil {
+		// let finalizers be removed from invalid objects
+		return nil
+	}
+
+	result := &multierror.Error{}
+	result = multierror.Append(result, obj.Validate())
+
+	var err error
+	switch o := obj.(type) {
+	case *fv1.Function:
+		err = v.validateFunction(o)
+	case *fv1.Package:
+		err = v.checkEnvironment("Package.Spec.Environment", o.Namespace, o.Spec.Environment)
+	case *fv1.Environment:
+		err = v.validateEnvironment(o)
+	case *fv1.HTTPTrigger:
+		err = v.validateHTTPTrigger(o)
+	case *fv1.KubernetesWatchTrigger:
+		err = v.checkFunctionReference("KubernetesWatchTrigger.Spec.FunctionReference", o.Namespace, o.Spec.FunctionReference)
+	case *fv1.TimeTrigger:
+		err = v.checkFunctionReference("TimeTrigger.Spec.FunctionReference", o.Namespace, o.Spec.FunctionReference)
+	case *fv1.MessageQueueTrigger:
+		err = v.checkFunctionReference("MessageQueueTrigger.Spec.FunctionReference", o.Namespace, o.Spec.FunctionReference)
+	case *fv1.BucketTrigger:
+		err = v.checkFunctionReference("BucketTrigger.Spec.FunctionReference", o.Namespace, o.Spec.FunctionReference)
+	case *fv1.DatabaseTrigger:
+		err = v.checkFunctionReference("DatabaseTrigger.Spec.FunctionReference", o.Namespace, o.Spec.FunctionReference)
+	case *fv1.Workflow:
+		err = v.validateWorkflow(o)
+	}
+	result = multierror.Append(result, err)
+
+	return fv1.AggregateValidationErrors(kind, result.ErrorOrNil())
+}
+
+func (v *validator) validateFunction(fn *fv1.Function) error {
+	result := &multierror.Error{}
+	result = multierror.Append(result,
+		v.policy.checkResources("Function.Spec.Resources", fn.Spec.Resources),
+		v.checkEnvironment("Function.Spec.Environment", fn.Namespace, fn.Spec.Environment))
+
+	if fn.Spec.Container != nil {
+		result = multierror.Append(result,
+			v.policy.checkImage("Function.Spec.Container.Image", fn.Spec.Container.Image))
+	}
+
+	if ref := fn.Spec.Package.PackageRef; len(ref.Name) > 0 {
+		namespace := ref.Namespace
+		if len(namespace) == 0 {
+			namespace = fn.Namespace
+		}
+		result = multierror.Append(result,
+			v.checkReference("Function.Spec.Package.PackageRef", "package", namespace, ref.Name))
+	}
+	return result.ErrorOrNil()
+}
+
+func (v *validator) validateEnvironment(env *fv1.Environment) error {
+	result := &multierror.Error{}
+	result = multierror.Append(result,
+		v.policy.checkResources("Environment.Spec.Resources", env.Spec.Resources),
+		v.policy.checkImage("Environment.Spec.Runtime.Image", env.Spec.Runtime.Image),
+		v.policy.checkPodSpecImages("Environment.Spec.Runtime.PodSpec", env.Spec.Runtime.PodSpec),
+		v.policy.checkImage("Environment.Spec.Builder.Image", env.Spec.Builder.Image),
+		v.policy.checkPodSpecImages("Environment.Spec.Builder.PodSpec", env.Spec.Builder.PodSpec))
+	return result.ErrorOrNil()
+}
+
+func (v *validator) validateHTTPTrigger(t *fv1.HTTPTrigger) error {
+	result := &multierror.Error{}
+	result = multierror.Append(result,
+		v.checkFunctionReference("HTTPTrigger.Spec.FunctionReference", t.Namespace, t.Spec.FunctionReference))
+	if t.Spec.Experiment != nil {
+		for i, variant := range t.Spec.Experiment.Variants {
+			result = multierror.Append(result,
+				v.checkFunction(fmt.Sprintf("HTTPTrigger.Spec.Experiment.Variants[%v].Function", i), t.Namespace, variant.Function))
+		}
+	}
+	return result.ErrorOrNil()
+}
+
+// checkFunctionReference checks the functions a function reference points to.
+func (v *validator) validateWorkflow(w *fv1.Workflow) error {
+	result := &multierror.Error{}
+	for _, step := range w.Spec.Steps {
+		if len(step.Function) > 0 {
+			result = multierror.Append(result, v.checkFunction("Workflow.Spec.Steps.Function", w.Namespace, step.Function))
+		}
+		for _, fn := range step.Parallel {
+			result = multierror.Append(result, v.checkFunction("Workflow.Spec.Steps.Parallel", w.Namespace, fn))
+		}
+	}
+	return result.ErrorOrNil()
+}
+
+func (v *validator) checkFunctionReference(field, namespace string, ref fv1.FunctionReference) error {
+	switch ref.Type {
+	case fv1.FunctionReferenceTypeFunctionName:
+		return v.checkFunction(field+".Name", namespace, ref.Name)
+	case fv1.FunctionReferenceTypeFunctionWeights:
+		names := make([]string, 0, len(ref.FunctionWeights))
+		for name := range ref.FunctionWeights {
+			names = append(names, name)
+		}
+		// keep the error messages stable
+		sort.Strings(names)
+		result := &multierror.Error{}
+		for _, name := range names {
+			result = multierror.Append(result, v.checkFunction(field+".FunctionWeights", namespace, name))
+		}
+		return result.ErrorOrNil()
+	}
+	return nil
+}
+
+func (v *validator) checkFunction(field, namespace, name string) error {
+	return v.checkReference(field, "function", namespace, name)
+}
+
+func (v *validator) checkEnvironment(field, namespace string, ref fv1.EnvironmentReference) error {
+	if len(ref.Namespace) > 0 {
+		namespace = ref.Namespace
+	}
+	return v.checkReference(field, "environment", namespace, ref.Name)
+}
+
+// checkReference returns an error if the function, environment or package a
+// field refers to doesn't exist, or can't be looked up.
+func (v *validator) checkReference(field, what, namespace, name string) error {
+	if v.references == nil || len(name) == 0 {
+		return nil
+	}
+	var ok bool
+	var err error
+	switch what {
+	case "function":
+		ok, err = v.references.functionExists(namespace, name)
+	case "environment":
+		ok, err = v.references.environmentExists(namespace, name)
+	case "package":
+		ok, err = v.references.packageExists(namespace, name)
+	default:
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "error looking up %v %v/%v", what, namespace, name)
+	}
+	if !ok {
+		return fv1.MakeValidationErr(fv1.ErrorInvalidValue, field, name,
+			fmt.Sprintf("%v %v/%v not found", what, namespace, name))
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// fakeReferences holds the existing objects as "kind/namespace/name".
+type fakeReferences map[string]bool
+
+func (f fakeReferences) functionExists(namespace, name string) (bool, error) {
+	return f["function/"+namespace+"/"+name], nil
+}
+
+func (f fakeReferences) environmentExists(namespace, name string) (bool, error) {
+	return f["environment/"+namespace+"/"+name], nil
+}
+
+func (f fakeReferences) packageExists(namespace, name string) (bool, error) {
+	return f["package/"+namespace+"/"+name], nil
+}
+
+func mustMarshal(t *testing.T, obj interface{}) []byte {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestValidateReferences(t *testing.T) {
+	v := &validator{
+		policy: &Policy{},
+		references: fakeReferences{
+			"environment/default/nodejs": true,
+			"package/default/hello-pkg":  true,
+			"function/default/hello":     true,
+		},
+	}
+
+	fn := &fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello"},
+		Spec: fv1.FunctionSpec{
+			Environment: fv1.EnvironmentReference{Name: "nodejs", Namespace: "default"},
+			Package: fv1.FunctionPackageRef{
+				PackageRef: fv1.PackageRef{Name: "hello-pkg", Namespace: "default"},
+			},
+			InvokeStrategy: fv1.InvokeStrategy{
+				StrategyType: fv1.StrategyTypeExecution,
+				ExecutionStrategy: fv1.ExecutionStrategy{
+					ExecutorType: fv1.ExecutorTypePoolmgr,
+				},
+			},
+		},
+	}
+	if err := v.validate("Function", "default", mustMarshal(t, fn)); err != nil {
+		t.Fatalf("expected function to be valid, got %v", err)
+	}
+
+	fn.Spec.Environment.Name = "python"
+	err := v.validate("Function", "default", mustMarshal(t, fn))
+	if err == nil || !strings.Contains(err.Error(), "environment default/python not found") {
+		t.Fatalf("expected missing environment error, got %v", err)
+	}
+
+	trigger := &fv1.HTTPTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"},
+		Spec: fv1.HTTPTriggerSpec{
+			RelativeURL: "/hello",
+			Method:      "GET",
+			FunctionReference: fv1.FunctionReference{
+				Type:            fv1.FunctionReferenceTypeFunctionWeights,
+				FunctionWeights: map[string]int{"hello": 90, "hello-v2": 10},
+			},
+		},
+	}
+	err = v.validate("HTTPTrigger", "default", mustMarshal(t, trigger))
+	if err == nil || !strings.Contains(err.Error(), "function default/hello-v2 not found") {
+		t.Fatalf("expected missing function error, got %v", err)
+	}
+
+	// references aren't checked without a reference checker
+	v.references = nil
+	if err := v.validate("HTTPTrigger", "default", mustMarshal(t, trigger)); err != nil {
+		t.Fatalf("expected trigger to be valid, got %v", err)
+	}
+
+	// other kinds are always valid
+	if err := v.validate("CanaryConfig", "default", []byte("{}")); err != nil {
+		t.Fatalf("expected unknown kind to be valid, got %v", err)
+	}
+}
+
+func TestValidateContainerFunction(t *testing.T) {
+	v := &validator{
+		policy:     &Policy{AllowedImages: []string{"ghcr.io/acme/*"}},
+		references: fakeReferences{"environment/default/nodejs": true},
+	}
+
+	fn := &fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello"},
+		Spec: fv1.FunctionSpec{
+			Container: &fv1.FunctionContainer{Image: "ghcr.io/acme/hello:1.0", Port: 8080},
+			InvokeStrategy: fv1.InvokeStrategy{
+				StrategyType: fv1.StrategyTypeExecution,
+				ExecutionStrategy: fv1.ExecutionStrategy{
+					ExecutorType:     fv1.ExecutorTypeNewdeploy,
+					MinScale:         1,
+					MaxScale:         1,
+					TargetCPUPercent: 80,
+				},
+			},
+		},
+	}
+	if err := v.validate("Function", "default", mustMarshal(t, fn)); err != nil {
+		t.Fatalf("expected container function to be valid, got %v", err)
+	}
+
+	fn.Spec.Container.Image = "docker.io/evil/miner"
+	if err := v.validate("Function", "default", mustMarshal(t, fn)); err == nil {
+		t.Error("expected image not allowed to be rejected")
+	}
+	fn.Spec.Container.Image = "ghcr.io/acme/hello:1.0"
+
+	fn.Spec.Environment = fv1.EnvironmentReference{Name: "nodejs", Namespace: "default"}
+	if err := v.validate("Function", "default", mustMarshal(t, fn)); err == nil {
+		t.Error("expected function with both a container and an environment to be rejected")
+	}
+	fn.Spec.Environment = fv1.EnvironmentReference{}
+
+	fn.Spec.InvokeStrategy.ExecutionStrategy = fv1.ExecutionStrategy{ExecutorType: fv1.ExecutorTypePoolmgr}
+	if err := v.validate("Function", "default", mustMarshal(t, fn)); err == nil {
+		t.Error("expected container function run by poolmgr to be rejected")
+	}
+}
+
+func TestPolicyImages(t *testing.T) {
+	p := &Policy{AllowedImages: []string{"ghcr.io/fission/*", "fission/node-env"}}
+	tests := []struct {
+		image   string
+		allowed bool
+	}{
+		{"ghcr.io/fission/python-env:1.2", true},
+		{"fission/node-env", true},
+		{"fission/node-env:latest", false},
+		{"docker.io/evil/miner", false},
+		{"ghcr.io/fission/nested/image", false},
+	}
+	for _, test := range tests {
+		if err := p.checkImage("Image", test.image); (err == nil) != test.allowed {
+			t.Errorf("image %v: expected allowed %v, got error %v", test.image, test.allowed, err)
+		}
+	}
+
+	spec := &apiv1.PodSpec{
+		Containers: []apiv1.Container{{Name: "sidecar", Image: "docker.io/evil/miner"}},
+	}
+	if err := p.checkPodSpecImages("PodSpec", spec); err == nil {
+		t.Error("expected pod spec image to be rejected")
+	}
+
+	if err := (&Policy{}).checkImage("Image", "docker.io/evil/miner"); err != nil {
+		t.Errorf("expected any image to be allowed without patterns, got %v", err)
+	}
+}
+
+func TestPolicyResources(t *testing.T) {
+	maxCPU := resource.MustParse("1")
+	maxMemory := resource.MustParse("512Mi")
+	p := &Policy{MaxCPU: &maxCPU, MaxMemory: &maxMemory}
+
+	resources := apiv1.ResourceRequirements{
+		Requests: apiv1.ResourceList{
+			apiv1.ResourceCPU:    resource.MustParse("100m"),
+			apiv1.ResourceMemory: resource.MustParse("128Mi"),
+		},
+		Limits: apiv1.ResourceList{
+			apiv1.ResourceCPU:    resource.MustParse("500m"),
+			apiv1.ResourceMemory: resource.MustParse("256Mi"),
+		},
+	}
+	if err := p.checkResources("Resources", resources); err != nil {
+		t.Fatalf("expected resources to be within policy, got %v", err)
+	}
+
+	resources.Limits[apiv1.ResourceMemory] = resource.MustParse("1Gi")
+	if err := p.checkResources("Resources", resources); err == nil {
+		t.Fatal("expected memory limit over the maximum to be rejected")
+	}
+
+	resources.Limits[apiv1.ResourceMemory] = resource.MustParse("256Mi")
+	resources.Requests[apiv1.ResourceCPU] = resource.MustParse("800m")
+	if err := p.checkResources("Resources", resources); err == nil {
+		t.Fatal("expected CPU request over the limit to be rejected")
+	}
+}
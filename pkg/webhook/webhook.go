@@ -0,0 +1,203 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fission/fission/pkg/crd"
+)
+
+const (
+	// defaultCertDir is where the serving certificate of the webhook is
+	// mounted, as tls.crt and tls.key.
+	defaultCertDir = "/etc/fission/webhook"
+
+	// maxReviewSize bounds the size of admission reviews, Kubernetes
+	// objects are limited to about 1.5MB anyway.
+	maxReviewSize = 3 << 20
+)
+
+// Webhook is the admission webhook of Fission objects. It fills the
+// defaults of functions and environments, and rejects invalid objects
+// applied with kubectl or by GitOps tools when they're applied, rather
+// than when executor or triggers fail to run them.
+type Webhook struct {
+	logger    *zap.Logger
+	defaulter *defaulter
+	validator *validator
+}
+
+// MakeWebhook returns a webhook validating objects against a policy, and
+// checking the objects they reference exist if fissionClient is set.
+func MakeWebhook(logger *zap.Logger, policy *Policy, fissionClient *crd.FissionClient) *Webhook {
+	v := &validator{policy: policy}
+	if fissionClient != nil {
+		v.references = &clientReferenceChecker{fissionClient: fissionClient}
+	}
+	return &Webhook{
+		logger:    logger.Named("webhook"),
+		defaulter: &defaulter{policy: policy},
+		validator: v,
+	}
+}
+
+// GetHandler returns the HTTP handler of the webhook.
+func (wh *Webhook) GetHandler() http.Handler {
+	r := mux.NewRouter()
+	r.HandleFunc("/mutate", wh.reviewHandler(wh.mutate)).Methods("POST")
+	r.HandleFunc("/validate", wh.reviewHandler(wh.validate)).Methods("POST")
+	r.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods("GET")
+	return r
+}
+
+// reviewHandler returns the handler of admission reviews answered by review.
+// Only creations and updates are reviewed, anything else is allowed.
+func (wh *Webhook) reviewHandler(review func(req *admissionv1.AdmissionRequest, resp *admissionv1.AdmissionResponse)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxReviewSize))
+		if err != nil {
+			http.Error(w, "error reading request body", http.StatusBadRequest)
+			return
+		}
+
+		ar := &admissionv1.AdmissionReview{}
+		err = json.Unmarshal(body, ar)
+		if err != nil || ar.Request == nil {
+			http.Error(w, "invalid admission review", http.StatusBadRequest)
+			return
+		}
+
+		ar.Response = &admissionv1.AdmissionResponse{
+			UID:     ar.Request.UID,
+			Allowed: true,
+		}
+		if ar.Request.Operation == admissionv1.Create || ar.Request.Operation == admissionv1.Update {
+			review(ar.Request, ar.Response)
+		}
+		ar.Request = nil
+
+		resp, err := json.Marshal(ar)
+		if err != nil {
+			wh.logger.Error("error encoding admission review", zap.Error(err))
+			http.Error(w, "error encoding admission review", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(resp)
+	}
+}
+
+// mutate fills the defaults of the object of an admission request.
+func (wh *Webhook) mutate(req *admissionv1.AdmissionRequest, resp *admissionv1.AdmissionResponse) {
+	patch, err := wh.defaulter.mutate(req.Kind.Kind, req.Namespace, req.Object.Raw)
+	if err != nil {
+		// the validating webhook rejects objects that can't be decoded
+		wh.logger.Error("error filling defaults",
+			zap.String("kind", req.Kind.Kind),
+			zap.String("namespace", req.Namespace),
+			zap.String("name", req.Name),
+			zap.Error(err))
+		return
+	}
+	if patch == nil {
+		return
+	}
+	patchType := admissionv1.PatchTypeJSONPatch
+	resp.Patch = patch
+	resp.PatchType = &patchType
+}
+
+// validate rejects the object of an admission request if it's invalid.
+func (wh *Webhook) validate(req *admissionv1.AdmissionRequest, resp *admissionv1.AdmissionResponse) {
+	err := wh.validator.validate(req.Kind.Kind, req.Namespace, req.Object.Raw)
+	if err == nil {
+		return
+	}
+
+	wh.logger.Info("rejected invalid object",
+		zap.String("kind", req.Kind.Kind),
+		zap.String("namespace", req.Namespace),
+		zap.String("name", req.Name),
+		zap.String("operation", string(req.Operation)),
+		zap.String("user", req.UserInfo.Username),
+		zap.Error(err))
+
+	resp.Allowed = false
+	resp.Result = &metav1.Status{
+		Status:  metav1.StatusFailure,
+		Reason:  metav1.StatusReasonInvalid,
+		Code:    http.StatusUnprocessableEntity,
+		Message: err.Error(),
+	}
+}
+
+// Start serves the webhook over HTTPS on the given port, with the
+// certificate in WEBHOOK_CERT_DIR.
+func Start(logger *zap.Logger, port int) error {
+	policy, err := PolicyFromEnv()
+	if err != nil {
+		return errors.Wrap(err, "error loading webhook policy")
+	}
+
+	var fissionClient *crd.FissionClient
+	checkReferences := true
+	if val := os.Getenv("WEBHOOK_CHECK_REFERENCES"); len(val) > 0 {
+		checkReferences, err = strconv.ParseBool(val)
+		if err != nil {
+			return errors.Wrap(err, "error parsing WEBHOOK_CHECK_REFERENCES")
+		}
+	}
+	if checkReferences {
+		fissionClient, _, _, _, err = crd.MakeFissionClient()
+		if err != nil {
+			return errors.Wrap(err, "failed to get fission client")
+		}
+	}
+
+	certDir := os.Getenv("WEBHOOK_CERT_DIR")
+	if len(certDir) == 0 {
+		certDir = defaultCertDir
+	}
+
+	wh := MakeWebhook(logger, policy, fissionClient)
+	logger.Info("starting admission webhook",
+		zap.Int("port", port),
+		zap.Strings("allowed_images", policy.AllowedImages),
+		zap.Bool("check_references", checkReferences))
+
+	go func() {
+		err := http.ListenAndServeTLS(fmt.Sprintf(":%v", port),
+			filepath.Join(certDir, "tls.crt"), filepath.Join(certDir, "tls.key"), wh.GetHandler())
+		logger.Fatal("done listening", zap.Error(err))
+	}()
+	return nil
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/utils"
+)
+
+const (
+	// maxSteps bounds the steps an execution runs, for branches looping
+	// back to steps before them.
+	maxSteps = 100
+
+	// maxBodySize bounds the responses of functions.
+	maxBodySize = 10 << 20
+
+	// maxRetryDelay bounds the delay between retries of an invocation.
+	maxRetryDelay = 30 * time.Second
+
+	headerExecution = "X-Fission-Workflow-Execution"
+	headerStep      = "X-Fission-Workflow-Step"
+)
+
+type (
+	// message is the input or the output of a step.
+	message struct {
+		statusCode int
+		header     http.Header
+		body       []byte
+	}
+)
+
+// execute runs a workflow with input. It returns the output of the last
+// step executed, nil if its function didn't respond, with the record of
+// the execution.
+func (e *Engine) execute(ctx context.Context, wf *fv1.Workflow, id string, input *message) (*message, *fv1.WorkflowExecution) {
+	exec := &fv1.WorkflowExecution{
+		ID:        id,
+		StartTime: metav1.Now(),
+	}
+	finish := func(phase fv1.WorkflowExecutionPhase, err error) {
+		exec.Phase = phase
+		if err != nil {
+			exec.Error = err.Error()
+		}
+		exec.CompletionTime = metav1.Now()
+	}
+
+	i := 0
+	for n := 0; ; n++ {
+		if n == maxSteps {
+			finish(fv1.WorkflowExecutionFailed, errors.Errorf("execution ran more than %v steps", maxSteps))
+			return input, exec
+		}
+
+		step := &wf.Spec.Steps[i]
+		output, record := e.runStep(ctx, wf, id, step, input)
+		exec.Steps = append(exec.Steps, *record)
+		if output == nil {
+			finish(fv1.WorkflowExecutionFailed, errors.Errorf("step %q failed: %v", step.Name, record.Error))
+			return nil, exec
+		}
+
+		next, ok := nextStep(&wf.Spec, i, output.statusCode)
+		if !ok {
+			finish(fv1.WorkflowExecutionFailed, errors.Errorf("step %q failed with status code %v", step.Name, output.statusCode))
+			return output, exec
+		}
+		if next < 0 {
+			finish(fv1.WorkflowExecutionSucceeded, nil)
+			return output, exec
+		}
+		i = next
+		input = output
+	}
+}
+
+// nextStep returns the index of the step to go to after the step at i
+// with a status code, or -1 if the execution ends there. It returns false
+// if the step failed the execution.
+func nextStep(spec *fv1.WorkflowSpec, i int, statusCode int) (int, bool) {
+	step := &spec.Steps[i]
+	for _, branch := range step.Branches {
+		if matchStatusCode(branch.StatusCode, statusCode) {
+			return stepIndex(spec, branch.Next), true
+		}
+	}
+
+	switch {
+	case statusCode < 200 || statusCode >= 300:
+		return 0, false
+	case step.End:
+		return -1, true
+	case len(step.Next) > 0:
+		return stepIndex(spec, step.Next), true
+	case i+1 < len(spec.Steps):
+		return i + 1, true
+	default:
+		return -1, true
+	}
+}
+
+// stepIndex returns the index of a step by name, or -1 if there's none.
+func stepIndex(spec *fv1.WorkflowSpec, name string) int {
+	for i := range spec.Steps {
+		if spec.Steps[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// matchStatusCode returns whether a status code matches a pattern of
+// branches, like "404" or "4xx".
+func matchStatusCode(pattern string, statusCode int) bool {
+	code := strconv.Itoa(statusCode)
+	if len(pattern) != 3 || len(code) != 3 {
+		return false
+	}
+	if pattern[1:] == "xx" {
+		return pattern[0] == code[0]
+	}
+	return pattern == code
+}
+
+// runStep invokes the functions of a step with its input. It returns the
+// output of the step, nil if a function didn't respond.
+func (e *Engine) runStep(ctx context.Context, wf *fv1.Workflow, id string, step *fv1.WorkflowStep, input *message) (*message, *fv1.WorkflowStepExecution) {
+	record := &fv1.WorkflowStepExecution{
+		Name:      step.Name,
+		StartTime: metav1.Now(),
+	}
+	defer func() {
+		record.CompletionTime = metav1.Now()
+	}()
+
+	if len(step.Function) > 0 {
+		output, attempts, err := e.invokeWithRetries(ctx, wf, id, step, step.Function, input)
+		record.Attempts = attempts
+		if err != nil {
+			record.Error = err.Error()
+			return nil, record
+		}
+		record.StatusCode = output.statusCode
+		return output, record
+	}
+
+	// fan out to the functions, and in to the array of their responses
+	outputs := make([]*message, len(step.Parallel))
+	attempts := make([]int, len(step.Parallel))
+	errs := make([]error, len(step.Parallel))
+	var wg sync.WaitGroup
+	for i, fn := range step.Parallel {
+		wg.Add(1)
+		go func(i int, fn string) {
+			defer wg.Done()
+			outputs[i], attempts[i], errs[i] = e.invokeWithRetries(ctx, wf, id, step, fn, input)
+		}(i, fn)
+	}
+	wg.Wait()
+
+	bodies := make([]json.RawMessage, len(outputs))
+	output := &message{header: http.Header{"Content-Type": []string{"application/json"}}}
+	for i, o := range outputs {
+		record.Attempts += attempts[i]
+		if errs[i] != nil {
+			record.Error = fmt.Sprintf("function %q: %v", step.Parallel[i], errs[i])
+			return nil, record
+		}
+		if o.statusCode > output.statusCode {
+			output.statusCode = o.statusCode
+		}
+		bodies[i] = jsonValue(o.body)
+	}
+	body, err := json.Marshal(bodies)
+	if err != nil {
+		record.Error = err.Error()
+		return nil, record
+	}
+	output.body = body
+	record.StatusCode = output.statusCode
+	return output, record
+}
+
+// jsonValue returns a response as JSON: as is if it is JSON already, as a
+// string otherwise.
+func jsonValue(body []byte) json.RawMessage {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return json.RawMessage("null")
+	}
+	if json.Valid(body) {
+		return body
+	}
+	s, _ := json.Marshal(string(body))
+	return s
+}
+
+// invokeWithRetries invokes a function, retrying with exponential backoff
+// as long as it fails with 5xx or 429 status codes or doesn't respond. It
+// returns the last response, with the number of attempts.
+func (e *Engine) invokeWithRetries(ctx context.Context, wf *fv1.Workflow, id string, step *fv1.WorkflowStep, fn string, input *message) (*message, int, error) {
+	delay := e.retryDelay
+	for attempt := 1; ; attempt++ {
+		output, err := e.invoke(ctx, wf, id, step, fn, input)
+		retryable := err != nil || output.statusCode >= 500 || output.statusCode == http.StatusTooManyRequests
+		if !retryable || attempt > step.MaxRetries {
+			return output, attempt, err
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return output, attempt, err
+		}
+		delay *= 2
+		if delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
+
+// invoke invokes a function of the namespace of a workflow through router.
+func (e *Engine) invoke(ctx context.Context, wf *fv1.Workflow, id string, step *fv1.WorkflowStep, fn string, input *message) (*message, error) {
+	url := e.routerUrl + utils.UrlForFunction(fn, wf.ObjectMeta.Namespace)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(input.body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	if contentType := input.header.Get("Content-Type"); len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set(headerExecution, id)
+	req.Header.Set(headerStep, step.Name)
+	req.Header.Set(fv1.HeaderTriggerType, fv1.TriggerTypeWorkflow)
+	req.Header.Set(fv1.HeaderTriggerName, wf.ObjectMeta.Name)
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "error invoking function")
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading response of function")
+	}
+	if len(body) > maxBodySize {
+		return nil, errors.Errorf("response of function larger than %v bytes", maxBodySize)
+	}
+	return &message{
+		statusCode: resp.StatusCode,
+		header:     resp.Header,
+		body:       body,
+	}, nil
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package workflow executes Workflows, which compose functions into steps.
+// A workflow is executed by a POST to /workflows/<namespace>/<name> of the
+// workflow engine, with the input of its first step as body. The engine
+// invokes the functions of the steps through router, one step after the
+// other, and responds with the output of the last step once the execution
+// ends. Executions are recorded in the status of workflows.
+package workflow
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"go.uber.org/zap"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	k8sCache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+	genClientset "github.com/fission/fission/pkg/generated/clientset/versioned"
+)
+
+const (
+	resyncPeriod = 30 * time.Second
+
+	// initialRetryDelay is the delay before the first retry of a failed
+	// invocation, doubled for each retry after it up to maxRetryDelay.
+	initialRetryDelay = time.Second
+
+	headerPhase = "X-Fission-Workflow-Phase"
+)
+
+type (
+	// Engine executes workflows.
+	Engine struct {
+		logger        *zap.Logger
+		fissionClient genClientset.Interface
+		workflows     k8sCache.Store
+		routerUrl     string
+		httpClient    *http.Client
+		retryDelay    time.Duration
+	}
+)
+
+// Start executes workflows on port, invoking functions through router at
+// routerUrl.
+func Start(logger *zap.Logger, port int, routerUrl string) error {
+	fissionClient, _, _, _, err := crd.MakeFissionClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to get fission client")
+	}
+
+	err = fissionClient.WaitForCRDs()
+	if err != nil {
+		return errors.Wrap(err, "error waiting for CRDs")
+	}
+
+	lw := k8sCache.NewListWatchFromClient(fissionClient.CoreV1().RESTClient(), "workflows", metav1.NamespaceAll, fields.Everything())
+	store, controller := k8sCache.NewInformer(lw, &fv1.Workflow{}, resyncPeriod, k8sCache.ResourceEventHandlerFuncs{})
+	go controller.Run(make(chan struct{}))
+
+	e := MakeEngine(logger, fissionClient, store, routerUrl)
+	logger.Info("starting workflow engine", zap.Int("port", port))
+	go func() {
+		err := http.ListenAndServe(fmt.Sprintf(":%v", port), e.GetHandler())
+		logger.Fatal("done listening", zap.Error(err))
+	}()
+	return nil
+}
+
+// MakeEngine returns an engine executing the workflows of a store.
+func MakeEngine(logger *zap.Logger, fissionClient genClientset.Interface, workflows k8sCache.Store, routerUrl string) *Engine {
+	return &Engine{
+		logger:        logger.Named("workflow_engine"),
+		fissionClient: fissionClient,
+		workflows:     workflows,
+		routerUrl:     strings.TrimSuffix(routerUrl, "/"),
+		httpClient:    &http.Client{Timeout: 5 * time.Minute},
+		retryDelay:    initialRetryDelay,
+	}
+}
+
+func (e *Engine) GetHandler() http.Handler {
+	r := mux.NewRouter()
+	r.HandleFunc("/workflows/{namespace}/{name}", e.executionHandler).Methods("POST")
+	r.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods("GET")
+	return r
+}
+
+// executionHandler executes a workflow with the body of the request, and
+// responds with the output of the last step executed.
+func (e *Engine) executionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	obj, exists, err := e.workflows.GetByKey(vars["namespace"] + "/" + vars["name"])
+	if err != nil || !exists {
+		http.Error(w, "workflow not found", http.StatusNotFound)
+		return
+	}
+	wf := obj.(*fv1.Workflow)
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil {
+		http.Error(w, "error reading request body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxBodySize {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	input := &message{header: http.Header{}, body: body}
+	if contentType := r.Header.Get("Content-Type"); len(contentType) > 0 {
+		input.header.Set("Content-Type", contentType)
+	}
+
+	id := uuid.NewV4().String()
+	output, exec := e.execute(r.Context(), wf, id, input)
+	e.record(wf, exec)
+
+	w.Header().Set(headerExecution, id)
+	w.Header().Set(headerPhase, string(exec.Phase))
+	if output == nil {
+		http.Error(w, exec.Error, http.StatusBadGateway)
+		return
+	}
+	if contentType := output.header.Get("Content-Type"); len(contentType) > 0 {
+		w.Header().Set("Content-Type", contentType)
+	}
+	statusCode := output.statusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	w.WriteHeader(statusCode)
+	w.Write(output.body)
+}
+
+// record adds an execution to the history in the status of a workflow,
+// dropping the oldest executions past its history limit.
+func (e *Engine) record(wf *fv1.Workflow, exec *fv1.WorkflowExecution) {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest, err := e.fissionClient.CoreV1().Workflows(wf.ObjectMeta.Namespace).Get(wf.ObjectMeta.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		// the workflow was recreated since the execution started
+		if latest.ObjectMeta.UID != wf.ObjectMeta.UID {
+			return nil
+		}
+
+		limit := latest.Spec.HistoryLimit
+		if limit == 0 {
+			limit = fv1.DefaultWorkflowHistoryLimit
+		}
+		executions := append([]fv1.WorkflowExecution{*exec}, latest.Status.Executions...)
+		if len(executions) > limit {
+			executions = executions[:limit]
+		}
+		latest.Status.Executions = executions
+
+		_, err = e.fissionClient.CoreV1().Workflows(latest.ObjectMeta.Namespace).UpdateStatus(latest)
+		return err
+	})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		e.logger.Error("error recording workflow execution", zap.Error(err),
+			zap.String("workflow", wf.ObjectMeta.Name), zap.String("namespace", wf.ObjectMeta.Namespace),
+			zap.String("execution", exec.ID))
+	}
+}
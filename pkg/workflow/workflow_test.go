@@ -0,0 +1,195 @@
+/*
+Copyright 2021 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sCache "k8s.io/client-go/tools/cache"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/generated/clientset/versioned/fake"
+)
+
+// makeTestRouter returns a router serving functions that echo their input
+// with their name, except for "lookup", which fails for unknown users,
+// and "flaky", which fails once.
+func makeTestRouter(t *testing.T) *httptest.Server {
+	var flakyCalls int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fn := strings.TrimPrefix(r.URL.Path, "/fission-function/")
+		if r.Header.Get(fv1.HeaderTriggerType) != fv1.TriggerTypeWorkflow || len(r.Header.Get(headerExecution)) == 0 {
+			t.Errorf("unexpected headers %v", r.Header)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		switch {
+		case fn == "lookup" && string(body) == "unknown":
+			w.WriteHeader(http.StatusNotFound)
+		case fn == "flaky" && atomic.AddInt32(&flakyCalls, 1) == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case fn == "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("broken"))
+		default:
+			w.Write([]byte(fn + ":" + string(body)))
+		}
+	}))
+}
+
+func makeTestEngine(t *testing.T, routerUrl string, wf *fv1.Workflow) (*Engine, *fake.Clientset) {
+	cs := fake.NewSimpleClientset(wf)
+	store := k8sCache.NewStore(k8sCache.MetaNamespaceKeyFunc)
+	if err := store.Add(wf); err != nil {
+		t.Fatal(err)
+	}
+	e := MakeEngine(zap.NewNop(), cs, store, routerUrl)
+	e.retryDelay = time.Millisecond
+	return e, cs
+}
+
+func executeWorkflow(e *Engine, wf *fv1.Workflow, input string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/workflows/"+wf.ObjectMeta.Namespace+"/"+wf.ObjectMeta.Name, strings.NewReader(input))
+	e.GetHandler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestExecute(t *testing.T) {
+	router := makeTestRouter(t)
+	defer router.Close()
+
+	wf := &fv1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "signup", Namespace: metav1.NamespaceDefault, UID: "6d1c6f43-4c1b-4a37-9a55-0a2f1b7f6d11"},
+		Spec: fv1.WorkflowSpec{
+			HistoryLimit: 2,
+			Steps: []fv1.WorkflowStep{
+				{
+					Name:     "lookup",
+					Function: "lookup",
+					Branches: []fv1.WorkflowBranch{{StatusCode: "404", Next: "create"}},
+					Next:     "notify",
+				},
+				{Name: "create", Function: "flaky", MaxRetries: 1},
+				{Name: "notify", Parallel: []string{"email", "audit"}},
+			},
+		},
+	}
+	e, cs := makeTestEngine(t, router.URL, wf)
+
+	// known users are notified right away
+	rec := executeWorkflow(e, wf, "alice")
+	if rec.Code != http.StatusOK || rec.Body.String() != `["email:lookup:alice","audit:lookup:alice"]` {
+		t.Errorf("unexpected output %v %v", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get(headerPhase) != string(fv1.WorkflowExecutionSucceeded) {
+		t.Errorf("unexpected phase %v", rec.Header().Get(headerPhase))
+	}
+
+	// unknown ones are created first, retrying the creation once
+	rec = executeWorkflow(e, wf, "unknown")
+	if rec.Code != http.StatusOK || rec.Body.String() != `["email:flaky:","audit:flaky:"]` {
+		t.Errorf("unexpected output %v %v", rec.Code, rec.Body.String())
+	}
+
+	// executions are recorded, the latest first
+	got, err := cs.CoreV1().Workflows(wf.ObjectMeta.Namespace).Get(wf.ObjectMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Status.Executions) != 2 {
+		t.Fatalf("expected 2 executions, got %v", len(got.Status.Executions))
+	}
+	exec := got.Status.Executions[0]
+	if exec.ID != rec.Header().Get(headerExecution) || exec.Phase != fv1.WorkflowExecutionSucceeded || len(exec.Steps) != 3 {
+		t.Fatalf("unexpected execution %+v", exec)
+	}
+	if exec.Steps[0].StatusCode != http.StatusNotFound || exec.Steps[1].Name != "create" || exec.Steps[1].Attempts != 2 {
+		t.Errorf("unexpected steps %+v", exec.Steps)
+	}
+
+	// down to the history limit
+	executeWorkflow(e, wf, "bob")
+	got, err = cs.CoreV1().Workflows(wf.ObjectMeta.Namespace).Get(wf.ObjectMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Status.Executions) != 2 || got.Status.Executions[1].ID != exec.ID {
+		t.Errorf("expected the last 2 executions, got %+v", got.Status.Executions)
+	}
+}
+
+func TestExecuteFailure(t *testing.T) {
+	router := makeTestRouter(t)
+	defer router.Close()
+
+	wf := &fv1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "broken", Namespace: metav1.NamespaceDefault},
+		Spec: fv1.WorkflowSpec{
+			Steps: []fv1.WorkflowStep{
+				{Name: "first", Function: "echo"},
+				{Name: "second", Function: "broken", MaxRetries: 2},
+				{Name: "third", Function: "echo"},
+			},
+		},
+	}
+	e, cs := makeTestEngine(t, router.URL, wf)
+
+	rec := executeWorkflow(e, wf, "input")
+	if rec.Code != http.StatusInternalServerError || rec.Body.String() != "broken" {
+		t.Errorf("expected the response of the failed step, got %v %v", rec.Code, rec.Body.String())
+	}
+
+	got, err := cs.CoreV1().Workflows(wf.ObjectMeta.Namespace).Get(wf.ObjectMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec := got.Status.Executions[0]
+	if exec.Phase != fv1.WorkflowExecutionFailed || len(exec.Steps) != 2 || exec.Steps[1].Attempts != 3 || len(exec.Error) == 0 {
+		t.Errorf("unexpected execution %+v", exec)
+	}
+
+	rec = executeWorkflow(e, &fv1.Workflow{ObjectMeta: metav1.ObjectMeta{Name: "missing", Namespace: "default"}}, "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected missing workflow not to be found, got %v", rec.Code)
+	}
+}
+
+func TestMatchStatusCode(t *testing.T) {
+	for _, c := range []struct {
+		pattern    string
+		statusCode int
+		expected   bool
+	}{
+		{"404", 404, true},
+		{"404", 400, false},
+		{"4xx", 429, true},
+		{"4xx", 500, false},
+		{"2xx", 0, false},
+	} {
+		if matchStatusCode(c.pattern, c.statusCode) != c.expected {
+			t.Errorf("expected %v to match %v: %v", c.pattern, c.statusCode, c.expected)
+		}
+	}
+}